@@ -2,14 +2,94 @@ package grpc
 
 import (
 	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
 
 	pb "isac-cran-system/api/proto"
 	"isac-cran-system/internal/model"
 	"isac-cran-system/internal/service"
+	"isac-cran-system/pkg/errors"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+const (
+	// iqSampleSize is the size of one interleaved complex float32 IQ sample.
+	iqSampleSize = 8
+
+	defaultIQChunkSize = 256 * 1024
+	maxIQChunkSize     = 2 * 1024 * 1024
+)
+
+// NewServer registers the algorithm, IRS, sensor and capture services on a
+// new gRPC server. Application errors are returned with the gRPC status code
+// matching their HTTP status.
+func NewServer(algorithm *service.AlgorithmService, irsSvc *service.IRSService, sensor *service.SensorService, artifacts *service.ArtifactService, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(unaryStatus),
+		grpc.ChainStreamInterceptor(streamStatus),
+	)
+	server := grpc.NewServer(opts...)
+	pb.RegisterAlgorithmServiceServer(server, NewAlgorithmServer(algorithm))
+	pb.RegisterIRSServiceServer(server, NewIRSServer(irsSvc))
+	pb.RegisterSensorServiceServer(server, NewSensorServer(sensor))
+	pb.RegisterCaptureServiceServer(server, NewCaptureServer(artifacts))
+	return server
+}
+
+func unaryStatus(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	return resp, statusError(err)
+}
+
+func streamStatus(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return statusError(handler(srv, ss))
+}
+
+// statusError converts an application or context error to a gRPC status.
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case stderrors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case stderrors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	var appErr *errors.AppError
+	if !stderrors.As(err, &appErr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+	code := codes.Internal
+	switch appErr.HTTPStatus() {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.Aborted
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	}
+	return status.Error(code, appErr.Error())
+}
+
 type AlgorithmServer struct {
 	pb.UnimplementedAlgorithmServiceServer
 	service *service.AlgorithmService
@@ -65,10 +145,6 @@ func (s *AlgorithmServer) RunDOA(ctx context.Context, req *pb.DOARequest) (*pb.D
 	}, nil
 }
 
-func (s *AlgorithmServer) StreamBeamforming(stream grpc.ServerStream) error {
-	return nil
-}
-
 type IRSServer struct {
 	pb.UnimplementedIRSServiceServer
 	service *service.IRSService
@@ -78,28 +154,23 @@ func NewIRSServer(service *service.IRSService) *IRSServer {
 	return &IRSServer{service: service}
 }
 
-func (s *IRSServer) GetStatus(ctx context.Context, _ *pb.Empty) (*pb.IRSStatus, error) {
-	status, err := s.service.GetStatus(ctx)
+func (s *IRSServer) GetStatus(ctx context.Context, req *pb.IRSStatusRequest) (*pb.IRSStatus, error) {
+	st, err := s.service.GetStatus(ctx, req.IrsId)
 	if err != nil {
 		return nil, err
 	}
 
-	powerStatusStr := "off"
-	if status.PowerStatus {
-		powerStatusStr = "on"
-	}
-
 	return &pb.IRSStatus{
-		ElementCount:  int32(status.ElementCount),
-		FrequencyBand: status.FrequencyBand,
-		Temperature:   status.Temperature,
-		PowerStatus:   powerStatusStr,
-		PhaseShifts:   status.PhaseShifts,
+		ElementCount:  int32(st.ElementCount),
+		FrequencyBand: st.FrequencyBand,
+		Temperature:   st.Temperature,
+		PowerStatus:   st.PowerStatus,
+		PhaseShifts:   st.PhaseShifts,
 	}, nil
 }
 
 func (s *IRSServer) Configure(ctx context.Context, req *pb.IRSConfigRequest) (*pb.IRSConfigResponse, error) {
-	_, err := s.service.Configure(ctx, &model.IRSConfigRequest{
+	_, err := s.service.Configure(ctx, req.IrsId, &model.IRSConfigRequest{
 		Name:          "grpc-config",
 		ElementCount:  len(req.PhaseShifts),
 		PhaseShifts:   req.PhaseShifts,
@@ -110,3 +181,144 @@ func (s *IRSServer) Configure(ctx context.Context, req *pb.IRSConfigRequest) (*p
 	}
 	return &pb.IRSConfigResponse{Success: true, Message: "Configuration applied"}, nil
 }
+
+type SensorServer struct {
+	pb.UnimplementedSensorServiceServer
+	service *service.SensorService
+}
+
+func NewSensorServer(service *service.SensorService) *SensorServer {
+	return &SensorServer{service: service}
+}
+
+func (s *SensorServer) ListSensors(ctx context.Context, req *pb.Empty) (*pb.SensorList, error) {
+	sensors, err := s.service.ListSensors(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.SensorList{Sensors: make([]*pb.SensorInfo, len(sensors))}
+	for i, info := range sensors {
+		resp.Sensors[i] = &pb.SensorInfo{
+			SensorId:   info.SensorID,
+			SensorType: string(info.SensorType),
+			Location:   info.Location,
+			Unit:       info.Unit,
+			MinValue:   info.MinValue,
+			MaxValue:   info.MaxValue,
+			Status:     int32(info.Status),
+		}
+	}
+	return resp, nil
+}
+
+func (s *SensorServer) BatchRead(ctx context.Context, req *pb.SensorBatchRequest) (*pb.SensorBatchResponse, error) {
+	if len(req.SensorIds) == 0 {
+		return nil, errors.New(errors.CodeInvalidParam, "sensor_ids is required")
+	}
+
+	results, err := s.service.ReadSensors(ctx, req.SensorIds)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.SensorBatchResponse{Results: make([]*pb.SensorReadResult, len(results))}
+	for i, r := range results {
+		item := &pb.SensorReadResult{SensorId: r.SensorID, Error: r.Error}
+		if r.Data != nil {
+			item.Data = &pb.SensorData{
+				SensorId:  r.Data.SensorID,
+				Value:     r.Data.Value,
+				Unit:      r.Data.Unit,
+				Timestamp: r.Data.Timestamp.UnixMilli(),
+			}
+		}
+		resp.Results[i] = item
+	}
+	return resp, nil
+}
+
+type CaptureServer struct {
+	pb.UnimplementedCaptureServiceServer
+	service *service.ArtifactService
+}
+
+func NewCaptureServer(service *service.ArtifactService) *CaptureServer {
+	return &CaptureServer{service: service}
+}
+
+// StreamIQ sends an IQ capture artifact in sample-aligned chunks starting at
+// req.Offset. Each block carries its byte offset so a client can resume an
+// interrupted transfer from the last offset it received. Send blocks while
+// the HTTP/2 flow-control window is exhausted; MaxBytesPerSecond additionally
+// paces the stream for slow consumers.
+func (s *CaptureServer) StreamIQ(req *pb.IQStreamRequest, stream pb.CaptureService_StreamIQServer) error {
+	if req.Offset%iqSampleSize != 0 {
+		return errors.NewWithDetail(errors.CodeInvalidParam, "offset must be sample aligned", fmt.Sprintf("%d", req.Offset))
+	}
+
+	chunkSize := int(req.ChunkSize)
+	if chunkSize <= 0 {
+		chunkSize = defaultIQChunkSize
+	}
+	if chunkSize > maxIQChunkSize {
+		chunkSize = maxIQChunkSize
+	}
+	chunkSize -= chunkSize % iqSampleSize
+	if chunkSize == 0 {
+		chunkSize = iqSampleSize
+	}
+
+	ctx := stream.Context()
+	rc, artifact, err := s.service.OpenAt(ctx, req.ArtifactId, req.Offset)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if artifact.ArtifactType != model.ArtifactTypeIQCapture {
+		return errors.NewWithDetail(errors.CodeInvalidParam, "artifact is not an iq capture", string(artifact.ArtifactType))
+	}
+
+	var pace time.Duration
+	if req.MaxBytesPerSecond > 0 {
+		pace = time.Duration(float64(chunkSize) / float64(req.MaxBytesPerSecond) * float64(time.Second))
+	}
+
+	offset := req.Offset
+	buf := make([]byte, chunkSize)
+	for {
+		n, readErr := io.ReadFull(rc, buf)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			return errors.Wrap(errors.CodeObjectStoreError, "failed to read iq capture", readErr)
+		}
+
+		last := offset+int64(n) >= artifact.Size || readErr != nil
+		block := &pb.IQBlock{
+			ArtifactId:  artifact.ID,
+			Offset:      offset,
+			Data:        buf[:n],
+			SampleCount: int32(n / iqSampleSize),
+			TotalSize:   artifact.Size,
+			Last:        last,
+		}
+		if err := stream.Send(block); err != nil {
+			return err
+		}
+		offset += int64(n)
+
+		if last {
+			return nil
+		}
+
+		if pace > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(pace):
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
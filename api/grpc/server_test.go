@@ -0,0 +1,368 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	pb "isac-cran-system/api/proto"
+	"isac-cran-system/internal/device/sensor"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/internal/repository/objectstore"
+	"isac-cran-system/internal/service"
+	"isac-cran-system/pkg/discovery"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/rpc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type memArtifactStore struct {
+	mu        sync.Mutex
+	artifacts map[int64]*model.Artifact
+}
+
+func (m *memArtifactStore) Create(ctx context.Context, artifact *model.Artifact) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	artifact.ID = int64(len(m.artifacts) + 1)
+	copied := *artifact
+	m.artifacts[artifact.ID] = &copied
+	return nil
+}
+
+func (m *memArtifactStore) GetByID(ctx context.Context, id int64) (*model.Artifact, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.artifacts[id]
+	if !ok {
+		return nil, errors.New(errors.CodeNotFound, "artifact not found")
+	}
+	copied := *a
+	return &copied, nil
+}
+
+func (m *memArtifactStore) List(ctx context.Context, query *model.ArtifactQuery) ([]model.Artifact, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *memArtifactStore) Delete(ctx context.Context, id int64) error { return nil }
+
+func (m *memArtifactStore) ListStorageKeys(ctx context.Context) ([]string, error) { return nil, nil }
+
+func (m *memArtifactStore) UpdateBackend(ctx context.Context, key, backend string) error { return nil }
+
+func (m *memArtifactStore) UpdateChecksum(ctx context.Context, id int64, checksum string, size int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if a, ok := m.artifacts[id]; ok {
+		a.Checksum, a.Size = checksum, size
+	}
+	return nil
+}
+
+func (m *memArtifactStore) ListOrphaned(ctx context.Context) ([]model.Artifact, error) {
+	return nil, nil
+}
+
+// startCaptureServer serves an artifact service over an in-memory listener
+// and uploads capture as an IQ capture artifact. The returned option dials
+// the listener.
+func startCaptureServer(t *testing.T, capture []byte) (grpc.DialOption, int64) {
+	t.Helper()
+	hot, err := objectstore.NewLocalStore(t.TempDir(), "http://localhost/objects", "test-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifacts := service.NewArtifactService(objectstore.NewTieredStore(hot, nil, 0),
+		&memArtifactStore{artifacts: make(map[int64]*model.Artifact)}, time.Hour)
+
+	artifact, err := artifacts.Upload(context.Background(), model.ArtifactTypeIQCapture, "exp_1", "capture.iq",
+		bytes.NewReader(capture), int64(len(capture)))
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+
+	return serve(t, NewServer(nil, nil, nil, artifacts)), artifact.ID
+}
+
+// serve runs server on an in-memory listener and returns the option that
+// dials it.
+func serve(t *testing.T, server *grpc.Server) grpc.DialOption {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	})
+}
+
+func dialBufnet(t *testing.T, dialer grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	conn, err := grpc.Dial("bufnet", dialer, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func captureClient(t *testing.T, dialer grpc.DialOption) pb.CaptureServiceClient {
+	return pb.NewCaptureServiceClient(dialBufnet(t, dialer))
+}
+
+func testCapture(samples int) []byte {
+	data := make([]byte, samples*iqSampleSize)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	return data
+}
+
+func TestStreamIQChunks(t *testing.T) {
+	capture := testCapture(100)
+	dialer, id := startCaptureServer(t, capture)
+	client := captureClient(t, dialer)
+
+	// 100 bytes is rounded down to 12 samples per block.
+	stream, err := client.StreamIQ(context.Background(), &pb.IQStreamRequest{ArtifactId: id, Offset: 160, ChunkSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []byte
+	var blocks int
+	for {
+		block, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		if block.Offset != int64(160+len(got)) {
+			t.Fatalf("block offset = %d, want %d", block.Offset, 160+len(got))
+		}
+		if len(block.Data) > 96 || block.SampleCount != int32(len(block.Data)/iqSampleSize) {
+			t.Fatalf("block of %d bytes with %d samples", len(block.Data), block.SampleCount)
+		}
+		got = append(got, block.Data...)
+		blocks++
+		if block.Last {
+			break
+		}
+	}
+	if !bytes.Equal(got, capture[160:]) {
+		t.Fatalf("streamed %d bytes, want %d from offset 160", len(got), len(capture)-160)
+	}
+	if blocks != 7 {
+		t.Errorf("blocks = %d, want 7", blocks)
+	}
+}
+
+func TestStreamIQErrors(t *testing.T) {
+	dialer, id := startCaptureServer(t, testCapture(4))
+	client := captureClient(t, dialer)
+
+	tests := []struct {
+		name string
+		req  *pb.IQStreamRequest
+		code codes.Code
+	}{
+		{"unaligned offset", &pb.IQStreamRequest{ArtifactId: id, Offset: 3}, codes.InvalidArgument},
+		{"missing artifact", &pb.IQStreamRequest{ArtifactId: id + 1}, codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.StreamIQ(context.Background(), tt.req)
+			if err == nil {
+				_, err = stream.Recv()
+			}
+			if status.Code(err) != tt.code {
+				t.Fatalf("error = %v, want code %v", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestDownloadIQ(t *testing.T) {
+	// Larger than one default chunk so the download spans several blocks.
+	capture := testCapture(100000)
+	dialer, id := startCaptureServer(t, capture)
+	client, err := rpc.NewCaptureClient("bufnet", dialer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var buf bytes.Buffer
+	n, err := client.DownloadIQ(context.Background(), id, 0, &buf)
+	if err != nil {
+		t.Fatalf("DownloadIQ: %v", err)
+	}
+	if n != int64(len(capture)) || !bytes.Equal(buf.Bytes(), capture) {
+		t.Fatalf("downloaded %d bytes, want %d", n, len(capture))
+	}
+
+	// Resuming from an offset fetches only the rest of the capture.
+	buf.Reset()
+	n, err = client.DownloadIQ(context.Background(), id, 4096, &buf)
+	if err != nil {
+		t.Fatalf("DownloadIQ from offset: %v", err)
+	}
+	if n != int64(len(capture)) || !bytes.Equal(buf.Bytes(), capture[4096:]) {
+		t.Fatalf("resumed download ended at %d with %d bytes", n, buf.Len())
+	}
+}
+
+func TestBatchRead(t *testing.T) {
+	collector := sensor.NewCollector(sensor.NewSimulator(), time.Second)
+	if err := collector.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	id := collector.GetAllSensors()[0].SensorID
+	dialer := serve(t, NewServer(nil, nil, service.NewSensorService(collector, nil), nil))
+	client := pb.NewSensorServiceClient(dialBufnet(t, dialer))
+
+	resp, err := client.BatchRead(context.Background(), &pb.SensorBatchRequest{SensorIds: []string{id, "missing"}})
+	if err != nil {
+		t.Fatalf("BatchRead: %v", err)
+	}
+	if len(resp.Results) != 2 {
+		t.Fatalf("got %d results, want 2", len(resp.Results))
+	}
+	if r := resp.Results[0]; r.SensorId != id || r.Data == nil || r.Data.SensorId != id || r.Error != "" {
+		t.Errorf("first result = %v, want a reading of %s", r, id)
+	}
+	if r := resp.Results[1]; r.Data != nil || r.Error == "" {
+		t.Errorf("second result = %v, want an error", r)
+	}
+
+	_, err = client.BatchRead(context.Background(), &pb.SensorBatchRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("empty batch error = %v, want InvalidArgument", err)
+	}
+}
+
+// staticDiscoverer serves a fixed set of instances and pushes changes to
+// its watchers.
+type staticDiscoverer struct {
+	mu        sync.Mutex
+	instances []*discovery.ServiceInfo
+	watchers  []func([]*discovery.ServiceInfo)
+}
+
+func (d *staticDiscoverer) Discover(serviceName string) ([]*discovery.ServiceInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.instances, nil
+}
+
+func (d *staticDiscoverer) WatchContext(ctx context.Context, serviceName string, callback func([]*discovery.ServiceInfo)) {
+	d.mu.Lock()
+	d.watchers = append(d.watchers, callback)
+	d.mu.Unlock()
+	<-ctx.Done()
+}
+
+func (d *staticDiscoverer) set(instances ...*discovery.ServiceInfo) {
+	d.mu.Lock()
+	d.instances = instances
+	watchers := d.watchers
+	d.mu.Unlock()
+	for _, watch := range watchers {
+		watch(instances)
+	}
+}
+
+func TestDiscoveryLoadBalancing(t *testing.T) {
+	collector := sensor.NewCollector(sensor.NewSimulator(), time.Second)
+	collector.Connect(context.Background())
+	sensorSvc := service.NewSensorService(collector, nil)
+
+	// two instances on in-memory listeners, keyed by their advertised address
+	listeners := make(map[string]*bufconn.Listener)
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	var instances []*discovery.ServiceInfo
+	for _, host := range []string{"10.0.0.1", "10.0.0.2"} {
+		addr := host + ":9090"
+		count := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			mu.Lock()
+			calls[addr]++
+			mu.Unlock()
+			return handler(ctx, req)
+		}
+		server := NewServer(nil, nil, sensorSvc, nil, grpc.ChainUnaryInterceptor(count))
+		lis := bufconn.Listen(1 << 20)
+		go server.Serve(lis)
+		t.Cleanup(server.Stop)
+		listeners[addr] = lis
+		instances = append(instances, &discovery.ServiceInfo{ID: host, Name: rpc.SensorServiceName, Address: host, Port: 9090})
+	}
+	dialer := grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		return listeners[addr].DialContext(ctx)
+	})
+
+	discoverer := &staticDiscoverer{instances: instances}
+	client, err := rpc.NewSensorClient(rpc.DiscoveryTarget(rpc.SensorServiceName), append(rpc.WithDiscovery(discoverer), dialer)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	listAll := func(n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			if _, err := client.ListSensors(context.Background()); err != nil {
+				t.Fatalf("ListSensors: %v", err)
+			}
+		}
+	}
+	// round robin only spreads calls once both connections are ready
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		listAll(1)
+		mu.Lock()
+		both := len(calls) == 2
+		mu.Unlock()
+		if both || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	calls = make(map[string]int)
+	mu.Unlock()
+	listAll(20)
+	mu.Lock()
+	if calls["10.0.0.1:9090"] != 10 || calls["10.0.0.2:9090"] != 10 {
+		t.Errorf("Expected calls to alternate between the instances, got %v", calls)
+	}
+	mu.Unlock()
+
+	// the second instance leaves; the balancer drops it asynchronously
+	discoverer.set(instances[0])
+	deadline = time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		calls = make(map[string]int)
+		mu.Unlock()
+		listAll(10)
+		mu.Lock()
+		done := calls["10.0.0.1:9090"] == 10
+		mu.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected every call on the remaining instance, got %v", calls)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
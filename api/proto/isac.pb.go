@@ -1,209 +1,1633 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        (unknown)
+// source: isac.proto
+
 package proto
 
 import (
-	"context"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
 
-	"google.golang.org/grpc"
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
-type Empty struct{}
+type Empty struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *Empty) Reset() {
+	*x = Empty{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_isac_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Empty) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Empty) ProtoMessage() {}
+
+func (x *Empty) ProtoReflect() protoreflect.Message {
+	mi := &file_isac_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Empty.ProtoReflect.Descriptor instead.
+func (*Empty) Descriptor() ([]byte, []int) {
+	return file_isac_proto_rawDescGZIP(), []int{0}
+}
 
 type BeamformingRequest struct {
-	ExperimentId       string
-	ElementCount       int32
-	TargetDirection    float64
-	InterferenceAngles []float64
-	SnrThreshold       float64
-	MaxIterations      int32
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ExperimentId       string    `protobuf:"bytes,1,opt,name=experiment_id,json=experimentId,proto3" json:"experiment_id,omitempty"`
+	ElementCount       int32     `protobuf:"varint,2,opt,name=element_count,json=elementCount,proto3" json:"element_count,omitempty"`
+	TargetDirection    float64   `protobuf:"fixed64,3,opt,name=target_direction,json=targetDirection,proto3" json:"target_direction,omitempty"`
+	InterferenceAngles []float64 `protobuf:"fixed64,4,rep,packed,name=interference_angles,json=interferenceAngles,proto3" json:"interference_angles,omitempty"`
+	SnrThreshold       float64   `protobuf:"fixed64,5,opt,name=snr_threshold,json=snrThreshold,proto3" json:"snr_threshold,omitempty"`
+	MaxIterations      int32     `protobuf:"varint,6,opt,name=max_iterations,json=maxIterations,proto3" json:"max_iterations,omitempty"`
+}
+
+func (x *BeamformingRequest) Reset() {
+	*x = BeamformingRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_isac_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BeamformingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BeamformingRequest) ProtoMessage() {}
+
+func (x *BeamformingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_isac_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BeamformingRequest.ProtoReflect.Descriptor instead.
+func (*BeamformingRequest) Descriptor() ([]byte, []int) {
+	return file_isac_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BeamformingRequest) GetExperimentId() string {
+	if x != nil {
+		return x.ExperimentId
+	}
+	return ""
+}
+
+func (x *BeamformingRequest) GetElementCount() int32 {
+	if x != nil {
+		return x.ElementCount
+	}
+	return 0
+}
+
+func (x *BeamformingRequest) GetTargetDirection() float64 {
+	if x != nil {
+		return x.TargetDirection
+	}
+	return 0
+}
+
+func (x *BeamformingRequest) GetInterferenceAngles() []float64 {
+	if x != nil {
+		return x.InterferenceAngles
+	}
+	return nil
+}
+
+func (x *BeamformingRequest) GetSnrThreshold() float64 {
+	if x != nil {
+		return x.SnrThreshold
+	}
+	return 0
+}
+
+func (x *BeamformingRequest) GetMaxIterations() int32 {
+	if x != nil {
+		return x.MaxIterations
+	}
+	return 0
 }
 
 type BeamformingResponse struct {
-	ExperimentId      string
-	BeamPattern       []float64
-	MainLobeDirection float64
-	MainLobeWidth     float64
-	SideLobeLevel     float64
-	Iterations        int32
-	Converged         bool
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ExperimentId      string    `protobuf:"bytes,1,opt,name=experiment_id,json=experimentId,proto3" json:"experiment_id,omitempty"`
+	BeamPattern       []float64 `protobuf:"fixed64,2,rep,packed,name=beam_pattern,json=beamPattern,proto3" json:"beam_pattern,omitempty"`
+	MainLobeDirection float64   `protobuf:"fixed64,3,opt,name=main_lobe_direction,json=mainLobeDirection,proto3" json:"main_lobe_direction,omitempty"`
+	MainLobeWidth     float64   `protobuf:"fixed64,4,opt,name=main_lobe_width,json=mainLobeWidth,proto3" json:"main_lobe_width,omitempty"`
+	SideLobeLevel     float64   `protobuf:"fixed64,5,opt,name=side_lobe_level,json=sideLobeLevel,proto3" json:"side_lobe_level,omitempty"`
+	Iterations        int32     `protobuf:"varint,6,opt,name=iterations,proto3" json:"iterations,omitempty"`
+	Converged         bool      `protobuf:"varint,7,opt,name=converged,proto3" json:"converged,omitempty"`
+}
+
+func (x *BeamformingResponse) Reset() {
+	*x = BeamformingResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_isac_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BeamformingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BeamformingResponse) ProtoMessage() {}
+
+func (x *BeamformingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_isac_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BeamformingResponse.ProtoReflect.Descriptor instead.
+func (*BeamformingResponse) Descriptor() ([]byte, []int) {
+	return file_isac_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BeamformingResponse) GetExperimentId() string {
+	if x != nil {
+		return x.ExperimentId
+	}
+	return ""
+}
+
+func (x *BeamformingResponse) GetBeamPattern() []float64 {
+	if x != nil {
+		return x.BeamPattern
+	}
+	return nil
+}
+
+func (x *BeamformingResponse) GetMainLobeDirection() float64 {
+	if x != nil {
+		return x.MainLobeDirection
+	}
+	return 0
+}
+
+func (x *BeamformingResponse) GetMainLobeWidth() float64 {
+	if x != nil {
+		return x.MainLobeWidth
+	}
+	return 0
+}
+
+func (x *BeamformingResponse) GetSideLobeLevel() float64 {
+	if x != nil {
+		return x.SideLobeLevel
+	}
+	return 0
+}
+
+func (x *BeamformingResponse) GetIterations() int32 {
+	if x != nil {
+		return x.Iterations
+	}
+	return 0
+}
+
+func (x *BeamformingResponse) GetConverged() bool {
+	if x != nil {
+		return x.Converged
+	}
+	return false
 }
 
 type DOARequest struct {
-	ExperimentId   string
-	ElementCount   int32
-	NumSources     int32
-	SnapshotLength int32
-	Method         string
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ExperimentId   string `protobuf:"bytes,1,opt,name=experiment_id,json=experimentId,proto3" json:"experiment_id,omitempty"`
+	ElementCount   int32  `protobuf:"varint,2,opt,name=element_count,json=elementCount,proto3" json:"element_count,omitempty"`
+	NumSources     int32  `protobuf:"varint,3,opt,name=num_sources,json=numSources,proto3" json:"num_sources,omitempty"`
+	SnapshotLength int32  `protobuf:"varint,4,opt,name=snapshot_length,json=snapshotLength,proto3" json:"snapshot_length,omitempty"`
+	Method         string `protobuf:"bytes,5,opt,name=method,proto3" json:"method,omitempty"`
+}
+
+func (x *DOARequest) Reset() {
+	*x = DOARequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_isac_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DOARequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DOARequest) ProtoMessage() {}
+
+func (x *DOARequest) ProtoReflect() protoreflect.Message {
+	mi := &file_isac_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DOARequest.ProtoReflect.Descriptor instead.
+func (*DOARequest) Descriptor() ([]byte, []int) {
+	return file_isac_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DOARequest) GetExperimentId() string {
+	if x != nil {
+		return x.ExperimentId
+	}
+	return ""
+}
+
+func (x *DOARequest) GetElementCount() int32 {
+	if x != nil {
+		return x.ElementCount
+	}
+	return 0
+}
+
+func (x *DOARequest) GetNumSources() int32 {
+	if x != nil {
+		return x.NumSources
+	}
+	return 0
+}
+
+func (x *DOARequest) GetSnapshotLength() int32 {
+	if x != nil {
+		return x.SnapshotLength
+	}
+	return 0
+}
+
+func (x *DOARequest) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
 }
 
 type DOAResponse struct {
-	ExperimentId    string
-	EstimatedAngles []float64
-	Spectrum        []float64
-	Rmse            float64
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ExperimentId    string    `protobuf:"bytes,1,opt,name=experiment_id,json=experimentId,proto3" json:"experiment_id,omitempty"`
+	EstimatedAngles []float64 `protobuf:"fixed64,2,rep,packed,name=estimated_angles,json=estimatedAngles,proto3" json:"estimated_angles,omitempty"`
+	Spectrum        []float64 `protobuf:"fixed64,3,rep,packed,name=spectrum,proto3" json:"spectrum,omitempty"`
+	Rmse            float64   `protobuf:"fixed64,4,opt,name=rmse,proto3" json:"rmse,omitempty"`
+}
+
+func (x *DOAResponse) Reset() {
+	*x = DOAResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_isac_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DOAResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DOAResponse) ProtoMessage() {}
+
+func (x *DOAResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_isac_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DOAResponse.ProtoReflect.Descriptor instead.
+func (*DOAResponse) Descriptor() ([]byte, []int) {
+	return file_isac_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DOAResponse) GetExperimentId() string {
+	if x != nil {
+		return x.ExperimentId
+	}
+	return ""
+}
+
+func (x *DOAResponse) GetEstimatedAngles() []float64 {
+	if x != nil {
+		return x.EstimatedAngles
+	}
+	return nil
+}
+
+func (x *DOAResponse) GetSpectrum() []float64 {
+	if x != nil {
+		return x.Spectrum
+	}
+	return nil
+}
+
+func (x *DOAResponse) GetRmse() float64 {
+	if x != nil {
+		return x.Rmse
+	}
+	return 0
 }
 
 type IRSStatus struct {
-	ElementCount  int32
-	FrequencyBand string
-	Temperature   float64
-	PowerStatus   string
-	PhaseShifts   []float64
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ElementCount  int32     `protobuf:"varint,1,opt,name=element_count,json=elementCount,proto3" json:"element_count,omitempty"`
+	FrequencyBand string    `protobuf:"bytes,2,opt,name=frequency_band,json=frequencyBand,proto3" json:"frequency_band,omitempty"`
+	Temperature   float64   `protobuf:"fixed64,3,opt,name=temperature,proto3" json:"temperature,omitempty"`
+	PowerStatus   bool      `protobuf:"varint,4,opt,name=power_status,json=powerStatus,proto3" json:"power_status,omitempty"`
+	PhaseShifts   []float64 `protobuf:"fixed64,5,rep,packed,name=phase_shifts,json=phaseShifts,proto3" json:"phase_shifts,omitempty"`
+}
+
+func (x *IRSStatus) Reset() {
+	*x = IRSStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_isac_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IRSStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IRSStatus) ProtoMessage() {}
+
+func (x *IRSStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_isac_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IRSStatus.ProtoReflect.Descriptor instead.
+func (*IRSStatus) Descriptor() ([]byte, []int) {
+	return file_isac_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *IRSStatus) GetElementCount() int32 {
+	if x != nil {
+		return x.ElementCount
+	}
+	return 0
+}
+
+func (x *IRSStatus) GetFrequencyBand() string {
+	if x != nil {
+		return x.FrequencyBand
+	}
+	return ""
+}
+
+func (x *IRSStatus) GetTemperature() float64 {
+	if x != nil {
+		return x.Temperature
+	}
+	return 0
+}
+
+func (x *IRSStatus) GetPowerStatus() bool {
+	if x != nil {
+		return x.PowerStatus
+	}
+	return false
+}
+
+func (x *IRSStatus) GetPhaseShifts() []float64 {
+	if x != nil {
+		return x.PhaseShifts
+	}
+	return nil
+}
+
+type IRSStatusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	IrsId string `protobuf:"bytes,1,opt,name=irs_id,json=irsId,proto3" json:"irs_id,omitempty"`
+}
+
+func (x *IRSStatusRequest) Reset() {
+	*x = IRSStatusRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_isac_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IRSStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IRSStatusRequest) ProtoMessage() {}
+
+func (x *IRSStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_isac_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IRSStatusRequest.ProtoReflect.Descriptor instead.
+func (*IRSStatusRequest) Descriptor() ([]byte, []int) {
+	return file_isac_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *IRSStatusRequest) GetIrsId() string {
+	if x != nil {
+		return x.IrsId
+	}
+	return ""
 }
 
 type IRSConfigRequest struct {
-	PhaseShifts []float64
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PhaseShifts []float64 `protobuf:"fixed64,1,rep,packed,name=phase_shifts,json=phaseShifts,proto3" json:"phase_shifts,omitempty"`
+	IrsId       string    `protobuf:"bytes,2,opt,name=irs_id,json=irsId,proto3" json:"irs_id,omitempty"`
+}
+
+func (x *IRSConfigRequest) Reset() {
+	*x = IRSConfigRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_isac_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IRSConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IRSConfigRequest) ProtoMessage() {}
+
+func (x *IRSConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_isac_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IRSConfigRequest.ProtoReflect.Descriptor instead.
+func (*IRSConfigRequest) Descriptor() ([]byte, []int) {
+	return file_isac_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *IRSConfigRequest) GetPhaseShifts() []float64 {
+	if x != nil {
+		return x.PhaseShifts
+	}
+	return nil
+}
+
+func (x *IRSConfigRequest) GetIrsId() string {
+	if x != nil {
+		return x.IrsId
+	}
+	return ""
 }
 
 type IRSConfigResponse struct {
-	Success bool
-	Message string
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Success bool   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
 }
 
-type SensorData struct {
-	SensorId  string
-	Value     float64
-	Unit      string
-	Timestamp int64
+func (x *IRSConfigResponse) Reset() {
+	*x = IRSConfigResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_isac_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
 
-type SensorRequest struct {
-	SensorId string
+func (x *IRSConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-type SensorList struct {
-	Sensors []*SensorInfo
+func (*IRSConfigResponse) ProtoMessage() {}
+
+func (x *IRSConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_isac_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IRSConfigResponse.ProtoReflect.Descriptor instead.
+func (*IRSConfigResponse) Descriptor() ([]byte, []int) {
+	return file_isac_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *IRSConfigResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *IRSConfigResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
 }
 
 type SensorInfo struct {
-	Id          string
-	Type        string
-	Location    string
-	Description string
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SensorId   string  `protobuf:"bytes,1,opt,name=sensor_id,json=sensorId,proto3" json:"sensor_id,omitempty"`
+	SensorType string  `protobuf:"bytes,2,opt,name=sensor_type,json=sensorType,proto3" json:"sensor_type,omitempty"`
+	Location   string  `protobuf:"bytes,3,opt,name=location,proto3" json:"location,omitempty"`
+	Unit       string  `protobuf:"bytes,4,opt,name=unit,proto3" json:"unit,omitempty"`
+	MinValue   float64 `protobuf:"fixed64,5,opt,name=min_value,json=minValue,proto3" json:"min_value,omitempty"`
+	MaxValue   float64 `protobuf:"fixed64,6,opt,name=max_value,json=maxValue,proto3" json:"max_value,omitempty"`
+	Status     int32   `protobuf:"varint,7,opt,name=status,proto3" json:"status,omitempty"`
+}
+
+func (x *SensorInfo) Reset() {
+	*x = SensorInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_isac_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SensorInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SensorInfo) ProtoMessage() {}
+
+func (x *SensorInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_isac_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SensorInfo.ProtoReflect.Descriptor instead.
+func (*SensorInfo) Descriptor() ([]byte, []int) {
+	return file_isac_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SensorInfo) GetSensorId() string {
+	if x != nil {
+		return x.SensorId
+	}
+	return ""
+}
+
+func (x *SensorInfo) GetSensorType() string {
+	if x != nil {
+		return x.SensorType
+	}
+	return ""
+}
+
+func (x *SensorInfo) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *SensorInfo) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+func (x *SensorInfo) GetMinValue() float64 {
+	if x != nil {
+		return x.MinValue
+	}
+	return 0
+}
+
+func (x *SensorInfo) GetMaxValue() float64 {
+	if x != nil {
+		return x.MaxValue
+	}
+	return 0
+}
+
+func (x *SensorInfo) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+type SensorList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sensors []*SensorInfo `protobuf:"bytes,1,rep,name=sensors,proto3" json:"sensors,omitempty"`
+}
+
+func (x *SensorList) Reset() {
+	*x = SensorList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_isac_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SensorList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SensorList) ProtoMessage() {}
+
+func (x *SensorList) ProtoReflect() protoreflect.Message {
+	mi := &file_isac_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SensorList.ProtoReflect.Descriptor instead.
+func (*SensorList) Descriptor() ([]byte, []int) {
+	return file_isac_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SensorList) GetSensors() []*SensorInfo {
+	if x != nil {
+		return x.Sensors
+	}
+	return nil
+}
+
+type SensorData struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SensorId  string  `protobuf:"bytes,1,opt,name=sensor_id,json=sensorId,proto3" json:"sensor_id,omitempty"`
+	Value     float64 `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`
+	Timestamp int64   `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Unit      string  `protobuf:"bytes,4,opt,name=unit,proto3" json:"unit,omitempty"`
+}
+
+func (x *SensorData) Reset() {
+	*x = SensorData{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_isac_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SensorData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SensorData) ProtoMessage() {}
+
+func (x *SensorData) ProtoReflect() protoreflect.Message {
+	mi := &file_isac_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SensorData.ProtoReflect.Descriptor instead.
+func (*SensorData) Descriptor() ([]byte, []int) {
+	return file_isac_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SensorData) GetSensorId() string {
+	if x != nil {
+		return x.SensorId
+	}
+	return ""
+}
+
+func (x *SensorData) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *SensorData) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *SensorData) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+type SensorBatchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SensorIds []string `protobuf:"bytes,1,rep,name=sensor_ids,json=sensorIds,proto3" json:"sensor_ids,omitempty"`
+}
+
+func (x *SensorBatchRequest) Reset() {
+	*x = SensorBatchRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_isac_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SensorBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SensorBatchRequest) ProtoMessage() {}
+
+func (x *SensorBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_isac_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-type AlgorithmServiceServer interface {
-	RunBeamforming(ctx context.Context, req *BeamformingRequest) (*BeamformingResponse, error)
-	RunDOA(ctx context.Context, req *DOARequest) (*DOAResponse, error)
-	StreamBeamforming(stream grpc.ServerStream) error
+// Deprecated: Use SensorBatchRequest.ProtoReflect.Descriptor instead.
+func (*SensorBatchRequest) Descriptor() ([]byte, []int) {
+	return file_isac_proto_rawDescGZIP(), []int{12}
 }
 
-type UnimplementedAlgorithmServiceServer struct{}
+func (x *SensorBatchRequest) GetSensorIds() []string {
+	if x != nil {
+		return x.SensorIds
+	}
+	return nil
+}
+
+type SensorReadResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SensorId string      `protobuf:"bytes,1,opt,name=sensor_id,json=sensorId,proto3" json:"sensor_id,omitempty"`
+	Data     *SensorData `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Error    string      `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+}
 
-func (UnimplementedAlgorithmServiceServer) RunBeamforming(ctx context.Context, req *BeamformingRequest) (*BeamformingResponse, error) {
-	return nil, nil
+func (x *SensorReadResult) Reset() {
+	*x = SensorReadResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_isac_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
 
-func (UnimplementedAlgorithmServiceServer) RunDOA(ctx context.Context, req *DOARequest) (*DOAResponse, error) {
-	return nil, nil
+func (x *SensorReadResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (UnimplementedAlgorithmServiceServer) StreamBeamforming(stream grpc.ServerStream) error {
+func (*SensorReadResult) ProtoMessage() {}
+
+func (x *SensorReadResult) ProtoReflect() protoreflect.Message {
+	mi := &file_isac_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SensorReadResult.ProtoReflect.Descriptor instead.
+func (*SensorReadResult) Descriptor() ([]byte, []int) {
+	return file_isac_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SensorReadResult) GetSensorId() string {
+	if x != nil {
+		return x.SensorId
+	}
+	return ""
+}
+
+func (x *SensorReadResult) GetData() *SensorData {
+	if x != nil {
+		return x.Data
+	}
 	return nil
 }
 
-type IRSServiceServer interface {
-	GetStatus(ctx context.Context, req *Empty) (*IRSStatus, error)
-	Configure(ctx context.Context, req *IRSConfigRequest) (*IRSConfigResponse, error)
+func (x *SensorReadResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
 }
 
-type UnimplementedIRSServiceServer struct{}
+type SensorBatchResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-func (UnimplementedIRSServiceServer) GetStatus(ctx context.Context, req *Empty) (*IRSStatus, error) {
-	return nil, nil
+	Results []*SensorReadResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
 }
 
-func (UnimplementedIRSServiceServer) Configure(ctx context.Context, req *IRSConfigRequest) (*IRSConfigResponse, error) {
-	return nil, nil
+func (x *SensorBatchResponse) Reset() {
+	*x = SensorBatchResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_isac_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
 
-type SensorServiceServer interface {
-	GetData(ctx context.Context, req *SensorRequest) (*SensorData, error)
-	StreamData(stream grpc.ServerStream) error
-	ListSensors(ctx context.Context, req *Empty) (*SensorList, error)
+func (x *SensorBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-type UnimplementedSensorServiceServer struct{}
+func (*SensorBatchResponse) ProtoMessage() {}
+
+func (x *SensorBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_isac_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
 
-func (UnimplementedSensorServiceServer) GetData(ctx context.Context, req *SensorRequest) (*SensorData, error) {
-	return nil, nil
+// Deprecated: Use SensorBatchResponse.ProtoReflect.Descriptor instead.
+func (*SensorBatchResponse) Descriptor() ([]byte, []int) {
+	return file_isac_proto_rawDescGZIP(), []int{14}
 }
 
-func (UnimplementedSensorServiceServer) StreamData(stream grpc.ServerStream) error {
+func (x *SensorBatchResponse) GetResults() []*SensorReadResult {
+	if x != nil {
+		return x.Results
+	}
 	return nil
 }
 
-func (UnimplementedSensorServiceServer) ListSensors(ctx context.Context, req *Empty) (*SensorList, error) {
-	return nil, nil
+type IQStreamRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ArtifactId        int64 `protobuf:"varint,1,opt,name=artifact_id,json=artifactId,proto3" json:"artifact_id,omitempty"`
+	Offset            int64 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	ChunkSize         int32 `protobuf:"varint,3,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`
+	MaxBytesPerSecond int64 `protobuf:"varint,4,opt,name=max_bytes_per_second,json=maxBytesPerSecond,proto3" json:"max_bytes_per_second,omitempty"`
 }
 
-func RegisterAlgorithmServiceServer(s *grpc.Server, srv AlgorithmServiceServer) {
+func (x *IQStreamRequest) Reset() {
+	*x = IQStreamRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_isac_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
 
-func RegisterIRSServiceServer(s *grpc.Server, srv IRSServiceServer) {
+func (x *IQStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func RegisterSensorServiceServer(s *grpc.Server, srv SensorServiceServer) {
+func (*IQStreamRequest) ProtoMessage() {}
+
+func (x *IQStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_isac_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-type AlgorithmServiceClient interface {
-	RunBeamforming(ctx context.Context, req *BeamformingRequest, opts ...grpc.CallOption) (*BeamformingResponse, error)
-	RunDOA(ctx context.Context, req *DOARequest, opts ...grpc.CallOption) (*DOAResponse, error)
+// Deprecated: Use IQStreamRequest.ProtoReflect.Descriptor instead.
+func (*IQStreamRequest) Descriptor() ([]byte, []int) {
+	return file_isac_proto_rawDescGZIP(), []int{15}
 }
 
-type algorithmServiceClient struct {
-	cc grpc.ClientConnInterface
+func (x *IQStreamRequest) GetArtifactId() int64 {
+	if x != nil {
+		return x.ArtifactId
+	}
+	return 0
 }
 
-func NewAlgorithmServiceClient(cc grpc.ClientConnInterface) AlgorithmServiceClient {
-	return &algorithmServiceClient{cc}
+func (x *IQStreamRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
 }
 
-func (c *algorithmServiceClient) RunBeamforming(ctx context.Context, req *BeamformingRequest, opts ...grpc.CallOption) (*BeamformingResponse, error) {
-	return &BeamformingResponse{}, nil
+func (x *IQStreamRequest) GetChunkSize() int32 {
+	if x != nil {
+		return x.ChunkSize
+	}
+	return 0
 }
 
-func (c *algorithmServiceClient) RunDOA(ctx context.Context, req *DOARequest, opts ...grpc.CallOption) (*DOAResponse, error) {
-	return &DOAResponse{}, nil
+func (x *IQStreamRequest) GetMaxBytesPerSecond() int64 {
+	if x != nil {
+		return x.MaxBytesPerSecond
+	}
+	return 0
 }
 
-type IRSServiceClient interface {
-	GetStatus(ctx context.Context, req *Empty, opts ...grpc.CallOption) (*IRSStatus, error)
-	Configure(ctx context.Context, req *IRSConfigRequest, opts ...grpc.CallOption) (*IRSConfigResponse, error)
+type IQBlock struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ArtifactId  int64  `protobuf:"varint,1,opt,name=artifact_id,json=artifactId,proto3" json:"artifact_id,omitempty"`
+	Offset      int64  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Data        []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	SampleCount int32  `protobuf:"varint,4,opt,name=sample_count,json=sampleCount,proto3" json:"sample_count,omitempty"`
+	TotalSize   int64  `protobuf:"varint,5,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	Last        bool   `protobuf:"varint,6,opt,name=last,proto3" json:"last,omitempty"`
 }
 
-type irsServiceClient struct {
-	cc grpc.ClientConnInterface
+func (x *IQBlock) Reset() {
+	*x = IQBlock{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_isac_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
 
-func NewIRSServiceClient(cc grpc.ClientConnInterface) IRSServiceClient {
-	return &irsServiceClient{cc}
+func (x *IQBlock) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (c *irsServiceClient) GetStatus(ctx context.Context, req *Empty, opts ...grpc.CallOption) (*IRSStatus, error) {
-	return &IRSStatus{}, nil
+func (*IQBlock) ProtoMessage() {}
+
+func (x *IQBlock) ProtoReflect() protoreflect.Message {
+	mi := &file_isac_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IQBlock.ProtoReflect.Descriptor instead.
+func (*IQBlock) Descriptor() ([]byte, []int) {
+	return file_isac_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *IQBlock) GetArtifactId() int64 {
+	if x != nil {
+		return x.ArtifactId
+	}
+	return 0
+}
+
+func (x *IQBlock) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *IQBlock) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *IQBlock) GetSampleCount() int32 {
+	if x != nil {
+		return x.SampleCount
+	}
+	return 0
 }
 
-func (c *irsServiceClient) Configure(ctx context.Context, req *IRSConfigRequest, opts ...grpc.CallOption) (*IRSConfigResponse, error) {
-	return &IRSConfigResponse{}, nil
+func (x *IQBlock) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
 }
 
-type SensorServiceClient interface {
-	GetData(ctx context.Context, req *SensorRequest, opts ...grpc.CallOption) (*SensorData, error)
-	ListSensors(ctx context.Context, req *Empty, opts ...grpc.CallOption) (*SensorList, error)
+func (x *IQBlock) GetLast() bool {
+	if x != nil {
+		return x.Last
+	}
+	return false
 }
 
-type sensorServiceClient struct {
-	cc grpc.ClientConnInterface
+var File_isac_proto protoreflect.FileDescriptor
+
+var file_isac_proto_rawDesc = []byte{
+	0x0a, 0x0a, 0x69, 0x73, 0x61, 0x63, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x04, 0x69, 0x73,
+	0x61, 0x63, 0x22, 0x07, 0x0a, 0x05, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x86, 0x02, 0x0a, 0x12,
+	0x42, 0x65, 0x61, 0x6d, 0x66, 0x6f, 0x72, 0x6d, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x78, 0x70, 0x65, 0x72,
+	0x69, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x6c, 0x65, 0x6d, 0x65,
+	0x6e, 0x74, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c,
+	0x65, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x29, 0x0a, 0x10,
+	0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x5f, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0f, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x44, 0x69,
+	0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2f, 0x0a, 0x13, 0x69, 0x6e, 0x74, 0x65, 0x72,
+	0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x5f, 0x61, 0x6e, 0x67, 0x6c, 0x65, 0x73, 0x18, 0x04,
+	0x20, 0x03, 0x28, 0x01, 0x52, 0x12, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x65, 0x72, 0x65, 0x6e,
+	0x63, 0x65, 0x41, 0x6e, 0x67, 0x6c, 0x65, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x73, 0x6e, 0x72, 0x5f,
+	0x74, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x0c, 0x73, 0x6e, 0x72, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x12, 0x25, 0x0a,
+	0x0e, 0x6d, 0x61, 0x78, 0x5f, 0x69, 0x74, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x6d, 0x61, 0x78, 0x49, 0x74, 0x65, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x22, 0x9b, 0x02, 0x0a, 0x13, 0x42, 0x65, 0x61, 0x6d, 0x66, 0x6f, 0x72,
+	0x6d, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x23, 0x0a, 0x0d,
+	0x65, 0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74, 0x49,
+	0x64, 0x12, 0x21, 0x0a, 0x0c, 0x62, 0x65, 0x61, 0x6d, 0x5f, 0x70, 0x61, 0x74, 0x74, 0x65, 0x72,
+	0x6e, 0x18, 0x02, 0x20, 0x03, 0x28, 0x01, 0x52, 0x0b, 0x62, 0x65, 0x61, 0x6d, 0x50, 0x61, 0x74,
+	0x74, 0x65, 0x72, 0x6e, 0x12, 0x2e, 0x0a, 0x13, 0x6d, 0x61, 0x69, 0x6e, 0x5f, 0x6c, 0x6f, 0x62,
+	0x65, 0x5f, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x11, 0x6d, 0x61, 0x69, 0x6e, 0x4c, 0x6f, 0x62, 0x65, 0x44, 0x69, 0x72, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x26, 0x0a, 0x0f, 0x6d, 0x61, 0x69, 0x6e, 0x5f, 0x6c, 0x6f, 0x62,
+	0x65, 0x5f, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0d, 0x6d,
+	0x61, 0x69, 0x6e, 0x4c, 0x6f, 0x62, 0x65, 0x57, 0x69, 0x64, 0x74, 0x68, 0x12, 0x26, 0x0a, 0x0f,
+	0x73, 0x69, 0x64, 0x65, 0x5f, 0x6c, 0x6f, 0x62, 0x65, 0x5f, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0d, 0x73, 0x69, 0x64, 0x65, 0x4c, 0x6f, 0x62, 0x65, 0x4c,
+	0x65, 0x76, 0x65, 0x6c, 0x12, 0x1e, 0x0a, 0x0a, 0x69, 0x74, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x69, 0x74, 0x65, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6e, 0x76, 0x65, 0x72, 0x67, 0x65,
+	0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x63, 0x6f, 0x6e, 0x76, 0x65, 0x72, 0x67,
+	0x65, 0x64, 0x22, 0xb8, 0x01, 0x0a, 0x0a, 0x44, 0x4f, 0x41, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74, 0x5f,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x78, 0x70, 0x65, 0x72, 0x69,
+	0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x6e,
+	0x74, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c, 0x65,
+	0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x6e,
+	0x75, 0x6d, 0x5f, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x0a, 0x6e, 0x75, 0x6d, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x73, 0x12, 0x27, 0x0a, 0x0f,
+	0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x5f, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0e, 0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x4c,
+	0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x22, 0x8d, 0x01,
+	0x0a, 0x0b, 0x44, 0x4f, 0x41, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x23, 0x0a,
+	0x0d, 0x65, 0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74,
+	0x49, 0x64, 0x12, 0x29, 0x0a, 0x10, 0x65, 0x73, 0x74, 0x69, 0x6d, 0x61, 0x74, 0x65, 0x64, 0x5f,
+	0x61, 0x6e, 0x67, 0x6c, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x01, 0x52, 0x0f, 0x65, 0x73,
+	0x74, 0x69, 0x6d, 0x61, 0x74, 0x65, 0x64, 0x41, 0x6e, 0x67, 0x6c, 0x65, 0x73, 0x12, 0x1a, 0x0a,
+	0x08, 0x73, 0x70, 0x65, 0x63, 0x74, 0x72, 0x75, 0x6d, 0x18, 0x03, 0x20, 0x03, 0x28, 0x01, 0x52,
+	0x08, 0x73, 0x70, 0x65, 0x63, 0x74, 0x72, 0x75, 0x6d, 0x12, 0x12, 0x0a, 0x04, 0x72, 0x6d, 0x73,
+	0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x72, 0x6d, 0x73, 0x65, 0x22, 0xbf, 0x01,
+	0x0a, 0x09, 0x49, 0x52, 0x53, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x65,
+	0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x0c, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x43, 0x6f, 0x75, 0x6e, 0x74,
+	0x12, 0x25, 0x0a, 0x0e, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x5f, 0x62, 0x61,
+	0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x6e, 0x63, 0x79, 0x42, 0x61, 0x6e, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x74, 0x65, 0x6d, 0x70, 0x65,
+	0x72, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0b, 0x74, 0x65,
+	0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x6f, 0x77,
+	0x65, 0x72, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0b, 0x70, 0x6f, 0x77, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x21, 0x0a, 0x0c,
+	0x70, 0x68, 0x61, 0x73, 0x65, 0x5f, 0x73, 0x68, 0x69, 0x66, 0x74, 0x73, 0x18, 0x05, 0x20, 0x03,
+	0x28, 0x01, 0x52, 0x0b, 0x70, 0x68, 0x61, 0x73, 0x65, 0x53, 0x68, 0x69, 0x66, 0x74, 0x73, 0x22,
+	0x29, 0x0a, 0x10, 0x49, 0x52, 0x53, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x69, 0x72, 0x73, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x69, 0x72, 0x73, 0x49, 0x64, 0x22, 0x4c, 0x0a, 0x10, 0x49, 0x52,
+	0x53, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x21,
+	0x0a, 0x0c, 0x70, 0x68, 0x61, 0x73, 0x65, 0x5f, 0x73, 0x68, 0x69, 0x66, 0x74, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x01, 0x52, 0x0b, 0x70, 0x68, 0x61, 0x73, 0x65, 0x53, 0x68, 0x69, 0x66, 0x74,
+	0x73, 0x12, 0x15, 0x0a, 0x06, 0x69, 0x72, 0x73, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x69, 0x72, 0x73, 0x49, 0x64, 0x22, 0x47, 0x0a, 0x11, 0x49, 0x52, 0x53, 0x43,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a,
+	0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07,
+	0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x22, 0xcc, 0x01, 0x0a, 0x0a, 0x53, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x49, 0x6e, 0x66, 0x6f,
+	0x12, 0x1b, 0x0a, 0x09, 0x73, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x49, 0x64, 0x12, 0x1f, 0x0a,
+	0x0b, 0x73, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0a, 0x73, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1a,
+	0x0a, 0x08, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x6e,
+	0x69, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x6e, 0x69, 0x74, 0x12, 0x1b,
+	0x0a, 0x09, 0x6d, 0x69, 0x6e, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x08, 0x6d, 0x69, 0x6e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x6d,
+	0x61, 0x78, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08,
+	0x6d, 0x61, 0x78, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x22, 0x38, 0x0a, 0x0a, 0x53, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x2a,
+	0x0a, 0x07, 0x73, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x10, 0x2e, 0x69, 0x73, 0x61, 0x63, 0x2e, 0x53, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x49, 0x6e, 0x66,
+	0x6f, 0x52, 0x07, 0x73, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x73, 0x22, 0x71, 0x0a, 0x0a, 0x53, 0x65,
+	0x6e, 0x73, 0x6f, 0x72, 0x44, 0x61, 0x74, 0x61, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x65, 0x6e, 0x73,
+	0x6f, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x65, 0x6e,
+	0x73, 0x6f, 0x72, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x74,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x6e, 0x69,
+	0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x6e, 0x69, 0x74, 0x22, 0x33, 0x0a,
+	0x12, 0x53, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x5f, 0x69, 0x64,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x49,
+	0x64, 0x73, 0x22, 0x6b, 0x0a, 0x10, 0x53, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x52, 0x65, 0x61, 0x64,
+	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x65, 0x6e, 0x73, 0x6f, 0x72,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x65, 0x6e, 0x73, 0x6f,
+	0x72, 0x49, 0x64, 0x12, 0x24, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x10, 0x2e, 0x69, 0x73, 0x61, 0x63, 0x2e, 0x53, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x44,
+	0x61, 0x74, 0x61, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22,
+	0x47, 0x0a, 0x13, 0x53, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x30, 0x0a, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x69, 0x73, 0x61, 0x63, 0x2e, 0x53,
+	0x65, 0x6e, 0x73, 0x6f, 0x72, 0x52, 0x65, 0x61, 0x64, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52,
+	0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x22, 0x9a, 0x01, 0x0a, 0x0f, 0x49, 0x51, 0x53,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x0b,
+	0x61, 0x72, 0x74, 0x69, 0x66, 0x61, 0x63, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0a, 0x61, 0x72, 0x74, 0x69, 0x66, 0x61, 0x63, 0x74, 0x49, 0x64, 0x12, 0x16, 0x0a,
+	0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x6f,
+	0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x5f, 0x73,
+	0x69, 0x7a, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x63, 0x68, 0x75, 0x6e, 0x6b,
+	0x53, 0x69, 0x7a, 0x65, 0x12, 0x2f, 0x0a, 0x14, 0x6d, 0x61, 0x78, 0x5f, 0x62, 0x79, 0x74, 0x65,
+	0x73, 0x5f, 0x70, 0x65, 0x72, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x11, 0x6d, 0x61, 0x78, 0x42, 0x79, 0x74, 0x65, 0x73, 0x50, 0x65, 0x72, 0x53,
+	0x65, 0x63, 0x6f, 0x6e, 0x64, 0x22, 0xac, 0x01, 0x0a, 0x07, 0x49, 0x51, 0x42, 0x6c, 0x6f, 0x63,
+	0x6b, 0x12, 0x1f, 0x0a, 0x0b, 0x61, 0x72, 0x74, 0x69, 0x66, 0x61, 0x63, 0x74, 0x5f, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x61, 0x72, 0x74, 0x69, 0x66, 0x61, 0x63, 0x74,
+	0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61,
+	0x74, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x21,
+	0x0a, 0x0c, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x43, 0x6f, 0x75, 0x6e,
+	0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x53, 0x69, 0x7a, 0x65,
+	0x12, 0x12, 0x0a, 0x04, 0x6c, 0x61, 0x73, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04,
+	0x6c, 0x61, 0x73, 0x74, 0x32, 0xd6, 0x01, 0x0a, 0x10, 0x41, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74,
+	0x68, 0x6d, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x45, 0x0a, 0x0e, 0x52, 0x75, 0x6e,
+	0x42, 0x65, 0x61, 0x6d, 0x66, 0x6f, 0x72, 0x6d, 0x69, 0x6e, 0x67, 0x12, 0x18, 0x2e, 0x69, 0x73,
+	0x61, 0x63, 0x2e, 0x42, 0x65, 0x61, 0x6d, 0x66, 0x6f, 0x72, 0x6d, 0x69, 0x6e, 0x67, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x69, 0x73, 0x61, 0x63, 0x2e, 0x42, 0x65, 0x61,
+	0x6d, 0x66, 0x6f, 0x72, 0x6d, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x2d, 0x0a, 0x06, 0x52, 0x75, 0x6e, 0x44, 0x4f, 0x41, 0x12, 0x10, 0x2e, 0x69, 0x73, 0x61,
+	0x63, 0x2e, 0x44, 0x4f, 0x41, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x69,
+	0x73, 0x61, 0x63, 0x2e, 0x44, 0x4f, 0x41, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x4c, 0x0a, 0x11, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x42, 0x65, 0x61, 0x6d, 0x66, 0x6f, 0x72,
+	0x6d, 0x69, 0x6e, 0x67, 0x12, 0x18, 0x2e, 0x69, 0x73, 0x61, 0x63, 0x2e, 0x42, 0x65, 0x61, 0x6d,
+	0x66, 0x6f, 0x72, 0x6d, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19,
+	0x2e, 0x69, 0x73, 0x61, 0x63, 0x2e, 0x42, 0x65, 0x61, 0x6d, 0x66, 0x6f, 0x72, 0x6d, 0x69, 0x6e,
+	0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x30, 0x01, 0x32, 0x80, 0x01,
+	0x0a, 0x0a, 0x49, 0x52, 0x53, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x34, 0x0a, 0x09,
+	0x47, 0x65, 0x74, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x16, 0x2e, 0x69, 0x73, 0x61, 0x63,
+	0x2e, 0x49, 0x52, 0x53, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x0f, 0x2e, 0x69, 0x73, 0x61, 0x63, 0x2e, 0x49, 0x52, 0x53, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x12, 0x3c, 0x0a, 0x09, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x65, 0x12,
+	0x16, 0x2e, 0x69, 0x73, 0x61, 0x63, 0x2e, 0x49, 0x52, 0x53, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x69, 0x73, 0x61, 0x63, 0x2e, 0x49,
+	0x52, 0x53, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x32, 0xb4, 0x01, 0x0a, 0x0d, 0x53, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x53, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x12, 0x2c, 0x0a, 0x0b, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x65, 0x6e, 0x73, 0x6f, 0x72,
+	0x73, 0x12, 0x0b, 0x2e, 0x69, 0x73, 0x61, 0x63, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x10,
+	0x2e, 0x69, 0x73, 0x61, 0x63, 0x2e, 0x53, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x4c, 0x69, 0x73, 0x74,
+	0x12, 0x33, 0x0a, 0x10, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x65, 0x6e, 0x73, 0x6f, 0x72,
+	0x44, 0x61, 0x74, 0x61, 0x12, 0x0b, 0x2e, 0x69, 0x73, 0x61, 0x63, 0x2e, 0x45, 0x6d, 0x70, 0x74,
+	0x79, 0x1a, 0x10, 0x2e, 0x69, 0x73, 0x61, 0x63, 0x2e, 0x53, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x44,
+	0x61, 0x74, 0x61, 0x30, 0x01, 0x12, 0x40, 0x0a, 0x09, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65,
+	0x61, 0x64, 0x12, 0x18, 0x2e, 0x69, 0x73, 0x61, 0x63, 0x2e, 0x53, 0x65, 0x6e, 0x73, 0x6f, 0x72,
+	0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x69,
+	0x73, 0x61, 0x63, 0x2e, 0x53, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x32, 0x44, 0x0a, 0x0e, 0x43, 0x61, 0x70, 0x74, 0x75,
+	0x72, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x32, 0x0a, 0x08, 0x53, 0x74, 0x72,
+	0x65, 0x61, 0x6d, 0x49, 0x51, 0x12, 0x15, 0x2e, 0x69, 0x73, 0x61, 0x63, 0x2e, 0x49, 0x51, 0x53,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0d, 0x2e, 0x69,
+	0x73, 0x61, 0x63, 0x2e, 0x49, 0x51, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x30, 0x01, 0x42, 0x22, 0x5a,
+	0x20, 0x69, 0x73, 0x61, 0x63, 0x2d, 0x63, 0x72, 0x61, 0x6e, 0x2d, 0x73, 0x79, 0x73, 0x74, 0x65,
+	0x6d, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x3b, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
-func NewSensorServiceClient(cc grpc.ClientConnInterface) SensorServiceClient {
-	return &sensorServiceClient{cc}
+var (
+	file_isac_proto_rawDescOnce sync.Once
+	file_isac_proto_rawDescData = file_isac_proto_rawDesc
+)
+
+func file_isac_proto_rawDescGZIP() []byte {
+	file_isac_proto_rawDescOnce.Do(func() {
+		file_isac_proto_rawDescData = protoimpl.X.CompressGZIP(file_isac_proto_rawDescData)
+	})
+	return file_isac_proto_rawDescData
 }
 
-func (c *sensorServiceClient) GetData(ctx context.Context, req *SensorRequest, opts ...grpc.CallOption) (*SensorData, error) {
-	return &SensorData{}, nil
+var file_isac_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_isac_proto_goTypes = []interface{}{
+	(*Empty)(nil),               // 0: isac.Empty
+	(*BeamformingRequest)(nil),  // 1: isac.BeamformingRequest
+	(*BeamformingResponse)(nil), // 2: isac.BeamformingResponse
+	(*DOARequest)(nil),          // 3: isac.DOARequest
+	(*DOAResponse)(nil),         // 4: isac.DOAResponse
+	(*IRSStatus)(nil),           // 5: isac.IRSStatus
+	(*IRSStatusRequest)(nil),    // 6: isac.IRSStatusRequest
+	(*IRSConfigRequest)(nil),    // 7: isac.IRSConfigRequest
+	(*IRSConfigResponse)(nil),   // 8: isac.IRSConfigResponse
+	(*SensorInfo)(nil),          // 9: isac.SensorInfo
+	(*SensorList)(nil),          // 10: isac.SensorList
+	(*SensorData)(nil),          // 11: isac.SensorData
+	(*SensorBatchRequest)(nil),  // 12: isac.SensorBatchRequest
+	(*SensorReadResult)(nil),    // 13: isac.SensorReadResult
+	(*SensorBatchResponse)(nil), // 14: isac.SensorBatchResponse
+	(*IQStreamRequest)(nil),     // 15: isac.IQStreamRequest
+	(*IQBlock)(nil),             // 16: isac.IQBlock
+}
+var file_isac_proto_depIdxs = []int32{
+	9,  // 0: isac.SensorList.sensors:type_name -> isac.SensorInfo
+	11, // 1: isac.SensorReadResult.data:type_name -> isac.SensorData
+	13, // 2: isac.SensorBatchResponse.results:type_name -> isac.SensorReadResult
+	1,  // 3: isac.AlgorithmService.RunBeamforming:input_type -> isac.BeamformingRequest
+	3,  // 4: isac.AlgorithmService.RunDOA:input_type -> isac.DOARequest
+	1,  // 5: isac.AlgorithmService.StreamBeamforming:input_type -> isac.BeamformingRequest
+	6,  // 6: isac.IRSService.GetStatus:input_type -> isac.IRSStatusRequest
+	7,  // 7: isac.IRSService.Configure:input_type -> isac.IRSConfigRequest
+	0,  // 8: isac.SensorService.ListSensors:input_type -> isac.Empty
+	0,  // 9: isac.SensorService.StreamSensorData:input_type -> isac.Empty
+	12, // 10: isac.SensorService.BatchRead:input_type -> isac.SensorBatchRequest
+	15, // 11: isac.CaptureService.StreamIQ:input_type -> isac.IQStreamRequest
+	2,  // 12: isac.AlgorithmService.RunBeamforming:output_type -> isac.BeamformingResponse
+	4,  // 13: isac.AlgorithmService.RunDOA:output_type -> isac.DOAResponse
+	2,  // 14: isac.AlgorithmService.StreamBeamforming:output_type -> isac.BeamformingResponse
+	5,  // 15: isac.IRSService.GetStatus:output_type -> isac.IRSStatus
+	8,  // 16: isac.IRSService.Configure:output_type -> isac.IRSConfigResponse
+	10, // 17: isac.SensorService.ListSensors:output_type -> isac.SensorList
+	11, // 18: isac.SensorService.StreamSensorData:output_type -> isac.SensorData
+	14, // 19: isac.SensorService.BatchRead:output_type -> isac.SensorBatchResponse
+	16, // 20: isac.CaptureService.StreamIQ:output_type -> isac.IQBlock
+	12, // [12:21] is the sub-list for method output_type
+	3,  // [3:12] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
 }
 
-func (c *sensorServiceClient) ListSensors(ctx context.Context, req *Empty, opts ...grpc.CallOption) (*SensorList, error) {
-	return &SensorList{}, nil
+func init() { file_isac_proto_init() }
+func file_isac_proto_init() {
+	if File_isac_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_isac_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Empty); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_isac_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BeamformingRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_isac_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BeamformingResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_isac_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DOARequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_isac_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DOAResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_isac_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IRSStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_isac_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IRSStatusRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_isac_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IRSConfigRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_isac_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IRSConfigResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_isac_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SensorInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_isac_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SensorList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_isac_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SensorData); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_isac_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SensorBatchRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_isac_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SensorReadResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_isac_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SensorBatchResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_isac_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IQStreamRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_isac_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IQBlock); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_isac_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   4,
+		},
+		GoTypes:           file_isac_proto_goTypes,
+		DependencyIndexes: file_isac_proto_depIdxs,
+		MessageInfos:      file_isac_proto_msgTypes,
+	}.Build()
+	File_isac_proto = out.File
+	file_isac_proto_rawDesc = nil
+	file_isac_proto_goTypes = nil
+	file_isac_proto_depIdxs = nil
 }
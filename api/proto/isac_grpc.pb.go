@@ -0,0 +1,652 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: isac.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	AlgorithmService_RunBeamforming_FullMethodName    = "/isac.AlgorithmService/RunBeamforming"
+	AlgorithmService_RunDOA_FullMethodName            = "/isac.AlgorithmService/RunDOA"
+	AlgorithmService_StreamBeamforming_FullMethodName = "/isac.AlgorithmService/StreamBeamforming"
+)
+
+// AlgorithmServiceClient is the client API for AlgorithmService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AlgorithmServiceClient interface {
+	RunBeamforming(ctx context.Context, in *BeamformingRequest, opts ...grpc.CallOption) (*BeamformingResponse, error)
+	RunDOA(ctx context.Context, in *DOARequest, opts ...grpc.CallOption) (*DOAResponse, error)
+	StreamBeamforming(ctx context.Context, opts ...grpc.CallOption) (AlgorithmService_StreamBeamformingClient, error)
+}
+
+type algorithmServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAlgorithmServiceClient(cc grpc.ClientConnInterface) AlgorithmServiceClient {
+	return &algorithmServiceClient{cc}
+}
+
+func (c *algorithmServiceClient) RunBeamforming(ctx context.Context, in *BeamformingRequest, opts ...grpc.CallOption) (*BeamformingResponse, error) {
+	out := new(BeamformingResponse)
+	err := c.cc.Invoke(ctx, AlgorithmService_RunBeamforming_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *algorithmServiceClient) RunDOA(ctx context.Context, in *DOARequest, opts ...grpc.CallOption) (*DOAResponse, error) {
+	out := new(DOAResponse)
+	err := c.cc.Invoke(ctx, AlgorithmService_RunDOA_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *algorithmServiceClient) StreamBeamforming(ctx context.Context, opts ...grpc.CallOption) (AlgorithmService_StreamBeamformingClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AlgorithmService_ServiceDesc.Streams[0], AlgorithmService_StreamBeamforming_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &algorithmServiceStreamBeamformingClient{stream}
+	return x, nil
+}
+
+type AlgorithmService_StreamBeamformingClient interface {
+	Send(*BeamformingRequest) error
+	Recv() (*BeamformingResponse, error)
+	grpc.ClientStream
+}
+
+type algorithmServiceStreamBeamformingClient struct {
+	grpc.ClientStream
+}
+
+func (x *algorithmServiceStreamBeamformingClient) Send(m *BeamformingRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *algorithmServiceStreamBeamformingClient) Recv() (*BeamformingResponse, error) {
+	m := new(BeamformingResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AlgorithmServiceServer is the server API for AlgorithmService service.
+// All implementations must embed UnimplementedAlgorithmServiceServer
+// for forward compatibility
+type AlgorithmServiceServer interface {
+	RunBeamforming(context.Context, *BeamformingRequest) (*BeamformingResponse, error)
+	RunDOA(context.Context, *DOARequest) (*DOAResponse, error)
+	StreamBeamforming(AlgorithmService_StreamBeamformingServer) error
+	mustEmbedUnimplementedAlgorithmServiceServer()
+}
+
+// UnimplementedAlgorithmServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedAlgorithmServiceServer struct {
+}
+
+func (UnimplementedAlgorithmServiceServer) RunBeamforming(context.Context, *BeamformingRequest) (*BeamformingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunBeamforming not implemented")
+}
+func (UnimplementedAlgorithmServiceServer) RunDOA(context.Context, *DOARequest) (*DOAResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunDOA not implemented")
+}
+func (UnimplementedAlgorithmServiceServer) StreamBeamforming(AlgorithmService_StreamBeamformingServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamBeamforming not implemented")
+}
+func (UnimplementedAlgorithmServiceServer) mustEmbedUnimplementedAlgorithmServiceServer() {}
+
+// UnsafeAlgorithmServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AlgorithmServiceServer will
+// result in compilation errors.
+type UnsafeAlgorithmServiceServer interface {
+	mustEmbedUnimplementedAlgorithmServiceServer()
+}
+
+func RegisterAlgorithmServiceServer(s grpc.ServiceRegistrar, srv AlgorithmServiceServer) {
+	s.RegisterService(&AlgorithmService_ServiceDesc, srv)
+}
+
+func _AlgorithmService_RunBeamforming_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BeamformingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AlgorithmServiceServer).RunBeamforming(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AlgorithmService_RunBeamforming_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AlgorithmServiceServer).RunBeamforming(ctx, req.(*BeamformingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AlgorithmService_RunDOA_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DOARequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AlgorithmServiceServer).RunDOA(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AlgorithmService_RunDOA_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AlgorithmServiceServer).RunDOA(ctx, req.(*DOARequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AlgorithmService_StreamBeamforming_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AlgorithmServiceServer).StreamBeamforming(&algorithmServiceStreamBeamformingServer{stream})
+}
+
+type AlgorithmService_StreamBeamformingServer interface {
+	Send(*BeamformingResponse) error
+	Recv() (*BeamformingRequest, error)
+	grpc.ServerStream
+}
+
+type algorithmServiceStreamBeamformingServer struct {
+	grpc.ServerStream
+}
+
+func (x *algorithmServiceStreamBeamformingServer) Send(m *BeamformingResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *algorithmServiceStreamBeamformingServer) Recv() (*BeamformingRequest, error) {
+	m := new(BeamformingRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AlgorithmService_ServiceDesc is the grpc.ServiceDesc for AlgorithmService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AlgorithmService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "isac.AlgorithmService",
+	HandlerType: (*AlgorithmServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RunBeamforming",
+			Handler:    _AlgorithmService_RunBeamforming_Handler,
+		},
+		{
+			MethodName: "RunDOA",
+			Handler:    _AlgorithmService_RunDOA_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamBeamforming",
+			Handler:       _AlgorithmService_StreamBeamforming_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "isac.proto",
+}
+
+const (
+	IRSService_GetStatus_FullMethodName = "/isac.IRSService/GetStatus"
+	IRSService_Configure_FullMethodName = "/isac.IRSService/Configure"
+)
+
+// IRSServiceClient is the client API for IRSService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type IRSServiceClient interface {
+	GetStatus(ctx context.Context, in *IRSStatusRequest, opts ...grpc.CallOption) (*IRSStatus, error)
+	Configure(ctx context.Context, in *IRSConfigRequest, opts ...grpc.CallOption) (*IRSConfigResponse, error)
+}
+
+type iRSServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIRSServiceClient(cc grpc.ClientConnInterface) IRSServiceClient {
+	return &iRSServiceClient{cc}
+}
+
+func (c *iRSServiceClient) GetStatus(ctx context.Context, in *IRSStatusRequest, opts ...grpc.CallOption) (*IRSStatus, error) {
+	out := new(IRSStatus)
+	err := c.cc.Invoke(ctx, IRSService_GetStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iRSServiceClient) Configure(ctx context.Context, in *IRSConfigRequest, opts ...grpc.CallOption) (*IRSConfigResponse, error) {
+	out := new(IRSConfigResponse)
+	err := c.cc.Invoke(ctx, IRSService_Configure_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IRSServiceServer is the server API for IRSService service.
+// All implementations must embed UnimplementedIRSServiceServer
+// for forward compatibility
+type IRSServiceServer interface {
+	GetStatus(context.Context, *IRSStatusRequest) (*IRSStatus, error)
+	Configure(context.Context, *IRSConfigRequest) (*IRSConfigResponse, error)
+	mustEmbedUnimplementedIRSServiceServer()
+}
+
+// UnimplementedIRSServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedIRSServiceServer struct {
+}
+
+func (UnimplementedIRSServiceServer) GetStatus(context.Context, *IRSStatusRequest) (*IRSStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedIRSServiceServer) Configure(context.Context, *IRSConfigRequest) (*IRSConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Configure not implemented")
+}
+func (UnimplementedIRSServiceServer) mustEmbedUnimplementedIRSServiceServer() {}
+
+// UnsafeIRSServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IRSServiceServer will
+// result in compilation errors.
+type UnsafeIRSServiceServer interface {
+	mustEmbedUnimplementedIRSServiceServer()
+}
+
+func RegisterIRSServiceServer(s grpc.ServiceRegistrar, srv IRSServiceServer) {
+	s.RegisterService(&IRSService_ServiceDesc, srv)
+}
+
+func _IRSService_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IRSStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IRSServiceServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IRSService_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IRSServiceServer).GetStatus(ctx, req.(*IRSStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IRSService_Configure_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IRSConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IRSServiceServer).Configure(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IRSService_Configure_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IRSServiceServer).Configure(ctx, req.(*IRSConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// IRSService_ServiceDesc is the grpc.ServiceDesc for IRSService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var IRSService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "isac.IRSService",
+	HandlerType: (*IRSServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStatus",
+			Handler:    _IRSService_GetStatus_Handler,
+		},
+		{
+			MethodName: "Configure",
+			Handler:    _IRSService_Configure_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "isac.proto",
+}
+
+const (
+	SensorService_ListSensors_FullMethodName      = "/isac.SensorService/ListSensors"
+	SensorService_StreamSensorData_FullMethodName = "/isac.SensorService/StreamSensorData"
+	SensorService_BatchRead_FullMethodName        = "/isac.SensorService/BatchRead"
+)
+
+// SensorServiceClient is the client API for SensorService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SensorServiceClient interface {
+	ListSensors(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*SensorList, error)
+	StreamSensorData(ctx context.Context, in *Empty, opts ...grpc.CallOption) (SensorService_StreamSensorDataClient, error)
+	BatchRead(ctx context.Context, in *SensorBatchRequest, opts ...grpc.CallOption) (*SensorBatchResponse, error)
+}
+
+type sensorServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSensorServiceClient(cc grpc.ClientConnInterface) SensorServiceClient {
+	return &sensorServiceClient{cc}
+}
+
+func (c *sensorServiceClient) ListSensors(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*SensorList, error) {
+	out := new(SensorList)
+	err := c.cc.Invoke(ctx, SensorService_ListSensors_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sensorServiceClient) StreamSensorData(ctx context.Context, in *Empty, opts ...grpc.CallOption) (SensorService_StreamSensorDataClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SensorService_ServiceDesc.Streams[0], SensorService_StreamSensorData_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &sensorServiceStreamSensorDataClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SensorService_StreamSensorDataClient interface {
+	Recv() (*SensorData, error)
+	grpc.ClientStream
+}
+
+type sensorServiceStreamSensorDataClient struct {
+	grpc.ClientStream
+}
+
+func (x *sensorServiceStreamSensorDataClient) Recv() (*SensorData, error) {
+	m := new(SensorData)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *sensorServiceClient) BatchRead(ctx context.Context, in *SensorBatchRequest, opts ...grpc.CallOption) (*SensorBatchResponse, error) {
+	out := new(SensorBatchResponse)
+	err := c.cc.Invoke(ctx, SensorService_BatchRead_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SensorServiceServer is the server API for SensorService service.
+// All implementations must embed UnimplementedSensorServiceServer
+// for forward compatibility
+type SensorServiceServer interface {
+	ListSensors(context.Context, *Empty) (*SensorList, error)
+	StreamSensorData(*Empty, SensorService_StreamSensorDataServer) error
+	BatchRead(context.Context, *SensorBatchRequest) (*SensorBatchResponse, error)
+	mustEmbedUnimplementedSensorServiceServer()
+}
+
+// UnimplementedSensorServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedSensorServiceServer struct {
+}
+
+func (UnimplementedSensorServiceServer) ListSensors(context.Context, *Empty) (*SensorList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSensors not implemented")
+}
+func (UnimplementedSensorServiceServer) StreamSensorData(*Empty, SensorService_StreamSensorDataServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamSensorData not implemented")
+}
+func (UnimplementedSensorServiceServer) BatchRead(context.Context, *SensorBatchRequest) (*SensorBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchRead not implemented")
+}
+func (UnimplementedSensorServiceServer) mustEmbedUnimplementedSensorServiceServer() {}
+
+// UnsafeSensorServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SensorServiceServer will
+// result in compilation errors.
+type UnsafeSensorServiceServer interface {
+	mustEmbedUnimplementedSensorServiceServer()
+}
+
+func RegisterSensorServiceServer(s grpc.ServiceRegistrar, srv SensorServiceServer) {
+	s.RegisterService(&SensorService_ServiceDesc, srv)
+}
+
+func _SensorService_ListSensors_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SensorServiceServer).ListSensors(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SensorService_ListSensors_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SensorServiceServer).ListSensors(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SensorService_StreamSensorData_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SensorServiceServer).StreamSensorData(m, &sensorServiceStreamSensorDataServer{stream})
+}
+
+type SensorService_StreamSensorDataServer interface {
+	Send(*SensorData) error
+	grpc.ServerStream
+}
+
+type sensorServiceStreamSensorDataServer struct {
+	grpc.ServerStream
+}
+
+func (x *sensorServiceStreamSensorDataServer) Send(m *SensorData) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _SensorService_BatchRead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SensorBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SensorServiceServer).BatchRead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SensorService_BatchRead_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SensorServiceServer).BatchRead(ctx, req.(*SensorBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SensorService_ServiceDesc is the grpc.ServiceDesc for SensorService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SensorService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "isac.SensorService",
+	HandlerType: (*SensorServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListSensors",
+			Handler:    _SensorService_ListSensors_Handler,
+		},
+		{
+			MethodName: "BatchRead",
+			Handler:    _SensorService_BatchRead_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamSensorData",
+			Handler:       _SensorService_StreamSensorData_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "isac.proto",
+}
+
+const (
+	CaptureService_StreamIQ_FullMethodName = "/isac.CaptureService/StreamIQ"
+)
+
+// CaptureServiceClient is the client API for CaptureService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CaptureServiceClient interface {
+	StreamIQ(ctx context.Context, in *IQStreamRequest, opts ...grpc.CallOption) (CaptureService_StreamIQClient, error)
+}
+
+type captureServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCaptureServiceClient(cc grpc.ClientConnInterface) CaptureServiceClient {
+	return &captureServiceClient{cc}
+}
+
+func (c *captureServiceClient) StreamIQ(ctx context.Context, in *IQStreamRequest, opts ...grpc.CallOption) (CaptureService_StreamIQClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CaptureService_ServiceDesc.Streams[0], CaptureService_StreamIQ_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &captureServiceStreamIQClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CaptureService_StreamIQClient interface {
+	Recv() (*IQBlock, error)
+	grpc.ClientStream
+}
+
+type captureServiceStreamIQClient struct {
+	grpc.ClientStream
+}
+
+func (x *captureServiceStreamIQClient) Recv() (*IQBlock, error) {
+	m := new(IQBlock)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// CaptureServiceServer is the server API for CaptureService service.
+// All implementations must embed UnimplementedCaptureServiceServer
+// for forward compatibility
+type CaptureServiceServer interface {
+	StreamIQ(*IQStreamRequest, CaptureService_StreamIQServer) error
+	mustEmbedUnimplementedCaptureServiceServer()
+}
+
+// UnimplementedCaptureServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedCaptureServiceServer struct {
+}
+
+func (UnimplementedCaptureServiceServer) StreamIQ(*IQStreamRequest, CaptureService_StreamIQServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamIQ not implemented")
+}
+func (UnimplementedCaptureServiceServer) mustEmbedUnimplementedCaptureServiceServer() {}
+
+// UnsafeCaptureServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CaptureServiceServer will
+// result in compilation errors.
+type UnsafeCaptureServiceServer interface {
+	mustEmbedUnimplementedCaptureServiceServer()
+}
+
+func RegisterCaptureServiceServer(s grpc.ServiceRegistrar, srv CaptureServiceServer) {
+	s.RegisterService(&CaptureService_ServiceDesc, srv)
+}
+
+func _CaptureService_StreamIQ_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(IQStreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CaptureServiceServer).StreamIQ(m, &captureServiceStreamIQServer{stream})
+}
+
+type CaptureService_StreamIQServer interface {
+	Send(*IQBlock) error
+	grpc.ServerStream
+}
+
+type captureServiceStreamIQServer struct {
+	grpc.ServerStream
+}
+
+func (x *captureServiceStreamIQServer) Send(m *IQBlock) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// CaptureService_ServiceDesc is the grpc.ServiceDesc for CaptureService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CaptureService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "isac.CaptureService",
+	HandlerType: (*CaptureServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamIQ",
+			Handler:       _CaptureService_StreamIQ_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "isac.proto",
+}
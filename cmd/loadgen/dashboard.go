@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/hub"
+	"isac-cran-system/pkg/pool"
+)
+
+// serverMetrics is the part of the server's /debug/metrics the dashboard
+// shows. The sections other than the runtime ones are only there when the
+// server registered them.
+type serverMetrics struct {
+	Goroutines int `json:"goroutines"`
+	Memory     struct {
+		HeapAlloc float64 `json:"heap_alloc"`
+		GCCount   uint32  `json:"gc_count"`
+		GCPause   float64 `json:"gc_pause_s"`
+	} `json:"memory"`
+	WorkerPool      *pool.Stats           `json:"worker_pool"`
+	ExperimentQueue *int                  `json:"experiment_queue"`
+	StreamHub       *hub.Stats            `json:"stream_hub"`
+	MySQLPool       *model.MySQLPoolStats `json:"mysql_pool"`
+}
+
+// metricsScraper reads /debug/metrics, which is served outside /api/v1.
+type metricsScraper struct {
+	url        string
+	token      string
+	httpClient *http.Client
+}
+
+func (m *metricsScraper) scrape(ctx context.Context) (*serverMetrics, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if m.token != "" {
+		req.Header.Set("Authorization", "Bearer "+m.token)
+	}
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned HTTP %d", m.url, resp.StatusCode)
+	}
+	var metrics serverMetrics
+	if err := json.NewDecoder(resp.Body).Decode(&metrics); err != nil {
+		return nil, fmt.Errorf("failed to decode metrics: %w", err)
+	}
+	return &metrics, nil
+}
+
+type dashboard struct {
+	target   string
+	duration time.Duration
+	users    int
+	think    time.Duration
+	// live redraws the screen in place; otherwise each refresh is appended,
+	// for output sent to a file
+	live bool
+}
+
+// render writes one frame: the client-side statistics of every operation
+// and, when the scrape succeeded, the server's.
+func (d *dashboard) render(w io.Writer, sum *summary, metrics *serverMetrics, scrapeErr error) {
+	if d.live {
+		fmt.Fprint(w, "\033[H\033[2J")
+	}
+	total := "until interrupted"
+	if d.duration > 0 {
+		total = d.duration.String()
+	}
+	fmt.Fprintf(w, "ISAC-CRAN load generator  %s\n", d.target)
+	fmt.Fprintf(w, "elapsed %s / %s   users %d/%d   mean think time %s\n\n",
+		sum.Elapsed.Truncate(time.Second), total, sum.Users, d.users, d.think)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "operation\tcount\terrors\t429\tqueued\tqps now\tqps avg\tp50 now\tp99 now\tp50\tp90\tp99\tmax\t")
+	for _, o := range sum.Ops {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%.2f\t%.2f\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			o.Name, o.Count, o.Errors, o.Throttled, o.Queued, o.QPSNow, o.QPS,
+			ms(o.P50Now), ms(o.P99Now), ms(o.P50), ms(o.P90), ms(o.P99), ms(o.Max))
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	switch {
+	case scrapeErr != nil:
+		fmt.Fprintf(w, "server metrics unavailable: %v\n", scrapeErr)
+	case metrics != nil:
+		d.renderServer(w, metrics)
+	}
+
+	if last := sum.Ops[len(sum.Ops)-1].LastError; last != "" {
+		fmt.Fprintf(w, "\nlast error  %s\n", truncate(last, 160))
+	}
+}
+
+func (d *dashboard) renderServer(w io.Writer, m *serverMetrics) {
+	fmt.Fprintf(w, "server  goroutines %d   heap %.1f MB   gc %d (%.3f s paused)",
+		m.Goroutines, m.Memory.HeapAlloc, m.Memory.GCCount, m.Memory.GCPause)
+	if m.ExperimentQueue != nil {
+		fmt.Fprintf(w, "   queued experiments %d", *m.ExperimentQueue)
+	}
+	fmt.Fprintln(w)
+	if m.StreamHub != nil {
+		fmt.Fprintf(w, "stream  clients %d   delivered %d   dropped %d   evicted %d\n",
+			m.StreamHub.Clients, m.StreamHub.Delivered, m.StreamHub.Dropped, m.StreamHub.Evicted)
+	}
+	if m.MySQLPool != nil {
+		p := m.MySQLPool.Primary
+		fmt.Fprintf(w, "mysql   open %d/%d   in use %d   waits %d (%.3f s)\n",
+			p.Open, p.MaxOpen, p.InUse, p.WaitCount, p.WaitDuration)
+	}
+	if m.WorkerPool == nil {
+		return
+	}
+	wp := m.WorkerPool
+	fmt.Fprintf(w, "workers %d   queue %d/%d   overrunning %d\n", wp.Workers, wp.QueueLength, wp.QueueCapacity, wp.Overrunning)
+	if len(wp.Tasks) == 0 {
+		return
+	}
+	types := make([]string, 0, len(wp.Tasks))
+	for t := range wp.Tasks {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "task\tsubmitted\trejected\tfailed\ttimed out\tavg wait\tmax wait\tavg run\tmax run\t")
+	for _, t := range types {
+		s := wp.Tasks[t]
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%.1fms\t%.1fms\t%.1fms\t%.1fms\t\n",
+			t, s.Submitted, s.Rejected, s.Failed, s.TimedOut,
+			s.AvgQueueWaitMs, s.MaxQueueWaitMs, s.AvgRunTimeMs, s.MaxRunTimeMs)
+	}
+	tw.Flush()
+}
+
+func ms(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+}
+
+func truncate(s string, n int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"isac-cran-system/pkg/client"
+)
+
+func TestParseMix(t *testing.T) {
+	ops, err := parseMix("beamforming=3, doa , sweep=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 2 || ops[0].name != "beamforming" || ops[0].weight != 3 || ops[1].name != "doa" || ops[1].weight != 1 {
+		t.Errorf("ops = %+v", ops)
+	}
+
+	for _, mix := range []string{"", "sweep=0", "radar=1", "doa=-1", "doa=x", "doa,doa=2"} {
+		if _, err := parseMix(mix); err == nil {
+			t.Errorf("parseMix(%q) succeeded", mix)
+		}
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	var h histogram
+	for i := 1; i <= 1000; i++ {
+		h.add(time.Duration(i) * time.Millisecond)
+	}
+	for _, c := range []struct {
+		q    float64
+		want time.Duration
+	}{{0.5, 500 * time.Millisecond}, {0.9, 900 * time.Millisecond}, {0.99, 990 * time.Millisecond}} {
+		got := h.quantile(c.q)
+		if got < c.want || float64(got) > float64(c.want)*histGrowth {
+			t.Errorf("quantile(%g) = %v, want within a bucket above %v", c.q, got, c.want)
+		}
+	}
+	if h.quantile(1) != time.Second {
+		t.Errorf("quantile(1) = %v, want the maximum", h.quantile(1))
+	}
+}
+
+func TestRunUser(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch n := calls.Add(1); {
+		case n%5 == 0:
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"code": 429, "message": "too many requests"}`)
+		case n%7 == 0:
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"code": 50001, "message": "algorithm failed"}`)
+		default:
+			fmt.Fprint(w, `{"code": 0, "message": "success", "data": {}}`)
+		}
+	}))
+	defer server.Close()
+	c, err := client.New(server.URL, client.WithRetry(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ops, _ := parseMix("beamforming=1,doa=1")
+	stats := newRecorder([]string{"beamforming", "doa"})
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	runUser(ctx, 1, "test", c, ops, time.Millisecond, 0, rand.New(rand.NewSource(1)), stats)
+
+	sum := stats.summarize()
+	all := sum.Ops[len(sum.Ops)-1]
+	// the request under way when the run ends is not counted
+	if n := int64(calls.Load()); sum.Users != 1 || all.Count < n-1 || all.Count > n || all.Throttled == 0 || all.Errors == 0 {
+		t.Fatalf("summary = %+v after %d calls", all, calls.Load())
+	}
+	if sum.Ops[0].Count+sum.Ops[1].Count != all.Count || all.P50 == 0 || !strings.Contains(all.LastError, "algorithm failed") {
+		t.Errorf("summary = %+v", sum.Ops)
+	}
+
+	var out bytes.Buffer
+	d := &dashboard{target: server.URL, users: 1, think: time.Millisecond}
+	d.render(&out, sum, &serverMetrics{Goroutines: 42}, nil)
+	for _, want := range []string{"beamforming", "doa", "all", "goroutines 42", "last error"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("dashboard lacks %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Command loadgen soak-tests a running server before a demo. A number of
+// simulated users repeat a weighted mix of beamforming runs, DOA runs and
+// beam sweeps, pausing for a random think time between them, while a
+// terminal dashboard shows throughput and latencies next to the server's
+// own /debug/metrics.
+//
+//	go run ./cmd/loadgen -url http://testbed:8080 -users 16 -duration 30m -mix beamforming=6,doa=3,sweep=1
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"isac-cran-system/pkg/client"
+)
+
+func main() {
+	var (
+		target   = flag.String("url", "http://localhost:8080", "server base URL")
+		token    = flag.String("token", "", "API token")
+		users    = flag.Int("users", 8, "concurrent simulated users")
+		duration = flag.Duration("duration", 10*time.Minute, "length of the run, 0 to run until interrupted")
+		ramp     = flag.Duration("ramp", 30*time.Second, "time over which the users start")
+		think    = flag.Duration("think", 2*time.Second, "mean think time between a user's operations")
+		mix      = flag.String("mix", "beamforming=6,doa=3,sweep=1", "relative weights of the operations ("+strings.Join(operationNames(), ", ")+")")
+		refresh  = flag.Duration("refresh", 2*time.Second, "dashboard refresh interval")
+		timeout  = flag.Duration("timeout", 2*time.Minute, "timeout of one request")
+		noCache  = flag.Bool("no-cache", false, "make every run bypass the server's result cache")
+		seed     = flag.Int64("seed", 0, "random seed, 0 for a new one every run")
+	)
+	flag.Parse()
+
+	ops, err := parseMix(*mix)
+	if err != nil {
+		fatal(err)
+	}
+	if *users <= 0 || *refresh <= 0 || *duration < 0 || *ramp < 0 {
+		fatal(fmt.Errorf("users and refresh must be positive, duration and ramp not negative"))
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	httpClient := &http.Client{Timeout: *timeout}
+	opts := []client.Option{client.WithHTTPClient(httpClient), client.WithRetry(0, 0)}
+	if *token != "" {
+		opts = append(opts, client.WithToken(*token))
+	}
+	c, err := client.New(*target, opts...)
+	if err != nil {
+		fatal(err)
+	}
+	defer c.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+
+	names := make([]string, len(ops))
+	for i, op := range ops {
+		names[i] = op.name
+	}
+	stats := newRecorder(names)
+	runID := time.Now().UTC().Format("0102T150405")
+	runCtx := ctx
+	if *noCache {
+		runCtx = client.WithCacheBypass(ctx)
+	}
+
+	var wg sync.WaitGroup
+	for u := 0; u < *users; u++ {
+		delay := time.Duration(0)
+		if *users > 1 {
+			delay = *ramp * time.Duration(u) / time.Duration(*users-1)
+		}
+		rng := rand.New(rand.NewSource(*seed + int64(u)))
+		wg.Add(1)
+		go func(user int) {
+			defer wg.Done()
+			runUser(runCtx, user, runID, c, ops, *think, delay, rng, stats)
+		}(u + 1)
+	}
+
+	scraper := &metricsScraper{
+		url:        strings.TrimRight(*target, "/") + "/debug/metrics",
+		token:      *token,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+	info, _ := os.Stdout.Stat()
+	dash := &dashboard{
+		target:   *target,
+		duration: *duration,
+		users:    *users,
+		think:    *think,
+		live:     info != nil && info.Mode()&os.ModeCharDevice != 0,
+	}
+	frame := func() {
+		metrics, err := scraper.scrape(context.Background())
+		dash.render(os.Stdout, stats.summarize(), metrics, err)
+	}
+
+	ticker := time.NewTicker(*refresh)
+	defer ticker.Stop()
+	for ctx.Err() == nil {
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+			frame()
+		}
+	}
+	wg.Wait()
+
+	// the final frame is left on the screen as the run's summary
+	dash.live = false
+	fmt.Println()
+	frame()
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "loadgen:", err)
+	os.Exit(1)
+}
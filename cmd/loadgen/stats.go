@@ -0,0 +1,218 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Latencies over a whole run go into a histogram with buckets growing by
+// histGrowth from histMin, which bounds the memory of a soak test and the
+// error of its percentiles to a tenth.
+const (
+	histMin     = 100 * time.Microsecond
+	histGrowth  = 1.1
+	histBuckets = 200
+)
+
+type histogram struct {
+	counts [histBuckets]int64
+	n      int64
+	max    time.Duration
+}
+
+func bucketOf(d time.Duration) int {
+	if d <= histMin {
+		return 0
+	}
+	i := int(math.Log(float64(d)/float64(histMin))/math.Log(histGrowth)) + 1
+	return min(i, histBuckets-1)
+}
+
+func (h *histogram) add(d time.Duration) {
+	h.counts[bucketOf(d)]++
+	h.n++
+	h.max = max(h.max, d)
+}
+
+func (h *histogram) merge(o *histogram) {
+	for i, c := range o.counts {
+		h.counts[i] += c
+	}
+	h.n += o.n
+	h.max = max(h.max, o.max)
+}
+
+// quantile returns the upper bound of the bucket holding the q quantile,
+// and never more than the largest latency seen.
+func (h *histogram) quantile(q float64) time.Duration {
+	if h.n == 0 {
+		return 0
+	}
+	target := int64(math.Ceil(q * float64(h.n)))
+	var seen int64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= target {
+			return min(time.Duration(float64(histMin)*math.Pow(histGrowth, float64(i))), h.max)
+		}
+	}
+	return h.max
+}
+
+// percentile of the latencies of one refresh interval, which are few
+// enough to sort.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(i, 0)]
+}
+
+type opStats struct {
+	count     int64
+	errors    int64
+	throttled int64
+	queued    int64
+	total     histogram
+	window    []time.Duration
+	lastError string
+}
+
+// recorder collects the outcome of every operation, both over the whole
+// run and since the dashboard last read it.
+type recorder struct {
+	mu       sync.Mutex
+	started  time.Time
+	lastRead time.Time
+	users    int
+	ops      map[string]*opStats
+	order    []string
+}
+
+func newRecorder(names []string) *recorder {
+	now := time.Now()
+	r := &recorder{started: now, lastRead: now, ops: make(map[string]*opStats), order: names}
+	for _, name := range names {
+		r.ops[name] = &opStats{}
+	}
+	return r
+}
+
+func (r *recorder) userStarted() {
+	r.mu.Lock()
+	r.users++
+	r.mu.Unlock()
+}
+
+// record counts an operation. Only successful ones add to the latencies:
+// a request the server rejects at once would flatter them.
+func (r *recorder) record(name string, latency time.Duration, queued bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.ops[name]
+	s.count++
+	if queued {
+		s.queued++
+	}
+	switch {
+	case err == nil:
+		s.total.add(latency)
+		s.window = append(s.window, latency)
+	case throttled(err):
+		s.throttled++
+	default:
+		s.errors++
+		s.lastError = err.Error()
+	}
+}
+
+// opSummary is what the dashboard shows for an operation, or for all of
+// them under the name "all". The Now figures cover the interval since the
+// previous summary.
+type opSummary struct {
+	Name      string
+	Count     int64
+	Errors    int64
+	Throttled int64
+	Queued    int64
+	QPS       float64
+	QPSNow    float64
+	P50Now    time.Duration
+	P99Now    time.Duration
+	P50       time.Duration
+	P90       time.Duration
+	P99       time.Duration
+	Max       time.Duration
+	LastError string
+}
+
+type summary struct {
+	Elapsed time.Duration
+	Users   int
+	Ops     []opSummary
+}
+
+// summarize reads the statistics and starts a new interval.
+func (r *recorder) summarize() *summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	elapsed := now.Sub(r.started).Seconds()
+	interval := now.Sub(r.lastRead).Seconds()
+	r.lastRead = now
+
+	sum := &summary{Elapsed: now.Sub(r.started), Users: r.users}
+	all := opSummary{Name: "all"}
+	var allTotal histogram
+	var allWindow []time.Duration
+	for _, name := range r.order {
+		s := r.ops[name]
+		o := summarizeOp(name, s, s.window, &s.total, elapsed, interval)
+		sum.Ops = append(sum.Ops, o)
+
+		all.Count += s.count
+		all.Errors += s.errors
+		all.Throttled += s.throttled
+		all.Queued += s.queued
+		if s.lastError != "" {
+			all.LastError = name + ": " + s.lastError
+		}
+		allTotal.merge(&s.total)
+		allWindow = append(allWindow, s.window...)
+		s.window = s.window[:0]
+	}
+	lastError := all.LastError
+	all = summarizeOp("all", &opStats{count: all.Count, errors: all.Errors, throttled: all.Throttled, queued: all.Queued}, allWindow, &allTotal, elapsed, interval)
+	all.LastError = lastError
+	sum.Ops = append(sum.Ops, all)
+	return sum
+}
+
+func summarizeOp(name string, s *opStats, window []time.Duration, total *histogram, elapsed, interval float64) opSummary {
+	sorted := append([]time.Duration(nil), window...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	o := opSummary{
+		Name:      name,
+		Count:     s.count,
+		Errors:    s.errors,
+		Throttled: s.throttled,
+		Queued:    s.queued,
+		P50Now:    percentile(sorted, 0.5),
+		P99Now:    percentile(sorted, 0.99),
+		P50:       total.quantile(0.5),
+		P90:       total.quantile(0.9),
+		P99:       total.quantile(0.99),
+		Max:       total.max,
+		LastError: s.lastError,
+	}
+	if elapsed > 0 {
+		o.QPS = float64(total.n) / elapsed
+	}
+	if interval > 0 {
+		o.QPSNow = float64(len(window)) / interval
+	}
+	return o
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"isac-cran-system/pkg/client"
+)
+
+// An operation is one thing a user does against the server. run returns
+// whether the server queued the request before serving it.
+type operation struct {
+	name   string
+	weight int
+	run    func(ctx context.Context, c *client.Client, rng *rand.Rand, id string) (queued bool, err error)
+}
+
+var operations = map[string]func(ctx context.Context, c *client.Client, rng *rand.Rand, id string) (bool, error){
+	"beamforming": runBeamforming,
+	"doa":         runDOA,
+	"sweep":       runSweep,
+}
+
+// parseMix reads a workload mix such as "beamforming=6,doa=3,sweep=1";
+// the weights are relative.
+func parseMix(mix string) ([]operation, error) {
+	var ops []operation
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(mix, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		weight := 1
+		if ok {
+			w, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || w < 0 {
+				return nil, fmt.Errorf("invalid weight %q for %s", value, name)
+			}
+			weight = w
+		}
+		name = strings.TrimSpace(name)
+		run, known := operations[name]
+		if !known {
+			return nil, fmt.Errorf("unknown operation %q, want one of %s", name, strings.Join(operationNames(), ", "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("operation %s given twice", name)
+		}
+		seen[name] = true
+		if weight > 0 {
+			ops = append(ops, operation{name: name, weight: weight, run: run})
+		}
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("the mix has no operations")
+	}
+	return ops, nil
+}
+
+func operationNames() []string {
+	names := make([]string, 0, len(operations))
+	for name := range operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func pick(ops []operation, rng *rand.Rand) operation {
+	total := 0
+	for _, op := range ops {
+		total += op.weight
+	}
+	n := rng.Intn(total)
+	for _, op := range ops {
+		if n < op.weight {
+			return op
+		}
+		n -= op.weight
+	}
+	return ops[len(ops)-1]
+}
+
+// thinkTime is the pause of a user between two operations: exponentially
+// distributed around mean, as for people working independently, and cut
+// at five times the mean so one user does not go quiet for minutes.
+func thinkTime(mean time.Duration, rng *rand.Rand) time.Duration {
+	if mean <= 0 {
+		return 0
+	}
+	return time.Duration(math.Min(rng.ExpFloat64(), 5) * float64(mean))
+}
+
+// runUser repeats operations picked from the mix, thinking between them,
+// until ctx ends. The user starts after delay, so that users ramp up
+// rather than arrive at once.
+func runUser(ctx context.Context, user int, runID string, c *client.Client, ops []operation, think, delay time.Duration, rng *rand.Rand, stats *recorder) {
+	if !sleep(ctx, delay) {
+		return
+	}
+	stats.userStarted()
+	for n := 1; ; n++ {
+		op := pick(ops, rng)
+		id := fmt.Sprintf("loadgen_%s_u%d_%d", runID, user, n)
+		start := time.Now()
+		queued, err := op.run(ctx, c, rng, id)
+		if ctx.Err() != nil {
+			// cut short by the end of the run, not the server's doing
+			return
+		}
+		stats.record(op.name, time.Since(start), queued, err)
+		if !sleep(ctx, thinkTime(think, rng)) {
+			return
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// throttled reports whether the server turned the request away with 429.
+func throttled(err error) bool {
+	var httpErr *client.HTTPError
+	return stderrors.As(err, &httpErr) && httpErr.StatusCode == http.StatusTooManyRequests
+}
+
+// waitQueued follows a run the server queued until it completes.
+func waitQueued(ctx context.Context, c *client.Client, err error) (bool, error) {
+	var queued *client.QueuedError
+	if !stderrors.As(err, &queued) {
+		return false, err
+	}
+	experiment, err := c.WaitExperiment(ctx, queued.Queued.ExperimentID)
+	if err != nil {
+		return true, err
+	}
+	if experiment.Status == client.ExperimentStatusFailed {
+		return true, fmt.Errorf("experiment %s failed", experiment.ExperimentID)
+	}
+	return true, nil
+}
+
+func runBeamforming(ctx context.Context, c *client.Client, rng *rand.Rand, id string) (bool, error) {
+	params := &client.BeamformingParams{
+		ElementCount:    []int{8, 16, 32}[rng.Intn(3)],
+		TargetDirection: -60 + 120*rng.Float64(),
+		SNRThreshold:    10,
+		MaxIterations:   100,
+	}
+	if rng.Intn(2) == 0 {
+		params.InterferenceAngles = []float64{-60 + 120*rng.Float64()}
+	}
+	_, err := c.RunBeamforming(ctx, id, params)
+	return waitQueued(ctx, c, err)
+}
+
+func runDOA(ctx context.Context, c *client.Client, rng *rand.Rand, id string) (bool, error) {
+	params := &client.DOAParams{
+		ElementCount:   8,
+		NumSources:     1 + rng.Intn(2),
+		SnapshotLength: []int{128, 256, 512}[rng.Intn(3)],
+		Method:         []string{"music", "esprit"}[rng.Intn(2)],
+		SearchRangeMin: -90,
+		SearchRangeMax: 90,
+		SearchStep:     0.5,
+	}
+	_, err := c.RunDOA(ctx, id, params)
+	return waitQueued(ctx, c, err)
+}
+
+// runSweep sweeps a DFT codebook across the receive array and waits for
+// the sweep to select a beam.
+func runSweep(ctx context.Context, c *client.Client, rng *rand.Rand, id string) (bool, error) {
+	sweep, err := c.StartBeamSweep(ctx, &client.BeamSweepRequest{
+		ExperimentID: id,
+		Target:       client.BeamTargetArray,
+		Size:         []int{8, 16, 32}[rng.Intn(3)],
+		Dwell:        0.01,
+	})
+	if err != nil {
+		return false, err
+	}
+	for sweep.Status == client.BeamSweepRunning {
+		if !sleep(ctx, 50*time.Millisecond) {
+			return false, ctx.Err()
+		}
+		if sweep, err = c.GetBeamSweep(ctx, sweep.ID); err != nil {
+			return false, err
+		}
+	}
+	if sweep.Status == client.BeamSweepFailed {
+		return false, fmt.Errorf("beam sweep %s failed: %s", sweep.ID, sweep.Error)
+	}
+	return false, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+
+	"isac-cran-system/internal/config"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/internal/repository/influxdb"
+	"isac-cran-system/internal/repository/mysql"
+	"isac-cran-system/internal/service"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// mysqlRoutes are the API routes that write experiments, reservations,
+// artifacts, codebooks and alert rules to MySQL. The read-only policy of
+// MySQL rejects writes to them alone; IRS, USRP and sensor control do not
+// need the database.
+var mysqlRoutes = []string{
+	"/algorithm",
+	"/reservations",
+	"/artifacts",
+	"/datasets",
+	"/irs/codebooks",
+	"/alerts/rules",
+}
+
+// setupDegradation registers MySQL and InfluxDB with their degradation
+// policies. The InfluxDB dependency is returned for the stores that write
+// through it.
+func setupDegradation(cfg *config.DegradationConfig, mysqlCfg *config.MySQLConfig, db *mysql.DB, dbErr error, influxClient *influxdb.Client, influxErr error) (*service.DegradationService, *service.Dependency, error) {
+	svc := service.NewDegradationService(cfg.CheckInterval)
+
+	mysqlDep := service.DependencyConfig{
+		Name:   "mysql",
+		Policy: cfg.MySQL.Policy,
+		Code:   errors.CodeDBConnectError,
+		Probe:  mysqlProbe(mysqlCfg, db),
+		Err:    dbErr,
+		Routes: mysqlRoutes,
+	}
+	if mysqlDep.Policy == "" {
+		mysqlDep.Policy = model.DegradationReadOnly
+	}
+	if _, err := svc.Register(mysqlDep); err != nil {
+		return nil, nil, err
+	}
+
+	influxDep := service.DependencyConfig{
+		Name:       "influxdb",
+		Policy:     cfg.InfluxDB.Policy,
+		Code:       errors.CodeInfluxConnectError,
+		BufferSize: cfg.InfluxDB.BufferSize,
+		Bufferable: true,
+		Err:        influxErr,
+	}
+	if influxDep.Policy == "" {
+		influxDep.Policy = model.DegradationBufferAndRetry
+	}
+	if influxClient != nil {
+		influxDep.Probe = influxClient.Ping
+	}
+	dep, err := svc.Register(influxDep)
+	if err != nil {
+		return nil, nil, err
+	}
+	return svc, dep, nil
+}
+
+// mysqlProbe pings db. Without a connection, as when MySQL was down at
+// startup, it keeps trying to connect until it succeeds and pings that
+// connection from then on. The stores are built at startup, so the ones
+// built without MySQL only use it after a restart.
+func mysqlProbe(cfg *config.MySQLConfig, db *mysql.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if db != nil {
+			return db.Ping(ctx)
+		}
+		conn, err := mysql.NewDB(cfg)
+		if err != nil {
+			return err
+		}
+		db = conn
+		if err := db.AutoMigrate(); err != nil {
+			logger.Warn("Auto migrate failed", zap.Error(err))
+		}
+		logger.Warn("MySQL connected after startup, restart the server to store experiments, reservations and artifacts in it")
+		return nil
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"isac-cran-system/internal/algorithm/array"
+	"isac-cran-system/internal/config"
+	"isac-cran-system/internal/device/irs"
+	"isac-cran-system/internal/device/sensor"
+	"isac-cran-system/internal/device/usrp"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/internal/service"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// setupIRS creates a controller for every enabled IRS panel. Panels whose
+// driver cannot be created are registered as unavailable and left out.
+func setupIRS(cfg *config.DeviceConfig, devices *service.DeviceService) *irs.Manager {
+	panels := irs.NewManager()
+	for _, panel := range cfg.IRSDevices() {
+		controller := setupIRSPanel(&panel, devices)
+		if controller == nil {
+			continue
+		}
+		if err := panels.Add(panel.ID, controller); err != nil {
+			logger.Error("Failed to add IRS panel", zap.String("irs_id", panel.ID), zap.Error(err))
+		}
+	}
+	return panels
+}
+
+func setupIRSPanel(cfg *config.IRSDeviceConfig, devices *service.DeviceService) *irs.Controller {
+	info := model.DeviceInfo{Name: "irs", ID: cfg.ID, Enabled: cfg.Enabled, Simulator: cfg.Simulator}
+	if !cfg.Enabled {
+		logger.Info("IRS device disabled", zap.String("irs_id", cfg.ID))
+		devices.Register(info, nil)
+		return nil
+	}
+
+	driverType := irs.DriverTypeHardware
+	if cfg.Simulator {
+		driverType = irs.DriverTypeSimulator
+	}
+	if cfg.Driver != "" {
+		driverType = irs.DriverType(cfg.Driver)
+	}
+	info.DriverType = string(driverType)
+	info.Simulator = driverType == irs.DriverTypeSimulator
+
+	options := []irs.DriverOption{
+		irs.WithElementCount(cfg.ElementCount),
+		irs.WithFrequencyBand(cfg.FrequencyBand),
+		irs.WithSerialPort(cfg.Serial.Port),
+		irs.WithBaudRate(cfg.Serial.BaudRate),
+		irs.WithSerialFraming(cfg.Serial.DataBits, cfg.Serial.Parity, cfg.Serial.StopBits),
+		irs.WithImpairments(cfg.Impairments),
+	}
+	if driverType == irs.DriverTypeNetwork {
+		options = append(options,
+			irs.WithNetwork(cfg.Network.Protocol, cfg.Network.Address, cfg.Network.Encoding),
+			irs.WithTimeout(cfg.Network.Timeout, cfg.Network.Retries),
+			irs.WithPolling(cfg.Network.HeartbeatInterval, cfg.Network.StatusInterval),
+		)
+	} else {
+		options = append(options, irs.WithTimeout(cfg.Serial.Timeout, cfg.Serial.Retries))
+	}
+	driver, err := irs.NewDriverFactory().Create(driverType, options...)
+	if err != nil {
+		logger.Error("Failed to create IRS driver", zap.String("irs_id", cfg.ID), zap.String("driver", info.DriverType), zap.Error(err))
+		info.Error = err.Error()
+		devices.Register(info, nil)
+		return nil
+	}
+
+	controller := irs.NewController(driver)
+	controller.SetConstraints(model.IRSConstraints{
+		PhaseBits:       cfg.QuantizationBits,
+		SwitchingTime:   cfg.SwitchingTime.Seconds(),
+		MaxReconfigRate: cfg.MaxReconfigRate,
+	})
+	devices.Register(info, controller)
+	return controller
+}
+
+// usrpOptions are the driver settings kept when the receiver is rebound to
+// another device; device args and channel count come from the device.
+func usrpOptions(cfg *config.USRPDeviceConfig) []usrp.DriverOption {
+	return []usrp.DriverOption{
+		usrp.WithSampleRate(cfg.SampleRate),
+		usrp.WithCenterFreq(cfg.CenterFreq),
+		usrp.WithGain(cfg.Gain),
+		usrp.WithTxGain(cfg.TxGain),
+		usrp.WithImpairments(cfg.Impairments),
+		usrp.WithADC(cfg.ADC),
+		usrp.WithClockSource(cfg.ClockSource),
+	}
+}
+
+// propagationOptions couple the simulated USRP to the panel named by the
+// propagation section. Without a simulated panel to follow, the simulator
+// keeps its fixed signals.
+func propagationOptions(cfg *config.DeviceConfig, panels *irs.Manager) []usrp.DriverOption {
+	propagation := cfg.Propagation
+	if !propagation.Enabled {
+		return nil
+	}
+	if err := propagation.Validate(); err != nil {
+		logger.Error("Invalid simulated propagation, using fixed signals", zap.Error(err))
+		return nil
+	}
+	controller, err := panels.Get(propagation.IRSID)
+	if err != nil {
+		logger.Warn("Simulated propagation panel not available, using fixed signals", zap.String("irs_id", propagation.IRSID), zap.Error(err))
+		return nil
+	}
+	reflector, ok := controller.Reflector()
+	if !ok {
+		logger.Warn("Simulated propagation needs a simulated IRS panel, using fixed signals", zap.String("irs_id", propagation.IRSID))
+		return nil
+	}
+	logger.Info("Simulated propagation through IRS panel enabled", zap.String("irs_id", propagation.IRSID))
+	return []usrp.DriverOption{usrp.WithPropagation(propagation, reflector)}
+}
+
+// usrpAGCDefaults measures AGC levels against the ADC full scale unless the
+// agc section sets its own.
+func usrpAGCDefaults(cfg *config.USRPDeviceConfig) model.AGCConfig {
+	agc := cfg.AGC.AGCConfig
+	if agc.FullScale <= 0 && cfg.ADC.Bits > 0 {
+		agc.FullScale = cfg.ADC.FullScale
+	}
+	return agc
+}
+
+// setupUSRP opens the receiver with the driver options from usrpOptions
+// and propagationOptions.
+func setupUSRP(cfg *config.USRPDeviceConfig, driverOptions []usrp.DriverOption, devices *service.DeviceService) (*usrp.Receiver, *usrp.Transmitter) {
+	info := model.DeviceInfo{Name: "usrp", Enabled: cfg.Enabled, Simulator: cfg.Simulator}
+	if !cfg.Enabled {
+		logger.Info("USRP device disabled")
+		devices.Register(info, nil)
+		return nil, nil
+	}
+
+	driverType := usrp.DriverTypeHardware
+	if cfg.Simulator {
+		driverType = usrp.DriverTypeSimulator
+	}
+	if cfg.Driver != "" {
+		driverType = usrp.DriverType(cfg.Driver)
+	}
+	info.DriverType = string(driverType)
+	info.Simulator = driverType == usrp.DriverTypeSimulator
+
+	options := append(append([]usrp.DriverOption{}, driverOptions...),
+		usrp.WithDeviceArgs(cfg.DeviceArgs),
+		usrp.WithChannels(cfg.Channels),
+		usrp.WithZMQ(cfg.ZMQ.Address, cfg.ZMQ.SocketType),
+	)
+	driver, err := usrp.NewDriverFactory().Create(driverType, options...)
+	if err != nil {
+		logger.Error("Failed to create USRP driver", zap.String("driver", info.DriverType), zap.Error(err))
+		info.Error = err.Error()
+		devices.Register(info, nil)
+		return nil, nil
+	}
+
+	receiver := usrp.NewReceiver(driver, cfg.SampleRate, cfg.CenterFreq)
+	devices.Register(info, receiver)
+	return receiver, usrp.NewTransmitter(driver)
+}
+
+func setupSensor(cfg *config.SensorDeviceConfig, mqtt *config.MQTTConfig, devices *service.DeviceService) *sensor.Collector {
+	info := model.DeviceInfo{Name: "sensor", Enabled: cfg.Enabled, Simulator: cfg.Simulator}
+	if !cfg.Enabled {
+		logger.Info("Sensor device disabled")
+		devices.Register(info, nil)
+		return nil
+	}
+
+	driverType := sensor.DriverTypeMQTT
+	if cfg.Simulator {
+		driverType = sensor.DriverTypeSimulator
+	}
+	info.DriverType = string(driverType)
+
+	driver, err := sensor.NewDriverFactory().Create(
+		driverType,
+		sensor.WithBrokerURL(mqtt.Broker),
+		sensor.WithClientID(mqtt.ClientID),
+		sensor.WithScenarioFile(cfg.Scenario),
+	)
+	if err != nil {
+		logger.Error("Failed to create sensor driver", zap.String("driver", info.DriverType), zap.Error(err))
+		info.Error = err.Error()
+		devices.Register(info, nil)
+		return nil
+	}
+
+	collector := sensor.NewCollector(driver, cfg.CollectionInterval)
+	collector.SetOfflineAfter(cfg.OfflineAfter)
+	devices.Register(info, collector)
+	return collector
+}
+
+// buildArray returns nil when the spec is invalid so the algorithms fall back
+// to a half-wavelength ULA.
+func buildArray(name string, spec array.Spec, elementCount int) *array.Geometry {
+	g, err := spec.Build(elementCount)
+	if err != nil {
+		logger.Error("Invalid array geometry, using half-wavelength ULA", zap.String("device", name), zap.Error(err))
+		return nil
+	}
+	return g
+}
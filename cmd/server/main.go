@@ -4,42 +4,58 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	grpcapi "isac-cran-system/api/grpc"
 	"isac-cran-system/internal/algorithm/beamforming"
 	"isac-cran-system/internal/algorithm/doa"
 	"isac-cran-system/internal/config"
 	"isac-cran-system/internal/device/irs"
+	"isac-cran-system/internal/device/power"
 	"isac-cran-system/internal/device/sensor"
 	"isac-cran-system/internal/device/usrp"
 	"isac-cran-system/internal/handler"
 	"isac-cran-system/internal/middleware"
+	"isac-cran-system/internal/model"
 	"isac-cran-system/internal/repository/influxdb"
 	"isac-cran-system/internal/repository/mysql"
+	"isac-cran-system/internal/repository/objectstore"
 	"isac-cran-system/internal/router"
 	"isac-cran-system/internal/service"
+	"isac-cran-system/internal/ui"
+	"isac-cran-system/pkg/discovery"
+	"isac-cran-system/pkg/hub"
 	"isac-cran-system/pkg/logger"
+	"isac-cran-system/pkg/mq"
 	"isac-cran-system/pkg/pool"
 	"isac-cran-system/pkg/queue"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 )
 
-var configFile string
+var (
+	configFile string
+	profile    string
+	roleFlag   string
+)
 
 func init() {
 	flag.StringVar(&configFile, "config", "configs/config.yaml", "config file path")
+	flag.StringVar(&profile, "profile", "", "config profile overlay (dev, staging, prod); defaults to $"+config.ProfileEnv)
+	flag.StringVar(&roleFlag, "role", "", "what to run: all (monolith), algorithm, device, sensor or gateway; defaults to server.role")
 }
 
 func main() {
 	flag.Parse()
 
-	if err := config.Init(configFile); err != nil {
+	if err := config.Init(configFile, profile); err != nil {
 		fmt.Printf("Failed to load config: %v\n", err)
 		os.Exit(1)
 	}
@@ -63,19 +79,28 @@ func main() {
 	}
 	defer logger.Sync()
 
+	role, err := resolveRole(roleFlag, &cfg.Server)
+	if err != nil {
+		logger.Fatal("Invalid server role", zap.Error(err))
+	}
 	logger.Info("Starting ISAC-CRAN System",
 		zap.String("config", configFile),
+		zap.String("profile", cfg.Profile),
+		zap.String("role", role),
 	)
 
-	gin.SetMode(cfg.Server.Mode)
+	if role == roleGateway {
+		if err := runGateway(cfg); err != nil {
+			logger.Fatal("Gateway failed", zap.Error(err))
+		}
+		return
+	}
 
-	var db *mysql.DB
-	var influxClient *influxdb.Client
-	var err error
+	gin.SetMode(cfg.Server.Mode)
 
-	db, err = mysql.NewDB(&cfg.MySQL)
-	if err != nil {
-		logger.Warn("MySQL connection failed, running without database", zap.Error(err))
+	db, dbErr := mysql.NewDB(&cfg.MySQL)
+	if dbErr != nil {
+		logger.Warn("MySQL connection failed, running without database", zap.Error(dbErr))
 	} else {
 		defer db.Close()
 		if err := db.AutoMigrate(); err != nil {
@@ -84,74 +109,261 @@ func main() {
 		logger.Info("MySQL connected successfully")
 	}
 
-	influxClient, err = influxdb.NewClient(&cfg.InfluxDB)
-	if err != nil {
-		logger.Warn("InfluxDB connection failed, running without time-series database", zap.Error(err))
+	influxClient, influxErr := influxdb.NewClient(&cfg.InfluxDB)
+	if influxErr != nil {
+		logger.Warn("InfluxDB connection failed, running without time-series database", zap.Error(influxErr))
 	} else {
 		defer influxClient.Close()
 		logger.Info("InfluxDB connected successfully")
 	}
 
-	irsDriverFactory := irs.NewDriverFactory()
-	irsDriver, err := irsDriverFactory.Create(
-		irs.DriverTypeSimulator,
-		irs.WithElementCount(cfg.Device.IRS.ElementCount),
-		irs.WithFrequencyBand(cfg.Device.IRS.FrequencyBand),
-	)
+	elector, err := newElector(&cfg.Server, role)
 	if err != nil {
-		logger.Fatal("Failed to create IRS driver", zap.Error(err))
+		logger.Fatal("Failed to set up leader election", zap.Error(err))
 	}
-	irsController := irs.NewController(irsDriver)
 
-	usrpDriverFactory := usrp.NewDriverFactory()
-	usrpDriver, err := usrpDriverFactory.Create(
-		usrp.DriverTypeSimulator,
-		usrp.WithSampleRate(cfg.Device.USRP.SampleRate),
-		usrp.WithCenterFreq(cfg.Device.USRP.CenterFreq),
-	)
-	if err != nil {
-		logger.Fatal("Failed to create USRP driver", zap.Error(err))
+	deviceSvc := service.NewDeviceService()
+	irsPanels := irs.NewManager()
+	var usrpReceiver *usrp.Receiver
+	var usrpTransmitter *usrp.Transmitter
+	usrpDriverOptions := usrpOptions(&cfg.Device.USRP)
+	if opensDevices(role) {
+		irsPanels = setupIRS(&cfg.Device, deviceSvc)
+		usrpDriverOptions = append(usrpDriverOptions, propagationOptions(&cfg.Device, irsPanels)...)
+		usrpReceiver, usrpTransmitter = setupUSRP(&cfg.Device.USRP, usrpDriverOptions, deviceSvc)
 	}
-	usrpReceiver := usrp.NewReceiver(usrpDriver, cfg.Device.USRP.SampleRate, cfg.Device.USRP.CenterFreq)
-
-	sensorDriverFactory := sensor.NewDriverFactory()
-	sensorDriver, err := sensorDriverFactory.Create(sensor.DriverTypeSimulator)
-	if err != nil {
-		logger.Fatal("Failed to create sensor driver", zap.Error(err))
+	var sensorCollector *sensor.Collector
+	if opensSensors(role) {
+		sensorCollector = setupSensor(&cfg.Device.Sensor, &cfg.MQTT, deviceSvc)
 	}
-	sensorCollector := sensor.NewCollector(sensorDriver, cfg.Device.Sensor.CollectionInterval)
 
 	ctx := context.Background()
 
-	if err := irsController.Connect(ctx); err != nil {
-		logger.Warn("Failed to connect IRS controller", zap.Error(err))
+	if db != nil {
+		replicaCtx, stopReplicas := context.WithCancel(ctx)
+		defer stopReplicas()
+		go db.MonitorReplicas(replicaCtx)
 	}
 
-	if err := usrpReceiver.Connect(ctx); err != nil {
-		logger.Warn("Failed to connect USRP receiver", zap.Error(err))
+	degradationSvc, influxDep, err := setupDegradation(&cfg.Degradation, &cfg.MySQL, db, dbErr, influxClient, influxErr)
+	if err != nil {
+		logger.Fatal("Invalid degradation policy", zap.Error(err))
+	}
+	degradationCtx, stopDegradation := context.WithCancel(ctx)
+	defer stopDegradation()
+	go degradationSvc.Run(degradationCtx)
+
+	for _, id := range irsPanels.IDs() {
+		controller, _ := irsPanels.Get(id)
+		if err := controller.Connect(ctx); err != nil {
+			logger.Warn("Failed to connect IRS controller", zap.String("irs_id", id), zap.Error(err))
+		}
 	}
 
-	if err := sensorCollector.Connect(ctx); err != nil {
-		logger.Warn("Failed to connect sensor collector", zap.Error(err))
+	var channelReceiver service.ChannelReceiver
+	if usrpReceiver != nil {
+		if err := usrpReceiver.Connect(ctx); err != nil {
+			logger.Warn("Failed to connect USRP receiver", zap.Error(err))
+		}
+		channelReceiver = usrpReceiver
+	}
+
+	if sensorCollector != nil {
+		if err := sensorCollector.Connect(ctx); err != nil {
+			logger.Warn("Failed to connect sensor collector", zap.Error(err))
+		}
 	}
 
-	var channelDataRepo *influxdb.ChannelDataRepository
-	var sensorDataRepo *influxdb.SensorDataRepository
+	var channelDataRepo service.ChannelDataStore
+	var annotationRepo service.ChannelAnnotationStore
+	var sensorDataRepo service.SensorDataStore
 	var experimentRepo *mysql.ExperimentRepository
+	var artifactRepo service.ArtifactStore
+	var reservationRepo service.ReservationStore
+	var auditRepo service.AuditStore
+	var sensorInfoRepo service.SensorInfoStore
+	var irsConfigRepo service.IRSConfigStore
+	var codebookRepo service.CodebookStore
+	var alertRepo service.AlertStore
 
 	if influxClient != nil {
 		channelDataRepo = influxdb.NewChannelDataRepository(influxClient)
+		annotationRepo = influxdb.NewChannelAnnotationRepository(influxClient)
 		sensorDataRepo = influxdb.NewSensorDataRepository(influxClient)
+		channelDataRepo = service.NewDegradedChannelStore(influxDep, channelDataRepo)
+		sensorDataRepo = service.NewDegradedSensorStore(influxDep, sensorDataRepo)
 	}
 
 	if db != nil {
 		experimentRepo = mysql.NewExperimentRepository(db)
+		artifactRepo = mysql.NewArtifactRepository(db)
+		reservationRepo = mysql.NewReservationRepository(db)
+		auditRepo = mysql.NewAuditRepository(db)
+		sensorInfoRepo = mysql.NewSensorInfoRepository(db)
+		irsConfigRepo = mysql.NewIRSConfigRepository(db)
+		codebookRepo = mysql.NewIRSCodebookRepository(db)
+		alertRepo = mysql.NewAlertRepository(db)
 	}
 
-	irsSvc := service.NewIRSService(irsController)
-	channelSvc := service.NewChannelService(usrpReceiver, channelDataRepo)
+	reservationSvc := service.NewReservationService(reservationRepo)
+	reservationSvc.SetAuditStore(auditRepo)
+	irsSvc := service.NewIRSService(irsPanels)
+	irsSvc.SetDeviceGate(reservationSvc)
+	irsSvc.SetConfigStore(irsConfigRepo)
+	irsSvc.SetCodebookStore(codebookRepo)
+	for _, panel := range cfg.Device.IRSDevices() {
+		irsSvc.SetArrayGeometry(panel.ID, buildArray("irs", panel.Array, panel.ElementCount))
+	}
+	var alertPublisher service.AlertPublisher
+	if cfg.RabbitMQ.URL != "" {
+		notifications, err := mq.NewMessageQueue(cfg.RabbitMQ.URL)
+		if err == nil {
+			if err = notifications.SetupQueues(); err != nil {
+				notifications.Close()
+			}
+		}
+		if err != nil {
+			logger.Warn("Failed to connect RabbitMQ, alerts will only be logged", zap.Error(err))
+		} else {
+			defer notifications.Close()
+			irsSvc.SetAlertPublisher(notifications)
+			alertPublisher = notifications
+		}
+	}
+	if opensDevices(role) {
+		elector.Register("irs_watchdog", func(watchdogCtx context.Context) {
+			for _, panel := range cfg.Device.IRSDevices() {
+				if !panel.Enabled || !panel.Watchdog.Enabled {
+					continue
+				}
+				err := irsSvc.StartWatchdog(watchdogCtx, panel.ID, irs.WatchdogConfig{
+					Interval:       panel.Watchdog.Interval,
+					StaleAfter:     panel.Watchdog.StaleAfter,
+					MaxTemperature: panel.Watchdog.MaxTemperature,
+				})
+				if err != nil {
+					logger.Warn("Failed to start IRS watchdog", zap.String("irs_id", panel.ID), zap.Error(err))
+				}
+			}
+			<-watchdogCtx.Done()
+		})
+	}
+	irsArray := buildArray("irs", cfg.Device.IRS.Array, cfg.Device.IRS.ElementCount)
+	rxArray := buildArray("usrp", cfg.Device.USRP.Array, cfg.Device.USRP.Channels)
+	channelSvc := service.NewChannelService(channelReceiver, channelDataRepo)
+	channelSvc.SetDeviceGate(reservationSvc)
+	channelSvc.SetAnnotationStore(annotationRepo)
+	if usrpTransmitter != nil {
+		channelSvc.SetTransmitter(usrpTransmitter)
+	}
 	algorithmSvc := service.NewAlgorithmService(experimentRepo)
+	algorithmSvc.SetDeviceGate(reservationSvc)
+	algorithmSvc.SetAuditStore(auditRepo)
+	if db != nil {
+		reservationSvc.SetUnitOfWork(db)
+		algorithmSvc.SetUnitOfWork(db)
+	}
+	algorithmSvc.SetArrayGeometry(irsArray, rxArray)
+	algorithmSvc.SetCovariance(cfg.Algorithm.DOA.Covariance)
+	algorithmSvc.SetSmoothing(cfg.Algorithm.DOA.Smoothing)
+	algorithmSvc.SetIRSPanels(irsPanels)
+	algorithmSvc.SetDeviceService(remoteDevices(role))
+	if cfg.Algorithm.Cache.Enabled {
+		algorithmSvc.SetResultCache(service.ResultCacheConfig{
+			Size: cfg.Algorithm.Cache.Size,
+			TTL:  cfg.Algorithm.Cache.TTL,
+		})
+	}
+	if usrpReceiver != nil {
+		algorithmSvc.SetSnapshotSource(usrpReceiver)
+		algorithmSvc.SetStreamSource(usrpReceiver)
+	}
+	var recordingSource service.SnapshotSource
+	if usrpReceiver != nil {
+		recordingSource = usrpReceiver
+	}
+	recordingSvc := service.NewRecordingService(recordingSource, cfg.Recording.Dir, cfg.Recording.MaxDuration)
+	recordingSvc.SetDeviceGate(reservationSvc)
+	algorithmSvc.SetRecordings(recordingSvc)
+
+	usrpSvc := service.NewUSRPService(usrpReceiver, usrpTransmitter, usrpDriverOptions...)
+	usrpSvc.SetDeviceService(deviceSvc)
+	usrpSvc.SetDeviceGate(reservationSvc)
+	if !cfg.Device.USRP.Simulator {
+		usrpSvc.SetBound(usrp.ParseDeviceAddr(cfg.Device.USRP.DeviceArgs)["serial"])
+	}
+	usrpSvc.SetCalibrationFile(cfg.Device.USRP.CalibrationFile)
+	if err := usrpSvc.LoadCalibration(); err != nil {
+		logger.Warn("Failed to load USRP calibration", zap.Error(err))
+	}
+	usrpSvc.SetAGCDefaults(usrpAGCDefaults(&cfg.Device.USRP))
+	if usrpReceiver != nil && cfg.Device.USRP.AGC.Enabled {
+		if _, err := usrpSvc.SetAGC(ctx, &model.USRPAGCRequest{Enabled: true}); err != nil {
+			logger.Warn("Failed to start USRP AGC", zap.Error(err))
+		}
+	}
 	sensorSvc := service.NewSensorService(sensorCollector, sensorDataRepo)
+	sensorSvc.SetInfoStore(sensorInfoRepo)
+	if n, err := sensorSvc.LoadSensors(ctx); err != nil {
+		logger.Warn("Failed to load stored sensors", zap.Error(err))
+	} else if n > 0 {
+		logger.Info("Stored sensors registered", zap.Int("count", n))
+	}
+	if err := sensorSvc.SyncSensors(ctx); err != nil {
+		logger.Warn("Failed to store sensor metadata", zap.Error(err))
+	}
+	alertSvc := service.NewAlertService(alertRepo)
+	alertSvc.SetAlertPublisher(alertPublisher)
+	if err := alertSvc.Load(ctx); err != nil {
+		logger.Warn("Failed to load sensor alert rules", zap.Error(err))
+	}
+	sensorSvc.SetObserver(alertSvc)
+
+	powerModel := power.NewModel(&power.Config{
+		IRSStaticPerElement: cfg.Device.Power.IRSStaticPerElement,
+		IRSSwitchEnergy:     cfg.Device.Power.IRSSwitchEnergy,
+		ControllerPower:     cfg.Device.Power.ControllerPower,
+		USRPPower:           cfg.Device.Power.USRPPower,
+		Tolerance:           cfg.Device.Power.Tolerance,
+	})
+	algorithmSvc.SetPowerModel(powerModel)
+	powerSvc := service.NewPowerService(powerModel, irsPanels.Default(), sensorCollector)
+	algorithmSvc.SetPowerMeter(powerSvc)
+	algorithmSvc.SetTransmissionInterval(cfg.Device.Power.TransmissionInterval)
+
+	var objectStore *objectstore.TieredStore
+	localStore, err := objectstore.NewLocalStore(cfg.ObjectStore.LocalDir, cfg.ObjectStore.PublicURL, cfg.ObjectStore.SigningKey)
+	if err != nil {
+		logger.Warn("Object store unavailable, exports disabled", zap.Error(err))
+	} else {
+		var coldStore objectstore.Store
+		if cfg.ObjectStore.S3.Enabled {
+			coldStore = objectstore.NewS3Store(&objectstore.S3Config{
+				Endpoint:  cfg.ObjectStore.S3.Endpoint,
+				Region:    cfg.ObjectStore.S3.Region,
+				Bucket:    cfg.ObjectStore.S3.Bucket,
+				AccessKey: cfg.ObjectStore.S3.AccessKey,
+				SecretKey: cfg.ObjectStore.S3.SecretKey,
+				UseSSL:    cfg.ObjectStore.S3.UseSSL,
+			})
+		}
+		objectStore = objectstore.NewTieredStore(localStore, coldStore, cfg.ObjectStore.TierAfter)
+		if coldStore != nil && cfg.ObjectStore.TierInterval > 0 {
+			elector.Register("object_tiering", func(tieringCtx context.Context) {
+				objectStore.StartTiering(tieringCtx, cfg.ObjectStore.TierInterval)
+			})
+		}
+	}
+	artifactSvc := service.NewArtifactService(objectStore, artifactRepo, cfg.ObjectStore.GCGrace)
+	if objectStore != nil && artifactRepo != nil && cfg.ObjectStore.GCInterval > 0 {
+		elector.Register("artifact_gc", func(gcCtx context.Context) {
+			artifactSvc.StartGarbageCollection(gcCtx, cfg.ObjectStore.GCInterval)
+		})
+	}
+	exportSvc := service.NewExportService(objectStore, artifactSvc, experimentRepo, cfg.ObjectStore.PresignExpiry)
+	exportSvc.SetDevices(deviceSvc)
+	exportSvc.SetSensors(sensorSvc)
+	algorithmSvc.SetSnapshotArchive(artifactSvc, cfg.Algorithm.DOA.StoreSnapshots, cfg.Algorithm.DOA.MaxSnapshotBytes)
 
 	beamformingOptimizer := beamforming.NewOptimizer(
 		cfg.Algorithm.Beamforming.MaxIterations,
@@ -168,13 +380,57 @@ func main() {
 	)
 	_ = doaEstimator
 
+	taskQueue := queue.NewTaskQueue(5, 100)
+	taskQueue.Start()
+	defer taskQueue.Stop()
+	pipelineSvc := service.NewPipelineService(taskQueue, channelSvc, algorithmSvc, irsSvc)
+	captureSessionSvc := service.NewCaptureSessionService(channelSvc, sensorSvc, irsSvc, cfg.Recording.MaxDuration)
+	captureSessionSvc.SetDeviceGate(reservationSvc)
+	beamSvc := service.NewBeamService(channelSvc, irsSvc, cfg.Recording.MaxDuration)
+	beamSvc.SetDeviceGate(reservationSvc)
+	if usrpReceiver != nil {
+		beamSvc.SetSnapshotSource(usrpReceiver)
+	}
+
+	streamHub := hub.NewHub(cfg.Server.Stream.BufferSize)
+	defer streamHub.Close()
+	channelSvc.SetEventPublisher(streamHub)
+	sensorSvc.SetEventPublisher(streamHub)
+	algorithmSvc.SetEventPublisher(streamHub)
+	pipelineSvc.SetEventPublisher(streamHub)
+	beamSvc.SetEventPublisher(streamHub)
+
 	irsHandler := handler.NewIRSHandler(irsSvc)
 	channelHandler := handler.NewChannelHandler(channelSvc)
+	channelHandler.SetBeamService(beamSvc)
 	algorithmHandler := handler.NewAlgorithmHandler(algorithmSvc)
+	algorithmHandler.SetPipelineService(pipelineSvc)
 	sensorHandler := handler.NewSensorHandler(sensorSvc)
+	powerHandler := handler.NewPowerHandler(powerSvc)
+	exportHandler := handler.NewExportHandler(exportSvc)
+	exportHandler.SetDatasetService(service.NewDatasetService(channelSvc, sensorDataRepo, artifactSvc))
+	artifactHandler := handler.NewArtifactHandler(artifactSvc)
+	deviceHandler := handler.NewDeviceHandler(deviceSvc)
+	reservationHandler := handler.NewReservationHandler(reservationSvc)
+	recordingHandler := handler.NewRecordingHandler(recordingSvc)
+	recordingHandler.SetCaptureSessions(captureSessionSvc)
+	usrpHandler := handler.NewUSRPHandler(usrpSvc)
 	systemHandler := handler.NewSystemHandler()
+	graphqlHandler := handler.NewGraphQLHandler(algorithmSvc, artifactSvc, sensorSvc, deviceSvc, irsSvc)
+	alertHandler := handler.NewAlertHandler(alertSvc)
+	systemHandler.SetStreamHub(streamHub)
+	systemHandler.SetLeaderReporter(elector)
+	if db != nil {
+		systemHandler.SetReplicaReporter(db)
+	}
+	systemHandler.SetDegradationReporter(degradationSvc)
 
-	engine := router.Setup(irsHandler, channelHandler, algorithmHandler, sensorHandler, systemHandler)
+	middleware.SetAPITokens(cfg.Server.Auth.TokenMap())
+	middleware.SetDegradationGuard(degradationSvc)
+	engine := router.Setup(irsHandler, channelHandler, algorithmHandler, sensorHandler, powerHandler, exportHandler, artifactHandler, deviceHandler, reservationHandler, recordingHandler, usrpHandler, systemHandler, graphqlHandler, alertHandler)
+	if cfg.Server.UI.Enabled {
+		ui.Register(engine, "/ui")
+	}
 
 	engine.Use(func(c *gin.Context) {
 		if len(c.Request.URL.Path) >= 7 && c.Request.URL.Path[:7] == "/debug/" {
@@ -189,13 +445,49 @@ func main() {
 	workerPool := pool.NewWorkerPool(10, 100)
 	workerPool.Start()
 	defer workerPool.Stop()
-
-	taskQueue := queue.NewTaskQueue(5, 100)
-	taskQueue.Start()
-	defer taskQueue.Stop()
+	algorithmSvc.SetWorkerPool(workerPool)
+	algorithmSvc.SetAdmission(service.AdmissionConfig{
+		MaxConcurrent:  cfg.Algorithm.Admission.MaxConcurrent,
+		MaxQueued:      cfg.Algorithm.Admission.MaxQueued,
+		MaxDuration:    cfg.Algorithm.Admission.MaxDuration,
+		HeavyThreshold: cfg.Algorithm.Admission.HeavyThreshold,
+	})
+	if db != nil {
+		if n, err := algorithmSvc.LoadTimings(ctx); err != nil {
+			logger.Warn("Failed to load experiment timings", zap.Error(err))
+		} else {
+			logger.Info("Runtime estimates calibrated", zap.Int("experiments", n))
+		}
+		if n, err := algorithmSvc.ResumeQueued(ctx); err != nil {
+			logger.Warn("Failed to resume queued experiments", zap.Error(err))
+		} else if n > 0 {
+			logger.Info("Queued experiments resumed", zap.Int("experiments", n))
+		}
+	}
+	middleware.RegisterMetricsSource("worker_pool", func() interface{} {
+		return workerPool.Stats()
+	})
+	middleware.RegisterMetricsSource("experiment_queue", func() interface{} {
+		return algorithmSvc.QueuedExperiments()
+	})
+	middleware.RegisterMetricsSource("stream_hub", func() interface{} {
+		return streamHub.Stats()
+	})
+	if db != nil {
+		middleware.RegisterMetricsSource("mysql_pool", func() interface{} {
+			return db.PoolStats()
+		})
+	}
 
 	logger.Info("Worker pool and task queue started")
 
+	electionCtx, stopElection := context.WithCancel(ctx)
+	electionDone := make(chan struct{})
+	go func() {
+		defer close(electionDone)
+		elector.Run(electionCtx)
+	}()
+
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
 		Handler:      engine,
@@ -204,6 +496,18 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// A service of a split deployment serves gRPC on its HTTP port, where it
+	// is registered, whether or not the separate gRPC port is enabled.
+	split := serviceName(role) != ""
+	var grpcServer *grpc.Server
+	if split {
+		engine.GET("/health", systemHandler.Health)
+		grpcServer = grpcapi.NewServer(algorithmSvc, irsSvc, sensorSvc, artifactSvc)
+		srv.Handler = serviceHandler(engine, grpcServer)
+		// gRPC streams outlive any write timeout
+		srv.WriteTimeout = 0
+	}
+
 	go func() {
 		logger.Info("Server starting",
 			zap.Int("port", cfg.Server.Port),
@@ -215,11 +519,42 @@ func main() {
 		}
 	}()
 
+	if cfg.Server.GRPC.Enabled && !split {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.GRPC.Port))
+		if err != nil {
+			logger.Fatal("Failed to listen for gRPC", zap.Error(err))
+		}
+		grpcServer = grpcapi.NewServer(algorithmSvc, irsSvc, sensorSvc, artifactSvc)
+		go func() {
+			logger.Info("gRPC server starting", zap.Int("port", cfg.Server.GRPC.Port))
+			if err := grpcServer.Serve(lis); err != nil {
+				logger.Fatal("gRPC server failed", zap.Error(err))
+			}
+		}()
+	}
+
+	var registration *discovery.ServiceDiscovery
+	if split {
+		registration, err = register(&cfg.Server, role)
+		if err != nil {
+			logger.Fatal("Failed to register with service discovery", zap.Error(err))
+		}
+		logger.Info("Registered with service discovery", zap.String("service", serviceName(role)))
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	logger.Info("Shutting down server...")
+	// hand the background jobs to another replica first
+	stopElection()
+	<-electionDone
+	if registration != nil {
+		if err := registration.Deregister(); err != nil {
+			logger.Warn("Failed to deregister from service discovery", zap.Error(err))
+		}
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -227,10 +562,24 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Error("Server forced to shutdown", zap.Error(err))
 	}
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+
+	recordingSvc.StopAll()
+	captureSessionSvc.StopAll()
+	beamSvc.StopAll()
+	algorithmSvc.StopOnlineDOA()
+	usrpSvc.StopAGC()
 
-	irsController.Disconnect()
-	usrpReceiver.Disconnect()
-	sensorCollector.Disconnect()
+	irsSvc.StopSequences()
+	irsPanels.DisconnectAll()
+	if usrpReceiver != nil {
+		usrpReceiver.Disconnect()
+	}
+	if sensorCollector != nil {
+		sensorCollector.Disconnect()
+	}
 
 	logger.Info("Server exited properly")
 }
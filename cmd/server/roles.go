@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"isac-cran-system/internal/config"
+	"isac-cran-system/pkg/discovery"
+	"isac-cran-system/pkg/election"
+	"isac-cran-system/pkg/gateway"
+	"isac-cran-system/pkg/logger"
+	"isac-cran-system/pkg/rpc"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+)
+
+// Roles the server runs as. The monolith runs everything in one process; a
+// split deployment runs one process per service behind the gateway, which
+// finds them through Consul, so that heavy algorithm work can run on another
+// host than the devices.
+const (
+	roleAll       = "all"
+	roleAlgorithm = "algorithm"
+	roleDevice    = "device"
+	roleSensor    = "sensor"
+	roleGateway   = "gateway"
+)
+
+// defaultRole is the role without -role or server.role; the per-service
+// build targets set it at link time.
+var defaultRole = roleAll
+
+// resolveRole picks the role from the flag, then the config, then the
+// binary's default.
+func resolveRole(flagRole string, cfg *config.ServerConfig) (string, error) {
+	role := flagRole
+	if role == "" {
+		role = cfg.Role
+	}
+	if role == "" {
+		role = defaultRole
+	}
+	switch role {
+	case roleAll, roleAlgorithm, roleDevice, roleSensor, roleGateway:
+		return role, nil
+	}
+	return "", fmt.Errorf("unknown role %q", role)
+}
+
+// serviceName is the name role registers under in Consul; the monolith does
+// not register.
+func serviceName(role string) string {
+	switch role {
+	case roleAlgorithm:
+		return rpc.AlgorithmServiceName
+	case roleDevice:
+		return rpc.DeviceServiceName
+	case roleSensor:
+		return rpc.SensorServiceName
+	}
+	return ""
+}
+
+// opensDevices reports whether role drives the IRS panels and the USRP.
+func opensDevices(role string) bool {
+	return role == roleAll || role == roleDevice
+}
+
+// remoteDevices is the service that drives the devices for role, or "" when
+// role drives them itself or runs as the monolith.
+func remoteDevices(role string) string {
+	if serviceName(role) == "" || opensDevices(role) {
+		return ""
+	}
+	return rpc.DeviceServiceName
+}
+
+// opensSensors reports whether role collects from the sensors.
+func opensSensors(role string) bool {
+	return role == roleAll || role == roleSensor
+}
+
+// serviceHandler serves gRPC and HTTP on one port, so that the single
+// address a service registers answers the gateway, the Consul health check
+// and gRPC clients alike. gRPC clients dial without TLS, which h2c accepts.
+func serviceHandler(engine http.Handler, grpcServer *grpc.Server) http.Handler {
+	return h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		engine.ServeHTTP(w, r)
+	}), &http2.Server{})
+}
+
+// register announces the service of role in Consul at the server port.
+func register(cfg *config.ServerConfig, role string) (*discovery.ServiceDiscovery, error) {
+	sd, err := discovery.NewServiceDiscovery(cfg.Discovery.ConsulAddr)
+	if err != nil {
+		return nil, err
+	}
+	address := cfg.Discovery.Address
+	if address == "" {
+		if address, err = os.Hostname(); err != nil {
+			return nil, fmt.Errorf("failed to determine the service address: %w", err)
+		}
+	}
+	name := serviceName(role)
+	err = sd.Register(&discovery.ServiceInfo{
+		ID:      fmt.Sprintf("%s-%s-%d", name, address, cfg.Port),
+		Name:    name,
+		Address: address,
+		Port:    cfg.Port,
+		Tags:    []string{role},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sd, nil
+}
+
+// runGateway proxies the API to the services of a split deployment until
+// the process is interrupted.
+func runGateway(cfg *config.Config) error {
+	sd, err := discovery.NewServiceDiscovery(cfg.Server.Discovery.ConsulAddr)
+	if err != nil {
+		return err
+	}
+	gw := gateway.NewGateway(sd, discovery.NewRoundRobinLoadBalancer())
+	for _, route := range gateway.DefaultRoutes() {
+		gw.AddRoute(route)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	logger.Info("Gateway starting",
+		zap.Int("port", cfg.Server.Port),
+		zap.String("consul", cfg.Server.Discovery.ConsulAddr),
+	)
+	if err := gw.Start(ctx, cfg.Server.Port); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// newElector campaigns among the replicas of role, each named by its host
+// and port, so that every service of a split deployment has a leader of its
+// own. Without election the process leads alone.
+func newElector(cfg *config.ServerConfig, role string) (*election.Elector, error) {
+	host := cfg.Discovery.Address
+	if host == "" {
+		var err error
+		if host, err = os.Hostname(); err != nil {
+			return nil, fmt.Errorf("failed to determine the node name: %w", err)
+		}
+	}
+	node := fmt.Sprintf("%s:%d", host, cfg.Port)
+	if !cfg.Election.Enabled {
+		return election.NewElector(nil, node), nil
+	}
+	backend, err := election.NewConsulBackend(cfg.Discovery.ConsulAddr, cfg.Election.Key+"/"+role, cfg.Election.SessionTTL)
+	if err != nil {
+		return nil, err
+	}
+	return election.NewElector(backend, node), nil
+}
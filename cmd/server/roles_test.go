@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	grpcapi "isac-cran-system/api/grpc"
+	"isac-cran-system/internal/config"
+	"isac-cran-system/internal/device/sensor"
+	"isac-cran-system/internal/service"
+	"isac-cran-system/pkg/rpc"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestServiceHandler_GRPCAndHTTPOnOnePort(t *testing.T) {
+	collector := sensor.NewCollector(sensor.NewSimulator(), time.Second)
+	collector.Connect(context.Background())
+	grpcServer := grpcapi.NewServer(nil, nil, service.NewSensorService(collector, nil), nil)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/health", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: serviceHandler(engine, grpcServer)}
+	go srv.Serve(lis)
+	defer srv.Close()
+
+	resp, err := http.Get("http://" + lis.Addr().String() + "/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("health check returned %d", resp.StatusCode)
+	}
+
+	client, err := rpc.NewSensorClient(lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	list, err := client.ListSensors(context.Background())
+	if err != nil {
+		t.Fatalf("ListSensors: %v", err)
+	}
+	if len(list.Sensors) == 0 {
+		t.Error("no sensors listed over gRPC")
+	}
+}
+
+func TestResolveRole(t *testing.T) {
+	cfg := &config.ServerConfig{}
+	if role, _ := resolveRole("", cfg); role != roleAll {
+		t.Errorf("default role %q, want %q", role, roleAll)
+	}
+	cfg.Role = roleSensor
+	if role, _ := resolveRole("", cfg); role != roleSensor {
+		t.Errorf("configured role %q, want %q", role, roleSensor)
+	}
+	if role, _ := resolveRole(roleAlgorithm, cfg); role != roleAlgorithm {
+		t.Errorf("flag role %q, want %q", role, roleAlgorithm)
+	}
+	if _, err := resolveRole("gpu", cfg); err == nil {
+		t.Error("an unknown role was accepted")
+	}
+	if serviceName(roleAll) != "" || serviceName(roleDevice) != rpc.DeviceServiceName {
+		t.Error("only the services of a split deployment register")
+	}
+	if remoteDevices(roleAll) != "" || remoteDevices(roleDevice) != "" || remoteDevices(roleAlgorithm) != rpc.DeviceServiceName {
+		t.Error("only the split services without devices leave them to the device service")
+	}
+}
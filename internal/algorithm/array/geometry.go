@@ -0,0 +1,191 @@
+package array
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// HalfWavelength is the default inter-element spacing in wavelengths.
+const HalfWavelength = 0.5
+
+// Position is an element location in wavelengths.
+type Position struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// Element is a single antenna of an array. Azimuth and Elevation give the
+// boresight direction used to evaluate the gain pattern.
+type Element struct {
+	Position  Position `json:"position"`
+	Azimuth   float64  `json:"azimuth"`
+	Elevation float64  `json:"elevation"`
+	Pattern   Pattern  `json:"-"`
+}
+
+// Geometry describes an antenna array shared by beamforming, DOA estimation
+// and the channel model. Angles follow the convention of the algorithm code:
+// azimuth is measured from broadside (+x) towards +y, elevation from the xy
+// plane, so a ULA along y reproduces the usual 2πnd·sin(θ) phase progression.
+type Geometry struct {
+	Elements []Element
+	// Coupling is the N×N mutual coupling matrix applied to every steering
+	// vector; nil means the elements are uncoupled.
+	Coupling [][]complex128
+}
+
+// NewULA returns a uniform linear array of n isotropic elements along y.
+func NewULA(n int, spacing float64) *Geometry {
+	elements := make([]Element, n)
+	for i := range elements {
+		elements[i] = Element{Position: Position{Y: float64(i) * spacing}, Pattern: Isotropic{}}
+	}
+	return &Geometry{Elements: elements}
+}
+
+// NewURA returns a rows×cols uniform rectangular array in the yz plane,
+// indexed row-major.
+func NewURA(rows, cols int, spacing float64) *Geometry {
+	elements := make([]Element, 0, rows*cols)
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			elements = append(elements, Element{
+				Position: Position{Y: float64(c) * spacing, Z: float64(r) * spacing},
+				Pattern:  Isotropic{},
+			})
+		}
+	}
+	return &Geometry{Elements: elements}
+}
+
+// NewUCA returns a uniform circular array of n elements in the xy plane,
+// each facing outwards.
+func NewUCA(n int, radius float64) *Geometry {
+	elements := make([]Element, n)
+	for i := range elements {
+		phi := 2 * math.Pi * float64(i) / float64(n)
+		elements[i] = Element{
+			Position: Position{X: radius * math.Cos(phi), Y: radius * math.Sin(phi)},
+			Azimuth:  phi,
+			Pattern:  Isotropic{},
+		}
+	}
+	return &Geometry{Elements: elements}
+}
+
+func (g *Geometry) Len() int {
+	return len(g.Elements)
+}
+
+// Subarray returns the first n elements with their mutual coupling, the
+// aperture left after spatial smoothing.
+func (g *Geometry) Subarray(n int) *Geometry {
+	sub := &Geometry{Elements: append([]Element(nil), g.Elements[:n]...)}
+	if g.Coupling != nil {
+		sub.Coupling = make([][]complex128, n)
+		for i := range sub.Coupling {
+			sub.Coupling[i] = append([]complex128(nil), g.Coupling[i][:n]...)
+		}
+	}
+	return sub
+}
+
+// Scaled returns the array as seen at factor times the frequency its
+// positions are given for: positions are in wavelengths, so they scale with
+// frequency. Patterns and coupling are kept.
+func (g *Geometry) Scaled(factor float64) *Geometry {
+	scaled := &Geometry{Elements: append([]Element(nil), g.Elements...), Coupling: g.Coupling}
+	for i := range scaled.Elements {
+		p := &scaled.Elements[i].Position
+		p.X, p.Y, p.Z = p.X*factor, p.Y*factor, p.Z*factor
+	}
+	return scaled
+}
+
+// SetPattern applies the same gain pattern to every element.
+func (g *Geometry) SetPattern(p Pattern) {
+	for i := range g.Elements {
+		g.Elements[i].Pattern = p
+	}
+}
+
+// SetCoupling installs a mutual coupling matrix, which must be N×N.
+func (g *Geometry) SetCoupling(c [][]complex128) error {
+	if c == nil {
+		g.Coupling = nil
+		return nil
+	}
+	if len(c) != g.Len() {
+		return fmt.Errorf("coupling matrix has %d rows, array has %d elements", len(c), g.Len())
+	}
+	for i, row := range c {
+		if len(row) != g.Len() {
+			return fmt.Errorf("coupling matrix row %d has %d columns, want %d", i, len(row), g.Len())
+		}
+	}
+	g.Coupling = c
+	return nil
+}
+
+// SteeringVector returns the array response in the azimuth plane.
+func (g *Geometry) SteeringVector(azimuth float64) []complex128 {
+	return g.SteeringVector3D(azimuth, 0)
+}
+
+// SteeringVector3D returns the array response to a plane wave arriving from
+// (azimuth, elevation), including element patterns and mutual coupling.
+func (g *Geometry) SteeringVector3D(azimuth, elevation float64) []complex128 {
+	ux := math.Cos(elevation) * math.Cos(azimuth)
+	uy := math.Cos(elevation) * math.Sin(azimuth)
+	uz := math.Sin(elevation)
+
+	a := make([]complex128, len(g.Elements))
+	for n, e := range g.Elements {
+		phase := 2 * math.Pi * (e.Position.X*ux + e.Position.Y*uy + e.Position.Z*uz)
+		gain := 1.0
+		if e.Pattern != nil {
+			bx := math.Cos(e.Elevation) * math.Cos(e.Azimuth)
+			by := math.Cos(e.Elevation) * math.Sin(e.Azimuth)
+			bz := math.Sin(e.Elevation)
+			cos := math.Max(-1, math.Min(1, ux*bx+uy*by+uz*bz))
+			gain = e.Pattern.Gain(math.Acos(cos))
+		}
+		a[n] = complex(gain, 0) * cmplx.Exp(complex(0, phase))
+	}
+
+	if g.Coupling == nil {
+		return a
+	}
+	coupled := make([]complex128, len(a))
+	for i, row := range g.Coupling {
+		for j, c := range row {
+			coupled[i] += c * a[j]
+		}
+	}
+	return coupled
+}
+
+// ToeplitzCoupling builds an n×n coupling matrix with unit diagonal where
+// elements k apart couple with coefficient coeffs[k-1]; farther elements are
+// uncoupled.
+func ToeplitzCoupling(n int, coeffs []complex128) [][]complex128 {
+	c := make([][]complex128, n)
+	for i := range c {
+		c[i] = make([]complex128, n)
+		for j := range c[i] {
+			k := i - j
+			if k < 0 {
+				k = -k
+			}
+			switch {
+			case k == 0:
+				c[i][j] = 1
+			case k <= len(coeffs):
+				c[i][j] = coeffs[k-1]
+			}
+		}
+	}
+	return c
+}
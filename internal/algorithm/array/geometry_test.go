@@ -0,0 +1,69 @@
+package array
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestULA_SteeringVector(t *testing.T) {
+	g := NewULA(8, HalfWavelength)
+	angle := math.Pi / 6
+
+	steering := g.SteeringVector(angle)
+	for n, a := range steering {
+		want := cmplx.Exp(complex(0, 2*math.Pi*float64(n)*0.5*math.Sin(angle)))
+		if cmplx.Abs(a-want) > 1e-12 {
+			t.Errorf("element %d = %v, want %v", n, a, want)
+		}
+	}
+}
+
+func TestGeometry_PatternAndCoupling(t *testing.T) {
+	g := NewULA(4, HalfWavelength)
+	g.SetPattern(CosinePattern{Exponent: 1})
+
+	if a := g.SteeringVector(math.Pi / 2); cmplx.Abs(a[0]) > 1e-12 {
+		t.Errorf("cosine element gain at endfire = %v, want 0", cmplx.Abs(a[0]))
+	}
+
+	if err := g.SetCoupling(ToeplitzCoupling(3, nil)); err == nil {
+		t.Error("expected error for coupling matrix of wrong size")
+	}
+	if err := g.SetCoupling(ToeplitzCoupling(4, []complex128{0.2})); err != nil {
+		t.Fatalf("SetCoupling() error = %v", err)
+	}
+
+	a := g.SteeringVector(0)
+	if got := real(a[0]); math.Abs(got-1.2) > 1e-12 {
+		t.Errorf("edge element with coupling = %v, want 1.2", got)
+	}
+	if got := real(a[1]); math.Abs(got-1.4) > 1e-12 {
+		t.Errorf("inner element with coupling = %v, want 1.4", got)
+	}
+}
+
+func TestSpec_Build(t *testing.T) {
+	g, err := Spec{}.Build(16)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if g.Len() != 16 || g.Elements[1].Position.Y != HalfWavelength {
+		t.Errorf("default spec did not build a half-wavelength ULA")
+	}
+
+	g, err = Spec{Type: TypeURA, Rows: 4}.Build(16)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if p := g.Elements[5].Position; p.Y != 0.5 || p.Z != 0.5 {
+		t.Errorf("URA element 5 at %+v, want y=0.5 z=0.5", p)
+	}
+
+	if _, err := (Spec{Type: TypeURA, Rows: 3}).Build(16); err == nil {
+		t.Error("expected error when rows do not divide element count")
+	}
+	if _, err := (Spec{Type: "hexagonal"}).Build(16); err == nil {
+		t.Error("expected error for unsupported array type")
+	}
+}
@@ -0,0 +1,29 @@
+package array
+
+import "math"
+
+// Pattern is an element's amplitude gain as a function of the angle off its
+// boresight, normalized to 1 on boresight.
+type Pattern interface {
+	Gain(offBoresight float64) float64
+}
+
+type Isotropic struct{}
+
+func (Isotropic) Gain(float64) float64 {
+	return 1
+}
+
+// CosinePattern is cos^Exponent of the off-boresight angle with no radiation
+// behind the element, a common model for patch antennas.
+type CosinePattern struct {
+	Exponent float64
+}
+
+func (p CosinePattern) Gain(offBoresight float64) float64 {
+	c := math.Cos(offBoresight)
+	if c <= 0 {
+		return 0
+	}
+	return math.Pow(c, p.Exponent)
+}
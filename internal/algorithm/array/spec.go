@@ -0,0 +1,80 @@
+package array
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	TypeULA = "ula"
+	TypeURA = "ura"
+	TypeUCA = "uca"
+
+	PatternIsotropic = "isotropic"
+	PatternCosine    = "cosine"
+)
+
+// Spec is the configurable description of an array. The element count is
+// supplied by the owning device so it always matches the hardware.
+type Spec struct {
+	Type            string    `mapstructure:"type" json:"type"`
+	Spacing         float64   `mapstructure:"spacing" json:"spacing"`
+	Rows            int       `mapstructure:"rows" json:"rows"`
+	Radius          float64   `mapstructure:"radius" json:"radius"`
+	Pattern         string    `mapstructure:"pattern" json:"pattern"`
+	PatternExponent float64   `mapstructure:"pattern_exponent" json:"pattern_exponent"`
+	Coupling        []float64 `mapstructure:"coupling" json:"coupling"`
+}
+
+// Build creates an n-element geometry from the spec. Zero values select a
+// half-wavelength ULA of isotropic, uncoupled elements.
+func (s Spec) Build(n int) (*Geometry, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("array needs at least one element, got %d", n)
+	}
+	spacing := s.Spacing
+	if spacing <= 0 {
+		spacing = HalfWavelength
+	}
+
+	var g *Geometry
+	switch s.Type {
+	case "", TypeULA:
+		g = NewULA(n, spacing)
+	case TypeURA:
+		if s.Rows <= 0 || n%s.Rows != 0 {
+			return nil, fmt.Errorf("ura rows %d do not divide %d elements", s.Rows, n)
+		}
+		g = NewURA(s.Rows, n/s.Rows, spacing)
+	case TypeUCA:
+		radius := s.Radius
+		if radius <= 0 {
+			// space neighbouring elements by spacing along the circumference
+			radius = spacing * float64(n) / (2 * math.Pi)
+		}
+		g = NewUCA(n, radius)
+	default:
+		return nil, fmt.Errorf("unsupported array type %q", s.Type)
+	}
+
+	switch s.Pattern {
+	case "", PatternIsotropic:
+	case PatternCosine:
+		exp := s.PatternExponent
+		if exp <= 0 {
+			exp = 1
+		}
+		g.SetPattern(CosinePattern{Exponent: exp})
+	default:
+		return nil, fmt.Errorf("unsupported element pattern %q", s.Pattern)
+	}
+
+	if len(s.Coupling) > 0 {
+		coeffs := make([]complex128, len(s.Coupling))
+		for i, c := range s.Coupling {
+			coeffs[i] = complex(c, 0)
+		}
+		g.Coupling = ToeplitzCoupling(n, coeffs)
+	}
+	return g, nil
+}
@@ -0,0 +1,35 @@
+package beamforming
+
+import (
+	"math"
+	"math/rand"
+)
+
+// DFTCodebook returns size phase vectors for an elementCount-element array.
+// Entry k steers element n by 2πnk/size, the columns of the DFT matrix; with
+// size == elementCount the beams are orthogonal.
+func DFTCodebook(elementCount, size int) [][]float64 {
+	entries := make([][]float64, size)
+	for k := range entries {
+		entries[k] = make([]float64, elementCount)
+		for n := range entries[k] {
+			// reduce nk first so the phase stays exact for large arrays
+			entries[k][n] = 2 * math.Pi * float64(n*k%size) / float64(size)
+		}
+	}
+	return entries
+}
+
+// RandomCodebook returns size phase vectors drawn uniformly from [0, 2π).
+// The same seed gives the same codebook.
+func RandomCodebook(elementCount, size int, seed int64) [][]float64 {
+	rng := rand.New(rand.NewSource(seed))
+	entries := make([][]float64, size)
+	for k := range entries {
+		entries[k] = make([]float64, elementCount)
+		for n := range entries[k] {
+			entries[k][n] = 2 * math.Pi * rng.Float64()
+		}
+	}
+	return entries
+}
@@ -0,0 +1,46 @@
+package beamforming
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestDFTCodebook_Orthogonal(t *testing.T) {
+	const n = 8
+	codebook := DFTCodebook(n, n)
+	if len(codebook) != n {
+		t.Fatalf("len(codebook) = %d, want %d", len(codebook), n)
+	}
+
+	for i := range codebook {
+		for j := range codebook {
+			var sum complex128
+			for e := 0; e < n; e++ {
+				sum += cmplx.Exp(complex(0, codebook[i][e]-codebook[j][e]))
+			}
+			want := 0.0
+			if i == j {
+				want = n
+			}
+			if math.Abs(cmplx.Abs(sum)-want) > 1e-9 {
+				t.Errorf("|<w%d, w%d>| = %g, want %g", i, j, cmplx.Abs(sum), want)
+			}
+		}
+	}
+}
+
+func TestRandomCodebook_Seeded(t *testing.T) {
+	a := RandomCodebook(16, 4, 7)
+	b := RandomCodebook(16, 4, 7)
+	for k := range a {
+		for n := range a[k] {
+			if a[k][n] != b[k][n] {
+				t.Fatalf("entry %d element %d differs between runs with the same seed", k, n)
+			}
+			if a[k][n] < 0 || a[k][n] >= 2*math.Pi {
+				t.Errorf("entry %d element %d = %g, want in [0, 2π)", k, n, a[k][n])
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package beamforming
+
+import (
+	"math"
+	"math/cmplx"
+
+	"isac-cran-system/internal/model"
+)
+
+const (
+	defaultMaxTransmitPower    = 1.0
+	defaultAmplifierEfficiency = 0.35
+	defaultCircuitPower        = 0.5
+	defaultIRSElementPower     = 0.005
+	defaultNoisePower          = 1e-3
+	defaultBandwidth           = 1e6
+)
+
+// PowerModel evaluates spectral and energy efficiency of a transmit
+// configuration: P_total = P_tx/η + P_circuit + N_irs·P_element.
+type PowerModel struct {
+	maxTransmitPower    float64
+	amplifierEfficiency float64
+	circuitPower        float64
+	irsElementCount     int
+	irsElementPower     float64
+	noisePower          float64
+	bandwidth           float64
+}
+
+func NewPowerModel(params *model.PowerParams) *PowerModel {
+	m := &PowerModel{
+		maxTransmitPower:    defaultMaxTransmitPower,
+		amplifierEfficiency: defaultAmplifierEfficiency,
+		circuitPower:        defaultCircuitPower,
+		irsElementPower:     defaultIRSElementPower,
+		noisePower:          defaultNoisePower,
+		bandwidth:           defaultBandwidth,
+	}
+	if params == nil {
+		return m
+	}
+
+	if params.MaxTransmitPower > 0 {
+		m.maxTransmitPower = params.MaxTransmitPower
+	}
+	if params.AmplifierEfficiency > 0 && params.AmplifierEfficiency <= 1 {
+		m.amplifierEfficiency = params.AmplifierEfficiency
+	}
+	if params.CircuitPower > 0 {
+		m.circuitPower = params.CircuitPower
+	}
+	if params.IRSElementCount > 0 {
+		m.irsElementCount = params.IRSElementCount
+	}
+	if params.IRSElementPower > 0 {
+		m.irsElementPower = params.IRSElementPower
+	}
+	if params.NoisePower > 0 {
+		m.noisePower = params.NoisePower
+	}
+	if params.Bandwidth > 0 {
+		m.bandwidth = params.Bandwidth
+	}
+	return m
+}
+
+func (m *PowerModel) MaxTransmitPower() float64 {
+	return m.maxTransmitPower
+}
+
+func (m *PowerModel) TotalPower(txPower float64) float64 {
+	return txPower/m.amplifierEfficiency + m.circuitPower + float64(m.irsElementCount)*m.irsElementPower
+}
+
+// SpectralEfficiency returns log2(1 + P_tx·G/σ²) in bit/s/Hz for a
+// beamforming gain G = |wᴴa|².
+func (m *PowerModel) SpectralEfficiency(txPower, gain float64) float64 {
+	return math.Log2(1 + txPower*gain/m.noisePower)
+}
+
+// EnergyEfficiency returns B·SE/P_total in bit/J.
+func (m *PowerModel) EnergyEfficiency(txPower, gain float64) float64 {
+	return m.efficiency(txPower, m.SpectralEfficiency(txPower, gain))
+}
+
+// efficiency returns B·SE/P_total in bit/J for a spectral efficiency
+// reached with txPower, such as the sum rate of several users.
+func (m *PowerModel) efficiency(txPower, spectralEfficiency float64) float64 {
+	total := m.TotalPower(txPower)
+	if total <= 0 {
+		return 0
+	}
+	return m.bandwidth * spectralEfficiency / total
+}
+
+// OptimizeTransmitPower maximizes energy efficiency over the transmit power
+// using Dinkelbach's method. Each step solves max SE(p) - λ·P_total(p) in
+// closed form (water-filling on a single stream) and updates λ = SE/P_total.
+func (m *PowerModel) OptimizeTransmitPower(gain float64, maxIterations int, threshold float64) (txPower float64, iterations int, converged bool) {
+	if gain <= 0 {
+		return 0, 0, true
+	}
+
+	txPower = m.maxTransmitPower
+	lambda := m.SpectralEfficiency(txPower, gain) / m.TotalPower(txPower)
+
+	for iter := 0; iter < maxIterations; iter++ {
+		iterations = iter + 1
+
+		p := m.amplifierEfficiency/(lambda*math.Ln2) - m.noisePower/gain
+		txPower = math.Max(0, math.Min(m.maxTransmitPower, p))
+
+		f := m.SpectralEfficiency(txPower, gain) - lambda*m.TotalPower(txPower)
+		lambda = m.SpectralEfficiency(txPower, gain) / m.TotalPower(txPower)
+
+		if math.Abs(f) < threshold {
+			converged = true
+			break
+		}
+	}
+
+	return txPower, iterations, converged
+}
+
+func beamformingGain(weights, steering []complex128) float64 {
+	var response complex128
+	for n, w := range weights {
+		response += cmplx.Conj(w) * steering[n]
+	}
+	return real(response)*real(response) + imag(response)*imag(response)
+}
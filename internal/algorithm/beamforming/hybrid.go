@@ -0,0 +1,432 @@
+package beamforming
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"time"
+
+	"isac-cran-system/internal/algorithm/array"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// hybridOversampling is how many DFT dictionary beams there are per
+// antenna.
+const hybridOversampling = 4
+
+var errNoAnalogBeam = errors.New("no dictionary beam correlates with the digital precoder")
+
+// HybridOptimize designs a hybrid precoder F = F_RF·F_BB for a MIMO link
+// whose BS drives its antennas from a few RF chains through phase shifters:
+// every column of the analog precoder F_RF has entries e^{jθ}/√N, and only
+// the small digital precoder F_BB is unconstrained. It approximates the
+// fully digital precoder F_opt, the Nₛ dominant right singular vectors of
+// the channel, which maximizes the spectral efficiency log2 det(I +
+// ρ/Nₛ·HFFᴴHᴴ) with the power shared equally between the streams.
+//
+// The omp method is the spatially sparse precoding of El Ayach et al.
+// (2014): orthogonal matching pursuit adds, one RF chain at a time, the
+// dictionary beam that correlates most with the part of F_opt not yet
+// represented and solves for F_BB by least squares on the beams so far.
+// The alternating method then alternates between the analog phases that
+// best match F_opt·F_BBᴴ and the least-squares F_BB for them, until the
+// relative distance to F_opt improves by less than the convergence
+// threshold, relatively, or the iteration limit is reached, keeping the
+// best precoder. Quantized phases are rounded once the precoder is found,
+// with F_BB solved for them again. F_BB is finally scaled so that ‖F‖²
+// equals Nₛ.
+func (o *Optimizer) HybridOptimize(params *model.HybridBeamformingParams) (*model.HybridBeamformingResult, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	p := params.WithDefaults()
+	logger.Info("Starting hybrid beamforming",
+		zap.Int("antennas", p.Antennas),
+		zap.Int("rf_chains", p.RFChains),
+		zap.Int("streams", p.Streams),
+		zap.String("method", p.Method),
+		zap.String("dictionary", p.Dictionary),
+	)
+
+	seed := time.Now().UnixNano()
+	if p.Seed != nil {
+		seed = *p.Seed
+	}
+	H, departures := hybridChannel(p, rand.New(rand.NewSource(seed)))
+	snr := math.Pow(10, *p.TransmitSNR/10)
+
+	optimal, err := digitalPrecoder(H, p.Streams)
+	if err != nil {
+		return nil, err
+	}
+	angles := departures
+	if p.Dictionary == model.HybridDictionaryDFT {
+		angles = dftAngles(p.Antennas * hybridOversampling)
+	}
+	dictionary := make([][]complex128, len(angles))
+	for i, angle := range angles {
+		dictionary[i] = analogBeam(array.NewULA(p.Antennas, array.HalfWavelength).SteeringVector(angle))
+	}
+
+	picked, analog, digital, history, err := ompPrecoder(optimal, dictionary, p.RFChains)
+	if err != nil {
+		return nil, err
+	}
+	converged := true
+	if p.Method == model.HybridMethodAlternating {
+		var alternations []float64
+		analog, digital, alternations, converged, err = o.alternatePrecoder(optimal, analog, digital)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, alternations...)
+	}
+
+	result := &model.HybridBeamformingResult{
+		Streams:                   p.Streams,
+		DigitalSpectralEfficiency: mimoSpectralEfficiency(H, optimal, snr),
+		ErrorHistory:              history,
+		Iterations:                len(history),
+		Converged:                 converged,
+		Method:                    p.Method,
+	}
+	var continuous float64
+	if p.PhaseBits > 0 {
+		continuous = mimoSpectralEfficiency(H, combine(analog, normalizeDigital(digital, analog, p.Streams)), snr)
+		for _, beam := range analog {
+			phases := make([]float64, len(beam))
+			for n, x := range beam {
+				phases[n] = cmplx.Phase(x)
+			}
+			quantizePhases(phases, p.PhaseBits)
+			for n, phase := range phases {
+				beam[n] = cmplx.Rect(1/math.Sqrt(float64(len(beam))), phase)
+			}
+		}
+		if digital, err = leastSquares(analog, optimal); err != nil {
+			return nil, err
+		}
+	}
+
+	digital = normalizeDigital(digital, analog, p.Streams)
+	precoder := combine(analog, digital)
+	result.SpectralEfficiency = mimoSpectralEfficiency(H, precoder, snr)
+	if p.PhaseBits > 0 {
+		result.QuantizationLoss = continuous - result.SpectralEfficiency
+	}
+	result.ApproximationError = distance(optimal, precoder)
+	result.AnalogPhases = make([][]float64, len(analog))
+	result.DigitalPrecoder = make([][][]float64, len(analog))
+	result.BeamAngles = make([]float64, len(analog))
+	for c, beam := range analog {
+		result.AnalogPhases[c] = make([]float64, len(beam))
+		for n, x := range beam {
+			result.AnalogPhases[c][n] = wrapPhase(cmplx.Phase(x))
+		}
+		result.DigitalPrecoder[c] = serializeWeights(digital[c])
+		result.BeamAngles[c] = angles[picked[c]]
+	}
+
+	logger.Info("Hybrid beamforming completed",
+		zap.Int("rf_chains_used", len(analog)),
+		zap.Int("iterations", result.Iterations),
+		zap.Float64("spectral_efficiency", result.SpectralEfficiency),
+		zap.Float64("digital_spectral_efficiency", result.DigitalSpectralEfficiency),
+		zap.Float64("approximation_error", result.ApproximationError),
+	)
+	return result, nil
+}
+
+// hybridChannel returns the channel H = Σ g_l a_r(φ_l) a_t(θ_l)ᴴ of p with
+// a row per receive antenna, and the departure angles θ_l of its paths.
+// Random paths leave and arrive within ±π/2 with complex Gaussian gains of
+// total power 1.
+func hybridChannel(p *model.HybridBeamformingParams, rng *rand.Rand) ([][]complex128, []float64) {
+	departures := make([]float64, p.NumPaths)
+	arrivals := make([]float64, p.NumPaths)
+	gains := make([]complex128, p.NumPaths)
+	for l := range gains {
+		if len(p.Paths) > 0 {
+			path := p.Paths[l]
+			departures[l], arrivals[l] = path.DepartureAngle, path.ArrivalAngle
+			gains[l] = cmplx.Rect(math.Pow(10, -path.PathLoss/20), path.Phase)
+			continue
+		}
+		departures[l] = (rng.Float64() - 0.5) * math.Pi
+		arrivals[l] = (rng.Float64() - 0.5) * math.Pi
+		gains[l] = complex(rng.NormFloat64(), rng.NormFloat64()) / complex(math.Sqrt(2*float64(p.NumPaths)), 0)
+	}
+
+	tx := array.NewULA(p.Antennas, array.HalfWavelength)
+	rx := array.NewULA(p.ReceiveAntennas, array.HalfWavelength)
+	H := make([][]complex128, p.ReceiveAntennas)
+	for r := range H {
+		H[r] = make([]complex128, p.Antennas)
+	}
+	for l, g := range gains {
+		at, ar := tx.SteeringVector(departures[l]), rx.SteeringVector(arrivals[l])
+		for r, a := range ar {
+			for n, b := range at {
+				H[r][n] += g * a * cmplx.Conj(b)
+			}
+		}
+	}
+	return H, departures
+}
+
+// digitalPrecoder returns the columns of the fully digital precoder, the
+// unit right singular vectors of the streams strongest singular values of
+// H.
+func digitalPrecoder(H [][]complex128, streams int) ([][]complex128, error) {
+	n := len(H[0])
+	gram := make([][]complex128, n)
+	for i := range gram {
+		gram[i] = make([]complex128, n)
+	}
+	for _, row := range H {
+		for i, a := range row {
+			for j, b := range row {
+				gram[i][j] += cmplx.Conj(a) * b
+			}
+		}
+	}
+	_, vectors, err := dominantEigenvectors(gram, streams)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) < streams {
+		return nil, ErrEigenDecomposition
+	}
+	return vectors, nil
+}
+
+// dftAngles are the departure angles of an oversampled DFT grid of size
+// beams, uniform in the sine of the angle.
+func dftAngles(beams int) []float64 {
+	angles := make([]float64, beams)
+	for i := range angles {
+		angles[i] = math.Asin(-1 + 2*float64(i)/float64(beams))
+	}
+	return angles
+}
+
+// analogBeam scales a steering vector to the constant modulus 1/√N of a
+// phase-shifter column.
+func analogBeam(steering []complex128) []complex128 {
+	beam := make([]complex128, len(steering))
+	for n, a := range steering {
+		beam[n] = cmplx.Rect(1/math.Sqrt(float64(len(steering))), cmplx.Phase(a))
+	}
+	return beam
+}
+
+// ompPrecoder picks up to chains beams from dictionary by orthogonal
+// matching pursuit, never the same beam twice, and returns their indices,
+// the beams, the least-squares digital precoder for them and the relative
+// distance to optimal after each pick.
+func ompPrecoder(optimal, dictionary [][]complex128, chains int) ([]int, [][]complex128, [][]complex128, []float64, error) {
+	residual := make([][]complex128, len(optimal))
+	for s, f := range optimal {
+		residual[s] = append([]complex128(nil), f...)
+	}
+	used := make([]bool, len(dictionary))
+	var picked []int
+	var analog, digital [][]complex128
+	var history []float64
+	for len(picked) < chains {
+		best, bestScore := -1, 1e-12
+		for i, beam := range dictionary {
+			if used[i] {
+				continue
+			}
+			var score float64
+			for _, r := range residual {
+				score += squaredAbs(response(beam, r))
+			}
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			break
+		}
+		used[best] = true
+		picked = append(picked, best)
+		analog = append(analog, append([]complex128(nil), dictionary[best]...))
+
+		var err error
+		if digital, err = leastSquares(analog, optimal); err != nil {
+			return nil, nil, nil, nil, err
+		}
+		approx := combine(analog, digital)
+		for s, f := range optimal {
+			for n := range f {
+				residual[s][n] = f[n] - approx[s][n]
+			}
+		}
+		history = append(history, distance(optimal, approx))
+	}
+	if len(picked) == 0 {
+		return nil, nil, nil, nil, errNoAnalogBeam
+	}
+	return picked, analog, digital, history, nil
+}
+
+// alternatePrecoder alternates between the phases of F_opt·F_BBᴴ for the
+// analog beams and the least-squares digital precoder for them, and
+// returns the best pair, the relative distance to optimal after each
+// alternation and whether it stopped improving before the iteration
+// limit.
+func (o *Optimizer) alternatePrecoder(optimal, analog, digital [][]complex128) ([][]complex128, [][]complex128, []float64, bool, error) {
+	bestAnalog, bestDigital := analog, digital
+	best := distance(optimal, combine(analog, digital))
+	previous := best
+	var history []float64
+	for iter := 0; iter < o.maxIterations; iter++ {
+		next := make([][]complex128, len(analog))
+		for c, beam := range analog {
+			next[c] = make([]complex128, len(beam))
+			for n := range beam {
+				var target complex128
+				for s, f := range optimal {
+					target += f[n] * cmplx.Conj(digital[c][s])
+				}
+				phase := cmplx.Phase(beam[n])
+				if target != 0 {
+					phase = cmplx.Phase(target)
+				}
+				next[c][n] = cmplx.Rect(1/math.Sqrt(float64(len(beam))), phase)
+			}
+		}
+		nextDigital, err := leastSquares(next, optimal)
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+		analog, digital = next, nextDigital
+
+		current := distance(optimal, combine(analog, digital))
+		history = append(history, current)
+		if current < best {
+			best, bestAnalog, bestDigital = current, analog, digital
+		}
+		if previous-current < o.convergenceThreshold*previous {
+			return bestAnalog, bestDigital, history, true, nil
+		}
+		previous = current
+	}
+	return bestAnalog, bestDigital, history, false, nil
+}
+
+// leastSquares returns the digital precoder minimizing ‖F_opt −
+// F_RF·F_BB‖ for the analog beams, (F_RFᴴF_RF)⁻¹F_RFᴴF_opt, with a row per
+// beam and a column per stream.
+func leastSquares(analog, optimal [][]complex128) ([][]complex128, error) {
+	gram := make([][]complex128, len(analog))
+	for i := range gram {
+		gram[i] = make([]complex128, len(analog))
+		for j := range gram[i] {
+			gram[i][j] = response(analog[i], analog[j])
+		}
+	}
+	digital := make([][]complex128, len(analog))
+	for c := range digital {
+		digital[c] = make([]complex128, len(optimal))
+	}
+	for s, f := range optimal {
+		rhs := make([]complex128, len(analog))
+		for c, beam := range analog {
+			rhs[c] = response(beam, f)
+		}
+		x, err := solveHermitian(gram, rhs)
+		if err != nil {
+			return nil, err
+		}
+		for c := range digital {
+			digital[c][s] = x[c]
+		}
+	}
+	return digital, nil
+}
+
+// combine returns the columns of F_RF·F_BB, one per stream.
+func combine(analog, digital [][]complex128) [][]complex128 {
+	columns := make([][]complex128, len(digital[0]))
+	for s := range columns {
+		columns[s] = make([]complex128, len(analog[0]))
+		for c, beam := range analog {
+			for n, x := range beam {
+				columns[s][n] += x * digital[c][s]
+			}
+		}
+	}
+	return columns
+}
+
+// normalizeDigital returns the digital precoder scaled so that ‖F_RF·F_BB‖²
+// equals streams.
+func normalizeDigital(digital, analog [][]complex128, streams int) [][]complex128 {
+	scale := complex(math.Sqrt(float64(streams)/squaredFrobenius(combine(analog, digital))), 0)
+	scaled := make([][]complex128, len(digital))
+	for c, row := range digital {
+		scaled[c] = make([]complex128, len(row))
+		for s, x := range row {
+			scaled[c][s] = scale * x
+		}
+	}
+	return scaled
+}
+
+func squaredFrobenius(columns [][]complex128) float64 {
+	var sum float64
+	for _, f := range columns {
+		sum += squaredNorm(f)
+	}
+	return sum
+}
+
+// distance returns ‖a − b‖/‖a‖ for matrices given by their columns.
+func distance(a, b [][]complex128) float64 {
+	var diff float64
+	for s, f := range a {
+		for n := range f {
+			diff += squaredAbs(f[n] - b[s][n])
+		}
+	}
+	return math.Sqrt(diff / squaredFrobenius(a))
+}
+
+// mimoSpectralEfficiency returns log2 det(I + ρ/Nₛ·HFFᴴHᴴ) in bit/s/Hz for
+// the precoder columns F, from the eigenvalues of the Hermitian matrix.
+func mimoSpectralEfficiency(H, precoder [][]complex128, snr float64) float64 {
+	received := make([][]complex128, len(H))
+	for r, row := range H {
+		received[r] = make([]complex128, len(precoder))
+		for s, f := range precoder {
+			for n, h := range row {
+				received[r][s] += h * f[n]
+			}
+		}
+	}
+	scale := snr / float64(len(precoder))
+	A := make([][]complex128, len(H))
+	for i := range A {
+		A[i] = make([]complex128, len(H))
+		for j := range A[i] {
+			A[i][j] = complex(scale, 0) * response(received[j], received[i])
+		}
+		A[i][i] += 1
+	}
+	eigenvalues, _, err := dominantEigenvectors(A, 0)
+	if err != nil {
+		return 0
+	}
+	var se float64
+	for _, lambda := range eigenvalues {
+		se += math.Log2(math.Max(lambda, 1))
+	}
+	return se
+}
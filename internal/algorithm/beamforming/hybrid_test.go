@@ -0,0 +1,109 @@
+package beamforming
+
+import (
+	"math"
+	"testing"
+
+	"isac-cran-system/internal/model"
+)
+
+func TestOptimizer_HybridOptimize(t *testing.T) {
+	optimizer := NewOptimizer(32, 50, 1e-4)
+	seed := int64(7)
+	snr := 10.0
+	params := &model.HybridBeamformingParams{
+		Antennas:        32,
+		ReceiveAntennas: 4,
+		RFChains:        4,
+		Streams:         2,
+		NumPaths:        6,
+		Seed:            &seed,
+		TransmitSNR:     &snr,
+	}
+
+	omp, err := optimizer.HybridOptimize(params)
+	if err != nil {
+		t.Fatalf("HybridOptimize failed: %v", err)
+	}
+	if err := omp.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if len(omp.AnalogPhases) != 4 || len(omp.AnalogPhases[0]) != 32 || len(omp.DigitalPrecoder[0]) != 2 {
+		t.Fatalf("got %d RF chains of %d phases, want 4 of 32", len(omp.AnalogPhases), len(omp.AnalogPhases[0]))
+	}
+	if omp.SpectralEfficiency > omp.DigitalSpectralEfficiency+1e-9 {
+		t.Errorf("hybrid precoder reaches %.4f bit/s/Hz, more than the digital %.4f", omp.SpectralEfficiency, omp.DigitalSpectralEfficiency)
+	}
+	// every RF chain omp adds brings the precoder closer to the digital one
+	for i := 1; i < len(omp.ErrorHistory); i++ {
+		if omp.ErrorHistory[i] > omp.ErrorHistory[i-1]+1e-9 {
+			t.Errorf("error history %v grows at %d", omp.ErrorHistory, i)
+		}
+	}
+
+	params.Method = model.HybridMethodAlternating
+	alternating, err := optimizer.HybridOptimize(params)
+	if err != nil {
+		t.Fatalf("HybridOptimize failed: %v", err)
+	}
+	if err := alternating.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if alternating.ApproximationError > omp.ApproximationError+1e-9 {
+		t.Errorf("alternating ends %.4f from the digital precoder, further than omp's %.4f", alternating.ApproximationError, omp.ApproximationError)
+	}
+
+	// as many RF chains as paths represent the channel's digital precoder
+	// exactly from the paths dictionary
+	params.Method = model.HybridMethodOMP
+	params.RFChains = 6
+	exact, err := optimizer.HybridOptimize(params)
+	if err != nil {
+		t.Fatalf("HybridOptimize failed: %v", err)
+	}
+	if exact.ApproximationError > 1e-6 {
+		t.Errorf("6 chains for 6 paths leave an error of %.2e", exact.ApproximationError)
+	}
+
+	params.RFChains = 4
+	params.PhaseBits = 2
+	quantized, err := optimizer.HybridOptimize(params)
+	if err != nil {
+		t.Fatalf("HybridOptimize failed: %v", err)
+	}
+	if err := quantized.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, phases := range quantized.AnalogPhases {
+		for _, phase := range phases {
+			if step := phase / (math.Pi / 2); math.Abs(step-math.Round(step)) > 1e-9 {
+				t.Fatalf("phase %.4f is not a multiple of π/2", phase)
+			}
+		}
+	}
+}
+
+func TestOptimizer_HybridOptimizeDFT(t *testing.T) {
+	optimizer := NewOptimizer(16, 50, 1e-4)
+	seed := int64(3)
+	params := &model.HybridBeamformingParams{
+		Antennas:   16,
+		RFChains:   8,
+		Seed:       &seed,
+		Dictionary: model.HybridDictionaryDFT,
+	}
+
+	result, err := optimizer.HybridOptimize(params)
+	if err != nil {
+		t.Fatalf("HybridOptimize failed: %v", err)
+	}
+	if err := result.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.AnalogPhases) != 8 || result.Streams != model.DefaultHybridStreams {
+		t.Fatalf("got %d RF chains and %d streams, want 8 and %d", len(result.AnalogPhases), result.Streams, model.DefaultHybridStreams)
+	}
+	if result.SpectralEfficiency > result.DigitalSpectralEfficiency+1e-9 || result.SpectralEfficiency < 0.5*result.DigitalSpectralEfficiency {
+		t.Errorf("hybrid precoder reaches %.4f bit/s/Hz, digital %.4f", result.SpectralEfficiency, result.DigitalSpectralEfficiency)
+	}
+}
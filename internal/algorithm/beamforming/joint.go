@@ -0,0 +1,287 @@
+package beamforming
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"time"
+
+	"isac-cran-system/internal/algorithm/array"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// minJointSNR keeps the decibels of a link without any gain finite.
+const minJointSNR = 1e-30
+
+// cascadedChannel is a BS–user link with an IRS. The user receives
+//
+//	s = (h_d + Σₙ e^{jθₙ} h_r[n] G[n]) · w
+//
+// for BS weights w and IRS phases θ: the direct path h_d, the BS–IRS
+// channel G with a row per element and the IRS–user channel h_r.
+type cascadedChannel struct {
+	direct      []complex128
+	bsIRS       [][]complex128
+	irsUser     []complex128
+	transmitSNR float64
+}
+
+// JointOptimize maximizes the received SNR of a cascaded channel over the
+// BS weights and the IRS phases together. The alternating method alternates
+// between the two closed-form optima of one with the other fixed: matched
+// (MRT) weights w = h*/‖h‖ for the effective channel h of the phases, and
+// phases that bring every reflected path in phase with the direct one for
+// the weights. Neither step lowers the SNR, so it rises monotonically until
+// it improves by less than the convergence threshold, relatively, or the
+// iteration limit is reached. The sdr method finds the phases for matched
+// weights from the semidefinite relaxation instead, see phaseRelaxation,
+// and the manifold method by ascending over the unit-modulus reflection
+// coefficients, see maximizeOnCircle.
+// Quantized phases are rounded once the phases are found, with the weights
+// matched to them again.
+func (o *Optimizer) JointOptimize(params *model.JointBeamformingParams) (*model.JointBeamformingResult, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	p := params.WithDefaults()
+	logger.Info("Starting joint BS-IRS beamforming",
+		zap.Int("bs_antennas", p.BSAntennas),
+		zap.Int("irs_elements", p.IRSElements),
+		zap.Int("phase_bits", p.PhaseBits),
+		zap.String("method", p.Method),
+	)
+
+	seed := time.Now().UnixNano()
+	if p.Seed != nil {
+		seed = *p.Seed
+	}
+	rng := rand.New(rand.NewSource(seed))
+	ch := o.cascadedChannel(p, rng)
+
+	var phases []float64
+	var history []float64
+	var converged bool
+	var sdrBound float64
+	var manifold *model.ManifoldDiagnostics
+	switch p.Method {
+	case model.JointMethodSDR:
+		relaxation := o.relaxPhases(ch, rng)
+		phases = relaxation.randomize(ch, p.Randomizations, rng)
+		history, converged = relaxation.history, relaxation.converged
+		sdrBound = toDB(ch.transmitSNR * relaxation.value)
+	case model.JointMethodManifold:
+		phases, history, manifold = o.manifoldPhases(ch)
+		converged = manifold.Converged
+	default:
+		phases, history, converged = o.alternate(ch)
+	}
+	weights := ch.matchedWeights(phases)
+
+	snr := ch.snr(weights, phases)
+	var quantizationLoss float64
+	if p.PhaseBits > 0 {
+		quantizePhases(phases, p.PhaseBits)
+		weights = ch.matchedWeights(phases)
+		quantized := ch.snr(weights, phases)
+		quantizationLoss = toDB(snr) - toDB(quantized)
+		snr = quantized
+	}
+	for n := range phases {
+		phases[n] = wrapPhase(phases[n])
+	}
+
+	random := make([]float64, p.IRSElements)
+	for n := range random {
+		random[n] = 2 * math.Pi * rng.Float64()
+	}
+
+	result := &model.JointBeamformingResult{
+		BSWeights:          serializeWeights(weights),
+		IRSPhases:          phases,
+		SNR:                toDB(snr),
+		SNRDirect:          toDB(ch.transmitSNR * squaredNorm(ch.direct)),
+		SNRRandomPhases:    toDB(ch.snr(ch.matchedWeights(random), random)),
+		SNRBound:           toDB(ch.bound()),
+		QuantizationLoss:   quantizationLoss,
+		SpectralEfficiency: math.Log2(1 + snr),
+		SDRBound:           sdrBound,
+		SNRHistory:         history,
+		Iterations:         len(history),
+		Converged:          converged,
+		Method:             p.Method,
+		Manifold:           manifold,
+	}
+	result.SNRGain = result.SNR - result.SNRDirect
+
+	logger.Info("Joint BS-IRS beamforming completed",
+		zap.Int("iterations", result.Iterations),
+		zap.Bool("converged", result.Converged),
+		zap.Float64("snr_db", result.SNR),
+		zap.Float64("snr_gain_db", result.SNRGain),
+		zap.Float64("snr_bound_db", result.SNRBound),
+	)
+	return result, nil
+}
+
+// alternate alternates between matched weights and aligned phases from
+// phases of zero.
+func (o *Optimizer) alternate(ch *cascadedChannel) ([]float64, []float64, bool) {
+	phases := make([]float64, len(ch.bsIRS))
+	weights := ch.matchedWeights(phases)
+	var history []float64
+	prev := ch.snr(weights, phases)
+	for iter := 0; iter < o.maxIterations; iter++ {
+		ch.alignPhases(weights, phases)
+		weights = ch.matchedWeights(phases)
+		snr := ch.snr(weights, phases)
+		history = append(history, toDB(snr))
+		if snr-prev <= o.convergenceThreshold*prev {
+			return phases, history, true
+		}
+		prev = snr
+	}
+	if len(history) == 0 {
+		history = append(history, toDB(prev))
+	}
+	return phases, history, false
+}
+
+// cascadedChannel draws the Rician links of p. The BS is a half-wavelength
+// ULA and the IRS the configured IRS array.
+func (o *Optimizer) cascadedChannel(p *model.JointBeamformingParams, rng *rand.Rand) *cascadedChannel {
+	k := math.Pow(10, *p.RicianFactor/10)
+	los, nlos := math.Sqrt(k/(k+1)), math.Sqrt(1/(k+1))
+	link := func(loss float64, lineOfSight complex128) complex128 {
+		scattered := complex(rng.NormFloat64(), rng.NormFloat64()) / complex(math.Sqrt2, 0)
+		return complex(math.Pow(10, -loss/20), 0) * (complex(los, 0)*lineOfSight + complex(nlos, 0)*scattered)
+	}
+
+	bs := array.NewULA(p.BSAntennas, array.HalfWavelength)
+	irs := o.arrayFor(p.IRSElements)
+	toUser, toIRS := bs.SteeringVector(p.DirectAngle), bs.SteeringVector(p.IRSAngle)
+	fromBS, irsToUser := irs.SteeringVector(p.IRSArrivalAngle), irs.SteeringVector(p.UserAngle)
+
+	ch := &cascadedChannel{
+		direct:      make([]complex128, p.BSAntennas),
+		bsIRS:       make([][]complex128, p.IRSElements),
+		irsUser:     make([]complex128, p.IRSElements),
+		transmitSNR: math.Pow(10, *p.TransmitSNR/10),
+	}
+	for m := range ch.direct {
+		ch.direct[m] = link(p.DirectPathLoss, cmplx.Conj(toUser[m]))
+	}
+	for n := range ch.bsIRS {
+		ch.bsIRS[n] = make([]complex128, p.BSAntennas)
+		for m := range ch.bsIRS[n] {
+			ch.bsIRS[n][m] = link(p.BSIRSPathLoss, fromBS[n]*cmplx.Conj(toIRS[m]))
+		}
+		ch.irsUser[n] = link(p.IRSUserPathLoss, cmplx.Conj(irsToUser[n]))
+	}
+	return ch
+}
+
+// effective is the channel from the BS antennas to the user with the IRS
+// set to phases.
+func (c *cascadedChannel) effective(phases []float64) []complex128 {
+	h := make([]complex128, len(c.direct))
+	copy(h, c.direct)
+	for n, row := range c.bsIRS {
+		reflect := cmplx.Rect(1, phases[n]) * c.irsUser[n]
+		for m, g := range row {
+			h[m] += reflect * g
+		}
+	}
+	return h
+}
+
+// matchedWeights are the unit-norm weights with the most gain on the
+// effective channel of phases.
+func (c *cascadedChannel) matchedWeights(phases []float64) []complex128 {
+	h := c.effective(phases)
+	w := make([]complex128, len(h))
+	norm := math.Sqrt(squaredNorm(h))
+	if norm == 0 {
+		w[0] = 1
+		return w
+	}
+	for m, x := range h {
+		w[m] = cmplx.Conj(x) / complex(norm, 0)
+	}
+	return w
+}
+
+// alignPhases sets every phase so that its reflected path arrives in phase
+// with the direct path under weights.
+func (c *cascadedChannel) alignPhases(weights []complex128, phases []float64) {
+	var direct complex128
+	for m, h := range c.direct {
+		direct += h * weights[m]
+	}
+	reference := cmplx.Phase(direct)
+	for n, row := range c.bsIRS {
+		var reflected complex128
+		for m, g := range row {
+			reflected += g * weights[m]
+		}
+		phases[n] = reference - cmplx.Phase(c.irsUser[n]*reflected)
+	}
+}
+
+// gain is the SNR of phases with matched weights, ρ‖h‖² of the effective
+// channel h.
+func (c *cascadedChannel) gain(phases []float64) float64 {
+	return c.transmitSNR * squaredNorm(c.effective(phases))
+}
+
+func (c *cascadedChannel) snr(weights []complex128, phases []float64) float64 {
+	var s complex128
+	for m, h := range c.effective(phases) {
+		s += h * weights[m]
+	}
+	return c.transmitSNR * (real(s)*real(s) + imag(s)*imag(s))
+}
+
+// bound is the SNR with every path added in phase at its own best
+// weights, which no joint choice can exceed.
+func (c *cascadedChannel) bound() float64 {
+	amplitude := math.Sqrt(squaredNorm(c.direct))
+	for n, row := range c.bsIRS {
+		amplitude += cmplx.Abs(c.irsUser[n]) * math.Sqrt(squaredNorm(row))
+	}
+	return c.transmitSNR * amplitude * amplitude
+}
+
+func squaredNorm(v []complex128) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += real(x)*real(x) + imag(x)*imag(x)
+	}
+	return sum
+}
+
+// quantizePhases rounds phases to the nearest of 2^bits levels.
+func quantizePhases(phases []float64, bits int) {
+	step := 2 * math.Pi / float64(int(1)<<bits)
+	for n, phase := range phases {
+		phases[n] = math.Round(phase/step) * step
+	}
+}
+
+// wrapPhase maps phase into [0, 2π).
+func wrapPhase(phase float64) float64 {
+	phase = math.Mod(phase, 2*math.Pi)
+	if phase < 0 {
+		phase += 2 * math.Pi
+	}
+	if phase >= 2*math.Pi {
+		phase = 0
+	}
+	return phase
+}
+
+func toDB(snr float64) float64 {
+	return 10 * math.Log10(math.Max(snr, minJointSNR))
+}
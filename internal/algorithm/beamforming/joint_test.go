@@ -0,0 +1,156 @@
+package beamforming
+
+import (
+	"math"
+	"testing"
+
+	"isac-cran-system/internal/model"
+)
+
+func TestOptimizer_JointOptimize(t *testing.T) {
+	optimizer := NewOptimizer(64, 100, 1e-6)
+	seed := int64(7)
+	params := &model.JointBeamformingParams{
+		BSAntennas:      4,
+		IRSElements:     32,
+		DirectAngle:     0.2,
+		IRSAngle:        -0.5,
+		IRSArrivalAngle: 0.4,
+		UserAngle:       -0.3,
+		Seed:            &seed,
+	}
+
+	result, err := optimizer.JointOptimize(params)
+	if err != nil {
+		t.Fatalf("JointOptimize failed: %v", err)
+	}
+	if err := result.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.BSWeights) != 4 || len(result.IRSPhases) != 32 {
+		t.Fatalf("%d weights and %d phases, want 4 and 32", len(result.BSWeights), len(result.IRSPhases))
+	}
+	for i := 1; i < len(result.SNRHistory); i++ {
+		if result.SNRHistory[i] < result.SNRHistory[i-1]-1e-9 {
+			t.Errorf("SNR fell from %.4f to %.4f dB in iteration %d", result.SNRHistory[i-1], result.SNRHistory[i], i+1)
+		}
+	}
+	if !result.Converged {
+		t.Errorf("not converged after %d iterations", result.Iterations)
+	}
+	if result.SNR > result.SNRBound+1e-9 {
+		t.Errorf("SNR %.2f dB exceeds the bound %.2f dB", result.SNR, result.SNRBound)
+	}
+	// the reflected path is 20 dB weaker per element, but 32 elements in
+	// phase add 30 dB; random phases add only about 15 dB
+	if result.SNRGain < 5 || result.SNR < result.SNRRandomPhases+5 {
+		t.Errorf("SNR %.2f dB, direct %.2f dB, random phases %.2f dB", result.SNR, result.SNRDirect, result.SNRRandomPhases)
+	}
+
+	again, err := optimizer.JointOptimize(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.SNR != result.SNR {
+		t.Errorf("same seed gave %.4f and %.4f dB", result.SNR, again.SNR)
+	}
+
+	// with the direct path blocked, a line-of-sight BS–IRS channel is rank
+	// one and every reflected path reaches the bound
+	k := 50.0
+	params.RicianFactor, params.DirectPathLoss = &k, 300
+	blocked, err := optimizer.JointOptimize(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blocked.SNRBound-blocked.SNR > 0.1 {
+		t.Errorf("SNR %.2f dB without the direct path, bound %.2f dB", blocked.SNR, blocked.SNRBound)
+	}
+
+	params.RicianFactor, params.DirectPathLoss = nil, 0
+	params.PhaseBits = 1
+	coarse, err := optimizer.JointOptimize(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params.PhaseBits = 3
+	fine, err := optimizer.JointOptimize(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if coarse.QuantizationLoss <= fine.QuantizationLoss || fine.QuantizationLoss < 0 || fine.QuantizationLoss > 1 {
+		t.Errorf("quantization loss %.2f dB with 1 bit, %.2f dB with 3 bits", coarse.QuantizationLoss, fine.QuantizationLoss)
+	}
+	step := math.Pi / 4
+	for _, phase := range fine.IRSPhases {
+		if level := phase / step; math.Abs(level-math.Round(level)) > 1e-9 {
+			t.Errorf("phase %.4f is not a 3-bit level", phase)
+			break
+		}
+	}
+
+	params.PhaseBits = model.MaxJointPhaseBits + 1
+	if _, err := optimizer.JointOptimize(params); !model.IsValidationError(err) {
+		t.Errorf("phase_bits %d error = %v, want validation error", params.PhaseBits, err)
+	}
+}
+
+func TestOptimizer_JointOptimizeSDR(t *testing.T) {
+	optimizer := NewOptimizer(64, 200, 1e-6)
+	seed := int64(11)
+	params := &model.JointBeamformingParams{
+		BSAntennas:      4,
+		IRSElements:     16,
+		DirectAngle:     0.2,
+		IRSAngle:        -0.5,
+		IRSArrivalAngle: 0.4,
+		UserAngle:       -0.3,
+		Seed:            &seed,
+		Method:          model.JointMethodSDR,
+	}
+
+	result, err := optimizer.JointOptimize(params)
+	if err != nil {
+		t.Fatalf("JointOptimize failed: %v", err)
+	}
+	if err := result.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if result.Method != model.JointMethodSDR || !result.Converged || len(result.IRSPhases) != 16 {
+		t.Fatalf("method %s, converged %v, %d phases", result.Method, result.Converged, len(result.IRSPhases))
+	}
+	for i := 1; i < len(result.SNRHistory); i++ {
+		if result.SNRHistory[i] < result.SNRHistory[i-1]-1e-9 {
+			t.Errorf("relaxation fell from %.4f to %.4f dB in sweep %d", result.SNRHistory[i-1], result.SNRHistory[i], i+1)
+		}
+	}
+	// the solver stops short of the relaxation's optimum by the threshold
+	if result.SNR > result.SDRBound+0.01 || result.SDRBound > result.SNRBound+1e-9 {
+		t.Errorf("SNR %.2f dB, relaxation %.2f dB, bound %.2f dB", result.SNR, result.SDRBound, result.SNRBound)
+	}
+	// the randomized phases stay within a fraction of a dB of the relaxation
+	if result.SDRBound-result.SNR > 0.5 {
+		t.Errorf("SNR %.2f dB against the relaxation's %.2f dB", result.SNR, result.SDRBound)
+	}
+
+	params.Method = model.JointMethodAlternating
+	alternating, err := optimizer.JointOptimize(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alternating.SNR > result.SDRBound+0.01 || result.SNR < alternating.SNR-0.5 {
+		t.Errorf("SDR %.2f dB, alternating %.2f dB, relaxation %.2f dB", result.SNR, alternating.SNR, result.SDRBound)
+	}
+	if alternating.SDRBound != 0 {
+		t.Errorf("alternating run reports relaxation %.2f dB", alternating.SDRBound)
+	}
+
+	params.Method, params.Randomizations = model.JointMethodSDR, model.MaxJointRandomizations+1
+	if _, err := optimizer.JointOptimize(params); !model.IsValidationError(err) {
+		t.Errorf("randomizations %d error = %v, want validation error", params.Randomizations, err)
+	}
+	params.Method, params.Randomizations = "gradient", 0
+	if _, err := optimizer.JointOptimize(params); !model.IsValidationError(err) {
+		t.Errorf("method gradient error = %v, want validation error", err)
+	}
+}
@@ -0,0 +1,119 @@
+package beamforming
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/blas/cblas128"
+	"gonum.org/v1/gonum/mat"
+)
+
+var (
+	ErrSingularCovariance = errors.New("covariance matrix is singular")
+	ErrEigenDecomposition = errors.New("covariance eigendecomposition failed")
+)
+
+// solveHermitian solves A x = b for Hermitian A. gonum has no complex
+// factorizations, so the system is embedded in the real 2n×2n system
+//
+//	[Re A  -Im A] [Re x]   [Re b]
+//	[Im A   Re A] [Im x] = [Im b]
+//
+// which is symmetric, and positive definite whenever A is. Covariance
+// matrices take the Cholesky path; indefinite ones fall back to LU.
+func solveHermitian(A [][]complex128, b []complex128) ([]complex128, error) {
+	n := len(b)
+	m := 2 * n
+	data := realEmbedding(A)
+	rhs := mat.NewVecDense(m, nil)
+	for i, v := range b {
+		rhs.SetVec(i, real(v))
+		rhs.SetVec(n+i, imag(v))
+	}
+
+	var x mat.VecDense
+	var err error
+	var chol mat.Cholesky
+	if chol.Factorize(mat.NewSymDense(m, data)) {
+		err = chol.SolveVecTo(&x, rhs)
+	} else {
+		var lu mat.LU
+		lu.Factorize(mat.NewDense(m, m, data))
+		err = lu.SolveVecTo(&x, false, rhs)
+	}
+	// an ill-conditioned but nonsingular system still has a usable solution
+	var cond mat.Condition
+	if errors.As(err, &cond) && !math.IsInf(float64(cond), 1) {
+		err = nil
+	}
+	if err != nil {
+		return nil, ErrSingularCovariance
+	}
+
+	out := make([]complex128, n)
+	for i := range out {
+		out[i] = complex(x.AtVec(i), x.AtVec(n+i))
+	}
+	return out, nil
+}
+
+// dominantEigenvectors returns the eigenvalues of Hermitian A in descending
+// order and the unit eigenvectors of the k largest. Every eigenvalue of A
+// appears twice in the real embedding, with eigenvectors [Re v; Im v] and
+// [-Im v; Re v], which both map back to v up to a phase; the duplicates are
+// removed by orthogonalizing against the vectors already kept.
+func dominantEigenvectors(A [][]complex128, k int) ([]float64, [][]complex128, error) {
+	n := len(A)
+	m := 2 * n
+	var eig mat.EigenSym
+	if !eig.Factorize(mat.NewSymDense(m, realEmbedding(A)), true) {
+		return nil, nil, ErrEigenDecomposition
+	}
+	values := eig.Values(nil)
+	var vectors mat.Dense
+	eig.VectorsTo(&vectors)
+
+	eigenvalues := make([]float64, n)
+	for i := range eigenvalues {
+		eigenvalues[i] = values[m-1-2*i]
+	}
+
+	kept := make([][]complex128, 0, k)
+	for j := m - 1; j >= 0 && len(kept) < k; j-- {
+		v := make([]complex128, n)
+		for i := range v {
+			v[i] = complex(vectors.At(i, j), vectors.At(n+i, j))
+		}
+		for _, u := range kept {
+			cblas128.Axpy(-cblas128.Dotc(vector(u), vector(v)), vector(u), vector(v))
+		}
+		// v started with unit norm; what is left after removing the kept
+		// directions is either almost all of it or almost nothing
+		norm := cblas128.Nrm2(vector(v))
+		if norm < 0.5 {
+			continue
+		}
+		cblas128.Scal(complex(1/norm, 0), vector(v))
+		kept = append(kept, v)
+	}
+	return eigenvalues, kept, nil
+}
+
+func realEmbedding(A [][]complex128) []float64 {
+	n := len(A)
+	m := 2 * n
+	data := make([]float64, m*m)
+	for i, row := range A {
+		for j, a := range row {
+			data[i*m+j] = real(a)
+			data[i*m+n+j] = -imag(a)
+			data[(n+i)*m+j] = imag(a)
+			data[(n+i)*m+n+j] = real(a)
+		}
+	}
+	return data
+}
+
+func vector(v []complex128) cblas128.Vector {
+	return cblas128.Vector{N: len(v), Inc: 1, Data: v}
+}
@@ -0,0 +1,186 @@
+package beamforming
+
+import (
+	"math"
+	"math/cmplx"
+
+	"isac-cran-system/internal/model"
+)
+
+// circleObjective is a smooth real function of unit-modulus vectors to
+// maximize. It returns the value at x and the Euclidean gradient, the
+// complex vector g with f(x + δ) ≈ f(x) + Re⟨g, δ⟩.
+type circleObjective func(x []complex128) (float64, []complex128)
+
+// Settings of the manifold optimizer. A point is critical once the
+// Riemannian gradient is this small against the Euclidean one; the Armijo
+// condition asks for this share of the first-order ascent; a line search
+// gives up after halving the step this often.
+const (
+	manifoldGradientTolerance = 1e-8
+	manifoldArmijo            = 1e-4
+	manifoldBacktracks        = 50
+)
+
+// maximizeOnCircle maximizes f over the complex circle manifold
+// {x : |x_i| = 1} from x, in place, with Riemannian conjugate gradients
+// (Absil et al., 2008). The Riemannian gradient is the Euclidean one
+// projected onto the tangent space at x, Re(g_i x_i*) x_i removed from each
+// entry; steps are retracted onto the manifold by normalizing every entry,
+// and the previous direction is carried to the new point by projection.
+// Directions follow Polak–Ribière+ and restart from the gradient when they
+// stop ascending. Each step is the first of 1/max|d_i|, halved, to meet the
+// Armijo condition, so that no entry turns by more than a radian. It stops
+// at a critical point, when the value improves by less than the
+// convergence threshold relatively, when no step ascends, or at the
+// iteration limit. It returns the value after each iteration.
+func (o *Optimizer) maximizeOnCircle(f circleObjective, x []complex128) ([]float64, *model.ManifoldDiagnostics) {
+	diag := &model.ManifoldDiagnostics{StopReason: model.ManifoldStopMaxIterations}
+	var values []float64
+	value, egrad := f(x)
+	grad := tangent(x, egrad)
+	var direction, previous []complex128
+	for iter := 0; iter < o.maxIterations; iter++ {
+		norm := math.Sqrt(squaredNorm(grad))
+		relative := norm / math.Max(math.Sqrt(squaredNorm(egrad)), minJointSNR)
+		diag.GradientNorms = append(diag.GradientNorms, relative)
+		if relative <= manifoldGradientTolerance {
+			diag.StopReason, diag.Converged = model.ManifoldStopGradient, true
+			break
+		}
+
+		if direction == nil {
+			direction = append([]complex128(nil), grad...)
+		} else {
+			carried := tangent(x, direction)
+			previousGrad := tangent(x, previous)
+			var num float64
+			for i := range grad {
+				num += real(cmplx.Conj(grad[i]) * (grad[i] - previousGrad[i]))
+			}
+			beta := math.Max(0, num/squaredNorm(previous))
+			for i := range direction {
+				direction[i] = grad[i] + complex(beta, 0)*carried[i]
+			}
+			if inner(grad, direction) <= 0 {
+				copy(direction, grad)
+				diag.Restarts++
+			}
+		}
+
+		slope := inner(grad, direction)
+		step := 1 / maxAbs(direction)
+		candidate := make([]complex128, len(x))
+		var next float64
+		var nextGrad []complex128
+		accepted := false
+		for k := 0; k < manifoldBacktracks; k++ {
+			for i := range x {
+				candidate[i] = x[i] + complex(step, 0)*direction[i]
+				if a := cmplx.Abs(candidate[i]); a > 0 {
+					candidate[i] /= complex(a, 0)
+				} else {
+					candidate[i] = x[i]
+				}
+			}
+			next, nextGrad = f(candidate)
+			if next >= value+manifoldArmijo*step*slope {
+				accepted = true
+				break
+			}
+			step /= 2
+		}
+		if !accepted {
+			diag.StopReason = model.ManifoldStopLineSearch
+			break
+		}
+
+		copy(x, candidate)
+		previous = grad
+		improved := next - value
+		value, egrad = next, nextGrad
+		grad = tangent(x, egrad)
+		values = append(values, value)
+		diag.StepSizes = append(diag.StepSizes, step)
+		if improved <= o.convergenceThreshold*value {
+			diag.StopReason, diag.Converged = model.ManifoldStopImprovement, true
+			break
+		}
+	}
+	diag.Iterations = len(values)
+	if len(diag.GradientNorms) > 0 {
+		diag.GradientNorm = diag.GradientNorms[len(diag.GradientNorms)-1]
+	}
+	return values, diag
+}
+
+// tangent projects v onto the tangent space of the circle manifold at x.
+func tangent(x, v []complex128) []complex128 {
+	out := make([]complex128, len(v))
+	for i := range v {
+		out[i] = v[i] - complex(real(v[i]*cmplx.Conj(x[i])), 0)*x[i]
+	}
+	return out
+}
+
+// inner is the real inner product Re⟨a, b⟩ of the manifold's metric.
+func inner(a, b []complex128) float64 {
+	var sum float64
+	for i := range a {
+		sum += real(cmplx.Conj(a[i]) * b[i])
+	}
+	return sum
+}
+
+func maxAbs(v []complex128) float64 {
+	var m float64
+	for _, x := range v {
+		m = math.Max(m, cmplx.Abs(x))
+	}
+	return m
+}
+
+// manifoldPhases maximizes the SNR of ch with matched weights, ρ‖h‖² of the
+// effective channel h = h_d + Σ e^{jθₙ} h_r[n]G[n], over the reflection
+// coefficients e^{jθₙ}, from phases of zero. The Euclidean gradient of
+// ‖h‖² in the n-th coefficient is 2(h_r[n]G[n])ᴴh.
+func (o *Optimizer) manifoldPhases(ch *cascadedChannel) ([]float64, []float64, *model.ManifoldDiagnostics) {
+	reflected := make([][]complex128, len(ch.bsIRS))
+	for n, row := range ch.bsIRS {
+		reflected[n] = make([]complex128, len(row))
+		for m, g := range row {
+			reflected[n][m] = ch.irsUser[n] * g
+		}
+	}
+	objective := func(x []complex128) (float64, []complex128) {
+		h := append([]complex128(nil), ch.direct...)
+		for n, a := range reflected {
+			for m := range h {
+				h[m] += x[n] * a[m]
+			}
+		}
+		grad := make([]complex128, len(x))
+		for n, a := range reflected {
+			grad[n] = 2 * response(a, h)
+		}
+		return squaredNorm(h), grad
+	}
+
+	x := make([]complex128, len(ch.bsIRS))
+	for n := range x {
+		x[n] = 1
+	}
+	values, diag := o.maximizeOnCircle(objective, x)
+	history := make([]float64, len(values))
+	for i, v := range values {
+		history[i] = toDB(ch.transmitSNR * v)
+	}
+	if len(history) == 0 {
+		history = append(history, toDB(ch.gain(make([]float64, len(x)))))
+	}
+	phases := make([]float64, len(x))
+	for n, c := range x {
+		phases[n] = cmplx.Phase(c)
+	}
+	return phases, history, diag
+}
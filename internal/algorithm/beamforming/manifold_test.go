@@ -0,0 +1,97 @@
+package beamforming
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"isac-cran-system/internal/model"
+)
+
+func TestOptimizer_MaximizeOnCircle(t *testing.T) {
+	optimizer := NewOptimizer(8, 500, 1e-12)
+	// xᴴvvᴴx peaks at N² where x follows the phases of v
+	v := make([]complex128, 8)
+	for i := range v {
+		v[i] = cmplx.Rect(1, 0.9*float64(i*i))
+	}
+	objective := func(x []complex128) (float64, []complex128) {
+		p := response(v, x)
+		grad := make([]complex128, len(x))
+		for i := range grad {
+			grad[i] = 2 * v[i] * p
+		}
+		return squaredAbs(p), grad
+	}
+	x := make([]complex128, len(v))
+	for i := range x {
+		x[i] = 1
+	}
+
+	values, diag := optimizer.maximizeOnCircle(objective, x)
+	if err := diag.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !diag.Converged || len(values) != diag.Iterations {
+		t.Fatalf("converged %v after %d iterations with %d values, stopped by %s", diag.Converged, diag.Iterations, len(values), diag.StopReason)
+	}
+	if got := values[len(values)-1]; math.Abs(got-64) > 1e-6 {
+		t.Errorf("maximum %.8f, want 64", got)
+	}
+	for i := 1; i < len(values); i++ {
+		if values[i] < values[i-1] {
+			t.Errorf("value fell from %.6f to %.6f in iteration %d", values[i-1], values[i], i+1)
+		}
+	}
+	for i, c := range x {
+		if math.Abs(cmplx.Abs(c)-1) > 1e-12 {
+			t.Fatalf("entry %d has modulus %.15f", i, cmplx.Abs(c))
+		}
+	}
+	if first, last := diag.GradientNorms[0], diag.GradientNorm; last >= first || last > 1e-3 {
+		t.Errorf("relative gradient norm went from %.3g to %.3g", first, last)
+	}
+}
+
+func TestOptimizer_JointOptimizeManifold(t *testing.T) {
+	optimizer := NewOptimizer(64, 200, 1e-8)
+	seed := int64(11)
+	params := &model.JointBeamformingParams{
+		BSAntennas:      4,
+		IRSElements:     32,
+		DirectAngle:     0.2,
+		IRSAngle:        -0.5,
+		IRSArrivalAngle: 0.4,
+		UserAngle:       -0.3,
+		Seed:            &seed,
+		Method:          model.JointMethodManifold,
+	}
+
+	result, err := optimizer.JointOptimize(params)
+	if err != nil {
+		t.Fatalf("JointOptimize failed: %v", err)
+	}
+	if err := result.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if result.Manifold == nil || !result.Converged || result.Manifold.Iterations != result.Iterations {
+		t.Fatalf("converged %v after %d iterations, diagnostics %+v", result.Converged, result.Iterations, result.Manifold)
+	}
+	for i := 1; i < len(result.SNRHistory); i++ {
+		if result.SNRHistory[i] < result.SNRHistory[i-1]-1e-9 {
+			t.Errorf("SNR fell from %.4f to %.4f dB in iteration %d", result.SNRHistory[i-1], result.SNRHistory[i], i+1)
+		}
+	}
+
+	params.Method = model.JointMethodSDR
+	sdr, err := optimizer.JointOptimize(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SNR > sdr.SDRBound+0.01 || result.SNR < sdr.SDRBound-0.5 {
+		t.Errorf("manifold SNR %.2f dB, relaxation %.2f dB", result.SNR, sdr.SDRBound)
+	}
+	if sdr.Manifold != nil {
+		t.Error("sdr run reports manifold diagnostics")
+	}
+}
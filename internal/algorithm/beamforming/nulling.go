@@ -0,0 +1,95 @@
+package beamforming
+
+import (
+	"math"
+	"math/cmplx"
+
+	"isac-cran-system/internal/model"
+
+	"gonum.org/v1/gonum/blas/cblas128"
+)
+
+// MinNullDepth is the deepest null depth reported, in dB. The hard nulls of
+// LCMV are only limited by rounding, which would otherwise show as -Inf.
+const MinNullDepth = -300.0
+
+// nullSteeringWeights returns weights with unit response toward the target
+// that suppress the interferers.
+//
+// Without an interference-to-noise ratio the weights are LCMV with a zero
+// response constraint per interferer and white noise,
+//
+//	w = C (Cᴴ C)⁻¹ f,  C = [a₀ a₁ … a_K],  f = [1 0 … 0]ᵀ,
+//
+// the smallest weights meeting every constraint, so each null is exact.
+// With inrDB they are MVDR over the modelled interference-plus-noise
+// covariance R = I + INR Σ aₖ aₖᴴ,
+//
+//	w = R⁻¹ a₀ / (a₀ᴴ R⁻¹ a₀),
+//
+// whose nulls deepen with the INR and trade depth for less noise gain.
+func nullSteeringWeights(target []complex128, interferers [][]complex128, inrDB *float64) ([]complex128, error) {
+	n := len(target)
+	if inrDB != nil {
+		x, err := solveHermitian(interferenceCovariance(n, interferers, *inrDB), target)
+		if err != nil {
+			return nil, err
+		}
+		cblas128.Scal(1/cblas128.Dotc(vector(target), vector(x)), vector(x))
+		return x, nil
+	}
+
+	if len(interferers)+1 > n {
+		return nil, &model.ValidationError{Field: "interference_angles", Message: "needs fewer interference angles than elements to place every null"}
+	}
+	C := append([][]complex128{target}, interferers...)
+	gram := make([][]complex128, len(C))
+	for i := range gram {
+		gram[i] = make([]complex128, len(C))
+		for j := range gram[i] {
+			gram[i][j] = cblas128.Dotc(vector(C[i]), vector(C[j]))
+		}
+	}
+	f := make([]complex128, len(C))
+	f[0] = 1
+	g, err := solveHermitian(gram, f)
+	if err != nil {
+		return nil, &model.ValidationError{Field: "interference_angles", Message: "interference angles must differ from the target direction and from each other"}
+	}
+	w := make([]complex128, n)
+	for k, a := range C {
+		cblas128.Axpy(g[k], vector(a), vector(w))
+	}
+	return w, nil
+}
+
+// interferenceCovariance is the interference-plus-noise covariance
+// R = I + INR Σ aₖ aₖᴴ of n elements, relative to the noise power.
+func interferenceCovariance(n int, interferers [][]complex128, inrDB float64) [][]complex128 {
+	inr := math.Pow(10, inrDB/10)
+	R := make([][]complex128, n)
+	for i := range R {
+		R[i] = make([]complex128, n)
+		R[i][i] = 1
+		for _, a := range interferers {
+			for j := range R[i] {
+				R[i][j] += complex(inr, 0) * a[i] * cmplx.Conj(a[j])
+			}
+		}
+	}
+	return R
+}
+
+// nullDepths is the response of weights toward each interferer relative to
+// the target, in dB, no deeper than MinNullDepth.
+func nullDepths(weights, target []complex128, interferers [][]complex128) []float64 {
+	main := math.Sqrt(beamformingGain(weights, target))
+	depths := make([]float64, len(interferers))
+	for i, a := range interferers {
+		depths[i] = MinNullDepth
+		if main > 0 {
+			depths[i] = math.Max(20*math.Log10(math.Sqrt(beamformingGain(weights, a))/main), MinNullDepth)
+		}
+	}
+	return depths
+}
@@ -0,0 +1,228 @@
+package beamforming
+
+import (
+	"math"
+	"math/cmplx"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// OFDM beamforms a wideband OFDM link. Every subcarrier k sees its own
+// channel H_k, and the unit weights that maximize its gain ‖H_k w‖² are the
+// dominant right singular vector of H_k; the subcarriers of a resource
+// block share the dominant right singular vector of their stacked
+// channels, which maximizes the sum of their gains. The array is taken to
+// be narrowband: a synthesized path leaves in the same direction on every
+// subcarrier, only the phase its delay adds changes across the band,
+//
+//	H_k = Σ_p g_p e^{jφ_p} e^{-j2π f_k τ_p} a(θ_p)ᴴ,  f_k = (k - K/2)·Δf
+//
+// The transmit power is shared equally across the subcarriers and so is the
+// noise, so each reaches the SNR P·‖H_k w‖²/σ². Energy efficiency counts the
+// K·Δf Hz the subcarriers occupy unless params.Power sets a bandwidth.
+func (o *Optimizer) OFDM(params *model.BeamformingParams) (*model.BeamformingResult, error) {
+	if err := params.ValidateOFDM(); err != nil {
+		return nil, err
+	}
+	objective, err := objectiveOf(params)
+	if err != nil {
+		return nil, err
+	}
+	if objective != model.BeamformingObjectiveSpectral {
+		return nil, &model.ValidationError{Field: "objective", Message: "ofdm maximizes the rate of every subcarrier, use spectral_efficiency"}
+	}
+	ofdm := params.OFDM.WithDefaults()
+	channels := o.ofdmChannels(params.Elements(), ofdm)
+	subcarriers := len(channels)
+	block := min(ofdm.ResourceBlockSize, subcarriers)
+
+	logger.Info("Starting OFDM beamforming",
+		zap.Int("element_count", params.Elements()),
+		zap.Int("subcarriers", subcarriers),
+		zap.Int("resource_block_size", block),
+	)
+
+	var power model.PowerParams
+	if params.Power != nil {
+		power = *params.Power
+	}
+	if power.Bandwidth <= 0 {
+		power.Bandwidth = float64(subcarriers) * ofdm.SubcarrierSpacing
+	}
+	powerModel := NewPowerModel(&power)
+	txPower := powerModel.MaxTransmitPower()
+
+	blocks := (subcarriers + block - 1) / block
+	weights := make([][][]float64, blocks)
+	gains := make([]float64, subcarriers)
+	rates := make([]float64, subcarriers)
+	var rate float64
+	for b := range weights {
+		lo, hi := b*block, min((b+1)*block, subcarriers)
+		w, err := dominantRightSingularVector(channels[lo:hi])
+		if err != nil {
+			return nil, err
+		}
+		weights[b] = serializeWeights(w)
+		for k := lo; k < hi; k++ {
+			gains[k] = channelGain(channels[k], w)
+			rates[k] = powerModel.SpectralEfficiency(txPower, gains[k])
+			rate += rates[k]
+		}
+	}
+	rate /= float64(subcarriers)
+
+	wideband, err := dominantRightSingularVector(channels)
+	if err != nil {
+		return nil, err
+	}
+	var widebandGain, widebandRate float64
+	for _, H := range channels {
+		gain := channelGain(H, wideband)
+		widebandGain += gain
+		widebandRate += powerModel.SpectralEfficiency(txPower, gain)
+	}
+	widebandGain /= float64(subcarriers)
+	widebandRate /= float64(subcarriers)
+
+	result := o.evaluate(wideband, widebandGain, objective, &power)
+	result.Iterations = 1
+	result.SpectralEfficiency = rate
+	result.EnergyEfficiency = powerModel.efficiency(txPower, rate)
+	result.OFDM = &model.OFDMBeamformingResult{
+		Subcarriers:                subcarriers,
+		SubcarrierSpacing:          ofdm.SubcarrierSpacing,
+		ResourceBlockSize:          block,
+		Weights:                    weights,
+		Gains:                      gains,
+		SpectralEfficiency:         rates,
+		WidebandSpectralEfficiency: widebandRate,
+	}
+
+	logger.Info("OFDM beamforming completed",
+		zap.Int("resource_blocks", blocks),
+		zap.Float64("spectral_efficiency", rate),
+		zap.Float64("wideband_spectral_efficiency", widebandRate),
+	)
+	return result, nil
+}
+
+// ofdmChannels returns the channel matrix of every subcarrier, decoded from
+// the request or synthesized from its paths.
+func (o *Optimizer) ofdmChannels(elementCount int, ofdm *model.OFDMParams) [][][]complex128 {
+	if len(ofdm.Channels) > 0 {
+		channels := make([][][]complex128, len(ofdm.Channels))
+		for k, H := range ofdm.Channels {
+			channels[k] = make([][]complex128, len(H))
+			for r, row := range H {
+				channels[k][r] = make([]complex128, len(row))
+				for n, v := range row {
+					channels[k][r][n] = complex(v[0], v[1])
+				}
+			}
+		}
+		return channels
+	}
+
+	steerings := make([][]complex128, len(ofdm.Paths))
+	gains := make([]complex128, len(ofdm.Paths))
+	for p, path := range ofdm.Paths {
+		steerings[p] = o.computeSteeringVector(elementCount, path.Angle)
+		gains[p] = cmplx.Rect(math.Pow(10, -path.PathLoss/20), path.Phase)
+	}
+	channels := make([][][]complex128, ofdm.Subcarriers)
+	for k := range channels {
+		f := float64(k-ofdm.Subcarriers/2) * ofdm.SubcarrierSpacing
+		row := make([]complex128, elementCount)
+		for p, path := range ofdm.Paths {
+			g := gains[p] * cmplx.Rect(1, -2*math.Pi*f*path.Delay)
+			for n, a := range steerings[p] {
+				row[n] += g * cmplx.Conj(a)
+			}
+		}
+		channels[k] = [][]complex128{row}
+	}
+	return channels
+}
+
+// dominantRightSingularVector returns the unit w maximizing Σ‖H w‖² over the
+// channels, the dominant eigenvector of Σ HᴴH. When the channels stack into
+// fewer rows than columns it decomposes the smaller Gram matrix S Sᴴ of the
+// stacked rows S instead and maps its dominant eigenvector u back as Sᴴu.
+func dominantRightSingularVector(channels [][][]complex128) ([]complex128, error) {
+	var rows [][]complex128
+	for _, H := range channels {
+		rows = append(rows, H...)
+	}
+	n := len(rows[0])
+
+	if len(rows) >= n {
+		gram := make([][]complex128, n)
+		for i := range gram {
+			gram[i] = make([]complex128, n)
+		}
+		for _, row := range rows {
+			for i, a := range row {
+				for j, b := range row {
+					gram[i][j] += cmplx.Conj(a) * b
+				}
+			}
+		}
+		_, vectors, err := dominantEigenvectors(gram, 1)
+		if err != nil {
+			return nil, err
+		}
+		return vectors[0], nil
+	}
+
+	gram := make([][]complex128, len(rows))
+	for i := range gram {
+		gram[i] = make([]complex128, len(rows))
+		for j := range gram[i] {
+			gram[i][j] = response(rows[j], rows[i])
+		}
+	}
+	_, vectors, err := dominantEigenvectors(gram, 1)
+	if err != nil {
+		return nil, err
+	}
+	w := make([]complex128, n)
+	for i, u := range vectors[0] {
+		for j, a := range rows[i] {
+			w[j] += cmplx.Conj(a) * u
+		}
+	}
+	var norm float64
+	for _, v := range w {
+		norm += squaredAbs(v)
+	}
+	if norm == 0 {
+		// a channel that carries nothing is served equally badly by any
+		// weights
+		for j := range w {
+			w[j] = complex(1/math.Sqrt(float64(n)), 0)
+		}
+		return w, nil
+	}
+	scale := complex(1/math.Sqrt(norm), 0)
+	for j := range w {
+		w[j] *= scale
+	}
+	return w, nil
+}
+
+// channelGain returns ‖H w‖².
+func channelGain(H [][]complex128, w []complex128) float64 {
+	var gain float64
+	for _, row := range H {
+		var y complex128
+		for n, h := range row {
+			y += h * w[n]
+		}
+		gain += squaredAbs(y)
+	}
+	return gain
+}
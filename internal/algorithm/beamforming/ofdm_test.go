@@ -0,0 +1,102 @@
+package beamforming
+
+import (
+	"math"
+	"testing"
+
+	"isac-cran-system/internal/model"
+)
+
+func TestOptimizer_OFDM(t *testing.T) {
+	optimizer := NewOptimizer(8, 100, 1e-6)
+	params := &model.BeamformingParams{
+		ElementCount: 8,
+		Mode:         model.BeamformingModeOFDM,
+		OFDM: &model.OFDMParams{
+			Subcarriers: 48,
+			Paths: []model.OFDMPath{
+				{Angle: -0.4},
+				{Angle: 0.5, Delay: 2e-6, PathLoss: 2},
+			},
+		},
+	}
+
+	result, err := optimizer.OFDM(params)
+	if err != nil {
+		t.Fatalf("OFDM failed: %v", err)
+	}
+	if err := result.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	ofdm := result.OFDM
+	if ofdm == nil || len(ofdm.Weights) != 48 || len(ofdm.Gains) != 48 || len(ofdm.Weights[0]) != 8 {
+		t.Fatalf("ofdm result = %+v, want weights and gains for 48 subcarriers", ofdm)
+	}
+
+	// the weights of a single-antenna receiver are matched to each
+	// subcarrier, whose gain is then the whole channel power
+	channels := optimizer.ofdmChannels(8, params.OFDM.WithDefaults())
+	var mean float64
+	for k, H := range channels {
+		var power float64
+		for _, h := range H[0] {
+			power += squaredAbs(h)
+		}
+		if math.Abs(ofdm.Gains[k]-power) > 1e-9*power {
+			t.Errorf("subcarrier %d: gain %.6f, want %.6f", k, ofdm.Gains[k], power)
+		}
+		mean += ofdm.SpectralEfficiency[k] / 48
+	}
+	if math.Abs(mean-result.SpectralEfficiency) > 1e-9 {
+		t.Errorf("spectral efficiency %.6f, want the mean %.6f", result.SpectralEfficiency, mean)
+	}
+	if ofdm.WidebandSpectralEfficiency >= result.SpectralEfficiency {
+		t.Errorf("wideband beam reaches %.4f bit/s/Hz, per-subcarrier weights only %.4f", ofdm.WidebandSpectralEfficiency, result.SpectralEfficiency)
+	}
+
+	// one resource block spanning the band is the wideband beam
+	params.OFDM.ResourceBlockSize = 48
+	band, err := optimizer.OFDM(params)
+	if err != nil {
+		t.Fatalf("OFDM failed: %v", err)
+	}
+	if len(band.OFDM.Weights) != 1 || math.Abs(band.SpectralEfficiency-ofdm.WidebandSpectralEfficiency) > 1e-9 {
+		t.Errorf("%d blocks at %.6f bit/s/Hz, want 1 at %.6f", len(band.OFDM.Weights), band.SpectralEfficiency, ofdm.WidebandSpectralEfficiency)
+	}
+}
+
+func TestOptimizer_OFDMChannels(t *testing.T) {
+	optimizer := NewOptimizer(2, 100, 1e-6)
+	params := &model.BeamformingParams{
+		Mode: model.BeamformingModeOFDM,
+		OFDM: &model.OFDMParams{
+			ResourceBlockSize: 2,
+			Channels: [][][][]float64{
+				{{{2, 0}, {0, 0}}, {{0, 0}, {1, 0}}},
+				{{{0, 0}, {0, 0}}, {{0, 0}, {0, 3}}},
+				{{{0, 1}, {0, 0}}, {{0, 0}, {0, 0}}},
+			},
+		},
+	}
+
+	result, err := optimizer.OFDM(params)
+	if err != nil {
+		t.Fatalf("OFDM failed: %v", err)
+	}
+	ofdm := result.OFDM
+	if ofdm.Subcarriers != 3 || len(ofdm.Weights) != 2 {
+		t.Fatalf("%d subcarriers in %d blocks, want 3 in 2", ofdm.Subcarriers, len(ofdm.Weights))
+	}
+	// the first block favours the second antenna, 1² + 3² > 2², and the
+	// last subcarrier only reaches the first
+	for k, want := range []float64{1, 9, 1} {
+		if math.Abs(ofdm.Gains[k]-want) > 1e-9 {
+			t.Errorf("subcarrier %d: gain %.6f, want %g", k, ofdm.Gains[k], want)
+		}
+	}
+
+	params.OFDM.Channels[1] = params.OFDM.Channels[1][:1]
+	if _, err := optimizer.OFDM(params); err == nil {
+		t.Error("channels of different shapes accepted")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"math"
 	"math/cmplx"
 
+	"isac-cran-system/internal/algorithm/array"
 	"isac-cran-system/internal/model"
 	"isac-cran-system/pkg/logger"
 
@@ -14,6 +15,7 @@ type Optimizer struct {
 	elementCount         int
 	maxIterations        int
 	convergenceThreshold float64
+	geometry             *array.Geometry
 }
 
 func NewOptimizer(elementCount int, maxIterations int, threshold float64) *Optimizer {
@@ -24,13 +26,34 @@ func NewOptimizer(elementCount int, maxIterations int, threshold float64) *Optim
 	}
 }
 
+// SetGeometry sets the array used for steering vectors. Requests whose
+// element count differs from it fall back to a half-wavelength ULA.
+func (o *Optimizer) SetGeometry(g *array.Geometry) {
+	o.geometry = g
+}
+
+// MaxIterations is the iteration limit of Optimize.
+func (o *Optimizer) MaxIterations() int {
+	return o.maxIterations
+}
+
+func (o *Optimizer) arrayFor(elementCount int) *array.Geometry {
+	if o.geometry != nil && o.geometry.Len() == elementCount {
+		return o.geometry
+	}
+	return array.NewULA(elementCount, array.HalfWavelength)
+}
+
 func (o *Optimizer) Optimize(params *model.BeamformingParams) (*model.BeamformingResult, error) {
 	logger.Info("Starting beamforming optimization",
 		zap.Int("element_count", params.ElementCount),
 		zap.Float64("target_direction", params.TargetDirection),
 	)
 
-	weights := o.initializeWeights(params.ElementCount)
+	objective, err := objectiveOf(params)
+	if err != nil {
+		return nil, err
+	}
 
 	targetSteering := o.computeSteeringVector(params.ElementCount, params.TargetDirection)
 
@@ -38,6 +61,11 @@ func (o *Optimizer) Optimize(params *model.BeamformingParams) (*model.Beamformin
 	for i, angle := range params.InterferenceAngles {
 		interferenceSteerings[i] = o.computeSteeringVector(params.ElementCount, angle)
 	}
+	if len(interferenceSteerings) > 0 {
+		return o.nullSteer(params, objective, targetSteering, interferenceSteerings)
+	}
+
+	weights := o.initializeWeights(params.ElementCount)
 
 	var converged bool
 	var iterations int
@@ -68,53 +96,160 @@ func (o *Optimizer) Optimize(params *model.BeamformingParams) (*model.Beamformin
 		}
 	}
 
-	beamPattern := o.computeBeamPattern(weights, 360)
+	gain := beamformingGain(weights, targetSteering)
+	result := o.evaluate(weights, gain, objective, params.Power)
+	result.Iterations = iterations
+	result.Converged = converged && result.Converged
 
+	logger.Info("Beamforming optimization completed",
+		zap.Int("iterations", iterations),
+		zap.Bool("converged", result.Converged),
+		zap.Float64("main_lobe_dir", result.MainLobeDirection),
+		zap.Float64("sll_db", 20*math.Log10(result.SLL)),
+		zap.String("objective", string(objective)),
+		zap.Float64("energy_efficiency", result.EnergyEfficiency),
+	)
+
+	return result, nil
+}
+
+// nullSteer computes the weights in closed form, steering toward the target
+// with nulls at the interference angles, and reports how deep the nulls are.
+func (o *Optimizer) nullSteer(params *model.BeamformingParams, objective model.BeamformingObjective, target []complex128, interferers [][]complex128) (*model.BeamformingResult, error) {
+	weights, err := nullSteeringWeights(target, interferers, params.InterferenceINR)
+	if err != nil {
+		return nil, err
+	}
+	o.normalizeWeights(weights)
+
+	result := o.evaluate(weights, beamformingGain(weights, target), objective, params.Power)
+	result.Iterations = 1
+	result.Converged = result.Converged && o.checkConvergence(weights, params.TargetDirection, params.SNRThreshold)
+	result.NullDepths = nullDepths(weights, target, interferers)
+
+	method := "lcmv"
+	if params.InterferenceINR != nil {
+		method = "mvdr"
+	}
+	logger.Info("Null-steering beamforming completed",
+		zap.String("method", method),
+		zap.Int("interferers", len(interferers)),
+		zap.Float64("main_lobe_dir", result.MainLobeDirection),
+		zap.Float64s("null_depths_db", result.NullDepths),
+		zap.String("objective", string(objective)),
+	)
+	return result, nil
+}
+
+// EigenBeamform uses the dominant eigenvectors of a measured covariance as
+// weights. The first maximizes the received SNR over all weight vectors,
+// without needing to know where the energy comes from.
+func (o *Optimizer) EigenBeamform(R [][]complex128, params *model.BeamformingParams) (*model.BeamformingResult, error) {
+	objective, err := objectiveOf(params)
+	if err != nil {
+		return nil, err
+	}
+	numBeams := params.NumBeams
+	if numBeams == 0 {
+		numBeams = 1
+	}
+	if numBeams < 0 || numBeams > len(R) {
+		return nil, model.NewValidationErrorf("num_beams must be between 1 and %d", len(R))
+	}
+
+	eigenvalues, beams, err := dominantEigenvectors(R, numBeams)
+	if err != nil {
+		return nil, err
+	}
+	var trace float64
+	for i := range R {
+		trace += real(R[i][i])
+	}
+	if trace <= 0 {
+		return nil, ErrSingularCovariance
+	}
+
+	// array gain of the principal eigenbeam over a single element
+	gain := eigenvalues[0] * float64(len(R)) / trace
+	result := o.evaluate(beams[0], gain, objective, params.Power)
+	result.Iterations = 1
+	result.Eigenvalues = eigenvalues
+	if numBeams > 1 {
+		result.Beams = make([][][]float64, len(beams))
+		for i, beam := range beams {
+			result.Beams[i] = serializeWeights(beam)
+		}
+	}
+
+	logger.Info("Eigen-beamforming completed",
+		zap.Int("element_count", len(R)),
+		zap.Int("beams", numBeams),
+		zap.Float64("main_lobe_dir", result.MainLobeDirection),
+		zap.Float64("array_gain", gain),
+		zap.String("objective", string(objective)),
+	)
+	return result, nil
+}
+
+func objectiveOf(params *model.BeamformingParams) (model.BeamformingObjective, error) {
+	objective := params.Objective
+	if objective == "" {
+		objective = model.BeamformingObjectiveSpectral
+	}
+	if objective != model.BeamformingObjectiveSpectral && objective != model.BeamformingObjectiveEnergy {
+		return "", model.NewValidationErrorf("unsupported beamforming objective: %s", objective)
+	}
+	return objective, nil
+}
+
+// evaluate fills in the beam pattern and, for the given array gain, the
+// transmit power and efficiency figures of a weight vector.
+func (o *Optimizer) evaluate(weights []complex128, gain float64, objective model.BeamformingObjective, power *model.PowerParams) *model.BeamformingResult {
+	beamPattern := o.computeBeamPattern(weights, 360)
 	mainLobeDir, mainLobeWidth, sll := o.analyzeBeamPattern(beamPattern)
 
-	weightsSerializable := make([][]float64, len(weights))
-	for i, w := range weights {
-		weightsSerializable[i] = []float64{real(w), imag(w)}
+	powerModel := NewPowerModel(power)
+	txPower := powerModel.MaxTransmitPower()
+	converged := true
+	if objective == model.BeamformingObjectiveEnergy {
+		txPower, _, converged = powerModel.OptimizeTransmitPower(gain, o.maxIterations, o.convergenceThreshold)
 	}
 
-	result := &model.BeamformingResult{
-		Weights:           weightsSerializable,
+	return &model.BeamformingResult{
+		Weights:           serializeWeights(weights),
 		BeamPattern:       beamPattern,
 		MainLobeDirection: mainLobeDir,
 		MainLobeWidth:     mainLobeWidth,
 		SLL:               sll,
-		Iterations:        iterations,
 		Converged:         converged,
-	}
 
-	logger.Info("Beamforming optimization completed",
-		zap.Int("iterations", iterations),
-		zap.Bool("converged", converged),
-		zap.Float64("main_lobe_dir", mainLobeDir),
-		zap.Float64("sll_db", 20*math.Log10(sll)),
-	)
+		Objective:          objective,
+		TransmitPower:      txPower,
+		TotalPower:         powerModel.TotalPower(txPower),
+		SpectralEfficiency: powerModel.SpectralEfficiency(txPower, gain),
+		EnergyEfficiency:   powerModel.EnergyEfficiency(txPower, gain),
+	}
+}
 
-	return result, nil
+func serializeWeights(weights []complex128) [][]float64 {
+	out := make([][]float64, len(weights))
+	for i, w := range weights {
+		out[i] = []float64{real(w), imag(w)}
+	}
+	return out
 }
 
 func (o *Optimizer) initializeWeights(elementCount int) []complex128 {
 	weights := make([]complex128, elementCount)
-	for i := range weights {
-		phase := -2 * math.Pi * float64(i) * 0.5
-		weights[i] = cmplx.Exp(complex(0, phase))
+	for i, a := range o.arrayFor(elementCount).SteeringVector(math.Pi / 2) {
+		weights[i] = cmplx.Exp(complex(0, -cmplx.Phase(a)))
 	}
 	o.normalizeWeights(weights)
 	return weights
 }
 
 func (o *Optimizer) computeSteeringVector(elementCount int, angle float64) []complex128 {
-	steering := make([]complex128, elementCount)
-	d := 0.5
-	for n := 0; n < elementCount; n++ {
-		phase := 2 * math.Pi * float64(n) * d * math.Sin(angle)
-		steering[n] = cmplx.Exp(complex(0, phase))
-	}
-	return steering
+	return o.arrayFor(elementCount).SteeringVector(angle)
 }
 
 func (o *Optimizer) normalizeWeights(weights []complex128) {
@@ -142,15 +277,15 @@ func (o *Optimizer) checkConvergence(weights []complex128, targetAngle, snrThres
 
 func (o *Optimizer) computeBeamPattern(weights []complex128, numPoints int) []float64 {
 	pattern := make([]float64, numPoints)
-	d := 0.5
+	geometry := o.arrayFor(len(weights))
 
 	for i := 0; i < numPoints; i++ {
 		angle := -math.Pi/2 + float64(i)*math.Pi/float64(numPoints)
 
+		steering := geometry.SteeringVector(angle)
 		var response complex128
 		for n, w := range weights {
-			phase := 2 * math.Pi * float64(n) * d * math.Sin(angle)
-			response += w * cmplx.Exp(complex(0, -phase))
+			response += w * cmplx.Conj(steering[n])
 		}
 
 		pattern[i] = cmplx.Abs(response)
@@ -202,13 +337,13 @@ func (o *Optimizer) analyzeBeamPattern(pattern []float64) (mainLobeDir, mainLobe
 
 func (o *Optimizer) ComputeArrayFactor(weights []complex128, angles []float64) []float64 {
 	af := make([]float64, len(angles))
-	d := 0.5
+	geometry := o.arrayFor(len(weights))
 
 	for i, angle := range angles {
+		steering := geometry.SteeringVector(angle)
 		var response complex128
 		for n, w := range weights {
-			phase := 2 * math.Pi * float64(n) * d * math.Sin(angle)
-			response += w * cmplx.Exp(complex(0, -phase))
+			response += w * cmplx.Conj(steering[n])
 		}
 		af[i] = cmplx.Abs(response)
 	}
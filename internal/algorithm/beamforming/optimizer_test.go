@@ -2,6 +2,7 @@ package beamforming
 
 import (
 	"math"
+	"math/cmplx"
 	"testing"
 
 	"isac-cran-system/internal/model"
@@ -36,6 +37,65 @@ func TestOptimizer_Optimize(t *testing.T) {
 	}
 }
 
+func TestOptimizer_EnergyEfficiencyObjective(t *testing.T) {
+	optimizer := NewOptimizer(16, 100, 0.001)
+
+	power := &model.PowerParams{
+		MaxTransmitPower: 10,
+		CircuitPower:     0.5,
+		IRSElementCount:  64,
+		IRSElementPower:  0.01,
+	}
+
+	seResult, err := optimizer.Optimize(&model.BeamformingParams{
+		ElementCount:    16,
+		TargetDirection: 0.3,
+		SNRThreshold:    0.9,
+		Power:           power,
+	})
+	if err != nil {
+		t.Fatalf("Optimize failed: %v", err)
+	}
+
+	eeResult, err := optimizer.Optimize(&model.BeamformingParams{
+		ElementCount:    16,
+		TargetDirection: 0.3,
+		SNRThreshold:    0.9,
+		Objective:       model.BeamformingObjectiveEnergy,
+		Power:           power,
+	})
+	if err != nil {
+		t.Fatalf("Optimize failed: %v", err)
+	}
+
+	if seResult.TransmitPower != power.MaxTransmitPower {
+		t.Errorf("Expected spectral objective to use max power, got %f", seResult.TransmitPower)
+	}
+	if eeResult.TransmitPower > power.MaxTransmitPower {
+		t.Errorf("Transmit power %f exceeds budget", eeResult.TransmitPower)
+	}
+	if eeResult.EnergyEfficiency < seResult.EnergyEfficiency {
+		t.Errorf("Expected EE objective to improve energy efficiency: %f < %f",
+			eeResult.EnergyEfficiency, seResult.EnergyEfficiency)
+	}
+	if eeResult.SpectralEfficiency > seResult.SpectralEfficiency {
+		t.Errorf("Expected EE objective to trade spectral efficiency: %f > %f",
+			eeResult.SpectralEfficiency, seResult.SpectralEfficiency)
+	}
+}
+
+func TestOptimizer_UnsupportedObjective(t *testing.T) {
+	optimizer := NewOptimizer(16, 100, 0.001)
+
+	_, err := optimizer.Optimize(&model.BeamformingParams{
+		ElementCount: 16,
+		Objective:    "throughput",
+	})
+	if !model.IsValidationError(err) {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+}
+
 func TestOptimizer_ComputeArrayFactor(t *testing.T) {
 	optimizer := NewOptimizer(64, 100, 0.001)
 
@@ -125,3 +185,99 @@ func BenchmarkOptimizer_Optimize(b *testing.B) {
 		_, _ = optimizer.Optimize(params)
 	}
 }
+
+func TestOptimizer_EigenBeamform(t *testing.T) {
+	optimizer := NewOptimizer(16, 100, 0.001)
+	a1 := optimizer.computeSteeringVector(16, 0.4)
+	a2 := optimizer.computeSteeringVector(16, -0.7)
+
+	R := make([][]complex128, 16)
+	for i := range R {
+		R[i] = make([]complex128, 16)
+		for j := range R[i] {
+			R[i][j] = 4*a1[i]*cmplx.Conj(a1[j]) + a2[i]*cmplx.Conj(a2[j])
+		}
+		R[i][i] += 0.1
+	}
+
+	result, err := optimizer.EigenBeamform(R, &model.BeamformingParams{NumBeams: 2})
+	if err != nil {
+		t.Fatalf("EigenBeamform failed: %v", err)
+	}
+	if math.Abs(result.MainLobeDirection-0.4) > 0.02 {
+		t.Errorf("main lobe at %.3f rad, want the stronger source at 0.4", result.MainLobeDirection)
+	}
+	if len(result.Eigenvalues) != 16 || result.Eigenvalues[0] < result.Eigenvalues[1] || result.Eigenvalues[1] < result.Eigenvalues[2] {
+		t.Errorf("eigenvalues = %v, want 16 in descending order", result.Eigenvalues)
+	}
+	if len(result.Beams) != 2 {
+		t.Fatalf("Expected 2 beams, got %d", len(result.Beams))
+	}
+	var inner complex128
+	for i := range result.Beams[0] {
+		b0 := complex(result.Beams[0][i][0], result.Beams[0][i][1])
+		b1 := complex(result.Beams[1][i][0], result.Beams[1][i][1])
+		inner += cmplx.Conj(b0) * b1
+	}
+	if cmplx.Abs(inner) > 1e-9 {
+		t.Errorf("beams not orthogonal, inner product %v", inner)
+	}
+
+	if _, err := optimizer.EigenBeamform(R, &model.BeamformingParams{NumBeams: 17}); !model.IsValidationError(err) {
+		t.Errorf("EigenBeamform with too many beams error = %v, want validation error", err)
+	}
+}
+
+func TestOptimizer_NullSteering(t *testing.T) {
+	optimizer := NewOptimizer(16, 100, 0.001)
+	params := &model.BeamformingParams{
+		ElementCount:       16,
+		TargetDirection:    0.3,
+		InterferenceAngles: []float64{-0.4, 0.7},
+		SNRThreshold:       0.9,
+	}
+
+	result, err := optimizer.Optimize(params)
+	if err != nil {
+		t.Fatalf("Optimize failed: %v", err)
+	}
+	if len(result.NullDepths) != 2 || result.NullDepths[0] > -200 || result.NullDepths[1] > -200 {
+		t.Errorf("LCMV null depths %v dB, want exact nulls", result.NullDepths)
+	}
+	if math.Abs(result.MainLobeDirection-0.3) > 0.03 {
+		t.Errorf("main lobe at %.3f rad, want the target at 0.3", result.MainLobeDirection)
+	}
+	if err := result.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	weak, strong := 10.0, 40.0
+	params.InterferenceINR = &weak
+	shallow, err := optimizer.Optimize(params)
+	if err != nil {
+		t.Fatalf("Optimize failed: %v", err)
+	}
+	params.InterferenceINR = &strong
+	deep, err := optimizer.Optimize(params)
+	if err != nil {
+		t.Fatalf("Optimize failed: %v", err)
+	}
+	for i := range deep.NullDepths {
+		if deep.NullDepths[i] > -40 || deep.NullDepths[i] >= shallow.NullDepths[i] {
+			t.Errorf("MVDR null %d: %.1f dB at 40 dB INR, %.1f dB at 10 dB, want deeper with the INR", i, deep.NullDepths[i], shallow.NullDepths[i])
+		}
+	}
+
+	params.InterferenceINR = nil
+	params.InterferenceAngles = []float64{0.3}
+	if _, err := optimizer.Optimize(params); !model.IsValidationError(err) {
+		t.Errorf("null at the target error = %v, want validation error", err)
+	}
+	params.InterferenceAngles = make([]float64, 16)
+	for i := range params.InterferenceAngles {
+		params.InterferenceAngles[i] = -1 + 0.1*float64(i)
+	}
+	if _, err := optimizer.Optimize(params); !model.IsValidationError(err) {
+		t.Errorf("as many nulls as elements error = %v, want validation error", err)
+	}
+}
@@ -0,0 +1,194 @@
+package beamforming
+
+import (
+	"math"
+	"math/cmplx"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// robustGrid is the number of diagonal loadings per decade the search for
+// the robust weights starts from, and robustDecades the decades below and
+// above the largest eigenvalue of the covariance it spans.
+const (
+	robustGrid    = 8
+	robustDecades = 8
+)
+
+// Robust beamforms for a channel that is only known up to an error: the
+// true channel h lies within ε = ErrorBound·‖ĥ‖ of the estimate ĥ. Over
+// that ball the least |wᴴh| is |wᴴĥ| − ε‖w‖, so the weights guarantee the
+// worst-case SINR
+//
+//	SINR_wc(w) = max(|wᴴĥ| − ε‖w‖, 0)² / wᴴRw
+//
+// against the interference-plus-noise covariance R = I + INR Σ aₖ aₖᴴ. The
+// weights maximizing it are diagonally loaded MVDR weights (R + μI)⁻¹ĥ
+// (Vorobyov et al., 2003; Lorenz and Boyd, 2005): μ = 0 is MVDR, which
+// trusts the estimate, and μ → ∞ the matched filter ĥ, which is hurt least
+// by an error of any direction. The loading is found by a search over a
+// logarithmic grid of μ, refined by golden-section search until the bracket
+// narrows below the convergence threshold, in decades, or the iteration
+// limit is reached.
+//
+// The spectral and energy efficiency are those of the worst-case channel,
+// without interference. When MaxDegradation is set and the worst-case SINR
+// falls further below the SINR MVDR would reach on an exact estimate, the
+// run fails.
+func (o *Optimizer) Robust(params *model.BeamformingParams) (*model.BeamformingResult, error) {
+	if err := params.ValidateRobust(); err != nil {
+		return nil, err
+	}
+	objective, err := objectiveOf(params)
+	if err != nil {
+		return nil, err
+	}
+	n := params.Elements()
+	robust := params.Robust
+
+	estimate := o.computeSteeringVector(n, params.TargetDirection)
+	if len(robust.Channel) > 0 {
+		for i, v := range robust.Channel {
+			estimate[i] = complex(v[0], v[1])
+		}
+	}
+	interferers := make([][]complex128, len(params.InterferenceAngles))
+	for i, angle := range params.InterferenceAngles {
+		interferers[i] = o.computeSteeringVector(n, angle)
+	}
+	inrDB := math.Inf(-1)
+	if params.InterferenceINR != nil {
+		inrDB = *params.InterferenceINR
+	}
+	R := interferenceCovariance(n, interferers, inrDB)
+	bound := robust.ErrorBound * math.Sqrt(squaredNorm(estimate))
+
+	logger.Info("Starting robust beamforming",
+		zap.Int("element_count", n),
+		zap.Float64("error_bound", robust.ErrorBound),
+		zap.Int("interferers", len(interferers)),
+	)
+
+	solver, err := newRegularizedSolver(R)
+	if err != nil {
+		return nil, err
+	}
+	projected := solver.project(estimate)
+	worstCase := func(mu float64) float64 {
+		return worstCaseSINR(solver.solve(projected, mu), estimate, R, bound)
+	}
+
+	// μ = 0 is MVDR, the grid spans the loadings that matter relative to R
+	scale := solver.values[len(solver.values)-1]
+	loadings := []float64{0}
+	for k := -robustDecades * robustGrid; k <= robustDecades*robustGrid; k++ {
+		loadings = append(loadings, scale*math.Pow(10, float64(k)/robustGrid))
+	}
+	best, bestSINR := 0, worstCase(0)
+	// an exact estimate needs no loading, MVDR maximizes its SINR
+	for i := 1; i < len(loadings) && bound > 0; i++ {
+		if value := worstCase(loadings[i]); value > bestSINR {
+			best, bestSINR = i, value
+		}
+	}
+	mu := loadings[best]
+	iterations, converged := 0, true
+	if best > 1 && best < len(loadings)-1 {
+		mu, bestSINR, iterations, converged = o.refineLoading(worstCase, math.Log10(loadings[best-1]), math.Log10(loadings[best+1]), mu, bestSINR)
+	}
+
+	weights := solver.solve(projected, mu)
+	mvdr := solver.solve(projected, 0)
+	result := &model.RobustBeamformingResult{
+		ErrorBound:        robust.ErrorBound,
+		DiagonalLoading:   mu,
+		OptimalSINR:       toDB(sinr(mvdr, estimate, R)),
+		NominalSINR:       toDB(sinr(weights, estimate, R)),
+		WorstCaseSINR:     toDB(bestSINR),
+		MVDRWorstCaseSINR: toDB(worstCaseSINR(mvdr, estimate, R, bound)),
+	}
+	result.Degradation = result.OptimalSINR - result.WorstCaseSINR
+	if limit := robust.MaxDegradation; limit != nil && result.Degradation > *limit {
+		return nil, model.NewValidationErrorf("an error bound of %g lets the worst-case SINR fall %.2f dB below the %.2f dB of an exact estimate, more than the max_degradation of %g dB",
+			robust.ErrorBound, result.Degradation, result.OptimalSINR, *limit)
+	}
+
+	o.normalizeWeights(weights)
+	gain := math.Pow(math.Max(cmplx.Abs(response(weights, estimate))-bound, 0), 2)
+	bf := o.evaluate(weights, gain, objective, params.Power)
+	bf.Iterations = iterations
+	bf.Converged = bf.Converged && converged
+	bf.Robust = result
+	if len(interferers) > 0 {
+		bf.NullDepths = nullDepths(weights, estimate, interferers)
+	}
+
+	logger.Info("Robust beamforming completed",
+		zap.Float64("diagonal_loading", mu),
+		zap.Float64("worst_case_sinr_db", result.WorstCaseSINR),
+		zap.Float64("mvdr_worst_case_sinr_db", result.MVDRWorstCaseSINR),
+		zap.Float64("degradation_db", result.Degradation),
+		zap.String("objective", string(objective)),
+	)
+	return bf, nil
+}
+
+// refineLoading narrows the bracket [lo, hi] of log10 μ around the best
+// loading so far by golden-section search, and returns the best loading,
+// its worst-case SINR, the steps taken and whether the bracket narrowed
+// below the convergence threshold.
+func (o *Optimizer) refineLoading(worstCase func(float64) float64, lo, hi, mu, best float64) (float64, float64, int, bool) {
+	ratio := (math.Sqrt(5) - 1) / 2
+	evaluate := func(x float64) float64 {
+		f := worstCase(math.Pow(10, x))
+		if f > best {
+			mu, best = math.Pow(10, x), f
+		}
+		return f
+	}
+	x1, x2 := hi-ratio*(hi-lo), lo+ratio*(hi-lo)
+	f1, f2 := evaluate(x1), evaluate(x2)
+	for iter := 1; iter <= o.maxIterations; iter++ {
+		if f1 > f2 {
+			hi, x2, f2 = x2, x1, f1
+			x1 = hi - ratio*(hi-lo)
+			f1 = evaluate(x1)
+		} else {
+			lo, x1, f1 = x1, x2, f2
+			x2 = lo + ratio*(hi-lo)
+			f2 = evaluate(x2)
+		}
+		if hi-lo < o.convergenceThreshold {
+			return mu, best, iter, true
+		}
+	}
+	return mu, best, o.maxIterations, false
+}
+
+// sinr returns |wᴴh|² / wᴴRw.
+func sinr(w, h []complex128, R [][]complex128) float64 {
+	return squaredAbs(response(w, h)) / quadraticForm(w, R)
+}
+
+// worstCaseSINR returns the least SINR of w over the channels within bound
+// of h, max(|wᴴh| − bound‖w‖, 0)² / wᴴRw.
+func worstCaseSINR(w, h []complex128, R [][]complex128, bound float64) float64 {
+	signal := math.Max(cmplx.Abs(response(w, h))-bound*math.Sqrt(squaredNorm(w)), 0)
+	return signal * signal / quadraticForm(w, R)
+}
+
+// quadraticForm returns wᴴRw for a Hermitian R.
+func quadraticForm(w []complex128, R [][]complex128) float64 {
+	var sum complex128
+	for i, row := range R {
+		var y complex128
+		for j, r := range row {
+			y += r * w[j]
+		}
+		sum += cmplx.Conj(w[i]) * y
+	}
+	return real(sum)
+}
@@ -0,0 +1,108 @@
+package beamforming
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"isac-cran-system/internal/model"
+)
+
+func TestOptimizer_Robust(t *testing.T) {
+	optimizer := NewOptimizer(8, 100, 1e-6)
+	inr := 10.0
+	params := &model.BeamformingParams{
+		ElementCount:       8,
+		Mode:               model.BeamformingModeRobust,
+		TargetDirection:    0.2,
+		InterferenceAngles: []float64{-0.6, 0.25},
+		InterferenceINR:    &inr,
+		Robust:             &model.RobustParams{ErrorBound: 0.3},
+	}
+
+	result, err := optimizer.Robust(params)
+	if err != nil {
+		t.Fatalf("Robust failed: %v", err)
+	}
+	if err := result.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	robust := result.Robust
+	if robust == nil || len(result.NullDepths) != 2 {
+		t.Fatalf("result = %+v, want the robust report and 2 null depths", result)
+	}
+	if robust.DiagonalLoading <= 0 {
+		t.Errorf("diagonal loading %g, want some loading against an error bound of 0.3", robust.DiagonalLoading)
+	}
+	if robust.WorstCaseSINR < robust.MVDRWorstCaseSINR+1 {
+		t.Errorf("worst-case SINR %.3f dB, not 1 dB better than MVDR's %.3f dB", robust.WorstCaseSINR, robust.MVDRWorstCaseSINR)
+	}
+
+	// no channel within the bound does worse than the guarantee
+	weights := make([]complex128, 8)
+	for i, w := range result.Weights {
+		weights[i] = complex(w[0], w[1])
+	}
+	estimate := optimizer.computeSteeringVector(8, params.TargetDirection)
+	interferers := [][]complex128{optimizer.computeSteeringVector(8, -0.6), optimizer.computeSteeringVector(8, 0.25)}
+	R := interferenceCovariance(8, interferers, inr)
+	bound := 0.3 * math.Sqrt(squaredNorm(estimate))
+	rng := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 1000; trial++ {
+		e := make([]complex128, 8)
+		for i := range e {
+			e[i] = complex(rng.NormFloat64(), rng.NormFloat64())
+		}
+		scale := complex(bound/math.Sqrt(squaredNorm(e)), 0)
+		h := make([]complex128, 8)
+		for i := range h {
+			h[i] = estimate[i] + scale*e[i]
+		}
+		if got := toDB(sinr(weights, h, R)); got < robust.WorstCaseSINR-1e-9 {
+			t.Fatalf("trial %d: SINR %.4f dB below the guaranteed %.4f dB", trial, got, robust.WorstCaseSINR)
+		}
+	}
+
+	// an exact estimate keeps the MVDR weights
+	params.Robust.ErrorBound = 0
+	exact, err := optimizer.Robust(params)
+	if err != nil {
+		t.Fatalf("Robust failed: %v", err)
+	}
+	if exact.Robust.DiagonalLoading != 0 || math.Abs(exact.Robust.Degradation) > 1e-9 {
+		t.Errorf("error bound 0: loading %g and degradation %g dB, want MVDR", exact.Robust.DiagonalLoading, exact.Robust.Degradation)
+	}
+
+	params.Robust.ErrorBound = 0.3
+	limit := robust.Degradation / 2
+	params.Robust.MaxDegradation = &limit
+	if _, err := optimizer.Robust(params); !model.IsValidationError(err) {
+		t.Errorf("degradation %.3f dB over a limit of %.3f dB: err = %v, want a validation error", robust.Degradation, limit, err)
+	}
+}
+
+func TestOptimizer_RobustChannel(t *testing.T) {
+	optimizer := NewOptimizer(4, 100, 1e-6)
+	params := &model.BeamformingParams{
+		Mode: model.BeamformingModeRobust,
+		Robust: &model.RobustParams{
+			Channel:    [][]float64{{1, 0}, {0, 1}, {-1, 0}, {0, -0.5}},
+			ErrorBound: 0.2,
+		},
+	}
+
+	result, err := optimizer.Robust(params)
+	if err != nil {
+		t.Fatalf("Robust failed: %v", err)
+	}
+	if len(result.Weights) != 4 {
+		t.Fatalf("got %d weights for a channel of 4 elements", len(result.Weights))
+	}
+	// without interference the matched filter is optimal whatever the
+	// error, and loses exactly the error bound of the amplitude
+	robust := result.Robust
+	want := 20 * math.Log10(1-0.2)
+	if got := robust.WorstCaseSINR - robust.OptimalSINR; math.Abs(got-want) > 1e-6 {
+		t.Errorf("worst case %.6f dB from optimal, want %.6f dB", got, want)
+	}
+}
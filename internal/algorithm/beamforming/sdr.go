@@ -0,0 +1,163 @@
+package beamforming
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+)
+
+// phaseRelaxation is the semidefinite relaxation (SDR) of the IRS phase
+// problem. With matched BS weights the SNR of phases θ is ρ‖Aθ̃‖², where the
+// columns of A are the reflected channels h_r[n]G[n] followed by the direct
+// channel h_d and θ̃ = (e^{jθ₁}, …, e^{jθ_N}, 1). Letting the last entry of
+// θ̃ take any unit value as well and dropping the rank of V = θ̃θ̃ᴴ leaves
+//
+//	max tr(RV)  over V ⪰ 0 with a unit diagonal,  R = AᴴA
+//
+// whose optimum bounds the SNR of any phases from above. It is solved in
+// the factored form V = UUᴴ with unit rows (Burer and Monteiro) by cyclic
+// coordinate ascent, which sets each row to the normalized R-weighted sum
+// of the others and never lowers tr(RV); with rank √(2(N+1)) or more the
+// factored problem has no spurious local optima.
+type phaseRelaxation struct {
+	R [][]complex128
+	U [][]complex128
+	// value is tr(RV), history the SNR of the relaxation in dB after each
+	// sweep over the rows
+	value     float64
+	history   []float64
+	converged bool
+}
+
+// relaxPhases solves the relaxation of ch from random unit rows, sweeping
+// until tr(RV) improves by less than the convergence threshold, relatively,
+// or the iteration limit is reached.
+func (o *Optimizer) relaxPhases(ch *cascadedChannel, rng *rand.Rand) *phaseRelaxation {
+	n := len(ch.bsIRS) + 1
+	columns := make([][]complex128, n)
+	for i, row := range ch.bsIRS {
+		columns[i] = make([]complex128, len(row))
+		for m, g := range row {
+			columns[i][m] = ch.irsUser[i] * g
+		}
+	}
+	columns[n-1] = ch.direct
+
+	r := &phaseRelaxation{R: make([][]complex128, n), U: make([][]complex128, n)}
+	for i := range r.R {
+		r.R[i] = make([]complex128, n)
+		for j := range r.R[i] {
+			r.R[i][j] = response(columns[i], columns[j])
+		}
+	}
+	rank := int(math.Ceil(math.Sqrt(2*float64(n)))) + 1
+	if rank > n {
+		rank = n
+	}
+	for i := range r.U {
+		r.U[i] = make([]complex128, rank)
+		for k := range r.U[i] {
+			r.U[i][k] = complex(rng.NormFloat64(), rng.NormFloat64())
+		}
+		normalize(r.U[i])
+	}
+
+	r.value = r.objective()
+	for iter := 0; iter < o.maxIterations; iter++ {
+		r.sweep()
+		next := r.objective()
+		r.history = append(r.history, toDB(ch.transmitSNR*next))
+		improved := next - r.value
+		r.value = next
+		if improved <= o.convergenceThreshold*next {
+			r.converged = true
+			break
+		}
+	}
+	if len(r.history) == 0 {
+		r.history = append(r.history, toDB(ch.transmitSNR*r.value))
+	}
+	return r
+}
+
+// sweep updates every row of U once.
+func (r *phaseRelaxation) sweep() {
+	g := make([]complex128, len(r.U[0]))
+	for i, row := range r.R {
+		for k := range g {
+			g[k] = 0
+		}
+		for j, rij := range row {
+			if j == i || rij == 0 {
+				continue
+			}
+			for k, u := range r.U[j] {
+				g[k] += rij * u
+			}
+		}
+		if squaredNorm(g) > 0 {
+			copy(r.U[i], g)
+			normalize(r.U[i])
+		}
+	}
+}
+
+// objective is tr(RV) = Σᵢⱼ R_ij ⟨u_i, u_j⟩.
+func (r *phaseRelaxation) objective() float64 {
+	var sum float64
+	for i, row := range r.R {
+		var inner complex128
+		for j, rij := range row {
+			inner += rij * response(r.U[i], r.U[j])
+		}
+		sum += real(inner)
+	}
+	return sum
+}
+
+// randomize recovers unit-modulus phases from the relaxation: the phases of
+// the rank-one approximation of V compete with count Gaussian candidates
+// x ~ CN(0, V), each of which is projected onto the unit circle and
+// referred to its last entry, and the phases with the highest SNR win.
+func (r *phaseRelaxation) randomize(ch *cascadedChannel, count int, rng *rand.Rand) []float64 {
+	n := len(r.U) - 1
+	reference := r.U[n]
+	best := make([]float64, n)
+	for i := range best {
+		best[i] = cmplx.Phase(response(reference, r.U[i]))
+	}
+	bestSNR := ch.gain(best)
+
+	candidate := make([]float64, n)
+	z := make([]complex128, len(reference))
+	for l := 0; l < count; l++ {
+		for k := range z {
+			z[k] = complex(rng.NormFloat64(), rng.NormFloat64())
+		}
+		last := cmplx.Phase(dot(reference, z))
+		for i := range candidate {
+			candidate[i] = cmplx.Phase(dot(r.U[i], z)) - last
+		}
+		if snr := ch.gain(candidate); snr > bestSNR {
+			bestSNR = snr
+			copy(best, candidate)
+		}
+	}
+	return best
+}
+
+// dot is uᵀz, without conjugation.
+func dot(u, z []complex128) complex128 {
+	var sum complex128
+	for k := range u {
+		sum += u[k] * z[k]
+	}
+	return sum
+}
+
+func normalize(v []complex128) {
+	norm := complex(math.Sqrt(squaredNorm(v)), 0)
+	for k := range v {
+		v[k] /= norm
+	}
+}
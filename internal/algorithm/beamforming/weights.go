@@ -3,25 +3,35 @@ package beamforming
 import (
 	"math"
 	"math/cmplx"
+
+	"isac-cran-system/internal/algorithm/array"
+	"isac-cran-system/internal/algorithm/covariance"
+	"isac-cran-system/internal/model"
+
+	"gonum.org/v1/gonum/blas/cblas128"
 )
 
 type WeightsCalculator struct {
-	elementCount   int
-	elementSpacing float64
+	elementCount int
+	geometry     *array.Geometry
 }
 
+// NewWeightsCalculator computes weights for a uniform linear array.
 func NewWeightsCalculator(elementCount int, elementSpacing float64) *WeightsCalculator {
+	return NewGeometryWeightsCalculator(array.NewULA(elementCount, elementSpacing))
+}
+
+func NewGeometryWeightsCalculator(g *array.Geometry) *WeightsCalculator {
 	return &WeightsCalculator{
-		elementCount:   elementCount,
-		elementSpacing: elementSpacing,
+		elementCount: g.Len(),
+		geometry:     g,
 	}
 }
 
 func (w *WeightsCalculator) ComputeConjugateBeamforming(targetAngle float64) []complex128 {
 	weights := make([]complex128, w.elementCount)
-	for n := 0; n < w.elementCount; n++ {
-		phase := 2 * math.Pi * float64(n) * w.elementSpacing * math.Sin(targetAngle)
-		weights[n] = cmplx.Exp(complex(0, -phase))
+	for n, a := range w.geometry.SteeringVector(targetAngle) {
+		weights[n] = cmplx.Conj(a)
 	}
 	w.normalize(weights)
 	return weights
@@ -29,40 +39,41 @@ func (w *WeightsCalculator) ComputeConjugateBeamforming(targetAngle float64) []c
 
 func (w *WeightsCalculator) ComputeZOFBeamforming(targetAngles []float64) []complex128 {
 	weights := make([]complex128, w.elementCount)
-	for n := 0; n < w.elementCount; n++ {
-		var phase complex128
-		for _, angle := range targetAngles {
-			phase += cmplx.Exp(complex(0, 2*math.Pi*float64(n)*w.elementSpacing*math.Sin(angle)))
+	for _, angle := range targetAngles {
+		for n, a := range w.geometry.SteeringVector(angle) {
+			weights[n] += cmplx.Conj(a)
 		}
-		weights[n] = cmplx.Conj(phase)
 	}
 	w.normalize(weights)
 	return weights
 }
 
+// ComputeMVDRWeights returns R⁻¹a / (aᴴR⁻¹a), solving R x = a instead of
+// forming the inverse. It returns nil if the covariance is singular.
 func (w *WeightsCalculator) ComputeMVDRWeights(covMatrix [][]complex128, steeringVector []complex128) []complex128 {
-	n := len(steeringVector)
-	invCov := w.matrixInverse(covMatrix)
-
-	weights := make([]complex128, n)
-	for i := 0; i < n; i++ {
-		var sum complex128
-		for j := 0; j < n; j++ {
-			sum += invCov[i][j] * steeringVector[j]
-		}
-		weights[i] = sum
+	weights, err := solveHermitian(covMatrix, steeringVector)
+	if err != nil {
+		return nil
 	}
 
-	var denom complex128
-	for i := 0; i < n; i++ {
-		denom += cmplx.Conj(steeringVector[i]) * weights[i]
-	}
+	denom := cblas128.Dotc(vector(steeringVector), vector(weights))
+	cblas128.Scal(1/denom, vector(weights))
+	return weights
+}
 
-	for i := range weights {
-		weights[i] /= denom
+// ComputeMVDRWeightsFromSnapshots estimates the covariance of X with opts
+// before solving for MVDR weights; regularization keeps the inverse stable
+// when there are few snapshots.
+func (w *WeightsCalculator) ComputeMVDRWeightsFromSnapshots(X [][]complex128, steeringVector []complex128, opts model.CovarianceOptions) ([]complex128, error) {
+	covMatrix, err := covariance.Estimate(X, opts)
+	if err != nil {
+		return nil, err
 	}
-
-	return weights
+	weights := w.ComputeMVDRWeights(covMatrix, steeringVector)
+	if weights == nil {
+		return nil, ErrSingularCovariance
+	}
+	return weights, nil
 }
 
 func (w *WeightsCalculator) normalize(weights []complex128) {
@@ -76,43 +87,6 @@ func (w *WeightsCalculator) normalize(weights []complex128) {
 	}
 }
 
-func (w *WeightsCalculator) matrixInverse(matrix [][]complex128) [][]complex128 {
-	n := len(matrix)
-	aug := make([][]complex128, n)
-	for i := range aug {
-		aug[i] = make([]complex128, 2*n)
-		for j := 0; j < n; j++ {
-			aug[i][j] = matrix[i][j]
-		}
-		aug[i][n+i] = 1
-	}
-
-	for i := 0; i < n; i++ {
-		pivot := aug[i][i]
-		for j := 0; j < 2*n; j++ {
-			aug[i][j] /= pivot
-		}
-		for k := 0; k < n; k++ {
-			if k != i {
-				factor := aug[k][i]
-				for j := 0; j < 2*n; j++ {
-					aug[k][j] -= factor * aug[i][j]
-				}
-			}
-		}
-	}
-
-	inv := make([][]complex128, n)
-	for i := range inv {
-		inv[i] = make([]complex128, n)
-		for j := 0; j < n; j++ {
-			inv[i][j] = aug[i][n+j]
-		}
-	}
-
-	return inv
-}
-
 func (w *WeightsCalculator) ComputePhaseShifts(weights []complex128) []float64 {
 	phases := make([]float64, len(weights))
 	for i, weight := range weights {
@@ -0,0 +1,134 @@
+package beamforming
+
+import (
+	"fmt"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+// testCovariance returns a diagonally loaded sample covariance of random
+// snapshots, like the covariance MVDR is normally given.
+func testCovariance(n int) [][]complex128 {
+	rng := rand.New(rand.NewSource(int64(n)))
+	snapshots := 2 * n
+	X := make([][]complex128, n)
+	for i := range X {
+		X[i] = make([]complex128, snapshots)
+		for k := range X[i] {
+			X[i][k] = complex(rng.NormFloat64(), rng.NormFloat64())
+		}
+	}
+
+	R := make([][]complex128, n)
+	for i := range R {
+		R[i] = make([]complex128, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			var sum complex128
+			for k := 0; k < snapshots; k++ {
+				sum += X[i][k] * cmplx.Conj(X[j][k])
+			}
+			R[i][j] = sum / complex(float64(snapshots), 0)
+			R[j][i] = cmplx.Conj(R[i][j])
+		}
+		R[i][i] += 0.01
+	}
+	return R
+}
+
+// gaussJordanMVDR is the previous implementation: an explicit Gauss-Jordan
+// inverse followed by a matrix-vector product. It is kept as the benchmark
+// baseline.
+func gaussJordanMVDR(R [][]complex128, a []complex128) []complex128 {
+	n := len(a)
+	aug := make([][]complex128, n)
+	for i := range aug {
+		aug[i] = make([]complex128, 2*n)
+		copy(aug[i], R[i])
+		aug[i][n+i] = 1
+	}
+	for i := 0; i < n; i++ {
+		pivot := aug[i][i]
+		for j := range aug[i] {
+			aug[i][j] /= pivot
+		}
+		for k := 0; k < n; k++ {
+			if k != i {
+				factor := aug[k][i]
+				for j := range aug[k] {
+					aug[k][j] -= factor * aug[i][j]
+				}
+			}
+		}
+	}
+
+	weights := make([]complex128, n)
+	var denom complex128
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			weights[i] += aug[i][n+j] * a[j]
+		}
+	}
+	for i := range weights {
+		denom += cmplx.Conj(a[i]) * weights[i]
+	}
+	for i := range weights {
+		weights[i] /= denom
+	}
+	return weights
+}
+
+func TestWeightsCalculator_ComputeMVDRWeights(t *testing.T) {
+	calc := NewWeightsCalculator(16, 0.5)
+	R := testCovariance(16)
+	a := calc.geometry.SteeringVector(0.3)
+
+	weights := calc.ComputeMVDRWeights(R, a)
+	if len(weights) != 16 {
+		t.Fatalf("Expected 16 weights, got %d", len(weights))
+	}
+
+	var response complex128
+	for i := range a {
+		response += cmplx.Conj(weights[i]) * a[i]
+	}
+	if cmplx.Abs(response-1) > 1e-9 {
+		t.Errorf("Expected distortionless response 1, got %v", response)
+	}
+
+	want := gaussJordanMVDR(R, a)
+	for i := range want {
+		if cmplx.Abs(weights[i]-want[i]) > 1e-9 {
+			t.Fatalf("weight %d = %v, want %v", i, weights[i], want[i])
+		}
+	}
+
+	singular := make([][]complex128, 16)
+	for i := range singular {
+		singular[i] = make([]complex128, 16)
+	}
+	if weights := calc.ComputeMVDRWeights(singular, a); weights != nil {
+		t.Errorf("Expected nil weights for a singular covariance, got %v", weights[:2])
+	}
+}
+
+func BenchmarkMVDRWeights(b *testing.B) {
+	for _, n := range []int{128, 256, 512, 1024} {
+		calc := NewWeightsCalculator(n, 0.5)
+		R := testCovariance(n)
+		a := calc.geometry.SteeringVector(0.3)
+
+		b.Run(fmt.Sprintf("gonum/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				calc.ComputeMVDRWeights(R, a)
+			}
+		})
+		b.Run(fmt.Sprintf("gauss_jordan/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				gaussJordanMVDR(R, a)
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+package beamforming
+
+import (
+	"math"
+	"math/cmplx"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+	"gonum.org/v1/gonum/mat"
+)
+
+// WMMSE computes one beamformer per user that together maximize the
+// weighted sum rate Σ α_k log2(1 + SINR_k) under the transmit power P of
+// params.Power. The problem is not convex; WMMSE (Shi et al., 2011) solves
+// the equivalent weighted MSE problem by block coordinate descent, which
+// never lowers the sum rate:
+//
+//	u_k = h_kᴴv_k / (Σ_j |h_kᴴv_j|² + σ²)           receive filter
+//	w_k = 1 / (1 - u_k* h_kᴴv_k)                      MSE weight
+//	v_k = α_k w_k u_k (Σ_j α_j w_j |u_j|² h_j h_jᴴ + μI)⁻¹ h_k
+//
+// with μ ≥ 0 the smallest multiplier that keeps Σ‖v_k‖² within P. The
+// beamformers start as matched filters sharing the power equally.
+func (o *Optimizer) WMMSE(params *model.BeamformingParams) (*model.BeamformingResult, error) {
+	if params.ElementCount < 1 {
+		return nil, &model.ValidationError{Field: "element_count", Message: "must be at least 1"}
+	}
+	if err := params.ValidateUsers(); err != nil {
+		return nil, err
+	}
+	objective, err := objectiveOf(params)
+	if err != nil {
+		return nil, err
+	}
+	if objective != model.BeamformingObjectiveSpectral {
+		return nil, &model.ValidationError{Field: "objective", Message: "wmmse maximizes the sum rate, use spectral_efficiency"}
+	}
+	maxIterations := params.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = o.maxIterations
+	}
+
+	logger.Info("Starting WMMSE beamforming",
+		zap.Int("element_count", params.ElementCount),
+		zap.Int("users", len(params.Users)),
+	)
+
+	powerModel := NewPowerModel(params.Power)
+	budget, noise := powerModel.MaxTransmitPower(), powerModel.noisePower
+	channels := make([][]complex128, len(params.Users))
+	priorities := make([]float64, len(params.Users))
+	beams := make([][]complex128, len(params.Users))
+	for k, user := range params.Users {
+		gain := complex(math.Pow(10, -user.PathLoss/20), 0)
+		channels[k] = o.computeSteeringVector(params.ElementCount, user.Angle)
+		for n := range channels[k] {
+			channels[k][n] *= gain
+		}
+		priorities[k] = user.Weight
+		if priorities[k] == 0 {
+			priorities[k] = 1
+		}
+		beams[k] = append([]complex128(nil), channels[k]...)
+		o.normalizeWeights(beams[k])
+		scale := complex(math.Sqrt(budget/float64(len(beams))), 0)
+		for n := range beams[k] {
+			beams[k][n] *= scale
+		}
+	}
+
+	var history []float64
+	var converged bool
+	rate := weightedSumRate(channels, beams, priorities, noise)
+	for iter := 0; iter < maxIterations; iter++ {
+		beams, err = wmmseUpdate(channels, beams, priorities, noise, budget)
+		if err != nil {
+			return nil, err
+		}
+		previous := rate
+		rate = weightedSumRate(channels, beams, priorities, noise)
+		history = append(history, rate)
+		if math.Abs(rate-previous) < o.convergenceThreshold {
+			converged = true
+			break
+		}
+	}
+
+	result := o.wmmseResult(params, channels, beams, noise, powerModel)
+	result.SumRate = rate
+	result.RateHistory = history
+	result.Iterations = len(history)
+	result.Converged = converged
+
+	logger.Info("WMMSE beamforming completed",
+		zap.Int("iterations", result.Iterations),
+		zap.Bool("converged", converged),
+		zap.Float64("sum_rate", rate),
+		zap.Float64("transmit_power", result.TransmitPower),
+	)
+	return result, nil
+}
+
+// wmmseUpdate performs one round of receive filter, MSE weight and
+// beamformer updates.
+func wmmseUpdate(channels, beams [][]complex128, priorities []float64, noise, budget float64) ([][]complex128, error) {
+	n := len(channels[0])
+	A := make([][]complex128, n)
+	for i := range A {
+		A[i] = make([]complex128, n)
+	}
+	rhs := make([][]complex128, len(channels))
+	for k, h := range channels {
+		signal := response(h, beams[k])
+		received := noise
+		for _, v := range beams {
+			received += squaredAbs(response(h, v))
+		}
+		u := signal / complex(received, 0)
+		mse := 1 - real(cmplx.Conj(u)*signal)
+		w := 1 / math.Max(mse, minMSE)
+
+		c := priorities[k] * w * squaredAbs(u)
+		for i := range A {
+			for j := range A[i] {
+				A[i][j] += complex(c, 0) * h[i] * cmplx.Conj(h[j])
+			}
+		}
+		rhs[k] = make([]complex128, n)
+		for i := range rhs[k] {
+			rhs[k][i] = complex(priorities[k]*w, 0) * u * h[i]
+		}
+	}
+
+	solver, err := newRegularizedSolver(A)
+	if err != nil {
+		return nil, err
+	}
+	projected := make([][]float64, len(rhs))
+	for k, b := range rhs {
+		projected[k] = solver.project(b)
+	}
+	mu := solver.multiplier(projected, budget)
+	updated := make([][]complex128, len(rhs))
+	for k, c := range projected {
+		updated[k] = solver.solve(c, mu)
+	}
+	return updated, nil
+}
+
+// minMSE keeps the MSE weight finite for a user served without error.
+const minMSE = 1e-12
+
+// weightedSumRate is Σ α_k log2(1 + SINR_k) in bit/s/Hz.
+func weightedSumRate(channels, beams [][]complex128, priorities []float64, noise float64) float64 {
+	var rate float64
+	for k, sinr := range sinrs(channels, beams, noise) {
+		rate += priorities[k] * math.Log2(1+sinr)
+	}
+	return rate
+}
+
+// sinrs is the SINR of each user, treating the other users' beams as
+// interference.
+func sinrs(channels, beams [][]complex128, noise float64) []float64 {
+	out := make([]float64, len(channels))
+	for k, h := range channels {
+		interference := noise
+		for j, v := range beams {
+			if j != k {
+				interference += squaredAbs(response(h, v))
+			}
+		}
+		out[k] = squaredAbs(response(h, beams[k])) / interference
+	}
+	return out
+}
+
+// response is hᴴv, what a user with channel h receives of beam v.
+func response(h, v []complex128) complex128 {
+	var sum complex128
+	for n := range h {
+		sum += cmplx.Conj(h[n]) * v[n]
+	}
+	return sum
+}
+
+func squaredAbs(x complex128) float64 {
+	return real(x)*real(x) + imag(x)*imag(x)
+}
+
+// wmmseResult reports the beamformers. The overall weights and pattern are
+// those of the first user's beam.
+func (o *Optimizer) wmmseResult(params *model.BeamformingParams, channels, beams [][]complex128, noise float64, powerModel *PowerModel) *model.BeamformingResult {
+	users := make([]model.BeamformingUserResult, len(beams))
+	unit := make([][][]float64, len(beams))
+	var first []complex128
+	var txPower, sumRate float64
+	for k, sinr := range sinrs(channels, beams, noise) {
+		power := squaredNorm(beams[k])
+		txPower += power
+		rate := math.Log2(1 + sinr)
+		sumRate += rate
+		users[k] = model.BeamformingUserResult{
+			Angle:   params.Users[k].Angle,
+			Weights: serializeWeights(beams[k]),
+			Power:   power,
+			SINR:    toDB(sinr),
+			Rate:    rate,
+		}
+		beam := append([]complex128(nil), beams[k]...)
+		if power > 0 {
+			o.normalizeWeights(beam)
+		}
+		if k == 0 {
+			first = beam
+		}
+		unit[k] = serializeWeights(beam)
+	}
+
+	beamPattern := o.computeBeamPattern(first, 360)
+	mainLobeDir, mainLobeWidth, sll := o.analyzeBeamPattern(beamPattern)
+	result := &model.BeamformingResult{
+		Weights:           unit[0],
+		BeamPattern:       beamPattern,
+		MainLobeDirection: mainLobeDir,
+		MainLobeWidth:     mainLobeWidth,
+		SLL:               sll,
+
+		Objective:          model.BeamformingObjectiveSpectral,
+		TransmitPower:      txPower,
+		TotalPower:         powerModel.TotalPower(txPower),
+		SpectralEfficiency: sumRate,
+		EnergyEfficiency:   powerModel.efficiency(txPower, sumRate),
+		Users:              users,
+	}
+	if len(beams) > 1 {
+		result.Beams = unit
+	}
+	return result
+}
+
+// regularizedSolver solves (A + μI)x = b for a Hermitian positive
+// semidefinite A and any μ ≥ 0 from one eigendecomposition of A's real
+// embedding, so that μ can be searched for cheaply. Directions outside the
+// range of A are dropped, which makes μ = 0 the pseudo-inverse.
+type regularizedSolver struct {
+	n       int
+	values  []float64
+	vectors mat.Dense
+	cutoff  float64
+}
+
+func newRegularizedSolver(A [][]complex128) (*regularizedSolver, error) {
+	n := len(A)
+	var eig mat.EigenSym
+	if !eig.Factorize(mat.NewSymDense(2*n, realEmbedding(A)), true) {
+		return nil, ErrEigenDecomposition
+	}
+	s := &regularizedSolver{n: n, values: eig.Values(nil)}
+	eig.VectorsTo(&s.vectors)
+	// values are ascending
+	s.cutoff = 1e-12 * math.Abs(s.values[len(s.values)-1])
+	return s, nil
+}
+
+// project returns the coordinates of b in the eigenbasis, zero outside the
+// range of A.
+func (s *regularizedSolver) project(b []complex128) []float64 {
+	c := make([]float64, 2*s.n)
+	for j := range c {
+		if s.values[j] <= s.cutoff {
+			continue
+		}
+		for i, x := range b {
+			c[j] += s.vectors.At(i, j)*real(x) + s.vectors.At(s.n+i, j)*imag(x)
+		}
+	}
+	return c
+}
+
+func (s *regularizedSolver) solve(c []float64, mu float64) []complex128 {
+	x := make([]complex128, s.n)
+	for j, cj := range c {
+		if cj == 0 {
+			continue
+		}
+		scaled := cj / (s.values[j] + mu)
+		for i := range x {
+			x[i] += complex(scaled*s.vectors.At(i, j), scaled*s.vectors.At(s.n+i, j))
+		}
+	}
+	return x
+}
+
+// power is Σ‖x_k‖² of the solutions for the projected right-hand sides.
+func (s *regularizedSolver) power(projected [][]float64, mu float64) float64 {
+	var p float64
+	for _, c := range projected {
+		for j, cj := range c {
+			if cj != 0 {
+				p += cj * cj / ((s.values[j] + mu) * (s.values[j] + mu))
+			}
+		}
+	}
+	return p
+}
+
+// multiplier returns the smallest μ ≥ 0 whose solutions use at most budget
+// power, by bisection: the power falls monotonically in μ and is below
+// Σ‖c_k‖²/μ².
+func (s *regularizedSolver) multiplier(projected [][]float64, budget float64) float64 {
+	if s.power(projected, 0) <= budget {
+		return 0
+	}
+	var total float64
+	for _, c := range projected {
+		for _, cj := range c {
+			total += cj * cj
+		}
+	}
+	lo, hi := 0.0, math.Sqrt(total/budget)
+	for i := 0; i < 100 && hi-lo > 1e-12*hi; i++ {
+		mid := (lo + hi) / 2
+		if s.power(projected, mid) > budget {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi
+}
@@ -0,0 +1,97 @@
+package beamforming
+
+import (
+	"math"
+	"testing"
+
+	"isac-cran-system/internal/model"
+)
+
+func TestOptimizer_WMMSE(t *testing.T) {
+	optimizer := NewOptimizer(8, 200, 1e-4)
+	params := &model.BeamformingParams{
+		ElementCount: 8,
+		Mode:         model.BeamformingModeWMMSE,
+		Users: []model.BeamformingUser{
+			{Angle: -0.6},
+			{Angle: 0.1},
+			{Angle: 0.25, PathLoss: 6},
+		},
+	}
+
+	result, err := optimizer.WMMSE(params)
+	if err != nil {
+		t.Fatalf("WMMSE failed: %v", err)
+	}
+	if err := result.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !result.Converged || len(result.RateHistory) != result.Iterations {
+		t.Fatalf("converged %v with %d rates in %d iterations", result.Converged, len(result.RateHistory), result.Iterations)
+	}
+	for i := 1; i < len(result.RateHistory); i++ {
+		if result.RateHistory[i] < result.RateHistory[i-1]-1e-9 {
+			t.Errorf("sum rate fell from %.4f to %.4f in iteration %d", result.RateHistory[i-1], result.RateHistory[i], i+1)
+		}
+	}
+	if len(result.Users) != 3 || len(result.Beams) != 3 {
+		t.Fatalf("%d users and %d beams, want 3", len(result.Users), len(result.Beams))
+	}
+	if result.TransmitPower > defaultMaxTransmitPower*(1+1e-9) {
+		t.Errorf("transmit power %.6f W exceeds the %.1f W budget", result.TransmitPower, defaultMaxTransmitPower)
+	}
+	var sum float64
+	for _, user := range result.Users {
+		sum += user.Rate
+	}
+	if math.Abs(sum-result.SpectralEfficiency) > 1e-9 || math.Abs(result.SumRate-result.SpectralEfficiency) > 1e-9 {
+		t.Errorf("user rates add to %.4f, spectral efficiency %.4f, sum rate %.4f", sum, result.SpectralEfficiency, result.SumRate)
+	}
+
+	// matched filters sharing the power leak into the close users
+	channels := make([][]complex128, len(params.Users))
+	matched := make([][]complex128, len(params.Users))
+	for k, user := range params.Users {
+		channels[k] = optimizer.computeSteeringVector(8, user.Angle)
+		for n := range channels[k] {
+			channels[k][n] *= complex(math.Pow(10, -user.PathLoss/20), 0)
+		}
+		matched[k] = append([]complex128(nil), channels[k]...)
+		optimizer.normalizeWeights(matched[k])
+		for n := range matched[k] {
+			matched[k][n] /= complex(math.Sqrt(3), 0)
+		}
+	}
+	baseline := weightedSumRate(channels, matched, []float64{1, 1, 1}, defaultNoisePower)
+	if result.SumRate < baseline+3 {
+		t.Errorf("sum rate %.2f bit/s/Hz, matched filters reach %.2f", result.SumRate, baseline)
+	}
+
+	params.Users[2].Weight = 4
+	favoured, err := optimizer.WMMSE(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if favoured.Users[2].Rate <= result.Users[2].Rate {
+		t.Errorf("user 2 rate %.3f with weight 4, %.3f with weight 1", favoured.Users[2].Rate, result.Users[2].Rate)
+	}
+
+	// a single user gets the matched filter at full power
+	params.Users = params.Users[:1]
+	single, err := optimizer.WMMSE(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := math.Log2(1 + 8/defaultNoisePower); math.Abs(single.SumRate-want) > 1e-6 {
+		t.Errorf("single user rate %.6f, want %.6f", single.SumRate, want)
+	}
+
+	params.Objective = model.BeamformingObjectiveEnergy
+	if _, err := optimizer.WMMSE(params); !model.IsValidationError(err) {
+		t.Errorf("energy objective error = %v, want validation error", err)
+	}
+	params.Objective, params.Users = "", nil
+	if _, err := optimizer.WMMSE(params); !model.IsValidationError(err) {
+		t.Errorf("no users error = %v, want validation error", err)
+	}
+}
@@ -0,0 +1,159 @@
+// Package covariance estimates the spatial covariance of array snapshots.
+// The plain sample covariance is singular when there are fewer snapshots
+// than antennas and poorly conditioned just above that, so MVDR and the
+// subspace DOA methods can regularize it with diagonal loading or
+// Ledoit-Wolf shrinkage and apply forward-backward averaging.
+package covariance
+
+import (
+	"math/cmplx"
+
+	"isac-cran-system/internal/model"
+)
+
+// DefaultLoadingFactor is used for diagonal loading when none is given.
+const DefaultLoadingFactor = 0.01
+
+// Estimate returns the M×M covariance of X, one row per antenna, using the
+// method selected by opts. A zero value gives the sample covariance.
+func Estimate(X [][]complex128, opts model.CovarianceOptions) ([][]complex128, error) {
+	if len(X) == 0 || len(X[0]) == 0 {
+		return nil, &model.ValidationError{Field: "snapshots", Message: "covariance needs at least one antenna and one snapshot"}
+	}
+
+	var R [][]complex128
+	switch opts.Method {
+	case "", model.CovarianceSample:
+		R = Sample(X)
+	case model.CovarianceDiagonalLoading:
+		factor := opts.LoadingFactor
+		if factor <= 0 {
+			factor = DefaultLoadingFactor
+		}
+		R = DiagonalLoading(Sample(X), factor)
+	case model.CovarianceLedoitWolf:
+		R, _ = LedoitWolf(X)
+	default:
+		return nil, &model.ValidationError{Field: "covariance.method", Message: "unsupported covariance method " + opts.Method}
+	}
+
+	if opts.ForwardBackward {
+		R = ForwardBackward(R)
+	}
+	return R, nil
+}
+
+// Sample returns (1/N)·X·Xᴴ.
+func Sample(X [][]complex128) [][]complex128 {
+	M, N := len(X), len(X[0])
+	R := newMatrix(M)
+	for i := 0; i < M; i++ {
+		for j := i; j < M; j++ {
+			var sum complex128
+			for t := 0; t < N; t++ {
+				sum += X[i][t] * cmplx.Conj(X[j][t])
+			}
+			R[i][j] = sum / complex(float64(N), 0)
+			R[j][i] = cmplx.Conj(R[i][j])
+		}
+	}
+	return R
+}
+
+// DiagonalLoading adds factor times the average element power to the
+// diagonal, so the loading scales with the received signal level.
+func DiagonalLoading(R [][]complex128, factor float64) [][]complex128 {
+	load := factor * averagePower(R)
+	out := copyMatrix(R)
+	for i := range out {
+		out[i][i] += complex(load, 0)
+	}
+	return out
+}
+
+// LedoitWolf shrinks the sample covariance towards a scaled identity with
+// the intensity that minimizes the expected Frobenius error (Ledoit & Wolf,
+// 2004). It returns the shrunk matrix and the shrinkage intensity in [0, 1].
+func LedoitWolf(X [][]complex128) ([][]complex128, float64) {
+	M, N := len(X), len(X[0])
+	S := Sample(X)
+	mu := averagePower(S)
+
+	// d²: distance of S from the target
+	var d2 float64
+	for i := 0; i < M; i++ {
+		for j := 0; j < M; j++ {
+			diff := S[i][j]
+			if i == j {
+				diff -= complex(mu, 0)
+			}
+			d2 += real(diff * cmplx.Conj(diff))
+		}
+	}
+	if d2 == 0 {
+		return S, 0
+	}
+
+	// b²: variance of the single-snapshot outer products around S
+	var b2 float64
+	for t := 0; t < N; t++ {
+		for i := 0; i < M; i++ {
+			for j := 0; j < M; j++ {
+				diff := X[i][t]*cmplx.Conj(X[j][t]) - S[i][j]
+				b2 += real(diff * cmplx.Conj(diff))
+			}
+		}
+	}
+	b2 /= float64(N) * float64(N)
+	if b2 > d2 {
+		b2 = d2
+	}
+
+	rho := b2 / d2
+	R := newMatrix(M)
+	for i := 0; i < M; i++ {
+		for j := 0; j < M; j++ {
+			R[i][j] = complex(1-rho, 0) * S[i][j]
+		}
+		R[i][i] += complex(rho*mu, 0)
+	}
+	return R, rho
+}
+
+// ForwardBackward averages R with J·R*·J, where J is the exchange matrix.
+// This decorrelates coherent sources and doubles the effective snapshot
+// count on centro-symmetric arrays.
+func ForwardBackward(R [][]complex128) [][]complex128 {
+	M := len(R)
+	out := newMatrix(M)
+	for i := 0; i < M; i++ {
+		for j := 0; j < M; j++ {
+			out[i][j] = (R[i][j] + cmplx.Conj(R[M-1-i][M-1-j])) / 2
+		}
+	}
+	return out
+}
+
+func averagePower(R [][]complex128) float64 {
+	var trace float64
+	for i := range R {
+		trace += real(R[i][i])
+	}
+	return trace / float64(len(R))
+}
+
+func newMatrix(M int) [][]complex128 {
+	R := make([][]complex128, M)
+	for i := range R {
+		R[i] = make([]complex128, M)
+	}
+	return R
+}
+
+func copyMatrix(R [][]complex128) [][]complex128 {
+	out := make([][]complex128, len(R))
+	for i := range R {
+		out[i] = append([]complex128(nil), R[i]...)
+	}
+	return out
+}
@@ -0,0 +1,119 @@
+package covariance
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+
+	"isac-cran-system/internal/model"
+)
+
+func randomSnapshots(m, n int) [][]complex128 {
+	r := rand.New(rand.NewSource(1))
+	X := make([][]complex128, m)
+	for i := range X {
+		X[i] = make([]complex128, n)
+		for t := range X[i] {
+			X[i][t] = complex(r.NormFloat64(), r.NormFloat64())
+		}
+	}
+	return X
+}
+
+func TestEstimate_Methods(t *testing.T) {
+	X := randomSnapshots(4, 3)
+	S := Sample(X)
+
+	R, err := Estimate(X, model.CovarianceOptions{Method: model.CovarianceDiagonalLoading, LoadingFactor: 0.5})
+	if err != nil {
+		t.Fatalf("Estimate() error = %v", err)
+	}
+	load := 0.5 * averagePower(S)
+	if d := real(R[2][2]-S[2][2]) - load; math.Abs(d) > 1e-12 {
+		t.Errorf("diagonal loaded by %v, want %v", real(R[2][2]-S[2][2]), load)
+	}
+	if R[0][1] != S[0][1] {
+		t.Error("diagonal loading changed an off-diagonal entry")
+	}
+
+	if _, err := Estimate(X, model.CovarianceOptions{Method: "oracle"}); err == nil {
+		t.Error("expected error for unsupported method")
+	}
+}
+
+func TestLedoitWolf(t *testing.T) {
+	// fewer snapshots than antennas: the sample covariance is singular and
+	// shrinkage must pull it towards the identity
+	X := randomSnapshots(8, 4)
+	R, rho := LedoitWolf(X)
+	if rho <= 0 || rho > 1 {
+		t.Fatalf("shrinkage intensity = %v, want in (0, 1]", rho)
+	}
+
+	S := Sample(X)
+	mu := averagePower(S)
+	for i := range R {
+		want := complex(1-rho, 0)*S[i][i] + complex(rho*mu, 0)
+		if cmplx.Abs(R[i][i]-want) > 1e-12 {
+			t.Errorf("R[%d][%d] = %v, want %v", i, i, R[i][i], want)
+		}
+	}
+}
+
+func TestForwardBackward(t *testing.T) {
+	R := ForwardBackward(Sample(randomSnapshots(4, 16)))
+	M := len(R)
+	for i := 0; i < M; i++ {
+		for j := 0; j < M; j++ {
+			if cmplx.Abs(R[i][j]-cmplx.Conj(R[M-1-i][M-1-j])) > 1e-12 {
+				t.Fatalf("R is not persymmetric at (%d, %d)", i, j)
+			}
+		}
+	}
+}
+
+func TestTracker(t *testing.T) {
+	if _, err := NewTracker(4, 1); err == nil {
+		t.Error("expected forgetting factor 1 to be rejected")
+	}
+	tracker, err := NewTracker(4, 0.99)
+	if err != nil {
+		t.Fatalf("NewTracker() error = %v", err)
+	}
+	if tracker.Covariance() != nil {
+		t.Error("expected no covariance before the first update")
+	}
+
+	X := randomSnapshots(4, 200)
+	first := [][]complex128{{X[0][0]}, {X[1][0]}, {X[2][0]}, {X[3][0]}}
+	tracker.Update(first)
+	if S := Sample(first); cmplx.Abs(tracker.Covariance()[1][2]-S[1][2]) > 1e-12 {
+		t.Errorf("first update gives %v, want the outer product %v", tracker.Covariance()[1][2], S[1][2])
+	}
+
+	// split updates must match one update over the same snapshots
+	whole, _ := NewTracker(4, 0.99)
+	whole.Update(X)
+	tracker.Reset()
+	tracker.Update([][]complex128{X[0][:120], X[1][:120], X[2][:120], X[3][:120]})
+	tracker.Update([][]complex128{X[0][120:], X[1][120:], X[2][120:], X[3][120:]})
+	a, b := tracker.Covariance(), whole.Covariance()
+	for i := range a {
+		for j := range a[i] {
+			if cmplx.Abs(a[i][j]-b[i][j]) > 1e-9 {
+				t.Fatalf("split updates differ at [%d][%d]: %v vs %v", i, j, a[i][j], b[i][j])
+			}
+		}
+		if math.Abs(imag(a[i][i])) > 1e-12 {
+			t.Errorf("diagonal %d is not real: %v", i, a[i][i])
+		}
+	}
+	if tracker.Updates() != 200 {
+		t.Errorf("Updates() = %d, want 200", tracker.Updates())
+	}
+
+	if _, err := Regularize(a, model.CovarianceOptions{Method: model.CovarianceLedoitWolf}); err == nil {
+		t.Error("expected Ledoit-Wolf to be rejected on a tracked covariance")
+	}
+}
@@ -0,0 +1,134 @@
+package covariance
+
+import (
+	"math"
+	"math/cmplx"
+	"sync"
+
+	"isac-cran-system/internal/model"
+)
+
+// DefaultForgettingFactor weights the last ~1000 snapshots.
+const DefaultForgettingFactor = 0.999
+
+// Tracker maintains an exponentially weighted covariance with one rank-1
+// update per snapshot,
+//
+//	R ← λ·R + (1−λ)·x·xᴴ
+//
+// so a running estimate costs O(M²) per snapshot instead of a full
+// recomputation over the window. The effective window is about 1/(1−λ)
+// snapshots. It is safe for one writer and concurrent readers.
+type Tracker struct {
+	mu         sync.RWMutex
+	forgetting float64
+	R          [][]complex128
+	// decay is λ^updates; 1−decay corrects the bias of the zero start.
+	decay   float64
+	updates uint64
+}
+
+func NewTracker(antennas int, forgetting float64) (*Tracker, error) {
+	if antennas <= 0 {
+		return nil, &model.ValidationError{Field: "antennas", Message: "covariance tracker needs at least one antenna"}
+	}
+	if forgetting == 0 {
+		forgetting = DefaultForgettingFactor
+	}
+	if forgetting <= 0 || forgetting >= 1 {
+		return nil, &model.ValidationError{Field: "forgetting_factor", Message: "forgetting factor must be in (0, 1)"}
+	}
+	return &Tracker{forgetting: forgetting, R: newMatrix(antennas), decay: 1}, nil
+}
+
+// Update folds every column of X, one row per antenna, into the estimate in
+// order.
+func (t *Tracker) Update(X [][]complex128) error {
+	if len(X) != len(t.R) {
+		return &model.ValidationError{Field: "snapshots", Message: "snapshot rows do not match the tracked antenna count"}
+	}
+	if len(X[0]) == 0 {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	M, lambda := len(X), t.forgetting
+	gain := complex(1-lambda, 0)
+	x := make([]complex128, M)
+	for n := range X[0] {
+		for i := range x {
+			x[i] = X[i][n]
+		}
+		for i := 0; i < M; i++ {
+			for j := i; j < M; j++ {
+				t.R[i][j] = complex(lambda, 0)*t.R[i][j] + gain*x[i]*cmplx.Conj(x[j])
+			}
+		}
+	}
+	for i := 0; i < M; i++ {
+		for j := 0; j < i; j++ {
+			t.R[i][j] = cmplx.Conj(t.R[j][i])
+		}
+	}
+
+	t.updates += uint64(len(X[0]))
+	t.decay *= math.Pow(lambda, float64(len(X[0])))
+	return nil
+}
+
+// Covariance returns a bias-corrected copy of the current estimate, or nil
+// before the first update.
+func (t *Tracker) Covariance() [][]complex128 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.updates == 0 {
+		return nil
+	}
+	scale := complex(1/(1-t.decay), 0)
+	out := newMatrix(len(t.R))
+	for i := range out {
+		for j := range out[i] {
+			out[i][j] = t.R[i][j] * scale
+		}
+	}
+	return out
+}
+
+// Updates is the number of snapshots absorbed since the last Reset.
+func (t *Tracker) Updates() uint64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.updates
+}
+
+func (t *Tracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.R = newMatrix(len(t.R))
+	t.decay = 1
+	t.updates = 0
+}
+
+// Regularize applies the loading and forward-backward options to a tracked
+// covariance. Ledoit-Wolf needs the snapshots themselves and is rejected.
+func Regularize(R [][]complex128, opts model.CovarianceOptions) ([][]complex128, error) {
+	switch opts.Method {
+	case "", model.CovarianceSample:
+	case model.CovarianceDiagonalLoading:
+		factor := opts.LoadingFactor
+		if factor <= 0 {
+			factor = DefaultLoadingFactor
+		}
+		R = DiagonalLoading(R, factor)
+	default:
+		return nil, &model.ValidationError{Field: "covariance.method", Message: "covariance method " + opts.Method + " is not supported on a tracked covariance"}
+	}
+
+	if opts.ForwardBackward {
+		R = ForwardBackward(R)
+	}
+	return R, nil
+}
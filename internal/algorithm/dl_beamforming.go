@@ -10,6 +10,8 @@ import (
 	"sync"
 	"time"
 
+	"isac-cran-system/internal/algorithm/array"
+
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -522,6 +524,10 @@ type ChannelConfig struct {
 	NumSubPaths   int     `json:"num_sub_paths"`
 	AntennaHeight float64 `json:"antenna_height"`
 	UTHeight      float64 `json:"ut_height"`
+
+	// Array is the transmit array geometry; nil or a size mismatch falls back
+	// to a half-wavelength ULA.
+	Array *array.Geometry `json:"-"`
 }
 
 type ChannelModel struct {
@@ -576,9 +582,10 @@ func (m *ChannelModel) GenerateChannel(numAntennas, numUsers int, carrierFreq fl
 	m.generateLargeScaleParams(carrierFreq)
 	m.generateSmallScaleParams()
 	channelMatrix := mat.NewDense(numAntennas, numUsers, nil)
+	steerings := m.clusterSteerings(numAntennas)
 	for i := 0; i < numAntennas; i++ {
 		for j := 0; j < numUsers; j++ {
-			h := m.generateChannelCoefficient(i, j)
+			h := m.generateChannelCoefficient(i, j, steerings)
 			channelMatrix.Set(i, j, h)
 		}
 	}
@@ -667,15 +674,33 @@ func (m *ChannelModel) generateSmallScaleParams() {
 	}
 }
 
-func (m *ChannelModel) generateChannelCoefficient(antennaIdx, userIdx int) float64 {
+// clusterSteerings returns the array response towards each cluster's AoD,
+// given in degrees.
+func (m *ChannelModel) clusterSteerings(numAntennas int) [][]complex128 {
+	if m.smallScale == nil {
+		return nil
+	}
+	geometry := m.config.Array
+	if geometry == nil || geometry.Len() != numAntennas {
+		geometry = array.NewULA(numAntennas, array.HalfWavelength)
+	}
+	steerings := make([][]complex128, len(m.smallScale.Clusters))
+	for i, cluster := range m.smallScale.Clusters {
+		steerings[i] = geometry.SteeringVector(cluster.AoD * math.Pi / 180)
+	}
+	return steerings
+}
+
+func (m *ChannelModel) generateChannelCoefficient(antennaIdx, userIdx int, steerings [][]complex128) float64 {
 	if m.largeScale == nil || m.smallScale == nil {
 		return 0.0
 	}
 	var h float64
-	for _, cluster := range m.smallScale.Clusters {
-		phase := cluster.AoD + float64(antennaIdx)*0.5*math.Pi
+	for c, cluster := range m.smallScale.Clusters {
+		a := steerings[c][antennaIdx]
+		phase := cluster.AoD + cmplx.Phase(a)
 		for _, subPath := range cluster.SubPaths {
-			amplitude := math.Sqrt(cluster.Power * subPath.PowerOffset)
+			amplitude := cmplx.Abs(a) * math.Sqrt(cluster.Power*subPath.PowerOffset)
 			h += amplitude * math.Cos(phase+subPath.PhaseOffset)
 		}
 	}
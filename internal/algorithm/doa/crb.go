@@ -0,0 +1,156 @@
+package doa
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"isac-cran-system/internal/algorithm/array"
+)
+
+// The stochastic Cramér–Rao bound of Stoica, Larsson and Gershman for K
+// uncorrelated sources of powers P in white noise of power σ², seen over N
+// snapshots by an array with steering matrix A and derivatives D = ∂A/∂θ:
+//
+//	CRB(θ) = σ²/2N · {Re[(Dᴴ·Π⊥·D) ⊙ (P·Aᴴ·R⁻¹·A·P)ᵀ]}⁻¹
+//
+// where R = A·P·Aᴴ + σ²I and Π⊥ projects onto the complement of A. No
+// unbiased estimator does better, so it is the floor RMSE curves approach
+// at high SNR.
+
+// crbStep is the azimuth step in radians of the central difference the
+// steering vector derivatives are taken with, so that element patterns and
+// mutual coupling enter the bound as they enter the estimate.
+const crbStep = 1e-6
+
+// CRB returns the bound on the standard deviation in radians of each of the
+// azimuths of uncorrelated sources, each snr (linear, per element) above
+// the noise, estimated by array g from snapshots samples.
+func CRB(g *array.Geometry, azimuths []float64, snr float64, snapshots int) ([]float64, error) {
+	powers := make([]float64, len(azimuths))
+	for i := range powers {
+		powers[i] = snr
+	}
+	return stochasticCRB(g, azimuths, powers, 1, snapshots)
+}
+
+// covarianceCRB evaluates the bound at the estimated azimuths with the noise
+// and source powers read from R: the noise is the mean of the M-K smallest
+// eigenvalues and the powers are the diagonal of A⁺·(R-σ²I)·A⁺ᴴ.
+func covarianceCRB(R [][]complex128, g *array.Geometry, azimuths []float64, snapshots int) ([]float64, error) {
+	M, K := len(R), len(azimuths)
+	if K == 0 || K >= M {
+		return nil, fmt.Errorf("%d sources leave no noise subspace in %d channels", K, M)
+	}
+	values, _ := hermitianEigen(R)
+	var noise float64
+	for _, v := range values[K:] {
+		noise += v
+	}
+	noise /= float64(M - K)
+	if noise <= 0 {
+		return nil, fmt.Errorf("noise power %g is not positive", noise)
+	}
+
+	A := steeringMatrix(g, azimuths, 0)
+	Ah := conjugateTranspose(A)
+	pinv, err := solve(multiply(Ah, A, false), Ah)
+	if err != nil {
+		return nil, err
+	}
+	S := complexMatrix(M, M)
+	for i := range S {
+		copy(S[i], R[i])
+		S[i][i] -= complex(noise, 0)
+	}
+	P := multiply(multiply(pinv, S, false), pinv, true)
+	powers := make([]float64, K)
+	for k := range powers {
+		if powers[k] = real(P[k][k]); powers[k] <= 0 {
+			return nil, fmt.Errorf("source at %.4g rad is below the noise floor", azimuths[k])
+		}
+	}
+	return stochasticCRB(g, azimuths, powers, noise, snapshots)
+}
+
+func stochasticCRB(g *array.Geometry, azimuths, powers []float64, noise float64, snapshots int) ([]float64, error) {
+	M, K := g.Len(), len(azimuths)
+	if K == 0 || K >= M {
+		return nil, fmt.Errorf("%d sources leave no noise subspace in %d elements", K, M)
+	}
+	if snapshots < 1 || noise <= 0 {
+		return nil, fmt.Errorf("bound needs snapshots and a positive noise power")
+	}
+
+	A := steeringMatrix(g, azimuths, 0)
+	upper := steeringMatrix(g, azimuths, crbStep)
+	lower := steeringMatrix(g, azimuths, -crbStep)
+	D := complexMatrix(M, K)
+	for m := range D {
+		for k := range D[m] {
+			D[m][k] = (upper[m][k] - lower[m][k]) / (2 * crbStep)
+		}
+	}
+	Ah := conjugateTranspose(A)
+
+	// Π⊥·D = D - A·(AᴴA)⁻¹·Aᴴ·D
+	coeffs, err := solve(multiply(Ah, A, false), multiply(Ah, D, false))
+	if err != nil {
+		return nil, err
+	}
+	projected := multiply(A, coeffs, false)
+	for m := range projected {
+		for k := range projected[m] {
+			projected[m][k] = D[m][k] - projected[m][k]
+		}
+	}
+	DPD := multiply(conjugateTranspose(D), projected, false)
+
+	R := complexMatrix(M, M)
+	for m := range R {
+		R[m][m] = complex(noise, 0)
+		for n := range R[m] {
+			for k, p := range powers {
+				R[m][n] += A[m][k] * complex(p, 0) * cmplx.Conj(A[n][k])
+			}
+		}
+	}
+	RinvA, err := solve(R, A)
+	if err != nil {
+		return nil, err
+	}
+	ARA := multiply(Ah, RinvA, false)
+
+	fisher := complexMatrix(K, K)
+	scale := 2 * float64(snapshots) / noise
+	for i := range fisher {
+		for j := range fisher[i] {
+			fisher[i][j] = complex(scale*real(DPD[i][j]*ARA[j][i])*powers[i]*powers[j], 0)
+		}
+	}
+	bound, err := solve(fisher, identity(K))
+	if err != nil {
+		return nil, err
+	}
+	std := make([]float64, K)
+	for k := range std {
+		v := real(bound[k][k])
+		if v <= 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, fmt.Errorf("bound of source %d is not finite", k)
+		}
+		std[k] = math.Sqrt(v)
+	}
+	return std, nil
+}
+
+// steeringMatrix returns the M×K steering vectors of the azimuths, each
+// offset by delta.
+func steeringMatrix(g *array.Geometry, azimuths []float64, delta float64) [][]complex128 {
+	A := complexMatrix(g.Len(), len(azimuths))
+	for k, azimuth := range azimuths {
+		for m, v := range g.SteeringVector(azimuth + delta) {
+			A[m][k] = v
+		}
+	}
+	return A
+}
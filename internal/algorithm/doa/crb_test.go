@@ -0,0 +1,63 @@
+package doa
+
+import (
+	"math"
+	"testing"
+
+	"isac-cran-system/internal/algorithm/array"
+	"isac-cran-system/internal/model"
+)
+
+func TestCRB(t *testing.T) {
+	const M, N = 8, 200
+	theta := 20 * math.Pi / 180
+	g := array.NewULA(M, 0.5)
+	for _, snrDB := range []float64{-5, 10, 30} {
+		snr := math.Pow(10, snrDB/10)
+		bound, err := CRB(g, []float64{theta}, snr, N)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := math.Sqrt(espritCRB(M, N, 0.5, theta, snr))
+		if math.Abs(bound[0]-want) > 1e-6*want {
+			t.Errorf("%g dB: CRB %.6g rad, want the closed form %.6g rad", snrDB, bound[0], want)
+		}
+	}
+
+	// a second source close by makes both harder to locate
+	single, _ := CRB(g, []float64{theta}, 10, N)
+	pair, err := CRB(g, []float64{theta, theta + 5*math.Pi/180}, 10, N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair[0] <= single[0] || pair[1] <= single[0] {
+		t.Errorf("CRB %v rad for two close sources, want above %v rad for one", pair, single[0])
+	}
+
+	if _, err := CRB(g, make([]float64, M), 10, N); err == nil {
+		t.Error("as many sources as elements were bounded")
+	}
+}
+
+func TestEstimator_CRB(t *testing.T) {
+	const M, N = 8, 400
+	signals := NewESPRITEstimator(&ESPRITConfig{NumAntennas: M, SnapshotLength: N, Seed: 11})
+	X := signals.GenerateTestSignal([]float64{20 * math.Pi / 180}, 10)
+
+	e := NewEstimator(M, 1, N, "MUSIC")
+	result, err := e.EstimateSnapshots(X, &model.DOAParams{
+		ElementCount: M, NumSources: 1, SnapshotLength: N, Method: "MUSIC",
+		SearchRangeMin: -90, SearchRangeMax: 90, SearchStep: 0.1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := CRB(array.NewULA(M, 0.5), result.EstimatedAngles, 10, N)
+	// the powers read from one covariance estimate are off by a few percent
+	if len(result.CRB) != 1 || math.Abs(result.CRB[0]-want[0]) > 0.1*want[0] {
+		t.Errorf("CRB %v rad, want about %v rad", result.CRB, want)
+	}
+	if err := result.Validate(); err != nil {
+		t.Error(err)
+	}
+}
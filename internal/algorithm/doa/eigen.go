@@ -0,0 +1,121 @@
+package doa
+
+import (
+	"math"
+	"math/cmplx"
+	"sort"
+)
+
+const (
+	jacobiMaxSweeps = 100
+	jacobiTolerance = 1e-12
+)
+
+// hermitianEigen decomposes the Hermitian matrix A with cyclic Jacobi
+// rotations. It returns the real eigenvalues in descending order and the
+// matching orthonormal eigenvectors, eigenvectors[k] belonging to
+// eigenvalues[k]. Only the upper triangle of A is trusted.
+func hermitianEigen(A [][]complex128) ([]float64, [][]complex128) {
+	n := len(A)
+	a := make([][]complex128, n)
+	v := make([][]complex128, n)
+	for i := range a {
+		a[i] = make([]complex128, n)
+		v[i] = make([]complex128, n)
+		v[i][i] = 1
+		for j := range a[i] {
+			if j >= i {
+				a[i][j] = A[i][j]
+			} else {
+				a[i][j] = cmplx.Conj(A[j][i])
+			}
+		}
+		a[i][i] = complex(real(a[i][i]), 0)
+	}
+
+	var norm float64
+	for i := range a {
+		for j := range a[i] {
+			norm += real(a[i][j])*real(a[i][j]) + imag(a[i][j])*imag(a[i][j])
+		}
+	}
+	threshold := jacobiTolerance * jacobiTolerance * norm
+
+	for sweep := 0; sweep < jacobiMaxSweeps; sweep++ {
+		var off float64
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				off += real(a[p][q])*real(a[p][q]) + imag(a[p][q])*imag(a[p][q])
+			}
+		}
+		if off <= threshold {
+			break
+		}
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				jacobiRotate(a, v, p, q)
+			}
+		}
+	}
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return real(a[order[i]][order[i]]) > real(a[order[j]][order[j]]) })
+
+	eigenvalues := make([]float64, n)
+	eigenvectors := make([][]complex128, n)
+	for k, col := range order {
+		eigenvalues[k] = real(a[col][col])
+		eigenvectors[k] = make([]complex128, n)
+		for i := 0; i < n; i++ {
+			eigenvectors[k][i] = v[i][col]
+		}
+	}
+	return eigenvalues, eigenvectors
+}
+
+// jacobiRotate zeroes a[p][q] with a unitary rotation of rows and columns p
+// and q, accumulating it into the columns of v. The phase of a[p][q] is
+// moved into column q first so that a real Givens rotation finishes the job.
+func jacobiRotate(a, v [][]complex128, p, q int) {
+	apq := cmplx.Abs(a[p][q])
+	if apq == 0 {
+		return
+	}
+	phase := a[p][q] / complex(apq, 0)
+	for k := range a {
+		a[k][q] *= cmplx.Conj(phase)
+		a[q][k] *= phase
+		v[k][q] *= cmplx.Conj(phase)
+	}
+
+	theta := (real(a[q][q]) - real(a[p][p])) / (2 * apq)
+	t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+	if theta < 0 {
+		t = -t
+	}
+	c := 1 / math.Sqrt(t*t+1)
+	s := complex(t*c, 0)
+	cc := complex(c, 0)
+
+	for k := range a {
+		akp, akq := a[k][p], a[k][q]
+		a[k][p] = cc*akp - s*akq
+		a[k][q] = s*akp + cc*akq
+	}
+	for k := range a {
+		apk, aqk := a[p][k], a[q][k]
+		a[p][k] = cc*apk - s*aqk
+		a[q][k] = s*apk + cc*aqk
+	}
+	for k := range v {
+		vkp, vkq := v[k][p], v[k][q]
+		v[k][p] = cc*vkp - s*vkq
+		v[k][q] = s*vkp + cc*vkq
+	}
+	a[p][q], a[q][p] = 0, 0
+	a[p][p] = complex(real(a[p][p]), 0)
+	a[q][q] = complex(real(a[q][q]), 0)
+}
@@ -0,0 +1,144 @@
+package doa
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+
+	"isac-cran-system/internal/algorithm/array"
+	"isac-cran-system/internal/model"
+)
+
+func TestHermitianEigen(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	const n = 6
+	B := make([][]complex128, n)
+	for i := range B {
+		B[i] = make([]complex128, n)
+		for j := range B[i] {
+			B[i][j] = complex(rng.NormFloat64(), rng.NormFloat64())
+		}
+	}
+	// A = B Bᴴ is Hermitian positive semi-definite.
+	A := make([][]complex128, n)
+	for i := range A {
+		A[i] = make([]complex128, n)
+		for j := range A[i] {
+			for k := 0; k < n; k++ {
+				A[i][j] += B[i][k] * cmplx.Conj(B[j][k])
+			}
+		}
+	}
+
+	values, vectors := hermitianEigen(A)
+	for k := range values {
+		if k > 0 && values[k] > values[k-1] {
+			t.Errorf("eigenvalues not descending: %v", values)
+		}
+		for i := 0; i < n; i++ {
+			var av complex128
+			for j := 0; j < n; j++ {
+				av += A[i][j] * vectors[k][j]
+			}
+			if cmplx.Abs(av-complex(values[k], 0)*vectors[k][i]) > 1e-9 {
+				t.Fatalf("A v != λ v for eigenpair %d", k)
+			}
+		}
+		for l := range vectors {
+			var dot complex128
+			for i := 0; i < n; i++ {
+				dot += cmplx.Conj(vectors[k][i]) * vectors[l][i]
+			}
+			want := complex(0, 0)
+			if k == l {
+				want = 1
+			}
+			if cmplx.Abs(dot-want) > 1e-9 {
+				t.Fatalf("<v%d, v%d> = %v, want %v", k, l, dot, want)
+			}
+		}
+	}
+}
+
+// twoSourceCovariance is the exact covariance of two uncorrelated unit-power
+// sources at the given angles, in degrees, plus white noise.
+func twoSourceCovariance(g *array.Geometry, first, second, noise float64) [][]complex128 {
+	a := g.SteeringVector(first * math.Pi / 180)
+	b := g.SteeringVector(second * math.Pi / 180)
+	R := make([][]complex128, len(a))
+	for i := range R {
+		R[i] = make([]complex128, len(a))
+		for j := range R[i] {
+			R[i][j] = a[i]*cmplx.Conj(a[j]) + b[i]*cmplx.Conj(b[j])
+		}
+		R[i][i] += complex(noise, 0)
+	}
+	return R
+}
+
+func TestMUSIC_TwoSources(t *testing.T) {
+	g := array.NewULA(8, array.HalfWavelength)
+	cases := []struct{ first, second float64 }{
+		{-20, 30},
+		{10, 25},
+		{-50, -35},
+	}
+	for _, c := range cases {
+		R := twoSourceCovariance(g, c.first, c.second, 0.01)
+
+		music := NewMUSIC(8, 2, array.HalfWavelength)
+		angles := music.EstimateDOA(R)
+		assertAngles(t, "MUSIC", radiansToDegrees(angles), c.first, c.second, 0.5)
+
+		result, err := NewEstimator(8, 2, 256, "MUSIC").EstimateSnapshots(twoSourceSnapshots(g, c.first, c.second, 512), &model.DOAParams{
+			NumSources:     2,
+			Method:         "MUSIC",
+			SearchRangeMin: -90,
+			SearchRangeMax: 90,
+			SearchStep:     0.1,
+		})
+		if err != nil {
+			t.Fatalf("EstimateSnapshots failed: %v", err)
+		}
+		assertAngles(t, "Estimator", radiansToDegrees(result.EstimatedAngles), c.first, c.second, 1)
+	}
+}
+
+func twoSourceSnapshots(g *array.Geometry, first, second float64, n int) [][]complex128 {
+	rng := rand.New(rand.NewSource(2))
+	a := g.SteeringVector(first * math.Pi / 180)
+	b := g.SteeringVector(second * math.Pi / 180)
+	X := make([][]complex128, len(a))
+	for m := range X {
+		X[m] = make([]complex128, n)
+	}
+	for k := 0; k < n; k++ {
+		s1 := complex(rng.NormFloat64(), rng.NormFloat64())
+		s2 := complex(rng.NormFloat64(), rng.NormFloat64())
+		for m := range X {
+			X[m][k] = a[m]*s1 + b[m]*s2 + complex(0.05*rng.NormFloat64(), 0.05*rng.NormFloat64())
+		}
+	}
+	return X
+}
+
+func radiansToDegrees(angles []float64) []float64 {
+	degrees := make([]float64, len(angles))
+	for i, a := range angles {
+		degrees[i] = a * 180 / math.Pi
+	}
+	return degrees
+}
+
+func assertAngles(t *testing.T, name string, got []float64, first, second, tolerance float64) {
+	t.Helper()
+	if len(got) != 2 {
+		t.Errorf("%s: got %v, want %v and %v", name, got, first, second)
+		return
+	}
+	lo, hi := math.Min(got[0], got[1]), math.Max(got[0], got[1])
+	if math.Abs(lo-first) > tolerance || math.Abs(hi-second) > tolerance {
+		t.Errorf("%s: got %v, want %v and %v", name, got, first, second)
+	}
+}
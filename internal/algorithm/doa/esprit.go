@@ -4,35 +4,58 @@ import (
 	"fmt"
 	"math"
 	"math/cmplx"
+	"math/rand"
 	"sort"
 	"sync"
+	"time"
 
-	"gonum.org/v1/gonum/mat"
+	"isac-cran-system/internal/algorithm/array"
+	"isac-cran-system/internal/algorithm/covariance"
+	"isac-cran-system/internal/model"
 )
 
+// ESPRIT exploits the shift invariance of a uniform linear array: the rows
+// of the signal subspace Es taken from two copies of the array displaced by
+// Δ elements satisfy Es2 = Es1·Ψ, and the eigenvalues of Ψ are
+// e^{j2πΔd·sin θ}, one per source.
+
+// ESPRITConfig describes a uniform linear array. ElementSpacing is in
+// wavelengths. SubarrayShift is the displacement Δ of the two subarrays in
+// elements; 1, the default, overlaps them the most, and larger shifts trade
+// aperture for a longer baseline but alias unless Δ·ElementSpacing ≤ 0.5.
+// Seed seeds the test signals.
 type ESPRITConfig struct {
 	NumAntennas    int     `json:"num_antennas"`
 	NumSources     int     `json:"num_sources"`
 	ElementSpacing float64 `json:"element_spacing"`
+	SubarrayShift  int     `json:"subarray_shift"`
 	SnapshotLength int     `json:"snapshot_length"`
 	SampleRate     float64 `json:"sample_rate"`
 	CarrierFreq    float64 `json:"carrier_freq"`
+	Seed           int64   `json:"seed"`
+
+	Covariance model.CovarianceOptions `json:"covariance"`
 }
 
 type ESPRITResult struct {
 	Angles         []float64 `json:"angles"`
 	RMSE           float64   `json:"rmse"`
+	CRB            float64   `json:"crb,omitempty"`
 	SuccessRate    float64   `json:"success_rate"`
 	ProcessingTime float64   `json:"processing_time"`
 	Eigenvalues    []float64 `json:"eigenvalues"`
 }
 
+// ESPRITEstimator solves the invariance equation by least squares, which
+// attributes all of the subspace error to Es2; TLS_ESPRITEstimator solves it
+// by total least squares.
 type ESPRITEstimator struct {
 	config         *ESPRITConfig
-	covMatrix      *mat.CDense
-	signalSubspace *mat.CDense
-	noiseSubspace  *mat.CDense
-	mu             sync.RWMutex
+	tls            bool
+	covMatrix      [][]complex128
+	signalSubspace [][]complex128
+	rng            *rand.Rand
+	mu             sync.Mutex
 }
 
 func NewESPRITEstimator(config *ESPRITConfig) *ESPRITEstimator {
@@ -42,288 +65,191 @@ func NewESPRITEstimator(config *ESPRITConfig) *ESPRITEstimator {
 	if config.SnapshotLength == 0 {
 		config.SnapshotLength = 256
 	}
+	if config.SubarrayShift == 0 {
+		config.SubarrayShift = 1
+	}
 	return &ESPRITEstimator{
 		config: config,
+		rng:    rand.New(rand.NewSource(config.Seed)),
 	}
 }
 
+// EstimateDOA returns the source angles in radians, in ascending order.
 func (e *ESPRITEstimator) EstimateDOA(receivedSignal [][]complex128) (*ESPRITResult, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
-
-	startTime := math.Float64bits(float64(0))
-	_ = startTime
+	start := time.Now()
 
 	M := e.config.NumAntennas
 	K := e.config.NumSources
-
 	if len(receivedSignal) != M {
 		return nil, fmt.Errorf("signal dimension mismatch: expected %d antennas, got %d", M, len(receivedSignal))
 	}
 
-	covMatrix := e.computeCovarianceMatrix(receivedSignal)
-
-	eigenvalues := e.computeEigenvalues(covMatrix)
-
-	signalSubspace, err := e.extractSignalSubspace(covMatrix, K)
+	R, err := covariance.Estimate(receivedSignal, e.config.Covariance)
 	if err != nil {
-		return nil, fmt.Errorf("extract signal subspace failed: %w", err)
+		R = covariance.Sample(receivedSignal)
 	}
+	eigenvalues, Es := signalSubspace(R, K)
 
-	angles, err := e.espritCore(signalSubspace, M, K)
+	angles, err := espritAngles(Es, e.config.ElementSpacing, e.config.SubarrayShift, e.tls)
 	if err != nil {
 		return nil, fmt.Errorf("esprit core computation failed: %w", err)
 	}
 
-	result := &ESPRITResult{
-		Angles:      angles,
-		Eigenvalues: eigenvalues,
-	}
-
-	e.covMatrix = covMatrix
-	e.signalSubspace = signalSubspace
-
-	return result, nil
-}
-
-func (e *ESPRITEstimator) computeCovarianceMatrix(X [][]complex128) *mat.CDense {
-	M := len(X)
-	N := len(X[0])
-
-	cov := mat.NewCDense(M, M, nil)
-
-	for i := 0; i < M; i++ {
-		for j := 0; j < M; j++ {
-			var sum complex128
-			for t := 0; t < N; t++ {
-				sum += X[i][t] * cmplx.Conj(X[j][t])
-			}
-			cov.Set(i, j, sum/complex(float64(N), 0))
-		}
-	}
-
-	return cov
+	e.covMatrix = R
+	e.signalSubspace = Es
+	return &ESPRITResult{
+		Angles:         angles,
+		Eigenvalues:    eigenvalues,
+		ProcessingTime: time.Since(start).Seconds(),
+	}, nil
 }
 
-func (e *ESPRITEstimator) computeEigenvalues(covMatrix *mat.CDense) []float64 {
-	M, _ := covMatrix.Dims()
-
-	realCov := mat.NewDense(M, M, nil)
-	for i := 0; i < M; i++ {
-		for j := 0; j < M; j++ {
-			val := covMatrix.At(i, j)
-			if i == j {
-				realCov.Set(i, j, real(val))
-			} else {
-				realCov.Set(i, j, real(val*cmplx.Conj(val)))
-			}
+// signalSubspace returns the eigenvalues of R in descending order and the
+// M×K matrix whose columns are the K dominant eigenvectors.
+func signalSubspace(R [][]complex128, K int) ([]float64, [][]complex128) {
+	eigenvalues, eigenvectors := hermitianEigen(R)
+	Es := complexMatrix(len(R), K)
+	for k := 0; k < K; k++ {
+		for m := range Es {
+			Es[m][k] = eigenvectors[k][m]
 		}
 	}
-
-	var eig mat.Eigen
-	ok := eig.Factorize(realCov, mat.EigenNone)
-	if !ok {
-		return make([]float64, M)
-	}
-
-	eigenvalues := make([]float64, M)
-	for i := 0; i < M; i++ {
-		eigenvalues[i] = real(eig.Values(nil)[i])
-	}
-
-	sort.Sort(sort.Reverse(sort.Float64Slice(eigenvalues)))
-
-	return eigenvalues
+	return eigenvalues, Es
 }
 
-func (e *ESPRITEstimator) extractSignalSubspace(covMatrix *mat.CDense, numSources int) (*mat.CDense, error) {
-	M, _ := covMatrix.Dims()
-
-	realCov := mat.NewDense(M, M, nil)
-	for i := 0; i < M; i++ {
-		for j := 0; j < M; j++ {
-			val := covMatrix.At(i, j)
-			if i == j {
-				realCov.Set(i, j, real(val))
-			} else {
-				realCov.Set(i, j, real(val*cmplx.Conj(val)))
-			}
-		}
+// espritAngles solves the invariance equation between the first and the
+// last M-shift rows of the signal subspace and converts the eigenvalues of
+// Ψ to angles in radians, in ascending order.
+func espritAngles(Es [][]complex128, spacing float64, shift int, tls bool) ([]float64, error) {
+	M, K := len(Es), len(Es[0])
+	if shift < 1 || M-shift < K {
+		return nil, fmt.Errorf("subarrays of %d elements cannot resolve %d sources", M-shift, K)
 	}
+	Es1, Es2 := Es[:M-shift], Es[shift:]
 
-	var svd mat.SVD
-	ok := svd.Factorize(realCov, mat.SVDFull)
-	if !ok {
-		return nil, fmt.Errorf("SVD factorization failed")
+	var psi [][]complex128
+	var err error
+	if tls {
+		psi, err = tlsRotation(Es1, Es2)
+	} else {
+		psi, err = solve(multiply(conjugateTranspose(Es1), Es1, false), multiply(conjugateTranspose(Es1), Es2, false))
 	}
-
-	var u mat.Dense
-	svd.UTo(&u)
-
-	signalSubspace := mat.NewCDense(M-numSources, numSources, nil)
-	for i := 0; i < M-numSources; i++ {
-		for j := 0; j < numSources; j++ {
-			signalSubspace.Set(i, j, complex(u.At(i, j), 0))
-		}
+	if err != nil {
+		return nil, err
 	}
-
-	return signalSubspace, nil
-}
-
-func (e *ESPRITEstimator) espritCore(signalSubspace *mat.CDense, M, K int) ([]float64, error) {
-	rows, cols := signalSubspace.Dims()
-	if rows < M-1 {
-		return nil, fmt.Errorf("signal subspace too small")
+	values, err := complexEigenvalues(psi)
+	if err != nil {
+		return nil, err
 	}
 
-	Us1 := mat.NewCDense(rows, cols, nil)
-	Us2 := mat.NewCDense(rows, cols, nil)
-
-	for i := 0; i < rows; i++ {
-		for j := 0; j < cols; j++ {
-			Us1.Set(i, j, signalSubspace.At(i, j))
-			if i+1 < rows {
-				Us2.Set(i, j, signalSubspace.At(i+1, j))
-			}
-		}
+	angles := make([]float64, K)
+	for i, v := range values {
+		sinTheta := cmplx.Phase(v) / (2 * math.Pi * spacing * float64(shift))
+		angles[i] = math.Asin(math.Max(-1, math.Min(1, sinTheta)))
 	}
-
-	psi := e.solveRotationMatrix(Us1, Us2, K)
-
-	angles := e.extractAngles(psi, K)
-
+	sort.Float64s(angles)
 	return angles, nil
 }
 
-func (e *ESPRITEstimator) solveRotationMatrix(Us1, Us2 *mat.CDense, K int) *mat.CDense {
-	rows, cols := Us1.Dims()
-
-	Us1Real := mat.NewDense(rows, cols, nil)
-	Us2Real := mat.NewDense(rows, cols, nil)
-
-	for i := 0; i < rows; i++ {
-		for j := 0; j < cols; j++ {
-			Us1Real.Set(i, j, real(Us1.At(i, j)))
-			Us2Real.Set(i, j, real(Us2.At(i, j)))
+// tlsRotation is the total least squares solution of Es2 = Es1·Ψ: with V
+// the eigenvectors of [Es1 Es2]ᴴ[Es1 Es2] in descending order, split into
+// K×K blocks, Ψ = -V12·V22⁻¹, where V12 and V22 span its K weakest
+// directions.
+func tlsRotation(Es1, Es2 [][]complex128) ([][]complex128, error) {
+	K := len(Es1[0])
+	C := complexMatrix(len(Es1), 2*K)
+	for i := range C {
+		copy(C[i], Es1[i])
+		copy(C[i][K:], Es2[i])
+	}
+	_, vectors := hermitianEigen(multiply(conjugateTranspose(C), C, false))
+
+	V12, V22 := complexMatrix(K, K), complexMatrix(K, K)
+	for c := 0; c < K; c++ {
+		for r := 0; r < K; r++ {
+			V12[r][c] = vectors[K+c][r]
+			V22[r][c] = vectors[K+c][K+r]
 		}
 	}
-
-	var Us1T mat.Dense
-	Us1T.CloneFrom(Us1Real.T())
-
-	var Us1TUs1 mat.Dense
-	Us1TUs1.Mul(&Us1T, Us1Real)
-
-	var inv mat.Dense
-	err := inv.Inverse(&Us1TUs1)
+	inverse, err := solve(V22, identity(K))
 	if err != nil {
-		psi := mat.NewCDense(K, K, nil)
-		for i := 0; i < K; i++ {
-			psi.Set(i, i, cmplx.Exp(complex(0, float64(i)*math.Pi/float64(K))))
-		}
-		return psi
+		return nil, err
 	}
-
-	var Us1TUs2 mat.Dense
-	Us1TUs2.Mul(&Us1T, Us2Real)
-
-	var psiReal mat.Dense
-	psiReal.Mul(&inv, &Us1TUs2)
-
-	psi := mat.NewCDense(K, K, nil)
-	for i := 0; i < K; i++ {
-		for j := 0; j < K; j++ {
-			psi.Set(i, j, complex(psiReal.At(i, j), 0))
+	psi := multiply(V12, inverse, false)
+	for i := range psi {
+		for j := range psi[i] {
+			psi[i][j] = -psi[i][j]
 		}
 	}
-
-	return psi
+	return psi, nil
 }
 
-func (e *ESPRITEstimator) extractAngles(psi *mat.CDense, K int) []float64 {
-	angles := make([]float64, K)
-
-	rows, _ := psi.Dims()
-	K_actual := min(K, rows)
-
-	for i := 0; i < K_actual; i++ {
-		diagVal := psi.At(i, i)
-		phase := cmplx.Phase(diagVal)
-
-		d := e.config.ElementSpacing
-		wavelength := 1.0
-		if e.config.CarrierFreq > 0 {
-			wavelength = 3e8 / e.config.CarrierFreq
-			d = e.config.ElementSpacing * wavelength
-		}
-
-		sinTheta := phase * wavelength / (2 * math.Pi * d)
-
-		if sinTheta > 1 {
-			sinTheta = 1
-		} else if sinTheta < -1 {
-			sinTheta = -1
+// ulaSpacing returns the element spacing of g if it is a uniform linear
+// array along y, the axis whose phase progression is 2πd·sin(azimuth).
+func ulaSpacing(g *array.Geometry) (float64, bool) {
+	if g.Len() < 2 {
+		return 0, false
+	}
+	first := g.Elements[0].Position
+	spacing := g.Elements[1].Position.Y - first.Y
+	for n, el := range g.Elements {
+		p := el.Position
+		if math.Abs(p.X-first.X) > 1e-9 || math.Abs(p.Z-first.Z) > 1e-9 ||
+			math.Abs(p.Y-first.Y-float64(n)*spacing) > 1e-9 {
+			return 0, false
 		}
-
-		angles[i] = math.Asin(sinTheta)
-	}
-
-	for i := K_actual; i < K; i++ {
-		angles[i] = -math.Pi/4 + float64(i)*math.Pi/(2*float64(K))
 	}
-
-	return angles
+	return spacing, spacing != 0
 }
 
+// GenerateTestSignal simulates independent unit-power complex Gaussian
+// sources at trueAngles, in radians, and white noise at snrDB per source.
 func (e *ESPRITEstimator) GenerateTestSignal(trueAngles []float64, snrDB float64) [][]complex128 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	M := e.config.NumAntennas
 	N := e.config.SnapshotLength
-	K := len(trueAngles)
-
-	X := make([][]complex128, M)
-	for i := range X {
-		X[i] = make([]complex128, N)
+	g := array.NewULA(M, e.config.ElementSpacing)
+	steerings := make([][]complex128, len(trueAngles))
+	for k, angle := range trueAngles {
+		steerings[k] = g.SteeringVector(angle)
 	}
 
-	d := e.config.ElementSpacing
-	snrLinear := math.Pow(10, snrDB/10)
-
+	X := complexMatrix(M, N)
+	noiseStd := math.Sqrt(math.Pow(10, -snrDB/10) / 2)
 	for t := 0; t < N; t++ {
-		for k := 0; k < K; k++ {
-			signal := cmplx.Exp(complex(0, 2*math.Pi*float64(t)*0.01))
-
-			for m := 0; m < M; m++ {
-				phase := 2 * math.Pi * float64(m) * d * math.Sin(trueAngles[k])
-				steering := cmplx.Exp(complex(0, phase))
-				X[m][t] += steering * signal
+		for _, a := range steerings {
+			s := complex(e.rng.NormFloat64(), e.rng.NormFloat64()) / math.Sqrt2
+			for m := range X {
+				X[m][t] += a[m] * s
 			}
 		}
-	}
-
-	noisePower := 1.0 / snrLinear
-	for m := 0; m < M; m++ {
-		for t := 0; t < N; t++ {
-			noiseReal := randNorm() * math.Sqrt(noisePower/2)
-			noiseImag := randNorm() * math.Sqrt(noisePower/2)
-			X[m][t] += complex(noiseReal, noiseImag)
+		for m := range X {
+			X[m][t] += complex(e.rng.NormFloat64()*noiseStd, e.rng.NormFloat64()*noiseStd)
 		}
 	}
-
 	return X
 }
 
+// ComputeRMSE pairs the estimates with the true angles in ascending order.
 func (e *ESPRITEstimator) ComputeRMSE(estimatedAngles, trueAngles []float64) float64 {
 	if len(estimatedAngles) != len(trueAngles) {
 		return math.Inf(1)
 	}
+	estimated := append([]float64(nil), estimatedAngles...)
+	truth := append([]float64(nil), trueAngles...)
+	sort.Float64s(estimated)
+	sort.Float64s(truth)
 
 	var sumSquaredError float64
-	for i := range estimatedAngles {
-		err := estimatedAngles[i] - trueAngles[i]
+	for i := range estimated {
+		err := estimated[i] - truth[i]
 		sumSquaredError += err * err
 	}
-
-	return math.Sqrt(sumSquaredError / float64(len(estimatedAngles)))
+	return math.Sqrt(sumSquaredError / float64(len(estimated)))
 }
 
 func (e *ESPRITEstimator) EstimateWithPerformance(trueAngles []float64, snrDB float64) (*ESPRITResult, error) {
@@ -339,12 +265,16 @@ func (e *ESPRITEstimator) EstimateWithPerformance(trueAngles []float64, snrDB fl
 	return result, nil
 }
 
+// MonteCarloSimulation reports, for each SNR, the RMSE over all trials, the
+// share of trials whose RMSE is below 0.1 rad and the CRB pooled over the
+// sources like the RMSE. Failed trials count as misses and do not enter the
+// RMSE.
 func (e *ESPRITEstimator) MonteCarloSimulation(trueAngles []float64, snrRange []float64, numTrials int) map[float64]*ESPRITResult {
 	results := make(map[float64]*ESPRITResult)
 
 	for _, snr := range snrRange {
-		var totalRMSE float64
-		var successCount int
+		var sumSquaredError float64
+		var estimated, successCount int
 
 		for trial := 0; trial < numTrials; trial++ {
 			result, err := e.EstimateWithPerformance(trueAngles, snr)
@@ -352,35 +282,54 @@ func (e *ESPRITEstimator) MonteCarloSimulation(trueAngles []float64, snrRange []
 				continue
 			}
 
-			totalRMSE += result.RMSE
+			estimated++
+			sumSquaredError += result.RMSE * result.RMSE
 			if result.RMSE < 0.1 {
 				successCount++
 			}
 		}
 
-		avgRMSE := totalRMSE / float64(numTrials)
-		successRate := float64(successCount) / float64(numTrials)
-
-		results[snr] = &ESPRITResult{
-			RMSE:        avgRMSE,
-			SuccessRate: successRate,
+		summary := &ESPRITResult{RMSE: math.Inf(1)}
+		if estimated > 0 {
+			summary.RMSE = math.Sqrt(sumSquaredError / float64(estimated))
 		}
+		if numTrials > 0 {
+			summary.SuccessRate = float64(successCount) / float64(numTrials)
+		}
+		summary.CRB = e.crb(trueAngles, snr)
+		results[snr] = summary
 	}
 
 	return results
 }
 
-func (e *ESPRITEstimator) CompareWithMUSIC(trueAngles []float64, snrDB float64) (map[string]interface{}, error) {
-	espritResult, err := e.EstimateWithPerformance(trueAngles, snrDB)
+// crb is the root mean square over the sources of their CRB at snrDB, or
+// 0 when the array cannot resolve them.
+func (e *ESPRITEstimator) crb(trueAngles []float64, snrDB float64) float64 {
+	g := array.NewULA(e.config.NumAntennas, e.config.ElementSpacing)
+	bounds, err := CRB(g, trueAngles, math.Pow(10, snrDB/10), e.config.SnapshotLength)
 	if err != nil {
-		return nil, err
+		return 0
+	}
+	var sum float64
+	for _, b := range bounds {
+		sum += b * b
 	}
+	return math.Sqrt(sum / float64(len(bounds)))
+}
 
-	musicAngles := make([]float64, len(trueAngles))
-	for i := range trueAngles {
-		musicAngles[i] = trueAngles[i] + randNorm()*0.05
+// CompareWithMUSIC runs ESPRIT and MUSIC, on a 0.5° grid, on the same test
+// signal.
+func (e *ESPRITEstimator) CompareWithMUSIC(trueAngles []float64, snrDB float64) (map[string]interface{}, error) {
+	signal := e.GenerateTestSignal(trueAngles, snrDB)
+	espritResult, err := e.EstimateDOA(signal)
+	if err != nil {
+		return nil, err
 	}
+	espritResult.RMSE = e.ComputeRMSE(espritResult.Angles, trueAngles)
 
+	music := NewMUSIC(e.config.NumAntennas, e.config.NumSources, e.config.ElementSpacing)
+	musicAngles := music.EstimateDOA(covariance.Sample(signal))
 	musicRMSE := e.ComputeRMSE(musicAngles, trueAngles)
 
 	comparison := map[string]interface{}{
@@ -394,85 +343,12 @@ func (e *ESPRITEstimator) CompareWithMUSIC(trueAngles []float64, snrDB float64)
 	return comparison, nil
 }
 
-func randNorm() float64 {
-	u1 := randFloat64()
-	u2 := randFloat64()
-
-	for u1 == 0 {
-		u1 = randFloat64()
-	}
-
-	return math.Sqrt(-2*math.Log(u1)) * math.Cos(2*math.Pi*u2)
-}
-
-func randFloat64() float64 {
-	return float64(int64(123456789+int(1000*math.Sin(float64(0))))%(1<<30)) / float64(1<<30)
-}
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
 type TLS_ESPRITEstimator struct {
 	*ESPRITEstimator
 }
 
 func NewTLS_ESPRITEstimator(config *ESPRITConfig) *TLS_ESPRITEstimator {
-	return &TLS_ESPRITEstimator{
-		ESPRITEstimator: NewESPRITEstimator(config),
-	}
-}
-
-func (e *TLS_ESPRITEstimator) EstimateDOA(receivedSignal [][]complex128) (*ESPRITResult, error) {
-	M := e.config.NumAntennas
-	K := e.config.NumSources
-
-	covMatrix := e.computeCovarianceMatrix(receivedSignal)
-
-	signalSubspace, err := e.extractSignalSubspace(covMatrix, K)
-	if err != nil {
-		return nil, err
-	}
-
-	angles, err := e.tlsEspritCore(signalSubspace, M, K)
-	if err != nil {
-		return nil, err
-	}
-
-	return &ESPRITResult{
-		Angles: angles,
-	}, nil
-}
-
-func (e *TLS_ESPRITEstimator) tlsEspritCore(signalSubspace *mat.CDense, M, K int) ([]float64, error) {
-	rows, cols := signalSubspace.Dims()
-
-	Us1 := mat.NewCDense(rows-1, cols, nil)
-	Us2 := mat.NewCDense(rows-1, cols, nil)
-
-	for i := 0; i < rows-1; i++ {
-		for j := 0; j < cols; j++ {
-			Us1.Set(i, j, signalSubspace.At(i, j))
-			Us2.Set(i, j, signalSubspace.At(i+1, j))
-		}
-	}
-
-	combined := mat.NewCDense(rows-1, 2*cols, nil)
-	for i := 0; i < rows-1; i++ {
-		for j := 0; j < cols; j++ {
-			combined.Set(i, j, Us1.At(i, j))
-			combined.Set(i, j+cols, Us2.At(i, j))
-		}
-	}
-
-	angles := make([]float64, K)
-	for i := 0; i < K; i++ {
-		phase := 2 * math.Pi * float64(i) / float64(K)
-		angles[i] = math.Asin(phase / (2 * math.Pi * e.config.ElementSpacing))
-	}
-
-	return angles, nil
+	e := NewESPRITEstimator(config)
+	e.tls = true
+	return &TLS_ESPRITEstimator{ESPRITEstimator: e}
 }
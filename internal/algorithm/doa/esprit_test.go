@@ -0,0 +1,127 @@
+package doa
+
+import (
+	"math"
+	"math/cmplx"
+	"sort"
+	"testing"
+
+	"isac-cran-system/internal/algorithm/array"
+	"isac-cran-system/internal/model"
+)
+
+// espritCRB is the stochastic Cramér-Rao bound on the variance, in rad², of
+// the angle of one unit-power source seen by an M-element ULA over N
+// snapshots at snr.
+func espritCRB(M, N int, spacing, theta, snr float64) float64 {
+	m := float64(M)
+	phase := 6 / (float64(N) * m * (m*m - 1) * snr) * (1 + 1/(m*snr))
+	slope := 2 * math.Pi * spacing * math.Cos(theta)
+	return phase / (slope * slope)
+}
+
+func TestESPRIT_MonteCarloNearCRB(t *testing.T) {
+	const (
+		M      = 8
+		N      = 200
+		snrDB  = 10.0
+		trials = 200
+	)
+	theta := 20 * math.Pi / 180
+	bound := math.Sqrt(espritCRB(M, N, 0.5, theta, math.Pow(10, snrDB/10)))
+
+	for _, tls := range []bool{false, true} {
+		config := &ESPRITConfig{NumAntennas: M, NumSources: 1, SnapshotLength: N, Seed: 7}
+		e := NewESPRITEstimator(config)
+		if tls {
+			e = NewTLS_ESPRITEstimator(config).ESPRITEstimator
+		}
+		summary := e.MonteCarloSimulation([]float64{theta}, []float64{snrDB}, trials)[snrDB]
+		rmse := summary.RMSE
+		if math.Abs(summary.CRB-bound) > 1e-6*bound {
+			t.Errorf("tls=%v: reported CRB %.6g rad, want %.6g rad", tls, summary.CRB, bound)
+		}
+		t.Logf("tls=%v: RMSE %.4f°, CRB %.4f°", tls, rmse*180/math.Pi, bound*180/math.Pi)
+		// ESPRIT is not efficient, but at this SNR it comes close to the bound
+		if rmse < 0.9*bound || rmse > 2*bound {
+			t.Errorf("tls=%v: RMSE %.4g rad, want within [0.9, 2]×√CRB = %.4g rad", tls, rmse, bound)
+		}
+	}
+}
+
+func TestESPRIT_TwoSources(t *testing.T) {
+	trueAngles := []float64{-15 * math.Pi / 180, 10 * math.Pi / 180}
+	for _, shift := range []int{1, 2} {
+		config := &ESPRITConfig{NumAntennas: 10, NumSources: 2, SubarrayShift: shift, ElementSpacing: 0.25, Seed: 3}
+		e := NewTLS_ESPRITEstimator(config)
+		result, err := e.EstimateWithPerformance(trueAngles, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertAngles(t, "TLS-ESPRIT", radiansToDegrees(result.Angles), -15, 10, 0.5)
+		if result.ProcessingTime <= 0 {
+			t.Error("processing time was not measured")
+		}
+	}
+
+	// subarrays of M-shift elements must hold the signal subspace
+	e := NewESPRITEstimator(&ESPRITConfig{NumAntennas: 4, NumSources: 2, SubarrayShift: 3})
+	if _, err := e.EstimateWithPerformance(trueAngles, 20); err == nil {
+		t.Error("subarrays smaller than the source count were accepted")
+	}
+}
+
+func TestComplexEigenvalues(t *testing.T) {
+	// A = V·diag(λ)·V⁻¹ with distinct unit-modulus λ, like an ESPRIT Ψ
+	want := []complex128{cmplx.Rect(1, 0.4), cmplx.Rect(1, -1.1), cmplx.Rect(0.9, 2.5)}
+	V := [][]complex128{{1, 0.5i, 0.2}, {0.3, 1, -0.4i}, {0.1i, 0.2, 1}}
+	D := complexMatrix(3, 3)
+	for i, v := range want {
+		D[i][i] = v
+	}
+	Vinv, err := solve(V, identity(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := complexEigenvalues(multiply(multiply(V, D, false), Vinv, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	phase := func(v []complex128) []float64 {
+		p := make([]float64, len(v))
+		for i := range v {
+			p[i] = cmplx.Phase(v[i])
+		}
+		sort.Float64s(p)
+		return p
+	}
+	got, expected := phase(values), phase(want)
+	for i := range got {
+		if math.Abs(got[i]-expected[i]) > 1e-9 {
+			t.Errorf("eigenvalue phases %v, want %v", got, expected)
+			break
+		}
+	}
+}
+
+func TestEstimator_ESPRIT(t *testing.T) {
+	g := array.NewULA(10, array.HalfWavelength)
+	R := coherentCovariance(g, -20, 25, 0.01)
+	params := &model.DOAParams{
+		NumSources: 2,
+		Method:     "ESPRIT",
+		Smoothing:  &model.SpatialSmoothingOptions{SubarraySize: 7, ForwardBackward: true},
+	}
+	e := NewEstimator(10, 2, 256, "ESPRIT")
+	result, err := e.EstimateCovariance(R, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAngles(t, "smoothed ESPRIT", radiansToDegrees(result.EstimatedAngles), -20, 25, 0.1)
+
+	// a planar array has no single shift invariance to exploit
+	planar := &model.DOAParams{NumSources: 1, Method: "ESPRIT", Rows: 3, Cols: 3}
+	if _, err := e.EstimateCovariance(complexMatrix(9, 9), planar); err == nil {
+		t.Error("ESPRIT on a planar array was accepted")
+	}
+}
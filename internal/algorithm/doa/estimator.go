@@ -2,10 +2,12 @@ package doa
 
 import (
 	"math"
-	"math/cmplx"
 
+	"isac-cran-system/internal/algorithm/array"
+	"isac-cran-system/internal/algorithm/covariance"
 	"isac-cran-system/internal/model"
 	"isac-cran-system/pkg/logger"
+	"isac-cran-system/pkg/pool"
 
 	"go.uber.org/zap"
 )
@@ -15,6 +17,10 @@ type Estimator struct {
 	numSources     int
 	snapshotLength int
 	method         string
+	geometry       *array.Geometry
+	covariance     model.CovarianceOptions
+	smoothing      model.SpatialSmoothingOptions
+	pool           *pool.WorkerPool
 }
 
 func NewEstimator(elementCount, numSources, snapshotLength int, method string) *Estimator {
@@ -26,118 +32,248 @@ func NewEstimator(elementCount, numSources, snapshotLength int, method string) *
 	}
 }
 
+// SetGeometry sets the receive array. Captures whose channel count differs
+// from it are treated as a half-wavelength ULA.
+func (e *Estimator) SetGeometry(g *array.Geometry) {
+	e.geometry = g
+}
+
+// SetCovariance sets the default covariance estimator; DOAParams.Covariance
+// overrides it per run.
+// SetWorkerPool lets MUSIC spread its grid search across pool workers.
+func (e *Estimator) SetWorkerPool(p *pool.WorkerPool) {
+	e.pool = p
+}
+
+func (e *Estimator) SetCovariance(opts model.CovarianceOptions) {
+	e.covariance = opts
+}
+
+// SetSmoothing sets the default spatial smoothing; DOAParams.Smoothing
+// overrides it per run.
+func (e *Estimator) SetSmoothing(opts model.SpatialSmoothingOptions) {
+	e.smoothing = opts
+}
+
+func (e *Estimator) arrayFor(elementCount int) *array.Geometry {
+	if e.geometry != nil && e.geometry.Len() == elementCount {
+		return e.geometry
+	}
+	return array.NewULA(elementCount, array.HalfWavelength)
+}
+
+// Estimate runs DOA estimation on an array response synthesized from the
+// reference signal data.
 func (e *Estimator) Estimate(data []complex128, params *model.DOAParams) (*model.DOAResult, error) {
+	return e.EstimateSnapshots(e.generateReceivedSignal(data, params), params)
+}
+
+// EstimateSnapshots runs DOA estimation on measured array snapshots, one row
+// per antenna element.
+func (e *Estimator) EstimateSnapshots(X [][]complex128, params *model.DOAParams) (*model.DOAResult, error) {
+	if len(X) <= params.NumSources || len(X[0]) == 0 {
+		return nil, &model.ValidationError{Field: "element_count", Message: "need more antenna channels than sources and at least one snapshot"}
+	}
+
+	opts := e.covariance
+	if params.Covariance != nil {
+		opts = *params.Covariance
+	}
+	if params.SnapshotLength != len(X[0]) {
+		counted := *params
+		counted.SnapshotLength = len(X[0])
+		params = &counted
+	}
 	logger.Info("Starting DOA estimation",
 		zap.String("method", params.Method),
 		zap.Int("num_sources", params.NumSources),
+		zap.Int("channels", len(X)),
+		zap.String("covariance", opts.Method),
+		zap.Bool("wideband", params.Wideband != nil),
 	)
 
-	var spectrum []float64
-	var estimatedAngles []float64
-
-	switch params.Method {
-	case "MUSIC":
-		spectrum, estimatedAngles = e.musicAlgorithm(data, params)
-	case "ESPRIT":
-		estimatedAngles = e.espritAlgorithm(data, params)
-		spectrum = make([]float64, 360)
-	default:
-		spectrum, estimatedAngles = e.musicAlgorithm(data, params)
+	var result *model.DOAResult
+	var err error
+	if params.Wideband != nil {
+		result, err = e.estimateWideband(X, params, opts)
+	} else {
+		var covMatrix [][]complex128
+		covMatrix, err = covariance.Estimate(X, opts)
+		if err == nil {
+			result, err = e.EstimateCovariance(covMatrix, params)
+		}
+		if err == nil && result.Directions == nil && len(result.EstimatedAngles) > 0 {
+			result.CRB = e.bound(covMatrix, params, result.EstimatedAngles)
+		}
 	}
-
-	result := &model.DOAResult{
-		EstimatedAngles: estimatedAngles,
-		Spectrum:        spectrum,
+	if err != nil {
+		return nil, err
 	}
 
 	logger.Info("DOA estimation completed",
-		zap.Int("num_estimated", len(estimatedAngles)),
+		zap.Int("num_estimated", len(result.EstimatedAngles)),
+		zap.Int("subbands", result.Subbands),
 	)
 
 	return result, nil
 }
 
-func (e *Estimator) musicAlgorithm(data []complex128, params *model.DOAParams) ([]float64, []float64) {
-	X := e.generateReceivedSignal(data, params)
-
-	covMatrix := e.computeCovarianceMatrix(X)
-
-	_, eigenvectors := e.eigenDecomposition(covMatrix)
-
-	noiseSubspace := e.extractNoiseSubspace(eigenvectors, params.NumSources)
-
-	numPoints := 360
-	spectrum := make([]float64, numPoints)
-	d := 0.5
-
-	for i := 0; i < numPoints; i++ {
-		angle := -math.Pi/2 + float64(i)*math.Pi/float64(numPoints)
-
-		steering := make([]complex128, params.ElementCount)
-		for n := 0; n < params.ElementCount; n++ {
-			phase := 2 * math.Pi * float64(n) * d * math.Sin(angle)
-			steering[n] = cmplx.Exp(complex(0, phase))
+// EstimateCovariance runs DOA estimation on an already estimated spatial
+// covariance, such as one tracked from a continuous stream.
+func (e *Estimator) EstimateCovariance(covMatrix [][]complex128, params *model.DOAParams) (*model.DOAResult, error) {
+	geometry, err := e.geometryFor(params, len(covMatrix))
+	if err != nil {
+		return nil, err
+	}
+	params = scanParams(params)
+	smoothing := e.smoothing
+	if params.Planar() {
+		// the configured smoothing is meant for the configured linear array
+		smoothing = model.SpatialSmoothingOptions{}
+	}
+	if params.Smoothing != nil {
+		smoothing = *params.Smoothing
+		if smoothing.SubarraySize > 0 && params.Planar() {
+			return nil, &model.ValidationError{Field: "smoothing", Message: "spatial smoothing needs a linear array"}
 		}
-
-		var denom float64
-		for k := 0; k < len(noiseSubspace); k++ {
-			var proj complex128
-			for n := 0; n < params.ElementCount; n++ {
-				proj += cmplx.Conj(noiseSubspace[k][n]) * steering[n]
-			}
-			denom += cmplx.Abs(proj) * cmplx.Abs(proj)
+	}
+	if smoothing.SubarraySize > 0 {
+		smoothed, err := SpatialSmoothing(covMatrix, smoothing.SubarraySize, smoothing.ForwardBackward)
+		if err != nil {
+			return nil, err
+		}
+		covMatrix = smoothed
+		geometry = geometry.Subarray(smoothing.SubarraySize)
+	}
+	var detected *int
+	if params.SourceDetection != "" {
+		if !validSourceDetection(params.SourceDetection) {
+			return nil, &model.ValidationError{Field: "source_detection", Message: "unsupported source detection " + params.SourceDetection}
 		}
+		params, err = detectSources(covMatrix, params)
+		if err != nil {
+			return nil, err
+		}
+		detected = &params.NumSources
+		if params.NumSources == 0 {
+			return &model.DOAResult{EstimatedAngles: []float64{}, DetectedSources: detected}, nil
+		}
+	}
+	if len(covMatrix) <= params.NumSources {
+		return nil, &model.ValidationError{Field: "element_count", Message: "need more antenna channels than sources"}
+	}
 
-		if denom > 1e-10 {
-			spectrum[i] = 1.0 / denom
-		} else {
-			spectrum[i] = 1e10
+	result := &model.DOAResult{DetectedSources: detected}
+	if params.Method == "ESPRIT" {
+		angles, err := e.esprit(covMatrix, geometry, params)
+		if err != nil {
+			return nil, err
+		}
+		result.EstimatedAngles = angles
+	} else {
+		scan, err := e.music(covMatrix, geometry, params)
+		if err != nil {
+			return nil, err
 		}
+		scan.fill(result)
 	}
+	return result, nil
+}
 
-	estimatedAngles := e.findPeaks(spectrum, params.NumSources)
+// bound is the CRB at the estimated angles over the full array, or nil
+// when the covariance does not support one.
+func (e *Estimator) bound(covMatrix [][]complex128, params *model.DOAParams, angles []float64) []float64 {
+	geometry, err := e.geometryFor(params, len(covMatrix))
+	if err != nil {
+		return nil
+	}
+	crb, err := covarianceCRB(covMatrix, geometry, angles, params.SnapshotLength)
+	if err != nil {
+		logger.Debug("No Cramér–Rao bound for the DOA estimate", zap.Error(err))
+		return nil
+	}
+	return crb
+}
 
-	return spectrum, estimatedAngles
+// SynthesizeSnapshots builds the array response Estimate would use, so
+// callers can modify it before EstimateSnapshots.
+func (e *Estimator) SynthesizeSnapshots(data []complex128, params *model.DOAParams) [][]complex128 {
+	return e.generateReceivedSignal(data, params)
 }
 
-func (e *Estimator) espritAlgorithm(data []complex128, params *model.DOAParams) []float64 {
-	X := e.generateReceivedSignal(data, params)
+func (e *Estimator) musicAlgorithm(data []complex128, params *model.DOAParams) ([]float64, []float64) {
+	return e.musicSpectrum(e.computeCovarianceMatrix(e.generateReceivedSignal(data, params)), params)
+}
+
+func (e *Estimator) musicSpectrum(covMatrix [][]complex128, params *model.DOAParams) ([]float64, []float64) {
+	result, err := e.music(covMatrix, e.arrayFor(len(covMatrix)), params)
+	if err != nil {
+		return nil, nil
+	}
+	return result.spectrum, result.azimuths
+}
 
-	covMatrix := e.computeCovarianceMatrix(X)
+func (e *Estimator) music(covMatrix [][]complex128, geometry *array.Geometry, params *model.DOAParams) (*scanResult, error) {
+	_, eigenvectors := hermitianEigen(covMatrix)
+	noiseSubspace := e.extractNoiseSubspace(eigenvectors, params.NumSources)
+	return e.scan(e.noisePower(noiseSubspace, geometry), params)
+}
 
-	_, eigenvectors := e.eigenDecomposition(covMatrix)
+func (e *Estimator) scan(spectrum pseudoSpectrum, params *model.DOAParams) (*scanResult, error) {
+	if params.Refine {
+		return e.refineScan(spectrum, params, params.NumSources)
+	}
+	grid, err := newSearchGrid(params)
+	if err != nil {
+		return nil, err
+	}
+	return e.musicScan(spectrum, grid, params.NumSources), nil
+}
 
-	signalSubspace := make([][]complex128, params.NumSources)
-	for i := 0; i < params.NumSources; i++ {
-		signalSubspace[i] = eigenvectors[i]
+// esprit estimates with TLS-ESPRIT, which needs the shift invariance of a
+// uniform linear array and gives no spectrum.
+func (e *Estimator) esprit(covMatrix [][]complex128, geometry *array.Geometry, params *model.DOAParams) ([]float64, error) {
+	spacing, ok := ulaSpacing(geometry)
+	if !ok {
+		return nil, &model.ValidationError{Field: "method", Message: "ESPRIT needs a uniform linear array"}
 	}
+	_, Es := signalSubspace(covMatrix, params.NumSources)
+	return espritAngles(Es, spacing, 1, true)
+}
 
-	angles := make([]float64, params.NumSources)
+func (e *Estimator) generateReceivedSignal(data []complex128, params *model.DOAParams) [][]complex128 {
+	elementCount := params.Elements()
+	sourceAngles := make([]float64, params.NumSources)
 	for i := 0; i < params.NumSources; i++ {
-		angles[i] = -math.Pi/4 + float64(i)*math.Pi/(2*float64(params.NumSources))
+		sourceAngles[i] = -math.Pi/3 + float64(i)*math.Pi/(3*float64(params.NumSources))
 	}
+	sourceElevations := syntheticElevations(params)
 
-	return angles
-}
+	geometry, err := e.geometryFor(params, elementCount)
+	if err != nil {
+		// EstimateSnapshots reports the invalid array
+		geometry = e.arrayFor(elementCount)
+	}
+	if params.Wideband != nil {
+		if X := widebandSnapshots(geometry, params, sourceAngles, sourceElevations); X != nil {
+			return X
+		}
+	}
 
-func (e *Estimator) generateReceivedSignal(data []complex128, params *model.DOAParams) [][]complex128 {
-	X := make([][]complex128, params.ElementCount)
+	X := make([][]complex128, elementCount)
 	for i := range X {
 		X[i] = make([]complex128, params.SnapshotLength)
 	}
-
-	sourceAngles := make([]float64, params.NumSources)
-	for i := 0; i < params.NumSources; i++ {
-		sourceAngles[i] = -math.Pi/3 + float64(i)*math.Pi/(3*float64(params.NumSources))
+	steerings := make([][]complex128, params.NumSources)
+	for s, angle := range sourceAngles {
+		steerings[s] = geometry.SteeringVector3D(angle, sourceElevations[s])
 	}
 
-	d := 0.5
 	for t := 0; t < params.SnapshotLength; t++ {
-		for n := 0; n < params.ElementCount; n++ {
+		for n := 0; n < elementCount; n++ {
 			var signal complex128
 			for s := 0; s < params.NumSources; s++ {
-				phase := 2 * math.Pi * float64(n) * d * math.Sin(sourceAngles[s])
-				signal += cmplx.Exp(complex(0, phase)) * data[t%len(data)]
+				signal += steerings[s][n] * data[t%len(data)]
 			}
 			noise := complex(0.1*(randFloat()-0.5), 0.1*(randFloat()-0.5))
 			X[n][t] = signal + noise
@@ -147,58 +283,16 @@ func (e *Estimator) generateReceivedSignal(data []complex128, params *model.DOAP
 	return X
 }
 
+// computeCovarianceMatrix applies the default estimator, falling back to the
+// sample covariance if it is misconfigured.
 func (e *Estimator) computeCovarianceMatrix(X [][]complex128) [][]complex128 {
-	M := len(X)
-	N := len(X[0])
-
-	cov := make([][]complex128, M)
-	for i := range cov {
-		cov[i] = make([]complex128, M)
+	cov, err := covariance.Estimate(X, e.covariance)
+	if err != nil {
+		return covariance.Sample(X)
 	}
-
-	for i := 0; i < M; i++ {
-		for j := 0; j < M; j++ {
-			var sum complex128
-			for t := 0; t < N; t++ {
-				sum += X[i][t] * cmplx.Conj(X[j][t])
-			}
-			cov[i][j] = sum / complex(float64(N), 0)
-		}
-	}
-
 	return cov
 }
 
-func (e *Estimator) eigenDecomposition(matrix [][]complex128) ([]float64, [][]complex128) {
-	n := len(matrix)
-
-	eigenvalues := make([]float64, n)
-	eigenvectors := make([][]complex128, n)
-	for i := range eigenvectors {
-		eigenvectors[i] = make([]complex128, n)
-		eigenvectors[i][i] = 1
-	}
-
-	for i := 0; i < n; i++ {
-		var sum float64
-		for j := 0; j < n; j++ {
-			sum += cmplx.Abs(matrix[i][j]) * cmplx.Abs(matrix[i][j])
-		}
-		eigenvalues[i] = sum / float64(n)
-	}
-
-	for i := 0; i < n; i++ {
-		for j := i + 1; j < n; j++ {
-			if eigenvalues[i] < eigenvalues[j] {
-				eigenvalues[i], eigenvalues[j] = eigenvalues[j], eigenvalues[i]
-				eigenvectors[i], eigenvectors[j] = eigenvectors[j], eigenvectors[i]
-			}
-		}
-	}
-
-	return eigenvalues, eigenvectors
-}
-
 func (e *Estimator) extractNoiseSubspace(eigenvectors [][]complex128, numSources int) [][]complex128 {
 	M := len(eigenvectors)
 	noiseDim := M - numSources
@@ -211,40 +305,6 @@ func (e *Estimator) extractNoiseSubspace(eigenvectors [][]complex128, numSources
 	return noiseSubspace
 }
 
-func (e *Estimator) findPeaks(spectrum []float64, numPeaks int) []float64 {
-	type peak struct {
-		index int
-		value float64
-	}
-
-	peaks := make([]peak, 0)
-
-	for i := 1; i < len(spectrum)-1; i++ {
-		if spectrum[i] > spectrum[i-1] && spectrum[i] > spectrum[i+1] {
-			peaks = append(peaks, peak{index: i, value: spectrum[i]})
-		}
-	}
-
-	for i := 0; i < len(peaks)-1; i++ {
-		for j := i + 1; j < len(peaks); j++ {
-			if peaks[i].value < peaks[j].value {
-				peaks[i], peaks[j] = peaks[j], peaks[i]
-			}
-		}
-	}
-
-	if len(peaks) > numPeaks {
-		peaks = peaks[:numPeaks]
-	}
-
-	angles := make([]float64, len(peaks))
-	for i, p := range peaks {
-		angles[i] = -math.Pi/2 + float64(p.index)*math.Pi/float64(len(spectrum))
-	}
-
-	return angles
-}
-
 func randFloat() float64 {
 	return float64(int64(123456789)%(1<<30)) / float64(1<<30)
 }
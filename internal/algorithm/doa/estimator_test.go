@@ -1,10 +1,14 @@
 package doa
 
 import (
+	"math"
 	"math/cmplx"
+	"math/rand"
 	"testing"
 
+	"isac-cran-system/internal/algorithm/array"
 	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/pool"
 )
 
 func TestEstimator_Estimate(t *testing.T) {
@@ -33,6 +37,38 @@ func TestEstimator_Estimate(t *testing.T) {
 	}
 }
 
+func TestEstimator_EstimateSnapshots(t *testing.T) {
+	estimator := NewEstimator(8, 1, 256, "MUSIC")
+	X := snapshotsFrom(array.NewULA(8, array.HalfWavelength), 20, 0, 256)
+
+	params := &model.DOAParams{
+		ElementCount:   8,
+		NumSources:     1,
+		SnapshotLength: 256,
+		Method:         "MUSIC",
+		SearchRangeMin: -90,
+		SearchRangeMax: 90,
+		SearchStep:     0.1,
+	}
+	result, err := estimator.EstimateSnapshots(X, params)
+	if err != nil {
+		t.Fatalf("EstimateSnapshots failed: %v", err)
+	}
+	if len(result.Spectrum) != 1801 {
+		t.Errorf("spectrum has %d points, want 1801", len(result.Spectrum))
+	}
+	if len(result.EstimatedAngles) != 1 || math.Abs(result.EstimatedAngles[0]*180/math.Pi-20) > 0.5 {
+		t.Errorf("estimated angles %v, want 20°", radiansToDegrees(result.EstimatedAngles))
+	}
+
+	if _, err := estimator.EstimateSnapshots(X[:1], params); !model.IsValidationError(err) {
+		t.Errorf("one channel: error = %v, want a validation error", err)
+	}
+	if _, err := estimator.EstimateSnapshots([][]complex128{{}, {}, {}}, params); !model.IsValidationError(err) {
+		t.Errorf("no snapshots: error = %v, want a validation error", err)
+	}
+}
+
 func TestEstimator_MUSICAlgorithm(t *testing.T) {
 	estimator := NewEstimator(64, 3, 1024, "MUSIC")
 
@@ -123,3 +159,124 @@ func BenchmarkEstimator_Estimate(b *testing.B) {
 		_, _ = estimator.Estimate(data, params)
 	}
 }
+
+func snapshotsFrom(g *array.Geometry, azimuth, elevation float64, n int) [][]complex128 {
+	rng := rand.New(rand.NewSource(1))
+	a := g.SteeringVector3D(azimuth*math.Pi/180, elevation*math.Pi/180)
+	X := make([][]complex128, len(a))
+	for m := range X {
+		X[m] = make([]complex128, n)
+	}
+	for k := 0; k < n; k++ {
+		s := complex(rng.NormFloat64(), rng.NormFloat64())
+		for m := range X {
+			X[m][k] = a[m]*s + complex(0.05*rng.NormFloat64(), 0.05*rng.NormFloat64())
+		}
+	}
+	return X
+}
+
+func TestMUSIC_ParallelSearch(t *testing.T) {
+	X := snapshotsFrom(array.NewULA(8, array.HalfWavelength), 20, 0, 256)
+	params := &model.DOAParams{
+		NumSources:     1,
+		Method:         "MUSIC",
+		SearchRangeMin: -90,
+		SearchRangeMax: 90,
+		SearchStep:     0.1,
+	}
+
+	serial, err := NewEstimator(8, 1, 256, "MUSIC").EstimateSnapshots(X, params)
+	if err != nil {
+		t.Fatalf("serial search failed: %v", err)
+	}
+
+	p := pool.NewWorkerPool(4, 16)
+	p.Start()
+	defer p.Stop()
+	estimator := NewEstimator(8, 1, 256, "MUSIC")
+	estimator.SetWorkerPool(p)
+	parallel, err := estimator.EstimateSnapshots(X, params)
+	if err != nil {
+		t.Fatalf("parallel search failed: %v", err)
+	}
+
+	if len(parallel.Spectrum) != 1801 || len(parallel.Spectrum) != len(serial.Spectrum) {
+		t.Fatalf("spectrum length: serial %d, parallel %d", len(serial.Spectrum), len(parallel.Spectrum))
+	}
+	for i := range serial.Spectrum {
+		if serial.Spectrum[i] != parallel.Spectrum[i] {
+			t.Fatalf("spectrum differs at %d", i)
+		}
+	}
+	if len(parallel.EstimatedAngles) != len(serial.EstimatedAngles) {
+		t.Errorf("peaks differ: serial %v, parallel %v", serial.EstimatedAngles, parallel.EstimatedAngles)
+	}
+
+	params.Refine = true
+	refined, err := estimator.EstimateSnapshots(X, params)
+	if err != nil {
+		t.Fatalf("refined search failed: %v", err)
+	}
+	if len(refined.EstimatedAngles) > params.NumSources {
+		t.Errorf("expected at most %d refined angles, got %v", params.NumSources, refined.EstimatedAngles)
+	}
+	for _, az := range refined.EstimatedAngles {
+		if az < -math.Pi/2 || az > math.Pi/2 {
+			t.Errorf("refined angle %v outside the search range", az)
+		}
+	}
+}
+
+func TestMUSIC_SearchGrid2D(t *testing.T) {
+	g := array.NewURA(4, 4, array.HalfWavelength)
+	X := snapshotsFrom(g, 30, 20, 256)
+	estimator := NewEstimator(16, 1, 256, "MUSIC")
+	estimator.SetGeometry(g)
+
+	result, err := estimator.EstimateSnapshots(X, &model.DOAParams{
+		NumSources:     1,
+		Method:         "MUSIC",
+		SearchRangeMin: -90,
+		SearchRangeMax: 90,
+		SearchStep:     1,
+		ElevationMin:   0,
+		ElevationMax:   60,
+		ElevationStep:  1,
+	})
+	if err != nil {
+		t.Fatalf("2D search failed: %v", err)
+	}
+	if len(result.Spectrum2D) != 61 || len(result.Spectrum2D[0]) != 181 {
+		t.Fatalf("unexpected 2D spectrum shape %dx%d", len(result.Spectrum2D), len(result.Spectrum2D[0]))
+	}
+	if len(result.EstimatedElevations) != len(result.EstimatedAngles) {
+		t.Errorf("got %d elevations for %d azimuths", len(result.EstimatedElevations), len(result.EstimatedAngles))
+	}
+
+	_, err = estimator.EstimateSnapshots(X, &model.DOAParams{NumSources: 1, Method: "MUSIC", SearchStep: 0.001, ElevationStep: 0.001})
+	if err == nil {
+		t.Error("expected an oversized grid to be rejected")
+	}
+}
+
+func TestScanPoints(t *testing.T) {
+	cases := []struct {
+		params model.DOAParams
+		want   int
+	}{
+		{model.DOAParams{NumSources: 1}, 360},
+		{model.DOAParams{NumSources: 1, SearchRangeMin: -60, SearchRangeMax: 60, SearchStep: 1}, 121},
+		{model.DOAParams{NumSources: 2, SearchRangeMin: -90, SearchRangeMax: 90, SearchStep: 0.1, Refine: true}, 181 + 2*21},
+	}
+	for _, c := range cases {
+		got, err := ScanPoints(&c.params)
+		if err != nil || got != c.want {
+			t.Errorf("ScanPoints(%+v) = %d, %v, want %d", c.params, got, err, c.want)
+		}
+	}
+
+	if _, err := ScanPoints(&model.DOAParams{SearchRangeMin: -90, SearchRangeMax: 90, SearchStep: 1e-5, ElevationMin: -90, ElevationMax: 90, ElevationStep: 1}); err == nil {
+		t.Error("expected an error for a grid that is too fine")
+	}
+}
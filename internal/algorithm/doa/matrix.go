@@ -0,0 +1,208 @@
+package doa
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+)
+
+// qrIterationsPerValue bounds the shifted QR iterations spent on each
+// eigenvalue before complexEigenvalues gives up.
+const qrIterationsPerValue = 100
+
+var (
+	errSingularMatrix = errors.New("matrix is singular")
+	errNoConvergence  = errors.New("eigenvalue iteration did not converge")
+)
+
+func complexMatrix(rows, cols int) [][]complex128 {
+	A := make([][]complex128, rows)
+	for i := range A {
+		A[i] = make([]complex128, cols)
+	}
+	return A
+}
+
+func identity(n int) [][]complex128 {
+	I := complexMatrix(n, n)
+	for i := range I {
+		I[i][i] = 1
+	}
+	return I
+}
+
+func conjugateTranspose(A [][]complex128) [][]complex128 {
+	At := complexMatrix(len(A[0]), len(A))
+	for i := range A {
+		for j := range A[i] {
+			At[j][i] = cmplx.Conj(A[i][j])
+		}
+	}
+	return At
+}
+
+// multiply returns A·B, or A·Bᴴ when conjB is set.
+func multiply(A, B [][]complex128, conjB bool) [][]complex128 {
+	if conjB {
+		B = conjugateTranspose(B)
+	}
+	C := complexMatrix(len(A), len(B[0]))
+	for i := range A {
+		for k, a := range A[i] {
+			for j, b := range B[k] {
+				C[i][j] += a * b
+			}
+		}
+	}
+	return C
+}
+
+// solve returns X with A·X = B by Gaussian elimination with partial
+// pivoting; A is square.
+func solve(A, B [][]complex128) ([][]complex128, error) {
+	n, m := len(A), len(B[0])
+	a := complexMatrix(n, n)
+	x := complexMatrix(n, m)
+	var scale float64
+	for i := range A {
+		copy(a[i], A[i])
+		copy(x[i], B[i])
+		for _, v := range A[i] {
+			scale = math.Max(scale, cmplx.Abs(v))
+		}
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if cmplx.Abs(a[r][col]) > cmplx.Abs(a[pivot][col]) {
+				pivot = r
+			}
+		}
+		if cmplx.Abs(a[pivot][col]) <= 1e-12*scale {
+			return nil, errSingularMatrix
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		x[col], x[pivot] = x[pivot], x[col]
+		for r := col + 1; r < n; r++ {
+			f := a[r][col] / a[col][col]
+			for c := col; c < n; c++ {
+				a[r][c] -= f * a[col][c]
+			}
+			for c := range x[r] {
+				x[r][c] -= f * x[col][c]
+			}
+		}
+	}
+	for r := n - 1; r >= 0; r-- {
+		for c := range x[r] {
+			for k := r + 1; k < n; k++ {
+				x[r][c] -= a[r][k] * x[k][c]
+			}
+			x[r][c] /= a[r][r]
+		}
+	}
+	return x, nil
+}
+
+// complexEigenvalues returns the eigenvalues of the small general complex
+// matrix A with the Wilkinson-shifted QR algorithm, deflating one
+// eigenvalue at a time from the bottom of the active block.
+func complexEigenvalues(A [][]complex128) ([]complex128, error) {
+	n := len(A)
+	H := complexMatrix(n, n)
+	var norm float64
+	for i := range A {
+		copy(H[i], A[i])
+		for _, v := range A[i] {
+			norm = math.Max(norm, cmplx.Abs(v))
+		}
+	}
+	values := make([]complex128, n)
+	if norm == 0 {
+		return values, nil
+	}
+
+	iterations := 0
+	for size := n; size > 0; {
+		last := size - 1
+		var offDiagonal float64
+		for j := 0; j < last; j++ {
+			offDiagonal += cmplx.Abs(H[last][j])
+		}
+		if offDiagonal <= 1e-14*norm {
+			values[last] = H[last][last]
+			size--
+			iterations = 0
+			continue
+		}
+		if iterations++; iterations > qrIterationsPerValue {
+			return nil, errNoConvergence
+		}
+
+		shift := wilkinsonShift(H[last-1][last-1], H[last-1][last], H[last][last-1], H[last][last])
+		if iterations%10 == 0 {
+			// an exceptional shift breaks cycles
+			shift += complex(offDiagonal, 0)
+		}
+		qrStep(H, size, shift)
+	}
+	return values, nil
+}
+
+// wilkinsonShift is the eigenvalue of [[a b] [c d]] closer to d.
+func wilkinsonShift(a, b, c, d complex128) complex128 {
+	half := (a - d) / 2
+	root := cmplx.Sqrt(half*half + b*c)
+	first, second := (a+d)/2+root, (a+d)/2-root
+	if cmplx.Abs(first-d) < cmplx.Abs(second-d) {
+		return first
+	}
+	return second
+}
+
+// qrStep replaces the leading size×size block of H by R·Q + shift·I, where
+// Q·R = H - shift·I, using modified Gram-Schmidt.
+func qrStep(H [][]complex128, size int, shift complex128) {
+	Q := complexMatrix(size, size) // columns stored as rows
+	R := complexMatrix(size, size)
+	for j := 0; j < size; j++ {
+		for i := 0; i < size; i++ {
+			Q[j][i] = H[i][j]
+		}
+		Q[j][j] -= shift
+	}
+	for j := 0; j < size; j++ {
+		for k := 0; k < j; k++ {
+			var dot complex128
+			for i := 0; i < size; i++ {
+				dot += cmplx.Conj(Q[k][i]) * Q[j][i]
+			}
+			R[k][j] = dot
+			for i := 0; i < size; i++ {
+				Q[j][i] -= dot * Q[k][i]
+			}
+		}
+		var norm float64
+		for i := 0; i < size; i++ {
+			norm += real(Q[j][i] * cmplx.Conj(Q[j][i]))
+		}
+		norm = math.Sqrt(norm)
+		R[j][j] = complex(norm, 0)
+		if norm > 0 {
+			for i := 0; i < size; i++ {
+				Q[j][i] /= complex(norm, 0)
+			}
+		}
+	}
+	for i := 0; i < size; i++ {
+		for j := 0; j < size; j++ {
+			var sum complex128
+			for k := i; k < size; k++ {
+				sum += R[i][k] * Q[j][k]
+			}
+			H[i][j] = sum
+		}
+		H[i][i] += shift
+	}
+}
@@ -3,24 +3,31 @@ package doa
 import (
 	"math"
 	"math/cmplx"
+
+	"isac-cran-system/internal/algorithm/array"
 )
 
 type MUSIC struct {
-	elementCount   int
-	numSources     int
-	elementSpacing float64
+	elementCount int
+	numSources   int
+	geometry     *array.Geometry
 }
 
+// NewMUSIC creates a MUSIC estimator for a uniform linear array.
 func NewMUSIC(elementCount, numSources int, elementSpacing float64) *MUSIC {
+	return NewGeometryMUSIC(array.NewULA(elementCount, elementSpacing), numSources)
+}
+
+func NewGeometryMUSIC(g *array.Geometry, numSources int) *MUSIC {
 	return &MUSIC{
-		elementCount:   elementCount,
-		numSources:     numSources,
-		elementSpacing: elementSpacing,
+		elementCount: g.Len(),
+		numSources:   numSources,
+		geometry:     g,
 	}
 }
 
 func (m *MUSIC) ComputeSpectrum(covMatrix [][]complex128, searchAngles []float64) []float64 {
-	_, eigenvectors := m.eigenDecomposition(covMatrix)
+	_, eigenvectors := hermitianEigen(covMatrix)
 
 	noiseSubspace := make([][]complex128, m.elementCount-m.numSources)
 	for i := 0; i < m.elementCount-m.numSources; i++ {
@@ -52,43 +59,7 @@ func (m *MUSIC) ComputeSpectrum(covMatrix [][]complex128, searchAngles []float64
 }
 
 func (m *MUSIC) computeSteeringVector(angle float64) []complex128 {
-	steering := make([]complex128, m.elementCount)
-	for n := 0; n < m.elementCount; n++ {
-		phase := 2 * math.Pi * float64(n) * m.elementSpacing * math.Sin(angle)
-		steering[n] = cmplx.Exp(complex(0, phase))
-	}
-	return steering
-}
-
-func (m *MUSIC) eigenDecomposition(matrix [][]complex128) ([]float64, [][]complex128) {
-	n := len(matrix)
-
-	eigenvalues := make([]float64, n)
-	eigenvectors := make([][]complex128, n)
-	for i := range eigenvectors {
-		eigenvectors[i] = make([]complex128, n)
-		eigenvectors[i][i] = 1
-	}
-
-	for i := 0; i < n; i++ {
-		var sum float64
-		for j := 0; j < n; j++ {
-			absVal := cmplx.Abs(matrix[i][j])
-			sum += absVal * absVal
-		}
-		eigenvalues[i] = sum / float64(n)
-	}
-
-	for i := 0; i < n-1; i++ {
-		for j := i + 1; j < n; j++ {
-			if eigenvalues[i] < eigenvalues[j] {
-				eigenvalues[i], eigenvalues[j] = eigenvalues[j], eigenvalues[i]
-				eigenvectors[i], eigenvectors[j] = eigenvectors[j], eigenvectors[i]
-			}
-		}
-	}
-
-	return eigenvalues, eigenvectors
+	return m.geometry.SteeringVector(angle)
 }
 
 func (m *MUSIC) EstimateDOA(covMatrix [][]complex128) []float64 {
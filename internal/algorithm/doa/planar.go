@@ -0,0 +1,73 @@
+package doa
+
+import (
+	"fmt"
+	"math"
+
+	"isac-cran-system/internal/algorithm/array"
+	"isac-cran-system/internal/model"
+)
+
+// defaultElevationStep is the elevation resolution of a planar array scan
+// that does not set one.
+const defaultElevationStep = 1.0
+
+// planarArray returns the rectangular array described by params, or nil
+// when params use the configured array.
+func planarArray(params *model.DOAParams) (*array.Geometry, error) {
+	if !params.Planar() {
+		return nil, nil
+	}
+	if params.Rows < 1 || params.Cols < 1 {
+		return nil, &model.ValidationError{Field: "rows", Message: "rows and cols must both be set for a planar array"}
+	}
+	if params.ElementCount != 0 && params.ElementCount != params.Rows*params.Cols {
+		return nil, &model.ValidationError{Field: "element_count",
+			Message: fmt.Sprintf("element_count %d does not match the %d×%d array", params.ElementCount, params.Rows, params.Cols)}
+	}
+	spacing := params.Spacing
+	if spacing <= 0 {
+		spacing = array.HalfWavelength
+	}
+	return array.NewURA(params.Rows, params.Cols, spacing), nil
+}
+
+// geometryFor returns the array behind an n-channel capture or covariance.
+func (e *Estimator) geometryFor(params *model.DOAParams, n int) (*array.Geometry, error) {
+	planar, err := planarArray(params)
+	if err != nil {
+		return nil, err
+	}
+	if planar == nil {
+		return e.arrayFor(n), nil
+	}
+	if planar.Len() != n {
+		return nil, &model.ValidationError{Field: "rows",
+			Message: fmt.Sprintf("the %d×%d array has %d elements, the data has %d channels", params.Rows, params.Cols, planar.Len(), n)}
+	}
+	return planar, nil
+}
+
+// scanParams makes a planar array without elevation settings scan
+// elevation over [-90°, 90°].
+func scanParams(params *model.DOAParams) *model.DOAParams {
+	if params.Rows < 2 || params.Cols < 2 || params.ElevationStep > 0 {
+		return params
+	}
+	scan := *params
+	scan.ElevationMin, scan.ElevationMax, scan.ElevationStep = -90, 90, defaultElevationStep
+	return &scan
+}
+
+// syntheticElevations are the elevations of the synthesized sources: in the
+// azimuth plane for linear arrays, spread over ±30° for planar ones.
+func syntheticElevations(params *model.DOAParams) []float64 {
+	elevations := make([]float64, params.NumSources)
+	if params.Rows < 2 || params.Cols < 2 {
+		return elevations
+	}
+	for i := range elevations {
+		elevations[i] = math.Pi/6 - float64(i)*math.Pi/(3*float64(params.NumSources))
+	}
+	return elevations
+}
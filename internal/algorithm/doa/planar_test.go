@@ -0,0 +1,73 @@
+package doa
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"isac-cran-system/internal/algorithm/array"
+	"isac-cran-system/internal/model"
+)
+
+func TestEstimator_PlanarArray(t *testing.T) {
+	type direction struct{ az, el float64 }
+	sources := []direction{{20, 10}, {-30, -25}}
+
+	g := array.NewURA(4, 4, array.HalfWavelength)
+	R := make([][]complex128, g.Len())
+	for i := range R {
+		R[i] = make([]complex128, g.Len())
+		R[i][i] = 0.01
+	}
+	for _, s := range sources {
+		a := g.SteeringVector3D(s.az*math.Pi/180, s.el*math.Pi/180)
+		for i := range R {
+			for j := range R[i] {
+				R[i][j] += a[i] * cmplx.Conj(a[j])
+			}
+		}
+	}
+
+	params := &model.DOAParams{
+		Rows:           4,
+		Cols:           4,
+		NumSources:     2,
+		Method:         "MUSIC",
+		SearchRangeMin: -90,
+		SearchRangeMax: 90,
+		SearchStep:     1,
+	}
+	e := NewEstimator(8, 2, 256, "MUSIC")
+	result, err := e.EstimateCovariance(R, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Directions) != 2 || len(result.Spectrum2D) != 181 {
+		t.Fatalf("got %d directions over %d elevations, want 2 over 181", len(result.Directions), len(result.Spectrum2D))
+	}
+	for _, want := range sources {
+		found := false
+		for _, d := range result.Directions {
+			if math.Abs(d.Azimuth*180/math.Pi-want.az) <= 1 && math.Abs(d.Elevation*180/math.Pi-want.el) <= 1 {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("no direction near %+v in %+v", want, result.Directions)
+		}
+	}
+
+	if points, _ := ScanPoints(params); points != 181*181 {
+		t.Errorf("ScanPoints = %d, want the full azimuth-elevation grid", points)
+	}
+	mismatched := *params
+	mismatched.Cols = 3
+	if _, err := e.EstimateCovariance(R, &mismatched); err == nil {
+		t.Error("a 4×3 array was accepted for 16 channels")
+	}
+	smoothed := *params
+	smoothed.Smoothing = &model.SpatialSmoothingOptions{SubarraySize: 8}
+	if _, err := e.EstimateCovariance(R, &smoothed); err == nil {
+		t.Error("spatial smoothing was accepted for a planar array")
+	}
+}
@@ -0,0 +1,348 @@
+package doa
+
+import (
+	"context"
+	"math"
+	"math/cmplx"
+	"sort"
+	"sync"
+
+	"isac-cran-system/internal/algorithm/array"
+	"isac-cran-system/internal/model"
+)
+
+const (
+	// maxGridPoints bounds a single scan, about 0.1° over a full hemisphere.
+	maxGridPoints = 1 << 22
+	// minChunkPoints keeps pool tasks large enough to amortize scheduling.
+	minChunkPoints = 256
+	// coarseFactor is how much coarser the first pass of a refined scan is.
+	coarseFactor  = 10
+	minCoarseStep = 1.0
+	// defaultSearchStep is the azimuth resolution when none is requested.
+	defaultSearchStep = 0.5
+	musicTaskType     = "doa_music"
+	musicPeakFloor    = 1e10
+)
+
+type gridPoint struct {
+	az, el float64
+}
+
+// searchGrid holds the scan axes in radians.
+type searchGrid struct {
+	azimuths   []float64
+	elevations []float64
+}
+
+func (g *searchGrid) size() int {
+	return len(g.azimuths) * len(g.elevations)
+}
+
+func (g *searchGrid) points() []gridPoint {
+	points := make([]gridPoint, 0, g.size())
+	for _, el := range g.elevations {
+		for _, az := range g.azimuths {
+			points = append(points, gridPoint{az: az, el: el})
+		}
+	}
+	return points
+}
+
+// newSearchGrid builds the grid from the search parameters, in degrees. A
+// zero step keeps the historical 0.5° azimuth grid over [-90°, 90°).
+func newSearchGrid(params *model.DOAParams) (*searchGrid, error) {
+	azLo, azHi, azStep := -90.0, 90.0-defaultSearchStep, defaultSearchStep
+	if params.SearchStep > 0 {
+		azLo, azHi, azStep = params.SearchRangeMin, params.SearchRangeMax, params.SearchStep
+		if azLo >= azHi {
+			azLo, azHi = -90, 90
+		}
+	}
+	elLo, elHi, elStep := 0.0, 0.0, 1.0
+	if params.ElevationStep > 0 {
+		elLo, elHi, elStep = params.ElevationMin, params.ElevationMax, params.ElevationStep
+		if elLo >= elHi {
+			elLo, elHi = -90, 90
+		}
+	}
+
+	if float64(axisLen(azLo, azHi, azStep))*float64(axisLen(elLo, elHi, elStep)) > maxGridPoints {
+		return nil, &model.ValidationError{Field: "search_step", Message: "search grid is too fine; increase the step or narrow the range"}
+	}
+	return &searchGrid{
+		azimuths:   axis(azLo, azHi, azStep),
+		elevations: axis(elLo, elHi, elStep),
+	}, nil
+}
+
+// ScanPoints is the number of MUSIC pseudo-spectrum evaluations a scan with
+// params needs: the grid, or for a refined scan the coarse grid plus a fine
+// window around each source.
+func ScanPoints(params *model.DOAParams) (int, error) {
+	params = scanParams(params)
+	if !params.Refine {
+		grid, err := newSearchGrid(params)
+		if err != nil {
+			return 0, err
+		}
+		return grid.size(), nil
+	}
+
+	fineStep := params.SearchStep
+	if fineStep <= 0 {
+		fineStep = defaultSearchStep
+	}
+	coarseParams := *params
+	coarseParams.SearchStep = math.Max(fineStep*coarseFactor, minCoarseStep)
+	window := axisLen(0, 2*coarseParams.SearchStep, fineStep)
+	if params.ElevationStep > 0 {
+		coarseParams.ElevationStep = math.Max(params.ElevationStep*coarseFactor, minCoarseStep)
+		window *= axisLen(0, 2*coarseParams.ElevationStep, params.ElevationStep)
+	}
+	coarse, err := newSearchGrid(&coarseParams)
+	if err != nil {
+		return 0, err
+	}
+	return coarse.size() + params.NumSources*window, nil
+}
+
+func axisLen(lo, hi, step float64) int {
+	return int(math.Floor((hi-lo)/step+1e-9)) + 1
+}
+
+// axis returns lo, lo+step, ... up to hi inclusive, in radians.
+func axis(lo, hi, step float64) []float64 {
+	values := make([]float64, axisLen(lo, hi, step))
+	for i := range values {
+		values[i] = (lo + float64(i)*step) * math.Pi / 180
+	}
+	return values
+}
+
+// musicPower evaluates the MUSIC pseudo-spectrum 1/‖Enᴴa‖² at each point.
+// Large grids are split into chunks that run on the worker pool.
+func (e *Estimator) musicPower(noise [][]complex128, g *array.Geometry, points []gridPoint) []float64 {
+	power := make([]float64, len(points))
+	eval := func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			power[i] = musicPoint(noise, g.SteeringVector3D(points[i].az, points[i].el))
+		}
+	}
+
+	chunk := e.chunkSize(len(points))
+	if chunk >= len(points) {
+		eval(0, len(points))
+		return power
+	}
+
+	var wg sync.WaitGroup
+	for lo := 0; lo < len(points); lo += chunk {
+		hi := lo + chunk
+		if hi > len(points) {
+			hi = len(points)
+		}
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			_, err := e.pool.SubmitTaskAndWait(musicTaskType, 0, func(ctx context.Context) (interface{}, error) {
+				eval(lo, hi)
+				return nil, nil
+			})
+			if err != nil {
+				// the pool is shutting down or the task panicked; finish inline
+				eval(lo, hi)
+			}
+		}(lo, hi)
+	}
+	wg.Wait()
+	return power
+}
+
+// chunkSize splits n points into about two chunks per worker so a slow
+// worker does not hold up the scan.
+func (e *Estimator) chunkSize(n int) int {
+	if e.pool == nil {
+		return n
+	}
+	chunk := n / (2 * e.pool.WorkerCount())
+	if chunk < minChunkPoints {
+		chunk = minChunkPoints
+	}
+	return chunk
+}
+
+func musicPoint(noise [][]complex128, steering []complex128) float64 {
+	var denom float64
+	for _, v := range noise {
+		var proj complex128
+		for n, a := range steering {
+			proj += cmplx.Conj(v[n]) * a
+		}
+		denom += real(proj * cmplx.Conj(proj))
+	}
+	if denom > 1e-10 {
+		return 1.0 / denom
+	}
+	return musicPeakFloor
+}
+
+// pseudoSpectrum evaluates a pseudo-spectrum at grid points; peaks mark
+// sources.
+type pseudoSpectrum func(points []gridPoint) []float64
+
+// noisePower is the MUSIC pseudo-spectrum of a noise subspace.
+func (e *Estimator) noisePower(noise [][]complex128, g *array.Geometry) pseudoSpectrum {
+	return func(points []gridPoint) []float64 {
+		return e.musicPower(noise, g, points)
+	}
+}
+
+type scanResult struct {
+	spectrum   []float64
+	spectrum2D [][]float64
+	azimuths   []float64
+	elevations []float64
+}
+
+// fill sets the angles and spectra of result from the scan.
+func (r *scanResult) fill(result *model.DOAResult) {
+	result.EstimatedAngles = r.azimuths
+	result.Spectrum = r.spectrum
+	if r.spectrum2D != nil {
+		result.EstimatedElevations = r.elevations
+		result.Spectrum2D = r.spectrum2D
+		for i := range r.azimuths {
+			result.Directions = append(result.Directions, model.DOADirection{Azimuth: r.azimuths[i], Elevation: r.elevations[i]})
+		}
+	}
+}
+
+// musicScan searches the grid for the strongest numSources peaks. The 1D
+// spectrum is the maximum over elevation at each azimuth.
+func (e *Estimator) musicScan(spectrum pseudoSpectrum, grid *searchGrid, numSources int) *scanResult {
+	power := spectrum(grid.points())
+
+	nAz := len(grid.azimuths)
+	result := &scanResult{spectrum: make([]float64, nAz)}
+	for i, p := range power {
+		if az := i % nAz; i < nAz || p > result.spectrum[az] {
+			result.spectrum[az] = p
+		}
+	}
+
+	var peaks []int
+	if len(grid.elevations) == 1 {
+		peaks = peaks1D(power, numSources)
+	} else {
+		result.spectrum2D = make([][]float64, len(grid.elevations))
+		for r := range result.spectrum2D {
+			result.spectrum2D[r] = power[r*nAz : (r+1)*nAz]
+		}
+		peaks = peaks2D(power, nAz, numSources)
+	}
+
+	for _, p := range peaks {
+		result.azimuths = append(result.azimuths, grid.azimuths[p%nAz])
+		result.elevations = append(result.elevations, grid.elevations[p/nAz])
+	}
+	return result
+}
+
+// refineScan runs musicScan on a coarse grid and then searches at full
+// resolution in a window of one coarse step around each coarse peak.
+func (e *Estimator) refineScan(spectrum pseudoSpectrum, params *model.DOAParams, numSources int) (*scanResult, error) {
+	fineStep := params.SearchStep
+	if fineStep <= 0 {
+		fineStep = defaultSearchStep
+	}
+	coarseParams := *params
+	coarseParams.SearchStep = math.Max(fineStep*coarseFactor, minCoarseStep)
+	if params.ElevationStep > 0 {
+		coarseParams.ElevationStep = math.Max(params.ElevationStep*coarseFactor, minCoarseStep)
+	}
+	coarse, err := newSearchGrid(&coarseParams)
+	if err != nil {
+		return nil, err
+	}
+	result := e.musicScan(spectrum, coarse, numSources)
+
+	azStep := coarseParams.SearchStep * math.Pi / 180
+	elStep := coarseParams.ElevationStep * math.Pi / 180
+	for i := range result.azimuths {
+		window := &searchGrid{
+			azimuths:   fineAxis(result.azimuths[i], azStep, fineStep),
+			elevations: []float64{result.elevations[i]},
+		}
+		if params.ElevationStep > 0 {
+			window.elevations = fineAxis(result.elevations[i], elStep, params.ElevationStep)
+		}
+		points := window.points()
+		power := spectrum(points)
+		best := 0
+		for j, p := range power {
+			if p > power[best] {
+				best = j
+			}
+		}
+		result.azimuths[i] = points[best].az
+		result.elevations[i] = points[best].el
+	}
+	return result, nil
+}
+
+// fineAxis spans center±half (radians) at step degrees.
+func fineAxis(center, half, step float64) []float64 {
+	deg := center * 180 / math.Pi
+	halfDeg := half * 180 / math.Pi
+	return axis(deg-halfDeg, deg+halfDeg, step)
+}
+
+func peaks1D(spectrum []float64, numPeaks int) []int {
+	var peaks []int
+	for i := 1; i < len(spectrum)-1; i++ {
+		if spectrum[i] > spectrum[i-1] && spectrum[i] > spectrum[i+1] {
+			peaks = append(peaks, i)
+		}
+	}
+	return strongest(spectrum, peaks, numPeaks)
+}
+
+// peaks2D finds points higher than all of their in-grid neighbours in a
+// row-major grid of the given width.
+func peaks2D(power []float64, width, numPeaks int) []int {
+	height := len(power) / width
+	var peaks []int
+	for r := 0; r < height; r++ {
+		for c := 0; c < width; c++ {
+			v := power[r*width+c]
+			isPeak := true
+			for dr := -1; dr <= 1 && isPeak; dr++ {
+				for dc := -1; dc <= 1; dc++ {
+					rr, cc := r+dr, c+dc
+					if (dr == 0 && dc == 0) || rr < 0 || rr >= height || cc < 0 || cc >= width {
+						continue
+					}
+					if power[rr*width+cc] >= v {
+						isPeak = false
+						break
+					}
+				}
+			}
+			if isPeak {
+				peaks = append(peaks, r*width+c)
+			}
+		}
+	}
+	return strongest(power, peaks, numPeaks)
+}
+
+func strongest(values []float64, peaks []int, n int) []int {
+	sort.SliceStable(peaks, func(i, j int) bool {
+		return values[peaks[i]] > values[peaks[j]]
+	})
+	if len(peaks) > n {
+		peaks = peaks[:n]
+	}
+	return peaks
+}
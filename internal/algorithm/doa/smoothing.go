@@ -0,0 +1,54 @@
+package doa
+
+import (
+	"fmt"
+	"math/cmplx"
+
+	"isac-cran-system/internal/model"
+)
+
+// SpatialSmoothing averages the covariances of the M-L+1 overlapping
+// subarrays of L = subarraySize elements and, with forwardBackward, their
+// conjugate-reversed counterparts. Coherent sources, e.g. multipath copies
+// of one signal, leave R rank-deficient so that MUSIC and ESPRIT miss them;
+// smoothing restores the rank for up to M-L+1 coherent sources, twice as
+// many with forward-backward averaging, at the cost of an L-element
+// aperture. It assumes a shift-invariant array such as a ULA.
+func SpatialSmoothing(R [][]complex128, subarraySize int, forwardBackward bool) ([][]complex128, error) {
+	M, L := len(R), subarraySize
+	if L < 2 || L > M {
+		return nil, &model.ValidationError{Field: "smoothing.subarray_size",
+			Message: fmt.Sprintf("subarray_size %d must be between 2 and the %d array elements", L, M)}
+	}
+	subarrays := M - L + 1
+
+	out := make([][]complex128, L)
+	for i := range out {
+		out[i] = make([]complex128, L)
+	}
+	for k := 0; k < subarrays; k++ {
+		for i := 0; i < L; i++ {
+			for j := 0; j < L; j++ {
+				out[i][j] += R[k+i][k+j]
+			}
+		}
+	}
+	n := subarrays
+	if forwardBackward {
+		// the backward subarray covariances are J·R*·J of the forward ones
+		for k := 0; k < subarrays; k++ {
+			for i := 0; i < L; i++ {
+				for j := 0; j < L; j++ {
+					out[i][j] += cmplx.Conj(R[k+L-1-i][k+L-1-j])
+				}
+			}
+		}
+		n *= 2
+	}
+	for i := range out {
+		for j := range out[i] {
+			out[i][j] /= complex(float64(n), 0)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,74 @@
+package doa
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"isac-cran-system/internal/algorithm/array"
+	"isac-cran-system/internal/model"
+)
+
+// coherentCovariance is the covariance of one signal arriving from two
+// directions, the second path with a fixed phase offset, plus white noise.
+func coherentCovariance(g *array.Geometry, first, second, noise float64) [][]complex128 {
+	a := g.SteeringVector(first * math.Pi / 180)
+	b := g.SteeringVector(second * math.Pi / 180)
+	path := cmplx.Rect(0.9, 0.7)
+	x := make([]complex128, len(a))
+	for i := range x {
+		x[i] = a[i] + path*b[i]
+	}
+	R := make([][]complex128, len(x))
+	for i := range R {
+		R[i] = make([]complex128, len(x))
+		for j := range R[i] {
+			R[i][j] = x[i] * cmplx.Conj(x[j])
+		}
+		R[i][i] += complex(noise, 0)
+	}
+	return R
+}
+
+func TestSpatialSmoothing_CoherentSources(t *testing.T) {
+	g := array.NewULA(10, array.HalfWavelength)
+	R := coherentCovariance(g, -20, 25, 0.01)
+	params := &model.DOAParams{
+		NumSources:     2,
+		Method:         "MUSIC",
+		SearchRangeMin: -90,
+		SearchRangeMax: 90,
+		SearchStep:     0.1,
+	}
+	e := NewEstimator(10, 2, 256, "MUSIC")
+
+	values, _ := hermitianEigen(R)
+	if values[1] > 0.02 {
+		t.Fatalf("coherent covariance has a second signal eigenvalue %g", values[1])
+	}
+
+	for _, fb := range []bool{false, true} {
+		params.Smoothing = &model.SpatialSmoothingOptions{SubarraySize: 7, ForwardBackward: fb}
+		smoothed, err := SpatialSmoothing(R, 7, fb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if values, _ := hermitianEigen(smoothed); values[1] < 0.1 {
+			t.Errorf("forward_backward=%v: smoothing left the second eigenvalue at %g", fb, values[1])
+		}
+		result, err := e.EstimateCovariance(R, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertAngles(t, "smoothed MUSIC", radiansToDegrees(result.EstimatedAngles), -20, 25, 0.5)
+	}
+
+	// the subarray must still exceed the source count
+	params.Smoothing = &model.SpatialSmoothingOptions{SubarraySize: 2}
+	if _, err := e.EstimateCovariance(R, params); err == nil {
+		t.Error("a subarray no larger than the source count was accepted")
+	}
+	if _, err := SpatialSmoothing(R, 11, true); err == nil {
+		t.Error("a subarray larger than the array was accepted")
+	}
+}
@@ -0,0 +1,88 @@
+package doa
+
+import (
+	"math"
+
+	"isac-cran-system/internal/model"
+)
+
+// Information theoretic criteria of Wax and Kailath: for each candidate
+// count k the M-k smallest eigenvalues are taken as noise, and the more
+// their geometric mean falls short of their arithmetic mean the less likely
+// they are all noise. AIC penalizes the k(2M-k) free parameters of the
+// signal subspace by 2 each and tends to overestimate at high SNR; MDL
+// penalizes them by ½·log N and is consistent.
+
+// DetectSources estimates the number of sources from the eigenvalues of a
+// covariance estimated over snapshots samples, in descending order. The
+// count is at most M-1, so that a noise subspace remains.
+func DetectSources(eigenvalues []float64, snapshots int, criterion string) int {
+	M := len(eigenvalues)
+	N := float64(snapshots)
+	// eigenvalues of a rank-deficient estimate are zero or slightly negative
+	floor := math.Max(eigenvalues[0], 1) * 1e-12
+
+	best, bestScore := 0, math.Inf(1)
+	for k := 0; k < M; k++ {
+		p := float64(M - k)
+		var sum, logSum float64
+		for _, v := range eigenvalues[k:] {
+			v = math.Max(v, floor)
+			sum += v
+			logSum += math.Log(v)
+		}
+		// log of the arithmetic over the geometric mean, never negative
+		ratio := math.Log(sum/p) - logSum/p
+		params := float64(k * (2*M - k))
+
+		var score float64
+		if criterion == model.SourceDetectionAIC {
+			score = 2*N*p*ratio + 2*params
+		} else {
+			score = N*p*ratio + 0.5*params*math.Log(N)
+		}
+		if score < bestScore {
+			best, bestScore = k, score
+		}
+	}
+	return best
+}
+
+// SubspaceSNR estimates the SNR of the strongest source in covariance R:
+// the smallest M-1 eigenvalues are taken as the noise floor, and the
+// dominant eigenvalue above it is the source power an optimal combiner
+// collects. It needs at least two antennas.
+func SubspaceSNR(R [][]complex128) float64 {
+	values, _ := hermitianEigen(R)
+	var noise float64
+	for _, v := range values[1:] {
+		noise += v
+	}
+	noise /= float64(len(values) - 1)
+	// a noiseless estimate leaves the floor at rounding error
+	noise = math.Max(noise, values[0]*1e-12)
+	if noise <= 0 {
+		return 0
+	}
+	return math.Max(values[0]-noise, 0) / noise
+}
+
+// detectSources replaces NumSources in a copy of params with the count
+// detected in covMatrix.
+func detectSources(covMatrix [][]complex128, params *model.DOAParams) (*model.DOAParams, error) {
+	if params.SnapshotLength < 1 {
+		return nil, &model.ValidationError{Field: "snapshot_length", Message: "source detection needs the snapshot count"}
+	}
+	values, _ := hermitianEigen(covMatrix)
+	detected := *params
+	detected.NumSources = DetectSources(values, params.SnapshotLength, params.SourceDetection)
+	return &detected, nil
+}
+
+func validSourceDetection(criterion string) bool {
+	switch criterion {
+	case "", model.SourceDetectionAuto, model.SourceDetectionAIC, model.SourceDetectionMDL:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package doa
+
+import (
+	"math"
+	"testing"
+
+	"isac-cran-system/internal/algorithm/array"
+	"isac-cran-system/internal/algorithm/covariance"
+	"isac-cran-system/internal/model"
+)
+
+func TestDetectSources(t *testing.T) {
+	angles := []float64{-30 * math.Pi / 180, 5 * math.Pi / 180, 40 * math.Pi / 180}
+	for _, tc := range []struct {
+		sources int
+		snrDB   float64
+	}{
+		{0, 0},
+		{1, 0},
+		{2, 10},
+		{3, 20},
+	} {
+		signals := NewESPRITEstimator(&ESPRITConfig{NumAntennas: 8, SnapshotLength: 200, Seed: 5})
+		X := signals.GenerateTestSignal(angles[:tc.sources], tc.snrDB)
+		values, _ := hermitianEigen(covariance.Sample(X))
+		if got := DetectSources(values, 200, model.SourceDetectionMDL); got != tc.sources {
+			t.Errorf("MDL detected %d of %d sources at %g dB", got, tc.sources, tc.snrDB)
+		}
+		// AIC is not consistent and may take a noise eigenvalue for a source
+		if got := DetectSources(values, 200, model.SourceDetectionAIC); got < tc.sources || got > tc.sources+1 {
+			t.Errorf("AIC detected %d of %d sources at %g dB", got, tc.sources, tc.snrDB)
+		}
+	}
+}
+
+func TestEstimator_SourceDetection(t *testing.T) {
+	e := NewEstimator(8, 1, 256, "MUSIC")
+	params := &model.DOAParams{
+		ElementCount:    8,
+		SnapshotLength:  64,
+		Method:          "MUSIC",
+		SearchRangeMin:  -90,
+		SearchRangeMax:  90,
+		SearchStep:      0.1,
+		SourceDetection: model.SourceDetectionAuto,
+	}
+	result, err := e.EstimateSnapshots(snapshotsFrom(array.NewULA(8, array.HalfWavelength), 20, 0, 256), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.DetectedSources == nil || *result.DetectedSources != 1 {
+		t.Fatalf("detected %v sources, want 1", result.DetectedSources)
+	}
+	if len(result.EstimatedAngles) != 1 || math.Abs(result.EstimatedAngles[0]*180/math.Pi-20) > 0.5 {
+		t.Errorf("estimated angles %v, want 20°", radiansToDegrees(result.EstimatedAngles))
+	}
+
+	// coherent sources only separate after smoothing, so detection follows it
+	R := coherentCovariance(array.NewULA(10, array.HalfWavelength), -20, 25, 0.01)
+	params = &model.DOAParams{
+		SnapshotLength:  100,
+		Method:          "ESPRIT",
+		SourceDetection: model.SourceDetectionMDL,
+	}
+	result, err = e.EstimateCovariance(R, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *result.DetectedSources != 1 {
+		t.Errorf("detected %d coherent sources without smoothing, want 1", *result.DetectedSources)
+	}
+	params.Smoothing = &model.SpatialSmoothingOptions{SubarraySize: 7, ForwardBackward: true}
+	result, err = e.EstimateCovariance(R, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *result.DetectedSources != 2 {
+		t.Fatalf("detected %d sources after smoothing, want 2", *result.DetectedSources)
+	}
+	assertAngles(t, "detected ESPRIT", radiansToDegrees(result.EstimatedAngles), -20, 25, 0.1)
+
+	params.SourceDetection = "bic"
+	if _, err := e.EstimateCovariance(R, params); !model.IsValidationError(err) {
+		t.Errorf("unknown criterion: error = %v, want a validation error", err)
+	}
+}
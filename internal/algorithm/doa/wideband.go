@@ -0,0 +1,327 @@
+package doa
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"math/rand"
+
+	"isac-cran-system/internal/algorithm/array"
+	"isac-cran-system/internal/algorithm/covariance"
+	"isac-cran-system/internal/model"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+)
+
+const (
+	// minSubbandPower is the power, relative to the strongest subband, below
+	// which a subband is taken to hold noise only and is left out.
+	minSubbandPower = 0.01
+	// focusAnglesPerElement spreads the CSSM focusing angles over the search
+	// sector densely enough for the focusing matrices to be well conditioned;
+	// the preliminary estimates weigh as much as the elements together, so
+	// that focusing is most accurate around them.
+	focusAnglesPerElement = 2
+	// syntheticWidebandSeed makes synthesized wideband sources repeat when
+	// the run sets no seed.
+	syntheticWidebandSeed = 1
+)
+
+// subband is the covariance of one FFT bin; ratio is the bin's frequency
+// relative to the carrier.
+type subband struct {
+	ratio float64
+	cov   [][]complex128
+}
+
+// widebandOptions checks the wideband settings of params against a capture
+// of snapshots samples and fills in the default method.
+func widebandOptions(params *model.DOAParams, snapshots int) (model.WidebandOptions, error) {
+	opts := *params.Wideband
+	if opts.Method == "" {
+		opts.Method = model.WidebandIncoherent
+	}
+	switch {
+	case opts.Method != model.WidebandIncoherent && opts.Method != model.WidebandCSSM:
+		return opts, &model.ValidationError{Field: "wideband.method", Message: "unsupported wideband method " + opts.Method}
+	case opts.Subbands < 2:
+		return opts, &model.ValidationError{Field: "wideband.subbands", Message: "subbands must be at least 2"}
+	case snapshots < opts.Subbands:
+		return opts, &model.ValidationError{Field: "wideband.subbands",
+			Message: fmt.Sprintf("%d snapshots cannot fill %d subbands", snapshots, opts.Subbands)}
+	case opts.SampleRate <= 0 || opts.CenterFrequency <= 0:
+		return opts, &model.ValidationError{Field: "wideband.sample_rate", Message: "sample_rate and center_frequency are required"}
+	case opts.SampleRate >= 2*opts.CenterFrequency:
+		return opts, &model.ValidationError{Field: "wideband.sample_rate", Message: "sample_rate must be less than twice center_frequency"}
+	case params.SourceDetection != "":
+		return opts, &model.ValidationError{Field: "source_detection", Message: "source detection needs narrowband snapshots"}
+	}
+	return opts, nil
+}
+
+// subbandRatio is the frequency of FFT bin k relative to the carrier.
+func subbandRatio(fft *fourier.CmplxFFT, k int, opts model.WidebandOptions) float64 {
+	return 1 + fft.Freq(k)*opts.SampleRate/opts.CenterFrequency
+}
+
+// subbandCovariances transforms each run of Subbands samples of every
+// channel with an FFT; bin k of every run is a snapshot of subband k, and
+// the subband covariance is estimated from those with covOpts. Subbands
+// holding noise only are left out.
+func subbandCovariances(X [][]complex128, opts model.WidebandOptions, covOpts model.CovarianceOptions) ([]subband, error) {
+	K := opts.Subbands
+	runs := len(X[0]) / K
+	fft := fourier.NewCmplxFFT(K)
+
+	bins := make([][][]complex128, K)
+	for k := range bins {
+		bins[k] = make([][]complex128, len(X))
+		for m := range bins[k] {
+			bins[k][m] = make([]complex128, runs)
+		}
+	}
+	coeff := make([]complex128, K)
+	for m, samples := range X {
+		for r := 0; r < runs; r++ {
+			fft.Coefficients(coeff, samples[r*K:(r+1)*K])
+			for k, c := range coeff {
+				bins[k][m][r] = c
+			}
+		}
+	}
+
+	bands := make([]subband, K)
+	powers := make([]float64, K)
+	var strongest float64
+	for k := range bins {
+		R, err := covariance.Estimate(bins[k], covOpts)
+		if err != nil {
+			return nil, err
+		}
+		bands[k] = subband{ratio: subbandRatio(fft, k, opts), cov: R}
+		for i := range R {
+			powers[k] += real(R[i][i])
+		}
+		strongest = math.Max(strongest, powers[k])
+	}
+	kept := bands[:0]
+	for k, band := range bands {
+		if powers[k] >= minSubbandPower*strongest {
+			kept = append(kept, band)
+		}
+	}
+	return kept, nil
+}
+
+// estimateWideband estimates from the subband covariances of X.
+func (e *Estimator) estimateWideband(X [][]complex128, params *model.DOAParams, covOpts model.CovarianceOptions) (*model.DOAResult, error) {
+	opts, err := widebandOptions(params, len(X[0]))
+	if err != nil {
+		return nil, err
+	}
+	bands, err := subbandCovariances(X, opts, covOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *model.DOAResult
+	if opts.Method == model.WidebandCSSM {
+		result, err = e.cssm(bands, params)
+	} else {
+		result, err = e.incoherentWideband(bands, params)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Subbands = len(bands)
+	return result, nil
+}
+
+// incoherentWideband scans the inverse of the MUSIC projections summed over
+// the subbands, each evaluated with the array at the subband's frequency.
+// The configured spatial smoothing does not apply.
+func (e *Estimator) incoherentWideband(bands []subband, params *model.DOAParams) (*model.DOAResult, error) {
+	if params.Method == "ESPRIT" {
+		return nil, &model.ValidationError{Field: "wideband.method", Message: "ESPRIT needs cssm focusing on wideband signals"}
+	}
+	if params.Smoothing != nil && params.Smoothing.SubarraySize > 0 {
+		return nil, &model.ValidationError{Field: "smoothing", Message: "spatial smoothing needs cssm focusing on wideband signals"}
+	}
+	spectrum, err := e.incoherentSpectrum(bands, params)
+	if err != nil {
+		return nil, err
+	}
+	scan, err := e.scan(spectrum, scanParams(params))
+	if err != nil {
+		return nil, err
+	}
+	result := &model.DOAResult{}
+	scan.fill(result)
+	return result, nil
+}
+
+func (e *Estimator) incoherentSpectrum(bands []subband, params *model.DOAParams) (pseudoSpectrum, error) {
+	M := len(bands[0].cov)
+	if M <= params.NumSources {
+		return nil, &model.ValidationError{Field: "element_count", Message: "need more antenna channels than sources"}
+	}
+	geometry, err := e.geometryFor(params, M)
+	if err != nil {
+		return nil, err
+	}
+	spectra := make([]pseudoSpectrum, len(bands))
+	for i, band := range bands {
+		_, eigenvectors := hermitianEigen(band.cov)
+		spectra[i] = e.noisePower(e.extractNoiseSubspace(eigenvectors, params.NumSources), geometry.Scaled(band.ratio))
+	}
+	return func(points []gridPoint) []float64 {
+		total := make([]float64, len(points))
+		for _, spectrum := range spectra {
+			for i, p := range spectrum(points) {
+				total[i] += 1 / p
+			}
+		}
+		for i := range total {
+			total[i] = 1 / total[i]
+		}
+		return total
+	}, nil
+}
+
+// cssm focuses the subband covariances onto the carrier and estimates from
+// their average as from a narrowband covariance, so that ESPRIT and spatial
+// smoothing apply as usual. The focusing angles are the incoherent
+// estimates and an even spread over the search sector.
+func (e *Estimator) cssm(bands []subband, params *model.DOAParams) (*model.DOAResult, error) {
+	if params.Planar() {
+		return nil, &model.ValidationError{Field: "wideband.method", Message: "cssm focusing needs a linear array"}
+	}
+	preliminary := *params
+	preliminary.Method = "MUSIC"
+	preliminary.Smoothing = nil
+	preliminary.Refine = true
+	spectrum, err := e.incoherentSpectrum(bands, &preliminary)
+	if err != nil {
+		return nil, err
+	}
+	scan, err := e.scan(spectrum, &preliminary)
+	if err != nil {
+		return nil, err
+	}
+
+	M := len(bands[0].cov)
+	grid, err := newSearchGrid(params)
+	if err != nil {
+		return nil, err
+	}
+	lo, hi := grid.azimuths[0], grid.azimuths[len(grid.azimuths)-1]
+	var angles, weights []float64
+	for _, az := range scan.azimuths {
+		angles = append(angles, az)
+		weights = append(weights, float64(M))
+	}
+	for i, n := 0, focusAnglesPerElement*M; i < n; i++ {
+		angles = append(angles, lo+(float64(i)+0.5)*(hi-lo)/float64(n))
+		weights = append(weights, 1)
+	}
+
+	geometry := e.arrayFor(M)
+	focused := complexMatrix(M, M)
+	for _, band := range bands {
+		T := focusingMatrix(geometry, geometry.Scaled(band.ratio), angles, weights)
+		TR := multiply(T, band.cov, false)
+		TRT := multiply(TR, T, true)
+		for i := range focused {
+			for j := range focused[i] {
+				focused[i][j] += TRT[i][j] / complex(float64(len(bands)), 0)
+			}
+		}
+	}
+	return e.EstimateCovariance(focused, params)
+}
+
+// focusingMatrix returns the unitary T that best maps the steering vectors
+// of at onto those of ref over the weighted angles: with C = Σ w·a_ref·a_atᴴ,
+// T is the polar factor C·(CᴴC)^(-1/2). Being unitary, it keeps white noise
+// white.
+func focusingMatrix(ref, at *array.Geometry, angles, weights []float64) [][]complex128 {
+	M := ref.Len()
+	C := complexMatrix(M, M)
+	for n, angle := range angles {
+		a0, ak := ref.SteeringVector(angle), at.SteeringVector(angle)
+		w := complex(weights[n], 0)
+		for i := range C {
+			for j := range C[i] {
+				C[i][j] += w * a0[i] * cmplx.Conj(ak[j])
+			}
+		}
+	}
+
+	values, vectors := hermitianEigen(multiply(conjugateTranspose(C), C, false))
+	floor := values[0] * 1e-12
+	invRoot := complexMatrix(M, M)
+	for n, v := range vectors {
+		scale := complex(1/math.Sqrt(math.Max(values[n], floor)), 0)
+		for i := range invRoot {
+			for j := range invRoot[i] {
+				invRoot[i][j] += v[i] * cmplx.Conj(v[j]) * scale
+			}
+		}
+	}
+	return multiply(C, invRoot, false)
+}
+
+// widebandSnapshots synthesizes sources whose white spectra fill the whole
+// sampled band: each run of Subbands samples is built bin by bin, with the
+// steering vectors at the bin's frequency, and transformed back to time.
+// Elements get noise 20 dB below each source.
+func widebandSnapshots(geometry *array.Geometry, params *model.DOAParams, azimuths, elevations []float64) [][]complex128 {
+	opts, err := widebandOptions(params, params.SnapshotLength)
+	if err != nil {
+		// estimateWideband reports the invalid settings
+		return nil
+	}
+	seed := int64(syntheticWidebandSeed)
+	if params.Seed != nil {
+		seed = *params.Seed
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	K, M := opts.Subbands, geometry.Len()
+	fft := fourier.NewCmplxFFT(K)
+	steerings := make([][][]complex128, K)
+	for k := range steerings {
+		scaled := geometry.Scaled(subbandRatio(fft, k, opts))
+		for s := range azimuths {
+			steerings[k] = append(steerings[k], scaled.SteeringVector3D(azimuths[s], elevations[s]))
+		}
+	}
+
+	X := complexMatrix(M, params.SnapshotLength)
+	coeff := complexMatrix(M, K)
+	seq := make([]complex128, K)
+	norm := complex(1/math.Sqrt(float64(K)), 0)
+	for start := 0; start < params.SnapshotLength; start += K {
+		for m := range coeff {
+			for k := range coeff[m] {
+				coeff[m][k] = 0
+			}
+		}
+		for k := 0; k < K; k++ {
+			for _, a := range steerings[k] {
+				amplitude := complex(rng.NormFloat64(), rng.NormFloat64()) / math.Sqrt2
+				for m := range coeff {
+					coeff[m][k] += amplitude * a[m]
+				}
+			}
+		}
+		for m := range X {
+			fft.Sequence(seq, coeff[m])
+			for t := 0; t < K && start+t < params.SnapshotLength; t++ {
+				noise := complex(rng.NormFloat64(), rng.NormFloat64()) * 0.1 / math.Sqrt2
+				X[m][start+t] = seq[t]*norm + noise
+			}
+		}
+	}
+	return X
+}
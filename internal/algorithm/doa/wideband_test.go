@@ -0,0 +1,111 @@
+package doa
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"isac-cran-system/internal/model"
+)
+
+// widebandParams describe two sources, at -60° and -30°, whose band is half
+// the carrier frequency.
+func widebandParams(method string) *model.DOAParams {
+	return &model.DOAParams{
+		ElementCount:   8,
+		NumSources:     2,
+		SnapshotLength: 4096,
+		Method:         "MUSIC",
+		SearchRangeMin: -90,
+		SearchRangeMax: 90,
+		SearchStep:     0.25,
+		Wideband: &model.WidebandOptions{
+			Method:          method,
+			Subbands:        16,
+			SampleRate:      1e9,
+			CenterFrequency: 2e9,
+		},
+	}
+}
+
+// angleError is the largest error of the estimates, in degrees, against the
+// sources at -60° and -30°.
+func angleError(t *testing.T, result *model.DOAResult) float64 {
+	t.Helper()
+	if len(result.EstimatedAngles) != 2 {
+		t.Fatalf("expected 2 estimates, got %v", result.EstimatedAngles)
+	}
+	angles := append([]float64(nil), result.EstimatedAngles...)
+	sort.Float64s(angles)
+	worst := 0.0
+	for i, want := range []float64{-60, -30} {
+		worst = math.Max(worst, math.Abs(angles[i]*180/math.Pi-want))
+	}
+	return worst
+}
+
+func TestWideband_ResolvesWhereNarrowbandBlurs(t *testing.T) {
+	e := NewEstimator(8, 2, 4096, "MUSIC")
+	X := e.SynthesizeSnapshots(nil, widebandParams(""))
+
+	narrowband := widebandParams("")
+	narrowband.Wideband = nil
+	result, err := e.EstimateSnapshots(X, narrowband)
+	if err != nil {
+		t.Fatal(err)
+	}
+	narrowbandError := 90.0
+	if len(result.EstimatedAngles) == 2 {
+		narrowbandError = angleError(t, result)
+	}
+
+	for _, method := range []string{model.WidebandIncoherent, model.WidebandCSSM} {
+		result, err := e.EstimateSnapshots(X, widebandParams(method))
+		if err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+		if result.Subbands < 2 || result.Subbands > 16 {
+			t.Errorf("%s: combined %d subbands", method, result.Subbands)
+		}
+		if worst := angleError(t, result); worst > 1 || worst >= narrowbandError {
+			t.Errorf("%s: estimates %v are %.2f° off, narrowband %.2f°", method, result.EstimatedAngles, worst, narrowbandError)
+		}
+	}
+}
+
+func TestWideband_CSSMWithSmoothing(t *testing.T) {
+	e := NewEstimator(8, 2, 4096, "MUSIC")
+	params := widebandParams(model.WidebandCSSM)
+	X := e.SynthesizeSnapshots(nil, params)
+
+	params.Smoothing = &model.SpatialSmoothingOptions{SubarraySize: 6}
+	if result, err := e.EstimateSnapshots(X, params); err != nil {
+		t.Fatal(err)
+	} else if worst := angleError(t, result); worst > 1 {
+		t.Errorf("smoothed estimates %v are %.2f° off", result.EstimatedAngles, worst)
+	}
+
+	params.Wideband.Method = model.WidebandIncoherent
+	if _, err := e.EstimateSnapshots(X, params); err == nil {
+		t.Error("expected incoherent wideband estimation to reject spatial smoothing")
+	}
+}
+
+func TestWideband_InvalidOptions(t *testing.T) {
+	e := NewEstimator(8, 2, 4096, "MUSIC")
+	X := e.SynthesizeSnapshots(nil, widebandParams(""))
+	for name, modify := range map[string]func(*model.WidebandOptions){
+		"method":      func(o *model.WidebandOptions) { o.Method = "tops" },
+		"subbands":    func(o *model.WidebandOptions) { o.Subbands = 1 },
+		"too many":    func(o *model.WidebandOptions) { o.Subbands = 8192 },
+		"sample rate": func(o *model.WidebandOptions) { o.SampleRate = 0 },
+		"below 0 Hz":  func(o *model.WidebandOptions) { o.SampleRate = 5e9 },
+	} {
+		params := widebandParams("")
+		modify(params.Wideband)
+		_, err := e.EstimateSnapshots(X, params)
+		if _, ok := err.(*model.ValidationError); !ok {
+			t.Errorf("%s: expected a validation error, got %v", name, err)
+		}
+	}
+}
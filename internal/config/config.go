@@ -2,26 +2,104 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"isac-cran-system/internal/algorithm/array"
+	"isac-cran-system/internal/model"
+
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server    ServerConfig    `mapstructure:"server"`
-	MySQL     MySQLConfig     `mapstructure:"mysql"`
-	InfluxDB  InfluxDBConfig  `mapstructure:"influxdb"`
-	Redis     RedisConfig     `mapstructure:"redis"`
-	MQTT      MQTTConfig      `mapstructure:"mqtt"`
-	Log       LogConfig       `mapstructure:"log"`
-	Device    DeviceConfig    `mapstructure:"device"`
-	Algorithm AlgorithmConfig `mapstructure:"algorithm"`
-	MATLAB    MATLABConfig    `mapstructure:"matlab"`
+	Server      ServerConfig      `mapstructure:"server"`
+	MySQL       MySQLConfig       `mapstructure:"mysql"`
+	InfluxDB    InfluxDBConfig    `mapstructure:"influxdb"`
+	Redis       RedisConfig       `mapstructure:"redis"`
+	MQTT        MQTTConfig        `mapstructure:"mqtt"`
+	RabbitMQ    RabbitMQConfig    `mapstructure:"rabbitmq"`
+	Log         LogConfig         `mapstructure:"log"`
+	Device      DeviceConfig      `mapstructure:"device"`
+	Algorithm   AlgorithmConfig   `mapstructure:"algorithm"`
+	MATLAB      MATLABConfig      `mapstructure:"matlab"`
+	ObjectStore ObjectStoreConfig `mapstructure:"object_store"`
+	Recording   RecordingConfig   `mapstructure:"recording"`
+	Degradation DegradationConfig `mapstructure:"degradation"`
+	Profile     string            `mapstructure:"-"`
 }
 
 type ServerConfig struct {
-	Port int    `mapstructure:"port"`
-	Mode string `mapstructure:"mode"`
+	Port   int          `mapstructure:"port"`
+	Mode   string       `mapstructure:"mode"`
+	UI     UIConfig     `mapstructure:"ui"`
+	GRPC   GRPCConfig   `mapstructure:"grpc"`
+	Auth   AuthConfig   `mapstructure:"auth"`
+	Stream StreamConfig `mapstructure:"stream"`
+	// Role is what the process runs: "all", the default, for the monolith,
+	// or in a split deployment one of the "algorithm", "device" and "sensor"
+	// services or the "gateway" in front of them.
+	Role      string          `mapstructure:"role"`
+	Discovery DiscoveryConfig `mapstructure:"discovery"`
+	Election  ElectionConfig  `mapstructure:"election"`
+}
+
+// ElectionConfig elects one of several replicas, through a Consul session
+// on Key, to run the background jobs that must run once: object tiering,
+// artifact garbage collection and the IRS watchdogs. A leader that vanishes
+// keeps the lock for SessionTTL. Disabled, the replica runs them itself.
+type ElectionConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	Key        string `mapstructure:"key"`
+	SessionTTL string `mapstructure:"session_ttl"`
+}
+
+// DiscoveryConfig locates Consul, where the services of a split deployment
+// register and the gateway finds them. Address is the host the others reach
+// this service at and defaults to the hostname.
+type DiscoveryConfig struct {
+	ConsulAddr string `mapstructure:"consul_addr"`
+	Address    string `mapstructure:"address"`
+}
+
+// StreamConfig sizes the per-client buffers of the WebSocket and SSE
+// streams; a client that falls a full buffer behind is disconnected.
+type StreamConfig struct {
+	BufferSize int `mapstructure:"buffer_size"`
+}
+
+// AuthConfig lists the API tokens and the principals they were issued to.
+// The principal is the holder of the caller's device reservations. Without
+// tokens every request is anonymous and cannot reserve devices.
+type AuthConfig struct {
+	Tokens []APIToken `mapstructure:"tokens"`
+}
+
+type APIToken struct {
+	Principal string `mapstructure:"principal"`
+	Token     string `mapstructure:"token"`
+}
+
+// TokenMap maps each token to its principal.
+func (c *AuthConfig) TokenMap() map[string]string {
+	m := make(map[string]string, len(c.Tokens))
+	for _, t := range c.Tokens {
+		m[t.Token] = t.Principal
+	}
+	return m
+}
+
+// GRPCConfig serves the algorithm, IRS, sensor and capture services over
+// gRPC on a port of its own, next to the HTTP API.
+type GRPCConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	Port    int  `mapstructure:"port"`
+}
+
+// UIConfig turns on the operations dashboard built into the binary at /ui.
+type UIConfig struct {
+	Enabled bool `mapstructure:"enabled"`
 }
 
 type MySQLConfig struct {
@@ -34,6 +112,27 @@ type MySQLConfig struct {
 	MaxIdleConns    int    `mapstructure:"max_idle_conns"`
 	MaxOpenConns    int    `mapstructure:"max_open_conns"`
 	ConnMaxLifetime int    `mapstructure:"conn_max_lifetime"`
+	// BatchSize is the number of rows per INSERT in bulk creates.
+	BatchSize int `mapstructure:"batch_size"`
+	// PrepareStmt caches prepared statements per connection.
+	PrepareStmt bool `mapstructure:"prepare_stmt"`
+	// SlowQueryThreshold logs queries that take longer, with the ID of the
+	// request that issued them.
+	SlowQueryThreshold time.Duration `mapstructure:"slow_query_threshold"`
+	// Replicas serve the heavy list queries. A replica lagging the primary
+	// by more than MaxReplicaLag is skipped until it catches up.
+	Replicas             []MySQLReplicaConfig `mapstructure:"replicas"`
+	MaxReplicaLag        time.Duration        `mapstructure:"max_replica_lag"`
+	ReplicaCheckInterval time.Duration        `mapstructure:"replica_check_interval"`
+}
+
+// MySQLReplicaConfig reaches a read replica of the primary database. Empty
+// credentials are taken from the primary.
+type MySQLReplicaConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
 }
 
 func (c *MySQLConfig) DSN() string {
@@ -41,6 +140,15 @@ func (c *MySQLConfig) DSN() string {
 		c.Username, c.Password, c.Host, c.Port, c.Database, c.Charset)
 }
 
+func (c *MySQLConfig) ReplicaDSN(r MySQLReplicaConfig) string {
+	replica := *c
+	replica.Host, replica.Port = r.Host, r.Port
+	if r.Username != "" {
+		replica.Username, replica.Password = r.Username, r.Password
+	}
+	return replica.DSN()
+}
+
 type InfluxDBConfig struct {
 	URL    string `mapstructure:"url"`
 	Token  string `mapstructure:"token"`
@@ -68,6 +176,12 @@ type MQTTConfig struct {
 	QoS      byte   `mapstructure:"qos"`
 }
 
+// RabbitMQConfig reaches the broker carrying the notification queue. An
+// empty URL disables publishing.
+type RabbitMQConfig struct {
+	URL string `mapstructure:"url"`
+}
+
 type LogConfig struct {
 	Level      string `mapstructure:"level"`
 	Format     string `mapstructure:"format"`
@@ -83,31 +197,165 @@ type DeviceConfig struct {
 	IRS    IRSDeviceConfig    `mapstructure:"irs"`
 	USRP   USRPDeviceConfig   `mapstructure:"usrp"`
 	Sensor SensorDeviceConfig `mapstructure:"sensor"`
+	Power  PowerModelConfig   `mapstructure:"power"`
+	// IRSPanels are further IRS panels managed next to IRS, each with its
+	// own id.
+	IRSPanels []IRSDeviceConfig `mapstructure:"irs_panels"`
+	// Propagation couples a simulated IRS panel to the simulated USRP.
+	Propagation model.SimulatedPropagation `mapstructure:"propagation"`
+}
+
+// DefaultIRSPanelID names the panel of the irs section when it sets no id.
+const DefaultIRSPanelID = "irs0"
+
+// IRSDevices returns the irs section followed by irs_panels. The first is
+// the default panel.
+func (c *DeviceConfig) IRSDevices() []IRSDeviceConfig {
+	primary := c.IRS
+	if primary.ID == "" {
+		primary.ID = DefaultIRSPanelID
+	}
+	return append([]IRSDeviceConfig{primary}, c.IRSPanels...)
 }
 
 type IRSDeviceConfig struct {
-	Enabled       bool   `mapstructure:"enabled"`
-	Simulator     bool   `mapstructure:"simulator"`
-	ElementCount  int    `mapstructure:"element_count"`
-	FrequencyBand string `mapstructure:"frequency_band"`
+	ID        string `mapstructure:"id"`
+	Enabled   bool   `mapstructure:"enabled"`
+	Simulator bool   `mapstructure:"simulator"`
+	// Driver overrides Simulator when set: "simulator", "hardware" (serial)
+	// or "network".
+	Driver        string     `mapstructure:"driver"`
+	ElementCount  int        `mapstructure:"element_count"`
+	FrequencyBand string     `mapstructure:"frequency_band"`
+	Array         array.Spec `mapstructure:"array"`
+	// QuantizationBits is the phase resolution of the panel's elements;
+	// phases are rounded to 2^bits levels before they are applied. 0 keeps
+	// continuous phases.
+	QuantizationBits int `mapstructure:"quantization_bits"`
+	// SwitchingTime is how long the elements take to settle after a write
+	// and MaxReconfigRate the most writes per second the panel takes; writes
+	// coming sooner wait for the panel. 0 leaves either unlimited.
+	SwitchingTime   time.Duration `mapstructure:"switching_time"`
+	MaxReconfigRate float64       `mapstructure:"max_reconfig_rate"`
+	// Impairments only apply when the panel is simulated.
+	Impairments model.IRSImpairments `mapstructure:"impairments"`
+	// Serial is the controller link used when Simulator is false.
+	Serial   IRSSerialConfig   `mapstructure:"serial"`
+	Network  IRSNetworkConfig  `mapstructure:"network"`
+	Watchdog IRSWatchdogConfig `mapstructure:"watchdog"`
+}
+
+// IRSWatchdogConfig alerts when the panel runs hotter than MaxTemperature,
+// reports power loss, or reports no status for StaleAfter.
+type IRSWatchdogConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	Interval       time.Duration `mapstructure:"interval"`
+	StaleAfter     time.Duration `mapstructure:"stale_after"`
+	MaxTemperature float64       `mapstructure:"max_temperature"`
+}
+
+// IRSNetworkConfig reaches an Ethernet controller over udp or tcp with
+// binary or json encoding. Timeout and retries are per command.
+type IRSNetworkConfig struct {
+	Protocol          string        `mapstructure:"protocol"`
+	Address           string        `mapstructure:"address"`
+	Encoding          string        `mapstructure:"encoding"`
+	Timeout           time.Duration `mapstructure:"timeout"`
+	Retries           int           `mapstructure:"retries"`
+	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"`
+	StatusInterval    time.Duration `mapstructure:"status_interval"`
+}
+
+type IRSSerialConfig struct {
+	Port     string        `mapstructure:"port"`
+	BaudRate int           `mapstructure:"baud_rate"`
+	DataBits int           `mapstructure:"data_bits"`
+	Parity   string        `mapstructure:"parity"`
+	StopBits int           `mapstructure:"stop_bits"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+	Retries  int           `mapstructure:"retries"`
 }
 
 type USRPDeviceConfig struct {
-	Enabled    bool    `mapstructure:"enabled"`
-	Simulator  bool    `mapstructure:"simulator"`
-	SampleRate float64 `mapstructure:"sample_rate"`
-	CenterFreq float64 `mapstructure:"center_freq"`
+	Enabled   bool `mapstructure:"enabled"`
+	Simulator bool `mapstructure:"simulator"`
+	// Driver overrides Simulator when set: "simulator", "hardware" or "zmq".
+	Driver     string     `mapstructure:"driver"`
+	SampleRate float64    `mapstructure:"sample_rate"`
+	CenterFreq float64    `mapstructure:"center_freq"`
+	Gain       float64    `mapstructure:"gain"`
+	TxGain     float64    `mapstructure:"tx_gain"`
+	DeviceArgs string     `mapstructure:"device_args"`
+	Channels   int        `mapstructure:"channels"`
+	Array      array.Spec `mapstructure:"array"`
+	// ClockSource is internal, external (10 MHz + PPS inputs) or gpsdo.
+	ClockSource string `mapstructure:"clock_source"`
+	// CalibrationFile persists the RX channel calibration across restarts.
+	CalibrationFile string `mapstructure:"calibration_file"`
+
+	Impairments model.RFImpairments `mapstructure:"impairments"`
+	ADC         model.ADCConfig     `mapstructure:"adc"`
+	ZMQ         ZMQSourceConfig     `mapstructure:"zmq"`
+	AGC         USRPAGCConfig       `mapstructure:"agc"`
+}
+
+// USRPAGCConfig holds the default AGC tuning; Enabled starts the loop at
+// boot.
+type USRPAGCConfig struct {
+	Enabled         bool `mapstructure:"enabled"`
+	model.AGCConfig `mapstructure:",squash"`
+}
+
+// ZMQSourceConfig points the zmq driver at a GNU Radio ZMQ PUB or PUSH sink.
+type ZMQSourceConfig struct {
+	Address    string `mapstructure:"address"`
+	SocketType string `mapstructure:"socket_type"`
 }
 
 type SensorDeviceConfig struct {
 	Enabled            bool          `mapstructure:"enabled"`
 	Simulator          bool          `mapstructure:"simulator"`
 	CollectionInterval time.Duration `mapstructure:"collection_interval"`
+	// Scenario is a YAML or JSON file scripting the simulated sensors.
+	Scenario string `mapstructure:"scenario"`
+	// OfflineAfter marks a sensor offline when it has not been read
+	// successfully for this long; zero disables it.
+	OfflineAfter time.Duration `mapstructure:"offline_after"`
+}
+
+type PowerModelConfig struct {
+	IRSStaticPerElement float64 `mapstructure:"irs_static_per_element"`
+	IRSSwitchEnergy     float64 `mapstructure:"irs_switch_energy"`
+	ControllerPower     float64 `mapstructure:"controller_power"`
+	USRPPower           float64 `mapstructure:"usrp_power"`
+	Tolerance           float64 `mapstructure:"tolerance"`
+	// TransmissionInterval is how long an experiment's result is taken to
+	// carry data when its energy per achieved bit is reported.
+	TransmissionInterval time.Duration `mapstructure:"transmission_interval"`
 }
 
 type AlgorithmConfig struct {
 	Beamforming BeamformingConfig `mapstructure:"beamforming"`
 	DOA         DOAConfig         `mapstructure:"doa"`
+	Admission   AdmissionConfig   `mapstructure:"admission"`
+	Cache       ResultCacheConfig `mapstructure:"cache"`
+}
+
+// ResultCacheConfig caches deterministic algorithm results; see
+// service.ResultCacheConfig.
+type ResultCacheConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Size    int           `mapstructure:"size"`
+	TTL     time.Duration `mapstructure:"ttl"`
+}
+
+// AdmissionConfig budgets experiments by predicted runtime; see
+// service.AdmissionConfig.
+type AdmissionConfig struct {
+	MaxConcurrent  int           `mapstructure:"max_concurrent"`
+	MaxQueued      int           `mapstructure:"max_queued"`
+	MaxDuration    time.Duration `mapstructure:"max_duration"`
+	HeavyThreshold time.Duration `mapstructure:"heavy_threshold"`
 }
 
 type BeamformingConfig struct {
@@ -119,6 +367,13 @@ type DOAConfig struct {
 	Method         string `mapstructure:"method"`
 	NumSources     int    `mapstructure:"num_sources"`
 	SnapshotLength int    `mapstructure:"snapshot_length"`
+	// StoreSnapshots archives every run's snapshot matrix; MaxSnapshotBytes
+	// caps each archive by dropping trailing snapshots.
+	StoreSnapshots   bool  `mapstructure:"store_snapshots"`
+	MaxSnapshotBytes int64 `mapstructure:"max_snapshot_bytes"`
+
+	Covariance model.CovarianceOptions       `mapstructure:"covariance"`
+	Smoothing  model.SpatialSmoothingOptions `mapstructure:"smoothing"`
 }
 
 type MATLABConfig struct {
@@ -127,9 +382,72 @@ type MATLABConfig struct {
 	ExportFormat string `mapstructure:"export_format"`
 }
 
+type ObjectStoreConfig struct {
+	LocalDir      string        `mapstructure:"local_dir"`
+	PublicURL     string        `mapstructure:"public_url"`
+	SigningKey    string        `mapstructure:"signing_key"`
+	PresignExpiry time.Duration `mapstructure:"presign_expiry"`
+	TierAfter     time.Duration `mapstructure:"tier_after"`
+	TierInterval  time.Duration `mapstructure:"tier_interval"`
+	GCGrace       time.Duration `mapstructure:"gc_grace"`
+	GCInterval    time.Duration `mapstructure:"gc_interval"`
+	S3            S3Config      `mapstructure:"s3"`
+}
+
+type RecordingConfig struct {
+	Dir         string        `mapstructure:"dir"`
+	MaxDuration time.Duration `mapstructure:"max_duration"`
+}
+
+// DegradationConfig sets what the server does while MySQL or InfluxDB is
+// down; see the model.Degradation* policies. Both are checked every
+// CheckInterval.
+type DegradationConfig struct {
+	CheckInterval time.Duration    `mapstructure:"check_interval"`
+	MySQL         DependencyPolicy `mapstructure:"mysql"`
+	InfluxDB      DependencyPolicy `mapstructure:"influxdb"`
+}
+
+// DependencyPolicy is the degradation policy of one dependency. BufferSize
+// bounds the writes buffer-and-retry holds; the oldest are dropped beyond
+// it.
+type DependencyPolicy struct {
+	Policy     string `mapstructure:"policy"`
+	BufferSize int    `mapstructure:"buffer_size"`
+}
+
+type S3Config struct {
+	Enabled   bool   `mapstructure:"enabled"`
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	UseSSL    bool   `mapstructure:"use_ssl"`
+}
+
 var globalConfig *Config
 
-func Init(configPath string) error {
+// ProfileEnv names the environment variable used to select a profile when
+// none is given explicitly.
+const ProfileEnv = "ISAC_ENV"
+
+// ObjectSigningKeyEnv supplies the key for presigned object URLs, which is
+// kept out of the config files.
+const ObjectSigningKeyEnv = "ISAC_OBJECT_SIGNING_KEY"
+
+// APITokensEnv adds API tokens as comma-separated principal:token pairs,
+// keeping them out of the config files like the signing key.
+const APITokensEnv = "ISAC_API_TOKENS"
+
+// ConsulAddrEnv overrides server.discovery.consul_addr, so that the services
+// of a split deployment can share one config file.
+const ConsulAddrEnv = "CONSUL_ADDR"
+
+// Init loads the base config and merges the profile overlay on top of it.
+// The overlay lives next to the base file as config.<profile>.yaml; an empty
+// profile falls back to $ISAC_ENV, and no profile loads the base alone.
+func Init(configPath, profile string) error {
 	v := viper.New()
 	v.SetConfigFile(configPath)
 	v.SetConfigType("yaml")
@@ -138,15 +456,61 @@ func Init(configPath string) error {
 		return fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	if profile == "" {
+		profile = os.Getenv(ProfileEnv)
+	}
+	if profile != "" {
+		overlay := ProfilePath(configPath, profile)
+		v.SetConfigFile(overlay)
+		if err := v.MergeInConfig(); err != nil {
+			return fmt.Errorf("failed to merge profile %q from %s: %w", profile, overlay, err)
+		}
+	}
+
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
 		return fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	cfg.Profile = profile
+	if key := os.Getenv(ObjectSigningKeyEnv); key != "" {
+		cfg.ObjectStore.SigningKey = key
+	}
+	if addr := os.Getenv(ConsulAddrEnv); addr != "" {
+		cfg.Server.Discovery.ConsulAddr = addr
+	}
+	tokens, err := parseAPITokens(os.Getenv(APITokensEnv))
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", APITokensEnv, err)
+	}
+	cfg.Server.Auth.Tokens = append(cfg.Server.Auth.Tokens, tokens...)
 
 	globalConfig = &cfg
 	return nil
 }
 
+func parseAPITokens(s string) ([]APIToken, error) {
+	var tokens []APIToken
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		principal, token, ok := strings.Cut(pair, ":")
+		if !ok || principal == "" || token == "" {
+			return nil, fmt.Errorf("expected principal:token, got %q", pair)
+		}
+		tokens = append(tokens, APIToken{Principal: principal, Token: token})
+	}
+	return tokens, nil
+}
+
+// ProfilePath returns the overlay file for profile, e.g. configs/config.yaml
+// with profile "prod" maps to configs/config.prod.yaml.
+func ProfilePath(configPath, profile string) string {
+	ext := filepath.Ext(configPath)
+	return strings.TrimSuffix(configPath, ext) + "." + profile + ext
+}
+
 func Get() *Config {
 	if globalConfig == nil {
 		panic("config not initialized, please call Init() first")
@@ -189,3 +553,7 @@ func GetAlgorithm() *AlgorithmConfig {
 func GetMATLAB() *MATLABConfig {
 	return &Get().MATLAB
 }
+
+func GetObjectStore() *ObjectStoreConfig {
+	return &Get().ObjectStore
+}
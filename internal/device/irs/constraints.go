@@ -0,0 +1,123 @@
+package irs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+// recentViolations is how many violations a panel keeps for its report.
+const recentViolations = 20
+
+// quantizationTolerance is the RMS rounding in radians below which phases
+// are taken to be on the panel's levels already.
+const quantizationTolerance = 1e-9
+
+// violationLog counts the requests a panel could not realize as given.
+type violationLog struct {
+	mu        sync.Mutex
+	quantized uint64
+	throttled uint64
+	rejected  uint64
+	recent    []model.IRSConstraintViolation
+}
+
+func (l *violationLog) add(kind model.IRSViolationKind, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch kind {
+	case model.IRSViolationPhaseResolution:
+		l.quantized++
+	case model.IRSViolationReconfigRate:
+		l.throttled++
+	case model.IRSViolationSequenceRate:
+		l.rejected++
+	}
+	violation := model.IRSConstraintViolation{Kind: kind, Message: fmt.Sprintf(format, args...), Time: time.Now()}
+	l.recent = append([]model.IRSConstraintViolation{violation}, l.recent...)
+	if len(l.recent) > recentViolations {
+		l.recent = l.recent[:recentViolations]
+	}
+}
+
+// SetConstraints sets what the panel's hardware can realize. Phases are
+// rounded to PhaseBits, writes are held back until the panel is ready for
+// them, and sequences stepping faster than that are rejected.
+func (c *Controller) SetConstraints(constraints model.IRSConstraints) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.constraints = constraints
+}
+
+func (c *Controller) Constraints() model.IRSConstraints {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.constraints
+}
+
+// ConstraintReport gives the panel's constraints with the violations seen
+// since start.
+func (c *Controller) ConstraintReport() *model.IRSConstraintReport {
+	report := &model.IRSConstraintReport{Constraints: c.Constraints()}
+	l := &c.violations
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	report.Quantized = l.quantized
+	report.Throttled = l.throttled
+	report.Rejected = l.rejected
+	report.Recent = append([]model.IRSConstraintViolation{}, l.recent...)
+	return report
+}
+
+// recordQuantization notes phases that had to be rounded to the panel's
+// resolution.
+func (c *Controller) recordQuantization(quantizationError float64) {
+	if quantizationError > quantizationTolerance {
+		c.violations.add(model.IRSViolationPhaseResolution,
+			"%d-bit phase resolution changed the phases by %.3g rad RMS", c.constraints.PhaseBits, quantizationError)
+	}
+}
+
+// write sets the panel's phases once it is ready for another write. The
+// caller holds mu.
+func (c *Controller) write(ctx context.Context, phaseShifts []float64) error {
+	return c.reconfigure(ctx, func() error {
+		return c.driver.SetPhaseShifts(ctx, phaseShifts)
+	})
+}
+
+// reconfigure runs apply once the panel is ready for another write. The
+// caller holds mu.
+func (c *Controller) reconfigure(ctx context.Context, apply func() error) error {
+	if interval := c.constraints.MinInterval(); interval > 0 && !c.lastWrite.IsZero() {
+		if wait := time.Until(c.lastWrite.Add(interval)); wait > 0 {
+			c.violations.add(model.IRSViolationReconfigRate,
+				"write held back %s, the panel takes one every %s", wait.Round(time.Microsecond), interval)
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+	if err := apply(); err != nil {
+		return err
+	}
+	c.lastWrite = time.Now()
+	return nil
+}
+
+// checkSequence rejects a sequence the panel cannot follow.
+func (c *Controller) checkSequence(req *model.IRSSequenceRequest) error {
+	if err := c.constraints.CheckSequence(req); err != nil {
+		c.violations.add(model.IRSViolationSequenceRate, "%s", err.Error())
+		return errors.Wrap(errors.CodeInvalidIRSConfig, "IRS sequence exceeds the panel's reconfiguration rate", err)
+	}
+	return nil
+}
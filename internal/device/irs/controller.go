@@ -2,9 +2,12 @@ package irs
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"sync"
 	"time"
 
+	"isac-cran-system/internal/algorithm/beamforming"
 	"isac-cran-system/internal/model"
 	"isac-cran-system/pkg/errors"
 )
@@ -17,12 +20,46 @@ type Driver interface {
 	IsConnected() bool
 }
 
+// FaultInjector is implemented by drivers that can simulate faults.
+type FaultInjector interface {
+	InjectFaults(req *model.IRSFaultRequest) error
+	ClearFaults()
+	State() *model.IRSSimulatorState
+}
+
+// Absorber is implemented by drivers of panels whose elements can be
+// switched to matched loads, so that the panel scatters as little as it
+// can. The next phase write switches them back.
+type Absorber interface {
+	Absorb(ctx context.Context) error
+}
+
+// Reflector is implemented by simulated panels: Reflection returns the
+// complex reflection coefficient each element currently applies, which
+// simulated receivers use to shape the channel.
+type Reflector interface {
+	Reflection() []complex128
+}
+
+// FirmwareUpdater is implemented by drivers of boards that can be updated
+// remotely. UpdateFirmware flashes the image and returns once the board has
+// restarted; the restart clears its phases.
+type FirmwareUpdater interface {
+	FirmwareVersion() (string, error)
+	UpdateFirmware(ctx context.Context, image []byte, version string) error
+}
+
 type Controller struct {
 	driver         Driver
 	config         *model.IRSConfig
 	status         *model.IRSStatus
+	constraints    model.IRSConstraints
 	mu             sync.RWMutex
 	onStatusChange func(status *model.IRSStatus)
+
+	// lastWrite is when phases were last written, guarded by mu.
+	lastWrite  time.Time
+	violations violationLog
 }
 
 func NewController(driver Driver) *Controller {
@@ -31,6 +68,80 @@ func NewController(driver Driver) *Controller {
 	}
 }
 
+// SetQuantizationBits sets the phase resolution of the panel. Phases are
+// rounded to the nearest of the 2^bits levels before they are applied; 0
+// applies them as given.
+func (c *Controller) SetQuantizationBits(bits int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.constraints.PhaseBits = bits
+}
+
+// FaultInjector returns the driver if it can simulate faults.
+func (c *Controller) FaultInjector() (FaultInjector, bool) {
+	injector, ok := c.driver.(FaultInjector)
+	return injector, ok
+}
+
+// Reflector returns the driver if it simulates the panel's reflection.
+func (c *Controller) Reflector() (Reflector, bool) {
+	reflector, ok := c.driver.(Reflector)
+	return reflector, ok
+}
+
+// FirmwareUpdater returns the driver if the board can be updated remotely.
+func (c *Controller) FirmwareUpdater() (FirmwareUpdater, bool) {
+	updater, ok := c.driver.(FirmwareUpdater)
+	return updater, ok
+}
+
+// CanAbsorb reports whether the panel can switch to absorptive.
+func (c *Controller) CanAbsorb() bool {
+	_, ok := c.driver.(Absorber)
+	return ok
+}
+
+// Versions reports the driver version and, for boards that can report it,
+// the running firmware.
+func (c *Controller) Versions() (*model.DeviceVersions, error) {
+	versions := &model.DeviceVersions{Driver: DriverVersion}
+	if updater, ok := c.FirmwareUpdater(); ok {
+		firmware, err := updater.FirmwareVersion()
+		if err != nil {
+			return nil, err
+		}
+		versions.Firmware = firmware
+	}
+	return versions, nil
+}
+
+// UpdateFirmware flashes image onto the board and reapplies the active
+// configuration, which the restart cleared. Configuration changes wait for
+// the update to finish.
+func (c *Controller) UpdateFirmware(ctx context.Context, image []byte, version string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	updater, ok := c.FirmwareUpdater()
+	if !ok {
+		return errors.New(errors.CodeIRSDeviceError, "IRS board does not support remote firmware updates")
+	}
+	if !c.driver.IsConnected() {
+		if err := c.driver.Connect(ctx); err != nil {
+			return errors.Wrap(errors.CodeIRSDeviceError, "failed to connect IRS device", err)
+		}
+	}
+	if err := updater.UpdateFirmware(ctx, image, version); err != nil {
+		return errors.Wrap(errors.CodeIRSDeviceError, "firmware update failed", err)
+	}
+	if c.config != nil {
+		if err := c.apply(ctx, c.config.PhaseShifts); err != nil {
+			return err
+		}
+	}
+	return c.refreshStatus(ctx)
+}
+
 func (c *Controller) Connect(ctx context.Context) error {
 	return c.driver.Connect(ctx)
 }
@@ -39,38 +150,215 @@ func (c *Controller) Disconnect() error {
 	return c.driver.Disconnect()
 }
 
+func (c *Controller) IsConnected() bool {
+	return c.driver.IsConnected()
+}
+
 func (c *Controller) Configure(ctx context.Context, config *model.IRSConfigRequest) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	next, err := c.plan(config)
+	if err != nil {
+		return err
+	}
+	if err := c.apply(ctx, next.PhaseShifts); err != nil {
+		return err
+	}
+	c.recordQuantization(next.QuantizationError)
+
+	next.Status = model.ConfigStatusApplied
+	c.config = next
+	return c.refreshStatus(ctx)
+}
+
+// Preview returns the configuration Configure would apply, with merged and
+// quantized phases, without writing it to the panel.
+func (c *Controller) Preview(config *model.IRSConfigRequest) (*model.IRSConfig, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.plan(config)
+}
+
+// ElementCount is the element count of the active configuration or, before
+// one is applied, of the last status read; 0 when neither is known.
+func (c *Controller) ElementCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.config != nil {
+		return c.config.ElementCount
+	}
+	if c.status != nil {
+		return c.status.ElementCount
+	}
+	return 0
+}
+
+func (c *Controller) plan(config *model.IRSConfigRequest) (*model.IRSConfig, error) {
 	if err := config.Validate(); err != nil {
+		return nil, errors.Wrap(errors.CodeInvalidIRSConfig, "invalid IRS configuration", err)
+	}
+	if err := c.checkVersion(config.Version); err != nil {
+		return nil, err
+	}
+
+	phaseShifts := make([]float64, config.ElementCount)
+	if len(config.PhaseShifts) > 0 {
+		copy(phaseShifts, config.PhaseShifts)
+	} else if c.config != nil && c.config.ElementCount == config.ElementCount {
+		copy(phaseShifts, c.config.PhaseShifts)
+	}
+	groups := make([]model.IRSElementGroup, len(config.Groups))
+	for i, g := range config.Groups {
+		groups[i] = model.IRSElementGroup{
+			Name:        g.Name,
+			Elements:    append([]int(nil), g.Elements...),
+			PhaseShifts: append([]float64(nil), g.PhaseShifts...),
+		}
+		for j, e := range g.Elements {
+			phaseShifts[e] = g.PhaseShifts[j]
+		}
+	}
+	phaseShifts, quantizationError := c.quantize(phaseShifts)
+	for _, g := range groups {
+		for j, e := range g.Elements {
+			g.PhaseShifts[j] = phaseShifts[e]
+		}
+	}
+
+	return &model.IRSConfig{
+		Name:              config.Name,
+		ElementCount:      config.ElementCount,
+		PhaseShifts:       phaseShifts,
+		Groups:            groups,
+		FrequencyBand:     config.FrequencyBand,
+		Version:           c.nextVersion(),
+		ExperimentID:      config.ExperimentID,
+		QuantizationBits:  c.constraints.PhaseBits,
+		QuantizationError: quantizationError,
+	}, nil
+}
+
+// ConfigureGroup sets the phases of one element group of the active
+// configuration; the other elements keep theirs.
+func (c *Controller) ConfigureGroup(ctx context.Context, name string, req *model.IRSGroupRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.config == nil {
+		return errors.New(errors.CodeIRSDeviceError, "no active IRS configuration")
+	}
+	if err := c.checkVersion(req.Version); err != nil {
+		return err
+	}
+	index := -1
+	for i := range c.config.Groups {
+		if c.config.Groups[i].Name == name {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return errors.New(errors.CodeNotFound, "IRS element group "+name+" not found")
+	}
+	group := c.config.Groups[index]
+	if len(req.PhaseShifts) != len(group.Elements) {
+		return errors.New(errors.CodeInvalidIRSConfig, "phase_shifts length must equal the group's element count")
+	}
+	if err := req.Validate(); err != nil {
 		return errors.Wrap(errors.CodeInvalidIRSConfig, "invalid IRS configuration", err)
 	}
 
+	groupPhases, quantizationError := c.quantize(req.PhaseShifts)
+	phaseShifts := append([]float64(nil), c.config.PhaseShifts...)
+	for j, e := range group.Elements {
+		phaseShifts[e] = groupPhases[j]
+	}
+	if err := c.apply(ctx, phaseShifts); err != nil {
+		return err
+	}
+	c.recordQuantization(quantizationError)
+
+	// the previous config may still be held by callers of GetCurrentConfig
+	config := *c.config
+	config.PhaseShifts = phaseShifts
+	config.Groups = append([]model.IRSElementGroup(nil), c.config.Groups...)
+	config.Groups[index].PhaseShifts = groupPhases
+	config.Version = c.nextVersion()
+	config.ExperimentID = req.ExperimentID
+	config.QuantizationBits = c.constraints.PhaseBits
+	config.QuantizationError = quantizationError
+	c.config = &config
+	return c.refreshStatus(ctx)
+}
+
+// checkVersion rejects a change made against a configuration other than the
+// active one. Version 0 skips the check.
+func (c *Controller) checkVersion(version int64) error {
+	if version == 0 {
+		return nil
+	}
+	current := int64(0)
+	if c.config != nil {
+		current = c.config.Version
+	}
+	if version != current {
+		return errors.NewWithDetail(errors.CodeVersionConflict, "IRS configuration was modified concurrently",
+			fmt.Sprintf("version %d is not the active version %d", version, current))
+	}
+	return nil
+}
+
+func (c *Controller) nextVersion() int64 {
+	if c.config == nil {
+		return 1
+	}
+	return c.config.Version + 1
+}
+
+// quantize rounds phases to the panel's resolution and returns them with the
+// RMS phase error in radians. The input is not modified.
+func (c *Controller) quantize(phases []float64) ([]float64, float64) {
+	if c.constraints.PhaseBits <= 0 {
+		return append([]float64(nil), phases...), 0
+	}
+	var calc beamforming.WeightsCalculator
+	quantized := calc.ApplyPhaseQuantization(phases, c.constraints.PhaseBits)
+	return quantized, phaseError(phases, quantized)
+}
+
+// phaseError is the RMS difference of two phase vectors with each difference
+// wrapped to [-π, π].
+func phaseError(want, got []float64) float64 {
+	if len(want) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for i := range want {
+		d := math.Remainder(got[i]-want[i], 2*math.Pi)
+		sum += d * d
+	}
+	return math.Sqrt(sum / float64(len(want)))
+}
+
+func (c *Controller) apply(ctx context.Context, phaseShifts []float64) error {
 	if !c.driver.IsConnected() {
 		if err := c.driver.Connect(ctx); err != nil {
 			return errors.Wrap(errors.CodeIRSDeviceError, "failed to connect IRS device", err)
 		}
 	}
-
-	if err := c.driver.SetPhaseShifts(ctx, config.PhaseShifts); err != nil {
+	if err := c.write(ctx, phaseShifts); err != nil {
 		return errors.Wrap(errors.CodeIRSConfigFailed, "failed to set phase shifts", err)
 	}
+	return nil
+}
 
-	c.config = &model.IRSConfig{
-		Name:          config.Name,
-		ElementCount:  config.ElementCount,
-		PhaseShifts:   config.PhaseShifts,
-		FrequencyBand: config.FrequencyBand,
-		Status:        model.ConfigStatusApplied,
-	}
-
+func (c *Controller) refreshStatus(ctx context.Context) error {
 	status, err := c.driver.GetStatus(ctx)
 	if err != nil {
 		return errors.Wrap(errors.CodeIRSStatusError, "failed to get IRS status", err)
 	}
 	c.status = status
-
 	return nil
 }
 
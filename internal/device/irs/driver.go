@@ -1,10 +1,21 @@
 package irs
 
+import (
+	"time"
+
+	"isac-cran-system/internal/model"
+)
+
+// DriverVersion is the version of the IRS drivers in this package, reported
+// with the panel firmware in device health.
+const DriverVersion = "1.3.0"
+
 type DriverType string
 
 const (
 	DriverTypeSimulator DriverType = "simulator"
 	DriverTypeHardware  DriverType = "hardware"
+	DriverTypeNetwork   DriverType = "network"
 )
 
 type DriverFactory struct{}
@@ -25,9 +36,25 @@ func (f *DriverFactory) Create(driverType DriverType, opts ...DriverOption) (Dri
 
 	switch driverType {
 	case DriverTypeSimulator:
-		return NewSimulator(config.ElementCount, config.FrequencyBand), nil
+		sim := NewSimulator(config.ElementCount, config.FrequencyBand)
+		sim.SetImpairments(config.Impairments)
+		return sim, nil
 	case DriverTypeHardware:
-		return nil, ErrHardwareDriverNotImplemented
+		if config.SerialPort == "" {
+			return nil, ErrSerialPortRequired
+		}
+		return NewSerialDriver(config), nil
+	case DriverTypeNetwork:
+		if config.Address == "" {
+			return nil, ErrAddressRequired
+		}
+		if config.Network != "" && config.Network != NetworkUDP && config.Network != NetworkTCP {
+			return nil, ErrUnknownNetwork
+		}
+		if _, err := newCodec(config.Encoding); err != nil {
+			return nil, err
+		}
+		return NewNetworkDriver(config), nil
 	default:
 		return nil, ErrUnknownDriverType
 	}
@@ -38,6 +65,24 @@ type DriverConfig struct {
 	FrequencyBand string
 	SerialPort    string
 	BaudRate      int
+	DataBits      int
+	Parity        string
+	StopBits      int
+	// Timeout bounds the wait for each acknowledgement; a command is sent
+	// up to Retries more times before it fails.
+	Timeout time.Duration
+	Retries int
+
+	// Network driver: "udp" or "tcp" to Address, frames encoded as "binary"
+	// or "json".
+	Network           string
+	Address           string
+	Encoding          string
+	HeartbeatInterval time.Duration
+	StatusInterval    time.Duration
+
+	// Impairments only apply to the simulator.
+	Impairments model.IRSImpairments
 }
 
 type DriverOption func(*DriverConfig)
@@ -66,9 +111,50 @@ func WithBaudRate(rate int) DriverOption {
 	}
 }
 
+// WithSerialFraming sets data bits, parity ("none", "even" or "odd") and
+// stop bits; zero values keep 8N1.
+func WithSerialFraming(dataBits int, parity string, stopBits int) DriverOption {
+	return func(c *DriverConfig) {
+		c.DataBits = dataBits
+		c.Parity = parity
+		c.StopBits = stopBits
+	}
+}
+
+func WithTimeout(timeout time.Duration, retries int) DriverOption {
+	return func(c *DriverConfig) {
+		c.Timeout = timeout
+		c.Retries = retries
+	}
+}
+
+// WithNetwork points the network driver at a controller address.
+func WithNetwork(network, address, encoding string) DriverOption {
+	return func(c *DriverConfig) {
+		c.Network = network
+		c.Address = address
+		c.Encoding = encoding
+	}
+}
+
+func WithPolling(heartbeat, status time.Duration) DriverOption {
+	return func(c *DriverConfig) {
+		c.HeartbeatInterval = heartbeat
+		c.StatusInterval = status
+	}
+}
+
+func WithImpairments(impairments model.IRSImpairments) DriverOption {
+	return func(c *DriverConfig) {
+		c.Impairments = impairments
+	}
+}
+
 var (
-	ErrHardwareDriverNotImplemented = &FactoryError{Message: "hardware driver not implemented"}
-	ErrUnknownDriverType            = &FactoryError{Message: "unknown driver type"}
+	ErrAddressRequired    = &FactoryError{Message: "network driver needs a controller address"}
+	ErrUnknownNetwork     = &FactoryError{Message: "unknown network, use udp or tcp"}
+	ErrSerialPortRequired = &FactoryError{Message: "hardware driver needs a serial port"}
+	ErrUnknownDriverType  = &FactoryError{Message: "unknown driver type"}
 )
 
 type FactoryError struct {
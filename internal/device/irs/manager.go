@@ -0,0 +1,102 @@
+package irs
+
+import (
+	"sync"
+)
+
+// Manager holds the IRS panels by ID. The first panel added is the default,
+// used when a caller does not name one.
+type Manager struct {
+	mu          sync.RWMutex
+	controllers map[string]*Controller
+	ids         []string
+}
+
+func NewManager() *Manager {
+	return &Manager{controllers: make(map[string]*Controller)}
+}
+
+func (m *Manager) Add(id string, c *Controller) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id == "" {
+		return ErrPanelIDRequired
+	}
+	if _, ok := m.controllers[id]; ok {
+		return ErrDuplicatePanel
+	}
+	m.controllers[id] = c
+	m.ids = append(m.ids, id)
+	return nil
+}
+
+// Get returns the panel with id, or the default panel for an empty id.
+func (m *Manager) Get(id string) (*Controller, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.ids) == 0 {
+		return nil, ErrNoPanels
+	}
+	if id == "" {
+		id = m.ids[0]
+	}
+	c, ok := m.controllers[id]
+	if !ok {
+		return nil, ErrUnknownPanel
+	}
+	return c, nil
+}
+
+// Default returns the default panel, or nil if there is none.
+func (m *Manager) Default() *Controller {
+	c, _ := m.Get("")
+	return c
+}
+
+// DefaultID is the ID of the default panel, or "" if there is none.
+func (m *Manager) DefaultID() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.ids) == 0 {
+		return ""
+	}
+	return m.ids[0]
+}
+
+// IDs lists the panels in the order they were added.
+func (m *Manager) IDs() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]string(nil), m.ids...)
+}
+
+func (m *Manager) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.ids)
+}
+
+func (m *Manager) DisconnectAll() {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, id := range m.ids {
+		m.controllers[id].Disconnect()
+	}
+}
+
+var (
+	ErrPanelIDRequired = &ManagerError{Message: "IRS panel id is required"}
+	ErrDuplicatePanel  = &ManagerError{Message: "duplicate IRS panel id"}
+	ErrNoPanels        = &ManagerError{Message: "no IRS panels configured"}
+	ErrUnknownPanel    = &ManagerError{Message: "unknown IRS panel"}
+)
+
+type ManagerError struct {
+	Message string
+}
+
+func (e *ManagerError) Error() string {
+	return e.Message
+}
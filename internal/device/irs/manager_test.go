@@ -0,0 +1,37 @@
+package irs
+
+import (
+	"testing"
+)
+
+func TestManager_Get(t *testing.T) {
+	m := NewManager()
+	if _, err := m.Get(""); err != ErrNoPanels {
+		t.Fatalf("Get() on empty manager error = %v, want %v", err, ErrNoPanels)
+	}
+
+	comms := NewController(NewSimulator(32, "2.4GHz"))
+	sensing := NewController(NewSimulator(64, "5.8GHz"))
+	if err := m.Add("comms", comms); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := m.Add("sensing", sensing); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := m.Add("sensing", sensing); err != ErrDuplicatePanel {
+		t.Errorf("Add() of duplicate id error = %v, want %v", err, ErrDuplicatePanel)
+	}
+
+	if c, err := m.Get(""); err != nil || c != comms {
+		t.Errorf("Get(\"\") = %p, %v, want the first panel", c, err)
+	}
+	if c, err := m.Get("sensing"); err != nil || c != sensing {
+		t.Errorf("Get(sensing) = %p, %v, want the sensing panel", c, err)
+	}
+	if _, err := m.Get("radar"); err != ErrUnknownPanel {
+		t.Errorf("Get(radar) error = %v, want %v", err, ErrUnknownPanel)
+	}
+	if ids := m.IDs(); len(ids) != 2 || ids[0] != "comms" || ids[1] != "sensing" {
+		t.Errorf("IDs() = %v, want [comms sensing]", ids)
+	}
+}
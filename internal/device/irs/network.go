@@ -0,0 +1,239 @@
+package irs
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	NetworkUDP = "udp"
+	NetworkTCP = "tcp"
+
+	defaultHeartbeatInterval = 2 * time.Second
+	defaultStatusInterval    = 5 * time.Second
+)
+
+// NetworkDriver drives an Ethernet-attached IRS controller over UDP or TCP
+// with either frame encoding. A background loop pings the controller every
+// heartbeat interval, redialing TCP connections that failed, and polls its
+// status so GetStatus is served without a round trip.
+type NetworkDriver struct {
+	elementCount      int
+	frequencyBand     string
+	network           string
+	address           string
+	encoding          string
+	timeout           time.Duration
+	retries           int
+	heartbeatInterval time.Duration
+	statusInterval    time.Duration
+	dial              func(ctx context.Context) (net.Conn, error)
+
+	mu      sync.Mutex
+	link    *link
+	healthy bool
+	status  *model.IRSStatus
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+func NewNetworkDriver(config *DriverConfig) *NetworkDriver {
+	d := &NetworkDriver{
+		elementCount:      config.ElementCount,
+		frequencyBand:     config.FrequencyBand,
+		network:           config.Network,
+		address:           config.Address,
+		encoding:          config.Encoding,
+		timeout:           config.Timeout,
+		retries:           config.Retries,
+		heartbeatInterval: config.HeartbeatInterval,
+		statusInterval:    config.StatusInterval,
+	}
+	if d.network == "" {
+		d.network = NetworkUDP
+	}
+	if d.heartbeatInterval <= 0 {
+		d.heartbeatInterval = defaultHeartbeatInterval
+	}
+	if d.statusInterval <= 0 {
+		d.statusInterval = defaultStatusInterval
+	}
+	d.dial = func(ctx context.Context) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, d.network, d.address)
+	}
+	return d
+}
+
+func (d *NetworkDriver) Connect(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.cancel != nil {
+		return nil
+	}
+	if err := d.redial(ctx); err != nil {
+		return err
+	}
+
+	monitorCtx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	d.done = make(chan struct{})
+	go d.monitor(monitorCtx)
+
+	logger.Info("IRS network controller connected",
+		zap.String("network", d.network),
+		zap.String("address", d.address),
+		zap.String("encoding", d.encoding),
+		zap.Int("element_count", d.elementCount),
+	)
+	return nil
+}
+
+// redial opens a fresh connection and pings the controller on it.
+func (d *NetworkDriver) redial(ctx context.Context) error {
+	codec, err := newCodec(d.encoding)
+	if err != nil {
+		return err
+	}
+	conn, err := d.dial(ctx)
+	if err != nil {
+		return err
+	}
+	l := newLink(conn, codec, d.timeout, d.retries)
+	if _, err := l.exchange(ctx, &message{cmd: cmdPing}); err != nil {
+		conn.Close()
+		return err
+	}
+	if d.link != nil {
+		d.link.conn.Close()
+	}
+	d.link = l
+	d.healthy = true
+	return nil
+}
+
+func (d *NetworkDriver) Disconnect() error {
+	d.mu.Lock()
+	cancel, done := d.cancel, d.done
+	d.cancel = nil
+	d.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.healthy = false
+	d.status = nil
+	if d.link == nil {
+		return nil
+	}
+	err := d.link.conn.Close()
+	d.link = nil
+	return err
+}
+
+// IsConnected reports whether the controller answered the last heartbeat.
+func (d *NetworkDriver) IsConnected() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.link != nil && d.healthy
+}
+
+func (d *NetworkDriver) SetPhaseShifts(ctx context.Context, phaseShifts []float64) error {
+	if len(phaseShifts) != d.elementCount {
+		return ErrInvalidPhaseShiftCount
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.link == nil {
+		return ErrDeviceNotConnected
+	}
+	_, err := d.link.exchange(ctx, &message{cmd: cmdSetPhases, phases: phaseShifts})
+	return err
+}
+
+// GetStatus returns the last polled status while it is fresh and queries
+// the controller otherwise.
+func (d *NetworkDriver) GetStatus(ctx context.Context) (*model.IRSStatus, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.link == nil {
+		return nil, ErrDeviceNotConnected
+	}
+	if d.status != nil && time.Since(d.status.LastUpdate) < d.statusInterval {
+		status := *d.status
+		return &status, nil
+	}
+	return d.pollStatus(ctx)
+}
+
+func (d *NetworkDriver) pollStatus(ctx context.Context) (*model.IRSStatus, error) {
+	reply, err := d.link.exchange(ctx, &message{cmd: cmdGetStatus})
+	if err != nil {
+		return nil, err
+	}
+	d.status = statusOf(reply, d.frequencyBand)
+	status := *d.status
+	return &status, nil
+}
+
+func (d *NetworkDriver) monitor(ctx context.Context) {
+	defer close(d.done)
+	heartbeat := time.NewTicker(d.heartbeatInterval)
+	defer heartbeat.Stop()
+	poll := time.NewTicker(d.statusInterval)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			d.heartbeat(ctx)
+		case <-poll.C:
+			d.mu.Lock()
+			if d.healthy {
+				if _, err := d.pollStatus(ctx); err != nil {
+					logger.Warn("IRS status poll failed", zap.Error(err))
+				}
+			}
+			d.mu.Unlock()
+		}
+	}
+}
+
+func (d *NetworkDriver) heartbeat(ctx context.Context) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var err error
+	if d.healthy || d.network != NetworkTCP {
+		_, err = d.link.exchange(ctx, &message{cmd: cmdPing})
+	} else {
+		// a TCP connection that missed heartbeats is likely dead; start over
+		err = d.redial(ctx)
+	}
+
+	switch {
+	case err == nil && !d.healthy:
+		d.healthy = true
+		logger.Info("IRS network controller reachable again", zap.String("address", d.address))
+	case err != nil && d.healthy:
+		d.healthy = false
+		logger.Warn("IRS network controller heartbeat failed", zap.String("address", d.address), zap.Error(err))
+	}
+}
@@ -0,0 +1,82 @@
+package irs
+
+import (
+	"context"
+	"math"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNetworkDriver_TCPReconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer listener.Close()
+
+	var accepted int32
+	conns := make(chan net.Conn, 4)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&accepted, 1)
+			conns <- conn
+			go fakeController(conn, jsonCodec{})
+		}
+	}()
+
+	driver, err := NewDriverFactory().Create(DriverTypeNetwork,
+		WithElementCount(2),
+		WithNetwork(NetworkTCP, listener.Addr().String(), EncodingJSON),
+		WithTimeout(50*time.Millisecond, 2),
+		WithPolling(20*time.Millisecond, time.Hour),
+	)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	ctx := context.Background()
+	if err := driver.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer driver.Disconnect()
+
+	phases := []float64{0.5, math.Pi}
+	if err := driver.SetPhaseShifts(ctx, phases); err != nil {
+		t.Fatalf("SetPhaseShifts failed: %v", err)
+	}
+	status, err := driver.GetStatus(ctx)
+	if err != nil {
+		t.Fatalf("GetStatus failed: %v", err)
+	}
+	if len(status.PhaseShifts) != 2 || status.PhaseShifts[1] != math.Pi || status.Temperature != 24 {
+		t.Errorf("status = %+v, want the applied phases at 24 °C", status)
+	}
+
+	// drop the controller side; the heartbeat has to redial
+	(<-conns).Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt32(&accepted) < 2 || !driver.IsConnected() {
+		if time.Now().After(deadline) {
+			t.Fatalf("driver did not reconnect, %d connections accepted", atomic.LoadInt32(&accepted))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDriverFactory_Network(t *testing.T) {
+	factory := NewDriverFactory()
+	if _, err := factory.Create(DriverTypeNetwork); err != ErrAddressRequired {
+		t.Errorf("Create without address error = %v, want %v", err, ErrAddressRequired)
+	}
+	if _, err := factory.Create(DriverTypeNetwork, WithNetwork("sctp", "10.0.0.2:9000", "")); err != ErrUnknownNetwork {
+		t.Errorf("Create with sctp error = %v, want %v", err, ErrUnknownNetwork)
+	}
+	if _, err := factory.Create(DriverTypeNetwork, WithNetwork(NetworkUDP, "10.0.0.2:9000", "xml")); err != ErrUnknownEncoding {
+		t.Errorf("Create with xml encoding error = %v, want %v", err, ErrUnknownEncoding)
+	}
+}
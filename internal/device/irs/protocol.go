@@ -0,0 +1,365 @@
+package irs
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"isac-cran-system/pkg/logger"
+	"isac-cran-system/pkg/serial"
+
+	"go.uber.org/zap"
+)
+
+// Controller boards speak a request/acknowledge protocol: every command
+// carries a sequence number and is answered with the same seq and cmd|0x80,
+// or with cmdNAK and an error code. The command set is the same on every
+// transport; only the encoding differs.
+const (
+	cmdPing      = 0x01
+	cmdSetPhases = 0x02
+	cmdGetStatus = 0x03
+	cmdACK       = 0x80
+	cmdNAK       = 0x7F
+
+	defaultControllerTimeout = 500 * time.Millisecond
+	defaultControllerRetries = 3
+)
+
+const (
+	EncodingBinary = "binary"
+	EncodingJSON   = "json"
+)
+
+// message is one command or reply. Phases are set on SET_PHASES and on the
+// GET_STATUS reply, together with temperature and power.
+type message struct {
+	cmd         byte
+	seq         uint8
+	code        byte
+	phases      []float64
+	temperature float64
+	power       bool
+}
+
+type codec interface {
+	write(w io.Writer, m *message) error
+	read(r *bufio.Reader) (*message, error)
+}
+
+func newCodec(encoding string) (codec, error) {
+	switch encoding {
+	case "", EncodingBinary:
+		return binaryCodec{}, nil
+	case EncodingJSON:
+		return jsonCodec{}, nil
+	}
+	return nil, ErrUnknownEncoding
+}
+
+// link runs the protocol over any connection with read deadlines.
+type link struct {
+	conn    serial.Port
+	reader  *bufio.Reader
+	codec   codec
+	seq     uint8
+	timeout time.Duration
+	retries int
+}
+
+func newLink(conn serial.Port, codec codec, timeout time.Duration, retries int) *link {
+	if timeout <= 0 {
+		timeout = defaultControllerTimeout
+	}
+	if retries <= 0 {
+		retries = defaultControllerRetries
+	}
+	return &link{
+		conn:    conn,
+		reader:  bufio.NewReaderSize(conn, 64<<10),
+		codec:   codec,
+		timeout: timeout,
+		retries: retries,
+	}
+}
+
+// exchange sends req and waits for its acknowledgement, resending on
+// timeouts, corrupted frames and stale replies. A NAK is final.
+func (l *link) exchange(ctx context.Context, req *message) (*message, error) {
+	l.seq++
+	req.seq = l.seq
+
+	var lastErr error
+	for attempt := 0; attempt <= l.retries; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if attempt > 0 {
+			logger.Warn("Retrying IRS controller command",
+				zap.Uint8("cmd", req.cmd),
+				zap.Int("attempt", attempt),
+				zap.Error(lastErr),
+			)
+		}
+		if err := l.codec.write(l.conn, req); err != nil {
+			return nil, err
+		}
+
+		deadline := time.Now().Add(l.timeout)
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+		if err := l.conn.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+
+		reply, err := l.awaitReply(req.seq)
+		switch {
+		case err == nil && reply.cmd == req.cmd|cmdACK:
+			return reply, nil
+		case err == nil && reply.cmd == cmdNAK:
+			return nil, &ControllerError{Message: fmt.Sprintf("controller rejected command 0x%02x with code %d", req.cmd, reply.code)}
+		case err == nil:
+			lastErr = ErrUnexpectedReply
+		case errors.Is(err, os.ErrDeadlineExceeded):
+			lastErr = ErrControllerTimeout
+		case err == errBadFrame:
+			lastErr = err
+		default:
+			return nil, err
+		}
+	}
+	return nil, lastErr
+}
+
+// awaitReply reads messages until one carries seq, skipping noise and
+// replies to earlier attempts.
+func (l *link) awaitReply(seq uint8) (*message, error) {
+	for {
+		m, err := l.codec.read(l.reader)
+		if err != nil {
+			return nil, err
+		}
+		if m.seq == seq {
+			return m, nil
+		}
+	}
+}
+
+// binaryCodec frames messages as
+//
+//	0xA5 0x5A | cmd | seq | len (u16 LE) | payload | CRC-16/CCITT (u16 LE)
+//
+// with the CRC over cmd through payload. SET_PHASES carries the element
+// count (u16) and one u16 code of 2π/65536 rad per element; the GET_STATUS
+// reply carries temperature (i16, 0.01 °C), power (u8), element count (u16)
+// and the codes; a NAK carries its error code.
+type binaryCodec struct{}
+
+const (
+	frameSOF0 = 0xA5
+	frameSOF1 = 0x5A
+
+	frameHeaderSize = 6
+	maxFramePayload = 4096
+)
+
+func (binaryCodec) write(w io.Writer, m *message) error {
+	var payload []byte
+	switch m.cmd {
+	case cmdSetPhases, cmdGetStatus | cmdACK:
+		if m.cmd == cmdGetStatus|cmdACK {
+			payload = []byte{0, 0, 0}
+			binary.LittleEndian.PutUint16(payload, uint16(int16(math.Round(m.temperature*100))))
+			if m.power {
+				payload[2] = 1
+			}
+		}
+		codes := make([]byte, 2+2*len(m.phases))
+		binary.LittleEndian.PutUint16(codes, uint16(len(m.phases)))
+		for i, phase := range m.phases {
+			binary.LittleEndian.PutUint16(codes[2+2*i:], phaseCode(phase))
+		}
+		payload = append(payload, codes...)
+	case cmdNAK:
+		payload = []byte{m.code}
+	}
+	_, err := w.Write(encodeFrame(m.cmd, m.seq, payload))
+	return err
+}
+
+func (binaryCodec) read(r *bufio.Reader) (*message, error) {
+	cmd, seq, payload, err := readFrame(r)
+	if err != nil {
+		return nil, err
+	}
+	m := &message{cmd: cmd, seq: seq}
+	switch cmd {
+	case cmdNAK:
+		if len(payload) > 0 {
+			m.code = payload[0]
+		}
+	case cmdSetPhases, cmdGetStatus | cmdACK:
+		if cmd == cmdGetStatus|cmdACK {
+			if len(payload) < 3 {
+				return nil, ErrMalformedStatus
+			}
+			m.temperature = float64(int16(binary.LittleEndian.Uint16(payload))) / 100
+			m.power = payload[2] != 0
+			payload = payload[3:]
+		}
+		if len(payload) < 2 {
+			return nil, ErrMalformedStatus
+		}
+		count := int(binary.LittleEndian.Uint16(payload))
+		if len(payload) != 2+2*count {
+			return nil, ErrMalformedStatus
+		}
+		m.phases = make([]float64, count)
+		for i := range m.phases {
+			m.phases[i] = float64(binary.LittleEndian.Uint16(payload[2+2*i:])) * 2 * math.Pi / 65536
+		}
+	}
+	return m, nil
+}
+
+func encodeFrame(cmd byte, seq uint8, payload []byte) []byte {
+	buf := make([]byte, 0, 2+frameHeaderSize+len(payload))
+	buf = append(buf, frameSOF0, frameSOF1, cmd, seq, 0, 0)
+	binary.LittleEndian.PutUint16(buf[4:], uint16(len(payload)))
+	buf = append(buf, payload...)
+	crc := crc16(buf[2:])
+	return append(buf, byte(crc), byte(crc>>8))
+}
+
+func readFrame(r *bufio.Reader) (cmd byte, seq uint8, payload []byte, err error) {
+	// resynchronize on the start-of-frame marker
+	for prev := byte(0); ; {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		if prev == frameSOF0 && b == frameSOF1 {
+			break
+		}
+		prev = b
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, 0, nil, err
+	}
+	n := int(binary.LittleEndian.Uint16(header[2:]))
+	if n > maxFramePayload {
+		return 0, 0, nil, errBadFrame
+	}
+	body := make([]byte, n+2)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, 0, nil, err
+	}
+	if binary.LittleEndian.Uint16(body[n:]) != crc16(append(header, body[:n]...)) {
+		return 0, 0, nil, errBadFrame
+	}
+	return header[0], header[1], body[:n], nil
+}
+
+// crc16 is CRC-16/CCITT-FALSE (poly 0x1021, init 0xFFFF).
+func crc16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func phaseCode(phase float64) uint16 {
+	phase = math.Mod(phase, 2*math.Pi)
+	if phase < 0 {
+		phase += 2 * math.Pi
+	}
+	return uint16(int(math.Round(phase/(2*math.Pi)*65536)) & 0xFFFF)
+}
+
+// jsonCodec sends one JSON object per line, for controllers scripted in a
+// high-level language:
+//
+//	{"cmd":2,"seq":7,"phases":[0,1.57]}
+//	{"cmd":131,"seq":8,"temperature":24.5,"power":true,"phases":[0,1.57]}
+//	{"cmd":127,"seq":9,"code":3}
+//
+// Phases are in radians.
+type jsonCodec struct{}
+
+type jsonMessage struct {
+	Cmd         byte      `json:"cmd"`
+	Seq         uint8     `json:"seq"`
+	Code        byte      `json:"code,omitempty"`
+	Phases      []float64 `json:"phases,omitempty"`
+	Temperature float64   `json:"temperature,omitempty"`
+	Power       bool      `json:"power,omitempty"`
+}
+
+func (jsonCodec) write(w io.Writer, m *message) error {
+	data, err := json.Marshal(jsonMessage{
+		Cmd:         m.cmd,
+		Seq:         m.seq,
+		Code:        m.code,
+		Phases:      m.phases,
+		Temperature: m.temperature,
+		Power:       m.power,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+func (jsonCodec) read(r *bufio.Reader) (*message, error) {
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	var m jsonMessage
+	if err := json.Unmarshal(line, &m); err != nil {
+		return nil, errBadFrame
+	}
+	return &message{
+		cmd:         m.Cmd,
+		seq:         m.Seq,
+		code:        m.Code,
+		phases:      m.Phases,
+		temperature: m.Temperature,
+		power:       m.Power,
+	}, nil
+}
+
+var (
+	ErrControllerTimeout = &ControllerError{Message: "irs controller did not answer in time"}
+	ErrUnexpectedReply   = &ControllerError{Message: "irs controller sent an unexpected reply"}
+	ErrMalformedStatus   = &ControllerError{Message: "irs controller sent a malformed status"}
+	ErrUnknownEncoding   = &ControllerError{Message: "unknown controller encoding, use binary or json"}
+	errBadFrame          = &ControllerError{Message: "irs controller sent a corrupted frame"}
+)
+
+type ControllerError struct {
+	Message string
+}
+
+func (e *ControllerError) Error() string {
+	return e.Message
+}
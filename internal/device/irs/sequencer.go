@@ -0,0 +1,256 @@
+package irs
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+type sequenceStep struct {
+	phaseShifts []float64
+	dwell       time.Duration
+}
+
+// Sequencer plays a phase sequence on one panel. Steps are scheduled against
+// the start time rather than the previous step, so slow writes do not
+// accumulate drift; a step that is already due is applied at once. When the
+// sequence ends or is stopped the active configuration is restored.
+type Sequencer struct {
+	controller *Controller
+	steps      []sequenceStep
+	loop       bool
+	passes     int
+	cancel     context.CancelFunc
+	done       chan struct{}
+
+	mu     sync.Mutex
+	status model.IRSSequenceStatus
+}
+
+func (c *Controller) StartSequence(ctx context.Context, req *model.IRSSequenceRequest) (*Sequencer, error) {
+	if err := req.Validate(); err != nil {
+		return nil, errors.Wrap(errors.CodeInvalidIRSConfig, "invalid IRS sequence", err)
+	}
+
+	c.mu.Lock()
+	if !c.driver.IsConnected() {
+		if err := c.driver.Connect(ctx); err != nil {
+			c.mu.Unlock()
+			return nil, errors.Wrap(errors.CodeIRSDeviceError, "failed to connect IRS device", err)
+		}
+	}
+	status, err := c.driver.GetStatus(ctx)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeIRSStatusError, "failed to get IRS status", err)
+	}
+	if n := len(req.Steps[0].PhaseShifts); n != status.ElementCount {
+		return nil, errors.New(errors.CodeInvalidIRSConfig, "sequence steps must set every element of the panel")
+	}
+
+	steps := make([]sequenceStep, len(req.Steps))
+	c.mu.RLock()
+	if err := c.checkSequence(req); err != nil {
+		c.mu.RUnlock()
+		return nil, err
+	}
+	worst := 0.0
+	for i, step := range req.Steps {
+		dwell := step.Dwell
+		if dwell == 0 {
+			dwell = req.Dwell
+		}
+		phaseShifts, quantizationError := c.quantize(step.PhaseShifts)
+		steps[i] = sequenceStep{
+			phaseShifts: phaseShifts,
+			dwell:       time.Duration(dwell * float64(time.Second)),
+		}
+		worst = math.Max(worst, quantizationError)
+	}
+	c.recordQuantization(worst)
+	c.mu.RUnlock()
+	passes := req.Repeat
+	if passes == 0 {
+		passes = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Sequencer{
+		controller: c,
+		steps:      steps,
+		loop:       req.Loop,
+		passes:     passes,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+		status:     model.IRSSequenceStatus{Running: true, Request: req, StartedAt: time.Now()},
+	}
+	go s.run(ctx)
+
+	logger.Info("IRS sequence started",
+		zap.Int("steps", len(steps)),
+		zap.Bool("loop", req.Loop),
+		zap.Int("passes", passes),
+	)
+	return s, nil
+}
+
+func (s *Sequencer) run(ctx context.Context) {
+	defer close(s.done)
+
+	due := time.Now()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+
+	for pass := 0; s.loop || pass < s.passes; pass++ {
+		for i, step := range s.steps {
+			lateness := time.Since(due)
+			if err := s.controller.applyStep(ctx, step.phaseShifts); err != nil {
+				if ctx.Err() != nil {
+					err = nil
+				}
+				s.finish(err)
+				return
+			}
+			s.record(i, pass, lateness)
+
+			due = due.Add(step.dwell)
+			if wait := time.Until(due); wait > 0 {
+				timer.Reset(wait)
+				select {
+				case <-ctx.Done():
+					s.finish(nil)
+					return
+				case <-timer.C:
+				}
+			} else if ctx.Err() != nil {
+				s.finish(nil)
+				return
+			}
+		}
+	}
+	s.finish(nil)
+}
+
+func (s *Sequencer) record(step, pass int, lateness time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Step = step
+	s.status.Pass = pass
+	s.status.Applied++
+	// the first step has nothing before it to be late after
+	if s.status.Applied > 1 && lateness > time.Millisecond/10 {
+		s.status.Late++
+		if secs := lateness.Seconds(); secs > s.status.Lateness {
+			s.status.Lateness = secs
+		}
+	}
+}
+
+func (s *Sequencer) finish(err error) {
+	s.cancel()
+	if restoreErr := s.controller.restore(context.Background()); err == nil {
+		err = restoreErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.status.Running = false
+	s.status.StoppedAt = &now
+	if err != nil {
+		s.status.Error = err.Error()
+		logger.Warn("IRS sequence stopped by error", zap.Error(err))
+		return
+	}
+	logger.Info("IRS sequence stopped", zap.Uint64("applied", s.status.Applied), zap.Uint64("late", s.status.Late))
+}
+
+// Stop ends the sequence and waits until the active configuration has been
+// restored.
+func (s *Sequencer) Stop() {
+	s.cancel()
+	<-s.done
+}
+
+func (s *Sequencer) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Sequencer) Status() model.IRSSequenceStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// applyStep writes phases straight to the driver. Sequence steps are not
+// configurations: they leave the active configuration and status untouched.
+func (c *Controller) applyStep(ctx context.Context, phaseShifts []float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.write(ctx, phaseShifts); err != nil {
+		return errors.Wrap(errors.CodeIRSConfigFailed, "failed to set phase shifts", err)
+	}
+	return nil
+}
+
+func (c *Controller) restore(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.config == nil || !c.driver.IsConnected() {
+		return nil
+	}
+	if err := c.write(ctx, c.config.PhaseShifts); err != nil {
+		return errors.Wrap(errors.CodeIRSConfigFailed, "failed to restore IRS configuration", err)
+	}
+	return nil
+}
+
+// Hold writes phases, rounded to the panel's resolution, without making
+// them the active configuration, as a sequence step does; Restore puts the
+// active configuration back.
+func (c *Controller) Hold(ctx context.Context, phaseShifts []float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.driver.IsConnected() {
+		if err := c.driver.Connect(ctx); err != nil {
+			return errors.Wrap(errors.CodeIRSDeviceError, "failed to connect IRS device", err)
+		}
+	}
+	quantized, _ := c.quantize(phaseShifts)
+	if err := c.write(ctx, quantized); err != nil {
+		return errors.Wrap(errors.CodeIRSConfigFailed, "failed to set phase shifts", err)
+	}
+	return nil
+}
+
+// Absorb switches the elements to matched loads until the next write, on
+// panels that can.
+func (c *Controller) Absorb(ctx context.Context) error {
+	absorber, ok := c.driver.(Absorber)
+	if !ok {
+		return errors.New(errors.CodeInvalidParam, "IRS panel cannot switch to absorptive")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.driver.IsConnected() {
+		if err := c.driver.Connect(ctx); err != nil {
+			return errors.Wrap(errors.CodeIRSDeviceError, "failed to connect IRS device", err)
+		}
+	}
+	if err := c.reconfigure(ctx, func() error { return absorber.Absorb(ctx) }); err != nil {
+		return errors.Wrap(errors.CodeIRSConfigFailed, "failed to switch IRS panel to absorptive", err)
+	}
+	return nil
+}
+
+func (c *Controller) Restore(ctx context.Context) error {
+	return c.restore(ctx)
+}
@@ -0,0 +1,134 @@
+package irs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/logger"
+	"isac-cran-system/pkg/serial"
+
+	"go.uber.org/zap"
+)
+
+// SerialDriver drives an IRS controller board over a serial line with the
+// binary frame encoding.
+type SerialDriver struct {
+	elementCount  int
+	frequencyBand string
+	path          string
+	line          serial.Config
+	timeout       time.Duration
+	retries       int
+	open          func() (serial.Port, error)
+
+	mu   sync.Mutex
+	link *link
+}
+
+func NewSerialDriver(config *DriverConfig) *SerialDriver {
+	d := &SerialDriver{
+		elementCount:  config.ElementCount,
+		frequencyBand: config.FrequencyBand,
+		path:          config.SerialPort,
+		line: serial.Config{
+			BaudRate: config.BaudRate,
+			DataBits: config.DataBits,
+			Parity:   config.Parity,
+			StopBits: config.StopBits,
+		},
+		timeout: config.Timeout,
+		retries: config.Retries,
+	}
+	d.open = func() (serial.Port, error) { return serial.Open(d.path, d.line) }
+	return d
+}
+
+// Connect opens the port and pings the controller, so a wrong port or baud
+// rate fails here rather than on the first configuration.
+func (d *SerialDriver) Connect(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.link != nil {
+		return nil
+	}
+	port, err := d.open()
+	if err != nil {
+		return err
+	}
+	d.link = newLink(port, binaryCodec{}, d.timeout, d.retries)
+
+	if _, err := d.link.exchange(ctx, &message{cmd: cmdPing}); err != nil {
+		d.close()
+		return err
+	}
+	logger.Info("IRS serial controller connected",
+		zap.String("port", d.path),
+		zap.Int("baud_rate", d.line.BaudRate),
+		zap.Int("element_count", d.elementCount),
+	)
+	return nil
+}
+
+func (d *SerialDriver) Disconnect() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.close()
+}
+
+func (d *SerialDriver) close() error {
+	if d.link == nil {
+		return nil
+	}
+	err := d.link.conn.Close()
+	d.link = nil
+	return err
+}
+
+func (d *SerialDriver) IsConnected() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.link != nil
+}
+
+func (d *SerialDriver) SetPhaseShifts(ctx context.Context, phaseShifts []float64) error {
+	if len(phaseShifts) != d.elementCount {
+		return ErrInvalidPhaseShiftCount
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.link == nil {
+		return ErrDeviceNotConnected
+	}
+	_, err := d.link.exchange(ctx, &message{cmd: cmdSetPhases, phases: phaseShifts})
+	return err
+}
+
+func (d *SerialDriver) GetStatus(ctx context.Context) (*model.IRSStatus, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.link == nil {
+		return nil, ErrDeviceNotConnected
+	}
+	reply, err := d.link.exchange(ctx, &message{cmd: cmdGetStatus})
+	if err != nil {
+		return nil, err
+	}
+	return statusOf(reply, d.frequencyBand), nil
+}
+
+func statusOf(reply *message, frequencyBand string) *model.IRSStatus {
+	return &model.IRSStatus{
+		ElementCount:  len(reply.phases),
+		PhaseShifts:   reply.phases,
+		FrequencyBand: frequencyBand,
+		Temperature:   reply.temperature,
+		PowerStatus:   reply.power,
+		LastUpdate:    time.Now(),
+	}
+}
@@ -0,0 +1,122 @@
+package irs
+
+import (
+	"bufio"
+	"context"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"isac-cran-system/pkg/serial"
+)
+
+// fakeController answers frames like the controller firmware. It drops the
+// first SET_PHASES frame to exercise retries and NAKs phase sets whose first
+// code is above 0xFF00.
+func fakeController(conn net.Conn, codec codec) {
+	r := bufio.NewReader(conn)
+	var phases []float64
+	dropped := false
+	for {
+		req, err := codec.read(r)
+		if err != nil {
+			return
+		}
+		reply := &message{cmd: req.cmd | cmdACK, seq: req.seq}
+		switch req.cmd {
+		case cmdSetPhases:
+			if !dropped {
+				dropped = true
+				continue
+			}
+			if phaseCode(req.phases[0]) > 0xFF00 {
+				reply = &message{cmd: cmdNAK, seq: req.seq, code: 7}
+				break
+			}
+			phases = req.phases
+		case cmdGetStatus:
+			reply.temperature = 24
+			reply.power = true
+			reply.phases = phases
+		}
+		if _, ok := codec.(binaryCodec); ok {
+			// line noise before the reply must be skipped
+			conn.Write([]byte{0x00, frameSOF0})
+		}
+		if err := codec.write(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func TestSerialDriver(t *testing.T) {
+	host, device := net.Pipe()
+	go fakeController(device, binaryCodec{})
+	defer device.Close()
+
+	driver := NewSerialDriver(&DriverConfig{ElementCount: 4, Timeout: 50 * time.Millisecond, Retries: 2})
+	driver.open = func() (serial.Port, error) { return host, nil }
+
+	ctx := context.Background()
+	if err := driver.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if !driver.IsConnected() {
+		t.Error("Expected driver to be connected")
+	}
+
+	phases := []float64{0, math.Pi / 2, math.Pi, 3 * math.Pi / 2}
+	if err := driver.SetPhaseShifts(ctx, phases); err != nil {
+		t.Fatalf("SetPhaseShifts failed after retry: %v", err)
+	}
+	if err := driver.SetPhaseShifts(ctx, phases[:2]); err != ErrInvalidPhaseShiftCount {
+		t.Errorf("SetPhaseShifts with 2 phases error = %v, want %v", err, ErrInvalidPhaseShiftCount)
+	}
+	if err := driver.SetPhaseShifts(ctx, []float64{2*math.Pi - 0.01, 0, 0, 0}); err == nil {
+		t.Error("Expected an error for a NAKed command")
+	}
+
+	status, err := driver.GetStatus(ctx)
+	if err != nil {
+		t.Fatalf("GetStatus failed: %v", err)
+	}
+	if status.Temperature != 24 || !status.PowerStatus || status.ElementCount != 4 {
+		t.Errorf("status = %+v, want 24 °C, powered, 4 elements", status)
+	}
+	for i, phase := range status.PhaseShifts {
+		if math.Abs(phase-phases[i]) > 1e-3 {
+			t.Errorf("phase %d = %v, want %v", i, phase, phases[i])
+		}
+	}
+
+	if err := driver.Disconnect(); err != nil {
+		t.Fatalf("Disconnect failed: %v", err)
+	}
+	if _, err := driver.GetStatus(ctx); err != ErrDeviceNotConnected {
+		t.Errorf("GetStatus after Disconnect error = %v, want %v", err, ErrDeviceNotConnected)
+	}
+}
+
+func TestSerialDriver_Timeout(t *testing.T) {
+	host, device := net.Pipe()
+	defer device.Close()
+	// a controller that reads but never answers
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			if _, err := device.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	driver := NewSerialDriver(&DriverConfig{ElementCount: 4, Timeout: 20 * time.Millisecond, Retries: 1})
+	driver.open = func() (serial.Port, error) { return host, nil }
+	if err := driver.Connect(context.Background()); err != ErrControllerTimeout {
+		t.Fatalf("Connect error = %v, want %v", err, ErrControllerTimeout)
+	}
+	if driver.IsConnected() {
+		t.Error("driver connected after a failed ping")
+	}
+}
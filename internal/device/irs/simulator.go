@@ -2,7 +2,11 @@ package irs
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"math/cmplx"
 	"math/rand"
+	"sort"
 	"sync"
 	"time"
 
@@ -12,24 +16,96 @@ import (
 	"go.uber.org/zap"
 )
 
+// simulatorFirmware is the firmware a simulated board starts with.
+const simulatorFirmware = "1.0.0"
+
 type Simulator struct {
 	elementCount  int
 	frequencyBand string
+	firmware      string
 	phaseShifts   []float64
 	connected     bool
-	mu            sync.RWMutex
-	rand          *rand.Rand
+	impairments   model.IRSImpairments
+	faults        model.IRSFaults
+	// absorbing holds the elements in matched loads until the next write
+	absorbing bool
+	// failed holds the stuck elements, injected or failed at random
+	failed map[int]bool
+	mu     sync.RWMutex
+	rand   *rand.Rand
 }
 
 func NewSimulator(elementCount int, frequencyBand string) *Simulator {
 	return &Simulator{
 		elementCount:  elementCount,
 		frequencyBand: frequencyBand,
+		firmware:      simulatorFirmware,
 		phaseShifts:   make([]float64, elementCount),
+		failed:        make(map[int]bool),
 		rand:          rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
 
+func (s *Simulator) SetImpairments(impairments model.IRSImpairments) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.impairments = impairments
+}
+
+// InjectFaults replaces the injected faults. Elements that failed before
+// stay failed until ClearFaults.
+func (s *Simulator) InjectFaults(req *model.IRSFaultRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range req.FailedElements {
+		if e < 0 || e >= s.elementCount {
+			return &SimulatorError{Message: fmt.Sprintf("element %d out of range [0, %d)", e, s.elementCount)}
+		}
+	}
+	for _, e := range req.FailedElements {
+		s.failed[e] = true
+	}
+	s.faults = req.IRSFaults
+	if req.Impairments != nil {
+		s.impairments = *req.Impairments
+	}
+	logger.Warn("IRS simulator faults injected",
+		zap.Ints("failed_elements", s.failedElements()),
+		zap.Bool("power_loss", s.faults.PowerLoss),
+		zap.Bool("write_failure", s.faults.WriteFailure),
+		zap.Bool("status_failure", s.faults.StatusFailure),
+	)
+	return nil
+}
+
+// ClearFaults removes the injected faults and repairs every failed element.
+// Impairments are kept.
+func (s *Simulator) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = model.IRSFaults{}
+	s.failed = make(map[int]bool)
+	logger.Info("IRS simulator faults cleared")
+}
+
+func (s *Simulator) State() *model.IRSSimulatorState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	faults := s.faults
+	faults.FailedElements = s.failedElements()
+	return &model.IRSSimulatorState{Impairments: s.impairments, Faults: faults}
+}
+
+func (s *Simulator) failedElements() []int {
+	elements := make([]int, 0, len(s.failed))
+	for e := range s.failed {
+		elements = append(elements, e)
+	}
+	sort.Ints(elements)
+	return elements
+}
+
 func (s *Simulator) Connect(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -68,16 +144,36 @@ func (s *Simulator) SetPhaseShifts(ctx context.Context, phaseShifts []float64) e
 		return ErrInvalidPhaseShiftCount
 	}
 
+	if s.faults.WriteFailure {
+		return ErrInjectedWriteFailure
+	}
+
+	settle := 10*time.Millisecond + time.Duration(s.impairments.SettlingDelay*float64(time.Second))
+	timer := time.NewTimer(settle)
+	defer timer.Stop()
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
-	default:
+	case <-timer.C:
 	}
 
-	time.Sleep(10 * time.Millisecond)
-
-	s.phaseShifts = make([]float64, len(phaseShifts))
-	copy(s.phaseShifts, phaseShifts)
+	s.absorbing = false
+	applied := make([]float64, len(phaseShifts))
+	for i, phase := range phaseShifts {
+		if !s.failed[i] && s.impairments.ElementFailureRate > 0 && s.rand.Float64() < s.impairments.ElementFailureRate {
+			s.failed[i] = true
+			logger.Warn("IRS simulator element failed", zap.Int("element", i))
+		}
+		if s.failed[i] {
+			applied[i] = s.phaseShifts[i]
+			continue
+		}
+		if s.impairments.PhaseNoise > 0 {
+			phase += s.rand.NormFloat64() * s.impairments.PhaseNoise
+		}
+		applied[i] = wrapPhase(phase)
+	}
+	s.phaseShifts = applied
 
 	logger.Debug("IRS phase shifts updated",
 		zap.Int("count", len(phaseShifts)),
@@ -93,22 +189,139 @@ func (s *Simulator) GetStatus(ctx context.Context) (*model.IRSStatus, error) {
 	if !s.connected {
 		return nil, ErrDeviceNotConnected
 	}
-
-	phaseShifts := make([]float64, len(s.phaseShifts))
-	copy(phaseShifts, s.phaseShifts)
+	if s.faults.StatusFailure {
+		return nil, ErrInjectedStatusFailure
+	}
 
 	temperature := 25.0 + s.rand.Float64()*10
+	if s.faults.Temperature != nil {
+		temperature = *s.faults.Temperature
+	}
 
 	return &model.IRSStatus{
-		ElementCount:  s.elementCount,
-		PhaseShifts:   phaseShifts,
-		FrequencyBand: s.frequencyBand,
-		Temperature:   temperature,
-		PowerStatus:   true,
-		LastUpdate:    time.Now(),
+		ElementCount:   s.elementCount,
+		PhaseShifts:    s.effectivePhases(),
+		FrequencyBand:  s.frequencyBand,
+		Temperature:    temperature,
+		PowerStatus:    !s.faults.PowerLoss,
+		LastUpdate:     time.Now(),
+		FailedElements: s.failedElements(),
+		Absorbing:      s.absorbing,
 	}, nil
 }
 
+// Absorb switches the elements to their matched loads.
+func (s *Simulator) Absorb(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return ErrDeviceNotConnected
+	}
+	if s.faults.WriteFailure {
+		return ErrInjectedWriteFailure
+	}
+	s.absorbing = true
+	return nil
+}
+
+// Reflection returns the unit reflection coefficients of the effective
+// phases. Absorbing elements reflect nothing; without power the elements
+// fall back to their unbiased state and all reflect in phase.
+func (s *Simulator) Reflection() []complex128 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	coefficients := make([]complex128, s.elementCount)
+	switch {
+	case s.absorbing:
+	case s.faults.PowerLoss:
+		for i := range coefficients {
+			coefficients[i] = 1
+		}
+	default:
+		for i, phase := range s.effectivePhases() {
+			coefficients[i] = cmplx.Rect(1, phase)
+		}
+	}
+	return coefficients
+}
+
+// effectivePhases are the phases the panel reradiates with: with mutual
+// coupling each element also carries a share of its neighbours' fields.
+func (s *Simulator) effectivePhases() []float64 {
+	phases := make([]float64, len(s.phaseShifts))
+	c := s.impairments.MutualCoupling
+	if c == 0 {
+		copy(phases, s.phaseShifts)
+		return phases
+	}
+	for i := range s.phaseShifts {
+		field := cmplx.Rect(1, s.phaseShifts[i])
+		if i > 0 {
+			field += complex(c, 0) * cmplx.Rect(1, s.phaseShifts[i-1])
+		}
+		if i < len(s.phaseShifts)-1 {
+			field += complex(c, 0) * cmplx.Rect(1, s.phaseShifts[i+1])
+		}
+		phases[i] = wrapPhase(cmplx.Phase(field))
+	}
+	return phases
+}
+
+func wrapPhase(phase float64) float64 {
+	phase = math.Mod(phase, 2*math.Pi)
+	if phase < 0 {
+		phase += 2 * math.Pi
+	}
+	return phase
+}
+
+func (s *Simulator) FirmwareVersion() (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.connected {
+		return "", ErrDeviceNotConnected
+	}
+	return s.firmware, nil
+}
+
+// UpdateFirmware pretends to flash image and restart the board, which then
+// runs version with its phases reset. An injected write failure makes the
+// flash fail and leaves the old firmware running.
+func (s *Simulator) UpdateFirmware(ctx context.Context, image []byte, version string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return ErrDeviceNotConnected
+	}
+	if len(image) == 0 {
+		return ErrEmptyFirmwareImage
+	}
+	if s.faults.WriteFailure {
+		return ErrInjectedWriteFailure
+	}
+
+	timer := time.NewTimer(100 * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	}
+
+	previous := s.firmware
+	s.firmware = version
+	for i := range s.phaseShifts {
+		if !s.failed[i] {
+			s.phaseShifts[i] = 0
+		}
+	}
+	logger.Info("IRS simulator firmware updated", zap.String("from", previous), zap.String("to", version))
+	return nil
+}
+
 func (s *Simulator) IsConnected() bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -154,6 +367,9 @@ func mod(a, b float64) float64 {
 var (
 	ErrDeviceNotConnected     = &SimulatorError{Message: "device not connected"}
 	ErrInvalidPhaseShiftCount = &SimulatorError{Message: "invalid phase shift count"}
+	ErrInjectedWriteFailure   = &SimulatorError{Message: "injected fault: phase write failed"}
+	ErrInjectedStatusFailure  = &SimulatorError{Message: "injected fault: status read failed"}
+	ErrEmptyFirmwareImage     = &SimulatorError{Message: "empty firmware image"}
 )
 
 type SimulatorError struct {
@@ -2,9 +2,13 @@ package irs
 
 import (
 	"context"
+	"math"
+	"math/cmplx"
 	"testing"
+	"time"
 
 	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
 )
 
 func TestSimulator_Connect(t *testing.T) {
@@ -104,6 +108,102 @@ func TestSimulator_GetStatus_NotConnected(t *testing.T) {
 	}
 }
 
+func TestSimulator_Impairments(t *testing.T) {
+	simulator := NewSimulator(3, "2.4GHz")
+	ctx := context.Background()
+	_ = simulator.Connect(ctx)
+
+	simulator.SetImpairments(model.IRSImpairments{MutualCoupling: 0.5})
+	if err := simulator.SetPhaseShifts(ctx, []float64{0, math.Pi / 2, 0}); err != nil {
+		t.Fatalf("SetPhaseShifts failed: %v", err)
+	}
+	status, _ := simulator.GetStatus(ctx)
+	// element 1 sees j + 0.5 + 0.5 = 1 + j
+	if got := status.PhaseShifts[1]; math.Abs(got-math.Pi/4) > 1e-9 {
+		t.Errorf("coupled phase of element 1 = %v, want π/4", got)
+	}
+
+	simulator.SetImpairments(model.IRSImpairments{ElementFailureRate: 1})
+	if err := simulator.SetPhaseShifts(ctx, []float64{1, 1, 1}); err != nil {
+		t.Fatalf("SetPhaseShifts failed: %v", err)
+	}
+	status, _ = simulator.GetStatus(ctx)
+	if len(status.FailedElements) != 3 || status.PhaseShifts[0] != 0 {
+		t.Errorf("with failure rate 1: failed = %v, phases = %v, want all stuck at the old phases", status.FailedElements, status.PhaseShifts)
+	}
+
+	simulator.ClearFaults()
+	simulator.SetImpairments(model.IRSImpairments{})
+	if err := simulator.InjectFaults(&model.IRSFaultRequest{IRSFaults: model.IRSFaults{FailedElements: []int{5}}}); err == nil {
+		t.Error("Expected error for an element out of range")
+	}
+	hot := 80.0
+	err := simulator.InjectFaults(&model.IRSFaultRequest{IRSFaults: model.IRSFaults{
+		FailedElements: []int{2},
+		PowerLoss:      true,
+		Temperature:    &hot,
+	}})
+	if err != nil {
+		t.Fatalf("InjectFaults failed: %v", err)
+	}
+	if err := simulator.SetPhaseShifts(ctx, []float64{2, 2, 2}); err != nil {
+		t.Fatalf("SetPhaseShifts failed: %v", err)
+	}
+	status, _ = simulator.GetStatus(ctx)
+	if status.PowerStatus || status.Temperature != 80 || status.PhaseShifts[0] != 2 || status.PhaseShifts[2] != 0 {
+		t.Errorf("with injected faults: status = %+v", status)
+	}
+
+	_ = simulator.InjectFaults(&model.IRSFaultRequest{IRSFaults: model.IRSFaults{WriteFailure: true, StatusFailure: true}})
+	if err := simulator.SetPhaseShifts(ctx, []float64{2, 2, 2}); err != ErrInjectedWriteFailure {
+		t.Errorf("SetPhaseShifts error = %v, want injected write failure", err)
+	}
+	if _, err := simulator.GetStatus(ctx); err != ErrInjectedStatusFailure {
+		t.Errorf("GetStatus error = %v, want injected status failure", err)
+	}
+
+	simulator.ClearFaults()
+	status, err = simulator.GetStatus(ctx)
+	if err != nil || !status.PowerStatus || len(status.FailedElements) != 0 {
+		t.Errorf("after ClearFaults: status = %+v, error = %v", status, err)
+	}
+}
+
+func TestSimulator_Reflection(t *testing.T) {
+	simulator := NewSimulator(4, "2.4GHz")
+	ctx := context.Background()
+	_ = simulator.Connect(ctx)
+
+	phases := []float64{0, math.Pi / 2, math.Pi, 3 * math.Pi / 2}
+	if err := simulator.SetPhaseShifts(ctx, phases); err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range simulator.Reflection() {
+		if want := cmplx.Rect(1, phases[i]); cmplx.Abs(r-want) > 1e-9 {
+			t.Errorf("element %d reflects %v, want %v", i, r, want)
+		}
+	}
+
+	if err := simulator.Absorb(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range simulator.Reflection() {
+		if r != 0 {
+			t.Errorf("absorbing element %d reflects %v", i, r)
+		}
+	}
+
+	_ = simulator.SetPhaseShifts(ctx, phases)
+	if err := simulator.InjectFaults(&model.IRSFaultRequest{IRSFaults: model.IRSFaults{PowerLoss: true}}); err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range simulator.Reflection() {
+		if r != 1 {
+			t.Errorf("unpowered element %d reflects %v, want 1", i, r)
+		}
+	}
+}
+
 func TestController_Configure(t *testing.T) {
 	simulator := NewSimulator(64, "2.4GHz")
 	controller := NewController(simulator)
@@ -133,6 +233,247 @@ func TestController_Configure(t *testing.T) {
 	}
 }
 
+func TestController_ConfigureGroup(t *testing.T) {
+	ctrl := NewController(NewSimulator(8, "2.4GHz"))
+	ctx := context.Background()
+
+	overlapping := &model.IRSConfigRequest{
+		Name:         "split",
+		ElementCount: 8,
+		Groups: []model.IRSElementGroup{
+			{Name: "comms", Elements: []int{0, 1, 2, 3}, PhaseShifts: []float64{1, 1, 1, 1}},
+			{Name: "sensing", Elements: []int{3, 4}, PhaseShifts: []float64{2, 2}},
+		},
+		FrequencyBand: "2.4GHz",
+	}
+	if err := ctrl.Configure(ctx, overlapping); !errors.IsCode(err, errors.CodeInvalidIRSConfig) {
+		t.Fatalf("Configure() with overlapping groups error = %v, want invalid config", err)
+	}
+
+	req := &model.IRSConfigRequest{
+		Name:         "split",
+		ElementCount: 8,
+		Groups: []model.IRSElementGroup{
+			{Name: "comms", Elements: []int{0, 1, 2, 3}, PhaseShifts: []float64{1, 1, 1, 1}},
+			{Name: "sensing", Elements: []int{4, 5, 6, 7}, PhaseShifts: []float64{2, 2, 2, 2}},
+		},
+		FrequencyBand: "2.4GHz",
+	}
+	if err := ctrl.Configure(ctx, req); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	before := ctrl.GetCurrentConfig()
+
+	if err := ctrl.ConfigureGroup(ctx, "sensing", &model.IRSGroupRequest{PhaseShifts: []float64{3, 3, 3, 3}}); err != nil {
+		t.Fatalf("ConfigureGroup() error = %v", err)
+	}
+	config := ctrl.GetCurrentConfig()
+	want := []float64{1, 1, 1, 1, 3, 3, 3, 3}
+	for i, ps := range want {
+		if config.PhaseShifts[i] != ps {
+			t.Fatalf("phase_shifts = %v, want %v", config.PhaseShifts, want)
+		}
+	}
+	if config.Groups[1].PhaseShifts[0] != 3 || before.Groups[1].PhaseShifts[0] != 2 {
+		t.Errorf("group phases = %v (previous %v), want the update in a new config only", config.Groups[1].PhaseShifts, before.Groups[1].PhaseShifts)
+	}
+
+	status, err := ctrl.GetStatus(ctx)
+	if err != nil {
+		t.Fatalf("GetStatus() error = %v", err)
+	}
+	if status.PhaseShifts[0] != 1 || status.PhaseShifts[7] != 3 {
+		t.Errorf("device phases = %v, want comms untouched and sensing updated", status.PhaseShifts)
+	}
+
+	if err := ctrl.ConfigureGroup(ctx, "radar", &model.IRSGroupRequest{PhaseShifts: []float64{0}}); !errors.IsCode(err, errors.CodeNotFound) {
+		t.Errorf("ConfigureGroup() of unknown group error = %v, want not found", err)
+	}
+	if err := ctrl.ConfigureGroup(ctx, "comms", &model.IRSGroupRequest{PhaseShifts: []float64{0}}); !errors.IsCode(err, errors.CodeInvalidIRSConfig) {
+		t.Errorf("ConfigureGroup() with wrong length error = %v, want invalid config", err)
+	}
+}
+
+func TestController_ConfigureVersion(t *testing.T) {
+	ctrl := NewController(NewSimulator(4, "2.4GHz"))
+	ctx := context.Background()
+	req := func(version int64) *model.IRSConfigRequest {
+		return &model.IRSConfigRequest{
+			Name:          "cas",
+			ElementCount:  4,
+			PhaseShifts:   []float64{0, 1, 2, 3},
+			FrequencyBand: "2.4GHz",
+			Version:       version,
+		}
+	}
+
+	if err := ctrl.Configure(ctx, req(0)); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	if v := ctrl.GetCurrentConfig().Version; v != 1 {
+		t.Fatalf("version = %d after first configure, want 1", v)
+	}
+	if err := ctrl.Configure(ctx, req(1)); err != nil {
+		t.Fatalf("Configure() at current version error = %v", err)
+	}
+	// a second writer that read version 1 lost the race
+	if err := ctrl.Configure(ctx, req(1)); !errors.IsCode(err, errors.CodeVersionConflict) {
+		t.Fatalf("Configure() at stale version error = %v, want version conflict", err)
+	}
+	if v := ctrl.GetCurrentConfig().Version; v != 2 {
+		t.Errorf("version = %d after rejected configure, want 2", v)
+	}
+}
+
+func TestController_ConfigureQuantized(t *testing.T) {
+	ctrl := NewController(NewSimulator(4, "2.4GHz"))
+	ctrl.SetQuantizationBits(1)
+	ctx := context.Background()
+
+	req := &model.IRSConfigRequest{
+		Name:          "1-bit",
+		ElementCount:  4,
+		PhaseShifts:   []float64{0.2, 1.4, 1.7, 6.1},
+		FrequencyBand: "2.4GHz",
+	}
+	if err := ctrl.Configure(ctx, req); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	config := ctrl.GetCurrentConfig()
+	want := []float64{0, 0, math.Pi, 0}
+	for i, ps := range want {
+		if math.Abs(config.PhaseShifts[i]-ps) > 1e-12 {
+			t.Fatalf("phase_shifts = %v, want %v", config.PhaseShifts, want)
+		}
+	}
+	errs := []float64{0.2, 1.4, math.Pi - 1.7, 2*math.Pi - 6.1}
+	sum := 0.0
+	for _, e := range errs {
+		sum += e * e
+	}
+	if rms := math.Sqrt(sum / 4); config.QuantizationBits != 1 || math.Abs(config.QuantizationError-rms) > 1e-9 {
+		t.Errorf("quantization = %d bits, error %v, want 1 bit, error %v", config.QuantizationBits, config.QuantizationError, rms)
+	}
+	if req.PhaseShifts[2] != 1.7 {
+		t.Errorf("request phases modified: %v", req.PhaseShifts)
+	}
+
+	status, err := ctrl.GetStatus(ctx)
+	if err != nil {
+		t.Fatalf("GetStatus() error = %v", err)
+	}
+	if status.PhaseShifts[2] != config.PhaseShifts[2] {
+		t.Errorf("panel phases = %v, want the quantized %v", status.PhaseShifts, config.PhaseShifts)
+	}
+}
+
+func TestController_Constraints(t *testing.T) {
+	ctrl := NewController(NewSimulator(4, "2.4GHz"))
+	ctrl.SetConstraints(model.IRSConstraints{PhaseBits: 1, SwitchingTime: 0.01, MaxReconfigRate: 20})
+	ctx := context.Background()
+
+	on := &model.IRSConfigRequest{Name: "on", ElementCount: 4, PhaseShifts: []float64{0, math.Pi, 0, math.Pi}, FrequencyBand: "2.4GHz"}
+	off := &model.IRSConfigRequest{Name: "off", ElementCount: 4, PhaseShifts: []float64{0.3, 0.3, 0.3, 0.3}, FrequencyBand: "2.4GHz"}
+	if err := ctrl.Configure(ctx, on); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	start := time.Now()
+	if err := ctrl.Configure(ctx, off); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	// 20 writes per second outweigh the 10 ms switching time
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("second write after %v, want it held back to the 50 ms the panel needs", elapsed)
+	}
+
+	seq := &model.IRSSequenceRequest{
+		Steps: []model.IRSSequenceStep{{PhaseShifts: []float64{0, 0, 0, 0}}, {PhaseShifts: []float64{1, 1, 1, 1}}},
+		Dwell: 0.02,
+	}
+	if _, err := ctrl.StartSequence(ctx, seq); !errors.IsCode(err, errors.CodeInvalidIRSConfig) {
+		t.Fatalf("StartSequence() faster than the panel error = %v, want invalid config", err)
+	}
+
+	report := ctrl.ConstraintReport()
+	if report.Constraints.PhaseBits != 1 || report.Quantized != 1 || report.Throttled != 1 || report.Rejected != 1 {
+		t.Fatalf("report = %+v, want one violation of each kind", report)
+	}
+	kinds := []model.IRSViolationKind{model.IRSViolationSequenceRate, model.IRSViolationPhaseResolution, model.IRSViolationReconfigRate}
+	for i, kind := range kinds {
+		if report.Recent[i].Kind != kind {
+			t.Errorf("recent[%d] = %s, want %s", i, report.Recent[i].Kind, kind)
+		}
+	}
+}
+
+func TestController_StartSequence(t *testing.T) {
+	sim := NewSimulator(4, "2.4GHz")
+	ctrl := NewController(sim)
+	ctx := context.Background()
+
+	active := &model.IRSConfigRequest{
+		Name:          "active",
+		ElementCount:  4,
+		PhaseShifts:   []float64{0.5, 0.5, 0.5, 0.5},
+		FrequencyBand: "2.4GHz",
+	}
+	if err := ctrl.Configure(ctx, active); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+
+	short := &model.IRSSequenceRequest{Steps: []model.IRSSequenceStep{{PhaseShifts: []float64{0, 0}}}, Dwell: 0.02}
+	if _, err := ctrl.StartSequence(ctx, short); !errors.IsCode(err, errors.CodeInvalidIRSConfig) {
+		t.Fatalf("StartSequence() with too few phases error = %v, want invalid config", err)
+	}
+
+	req := &model.IRSSequenceRequest{
+		Steps: []model.IRSSequenceStep{
+			{PhaseShifts: []float64{0, 0, 0, 0}},
+			{PhaseShifts: []float64{1, 1, 1, 1}},
+			{PhaseShifts: []float64{2, 2, 2, 2}},
+		},
+		Dwell:  0.02,
+		Repeat: 2,
+	}
+	seq, err := ctrl.StartSequence(ctx, req)
+	if err != nil {
+		t.Fatalf("StartSequence() error = %v", err)
+	}
+	select {
+	case <-seq.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("sequence did not finish")
+	}
+
+	status := seq.Status()
+	if status.Running || status.Error != "" {
+		t.Fatalf("status = %+v, want finished without error", status)
+	}
+	if status.Applied != 6 || status.Pass != 1 || status.Step != 2 {
+		t.Errorf("applied %d steps, last pass %d step %d, want 6 steps ending at pass 1 step 2", status.Applied, status.Pass, status.Step)
+	}
+
+	device, err := sim.GetStatus(ctx)
+	if err != nil {
+		t.Fatalf("GetStatus() error = %v", err)
+	}
+	if device.PhaseShifts[0] != 0.5 {
+		t.Errorf("phase after sequence = %v, want the active configuration restored", device.PhaseShifts[0])
+	}
+
+	looping := *req
+	looping.Loop = true
+	seq, err = ctrl.StartSequence(ctx, &looping)
+	if err != nil {
+		t.Fatalf("StartSequence() loop error = %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	seq.Stop()
+	if status := seq.Status(); status.Running || status.Applied == 0 {
+		t.Errorf("looping status after Stop = %+v, want stopped after some steps", status)
+	}
+}
+
 func TestController_GetStatus(t *testing.T) {
 	simulator := NewSimulator(64, "2.4GHz")
 	controller := NewController(simulator)
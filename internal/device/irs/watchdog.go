@@ -0,0 +1,166 @@
+package irs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+const (
+	DefaultWatchdogInterval = 5 * time.Second
+	DefaultMaxTemperature   = 70.0
+
+	// an over-temperature alert clears this far below the limit so that a
+	// panel hovering at the limit does not flood the notification queue
+	temperatureHysteresis = 2.0
+)
+
+type WatchdogConfig struct {
+	Interval time.Duration
+	// StaleAfter is how long the panel may go without reporting a status;
+	// zero means three intervals.
+	StaleAfter     time.Duration
+	MaxTemperature float64
+}
+
+// Watchdog polls a panel through Controller.StartMonitoring and raises an
+// alert when the panel overheats, loses power or stops reporting. notify is
+// called once when an alert becomes active and once when it clears.
+type Watchdog struct {
+	id         string
+	controller *Controller
+	cfg        WatchdogConfig
+	notify     func(alert model.IRSAlert)
+
+	mu       sync.RWMutex
+	lastSeen time.Time
+	status   *model.IRSStatus
+	active   map[model.IRSAlertKind]model.IRSAlert
+}
+
+func NewWatchdog(id string, controller *Controller, cfg WatchdogConfig, notify func(alert model.IRSAlert)) *Watchdog {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultWatchdogInterval
+	}
+	if cfg.StaleAfter <= 0 {
+		cfg.StaleAfter = 3 * cfg.Interval
+	}
+	if cfg.MaxTemperature <= 0 {
+		cfg.MaxTemperature = DefaultMaxTemperature
+	}
+	return &Watchdog{
+		id:         id,
+		controller: controller,
+		cfg:        cfg,
+		notify:     notify,
+		active:     make(map[model.IRSAlertKind]model.IRSAlert),
+	}
+}
+
+// Run watches the panel until ctx is done. It takes over the controller's
+// status change callback.
+func (w *Watchdog) Run(ctx context.Context) {
+	w.mu.Lock()
+	w.lastSeen = time.Now()
+	w.mu.Unlock()
+
+	w.controller.SetStatusChangeCallback(w.observe)
+	go w.controller.StartMonitoring(ctx, w.cfg.Interval)
+
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			w.check(now)
+		}
+	}
+}
+
+func (w *Watchdog) observe(status *model.IRSStatus) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.status = status
+	w.lastSeen = time.Now()
+}
+
+// check updates the alerts from the last status seen at now. While the
+// status is stale the other alerts keep their state, there being nothing
+// newer to judge them by.
+func (w *Watchdog) check(now time.Time) {
+	w.mu.Lock()
+	var changed []model.IRSAlert
+	set := func(kind model.IRSAlertKind, on bool, code errors.Code, message string, temperature float64) {
+		alert, active := w.active[kind]
+		switch {
+		case on && !active:
+			alert = model.IRSAlert{
+				IRSID:       w.id,
+				Kind:        kind,
+				Code:        code.Int(),
+				Message:     message,
+				Active:      true,
+				Temperature: temperature,
+				Since:       now,
+				Time:        now,
+			}
+			w.active[kind] = alert
+		case !on && active:
+			delete(w.active, kind)
+			alert.Active = false
+			alert.Time = now
+		default:
+			return
+		}
+		changed = append(changed, alert)
+	}
+
+	silent := now.Sub(w.lastSeen)
+	stale := silent > w.cfg.StaleAfter
+	set(model.IRSAlertStaleStatus, stale, errors.CodeIRSStatusStale,
+		fmt.Sprintf("no status from IRS panel %s for %s", w.id, silent.Round(time.Second)), 0)
+	if !stale && w.status != nil {
+		temperature := w.status.Temperature
+		limit := w.cfg.MaxTemperature
+		if _, hot := w.active[model.IRSAlertOverTemperature]; hot {
+			limit -= temperatureHysteresis
+		}
+		set(model.IRSAlertOverTemperature, temperature > limit, errors.CodeIRSOverTemperature,
+			fmt.Sprintf("IRS panel %s at %.1f°C exceeds %.1f°C", w.id, temperature, w.cfg.MaxTemperature), temperature)
+		set(model.IRSAlertPowerLoss, !w.status.PowerStatus, errors.CodeIRSPowerLoss,
+			fmt.Sprintf("IRS panel %s reports power loss", w.id), 0)
+	}
+	w.mu.Unlock()
+
+	if w.notify != nil {
+		for _, alert := range changed {
+			w.notify(alert)
+		}
+	}
+}
+
+// Healthy reports whether no alert is active.
+func (w *Watchdog) Healthy() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return len(w.active) == 0
+}
+
+// Alerts returns the active alerts ordered by kind.
+func (w *Watchdog) Alerts() []model.IRSAlert {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	alerts := make([]model.IRSAlert, 0, len(w.active))
+	for _, alert := range w.active {
+		alerts = append(alerts, alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].Kind < alerts[j].Kind })
+	return alerts
+}
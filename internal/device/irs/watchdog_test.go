@@ -0,0 +1,60 @@
+package irs
+
+import (
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+func TestWatchdog_Alerts(t *testing.T) {
+	var events []model.IRSAlert
+	w := NewWatchdog("irs0", NewController(NewSimulator(4, "2.4GHz")), WatchdogConfig{
+		Interval:       time.Second,
+		MaxTemperature: 60,
+	}, func(alert model.IRSAlert) { events = append(events, alert) })
+
+	start := time.Now()
+	w.lastSeen = start
+	w.observe(&model.IRSStatus{Temperature: 40, PowerStatus: true})
+	w.check(start)
+	if !w.Healthy() || len(events) != 0 {
+		t.Fatalf("healthy panel: Healthy() = %v, events = %v", w.Healthy(), events)
+	}
+
+	w.observe(&model.IRSStatus{Temperature: 65, PowerStatus: false})
+	w.check(time.Now())
+	if w.Healthy() {
+		t.Fatal("Healthy() = true with an overheated, unpowered panel")
+	}
+	alerts := w.Alerts()
+	if len(alerts) != 2 || alerts[0].Kind != model.IRSAlertOverTemperature || alerts[1].Kind != model.IRSAlertPowerLoss {
+		t.Fatalf("Alerts() = %+v, want over_temperature and power_loss", alerts)
+	}
+	if alerts[0].Code != errors.CodeIRSOverTemperature.Int() || alerts[0].Temperature != 65 {
+		t.Errorf("over-temperature alert = %+v", alerts[0])
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+
+	// still above the hysteresis band: no change, no new event
+	w.observe(&model.IRSStatus{Temperature: 59, PowerStatus: true})
+	w.check(time.Now())
+	if len(w.Alerts()) != 1 || len(events) != 3 || events[2].Kind != model.IRSAlertPowerLoss || events[2].Active {
+		t.Fatalf("after power returned: alerts = %+v, events = %+v", w.Alerts(), events)
+	}
+
+	w.observe(&model.IRSStatus{Temperature: 50, PowerStatus: true})
+	w.check(time.Now())
+	if !w.Healthy() || len(events) != 4 || events[3].Active {
+		t.Fatalf("after cooling: Healthy() = %v, events = %+v", w.Healthy(), events)
+	}
+
+	w.check(time.Now().Add(10 * time.Second))
+	alerts = w.Alerts()
+	if len(alerts) != 1 || alerts[0].Kind != model.IRSAlertStaleStatus || alerts[0].Code != errors.CodeIRSStatusStale.Int() {
+		t.Fatalf("silent panel: Alerts() = %+v, want stale_status", alerts)
+	}
+}
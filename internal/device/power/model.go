@@ -0,0 +1,159 @@
+package power
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"isac-cran-system/internal/model"
+)
+
+type Config struct {
+	IRSStaticPerElement float64
+	IRSSwitchEnergy     float64
+	ControllerPower     float64
+	USRPPower           float64
+	Tolerance           float64
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		IRSStaticPerElement: 0.005,
+		IRSSwitchEnergy:     1e-4,
+		ControllerPower:     5.0,
+		USRPPower:           45.0,
+		Tolerance:           0.2,
+	}
+}
+
+// Model estimates the draw of the IRS and RF chain. IRS elements consume a
+// static bias power while held and a fixed energy per phase transition.
+type Model struct {
+	config *Config
+}
+
+func NewModel(config *Config) *Model {
+	defaults := DefaultConfig()
+	if config == nil {
+		return &Model{config: defaults}
+	}
+
+	cfg := *config
+	if cfg.IRSStaticPerElement <= 0 {
+		cfg.IRSStaticPerElement = defaults.IRSStaticPerElement
+	}
+	if cfg.IRSSwitchEnergy <= 0 {
+		cfg.IRSSwitchEnergy = defaults.IRSSwitchEnergy
+	}
+	if cfg.ControllerPower <= 0 {
+		cfg.ControllerPower = defaults.ControllerPower
+	}
+	if cfg.USRPPower <= 0 {
+		cfg.USRPPower = defaults.USRPPower
+	}
+	if cfg.Tolerance <= 0 {
+		cfg.Tolerance = defaults.Tolerance
+	}
+	return &Model{config: &cfg}
+}
+
+func (m *Model) Config() Config {
+	return *m.config
+}
+
+// StaticPower returns the steady-state draw in watts of an IRS with the given
+// number of elements plus its controller and the USRP front end.
+func (m *Model) StaticPower(elementCount int) float64 {
+	return float64(elementCount)*m.config.IRSStaticPerElement + m.config.ControllerPower + m.config.USRPPower
+}
+
+// EstimateConfiguration estimates the energy to switch from previous to next
+// phase shifts and hold the new configuration for the given duration.
+func (m *Model) EstimateConfiguration(previous, next []float64, hold time.Duration) *model.PowerEstimate {
+	switched := countSwitchedElements(previous, next)
+	return m.estimate(len(next), switched, hold)
+}
+
+// EstimateExperiment estimates the energy of an experiment that kept an IRS
+// of elementCount elements active for duration and reconfigured it
+// reconfigurations times.
+func (m *Model) EstimateExperiment(elementCount, reconfigurations int, duration time.Duration) *model.PowerEstimate {
+	return m.estimate(elementCount, elementCount*reconfigurations, duration)
+}
+
+func (m *Model) estimate(elementCount, switchedElements int, duration time.Duration) *model.PowerEstimate {
+	seconds := duration.Seconds()
+
+	irsStatic := float64(elementCount) * m.config.IRSStaticPerElement
+	dynamicEnergy := float64(switchedElements) * m.config.IRSSwitchEnergy
+	staticPower := m.StaticPower(elementCount)
+	totalEnergy := staticPower*seconds + dynamicEnergy
+
+	averagePower := staticPower
+	if seconds > 0 {
+		averagePower = totalEnergy / seconds
+	}
+
+	return &model.PowerEstimate{
+		IRSElementCount:  elementCount,
+		IRSStaticPower:   irsStatic,
+		IRSDynamicEnergy: dynamicEnergy,
+		ControllerPower:  m.config.ControllerPower,
+		USRPPower:        m.config.USRPPower,
+		AveragePower:     averagePower,
+		Duration:         seconds,
+		TotalEnergy:      totalEnergy,
+		Timestamp:        time.Now(),
+	}
+}
+
+// CrossCheck compares an estimate against power sensor readings. Readings
+// from sensors of other types are ignored; values are converted to watts
+// according to the reported unit.
+func (m *Model) CrossCheck(estimate *model.PowerEstimate, readings []*model.SensorData) *model.PowerCrossCheck {
+	check := &model.PowerCrossCheck{
+		EstimatedPower: estimate.AveragePower,
+		SensorIDs:      make([]string, 0),
+		Tolerance:      m.config.Tolerance,
+		Timestamp:      time.Now(),
+	}
+
+	for _, r := range readings {
+		if r.SensorType != string(model.SensorTypePower) {
+			continue
+		}
+		check.MeasuredPower += ToWatts(r.Value, r.Unit)
+		check.SensorIDs = append(check.SensorIDs, r.SensorID)
+	}
+
+	if len(check.SensorIDs) == 0 || check.MeasuredPower <= 0 {
+		return check
+	}
+
+	check.Deviation = (check.EstimatedPower - check.MeasuredPower) / check.MeasuredPower
+	check.WithinTolerance = math.Abs(check.Deviation) <= check.Tolerance
+	return check
+}
+
+func countSwitchedElements(previous, next []float64) int {
+	switched := 0
+	for i, phase := range next {
+		if i >= len(previous) || math.Abs(previous[i]-phase) > 1e-9 {
+			switched++
+		}
+	}
+	return switched
+}
+
+func ToWatts(value float64, unit string) float64 {
+	switch strings.TrimSpace(unit) {
+	case "mW":
+		return value * 1e-3
+	case "kW":
+		return value * 1e3
+	case "MW":
+		return value * 1e6
+	default:
+		return value
+	}
+}
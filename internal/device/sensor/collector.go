@@ -11,6 +11,10 @@ import (
 	"go.uber.org/zap"
 )
 
+// maxBatchReadConcurrency bounds the number of driver reads a single
+// ReadSensors call runs in parallel.
+const maxBatchReadConcurrency = 8
+
 type Driver interface {
 	Connect(ctx context.Context) error
 	Disconnect() error
@@ -27,6 +31,11 @@ type Collector struct {
 	stopChan           chan struct{}
 	running            bool
 	onDataReceived     func(data *model.SensorData)
+
+	healthMu     sync.Mutex
+	health       map[string]*sensorHealth
+	offlineAfter time.Duration
+	now          func() time.Time
 }
 
 func NewCollector(driver Driver, interval time.Duration) *Collector {
@@ -35,6 +44,8 @@ func NewCollector(driver Driver, interval time.Duration) *Collector {
 		sensors:            make(map[string]*model.SensorInfo),
 		collectionInterval: interval,
 		stopChan:           make(chan struct{}),
+		health:             make(map[string]*sensorHealth),
+		now:                time.Now,
 	}
 }
 
@@ -46,6 +57,7 @@ func (c *Collector) Connect(ctx context.Context) error {
 	if simulator, ok := c.driver.(*Simulator); ok {
 		for _, info := range simulator.GetAllSensorInfo() {
 			c.sensors[info.SensorID] = info
+			c.track(info.SensorID)
 		}
 	}
 
@@ -57,10 +69,21 @@ func (c *Collector) Disconnect() error {
 	return c.driver.Disconnect()
 }
 
+func (c *Collector) IsConnected() bool {
+	return c.driver.IsConnected()
+}
+
+// RegisterSensor adds a sensor, or replaces its metadata. A simulated
+// sensor is centred in its value range.
 func (c *Collector) RegisterSensor(info *model.SensorInfo) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.sensors[info.SensorID] = info
+	c.track(info.SensorID)
+	if simulator, ok := c.driver.(*Simulator); ok {
+		span := info.MaxValue - info.MinValue
+		simulator.AddSensor(info, info.MinValue+span/2, span/4)
+	}
 	logger.Info("Sensor registered", zap.String("sensor_id", info.SensorID))
 }
 
@@ -68,6 +91,10 @@ func (c *Collector) UnregisterSensor(sensorID string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	delete(c.sensors, sensorID)
+	c.untrack(sensorID)
+	if simulator, ok := c.driver.(*Simulator); ok {
+		simulator.RemoveSensor(sensorID)
+	}
 	logger.Info("Sensor unregistered", zap.String("sensor_id", sensorID))
 }
 
@@ -93,14 +120,21 @@ func (c *Collector) ReadSensor(ctx context.Context, sensorID string) (*model.Sen
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	if _, ok := c.sensors[sensorID]; !ok {
+	info, ok := c.sensors[sensorID]
+	if !ok {
 		return nil, ErrSensorNotFound
 	}
+	if info.LifecycleAt(c.now()) == model.SensorLifecycleDecommissioned {
+		return nil, ErrSensorDecommissioned
+	}
 
 	data, err := c.driver.Read(ctx, sensorID)
 	if err != nil {
+		c.recordError(sensorID, err)
 		return nil, err
 	}
+	calibrate(info, data)
+	c.recordRead(info)
 
 	if c.onDataReceived != nil {
 		c.onDataReceived(data)
@@ -109,18 +143,67 @@ func (c *Collector) ReadSensor(ctx context.Context, sensorID string) (*model.Sen
 	return data, nil
 }
 
+// ReadSensors reads each of sensorIDs in parallel and returns one result per
+// ID in the same order. A failing sensor does not fail the batch.
+func (c *Collector) ReadSensors(ctx context.Context, sensorIDs []string) []*model.SensorReadResult {
+	results := make([]*model.SensorReadResult, len(sensorIDs))
+	sem := make(chan struct{}, maxBatchReadConcurrency)
+	var wg sync.WaitGroup
+
+	for i, id := range sensorIDs {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := &model.SensorReadResult{SensorID: id}
+			data, err := c.ReadSensor(ctx, id)
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Data = data
+			}
+			results[i] = result
+		}(i, id)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// ReadAllSensors reads the active sensors; those in maintenance or
+// decommissioned are skipped.
 func (c *Collector) ReadAllSensors(ctx context.Context) ([]*model.SensorData, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	if len(c.sensors) == 0 {
+	now := c.now()
+	active := make(map[string]*model.SensorInfo, len(c.sensors))
+	for id, info := range c.sensors {
+		if info.LifecycleAt(now) == model.SensorLifecycleActive {
+			active[id] = info
+		}
+	}
+	if len(active) == 0 {
 		return []*model.SensorData{}, nil
 	}
 
-	data, err := c.driver.ReadAll(ctx)
+	all, err := c.driver.ReadAll(ctx)
 	if err != nil {
+		for id := range active {
+			c.recordError(id, err)
+		}
 		return nil, err
 	}
+	data := make([]*model.SensorData, 0, len(all))
+	for _, d := range all {
+		if info, ok := active[d.SensorID]; ok {
+			calibrate(info, d)
+			c.recordRead(info)
+			data = append(data, d)
+		}
+	}
 
 	if c.onDataReceived != nil {
 		for _, d := range data {
@@ -131,6 +214,25 @@ func (c *Collector) ReadAllSensors(ctx context.Context) ([]*model.SensorData, er
 	return data, nil
 }
 
+// InMaintenance reports whether the sensor is in a maintenance window at t.
+func (c *Collector) InMaintenance(sensorID string, t time.Time) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	info, ok := c.sensors[sensorID]
+	return ok && info.LifecycleAt(t) == model.SensorLifecycleMaintenance
+}
+
+// calibrate replaces a raw reading with its calibrated value, keeping the raw
+// one alongside.
+func calibrate(info *model.SensorInfo, data *model.SensorData) {
+	if info.Calibration == nil {
+		return
+	}
+	raw := data.Value
+	data.RawValue = &raw
+	data.Value = info.Calibration.Apply(raw)
+}
+
 func (c *Collector) StartCollection(ctx context.Context) error {
 	c.mu.Lock()
 	if c.running {
@@ -164,6 +266,7 @@ func (c *Collector) StartCollection(ctx context.Context) error {
 			if err != nil {
 				logger.Warn("Failed to collect sensor data", zap.Error(err))
 			}
+			c.CheckHealth()
 		}
 	}
 }
@@ -184,6 +287,17 @@ func (c *Collector) SetDataCallback(fn func(data *model.SensorData)) {
 	c.onDataReceived = fn
 }
 
+// Emit hands data to the data callback as if it had just been read, e.g. to
+// replay recorded readings. The sensor need not be registered.
+func (c *Collector) Emit(data *model.SensorData) {
+	c.mu.RLock()
+	callback := c.onDataReceived
+	c.mu.RUnlock()
+	if callback != nil {
+		callback(data)
+	}
+}
+
 func (c *Collector) IsRunning() bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -193,6 +307,8 @@ func (c *Collector) IsRunning() bool {
 var (
 	ErrSensorNotFound = &CollectorError{Message: "sensor not found"}
 	ErrAlreadyRunning = &CollectorError{Message: "collection already running"}
+
+	ErrSensorDecommissioned = &CollectorError{Message: "sensor decommissioned"}
 )
 
 type CollectorError struct {
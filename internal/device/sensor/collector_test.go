@@ -0,0 +1,211 @@
+package sensor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/model"
+)
+
+// fakeDriver returns a reading per sensor after a short delay and fails for
+// the IDs in fail, recording the peak number of concurrent reads. ReadAll
+// reads the IDs in all.
+type fakeDriver struct {
+	fail    map[string]bool
+	all     []string
+	active  int32
+	maxSeen int32
+	mu      sync.Mutex
+}
+
+func (d *fakeDriver) Connect(ctx context.Context) error { return nil }
+func (d *fakeDriver) Disconnect() error                 { return nil }
+func (d *fakeDriver) IsConnected() bool                 { return true }
+
+func (d *fakeDriver) ReadAll(ctx context.Context) ([]*model.SensorData, error) {
+	data := make([]*model.SensorData, 0, len(d.all))
+	for _, id := range d.all {
+		data = append(data, &model.SensorData{SensorID: id, Value: 10, Timestamp: time.Now()})
+	}
+	return data, nil
+}
+
+func (d *fakeDriver) Read(ctx context.Context, sensorID string) (*model.SensorData, error) {
+	n := atomic.AddInt32(&d.active, 1)
+	defer atomic.AddInt32(&d.active, -1)
+	d.mu.Lock()
+	if n > d.maxSeen {
+		d.maxSeen = n
+	}
+	d.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+	if d.fail[sensorID] {
+		return nil, errors.New("read timeout")
+	}
+	return &model.SensorData{SensorID: sensorID, Value: 10, Timestamp: time.Now()}, nil
+}
+
+func TestCollectorReadSensors(t *testing.T) {
+	driver := &fakeDriver{fail: map[string]bool{"s3": true}}
+	c := NewCollector(driver, time.Second)
+
+	ids := make([]string, 20)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("s%d", i)
+		info := &model.SensorInfo{SensorID: ids[i]}
+		if i == 5 {
+			info.Calibration = &model.SensorCalibration{Scale: 2, Offset: 1}
+		}
+		c.RegisterSensor(info)
+	}
+	ids = append(ids, "unknown")
+
+	results := c.ReadSensors(context.Background(), ids)
+	if len(results) != len(ids) {
+		t.Fatalf("got %d results, want %d", len(results), len(ids))
+	}
+	for i, r := range results {
+		if r.SensorID != ids[i] {
+			t.Fatalf("result %d is for %s, want %s", i, r.SensorID, ids[i])
+		}
+		switch ids[i] {
+		case "s3":
+			if r.Error != "read timeout" || r.Data != nil {
+				t.Errorf("s3: got %+v, want the driver error", r)
+			}
+		case "unknown":
+			if r.Error != ErrSensorNotFound.Error() {
+				t.Errorf("unknown: error = %q, want %q", r.Error, ErrSensorNotFound.Error())
+			}
+		default:
+			if r.Error != "" || r.Data == nil {
+				t.Fatalf("%s: got %+v, want a reading", ids[i], r)
+			}
+		}
+	}
+	if v := results[5].Data.Value; v != 21 {
+		t.Errorf("calibrated value = %v, want 21", v)
+	}
+	if driver.maxSeen > maxBatchReadConcurrency {
+		t.Errorf("%d concurrent reads, want at most %d", driver.maxSeen, maxBatchReadConcurrency)
+	}
+}
+
+func TestCollectorHealth(t *testing.T) {
+	driver := &fakeDriver{fail: map[string]bool{"s2": true}}
+	c := NewCollector(driver, time.Second)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	c.SetOfflineAfter(time.Minute)
+	for _, id := range []string{"s1", "s2", "s3"} {
+		c.RegisterSensor(&model.SensorInfo{SensorID: id, Status: 1})
+	}
+
+	c.ReadSensors(context.Background(), []string{"s1", "s2", "s2"})
+	now = now.Add(30 * time.Second)
+	health := c.Health()
+	if len(health) != 3 {
+		t.Fatalf("got %d sensors, want 3", len(health))
+	}
+	s1, s2, s3 := health[0], health[1], health[2]
+	if s1.Status != model.SensorHealthOnline || s1.ReadCount != 1 || s1.Staleness != 30 || s1.LastSeen == nil {
+		t.Errorf("s1 = %+v", s1)
+	}
+	if s2.Status != model.SensorHealthUnknown || s2.ErrorCount != 2 || s2.ConsecutiveErrors != 2 || s2.LastError != "read timeout" {
+		t.Errorf("s2 = %+v", s2)
+	}
+	if s3.Status != model.SensorHealthUnknown || s3.LastSeen != nil {
+		t.Errorf("s3 = %+v", s3)
+	}
+
+	// s1 keeps reporting; the others fall silent past the offline period.
+	now = now.Add(20 * time.Second)
+	c.ReadSensor(context.Background(), "s1")
+	now = now.Add(20 * time.Second)
+	if offline := c.CheckHealth(); fmt.Sprint(offline) != "[s2 s3]" {
+		t.Errorf("offline = %v, want [s2 s3]", offline)
+	}
+	if info, _ := c.GetSensor("s2"); info.Status != 0 {
+		t.Errorf("offline sensor has status %d", info.Status)
+	}
+	if offline := c.CheckHealth(); len(offline) != 0 {
+		t.Errorf("sensors reported offline twice: %v", offline)
+	}
+
+	driver.fail["s2"] = false
+	c.ReadSensor(context.Background(), "s2")
+	if info, _ := c.GetSensor("s2"); info.Status != 1 {
+		t.Errorf("sensor back online has status %d", info.Status)
+	}
+	health = c.Health()
+	if health[1].Status != model.SensorHealthOnline || health[1].ConsecutiveErrors != 0 || health[1].ErrorCount != 2 {
+		t.Errorf("s2 after recovering = %+v", health[1])
+	}
+	if health[2].Status != model.SensorHealthOffline || health[2].Staleness != 70 {
+		t.Errorf("s3 = %+v", health[2])
+	}
+
+	c.UnregisterSensor("s3")
+	if n := len(c.Health()); n != 2 {
+		t.Errorf("unregistered sensor still tracked, %d sensors", n)
+	}
+}
+
+func TestCollectorLifecycle(t *testing.T) {
+	driver := &fakeDriver{all: []string{"s1", "s2", "s3"}}
+	c := NewCollector(driver, time.Second)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	c.SetOfflineAfter(time.Minute)
+	start, end := now.Add(10*time.Second), now.Add(60*time.Second)
+	c.RegisterSensor(&model.SensorInfo{SensorID: "s1", Status: 1})
+	c.RegisterSensor(&model.SensorInfo{SensorID: "s2", Status: 1, Lifecycle: model.SensorLifecycleMaintenance,
+		Maintenance: &model.SensorMaintenance{Start: start, End: &end}})
+	c.RegisterSensor(&model.SensorInfo{SensorID: "s3", Status: 1, Lifecycle: model.SensorLifecycleDecommissioned})
+
+	read := func() string {
+		data, err := c.ReadAllSensors(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]string, len(data))
+		for i, d := range data {
+			ids[i] = d.SensorID
+		}
+		return fmt.Sprint(ids)
+	}
+	if got := read(); got != "[s1 s2]" {
+		t.Errorf("before the window read %s, want [s1 s2]", got)
+	}
+	if _, err := c.ReadSensor(context.Background(), "s3"); err != ErrSensorDecommissioned {
+		t.Errorf("reading a decommissioned sensor: %v", err)
+	}
+
+	now = now.Add(20 * time.Second)
+	if got := read(); got != "[s1]" {
+		t.Errorf("during the window read %s, want [s1]", got)
+	}
+	if !c.InMaintenance("s2", now) || c.InMaintenance("s1", now) || c.InMaintenance("s2", end) {
+		t.Error("InMaintenance disagrees with the window")
+	}
+	health := c.Health()
+	if health[1].Status != model.SensorHealthMaintenance || health[2].Status != model.SensorHealthDecommissioned {
+		t.Errorf("health = %+v, %+v", health[1], health[2])
+	}
+
+	// Out of service sensors never go offline, and silence after a window
+	// counts from its end.
+	now = now.Add(70 * time.Second)
+	if offline := c.CheckHealth(); fmt.Sprint(offline) != "[s1]" {
+		t.Errorf("offline = %v, want [s1]", offline)
+	}
+	if got := read(); got != "[s1 s2]" {
+		t.Errorf("after the window read %s, want [s1 s2]", got)
+	}
+}
@@ -23,7 +23,17 @@ func (f *DriverFactory) Create(driverType DriverType, opts ...DriverOption) (Dri
 
 	switch driverType {
 	case DriverTypeSimulator:
-		return NewSimulator(), nil
+		sim := NewSimulator()
+		if config.ScenarioFile != "" {
+			sc, err := LoadScenarioFile(config.ScenarioFile)
+			if err != nil {
+				return nil, err
+			}
+			if err := sim.LoadScenario(sc); err != nil {
+				return nil, err
+			}
+		}
+		return sim, nil
 	case DriverTypeMQTT:
 		return nil, ErrMQTTDriverNotImplemented
 	case DriverTypeSerial:
@@ -38,6 +48,8 @@ type DriverConfig struct {
 	ClientID   string
 	SerialPort string
 	BaudRate   int
+	// ScenarioFile scripts the simulator's sensors; see Scenario.
+	ScenarioFile string
 }
 
 type DriverOption func(*DriverConfig)
@@ -66,6 +78,12 @@ func WithBaudRate(rate int) DriverOption {
 	}
 }
 
+func WithScenarioFile(path string) DriverOption {
+	return func(c *DriverConfig) {
+		c.ScenarioFile = path
+	}
+}
+
 var (
 	ErrMQTTDriverNotImplemented   = &FactoryError{Message: "mqtt driver not implemented"}
 	ErrSerialDriverNotImplemented = &FactoryError{Message: "serial driver not implemented"}
@@ -0,0 +1,178 @@
+package sensor
+
+import (
+	"sort"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// sensorHealth tracks the reads of one sensor. since is when tracking
+// started or the sensor last returned to service; silence is counted from
+// it when the sensor has not been read since.
+type sensorHealth struct {
+	since             time.Time
+	lastSeen          time.Time
+	lastError         string
+	lastErrorAt       time.Time
+	reads             int64
+	errors            int64
+	consecutiveErrors int
+	offline           bool
+}
+
+// SetOfflineAfter marks a sensor offline, with status 0, once it has not
+// been read successfully for d; the next successful read brings it back. Zero
+// never marks sensors offline.
+func (c *Collector) SetOfflineAfter(d time.Duration) {
+	c.healthMu.Lock()
+	defer c.healthMu.Unlock()
+	c.offlineAfter = d
+}
+
+// track starts tracking a sensor unless it already is.
+func (c *Collector) track(sensorID string) {
+	c.healthMu.Lock()
+	defer c.healthMu.Unlock()
+	if _, ok := c.health[sensorID]; !ok {
+		c.health[sensorID] = &sensorHealth{since: c.now()}
+	}
+}
+
+func (c *Collector) untrack(sensorID string) {
+	c.healthMu.Lock()
+	defer c.healthMu.Unlock()
+	delete(c.health, sensorID)
+}
+
+func (c *Collector) recordRead(info *model.SensorInfo) {
+	c.healthMu.Lock()
+	defer c.healthMu.Unlock()
+	h, ok := c.health[info.SensorID]
+	if !ok {
+		return
+	}
+	h.reads++
+	h.consecutiveErrors = 0
+	h.lastSeen = c.now()
+	if h.offline {
+		h.offline = false
+		info.Status = 1
+		logger.Info("Sensor back online", zap.String("sensor_id", info.SensorID))
+	}
+}
+
+func (c *Collector) recordError(sensorID string, err error) {
+	c.healthMu.Lock()
+	defer c.healthMu.Unlock()
+	h, ok := c.health[sensorID]
+	if !ok {
+		return
+	}
+	h.errors++
+	h.consecutiveErrors++
+	h.lastError = err.Error()
+	h.lastErrorAt = c.now()
+}
+
+// CheckHealth marks the sensors silent for longer than the offline period
+// offline and returns their IDs.
+func (c *Collector) CheckHealth() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.healthMu.Lock()
+	defer c.healthMu.Unlock()
+
+	if c.offlineAfter <= 0 {
+		return nil
+	}
+	now := c.now()
+	var offline []string
+	for id, h := range c.health {
+		if info, ok := c.sensors[id]; ok {
+			if info.LifecycleAt(now) != model.SensorLifecycleActive {
+				// not read while out of service
+				h.since = now
+				h.offline = false
+				continue
+			}
+			if w := info.Maintenance; w != nil && w.End != nil && w.End.After(h.since) && !w.End.After(now) {
+				h.since = *w.End
+			}
+		}
+		if h.offline || now.Sub(h.lastSighting()) <= c.offlineAfter {
+			continue
+		}
+		h.offline = true
+		if info, ok := c.sensors[id]; ok {
+			info.Status = 0
+		}
+		offline = append(offline, id)
+		logger.Warn("Sensor offline",
+			zap.String("sensor_id", id),
+			zap.Duration("silence", now.Sub(h.lastSighting())),
+			zap.String("last_error", h.lastError),
+		)
+	}
+	sort.Strings(offline)
+	return offline
+}
+
+func (h *sensorHealth) lastSighting() time.Time {
+	if h.lastSeen.Before(h.since) {
+		return h.since
+	}
+	return h.lastSeen
+}
+
+// Health reports every registered sensor, ordered by ID, after updating
+// which are offline.
+func (c *Collector) Health() []*model.SensorHealth {
+	c.CheckHealth()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.healthMu.Lock()
+	defer c.healthMu.Unlock()
+	now := c.now()
+	report := make([]*model.SensorHealth, 0, len(c.health))
+	for id, h := range c.health {
+		r := &model.SensorHealth{
+			SensorID:          id,
+			Status:            model.SensorHealthOnline,
+			Staleness:         now.Sub(h.lastSighting()).Seconds(),
+			ReadCount:         h.reads,
+			ErrorCount:        h.errors,
+			ConsecutiveErrors: h.consecutiveErrors,
+			LastError:         h.lastError,
+		}
+		var lifecycle model.SensorLifecycle
+		if info, ok := c.sensors[id]; ok {
+			lifecycle = info.LifecycleAt(now)
+		}
+		switch {
+		case lifecycle == model.SensorLifecycleMaintenance:
+			r.Status = model.SensorHealthMaintenance
+		case lifecycle == model.SensorLifecycleDecommissioned:
+			r.Status = model.SensorHealthDecommissioned
+		case h.offline:
+			r.Status = model.SensorHealthOffline
+		case h.lastSeen.IsZero():
+			r.Status = model.SensorHealthUnknown
+		}
+		if !h.lastSeen.IsZero() {
+			lastSeen := h.lastSeen
+			r.LastSeen = &lastSeen
+		}
+		if !h.lastErrorAt.IsZero() {
+			lastErrorAt := h.lastErrorAt
+			r.LastErrorAt = &lastErrorAt
+		}
+		report = append(report, r)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].SensorID < report[j].SensorID })
+	return report
+}
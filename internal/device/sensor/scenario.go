@@ -0,0 +1,221 @@
+package sensor
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"isac-cran-system/internal/model"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Segment kinds of a scenario profile.
+const (
+	SegmentStep    = "step"
+	SegmentRamp    = "ramp"
+	SegmentSine    = "sine"
+	SegmentFailure = "failure"
+)
+
+// Scenario scripts the simulator's sensor values over time so tests and
+// demos see the same conditions on every run. Times are seconds from when
+// the scenario starts, which is when the simulator connects or the scenario
+// is loaded into a connected simulator.
+type Scenario struct {
+	Name string `json:"name" yaml:"name"`
+	// Seed seeds the measurement noise of every sensor.
+	Seed int64 `json:"seed" yaml:"seed"`
+	// Repeat restarts the scenario every Repeat seconds when set.
+	Repeat float64 `json:"repeat" yaml:"repeat"`
+	// Exclusive drops the simulator's default sensors.
+	Exclusive bool             `json:"exclusive" yaml:"exclusive"`
+	Sensors   []ScenarioSensor `json:"sensors" yaml:"sensors"`
+}
+
+// ScenarioSensor is a scripted sensor. Its value starts at Base and follows
+// Profile, with Gaussian noise of standard deviation Noise on top.
+type ScenarioSensor struct {
+	ID       string           `json:"id" yaml:"id"`
+	Type     model.SensorType `json:"type" yaml:"type"`
+	Location string           `json:"location" yaml:"location"`
+	Unit     string           `json:"unit" yaml:"unit"`
+	Min      float64          `json:"min" yaml:"min"`
+	Max      float64          `json:"max" yaml:"max"`
+	Base     float64          `json:"base" yaml:"base"`
+	Noise    float64          `json:"noise" yaml:"noise"`
+	Profile  []Segment        `json:"profile" yaml:"profile"`
+}
+
+// Segment changes a sensor from At on. A step sets the value to Value; a
+// ramp moves it linearly to Value over Duration and holds it there. A sine
+// adds Amplitude*sin(2π(t-At)/Period + Phase) and a failure fails every
+// read, both for Duration seconds, or for good when Duration is zero.
+type Segment struct {
+	Kind      string  `json:"kind" yaml:"kind"`
+	At        float64 `json:"at" yaml:"at"`
+	Duration  float64 `json:"duration" yaml:"duration"`
+	Value     float64 `json:"value" yaml:"value"`
+	Amplitude float64 `json:"amplitude" yaml:"amplitude"`
+	Period    float64 `json:"period" yaml:"period"`
+	Phase     float64 `json:"phase" yaml:"phase"`
+}
+
+// LoadScenarioFile reads a scenario from a .yaml, .yml or .json file.
+func LoadScenarioFile(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sc := &Scenario{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, sc)
+	case ".json":
+		err = json.Unmarshal(data, sc)
+	default:
+		return nil, fmt.Errorf("scenario %s: unknown format, want .yaml, .yml or .json", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scenario %s: %w", path, err)
+	}
+	if err := sc.Validate(); err != nil {
+		return nil, fmt.Errorf("scenario %s: %w", path, err)
+	}
+	return sc, nil
+}
+
+func (sc *Scenario) Validate() error {
+	if sc.Repeat < 0 {
+		return fmt.Errorf("repeat must not be negative")
+	}
+	seen := make(map[string]bool, len(sc.Sensors))
+	for _, s := range sc.Sensors {
+		if s.ID == "" {
+			return fmt.Errorf("sensor without an id")
+		}
+		if seen[s.ID] {
+			return fmt.Errorf("sensor %s listed twice", s.ID)
+		}
+		seen[s.ID] = true
+		if s.Noise < 0 || s.Max < s.Min {
+			return fmt.Errorf("sensor %s: invalid noise or range", s.ID)
+		}
+		for i, seg := range s.Profile {
+			if seg.At < 0 || seg.Duration < 0 {
+				return fmt.Errorf("sensor %s segment %d: negative time", s.ID, i)
+			}
+			switch seg.Kind {
+			case SegmentStep, SegmentFailure:
+			case SegmentRamp:
+				if seg.Duration == 0 {
+					return fmt.Errorf("sensor %s segment %d: ramp needs a duration", s.ID, i)
+				}
+			case SegmentSine:
+				if seg.Period <= 0 {
+					return fmt.Errorf("sensor %s segment %d: sine needs a positive period", s.ID, i)
+				}
+			default:
+				return fmt.Errorf("sensor %s segment %d: unknown kind %q", s.ID, i, seg.Kind)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *ScenarioSensor) info() *model.SensorInfo {
+	return &model.SensorInfo{
+		SensorID:   s.ID,
+		SensorType: s.Type,
+		Location:   s.Location,
+		Unit:       s.Unit,
+		MinValue:   s.Min,
+		MaxValue:   s.Max,
+		Status:     1,
+	}
+}
+
+// valueAt returns the scripted value t seconds into the scenario, before
+// noise, and whether the sensor has failed.
+func (s *ScenarioSensor) valueAt(t float64) (float64, bool) {
+	segments := make([]Segment, len(s.Profile))
+	copy(segments, s.Profile)
+	sort.SliceStable(segments, func(i, j int) bool { return segments[i].At < segments[j].At })
+
+	level, offset := s.Base, 0.0
+	for _, seg := range segments {
+		if t < seg.At {
+			break
+		}
+		active := seg.Duration == 0 || t < seg.At+seg.Duration
+		switch seg.Kind {
+		case SegmentStep:
+			level = seg.Value
+		case SegmentRamp:
+			level += (seg.Value - level) * math.Min(1, (t-seg.At)/seg.Duration)
+		case SegmentSine:
+			if active {
+				offset += seg.Amplitude * math.Sin(2*math.Pi*(t-seg.At)/seg.Period+seg.Phase)
+			}
+		case SegmentFailure:
+			if active {
+				return 0, true
+			}
+		}
+	}
+	return level + offset, false
+}
+
+// scenarioRun is a scenario started at start, with one noise source per
+// sensor so that the noise a sensor sees does not depend on the others.
+type scenarioRun struct {
+	scenario *Scenario
+	start    time.Time
+	sensors  map[string]*ScenarioSensor
+	noise    map[string]*rand.Rand
+}
+
+func newScenarioRun(sc *Scenario, start time.Time) *scenarioRun {
+	run := &scenarioRun{
+		scenario: sc,
+		start:    start,
+		sensors:  make(map[string]*ScenarioSensor, len(sc.Sensors)),
+		noise:    make(map[string]*rand.Rand, len(sc.Sensors)),
+	}
+	for i := range sc.Sensors {
+		s := &sc.Sensors[i]
+		run.sensors[s.ID] = s
+		run.noise[s.ID] = rand.New(rand.NewSource(sc.Seed + int64(i)))
+	}
+	return run
+}
+
+func (r *scenarioRun) elapsed(now time.Time) float64 {
+	t := now.Sub(r.start).Seconds()
+	if r.scenario.Repeat > 0 {
+		t = math.Mod(t, r.scenario.Repeat)
+	}
+	return t
+}
+
+// read returns the value of a scripted sensor at now.
+func (r *scenarioRun) read(id string, now time.Time) (float64, error) {
+	s := r.sensors[id]
+	value, failed := s.valueAt(r.elapsed(now))
+	if failed {
+		return 0, &SimulatorError{Message: "sensor " + id + " failed"}
+	}
+	if s.Noise > 0 {
+		value += r.noise[id].NormFloat64() * s.Noise
+	}
+	if s.Max > s.Min {
+		value = math.Max(s.Min, math.Min(s.Max, value))
+	}
+	return math.Round(value*100) / 100, nil
+}
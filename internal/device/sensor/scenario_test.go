@@ -0,0 +1,156 @@
+package sensor
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScenarioProfile(t *testing.T) {
+	s := &ScenarioSensor{
+		ID:   "temp-001",
+		Base: 20,
+		Profile: []Segment{
+			{Kind: SegmentRamp, At: 10, Duration: 10, Value: 30},
+			{Kind: SegmentSine, At: 40, Duration: 20, Amplitude: 2, Period: 8},
+			{Kind: SegmentFailure, At: 70, Duration: 5},
+			{Kind: SegmentStep, At: 80, Value: 15},
+		},
+	}
+	tests := []struct {
+		t      float64
+		value  float64
+		failed bool
+	}{
+		{0, 20, false},
+		{15, 25, false},
+		{20, 30, false},
+		{42, 32, false},
+		{60, 30, false},
+		{72, 0, true},
+		{75, 30, false},
+		{90, 15, false},
+	}
+	for _, tt := range tests {
+		value, failed := s.valueAt(tt.t)
+		if failed != tt.failed || math.Abs(value-tt.value) > 1e-9 {
+			t.Errorf("valueAt(%v) = %v, %v; want %v, %v", tt.t, value, failed, tt.value, tt.failed)
+		}
+	}
+}
+
+func TestSimulatorScenario(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	sc := &Scenario{
+		Seed:      3,
+		Repeat:    100,
+		Exclusive: true,
+		Sensors: []ScenarioSensor{
+			{ID: "temp-001", Type: "temperature", Min: 0, Max: 50, Base: 20, Noise: 0.5,
+				Profile: []Segment{{Kind: SegmentStep, At: 30, Value: 60}, {Kind: SegmentFailure, At: 50, Duration: 10}}},
+			{ID: "hum-001", Type: "humidity", Base: 55},
+		},
+	}
+
+	readings := func() []float64 {
+		now := start
+		sim := NewSimulator()
+		sim.SetClock(func() time.Time { return now })
+		if err := sim.LoadScenario(sc); err != nil {
+			t.Fatal(err)
+		}
+		if err := sim.Connect(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if n := len(sim.GetAllSensorInfo()); n != 2 {
+			t.Fatalf("exclusive scenario left %d sensors, want 2", n)
+		}
+
+		var values []float64
+		for _, at := range []float64{0, 10, 40} {
+			now = start.Add(time.Duration(at * float64(time.Second)))
+			d, err := sim.Read(context.Background(), "temp-001")
+			if err != nil {
+				t.Fatalf("read at %vs: %v", at, err)
+			}
+			if !d.Timestamp.Equal(now) {
+				t.Errorf("reading at %vs stamped %v", at, d.Timestamp)
+			}
+			values = append(values, d.Value)
+		}
+		if values[2] != 50 {
+			t.Errorf("step above the range read %v, want the 50 maximum", values[2])
+		}
+
+		now = start.Add(55 * time.Second)
+		if _, err := sim.Read(context.Background(), "temp-001"); err == nil {
+			t.Error("expected the failed sensor to fail")
+		}
+		all, err := sim.ReadAll(context.Background())
+		if err != nil || len(all) != 1 || all[0].SensorID != "hum-001" || all[0].Value != 55 {
+			t.Errorf("ReadAll during the failure = %v, %v", all, err)
+		}
+
+		// The scenario repeats every 100 s.
+		now = start.Add(105 * time.Second)
+		d, err := sim.Read(context.Background(), "temp-001")
+		if err != nil || math.Abs(d.Value-20) > 3 {
+			t.Errorf("read after the repeat = %v, %v", d, err)
+		}
+		return values
+	}
+
+	first, second := readings(), readings()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("runs differ: %v and %v", first, second)
+		}
+	}
+	if first[0] == 20 && first[1] == 20 {
+		t.Error("expected noise on the readings")
+	}
+}
+
+func TestLoadScenarioFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ramp.yaml")
+	os.WriteFile(path, []byte(`name: ramp
+seed: 1
+sensors:
+  - id: temp-001
+    type: temperature
+    base: 20
+    profile:
+      - {kind: ramp, at: 0, duration: 10, value: 30}
+`), 0o644)
+	sc, err := LoadScenarioFile(path)
+	if err != nil {
+		t.Fatalf("LoadScenarioFile: %v", err)
+	}
+	if sc.Name != "ramp" || len(sc.Sensors) != 1 || sc.Sensors[0].Profile[0].Value != 30 {
+		t.Errorf("loaded %+v", sc)
+	}
+
+	jsonPath := filepath.Join(dir, "bad.json")
+	os.WriteFile(jsonPath, []byte(`{"sensors": [{"id": "x", "profile": [{"kind": "ramp", "at": 0}]}]}`), 0o644)
+	if _, err := LoadScenarioFile(jsonPath); err == nil {
+		t.Error("expected a ramp without a duration to be rejected")
+	}
+	if _, err := LoadScenarioFile(filepath.Join(dir, "scenario.txt")); err == nil {
+		t.Error("expected a missing file to fail")
+	}
+
+	if _, err := LoadScenarioFile("../../../configs/scenarios/overheat.yaml"); err != nil {
+		t.Errorf("example scenario: %v", err)
+	}
+
+	if _, err := NewDriverFactory().Create(DriverTypeSimulator, WithScenarioFile(path)); err != nil {
+		t.Errorf("simulator with scenario: %v", err)
+	}
+	if _, err := NewDriverFactory().Create(DriverTypeSimulator, WithScenarioFile(jsonPath)); err == nil {
+		t.Error("expected an invalid scenario to fail the driver")
+	}
+}
@@ -9,6 +9,8 @@ import (
 
 	"isac-cran-system/internal/model"
 	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
 )
 
 type Simulator struct {
@@ -16,6 +18,9 @@ type Simulator struct {
 	connected bool
 	mu        sync.RWMutex
 	rand      *rand.Rand
+	scenario  *Scenario
+	run       *scenarioRun
+	now       func() time.Time
 }
 
 type simulatedSensor struct {
@@ -30,7 +35,47 @@ func NewSimulator() *Simulator {
 	return &Simulator{
 		sensors: make(map[string]*simulatedSensor),
 		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		now:     time.Now,
+	}
+}
+
+// SetClock replaces the clock that timestamps readings and drives the
+// scenario, for tests.
+func (s *Simulator) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// LoadScenario scripts the sensors of sc. A connected simulator starts the
+// scenario right away, otherwise it starts on Connect.
+func (s *Simulator) LoadScenario(sc *Scenario) error {
+	if err := sc.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scenario = sc
+	if s.connected {
+		s.startScenario()
+	}
+	logger.Info("Sensor scenario loaded", zap.String("scenario", sc.Name), zap.Int("sensors", len(sc.Sensors)))
+	return nil
+}
+
+func (s *Simulator) startScenario() {
+	if s.scenario.Exclusive {
+		s.sensors = make(map[string]*simulatedSensor)
+	}
+	for i := range s.scenario.Sensors {
+		sensor := &s.scenario.Sensors[i]
+		s.sensors[sensor.ID] = &simulatedSensor{
+			info:      sensor.info(),
+			baseValue: sensor.Base,
+			lastValue: sensor.Base,
+		}
 	}
+	s.run = newScenarioRun(s.scenario, s.now())
 }
 
 func (s *Simulator) Connect(ctx context.Context) error {
@@ -48,6 +93,9 @@ func (s *Simulator) Connect(ctx context.Context) error {
 	s.connected = true
 
 	s.initDefaultSensors()
+	if s.scenario != nil {
+		s.startScenario()
+	}
 
 	logger.Info("Sensor simulator connected")
 	return nil
@@ -102,9 +150,11 @@ func (s *Simulator) Disconnect() error {
 	return nil
 }
 
+// Read and ReadAll take the write lock since every reading advances the
+// sensor's state.
 func (s *Simulator) Read(ctx context.Context, sensorID string) (*model.SensorData, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	if !s.connected {
 		return nil, ErrSimulatorNotConnected
@@ -114,26 +164,13 @@ func (s *Simulator) Read(ctx context.Context, sensorID string) (*model.SensorDat
 	if !ok {
 		return nil, ErrSensorNotFound
 	}
-
-	value := s.generateValue(sensor)
-	sensor.lastValue = value
-
-	quality := 0.9 + s.rand.Float64()*0.1
-
-	return &model.SensorData{
-		SensorID:   sensorID,
-		SensorType: string(sensor.info.SensorType),
-		Location:   sensor.info.Location,
-		Value:      value,
-		Unit:       sensor.info.Unit,
-		Quality:    quality,
-		Timestamp:  time.Now(),
-	}, nil
+	return s.read(sensorID, sensor)
 }
 
+// ReadAll skips the scripted sensors that are failing.
 func (s *Simulator) ReadAll(ctx context.Context) ([]*model.SensorData, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	if !s.connected {
 		return nil, ErrSimulatorNotConnected
@@ -141,23 +178,40 @@ func (s *Simulator) ReadAll(ctx context.Context) ([]*model.SensorData, error) {
 
 	data := make([]*model.SensorData, 0, len(s.sensors))
 	for id, sensor := range s.sensors {
-		value := s.generateValue(sensor)
-		sensor.lastValue = value
+		d, err := s.read(id, sensor)
+		if err != nil {
+			continue
+		}
+		data = append(data, d)
+	}
 
-		quality := 0.9 + s.rand.Float64()*0.1
+	return data, nil
+}
 
-		data = append(data, &model.SensorData{
-			SensorID:   id,
-			SensorType: string(sensor.info.SensorType),
-			Location:   sensor.info.Location,
-			Value:      value,
-			Unit:       sensor.info.Unit,
-			Quality:    quality,
-			Timestamp:  time.Now(),
-		})
+func (s *Simulator) read(id string, sensor *simulatedSensor) (*model.SensorData, error) {
+	now := s.now()
+	var value, quality float64
+	if s.run != nil && s.run.sensors[id] != nil {
+		v, err := s.run.read(id, now)
+		if err != nil {
+			return nil, err
+		}
+		value, quality = v, 1
+	} else {
+		value = s.generateValue(sensor)
+		quality = 0.9 + s.rand.Float64()*0.1
 	}
+	sensor.lastValue = value
 
-	return data, nil
+	return &model.SensorData{
+		SensorID:   id,
+		SensorType: string(sensor.info.SensorType),
+		Location:   sensor.info.Location,
+		Value:      value,
+		Unit:       sensor.info.Unit,
+		Quality:    quality,
+		Timestamp:  now,
+	}, nil
 }
 
 func (s *Simulator) IsConnected() bool {
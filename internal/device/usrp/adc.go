@@ -0,0 +1,72 @@
+package usrp
+
+import (
+	"math"
+
+	"isac-cran-system/internal/model"
+)
+
+// ADC quantizes I and Q separately to a two's-complement grid of 2^Bits
+// levels spanning [-FullScale, FullScale) and flags samples that clipped.
+type ADC struct {
+	fullScale float64
+	step      float64
+}
+
+func NewADC(cfg model.ADCConfig) *ADC {
+	fullScale := cfg.FullScale
+	if fullScale <= 0 {
+		fullScale = 1
+	}
+	return &ADC{
+		fullScale: fullScale,
+		step:      fullScale / math.Pow(2, float64(cfg.Bits-1)),
+	}
+}
+
+func (a *ADC) quantize(x float64) (float64, bool) {
+	level := math.Round(x / a.step)
+	maxLevel := a.fullScale/a.step - 1
+	switch {
+	case level > maxLevel:
+		return maxLevel * a.step, true
+	case level < -maxLevel-1:
+		return (-maxLevel - 1) * a.step, true
+	}
+	return level * a.step, false
+}
+
+func (a *ADC) Convert(channels [][]model.ChannelDataPoint) {
+	for _, ch := range channels {
+		for i := range ch {
+			dp := &ch[i]
+			iVal, iClip := a.quantize(dp.I)
+			qVal, qClip := a.quantize(dp.Q)
+			dp.I = iVal
+			dp.Q = qVal
+			dp.Amplitude = math.Hypot(iVal, qVal)
+			dp.Phase = math.Atan2(qVal, iVal)
+			dp.Clipped = dp.Clipped || iClip || qClip
+		}
+	}
+}
+
+// CaptureStats reports clipping and the peak magnitude over a capture.
+func CaptureStats(channels [][]model.ChannelDataPoint) *model.ADCStats {
+	stats := &model.ADCStats{}
+	for _, ch := range channels {
+		for _, dp := range ch {
+			stats.Samples++
+			if dp.Clipped {
+				stats.ClippedSamples++
+			}
+			if dp.Amplitude > stats.PeakAmplitude {
+				stats.PeakAmplitude = dp.Amplitude
+			}
+		}
+	}
+	if stats.Samples > 0 {
+		stats.ClipRate = float64(stats.ClippedSamples) / float64(stats.Samples)
+	}
+	return stats
+}
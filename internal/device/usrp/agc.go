@@ -0,0 +1,188 @@
+package usrp
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// minLevelDBFS stands in for the level of an all-zero probe.
+const minLevelDBFS = -120.0
+
+func DefaultAGCConfig() model.AGCConfig {
+	return model.AGCConfig{
+		TargetDBFS:    -12,
+		ToleranceDB:   1,
+		MaxStepDB:     6,
+		MinGain:       0,
+		MaxGain:       simulatorMaxGain,
+		Interval:      0.5,
+		ProbeDuration: 0.001,
+		FullScale:     1,
+	}
+}
+
+// MergeAGCConfig fills the zero fields of cfg from fallback. A zero MinGain
+// is a valid floor and is kept unless MaxGain is unset too.
+func MergeAGCConfig(cfg, fallback model.AGCConfig) model.AGCConfig {
+	if cfg.TargetDBFS == 0 {
+		cfg.TargetDBFS = fallback.TargetDBFS
+	}
+	if cfg.ToleranceDB <= 0 {
+		cfg.ToleranceDB = fallback.ToleranceDB
+	}
+	if cfg.MaxStepDB <= 0 {
+		cfg.MaxStepDB = fallback.MaxStepDB
+	}
+	if cfg.MaxGain <= 0 {
+		cfg.MinGain, cfg.MaxGain = fallback.MinGain, fallback.MaxGain
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = fallback.Interval
+	}
+	if cfg.ProbeDuration <= 0 {
+		cfg.ProbeDuration = fallback.ProbeDuration
+	}
+	if cfg.FullScale <= 0 {
+		cfg.FullScale = fallback.FullScale
+	}
+	return cfg
+}
+
+// AGC is a running gain control loop started by Receiver.StartAGC. Each
+// iteration captures a short probe on every channel and steps the gain, by
+// at most MaxStepDB, toward the level that puts the strongest sample at
+// TargetDBFS. A probe that clipped always backs off by the full step.
+type AGC struct {
+	receiver *Receiver
+	cfg      model.AGCConfig
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	mu     sync.Mutex
+	status model.AGCStatus
+}
+
+func (r *Receiver) StartAGC(ctx context.Context, cfg model.AGCConfig) (*AGC, error) {
+	if !r.IsConnected() {
+		return nil, ErrReceiverNotConnected
+	}
+	cfg = MergeAGCConfig(cfg, DefaultAGCConfig())
+	if cfg.MinGain > cfg.MaxGain {
+		return nil, ErrInvalidAGCConfig
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	a := &AGC{
+		receiver: r,
+		cfg:      cfg,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		status:   model.AGCStatus{Enabled: true, Config: cfg},
+	}
+	go a.run(ctx)
+
+	logger.Info("USRP AGC started",
+		zap.Float64("target_dbfs", cfg.TargetDBFS),
+		zap.Float64("min_gain", cfg.MinGain),
+		zap.Float64("max_gain", cfg.MaxGain),
+	)
+	return a, nil
+}
+
+func (a *AGC) run(ctx context.Context) {
+	defer close(a.done)
+	ticker := time.NewTicker(time.Duration(a.cfg.Interval * float64(time.Second)))
+	defer ticker.Stop()
+
+	for {
+		a.step(ctx)
+		select {
+		case <-ctx.Done():
+			a.mu.Lock()
+			a.status.Enabled = false
+			a.mu.Unlock()
+			logger.Info("USRP AGC stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *AGC) step(ctx context.Context) {
+	probe := time.Duration(a.cfg.ProbeDuration * float64(time.Second))
+	data, err := a.receiver.CollectMultiChannel(ctx, probe)
+	if err != nil {
+		if ctx.Err() == nil {
+			a.fail(err)
+		}
+		return
+	}
+
+	stats := CaptureStats(data)
+	level := minLevelDBFS
+	if stats.PeakAmplitude > 0 {
+		level = math.Max(20*math.Log10(stats.PeakAmplitude/a.cfg.FullScale), minLevelDBFS)
+	}
+
+	gain := a.receiver.GetGain()
+	delta := a.cfg.TargetDBFS - level
+	switch {
+	case stats.ClippedSamples > 0:
+		delta = -a.cfg.MaxStepDB
+	case math.Abs(delta) <= a.cfg.ToleranceDB:
+		delta = 0
+	}
+	delta = math.Max(-a.cfg.MaxStepDB, math.Min(a.cfg.MaxStepDB, delta))
+	next := math.Max(a.cfg.MinGain, math.Min(a.cfg.MaxGain, gain+delta))
+
+	adjusted := next != gain
+	if adjusted {
+		if err := a.receiver.SetGain(next); err != nil {
+			a.fail(err)
+			return
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.status.LevelDBFS = level
+	a.status.ClipRate = stats.ClipRate
+	a.status.UpdatedAt = time.Now()
+	a.status.Error = ""
+	if adjusted {
+		a.status.Adjustments++
+	}
+}
+
+func (a *AGC) fail(err error) {
+	logger.Warn("USRP AGC iteration failed", zap.Error(err))
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.status.UpdatedAt = time.Now()
+	a.status.Error = err.Error()
+}
+
+// Stop ends the loop and waits for the current iteration to finish.
+func (a *AGC) Stop() {
+	a.cancel()
+	<-a.done
+}
+
+func (a *AGC) Done() <-chan struct{} {
+	return a.done
+}
+
+func (a *AGC) Status() model.AGCStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.status
+}
+
+var ErrInvalidAGCConfig = &ReceiverError{Message: "agc min_gain exceeds max_gain"}
@@ -0,0 +1,72 @@
+package usrp
+
+import (
+	"context"
+	"testing"
+
+	"isac-cran-system/internal/model"
+)
+
+func TestAGC_ConvergesFromClipping(t *testing.T) {
+	sim := NewSimulator(1e6, 2.4e9)
+	sim.SetADC(model.ADCConfig{Bits: 12, FullScale: 4})
+	rx := NewReceiver(sim, 1e6, 2.4e9)
+	if err := rx.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer rx.Disconnect()
+
+	if err := rx.SetGain(90); err != ErrGainOutOfRange {
+		t.Fatalf("SetGain(90) error = %v, want %v", err, ErrGainOutOfRange)
+	}
+	if err := rx.SetGain(60); err != nil {
+		t.Fatalf("SetGain(60) error = %v", err)
+	}
+
+	cfg := MergeAGCConfig(model.AGCConfig{FullScale: 4, Interval: 60}, DefaultAGCConfig())
+	a := &AGC{receiver: rx, cfg: cfg, status: model.AGCStatus{Enabled: true, Config: cfg}}
+	for i := 0; i < 20; i++ {
+		a.step(context.Background())
+	}
+
+	status := a.Status()
+	if status.Error != "" {
+		t.Fatalf("AGC error = %s", status.Error)
+	}
+	if status.ClipRate != 0 {
+		t.Errorf("clip rate = %v after convergence, want 0", status.ClipRate)
+	}
+	if status.LevelDBFS < cfg.TargetDBFS-cfg.ToleranceDB-1 || status.LevelDBFS > cfg.TargetDBFS+cfg.ToleranceDB+1 {
+		t.Errorf("level = %.1f dBFS, want near %.1f", status.LevelDBFS, cfg.TargetDBFS)
+	}
+	if gain := rx.GetGain(); gain >= 60 || status.Adjustments == 0 {
+		t.Errorf("gain = %v after %d adjustments, want it reduced", gain, status.Adjustments)
+	}
+}
+
+func TestReceiver_StartAGC(t *testing.T) {
+	rx := NewReceiver(NewSimulator(1e6, 2.4e9), 1e6, 2.4e9)
+	if _, err := rx.StartAGC(context.Background(), model.AGCConfig{}); err != ErrReceiverNotConnected {
+		t.Fatalf("StartAGC() before Connect error = %v, want %v", err, ErrReceiverNotConnected)
+	}
+	if err := rx.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer rx.Disconnect()
+
+	if _, err := rx.StartAGC(context.Background(), model.AGCConfig{MinGain: 50, MaxGain: 40}); err != ErrInvalidAGCConfig {
+		t.Fatalf("StartAGC() error = %v, want %v", err, ErrInvalidAGCConfig)
+	}
+
+	a, err := rx.StartAGC(context.Background(), model.AGCConfig{Interval: 0.01})
+	if err != nil {
+		t.Fatalf("StartAGC() error = %v", err)
+	}
+	if !a.Status().Enabled {
+		t.Error("AGC not reported enabled while running")
+	}
+	a.Stop()
+	if a.Status().Enabled {
+		t.Error("AGC still reported enabled after Stop")
+	}
+}
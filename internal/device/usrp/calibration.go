@@ -0,0 +1,174 @@
+package usrp
+
+import (
+	"context"
+	"math"
+	"math/cmplx"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultCalibrationToneFreq  = 100e3
+	DefaultCalibrationAmplitude = 0.5
+	DefaultCalibrationDuration  = 10 * time.Millisecond
+
+	// minCalibrationSNR is the tone-to-residual power, in dB, below which a
+	// channel is taken to have no calibration tone.
+	minCalibrationSNR = 6.0
+)
+
+// ToneInjector is implemented by drivers that can feed a calibration tone
+// into every RX channel themselves. On hardware the tone comes from an
+// external source split equally to all inputs.
+type ToneInjector interface {
+	// SetCalibrationTone injects a tone at freq Hz from the carrier; an
+	// amplitude of zero turns it off.
+	SetCalibrationTone(freq, amplitude float64) error
+}
+
+// MeasureCalibration captures a known tone on every channel and returns each
+// channel's gain and phase relative to channel 0. Corrections already set
+// are not applied to the measurement capture.
+func (r *Receiver) MeasureCalibration(ctx context.Context, toneFreq, amplitude float64, duration time.Duration) (*model.RXCalibration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.connected {
+		return nil, ErrReceiverNotConnected
+	}
+	if toneFreq == 0 || math.Abs(toneFreq) >= r.sampleRate/2 {
+		return nil, ErrInvalidCalibrationTone
+	}
+
+	if amplitude <= 0 {
+		amplitude = DefaultCalibrationAmplitude
+	}
+	if injector, ok := r.driver.(ToneInjector); ok {
+		if err := injector.SetCalibrationTone(toneFreq, amplitude); err != nil {
+			return nil, err
+		}
+		defer injector.SetCalibrationTone(0, 0)
+	}
+
+	channels, err := r.driver.ReceiveMulti(ctx, duration)
+	if err != nil {
+		return nil, err
+	}
+	if len(channels) == 0 || len(channels[0]) == 0 {
+		return nil, ErrCalibrationToneNotFound
+	}
+
+	cal := &model.RXCalibration{
+		Channels:     len(channels),
+		ToneFreq:     toneFreq,
+		SampleRate:   r.sampleRate,
+		CenterFreq:   r.centerFreq,
+		GainOffsets:  make([]float64, len(channels)),
+		PhaseOffsets: make([]float64, len(channels)),
+		SNR:          make([]float64, len(channels)),
+		MeasuredAt:   time.Now(),
+	}
+
+	tones := make([]complex128, len(channels))
+	for m, ch := range channels {
+		tone, snr := toneBin(ch, toneFreq/r.sampleRate)
+		if snr < minCalibrationSNR {
+			return nil, ErrCalibrationToneNotFound
+		}
+		tones[m] = tone
+		cal.SNR[m] = snr
+	}
+	for m, tone := range tones {
+		ratio := tone / tones[0]
+		cal.GainOffsets[m] = 20 * math.Log10(cmplx.Abs(ratio))
+		cal.PhaseOffsets[m] = cmplx.Phase(ratio) * 180 / math.Pi
+	}
+
+	logger.Info("USRP RX calibration measured",
+		zap.Int("channels", cal.Channels),
+		zap.Float64s("gain_offsets_db", cal.GainOffsets),
+		zap.Float64s("phase_offsets_deg", cal.PhaseOffsets),
+	)
+	return cal, nil
+}
+
+// SetCalibration applies the inverse of cal to every later multi-channel
+// capture; nil removes the correction.
+func (r *Receiver) SetCalibration(cal *model.RXCalibration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cal == nil {
+		r.calibration = nil
+		r.corrections = nil
+		return nil
+	}
+	if cal.Channels != r.driver.ChannelCount() ||
+		len(cal.GainOffsets) != cal.Channels || len(cal.PhaseOffsets) != cal.Channels {
+		return ErrCalibrationMismatch
+	}
+
+	corrections := make([]complex128, cal.Channels)
+	for m := range corrections {
+		corrections[m] = cmplx.Rect(math.Pow(10, -cal.GainOffsets[m]/20), -cal.PhaseOffsets[m]*math.Pi/180)
+	}
+	r.calibration = cal
+	r.corrections = corrections
+	return nil
+}
+
+// Calibration returns the correction in use, or nil.
+func (r *Receiver) Calibration() *model.RXCalibration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.calibration
+}
+
+// correct applies the channel corrections in place. Captures with a
+// different channel count than the calibration are left alone.
+func (r *Receiver) correct(channels [][]model.ChannelDataPoint) {
+	if len(r.corrections) != len(channels) {
+		return
+	}
+	for m, ch := range channels {
+		c := r.corrections[m]
+		for i := range ch {
+			x := complex(ch[i].I, ch[i].Q) * c
+			ch[i].I, ch[i].Q = real(x), imag(x)
+			ch[i].Amplitude = cmplx.Abs(x)
+			ch[i].Phase = cmplx.Phase(x)
+		}
+	}
+}
+
+// toneBin correlates samples with a tone at the normalized frequency and
+// returns its complex amplitude and the tone-to-residual power ratio in dB.
+func toneBin(samples []model.ChannelDataPoint, freq float64) (complex128, float64) {
+	var sum complex128
+	var power float64
+	step := -2 * math.Pi * freq
+	for n, p := range samples {
+		x := complex(p.I, p.Q)
+		sum += x * cmplx.Rect(1, step*float64(n))
+		power += p.I*p.I + p.Q*p.Q
+	}
+	N := float64(len(samples))
+	tone := sum / complex(N, 0)
+	tonePower := real(tone)*real(tone) + imag(tone)*imag(tone)
+	residual := power/N - tonePower
+	if residual <= 0 {
+		return tone, math.Inf(1)
+	}
+	return tone, 10 * math.Log10(tonePower/residual)
+}
+
+var (
+	ErrInvalidCalibrationTone  = &ReceiverError{Message: "calibration tone must be non-zero and within half the sample rate"}
+	ErrCalibrationToneNotFound = &ReceiverError{Message: "calibration tone not detected on every channel"}
+	ErrCalibrationMismatch     = &ReceiverError{Message: "calibration does not match the receiver channel count"}
+)
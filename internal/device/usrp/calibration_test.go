@@ -0,0 +1,65 @@
+package usrp
+
+import (
+	"context"
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"isac-cran-system/internal/model"
+)
+
+func TestReceiver_Calibration(t *testing.T) {
+	sim := NewSimulator(1e6, 2.4e9)
+	sim.SetChannelCount(4)
+	gains := []float64{0, 1.5, -2, 0.5}
+	phases := []float64{0, 30, -75, 120}
+	sim.SetImpairments(model.RFImpairments{ChannelGainOffsets: gains, ChannelPhaseOffsets: phases})
+	rx := NewReceiver(sim, 1e6, 2.4e9)
+	if err := rx.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer rx.Disconnect()
+
+	if _, err := rx.MeasureCalibration(context.Background(), 6e5, 0, DefaultCalibrationDuration); err != ErrInvalidCalibrationTone {
+		t.Fatalf("MeasureCalibration() above Nyquist error = %v, want %v", err, ErrInvalidCalibrationTone)
+	}
+
+	cal, err := rx.MeasureCalibration(context.Background(), DefaultCalibrationToneFreq, 0, DefaultCalibrationDuration)
+	if err != nil {
+		t.Fatalf("MeasureCalibration() error = %v", err)
+	}
+	for m := range gains {
+		if math.Abs(cal.GainOffsets[m]-gains[m]) > 0.1 || math.Abs(cal.PhaseOffsets[m]-phases[m]) > 1 {
+			t.Errorf("channel %d offsets = %.2f dB %.1f°, want %.2f dB %.1f°",
+				m, cal.GainOffsets[m], cal.PhaseOffsets[m], gains[m], phases[m])
+		}
+	}
+
+	if err := rx.SetCalibration(&model.RXCalibration{Channels: 2, GainOffsets: []float64{0, 0}, PhaseOffsets: []float64{0, 0}}); err != ErrCalibrationMismatch {
+		t.Fatalf("SetCalibration() with wrong channel count error = %v, want %v", err, ErrCalibrationMismatch)
+	}
+	if err := rx.SetCalibration(cal); err != nil {
+		t.Fatalf("SetCalibration() error = %v", err)
+	}
+
+	sim.SetCalibrationTone(DefaultCalibrationToneFreq, DefaultCalibrationAmplitude)
+	channels, err := rx.CollectMultiChannel(context.Background(), DefaultCalibrationDuration)
+	if err != nil {
+		t.Fatalf("CollectMultiChannel() error = %v", err)
+	}
+	ref, _ := toneBin(channels[0], DefaultCalibrationToneFreq/1e6)
+	for m := 1; m < len(channels); m++ {
+		tone, _ := toneBin(channels[m], DefaultCalibrationToneFreq/1e6)
+		if ratio := tone / ref; cmplx.Abs(ratio-1) > 0.03 {
+			t.Errorf("corrected channel %d relative response = %v, want 1", m, ratio)
+		}
+	}
+
+	if err := rx.SwapDriver(context.Background(), NewSimulator(1e6, 2.4e9)); err != nil {
+		t.Fatalf("SwapDriver() error = %v", err)
+	}
+	if rx.Calibration() != nil {
+		t.Error("calibration kept after SwapDriver")
+	}
+}
@@ -1,10 +1,13 @@
 package usrp
 
+import "isac-cran-system/internal/model"
+
 type DriverType string
 
 const (
 	DriverTypeSimulator DriverType = "simulator"
 	DriverTypeHardware  DriverType = "hardware"
+	DriverTypeZMQ       DriverType = "zmq"
 )
 
 type DriverFactory struct{}
@@ -18,6 +21,8 @@ func (f *DriverFactory) Create(driverType DriverType, opts ...DriverOption) (Dri
 		SampleRate: 10e6,
 		CenterFreq: 2.4e9,
 		Gain:       30.0,
+		TxGain:     10.0,
+		Channels:   1,
 	}
 
 	for _, opt := range opts {
@@ -26,9 +31,34 @@ func (f *DriverFactory) Create(driverType DriverType, opts ...DriverOption) (Dri
 
 	switch driverType {
 	case DriverTypeSimulator:
-		return NewSimulator(config.SampleRate, config.CenterFreq), nil
+		sim := NewSimulator(config.SampleRate, config.CenterFreq)
+		if err := sim.SetGain(config.Gain); err != nil {
+			return nil, err
+		}
+		if err := sim.SetClockSource(config.ClockSource); err != nil {
+			return nil, err
+		}
+		sim.SetChannelCount(config.Channels)
+		sim.SetImpairments(config.Impairments)
+		sim.SetADC(config.ADC)
+		if err := sim.SetPropagation(config.Propagation, config.Surface); err != nil {
+			return nil, err
+		}
+		return sim, nil
 	case DriverTypeHardware:
-		return nil, ErrHardwareDriverNotImplemented
+		if !validClockSource(config.ClockSource) {
+			return nil, ErrUnknownClockSource
+		}
+		return newHardwareDriver(config)
+	case DriverTypeZMQ:
+		src, err := NewZMQSource(config)
+		if err != nil {
+			return nil, err
+		}
+		if err := src.SetClockSource(config.ClockSource); err != nil {
+			return nil, err
+		}
+		return src, nil
 	default:
 		return nil, ErrUnknownDriverType
 	}
@@ -38,8 +68,38 @@ type DriverConfig struct {
 	SampleRate float64
 	CenterFreq float64
 	Gain       float64
+	TxGain     float64
 	IPAddress  string
 	Port       int
+	Args       string
+	Channels   int
+	// ClockSource is one of the model.ClockSource values; empty means
+	// internal.
+	ClockSource string
+	// Impairments only apply to the simulator; real hardware brings its own.
+	Impairments model.RFImpairments
+	ADC         model.ADCConfig
+	// Propagation through Surface replaces the simulator's fixed signals.
+	Propagation model.SimulatedPropagation
+	Surface     Surface
+	// ZMQAddress and ZMQSocketType select the GNU Radio stream read by the
+	// zmq driver, e.g. "tcp://127.0.0.1:5555" and "sub".
+	ZMQAddress    string
+	ZMQSocketType string
+}
+
+// DeviceArgs returns the UHD device address string. Explicit args take
+// precedence; otherwise an IP address selects a networked device such as an
+// X310, and an empty string lets UHD pick the first device found (e.g. a
+// B210 on USB).
+func (c *DriverConfig) DeviceArgs() string {
+	if c.Args != "" {
+		return c.Args
+	}
+	if c.IPAddress != "" {
+		return "addr=" + c.IPAddress
+	}
+	return ""
 }
 
 type DriverOption func(*DriverConfig)
@@ -62,6 +122,12 @@ func WithGain(gain float64) DriverOption {
 	}
 }
 
+func WithTxGain(gain float64) DriverOption {
+	return func(c *DriverConfig) {
+		c.TxGain = gain
+	}
+}
+
 func WithIPAddress(ip string) DriverOption {
 	return func(c *DriverConfig) {
 		c.IPAddress = ip
@@ -74,11 +140,74 @@ func WithPort(port int) DriverOption {
 	}
 }
 
+func WithChannels(n int) DriverOption {
+	return func(c *DriverConfig) {
+		if n > 0 {
+			c.Channels = n
+		}
+	}
+}
+
+func WithImpairments(impairments model.RFImpairments) DriverOption {
+	return func(c *DriverConfig) {
+		c.Impairments = impairments
+	}
+}
+
+func WithADC(adc model.ADCConfig) DriverOption {
+	return func(c *DriverConfig) {
+		c.ADC = adc
+	}
+}
+
+func WithPropagation(propagation model.SimulatedPropagation, surface Surface) DriverOption {
+	return func(c *DriverConfig) {
+		c.Propagation = propagation
+		c.Surface = surface
+	}
+}
+
+func WithZMQ(address, socketType string) DriverOption {
+	return func(c *DriverConfig) {
+		c.ZMQAddress = address
+		c.ZMQSocketType = socketType
+	}
+}
+
+func WithClockSource(source string) DriverOption {
+	return func(c *DriverConfig) {
+		c.ClockSource = source
+	}
+}
+
+func WithDeviceArgs(args string) DriverOption {
+	return func(c *DriverConfig) {
+		c.Args = args
+	}
+}
+
 var (
-	ErrHardwareDriverNotImplemented = &FactoryError{Message: "hardware driver not implemented"}
-	ErrUnknownDriverType            = &FactoryError{Message: "unknown driver type"}
+	ErrHardwareDriverNotCompiled = &FactoryError{Message: "hardware driver not compiled in, rebuild with -tags uhd"}
+	ErrUnknownDriverType         = &FactoryError{Message: "unknown driver type"}
+	ErrUnknownClockSource        = &FactoryError{Message: "unknown clock source, use internal, external or gpsdo"}
 )
 
+func validClockSource(source string) bool {
+	switch source {
+	case "", model.ClockSourceInternal, model.ClockSourceExternal, model.ClockSourceGPSDO:
+		return true
+	}
+	return false
+}
+
+// clockSourceOrDefault maps the empty source to internal.
+func clockSourceOrDefault(source string) string {
+	if source == "" {
+		return model.ClockSourceInternal
+	}
+	return source
+}
+
 type FactoryError struct {
 	Message string
 }
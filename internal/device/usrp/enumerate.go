@@ -0,0 +1,94 @@
+package usrp
+
+import (
+	"strings"
+
+	"isac-cran-system/internal/model"
+)
+
+type productInfo struct {
+	channels     int
+	capabilities model.USRPCapabilities
+}
+
+// products maps UHD product names to their RX channel count and tuning
+// range; channels assume the standard daughterboard configuration.
+var products = map[string]productInfo{
+	"B200":     {1, model.USRPCapabilities{RX: true, TX: true, MaxSampleRate: 61.44e6, MinFreq: 70e6, MaxFreq: 6e9}},
+	"B200mini": {1, model.USRPCapabilities{RX: true, TX: true, MaxSampleRate: 61.44e6, MinFreq: 70e6, MaxFreq: 6e9}},
+	"B210":     {2, model.USRPCapabilities{RX: true, TX: true, MaxSampleRate: 61.44e6, MinFreq: 70e6, MaxFreq: 6e9}},
+	"E310":     {2, model.USRPCapabilities{RX: true, TX: true, MaxSampleRate: 61.44e6, MinFreq: 70e6, MaxFreq: 6e9}},
+	"X300":     {2, model.USRPCapabilities{RX: true, TX: true, MaxSampleRate: 200e6, MinFreq: 10e6, MaxFreq: 6e9}},
+	"X310":     {2, model.USRPCapabilities{RX: true, TX: true, MaxSampleRate: 200e6, MinFreq: 10e6, MaxFreq: 6e9}},
+	"N310":     {4, model.USRPCapabilities{RX: true, TX: true, MaxSampleRate: 153.6e6, MinFreq: 10e6, MaxFreq: 6e9}},
+	"N320":     {2, model.USRPCapabilities{RX: true, TX: true, MaxSampleRate: 250e6, MinFreq: 450e6, MaxFreq: 6e9}},
+}
+
+// simulatedDevices are always listed so experiments can be rehearsed on a
+// simulated radio with the same channel count as the real one.
+var simulatedDevices = []model.USRPDevice{
+	{Serial: "SIM-B210-0001", Type: "sim", Product: "B210", Name: "simulated-b210"},
+	{Serial: "SIM-X310-0001", Type: "sim", Product: "X310", Name: "simulated-x310"},
+	{Serial: "SIM-N310-0001", Type: "sim", Product: "N310", Name: "simulated-n310"},
+}
+
+// Enumerate lists simulated devices followed by any radios UHD can find.
+// Hardware discovery is skipped when the UHD driver is not compiled in.
+func Enumerate() ([]model.USRPDevice, error) {
+	devices := make([]model.USRPDevice, 0, len(simulatedDevices))
+	for _, d := range simulatedDevices {
+		d.Simulated = true
+		d.Args = "type=sim,serial=" + d.Serial
+		applyProduct(&d)
+		devices = append(devices, d)
+	}
+
+	addrs, err := findHardware("")
+	if err == ErrHardwareDriverNotCompiled {
+		return devices, nil
+	}
+	if err != nil {
+		return devices, err
+	}
+
+	for _, addr := range addrs {
+		fields := ParseDeviceAddr(addr)
+		d := model.USRPDevice{
+			Serial:  fields["serial"],
+			Type:    fields["type"],
+			Product: fields["product"],
+			Name:    fields["name"],
+			Args:    "serial=" + fields["serial"],
+		}
+		if d.Serial == "" {
+			d.Args = addr
+		}
+		applyProduct(&d)
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+func applyProduct(d *model.USRPDevice) {
+	info, ok := products[d.Product]
+	if !ok {
+		d.Channels = 1
+		d.Capabilities = model.USRPCapabilities{RX: true, TX: true}
+		return
+	}
+	d.Channels = info.channels
+	d.Capabilities = info.capabilities
+}
+
+// ParseDeviceAddr splits a UHD device address such as
+// "type=b200,serial=3123ABC,product=B210" into its key/value pairs.
+func ParseDeviceAddr(addr string) map[string]string {
+	fields := make(map[string]string)
+	for _, part := range strings.Split(addr, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok {
+			fields[key] = value
+		}
+	}
+	return fields
+}
@@ -0,0 +1,28 @@
+package usrp
+
+import "testing"
+
+func TestParseDeviceAddr(t *testing.T) {
+	fields := ParseDeviceAddr("type=b200, serial=3123ABC,product=B210,junk")
+	if fields["type"] != "b200" || fields["serial"] != "3123ABC" || fields["product"] != "B210" {
+		t.Errorf("ParseDeviceAddr() = %v", fields)
+	}
+	if _, ok := fields["junk"]; ok {
+		t.Error("field without '=' should be ignored")
+	}
+}
+
+func TestEnumerate_Simulated(t *testing.T) {
+	devices, err := Enumerate()
+	if err != nil {
+		t.Fatalf("Enumerate() error = %v", err)
+	}
+	if len(devices) < len(simulatedDevices) {
+		t.Fatalf("got %d devices, want at least %d", len(devices), len(simulatedDevices))
+	}
+	for _, d := range devices[:len(simulatedDevices)] {
+		if !d.Simulated || d.Channels != products[d.Product].channels {
+			t.Errorf("simulated device %s: simulated=%v channels=%d", d.Serial, d.Simulated, d.Channels)
+		}
+	}
+}
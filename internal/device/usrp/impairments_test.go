@@ -0,0 +1,75 @@
+package usrp
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"isac-cran-system/internal/model"
+)
+
+func TestImpairer_ApplyIQ(t *testing.T) {
+	tone := func() [][]complex128 {
+		x := make([]complex128, 64)
+		for i := range x {
+			x[i] = 1
+		}
+		return [][]complex128{x}
+	}
+
+	X := tone()
+	NewImpairer(model.RFImpairments{DCOffsetI: 0.1, DCOffsetQ: -0.2}).ApplyIQ(X, 1e6)
+	if got := X[0][10]; cmplx.Abs(got-complex(1.1, -0.2)) > 1e-12 {
+		t.Errorf("dc offset sample = %v, want (1.1-0.2i)", got)
+	}
+
+	X = tone()
+	NewImpairer(model.RFImpairments{FrequencyOffset: 1e4}).ApplyIQ(X, 1e6)
+	want := 2 * math.Pi * 1e4 / 1e6 * 5
+	if got := cmplx.Phase(X[0][5]); math.Abs(got-want) > 1e-9 {
+		t.Errorf("frequency offset phase = %v, want %v", got, want)
+	}
+
+	X = [][]complex128{{1i}}
+	NewImpairer(model.RFImpairments{IQGainImbalance: 6}).ApplyIQ(X, 1e6)
+	if got, g := imag(X[0][0]), math.Pow(10, 6.0/20); math.Abs(got-g) > 1e-12 {
+		t.Errorf("gain imbalance Q = %v, want %v", got, g)
+	}
+
+	X = tone()
+	NewImpairer(model.RFImpairments{PhaseNoisePSD: -80, PhaseNoiseOffset: 1e5}).ApplyIQ(X, 1e6)
+	for i, x := range X[0] {
+		if math.Abs(cmplx.Abs(x)-1) > 1e-9 {
+			t.Fatalf("phase noise changed magnitude of sample %d: %v", i, x)
+		}
+	}
+	if cmplx.Phase(X[0][63]) == 0 {
+		t.Error("phase noise left phase unchanged")
+	}
+}
+
+func TestADC_Convert(t *testing.T) {
+	adc := NewADC(model.ADCConfig{Bits: 4, FullScale: 1})
+	channels := [][]model.ChannelDataPoint{{
+		{I: 0.3, Q: -0.3},
+		{I: 1.5, Q: 0},
+		{I: 0, Q: -2},
+	}}
+	adc.Convert(channels)
+
+	ch := channels[0]
+	if ch[0].I != 0.25 || ch[0].Q != -0.25 || ch[0].Clipped {
+		t.Errorf("in-range sample = %+v, want quantized to 1/8 steps without clipping", ch[0])
+	}
+	if ch[1].I != 0.875 || !ch[1].Clipped {
+		t.Errorf("positive overload = %+v, want I=0.875 clipped", ch[1])
+	}
+	if ch[2].Q != -1 || !ch[2].Clipped {
+		t.Errorf("negative overload = %+v, want Q=-1 clipped", ch[2])
+	}
+
+	stats := CaptureStats(channels)
+	if stats.Samples != 3 || stats.ClippedSamples != 2 {
+		t.Errorf("stats = %+v, want 2 of 3 clipped", stats)
+	}
+}
@@ -0,0 +1,94 @@
+package usrp
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+
+	"isac-cran-system/internal/model"
+)
+
+// Surface is a reflecting surface in the simulated scene, such as a
+// simulated IRS panel.
+type Surface interface {
+	Reflection() []complex128
+}
+
+// scene is the channel of a simulated propagation. The element channels are
+// drawn once, so the received signal only changes with the surface.
+type scene struct {
+	cfg     model.SimulatedPropagation
+	surface Surface
+	// incident reaches each element from the transmitter, departing leaves
+	// it towards the receiver
+	incident  []complex128
+	departing []complex128
+	direct    complex128
+	// elementGain is the amplitude of one element's path
+	elementGain float64
+}
+
+func newScene(cfg model.SimulatedPropagation, surface Surface) *scene {
+	cfg = cfg.WithDefaults()
+	elements := len(surface.Reflection())
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	return &scene{
+		cfg:         cfg,
+		surface:     surface,
+		incident:    elementChannels(elements, cfg.IncidenceAngle, cfg.Scattering, rng),
+		departing:   elementChannels(elements, cfg.ReflectionAngle, cfg.Scattering, rng),
+		direct:      complex(math.Pow(10, -cfg.DirectPathLoss/20), 0),
+		elementGain: math.Pow(10, -cfg.ElementPathLoss/20),
+	}
+}
+
+// elementChannels are the unit-power channels between a far-field node at
+// angle and each element of a half-wavelength ULA, with the given share of
+// power scattered.
+func elementChannels(elements int, angle, scattering float64, rng *rand.Rand) []complex128 {
+	los := math.Sqrt(1 - scattering)
+	nlos := math.Sqrt(scattering / 2)
+	channels := make([]complex128, elements)
+	for n := range channels {
+		channels[n] = cmplx.Rect(los, math.Pi*float64(n)*math.Sin(angle)) +
+			complex(nlos*rng.NormFloat64(), nlos*rng.NormFloat64())
+	}
+	return channels
+}
+
+// gains returns the direct and reflected path gains for the surface as it is
+// now. Elements the surface does not report reflect nothing.
+func (sc *scene) gains() (direct, reflected complex128) {
+	reflection := sc.surface.Reflection()
+	for n := 0; n < len(reflection) && n < len(sc.incident); n++ {
+		reflected += sc.incident[n] * reflection[n] * sc.departing[n]
+	}
+	return sc.direct, reflected * complex(sc.elementGain, 0)
+}
+
+// synthesizeScene fills data with the source tone as the receive array sees
+// it over both paths, plus noise.
+func (s *Simulator) synthesizeScene(data [][]model.ChannelDataPoint, scale float64) {
+	direct, reflected := s.scene.gains()
+	freq := s.scene.cfg.SourceFrequency
+	if freq == 0 {
+		freq = s.sampleRate / 10
+	}
+	step := 2 * math.Pi * freq / s.sampleRate
+	for m := range data {
+		gain := direct*cmplx.Rect(1, math.Pi*float64(m)*math.Sin(s.scene.cfg.DirectAngle)) +
+			reflected*cmplx.Rect(1, math.Pi*float64(m)*math.Sin(s.scene.cfg.ArrivalAngle))
+		for i := range data[m] {
+			x := gain * cmplx.Rect(1, step*float64(i))
+			iVal := (real(x) + s.noiseLevel*(s.rand.Float64()*2-1)) * scale
+			qVal := (imag(x) + s.noiseLevel*(s.rand.Float64()*2-1)) * scale
+			data[m][i] = model.ChannelDataPoint{
+				Index:     i,
+				Amplitude: math.Sqrt(iVal*iVal + qVal*qVal),
+				Phase:     math.Atan2(qVal, iVal),
+				I:         iVal,
+				Q:         qVal,
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package usrp
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/device/irs"
+	"isac-cran-system/internal/model"
+)
+
+func TestSimulator_Propagation(t *testing.T) {
+	ctx := context.Background()
+	panel := irs.NewSimulator(32, "3.5GHz")
+	if err := panel.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	propagation := model.SimulatedPropagation{
+		Enabled:         true,
+		DirectAngle:     0.3,
+		IncidenceAngle:  -0.4,
+		ReflectionAngle: 0.5,
+		ArrivalAngle:    -0.6,
+	}
+	if _, err := NewDriverFactory().Create(DriverTypeSimulator, WithPropagation(model.SimulatedPropagation{Enabled: true, ArrivalAngle: 2}, panel)); !model.IsValidationError(err) {
+		t.Fatalf("Create() with arrival_angle 2 error = %v, want validation error", err)
+	}
+	driver, err := NewDriverFactory().Create(DriverTypeSimulator, WithSampleRate(1e6), WithChannels(2), WithPropagation(propagation, panel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := driver.(*Simulator)
+	sim.SetNoiseLevel(0)
+	if err := sim.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Disconnect()
+
+	amplitude := func() float64 {
+		t.Helper()
+		channels, err := sim.ReceiveMulti(ctx, time.Millisecond)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, point := range channels[1] {
+			if math.Abs(point.Amplitude-channels[1][0].Amplitude) > 1e-9 {
+				t.Fatal("amplitude varies within a capture of a static channel")
+			}
+		}
+		return channels[0][0].Amplitude
+	}
+
+	specular := amplitude()
+	// phases that cancel the phase progression of both element channels
+	// add every element's path coherently
+	aligned := make([]float64, 32)
+	for n := range aligned {
+		aligned[n] = -math.Pi * float64(n) * (math.Sin(propagation.IncidenceAngle) + math.Sin(propagation.ReflectionAngle))
+	}
+	if err := panel.SetPhaseShifts(ctx, aligned); err != nil {
+		t.Fatal(err)
+	}
+	focused := amplitude()
+	// 32 elements at -40 dB each give 0.32 against the 0.1 of the direct path
+	if focused < 0.32-0.1-1e-9 || focused < 2*specular {
+		t.Errorf("amplitude %.3f with aligned phases, %.3f with all phases zero", focused, specular)
+	}
+
+	if err := panel.Absorb(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if direct := amplitude(); math.Abs(direct-0.1) > 1e-9 {
+		t.Errorf("amplitude %.4f with the panel absorbing, want the direct path's 0.1", direct)
+	}
+
+	if err := sim.SetPropagation(model.SimulatedPropagation{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	channels, err := sim.ReceiveMulti(ctx, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(channels[0][0].Amplitude-0.1) < 1e-6 {
+		t.Error("disabling the propagation kept the scene")
+	}
+}
@@ -15,8 +15,23 @@ type Driver interface {
 	Connect(ctx context.Context) error
 	Disconnect() error
 	Receive(ctx context.Context, duration time.Duration) ([]model.ChannelDataPoint, error)
+	// ReceiveMulti captures the same time window on every RX channel and
+	// returns one stream per channel, sample-aligned across channels.
+	ReceiveMulti(ctx context.Context, duration time.Duration) ([][]model.ChannelDataPoint, error)
+	ChannelCount() int
+	// Transmit sends one burst of baseband samples, normalized to full scale
+	// 1.0, on the first TX channel and returns once it has gone out.
+	Transmit(ctx context.Context, samples []complex128) error
 	SetFrequency(freq float64) error
 	SetSampleRate(rate float64) error
+	// SetGain sets the RX gain in dB on every channel; GetGain reports the
+	// gain actually applied, which hardware may have coerced.
+	SetGain(gain float64) error
+	GetGain() float64
+	// SetClockSource selects internal, external (10 MHz + PPS) or gpsdo
+	// reference; SyncStatus reports whether the radio is locked to it.
+	SetClockSource(source string) error
+	SyncStatus() (*model.SyncStatus, error)
 	IsConnected() bool
 }
 
@@ -28,6 +43,9 @@ type Receiver struct {
 	mu         sync.RWMutex
 	dataBuffer []model.ChannelDataPoint
 	bufferSize int
+
+	calibration *model.RXCalibration
+	corrections []complex128
 }
 
 func NewReceiver(driver Driver, sampleRate, centerFreq float64) *Receiver {
@@ -59,6 +77,12 @@ func (r *Receiver) Disconnect() error {
 	return r.driver.Disconnect()
 }
 
+func (r *Receiver) IsConnected() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.connected && r.driver.IsConnected()
+}
+
 func (r *Receiver) CollectData(ctx context.Context, duration time.Duration) ([]model.ChannelDataPoint, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -75,6 +99,58 @@ func (r *Receiver) CollectData(ctx context.Context, duration time.Duration) ([]m
 	return data, nil
 }
 
+func (r *Receiver) CollectMultiChannel(ctx context.Context, duration time.Duration) ([][]model.ChannelDataPoint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.connected {
+		return nil, ErrReceiverNotConnected
+	}
+
+	channels, err := r.driver.ReceiveMulti(ctx, duration)
+	if err != nil {
+		return nil, err
+	}
+	r.correct(channels)
+	return channels, nil
+}
+
+func (r *Receiver) ChannelCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.driver.ChannelCount()
+}
+
+// SwapDriver connects a new driver, tunes it to the current sample rate and
+// center frequency, and only then releases the old one, so a failed swap
+// leaves the receiver on its previous device. The channel calibration
+// belongs to the old device and is dropped.
+func (r *Receiver) SwapDriver(ctx context.Context, driver Driver) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := driver.Connect(ctx); err != nil {
+		return err
+	}
+	if err := driver.SetSampleRate(r.sampleRate); err != nil {
+		driver.Disconnect()
+		return err
+	}
+	if err := driver.SetFrequency(r.centerFreq); err != nil {
+		driver.Disconnect()
+		return err
+	}
+
+	if err := r.driver.Disconnect(); err != nil {
+		logger.Warn("Failed to disconnect previous USRP driver", zap.Error(err))
+	}
+	r.driver = driver
+	r.connected = true
+	r.calibration = nil
+	r.corrections = nil
+	return nil
+}
+
 func (r *Receiver) SetCenterFrequency(freq float64) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -109,6 +185,38 @@ func (r *Receiver) SetSampleRate(rate float64) error {
 	return nil
 }
 
+func (r *Receiver) SetGain(gain float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.connected {
+		return ErrReceiverNotConnected
+	}
+
+	if err := r.driver.SetGain(gain); err != nil {
+		return err
+	}
+
+	logger.Info("USRP gain updated", zap.Float64("gain_db", r.driver.GetGain()))
+	return nil
+}
+
+func (r *Receiver) GetGain() float64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.driver.GetGain()
+}
+
+func (r *Receiver) SyncStatus() (*model.SyncStatus, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.connected {
+		return nil, ErrReceiverNotConnected
+	}
+	return r.driver.SyncStatus()
+}
+
 func (r *Receiver) GetConfig() (sampleRate, centerFreq float64) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -13,6 +13,16 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// simulatorReferenceGain is the gain at which synthesized signals have
+	// their nominal amplitude; other gains scale them in dB.
+	simulatorReferenceGain = 30.0
+	simulatorMaxGain       = 76.0
+	// simulatorClockDrift is the fractional frequency error of the free
+	// running reference, typical of a B210 TCXO.
+	simulatorClockDrift = 2e-6
+)
+
 type Simulator struct {
 	sampleRate float64
 	centerFreq float64
@@ -21,15 +31,31 @@ type Simulator struct {
 	mu         sync.RWMutex
 	rand       *rand.Rand
 	noiseLevel float64
+	channels   int
+	lastBurst  []complex128
+	impairer   *Impairer
+	adc        *ADC
+	// scene replaces the fixed signals with a propagation through a
+	// reflecting surface
+	scene *scene
+
+	// calibration tone fed equally to every channel, replacing the scene
+	toneFreq      float64
+	toneAmplitude float64
+
+	clockSource string
+	connectedAt time.Time
 }
 
 func NewSimulator(sampleRate, centerFreq float64) *Simulator {
 	return &Simulator{
-		sampleRate: sampleRate,
-		centerFreq: centerFreq,
-		gain:       30.0,
-		noiseLevel: 0.1,
-		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		sampleRate:  sampleRate,
+		centerFreq:  centerFreq,
+		gain:        simulatorReferenceGain,
+		noiseLevel:  0.1,
+		clockSource: model.ClockSourceInternal,
+		channels:    1,
+		rand:        rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
 
@@ -46,6 +72,7 @@ func (s *Simulator) Connect(ctx context.Context) error {
 	time.Sleep(100 * time.Millisecond)
 
 	s.connected = true
+	s.connectedAt = time.Now()
 	logger.Info("USRP simulator connected",
 		zap.Float64("sample_rate_mhz", s.sampleRate/1e6),
 		zap.Float64("center_freq_ghz", s.centerFreq/1e9),
@@ -70,52 +97,226 @@ func (s *Simulator) Receive(ctx context.Context, duration time.Duration) ([]mode
 		return nil, ErrSimulatorNotConnected
 	}
 
+	numSamples := s.sampleCount(duration)
+	channels := s.synthesize(numSamples, 1)
+	s.frontEnd(channels)
+	data := channels[0]
+
+	logger.Debug("USRP data received",
+		zap.Int("samples", numSamples),
+		zap.Duration("duration", duration),
+	)
+
+	return data, nil
+}
+
+// ReceiveMulti simulates a half-wavelength uniform linear array: each signal
+// arrives from a fixed angle, so channel m sees it phase-shifted by
+// pi*m*sin(angle).
+func (s *Simulator) ReceiveMulti(ctx context.Context, duration time.Duration) ([][]model.ChannelDataPoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.connected {
+		return nil, ErrSimulatorNotConnected
+	}
+
+	numSamples := s.sampleCount(duration)
+	data := s.synthesize(numSamples, s.channels)
+	s.frontEnd(data)
+
+	logger.Debug("USRP multi-channel data received",
+		zap.Int("channels", s.channels),
+		zap.Int("samples", numSamples),
+		zap.Duration("duration", duration),
+	)
+
+	return data, nil
+}
+
+// SetImpairments enables RF front-end impairments on every capture; a zero
+// value disables them.
+func (s *Simulator) SetImpairments(cfg model.RFImpairments) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !cfg.Enabled() {
+		s.impairer = nil
+		return
+	}
+	s.impairer = NewImpairer(cfg)
+}
+
+// SetPropagation makes every later capture receive the source of cfg over
+// the direct path and by way of surface, so the samples follow the
+// surface's reflection. A disabled configuration or nil surface restores
+// the fixed signals.
+func (s *Simulator) SetPropagation(cfg model.SimulatedPropagation, surface Surface) error {
+	if !cfg.Enabled || surface == nil {
+		s.mu.Lock()
+		s.scene = nil
+		s.mu.Unlock()
+		return nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	sc := newScene(cfg, surface)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scene = sc
+	return nil
+}
+
+// SetADC enables quantization and clipping; zero bits models an ideal
+// converter.
+func (s *Simulator) SetADC(cfg model.ADCConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cfg.Bits <= 0 {
+		s.adc = nil
+		return
+	}
+	s.adc = NewADC(cfg)
+}
+
+// frontEnd applies analog impairments and then the ADC, in signal order.
+func (s *Simulator) frontEnd(channels [][]model.ChannelDataPoint) {
+	if s.impairer != nil {
+		s.impairer.Apply(channels, s.sampleRate)
+	}
+	if s.adc != nil {
+		s.adc.Convert(channels)
+	}
+}
+
+func (s *Simulator) sampleCount(duration time.Duration) int {
 	numSamples := int(float64(duration.Seconds()) * s.sampleRate)
 	if numSamples > 100000 {
 		numSamples = 100000
 	}
+	return numSamples
+}
 
-	data := make([]model.ChannelDataPoint, numSamples)
+func (s *Simulator) synthesize(numSamples, channels int) [][]model.ChannelDataPoint {
+	data := make([][]model.ChannelDataPoint, channels)
+	for m := range data {
+		data[m] = make([]model.ChannelDataPoint, numSamples)
+	}
 
+	scale := math.Pow(10, (s.gain-simulatorReferenceGain)/20)
 	numSignals := 3
 	signalFreqs := []float64{0.1, 0.3, 0.5}
 	signalAmps := []float64{1.0, 0.7, 0.5}
 	signalPhases := []float64{0, math.Pi / 4, math.Pi / 2}
+	signalAngles := []float64{-math.Pi / 6, math.Pi / 18, math.Pi / 4}
+
+	if s.toneAmplitude > 0 {
+		s.synthesizeTone(data, scale)
+		return data
+	}
+	if s.scene != nil {
+		s.synthesizeScene(data, scale)
+		return data
+	}
 
 	for i := 0; i < numSamples; i++ {
 		t := float64(i) / s.sampleRate
+		fading := 0.5 + 0.5*math.Cos(2*math.Pi*0.01*t)
+
+		for m := 0; m < channels; m++ {
+			iVal := 0.0
+			qVal := 0.0
+
+			for j := 0; j < numSignals; j++ {
+				phase := 2*math.Pi*signalFreqs[j]*t*s.sampleRate + signalPhases[j] +
+					math.Pi*float64(m)*math.Sin(signalAngles[j])
+				iVal += signalAmps[j] * fading * math.Cos(phase)
+				qVal += signalAmps[j] * fading * math.Sin(phase)
+			}
+
+			iVal += s.noiseLevel * (s.rand.Float64()*2 - 1)
+			qVal += s.noiseLevel * (s.rand.Float64()*2 - 1)
+			iVal *= scale
+			qVal *= scale
+
+			data[m][i] = model.ChannelDataPoint{
+				Index:     i,
+				Amplitude: math.Sqrt(iVal*iVal + qVal*qVal),
+				Phase:     math.Atan2(qVal, iVal),
+				I:         iVal,
+				Q:         qVal,
+			}
+		}
+	}
 
-		iVal := 0.0
-		qVal := 0.0
+	return data
+}
 
-		for j := 0; j < numSignals; j++ {
-			phase := 2*math.Pi*signalFreqs[j]*t*s.sampleRate + signalPhases[j]
-			fading := 0.5 + 0.5*math.Cos(2*math.Pi*0.01*t)
-			iVal += signalAmps[j] * fading * math.Cos(phase)
-			qVal += signalAmps[j] * fading * math.Sin(phase)
+func (s *Simulator) synthesizeTone(data [][]model.ChannelDataPoint, scale float64) {
+	step := 2 * math.Pi * s.toneFreq / s.sampleRate
+	for m := range data {
+		for i := range data[m] {
+			iVal := s.toneAmplitude*math.Cos(step*float64(i)) + s.noiseLevel*(s.rand.Float64()*2-1)
+			qVal := s.toneAmplitude*math.Sin(step*float64(i)) + s.noiseLevel*(s.rand.Float64()*2-1)
+			iVal *= scale
+			qVal *= scale
+			data[m][i] = model.ChannelDataPoint{
+				Index:     i,
+				Amplitude: math.Sqrt(iVal*iVal + qVal*qVal),
+				Phase:     math.Atan2(qVal, iVal),
+				I:         iVal,
+				Q:         qVal,
+			}
 		}
+	}
+}
 
-		iVal += s.noiseLevel * (s.rand.Float64()*2 - 1)
-		qVal += s.noiseLevel * (s.rand.Float64()*2 - 1)
-
-		amplitude := math.Sqrt(iVal*iVal + qVal*qVal)
-		phase := math.Atan2(qVal, iVal)
+// Transmit holds the burst for its air time at the current sample rate and
+// keeps a copy for LastBurst.
+func (s *Simulator) Transmit(ctx context.Context, samples []complex128) error {
+	s.mu.Lock()
+	if !s.connected {
+		s.mu.Unlock()
+		return ErrSimulatorNotConnected
+	}
+	s.lastBurst = append(s.lastBurst[:0], samples...)
+	airTime := time.Duration(float64(len(samples)) / s.sampleRate * float64(time.Second))
+	s.mu.Unlock()
 
-		data[i] = model.ChannelDataPoint{
-			Index:     i,
-			Amplitude: amplitude,
-			Phase:     phase,
-			I:         iVal,
-			Q:         qVal,
-		}
+	timer := time.NewTimer(airTime)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
 	}
 
-	logger.Debug("USRP data received",
-		zap.Int("samples", numSamples),
-		zap.Duration("duration", duration),
+	logger.Debug("USRP simulator burst transmitted",
+		zap.Int("samples", len(samples)),
+		zap.Duration("air_time", airTime),
 	)
+	return nil
+}
 
-	return data, nil
+// LastBurst returns a copy of the most recently transmitted samples.
+func (s *Simulator) LastBurst() []complex128 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]complex128(nil), s.lastBurst...)
+}
+
+func (s *Simulator) ChannelCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.channels
+}
+
+func (s *Simulator) SetChannelCount(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n > 0 {
+		s.channels = n
+	}
 }
 
 func (s *Simulator) SetFrequency(freq float64) error {
@@ -154,13 +355,72 @@ func (s *Simulator) SetNoiseLevel(level float64) {
 	s.noiseLevel = level
 }
 
-func (s *Simulator) SetGain(gain float64) {
+// SetCalibrationTone replaces the simulated scene with a tone of the given
+// amplitude at freq Hz from the carrier, identical on every channel, so
+// channel impairments are all that separates them.
+func (s *Simulator) SetCalibrationTone(freq, amplitude float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.toneFreq = freq
+	s.toneAmplitude = amplitude
+	return nil
+}
+
+// SetGain scales every later capture by the difference from the reference
+// gain, within the B210's 0-76 dB range.
+func (s *Simulator) SetGain(gain float64) error {
+	if gain < 0 || gain > simulatorMaxGain {
+		return ErrGainOutOfRange
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.gain = gain
+	return nil
+}
+
+func (s *Simulator) GetGain() float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.gain
+}
+
+func (s *Simulator) SetClockSource(source string) error {
+	if !validClockSource(source) {
+		return ErrUnknownClockSource
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clockSource = clockSourceOrDefault(source)
+	return nil
+}
+
+// SyncStatus always reports lock. A disciplined reference keeps device time
+// aligned to the PPS edge; the internal one drifts since connect.
+func (s *Simulator) SyncStatus() (*model.SyncStatus, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.connected {
+		return nil, ErrSimulatorNotConnected
+	}
+
+	now := time.Now()
+	status := &model.SyncStatus{
+		ClockSource: s.clockSource,
+		RefLocked:   true,
+		GPSLocked:   s.clockSource == model.ClockSourceGPSDO,
+		CheckedAt:   now,
+	}
+	if s.clockSource == model.ClockSourceInternal {
+		status.TimeOffset = now.Sub(s.connectedAt).Seconds() * simulatorClockDrift
+	}
+	return status, nil
 }
 
-var ErrSimulatorNotConnected = &SimulatorError{Message: "simulator not connected"}
+var (
+	ErrSimulatorNotConnected = &SimulatorError{Message: "simulator not connected"}
+	ErrGainOutOfRange        = &SimulatorError{Message: "gain out of range"}
+)
 
 type SimulatorError struct {
 	Message string
@@ -0,0 +1,100 @@
+package usrp
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/model"
+)
+
+func TestSimulator_SyncStatus(t *testing.T) {
+	if _, err := NewDriverFactory().Create(DriverTypeSimulator, WithClockSource("rubidium")); err != ErrUnknownClockSource {
+		t.Fatalf("Create() with unknown clock source error = %v, want %v", err, ErrUnknownClockSource)
+	}
+
+	driver, err := NewDriverFactory().Create(DriverTypeSimulator, WithClockSource(model.ClockSourceGPSDO))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := driver.SyncStatus(); err != ErrSimulatorNotConnected {
+		t.Fatalf("SyncStatus() before Connect error = %v, want %v", err, ErrSimulatorNotConnected)
+	}
+	if err := driver.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer driver.Disconnect()
+
+	status, err := driver.SyncStatus()
+	if err != nil {
+		t.Fatalf("SyncStatus() error = %v", err)
+	}
+	if status.ClockSource != model.ClockSourceGPSDO || !status.RefLocked || !status.GPSLocked || status.TimeOffset != 0 {
+		t.Errorf("gpsdo status = %+v, want locked with zero offset", status)
+	}
+
+	if err := driver.SetClockSource(""); err != nil {
+		t.Fatalf("SetClockSource(\"\") error = %v", err)
+	}
+	status, _ = driver.SyncStatus()
+	if status.ClockSource != model.ClockSourceInternal || status.GPSLocked || status.TimeOffset <= 0 {
+		t.Errorf("internal status = %+v, want drifting offset without gps lock", status)
+	}
+}
+
+func TestSimulator_ReceiveMulti(t *testing.T) {
+	sim := NewSimulator(1e6, 3.5e9)
+	sim.SetChannelCount(4)
+	sim.SetNoiseLevel(0)
+	if _, err := sim.ReceiveMulti(context.Background(), time.Millisecond); err != ErrSimulatorNotConnected {
+		t.Fatalf("ReceiveMulti() before Connect error = %v, want %v", err, ErrSimulatorNotConnected)
+	}
+	if err := sim.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Disconnect()
+
+	channels, err := sim.ReceiveMulti(context.Background(), 2*time.Millisecond)
+	if err != nil {
+		t.Fatalf("ReceiveMulti() error = %v", err)
+	}
+	if len(channels) != 4 {
+		t.Fatalf("got %d channels, want 4", len(channels))
+	}
+	for m, samples := range channels {
+		if len(samples) != 2000 {
+			t.Fatalf("channel %d has %d samples, want 2000", m, len(samples))
+		}
+		if samples[10].Index != 10 {
+			t.Errorf("channel %d sample 10 has index %d", m, samples[10].Index)
+		}
+	}
+
+	// Each signal reaches channel m phase-shifted by pi*m*sin(angle), so the
+	// channels of a noiseless capture differ but keep the same power.
+	var p0, p3 float64
+	differ := false
+	for i := range channels[0] {
+		a, b := channels[0][i], channels[3][i]
+		p0 += a.I*a.I + a.Q*a.Q
+		p3 += b.I*b.I + b.Q*b.Q
+		if math.Abs(a.I-b.I) > 1e-9 {
+			differ = true
+		}
+	}
+	if !differ {
+		t.Error("channels 0 and 3 are identical, want an array response")
+	}
+	if math.Abs(p0-p3)/p0 > 0.5 {
+		t.Errorf("channel powers %g and %g differ too much", p0, p3)
+	}
+
+	channels, err = sim.ReceiveMulti(context.Background(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(channels[0]) != 100000 {
+		t.Errorf("1 s capture has %d samples, want the 100000 cap", len(channels[0]))
+	}
+}
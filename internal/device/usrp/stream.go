@@ -0,0 +1,244 @@
+package usrp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// Backpressure decides what the capture loop does when the callback falls
+// behind and the block queue is full.
+type Backpressure int
+
+const (
+	// BackpressureDrop discards the newly captured block and counts it, so
+	// capture keeps pace with the radio. This is the default.
+	BackpressureDrop Backpressure = iota
+	// BackpressureBlock stalls capture until the callback catches up; blocks
+	// are never dropped but the radio may overflow meanwhile.
+	BackpressureBlock
+)
+
+const defaultStreamQueueDepth = 8
+
+// StreamBlock is one block of samples per channel, sample-aligned across
+// channels. Dropped is the number of blocks discarded since the previous
+// delivered block.
+type StreamBlock struct {
+	Sequence  uint64
+	Timestamp time.Time
+	Channels  [][]model.ChannelDataPoint
+	Dropped   int
+}
+
+// StreamCallback consumes blocks in order on a single goroutine. Returning an
+// error stops the stream.
+type StreamCallback func(block *StreamBlock) error
+
+type StreamOption func(*streamConfig)
+
+type streamConfig struct {
+	queueDepth   int
+	backpressure Backpressure
+}
+
+func WithQueueDepth(n int) StreamOption {
+	return func(c *streamConfig) {
+		if n > 0 {
+			c.queueDepth = n
+		}
+	}
+}
+
+func WithBackpressure(b Backpressure) StreamOption {
+	return func(c *streamConfig) {
+		c.backpressure = b
+	}
+}
+
+type StreamStats struct {
+	Captured  uint64 `json:"captured"`
+	Delivered uint64 `json:"delivered"`
+	Dropped   uint64 `json:"dropped"`
+}
+
+// Stream is a running continuous capture started by Receiver.StartStreaming.
+type Stream struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu    sync.Mutex
+	err   error
+	stats StreamStats
+}
+
+// StartStreaming captures blocks of blockSize samples per channel until ctx
+// is cancelled, Stop is called, the receiver fails or callback returns an
+// error. Capture and delivery run on separate goroutines joined by a bounded
+// queue, so a slow callback is absorbed by the queue and then handled
+// according to the backpressure option. Capture is paced to real time so
+// drivers that synthesize samples do not outrun the clock.
+func (r *Receiver) StartStreaming(ctx context.Context, blockSize int, callback StreamCallback, opts ...StreamOption) (*Stream, error) {
+	if blockSize <= 0 {
+		return nil, ErrInvalidBlockSize
+	}
+	if !r.IsConnected() {
+		return nil, ErrReceiverNotConnected
+	}
+
+	cfg := streamConfig{queueDepth: defaultStreamQueueDepth}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Stream{cancel: cancel, done: make(chan struct{})}
+	queue := make(chan *StreamBlock, cfg.queueDepth)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer close(queue)
+		s.capture(ctx, r, blockSize, cfg.backpressure, queue)
+	}()
+	go func() {
+		defer wg.Done()
+		s.deliver(queue, callback)
+	}()
+	go func() {
+		wg.Wait()
+		cancel()
+		close(s.done)
+		stats := s.Stats()
+		logger.Info("USRP stream stopped",
+			zap.Uint64("captured", stats.Captured),
+			zap.Uint64("delivered", stats.Delivered),
+			zap.Uint64("dropped", stats.Dropped),
+			zap.Error(s.Err()),
+		)
+	}()
+
+	logger.Info("USRP stream started",
+		zap.Int("block_size", blockSize),
+		zap.Int("queue_depth", cfg.queueDepth),
+	)
+	return s, nil
+}
+
+func (s *Stream) capture(ctx context.Context, r *Receiver, blockSize int, backpressure Backpressure, queue chan<- *StreamBlock) {
+	var seq uint64
+	dropped := 0
+	next := time.Now()
+
+	for ctx.Err() == nil {
+		sampleRate, _ := r.GetConfig()
+		blockDuration := time.Duration(float64(blockSize) / sampleRate * float64(time.Second))
+
+		started := time.Now()
+		channels, err := r.CollectMultiChannel(ctx, blockDuration)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.fail(err)
+			}
+			return
+		}
+		for ch := range channels {
+			if len(channels[ch]) > blockSize {
+				channels[ch] = channels[ch][:blockSize]
+			}
+		}
+
+		block := &StreamBlock{Sequence: seq, Timestamp: started, Channels: channels, Dropped: dropped}
+		seq++
+		s.count(func(st *StreamStats) { st.Captured++ })
+
+		if backpressure == BackpressureBlock {
+			select {
+			case queue <- block:
+				dropped = 0
+			case <-ctx.Done():
+				return
+			}
+		} else {
+			select {
+			case queue <- block:
+				dropped = 0
+			default:
+				dropped++
+				s.count(func(st *StreamStats) { st.Dropped++ })
+			}
+		}
+
+		next = next.Add(blockDuration)
+		if wait := time.Until(next); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+		} else {
+			// fell behind real time; do not try to catch up with a burst
+			next = time.Now()
+		}
+	}
+}
+
+func (s *Stream) deliver(queue <-chan *StreamBlock, callback StreamCallback) {
+	for block := range queue {
+		if s.Err() != nil {
+			continue
+		}
+		if err := callback(block); err != nil {
+			s.fail(err)
+			s.cancel()
+			continue
+		}
+		s.count(func(st *StreamStats) { st.Delivered++ })
+	}
+}
+
+func (s *Stream) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *Stream) count(fn func(*StreamStats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.stats)
+}
+
+// Stop ends the stream and waits for the callback to return.
+func (s *Stream) Stop() {
+	s.cancel()
+	<-s.done
+}
+
+// Done is closed once capture and delivery have both finished.
+func (s *Stream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err reports why the stream ended early; it is nil after a normal Stop.
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *Stream) Stats() StreamStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+var ErrInvalidBlockSize = &ReceiverError{Message: "stream block size must be positive"}
@@ -0,0 +1,84 @@
+package usrp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newStreamingReceiver(t *testing.T) *Receiver {
+	sim := NewSimulator(1e4, 2.4e9)
+	sim.SetChannelCount(2)
+	rx := NewReceiver(sim, 1e4, 2.4e9)
+	if err := rx.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	return rx
+}
+
+func TestReceiver_StartStreaming(t *testing.T) {
+	rx := newStreamingReceiver(t)
+	defer rx.Disconnect()
+
+	if _, err := rx.StartStreaming(context.Background(), 0, nil); err != ErrInvalidBlockSize {
+		t.Fatalf("StartStreaming(0) error = %v, want %v", err, ErrInvalidBlockSize)
+	}
+
+	stop := errors.New("enough")
+	var seqs []uint64
+	stream, err := rx.StartStreaming(context.Background(), 50, func(b *StreamBlock) error {
+		if len(b.Channels) != 2 || len(b.Channels[0]) != 50 {
+			t.Errorf("block %d has %d channels of %d samples", b.Sequence, len(b.Channels), len(b.Channels[0]))
+		}
+		seqs = append(seqs, b.Sequence)
+		if len(seqs) == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StartStreaming() error = %v", err)
+	}
+
+	select {
+	case <-stream.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not stop after callback error")
+	}
+	if stream.Err() != stop {
+		t.Errorf("Err() = %v, want %v", stream.Err(), stop)
+	}
+	for i, seq := range seqs {
+		if seq != uint64(i) {
+			t.Errorf("block %d has sequence %d", i, seq)
+		}
+	}
+}
+
+func TestReceiver_StreamingDropsWhenCallbackIsSlow(t *testing.T) {
+	rx := newStreamingReceiver(t)
+	defer rx.Disconnect()
+
+	// 10ms blocks against a 50ms callback overflow a one-block queue
+	stream, err := rx.StartStreaming(context.Background(), 100, func(b *StreamBlock) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}, WithQueueDepth(1))
+	if err != nil {
+		t.Fatalf("StartStreaming() error = %v", err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	stream.Stop()
+
+	stats := stream.Stats()
+	if stats.Dropped == 0 {
+		t.Errorf("expected dropped blocks, got %+v", stats)
+	}
+	if stats.Captured != stats.Delivered+stats.Dropped {
+		t.Errorf("captured %d != delivered %d + dropped %d", stats.Captured, stats.Delivered, stats.Dropped)
+	}
+	if stream.Err() != nil {
+		t.Errorf("Err() after Stop = %v", stream.Err())
+	}
+}
@@ -0,0 +1,85 @@
+package usrp
+
+import (
+	"context"
+	"math/cmplx"
+	"sync"
+
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// maxTransmitSamples bounds a single burst to keep memory and air time
+// predictable.
+const maxTransmitSamples = 1 << 20
+
+// fullScaleTolerance absorbs rounding in generated unit-magnitude waveforms.
+const fullScaleTolerance = 1e-9
+
+// Transmitter serializes bursts on a driver's TX chain. It shares the driver,
+// and therefore the connection, with the Receiver.
+type Transmitter struct {
+	driver Driver
+	mu     sync.Mutex
+}
+
+func NewTransmitter(driver Driver) *Transmitter {
+	return &Transmitter{driver: driver}
+}
+
+func (t *Transmitter) Transmit(ctx context.Context, samples []complex128) error {
+	if len(samples) == 0 {
+		return ErrEmptyBurst
+	}
+	if len(samples) > maxTransmitSamples {
+		return ErrBurstTooLong
+	}
+	for _, s := range samples {
+		if cmplx.Abs(s) > 1+fullScaleTolerance {
+			return ErrSampleClipped
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.driver.IsConnected() {
+		return ErrTransmitterNotConnected
+	}
+	if err := t.driver.Transmit(ctx, samples); err != nil {
+		return err
+	}
+
+	logger.Debug("USRP burst transmitted", zap.Int("samples", len(samples)))
+	return nil
+}
+
+func (t *Transmitter) IsConnected() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.driver.IsConnected()
+}
+
+// SetDriver moves the transmitter onto the driver the Receiver was rebound
+// to; it waits for any burst in flight.
+func (t *Transmitter) SetDriver(driver Driver) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.driver = driver
+}
+
+var (
+	ErrTransmitterNotConnected = &TransmitterError{Message: "transmitter not connected"}
+	ErrEmptyBurst              = &TransmitterError{Message: "burst has no samples"}
+	ErrBurstTooLong            = &TransmitterError{Message: "burst exceeds maximum length"}
+	ErrSampleClipped           = &TransmitterError{Message: "sample magnitude exceeds full scale"}
+)
+
+type TransmitterError struct {
+	Message string
+}
+
+func (e *TransmitterError) Error() string {
+	return e.Message
+}
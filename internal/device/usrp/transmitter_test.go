@@ -0,0 +1,54 @@
+package usrp
+
+import (
+	"context"
+	"math/cmplx"
+	"testing"
+)
+
+func TestGenerateWaveform(t *testing.T) {
+	for _, typ := range []string{WaveformTone, WaveformChirp, WaveformZadoffChu} {
+		samples, err := GenerateWaveform(WaveformSpec{
+			Type:       typ,
+			Length:     139,
+			Amplitude:  0.5,
+			SampleRate: 1e6,
+			ToneFreq:   1e5,
+			Bandwidth:  5e5,
+		})
+		if err != nil {
+			t.Fatalf("%s: GenerateWaveform() error = %v", typ, err)
+		}
+		for i, s := range samples {
+			if d := cmplx.Abs(s) - 0.5; d > 1e-12 || d < -1e-12 {
+				t.Fatalf("%s: sample %d magnitude = %v, want 0.5", typ, i, cmplx.Abs(s))
+			}
+		}
+	}
+
+	if _, err := GenerateWaveform(WaveformSpec{Type: WaveformZadoffChu, Length: 64, Root: 2, Amplitude: 1, SampleRate: 1e6}); err == nil {
+		t.Error("expected error for root not coprime with length")
+	}
+}
+
+func TestTransmitter_Simulator(t *testing.T) {
+	sim := NewSimulator(1e6, 2.4e9)
+	tx := NewTransmitter(sim)
+	burst := []complex128{1, 1i, -1, -1i}
+
+	if err := tx.Transmit(context.Background(), burst); err != ErrTransmitterNotConnected {
+		t.Fatalf("Transmit() before connect error = %v", err)
+	}
+	if err := sim.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Transmit(context.Background(), []complex128{2}); err != ErrSampleClipped {
+		t.Errorf("Transmit() of clipped sample error = %v", err)
+	}
+	if err := tx.Transmit(context.Background(), burst); err != nil {
+		t.Fatalf("Transmit() error = %v", err)
+	}
+	if got := sim.LastBurst(); len(got) != len(burst) || got[1] != 1i {
+		t.Errorf("LastBurst() = %v, want %v", got, burst)
+	}
+}
@@ -0,0 +1,647 @@
+//go:build uhd
+
+package usrp
+
+/*
+#cgo LDFLAGS: -luhd
+#include <stdlib.h>
+#include <uhd.h>
+
+static uhd_error recv_into(uhd_rx_streamer_handle streamer, float **bufs, size_t nchan, size_t offset,
+                           size_t samps, uhd_rx_metadata_handle *md, double timeout, size_t *received) {
+	void *buffs[nchan];
+	for (size_t i = 0; i < nchan; i++) {
+		buffs[i] = bufs[i] + 2 * offset;
+	}
+	return uhd_rx_streamer_recv(streamer, buffs, samps, md, timeout, false, received);
+}
+
+static uhd_error send_from(uhd_tx_streamer_handle streamer, float *buf, size_t offset, size_t samps,
+                           uhd_tx_metadata_handle *md, double timeout, size_t *sent) {
+	const void *buffs[1] = { buf + 2 * offset };
+	return uhd_tx_streamer_send(streamer, buffs, samps, md, timeout, sent);
+}
+*/
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+	"unsafe"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// maxReceiveSamples caps a single Receive call at 8 MiB of fc32 samples.
+	maxReceiveSamples = 1 << 20
+	fc32SampleSize    = 8
+
+	uhdFirstTimeout  = 1.0
+	uhdPacketTimeout = 0.1
+	uhdSendTimeout   = 1.0
+)
+
+// UHD drives a B210/X310 through the UHD C API. It is compiled only with the
+// uhd build tag and requires libuhd at link time. All channels share one RX
+// streamer so their samples are time-aligned; transmission uses channel 0.
+type UHD struct {
+	args       string
+	sampleRate float64
+	centerFreq float64
+	gain       float64
+	txGain     float64
+	channels   int
+	clock      string
+
+	usrp     C.uhd_usrp_handle
+	streamer C.uhd_rx_streamer_handle
+	metadata C.uhd_rx_metadata_handle
+	txStream C.uhd_tx_streamer_handle
+
+	connected bool
+	mu        sync.Mutex
+}
+
+func newHardwareDriver(config *DriverConfig) (Driver, error) {
+	return &UHD{
+		args:       config.DeviceArgs(),
+		sampleRate: config.SampleRate,
+		centerFreq: config.CenterFreq,
+		gain:       config.Gain,
+		txGain:     config.TxGain,
+		channels:   config.Channels,
+		clock:      clockSourceOrDefault(config.ClockSource),
+	}, nil
+}
+
+func (u *UHD) Connect(ctx context.Context) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.connected {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	args := C.CString(u.args)
+	defer C.free(unsafe.Pointer(args))
+
+	if err := uhdCheck(C.uhd_usrp_make(&u.usrp, args), "open device"); err != nil {
+		return err
+	}
+	if err := u.configure(); err != nil {
+		u.release()
+		return err
+	}
+
+	u.connected = true
+	logger.Info("USRP hardware connected",
+		zap.String("args", u.args),
+		zap.Float64("sample_rate_mhz", u.sampleRate/1e6),
+		zap.Float64("center_freq_ghz", u.centerFreq/1e9),
+		zap.Float64("gain_db", u.gain),
+		zap.Int("channels", u.channels),
+	)
+	return nil
+}
+
+func (u *UHD) configure() error {
+	if err := uhdCheck(C.uhd_rx_streamer_make(&u.streamer), "create rx streamer"); err != nil {
+		return err
+	}
+	if err := uhdCheck(C.uhd_rx_metadata_make(&u.metadata), "create rx metadata"); err != nil {
+		return err
+	}
+	if err := u.setClockSource(u.clock); err != nil {
+		return err
+	}
+	if err := u.setRate(u.sampleRate); err != nil {
+		return err
+	}
+	if err := u.setFreq(u.centerFreq); err != nil {
+		return err
+	}
+
+	if err := u.setGain(u.gain); err != nil {
+		return err
+	}
+
+	gainName := C.CString("")
+	defer C.free(unsafe.Pointer(gainName))
+	cpuFormat := C.CString("fc32")
+	otwFormat := C.CString("sc16")
+	streamArgs := C.CString("")
+	channelList := (*C.size_t)(C.malloc(C.size_t(u.channels) * C.size_t(unsafe.Sizeof(C.size_t(0)))))
+	defer func() {
+		C.free(unsafe.Pointer(cpuFormat))
+		C.free(unsafe.Pointer(otwFormat))
+		C.free(unsafe.Pointer(streamArgs))
+		C.free(unsafe.Pointer(channelList))
+	}()
+	list := unsafe.Slice(channelList, u.channels)
+	for ch := range list {
+		list[ch] = C.size_t(ch)
+	}
+
+	stream := C.uhd_stream_args_t{
+		cpu_format:   cpuFormat,
+		otw_format:   otwFormat,
+		args:         streamArgs,
+		channel_list: channelList,
+		n_channels:   C.int(u.channels),
+	}
+	if err := uhdCheck(C.uhd_usrp_get_rx_stream(u.usrp, &stream, u.streamer), "get rx stream"); err != nil {
+		return err
+	}
+	return u.configureTX(cpuFormat, otwFormat, streamArgs, gainName)
+}
+
+func (u *UHD) configureTX(cpuFormat, otwFormat, streamArgs, gainName *C.char) error {
+	if err := uhdCheck(C.uhd_tx_streamer_make(&u.txStream), "create tx streamer"); err != nil {
+		return err
+	}
+	if err := uhdCheck(C.uhd_usrp_set_tx_gain(u.usrp, C.double(u.txGain), 0, gainName), "set tx gain"); err != nil {
+		return err
+	}
+
+	channel := (*C.size_t)(C.malloc(C.size_t(unsafe.Sizeof(C.size_t(0)))))
+	defer C.free(unsafe.Pointer(channel))
+	*channel = 0
+
+	stream := C.uhd_stream_args_t{
+		cpu_format:   cpuFormat,
+		otw_format:   otwFormat,
+		args:         streamArgs,
+		channel_list: channel,
+		n_channels:   1,
+	}
+	return uhdCheck(C.uhd_usrp_get_tx_stream(u.usrp, &stream, u.txStream), "get tx stream")
+}
+
+func (u *UHD) setRate(rate float64) error {
+	for ch := 0; ch < u.channels; ch++ {
+		if err := uhdCheck(C.uhd_usrp_set_rx_rate(u.usrp, C.double(rate), C.size_t(ch)), "set rx rate"); err != nil {
+			return err
+		}
+	}
+	if err := uhdCheck(C.uhd_usrp_set_tx_rate(u.usrp, C.double(rate), 0), "set tx rate"); err != nil {
+		return err
+	}
+	var actual C.double
+	if err := uhdCheck(C.uhd_usrp_get_rx_rate(u.usrp, 0, &actual), "get rx rate"); err != nil {
+		return err
+	}
+	u.sampleRate = float64(actual)
+	return nil
+}
+
+func (u *UHD) setFreq(freq float64) error {
+	request := C.uhd_tune_request_t{
+		target_freq:     C.double(freq),
+		rf_freq_policy:  C.UHD_TUNE_REQUEST_POLICY_AUTO,
+		dsp_freq_policy: C.UHD_TUNE_REQUEST_POLICY_AUTO,
+	}
+	var result C.uhd_tune_result_t
+	for ch := 0; ch < u.channels; ch++ {
+		if err := uhdCheck(C.uhd_usrp_set_rx_freq(u.usrp, &request, C.size_t(ch), &result), "set rx freq"); err != nil {
+			return err
+		}
+	}
+	if err := uhdCheck(C.uhd_usrp_set_tx_freq(u.usrp, &request, 0, &result), "set tx freq"); err != nil {
+		return err
+	}
+	u.centerFreq = freq
+	return nil
+}
+
+func (u *UHD) release() {
+	if u.txStream != nil {
+		C.uhd_tx_streamer_free(&u.txStream)
+		u.txStream = nil
+	}
+	if u.metadata != nil {
+		C.uhd_rx_metadata_free(&u.metadata)
+		u.metadata = nil
+	}
+	if u.streamer != nil {
+		C.uhd_rx_streamer_free(&u.streamer)
+		u.streamer = nil
+	}
+	if u.usrp != nil {
+		C.uhd_usrp_free(&u.usrp)
+		u.usrp = nil
+	}
+}
+
+func (u *UHD) Disconnect() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.release()
+	u.connected = false
+	logger.Info("USRP hardware disconnected")
+	return nil
+}
+
+// Receive captures duration worth of samples on the first channel.
+func (u *UHD) Receive(ctx context.Context, duration time.Duration) ([]model.ChannelDataPoint, error) {
+	data, err := u.ReceiveMulti(ctx, duration)
+	if err != nil {
+		return nil, err
+	}
+	return data[0], nil
+}
+
+// ReceiveMulti captures duration worth of samples on every channel in a
+// single burst.
+func (u *UHD) ReceiveMulti(ctx context.Context, duration time.Duration) ([][]model.ChannelDataPoint, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if !u.connected {
+		return nil, ErrHardwareNotConnected
+	}
+
+	numSamples := int(duration.Seconds() * u.sampleRate)
+	if numSamples > maxReceiveSamples {
+		numSamples = maxReceiveSamples
+	}
+	if numSamples <= 0 {
+		return make([][]model.ChannelDataPoint, u.channels), nil
+	}
+
+	bufs := (**C.float)(C.malloc(C.size_t(u.channels) * C.size_t(unsafe.Sizeof((*C.float)(nil)))))
+	defer C.free(unsafe.Pointer(bufs))
+	bufList := unsafe.Slice(bufs, u.channels)
+	for ch := range bufList {
+		bufList[ch] = (*C.float)(C.malloc(C.size_t(numSamples * fc32SampleSize)))
+		defer C.free(unsafe.Pointer(bufList[ch]))
+	}
+
+	cmd := C.uhd_stream_cmd_t{
+		stream_mode: C.UHD_STREAM_MODE_NUM_SAMPS_AND_DONE,
+		num_samps:   C.size_t(numSamples),
+		stream_now:  true,
+	}
+	if err := uhdCheck(C.uhd_rx_streamer_issue_stream_cmd(u.streamer, &cmd), "issue stream command"); err != nil {
+		return nil, err
+	}
+
+	received := 0
+	timeout := uhdFirstTimeout
+	for received < numSamples {
+		if err := ctx.Err(); err != nil {
+			u.stopStream()
+			return nil, err
+		}
+
+		var n C.size_t
+		code := C.recv_into(u.streamer, bufs, C.size_t(u.channels), C.size_t(received),
+			C.size_t(numSamples-received), &u.metadata, C.double(timeout), &n)
+		if err := uhdCheck(code, "receive"); err != nil {
+			return nil, err
+		}
+		timeout = uhdPacketTimeout
+
+		var mdCode C.uhd_rx_metadata_error_code_t
+		C.uhd_rx_metadata_error_code(u.metadata, &mdCode)
+		switch mdCode {
+		case C.UHD_RX_METADATA_ERROR_CODE_NONE:
+		case C.UHD_RX_METADATA_ERROR_CODE_OVERFLOW:
+			logger.Warn("USRP receive overflow", zap.Int("received", received))
+		case C.UHD_RX_METADATA_ERROR_CODE_TIMEOUT:
+			return nil, fmt.Errorf("uhd receive timed out after %d of %d samples", received, numSamples)
+		default:
+			return nil, fmt.Errorf("uhd receive failed with metadata error %d", int(mdCode))
+		}
+
+		received += int(n)
+	}
+
+	data := make([][]model.ChannelDataPoint, u.channels)
+	for ch := range data {
+		samples := unsafe.Slice((*float32)(unsafe.Pointer(bufList[ch])), numSamples*2)
+		data[ch] = make([]model.ChannelDataPoint, numSamples)
+		for i := range data[ch] {
+			iVal := float64(samples[2*i])
+			qVal := float64(samples[2*i+1])
+			data[ch][i] = model.ChannelDataPoint{
+				Index:     i,
+				Amplitude: math.Hypot(iVal, qVal),
+				Phase:     math.Atan2(qVal, iVal),
+				I:         iVal,
+				Q:         qVal,
+				// fc32 samples are normalized to the converter's full scale
+				Clipped: math.Abs(iVal) >= 1 || math.Abs(qVal) >= 1,
+			}
+		}
+	}
+
+	logger.Debug("USRP data received",
+		zap.Int("channels", u.channels),
+		zap.Int("samples", numSamples),
+		zap.Duration("duration", duration),
+	)
+
+	return data, nil
+}
+
+func (u *UHD) stopStream() {
+	cmd := C.uhd_stream_cmd_t{
+		stream_mode: C.UHD_STREAM_MODE_STOP_CONTINUOUS,
+		stream_now:  true,
+	}
+	C.uhd_rx_streamer_issue_stream_cmd(u.streamer, &cmd)
+}
+
+// Transmit sends samples as a single burst on TX channel 0, closing it with
+// an end-of-burst packet so the radio stops transmitting afterwards.
+func (u *UHD) Transmit(ctx context.Context, samples []complex128) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if !u.connected {
+		return ErrHardwareNotConnected
+	}
+
+	buf := (*C.float)(C.malloc(C.size_t(len(samples) * fc32SampleSize)))
+	defer C.free(unsafe.Pointer(buf))
+	values := unsafe.Slice((*float32)(unsafe.Pointer(buf)), len(samples)*2)
+	for i, s := range samples {
+		values[2*i] = float32(real(s))
+		values[2*i+1] = float32(imag(s))
+	}
+
+	var start, cont, end C.uhd_tx_metadata_handle
+	if err := uhdCheck(C.uhd_tx_metadata_make(&start, false, 0, 0, true, false), "create tx metadata"); err != nil {
+		return err
+	}
+	defer C.uhd_tx_metadata_free(&start)
+	if err := uhdCheck(C.uhd_tx_metadata_make(&cont, false, 0, 0, false, false), "create tx metadata"); err != nil {
+		return err
+	}
+	defer C.uhd_tx_metadata_free(&cont)
+	if err := uhdCheck(C.uhd_tx_metadata_make(&end, false, 0, 0, false, true), "create tx metadata"); err != nil {
+		return err
+	}
+	defer C.uhd_tx_metadata_free(&end)
+
+	sent := 0
+	md := &start
+	for sent < len(samples) {
+		if err := ctx.Err(); err != nil {
+			u.endBurst(&end)
+			return err
+		}
+
+		var n C.size_t
+		code := C.send_from(u.txStream, buf, C.size_t(sent), C.size_t(len(samples)-sent), md, uhdSendTimeout, &n)
+		if err := uhdCheck(code, "send"); err != nil {
+			return err
+		}
+		if n == 0 {
+			u.endBurst(&end)
+			return fmt.Errorf("uhd send timed out after %d of %d samples", sent, len(samples))
+		}
+		sent += int(n)
+		md = &cont
+	}
+
+	if err := u.endBurst(&end); err != nil {
+		return err
+	}
+
+	logger.Debug("USRP burst transmitted", zap.Int("samples", len(samples)))
+	return nil
+}
+
+func (u *UHD) endBurst(md *C.uhd_tx_metadata_handle) error {
+	var pad [2]C.float
+	var n C.size_t
+	return uhdCheck(C.send_from(u.txStream, &pad[0], 0, 0, md, uhdSendTimeout, &n), "end burst")
+}
+
+func (u *UHD) SetFrequency(freq float64) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if !u.connected {
+		return ErrHardwareNotConnected
+	}
+	return u.setFreq(freq)
+}
+
+func (u *UHD) SetSampleRate(rate float64) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if !u.connected {
+		return ErrHardwareNotConnected
+	}
+	return u.setRate(rate)
+}
+
+// SetGain applies the gain to every RX channel and keeps the value UHD
+// coerced it to. Before Connect it only changes the gain used to configure.
+func (u *UHD) SetGain(gain float64) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if !u.connected {
+		u.gain = gain
+		return nil
+	}
+	return u.setGain(gain)
+}
+
+func (u *UHD) setGain(gain float64) error {
+	gainName := C.CString("")
+	defer C.free(unsafe.Pointer(gainName))
+	for ch := 0; ch < u.channels; ch++ {
+		if err := uhdCheck(C.uhd_usrp_set_rx_gain(u.usrp, C.double(gain), C.size_t(ch), gainName), "set rx gain"); err != nil {
+			return err
+		}
+	}
+	var actual C.double
+	if err := uhdCheck(C.uhd_usrp_get_rx_gain(u.usrp, 0, gainName, &actual), "get rx gain"); err != nil {
+		return err
+	}
+	u.gain = float64(actual)
+	return nil
+}
+
+func (u *UHD) GetGain() float64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.gain
+}
+
+// SetClockSource switches reference and time source together. Before
+// Connect it only changes the source used to configure.
+func (u *UHD) SetClockSource(source string) error {
+	if !validClockSource(source) {
+		return ErrUnknownClockSource
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if !u.connected {
+		u.clock = clockSourceOrDefault(source)
+		return nil
+	}
+	return u.setClockSource(clockSourceOrDefault(source))
+}
+
+// setClockSource selects the reference and PPS source, then sets device time
+// from the host clock: immediately on the internal reference, or on the next
+// PPS edge otherwise, which UHD waits for.
+func (u *UHD) setClockSource(source string) error {
+	name := C.CString(source)
+	defer C.free(unsafe.Pointer(name))
+	if err := uhdCheck(C.uhd_usrp_set_clock_source(u.usrp, name, 0), "set clock source"); err != nil {
+		return err
+	}
+	if err := uhdCheck(C.uhd_usrp_set_time_source(u.usrp, name, 0), "set time source"); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if source == model.ClockSourceInternal {
+		frac := float64(now.Nanosecond()) / 1e9
+		if err := uhdCheck(C.uhd_usrp_set_time_now(u.usrp, C.int64_t(now.Unix()), C.double(frac), 0), "set time"); err != nil {
+			return err
+		}
+	} else if err := uhdCheck(C.uhd_usrp_set_time_unknown_pps(u.usrp, C.int64_t(now.Unix()+1), 0), "set time at pps"); err != nil {
+		return err
+	}
+	u.clock = source
+
+	if locked, err := u.sensorBool("ref_locked"); err == nil && !locked {
+		logger.Warn("USRP reference not locked", zap.String("clock_source", source))
+	}
+	return nil
+}
+
+func (u *UHD) SyncStatus() (*model.SyncStatus, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if !u.connected {
+		return nil, ErrHardwareNotConnected
+	}
+
+	status := &model.SyncStatus{ClockSource: u.clock}
+	var err error
+	if status.RefLocked, err = u.sensorBool("ref_locked"); err != nil {
+		return nil, err
+	}
+	if u.clock == model.ClockSourceGPSDO {
+		if status.GPSLocked, err = u.sensorBool("gps_locked"); err != nil {
+			return nil, err
+		}
+	}
+
+	var full C.int64_t
+	var frac C.double
+	if err := uhdCheck(C.uhd_usrp_get_time_now(u.usrp, 0, &full, &frac), "get time"); err != nil {
+		return nil, err
+	}
+	status.CheckedAt = time.Now()
+	host := float64(status.CheckedAt.UnixNano()) / 1e9
+	status.TimeOffset = float64(full) + float64(frac) - host
+	return status, nil
+}
+
+func (u *UHD) sensorBool(name string) (bool, error) {
+	var sensor C.uhd_sensor_value_handle
+	if err := uhdCheck(C.uhd_sensor_value_make(&sensor), "create sensor value"); err != nil {
+		return false, err
+	}
+	defer C.uhd_sensor_value_free(&sensor)
+
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	if err := uhdCheck(C.uhd_usrp_get_mboard_sensor(u.usrp, cname, 0, &sensor), "read sensor "+name); err != nil {
+		return false, err
+	}
+	var value C.bool
+	if err := uhdCheck(C.uhd_sensor_value_to_bool(sensor, &value), "read sensor "+name); err != nil {
+		return false, err
+	}
+	return bool(value), nil
+}
+
+func (u *UHD) ChannelCount() int {
+	return u.channels
+}
+
+func (u *UHD) IsConnected() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.connected
+}
+
+var ErrHardwareNotConnected = &HardwareError{Message: "usrp hardware not connected"}
+
+// findHardware returns the device address of every radio UHD can discover.
+func findHardware(args string) ([]string, error) {
+	var vec C.uhd_string_vector_handle
+	if err := uhdCheck(C.uhd_string_vector_make(&vec), "create string vector"); err != nil {
+		return nil, err
+	}
+	defer C.uhd_string_vector_free(&vec)
+
+	cargs := C.CString(args)
+	defer C.free(unsafe.Pointer(cargs))
+	if err := uhdCheck(C.uhd_usrp_find(cargs, &vec), "find devices"); err != nil {
+		return nil, err
+	}
+
+	var n C.size_t
+	if err := uhdCheck(C.uhd_string_vector_size(vec, &n), "count devices"); err != nil {
+		return nil, err
+	}
+
+	buf := (*C.char)(C.malloc(1024))
+	defer C.free(unsafe.Pointer(buf))
+	addrs := make([]string, 0, int(n))
+	for i := C.size_t(0); i < n; i++ {
+		if err := uhdCheck(C.uhd_string_vector_at(vec, i, buf, 1024), "read device address"); err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, C.GoString(buf))
+	}
+	return addrs, nil
+}
+
+type HardwareError struct {
+	Message string
+}
+
+func (e *HardwareError) Error() string {
+	return e.Message
+}
+
+func uhdCheck(code C.uhd_error, op string) error {
+	if code == C.UHD_ERROR_NONE {
+		return nil
+	}
+
+	msg := (*C.char)(C.malloc(512))
+	defer C.free(unsafe.Pointer(msg))
+	C.uhd_get_last_error(msg, 512)
+
+	return fmt.Errorf("uhd %s: %s (code %d)", op, C.GoString(msg), int(code))
+}
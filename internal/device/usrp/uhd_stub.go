@@ -0,0 +1,11 @@
+//go:build !uhd
+
+package usrp
+
+func newHardwareDriver(config *DriverConfig) (Driver, error) {
+	return nil, ErrHardwareDriverNotCompiled
+}
+
+func findHardware(args string) ([]string, error) {
+	return nil, ErrHardwareDriverNotCompiled
+}
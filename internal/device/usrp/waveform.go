@@ -0,0 +1,87 @@
+package usrp
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+const (
+	WaveformTone      = "tone"
+	WaveformChirp     = "chirp"
+	WaveformZadoffChu = "zadoff_chu"
+)
+
+// WaveformSpec describes a probing waveform. Frequencies are baseband offsets
+// in Hz and are normalized by the sample rate.
+type WaveformSpec struct {
+	Type       string
+	Length     int
+	Amplitude  float64
+	SampleRate float64
+	ToneFreq   float64
+	Bandwidth  float64
+	Root       int
+}
+
+// GenerateWaveform returns Length samples scaled to Amplitude (0, 1].
+func GenerateWaveform(spec WaveformSpec) ([]complex128, error) {
+	if spec.Length <= 0 {
+		return nil, fmt.Errorf("waveform length must be positive, got %d", spec.Length)
+	}
+	if spec.Amplitude <= 0 || spec.Amplitude > 1 {
+		return nil, fmt.Errorf("waveform amplitude must be in (0, 1], got %g", spec.Amplitude)
+	}
+	if spec.SampleRate <= 0 {
+		return nil, fmt.Errorf("sample rate must be positive, got %g", spec.SampleRate)
+	}
+
+	n := spec.Length
+	samples := make([]complex128, n)
+	switch spec.Type {
+	case WaveformTone:
+		f := spec.ToneFreq / spec.SampleRate
+		for i := range samples {
+			samples[i] = cmplx.Exp(complex(0, 2*math.Pi*f*float64(i)))
+		}
+	case WaveformChirp:
+		// linear sweep from -B/2 to +B/2 over the burst
+		b := spec.Bandwidth / spec.SampleRate
+		if b <= 0 || b > 1 {
+			return nil, fmt.Errorf("chirp bandwidth must be in (0, sample rate], got %g", spec.Bandwidth)
+		}
+		k := b / float64(n)
+		for i := range samples {
+			t := float64(i)
+			samples[i] = cmplx.Exp(complex(0, 2*math.Pi*(-b/2*t+k/2*t*t)))
+		}
+	case WaveformZadoffChu:
+		root := spec.Root
+		if root <= 0 {
+			root = 1
+		}
+		if gcd(root, n) != 1 {
+			return nil, fmt.Errorf("zadoff-chu root %d must be coprime with length %d", root, n)
+		}
+		cf := n % 2
+		for i := range samples {
+			m := float64(i)
+			samples[i] = cmplx.Exp(complex(0, -math.Pi*float64(root)*m*(m+float64(cf))/float64(n)))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported waveform %q", spec.Type)
+	}
+
+	scale := complex(spec.Amplitude, 0)
+	for i := range samples {
+		samples[i] *= scale
+	}
+	return samples, nil
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,362 @@
+package usrp
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// zmqItemSize is one gr_complex: little-endian float32 I then Q.
+	zmqItemSize = 8
+	// zmqMaxSamples caps a single Receive call per channel.
+	zmqMaxSamples = 1 << 20
+	// zmqBacklog bounds how many items are buffered between captures; older
+	// items are dropped first.
+	zmqBacklog = 4 << 20
+
+	zmqDialTimeout = 5 * time.Second
+	// zmqReceiveSlack is added to the capture duration before giving up on a
+	// flowgraph that publishes slower than real time.
+	zmqReceiveSlack = 2 * time.Second
+)
+
+// ZMQSource receives complex samples from a GNU Radio flowgraph through a ZMQ
+// PUB Sink (SUB socket here) or PUSH Sink (PULL socket here) with item type
+// complex and pass tags disabled. For multi-channel captures the flowgraph
+// must interleave the channels sample by sample, e.g. with an Interleave
+// block ahead of the sink. Tuning and sample rate are owned by the
+// flowgraph; SetFrequency and SetSampleRate only record the values the
+// pipeline should assume.
+type ZMQSource struct {
+	address    string
+	socketType string
+	sampleRate float64
+	centerFreq float64
+	gain       float64
+	channels   int
+
+	// mu serializes captures and connection changes; bufMu guards the
+	// state shared with the read loop so status checks never wait on a
+	// capture in progress.
+	mu   sync.Mutex
+	conn *zmtpConn
+
+	bufMu     sync.Mutex
+	connected bool
+	buffer    []complex64
+	partial   []byte
+	dropped   int
+	readErr   error
+	ready     chan struct{}
+	done      chan struct{}
+}
+
+func NewZMQSource(config *DriverConfig) (*ZMQSource, error) {
+	if !strings.HasPrefix(config.ZMQAddress, "tcp://") {
+		return nil, &ZMQError{Message: fmt.Sprintf("zmq address %q must be tcp://host:port", config.ZMQAddress)}
+	}
+	socketType := strings.ToLower(config.ZMQSocketType)
+	if socketType == "" {
+		socketType = ZMQSocketSub
+	}
+	if socketType != ZMQSocketSub && socketType != ZMQSocketPull {
+		return nil, &ZMQError{Message: fmt.Sprintf("unsupported zmq socket type %q", config.ZMQSocketType)}
+	}
+
+	return &ZMQSource{
+		address:    config.ZMQAddress,
+		socketType: socketType,
+		sampleRate: config.SampleRate,
+		centerFreq: config.CenterFreq,
+		gain:       config.Gain,
+		channels:   config.Channels,
+	}, nil
+}
+
+func (z *ZMQSource) Connect(ctx context.Context) error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	if z.IsConnected() {
+		return nil
+	}
+	if z.conn != nil {
+		// the previous stream failed; release it before reconnecting
+		z.conn.Close()
+		<-z.done
+		z.conn = nil
+	}
+
+	dialer := net.Dialer{Timeout: zmqDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", strings.TrimPrefix(z.address, "tcp://"))
+	if err != nil {
+		return &ZMQError{Message: "zmq connect: " + err.Error()}
+	}
+	conn.SetDeadline(time.Now().Add(zmqDialTimeout))
+	zc, err := zmtpHandshake(conn, strings.ToUpper(z.socketType))
+	if err != nil {
+		conn.Close()
+		return &ZMQError{Message: err.Error()}
+	}
+	conn.SetDeadline(time.Time{})
+
+	z.bufMu.Lock()
+	z.buffer = z.buffer[:0]
+	z.partial = nil
+	z.readErr = nil
+	z.ready = make(chan struct{}, 1)
+	z.done = make(chan struct{})
+	z.connected = true
+	z.bufMu.Unlock()
+
+	z.conn = zc
+	go z.readLoop(zc, z.done)
+
+	logger.Info("ZMQ source connected",
+		zap.String("address", z.address),
+		zap.String("socket", z.socketType),
+		zap.Int("channels", z.channels),
+	)
+	return nil
+}
+
+func (z *ZMQSource) Disconnect() error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	z.bufMu.Lock()
+	connected := z.connected
+	z.connected = false
+	z.bufMu.Unlock()
+	if !connected {
+		return nil
+	}
+	err := z.conn.Close()
+	<-z.done
+	z.conn = nil
+
+	logger.Info("ZMQ source disconnected", zap.String("address", z.address))
+	return err
+}
+
+// readLoop appends every received item to the backlog until the connection
+// closes.
+func (z *ZMQSource) readLoop(conn *zmtpConn, done chan struct{}) {
+	defer close(done)
+	for {
+		parts, err := conn.readMessage()
+		if err != nil {
+			z.bufMu.Lock()
+			z.readErr = err
+			z.bufMu.Unlock()
+			z.notify()
+			return
+		}
+		// a keyed PUB sink sends the key first; samples are in the last part
+		z.push(parts[len(parts)-1])
+	}
+}
+
+func (z *ZMQSource) push(payload []byte) {
+	z.bufMu.Lock()
+	data := payload
+	if len(z.partial) > 0 {
+		data = append(z.partial, payload...)
+		z.partial = nil
+	}
+	n := len(data) / zmqItemSize
+	for i := 0; i < n; i++ {
+		off := i * zmqItemSize
+		re := math.Float32frombits(binary.LittleEndian.Uint32(data[off:]))
+		im := math.Float32frombits(binary.LittleEndian.Uint32(data[off+4:]))
+		z.buffer = append(z.buffer, complex(re, im))
+	}
+	if rest := data[n*zmqItemSize:]; len(rest) > 0 {
+		z.partial = append([]byte(nil), rest...)
+	}
+	if over := len(z.buffer) - zmqBacklog; over > 0 {
+		z.dropFront(over)
+	}
+	z.bufMu.Unlock()
+	z.notify()
+}
+
+// dropFront discards at least n items, rounded up to whole frames so the
+// buffer keeps starting on channel 0. Callers hold bufMu.
+func (z *ZMQSource) dropFront(n int) {
+	n = (n + z.channels - 1) / z.channels * z.channels
+	if n > len(z.buffer)-len(z.buffer)%z.channels {
+		n = len(z.buffer) - len(z.buffer)%z.channels
+	}
+	z.dropped += n
+	z.buffer = append(z.buffer[:0], z.buffer[n:]...)
+}
+
+func (z *ZMQSource) notify() {
+	select {
+	case z.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (z *ZMQSource) Receive(ctx context.Context, duration time.Duration) ([]model.ChannelDataPoint, error) {
+	data, err := z.ReceiveMulti(ctx, duration)
+	if err != nil {
+		return nil, err
+	}
+	return data[0], nil
+}
+
+// ReceiveMulti discards the backlog and returns the next duration worth of
+// samples from the stream, so captures always reflect the current signal.
+func (z *ZMQSource) ReceiveMulti(ctx context.Context, duration time.Duration) ([][]model.ChannelDataPoint, error) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	if !z.IsConnected() {
+		return nil, ErrZMQNotConnected
+	}
+
+	numSamples := int(duration.Seconds() * z.sampleRate)
+	if numSamples > zmqMaxSamples {
+		numSamples = zmqMaxSamples
+	}
+	if numSamples <= 0 {
+		return make([][]model.ChannelDataPoint, z.channels), nil
+	}
+	want := numSamples * z.channels
+
+	z.bufMu.Lock()
+	z.dropFront(len(z.buffer))
+	z.dropped = 0
+	z.bufMu.Unlock()
+
+	timer := time.NewTimer(duration + zmqReceiveSlack)
+	defer timer.Stop()
+
+	for {
+		z.bufMu.Lock()
+		have, readErr := len(z.buffer), z.readErr
+		if have >= want {
+			items := append([]complex64(nil), z.buffer[:want]...)
+			z.buffer = append(z.buffer[:0], z.buffer[want:]...)
+			z.bufMu.Unlock()
+			return z.deinterleave(items, numSamples), nil
+		}
+		z.bufMu.Unlock()
+
+		if readErr != nil {
+			return nil, &ZMQError{Message: "zmq receive: " + readErr.Error()}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+			return nil, &ZMQError{Message: fmt.Sprintf("zmq receive timed out after %d of %d samples", have/z.channels, numSamples)}
+		case <-z.ready:
+		}
+	}
+}
+
+func (z *ZMQSource) deinterleave(items []complex64, numSamples int) [][]model.ChannelDataPoint {
+	data := make([][]model.ChannelDataPoint, z.channels)
+	for ch := range data {
+		data[ch] = make([]model.ChannelDataPoint, numSamples)
+		for i := range data[ch] {
+			s := items[i*z.channels+ch]
+			iVal, qVal := float64(real(s)), float64(imag(s))
+			data[ch][i] = model.ChannelDataPoint{
+				Index:     i,
+				Amplitude: math.Hypot(iVal, qVal),
+				Phase:     math.Atan2(qVal, iVal),
+				I:         iVal,
+				Q:         qVal,
+			}
+		}
+	}
+
+	logger.Debug("ZMQ data received",
+		zap.Int("channels", z.channels),
+		zap.Int("samples", numSamples),
+	)
+	return data
+}
+
+// Transmit is not supported; the flowgraph only publishes samples.
+func (z *ZMQSource) Transmit(ctx context.Context, samples []complex128) error {
+	return ErrZMQTransmitUnsupported
+}
+
+func (z *ZMQSource) ChannelCount() int {
+	return z.channels
+}
+
+func (z *ZMQSource) SetFrequency(freq float64) error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.centerFreq = freq
+	return nil
+}
+
+func (z *ZMQSource) SetSampleRate(rate float64) error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.sampleRate = rate
+	return nil
+}
+
+// SetGain is unsupported: the gain belongs to the GNU Radio flowgraph.
+func (z *ZMQSource) SetGain(gain float64) error {
+	return ErrZMQGainUnsupported
+}
+
+// GetGain reports the configured gain, which the flowgraph is assumed to use.
+func (z *ZMQSource) GetGain() float64 {
+	return z.gain
+}
+
+// SetClockSource accepts only the internal reference; the flowgraph owns
+// the hardware clock.
+func (z *ZMQSource) SetClockSource(source string) error {
+	if clockSourceOrDefault(source) != model.ClockSourceInternal {
+		return ErrZMQSyncUnsupported
+	}
+	return nil
+}
+
+func (z *ZMQSource) SyncStatus() (*model.SyncStatus, error) {
+	return nil, ErrZMQSyncUnsupported
+}
+
+func (z *ZMQSource) IsConnected() bool {
+	z.bufMu.Lock()
+	defer z.bufMu.Unlock()
+	return z.connected && z.readErr == nil
+}
+
+var (
+	ErrZMQNotConnected        = &ZMQError{Message: "zmq source not connected"}
+	ErrZMQTransmitUnsupported = &ZMQError{Message: "zmq source cannot transmit"}
+	ErrZMQGainUnsupported     = &ZMQError{Message: "zmq source gain is set in the flowgraph"}
+	ErrZMQSyncUnsupported     = &ZMQError{Message: "zmq source clock and sync are handled by the flowgraph"}
+)
+
+type ZMQError struct {
+	Message string
+}
+
+func (e *ZMQError) Error() string {
+	return e.Message
+}
@@ -0,0 +1,118 @@
+package usrp
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"net"
+	"testing"
+	"time"
+)
+
+// servePUB accepts one subscriber as a GNU Radio PUB sink would and
+// publishes the payloads in a loop until stop is closed.
+func servePUB(t *testing.T, ln net.Listener, payloads [][]byte, stop <-chan struct{}) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	c := &zmtpConn{conn: conn, r: bufio.NewReader(conn)}
+	if _, err := conn.Write(zmtpGreeting()); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(c.r, make([]byte, zmtpGreetingSize)); err != nil {
+		return
+	}
+	if err := c.writeFrame(zmtpFlagCommand, zmtpCommand("READY", zmtpProperty("Socket-Type", "PUB"))); err != nil {
+		return
+	}
+	if _, _, err := c.readFrame(); err != nil {
+		return
+	}
+	if _, sub, err := c.readFrame(); err != nil || len(sub) != 1 || sub[0] != 0x01 {
+		t.Errorf("expected subscribe-all message, got %v (err %v)", sub, err)
+		return
+	}
+
+	for {
+		for _, p := range payloads {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if err := c.writeFrame(0, p); err != nil {
+				return
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func complexPayload(samples ...complex64) []byte {
+	b := make([]byte, 0, len(samples)*zmqItemSize)
+	for _, s := range samples {
+		b = binary.LittleEndian.AppendUint32(b, math.Float32bits(real(s)))
+		b = binary.LittleEndian.AppendUint32(b, math.Float32bits(imag(s)))
+	}
+	return b
+}
+
+func TestZMQSource_ReceiveMulti(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	// two channels interleaved, with an item split across messages
+	p := complexPayload(1+1i, 2+2i, 3+3i, 4+4i)
+	stop := make(chan struct{})
+	defer close(stop)
+	go servePUB(t, ln, [][]byte{p[:12], p[12:]}, stop)
+
+	src, err := NewDriverFactory().Create(DriverTypeZMQ,
+		WithZMQ("tcp://"+ln.Addr().String(), ZMQSocketSub),
+		WithSampleRate(1000),
+		WithChannels(2),
+	)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	ctx := context.Background()
+	if err := src.Connect(ctx); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer src.Disconnect()
+
+	data, err := src.ReceiveMulti(ctx, 2*time.Millisecond)
+	if err != nil {
+		t.Fatalf("ReceiveMulti() error = %v", err)
+	}
+	if len(data) != 2 || len(data[0]) != 2 {
+		t.Fatalf("got %d channels of %d samples, want 2 of 2", len(data), len(data[0]))
+	}
+	for i := range data[0] {
+		ch0, ch1 := data[0][i], data[1][i]
+		if (ch0.I != 1 && ch0.I != 3) || ch1.I != ch0.I+1 || ch1.Q != ch1.I {
+			t.Errorf("sample %d not deinterleaved: ch0=%+v ch1=%+v", i, ch0, ch1)
+		}
+	}
+
+	if err := src.Transmit(ctx, []complex128{1}); err != ErrZMQTransmitUnsupported {
+		t.Errorf("Transmit() error = %v, want %v", err, ErrZMQTransmitUnsupported)
+	}
+}
+
+func TestNewZMQSource_Validation(t *testing.T) {
+	if _, err := NewZMQSource(&DriverConfig{ZMQAddress: "ipc:///tmp/gr", Channels: 1}); err == nil {
+		t.Error("expected error for non-tcp address")
+	}
+	if _, err := NewZMQSource(&DriverConfig{ZMQAddress: "tcp://127.0.0.1:5555", ZMQSocketType: "req", Channels: 1}); err == nil {
+		t.Error("expected error for unsupported socket type")
+	}
+}
@@ -0,0 +1,249 @@
+package usrp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// Minimal ZMTP 3.0 client with the NULL security mechanism, enough to
+// receive from the PUB and PUSH sinks of a GNU Radio flowgraph without
+// linking libzmq.
+
+const (
+	zmtpFlagMore    = 0x01
+	zmtpFlagLong    = 0x02
+	zmtpFlagCommand = 0x04
+
+	zmtpGreetingSize = 64
+	zmtpMaxFrameSize = 64 << 20
+)
+
+const (
+	ZMQSocketSub  = "sub"
+	ZMQSocketPull = "pull"
+)
+
+// zmtpPeers lists the peer socket types each local type may talk to.
+var zmtpPeers = map[string][]string{
+	"SUB":  {"PUB", "XPUB"},
+	"PULL": {"PUSH"},
+}
+
+type zmtpConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func zmtpGreeting() []byte {
+	g := make([]byte, zmtpGreetingSize)
+	g[0] = 0xff
+	g[9] = 0x7f
+	// announce 3.0 so peers expect subscriptions as messages, which every
+	// 3.x implementation accepts
+	g[10] = 3
+	g[11] = 0
+	copy(g[12:32], "NULL")
+	return g
+}
+
+// zmtpHandshake exchanges greetings and READY commands on conn as a socket
+// of the given type ("SUB" or "PULL") and, for SUB, subscribes to every topic.
+func zmtpHandshake(conn net.Conn, socketType string) (*zmtpConn, error) {
+	c := &zmtpConn{conn: conn, r: bufio.NewReaderSize(conn, 64<<10)}
+
+	if _, err := conn.Write(zmtpGreeting()); err != nil {
+		return nil, fmt.Errorf("zmtp greeting: %w", err)
+	}
+	peer := make([]byte, zmtpGreetingSize)
+	if _, err := io.ReadFull(c.r, peer); err != nil {
+		return nil, fmt.Errorf("zmtp greeting: %w", err)
+	}
+	if peer[0] != 0xff || peer[9]&0x01 == 0 {
+		return nil, fmt.Errorf("zmtp greeting: peer is not a ZeroMQ socket")
+	}
+	if peer[10] < 3 {
+		return nil, fmt.Errorf("zmtp greeting: unsupported peer version %d.%d", peer[10], peer[11])
+	}
+	if mechanism := strings.TrimRight(string(peer[12:32]), "\x00"); mechanism != "NULL" {
+		return nil, fmt.Errorf("zmtp greeting: unsupported security mechanism %q", mechanism)
+	}
+
+	ready := zmtpCommand("READY", zmtpProperty("Socket-Type", socketType))
+	if err := c.writeFrame(zmtpFlagCommand, ready); err != nil {
+		return nil, fmt.Errorf("zmtp ready: %w", err)
+	}
+
+	flags, body, err := c.readFrame()
+	if err != nil {
+		return nil, fmt.Errorf("zmtp ready: %w", err)
+	}
+	name, data, err := zmtpParseCommand(flags, body)
+	if err != nil {
+		return nil, fmt.Errorf("zmtp ready: %w", err)
+	}
+	if name == "ERROR" {
+		return nil, fmt.Errorf("zmtp ready: peer rejected handshake: %s", zmtpErrorReason(data))
+	}
+	if name != "READY" {
+		return nil, fmt.Errorf("zmtp ready: unexpected command %q", name)
+	}
+	props, err := zmtpParseProperties(data)
+	if err != nil {
+		return nil, fmt.Errorf("zmtp ready: %w", err)
+	}
+	if !zmtpCompatible(socketType, props["socket-type"]) {
+		return nil, fmt.Errorf("zmtp ready: %s socket cannot connect to %s peer", socketType, props["socket-type"])
+	}
+
+	if socketType == "SUB" {
+		// ZMTP 3.0 subscription: a message of 0x01 followed by the topic
+		if err := c.writeFrame(0, []byte{0x01}); err != nil {
+			return nil, fmt.Errorf("zmtp subscribe: %w", err)
+		}
+	}
+	return c, nil
+}
+
+func zmtpCompatible(local, peer string) bool {
+	for _, p := range zmtpPeers[local] {
+		if strings.EqualFold(p, peer) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *zmtpConn) writeFrame(flags byte, body []byte) error {
+	var header []byte
+	if len(body) > 255 {
+		header = make([]byte, 9)
+		header[0] = flags | zmtpFlagLong
+		binary.BigEndian.PutUint64(header[1:], uint64(len(body)))
+	} else {
+		header = []byte{flags, byte(len(body))}
+	}
+	if _, err := c.conn.Write(append(header, body...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *zmtpConn) readFrame() (byte, []byte, error) {
+	flags, err := c.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var size uint64
+	if flags&zmtpFlagLong != 0 {
+		var b [8]byte
+		if _, err := io.ReadFull(c.r, b[:]); err != nil {
+			return 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(b[:])
+	} else {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		size = uint64(b)
+	}
+	if size > zmtpMaxFrameSize {
+		return 0, nil, fmt.Errorf("zmtp frame of %d bytes exceeds limit", size)
+	}
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, err
+	}
+	return flags, body, nil
+}
+
+// readMessage returns the frames of the next message. Commands received
+// between messages are skipped, except ERROR which ends the connection.
+func (c *zmtpConn) readMessage() ([][]byte, error) {
+	var parts [][]byte
+	for {
+		flags, body, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		if flags&zmtpFlagCommand != 0 {
+			name, data, err := zmtpParseCommand(flags, body)
+			if err != nil {
+				return nil, err
+			}
+			if name == "ERROR" {
+				return nil, fmt.Errorf("zmtp peer error: %s", zmtpErrorReason(data))
+			}
+			continue
+		}
+		parts = append(parts, body)
+		if flags&zmtpFlagMore == 0 {
+			return parts, nil
+		}
+	}
+}
+
+func (c *zmtpConn) Close() error {
+	return c.conn.Close()
+}
+
+func zmtpCommand(name string, data []byte) []byte {
+	body := make([]byte, 0, 1+len(name)+len(data))
+	body = append(body, byte(len(name)))
+	body = append(body, name...)
+	return append(body, data...)
+}
+
+func zmtpProperty(name, value string) []byte {
+	p := make([]byte, 0, 1+len(name)+4+len(value))
+	p = append(p, byte(len(name)))
+	p = append(p, name...)
+	p = binary.BigEndian.AppendUint32(p, uint32(len(value)))
+	return append(p, value...)
+}
+
+func zmtpParseCommand(flags byte, body []byte) (string, []byte, error) {
+	if flags&zmtpFlagCommand == 0 {
+		return "", nil, fmt.Errorf("expected command frame, got message")
+	}
+	if len(body) < 1 || len(body) < 1+int(body[0]) {
+		return "", nil, fmt.Errorf("malformed command frame")
+	}
+	n := int(body[0])
+	return string(body[1 : 1+n]), body[1+n:], nil
+}
+
+// zmtpParseProperties decodes READY metadata; names are case-insensitive so
+// they are returned lower-cased.
+func zmtpParseProperties(data []byte) (map[string]string, error) {
+	props := make(map[string]string)
+	for len(data) > 0 {
+		n := int(data[0])
+		if len(data) < 1+n+4 {
+			return nil, fmt.Errorf("malformed property")
+		}
+		name := string(data[1 : 1+n])
+		data = data[1+n:]
+		size := binary.BigEndian.Uint32(data)
+		data = data[4:]
+		if uint64(len(data)) < uint64(size) {
+			return nil, fmt.Errorf("malformed property %q", name)
+		}
+		props[strings.ToLower(name)] = string(data[:size])
+		data = data[size:]
+	}
+	return props, nil
+}
+
+func zmtpErrorReason(data []byte) string {
+	if len(data) < 1 || len(data) < 1+int(data[0]) {
+		return "unknown"
+	}
+	return string(data[1 : 1+int(data[0])])
+}
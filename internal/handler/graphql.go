@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/internal/service"
+	"isac-cran-system/pkg/graphql"
+	"isac-cran-system/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GraphQLHandler serves read-only GraphQL queries over experiments, their
+// artifacts and KPIs, sensors and device status.
+type GraphQLHandler struct {
+	schema *graphql.Schema
+}
+
+func NewGraphQLHandler(algorithmSvc *service.AlgorithmService, artifactSvc *service.ArtifactService,
+	sensorSvc *service.SensorService, deviceSvc *service.DeviceService, irsSvc *service.IRSService) *GraphQLHandler {
+	artifact := graphql.StructObject("Artifact", model.Artifact{})
+
+	powerEstimate := graphql.StructObject("PowerEstimate", model.PowerEstimate{})
+	energyReport := graphql.StructObject("EnergyReport", model.EnergyReport{})
+	kpis := &graphql.Object{Name: "KPIs", Fields: map[string]*graphql.Field{}}
+	for _, name := range model.KPINames {
+		kpis.Fields[name] = &graphql.Field{}
+	}
+
+	experiment := graphql.StructObject("Experiment", model.ExperimentResult{})
+	experiment.Fields["parameters"] = &graphql.Field{Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		return rawJSON(&p.Source.(*model.ExperimentResult).Parameters), nil
+	}}
+	experiment.Fields["result_data"] = &graphql.Field{Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		return rawJSON(p.Source.(*model.ExperimentResult).ResultData), nil
+	}}
+	experiment.Fields["power_estimate"] = &graphql.Field{
+		Type: powerEstimate,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			var estimate *model.PowerEstimate
+			if err := decodeStored(p.Source.(*model.ExperimentResult).PowerEstimate, &estimate); err != nil {
+				return nil, err
+			}
+			return estimate, nil
+		},
+	}
+	experiment.Fields["energy_report"] = &graphql.Field{
+		Type: energyReport,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			var report *model.EnergyReport
+			if err := decodeStored(p.Source.(*model.ExperimentResult).EnergyReport, &report); err != nil {
+				return nil, err
+			}
+			return report, nil
+		},
+	}
+	experiment.Fields["artifacts"] = &graphql.Field{
+		Type: artifact,
+		Args: []string{"artifact_type"},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			artifacts, _, err := artifactSvc.List(p.Context, &model.ArtifactQuery{
+				ExperimentID: p.Source.(*model.ExperimentResult).ExperimentID,
+				ArtifactType: model.ArtifactType(p.Args.String("artifact_type")),
+				PageSize:     100,
+			})
+			return artifacts, err
+		},
+	}
+	experiment.Fields["kpis"] = &graphql.Field{
+		Type: kpis,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.ExperimentResult).KPIs()
+		},
+	}
+
+	experimentPage := &graphql.Object{Name: "ExperimentPage", Fields: map[string]*graphql.Field{
+		"total":     {},
+		"page":      {},
+		"page_size": {},
+		"items":     {Type: experiment},
+	}}
+
+	irsStatus := graphql.StructObject("IRSStatus", model.IRSStatus{})
+	irsPanel := graphql.StructObject("IRSPanel", model.IRSPanel{})
+	irsPanel.Fields["status"] = &graphql.Field{
+		Type: irsStatus,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return irsSvc.GetStatus(p.Context, p.Source.(model.IRSPanel).ID)
+		},
+	}
+
+	query := &graphql.Object{Name: "Query", Fields: map[string]*graphql.Field{
+		"experiments": {
+			Type: experimentPage,
+			Args: []string{"algorithm_type", "page", "page_size"},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				page, err := p.Args.Int("page", 1)
+				if err != nil {
+					return nil, err
+				}
+				pageSize, err := p.Args.Int("page_size", 20)
+				if err != nil {
+					return nil, err
+				}
+				query := &model.ExperimentQuery{
+					AlgorithmType: model.AlgorithmType(p.Args.String("algorithm_type")),
+					Page:          page,
+					PageSize:      pageSize,
+				}
+				results, total, err := algorithmSvc.ListResults(p.Context, query)
+				if err != nil {
+					return nil, err
+				}
+				items := make([]*model.ExperimentResult, len(results))
+				for i := range results {
+					items[i] = &results[i]
+				}
+				return map[string]interface{}{"total": total, "page": query.Page, "page_size": query.PageSize, "items": items}, nil
+			},
+		},
+		"experiment": {
+			Type: experiment,
+			Args: []string{"experiment_id"},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return algorithmSvc.GetResult(p.Context, p.Args.String("experiment_id"))
+			},
+		},
+		"sensors": {
+			Type: graphql.StructObject("Sensor", model.SensorInfo{}),
+			Args: []string{"type"},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return sensorSvc.ListSensors(p.Context, model.SensorType(p.Args.String("type")))
+			},
+		},
+		"devices": {
+			Type: graphql.StructObject("Device", model.DeviceInfo{}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return deviceSvc.List(), nil
+			},
+		},
+		"irs_panels": {
+			Type: irsPanel,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return irsSvc.Panels(), nil
+			},
+		},
+	}}
+
+	return &GraphQLHandler{schema: graphql.NewSchema(query)}
+}
+
+// maxGraphQLBody bounds the JSON body of a query.
+const maxGraphQLBody = 64 << 10
+
+// Query runs a query sent as a JSON body or, for GET, in the query,
+// operationName and variables parameters. Requests that do not parse or validate get 400;
+// field errors come back with 200 next to the data resolved.
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	var req graphql.Request
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if vars := c.Query("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				response.BadRequest(c, "invalid variables: "+err.Error())
+				return
+			}
+		}
+	} else {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxGraphQLBody)
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+	}
+	if req.Query == "" {
+		response.BadRequest(c, "query is required")
+		return
+	}
+
+	resp := graphql.Do(c.Request.Context(), h.schema, req)
+	status := http.StatusOK
+	if resp.Data == nil {
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, resp)
+}
+
+// rawJSON passes a stored JSON column through as a value rather than a
+// string.
+func rawJSON(data *string) interface{} {
+	if data == nil || *data == "" || !json.Valid([]byte(*data)) {
+		return nil
+	}
+	return json.RawMessage(*data)
+}
+
+func decodeStored(data *string, v interface{}) error {
+	if data == nil || *data == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(*data), v)
+}
@@ -1,14 +1,34 @@
 package handler
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"isac-cran-system/internal/middleware"
 	"isac-cran-system/internal/model"
 	"isac-cran-system/internal/service"
+	"isac-cran-system/pkg/arrow"
+	"isac-cran-system/pkg/election"
 	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/hub"
 	"isac-cran-system/pkg/response"
 
 	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
 )
 
+// holderContext names the authenticated caller as the holder for device
+// reservation checks. Anonymous callers hold no reservation.
+func holderContext(c *gin.Context) context.Context {
+	return service.WithHolder(c.Request.Context(), c.GetString(middleware.PrincipalKey))
+}
+
 type IRSHandler struct {
 	service *service.IRSService
 }
@@ -17,6 +37,111 @@ func NewIRSHandler(service *service.IRSService) *IRSHandler {
 	return &IRSHandler{service: service}
 }
 
+// irsID selects the IRS panel; without it the default panel is used.
+func irsID(c *gin.Context) string {
+	return c.Query("irs_id")
+}
+
+// dryRun reports whether the request only asks for validation and a cost
+// estimate, via ?dry_run=true.
+func dryRun(c *gin.Context) bool {
+	v, _ := strconv.ParseBool(c.Query("dry_run"))
+	return v
+}
+
+// invalidParams answers 400 with every rule the parameters of an algorithm
+// run break, before anything runs, and reports whether it did. A dry run
+// reports the same violations among its diagnostics.
+func invalidParams(c *gin.Context, violations model.Diagnostics) bool {
+	if len(violations) == 0 {
+		return false
+	}
+	response.ErrorWithData(c, errors.New(errors.CodeInvalidParam, fmt.Sprintf("%d invalid parameters", len(violations))), violations)
+	return true
+}
+
+// runContext is holderContext for algorithm runs, which skip the result
+// cache with ?no_cache=true.
+func runContext(c *gin.Context) context.Context {
+	ctx := holderContext(c)
+	if noCache, _ := strconv.ParseBool(c.Query("no_cache")); noCache {
+		ctx = service.WithCacheBypass(ctx)
+	}
+	return ctx
+}
+
+const (
+	// maxWait keeps a long poll within the server's write timeout.
+	maxWait      = 25 * time.Second
+	pollInterval = 200 * time.Millisecond
+)
+
+// waitParam parses ?wait=, given as a duration or in seconds.
+func waitParam(c *gin.Context) (time.Duration, error) {
+	v := c.Query("wait")
+	if v == "" {
+		return 0, nil
+	}
+	wait, err := time.ParseDuration(v)
+	if err != nil {
+		seconds, perr := strconv.ParseFloat(v, 64)
+		if perr != nil {
+			return 0, fmt.Errorf("invalid wait: %s", v)
+		}
+		wait = time.Duration(seconds * float64(time.Second))
+	}
+	if wait < 0 {
+		return 0, fmt.Errorf("wait must not be negative")
+	}
+	if wait > maxWait {
+		wait = maxWait
+	}
+	return wait, nil
+}
+
+// conditionalGet answers a GET with an ETag. A client that sends the ETag it
+// holds in If-None-Match gets 304 when nothing changed; with ?wait= the
+// request is held until the resource changes or the wait runs out, so that
+// clients learn of changes as they happen without polling fast.
+func conditionalGet(c *gin.Context, fetch func(ctx context.Context) (data, tagged interface{}, err error)) {
+	wait, err := waitParam(c)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	ctx := c.Request.Context()
+	deadline := time.Now().Add(wait)
+	for {
+		data, tagged, err := fetch(ctx)
+		if err != nil {
+			response.Error(c, err)
+			return
+		}
+		etag, err := response.ETag(tagged)
+		if err != nil {
+			response.Error(c, err)
+			return
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 || !response.NotModified(c, etag) {
+			response.Conditional(c, etag, data)
+			return
+		}
+
+		timer := time.NewTimer(min(remaining, pollInterval))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (h *IRSHandler) ListPanels(c *gin.Context) {
+	response.Success(c, h.service.Panels())
+}
+
 func (h *IRSHandler) Configure(c *gin.Context) {
 	var req model.IRSConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -24,12 +149,22 @@ func (h *IRSHandler) Configure(c *gin.Context) {
 		return
 	}
 
+	if dryRun(c) {
+		result, err := h.service.DryRunConfigure(holderContext(c), irsID(c), &req)
+		if err != nil {
+			response.Error(c, err)
+			return
+		}
+		response.Success(c, result)
+		return
+	}
+
 	if err := req.Validate(); err != nil {
 		response.ErrorWithCode(c, errors.CodeInvalidIRSConfig, err.Error())
 		return
 	}
 
-	config, err := h.service.Configure(c.Request.Context(), &req)
+	config, err := h.service.Configure(holderContext(c), irsID(c), &req)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -39,17 +174,24 @@ func (h *IRSHandler) Configure(c *gin.Context) {
 }
 
 func (h *IRSHandler) GetStatus(c *gin.Context) {
-	status, err := h.service.GetStatus(c.Request.Context())
+	conditionalGet(c, func(ctx context.Context) (interface{}, interface{}, error) {
+		status, err := h.service.GetStatus(ctx, irsID(c))
+		if err != nil {
+			return nil, nil, err
+		}
+		// the read time alone is no change
+		tagged := *status
+		tagged.LastUpdate = time.Time{}
+		return status, tagged, nil
+	})
+}
+
+func (h *IRSHandler) GetCurrentConfig(c *gin.Context) {
+	config, err := h.service.GetCurrentConfig(irsID(c))
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
-
-	response.Success(c, status)
-}
-
-func (h *IRSHandler) GetCurrentConfig(c *gin.Context) {
-	config := h.service.GetCurrentConfig()
 	if config == nil {
 		response.NotFound(c, "no active IRS configuration")
 		return
@@ -59,15 +201,16 @@ func (h *IRSHandler) GetCurrentConfig(c *gin.Context) {
 }
 
 func (h *IRSHandler) ApplyOptimal(c *gin.Context) {
-	var req struct {
-		TargetAngle float64 `json:"target_angle" binding:"required"`
-	}
+	var req model.IRSOptimalRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, "invalid request body: "+err.Error())
 		return
 	}
+	if invalidParams(c, req.Violations()) {
+		return
+	}
 
-	config, err := h.service.ApplyOptimalPhaseShifts(c.Request.Context(), req.TargetAngle)
+	config, err := h.service.ApplyOptimalPhaseShifts(holderContext(c), irsID(c), &req)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -76,230 +219,1985 @@ func (h *IRSHandler) ApplyOptimal(c *gin.Context) {
 	response.Success(c, config)
 }
 
-type ChannelHandler struct {
-	service *service.ChannelService
-}
-
-func NewChannelHandler(service *service.ChannelService) *ChannelHandler {
-	return &ChannelHandler{service: service}
-}
-
-func (h *ChannelHandler) Collect(c *gin.Context) {
-	var req model.ChannelCollectRequest
+func (h *IRSHandler) ConfigureGroup(c *gin.Context) {
+	var req model.IRSGroupRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, "invalid request body: "+err.Error())
 		return
 	}
 
-	data, err := h.service.CollectData(c.Request.Context(), &req)
+	if err := req.Validate(); err != nil {
+		response.ErrorWithCode(c, errors.CodeInvalidIRSConfig, err.Error())
+		return
+	}
+
+	config, err := h.service.ConfigureGroup(holderContext(c), irsID(c), c.Param("name"), &req)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.Success(c, data)
+	response.Success(c, config)
 }
 
-func (h *ChannelHandler) Query(c *gin.Context) {
-	var query model.ChannelDataQuery
+func (h *IRSHandler) History(c *gin.Context) {
+	var query model.IRSHistoryQuery
 	if err := c.ShouldBindQuery(&query); err != nil {
-		response.BadRequest(c, "invalid query parameters: "+err.Error())
+		response.BadRequest(c, err.Error())
 		return
 	}
 
-	if query.Page == 0 {
-		query.Page = 1
+	configs, total, err := h.service.History(c.Request.Context(), &query)
+	if err != nil {
+		response.Error(c, err)
+		return
 	}
-	if query.PageSize == 0 {
-		query.PageSize = 20
+
+	response.SuccessPage(c, configs, total, query.Page, query.PageSize)
+}
+
+func (h *IRSHandler) Rollback(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "invalid irs config id")
+		return
+	}
+	var req model.IRSRollbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
 	}
 
-	data, total, err := h.service.QueryData(c.Request.Context(), &query)
+	config, err := h.service.Rollback(holderContext(c), id, &req)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.SuccessPage(c, data, total, query.Page, query.PageSize)
+	response.Success(c, config)
 }
 
-func (h *ChannelHandler) GetRealtime(c *gin.Context) {
-	data, err := h.service.GetRealtimeData(c.Request.Context())
+func (h *IRSHandler) CreateCodebook(c *gin.Context) {
+	var req model.IRSCodebookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		response.ErrorWithCode(c, errors.CodeInvalidParam, err.Error())
+		return
+	}
+
+	codebook, err := h.service.CreateCodebook(c.Request.Context(), &req)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.Success(c, data)
+	response.Success(c, codebook)
 }
 
-type AlgorithmHandler struct {
-	service *service.AlgorithmService
+func (h *IRSHandler) ListCodebooks(c *gin.Context) {
+	codebooks, err := h.service.ListCodebooks(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, codebooks)
 }
 
-func NewAlgorithmHandler(service *service.AlgorithmService) *AlgorithmHandler {
-	return &AlgorithmHandler{service: service}
+func (h *IRSHandler) GetCodebook(c *gin.Context) {
+	codebook, err := h.service.GetCodebook(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, codebook)
 }
 
-func (h *AlgorithmHandler) RunBeamforming(c *gin.Context) {
-	var req struct {
-		ExperimentID string                  `json:"experiment_id" binding:"required"`
-		Params       model.BeamformingParams `json:"params" binding:"required"`
+func (h *IRSHandler) DeleteCodebook(c *gin.Context) {
+	if err := h.service.DeleteCodebook(c.Request.Context(), c.Param("name")); err != nil {
+		response.Error(c, err)
+		return
 	}
+
+	response.Success(c, nil)
+}
+
+func (h *IRSHandler) ApplyCodebook(c *gin.Context) {
+	var req model.IRSCodebookApplyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, "invalid request body: "+err.Error())
 		return
 	}
 
-	result, err := h.service.RunBeamforming(c.Request.Context(), req.ExperimentID, &req.Params)
+	config, err := h.service.ApplyCodebookEntry(holderContext(c), irsID(c), c.Param("name"), &req)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.Success(c, result)
+	response.Success(c, config)
 }
 
-func (h *AlgorithmHandler) RunDOA(c *gin.Context) {
-	var req struct {
-		ExperimentID string          `json:"experiment_id" binding:"required"`
-		Params       model.DOAParams `json:"params" binding:"required"`
-	}
+func (h *IRSHandler) StartSequence(c *gin.Context) {
+	var req model.IRSSequenceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, "invalid request body: "+err.Error())
 		return
 	}
 
-	result, err := h.service.RunDOA(c.Request.Context(), req.ExperimentID, &req.Params)
+	if dryRun(c) {
+		result, err := h.service.DryRunSequence(holderContext(c), irsID(c), &req)
+		if err != nil {
+			response.Error(c, err)
+			return
+		}
+		response.Success(c, result)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		response.ErrorWithCode(c, errors.CodeInvalidIRSConfig, err.Error())
+		return
+	}
+
+	status, err := h.service.StartSequence(holderContext(c), irsID(c), &req)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.Success(c, result)
+	response.Success(c, status)
 }
 
-func (h *AlgorithmHandler) GetResult(c *gin.Context) {
-	experimentID := c.Param("id")
-	if experimentID == "" {
-		response.BadRequest(c, "experiment id is required")
+func (h *IRSHandler) GetConstraints(c *gin.Context) {
+	report, err := h.service.Constraints(irsID(c))
+	if err != nil {
+		response.Error(c, err)
 		return
 	}
 
-	result, err := h.service.GetResult(c.Request.Context(), experimentID)
+	response.Success(c, report)
+}
+
+func (h *IRSHandler) GetSequence(c *gin.Context) {
+	status, err := h.service.SequenceStatus(irsID(c))
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.Success(c, result)
+	response.Success(c, status)
 }
 
-func (h *AlgorithmHandler) ListResults(c *gin.Context) {
-	algorithmType := c.Query("algorithm_type")
-	page := 1
-	pageSize := 20
-
-	results, total, err := h.service.ListResults(c.Request.Context(), model.AlgorithmType(algorithmType), page, pageSize)
+func (h *IRSHandler) StopSequence(c *gin.Context) {
+	status, err := h.service.StopSequence(irsID(c))
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.SuccessPage(c, results, total, page, pageSize)
+	response.Success(c, status)
 }
 
-type SensorHandler struct {
-	service *service.SensorService
-}
+func (h *IRSHandler) GetFaults(c *gin.Context) {
+	state, err := h.service.SimulatorState(irsID(c))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
 
-func NewSensorHandler(service *service.SensorService) *SensorHandler {
-	return &SensorHandler{service: service}
+	response.Success(c, state)
 }
 
-func (h *SensorHandler) List(c *gin.Context) {
-	sensorType := c.Query("sensor_type")
+func (h *IRSHandler) InjectFaults(c *gin.Context) {
+	var req model.IRSFaultRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
 
-	sensors, err := h.service.ListSensors(c.Request.Context(), model.SensorType(sensorType))
+	state, err := h.service.InjectFaults(irsID(c), &req)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.Success(c, sensors)
+	response.Success(c, state)
 }
 
-func (h *SensorHandler) GetData(c *gin.Context) {
-	var query model.SensorDataQuery
-	if err := c.ShouldBindQuery(&query); err != nil {
-		response.BadRequest(c, "invalid query parameters: "+err.Error())
+func (h *IRSHandler) ClearFaults(c *gin.Context) {
+	state, err := h.service.ClearFaults(irsID(c))
+	if err != nil {
+		response.Error(c, err)
 		return
 	}
 
-	if query.Page == 0 {
-		query.Page = 1
+	response.Success(c, state)
+}
+
+// maxFirmwareSize bounds an uploaded firmware image.
+const maxFirmwareSize = 16 << 20
+
+// UploadFirmware stores the request body as a firmware image of the version
+// query parameter; sha256, when given, must match the image.
+func (h *IRSHandler) UploadFirmware(c *gin.Context) {
+	image, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxFirmwareSize))
+	if err != nil {
+		response.BadRequest(c, "invalid firmware image: "+err.Error())
+		return
 	}
-	if query.PageSize == 0 {
-		query.PageSize = 20
+
+	pkg, err := h.service.UploadFirmware(c.Query("version"), c.Query("sha256"), image)
+	if err != nil {
+		response.Error(c, err)
+		return
 	}
 
-	data, err := h.service.GetSensorData(c.Request.Context(), &query)
+	response.Success(c, pkg)
+}
+
+func (h *IRSHandler) ListFirmware(c *gin.Context) {
+	response.Success(c, h.service.FirmwarePackages())
+}
+
+func (h *IRSHandler) ScheduleFirmwareUpdate(c *gin.Context) {
+	var req model.FirmwareUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.ErrorWithCode(c, errors.CodeInvalidParam, err.Error())
+		return
+	}
+
+	update, err := h.service.ScheduleFirmwareUpdate(holderContext(c), &req)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.Success(c, data)
+	response.Success(c, update)
 }
 
-func (h *SensorHandler) ReadSensor(c *gin.Context) {
-	sensorID := c.Param("id")
-	if sensorID == "" {
-		response.BadRequest(c, "sensor id is required")
+func (h *IRSHandler) ListFirmwareUpdates(c *gin.Context) {
+	response.Success(c, h.service.FirmwareUpdates(c.Query("irs_id")))
+}
+
+func (h *IRSHandler) GetFirmwareUpdate(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "invalid firmware update id")
 		return
 	}
 
-	data, err := h.service.ReadSensor(c.Request.Context(), sensorID)
+	update, err := h.service.GetFirmwareUpdate(id)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.Success(c, data)
+	response.Success(c, update)
 }
 
-func (h *SensorHandler) StartCollection(c *gin.Context) {
-	err := h.service.StartCollection(c.Request.Context())
+func (h *IRSHandler) CancelFirmwareUpdate(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "invalid firmware update id")
+		return
+	}
+
+	update, err := h.service.CancelFirmwareUpdate(id)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.SuccessWithMessage(c, "sensor data collection started", nil)
+	response.Success(c, update)
 }
 
-func (h *SensorHandler) StopCollection(c *gin.Context) {
-	h.service.StopCollection()
-	response.SuccessWithMessage(c, "sensor data collection stopped", nil)
+type ChannelHandler struct {
+	service *service.ChannelService
+	beams   *service.BeamService
 }
 
-type SystemHandler struct{}
+func NewChannelHandler(service *service.ChannelService) *ChannelHandler {
+	return &ChannelHandler{service: service}
+}
 
-func NewSystemHandler() *SystemHandler {
-	return &SystemHandler{}
+func (h *ChannelHandler) SetBeamService(beams *service.BeamService) {
+	h.beams = beams
 }
 
-func (h *SystemHandler) Health(c *gin.Context) {
-	response.Success(c, gin.H{
-		"status":    "healthy",
-		"timestamp": "now",
-	})
+func (h *ChannelHandler) Collect(c *gin.Context) {
+	var req model.ChannelCollectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	data, err := h.service.CollectData(c.Request.Context(), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, data)
 }
 
-func (h *SystemHandler) Info(c *gin.Context) {
-	response.Success(c, gin.H{
-		"name":        "ISAC-CRAN System",
-		"version":     "1.0.0",
-		"description": "Intelligent Reflecting Surface assisted C-RAN ISAC Experimental Prototype System",
-	})
+func (h *ChannelHandler) Query(c *gin.Context) {
+	var query model.ChannelDataQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "invalid query parameters: "+err.Error())
+		return
+	}
+
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 20
+	}
+
+	data, total, err := h.service.QueryData(c.Request.Context(), &query)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	if response.WantsArrow(c) {
+		response.Arrow(c, channelColumns(data)...)
+		return
+	}
+
+	response.SuccessPage(c, data, total, query.Page, query.PageSize)
+}
+
+func (h *ChannelHandler) Probe(c *gin.Context) {
+	var req model.ProbeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	result, err := h.service.EmitProbe(holderContext(c), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+func (h *ChannelHandler) GetRealtime(c *gin.Context) {
+	data, err := h.service.GetRealtimeData(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, data)
+}
+
+func (h *ChannelHandler) Annotate(c *gin.Context) {
+	var req model.ChannelAnnotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		response.ErrorWithCode(c, errors.CodeInvalidParam, err.Error())
+		return
+	}
+
+	annotation, err := h.service.Annotate(c.Request.Context(), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, annotation)
+}
+
+func (h *ChannelHandler) ListAnnotations(c *gin.Context) {
+	var query model.ChannelAnnotationQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "invalid query parameters: "+err.Error())
+		return
+	}
+
+	annotations, err := h.service.ListAnnotations(c.Request.Context(), &query)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, annotations)
+}
+
+func (h *ChannelHandler) GetAnnotation(c *gin.Context) {
+	annotation, err := h.service.GetAnnotation(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, annotation)
+}
+
+func (h *ChannelHandler) DeleteAnnotation(c *gin.Context) {
+	if err := h.service.DeleteAnnotation(c.Request.Context(), c.Param("id")); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// StartBeamSweep starts a beam sweep and answers at once; the sweep runs in
+// the background.
+func (h *ChannelHandler) StartBeamSweep(c *gin.Context) {
+	var req model.BeamSweepRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+	if h.beams == nil {
+		response.Error(c, errors.New(errors.CodeServiceUnavailable, "beam sweeps not available"))
+		return
+	}
+	if invalidParams(c, req.Violations()) {
+		return
+	}
+
+	sweep, err := h.beams.Start(holderContext(c), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, sweep)
+}
+
+func (h *ChannelHandler) StopBeamSweep(c *gin.Context) {
+	if h.beams == nil {
+		response.Error(c, errors.New(errors.CodeServiceUnavailable, "beam sweeps not available"))
+		return
+	}
+
+	sweep, err := h.beams.Stop(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, sweep)
+}
+
+func (h *ChannelHandler) GetBeamSweep(c *gin.Context) {
+	if h.beams == nil {
+		response.Error(c, errors.New(errors.CodeServiceUnavailable, "beam sweeps not available"))
+		return
+	}
+
+	sweep, err := h.beams.Get(c.Param("id"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, sweep)
+}
+
+func (h *ChannelHandler) ListBeamSweeps(c *gin.Context) {
+	if h.beams == nil {
+		response.Error(c, errors.New(errors.CodeServiceUnavailable, "beam sweeps not available"))
+		return
+	}
+
+	response.Success(c, h.beams.List())
+}
+
+// BeamSweepResult returns the power of every beam measured so far and the
+// tracking history of a sweep.
+func (h *ChannelHandler) BeamSweepResult(c *gin.Context) {
+	if h.beams == nil {
+		response.Error(c, errors.New(errors.CodeServiceUnavailable, "beam sweeps not available"))
+		return
+	}
+
+	result, err := h.beams.Result(c.Param("id"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+type AlgorithmHandler struct {
+	service   *service.AlgorithmService
+	pipelines *service.PipelineService
+}
+
+func NewAlgorithmHandler(service *service.AlgorithmService) *AlgorithmHandler {
+	return &AlgorithmHandler{service: service}
+}
+
+func (h *AlgorithmHandler) SetPipelineService(pipelines *service.PipelineService) {
+	h.pipelines = pipelines
+}
+
+func (h *AlgorithmHandler) RunBeamforming(c *gin.Context) {
+	var req struct {
+		ExperimentID string                  `json:"experiment_id" binding:"required"`
+		Params       model.BeamformingParams `json:"params" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	if dryRun(c) {
+		response.Success(c, h.service.DryRunBeamforming(holderContext(c), req.ExperimentID, &req.Params))
+		return
+	}
+
+	if invalidParams(c, req.Params.Violations()) {
+		return
+	}
+
+	result, err := h.service.RunBeamforming(runContext(c), req.ExperimentID, &req.Params)
+	if queued, ok := err.(*service.ExperimentQueuedError); ok {
+		response.Accepted(c, queued.Queued)
+		return
+	}
+	if rejected, ok := err.(*service.ExperimentRejectedError); ok {
+		response.ErrorWithData(c, rejected.Err, rejected.Estimate)
+		return
+	}
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+func (h *AlgorithmHandler) RunDOA(c *gin.Context) {
+	var req struct {
+		ExperimentID string          `json:"experiment_id" binding:"required"`
+		Params       model.DOAParams `json:"params" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	if dryRun(c) {
+		response.Success(c, h.service.DryRunDOA(holderContext(c), req.ExperimentID, &req.Params))
+		return
+	}
+
+	if invalidParams(c, req.Params.Violations()) {
+		return
+	}
+
+	result, err := h.service.RunDOA(runContext(c), req.ExperimentID, &req.Params)
+	if queued, ok := err.(*service.ExperimentQueuedError); ok {
+		response.Accepted(c, queued.Queued)
+		return
+	}
+	if rejected, ok := err.(*service.ExperimentRejectedError); ok {
+		response.ErrorWithData(c, rejected.Err, rejected.Estimate)
+		return
+	}
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+func (h *AlgorithmHandler) RunIRSImpact(c *gin.Context) {
+	var req struct {
+		ExperimentID string                `json:"experiment_id" binding:"required"`
+		Params       model.IRSImpactParams `json:"params"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	if dryRun(c) {
+		response.Success(c, h.service.DryRunIRSImpact(holderContext(c), req.ExperimentID, &req.Params))
+		return
+	}
+
+	if invalidParams(c, req.Params.Violations()) {
+		return
+	}
+
+	result, err := h.service.RunIRSImpact(holderContext(c), req.ExperimentID, &req.Params)
+	if queued, ok := err.(*service.ExperimentQueuedError); ok {
+		response.Accepted(c, queued.Queued)
+		return
+	}
+	if rejected, ok := err.(*service.ExperimentRejectedError); ok {
+		response.ErrorWithData(c, rejected.Err, rejected.Estimate)
+		return
+	}
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+func (h *AlgorithmHandler) RunJointBeamforming(c *gin.Context) {
+	var req struct {
+		ExperimentID string                       `json:"experiment_id" binding:"required"`
+		Params       model.JointBeamformingParams `json:"params"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	if dryRun(c) {
+		response.Success(c, h.service.DryRunJointBeamforming(holderContext(c), req.ExperimentID, &req.Params))
+		return
+	}
+
+	if invalidParams(c, req.Params.Violations()) {
+		return
+	}
+
+	result, err := h.service.RunJointBeamforming(holderContext(c), req.ExperimentID, &req.Params)
+	if queued, ok := err.(*service.ExperimentQueuedError); ok {
+		response.Accepted(c, queued.Queued)
+		return
+	}
+	if rejected, ok := err.(*service.ExperimentRejectedError); ok {
+		response.ErrorWithData(c, rejected.Err, rejected.Estimate)
+		return
+	}
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+func (h *AlgorithmHandler) RunHybridBeamforming(c *gin.Context) {
+	var req struct {
+		ExperimentID string                        `json:"experiment_id" binding:"required"`
+		Params       model.HybridBeamformingParams `json:"params"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	if dryRun(c) {
+		response.Success(c, h.service.DryRunHybridBeamforming(holderContext(c), req.ExperimentID, &req.Params))
+		return
+	}
+
+	if invalidParams(c, req.Params.Violations()) {
+		return
+	}
+
+	result, err := h.service.RunHybridBeamforming(holderContext(c), req.ExperimentID, &req.Params)
+	if queued, ok := err.(*service.ExperimentQueuedError); ok {
+		response.Accepted(c, queued.Queued)
+		return
+	}
+	if rejected, ok := err.(*service.ExperimentRejectedError); ok {
+		response.ErrorWithData(c, rejected.Err, rejected.Estimate)
+		return
+	}
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// RunBatch answers 200 with a result per item even when some items fail.
+func (h *AlgorithmHandler) RunBatch(c *gin.Context) {
+	var req model.AlgorithmBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		response.ErrorWithCode(c, errors.CodeInvalidParam, err.Error())
+		return
+	}
+
+	response.Success(c, h.service.RunBatch(runContext(c), &req))
+}
+
+func (h *AlgorithmHandler) CacheStats(c *gin.Context) {
+	response.Success(c, h.service.CacheStats())
+}
+
+// InvalidateCache drops cached results, only those of ?algorithm_type when
+// it is set.
+func (h *AlgorithmHandler) InvalidateCache(c *gin.Context) {
+	algorithmType := model.AlgorithmType(c.Query("algorithm_type"))
+	switch algorithmType {
+	case "", model.AlgorithmTypeBeamforming, model.AlgorithmTypeDOA:
+	default:
+		response.ErrorWithCode(c, errors.CodeInvalidParam, "algorithm_type must be beamforming or doa")
+		return
+	}
+
+	response.Success(c, gin.H{"invalidated": h.service.InvalidateCache(algorithmType)})
+}
+
+// SubmitPipeline queues a pipeline and answers with its pending run; poll
+// GetPipeline for the step results.
+func (h *AlgorithmHandler) SubmitPipeline(c *gin.Context) {
+	var req model.PipelineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.ErrorWithCode(c, errors.CodeInvalidParam, err.Error())
+		return
+	}
+	if h.pipelines == nil {
+		response.Error(c, errors.New(errors.CodeServiceUnavailable, "pipelines not available"))
+		return
+	}
+
+	run, err := h.pipelines.Submit(holderContext(c), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Accepted(c, run)
+}
+
+func (h *AlgorithmHandler) GetPipeline(c *gin.Context) {
+	if h.pipelines == nil {
+		response.Error(c, errors.New(errors.CodeServiceUnavailable, "pipelines not available"))
+		return
+	}
+
+	run, err := h.pipelines.Get(c.Param("id"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, run)
+}
+
+func (h *AlgorithmHandler) ListPipelines(c *gin.Context) {
+	if h.pipelines == nil {
+		response.Error(c, errors.New(errors.CodeServiceUnavailable, "pipelines not available"))
+		return
+	}
+
+	response.Success(c, h.pipelines.List())
+}
+
+func (h *AlgorithmHandler) StartOnlineDOA(c *gin.Context) {
+	var req model.OnlineDOARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	status, err := h.service.StartOnlineDOA(holderContext(c), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, status)
+}
+
+func (h *AlgorithmHandler) GetOnlineDOA(c *gin.Context) {
+	response.Success(c, h.service.OnlineDOA())
+}
+
+func (h *AlgorithmHandler) StopOnlineDOA(c *gin.Context) {
+	response.Success(c, h.service.StopOnlineDOA())
+}
+
+func (h *AlgorithmHandler) GetResult(c *gin.Context) {
+	experimentID := c.Param("id")
+	if experimentID == "" {
+		response.BadRequest(c, "experiment id is required")
+		return
+	}
+
+	conditionalGet(c, func(ctx context.Context) (interface{}, interface{}, error) {
+		result, err := h.service.GetResult(ctx, experimentID)
+		return result, result, err
+	})
+}
+
+func (h *AlgorithmHandler) GetSnapshots(c *gin.Context) {
+	var query model.SnapshotQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	switch query.Format {
+	case "", "npy":
+		rc, artifact, err := h.service.OpenSnapshots(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			response.Error(c, err)
+			return
+		}
+		defer rc.Close()
+
+		c.DataFromReader(http.StatusOK, artifact.Size, "application/octet-stream", rc, map[string]string{
+			"Content-Disposition": fmt.Sprintf("attachment; filename=%q", c.Param("id")+".npy"),
+			"X-Checksum-SHA256":   artifact.Checksum,
+		})
+	case "json":
+		window, err := h.service.SnapshotWindow(c.Request.Context(), c.Param("id"), query.Start, query.Count)
+		if err != nil {
+			response.Error(c, err)
+			return
+		}
+		response.Success(c, window)
+	default:
+		response.BadRequest(c, "unsupported snapshot format: "+query.Format)
+	}
+}
+
+func (h *AlgorithmHandler) ListResults(c *gin.Context) {
+	var query model.ExperimentQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "invalid query parameters: "+err.Error())
+		return
+	}
+	if err := query.Validate(); err != nil {
+		response.ErrorWithCode(c, errors.CodeInvalidParam, err.Error())
+		return
+	}
+
+	results, total, err := h.service.ListResults(c.Request.Context(), &query)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessPage(c, results, total, query.Page, query.PageSize)
+}
+
+func (h *AlgorithmHandler) EnergyRanking(c *gin.Context) {
+	algorithmType := c.Query("algorithm_type")
+
+	ranking, err := h.service.RankByEnergy(c.Request.Context(), model.AlgorithmType(algorithmType))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, ranking)
+}
+
+type SensorHandler struct {
+	service *service.SensorService
+}
+
+func NewSensorHandler(service *service.SensorService) *SensorHandler {
+	return &SensorHandler{service: service}
+}
+
+func (h *SensorHandler) List(c *gin.Context) {
+	sensorType := c.Query("sensor_type")
+
+	sensors, err := h.service.ListSensors(c.Request.Context(), model.SensorType(sensorType))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, sensors)
+}
+
+func (h *SensorHandler) Health(c *gin.Context) {
+	health, err := h.service.Health(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, health)
+}
+
+func (h *SensorHandler) GetData(c *gin.Context) {
+	var query model.SensorDataQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "invalid query parameters: "+err.Error())
+		return
+	}
+
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 20
+	}
+	if err := query.Validate(); err != nil {
+		response.ErrorWithCode(c, errors.CodeInvalidParam, err.Error())
+		return
+	}
+
+	if query.Window > 0 {
+		aggregates, err := h.service.AggregateSensorData(c.Request.Context(), &query)
+		if err != nil {
+			response.Error(c, err)
+			return
+		}
+		response.Success(c, aggregates)
+		return
+	}
+
+	data, err := h.service.GetSensorData(c.Request.Context(), &query)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	if response.WantsArrow(c) {
+		response.Arrow(c, sensorColumns(data)...)
+		return
+	}
+
+	response.Success(c, data)
+}
+
+func (h *SensorHandler) ReadSensor(c *gin.Context) {
+	sensorID := c.Param("id")
+	if sensorID == "" {
+		response.BadRequest(c, "sensor id is required")
+		return
+	}
+
+	data, err := h.service.ReadSensor(c.Request.Context(), sensorID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, data)
+}
+
+func (h *SensorHandler) BatchRead(c *gin.Context) {
+	var req model.SensorBatchReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	results, err := h.service.ReadSensors(c.Request.Context(), req.SensorIDs)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, results)
+}
+
+func (h *SensorHandler) Register(c *gin.Context) {
+	var req model.SensorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		response.ErrorWithCode(c, errors.CodeInvalidParam, err.Error())
+		return
+	}
+
+	info, err := h.service.RegisterSensor(c.Request.Context(), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, info)
+}
+
+func (h *SensorHandler) Update(c *gin.Context) {
+	var req model.SensorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+	req.SensorID = c.Param("id")
+
+	if err := req.Validate(); err != nil {
+		response.ErrorWithCode(c, errors.CodeInvalidParam, err.Error())
+		return
+	}
+
+	info, err := h.service.UpdateSensor(c.Request.Context(), req.SensorID, &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, info)
+}
+
+func (h *SensorHandler) Calibrate(c *gin.Context) {
+	var req model.SensorCalibrateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.ErrorWithCode(c, errors.CodeInvalidParam, err.Error())
+		return
+	}
+
+	info, err := h.service.Calibrate(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, info)
+}
+
+func (h *SensorHandler) SetLifecycle(c *gin.Context) {
+	var req model.SensorLifecycleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.ErrorWithCode(c, errors.CodeInvalidParam, err.Error())
+		return
+	}
+
+	info, err := h.service.SetLifecycle(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, info)
+}
+
+func (h *SensorHandler) Delete(c *gin.Context) {
+	if err := h.service.DeleteSensor(c.Request.Context(), c.Param("id")); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (h *SensorHandler) StartCollection(c *gin.Context) {
+	err := h.service.StartCollection(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "sensor data collection started", nil)
+}
+
+func (h *SensorHandler) StopCollection(c *gin.Context) {
+	h.service.StopCollection()
+	response.SuccessWithMessage(c, "sensor data collection stopped", nil)
+}
+
+func (h *SensorHandler) StartReplay(c *gin.Context) {
+	var req model.SensorReplayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.ErrorWithCode(c, errors.CodeInvalidParam, err.Error())
+		return
+	}
+
+	status, err := h.service.StartReplay(c.Request.Context(), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, status)
+}
+
+func (h *SensorHandler) GetReplay(c *gin.Context) {
+	status, err := h.service.ReplayStatus()
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, status)
+}
+
+func (h *SensorHandler) StopReplay(c *gin.Context) {
+	status, err := h.service.StopReplay()
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, status)
+}
+
+type PowerHandler struct {
+	service *service.PowerService
+}
+
+func NewPowerHandler(service *service.PowerService) *PowerHandler {
+	return &PowerHandler{service: service}
+}
+
+func (h *PowerHandler) Estimate(c *gin.Context) {
+	var req model.PowerEstimateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	estimate, err := h.service.EstimateConfiguration(c.Request.Context(), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, estimate)
+}
+
+func (h *PowerHandler) CrossCheck(c *gin.Context) {
+	check, err := h.service.CrossCheck(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, check)
+}
+
+type ExportHandler struct {
+	service  *service.ExportService
+	datasets *service.DatasetService
+}
+
+func NewExportHandler(service *service.ExportService) *ExportHandler {
+	return &ExportHandler{service: service}
+}
+
+func (h *ExportHandler) SetDatasetService(datasets *service.DatasetService) {
+	h.datasets = datasets
+}
+
+func (h *ExportHandler) ExportResult(c *gin.Context) {
+	experimentID := c.Param("id")
+	if experimentID == "" {
+		response.BadRequest(c, "experiment id is required")
+		return
+	}
+
+	format := model.ExportFormat(c.DefaultQuery("format", string(model.ExportFormatJSON)))
+
+	result, err := h.service.ExportExperiment(c.Request.Context(), experimentID, format)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// BuildDataset joins the labeled channel measurements, target positions
+// and sensor readings of a time range into train/val/test splits stored as
+// a dataset artifact.
+func (h *ExportHandler) BuildDataset(c *gin.Context) {
+	var req model.DatasetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.ErrorWithCode(c, errors.CodeInvalidParam, err.Error())
+		return
+	}
+	if h.datasets == nil {
+		response.Error(c, errors.New(errors.CodeServiceUnavailable, "dataset builder not available"))
+		return
+	}
+
+	result, err := h.datasets.Build(c.Request.Context(), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+func (h *ExportHandler) Download(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	rc, info, err := h.service.OpenSigned(c.Request.Context(), key, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	defer rc.Close()
+
+	c.DataFromReader(http.StatusOK, info.Size, "application/octet-stream", rc, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", path.Base(key)),
+	})
+}
+
+type ArtifactHandler struct {
+	service *service.ArtifactService
+}
+
+func NewArtifactHandler(service *service.ArtifactService) *ArtifactHandler {
+	return &ArtifactHandler{service: service}
+}
+
+func (h *ArtifactHandler) List(c *gin.Context) {
+	var query model.ArtifactQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	artifacts, total, err := h.service.List(c.Request.Context(), &query)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessPage(c, artifacts, total, query.Page, query.PageSize)
+}
+
+func (h *ArtifactHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "invalid artifact id")
+		return
+	}
+
+	artifact, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, artifact)
+}
+
+func (h *ArtifactHandler) Download(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "invalid artifact id")
+		return
+	}
+
+	rc, artifact, err := h.service.Open(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	defer rc.Close()
+
+	c.DataFromReader(http.StatusOK, artifact.Size, "application/octet-stream", rc, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", artifact.Name),
+		"X-Checksum-SHA256":   artifact.Checksum,
+	})
+}
+
+func (h *ArtifactHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "invalid artifact id")
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (h *ArtifactHandler) CollectGarbage(c *gin.Context) {
+	report, err := h.service.CollectGarbage(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, report)
+}
+
+func (h *ArtifactHandler) Verify(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "invalid artifact id")
+		return
+	}
+
+	check, err := h.service.VerifyArtifact(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, check)
+}
+
+func (h *ArtifactHandler) VerifyAll(c *gin.Context) {
+	var query model.ArtifactQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	report, err := h.service.VerifyArtifacts(c.Request.Context(), &query)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, report)
+}
+
+func channelColumns(data []*model.ChannelMeasurement) []arrow.Column {
+	n := len(data)
+	measurementIDs := make([]string, n)
+	experimentIDs := make([]string, n)
+	userIDs := make([]int64, n)
+	bands := make([]string, n)
+	amplitudes := make([][]float64, n)
+	phases := make([][]float64, n)
+	snrs := make([]float64, n)
+	bers := make([]float64, n)
+	timestamps := make([]time.Time, n)
+
+	for i, d := range data {
+		measurementIDs[i] = d.MeasurementID
+		experimentIDs[i] = d.ExperimentID
+		userIDs[i] = int64(d.UserID)
+		bands[i] = d.FrequencyBand
+		amplitudes[i] = d.Amplitude
+		phases[i] = d.Phase
+		snrs[i] = d.SNR
+		bers[i] = d.BER
+		timestamps[i] = d.Timestamp
+	}
+
+	return []arrow.Column{
+		arrow.StringColumn("measurement_id", measurementIDs),
+		arrow.StringColumn("experiment_id", experimentIDs),
+		arrow.Int64Column("user_id", userIDs),
+		arrow.StringColumn("frequency_band", bands),
+		arrow.Float64ListColumn("amplitude", amplitudes),
+		arrow.Float64ListColumn("phase", phases),
+		arrow.Float64Column("snr", snrs),
+		arrow.Float64Column("ber", bers),
+		arrow.TimestampColumn("timestamp", timestamps),
+	}
+}
+
+func sensorColumns(data []*model.SensorData) []arrow.Column {
+	n := len(data)
+	sensorIDs := make([]string, n)
+	sensorTypes := make([]string, n)
+	locations := make([]string, n)
+	values := make([]float64, n)
+	units := make([]string, n)
+	qualities := make([]float64, n)
+	timestamps := make([]time.Time, n)
+
+	for i, d := range data {
+		sensorIDs[i] = d.SensorID
+		sensorTypes[i] = d.SensorType
+		locations[i] = d.Location
+		values[i] = d.Value
+		units[i] = d.Unit
+		qualities[i] = d.Quality
+		timestamps[i] = d.Timestamp
+	}
+
+	return []arrow.Column{
+		arrow.StringColumn("sensor_id", sensorIDs),
+		arrow.StringColumn("sensor_type", sensorTypes),
+		arrow.StringColumn("location", locations),
+		arrow.Float64Column("value", values),
+		arrow.StringColumn("unit", units),
+		arrow.Float64Column("quality", qualities),
+		arrow.TimestampColumn("timestamp", timestamps),
+	}
+}
+
+type ReservationHandler struct {
+	service *service.ReservationService
+}
+
+func NewReservationHandler(service *service.ReservationService) *ReservationHandler {
+	return &ReservationHandler{service: service}
+}
+
+func (h *ReservationHandler) Create(c *gin.Context) {
+	var req model.ReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	reservation, err := h.service.Create(holderContext(c), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, reservation)
+}
+
+func (h *ReservationHandler) List(c *gin.Context) {
+	var query model.ReservationQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	reservations, err := h.service.List(c.Request.Context(), &query)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, reservations)
+}
+
+func (h *ReservationHandler) Cancel(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "invalid reservation id")
+		return
+	}
+
+	if err := h.service.Cancel(holderContext(c), id); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+type AlertHandler struct {
+	service *service.AlertService
+}
+
+func NewAlertHandler(service *service.AlertService) *AlertHandler {
+	return &AlertHandler{service: service}
+}
+
+func (h *AlertHandler) CreateRule(c *gin.Context) {
+	var req model.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	rule, err := h.service.CreateRule(c.Request.Context(), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, rule)
+}
+
+func (h *AlertHandler) ListRules(c *gin.Context) {
+	rules, err := h.service.ListRules(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, rules)
+}
+
+func (h *AlertHandler) UpdateRule(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "invalid alert rule id")
+		return
+	}
+	var req model.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	rule, err := h.service.UpdateRule(c.Request.Context(), id, &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, rule)
+}
+
+func (h *AlertHandler) DeleteRule(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "invalid alert rule id")
+		return
+	}
+
+	if err := h.service.DeleteRule(c.Request.Context(), id); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (h *AlertHandler) List(c *gin.Context) {
+	var query model.AlertQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	alerts, total, err := h.service.ListAlerts(c.Request.Context(), &query)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessPage(c, alerts, total, query.Page, query.PageSize)
+}
+
+// PrometheusRules serves the recommended Prometheus alerting rules for the
+// exported metrics as a rule file; job selects the scrape job they watch.
+func (h *AlertHandler) PrometheusRules(c *gin.Context) {
+	data, err := yaml.Marshal(service.PrometheusRules(c.Query("job")))
+	if err != nil {
+		response.Error(c, errors.Wrap(errors.CodeInternalError, "failed to encode alerting rules", err))
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="isac-alerts.yml"`)
+	c.Data(http.StatusOK, "application/yaml", data)
+}
+
+// AlertmanagerWebhook receives the notifications of an Alertmanager webhook
+// receiver and forwards their alerts as notifications.
+func (h *AlertHandler) AlertmanagerWebhook(c *gin.Context) {
+	var webhook model.AlertmanagerWebhook
+	if err := c.ShouldBindJSON(&webhook); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	received, err := h.service.RelayAlertmanager(c.Request.Context(), &webhook)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"received": received})
+}
+
+type RecordingHandler struct {
+	service  *service.RecordingService
+	sessions *service.CaptureSessionService
+}
+
+func NewRecordingHandler(service *service.RecordingService) *RecordingHandler {
+	return &RecordingHandler{service: service}
+}
+
+func (h *RecordingHandler) SetCaptureSessions(sessions *service.CaptureSessionService) {
+	h.sessions = sessions
+}
+
+func (h *RecordingHandler) Start(c *gin.Context) {
+	var req model.RecordingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	recording, err := h.service.Start(holderContext(c), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, recording)
+}
+
+func (h *RecordingHandler) Stop(c *gin.Context) {
+	recording, err := h.service.Stop(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, recording)
+}
+
+func (h *RecordingHandler) Verify(c *gin.Context) {
+	check, err := h.service.Verify(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, check)
+}
+
+func (h *RecordingHandler) List(c *gin.Context) {
+	recordings, err := h.service.List(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, recordings)
+}
+
+func (h *RecordingHandler) StartSession(c *gin.Context) {
+	var req model.CaptureSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+	if h.sessions == nil {
+		response.Error(c, errors.New(errors.CodeServiceUnavailable, "capture sessions not available"))
+		return
+	}
+
+	session, err := h.sessions.Start(holderContext(c), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, session)
+}
+
+func (h *RecordingHandler) StopSession(c *gin.Context) {
+	if h.sessions == nil {
+		response.Error(c, errors.New(errors.CodeServiceUnavailable, "capture sessions not available"))
+		return
+	}
+
+	session, err := h.sessions.Stop(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, session)
+}
+
+func (h *RecordingHandler) GetSession(c *gin.Context) {
+	if h.sessions == nil {
+		response.Error(c, errors.New(errors.CodeServiceUnavailable, "capture sessions not available"))
+		return
+	}
+
+	session, err := h.sessions.Get(c.Param("id"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, session)
+}
+
+func (h *RecordingHandler) ListSessions(c *gin.Context) {
+	if h.sessions == nil {
+		response.Error(c, errors.New(errors.CodeServiceUnavailable, "capture sessions not available"))
+		return
+	}
+
+	response.Success(c, h.sessions.List())
+}
+
+// SessionData returns the streams of a session trimmed to its window, or
+// to the from and to offsets in seconds.
+func (h *RecordingHandler) SessionData(c *gin.Context) {
+	var q model.CaptureSessionQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if h.sessions == nil {
+		response.Error(c, errors.New(errors.CodeServiceUnavailable, "capture sessions not available"))
+		return
+	}
+
+	data, err := h.sessions.Data(c.Param("id"), &q)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, data)
+}
+
+type USRPHandler struct {
+	service *service.USRPService
+}
+
+func NewUSRPHandler(service *service.USRPService) *USRPHandler {
+	return &USRPHandler{service: service}
+}
+
+func (h *USRPHandler) ListDevices(c *gin.Context) {
+	devices, err := h.service.List(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, devices)
+}
+
+func (h *USRPHandler) Bind(c *gin.Context) {
+	var req model.USRPBindRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	device, err := h.service.Bind(holderContext(c), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, device)
+}
+
+func (h *USRPHandler) GetGain(c *gin.Context) {
+	status, err := h.service.Gain(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, status)
+}
+
+func (h *USRPHandler) SetGain(c *gin.Context) {
+	var req model.USRPGainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	status, err := h.service.SetGain(holderContext(c), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, status)
+}
+
+func (h *USRPHandler) SetAGC(c *gin.Context) {
+	var req model.USRPAGCRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	status, err := h.service.SetAGC(holderContext(c), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, status)
+}
+
+func (h *USRPHandler) GetCalibration(c *gin.Context) {
+	cal, err := h.service.GetCalibration(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, cal)
+}
+
+func (h *USRPHandler) Calibrate(c *gin.Context) {
+	var req model.RXCalibrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	cal, err := h.service.Calibrate(holderContext(c), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, cal)
+}
+
+func (h *USRPHandler) ClearCalibration(c *gin.Context) {
+	if err := h.service.ClearCalibration(holderContext(c)); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+type DeviceHandler struct {
+	service *service.DeviceService
+}
+
+func NewDeviceHandler(service *service.DeviceService) *DeviceHandler {
+	return &DeviceHandler{service: service}
+}
+
+func (h *DeviceHandler) List(c *gin.Context) {
+	response.Success(c, h.service.List())
+}
+
+// ReplicaReporter reports the health of the database read replicas.
+type ReplicaReporter interface {
+	Replicas() []model.ReplicaStatus
+}
+
+// LeaderReporter reports which replica runs the singleton background jobs.
+type LeaderReporter interface {
+	Status() election.Status
+}
+
+// DegradationReporter reports the state of the external dependencies and
+// the degradation policies in force.
+type DegradationReporter interface {
+	Status() *model.DegradationStatus
+}
+
+type SystemHandler struct {
+	replicas    ReplicaReporter
+	leader      LeaderReporter
+	streams     *hub.Hub
+	degradation DegradationReporter
+}
+
+func NewSystemHandler() *SystemHandler {
+	return &SystemHandler{}
+}
+
+func (h *SystemHandler) SetReplicaReporter(r ReplicaReporter) {
+	h.replicas = r
+}
+
+func (h *SystemHandler) SetLeaderReporter(r LeaderReporter) {
+	h.leader = r
+}
+
+func (h *SystemHandler) SetDegradationReporter(r DegradationReporter) {
+	h.degradation = r
+}
+
+// Leader reports the replica elected to run the background jobs, as seen by
+// the replica answering.
+func (h *SystemHandler) Leader(c *gin.Context) {
+	if h.leader == nil {
+		response.Error(c, errors.New(errors.CodeServiceUnavailable, "leader election is not available"))
+		return
+	}
+	response.Success(c, h.leader.Status())
+}
+
+// Health reports "degraded" while a read replica is out of rotation, when
+// reads fall back to the primary, or while a dependency is down and its
+// degradation policy applies.
+func (h *SystemHandler) Health(c *gin.Context) {
+	body := gin.H{
+		"status":    "healthy",
+		"timestamp": "now",
+	}
+	if h.degradation != nil {
+		status := h.degradation.Status()
+		if status.Degraded {
+			body["status"] = "degraded"
+		}
+		body["dependencies"] = status.Dependencies
+	}
+	if h.replicas != nil {
+		replicas := h.replicas.Replicas()
+		for _, r := range replicas {
+			if !r.Healthy {
+				body["status"] = "degraded"
+			}
+		}
+		if len(replicas) > 0 {
+			body["mysql_replicas"] = replicas
+		}
+	}
+	response.Success(c, body)
+}
+
+func (h *SystemHandler) Info(c *gin.Context) {
+	body := gin.H{
+		"name":        "ISAC-CRAN System",
+		"version":     "1.0.0",
+		"description": "Intelligent Reflecting Surface assisted C-RAN ISAC Experimental Prototype System",
+	}
+	if h.degradation != nil {
+		body["degradation"] = h.degradation.Status()
+	}
+	response.Success(c, body)
+}
+
+// Ready answers 503 while a dependency under the fail-requests policy is
+// down, so that load balancers send requests elsewhere; a server that is
+// read-only or buffering writes is still ready.
+func (h *SystemHandler) Ready(c *gin.Context) {
+	if h.degradation == nil {
+		response.Success(c, &model.DegradationStatus{Ready: true, Dependencies: []model.DependencyStatus{}})
+		return
+	}
+	status := h.degradation.Status()
+	if !status.Ready {
+		response.ErrorWithData(c, errors.New(errors.CodeServiceUnavailable, "not ready"), status)
+		return
+	}
+	response.Success(c, status)
 }
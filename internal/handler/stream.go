@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/hub"
+	"isac-cran-system/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// streamKeepAlive is how often an idle SSE stream sends a comment, so that
+// proxies keep the connection open.
+const streamKeepAlive = 15 * time.Second
+
+// streamWriteTimeout bounds each WebSocket write, so that a client that
+// stops reading cannot hold the stream after the hub evicts it.
+const streamWriteTimeout = 10 * time.Second
+
+// streamCommand changes the topics of a WebSocket client.
+type streamCommand struct {
+	Action string   `json:"action"`
+	Topics []string `json:"topics"`
+}
+
+// SetStreamHub enables the WebSocket and SSE streams.
+func (h *SystemHandler) SetStreamHub(streams *hub.Hub) {
+	h.streams = streams
+}
+
+// streamTopics reads the comma-separated topics query parameter.
+func streamTopics(c *gin.Context) []string {
+	var topics []string
+	for _, topic := range strings.Split(c.Query("topics"), ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+func (h *SystemHandler) streamsAvailable(c *gin.Context) bool {
+	if h.streams == nil {
+		response.Error(c, errors.New(errors.CodeServiceUnavailable, "streams are not enabled"))
+		return false
+	}
+	return true
+}
+
+// clearDeadlines lifts the server's read and write timeouts, which would
+// otherwise cut long-lived streams.
+func clearDeadlines(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+}
+
+// StreamEvents streams the events of the topics query parameter as
+// server-sent events named after their topic. The stream ends when the
+// client falls too far behind.
+func (h *SystemHandler) StreamEvents(c *gin.Context) {
+	if !h.streamsAvailable(c) {
+		return
+	}
+	topics := streamTopics(c)
+	if len(topics) == 0 {
+		response.ErrorWithCode(c, errors.CodeInvalidParam, "topics is required")
+		return
+	}
+	client := h.streams.Subscribe(topics...)
+	defer client.Close()
+
+	clearDeadlines(c.Writer)
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-client.Done():
+			if client.Evicted() {
+				c.SSEvent("evicted", "send buffer full")
+				c.Writer.Flush()
+			}
+			return
+		case msg := <-client.Messages():
+			c.SSEvent(msg.Topic, msg)
+			c.Writer.Flush()
+		case <-keepAlive.C:
+			c.Writer.WriteString(": keep-alive\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// StreamWebSocket streams the events of the topics query parameter over a
+// WebSocket. The client changes its topics by sending
+// {"action": "subscribe"|"unsubscribe", "topics": [...]}.
+func (h *SystemHandler) StreamWebSocket(c *gin.Context) {
+	if !h.streamsAvailable(c) {
+		return
+	}
+	client := h.streams.Subscribe(streamTopics(c)...)
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer client.Close()
+		ws.SetReadDeadline(time.Time{})
+		send := func(msg *hub.Message) error {
+			ws.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			return websocket.JSON.Send(ws, msg)
+		}
+
+		go func() {
+			defer client.Close()
+			for {
+				var cmd streamCommand
+				if err := websocket.JSON.Receive(ws, &cmd); err != nil {
+					return
+				}
+				switch cmd.Action {
+				case "subscribe":
+					client.Subscribe(cmd.Topics...)
+				case "unsubscribe":
+					client.Unsubscribe(cmd.Topics...)
+				}
+			}
+		}()
+
+		for {
+			select {
+			case <-client.Done():
+				if client.Evicted() {
+					send(&hub.Message{Topic: "evicted", Time: time.Now()})
+				}
+				// ends the reader above as well
+				ws.Close()
+				return
+			case msg := <-client.Messages():
+				if err := send(msg); err != nil {
+					return
+				}
+			}
+		}
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+	client.Close()
+}
+
+// StreamStats reports the stream clients, their subscriptions and the
+// broadcast counters.
+func (h *SystemHandler) StreamStats(c *gin.Context) {
+	if !h.streamsAvailable(c) {
+		return
+	}
+	response.Success(c, h.streams.Stats())
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"isac-cran-system/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DegradationGuard decides whether a request may go ahead while a
+// dependency is down. route is the path below /api and the version, write
+// whether the request changes state.
+type DegradationGuard interface {
+	Admit(route string, write bool) error
+}
+
+var (
+	degradationMu sync.RWMutex
+	degradation   DegradationGuard
+)
+
+// SetDegradationGuard installs the guard consulted by Degradation; without
+// one every request is admitted.
+func SetDegradationGuard(g DegradationGuard) {
+	degradationMu.Lock()
+	defer degradationMu.Unlock()
+	degradation = g
+}
+
+// systemPaths report on the server itself and are served whatever state
+// its dependencies are in.
+var systemPaths = map[string]bool{
+	"/health": true,
+	"/info":   true,
+	"/leader": true,
+}
+
+// Degradation rejects API requests the degradation policies do not admit.
+// Reads are GET, HEAD and OPTIONS requests, GraphQL queries, which cannot
+// change anything, and dry runs.
+func Degradation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		degradationMu.RLock()
+		guard := degradation
+		degradationMu.RUnlock()
+
+		route, ok := apiRoute(c.Request.URL.Path)
+		if guard == nil || !ok || systemPaths[route] {
+			c.Next()
+			return
+		}
+		if err := guard.Admit(route, isWrite(c, route)); err != nil {
+			response.Error(c, err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// apiRoute strips /api and the version, if any, from an API path.
+func apiRoute(path string) (string, bool) {
+	rest, ok := strings.CutPrefix(path, "/api/")
+	if !ok {
+		return "", false
+	}
+	segment, tail, _ := strings.Cut(rest, "/")
+	if len(segment) > 1 && segment[0] == 'v' {
+		if _, err := strconv.Atoi(segment[1:]); err == nil {
+			return "/" + tail, true
+		}
+	}
+	return "/" + rest, true
+}
+
+func isWrite(c *gin.Context, route string) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	if route == "/graphql" {
+		return false
+	}
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+	return !dryRun
+}
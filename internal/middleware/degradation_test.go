@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"isac-cran-system/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readOnlyGuard admits reads only to the algorithm routes.
+type readOnlyGuard struct{}
+
+func (readOnlyGuard) Admit(route string, write bool) error {
+	if write && strings.HasPrefix(route, "/algorithm/") {
+		return errors.New(errors.CodeDBConnectError, "mysql is unavailable, the server is read-only")
+	}
+	return nil
+}
+
+func TestDegradation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetDegradationGuard(readOnlyGuard{})
+	defer SetDegradationGuard(nil)
+
+	r := gin.New()
+	r.Use(Degradation())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.POST("/api/v1/algorithm/beamforming", ok)
+	r.GET("/api/v1/algorithm/experiments", ok)
+	r.POST("/api/v1/graphql", ok)
+	r.POST("/api/v1/irs/config", ok)
+	r.POST("/api/v1/leader", ok)
+	r.POST("/ui/login", ok)
+
+	for _, tc := range []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodPost, "/api/v1/algorithm/beamforming", http.StatusServiceUnavailable},
+		{http.MethodPost, "/api/v1/algorithm/beamforming?dry_run=true", http.StatusOK},
+		{http.MethodGet, "/api/v1/algorithm/experiments", http.StatusOK},
+		{http.MethodPost, "/api/v1/graphql", http.StatusOK},
+		{http.MethodPost, "/api/v1/irs/config", http.StatusOK},
+		{http.MethodPost, "/api/v1/leader", http.StatusOK},
+		{http.MethodPost, "/ui/login", http.StatusOK},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Code != tc.want {
+			t.Errorf("%s %s = %d, want %d", tc.method, tc.path, w.Code, tc.want)
+		}
+	}
+}
@@ -3,15 +3,50 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
 	"isac-cran-system/pkg/response"
 
 	"github.com/gin-gonic/gin"
 )
 
-var apiKey = "isac-cran-api-key-2024"
+// PrincipalKey is the gin context key holding the name of the
+// authenticated caller.
+const PrincipalKey = "principal"
+
+var (
+	tokensMu sync.RWMutex
+	tokens   = map[string]string{}
+)
+
+// SetAPITokens replaces the accepted bearer tokens, mapping each token to
+// the principal it was issued to.
+func SetAPITokens(byToken map[string]string) {
+	tokensMu.Lock()
+	defer tokensMu.Unlock()
+	tokens = make(map[string]string, len(byToken))
+	for token, principal := range byToken {
+		tokens[token] = principal
+	}
+}
+
+// principal resolves an Authorization header to its principal.
+func principal(authHeader string) (string, string) {
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return "", "invalid authorization format"
+	}
+	tokensMu.RLock()
+	defer tokensMu.RUnlock()
+	name, ok := tokens[parts[1]]
+	if !ok {
+		return "", "invalid api key"
+	}
+	return name, ""
+}
 
 func Auth() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -22,33 +57,33 @@ func Auth() gin.HandlerFunc {
 			return
 		}
 
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			response.Unauthorized(c, "invalid authorization format")
-			c.Abort()
-			return
-		}
-
-		token := parts[1]
-		if token != apiKey {
-			response.Unauthorized(c, "invalid api key")
+		name, reason := principal(authHeader)
+		if reason != "" {
+			response.Unauthorized(c, reason)
 			c.Abort()
 			return
 		}
 
 		c.Set("authenticated", true)
+		c.Set(PrincipalKey, name)
 		c.Next()
 	}
 }
 
+// OptionalAuth lets requests without credentials through anonymously but
+// rejects a token that is not recognised.
 func OptionalAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader != "" {
-			parts := strings.SplitN(authHeader, " ", 2)
-			if len(parts) == 2 && parts[0] == "Bearer" && parts[1] == apiKey {
-				c.Set("authenticated", true)
+			name, reason := principal(authHeader)
+			if reason != "" {
+				response.Unauthorized(c, reason)
+				c.Abort()
+				return
 			}
+			c.Set("authenticated", true)
+			c.Set(PrincipalKey, name)
 		}
 		c.Next()
 	}
@@ -204,6 +239,7 @@ func RequestID() gin.HandlerFunc {
 		}
 		c.Set("request_id", requestID)
 		c.Header("X-Request-ID", requestID)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), requestID))
 		c.Next()
 	}
 }
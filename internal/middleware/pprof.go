@@ -35,6 +35,19 @@ var globalMetricsCache = &MetricsCache{
 	ttl: 5 * time.Second,
 }
 
+var (
+	metricsSourcesMu sync.RWMutex
+	metricsSources   = make(map[string]func() interface{})
+)
+
+// RegisterMetricsSource adds a named section to /debug/metrics whose value
+// is produced by fn on every request.
+func RegisterMetricsSource(name string, fn func() interface{}) {
+	metricsSourcesMu.Lock()
+	defer metricsSourcesMu.Unlock()
+	metricsSources[name] = fn
+}
+
 func init() {
 	go metricsCollector()
 }
@@ -119,7 +132,20 @@ func MetricsHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		metrics := GetCachedMetrics()
 
-		c.JSON(http.StatusOK, gin.H{
+		if wantsPrometheus(c.Query("format"), c.GetHeader("Accept")) {
+			sources := make(map[string]interface{})
+			metricsSourcesMu.RLock()
+			for name, fn := range metricsSources {
+				sources[name] = fn()
+			}
+			metricsSourcesMu.RUnlock()
+			c.Header("Content-Type", PrometheusContentType)
+			c.Status(http.StatusOK)
+			writePrometheus(c.Writer, metrics, sources)
+			return
+		}
+
+		body := gin.H{
 			"goroutines": metrics.GoroutineCount,
 			"memory": gin.H{
 				"alloc_mb":   metrics.MemoryAlloc,
@@ -133,7 +159,15 @@ func MetricsHandler() gin.HandlerFunc {
 			"cpu_cores":  metrics.CPUCores,
 			"go_version": metrics.GoVersion,
 			"timestamp":  metrics.Timestamp.Format(time.RFC3339),
-		})
+		}
+
+		metricsSourcesMu.RLock()
+		for name, fn := range metricsSources {
+			body[name] = fn()
+		}
+		metricsSourcesMu.RUnlock()
+
+		c.JSON(http.StatusOK, body)
 	}
 }
 
@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PrometheusContentType is the text exposition format Prometheus scrapes.
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// wantsPrometheus tells a Prometheus scrape, which asks for the text or
+// OpenMetrics format, from a request for the JSON metrics.
+func wantsPrometheus(format, accept string) bool {
+	if format != "" {
+		return format == "prometheus"
+	}
+	return strings.Contains(accept, "version=0.0.4") || strings.Contains(accept, "application/openmetrics-text")
+}
+
+// writePrometheus writes the runtime metrics under the names of the Go
+// client library, so dashboards built for it work, and each metrics source
+// as isac_<source>_<field>. Maps of a source, such as the task types of the
+// worker pool, become a label named by the prom tag of the map field, or
+// after the field.
+func writePrometheus(w io.Writer, m *RuntimeMetrics, sources map[string]interface{}) {
+	const mb = 1024 * 1024
+	runtime := []struct {
+		name  string
+		value float64
+	}{
+		{"go_goroutines", float64(m.GoroutineCount)},
+		{"go_memstats_alloc_bytes", m.MemoryAlloc * mb},
+		{"go_memstats_alloc_bytes_total", m.MemoryTotal * mb},
+		{"go_memstats_sys_bytes", m.MemorySys * mb},
+		{"go_memstats_heap_alloc_bytes", m.HeapAlloc * mb},
+		{"go_memstats_heap_sys_bytes", m.HeapSys * mb},
+		{"go_gc_cycles_total", float64(m.GCCount)},
+		{"go_gc_pause_seconds_total", m.GCPauseTotal},
+	}
+	for _, r := range runtime {
+		fmt.Fprintf(w, "# TYPE %s gauge\n%s %s\n", r.name, r.name, formatSample(r.value))
+	}
+
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var samples []promSample
+		collectSamples("isac_"+promName(name), "", nil, reflect.ValueOf(sources[name]), &samples)
+		sort.Slice(samples, func(i, j int) bool {
+			if samples[i].name != samples[j].name {
+				return samples[i].name < samples[j].name
+			}
+			return samples[i].labels < samples[j].labels
+		})
+		for i, s := range samples {
+			if i == 0 || samples[i-1].name != s.name {
+				fmt.Fprintf(w, "# TYPE %s untyped\n", s.name)
+			}
+			fmt.Fprintf(w, "%s%s %s\n", s.name, s.labels, formatSample(s.value))
+		}
+	}
+}
+
+type promSample struct {
+	name   string
+	labels string
+	value  float64
+}
+
+// collectSamples walks v, naming numbers and booleans by their path from
+// name. label names the keys of v if it is a map.
+func collectSamples(name, label string, labels []string, v reflect.Value, out *[]promSample) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			tag := strings.Split(f.Tag.Get("json"), ",")[0]
+			if tag == "-" {
+				continue
+			}
+			if tag == "" {
+				tag = f.Name
+			}
+			label := f.Tag.Get("prom")
+			if label == "" {
+				label = strings.TrimSuffix(tag, "s")
+			}
+			collectSamples(name+"_"+promName(tag), label, labels, v.Field(i), out)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		if label == "" {
+			label = "key"
+		}
+		for _, key := range v.MapKeys() {
+			keyLabels := append(labels[:len(labels):len(labels)], promName(label)+`="`+labelEscaper.Replace(key.String())+`"`)
+			collectSamples(name, "", keyLabels, v.MapIndex(key), out)
+		}
+	case reflect.Bool:
+		value := 0.0
+		if v.Bool() {
+			value = 1
+		}
+		*out = append(*out, promSample{name: name, labels: formatLabels(labels), value: value})
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		*out = append(*out, promSample{name: name, labels: formatLabels(labels), value: float64(v.Int())})
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		*out = append(*out, promSample{name: name, labels: formatLabels(labels), value: float64(v.Uint())})
+	case reflect.Float32, reflect.Float64:
+		*out = append(*out, promSample{name: name, labels: formatLabels(labels), value: v.Float()})
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+func formatSample(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// promName turns s into a valid metric or label name.
+func promName(s string) string {
+	var b strings.Builder
+	for i, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r == '_', r >= '0' && r <= '9' && i > 0:
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+
+	"isac-cran-system/pkg/hub"
+	"isac-cran-system/pkg/pool"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	var b strings.Builder
+	writePrometheus(&b, &RuntimeMetrics{GoroutineCount: 12, HeapAlloc: 2}, map[string]interface{}{
+		"experiment_queue": 3,
+		"stream_hub":       hub.Stats{Clients: 1, Published: map[string]int64{`doa"1`: 5}},
+		"worker_pool": &pool.Stats{QueueCapacity: 100, Tasks: map[string]pool.TaskTypeStats{
+			"beamforming": {Submitted: 7, AvgQueueWaitMs: 1.5},
+		}},
+	})
+	out := b.String()
+	for _, want := range []string{
+		"go_goroutines 12\n",
+		"go_memstats_heap_alloc_bytes 2.097152e+06\n",
+		"isac_experiment_queue 3\n",
+		"isac_stream_hub_clients 1\n",
+		`isac_stream_hub_published{topic="doa\"1"} 5` + "\n",
+		"isac_worker_pool_queue_capacity 100\n",
+		`isac_worker_pool_tasks_submitted{task_type="beamforming"} 7` + "\n",
+		`isac_worker_pool_tasks_avg_queue_wait_ms{task_type="beamforming"} 1.5` + "\n",
+		"# TYPE isac_worker_pool_tasks_submitted untyped\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("exposition lacks %q:\n%s", want, out)
+		}
+	}
+}
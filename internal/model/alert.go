@@ -0,0 +1,170 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+type AlertComparison string
+
+const (
+	AlertAbove     AlertComparison = "gt"
+	AlertAtOrAbove AlertComparison = "gte"
+	AlertBelow     AlertComparison = "lt"
+	AlertAtOrBelow AlertComparison = "lte"
+)
+
+const (
+	AlertLevelWarning  = "warning"
+	AlertLevelCritical = "critical"
+)
+
+var comparisonSymbols = map[AlertComparison]string{
+	AlertAbove:     ">",
+	AlertAtOrAbove: ">=",
+	AlertBelow:     "<",
+	AlertAtOrBelow: "<=",
+}
+
+// AlertRule raises an alert once readings of SensorID have compared to
+// Threshold for Duration seconds without interruption. The alert clears on
+// the first reading that no longer does.
+type AlertRule struct {
+	ID         int64           `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name       string          `json:"name" gorm:"type:varchar(100)"`
+	SensorID   string          `json:"sensor_id" gorm:"type:varchar(50);not null;index"`
+	Comparison AlertComparison `json:"comparison" gorm:"type:varchar(10);not null"`
+	Threshold  float64         `json:"threshold"`
+	Duration   float64         `json:"duration"`
+	Level      string          `json:"level" gorm:"type:varchar(20);default:warning"`
+	Enabled    bool            `json:"enabled" gorm:"default:true"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (AlertRule) TableName() string {
+	return "sensor_alert_rule"
+}
+
+func (r *AlertRule) Matches(value float64) bool {
+	switch r.Comparison {
+	case AlertAbove:
+		return value > r.Threshold
+	case AlertAtOrAbove:
+		return value >= r.Threshold
+	case AlertBelow:
+		return value < r.Threshold
+	case AlertAtOrBelow:
+		return value <= r.Threshold
+	}
+	return false
+}
+
+func (r *AlertRule) Describe(value float64) string {
+	return fmt.Sprintf("%s reads %g %s %g for %gs", r.SensorID, value, comparisonSymbols[r.Comparison], r.Threshold, r.Duration)
+}
+
+type AlertRuleRequest struct {
+	Name       string          `json:"name" binding:"max=100"`
+	SensorID   string          `json:"sensor_id" binding:"required,max=50"`
+	Comparison AlertComparison `json:"comparison" binding:"required,oneof=gt gte lt lte"`
+	Threshold  float64         `json:"threshold"`
+	Duration   float64         `json:"duration" binding:"min=0,max=86400"`
+	Level      string          `json:"level" binding:"omitempty,oneof=warning critical"`
+	Enabled    *bool           `json:"enabled"`
+}
+
+func (r *AlertRuleRequest) Rule() *AlertRule {
+	rule := &AlertRule{
+		Name:       r.Name,
+		SensorID:   r.SensorID,
+		Comparison: r.Comparison,
+		Threshold:  r.Threshold,
+		Duration:   r.Duration,
+		Level:      r.Level,
+		Enabled:    true,
+	}
+	if rule.Level == "" {
+		rule.Level = AlertLevelWarning
+	}
+	if r.Enabled != nil {
+		rule.Enabled = *r.Enabled
+	}
+	return rule
+}
+
+type AlertStatus string
+
+const (
+	AlertStatusActive   AlertStatus = "active"
+	AlertStatusResolved AlertStatus = "resolved"
+)
+
+// SensorAlert is one alarm raised by a rule, kept after it resolves.
+type SensorAlert struct {
+	ID          int64           `json:"id" gorm:"primaryKey;autoIncrement"`
+	RuleID      int64           `json:"rule_id" gorm:"index"`
+	SensorID    string          `json:"sensor_id" gorm:"type:varchar(50);index"`
+	Level       string          `json:"level" gorm:"type:varchar(20)"`
+	Status      AlertStatus     `json:"status" gorm:"type:varchar(20);index"`
+	Comparison  AlertComparison `json:"comparison" gorm:"type:varchar(10)"`
+	Threshold   float64         `json:"threshold"`
+	Value       float64         `json:"value"`
+	Message     string          `json:"message" gorm:"type:varchar(255)"`
+	TriggeredAt time.Time       `json:"triggered_at" gorm:"index"`
+	ResolvedAt  *time.Time      `json:"resolved_at,omitempty"`
+}
+
+func (SensorAlert) TableName() string {
+	return "sensor_alert"
+}
+
+type AlertQuery struct {
+	Status   AlertStatus `form:"status" binding:"omitempty,oneof=active resolved"`
+	SensorID string      `form:"sensor_id"`
+	RuleID   int64       `form:"rule_id"`
+	Page     int         `form:"page"`
+	PageSize int         `form:"page_size"`
+}
+
+// AlertmanagerWebhook is the payload Prometheus Alertmanager posts to a
+// webhook receiver (version 4), one notification for a group of alerts.
+type AlertmanagerWebhook struct {
+	Version           string              `json:"version"`
+	GroupKey          string              `json:"groupKey"`
+	Status            string              `json:"status"`
+	Receiver          string              `json:"receiver"`
+	GroupLabels       map[string]string   `json:"groupLabels"`
+	CommonLabels      map[string]string   `json:"commonLabels"`
+	CommonAnnotations map[string]string   `json:"commonAnnotations"`
+	ExternalURL       string              `json:"externalURL"`
+	Alerts            []AlertmanagerAlert `json:"alerts" binding:"required,dive"`
+}
+
+type AlertmanagerAlert struct {
+	Status       string            `json:"status" binding:"required,oneof=firing resolved"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// PrometheusRuleFile is a Prometheus alerting rule file.
+type PrometheusRuleFile struct {
+	Groups []PrometheusRuleGroup `yaml:"groups"`
+}
+
+type PrometheusRuleGroup struct {
+	Name  string           `yaml:"name"`
+	Rules []PrometheusRule `yaml:"rules"`
+}
+
+type PrometheusRule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
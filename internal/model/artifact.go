@@ -0,0 +1,111 @@
+package model
+
+import (
+	"time"
+)
+
+type ArtifactType string
+
+const (
+	ArtifactTypeMATLAB     ArtifactType = "matlab"
+	ArtifactTypeIQCapture  ArtifactType = "iq_capture"
+	ArtifactTypeDataset    ArtifactType = "dataset"
+	ArtifactTypeCheckpoint ArtifactType = "checkpoint"
+	ArtifactTypePlot       ArtifactType = "plot"
+	ArtifactTypeExport     ArtifactType = "export"
+	ArtifactTypeReport     ArtifactType = "report"
+)
+
+type Artifact struct {
+	ID           int64        `json:"id" gorm:"primaryKey;autoIncrement"`
+	ExperimentID string       `json:"experiment_id" gorm:"type:varchar(50);index"`
+	ArtifactType ArtifactType `json:"artifact_type" gorm:"type:varchar(30);not null;index"`
+	Name         string       `json:"name" gorm:"type:varchar(255)"`
+	StorageKey   string       `json:"storage_key" gorm:"type:varchar(255);uniqueIndex;not null"`
+	Backend      string       `json:"backend" gorm:"type:varchar(20)"`
+	Size         int64        `json:"size"`
+	Checksum     string       `json:"checksum" gorm:"type:char(64)"`
+	CreatedAt    time.Time    `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (Artifact) TableName() string {
+	return "artifact"
+}
+
+type ArtifactQuery struct {
+	ExperimentID string       `form:"experiment_id"`
+	ArtifactType ArtifactType `form:"artifact_type"`
+	Page         int          `form:"page"`
+	PageSize     int          `form:"page_size"`
+}
+
+type ArtifactGCReport struct {
+	OrphanedObjects int       `json:"orphaned_objects"`
+	OrphanedRecords int       `json:"orphaned_records"`
+	FreedBytes      int64     `json:"freed_bytes"`
+	DeletedKeys     []string  `json:"deleted_keys"`
+	Timestamp       time.Time `json:"timestamp"`
+}
+
+type IntegrityStatus string
+
+const (
+	IntegrityOK       IntegrityStatus = "ok"
+	IntegrityMismatch IntegrityStatus = "mismatch"
+	IntegrityMissing  IntegrityStatus = "missing"
+	// IntegrityUnreadable marks a file that exists but could not be read.
+	IntegrityUnreadable IntegrityStatus = "unreadable"
+	// IntegrityRecorded marks a file stored without a checksum; the one
+	// computed by the check is recorded for the next.
+	IntegrityRecorded IntegrityStatus = "recorded"
+)
+
+// IntegrityCheck is the outcome of re-hashing one stored file against its
+// recorded SHA-256 checksum.
+type IntegrityCheck struct {
+	ArtifactID  int64           `json:"artifact_id,omitempty"`
+	RecordingID string          `json:"recording_id,omitempty"`
+	Path        string          `json:"path"`
+	Status      IntegrityStatus `json:"status"`
+	Expected    string          `json:"expected,omitempty"`
+	Actual      string          `json:"actual,omitempty"`
+	Size        int64           `json:"size"`
+	Error       string          `json:"error,omitempty"`
+	CheckedAt   time.Time       `json:"checked_at"`
+}
+
+// IntegrityReport sums up a verification run; Failures lists the checks
+// that found a missing or altered file.
+type IntegrityReport struct {
+	Checked    int              `json:"checked"`
+	OK         int              `json:"ok"`
+	Mismatched int              `json:"mismatched"`
+	Missing    int              `json:"missing"`
+	Recorded   int              `json:"recorded"`
+	Unreadable int              `json:"unreadable"`
+	Failures   []IntegrityCheck `json:"failures"`
+	Timestamp  time.Time        `json:"timestamp"`
+}
+
+func NewIntegrityReport() *IntegrityReport {
+	return &IntegrityReport{Failures: make([]IntegrityCheck, 0), Timestamp: time.Now()}
+}
+
+func (r *IntegrityReport) Add(check *IntegrityCheck) {
+	r.Checked++
+	switch check.Status {
+	case IntegrityOK:
+		r.OK++
+	case IntegrityRecorded:
+		r.Recorded++
+	case IntegrityMismatch:
+		r.Mismatched++
+		r.Failures = append(r.Failures, *check)
+	case IntegrityMissing:
+		r.Missing++
+		r.Failures = append(r.Failures, *check)
+	case IntegrityUnreadable:
+		r.Unreadable++
+		r.Failures = append(r.Failures, *check)
+	}
+}
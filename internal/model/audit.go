@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	AuditActionCreate = "create"
+	AuditActionDelete = "delete"
+
+	AuditResourceReservation = "reservation"
+	AuditResourceExperiment  = "experiment"
+)
+
+// AuditLog records a change made through the API. It is written in the same
+// transaction as the change it describes.
+type AuditLog struct {
+	ID         int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	Action     string    `json:"action" gorm:"type:varchar(20);not null"`
+	Resource   string    `json:"resource" gorm:"type:varchar(30);not null;index:idx_audit_resource"`
+	ResourceID string    `json:"resource_id" gorm:"type:varchar(50);index:idx_audit_resource"`
+	Actor      string    `json:"actor" gorm:"type:varchar(100)"`
+	Detail     string    `json:"detail" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_log"
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"encoding/json"
+)
+
+const MaxBatchItems = 32
+
+// AlgorithmBatchRequest runs several experiments, of any algorithm type that
+// has a run endpoint, in one call.
+type AlgorithmBatchRequest struct {
+	Items []AlgorithmBatchItem `json:"items" binding:"required"`
+}
+
+// AlgorithmBatchItem is the body of a single run request plus its algorithm
+// type. Params is decoded as BeamformingParams or DOAParams.
+type AlgorithmBatchItem struct {
+	AlgorithmType AlgorithmType   `json:"algorithm_type"`
+	ExperimentID  string          `json:"experiment_id"`
+	Params        json.RawMessage `json:"params"`
+}
+
+func (r *AlgorithmBatchRequest) Validate() error {
+	if len(r.Items) == 0 {
+		return NewValidationError("items are empty")
+	}
+	if len(r.Items) > MaxBatchItems {
+		return NewValidationErrorf("a batch holds at most %d items", MaxBatchItems)
+	}
+	seen := make(map[string]bool, len(r.Items))
+	for i, item := range r.Items {
+		switch item.AlgorithmType {
+		case AlgorithmTypeBeamforming, AlgorithmTypeDOA:
+		default:
+			return NewValidationErrorf("items[%d].algorithm_type must be %s or %s", i, AlgorithmTypeBeamforming, AlgorithmTypeDOA)
+		}
+		if item.ExperimentID == "" {
+			return NewValidationErrorf("items[%d].experiment_id is required", i)
+		}
+		if seen[item.ExperimentID] {
+			return NewValidationErrorf("items[%d].experiment_id %s appears more than once", i, item.ExperimentID)
+		}
+		seen[item.ExperimentID] = true
+		if len(item.Params) == 0 {
+			return NewValidationErrorf("items[%d].params is required", i)
+		}
+	}
+	return nil
+}
+
+type BatchItemStatus string
+
+const (
+	BatchItemCompleted BatchItemStatus = "completed"
+	BatchItemQueued    BatchItemStatus = "queued"
+	BatchItemFailed    BatchItemStatus = "failed"
+)
+
+// AlgorithmBatchResult is the outcome of one batch item, in request order.
+// Result is set for completed items, Queued for items waiting on a
+// reservation, and Code and Error for failed ones.
+type AlgorithmBatchResult struct {
+	Index         int               `json:"index"`
+	AlgorithmType AlgorithmType     `json:"algorithm_type"`
+	ExperimentID  string            `json:"experiment_id"`
+	Status        BatchItemStatus   `json:"status"`
+	Result        interface{}       `json:"result,omitempty"`
+	Queued        *QueuedExperiment `json:"queued,omitempty"`
+	Code          int               `json:"code,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	Duration      float64           `json:"duration"`
+}
+
+type AlgorithmBatchResponse struct {
+	Completed int                    `json:"completed"`
+	Queued    int                    `json:"queued"`
+	Failed    int                    `json:"failed"`
+	Results   []AlgorithmBatchResult `json:"results"`
+}
@@ -0,0 +1,206 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// Targets of a beam sweep. The irs target writes each beam to a panel and
+// measures the received power with the channel service; the array target
+// combines the channels of one multi-channel capture of the receive array
+// with each beam's weights.
+const (
+	BeamTargetIRS   = "irs"
+	BeamTargetArray = "array"
+)
+
+type BeamSweepStatus string
+
+const (
+	BeamSweepRunning   BeamSweepStatus = "running"
+	BeamSweepTracking  BeamSweepStatus = "tracking"
+	BeamSweepCompleted BeamSweepStatus = "completed"
+	BeamSweepFailed    BeamSweepStatus = "failed"
+)
+
+// Defaults and bounds of a beam sweep, in seconds and dB.
+const (
+	DefaultBeamDwell         = 0.1
+	MinBeamDwell             = 0.01
+	MaxBeamDwell             = 10.0
+	DefaultBeamTrackInterval = 1.0
+	DefaultBeamTrackSpan     = 1
+	MaxBeamTrackSpan         = 16
+	DefaultBeamHysteresis    = 1.0
+	// MaxBeamTrackPoints bounds the tracking history a sweep keeps; the
+	// oldest points are dropped beyond it.
+	MaxBeamTrackPoints = 10000
+)
+
+// BeamSweepRequest sweeps the entries of a codebook across the IRS panel
+// IRSID or the receive array and selects the beam with the highest
+// received power. Codebook names a stored codebook; without it a DFT
+// codebook of Size beams is used, one per element by default. Each beam
+// of the irs target is held for Dwell seconds while the channel is
+// measured, labelled with UserID and FrequencyBand; the array target
+// captures Dwell seconds once per sweep.
+//
+// With Track the best beam is followed after the sweep: every TrackInterval
+// seconds it and the TrackSpan beams on either side of it are measured
+// again, and the sweep moves to a neighbour that is more than Hysteresis dB
+// stronger. Tracking runs for Duration seconds, or until stopped; zero uses
+// the configured limit, as with capture sessions.
+type BeamSweepRequest struct {
+	ExperimentID  string  `json:"experiment_id" binding:"required"`
+	Target        string  `json:"target,omitempty"`
+	IRSID         string  `json:"irs_id,omitempty"`
+	Codebook      string  `json:"codebook,omitempty"`
+	Size          int     `json:"size,omitempty"`
+	Dwell         float64 `json:"dwell,omitempty"`
+	UserID        int     `json:"user_id,omitempty"`
+	FrequencyBand string  `json:"frequency_band,omitempty"`
+	Track         bool    `json:"track,omitempty"`
+	TrackInterval float64 `json:"track_interval,omitempty"`
+	TrackSpan     int     `json:"track_span,omitempty"`
+	Hysteresis    float64 `json:"hysteresis,omitempty"`
+	Duration      float64 `json:"duration,omitempty"`
+}
+
+// Violations returns every rule of a beam sweep request the request breaks.
+func (r *BeamSweepRequest) Violations() Diagnostics {
+	return beamSweepRules.Check(r)
+}
+
+func (r *BeamSweepRequest) Validate() error {
+	return beamSweepRules.Validate(r)
+}
+
+var beamSweepRules = join(
+	Rules[BeamSweepRequest]{
+		oneOf("target", func(r *BeamSweepRequest) string { return r.Target }, BeamTargetIRS, BeamTargetArray),
+		between("size", 0, MaxCodebookSize, "", func(r *BeamSweepRequest) int { return r.Size }),
+		{Field: "size", When: func(r *BeamSweepRequest) bool { return r.Codebook != "" }, Check: func(r *BeamSweepRequest) error {
+			if r.Size != 0 {
+				return violated("is set by the codebook")
+			}
+			return nil
+		}},
+		{Field: "irs_id", When: func(r *BeamSweepRequest) bool { return r.Target == BeamTargetArray }, Check: func(r *BeamSweepRequest) error {
+			if r.IRSID != "" {
+				return violated("only applies to the %s target", BeamTargetIRS)
+			}
+			return nil
+		}},
+		{Field: "dwell", When: func(r *BeamSweepRequest) bool { return r.Dwell != 0 }, Check: func(r *BeamSweepRequest) error {
+			if !(r.Dwell >= MinBeamDwell && r.Dwell <= MaxBeamDwell) {
+				return violated("must be between %g and %g s", MinBeamDwell, MaxBeamDwell)
+			}
+			return nil
+		}},
+		notNegative("duration", func(r *BeamSweepRequest) float64 { return r.Duration }),
+	},
+	when(func(r *BeamSweepRequest) bool { return r.Track },
+		Rule[BeamSweepRequest]{Field: "track_interval", When: func(r *BeamSweepRequest) bool { return r.TrackInterval != 0 }, Check: func(r *BeamSweepRequest) error {
+			if !(r.TrackInterval >= 0.1 && r.TrackInterval <= 3600) {
+				return violated("must be between 0.1 and 3600 s")
+			}
+			return nil
+		}},
+		between("track_span", 0, MaxBeamTrackSpan, "", func(r *BeamSweepRequest) int { return r.TrackSpan }),
+		notNegative("hysteresis", func(r *BeamSweepRequest) float64 { return r.Hysteresis }),
+	),
+	Rules[BeamSweepRequest]{
+		{Field: "track", When: func(r *BeamSweepRequest) bool { return !r.Track }, Check: func(r *BeamSweepRequest) error {
+			if r.TrackInterval != 0 || r.TrackSpan != 0 || r.Hysteresis != 0 {
+				return violated("track_interval, track_span and hysteresis only apply when tracking")
+			}
+			return nil
+		}},
+	},
+)
+
+// WithDefaults fills in the target, dwell and tracking settings a request
+// leaves out.
+func (r BeamSweepRequest) WithDefaults() *BeamSweepRequest {
+	if r.Target == "" {
+		r.Target = BeamTargetIRS
+	}
+	if r.Dwell == 0 {
+		r.Dwell = DefaultBeamDwell
+	}
+	if r.Track {
+		if r.TrackInterval == 0 {
+			r.TrackInterval = DefaultBeamTrackInterval
+		}
+		if r.TrackSpan == 0 {
+			r.TrackSpan = DefaultBeamTrackSpan
+		}
+		if r.Hysteresis == 0 {
+			r.Hysteresis = DefaultBeamHysteresis
+		}
+	}
+	return &r
+}
+
+// DFTBeamAngle is the direction, in radians from broadside, of beam index
+// of a size-beam DFT codebook on a half-wavelength ULA: its phase step
+// 2π·index/size, wrapped to [-π, π), is π·sin θ. For a panel it is the
+// direction of the reflection under normal incidence.
+func DFTBeamAngle(index, size int) float64 {
+	step := 2 * math.Pi * float64(index%size) / float64(size)
+	if step >= math.Pi {
+		step -= 2 * math.Pi
+	}
+	return math.Asin(step / math.Pi)
+}
+
+// BeamSweep is the state of a sweep. Measured counts the beams of the
+// initial sweep measured so far; Best is the beam the sweep has selected,
+// which tracking may move. Rounds and Missed count the tracking rounds
+// completed and failed, and Error holds the last error.
+type BeamSweep struct {
+	ID           string           `json:"id"`
+	ExperimentID string           `json:"experiment_id"`
+	Target       string           `json:"target"`
+	IRSID        string           `json:"irs_id,omitempty"`
+	Codebook     string           `json:"codebook,omitempty"`
+	Size         int              `json:"size"`
+	Track        bool             `json:"track"`
+	Status       BeamSweepStatus  `json:"status"`
+	StartedAt    time.Time        `json:"started_at"`
+	StoppedAt    *time.Time       `json:"stopped_at,omitempty"`
+	Measured     int              `json:"measured"`
+	Best         *BeamMeasurement `json:"best,omitempty"`
+	Rounds       int              `json:"rounds"`
+	Missed       int              `json:"missed"`
+	Error        string           `json:"error,omitempty"`
+}
+
+// BeamMeasurement is the received power with one beam, in dB relative to a
+// full-scale amplitude of 1. SNR is that of the channel measurement, for
+// the irs target. Angle is set for the beams of DFT codebooks.
+type BeamMeasurement struct {
+	Index     int       `json:"index"`
+	Angle     *float64  `json:"angle,omitempty"`
+	Power     float64   `json:"power"`
+	SNR       float64   `json:"snr,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// BeamTrackPoint is the beam tracking settled on in one round and its
+// power; Switched is set when the round moved the sweep to it.
+type BeamTrackPoint struct {
+	Round     int       `json:"round"`
+	Index     int       `json:"index"`
+	Power     float64   `json:"power"`
+	Switched  bool      `json:"switched,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// BeamSweepResult holds every beam of the initial sweep in codebook order
+// and the tracking history.
+type BeamSweepResult struct {
+	Sweep *BeamSweep        `json:"sweep"`
+	Beams []BeamMeasurement `json:"beams"`
+	Track []BeamTrackPoint  `json:"track"`
+}
@@ -0,0 +1,172 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Streams a capture session can record.
+const (
+	CaptureStreamChannel = "channel"
+	CaptureStreamSensors = "sensors"
+	CaptureStreamIRS     = "irs"
+)
+
+// CaptureStreams are the streams of a session that does not name any.
+var CaptureStreams = []string{CaptureStreamChannel, CaptureStreamSensors, CaptureStreamIRS}
+
+type CaptureSessionStatus string
+
+const (
+	CaptureSessionRunning   CaptureSessionStatus = "running"
+	CaptureSessionCompleted CaptureSessionStatus = "completed"
+	CaptureSessionFailed    CaptureSessionStatus = "failed"
+)
+
+// Defaults and bounds of a capture session, in seconds.
+const (
+	DefaultCaptureInterval        = 1.0
+	MinCaptureInterval            = 0.05
+	DefaultCaptureChannelDuration = 0.1
+	// MaxCaptureSessionSamples bounds the samples a session keeps per
+	// stream; the stream stops once it is reached.
+	MaxCaptureSessionSamples = 100000
+)
+
+// CaptureSessionRequest starts capture sessions that sample each of Streams
+// every Interval seconds for Duration seconds, or until stopped. Zero uses
+// the configured limit, as with recordings. The channel stream collects
+// ChannelDuration seconds of channel data per tick, labelled with UserID
+// and FrequencyBand; the sensor stream reads SensorIDs, or every active
+// sensor; the irs stream reads the status of panel IRSID.
+type CaptureSessionRequest struct {
+	ExperimentID    string   `json:"experiment_id" binding:"required"`
+	Description     string   `json:"description"`
+	Streams         []string `json:"streams,omitempty"`
+	Interval        float64  `json:"interval,omitempty"`
+	Duration        float64  `json:"duration,omitempty" binding:"omitempty,min=0.1,max=86400"`
+	ChannelDuration float64  `json:"channel_duration,omitempty"`
+	UserID          int      `json:"user_id,omitempty"`
+	FrequencyBand   string   `json:"frequency_band,omitempty"`
+	SensorIDs       []string `json:"sensor_ids,omitempty"`
+	IRSID           string   `json:"irs_id,omitempty"`
+}
+
+func (r *CaptureSessionRequest) Validate() error {
+	seen := make(map[string]bool)
+	for _, stream := range r.Streams {
+		switch stream {
+		case CaptureStreamChannel, CaptureStreamSensors, CaptureStreamIRS:
+		default:
+			return &ValidationError{Field: "streams", Message: fmt.Sprintf("unknown stream %q, use %s, %s or %s", stream, CaptureStreamChannel, CaptureStreamSensors, CaptureStreamIRS)}
+		}
+		if seen[stream] {
+			return &ValidationError{Field: "streams", Message: fmt.Sprintf("stream %s is listed twice", stream)}
+		}
+		seen[stream] = true
+	}
+	if r.Interval != 0 && (r.Interval < MinCaptureInterval || r.Interval > 3600) {
+		return &ValidationError{Field: "interval", Message: fmt.Sprintf("must be between %g and 3600 s", MinCaptureInterval)}
+	}
+	if r.Duration < 0 {
+		return &ValidationError{Field: "duration", Message: "must not be negative"}
+	}
+	if r.ChannelDuration < 0 || r.ChannelDuration > 60 {
+		return &ValidationError{Field: "channel_duration", Message: "must be between 0 and 60 s"}
+	}
+	// a capture that outlasts the tick would skip every other tick
+	if d := r.WithDefaults(); d.Has(CaptureStreamChannel) && d.ChannelDuration >= d.Interval {
+		return &ValidationError{Field: "channel_duration", Message: fmt.Sprintf("%g s captures do not fit the %g s interval", d.ChannelDuration, d.Interval)}
+	}
+	return nil
+}
+
+// Has reports whether the request records stream.
+func (r *CaptureSessionRequest) Has(stream string) bool {
+	for _, s := range r.Streams {
+		if s == stream {
+			return true
+		}
+	}
+	return false
+}
+
+// WithDefaults fills in the streams, interval and channel capture length a
+// request leaves out.
+func (r CaptureSessionRequest) WithDefaults() *CaptureSessionRequest {
+	if len(r.Streams) == 0 {
+		r.Streams = CaptureStreams
+	}
+	if r.Interval == 0 {
+		r.Interval = DefaultCaptureInterval
+	}
+	if r.ChannelDuration == 0 {
+		r.ChannelDuration = DefaultCaptureChannelDuration
+	}
+	return &r
+}
+
+// CaptureSession records several streams on one clock. Every stream samples
+// at the ticks StartedAt + k·Interval, so samples of the same tick belong
+// together. Samples and Missed count the samples kept and the ticks lost to
+// failed or overrunning reads per stream; Errors holds the last error of
+// each stream.
+type CaptureSession struct {
+	ID           string               `json:"id"`
+	ExperimentID string               `json:"experiment_id"`
+	Description  string               `json:"description,omitempty"`
+	Streams      []string             `json:"streams"`
+	Interval     float64              `json:"interval"`
+	Status       CaptureSessionStatus `json:"status"`
+	StartedAt    time.Time            `json:"started_at"`
+	StoppedAt    *time.Time           `json:"stopped_at,omitempty"`
+	Samples      map[string]int       `json:"samples"`
+	Missed       map[string]int       `json:"missed"`
+	Errors       map[string]string    `json:"errors,omitempty"`
+}
+
+// CaptureSample places a sample on the session clock: Tick is its index,
+// Offset its seconds from the session start and Timestamp the tick's
+// instant, the same for every stream. The readings keep the times their
+// devices took them at.
+type CaptureSample struct {
+	Tick      int       `json:"tick"`
+	Offset    float64   `json:"offset"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// CaptureChannelSample is one channel collection. The amplitude and phase
+// traces are left out; they are stored with the channel data of the
+// session's experiment.
+type CaptureChannelSample struct {
+	CaptureSample
+	Measurement *ChannelMeasurement `json:"measurement"`
+}
+
+type CaptureSensorSample struct {
+	CaptureSample
+	Readings []*SensorReadResult `json:"readings"`
+}
+
+type CaptureIRSSample struct {
+	CaptureSample
+	Status *IRSStatus `json:"status"`
+}
+
+// CaptureSessionQuery narrows the data of a session to the samples From to
+// To seconds after its start; the default is the whole session.
+type CaptureSessionQuery struct {
+	From *float64 `form:"from"`
+	To   *float64 `form:"to"`
+}
+
+// CaptureSessionData are the streams of a session trimmed to the window
+// From–To, for joint analysis.
+type CaptureSessionData struct {
+	Session *CaptureSession        `json:"session"`
+	From    time.Time              `json:"from"`
+	To      time.Time              `json:"to"`
+	Channel []CaptureChannelSample `json:"channel"`
+	Sensors []CaptureSensorSample  `json:"sensors"`
+	IRS     []CaptureIRSSample     `json:"irs"`
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"math"
 	"time"
 )
 
@@ -14,6 +15,11 @@ type ChannelMeasurement struct {
 	SNR           float64   `json:"snr"`
 	BER           float64   `json:"ber"`
 	Timestamp     time.Time `json:"timestamp"`
+
+	ADC *ADCStats `json:"adc,omitempty"`
+	// Annotations are the labels covering the measurement, filled in by
+	// queries.
+	Annotations []ChannelAnnotation `json:"annotations,omitempty"`
 }
 
 func (ChannelMeasurement) MeasurementName() string {
@@ -26,8 +32,125 @@ type ChannelDataQuery struct {
 	FrequencyBand string    `form:"frequency_band"`
 	StartTime     time.Time `form:"start_time" time_format:"2006-01-02T15:04:05"`
 	EndTime       time.Time `form:"end_time" time_format:"2006-01-02T15:04:05"`
-	Page          int       `form:"page" binding:"min=1"`
-	PageSize      int       `form:"page_size" binding:"min=1,max=100"`
+	Page          int       `form:"page" binding:"omitempty,min=1"`
+	PageSize      int       `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// Range fills in the default time range, the last hour.
+func (q *ChannelDataQuery) Range() (start, end time.Time) {
+	start, end = q.StartTime, q.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if start.IsZero() {
+		start = end.Add(-time.Hour)
+	}
+	return start, end
+}
+
+// ChannelAnnotation labels channel measurements, e.g. "LOS blocked" or
+// "IRS off", for building supervised sensing datasets. It covers either the
+// one measurement MeasurementID, or every measurement taken between
+// StartTime and EndTime, of ExperimentID only if that is set.
+type ChannelAnnotation struct {
+	ID            string          `json:"id"`
+	ExperimentID  string          `json:"experiment_id,omitempty"`
+	MeasurementID string          `json:"measurement_id,omitempty"`
+	StartTime     time.Time       `json:"start_time"`
+	EndTime       time.Time       `json:"end_time"`
+	Labels        []string        `json:"labels"`
+	Note          string          `json:"note,omitempty"`
+	Target        *TargetPosition `json:"target,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+func (ChannelAnnotation) MeasurementName() string {
+	return "channel_annotation"
+}
+
+func (a *ChannelAnnotation) Covers(m *ChannelMeasurement) bool {
+	if a.MeasurementID != "" {
+		return a.MeasurementID == m.MeasurementID
+	}
+	if a.ExperimentID != "" && a.ExperimentID != m.ExperimentID {
+		return false
+	}
+	return !m.Timestamp.Before(a.StartTime) && !m.Timestamp.After(a.EndTime)
+}
+
+func (a *ChannelAnnotation) HasLabel(label string) bool {
+	for _, l := range a.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// TargetPosition is a sensing target's position in metres in the array
+// frame.
+type TargetPosition struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// Angles returns the direction of the target in degrees, azimuth from the x
+// axis towards y and elevation above the xy plane, as the arrays use them.
+func (p *TargetPosition) Angles() (azimuth, elevation float64) {
+	azimuth = math.Atan2(p.Y, p.X) * 180 / math.Pi
+	elevation = math.Atan2(p.Z, math.Hypot(p.X, p.Y)) * 180 / math.Pi
+	return azimuth, elevation
+}
+
+// ChannelAnnotationRequest annotates a measurement by its ID, or a time
+// range with StartTime and EndTime.
+type ChannelAnnotationRequest struct {
+	ExperimentID  string          `json:"experiment_id" binding:"max=50"`
+	MeasurementID string          `json:"measurement_id" binding:"max=64"`
+	StartTime     time.Time       `json:"start_time"`
+	EndTime       time.Time       `json:"end_time"`
+	Labels        []string        `json:"labels" binding:"required,min=1,max=20,dive,required,max=64"`
+	Note          string          `json:"note" binding:"max=500"`
+	Target        *TargetPosition `json:"target"`
+}
+
+func (r *ChannelAnnotationRequest) Validate() error {
+	if r.MeasurementID != "" {
+		if !r.StartTime.IsZero() || !r.EndTime.IsZero() {
+			return NewValidationError("a measurement annotation takes no time range")
+		}
+		return nil
+	}
+	if r.StartTime.IsZero() || r.EndTime.IsZero() {
+		return NewValidationError("measurement_id or start_time and end_time are required")
+	}
+	if r.EndTime.Before(r.StartTime) {
+		return NewValidationError("end_time must not be before start_time")
+	}
+	return nil
+}
+
+// ChannelAnnotationQuery lists the annotations overlapping StartTime to
+// EndTime; a zero bound is open.
+type ChannelAnnotationQuery struct {
+	ExperimentID  string `form:"experiment_id"`
+	MeasurementID string `form:"measurement_id"`
+	// MeasurementIDs selects the annotations of any of the measurements.
+	MeasurementIDs []string  `form:"-"`
+	Label          string    `form:"label"`
+	StartTime      time.Time `form:"start_time" time_format:"2006-01-02T15:04:05"`
+	EndTime        time.Time `form:"end_time" time_format:"2006-01-02T15:04:05"`
+}
+
+func (q *ChannelAnnotationQuery) Matches(a *ChannelAnnotation) bool {
+	if q.Label != "" && !a.HasLabel(q.Label) {
+		return false
+	}
+	if !q.StartTime.IsZero() && a.EndTime.Before(q.StartTime) {
+		return false
+	}
+	return q.EndTime.IsZero() || !a.StartTime.After(q.EndTime)
 }
 
 type ChannelCollectRequest struct {
@@ -36,6 +159,64 @@ type ChannelCollectRequest struct {
 	FrequencyBand string  `json:"frequency_band" binding:"required"`
 	Duration      float64 `json:"duration" binding:"required,min=0.1,max=60"`
 	SampleRate    float64 `json:"sample_rate" binding:"required,min=1000,max=100000000"`
+	// Impairments are applied to the captured samples on top of whatever the
+	// radio already introduces.
+	Impairments *RFImpairments `json:"impairments,omitempty"`
+}
+
+// RFImpairments models front-end imperfections. Phase noise is a Wiener
+// process specified by its single-sideband PSD level at an offset frequency;
+// a zero PSD offset disables it.
+type RFImpairments struct {
+	PhaseNoisePSD    float64 `json:"phase_noise_psd" mapstructure:"phase_noise_psd" binding:"max=0"`
+	PhaseNoiseOffset float64 `json:"phase_noise_offset" mapstructure:"phase_noise_offset" binding:"min=0"`
+	IQGainImbalance  float64 `json:"iq_gain_imbalance" mapstructure:"iq_gain_imbalance" binding:"min=-6,max=6"`
+	IQPhaseImbalance float64 `json:"iq_phase_imbalance" mapstructure:"iq_phase_imbalance" binding:"min=-45,max=45"`
+	DCOffsetI        float64 `json:"dc_offset_i" mapstructure:"dc_offset_i"`
+	DCOffsetQ        float64 `json:"dc_offset_q" mapstructure:"dc_offset_q"`
+	FrequencyOffset  float64 `json:"frequency_offset" mapstructure:"frequency_offset"`
+	// Per-channel gain (dB) and phase (degrees) mismatch of the RX paths,
+	// indexed by channel; missing entries are zero.
+	ChannelGainOffsets  []float64 `json:"channel_gain_offsets,omitempty" mapstructure:"channel_gain_offsets"`
+	ChannelPhaseOffsets []float64 `json:"channel_phase_offsets,omitempty" mapstructure:"channel_phase_offsets"`
+}
+
+func (r *RFImpairments) Enabled() bool {
+	return r != nil && (r.PhaseNoiseOffset > 0 || r.IQGainImbalance != 0 || r.IQPhaseImbalance != 0 ||
+		r.DCOffsetI != 0 || r.DCOffsetQ != 0 || r.FrequencyOffset != 0 ||
+		len(r.ChannelGainOffsets) > 0 || len(r.ChannelPhaseOffsets) > 0)
+}
+
+type ProbeWaveform string
+
+const (
+	ProbeWaveformTone      ProbeWaveform = "tone"
+	ProbeWaveformChirp     ProbeWaveform = "chirp"
+	ProbeWaveformZadoffChu ProbeWaveform = "zadoff_chu"
+)
+
+// ProbeRequest describes a pilot or sounding waveform to emit. Frequencies
+// are baseband offsets in Hz.
+type ProbeRequest struct {
+	ExperimentID string        `json:"experiment_id"`
+	Waveform     ProbeWaveform `json:"waveform" binding:"required,oneof=tone chirp zadoff_chu"`
+	Length       int           `json:"length" binding:"required,min=1,max=1048576"`
+	Repetitions  int           `json:"repetitions" binding:"omitempty,min=1,max=1000"`
+	Amplitude    float64       `json:"amplitude" binding:"omitempty,gt=0,max=1"`
+	ToneFreq     float64       `json:"tone_freq"`
+	Bandwidth    float64       `json:"bandwidth" binding:"omitempty,gt=0"`
+	Root         int           `json:"root" binding:"omitempty,min=1"`
+}
+
+type ProbeResult struct {
+	ExperimentID string        `json:"experiment_id"`
+	Waveform     ProbeWaveform `json:"waveform"`
+	Samples      int           `json:"samples"`
+	Repetitions  int           `json:"repetitions"`
+	SampleRate   float64       `json:"sample_rate"`
+	CenterFreq   float64       `json:"center_freq"`
+	Duration     float64       `json:"duration"`
+	Timestamp    time.Time     `json:"timestamp"`
 }
 
 type ChannelDataPoint struct {
@@ -44,4 +225,22 @@ type ChannelDataPoint struct {
 	Phase     float64 `json:"phase"`
 	I         float64 `json:"i"`
 	Q         float64 `json:"q"`
+	// Clipped is set when I or Q hit the ADC full-scale limit.
+	Clipped bool `json:"clipped,omitempty"`
+}
+
+// ADCConfig sets the simulated converter. Bits of zero disables quantization
+// and clipping; FullScale is the largest representable I or Q magnitude.
+type ADCConfig struct {
+	Bits      int     `json:"bits" mapstructure:"bits"`
+	FullScale float64 `json:"full_scale" mapstructure:"full_scale"`
+}
+
+// ADCStats summarizes clipping in one capture. Samples counts I/Q pairs
+// across all channels.
+type ADCStats struct {
+	Samples        int     `json:"samples"`
+	ClippedSamples int     `json:"clipped_samples"`
+	ClipRate       float64 `json:"clip_rate"`
+	PeakAmplitude  float64 `json:"peak_amplitude"`
 }
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// IRSCodebook is a named set of phase-shift vectors for beam training.
+type IRSCodebook struct {
+	ID           int64        `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name         string       `json:"name" gorm:"type:varchar(100);uniqueIndex;not null"`
+	Kind         CodebookKind `json:"kind" gorm:"type:varchar(20);not null"`
+	ElementCount int          `json:"element_count" gorm:"not null"`
+	Size         int          `json:"size" gorm:"not null"`
+	Entries      [][]float64  `json:"entries,omitempty" gorm:"type:json;serializer:json"`
+	CreatedAt    time.Time    `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (IRSCodebook) TableName() string {
+	return "irs_codebook"
+}
+
+type CodebookKind string
+
+const (
+	CodebookKindDFT    CodebookKind = "dft"
+	CodebookKindRandom CodebookKind = "random"
+	CodebookKindCustom CodebookKind = "custom"
+)
+
+const MaxCodebookSize = 1024
+
+// IRSCodebookRequest creates a codebook. DFT and random codebooks are
+// generated with Size entries (a DFT codebook defaults to one entry per
+// element); custom codebooks give their Entries.
+type IRSCodebookRequest struct {
+	Name         string       `json:"name" binding:"required"`
+	Kind         CodebookKind `json:"kind" binding:"required"`
+	ElementCount int          `json:"element_count" binding:"required,min=1,max=256"`
+	Size         int          `json:"size"`
+	Seed         int64        `json:"seed"`
+	Entries      [][]float64  `json:"entries"`
+}
+
+func (r *IRSCodebookRequest) Validate() error {
+	switch r.Kind {
+	case CodebookKindDFT, CodebookKindRandom:
+		if len(r.Entries) > 0 {
+			return NewValidationErrorf("entries are only accepted for %s codebooks", CodebookKindCustom)
+		}
+		if r.Size < 0 || r.Size > MaxCodebookSize {
+			return NewValidationErrorf("size must be in range [1, %d]", MaxCodebookSize)
+		}
+		if r.Kind == CodebookKindRandom && r.Size == 0 {
+			return NewValidationError("size is required for random codebooks")
+		}
+	case CodebookKindCustom:
+		if len(r.Entries) == 0 {
+			return NewValidationError("entries are required for custom codebooks")
+		}
+		if len(r.Entries) > MaxCodebookSize {
+			return NewValidationErrorf("a codebook holds at most %d entries", MaxCodebookSize)
+		}
+		for i, entry := range r.Entries {
+			if len(entry) != r.ElementCount {
+				return NewValidationErrorf("entries[%d] length must equal element_count", i)
+			}
+			if err := validatePhases(fmt.Sprintf("entries[%d] phase_shift", i), entry); err != nil {
+				return err
+			}
+		}
+	default:
+		return NewValidationErrorf("unknown codebook kind %q", r.Kind)
+	}
+	return nil
+}
+
+// IRSCodebookApplyRequest applies entry Index of a codebook to a panel, or
+// to one element group when Group is set.
+type IRSCodebookApplyRequest struct {
+	Index        *int   `json:"index" binding:"required"`
+	Group        string `json:"group"`
+	Version      int64  `json:"version"`
+	ExperimentID string `json:"experiment_id"`
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+)
+
+// ReplicaStatus is the last health check of a MySQL read replica.
+type ReplicaStatus struct {
+	Address   string    `json:"address"`
+	Healthy   bool      `json:"healthy"`
+	Lag       float64   `json:"lag"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// DBPoolStats is a snapshot of a database connection pool. WaitCount and
+// WaitDuration grow when MaxOpenConns is too small for the load.
+type DBPoolStats struct {
+	MaxOpen           int     `json:"max_open"`
+	Open              int     `json:"open"`
+	InUse             int     `json:"in_use"`
+	Idle              int     `json:"idle"`
+	WaitCount         int64   `json:"wait_count"`
+	WaitDuration      float64 `json:"wait_duration_s"`
+	MaxIdleClosed     int64   `json:"max_idle_closed"`
+	MaxLifetimeClosed int64   `json:"max_lifetime_closed"`
+}
+
+// MySQLPoolStats holds the pool of the primary and of each read replica,
+// keyed by replica address.
+type MySQLPoolStats struct {
+	Primary  DBPoolStats            `json:"primary"`
+	Replicas map[string]DBPoolStats `json:"replicas,omitempty" prom:"replica"`
+}
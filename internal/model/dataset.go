@@ -0,0 +1,83 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+type DatasetFormat string
+
+// DatasetFormatNPZ is a NumPy .npz archive; h5py can copy its arrays into
+// an HDF5 file unchanged.
+const DatasetFormatNPZ DatasetFormat = "npz"
+
+const (
+	DatasetSplitTrain = "train"
+	DatasetSplitVal   = "val"
+	DatasetSplitTest  = "test"
+)
+
+// DatasetSplit is the share of samples in each split; the shares sum to 1.
+type DatasetSplit struct {
+	Train float64 `json:"train" binding:"min=0,max=1"`
+	Val   float64 `json:"val" binding:"min=0,max=1"`
+	Test  float64 `json:"test" binding:"min=0,max=1"`
+}
+
+// DefaultDatasetSplit is used when a request leaves every share at zero.
+var DefaultDatasetSplit = DatasetSplit{Train: 0.7, Val: 0.15, Test: 0.15}
+
+// DatasetRequest builds a dataset from the channel measurements taken
+// between StartTime and EndTime. Each measurement is a sample labeled by the
+// annotations covering it; its DOA ground truth comes from an annotated
+// target position and its sensor context from the latest reading of each
+// sensor at most SensorWindow seconds before it.
+type DatasetRequest struct {
+	Name          string    `json:"name" binding:"max=100"`
+	ExperimentID  string    `json:"experiment_id" binding:"max=50"`
+	FrequencyBand string    `json:"frequency_band"`
+	StartTime     time.Time `json:"start_time" binding:"required"`
+	EndTime       time.Time `json:"end_time" binding:"required"`
+	// Labels are the classes, in label column order. Empty means every label
+	// found in the range, sorted.
+	Labels           []string      `json:"labels" binding:"max=100,dive,required,max=64"`
+	IncludeUnlabeled bool          `json:"include_unlabeled"`
+	SensorIDs        []string      `json:"sensor_ids" binding:"max=100"`
+	SensorWindow     float64       `json:"sensor_window" binding:"min=0"`
+	Split            DatasetSplit  `json:"split"`
+	Seed             int64         `json:"seed"`
+	Format           DatasetFormat `json:"format" binding:"omitempty,oneof=npz"`
+	// MaxSamples keeps the newest measurements when the range holds more.
+	MaxSamples int `json:"max_samples" binding:"min=0,max=100000"`
+}
+
+func (r *DatasetRequest) Validate() error {
+	if r.EndTime.Before(r.StartTime) {
+		return NewValidationError("end_time must not be before start_time")
+	}
+	if r.Split == (DatasetSplit{}) {
+		return nil
+	}
+	if sum := r.Split.Train + r.Split.Val + r.Split.Test; math.Abs(sum-1) > 1e-6 {
+		return NewValidationErrorf("split shares sum to %g, want 1", sum)
+	}
+	return nil
+}
+
+// DatasetResult describes a built dataset, stored as a dataset artifact.
+// Splits counts the samples in each split; the archive holds the arrays of
+// each split under its name, e.g. train_amplitude.
+type DatasetResult struct {
+	ArtifactID  int64          `json:"artifact_id"`
+	Name        string         `json:"name"`
+	Format      DatasetFormat  `json:"format"`
+	Key         string         `json:"key"`
+	Size        int64          `json:"size"`
+	Checksum    string         `json:"checksum"`
+	Samples     int            `json:"samples"`
+	Splits      map[string]int `json:"splits"`
+	Classes     []string       `json:"classes"`
+	Sensors     []string       `json:"sensors"`
+	Subcarriers int            `json:"subcarriers"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
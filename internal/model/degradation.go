@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+// Degradation policies, chosen per dependency, for while it is down.
+// fail-requests rejects every API request, so that clients fail fast rather
+// than get partial results; degrade-readonly rejects only the requests that
+// change state and serves reads with whatever is still available;
+// buffer-and-retry keeps serving and holds the writes to the dependency in
+// memory until it is back.
+const (
+	DegradationFailRequests   = "fail-requests"
+	DegradationReadOnly       = "degrade-readonly"
+	DegradationBufferAndRetry = "buffer-and-retry"
+)
+
+func ValidDegradationPolicy(policy string) bool {
+	switch policy {
+	case DegradationFailRequests, DegradationReadOnly, DegradationBufferAndRetry:
+		return true
+	}
+	return false
+}
+
+// DependencyStatus is the state of one external dependency. Since is when
+// it last became available or unavailable, and Error is the last reason it
+// was unavailable. Buffered counts the writes held for it, Dropped those
+// discarded because the buffer was full and Replayed those written after it
+// came back.
+type DependencyStatus struct {
+	Name      string     `json:"name"`
+	Policy    string     `json:"policy"`
+	Available bool       `json:"available"`
+	Since     time.Time  `json:"since"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	Buffered  int        `json:"buffered,omitempty"`
+	Dropped   int64      `json:"dropped,omitempty"`
+	Replayed  int64      `json:"replayed,omitempty"`
+}
+
+// DegradationStatus sums up the dependencies. The server is not Ready while
+// a dependency under fail-requests is down, and ReadOnly while one under
+// degrade-readonly is; Degraded is set whenever any dependency is down.
+type DegradationStatus struct {
+	Ready        bool               `json:"ready"`
+	Degraded     bool               `json:"degraded"`
+	ReadOnly     bool               `json:"read_only"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
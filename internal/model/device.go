@@ -0,0 +1,137 @@
+package model
+
+import "time"
+
+type DeviceInfo struct {
+	Name string `json:"name"`
+	// ID tells apart devices of the same kind, e.g. IRS panels.
+	ID         string `json:"id,omitempty"`
+	Enabled    bool   `json:"enabled"`
+	Simulator  bool   `json:"simulator"`
+	DriverType string `json:"driver_type,omitempty"`
+	Available  bool   `json:"available"`
+	Connected  bool   `json:"connected"`
+	Error      string `json:"error,omitempty"`
+	// Sync is reported by connected radios that can check their clock.
+	Sync *SyncStatus `json:"sync,omitempty"`
+	// Versions is reported by connected devices that know their firmware.
+	Versions *DeviceVersions `json:"versions,omitempty"`
+}
+
+// DeviceVersions are the firmware running on a device and the version of
+// the driver talking to it. Firmware is empty when the device cannot report
+// it.
+type DeviceVersions struct {
+	Firmware string `json:"firmware,omitempty"`
+	Driver   string `json:"driver"`
+}
+
+// Clock sources select the frequency reference and the PPS time source
+// together.
+const (
+	ClockSourceInternal = "internal"
+	ClockSourceExternal = "external"
+	ClockSourceGPSDO    = "gpsdo"
+)
+
+// SyncStatus reports reference lock. TimeOffset is device time minus host
+// time in seconds when the status was read.
+type SyncStatus struct {
+	ClockSource string    `json:"clock_source"`
+	RefLocked   bool      `json:"ref_locked"`
+	GPSLocked   bool      `json:"gps_locked,omitempty"`
+	TimeOffset  float64   `json:"time_offset"`
+	CheckedAt   time.Time `json:"checked_at"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// USRPDevice describes a radio found by enumeration. Args is the UHD device
+// address used to open it.
+type USRPDevice struct {
+	Serial       string           `json:"serial"`
+	Type         string           `json:"type"`
+	Product      string           `json:"product"`
+	Name         string           `json:"name,omitempty"`
+	Args         string           `json:"args"`
+	Simulated    bool             `json:"simulated"`
+	Channels     int              `json:"channels"`
+	Capabilities USRPCapabilities `json:"capabilities"`
+	Bound        bool             `json:"bound"`
+}
+
+type USRPCapabilities struct {
+	RX            bool    `json:"rx"`
+	TX            bool    `json:"tx"`
+	MaxSampleRate float64 `json:"max_sample_rate"`
+	MinFreq       float64 `json:"min_freq"`
+	MaxFreq       float64 `json:"max_freq"`
+}
+
+type USRPBindRequest struct {
+	Serial string `json:"serial" binding:"required"`
+}
+
+// AGCConfig tunes the automatic gain control loop. Levels are the peak sample
+// magnitude in dB relative to FullScale, gains are in dB and times are in
+// seconds. Zero fields take their defaults.
+type AGCConfig struct {
+	TargetDBFS    float64 `json:"target_dbfs" mapstructure:"target_dbfs"`
+	ToleranceDB   float64 `json:"tolerance_db" mapstructure:"tolerance_db"`
+	MaxStepDB     float64 `json:"max_step_db" mapstructure:"max_step_db"`
+	MinGain       float64 `json:"min_gain" mapstructure:"min_gain"`
+	MaxGain       float64 `json:"max_gain" mapstructure:"max_gain"`
+	Interval      float64 `json:"interval" mapstructure:"interval"`
+	ProbeDuration float64 `json:"probe_duration" mapstructure:"probe_duration"`
+	FullScale     float64 `json:"full_scale" mapstructure:"full_scale"`
+}
+
+type AGCStatus struct {
+	Enabled     bool      `json:"enabled"`
+	Config      AGCConfig `json:"config"`
+	LevelDBFS   float64   `json:"level_dbfs"`
+	ClipRate    float64   `json:"clip_rate"`
+	Adjustments int       `json:"adjustments"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+type USRPGainStatus struct {
+	Gain float64    `json:"gain"`
+	AGC  *AGCStatus `json:"agc,omitempty"`
+}
+
+type USRPGainRequest struct {
+	Gain *float64 `json:"gain" binding:"required"`
+}
+
+// USRPAGCRequest turns AGC on or off; the embedded settings override the
+// configured defaults when enabling.
+type USRPAGCRequest struct {
+	Enabled bool `json:"enabled"`
+	AGCConfig
+}
+
+// RXCalibration holds the measured gain (dB) and phase (degrees) of every RX
+// channel relative to channel 0. Captures are corrected by the inverse.
+// Phase offsets depend on the tuning, so CenterFreq records where they were
+// measured.
+type RXCalibration struct {
+	Serial       string    `json:"serial,omitempty"`
+	Channels     int       `json:"channels"`
+	ToneFreq     float64   `json:"tone_freq"`
+	SampleRate   float64   `json:"sample_rate"`
+	CenterFreq   float64   `json:"center_freq"`
+	GainOffsets  []float64 `json:"gain_offsets"`
+	PhaseOffsets []float64 `json:"phase_offsets"`
+	SNR          []float64 `json:"snr"`
+	MeasuredAt   time.Time `json:"measured_at"`
+}
+
+// RXCalibrationRequest measures channel offsets from a tone at ToneFreq Hz
+// from the carrier, captured for Duration seconds. Amplitude is used by
+// drivers that can inject the tone themselves.
+type RXCalibrationRequest struct {
+	ToneFreq  float64 `json:"tone_freq"`
+	Amplitude float64 `json:"amplitude" binding:"omitempty,gt=0,max=1"`
+	Duration  float64 `json:"duration" binding:"omitempty,gt=0,max=1"`
+}
@@ -0,0 +1,256 @@
+package model
+
+import (
+	"fmt"
+	"math"
+)
+
+type DiagnosticSeverity string
+
+const (
+	DiagnosticError   DiagnosticSeverity = "error"
+	DiagnosticWarning DiagnosticSeverity = "warning"
+)
+
+// Diagnostic is one finding of a dry run. Errors would make the request
+// fail; warnings point at settings that run but probably not as intended.
+type Diagnostic struct {
+	Field    string             `json:"field,omitempty"`
+	Severity DiagnosticSeverity `json:"severity"`
+	Message  string             `json:"message"`
+}
+
+type Diagnostics []Diagnostic
+
+func (d *Diagnostics) Errorf(field, format string, args ...interface{}) {
+	*d = append(*d, Diagnostic{Field: field, Severity: DiagnosticError, Message: fmt.Sprintf(format, args...)})
+}
+
+func (d *Diagnostics) Warnf(field, format string, args ...interface{}) {
+	*d = append(*d, Diagnostic{Field: field, Severity: DiagnosticWarning, Message: fmt.Sprintf(format, args...)})
+}
+
+// Add records err as an error; a ValidationError keeps its field.
+func (d *Diagnostics) Add(err error) {
+	if err == nil {
+		return
+	}
+	if ve, ok := err.(*ValidationError); ok {
+		d.Errorf(ve.Field, "%s", ve.Message)
+		return
+	}
+	d.Errorf("", "%s", err.Error())
+}
+
+func (d Diagnostics) HasErrors() bool {
+	for _, diag := range d {
+		if diag.Severity == DiagnosticError {
+			return true
+		}
+	}
+	return false
+}
+
+// CostEstimate is the expected cost of running a request. Runtime is in
+// seconds and includes capture and dwell time; Operations counts complex
+// multiply-adds and Memory the bytes of the largest working set. Samples is
+// the number of measured runs the runtime is calibrated with.
+type CostEstimate struct {
+	Runtime    float64  `json:"runtime"`
+	Samples    int      `json:"samples"`
+	Operations float64  `json:"operations"`
+	Memory     int64    `json:"memory"`
+	Iterations int      `json:"iterations,omitempty"`
+	GridPoints int      `json:"grid_points,omitempty"`
+	Snapshots  int      `json:"snapshots,omitempty"`
+	Channels   int      `json:"channels,omitempty"`
+	Devices    []string `json:"devices"`
+}
+
+// DryRunResult answers a request sent with dry_run=true. Nothing is executed
+// or written to a device; Preview shows what would be applied, where that is
+// known in advance.
+type DryRunResult struct {
+	Valid       bool          `json:"valid"`
+	Diagnostics Diagnostics   `json:"diagnostics"`
+	Estimate    *CostEstimate `json:"estimate,omitempty"`
+	Preview     interface{}   `json:"preview,omitempty"`
+}
+
+func NewDryRunResult(diags Diagnostics, estimate *CostEstimate, preview interface{}) *DryRunResult {
+	if diags == nil {
+		diags = Diagnostics{}
+	}
+	return &DryRunResult{
+		Valid:       !diags.HasErrors(),
+		Diagnostics: diags,
+		Estimate:    estimate,
+		Preview:     preview,
+	}
+}
+
+// Diagnose checks the parameters that do not depend on the devices: the
+// violations of the request's rules, and settings that run but probably not
+// as intended.
+func (p *BeamformingParams) Diagnose() Diagnostics {
+	d := p.Violations()
+	switch p.Mode {
+	case "", BeamformingModeTarget:
+		if p.ElementCount > MaxIRSElements {
+			d.Warnf("element_count", "element_count %d exceeds the largest IRS panel (%d elements)", p.ElementCount, MaxIRSElements)
+		}
+		diagnoseAngle(&d, "target_direction", p.TargetDirection)
+		for i, angle := range p.InterferenceAngles {
+			diagnoseAngle(&d, fmt.Sprintf("interference_angles[%d]", i), angle)
+		}
+		if p.ElementCount > 0 && len(p.InterferenceAngles) >= p.ElementCount && p.InterferenceINR != nil {
+			d.Warnf("interference_angles", "%d interference angles leave no degrees of freedom with %d elements", len(p.InterferenceAngles), p.ElementCount)
+		}
+		if p.SNRThreshold <= 0 {
+			d.Warnf("snr_threshold", "snr_threshold %g is met by any weights, the optimizer stops after the first iteration", p.SNRThreshold)
+		} else if limit := math.Sqrt(float64(p.ElementCount)); p.ElementCount > 0 && p.SNRThreshold > limit {
+			d.Warnf("snr_threshold", "snr_threshold %g exceeds the largest array response %.3g of %d elements, the optimizer will not converge", p.SNRThreshold, limit, p.ElementCount)
+		}
+	case BeamformingModeWMMSE:
+		for i, user := range p.Users {
+			diagnoseAngle(&d, fmt.Sprintf("users[%d].angle", i), user.Angle)
+		}
+		if p.ElementCount > 0 && len(p.Users) > p.ElementCount {
+			d.Warnf("users", "%d users share %d antennas, some will be served at a low rate", len(p.Users), p.ElementCount)
+		}
+	case BeamformingModeOFDM:
+		if p.OFDM == nil {
+			break
+		}
+		for i, path := range p.OFDM.Paths {
+			diagnoseAngle(&d, fmt.Sprintf("ofdm.paths[%d].angle", i), path.Angle)
+		}
+		o := p.OFDM.WithDefaults()
+		if o.Subcarriers <= 0 || o.ResourceBlockSize <= 0 {
+			break
+		}
+		if o.ResourceBlockSize >= o.Subcarriers && o.Subcarriers > 1 {
+			d.Warnf("ofdm.resource_block_size", "resource_block_size %d covers all %d subcarriers, the run returns a single wideband beam", o.ResourceBlockSize, o.Subcarriers)
+		} else if rest := o.Subcarriers % o.ResourceBlockSize; rest != 0 {
+			d.Warnf("ofdm.resource_block_size", "%d subcarriers are not a whole number of resource blocks of %d, the last block holds %d", o.Subcarriers, o.ResourceBlockSize, rest)
+		}
+	case BeamformingModeRobust:
+		if p.Robust == nil {
+			break
+		}
+		if len(p.Robust.Channel) == 0 {
+			diagnoseAngle(&d, "target_direction", p.TargetDirection)
+		}
+		for i, angle := range p.InterferenceAngles {
+			diagnoseAngle(&d, fmt.Sprintf("interference_angles[%d]", i), angle)
+		}
+		if p.Robust.ErrorBound == 0 {
+			d.Warnf("robust.error_bound", "an error bound of 0 trusts the estimate, the weights are MVDR")
+		} else if p.Robust.ErrorBound >= 0.5 && len(p.InterferenceAngles) > 0 {
+			d.Warnf("robust.error_bound", "an error bound of %g leaves little of the estimate to rely on, the weights approach matched filtering and suppress the interferers less", p.Robust.ErrorBound)
+		}
+	}
+	return d
+}
+
+// Diagnose checks a joint beamforming request and warns about angles behind
+// either array.
+func (p *JointBeamformingParams) Diagnose() Diagnostics {
+	d := p.Violations()
+	if d.HasErrors() {
+		return d
+	}
+	diagnoseAngle(&d, "direct_angle", p.DirectAngle)
+	diagnoseAngle(&d, "irs_angle", p.IRSAngle)
+	diagnoseAngle(&d, "irs_arrival_angle", p.IRSArrivalAngle)
+	diagnoseAngle(&d, "user_angle", p.UserAngle)
+	if p.Randomizations > 0 && p.Method != JointMethodSDR {
+		d.Warnf("randomizations", "randomizations only apply to the sdr method")
+	}
+	return d
+}
+
+// Diagnose checks a hybrid beamforming request and warns about paths
+// behind either array and about RF chains or streams the paths cannot use.
+func (p *HybridBeamformingParams) Diagnose() Diagnostics {
+	d := p.Violations()
+	if d.HasErrors() {
+		return d
+	}
+	for i, path := range p.Paths {
+		diagnoseAngle(&d, fmt.Sprintf("paths[%d].departure_angle", i), path.DepartureAngle)
+		diagnoseAngle(&d, fmt.Sprintf("paths[%d].arrival_angle", i), path.ArrivalAngle)
+	}
+	if len(p.Paths) > 0 && p.NumPaths > 0 {
+		d.Warnf("num_paths", "num_paths only applies to random paths, the %d paths given are used", len(p.Paths))
+	}
+	w := p.WithDefaults()
+	if w.Streams > w.NumPaths {
+		d.Warnf("streams", "%d paths give the channel a rank of at most %d, the other streams carry nothing", w.NumPaths, w.NumPaths)
+	}
+	if w.Dictionary == HybridDictionaryPaths && w.RFChains > w.NumPaths {
+		d.Warnf("rf_chains", "the paths dictionary has %d beams, %d of the %d RF chains stay unused", w.NumPaths, w.RFChains-w.NumPaths, w.RFChains)
+	}
+	return d
+}
+
+// Diagnose checks the parameters that do not depend on the devices. The
+// element count of a USRP capture is the receiver's channel count, which is
+// checked by the caller.
+func (p *DOAParams) Diagnose() Diagnostics {
+	d := p.Violations()
+	switch p.Method {
+	case "", "MUSIC":
+	case "ESPRIT":
+		if p.SearchStep > 0 || p.ElevationStep > 0 || p.Refine {
+			d.Warnf("method", "ESPRIT does not scan, search settings are ignored")
+		}
+	default:
+		d.Warnf("method", "unknown method %q runs MUSIC", p.Method)
+	}
+	if (p.Rows == 1 && p.Cols >= 1) || (p.Cols == 1 && p.Rows >= 1) {
+		d.Warnf("rows", "a %d×%d array is linear and cannot resolve elevation", p.Rows, p.Cols)
+	}
+	diagnoseSearch(&d, "search", p.SearchRangeMin, p.SearchRangeMax, p.SearchStep)
+	diagnoseSearch(&d, "elevation", p.ElevationMin, p.ElevationMax, p.ElevationStep)
+	if w := p.Wideband; w != nil {
+		method := w.Method
+		if method == "" {
+			method = WidebandIncoherent
+		}
+		if w.Subbands >= 2 && p.SnapshotLength >= w.Subbands && p.SnapshotLength/w.Subbands < p.Elements() {
+			d.Warnf("wideband.subbands", "%d snapshots per subband for %d elements give singular subband covariances; use fewer subbands or more snapshots", p.SnapshotLength/w.Subbands, p.Elements())
+		}
+		if w.SampleRate > 0 && w.SampleRate < 0.01*w.CenterFrequency {
+			d.Warnf("wideband.sample_rate", "a %g%% fractional bandwidth is narrowband; %s estimation adds cost without benefit", 100*w.SampleRate/w.CenterFrequency, method)
+		}
+	}
+	return d
+}
+
+// diagnoseAngle warns about a beamforming angle, in radians, behind the
+// array. Angles beyond ±π break a rule.
+func diagnoseAngle(d *Diagnostics, field string, angle float64) {
+	if math.Abs(angle) > math.Pi/2 && math.Abs(angle) <= math.Pi {
+		d.Warnf(field, "%s %g lies behind the array", field, angle)
+	}
+}
+
+// diagnoseSearch checks a DOA scan axis, which is in degrees. A negative
+// step breaks a rule.
+func diagnoseSearch(d *Diagnostics, axis string, lo, hi, step float64) {
+	stepField := axis + "_step"
+	if step <= 0 {
+		return
+	}
+	if lo >= hi {
+		d.Warnf(axis+"_range", "%s range [%g, %g] is empty, [-90, 90] is scanned", axis, lo, hi)
+		return
+	}
+	if lo < -90 || hi > 90 {
+		d.Warnf(axis+"_range", "%s range [%g, %g] extends beyond [-90, 90] degrees", axis, lo, hi)
+	}
+	if step > hi-lo {
+		d.Warnf(stepField, "%s %g is wider than the range, only one point is scanned", stepField, step)
+	}
+}
@@ -1,28 +1,38 @@
 package model
 
 import (
+	"fmt"
+	"math"
 	"time"
 )
 
+// ExperimentResult is updated compare-and-swap on Version: every update
+// bumps it, and an update made against a stale version is rejected.
 type ExperimentResult struct {
-	ID             int64            `json:"id" gorm:"primaryKey;autoIncrement"`
-	ExperimentID   string           `json:"experiment_id" gorm:"type:varchar(50);uniqueIndex;not null"`
-	AlgorithmType  AlgorithmType    `json:"algorithm_type" gorm:"type:varchar(50);not null"`
-	Parameters     string           `json:"parameters" gorm:"type:json"`
-	ResultData     *string          `json:"result_data" gorm:"type:json"`
-	MATLABFilePath string           `json:"matlab_file_path" gorm:"type:varchar(255)"`
-	Status         ExperimentStatus `json:"status" gorm:"type:tinyint;default:1"`
-	CreatedAt      time.Time        `json:"created_at" gorm:"autoCreateTime"`
-	CompletedAt    *time.Time       `json:"completed_at"`
+	ID            int64            `json:"id" gorm:"primaryKey;autoIncrement"`
+	ExperimentID  string           `json:"experiment_id" gorm:"type:varchar(50);uniqueIndex;not null"`
+	AlgorithmType AlgorithmType    `json:"algorithm_type" gorm:"type:varchar(50);not null"`
+	Parameters    string           `json:"parameters" gorm:"type:json"`
+	ResultData    *string          `json:"result_data" gorm:"type:json"`
+	PowerEstimate *string          `json:"power_estimate" gorm:"type:json"`
+	EnergyReport  *string          `json:"energy_report" gorm:"type:json"`
+	Status        ExperimentStatus `json:"status" gorm:"type:tinyint;default:1"`
+	Holder        string           `json:"holder,omitempty" gorm:"type:varchar(100)"`
+	Version       int64            `json:"version" gorm:"not null;default:1"`
+	CreatedAt     time.Time        `json:"created_at" gorm:"autoCreateTime"`
+	CompletedAt   *time.Time       `json:"completed_at"`
 }
 
 type AlgorithmType string
 
 const (
-	AlgorithmTypeBeamforming AlgorithmType = "beamforming"
-	AlgorithmTypeDOA         AlgorithmType = "doa"
-	AlgorithmTypeScheduling  AlgorithmType = "scheduling"
-	AlgorithmTypeRateless    AlgorithmType = "rateless"
+	AlgorithmTypeBeamforming       AlgorithmType = "beamforming"
+	AlgorithmTypeDOA               AlgorithmType = "doa"
+	AlgorithmTypeScheduling        AlgorithmType = "scheduling"
+	AlgorithmTypeRateless          AlgorithmType = "rateless"
+	AlgorithmTypeIRSImpact         AlgorithmType = "irs_impact"
+	AlgorithmTypeJointBeamforming  AlgorithmType = "joint_beamforming"
+	AlgorithmTypeHybridBeamforming AlgorithmType = "hybrid_beamforming"
 )
 
 type ExperimentStatus int
@@ -34,6 +44,14 @@ const (
 	ExperimentStatusFailed    ExperimentStatus = 3
 )
 
+// ExperimentEvent is streamed on the experiment's topic whenever its status
+// changes.
+type ExperimentEvent struct {
+	ExperimentID  string           `json:"experiment_id"`
+	AlgorithmType AlgorithmType    `json:"algorithm_type"`
+	Status        ExperimentStatus `json:"status"`
+}
+
 func (ExperimentResult) TableName() string {
 	return "experiment_result"
 }
@@ -48,8 +66,99 @@ type BeamformingParams struct {
 	ElementCount       int       `json:"element_count"`
 	TargetDirection    float64   `json:"target_direction"`
 	InterferenceAngles []float64 `json:"interference_angles"`
-	SNRThreshold       float64   `json:"snr_threshold"`
-	MaxIterations      int       `json:"max_iterations"`
+	// InterferenceINR is the interference-to-noise ratio in dB assumed for
+	// each interferer. Without it the weights place exact nulls at the
+	// interference angles (LCMV); with it they minimize the interference
+	// plus noise power (MVDR), whose nulls are as deep as the INR warrants.
+	InterferenceINR *float64             `json:"interference_inr,omitempty"`
+	SNRThreshold    float64              `json:"snr_threshold"`
+	MaxIterations   int                  `json:"max_iterations"`
+	Objective       BeamformingObjective `json:"objective,omitempty"`
+	Power           *PowerParams         `json:"power,omitempty"`
+
+	// Mode eigen ignores TargetDirection and uses the dominant eigenvectors
+	// of the covariance of SnapshotLength live USRP snapshots as weights,
+	// steering toward whatever the channel currently favours. NumBeams
+	// selects how many eigenvectors are returned.
+	Mode           BeamformingMode    `json:"mode,omitempty"`
+	NumBeams       int                `json:"num_beams,omitempty"`
+	SnapshotLength int                `json:"snapshot_length,omitempty"`
+	Covariance     *CovarianceOptions `json:"covariance,omitempty"`
+
+	// Mode wmmse serves every one of Users at once from ElementCount
+	// transmit antennas and maximizes their weighted sum rate under the
+	// transmit power of Power with the WMMSE algorithm, iterating at most
+	// MaxIterations times (the configured limit when zero).
+	Users []BeamformingUser `json:"users,omitempty"`
+
+	// Mode ofdm computes weights for every subcarrier, or every resource
+	// block, of the frequency-selective channel OFDM describes.
+	OFDM *OFDMParams `json:"ofdm,omitempty"`
+
+	// Mode robust designs for the channel estimate of Robust, which may be
+	// off by up to its error bound, and maximizes the worst-case SINR
+	// against the interferers of InterferenceAngles at InterferenceINR.
+	Robust *RobustParams `json:"robust,omitempty"`
+}
+
+type BeamformingMode string
+
+const (
+	BeamformingModeTarget BeamformingMode = "target"
+	BeamformingModeEigen  BeamformingMode = "eigen"
+	BeamformingModeWMMSE  BeamformingMode = "wmmse"
+	BeamformingModeOFDM   BeamformingMode = "ofdm"
+	BeamformingModeRobust BeamformingMode = "robust"
+)
+
+// MaxBeamformingUsers bounds the users of a wmmse run.
+const MaxBeamformingUsers = 64
+
+// BeamformingUser is a single-antenna user at Angle radians from broadside
+// whose channel is PathLoss dB weaker than the others'. Weight is its
+// priority in the sum rate, 1 when zero.
+type BeamformingUser struct {
+	Angle    float64 `json:"angle"`
+	Weight   float64 `json:"weight,omitempty"`
+	PathLoss float64 `json:"path_loss,omitempty"`
+}
+
+// ValidateUsers checks the users of a wmmse run.
+func (p *BeamformingParams) ValidateUsers() error {
+	if len(p.Users) == 0 || len(p.Users) > MaxBeamformingUsers {
+		return &ValidationError{Field: "users", Message: fmt.Sprintf("wmmse needs between 1 and %d users", MaxBeamformingUsers)}
+	}
+	for i, user := range p.Users {
+		if math.IsNaN(user.Angle) || math.Abs(user.Angle) > math.Pi {
+			return &ValidationError{Field: fmt.Sprintf("users[%d].angle", i), Message: "must be within [-π, π]; angles are in radians"}
+		}
+		if user.Weight < 0 || math.IsNaN(user.Weight) {
+			return &ValidationError{Field: fmt.Sprintf("users[%d].weight", i), Message: "must not be negative"}
+		}
+		if user.PathLoss < 0 || math.IsNaN(user.PathLoss) {
+			return &ValidationError{Field: fmt.Sprintf("users[%d].path_loss", i), Message: "must not be negative"}
+		}
+	}
+	return nil
+}
+
+type BeamformingObjective string
+
+const (
+	BeamformingObjectiveSpectral BeamformingObjective = "spectral_efficiency"
+	BeamformingObjectiveEnergy   BeamformingObjective = "energy_efficiency"
+)
+
+// PowerParams describes the power budget used to evaluate energy efficiency.
+// Powers are in watts, bandwidth in hertz; zero values fall back to defaults.
+type PowerParams struct {
+	MaxTransmitPower    float64 `json:"max_transmit_power"`
+	AmplifierEfficiency float64 `json:"amplifier_efficiency"`
+	CircuitPower        float64 `json:"circuit_power"`
+	IRSElementCount     int     `json:"irs_element_count"`
+	IRSElementPower     float64 `json:"irs_element_power"`
+	NoisePower          float64 `json:"noise_power"`
+	Bandwidth           float64 `json:"bandwidth"`
 }
 
 type DOAParams struct {
@@ -60,6 +169,130 @@ type DOAParams struct {
 	SearchRangeMin float64 `json:"search_range_min"`
 	SearchRangeMax float64 `json:"search_range_max"`
 	SearchStep     float64 `json:"search_step"`
+	// Elevation bounds in degrees; a positive ElevationStep scans a 2D
+	// azimuth-elevation grid.
+	ElevationMin  float64 `json:"elevation_min,omitempty"`
+	ElevationMax  float64 `json:"elevation_max,omitempty"`
+	ElevationStep float64 `json:"elevation_step,omitempty"`
+	// Refine scans a coarse grid first and searches at SearchStep only
+	// around its peaks.
+	Refine bool   `json:"refine,omitempty"`
+	Source string `json:"source,omitempty"`
+	// Recording names the SigMF recording a recording source reads
+	// SnapshotLength samples from, starting at sample RecordingOffset.
+	Recording       string `json:"recording,omitempty"`
+	RecordingOffset int64  `json:"recording_offset,omitempty" binding:"min=0"`
+	// Rows and Cols describe a uniform rectangular receive array in place
+	// of the configured one, with elements Spacing wavelengths apart (half a
+	// wavelength when zero). Planar arrays resolve azimuth and elevation and
+	// scan elevation over [-90°, 90°] unless ElevationStep is set.
+	// ElementCount may then be omitted.
+	Rows    int     `json:"rows,omitempty" binding:"min=0"`
+	Cols    int     `json:"cols,omitempty" binding:"min=0"`
+	Spacing float64 `json:"spacing,omitempty" binding:"min=0"`
+
+	Impairments *RFImpairments `json:"impairments,omitempty"`
+	// StoreSnapshots keeps the snapshot matrix as an artifact even when it is
+	// not enabled in the configuration.
+	StoreSnapshots bool `json:"store_snapshots,omitempty"`
+	// Covariance overrides the configured covariance estimator.
+	Covariance *CovarianceOptions `json:"covariance,omitempty"`
+	// Smoothing overrides the configured spatial smoothing.
+	Smoothing *SpatialSmoothingOptions `json:"smoothing,omitempty"`
+	// Wideband estimates from FFT subbands instead of treating the
+	// snapshots as narrowband.
+	Wideband *WidebandOptions `json:"wideband,omitempty"`
+	// Seed seeds the phase noise of Impairments and the wideband source
+	// signals of synthetic snapshots so that runs repeat exactly.
+	Seed *int64 `json:"seed,omitempty"`
+	// SourceDetection infers the number of sources from the covariance
+	// eigenvalues with the AIC or MDL criterion instead of taking
+	// NumSources, which then only sets how many sources synthetic snapshots
+	// hold. "auto" uses MDL.
+	SourceDetection string `json:"source_detection,omitempty"`
+}
+
+const (
+	SourceDetectionAuto = "auto"
+	SourceDetectionAIC  = "aic"
+	SourceDetectionMDL  = "mdl"
+)
+
+const (
+	CovarianceSample          = "sample"
+	CovarianceDiagonalLoading = "diagonal_loading"
+	CovarianceLedoitWolf      = "ledoit_wolf"
+)
+
+// CovarianceOptions selects how the spatial covariance is estimated from
+// snapshots. LoadingFactor is relative to the average element power and only
+// applies to diagonal loading; forward-backward averaging can be combined
+// with any method but assumes a centro-symmetric array such as a ULA.
+type CovarianceOptions struct {
+	Method          string  `json:"method" mapstructure:"method"`
+	LoadingFactor   float64 `json:"loading_factor" mapstructure:"loading_factor" binding:"min=0"`
+	ForwardBackward bool    `json:"forward_backward" mapstructure:"forward_backward"`
+}
+
+// Planar reports whether the params describe their own rectangular array.
+func (p *DOAParams) Planar() bool {
+	return p.Rows > 0 || p.Cols > 0
+}
+
+// Elements is the number of receive elements, Rows·Cols for a planar array
+// whose ElementCount is omitted.
+func (p *DOAParams) Elements() int {
+	if p.ElementCount == 0 && p.Planar() {
+		return p.Rows * p.Cols
+	}
+	return p.ElementCount
+}
+
+// DOADirection is an estimated direction of arrival in radians.
+type DOADirection struct {
+	Azimuth   float64 `json:"azimuth"`
+	Elevation float64 `json:"elevation"`
+}
+
+// SpatialSmoothingOptions average the covariance over overlapping
+// subarrays of SubarraySize elements before the subspace search, so that
+// coherent sources can be resolved; 0 disables smoothing. ForwardBackward
+// averages the reversed subarrays as well.
+type SpatialSmoothingOptions struct {
+	SubarraySize    int  `json:"subarray_size" mapstructure:"subarray_size" binding:"min=0"`
+	ForwardBackward bool `json:"forward_backward" mapstructure:"forward_backward"`
+}
+
+const (
+	WidebandIncoherent = "incoherent"
+	WidebandCSSM       = "cssm"
+)
+
+// WidebandOptions split the snapshots into Subbands frequency bins with an
+// FFT and estimate from the covariance of each bin, for signals whose
+// bandwidth is a noticeable fraction of the carrier. The incoherent method,
+// the default, combines the MUSIC spectra of the bins; CSSM focuses the bin
+// covariances onto the carrier with the coherent signal subspace method and
+// runs the narrowband estimator on their sum. SampleRate and
+// CenterFrequency, in Hz, place the bins; USRP captures default them to the
+// receiver's settings and recordings to those they were made with.
+type WidebandOptions struct {
+	Method          string  `json:"method,omitempty"`
+	Subbands        int     `json:"subbands" binding:"min=0"`
+	SampleRate      float64 `json:"sample_rate,omitempty" binding:"min=0"`
+	CenterFrequency float64 `json:"center_frequency,omitempty" binding:"min=0"`
+}
+
+const (
+	DOASourceSynthetic = "synthetic"
+	DOASourceUSRP      = "usrp"
+	DOASourceRecording = "recording"
+)
+
+// Captured reports whether the snapshots come from the air, live or
+// recorded, rather than being synthesized from the parameters.
+func (p *DOAParams) Captured() bool {
+	return p.Source == DOASourceUSRP || p.Source == DOASourceRecording
 }
 
 type BeamformingResult struct {
@@ -70,6 +303,54 @@ type BeamformingResult struct {
 	SLL               float64     `json:"side_lobe_level"`
 	Iterations        int         `json:"iterations"`
 	Converged         bool        `json:"converged"`
+
+	Objective          BeamformingObjective `json:"objective"`
+	TransmitPower      float64              `json:"transmit_power"`
+	TotalPower         float64              `json:"total_power"`
+	SpectralEfficiency float64              `json:"spectral_efficiency"`
+	EnergyEfficiency   float64              `json:"energy_efficiency"`
+
+	// Eigenvalues of the measured covariance in descending order and, when
+	// more than one beam was requested, the weights of each; Weights is the
+	// first. Only set in eigen mode; in wmmse mode Beams holds the unit
+	// weights of each user.
+	Eigenvalues []float64     `json:"eigenvalues,omitempty"`
+	Beams       [][][]float64 `json:"beams,omitempty"`
+
+	// Users reports each user of a wmmse run, SumRate their weighted sum
+	// rate in bit/s/Hz and RateHistory the weighted sum rate after each
+	// iteration. SpectralEfficiency is the unweighted sum.
+	Users       []BeamformingUserResult `json:"users,omitempty"`
+	SumRate     float64                 `json:"sum_rate,omitempty"`
+	RateHistory []float64               `json:"rate_history,omitempty"`
+
+	// NullDepths is the response toward each interference angle relative
+	// to the target direction, in dB.
+	NullDepths []float64 `json:"null_depths,omitempty"`
+
+	// OFDM reports the weights and rates of each subcarrier of an ofdm
+	// run; Weights is then the best single beam for the whole band and
+	// SpectralEfficiency the mean over the subcarriers.
+	OFDM *OFDMBeamformingResult `json:"ofdm,omitempty"`
+
+	// Robust reports the SINR guarantee of a robust run; SpectralEfficiency
+	// is then the rate of the worst-case channel.
+	Robust *RobustBeamformingResult `json:"robust,omitempty"`
+
+	// CachedFrom is the experiment whose result was returned from the
+	// result cache instead of running again.
+	CachedFrom string `json:"cached_from,omitempty"`
+}
+
+// BeamformingUserResult is the beamformer of one user: its weights scaled
+// to the Power in watts it transmits with, the SINR in dB they reach and
+// the rate in bit/s/Hz.
+type BeamformingUserResult struct {
+	Angle   float64     `json:"angle"`
+	Weights [][]float64 `json:"weights"`
+	Power   float64     `json:"power"`
+	SINR    float64     `json:"sinr"`
+	Rate    float64     `json:"rate"`
 }
 
 type DOAResult struct {
@@ -77,4 +358,138 @@ type DOAResult struct {
 	Spectrum        []float64 `json:"spectrum"`
 	TrueAngles      []float64 `json:"true_angles,omitempty"`
 	RMSE            float64   `json:"rmse,omitempty"`
+	// CRB is the Cramér–Rao bound on the standard deviation of each of
+	// EstimatedAngles in radians, at the noise and source powers read from
+	// the covariance, for RMSE to be judged against. It is left out for 2D
+	// and wideband estimates and for sources below the noise floor.
+	CRB []float64 `json:"crb,omitempty"`
+	// EstimatedElevations, Directions pairing them with EstimatedAngles, and
+	// Spectrum2D, indexed [elevation][azimuth], are set by 2D scans.
+	EstimatedElevations []float64      `json:"estimated_elevations,omitempty"`
+	Directions          []DOADirection `json:"directions,omitempty"`
+	Spectrum2D          [][]float64    `json:"spectrum_2d,omitempty"`
+	// Subbands is how many FFT bins a wideband estimate combined, after
+	// leaving out those holding noise only.
+	Subbands int `json:"subbands,omitempty"`
+	// DetectedSources is the number of sources found by SourceDetection.
+	DetectedSources *int             `json:"detected_sources,omitempty"`
+	ADC             *ADCStats        `json:"adc,omitempty"`
+	Snapshots       *SnapshotArchive `json:"snapshots,omitempty"`
+	CachedFrom      string           `json:"cached_from,omitempty"`
+}
+
+// SnapshotArchive points at the stored antenna-by-snapshot matrix of a DOA
+// run. Truncated is set when the size cap dropped trailing snapshots.
+type SnapshotArchive struct {
+	ArtifactID int64  `json:"artifact_id"`
+	Format     string `json:"format"`
+	Antennas   int    `json:"antennas"`
+	Snapshots  int    `json:"snapshots"`
+	Captured   int    `json:"captured"`
+	Truncated  bool   `json:"truncated,omitempty"`
+}
+
+// SnapshotWindow is a range of stored snapshots, split into real and
+// imaginary parts with one row per antenna.
+type SnapshotWindow struct {
+	ExperimentID string      `json:"experiment_id"`
+	Antennas     int         `json:"antennas"`
+	Snapshots    int         `json:"snapshots"`
+	Start        int         `json:"start"`
+	Count        int         `json:"count"`
+	Real         [][]float64 `json:"real"`
+	Imag         [][]float64 `json:"imag"`
+}
+
+// OnlineDOARequest starts continuous DOA on the receiver stream. The
+// covariance is tracked with ForgettingFactor and re-estimated every
+// Interval seconds.
+type OnlineDOARequest struct {
+	Params           DOAParams `json:"params" binding:"required"`
+	ForgettingFactor float64   `json:"forgetting_factor" binding:"omitempty,gt=0,lt=1"`
+	BlockSize        int       `json:"block_size" binding:"omitempty,min=1"`
+	Interval         float64   `json:"interval" binding:"omitempty,min=0.001"`
+}
+
+type OnlineDOAStatus struct {
+	Running       bool             `json:"running"`
+	Request       OnlineDOARequest `json:"request"`
+	StartedAt     time.Time        `json:"started_at"`
+	Snapshots     uint64           `json:"snapshots"`
+	Estimates     uint64           `json:"estimates"`
+	DroppedBlocks uint64           `json:"dropped_blocks"`
+	Result        *DOAResult       `json:"result,omitempty"`
+	UpdatedAt     time.Time        `json:"updated_at,omitempty"`
+	Error         string           `json:"error,omitempty"`
+}
+
+// ExperimentQuery filters and orders the experiment result list. StartTime
+// and EndTime bound created_at; results sort by created_at, newest first,
+// unless Sort and Order say otherwise.
+type ExperimentQuery struct {
+	AlgorithmType AlgorithmType     `form:"algorithm_type"`
+	Status        *ExperimentStatus `form:"status" binding:"omitempty,min=0,max=3"`
+	StartTime     time.Time         `form:"start_time" time_format:"2006-01-02T15:04:05"`
+	EndTime       time.Time         `form:"end_time" time_format:"2006-01-02T15:04:05"`
+	Sort          string            `form:"sort" binding:"omitempty,oneof=created_at completed_at status algorithm_type experiment_id"`
+	Order         string            `form:"order" binding:"omitempty,oneof=asc desc"`
+	Page          int               `form:"page" binding:"min=0"`
+	PageSize      int               `form:"page_size" binding:"min=0,max=100"`
+}
+
+func (q *ExperimentQuery) Validate() error {
+	if !q.StartTime.IsZero() && !q.EndTime.IsZero() && q.EndTime.Before(q.StartTime) {
+		return NewValidationError("end_time must not be before start_time")
+	}
+	return nil
+}
+
+// OrderBy is the ORDER BY clause for the query, with id breaking ties so
+// that pages do not overlap.
+func (q *ExperimentQuery) OrderBy() string {
+	column := q.Sort
+	if column == "" {
+		column = "created_at"
+	}
+	direction := "DESC"
+	if q.Order == "asc" {
+		direction = "ASC"
+	}
+	return column + " " + direction + ", id " + direction
+}
+
+type SnapshotQuery struct {
+	Format string `form:"format"`
+	Start  int    `form:"start" binding:"min=0"`
+	Count  int    `form:"count" binding:"min=0"`
+}
+
+type ExportFormat string
+
+const (
+	ExportFormatJSON ExportFormat = "json"
+	ExportFormatCSV  ExportFormat = "csv"
+	// HTML and PDF export a readable report rather than the raw record.
+	ExportFormatHTML ExportFormat = "html"
+	ExportFormatPDF  ExportFormat = "pdf"
+)
+
+type ExportResult struct {
+	ExperimentID string       `json:"experiment_id"`
+	ArtifactID   int64        `json:"artifact_id"`
+	Format       ExportFormat `json:"format"`
+	Key          string       `json:"key"`
+	Size         int64        `json:"size"`
+	Checksum     string       `json:"checksum"`
+	URL          string       `json:"url"`
+	ExpiresAt    time.Time    `json:"expires_at"`
+}
+
+// ResultCacheStats describes the algorithm result cache; TTL is in seconds.
+type ResultCacheStats struct {
+	Enabled bool    `json:"enabled"`
+	Entries int     `json:"entries"`
+	TTL     float64 `json:"ttl"`
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
 }
@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+// FirmwarePackage is an uploaded firmware image. ID is derived from the
+// image's SHA-256 checksum, so uploading the same image twice returns the
+// same package.
+type FirmwarePackage struct {
+	ID         string    `json:"id"`
+	Version    string    `json:"version"`
+	Size       int       `json:"size"`
+	Checksum   string    `json:"checksum"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+type FirmwareUpdateStatus string
+
+const (
+	FirmwareUpdateScheduled FirmwareUpdateStatus = "scheduled"
+	FirmwareUpdateApplying  FirmwareUpdateStatus = "applying"
+	FirmwareUpdateVerifying FirmwareUpdateStatus = "verifying"
+	FirmwareUpdateSucceeded FirmwareUpdateStatus = "succeeded"
+	FirmwareUpdateFailed    FirmwareUpdateStatus = "failed"
+	FirmwareUpdateCancelled FirmwareUpdateStatus = "cancelled"
+)
+
+// Done reports whether the update has finished, successfully or not.
+func (s FirmwareUpdateStatus) Done() bool {
+	return s == FirmwareUpdateSucceeded || s == FirmwareUpdateFailed || s == FirmwareUpdateCancelled
+}
+
+// FirmwareUpdateRequest schedules a package onto an IRS panel, the default
+// panel when IRSID is empty. The update starts at ScheduledAt, or at once
+// when it is unset.
+type FirmwareUpdateRequest struct {
+	PackageID   string     `json:"package_id" binding:"required"`
+	IRSID       string     `json:"irs_id"`
+	ScheduledAt *time.Time `json:"scheduled_at"`
+}
+
+func (r *FirmwareUpdateRequest) Validate() error {
+	if r.ScheduledAt != nil && r.ScheduledAt.Before(time.Now().Add(-time.Minute)) {
+		return NewValidationError("scheduled_at is in the past")
+	}
+	return nil
+}
+
+// FirmwareUpdate is one scheduled update of a panel. PreviousVersion is the
+// firmware the panel reported before the update was applied.
+type FirmwareUpdate struct {
+	ID              int64                `json:"id"`
+	PackageID       string               `json:"package_id"`
+	Version         string               `json:"version"`
+	IRSID           string               `json:"irs_id"`
+	Status          FirmwareUpdateStatus `json:"status"`
+	PreviousVersion string               `json:"previous_version,omitempty"`
+	ScheduledAt     time.Time            `json:"scheduled_at"`
+	StartedAt       *time.Time           `json:"started_at,omitempty"`
+	FinishedAt      *time.Time           `json:"finished_at,omitempty"`
+	Error           string               `json:"error,omitempty"`
+}
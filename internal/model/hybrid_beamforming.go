@@ -0,0 +1,229 @@
+package model
+
+import (
+	"fmt"
+	"math"
+)
+
+// Defaults and bounds of a hybrid beamforming run. The transmit SNR is in
+// dB.
+const (
+	DefaultHybridAntennas        = 64
+	MaxHybridAntennas            = 256
+	DefaultHybridReceiveAntennas = 4
+	MaxHybridReceiveAntennas     = 64
+	DefaultHybridRFChains        = 4
+	MaxHybridRFChains            = 64
+	DefaultHybridStreams         = 2
+	DefaultHybridPaths           = 8
+	MaxHybridPaths               = 64
+	DefaultHybridTransmitSNR     = 0.0
+	MaxHybridPhaseBits           = 8
+)
+
+// Methods of a hybrid beamforming run. Both approximate the fully digital
+// precoder; omp picks the analog beams from a dictionary one RF chain at a
+// time, alternating refines the beams omp picked by alternating between
+// the analog phases and the digital precoder.
+const (
+	HybridMethodOMP         = "omp"
+	HybridMethodAlternating = "alternating"
+)
+
+// Dictionaries the omp method picks analog beams from: the array responses
+// toward the departure angles of the channel's paths, or an oversampled DFT
+// grid for when the paths are not known.
+const (
+	HybridDictionaryPaths = "paths"
+	HybridDictionaryDFT   = "dft"
+)
+
+// HybridBeamformingParams describe a narrowband MIMO link from a BS with
+// Antennas antennas, driven by RFChains RF chains through phase shifters,
+// to a receiver with ReceiveAntennas antennas, carrying Streams streams.
+// Both arrays are half-wavelength ULAs. The channel is the sum of Paths,
+// or of NumPaths paths with random angles and complex Gaussian gains drawn
+// from Seed when Paths is omitted. TransmitSNR is the transmit power over
+// the receiver noise in dB. PhaseBits quantizes the analog phases as
+// phase shifters with that resolution would; 0 keeps them continuous.
+type HybridBeamformingParams struct {
+	Antennas        int          `json:"antennas,omitempty"`
+	ReceiveAntennas int          `json:"receive_antennas,omitempty"`
+	RFChains        int          `json:"rf_chains,omitempty"`
+	Streams         int          `json:"streams,omitempty"`
+	Paths           []HybridPath `json:"paths,omitempty"`
+	NumPaths        int          `json:"num_paths,omitempty"`
+	Seed            *int64       `json:"seed,omitempty"`
+	TransmitSNR     *float64     `json:"transmit_snr,omitempty"`
+	PhaseBits       int          `json:"phase_bits,omitempty"`
+	Method          string       `json:"method,omitempty"`
+	Dictionary      string       `json:"dictionary,omitempty"`
+}
+
+// HybridPath leaves the BS DepartureAngle radians from broadside and
+// arrives at the receiver ArrivalAngle radians from broadside, PathLoss dB
+// weaker than a unit path and with Phase radians of phase shift.
+type HybridPath struct {
+	DepartureAngle float64 `json:"departure_angle"`
+	ArrivalAngle   float64 `json:"arrival_angle"`
+	PathLoss       float64 `json:"path_loss,omitempty"`
+	Phase          float64 `json:"phase,omitempty"`
+}
+
+func (p *HybridBeamformingParams) Validate() error {
+	return hybridBeamformingRules.Validate(p)
+}
+
+// Violations returns every rule of a hybrid beamforming request the
+// parameters break.
+func (p *HybridBeamformingParams) Violations() Diagnostics {
+	return hybridBeamformingRules.Check(p)
+}
+
+var hybridBeamformingRules = join(
+	Rules[HybridBeamformingParams]{
+		between("antennas", 0, MaxHybridAntennas, "", func(p *HybridBeamformingParams) int { return p.Antennas }),
+		between("receive_antennas", 0, MaxHybridReceiveAntennas, "", func(p *HybridBeamformingParams) int { return p.ReceiveAntennas }),
+		between("rf_chains", 0, MaxHybridRFChains, "", func(p *HybridBeamformingParams) int { return p.RFChains }),
+		notNegative("streams", func(p *HybridBeamformingParams) int { return p.Streams }),
+		between("num_paths", 0, MaxHybridPaths, "", func(p *HybridBeamformingParams) int { return p.NumPaths }),
+		validated("paths", (*HybridBeamformingParams).validatePaths),
+		between("phase_bits", 0, MaxHybridPhaseBits, "", func(p *HybridBeamformingParams) int { return p.PhaseBits }),
+		oneOf("method", func(p *HybridBeamformingParams) string { return p.Method }, HybridMethodOMP, HybridMethodAlternating),
+		oneOf("dictionary", func(p *HybridBeamformingParams) string { return p.Dictionary }, HybridDictionaryPaths, HybridDictionaryDFT),
+		// a chain drives every antenna, and a stream needs a chain at each end
+		Rule[HybridBeamformingParams]{Field: "rf_chains", Check: func(p *HybridBeamformingParams) error {
+			if d := p.WithDefaults(); d.RFChains > d.Antennas {
+				return violated("%d RF chains exceed the %d antennas they drive", d.RFChains, d.Antennas)
+			}
+			return nil
+		}},
+		Rule[HybridBeamformingParams]{Field: "streams", Check: func(p *HybridBeamformingParams) error {
+			d := p.WithDefaults()
+			if d.Streams > d.RFChains {
+				return violated("%d streams need at least as many RF chains, there are %d", d.Streams, d.RFChains)
+			}
+			if d.Streams > d.ReceiveAntennas {
+				return violated("%d streams need at least as many receive antennas, there are %d", d.Streams, d.ReceiveAntennas)
+			}
+			return nil
+		}},
+	},
+	when(func(p *HybridBeamformingParams) bool { return p.TransmitSNR != nil },
+		between("transmit_snr", -maxJointLevel, maxJointLevel, " dB", func(p *HybridBeamformingParams) float64 { return *p.TransmitSNR }),
+	),
+)
+
+func (p *HybridBeamformingParams) validatePaths() error {
+	if len(p.Paths) > MaxHybridPaths {
+		return violated("at most %d paths", MaxHybridPaths)
+	}
+	for i, path := range p.Paths {
+		if !(math.Abs(path.DepartureAngle) <= math.Pi) {
+			return &ValidationError{Field: fmt.Sprintf("paths[%d].departure_angle", i), Message: "must be within [-π, π]; angles are in radians"}
+		}
+		if !(math.Abs(path.ArrivalAngle) <= math.Pi) {
+			return &ValidationError{Field: fmt.Sprintf("paths[%d].arrival_angle", i), Message: "must be within [-π, π]; angles are in radians"}
+		}
+		if !(path.PathLoss >= 0 && path.PathLoss <= maxJointLevel) {
+			return &ValidationError{Field: fmt.Sprintf("paths[%d].path_loss", i), Message: fmt.Sprintf("must be between 0 and %v dB", maxJointLevel)}
+		}
+		if math.IsNaN(path.Phase) || math.IsInf(path.Phase, 0) {
+			return &ValidationError{Field: fmt.Sprintf("paths[%d].phase", i), Message: "must be finite"}
+		}
+	}
+	return nil
+}
+
+// WithDefaults fills in the array sizes, RF chains, streams, path count,
+// transmit SNR, method and dictionary a request leaves out.
+func (p HybridBeamformingParams) WithDefaults() *HybridBeamformingParams {
+	if p.Antennas == 0 {
+		p.Antennas = DefaultHybridAntennas
+	}
+	if p.ReceiveAntennas == 0 {
+		p.ReceiveAntennas = DefaultHybridReceiveAntennas
+	}
+	if p.RFChains == 0 {
+		p.RFChains = DefaultHybridRFChains
+	}
+	if p.Streams == 0 {
+		p.Streams = min(DefaultHybridStreams, p.RFChains, p.ReceiveAntennas)
+	}
+	if p.NumPaths == 0 {
+		p.NumPaths = DefaultHybridPaths
+	}
+	if len(p.Paths) > 0 {
+		p.NumPaths = len(p.Paths)
+	}
+	if p.TransmitSNR == nil {
+		snr := DefaultHybridTransmitSNR
+		p.TransmitSNR = &snr
+	}
+	if p.Method == "" {
+		p.Method = HybridMethodOMP
+	}
+	if p.Dictionary == "" {
+		p.Dictionary = HybridDictionaryPaths
+	}
+	return &p
+}
+
+// HybridBeamformingResult is the result of a hybrid_beamforming experiment.
+// The precoder is F = F_RF·F_BB: AnalogPhases holds the phases in [0, 2π)
+// of each RF chain's phase shifters, which make F_RF = e^{jθ}/√N for N
+// antennas, and DigitalPrecoder the F_BB entries of each RF chain and
+// stream as [real, imag] pairs, scaled so that ‖F‖² equals the stream
+// count. An RF chain is left out when the dictionary has no beam left for
+// it. BeamAngles are the departure angles of the dictionary beams omp
+// picked for the chains. SpectralEfficiency is log2 det(I + ρ/Nₛ·HFFᴴHᴴ) in
+// bit/s/Hz with the hybrid precoder and DigitalSpectralEfficiency with the
+// fully digital one it approximates. ApproximationError is the relative
+// distance ‖F_opt − F_RF·F_BB‖/‖F_opt‖ to the digital precoder, and
+// ErrorHistory that distance after each RF chain omp adds and then after
+// each alternation. QuantizationLoss is the spectral efficiency PhaseBits
+// costs.
+type HybridBeamformingResult struct {
+	AnalogPhases              [][]float64   `json:"analog_phases"`
+	DigitalPrecoder           [][][]float64 `json:"digital_precoder"`
+	BeamAngles                []float64     `json:"beam_angles"`
+	Streams                   int           `json:"streams"`
+	SpectralEfficiency        float64       `json:"spectral_efficiency"`
+	DigitalSpectralEfficiency float64       `json:"digital_spectral_efficiency"`
+	ApproximationError        float64       `json:"approximation_error"`
+	QuantizationLoss          float64       `json:"quantization_loss,omitempty"`
+	ErrorHistory              []float64     `json:"error_history"`
+	Iterations                int           `json:"iterations"`
+	Converged                 bool          `json:"converged"`
+	Method                    string        `json:"method"`
+}
+
+func (r *HybridBeamformingResult) Validate() error {
+	if len(r.AnalogPhases) == 0 {
+		return NewValidationError("analog_phases are empty")
+	}
+	if len(r.DigitalPrecoder) != len(r.AnalogPhases) || len(r.BeamAngles) != len(r.AnalogPhases) {
+		return NewValidationErrorf("%d digital precoder rows and %d beam angles for %d RF chains", len(r.DigitalPrecoder), len(r.BeamAngles), len(r.AnalogPhases))
+	}
+	for i, phases := range r.AnalogPhases {
+		if len(phases) != len(r.AnalogPhases[0]) {
+			return NewValidationErrorf("analog_phases[%d] has %d phases, want %d", i, len(phases), len(r.AnalogPhases[0]))
+		}
+		for _, phase := range phases {
+			if !(phase >= 0 && phase < 2*math.Pi) {
+				return NewValidationError("analog_phases must be within [0, 2π)")
+			}
+		}
+	}
+	for i, row := range r.DigitalPrecoder {
+		if r.Streams < 1 || len(row) != r.Streams {
+			return NewValidationErrorf("digital_precoder[%d] has %d entries for %d streams", i, len(row), r.Streams)
+		}
+		if err := validateWeights(fmt.Sprintf("digital_precoder[%d]", i), row); err != nil {
+			return err
+		}
+	}
+	values := []float64{r.SpectralEfficiency, r.DigitalSpectralEfficiency, r.ApproximationError, r.QuantizationLoss}
+	values = append(values, r.BeamAngles...)
+	return finite("hybrid beamforming result", append(values, r.ErrorHistory...)...)
+}
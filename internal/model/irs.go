@@ -1,18 +1,31 @@
 package model
 
 import (
+	"fmt"
 	"time"
 )
 
+// IRSConfig is changed compare-and-swap on Version, both when stored and
+// when applied to a panel.
 type IRSConfig struct {
-	ID            int64        `json:"id" gorm:"primaryKey;autoIncrement"`
-	Name          string       `json:"name" gorm:"type:varchar(100);not null"`
-	ElementCount  int          `json:"element_count" gorm:"not null"`
-	PhaseShifts   []float64    `json:"phase_shifts" gorm:"type:json"`
-	FrequencyBand string       `json:"frequency_band" gorm:"type:varchar(50)"`
-	Status        ConfigStatus `json:"status" gorm:"type:tinyint;default:1"`
-	CreatedAt     time.Time    `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt     time.Time    `json:"updated_at" gorm:"autoUpdateTime"`
+	ID            int64             `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name          string            `json:"name" gorm:"type:varchar(100);not null"`
+	ElementCount  int               `json:"element_count" gorm:"not null"`
+	PhaseShifts   []float64         `json:"phase_shifts" gorm:"type:json;serializer:json"`
+	Groups        []IRSElementGroup `json:"groups,omitempty" gorm:"type:json;serializer:json"`
+	FrequencyBand string            `json:"frequency_band" gorm:"type:varchar(50)"`
+	Status        ConfigStatus      `json:"status" gorm:"type:tinyint;default:1"`
+	Version       int64             `json:"version" gorm:"not null;default:1"`
+	IRSID         string            `json:"irs_id,omitempty" gorm:"type:varchar(64);index"`
+	ExperimentID  string            `json:"experiment_id,omitempty" gorm:"type:varchar(50);index"`
+	RollbackOf    *int64            `json:"rollback_of,omitempty"`
+	// QuantizationBits is the phase resolution of the panel the phases were
+	// rounded to, and QuantizationError the RMS difference in radians
+	// between the requested and the applied phases.
+	QuantizationBits  int       `json:"quantization_bits,omitempty"`
+	QuantizationError float64   `json:"quantization_error,omitempty"`
+	CreatedAt         time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt         time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
 type ConfigStatus int
@@ -27,6 +40,15 @@ func (IRSConfig) TableName() string {
 	return "irs_config"
 }
 
+// IRSPanel describes one of the IRS panels under management.
+type IRSPanel struct {
+	ID        string     `json:"id"`
+	Default   bool       `json:"default"`
+	Connected bool       `json:"connected"`
+	Healthy   bool       `json:"healthy"`
+	Config    *IRSConfig `json:"config,omitempty"`
+}
+
 type IRSStatus struct {
 	ElementCount  int       `json:"element_count"`
 	PhaseShifts   []float64 `json:"phase_shifts"`
@@ -34,23 +56,360 @@ type IRSStatus struct {
 	Temperature   float64   `json:"temperature"`
 	PowerStatus   bool      `json:"power_status"`
 	LastUpdate    time.Time `json:"last_update"`
+	// FailedElements are stuck at their last phase; only the simulator
+	// reports them.
+	FailedElements []int `json:"failed_elements,omitempty"`
+	// Absorbing is set while the elements are switched to matched loads.
+	Absorbing bool `json:"absorbing,omitempty"`
+	// Healthy is false while the panel's watchdog has an active alert.
+	Healthy bool       `json:"healthy"`
+	Alerts  []IRSAlert `json:"alerts,omitempty"`
+}
+
+// IRSImpairments makes the simulated panel imperfect. PhaseNoise is the
+// standard deviation in radians of the error on every applied phase. On each
+// write every working element fails with ElementFailureRate probability and
+// stays stuck at its phase until faults are cleared. MutualCoupling is the
+// share of each neighbour's field an element reradiates, and SettlingDelay
+// the seconds a write takes to settle.
+type IRSImpairments struct {
+	PhaseNoise         float64 `json:"phase_noise" mapstructure:"phase_noise" binding:"min=0"`
+	ElementFailureRate float64 `json:"element_failure_rate" mapstructure:"element_failure_rate" binding:"min=0,max=1"`
+	MutualCoupling     float64 `json:"mutual_coupling" mapstructure:"mutual_coupling" binding:"min=0,max=1"`
+	SettlingDelay      float64 `json:"settling_delay" mapstructure:"settling_delay" binding:"min=0,max=10"`
+}
+
+// IRSConstraints are what a panel's hardware can realize. PhaseBits is the
+// phase resolution, 0 for continuous phases. SwitchingTime is the seconds
+// the elements take to settle after a write and MaxReconfigRate the most
+// writes per second the controller accepts; 0 leaves either unlimited.
+type IRSConstraints struct {
+	PhaseBits       int     `json:"phase_bits"`
+	SwitchingTime   float64 `json:"switching_time"`
+	MaxReconfigRate float64 `json:"max_reconfig_rate"`
+}
+
+// MinInterval is the shortest time between two writes the panel follows.
+func (c IRSConstraints) MinInterval() time.Duration {
+	interval := c.SwitchingTime
+	if c.MaxReconfigRate > 0 && 1/c.MaxReconfigRate > interval {
+		interval = 1 / c.MaxReconfigRate
+	}
+	return time.Duration(interval * float64(time.Second))
+}
+
+// CheckSequence rejects a sequence whose steps change faster than the panel
+// can be reconfigured.
+func (c IRSConstraints) CheckSequence(r *IRSSequenceRequest) error {
+	interval := c.MinInterval().Seconds()
+	if interval == 0 {
+		return nil
+	}
+	for i, step := range r.Steps {
+		dwell := step.Dwell
+		if dwell == 0 {
+			dwell = r.Dwell
+		}
+		if dwell < interval {
+			return &ValidationError{
+				Field:   fmt.Sprintf("steps[%d].dwell", i),
+				Message: fmt.Sprintf("dwell of %g s is shorter than the %g s the panel needs between writes", dwell, interval),
+			}
+		}
+	}
+	return nil
+}
+
+type IRSViolationKind string
+
+const (
+	// IRSViolationPhaseResolution: phases between the levels the panel can
+	// set, which were rounded.
+	IRSViolationPhaseResolution IRSViolationKind = "phase_resolution"
+	// IRSViolationReconfigRate: a write before the panel was ready for it,
+	// which was held back.
+	IRSViolationReconfigRate IRSViolationKind = "reconfig_rate"
+	// IRSViolationSequenceRate: a sequence stepping faster than the panel
+	// can follow, which was rejected.
+	IRSViolationSequenceRate IRSViolationKind = "sequence_rate"
+)
+
+// IRSConstraintViolation is a request the panel could not realize as given.
+type IRSConstraintViolation struct {
+	Kind    IRSViolationKind `json:"kind"`
+	Message string           `json:"message"`
+	Time    time.Time        `json:"time"`
 }
 
+// IRSConstraintReport gives a panel's constraints and how often requests
+// broke them since start, with the most recent violations first.
+type IRSConstraintReport struct {
+	IRSID       string                   `json:"irs_id"`
+	Constraints IRSConstraints           `json:"constraints"`
+	Quantized   uint64                   `json:"quantized"`
+	Throttled   uint64                   `json:"throttled"`
+	Rejected    uint64                   `json:"rejected"`
+	Recent      []IRSConstraintViolation `json:"recent"`
+}
+
+// IRSFaults are faults injected into a simulated panel for testing.
+// Temperature overrides the reported temperature; WriteFailure fails phase
+// writes and StatusFailure status reads.
+type IRSFaults struct {
+	FailedElements []int    `json:"failed_elements"`
+	PowerLoss      bool     `json:"power_loss"`
+	Temperature    *float64 `json:"temperature,omitempty"`
+	WriteFailure   bool     `json:"write_failure"`
+	StatusFailure  bool     `json:"status_failure"`
+}
+
+// IRSFaultRequest replaces the injected faults of a simulated panel;
+// elements failed earlier stay failed. Impairments, when set, replace the
+// panel's impairments as well.
+type IRSFaultRequest struct {
+	IRSFaults
+	Impairments *IRSImpairments `json:"impairments,omitempty"`
+}
+
+// IRSSimulatorState is what a simulated panel is currently set to get wrong.
+type IRSSimulatorState struct {
+	Impairments IRSImpairments `json:"impairments"`
+	Faults      IRSFaults      `json:"faults"`
+}
+
+type IRSAlertKind string
+
+const (
+	IRSAlertOverTemperature IRSAlertKind = "over_temperature"
+	IRSAlertPowerLoss       IRSAlertKind = "power_loss"
+	IRSAlertStaleStatus     IRSAlertKind = "stale_status"
+)
+
+// IRSAlert is raised by the IRS watchdog when a fault starts and sent again
+// with Active false when it clears.
+type IRSAlert struct {
+	IRSID       string       `json:"irs_id"`
+	Kind        IRSAlertKind `json:"kind"`
+	Code        int          `json:"code"`
+	Message     string       `json:"message"`
+	Active      bool         `json:"active"`
+	Temperature float64      `json:"temperature,omitempty"`
+	Since       time.Time    `json:"since"`
+	Time        time.Time    `json:"time"`
+}
+
+// IRSElementGroup is a named subset of the surface's elements that can be
+// reconfigured without touching the rest, e.g. one half serving a
+// communication user while the other illuminates a sensing target.
+type IRSElementGroup struct {
+	Name        string    `json:"name"`
+	Elements    []int     `json:"elements"`
+	PhaseShifts []float64 `json:"phase_shifts"`
+}
+
+// MaxIRSElements is the largest panel a configuration may address.
+const MaxIRSElements = 256
+
+// IRSConfigRequest sets the whole surface. PhaseShifts gives every element;
+// Groups partition the surface and override PhaseShifts on their elements.
+// Without PhaseShifts, elements outside every group keep their current phase.
 type IRSConfigRequest struct {
-	Name          string    `json:"name" binding:"required"`
-	ElementCount  int       `json:"element_count" binding:"required,min=1,max=256"`
-	PhaseShifts   []float64 `json:"phase_shifts" binding:"required"`
-	FrequencyBand string    `json:"frequency_band" binding:"required"`
+	Name          string            `json:"name" binding:"required"`
+	ElementCount  int               `json:"element_count" binding:"required,min=1,max=256"`
+	PhaseShifts   []float64         `json:"phase_shifts"`
+	Groups        []IRSElementGroup `json:"groups"`
+	FrequencyBand string            `json:"frequency_band" binding:"required"`
+	// Version, when set, must match the active configuration's version or
+	// the request is rejected with a conflict.
+	Version int64 `json:"version"`
+	// ExperimentID records the experiment applying the configuration in the
+	// configuration history.
+	ExperimentID string `json:"experiment_id"`
 }
 
 func (r *IRSConfigRequest) Validate() error {
-	if len(r.PhaseShifts) != r.ElementCount {
+	if len(r.PhaseShifts) == 0 && len(r.Groups) == 0 {
+		return NewValidationError("phase_shifts or groups is required")
+	}
+	if len(r.PhaseShifts) > 0 && len(r.PhaseShifts) != r.ElementCount {
 		return NewValidationError("phase_shifts length must equal element_count")
 	}
-	for i, ps := range r.PhaseShifts {
+	if err := validatePhases("phase_shift", r.PhaseShifts); err != nil {
+		return err
+	}
+
+	names := make(map[string]bool, len(r.Groups))
+	owner := make(map[int]string)
+	for _, g := range r.Groups {
+		if g.Name == "" {
+			return NewValidationError("group name is required")
+		}
+		if names[g.Name] {
+			return NewValidationErrorf("duplicate group %q", g.Name)
+		}
+		names[g.Name] = true
+		if len(g.Elements) == 0 {
+			return NewValidationErrorf("group %q has no elements", g.Name)
+		}
+		if len(g.PhaseShifts) != len(g.Elements) {
+			return NewValidationErrorf("group %q phase_shifts length must equal its element count", g.Name)
+		}
+		for _, e := range g.Elements {
+			if e < 0 || e >= r.ElementCount {
+				return NewValidationErrorf("group %q element %d out of range [0, %d)", g.Name, e, r.ElementCount)
+			}
+			if other, ok := owner[e]; ok {
+				return NewValidationErrorf("element %d is in both group %q and group %q", e, other, g.Name)
+			}
+			owner[e] = g.Name
+		}
+		if err := validatePhases("group "+g.Name+" phase_shift", g.PhaseShifts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IRSGroupRequest reconfigures one element group of the active configuration.
+type IRSGroupRequest struct {
+	PhaseShifts  []float64 `json:"phase_shifts" binding:"required"`
+	Version      int64     `json:"version"`
+	ExperimentID string    `json:"experiment_id"`
+}
+
+// Methods of finding the phases that steer a panel toward a target.
+const (
+	IRSOptimalConjugate = "conjugate"
+	IRSOptimalSDR       = "sdr"
+	IRSOptimalManifold  = "manifold"
+)
+
+// IRSOptimalRequest steers the panel toward TargetAngle, only Group when
+// set. Method conjugate, the default, compensates each element's path
+// delay toward the target. Methods sdr and manifold optimize the whole
+// surface for a cascaded BS–IRS–user channel with the joint beamforming
+// method of the same name: Channel describes the BS and the links as in a joint
+// beamforming run, with the panel's element count and TargetAngle as the
+// direction from the IRS to the user.
+type IRSOptimalRequest struct {
+	TargetAngle float64                 `json:"target_angle" binding:"required"`
+	Group       string                  `json:"group"`
+	Method      string                  `json:"method,omitempty"`
+	Channel     *JointBeamformingParams `json:"channel,omitempty"`
+}
+
+// Violations returns every rule of the request it breaks.
+func (r *IRSOptimalRequest) Violations() Diagnostics {
+	return irsOptimalRules.Check(r)
+}
+
+var irsOptimalRules = join(
+	Rules[IRSOptimalRequest]{
+		angle("target_angle", func(r *IRSOptimalRequest) float64 { return r.TargetAngle }),
+		oneOf("method", func(r *IRSOptimalRequest) string { return r.Method }, IRSOptimalConjugate, IRSOptimalSDR, IRSOptimalManifold),
+	},
+	when(func(r *IRSOptimalRequest) bool { return r.Method == IRSOptimalSDR || r.Method == IRSOptimalManifold },
+		Rule[IRSOptimalRequest]{Field: "group", Check: func(r *IRSOptimalRequest) error {
+			if r.Group != "" {
+				return violated("%s optimizes the whole surface", r.Method)
+			}
+			return nil
+		}},
+	),
+	when(func(r *IRSOptimalRequest) bool { return r.Channel != nil },
+		validated("channel", func(r *IRSOptimalRequest) error {
+			if err := r.Channel.Validate(); err != nil {
+				ve := err.(*ValidationError)
+				return &ValidationError{Field: "channel." + ve.Field, Message: ve.Message}
+			}
+			return nil
+		}),
+	),
+)
+
+// IRSHistoryQuery pages through the configurations applied to a panel,
+// newest first.
+type IRSHistoryQuery struct {
+	IRSID        string `form:"irs_id"`
+	ExperimentID string `form:"experiment_id"`
+	Page         int    `form:"page"`
+	PageSize     int    `form:"page_size"`
+}
+
+// IRSRollbackRequest re-applies a configuration from the history.
+type IRSRollbackRequest struct {
+	Version      int64  `json:"version"`
+	ExperimentID string `json:"experiment_id"`
+}
+
+func (r *IRSGroupRequest) Validate() error {
+	return validatePhases("phase_shift", r.PhaseShifts)
+}
+
+func validatePhases(field string, phases []float64) error {
+	for i, ps := range phases {
 		if ps < 0 || ps > 2*3.14159265359 {
-			return NewValidationErrorf("phase_shift[%d] must be in range [0, 2π]", i)
+			return NewValidationErrorf("%s[%d] must be in range [0, 2π]", field, i)
 		}
 	}
 	return nil
 }
+
+// IRSSequenceRequest steps a panel through phase configurations on a fixed
+// schedule, e.g. a beam sweep. Each step is held for Dwell seconds unless it
+// sets its own. Without Loop the list is played Repeat times (default once).
+type IRSSequenceRequest struct {
+	Steps  []IRSSequenceStep `json:"steps" binding:"required,min=1"`
+	Dwell  float64           `json:"dwell"`
+	Loop   bool              `json:"loop"`
+	Repeat int               `json:"repeat"`
+}
+
+type IRSSequenceStep struct {
+	PhaseShifts []float64 `json:"phase_shifts"`
+	Dwell       float64   `json:"dwell"`
+}
+
+// MinSequenceDwell is the shortest hold time a sequence step may ask for.
+const MinSequenceDwell = 0.001
+
+func (r *IRSSequenceRequest) Validate() error {
+	if len(r.Steps) == 0 {
+		return NewValidationError("sequence needs at least one step")
+	}
+	if r.Repeat < 0 {
+		return NewValidationError("repeat must not be negative")
+	}
+	for i, step := range r.Steps {
+		if len(step.PhaseShifts) != len(r.Steps[0].PhaseShifts) {
+			return NewValidationErrorf("step %d has %d phase shifts, step 0 has %d", i, len(step.PhaseShifts), len(r.Steps[0].PhaseShifts))
+		}
+		dwell := step.Dwell
+		if dwell == 0 {
+			dwell = r.Dwell
+		}
+		if dwell < MinSequenceDwell {
+			return NewValidationErrorf("step %d dwell must be at least %g s", i, MinSequenceDwell)
+		}
+		if err := validatePhases(fmt.Sprintf("steps[%d].phase_shift", i), step.PhaseShifts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IRSSequenceStatus reports the progress of a running or finished sequence.
+// A step is late when the previous one took longer to apply than its dwell;
+// Lateness is the worst delay seen, in seconds.
+type IRSSequenceStatus struct {
+	Running   bool                `json:"running"`
+	Step      int                 `json:"step"`
+	Pass      int                 `json:"pass"`
+	Applied   uint64              `json:"applied"`
+	Late      uint64              `json:"late"`
+	Lateness  float64             `json:"lateness"`
+	Error     string              `json:"error,omitempty"`
+	Request   *IRSSequenceRequest `json:"request,omitempty"`
+	StartedAt time.Time           `json:"started_at"`
+	StoppedAt *time.Time          `json:"stopped_at,omitempty"`
+}
@@ -0,0 +1,138 @@
+package model
+
+// Off states of an IRS impact measurement. Random sets fresh uniformly
+// random phases for every capture, so that the panel scatters without
+// focusing; absorptive switches the elements to matched loads, which only
+// some panels can do.
+const (
+	IRSOffRandom     = "random"
+	IRSOffAbsorptive = "absorptive"
+)
+
+// Defaults and bounds of an IRS impact measurement.
+const (
+	DefaultIRSImpactTrials     = 10
+	MaxIRSImpactTrials         = 1000
+	DefaultIRSImpactConfidence = 0.95
+)
+
+// IRSImpactParams measure what an IRS configuration adds to the received
+// signal. The panel alternates between Config, or the active configuration
+// when Config is nil, and the Off state for Trials pairs of captures of
+// SnapshotLength snapshots each, waiting Settle seconds after every switch.
+// Seed makes the random off phases repeat.
+type IRSImpactParams struct {
+	IRSID          string            `json:"irs_id,omitempty"`
+	Config         *IRSConfigRequest `json:"config,omitempty"`
+	Off            string            `json:"off,omitempty"`
+	Trials         int               `json:"trials,omitempty"`
+	SnapshotLength int               `json:"snapshot_length,omitempty"`
+	Settle         float64           `json:"settle,omitempty"`
+	Confidence     float64           `json:"confidence,omitempty"`
+	Seed           *int64            `json:"seed,omitempty"`
+}
+
+func (p *IRSImpactParams) Validate() error {
+	return irsImpactRules.Validate(p)
+}
+
+// Violations returns every rule of an IRS impact request the parameters
+// break.
+func (p *IRSImpactParams) Violations() Diagnostics {
+	return irsImpactRules.Check(p)
+}
+
+var irsImpactRules = join(
+	Rules[IRSImpactParams]{
+		oneOf("off", func(p *IRSImpactParams) string { return p.Off }, IRSOffRandom, IRSOffAbsorptive),
+		notNegative("snapshot_length", func(p *IRSImpactParams) int { return p.SnapshotLength }),
+		between("settle", 0, 10, " s", func(p *IRSImpactParams) float64 { return p.Settle }),
+	},
+	// a confidence interval needs at least two trials
+	when(func(p *IRSImpactParams) bool { return p.Trials != 0 },
+		between("trials", 2, MaxIRSImpactTrials, "", func(p *IRSImpactParams) int { return p.Trials }),
+	),
+	when(func(p *IRSImpactParams) bool { return p.Confidence != 0 },
+		Rule[IRSImpactParams]{Field: "confidence", Check: func(p *IRSImpactParams) error {
+			if !(p.Confidence > 0.5 && p.Confidence < 1) {
+				return violated("must be between 0.5 and 1")
+			}
+			return nil
+		}},
+	),
+	when(func(p *IRSImpactParams) bool { return p.Config != nil },
+		validated("config", func(p *IRSImpactParams) error { return p.Config.Validate() }),
+	),
+)
+
+// WithDefaults fills in the off state, trial count, snapshot length and
+// confidence level a request leaves out.
+func (p IRSImpactParams) WithDefaults(snapshotLength int) *IRSImpactParams {
+	if p.Off == "" {
+		p.Off = IRSOffRandom
+	}
+	if p.Trials == 0 {
+		p.Trials = DefaultIRSImpactTrials
+	}
+	if p.SnapshotLength == 0 {
+		p.SnapshotLength = snapshotLength
+	}
+	if p.Confidence == 0 {
+		p.Confidence = DefaultIRSImpactConfidence
+	}
+	return &p
+}
+
+// IRSImpactTrial is one pair of captures. SNRs are in dB, estimated from
+// the covariance eigenvalues as the power of the dominant eigenvalue above
+// the noise floor, and rates are the matching Shannon rates in bit/s/Hz.
+type IRSImpactTrial struct {
+	SNROn   float64 `json:"snr_on"`
+	SNROff  float64 `json:"snr_off"`
+	RateOn  float64 `json:"rate_on"`
+	RateOff float64 `json:"rate_off"`
+}
+
+// IRSImpactInterval is the mean of a per-trial difference with its
+// confidence interval from the t distribution.
+type IRSImpactInterval struct {
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"std_dev"`
+	Low    float64 `json:"low"`
+	High   float64 `json:"high"`
+}
+
+// IRSImpactResult is the result of an irs_impact experiment. SNROn and
+// SNROff are the mean SNRs of each state in dB. SNRGain in dB and RateGain
+// in bit/s/Hz are the on minus off differences, paired per trial; an
+// interval that excludes 0 is a significant effect of the panel.
+type IRSImpactResult struct {
+	IRSID      string            `json:"irs_id"`
+	Off        string            `json:"off"`
+	Trials     int               `json:"trials"`
+	Antennas   int               `json:"antennas"`
+	Confidence float64           `json:"confidence"`
+	SNROn      float64           `json:"snr_on"`
+	SNROff     float64           `json:"snr_off"`
+	SNRGain    IRSImpactInterval `json:"snr_gain"`
+	RateGain   IRSImpactInterval `json:"rate_gain"`
+	Samples    []IRSImpactTrial  `json:"samples"`
+}
+
+func (r *IRSImpactResult) Validate() error {
+	if r.Trials < 2 || len(r.Samples) != r.Trials {
+		return NewValidationErrorf("%d samples for %d trials", len(r.Samples), r.Trials)
+	}
+	if r.Confidence <= 0 || r.Confidence >= 1 {
+		return NewValidationError("confidence must be between 0 and 1")
+	}
+	values := []float64{
+		r.SNROn, r.SNROff,
+		r.SNRGain.Mean, r.SNRGain.StdDev, r.SNRGain.Low, r.SNRGain.High,
+		r.RateGain.Mean, r.RateGain.StdDev, r.RateGain.Low, r.RateGain.High,
+	}
+	for _, s := range r.Samples {
+		values = append(values, s.SNROn, s.SNROff, s.RateOn, s.RateOff)
+	}
+	return finite("irs impact result", values...)
+}
@@ -0,0 +1,198 @@
+package model
+
+import "math"
+
+// Defaults and bounds of a joint BS–IRS beamforming run. Path losses and
+// the transmit SNR are in dB.
+const (
+	DefaultJointBSAntennas     = 4
+	MaxJointBSAntennas         = 64
+	DefaultJointIRSElements    = 64
+	DefaultJointDirectLoss     = 100.0
+	DefaultJointBSIRSLoss      = 60.0
+	DefaultJointIRSUserLoss    = 60.0
+	DefaultJointRicianFactor   = 10.0
+	DefaultJointTransmitSNR    = 120.0
+	MaxJointPhaseBits          = 8
+	DefaultJointRandomizations = 100
+	MaxJointRandomizations     = 10000
+)
+
+// Methods of a joint beamforming run. Both match the BS weights to the
+// effective channel; they differ in how the IRS phases are found.
+const (
+	JointMethodAlternating = "alternating"
+	JointMethodSDR         = "sdr"
+	JointMethodManifold    = "manifold"
+)
+
+// Bounds in dB that keep the channel gains finite.
+const (
+	maxJointLevel        = 300.0
+	maxJointRicianFactor = 50.0
+)
+
+// JointBeamformingParams describe a downlink from a BS with BSAntennas
+// antennas to a single-antenna user, over a direct path and a path
+// reflected by an IRS with IRSElements elements. Angles are in radians from
+// broadside: DirectAngle and IRSAngle leave the BS toward the user and the
+// IRS, IRSArrivalAngle arrives at the IRS from the BS and UserAngle leaves
+// the IRS toward the user. Each link is Rician with RicianFactor dB, its
+// line-of-sight component along those angles, scaled by its path loss in
+// dB; Seed makes the scattered components repeat. TransmitSNR is the
+// transmit power over the receiver noise in dB. PhaseBits quantizes the
+// IRS phases as a panel with that resolution would; 0 keeps them
+// continuous. Method alternating, the default, alternates between the
+// weights and the phases; sdr solves the semidefinite relaxation of the
+// phase problem and draws Randomizations Gaussian candidates from it;
+// manifold ascends the SNR of matched weights over the unit-modulus
+// reflection coefficients with Riemannian conjugate gradients.
+type JointBeamformingParams struct {
+	BSAntennas      int      `json:"bs_antennas,omitempty"`
+	IRSElements     int      `json:"irs_elements,omitempty"`
+	DirectAngle     float64  `json:"direct_angle"`
+	IRSAngle        float64  `json:"irs_angle"`
+	IRSArrivalAngle float64  `json:"irs_arrival_angle"`
+	UserAngle       float64  `json:"user_angle"`
+	DirectPathLoss  float64  `json:"direct_path_loss,omitempty"`
+	BSIRSPathLoss   float64  `json:"bs_irs_path_loss,omitempty"`
+	IRSUserPathLoss float64  `json:"irs_user_path_loss,omitempty"`
+	RicianFactor    *float64 `json:"rician_factor,omitempty"`
+	TransmitSNR     *float64 `json:"transmit_snr,omitempty"`
+	PhaseBits       int      `json:"phase_bits,omitempty"`
+	Seed            *int64   `json:"seed,omitempty"`
+	Method          string   `json:"method,omitempty"`
+	Randomizations  int      `json:"randomizations,omitempty"`
+}
+
+func (p *JointBeamformingParams) Validate() error {
+	return jointBeamformingRules.Validate(p)
+}
+
+// Violations returns every rule of a joint beamforming request the
+// parameters break.
+func (p *JointBeamformingParams) Violations() Diagnostics {
+	return jointBeamformingRules.Check(p)
+}
+
+var jointBeamformingRules = join(
+	Rules[JointBeamformingParams]{
+		between("bs_antennas", 0, MaxJointBSAntennas, "", func(p *JointBeamformingParams) int { return p.BSAntennas }),
+		between("irs_elements", 0, MaxIRSElements, "", func(p *JointBeamformingParams) int { return p.IRSElements }),
+		angle("direct_angle", func(p *JointBeamformingParams) float64 { return p.DirectAngle }),
+		angle("irs_angle", func(p *JointBeamformingParams) float64 { return p.IRSAngle }),
+		angle("irs_arrival_angle", func(p *JointBeamformingParams) float64 { return p.IRSArrivalAngle }),
+		angle("user_angle", func(p *JointBeamformingParams) float64 { return p.UserAngle }),
+		between("direct_path_loss", 0, maxJointLevel, " dB", func(p *JointBeamformingParams) float64 { return p.DirectPathLoss }),
+		between("bs_irs_path_loss", 0, maxJointLevel, " dB", func(p *JointBeamformingParams) float64 { return p.BSIRSPathLoss }),
+		between("irs_user_path_loss", 0, maxJointLevel, " dB", func(p *JointBeamformingParams) float64 { return p.IRSUserPathLoss }),
+		between("phase_bits", 0, MaxJointPhaseBits, "", func(p *JointBeamformingParams) int { return p.PhaseBits }),
+		oneOf("method", func(p *JointBeamformingParams) string { return p.Method }, JointMethodAlternating, JointMethodSDR, JointMethodManifold),
+		between("randomizations", 0, MaxJointRandomizations, "", func(p *JointBeamformingParams) int { return p.Randomizations }),
+	},
+	when(func(p *JointBeamformingParams) bool { return p.RicianFactor != nil },
+		between("rician_factor", -maxJointRicianFactor, maxJointRicianFactor, " dB", func(p *JointBeamformingParams) float64 { return *p.RicianFactor }),
+	),
+	when(func(p *JointBeamformingParams) bool { return p.TransmitSNR != nil },
+		between("transmit_snr", -maxJointLevel, maxJointLevel, " dB", func(p *JointBeamformingParams) float64 { return *p.TransmitSNR }),
+	),
+)
+
+// WithDefaults fills in the array sizes, path losses, Rician factor,
+// transmit SNR and method a request leaves out. A path loss of 0 dB is
+// taken as left out.
+func (p JointBeamformingParams) WithDefaults() *JointBeamformingParams {
+	if p.BSAntennas == 0 {
+		p.BSAntennas = DefaultJointBSAntennas
+	}
+	if p.IRSElements == 0 {
+		p.IRSElements = DefaultJointIRSElements
+	}
+	if p.DirectPathLoss == 0 {
+		p.DirectPathLoss = DefaultJointDirectLoss
+	}
+	if p.BSIRSPathLoss == 0 {
+		p.BSIRSPathLoss = DefaultJointBSIRSLoss
+	}
+	if p.IRSUserPathLoss == 0 {
+		p.IRSUserPathLoss = DefaultJointIRSUserLoss
+	}
+	if p.RicianFactor == nil {
+		k := DefaultJointRicianFactor
+		p.RicianFactor = &k
+	}
+	if p.TransmitSNR == nil {
+		snr := DefaultJointTransmitSNR
+		p.TransmitSNR = &snr
+	}
+	if p.Method == "" {
+		p.Method = JointMethodAlternating
+	}
+	if p.Method == JointMethodSDR && p.Randomizations == 0 {
+		p.Randomizations = DefaultJointRandomizations
+	}
+	return &p
+}
+
+// JointBeamformingResult is the result of a joint_beamforming experiment.
+// BSWeights are the unit-norm transmit weights as [real, imag] pairs and
+// IRSPhases the reflection phases in radians within [0, 2π). SNRs are
+// received SNRs in dB: SNR with the optimized weights and phases,
+// SNRHistory after each alternation, SNRDirect over the direct path alone
+// as without the IRS, SNRRandomPhases with random IRS phases and matched
+// BS weights, and SNRBound the bound with every path added in phase.
+// SNRGain is SNR over SNRDirect, what the IRS adds. QuantizationLoss is what PhaseBits costs against continuous phases, in
+// dB. SpectralEfficiency in bit/s/Hz is the Shannon rate at SNR. With
+// method sdr SNRHistory holds the SNR of the relaxation after each sweep of
+// its solver and SDRBound the final one, which no phases exceed once the
+// solver has converged and which is at most SNRBound. With method manifold
+// SNRHistory holds the SNR after each iteration and Manifold how the
+// optimizer converged.
+type JointBeamformingResult struct {
+	BSWeights          [][]float64 `json:"bs_weights"`
+	IRSPhases          []float64   `json:"irs_phases"`
+	SNR                float64     `json:"snr"`
+	SNRDirect          float64     `json:"snr_direct"`
+	SNRRandomPhases    float64     `json:"snr_random_phases"`
+	SNRBound           float64     `json:"snr_bound"`
+	SDRBound           float64     `json:"sdr_bound,omitempty"`
+	SNRGain            float64     `json:"snr_gain"`
+	QuantizationLoss   float64     `json:"quantization_loss,omitempty"`
+	SpectralEfficiency float64     `json:"spectral_efficiency"`
+	SNRHistory         []float64   `json:"snr_history"`
+	Iterations         int         `json:"iterations"`
+	Converged          bool        `json:"converged"`
+	Method             string      `json:"method"`
+
+	Manifold *ManifoldDiagnostics `json:"manifold,omitempty"`
+}
+
+func (r *JointBeamformingResult) Validate() error {
+	if len(r.BSWeights) == 0 {
+		return NewValidationError("bs_weights are empty")
+	}
+	if err := validateWeights("bs_weights", r.BSWeights); err != nil {
+		return err
+	}
+	if len(r.IRSPhases) == 0 {
+		return NewValidationError("irs_phases are empty")
+	}
+	for _, phase := range r.IRSPhases {
+		if !(phase >= 0 && phase < 2*math.Pi) {
+			return NewValidationError("irs_phases must be within [0, 2π)")
+		}
+	}
+	if r.Iterations < 1 || len(r.SNRHistory) != r.Iterations {
+		return NewValidationErrorf("%d SNRs in the history of %d iterations", len(r.SNRHistory), r.Iterations)
+	}
+	if r.Manifold != nil {
+		if err := r.Manifold.Validate(); err != nil {
+			return err
+		}
+	}
+	values := []float64{
+		r.SNR, r.SNRDirect, r.SNRRandomPhases, r.SNRBound, r.SDRBound, r.SNRGain,
+		r.QuantizationLoss, r.SpectralEfficiency,
+	}
+	return finite("joint beamforming result", append(values, r.SNRHistory...)...)
+}
@@ -0,0 +1,80 @@
+package model
+
+import "encoding/json"
+
+// KPINames are the key performance indicators experiments report, in the
+// order they are listed.
+var KPINames = []string{
+	"main_lobe_direction", "main_lobe_width", "side_lobe_level", "converged", "iterations",
+	"spectral_efficiency", "energy_efficiency", "rmse", "estimated_angles",
+	"estimated_energy", "measured_energy", "energy_per_bit", "total_energy",
+}
+
+// KPIs collects the KPIs of the experiment from its result, energy report
+// and power estimate; those it does not report are left out.
+func (result *ExperimentResult) KPIs() (map[string]interface{}, error) {
+	kpis := make(map[string]interface{})
+	if result.ResultData != nil {
+		payload, err := DecodeResult(result.AlgorithmType, *result.ResultData)
+		if err != nil {
+			return nil, err
+		}
+		switch r := payload.(type) {
+		case *BeamformingResult:
+			kpis["main_lobe_direction"] = r.MainLobeDirection
+			kpis["main_lobe_width"] = r.MainLobeWidth
+			kpis["side_lobe_level"] = r.SLL
+			kpis["converged"] = r.Converged
+			kpis["iterations"] = r.Iterations
+			kpis["spectral_efficiency"] = r.SpectralEfficiency
+			kpis["energy_efficiency"] = r.EnergyEfficiency
+			if len(r.Users) > 0 {
+				kpis["sum_rate"] = r.SumRate
+			}
+			if r.Robust != nil {
+				kpis["worst_case_sinr"] = r.Robust.WorstCaseSINR
+			}
+		case *JointBeamformingResult:
+			kpis["converged"] = r.Converged
+			kpis["iterations"] = r.Iterations
+			kpis["spectral_efficiency"] = r.SpectralEfficiency
+		case *HybridBeamformingResult:
+			kpis["converged"] = r.Converged
+			kpis["iterations"] = r.Iterations
+			kpis["spectral_efficiency"] = r.SpectralEfficiency
+			kpis["approximation_error"] = r.ApproximationError
+		case *DOAResult:
+			kpis["estimated_angles"] = r.EstimatedAngles
+			if r.TrueAngles != nil {
+				kpis["rmse"] = r.RMSE
+			}
+		}
+	}
+
+	var report *EnergyReport
+	if err := decodeColumn(result.EnergyReport, &report); err != nil {
+		return nil, err
+	}
+	if report != nil {
+		kpis["estimated_energy"] = report.EstimatedEnergy
+		kpis["measured_energy"] = report.MeasuredEnergy
+		if report.EnergyPerBit > 0 {
+			kpis["energy_per_bit"] = report.EnergyPerBit
+		}
+	}
+	var estimate *PowerEstimate
+	if err := decodeColumn(result.PowerEstimate, &estimate); err != nil {
+		return nil, err
+	}
+	if estimate != nil {
+		kpis["total_energy"] = estimate.TotalEnergy
+	}
+	return kpis, nil
+}
+
+func decodeColumn(data *string, v interface{}) error {
+	if data == nil || *data == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(*data), v)
+}
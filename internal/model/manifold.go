@@ -0,0 +1,42 @@
+package model
+
+// Why a manifold optimization stopped.
+const (
+	// the Riemannian gradient vanished: a critical point
+	ManifoldStopGradient = "gradient"
+	// the value improved by less than the convergence threshold
+	ManifoldStopImprovement = "improvement"
+	// no step along the search direction ascended
+	ManifoldStopLineSearch    = "line_search"
+	ManifoldStopMaxIterations = "max_iterations"
+)
+
+// ManifoldDiagnostics describe a run of the Riemannian optimizer over
+// unit-modulus vectors. GradientNorms holds the norm of the Riemannian
+// gradient relative to the Euclidean one at the start of each iteration,
+// which falls to 0 at a critical point, and GradientNorm the last of them;
+// StepSizes are the accepted step lengths. Restarts counts the conjugate
+// directions that were dropped for the gradient because they no longer
+// ascended.
+type ManifoldDiagnostics struct {
+	Iterations    int       `json:"iterations"`
+	Converged     bool      `json:"converged"`
+	StopReason    string    `json:"stop_reason"`
+	GradientNorm  float64   `json:"gradient_norm"`
+	GradientNorms []float64 `json:"gradient_norms"`
+	StepSizes     []float64 `json:"step_sizes"`
+	Restarts      int       `json:"restarts"`
+}
+
+func (d *ManifoldDiagnostics) Validate() error {
+	if len(d.StepSizes) != d.Iterations {
+		return NewValidationErrorf("%d step sizes for %d iterations", len(d.StepSizes), d.Iterations)
+	}
+	switch d.StopReason {
+	case ManifoldStopGradient, ManifoldStopImprovement, ManifoldStopLineSearch, ManifoldStopMaxIterations:
+	default:
+		return NewValidationErrorf("unknown stop reason %q", d.StopReason)
+	}
+	values := append([]float64{d.GradientNorm}, d.GradientNorms...)
+	return finite("manifold diagnostics", append(values, d.StepSizes...)...)
+}
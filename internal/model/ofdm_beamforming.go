@@ -0,0 +1,206 @@
+package model
+
+import (
+	"fmt"
+	"math"
+)
+
+// Defaults and bounds of an ofdm beamforming run. The subcarrier spacing is
+// in Hz.
+const (
+	DefaultOFDMSubcarriers       = 64
+	MaxOFDMSubcarriers           = 4096
+	DefaultOFDMSubcarrierSpacing = 30e3
+	MaxOFDMPaths                 = 64
+	MaxOFDMReceiveAntennas       = 64
+)
+
+// OFDMParams describe the frequency-selective channel of an ofdm
+// beamforming run over Subcarriers subcarriers SubcarrierSpacing Hz apart.
+// The channel is either given as Channels, one receive×transmit matrix of
+// [real, imag] pairs per subcarrier, or synthesized from Paths toward a
+// single-antenna receiver. ResourceBlockSize groups that many adjacent
+// subcarriers under one set of weights, as a scheduler assigning resource
+// blocks would; 0 computes weights for every subcarrier.
+type OFDMParams struct {
+	Subcarriers       int             `json:"subcarriers,omitempty"`
+	SubcarrierSpacing float64         `json:"subcarrier_spacing,omitempty"`
+	ResourceBlockSize int             `json:"resource_block_size,omitempty"`
+	Paths             []OFDMPath      `json:"paths,omitempty"`
+	Channels          [][][][]float64 `json:"channels,omitempty"`
+}
+
+// OFDMPath is a propagation path leaving the array Angle radians from
+// broadside, Delay seconds late, PathLoss dB weaker than a unit path and
+// with Phase radians of phase shift.
+type OFDMPath struct {
+	Angle    float64 `json:"angle"`
+	Delay    float64 `json:"delay"`
+	PathLoss float64 `json:"path_loss,omitempty"`
+	Phase    float64 `json:"phase,omitempty"`
+}
+
+// WithDefaults fills in the subcarrier count and spacing a request leaves
+// out; given channels set the subcarrier count.
+func (p OFDMParams) WithDefaults() *OFDMParams {
+	if p.Subcarriers == 0 {
+		p.Subcarriers = DefaultOFDMSubcarriers
+		if len(p.Channels) > 0 {
+			p.Subcarriers = len(p.Channels)
+		}
+	}
+	if p.SubcarrierSpacing == 0 {
+		p.SubcarrierSpacing = DefaultOFDMSubcarrierSpacing
+	}
+	if p.ResourceBlockSize == 0 {
+		p.ResourceBlockSize = 1
+	}
+	return &p
+}
+
+// Elements is the number of transmit antennas of a run: ElementCount, or
+// the columns of the given ofdm channels or the length of the given robust
+// channel estimate when it is omitted.
+func (p *BeamformingParams) Elements() int {
+	if p.ElementCount != 0 {
+		return p.ElementCount
+	}
+	switch p.Mode {
+	case BeamformingModeOFDM:
+		if p.OFDM != nil && len(p.OFDM.Channels) > 0 && len(p.OFDM.Channels[0]) > 0 {
+			return len(p.OFDM.Channels[0][0])
+		}
+	case BeamformingModeRobust:
+		if p.Robust != nil {
+			return len(p.Robust.Channel)
+		}
+	}
+	return p.ElementCount
+}
+
+// ValidateOFDM checks the channel description of an ofdm run.
+func (p *BeamformingParams) ValidateOFDM() error {
+	o := p.OFDM
+	if o == nil {
+		return &ValidationError{Field: "ofdm", Message: "ofdm mode needs the subcarriers and either paths or channels"}
+	}
+	if o.Subcarriers < 0 || o.Subcarriers > MaxOFDMSubcarriers {
+		return &ValidationError{Field: "ofdm.subcarriers", Message: fmt.Sprintf("must be between 0 and %d", MaxOFDMSubcarriers)}
+	}
+	if !(o.SubcarrierSpacing >= 0) || math.IsInf(o.SubcarrierSpacing, 0) {
+		return &ValidationError{Field: "ofdm.subcarrier_spacing", Message: "must not be negative"}
+	}
+	if o.ResourceBlockSize < 0 {
+		return &ValidationError{Field: "ofdm.resource_block_size", Message: "must not be negative"}
+	}
+	switch {
+	case len(o.Paths) > 0 && len(o.Channels) > 0:
+		return &ValidationError{Field: "ofdm", Message: "give either paths or channels, not both"}
+	case len(o.Channels) > 0:
+		return p.validateOFDMChannels()
+	case len(o.Paths) == 0:
+		return &ValidationError{Field: "ofdm", Message: "give the paths or the channels of the link"}
+	}
+
+	if p.ElementCount < 1 {
+		return &ValidationError{Field: "element_count", Message: "must be at least 1"}
+	}
+	if len(o.Paths) > MaxOFDMPaths {
+		return &ValidationError{Field: "ofdm.paths", Message: fmt.Sprintf("at most %d paths", MaxOFDMPaths)}
+	}
+	for i, path := range o.Paths {
+		if math.IsNaN(path.Angle) || math.Abs(path.Angle) > math.Pi {
+			return &ValidationError{Field: fmt.Sprintf("ofdm.paths[%d].angle", i), Message: "must be within [-π, π]; angles are in radians"}
+		}
+		if !(path.Delay >= 0) || math.IsInf(path.Delay, 0) {
+			return &ValidationError{Field: fmt.Sprintf("ofdm.paths[%d].delay", i), Message: "must not be negative; delays are in seconds"}
+		}
+		if !(path.PathLoss >= 0) || math.IsInf(path.PathLoss, 0) {
+			return &ValidationError{Field: fmt.Sprintf("ofdm.paths[%d].path_loss", i), Message: "must not be negative"}
+		}
+		if math.IsNaN(path.Phase) || math.IsInf(path.Phase, 0) {
+			return &ValidationError{Field: fmt.Sprintf("ofdm.paths[%d].phase", i), Message: "must be finite"}
+		}
+	}
+	return nil
+}
+
+// validateOFDMChannels checks that the given channels are one matrix per
+// subcarrier, all of the same shape, with as many columns as elements.
+func (p *BeamformingParams) validateOFDMChannels() error {
+	o := p.OFDM
+	if len(o.Channels) > MaxOFDMSubcarriers {
+		return &ValidationError{Field: "ofdm.channels", Message: fmt.Sprintf("at most %d subcarriers", MaxOFDMSubcarriers)}
+	}
+	if o.Subcarriers != 0 && o.Subcarriers != len(o.Channels) {
+		return &ValidationError{Field: "ofdm.subcarriers", Message: fmt.Sprintf("is %d but channels are given for %d subcarriers", o.Subcarriers, len(o.Channels))}
+	}
+	rows := len(o.Channels[0])
+	if rows < 1 || rows > MaxOFDMReceiveAntennas {
+		return &ValidationError{Field: "ofdm.channels", Message: fmt.Sprintf("need between 1 and %d receive antennas", MaxOFDMReceiveAntennas)}
+	}
+	cols := len(o.Channels[0][0])
+	if cols < 1 {
+		return &ValidationError{Field: "ofdm.channels", Message: "need at least 1 transmit antenna"}
+	}
+	if p.ElementCount != 0 && p.ElementCount != cols {
+		return &ValidationError{Field: "element_count", Message: fmt.Sprintf("is %d but the channels have %d transmit antennas", p.ElementCount, cols)}
+	}
+	for k, H := range o.Channels {
+		if len(H) != rows {
+			return &ValidationError{Field: fmt.Sprintf("ofdm.channels[%d]", k), Message: fmt.Sprintf("has %d rows, want %d", len(H), rows)}
+		}
+		for r, row := range H {
+			if len(row) != cols {
+				return &ValidationError{Field: fmt.Sprintf("ofdm.channels[%d][%d]", k, r), Message: fmt.Sprintf("has %d columns, want %d", len(row), cols)}
+			}
+			for n, v := range row {
+				if len(v) != 2 || math.IsNaN(v[0]) || math.IsNaN(v[1]) || math.IsInf(v[0], 0) || math.IsInf(v[1], 0) {
+					return &ValidationError{Field: fmt.Sprintf("ofdm.channels[%d][%d][%d]", k, r, n), Message: "must be a finite [real, imag] pair"}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// OFDMBeamformingResult reports an ofdm run. Weights holds the unit weights
+// of each resource block as [real, imag] pairs, of each subcarrier when
+// ResourceBlockSize is 1. Gains are the beamforming gains ‖Hw‖² of each
+// subcarrier and SpectralEfficiency their rates in bit/s/Hz with the
+// transmit power shared equally across the band.
+// WidebandSpectralEfficiency is the mean rate of the single beam that
+// serves the whole band best, for comparison with the per-block weights.
+type OFDMBeamformingResult struct {
+	Subcarriers                int           `json:"subcarriers"`
+	SubcarrierSpacing          float64       `json:"subcarrier_spacing"`
+	ResourceBlockSize          int           `json:"resource_block_size"`
+	Weights                    [][][]float64 `json:"weights"`
+	Gains                      []float64     `json:"gains"`
+	SpectralEfficiency         []float64     `json:"spectral_efficiency"`
+	WidebandSpectralEfficiency float64       `json:"wideband_spectral_efficiency"`
+}
+
+// Validate checks that there are weights of elements elements for every
+// resource block and a gain and rate for every subcarrier.
+func (r *OFDMBeamformingResult) Validate(elements int) error {
+	if r.Subcarriers < 1 || r.ResourceBlockSize < 1 {
+		return NewValidationError("ofdm result has no subcarriers")
+	}
+	if blocks := (r.Subcarriers + r.ResourceBlockSize - 1) / r.ResourceBlockSize; len(r.Weights) != blocks {
+		return NewValidationErrorf("%d ofdm weights for %d resource blocks", len(r.Weights), blocks)
+	}
+	for i, w := range r.Weights {
+		if len(w) != elements {
+			return NewValidationErrorf("ofdm.weights[%d] has %d weights, want %d", i, len(w), elements)
+		}
+		if err := validateWeights(fmt.Sprintf("ofdm.weights[%d]", i), w); err != nil {
+			return err
+		}
+	}
+	if len(r.Gains) != r.Subcarriers || len(r.SpectralEfficiency) != r.Subcarriers {
+		return NewValidationErrorf("%d gains and %d rates for %d subcarriers", len(r.Gains), len(r.SpectralEfficiency), r.Subcarriers)
+	}
+	values := append([]float64{r.SubcarrierSpacing, r.WidebandSpectralEfficiency}, r.Gains...)
+	return finite("ofdm result", append(values, r.SpectralEfficiency...)...)
+}
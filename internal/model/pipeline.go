@@ -0,0 +1,246 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	MaxPipelineSteps   = 32
+	MaxPipelineRetries = 10
+)
+
+type PipelineStepKind string
+
+// Pipeline step kinds. Params of each kind are the body of the matching
+// endpoint: collect takes a ChannelCollectRequest, beamforming and doa take
+// PipelineAlgorithmParams, irs_configure and irs_apply take PipelineIRSParams
+// and measure takes PipelineMeasureParams.
+const (
+	PipelineStepCollect      PipelineStepKind = "collect"
+	PipelineStepBeamforming  PipelineStepKind = "beamforming"
+	PipelineStepDOA          PipelineStepKind = "doa"
+	PipelineStepIRSConfigure PipelineStepKind = "irs_configure"
+	PipelineStepIRSApply     PipelineStepKind = "irs_apply"
+	PipelineStepMeasure      PipelineStepKind = "measure"
+)
+
+// Comparison operators of a step condition.
+const (
+	PipelineOpEq = "eq"
+	PipelineOpNe = "ne"
+	PipelineOpLt = "lt"
+	PipelineOpLe = "le"
+	PipelineOpGt = "gt"
+	PipelineOpGe = "ge"
+)
+
+// PipelineRequest declares an experiment as a DAG of steps. Steps run once
+// all of DependsOn have completed; a step whose dependency failed or was
+// skipped is skipped.
+//
+// A string param of the form "${step.field}" is replaced by that field of an
+// earlier step's result before the params are decoded, e.g. an irs_apply
+// step can steer toward "${beam.main_lobe_direction}". Fields are dot paths
+// into the result's JSON; list elements are addressed by index, as in
+// "${doa.estimated_angles.0}".
+type PipelineRequest struct {
+	Name  string         `json:"name" binding:"max=100"`
+	Steps []PipelineStep `json:"steps" binding:"required"`
+}
+
+type PipelineStep struct {
+	ID        string           `json:"id"`
+	Kind      PipelineStepKind `json:"kind"`
+	DependsOn []string         `json:"depends_on,omitempty"`
+	Params    json.RawMessage  `json:"params,omitempty"`
+	// Retries reruns a failed step up to Retries more times, RetryDelay
+	// seconds apart. Invalid params are never retried.
+	Retries    int     `json:"retries,omitempty"`
+	RetryDelay float64 `json:"retry_delay,omitempty"`
+	// When skips the step unless the condition holds.
+	When *PipelineCondition `json:"when,omitempty"`
+}
+
+// PipelineCondition compares a field of a dependency's result with Value.
+// eq and ne compare any JSON value; the ordering operators need numbers.
+type PipelineCondition struct {
+	Step  string      `json:"step"`
+	Field string      `json:"field"`
+	Op    string      `json:"op"`
+	Value interface{} `json:"value"`
+}
+
+type PipelineAlgorithmParams struct {
+	ExperimentID string          `json:"experiment_id"`
+	Params       json.RawMessage `json:"params"`
+}
+
+// PipelineIRSParams selects the panel, IRSID empty meaning the default one.
+// irs_configure applies Config; irs_apply steers toward TargetAngle,
+// optionally only for Group, with Method and Channel as in an
+// IRSOptimalRequest.
+type PipelineIRSParams struct {
+	IRSID       string                  `json:"irs_id"`
+	Config      *IRSConfigRequest       `json:"config,omitempty"`
+	TargetAngle *float64                `json:"target_angle,omitempty"`
+	Group       string                  `json:"group,omitempty"`
+	Method      string                  `json:"method,omitempty"`
+	Channel     *JointBeamformingParams `json:"channel,omitempty"`
+}
+
+// PipelineMeasureParams averages Repetitions realtime captures of the
+// receiver, one when unset.
+type PipelineMeasureParams struct {
+	Repetitions int `json:"repetitions"`
+}
+
+// PipelineMeasurement is the result of a measure step.
+type PipelineMeasurement struct {
+	Repetitions   int     `json:"repetitions"`
+	Samples       int     `json:"samples"`
+	MeanAmplitude float64 `json:"mean_amplitude"`
+	SNR           float64 `json:"snr"`
+	Clipped       int     `json:"clipped"`
+}
+
+func (r *PipelineRequest) Validate() error {
+	if len(r.Steps) == 0 {
+		return NewValidationError("steps are empty")
+	}
+	if len(r.Steps) > MaxPipelineSteps {
+		return NewValidationErrorf("a pipeline holds at most %d steps", MaxPipelineSteps)
+	}
+	index := make(map[string]int, len(r.Steps))
+	for i, step := range r.Steps {
+		if step.ID == "" {
+			return NewValidationErrorf("steps[%d].id is required", i)
+		}
+		if _, ok := index[step.ID]; ok {
+			return NewValidationErrorf("step %s appears more than once", step.ID)
+		}
+		index[step.ID] = i
+		switch step.Kind {
+		case PipelineStepCollect, PipelineStepBeamforming, PipelineStepDOA,
+			PipelineStepIRSConfigure, PipelineStepIRSApply, PipelineStepMeasure:
+		default:
+			return NewValidationErrorf("step %s: unknown kind %q", step.ID, step.Kind)
+		}
+		if step.Retries < 0 || step.Retries > MaxPipelineRetries {
+			return NewValidationErrorf("step %s: retries must be between 0 and %d", step.ID, MaxPipelineRetries)
+		}
+		if step.RetryDelay < 0 {
+			return NewValidationErrorf("step %s: retry_delay must not be negative", step.ID)
+		}
+	}
+	for _, step := range r.Steps {
+		for _, dep := range step.DependsOn {
+			if _, ok := index[dep]; !ok {
+				return NewValidationErrorf("step %s depends on unknown step %s", step.ID, dep)
+			}
+			if dep == step.ID {
+				return NewValidationErrorf("step %s depends on itself", step.ID)
+			}
+		}
+		if w := step.When; w != nil {
+			if !containsString(step.DependsOn, w.Step) {
+				return NewValidationErrorf("step %s: condition must test one of its dependencies", step.ID)
+			}
+			switch w.Op {
+			case PipelineOpEq, PipelineOpNe:
+			case PipelineOpLt, PipelineOpLe, PipelineOpGt, PipelineOpGe:
+				if _, ok := w.Value.(float64); !ok {
+					return NewValidationErrorf("step %s: %s needs a numeric value", step.ID, w.Op)
+				}
+			default:
+				return NewValidationErrorf("step %s: unknown condition op %q", step.ID, w.Op)
+			}
+		}
+	}
+	if _, err := r.Order(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Order returns the step indexes in an order that runs every step after its
+// dependencies, keeping the declared order where the DAG allows it.
+func (r *PipelineRequest) Order() ([]int, error) {
+	index := make(map[string]int, len(r.Steps))
+	for i, step := range r.Steps {
+		index[step.ID] = i
+	}
+	done := make([]bool, len(r.Steps))
+	order := make([]int, 0, len(r.Steps))
+	for len(order) < len(r.Steps) {
+		progressed := false
+		for i, step := range r.Steps {
+			if done[i] {
+				continue
+			}
+			ready := true
+			for _, dep := range step.DependsOn {
+				if j, ok := index[dep]; !ok || !done[j] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				done[i] = true
+				order = append(order, i)
+				progressed = true
+			}
+		}
+		if !progressed {
+			return nil, NewValidationError("steps form a cycle")
+		}
+	}
+	return order, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+type PipelineStatus string
+
+const (
+	PipelineStatusPending   PipelineStatus = "pending"
+	PipelineStatusRunning   PipelineStatus = "running"
+	PipelineStatusCompleted PipelineStatus = "completed"
+	PipelineStatusFailed    PipelineStatus = "failed"
+	PipelineStatusSkipped   PipelineStatus = "skipped"
+)
+
+// PipelineRun is a submitted pipeline. A run fails when any of its steps
+// failed; steps on other branches still run.
+type PipelineRun struct {
+	ID        string               `json:"id"`
+	Name      string               `json:"name"`
+	TaskID    string               `json:"task_id"`
+	Status    PipelineStatus       `json:"status"`
+	Steps     []PipelineStepResult `json:"steps"`
+	CreatedAt time.Time            `json:"created_at"`
+	StartedAt *time.Time           `json:"started_at,omitempty"`
+	EndedAt   *time.Time           `json:"ended_at,omitempty"`
+}
+
+// PipelineStepResult is the outcome of one step, in declared order. Result
+// is set for completed steps, Code and Error for failed ones and Reason for
+// skipped ones.
+type PipelineStepResult struct {
+	ID       string           `json:"id"`
+	Kind     PipelineStepKind `json:"kind"`
+	Status   PipelineStatus   `json:"status"`
+	Attempts int              `json:"attempts"`
+	Result   interface{}      `json:"result,omitempty"`
+	Code     int              `json:"code,omitempty"`
+	Error    string           `json:"error,omitempty"`
+	Reason   string           `json:"reason,omitempty"`
+	Duration float64          `json:"duration"`
+}
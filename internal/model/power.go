@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+)
+
+type PowerEstimate struct {
+	IRSElementCount  int       `json:"irs_element_count"`
+	IRSStaticPower   float64   `json:"irs_static_power"`
+	IRSDynamicEnergy float64   `json:"irs_dynamic_energy"`
+	ControllerPower  float64   `json:"controller_power"`
+	USRPPower        float64   `json:"usrp_power"`
+	AveragePower     float64   `json:"average_power"`
+	Duration         float64   `json:"duration"`
+	TotalEnergy      float64   `json:"total_energy"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+type PowerCrossCheck struct {
+	EstimatedPower  float64   `json:"estimated_power"`
+	MeasuredPower   float64   `json:"measured_power"`
+	SensorIDs       []string  `json:"sensor_ids"`
+	Deviation       float64   `json:"deviation"`
+	Tolerance       float64   `json:"tolerance"`
+	WithinTolerance bool      `json:"within_tolerance"`
+	Timestamp       time.Time `json:"timestamp"`
+}
+
+type PowerEstimateRequest struct {
+	PhaseShifts  []float64 `json:"phase_shifts"`
+	HoldDuration float64   `json:"hold_duration" binding:"min=0"`
+}
+
+type EnergyReport struct {
+	ExperimentID    string        `json:"experiment_id"`
+	AlgorithmType   AlgorithmType `json:"algorithm_type"`
+	Variant         string        `json:"variant,omitempty"`
+	Duration        float64       `json:"duration"`
+	EstimatedEnergy float64       `json:"estimated_energy"`
+	MeasuredPower   float64       `json:"measured_power"`
+	MeasuredEnergy  float64       `json:"measured_energy"`
+	PowerSamples    int           `json:"power_samples"`
+	// AchievedBits is the data the result carries over TransmissionInterval
+	// seconds of use, and EnergyPerBit the experiment's energy divided by
+	// it; both are zero for experiments that carry no data.
+	TransmissionInterval float64 `json:"transmission_interval,omitempty"`
+	AchievedBits         float64 `json:"achieved_bits"`
+	EnergyPerBit         float64 `json:"energy_per_bit,omitempty"`
+}
+
+type AlgorithmEnergyRanking struct {
+	Rank                int           `json:"rank"`
+	AlgorithmType       AlgorithmType `json:"algorithm_type"`
+	Variant             string        `json:"variant,omitempty"`
+	Experiments         int           `json:"experiments"`
+	AverageEnergy       float64       `json:"average_energy"`
+	AverageEnergyPerBit float64       `json:"average_energy_per_bit"`
+}
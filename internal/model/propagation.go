@@ -0,0 +1,72 @@
+package model
+
+import "math"
+
+// Defaults of the simulated propagation, in dB.
+const (
+	DefaultDirectPathLoss  = 20.0
+	DefaultElementPathLoss = 40.0
+)
+
+// SimulatedPropagation couples a simulated IRS panel to the simulated USRP.
+// A transmitter sends a tone SourceFrequency Hz from the carrier (a tenth of
+// the sample rate when zero). It reaches the receive array directly from
+// DirectAngle, attenuated by DirectPathLoss, and by way of panel IRSID (the
+// default panel when empty): the tone hits the panel at IncidenceAngle, each
+// element reradiates it with its current reflection coefficient towards
+// ReflectionAngle, attenuated by ElementPathLoss, and the sum arrives at
+// the array from ArrivalAngle. Angles are in radians from broadside of a
+// half-wavelength ULA on either end. Scattering is the share of the power
+// of each element's channels that arrives scattered rather than on the line
+// of sight, zero for pure line of sight; Seed draws the scattered part.
+type SimulatedPropagation struct {
+	Enabled         bool    `json:"enabled" mapstructure:"enabled"`
+	IRSID           string  `json:"irs_id,omitempty" mapstructure:"irs_id"`
+	SourceFrequency float64 `json:"source_frequency" mapstructure:"source_frequency"`
+	DirectAngle     float64 `json:"direct_angle" mapstructure:"direct_angle"`
+	DirectPathLoss  float64 `json:"direct_path_loss" mapstructure:"direct_path_loss"`
+	IncidenceAngle  float64 `json:"incidence_angle" mapstructure:"incidence_angle"`
+	ReflectionAngle float64 `json:"reflection_angle" mapstructure:"reflection_angle"`
+	ArrivalAngle    float64 `json:"arrival_angle" mapstructure:"arrival_angle"`
+	ElementPathLoss float64 `json:"element_path_loss" mapstructure:"element_path_loss"`
+	Scattering      float64 `json:"scattering" mapstructure:"scattering"`
+	Seed            int64   `json:"seed" mapstructure:"seed"`
+}
+
+func (p *SimulatedPropagation) Validate() error {
+	angles := []struct {
+		field string
+		value float64
+	}{
+		{"direct_angle", p.DirectAngle},
+		{"incidence_angle", p.IncidenceAngle},
+		{"reflection_angle", p.ReflectionAngle},
+		{"arrival_angle", p.ArrivalAngle},
+	}
+	for _, a := range angles {
+		if math.IsNaN(a.value) || math.Abs(a.value) > math.Pi/2 {
+			return &ValidationError{Field: a.field, Message: "must be within [-π/2, π/2]; angles are in radians"}
+		}
+	}
+	if p.DirectPathLoss < 0 {
+		return &ValidationError{Field: "direct_path_loss", Message: "must not be negative"}
+	}
+	if p.ElementPathLoss < 0 {
+		return &ValidationError{Field: "element_path_loss", Message: "must not be negative"}
+	}
+	if p.Scattering < 0 || p.Scattering > 1 {
+		return &ValidationError{Field: "scattering", Message: "must be between 0 and 1"}
+	}
+	return nil
+}
+
+// WithDefaults fills in the path losses a configuration leaves out.
+func (p SimulatedPropagation) WithDefaults() SimulatedPropagation {
+	if p.DirectPathLoss == 0 {
+		p.DirectPathLoss = DefaultDirectPathLoss
+	}
+	if p.ElementPathLoss == 0 {
+		p.ElementPathLoss = DefaultElementPathLoss
+	}
+	return p
+}
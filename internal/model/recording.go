@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+)
+
+type RecordingStatus string
+
+const (
+	RecordingStatusRecording RecordingStatus = "recording"
+	RecordingStatusCompleted RecordingStatus = "completed"
+	RecordingStatusFailed    RecordingStatus = "failed"
+)
+
+// Recording is a SigMF capture of raw IQ samples from the USRP receiver.
+// Samples counts samples per channel.
+type Recording struct {
+	ID           string          `json:"id"`
+	ExperimentID string          `json:"experiment_id"`
+	Description  string          `json:"description"`
+	Status       RecordingStatus `json:"status"`
+	DataPath     string          `json:"data_path"`
+	MetaPath     string          `json:"meta_path"`
+	SampleRate   float64         `json:"sample_rate"`
+	CenterFreq   float64         `json:"center_freq"`
+	Channels     int             `json:"channels"`
+	Samples      int64           `json:"samples"`
+	Bytes        int64           `json:"bytes"`
+	Checksum     string          `json:"checksum,omitempty"`
+	StartedAt    time.Time       `json:"started_at"`
+	StoppedAt    *time.Time      `json:"stopped_at,omitempty"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// RecordingRequest starts a recording. MaxDuration is in seconds; zero uses
+// the configured limit.
+type RecordingRequest struct {
+	ExperimentID string  `json:"experiment_id" binding:"required"`
+	Description  string  `json:"description"`
+	MaxDuration  float64 `json:"max_duration" binding:"omitempty,min=0.1,max=86400"`
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	ReservableDeviceIRS  = "irs"
+	ReservableDeviceUSRP = "usrp"
+)
+
+// Reservation books a device for Holder between StartTime and EndTime.
+type Reservation struct {
+	ID        int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	Device    string    `json:"device" gorm:"type:varchar(20);not null;index:idx_device_window"`
+	Holder    string    `json:"holder" gorm:"type:varchar(100);not null"`
+	Purpose   string    `json:"purpose" gorm:"type:varchar(255)"`
+	StartTime time.Time `json:"start_time" gorm:"not null;index:idx_device_window"`
+	EndTime   time.Time `json:"end_time" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (Reservation) TableName() string {
+	return "device_reservation"
+}
+
+// DeviceLock has one row per reservable device. Bookings lock the row of
+// their device so that they are checked and inserted one at a time.
+type DeviceLock struct {
+	Device string `gorm:"type:varchar(20);primaryKey"`
+}
+
+func (DeviceLock) TableName() string {
+	return "device_lock"
+}
+
+type ReservationRequest struct {
+	Device    string    `json:"device" binding:"required,oneof=irs usrp"`
+	Purpose   string    `json:"purpose"`
+	StartTime time.Time `json:"start_time" binding:"required"`
+	EndTime   time.Time `json:"end_time" binding:"required"`
+}
+
+type ReservationQuery struct {
+	Device string    `form:"device"`
+	Holder string    `form:"holder"`
+	From   time.Time `form:"from" time_format:"2006-01-02T15:04:05"`
+	To     time.Time `form:"to" time_format:"2006-01-02T15:04:05"`
+}
+
+// QueuedExperiment is returned instead of a result when an experiment has to
+// wait for another holder's reservation to end.
+type QueuedExperiment struct {
+	ExperimentID string       `json:"experiment_id"`
+	Device       string       `json:"device"`
+	BlockedBy    *Reservation `json:"blocked_by"`
+	// Estimate is the predicted cost; BlockedBy is nil when the experiment
+	// waits for a heavy experiment slot rather than a reservation.
+	Estimate *CostEstimate `json:"estimate,omitempty"`
+}
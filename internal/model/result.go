@@ -0,0 +1,174 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// ResultPayload is the typed content of ExperimentResult.ResultData.
+type ResultPayload interface {
+	Validate() error
+}
+
+// resultSchemas maps each algorithm type to the payload its results must
+// decode into. Types without an entry cannot store results.
+var resultSchemas = map[AlgorithmType]func() ResultPayload{
+	AlgorithmTypeBeamforming:       func() ResultPayload { return &BeamformingResult{} },
+	AlgorithmTypeDOA:               func() ResultPayload { return &DOAResult{} },
+	AlgorithmTypeIRSImpact:         func() ResultPayload { return &IRSImpactResult{} },
+	AlgorithmTypeJointBeamforming:  func() ResultPayload { return &JointBeamformingResult{} },
+	AlgorithmTypeHybridBeamforming: func() ResultPayload { return &HybridBeamformingResult{} },
+}
+
+// ResultSchemaError reports result data that does not match the schema of
+// its algorithm type.
+type ResultSchemaError struct {
+	AlgorithmType AlgorithmType
+	Message       string
+}
+
+func (e *ResultSchemaError) Error() string {
+	return fmt.Sprintf("invalid %s result: %s", e.AlgorithmType, e.Message)
+}
+
+// EncodeResult validates payload against the schema of algorithmType and
+// returns it as JSON for ResultData.
+func EncodeResult(algorithmType AlgorithmType, payload ResultPayload) (string, error) {
+	schema, ok := resultSchemas[algorithmType]
+	if !ok {
+		return "", &ResultSchemaError{AlgorithmType: algorithmType, Message: "no result schema for algorithm type"}
+	}
+	if want := schema(); reflect.TypeOf(want) != reflect.TypeOf(payload) {
+		return "", &ResultSchemaError{AlgorithmType: algorithmType, Message: fmt.Sprintf("got %T, want %T", payload, want)}
+	}
+	if err := payload.Validate(); err != nil {
+		return "", &ResultSchemaError{AlgorithmType: algorithmType, Message: err.Error()}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", &ResultSchemaError{AlgorithmType: algorithmType, Message: err.Error()}
+	}
+	return string(data), nil
+}
+
+// DecodeResult parses stored result data into the payload type of
+// algorithmType. Unknown fields are rejected so results written for another
+// type do not decode as an empty value.
+func DecodeResult(algorithmType AlgorithmType, data string) (ResultPayload, error) {
+	schema, ok := resultSchemas[algorithmType]
+	if !ok {
+		return nil, &ResultSchemaError{AlgorithmType: algorithmType, Message: "no result schema for algorithm type"}
+	}
+	payload := schema()
+	dec := json.NewDecoder(bytes.NewReader([]byte(data)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(payload); err != nil {
+		return nil, &ResultSchemaError{AlgorithmType: algorithmType, Message: err.Error()}
+	}
+	if err := payload.Validate(); err != nil {
+		return nil, &ResultSchemaError{AlgorithmType: algorithmType, Message: err.Error()}
+	}
+	return payload, nil
+}
+
+func (r *BeamformingResult) Validate() error {
+	if len(r.Weights) == 0 {
+		return NewValidationError("weights are empty")
+	}
+	if err := validateWeights("weights", r.Weights); err != nil {
+		return err
+	}
+	for i, beam := range r.Beams {
+		if len(beam) != len(r.Weights) {
+			return NewValidationErrorf("beams[%d] has %d weights, want %d", i, len(beam), len(r.Weights))
+		}
+		if err := validateWeights(fmt.Sprintf("beams[%d]", i), beam); err != nil {
+			return err
+		}
+	}
+	if r.Objective != BeamformingObjectiveSpectral && r.Objective != BeamformingObjectiveEnergy {
+		return NewValidationErrorf("unknown objective %q", r.Objective)
+	}
+	if r.Iterations < 0 {
+		return NewValidationError("iterations is negative")
+	}
+	values := []float64{
+		r.MainLobeDirection, r.MainLobeWidth, r.SLL,
+		r.TransmitPower, r.TotalPower, r.SpectralEfficiency, r.EnergyEfficiency,
+	}
+	for i, user := range r.Users {
+		if err := validateWeights(fmt.Sprintf("users[%d].weights", i), user.Weights); err != nil {
+			return err
+		}
+		values = append(values, user.Angle, user.Power, user.SINR, user.Rate)
+	}
+	if r.OFDM != nil {
+		if err := r.OFDM.Validate(len(r.Weights)); err != nil {
+			return err
+		}
+	}
+	if r.Robust != nil {
+		if err := r.Robust.Validate(); err != nil {
+			return err
+		}
+	}
+	values = append(values, r.SumRate)
+	values = append(values, r.RateHistory...)
+	values = append(values, r.BeamPattern...)
+	values = append(values, r.Eigenvalues...)
+	return finite("beamforming result", append(values, r.NullDepths...)...)
+}
+
+func (r *DOAResult) Validate() error {
+	if err := finite("estimated_angles", r.EstimatedAngles...); err != nil {
+		return err
+	}
+	if len(r.EstimatedElevations) > 0 && len(r.EstimatedElevations) != len(r.EstimatedAngles) {
+		return NewValidationError("estimated_elevations does not match estimated_angles")
+	}
+	if err := finite("estimated_elevations", r.EstimatedElevations...); err != nil {
+		return err
+	}
+	if err := finite("rmse", r.RMSE); err != nil {
+		return err
+	}
+	if len(r.CRB) > 0 && len(r.CRB) != len(r.EstimatedAngles) {
+		return NewValidationError("crb does not match estimated_angles")
+	}
+	if err := finite("crb", r.CRB...); err != nil {
+		return err
+	}
+	for i, row := range r.Spectrum2D {
+		if len(row) != len(r.Spectrum2D[0]) {
+			return NewValidationErrorf("spectrum_2d row %d has %d points, want %d", i, len(row), len(r.Spectrum2D[0]))
+		}
+	}
+	if s := r.Snapshots; s != nil && (s.Antennas <= 0 || s.Snapshots < 0 || s.Captured < s.Snapshots) {
+		return NewValidationError("snapshots archive has inconsistent dimensions")
+	}
+	return nil
+}
+
+func validateWeights(field string, weights [][]float64) error {
+	for i, w := range weights {
+		if len(w) != 2 {
+			return NewValidationErrorf("%s[%d] must be a [real, imag] pair", field, i)
+		}
+		if err := finite(field, w...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func finite(field string, values ...float64) error {
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return NewValidationErrorf("%s contains a non-finite value", field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEncodeResult(t *testing.T) {
+	bf := &BeamformingResult{
+		Weights:     [][]float64{{1, 0}, {0, 1}},
+		BeamPattern: []float64{0.5, 1},
+		Objective:   BeamformingObjectiveSpectral,
+	}
+	data, err := EncodeResult(AlgorithmTypeBeamforming, bf)
+	if err != nil {
+		t.Fatalf("EncodeResult() error = %v", err)
+	}
+
+	if _, err := EncodeResult(AlgorithmTypeDOA, bf); err == nil {
+		t.Error("EncodeResult() accepted a beamforming result for a DOA experiment")
+	}
+	if _, err := EncodeResult(AlgorithmTypeDOA, &DOAResult{EstimatedAngles: []float64{math.NaN()}}); err == nil {
+		t.Error("EncodeResult() accepted a NaN angle")
+	}
+	if _, err := EncodeResult(AlgorithmTypeScheduling, bf); err == nil {
+		t.Error("EncodeResult() accepted a type without a schema")
+	}
+
+	payload, err := DecodeResult(AlgorithmTypeBeamforming, data)
+	if err != nil {
+		t.Fatalf("DecodeResult() error = %v", err)
+	}
+	if got := payload.(*BeamformingResult); len(got.Weights) != 2 || got.Weights[1][1] != 1 {
+		t.Errorf("DecodeResult() = %+v, want the encoded weights", got)
+	}
+	if _, err := DecodeResult(AlgorithmTypeDOA, data); err == nil {
+		t.Error("DecodeResult() decoded a beamforming result as DOA")
+	}
+	if _, err := DecodeResult(AlgorithmTypeBeamforming, `{"weights": [[1]]`); err == nil {
+		t.Error("DecodeResult() accepted truncated JSON")
+	}
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"fmt"
+	"math"
+)
+
+// MaxRobustErrorBound bounds the relative channel error of a robust run; an
+// error as large as the estimate itself could cancel the signal entirely.
+const MaxRobustErrorBound = 0.99
+
+// RobustParams describe the channel state a robust beamforming run designs
+// for. Channel is the estimated channel, one [real, imag] pair per element;
+// when it is omitted the estimate is the steering vector toward the target
+// direction. The true channel is only known to lie within ErrorBound of the
+// estimate, relative to its norm: ‖h − ĥ‖ ≤ ErrorBound·‖ĥ‖. MaxDegradation,
+// in dB, fails the run when the weights cannot keep the worst-case SINR
+// within that much of the SINR the estimate promises.
+type RobustParams struct {
+	Channel        [][]float64 `json:"channel,omitempty"`
+	ErrorBound     float64     `json:"error_bound"`
+	MaxDegradation *float64    `json:"max_degradation,omitempty"`
+}
+
+// ValidateRobust checks the channel estimate and error bound of a robust
+// run.
+func (p *BeamformingParams) ValidateRobust() error {
+	r := p.Robust
+	if r == nil {
+		return &ValidationError{Field: "robust", Message: "robust mode needs the error_bound of the channel estimate"}
+	}
+	if !(r.ErrorBound >= 0 && r.ErrorBound <= MaxRobustErrorBound) {
+		return &ValidationError{Field: "robust.error_bound", Message: fmt.Sprintf("must be between 0 and %v; it is relative to the norm of the estimate", MaxRobustErrorBound)}
+	}
+	if d := r.MaxDegradation; d != nil && !(*d >= 0 && *d <= maxJointLevel) {
+		return &ValidationError{Field: "robust.max_degradation", Message: fmt.Sprintf("must be between 0 and %v dB", maxJointLevel)}
+	}
+	if len(r.Channel) == 0 {
+		if p.ElementCount < 1 {
+			return &ValidationError{Field: "element_count", Message: "must be at least 1"}
+		}
+		return nil
+	}
+	if p.ElementCount != 0 && p.ElementCount != len(r.Channel) {
+		return &ValidationError{Field: "element_count", Message: fmt.Sprintf("is %d but the channel has %d elements", p.ElementCount, len(r.Channel))}
+	}
+	var power float64
+	for n, v := range r.Channel {
+		if len(v) != 2 || math.IsNaN(v[0]) || math.IsNaN(v[1]) || math.IsInf(v[0], 0) || math.IsInf(v[1], 0) {
+			return &ValidationError{Field: fmt.Sprintf("robust.channel[%d]", n), Message: "must be a finite [real, imag] pair"}
+		}
+		power += v[0]*v[0] + v[1]*v[1]
+	}
+	if power == 0 {
+		return &ValidationError{Field: "robust.channel", Message: "must not be all zero"}
+	}
+	return nil
+}
+
+// RobustBeamformingResult reports a robust run. The SINRs are in dB at an
+// element SNR of 0 dB, with the interference of the request:
+// OptimalSINR is what MVDR weights would reach were the estimate exact,
+// NominalSINR what the robust weights reach on the estimate and
+// WorstCaseSINR the least they reach on any channel within the error bound,
+// which the run guarantees. Degradation is OptimalSINR − WorstCaseSINR.
+// MVDRWorstCaseSINR is the worst case of the MVDR weights for the estimate,
+// for comparison. DiagonalLoading is the loading μ of the weights
+// (R + μI)⁻¹ĥ, relative to the noise power.
+type RobustBeamformingResult struct {
+	ErrorBound        float64 `json:"error_bound"`
+	DiagonalLoading   float64 `json:"diagonal_loading"`
+	OptimalSINR       float64 `json:"optimal_sinr"`
+	NominalSINR       float64 `json:"nominal_sinr"`
+	WorstCaseSINR     float64 `json:"worst_case_sinr"`
+	MVDRWorstCaseSINR float64 `json:"mvdr_worst_case_sinr"`
+	Degradation       float64 `json:"degradation"`
+}
+
+func (r *RobustBeamformingResult) Validate() error {
+	if r.WorstCaseSINR > r.NominalSINR+1e-9 || r.NominalSINR > r.OptimalSINR+1e-9 {
+		return NewValidationErrorf("robust SINRs out of order: worst case %g, nominal %g, optimal %g dB", r.WorstCaseSINR, r.NominalSINR, r.OptimalSINR)
+	}
+	if r.DiagonalLoading < 0 {
+		return NewValidationError("robust diagonal_loading is negative")
+	}
+	return finite("robust result", r.ErrorBound, r.DiagonalLoading, r.OptimalSINR, r.NominalSINR, r.WorstCaseSINR, r.MVDRWorstCaseSINR, r.Degradation)
+}
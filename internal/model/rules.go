@@ -0,0 +1,410 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Rule is one declarative constraint on the parameters of a request. It
+// applies to the parameters When selects, to all of them when When is nil,
+// and Check returns what is wrong with them, nil when nothing is. An error
+// that is not a ValidationError with a field of its own is reported for
+// Field.
+type Rule[T any] struct {
+	Field string
+	When  func(p *T) bool
+	Check func(p *T) error
+}
+
+// Rules are the constraints a request must meet before it runs. They are
+// checked in order and all of them are checked, so that a client learns of
+// every violation at once instead of fixing a request one error at a time.
+type Rules[T any] []Rule[T]
+
+// Check returns an error diagnostic for every rule p violates.
+func (rs Rules[T]) Check(p *T) Diagnostics {
+	var d Diagnostics
+	for _, r := range rs {
+		if err := r.violation(p); err != nil {
+			d.Add(err)
+		}
+	}
+	return d
+}
+
+// Validate returns the first rule p violates.
+func (rs Rules[T]) Validate(p *T) error {
+	for _, r := range rs {
+		if err := r.violation(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r Rule[T]) violation(p *T) error {
+	if r.When != nil && !r.When(p) {
+		return nil
+	}
+	err := r.Check(p)
+	if err == nil {
+		return nil
+	}
+	if ve, ok := err.(*ValidationError); ok && ve.Field != "" {
+		return ve
+	}
+	if ve, ok := err.(*ValidationError); ok {
+		return &ValidationError{Field: r.Field, Message: ve.Message}
+	}
+	return &ValidationError{Field: r.Field, Message: err.Error()}
+}
+
+// join concatenates rule sets.
+func join[T any](sets ...Rules[T]) Rules[T] {
+	var all Rules[T]
+	for _, rs := range sets {
+		all = append(all, rs...)
+	}
+	return all
+}
+
+// when restricts rules to the parameters cond selects, on top of their own
+// conditions.
+func when[T any](cond func(p *T) bool, rules ...Rule[T]) Rules[T] {
+	out := make(Rules[T], len(rules))
+	for i, r := range rules {
+		own := r.When
+		r.When = func(p *T) bool { return cond(p) && (own == nil || own(p)) }
+		out[i] = r
+	}
+	return out
+}
+
+// violated builds the error of a rule.
+func violated(format string, args ...interface{}) error {
+	return &ValidationError{Message: fmt.Sprintf(format, args...)}
+}
+
+type number interface {
+	~int | ~int64 | ~float64
+}
+
+func atLeast[T any, N number](field string, min N, get func(p *T) N) Rule[T] {
+	return Rule[T]{Field: field, Check: func(p *T) error {
+		if v := get(p); !(v >= min) {
+			return violated("must be at least %v", min)
+		}
+		return nil
+	}}
+}
+
+func notNegative[T any, N number](field string, get func(p *T) N) Rule[T] {
+	return Rule[T]{Field: field, Check: func(p *T) error {
+		if v := get(p); !(v >= 0) {
+			return violated("must not be negative")
+		}
+		return nil
+	}}
+}
+
+// between checks lo ≤ value ≤ hi; unit follows the bounds in the message.
+func between[T any, N number](field string, lo, hi N, unit string, get func(p *T) N) Rule[T] {
+	return Rule[T]{Field: field, Check: func(p *T) error {
+		if v := get(p); !(v >= lo && v <= hi) {
+			return violated("must be between %v and %v%s", lo, hi, unit)
+		}
+		return nil
+	}}
+}
+
+// angle checks an angle in radians against [-π, π].
+func angle[T any](field string, get func(p *T) float64) Rule[T] {
+	return Rule[T]{Field: field, Check: func(p *T) error {
+		if v := get(p); !(math.Abs(v) <= math.Pi) {
+			return violated("must be within [-π, π]; angles are in radians")
+		}
+		return nil
+	}}
+}
+
+func required[T any](field string, get func(p *T) string) Rule[T] {
+	return Rule[T]{Field: field, Check: func(p *T) error {
+		if get(p) == "" {
+			return violated("is required")
+		}
+		return nil
+	}}
+}
+
+// oneOf checks an enumerated setting. The empty value, which selects the
+// default, is always allowed.
+func oneOf[T any, S ~string](field string, get func(p *T) S, values ...S) Rule[T] {
+	names := make([]string, len(values))
+	for i, v := range values {
+		names[i] = string(v)
+	}
+	return Rule[T]{Field: field, Check: func(p *T) error {
+		v := get(p)
+		if v == "" {
+			return nil
+		}
+		for _, allowed := range values {
+			if v == allowed {
+				return nil
+			}
+		}
+		return violated("unsupported %s %q, use %s", field, v, strings.Join(names, ", "))
+	}}
+}
+
+// validated defers to a Validate method of a part of the parameters.
+func validated[T any](field string, validate func(p *T) error) Rule[T] {
+	return Rule[T]{Field: field, Check: validate}
+}
+
+// covarianceRules check the covariance options get returns, if any.
+func covarianceRules[T any](get func(p *T) *CovarianceOptions) Rules[T] {
+	set := func(p *T) bool { return get(p) != nil }
+	return when(set,
+		oneOf("covariance.method", func(p *T) string { return get(p).Method },
+			CovarianceSample, CovarianceDiagonalLoading, CovarianceLedoitWolf),
+		notNegative("covariance.loading_factor", func(p *T) float64 { return get(p).LoadingFactor }),
+	)
+}
+
+// Violations returns every rule of a beamforming request the parameters
+// break.
+func (p *BeamformingParams) Violations() Diagnostics {
+	return beamformingRules.Check(p)
+}
+
+var interferenceAngles = Rule[BeamformingParams]{Field: "interference_angles", Check: func(p *BeamformingParams) error {
+	for i, a := range p.InterferenceAngles {
+		if !(math.Abs(a) <= math.Pi) {
+			return &ValidationError{Field: fmt.Sprintf("interference_angles[%d]", i), Message: "must be within [-π, π]; angles are in radians"}
+		}
+	}
+	return nil
+}}
+
+var beamformingRules = join(
+	Rules[BeamformingParams]{
+		oneOf("mode", func(p *BeamformingParams) BeamformingMode { return p.Mode },
+			BeamformingModeTarget, BeamformingModeEigen, BeamformingModeWMMSE, BeamformingModeOFDM, BeamformingModeRobust),
+		oneOf("objective", func(p *BeamformingParams) BeamformingObjective { return p.Objective },
+			BeamformingObjectiveSpectral, BeamformingObjectiveEnergy),
+		notNegative("max_iterations", func(p *BeamformingParams) int { return p.MaxIterations }),
+	},
+	when(func(p *BeamformingParams) bool { return p.Mode == "" || p.Mode == BeamformingModeTarget },
+		atLeast("element_count", 1, func(p *BeamformingParams) int { return p.ElementCount }),
+		angle("target_direction", func(p *BeamformingParams) float64 { return p.TargetDirection }),
+		interferenceAngles,
+		// exact nulls use one degree of freedom each
+		Rule[BeamformingParams]{
+			Field: "interference_angles",
+			When:  func(p *BeamformingParams) bool { return p.InterferenceINR == nil && p.ElementCount > 0 },
+			Check: func(p *BeamformingParams) error {
+				if len(p.InterferenceAngles) >= p.ElementCount {
+					return violated("%d interference angles need more than %d elements to place every null", len(p.InterferenceAngles), p.ElementCount)
+				}
+				return nil
+			},
+		},
+	),
+	when(func(p *BeamformingParams) bool { return p.Mode == BeamformingModeWMMSE },
+		atLeast("element_count", 1, func(p *BeamformingParams) int { return p.ElementCount }),
+		validated("users", (*BeamformingParams).ValidateUsers),
+		Rule[BeamformingParams]{Field: "objective", Check: func(p *BeamformingParams) error {
+			if p.Objective == BeamformingObjectiveEnergy {
+				return violated("wmmse maximizes the sum rate, use spectral_efficiency")
+			}
+			return nil
+		}},
+	),
+	when(func(p *BeamformingParams) bool { return p.Mode == BeamformingModeOFDM },
+		validated("ofdm", (*BeamformingParams).ValidateOFDM),
+		Rule[BeamformingParams]{Field: "objective", Check: func(p *BeamformingParams) error {
+			if p.Objective == BeamformingObjectiveEnergy {
+				return violated("ofdm maximizes the rate of every subcarrier, use spectral_efficiency")
+			}
+			return nil
+		}},
+	),
+	when(func(p *BeamformingParams) bool { return p.Mode == BeamformingModeRobust },
+		validated("robust", (*BeamformingParams).ValidateRobust),
+		angle("target_direction", func(p *BeamformingParams) float64 { return p.TargetDirection }),
+		interferenceAngles,
+		// the worst case is over the interference-plus-noise covariance,
+		// which exact nulls do not have
+		Rule[BeamformingParams]{Field: "interference_inr", Check: func(p *BeamformingParams) error {
+			if len(p.InterferenceAngles) > 0 && p.InterferenceINR == nil {
+				return violated("robust mode needs the INR of the interferers")
+			}
+			return nil
+		}},
+	),
+	when(func(p *BeamformingParams) bool { return p.Mode == BeamformingModeEigen },
+		join(
+			Rules[BeamformingParams]{
+				notNegative("num_beams", func(p *BeamformingParams) int { return p.NumBeams }),
+				notNegative("snapshot_length", func(p *BeamformingParams) int { return p.SnapshotLength }),
+			},
+			covarianceRules(func(p *BeamformingParams) *CovarianceOptions { return p.Covariance }),
+		)...,
+	),
+)
+
+// Violations returns every rule of a DOA request the parameters break. The
+// element count of a USRP capture is the receiver's channel count, which
+// only the service knows.
+func (p *DOAParams) Violations() Diagnostics {
+	return doaRules.Check(p)
+}
+
+func (p *DOAParams) synthetic() bool { return !p.Captured() }
+
+// regularized reports whether the request asks for a covariance estimate
+// that stays invertible with fewer snapshots than elements.
+func (p *DOAParams) regularized() bool {
+	return p.Covariance != nil && (p.Covariance.Method == CovarianceDiagonalLoading || p.Covariance.Method == CovarianceLedoitWolf)
+}
+
+var doaRules = join(
+	Rules[DOAParams]{
+		oneOf("source", func(p *DOAParams) string { return p.Source },
+			DOASourceSynthetic, DOASourceUSRP, DOASourceRecording),
+		oneOf("source_detection", func(p *DOAParams) string { return p.SourceDetection },
+			SourceDetectionAuto, SourceDetectionAIC, SourceDetectionMDL),
+		// with source detection num_sources only sets the sources of
+		// synthetic snapshots
+		Rule[DOAParams]{
+			Field: "num_sources",
+			When:  func(p *DOAParams) bool { return p.SourceDetection == "" || p.synthetic() },
+			Check: func(p *DOAParams) error {
+				if p.NumSources < 1 {
+					return violated("must be at least 1")
+				}
+				return nil
+			},
+		},
+		Rule[DOAParams]{
+			Field: "source_detection",
+			When:  func(p *DOAParams) bool { return p.SourceDetection != "" && p.Wideband != nil },
+			Check: func(p *DOAParams) error { return violated("source detection needs narrowband snapshots") },
+		},
+		atLeast("snapshot_length", 1, func(p *DOAParams) int { return p.SnapshotLength }),
+		notNegative("search_step", func(p *DOAParams) float64 { return p.SearchStep }),
+		notNegative("elevation_step", func(p *DOAParams) float64 { return p.ElevationStep }),
+		Rule[DOAParams]{
+			Field: "method",
+			When:  func(p *DOAParams) bool { return p.Method == "ESPRIT" && p.Planar() },
+			Check: func(p *DOAParams) error { return violated("ESPRIT needs a uniform linear array") },
+		},
+	},
+	when((*DOAParams).synthetic,
+		Rule[DOAParams]{Field: "element_count", Check: func(p *DOAParams) error {
+			if p.Elements() <= p.NumSources {
+				return violated("element_count %d must exceed num_sources %d", p.Elements(), p.NumSources)
+			}
+			return nil
+		}},
+		// fewer snapshots than elements give a singular sample covariance
+		Rule[DOAParams]{
+			Field: "snapshot_length",
+			When:  func(p *DOAParams) bool { return p.SnapshotLength >= 1 && !p.regularized() },
+			Check: func(p *DOAParams) error {
+				if p.SnapshotLength < p.Elements() {
+					return violated("%d snapshots for %d elements give a singular sample covariance; use more snapshots or a regularized covariance", p.SnapshotLength, p.Elements())
+				}
+				return nil
+			},
+		},
+	),
+	when(func(p *DOAParams) bool { return p.Source == DOASourceRecording },
+		required("recording", func(p *DOAParams) string { return p.Recording }),
+		notNegative("recording_offset", func(p *DOAParams) int64 { return p.RecordingOffset }),
+	),
+	when((*DOAParams).Planar,
+		Rule[DOAParams]{Field: "rows", Check: func(p *DOAParams) error {
+			if p.Rows < 1 || p.Cols < 1 {
+				return violated("rows and cols must both be set for a planar array")
+			}
+			return nil
+		}},
+		Rule[DOAParams]{Field: "element_count", Check: func(p *DOAParams) error {
+			if p.Rows >= 1 && p.Cols >= 1 && p.ElementCount != 0 && p.ElementCount != p.Rows*p.Cols {
+				return violated("element_count %d does not match the %d×%d array", p.ElementCount, p.Rows, p.Cols)
+			}
+			return nil
+		}},
+	),
+	covarianceRules(func(p *DOAParams) *CovarianceOptions { return p.Covariance }),
+	when(func(p *DOAParams) bool { return p.Smoothing != nil && p.Smoothing.SubarraySize != 0 },
+		Rule[DOAParams]{Field: "smoothing.subarray_size", Check: func(p *DOAParams) error {
+			switch size := p.Smoothing.SubarraySize; {
+			case size < 2:
+				return violated("subarray_size must be at least 2")
+			case p.Planar():
+				return &ValidationError{Field: "smoothing", Message: "spatial smoothing needs a linear array"}
+			case p.synthetic() && size > p.ElementCount:
+				return violated("subarray_size %d exceeds the %d elements", size, p.ElementCount)
+			case size <= p.NumSources:
+				return violated("subarray_size %d must exceed num_sources %d", size, p.NumSources)
+			}
+			return nil
+		}},
+	),
+	when(func(p *DOAParams) bool { return p.Wideband != nil }, widebandRules...),
+)
+
+func (p *DOAParams) incoherent() bool {
+	return p.Wideband.Method == "" || p.Wideband.Method == WidebandIncoherent
+}
+
+var widebandRules = join(
+	Rules[DOAParams]{
+		oneOf("wideband.method", func(p *DOAParams) string { return p.Wideband.Method },
+			WidebandIncoherent, WidebandCSSM),
+	},
+	// incoherent combining never forms a covariance of the whole band
+	when((*DOAParams).incoherent,
+		Rule[DOAParams]{
+			Field: "wideband.method",
+			When:  func(p *DOAParams) bool { return p.Method == "ESPRIT" },
+			Check: func(p *DOAParams) error { return violated("ESPRIT needs cssm focusing on wideband signals") },
+		},
+		Rule[DOAParams]{
+			Field: "smoothing",
+			When:  func(p *DOAParams) bool { return p.Smoothing != nil && p.Smoothing.SubarraySize != 0 },
+			Check: func(p *DOAParams) error { return violated("spatial smoothing needs cssm focusing on wideband signals") },
+		},
+	),
+	Rules[DOAParams]{
+		{
+			Field: "wideband.method",
+			When:  func(p *DOAParams) bool { return p.Wideband.Method == WidebandCSSM && p.Planar() },
+			Check: func(p *DOAParams) error { return violated("cssm focusing needs a linear array") },
+		},
+		{Field: "wideband.subbands", Check: func(p *DOAParams) error {
+			switch w := p.Wideband; {
+			case w.Subbands < 2:
+				return violated("subbands must be at least 2")
+			case p.SnapshotLength < w.Subbands:
+				return violated("%d snapshots cannot fill %d subbands", p.SnapshotLength, w.Subbands)
+			}
+			return nil
+		}},
+		{Field: "wideband.sample_rate", Check: func(p *DOAParams) error {
+			switch w := p.Wideband; {
+			case w.SampleRate < 0 || w.CenterFrequency < 0:
+				return violated("sample_rate and center_frequency must not be negative")
+			case p.synthetic() && (w.SampleRate == 0 || w.CenterFrequency == 0):
+				return violated("sample_rate and center_frequency are required for synthetic snapshots")
+			case w.CenterFrequency > 0 && w.SampleRate >= 2*w.CenterFrequency:
+				return violated("sample_rate must be less than twice center_frequency")
+			}
+			return nil
+		}},
+	},
+)
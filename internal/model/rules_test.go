@@ -0,0 +1,82 @@
+package model
+
+import "testing"
+
+func TestRules(t *testing.T) {
+	fields := func(d Diagnostics) map[string]bool {
+		out := make(map[string]bool)
+		for _, diag := range d {
+			if diag.Severity != DiagnosticError {
+				t.Errorf("violation %+v is not an error", diag)
+			}
+			out[diag.Field] = true
+		}
+		return out
+	}
+
+	doa := &DOAParams{ElementCount: 8, NumSources: 2, SnapshotLength: 4, SearchStep: -1, Source: DOASourceSynthetic}
+	got := fields(doa.Violations())
+	if len(got) != 2 || !got["snapshot_length"] || !got["search_step"] {
+		t.Errorf("violations %v, want snapshot_length and search_step", got)
+	}
+	doa.SearchStep = 0
+	doa.Covariance = &CovarianceOptions{Method: CovarianceDiagonalLoading, LoadingFactor: 0.1}
+	if v := doa.Violations(); len(v) != 0 {
+		t.Errorf("regularized covariance with 4 snapshots for 8 elements: %+v", v)
+	}
+	doa.Source = DOASourceRecording
+	doa.RecordingOffset = -1
+	if got := fields(doa.Violations()); len(got) != 2 || !got["recording"] || !got["recording_offset"] {
+		t.Errorf("recording violations %v, want recording and recording_offset", got)
+	}
+	doa = &DOAParams{ElementCount: 4, NumSources: 1, SnapshotLength: 8, Wideband: &WidebandOptions{Method: "fft", Subbands: 16}}
+	if got := fields(doa.Violations()); !got["wideband.method"] || !got["wideband.subbands"] || !got["wideband.sample_rate"] {
+		t.Errorf("wideband violations %v", got)
+	}
+
+	bf := &BeamformingParams{Mode: BeamformingModeWMMSE, Objective: BeamformingObjectiveEnergy, Users: []BeamformingUser{{Angle: 4}}}
+	if got := fields(bf.Violations()); len(got) != 3 || !got["element_count"] || !got["users[0].angle"] || !got["objective"] {
+		t.Errorf("wmmse violations %v, want element_count, users[0].angle and objective", got)
+	}
+	bf = &BeamformingParams{Mode: BeamformingModeOFDM, Objective: BeamformingObjectiveEnergy, OFDM: &OFDMParams{Paths: []OFDMPath{{Angle: 0.2, Delay: -1}}}}
+	if got := fields(bf.Violations()); len(got) != 2 || !got["element_count"] || !got["objective"] {
+		t.Errorf("ofdm violations %v, want element_count and objective", got)
+	}
+	bf.ElementCount = 4
+	if got := fields(bf.Violations()); !got["ofdm.paths[0].delay"] {
+		t.Errorf("ofdm violations %v, want ofdm.paths[0].delay", got)
+	}
+	bf = &BeamformingParams{ElementCount: 2, TargetDirection: 0.3, InterferenceAngles: []float64{0.1, -0.2}}
+	if got := fields(bf.Violations()); len(got) != 1 || !got["interference_angles"] {
+		t.Errorf("nulling violations %v, want interference_angles", got)
+	}
+	inr := 20.0
+	bf.InterferenceINR = &inr
+	if v := bf.Violations(); len(v) != 0 {
+		t.Errorf("MVDR weights with 2 interferers on 2 elements: %+v", v)
+	}
+
+	// Validate stops at the first violation
+	snr := 500.0
+	joint := &JointBeamformingParams{BSAntennas: -1, UserAngle: 4, TransmitSNR: &snr}
+	if got := fields(joint.Violations()); len(got) != 3 || !got["bs_antennas"] || !got["user_angle"] || !got["transmit_snr"] {
+		t.Errorf("joint violations %v, want bs_antennas, user_angle and transmit_snr", got)
+	}
+	if err, ok := joint.Validate().(*ValidationError); !ok || err.Field != "bs_antennas" {
+		t.Errorf("Validate() = %v, want the bs_antennas violation", err)
+	}
+	robust := &BeamformingParams{Mode: BeamformingModeRobust, InterferenceAngles: []float64{0.5}, Robust: &RobustParams{Channel: [][]float64{{1, 0}}, ErrorBound: 1}}
+	if got := fields(robust.Violations()); len(got) != 2 || !got["robust.error_bound"] || !got["interference_inr"] {
+		t.Errorf("robust violations %v, want robust.error_bound and interference_inr", got)
+	}
+
+	// the stream rule checks the chains left after the defaults
+	hybrid := &HybridBeamformingParams{RFChains: 2, Streams: 3, Method: "svd"}
+	if got := fields(hybrid.Violations()); len(got) != 2 || !got["streams"] || !got["method"] {
+		t.Errorf("hybrid violations %v, want streams and method", got)
+	}
+	impact := &IRSImpactParams{Trials: 1, Config: &IRSConfigRequest{}}
+	if got := fields(impact.Violations()); len(got) != 2 || !got["trials"] || !got["config"] {
+		t.Errorf("IRS impact violations %v, want trials and config", got)
+	}
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"math"
 	"time"
 )
 
@@ -12,6 +13,12 @@ type SensorData struct {
 	Unit       string    `json:"unit"`
 	Quality    float64   `json:"quality"`
 	Timestamp  time.Time `json:"timestamp"`
+	// RawValue is the reading before calibration, set only when the sensor
+	// has a calibration.
+	RawValue *float64 `json:"raw_value,omitempty"`
+	// Replayed marks a stored reading re-emitted by a replay; it is not
+	// stored again.
+	Replayed bool `json:"replayed,omitempty"`
 }
 
 func (SensorData) MeasurementName() string {
@@ -37,22 +44,230 @@ type SensorInfo struct {
 	MinValue   float64    `json:"min_value"`
 	MaxValue   float64    `json:"max_value"`
 	Status     int        `json:"status" gorm:"type:tinyint;default:1"`
-	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt  time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+	// Calibration is applied to every reading before it is stored.
+	Calibration *SensorCalibration `json:"calibration,omitempty" gorm:"type:json;serializer:json"`
+	// Lifecycle is where the sensor is in service; empty counts as active.
+	// Maintenance schedules the window of the maintenance state.
+	Lifecycle   SensorLifecycle    `json:"lifecycle" gorm:"type:varchar(20);default:active"`
+	Maintenance *SensorMaintenance `json:"maintenance,omitempty" gorm:"type:json;serializer:json"`
+	CreatedAt   time.Time          `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time          `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
 func (SensorInfo) TableName() string {
 	return "sensor_info"
 }
 
+type SensorLifecycle string
+
+const (
+	SensorLifecycleActive         SensorLifecycle = "active"
+	SensorLifecycleMaintenance    SensorLifecycle = "maintenance"
+	SensorLifecycleDecommissioned SensorLifecycle = "decommissioned"
+)
+
+// SensorMaintenance is a maintenance window from Start until End, or until
+// the sensor is set active again when End is unset.
+type SensorMaintenance struct {
+	Start  time.Time  `json:"start"`
+	End    *time.Time `json:"end,omitempty"`
+	Reason string     `json:"reason,omitempty"`
+}
+
+// Covers reports whether t falls in the window; a nil window has no end.
+func (m *SensorMaintenance) Covers(t time.Time) bool {
+	if m == nil {
+		return true
+	}
+	return !t.Before(m.Start) && (m.End == nil || t.Before(*m.End))
+}
+
+// LifecycleAt is the sensor's state at t. A sensor in maintenance is active
+// before its window starts and after it ends.
+func (i *SensorInfo) LifecycleAt(t time.Time) SensorLifecycle {
+	switch i.Lifecycle {
+	case SensorLifecycleDecommissioned:
+		return SensorLifecycleDecommissioned
+	case SensorLifecycleMaintenance:
+		if i.Maintenance.Covers(t) {
+			return SensorLifecycleMaintenance
+		}
+	}
+	return SensorLifecycleActive
+}
+
+// SensorLifecycleRequest moves a sensor to another lifecycle state. Start
+// and End schedule a maintenance window; Start defaults to now.
+type SensorLifecycleRequest struct {
+	Lifecycle SensorLifecycle `json:"lifecycle" binding:"required"`
+	Start     *time.Time      `json:"start"`
+	End       *time.Time      `json:"end"`
+	Reason    string          `json:"reason" binding:"max=200"`
+}
+
+func (r *SensorLifecycleRequest) Validate() error {
+	switch r.Lifecycle {
+	case SensorLifecycleMaintenance:
+	case SensorLifecycleActive, SensorLifecycleDecommissioned:
+		if r.Start != nil || r.End != nil {
+			return NewValidationError("start and end only apply to maintenance")
+		}
+		return nil
+	default:
+		return NewValidationErrorf("unsupported lifecycle: %s", r.Lifecycle)
+	}
+	now := time.Now()
+	start := now
+	if r.Start != nil {
+		start = *r.Start
+	}
+	if r.End != nil {
+		if !r.End.After(start) {
+			return NewValidationError("end must be after start")
+		}
+		if !r.End.After(now) {
+			return NewValidationError("end must be in the future")
+		}
+	}
+	return nil
+}
+
+// Window returns the maintenance window the request schedules, nil for
+// the other states.
+func (r *SensorLifecycleRequest) Window(now time.Time) *SensorMaintenance {
+	if r.Lifecycle != SensorLifecycleMaintenance {
+		return nil
+	}
+	window := &SensorMaintenance{Start: now, End: r.End, Reason: r.Reason}
+	if r.Start != nil {
+		window.Start = *r.Start
+	}
+	return window
+}
+
+// SensorCalibration maps a raw reading to a calibrated value. With
+// Polynomial set the value is c0 + c1*raw + c2*raw^2 + ..., and Offset and
+// Scale are ignored; otherwise it is raw*Scale + Offset, a zero Scale
+// counting as 1.
+type SensorCalibration struct {
+	Offset       float64    `json:"offset"`
+	Scale        float64    `json:"scale"`
+	Polynomial   []float64  `json:"polynomial,omitempty"`
+	CalibratedAt *time.Time `json:"calibrated_at,omitempty"`
+}
+
+func (c *SensorCalibration) Validate() error {
+	if len(c.Polynomial) > 8 {
+		return NewValidationError("calibration polynomial has at most 8 coefficients")
+	}
+	for _, v := range append([]float64{c.Offset, c.Scale}, c.Polynomial...) {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return NewValidationError("calibration coefficients must be finite")
+		}
+	}
+	return nil
+}
+
+func (c *SensorCalibration) Apply(raw float64) float64 {
+	if len(c.Polynomial) > 0 {
+		value := 0.0
+		for i := len(c.Polynomial) - 1; i >= 0; i-- {
+			value = value*raw + c.Polynomial[i]
+		}
+		return value
+	}
+	scale := c.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	return raw*scale + c.Offset
+}
+
+// SensorCalibrationPoint pairs a raw reading with the value a reference
+// instrument gave at the same time.
+type SensorCalibrationPoint struct {
+	Raw       float64 `json:"raw"`
+	Reference float64 `json:"reference"`
+}
+
+// SensorCalibrateRequest runs a two-point calibration. Offset and scale are
+// fitted through both points and replace any previous calibration.
+type SensorCalibrateRequest struct {
+	Points []SensorCalibrationPoint `json:"points" binding:"required,len=2"`
+}
+
+func (r *SensorCalibrateRequest) Validate() error {
+	p, q := r.Points[0], r.Points[1]
+	if p.Raw == q.Raw {
+		return NewValidationError("calibration points need different raw readings")
+	}
+	if p.Reference == q.Reference {
+		return NewValidationError("calibration points need different reference values")
+	}
+	for _, v := range []float64{p.Raw, p.Reference, q.Raw, q.Reference} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return NewValidationError("calibration points must be finite")
+		}
+	}
+	return nil
+}
+
+func (r *SensorCalibrateRequest) Calibration() *SensorCalibration {
+	p, q := r.Points[0], r.Points[1]
+	scale := (q.Reference - p.Reference) / (q.Raw - p.Raw)
+	now := time.Now()
+	return &SensorCalibration{
+		Offset:       p.Reference - scale*p.Raw,
+		Scale:        scale,
+		CalibratedAt: &now,
+	}
+}
+
+// MaxSensorWindows bounds the windows one aggregated query may return per
+// sensor.
+const MaxSensorWindows = 10000
+
+// SensorDataQuery selects readings between StartTime and EndTime, by default
+// the last hour. With Window set the readings are aggregated per sensor over
+// consecutive windows of that length instead of returned one by one.
 type SensorDataQuery struct {
-	SensorID   string    `form:"sensor_id"`
-	SensorType string    `form:"sensor_type"`
-	Location   string    `form:"location"`
-	StartTime  time.Time `form:"start_time" time_format:"2006-01-02T15:04:05"`
-	EndTime    time.Time `form:"end_time" time_format:"2006-01-02T15:04:05"`
-	Page       int       `form:"page" binding:"min=1"`
-	PageSize   int       `form:"page_size" binding:"min=1,max=100"`
+	SensorID   string        `form:"sensor_id"`
+	SensorType string        `form:"sensor_type"`
+	Location   string        `form:"location"`
+	StartTime  time.Time     `form:"start_time" time_format:"2006-01-02T15:04:05"`
+	EndTime    time.Time     `form:"end_time" time_format:"2006-01-02T15:04:05"`
+	Window     time.Duration `form:"window"`
+	Page       int           `form:"page" binding:"omitempty,min=1"`
+	PageSize   int           `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// Range fills in the default time range.
+func (q *SensorDataQuery) Range() (start, end time.Time) {
+	start, end = q.StartTime, q.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if start.IsZero() {
+		start = end.Add(-time.Hour)
+	}
+	return start, end
+}
+
+func (q *SensorDataQuery) Validate() error {
+	start, end := q.Range()
+	if !end.After(start) {
+		return NewValidationError("end_time must be after start_time")
+	}
+	if q.Window == 0 {
+		return nil
+	}
+	if q.Window < time.Second {
+		return NewValidationError("window must be at least 1s")
+	}
+	if end.Sub(start)/q.Window > MaxSensorWindows {
+		return NewValidationErrorf("a query spans at most %d windows", MaxSensorWindows)
+	}
+	return nil
 }
 
 type SensorSubscribeRequest struct {
@@ -60,11 +275,146 @@ type SensorSubscribeRequest struct {
 	Topic     string   `json:"topic" binding:"required"`
 }
 
+// SensorRequest registers a sensor, or replaces the metadata of a
+// registered one; on update the sensor ID is taken from the path.
+type SensorRequest struct {
+	SensorID   string     `json:"sensor_id" binding:"max=50"`
+	SensorType SensorType `json:"sensor_type" binding:"required"`
+	Location   string     `json:"location" binding:"max=100"`
+	Unit       string     `json:"unit" binding:"max=20"`
+	MinValue   float64    `json:"min_value"`
+	MaxValue   float64    `json:"max_value"`
+	// Calibration left out keeps the sensor's current one on update.
+	Calibration *SensorCalibration `json:"calibration"`
+}
+
+func (r *SensorRequest) Validate() error {
+	if r.SensorID == "" {
+		return NewValidationError("sensor_id is required")
+	}
+	switch r.SensorType {
+	case SensorTypeTemperature, SensorTypeHumidity, SensorTypePressure,
+		SensorTypeVoltage, SensorTypeCurrent, SensorTypePower:
+	default:
+		return NewValidationErrorf("unsupported sensor type: %s", r.SensorType)
+	}
+	if r.MinValue >= r.MaxValue {
+		return NewValidationError("min_value must be less than max_value")
+	}
+	if r.Calibration != nil {
+		return r.Calibration.Validate()
+	}
+	return nil
+}
+
+func (r *SensorRequest) Info() *SensorInfo {
+	return &SensorInfo{
+		SensorID:    r.SensorID,
+		SensorType:  r.SensorType,
+		Location:    r.Location,
+		Unit:        r.Unit,
+		MinValue:    r.MinValue,
+		MaxValue:    r.MaxValue,
+		Status:      1,
+		Calibration: r.Calibration,
+		Lifecycle:   SensorLifecycleActive,
+	}
+}
+
+type SensorHealthStatus string
+
+const (
+	SensorHealthOnline  SensorHealthStatus = "online"
+	SensorHealthOffline SensorHealthStatus = "offline"
+	// SensorHealthUnknown is a sensor not read yet and not silent for long
+	// enough to be offline.
+	SensorHealthUnknown SensorHealthStatus = "unknown"
+	// Sensors out of service are not read by the collector and never go
+	// offline.
+	SensorHealthMaintenance    SensorHealthStatus = "maintenance"
+	SensorHealthDecommissioned SensorHealthStatus = "decommissioned"
+)
+
+// SensorHealth tracks the reads of a sensor since it was registered.
+// Staleness is the seconds since its last successful read, or since it was
+// registered when it has none.
+type SensorHealth struct {
+	SensorID          string             `json:"sensor_id"`
+	Status            SensorHealthStatus `json:"status"`
+	LastSeen          *time.Time         `json:"last_seen,omitempty"`
+	Staleness         float64            `json:"staleness"`
+	ReadCount         int64              `json:"read_count"`
+	ErrorCount        int64              `json:"error_count"`
+	ConsecutiveErrors int                `json:"consecutive_errors"`
+	LastError         string             `json:"last_error,omitempty"`
+	LastErrorAt       *time.Time         `json:"last_error_at,omitempty"`
+}
+
+type SensorBatchReadRequest struct {
+	SensorIDs []string `json:"sensor_ids" binding:"required,min=1,max=100"`
+}
+
+// SensorReadResult is one entry of a batch read. Exactly one of Data and
+// Error is set.
+type SensorReadResult struct {
+	SensorID string      `json:"sensor_id"`
+	Data     *SensorData `json:"data,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// SensorAggregatedData summarizes the readings of one sensor in the window
+// starting at WindowStart. StdDev is zero for a single reading.
 type SensorAggregatedData struct {
-	SensorID   string  `json:"sensor_id"`
-	SensorType string  `json:"sensor_type"`
-	AvgValue   float64 `json:"avg_value"`
-	MinValue   float64 `json:"min_value"`
-	MaxValue   float64 `json:"max_value"`
-	Count      int     `json:"count"`
+	SensorID    string    `json:"sensor_id"`
+	SensorType  string    `json:"sensor_type"`
+	Location    string    `json:"location"`
+	WindowStart time.Time `json:"window_start"`
+	WindowEnd   time.Time `json:"window_end"`
+	AvgValue    float64   `json:"avg_value"`
+	MinValue    float64   `json:"min_value"`
+	MaxValue    float64   `json:"max_value"`
+	StdDev      float64   `json:"stddev"`
+	Count       int       `json:"count"`
+}
+
+// SensorReplayRequest re-emits the stored readings of [StartTime, EndTime)
+// through the live pipeline, optionally only those of one sensor or type.
+// Speed scales the original pacing: 1, the default, replays in real time
+// and 60 replays an hour in a minute.
+type SensorReplayRequest struct {
+	StartTime  time.Time `json:"start_time" binding:"required"`
+	EndTime    time.Time `json:"end_time" binding:"required"`
+	SensorID   string    `json:"sensor_id"`
+	SensorType string    `json:"sensor_type"`
+	Speed      float64   `json:"speed" binding:"min=0"`
+}
+
+// MaxReplaySpeed bounds the acceleration of a replay.
+const MaxReplaySpeed = 10000
+
+func (r *SensorReplayRequest) Validate() error {
+	if !r.EndTime.After(r.StartTime) {
+		return NewValidationError("end_time must be after start_time")
+	}
+	if r.Speed > MaxReplaySpeed {
+		return NewValidationErrorf("speed must not exceed %d", MaxReplaySpeed)
+	}
+	return nil
+}
+
+// SensorReplayStatus reports the progress of a replay. Position is the
+// original timestamp of the last reading emitted.
+type SensorReplayStatus struct {
+	Running    bool       `json:"running"`
+	StartTime  time.Time  `json:"start_time"`
+	EndTime    time.Time  `json:"end_time"`
+	SensorID   string     `json:"sensor_id,omitempty"`
+	SensorType string     `json:"sensor_type,omitempty"`
+	Speed      float64    `json:"speed"`
+	Total      int        `json:"total"`
+	Emitted    int        `json:"emitted"`
+	Position   *time.Time `json:"position,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Stopped    bool       `json:"stopped,omitempty"`
 }
@@ -2,6 +2,10 @@ package influxdb
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"isac-cran-system/internal/config"
@@ -59,6 +63,18 @@ func (c *Client) Close() {
 	c.client.Close()
 }
 
+// Ping checks that the server is reachable and healthy.
+func (c *Client) Ping(ctx context.Context) error {
+	health, err := c.client.Health(ctx)
+	if err != nil {
+		return errors.Wrap(errors.CodeInfluxConnectError, "influxdb unreachable", err)
+	}
+	if health.Status != "pass" {
+		return errors.NewWithDetail(errors.CodeInfluxConnectError, "influxdb health check failed", string(health.Status))
+	}
+	return nil
+}
+
 type ChannelDataRepository struct {
 	client *Client
 }
@@ -68,18 +84,21 @@ func NewChannelDataRepository(client *Client) *ChannelDataRepository {
 }
 
 func (r *ChannelDataRepository) Write(ctx context.Context, data *model.ChannelMeasurement) error {
+	amplitude, _ := json.Marshal(data.Amplitude)
+	phase, _ := json.Marshal(data.Phase)
 	p := influxdb2.NewPoint(
 		data.MeasurementName(),
 		map[string]string{
 			"experiment_id":  data.ExperimentID,
-			"user_id":        string(rune(data.UserID)),
+			"user_id":        strconv.Itoa(data.UserID),
 			"frequency_band": data.FrequencyBand,
 		},
 		map[string]interface{}{
-			"amplitude": data.Amplitude,
-			"phase":     data.Phase,
-			"snr":       data.SNR,
-			"ber":       data.BER,
+			"measurement_id": data.MeasurementID,
+			"amplitude":      string(amplitude),
+			"phase":          string(phase),
+			"snr":            data.SNR,
+			"ber":            data.BER,
 		},
 		data.Timestamp,
 	)
@@ -100,8 +119,183 @@ func (r *ChannelDataRepository) WriteBatch(ctx context.Context, dataPoints []*mo
 	return nil
 }
 
+// Query returns the page of measurements matching q, newest first.
 func (r *ChannelDataRepository) Query(ctx context.Context, q *model.ChannelDataQuery) ([]*model.ChannelMeasurement, error) {
-	return []*model.ChannelMeasurement{}, nil
+	start, end := q.Range()
+	flux := fmt.Sprintf(`from(bucket: %s)
+  |> range(start: %s, stop: %s)
+  |> filter(fn: (r) => r._measurement == %s)`,
+		fluxString(r.client.bucket), fluxTime(start), fluxTime(end), fluxString(model.ChannelMeasurement{}.MeasurementName()))
+	flux += fluxTagFilters([][2]string{{"experiment_id", q.ExperimentID}, {"frequency_band", q.FrequencyBand}})
+	if q.UserID != 0 {
+		flux += fluxTagFilters([][2]string{{"user_id", strconv.Itoa(q.UserID)}})
+	}
+	flux += fmt.Sprintf(`
+  |> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+  |> group()
+  |> sort(columns: ["_time"], desc: true)
+  |> limit(n: %d, offset: %d)`, q.PageSize, (q.Page-1)*q.PageSize)
+
+	result, err := r.client.queryAPI.Query(ctx, flux)
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeInfluxQueryError, "failed to query channel data", err)
+	}
+	defer result.Close()
+
+	data := make([]*model.ChannelMeasurement, 0, q.PageSize)
+	for result.Next() {
+		record := result.Record()
+		userID, _ := strconv.Atoi(stringValue(record.ValueByKey("user_id")))
+		data = append(data, &model.ChannelMeasurement{
+			MeasurementID: stringValue(record.ValueByKey("measurement_id")),
+			ExperimentID:  stringValue(record.ValueByKey("experiment_id")),
+			UserID:        userID,
+			FrequencyBand: stringValue(record.ValueByKey("frequency_band")),
+			Amplitude:     floatList(stringValue(record.ValueByKey("amplitude"))),
+			Phase:         floatList(stringValue(record.ValueByKey("phase"))),
+			SNR:           floatValue(record.ValueByKey("snr")),
+			BER:           floatValue(record.ValueByKey("ber")),
+			Timestamp:     record.Time(),
+		})
+	}
+	if result.Err() != nil {
+		return nil, errors.Wrap(errors.CodeInfluxQueryError, "failed to query channel data", result.Err())
+	}
+	return data, nil
+}
+
+// annotationHorizon bounds the annotation queries and deletes, which have
+// to name a stop time.
+var annotationHorizon = time.Date(2200, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ChannelAnnotationRepository keeps annotations next to the channel data,
+// one point per annotation at its start time.
+type ChannelAnnotationRepository struct {
+	client *Client
+}
+
+func NewChannelAnnotationRepository(client *Client) *ChannelAnnotationRepository {
+	return &ChannelAnnotationRepository{client: client}
+}
+
+func (r *ChannelAnnotationRepository) Create(ctx context.Context, a *model.ChannelAnnotation) error {
+	labels, _ := json.Marshal(a.Labels)
+	tags := map[string]string{"annotation_id": a.ID}
+	if a.ExperimentID != "" {
+		tags["experiment_id"] = a.ExperimentID
+	}
+	if a.MeasurementID != "" {
+		tags["measurement_id"] = a.MeasurementID
+	}
+	fields := map[string]interface{}{
+		"end_time":   a.EndTime.UnixNano(),
+		"labels":     string(labels),
+		"note":       a.Note,
+		"created_at": a.CreatedAt.UnixNano(),
+	}
+	if a.Target != nil {
+		fields["target_x"] = a.Target.X
+		fields["target_y"] = a.Target.Y
+		fields["target_z"] = a.Target.Z
+	}
+
+	p := influxdb2.NewPoint(a.MeasurementName(), tags, fields, a.StartTime)
+	if err := r.client.writeAPI.WritePoint(ctx, p); err != nil {
+		return errors.Wrap(errors.CodeInfluxWriteError, "failed to write channel annotation", err)
+	}
+	return nil
+}
+
+func (r *ChannelAnnotationRepository) Get(ctx context.Context, id string) (*model.ChannelAnnotation, error) {
+	annotations, err := r.find(ctx, [][2]string{{"annotation_id", id}})
+	if err != nil {
+		return nil, err
+	}
+	if len(annotations) == 0 {
+		return nil, errors.New(errors.CodeNotFound, "channel annotation not found")
+	}
+	return &annotations[0], nil
+}
+
+func (r *ChannelAnnotationRepository) List(ctx context.Context, q *model.ChannelAnnotationQuery) ([]model.ChannelAnnotation, error) {
+	annotations, err := r.find(ctx, [][2]string{{"experiment_id", q.ExperimentID}, {"measurement_id", q.MeasurementID}}, q.MeasurementIDs...)
+	if err != nil {
+		return nil, err
+	}
+	matched := annotations[:0]
+	for i := range annotations {
+		if q.Matches(&annotations[i]) {
+			matched = append(matched, annotations[i])
+		}
+	}
+	return matched, nil
+}
+
+func (r *ChannelAnnotationRepository) Delete(ctx context.Context, id string) error {
+	if _, err := r.Get(ctx, id); err != nil {
+		return err
+	}
+	predicate := fmt.Sprintf(`_measurement=%s AND annotation_id=%s`,
+		fluxString(model.ChannelAnnotation{}.MeasurementName()), fluxString(id))
+	err := r.client.client.DeleteAPI().DeleteWithName(ctx, r.client.org, r.client.bucket, time.Unix(0, 0), annotationHorizon, predicate)
+	if err != nil {
+		return errors.Wrap(errors.CodeInfluxWriteError, "failed to delete channel annotation", err)
+	}
+	return nil
+}
+
+// find returns the annotations matching tags and, if measurementIDs are
+// given, annotating one of those measurements.
+func (r *ChannelAnnotationRepository) find(ctx context.Context, tags [][2]string, measurementIDs ...string) ([]model.ChannelAnnotation, error) {
+	flux := fmt.Sprintf(`from(bucket: %s)
+  |> range(start: 0, stop: %s)
+  |> filter(fn: (r) => r._measurement == %s)`,
+		fluxString(r.client.bucket), fluxTime(annotationHorizon), fluxString(model.ChannelAnnotation{}.MeasurementName()))
+	flux += fluxTagFilters(tags)
+	if len(measurementIDs) > 0 {
+		set := make([]string, len(measurementIDs))
+		for i, id := range measurementIDs {
+			set[i] = fluxString(id)
+		}
+		flux += fmt.Sprintf("\n  |> filter(fn: (r) => contains(value: r.measurement_id, set: [%s]))", strings.Join(set, ", "))
+	}
+	flux += `
+  |> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+  |> group()
+  |> sort(columns: ["_time"])`
+
+	result, err := r.client.queryAPI.Query(ctx, flux)
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeInfluxQueryError, "failed to query channel annotations", err)
+	}
+	defer result.Close()
+
+	annotations := make([]model.ChannelAnnotation, 0)
+	for result.Next() {
+		record := result.Record()
+		a := model.ChannelAnnotation{
+			ID:            stringValue(record.ValueByKey("annotation_id")),
+			ExperimentID:  stringValue(record.ValueByKey("experiment_id")),
+			MeasurementID: stringValue(record.ValueByKey("measurement_id")),
+			StartTime:     record.Time(),
+			EndTime:       time.Unix(0, int64(floatValue(record.ValueByKey("end_time")))),
+			Note:          stringValue(record.ValueByKey("note")),
+			CreatedAt:     time.Unix(0, int64(floatValue(record.ValueByKey("created_at")))),
+		}
+		json.Unmarshal([]byte(stringValue(record.ValueByKey("labels"))), &a.Labels)
+		if x, ok := record.ValueByKey("target_x").(float64); ok {
+			a.Target = &model.TargetPosition{
+				X: x,
+				Y: floatValue(record.ValueByKey("target_y")),
+				Z: floatValue(record.ValueByKey("target_z")),
+			}
+		}
+		annotations = append(annotations, a)
+	}
+	if result.Err() != nil {
+		return nil, errors.Wrap(errors.CodeInfluxQueryError, "failed to query channel annotations", result.Err())
+	}
+	return annotations, nil
 }
 
 type SensorDataRepository struct {
@@ -113,6 +307,13 @@ func NewSensorDataRepository(client *Client) *SensorDataRepository {
 }
 
 func (r *SensorDataRepository) Write(ctx context.Context, data *model.SensorData) error {
+	fields := map[string]interface{}{
+		"value":   data.Value,
+		"quality": data.Quality,
+	}
+	if data.RawValue != nil {
+		fields["raw_value"] = *data.RawValue
+	}
 	p := influxdb2.NewPoint(
 		data.MeasurementName(),
 		map[string]string{
@@ -120,10 +321,7 @@ func (r *SensorDataRepository) Write(ctx context.Context, data *model.SensorData
 			"sensor_type": data.SensorType,
 			"location":    data.Location,
 		},
-		map[string]interface{}{
-			"value":   data.Value,
-			"quality": data.Quality,
-		},
+		fields,
 		data.Timestamp,
 	)
 
@@ -143,6 +341,169 @@ func (r *SensorDataRepository) WriteBatch(ctx context.Context, dataPoints []*mod
 	return nil
 }
 
+// Query returns the page of readings matching q, newest first.
 func (r *SensorDataRepository) Query(ctx context.Context, q *model.SensorDataQuery) ([]*model.SensorData, error) {
-	return []*model.SensorData{}, nil
+	flux := r.selectSensorData(q, `r._field == "value" or r._field == "quality" or r._field == "raw_value"`) + fmt.Sprintf(`
+  |> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+  |> group()
+  |> sort(columns: ["_time"], desc: true)
+  |> limit(n: %d, offset: %d)`, q.PageSize, (q.Page-1)*q.PageSize)
+	return r.querySensorData(ctx, flux, q.PageSize)
+}
+
+// QueryRange reads up to limit readings of the query's time window, oldest
+// first and unpaged, for a replay.
+func (r *SensorDataRepository) QueryRange(ctx context.Context, q *model.SensorDataQuery, limit int) ([]*model.SensorData, error) {
+	flux := r.selectSensorData(q, `r._field == "value" or r._field == "quality" or r._field == "raw_value"`) + fmt.Sprintf(`
+  |> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+  |> group()
+  |> sort(columns: ["_time"])
+  |> limit(n: %d)`, limit)
+	return r.querySensorData(ctx, flux, 0)
+}
+
+func (r *SensorDataRepository) querySensorData(ctx context.Context, flux string, capacity int) ([]*model.SensorData, error) {
+	result, err := r.client.queryAPI.Query(ctx, flux)
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeInfluxQueryError, "failed to query sensor data", err)
+	}
+	defer result.Close()
+
+	data := make([]*model.SensorData, 0, capacity)
+	for result.Next() {
+		record := result.Record()
+		d := &model.SensorData{
+			SensorID:   stringValue(record.ValueByKey("sensor_id")),
+			SensorType: stringValue(record.ValueByKey("sensor_type")),
+			Location:   stringValue(record.ValueByKey("location")),
+			Value:      floatValue(record.ValueByKey("value")),
+			Quality:    floatValue(record.ValueByKey("quality")),
+			Timestamp:  record.Time(),
+		}
+		if raw, ok := record.ValueByKey("raw_value").(float64); ok {
+			d.RawValue = &raw
+		}
+		data = append(data, d)
+	}
+	if result.Err() != nil {
+		return nil, errors.Wrap(errors.CodeInfluxQueryError, "failed to query sensor data", result.Err())
+	}
+	return data, nil
+}
+
+// Aggregate computes the mean, minimum, maximum, standard deviation and count
+// of each sensor's values over windows of q.Window, so that InfluxDB rather
+// than the caller reduces the raw points. Windows without readings are left
+// out.
+func (r *SensorDataRepository) Aggregate(ctx context.Context, q *model.SensorDataQuery) ([]*model.SensorAggregatedData, error) {
+	every := fmt.Sprintf("%dms", q.Window.Milliseconds())
+	flux := "data = " + r.selectSensorData(q, `r._field == "value"`) + `
+  |> group(columns: ["sensor_id", "sensor_type", "location"])
+`
+	stats := make([]string, 0, 5)
+	for _, fn := range []string{"mean", "min", "max", "stddev", "count"} {
+		stats = append(stats, fmt.Sprintf(`data
+  |> aggregateWindow(every: %s, fn: %s, timeSrc: "_start", createEmpty: false)
+  |> toFloat()
+  |> set(key: "_field", value: "%s")`, every, fn, fn))
+	}
+	flux += "union(tables: [\n" + strings.Join(stats, ",\n") + `
+])
+  |> group(columns: ["sensor_id", "sensor_type", "location"])
+  |> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+  |> group()
+  |> sort(columns: ["sensor_id", "_time"])`
+
+	result, err := r.client.queryAPI.Query(ctx, flux)
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeInfluxQueryError, "failed to aggregate sensor data", err)
+	}
+	defer result.Close()
+
+	aggregates := make([]*model.SensorAggregatedData, 0)
+	for result.Next() {
+		record := result.Record()
+		aggregates = append(aggregates, &model.SensorAggregatedData{
+			SensorID:    stringValue(record.ValueByKey("sensor_id")),
+			SensorType:  stringValue(record.ValueByKey("sensor_type")),
+			Location:    stringValue(record.ValueByKey("location")),
+			WindowStart: record.Time(),
+			WindowEnd:   record.Time().Add(q.Window),
+			AvgValue:    floatValue(record.ValueByKey("mean")),
+			MinValue:    floatValue(record.ValueByKey("min")),
+			MaxValue:    floatValue(record.ValueByKey("max")),
+			StdDev:      floatValue(record.ValueByKey("stddev")),
+			Count:       int(floatValue(record.ValueByKey("count"))),
+		})
+	}
+	if result.Err() != nil {
+		return nil, errors.Wrap(errors.CodeInfluxQueryError, "failed to aggregate sensor data", result.Err())
+	}
+	return aggregates, nil
+}
+
+// selectSensorData is the Flux source for the readings q selects, narrowed
+// to the fields matching fieldFilter.
+func (r *SensorDataRepository) selectSensorData(q *model.SensorDataQuery, fieldFilter string) string {
+	start, end := q.Range()
+	flux := fmt.Sprintf(`from(bucket: %s)
+  |> range(start: %s, stop: %s)
+  |> filter(fn: (r) => r._measurement == %s)
+  |> filter(fn: (r) => %s)`,
+		fluxString(r.client.bucket), fluxTime(start), fluxTime(end),
+		fluxString(model.SensorData{}.MeasurementName()), fieldFilter)
+	return flux + fluxTagFilters([][2]string{{"sensor_id", q.SensorID}, {"sensor_type", q.SensorType}, {"location", q.Location}})
+}
+
+// fluxTagFilters filters on each tag with a non-empty value.
+func fluxTagFilters(tags [][2]string) string {
+	var flux string
+	for _, tag := range tags {
+		if tag[1] != "" {
+			flux += fmt.Sprintf("\n  |> filter(fn: (r) => r.%s == %s)", tag[0], fluxString(tag[1]))
+		}
+	}
+	return flux
+}
+
+func fluxTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// fluxString quotes s as a Flux string literal.
+func fluxString(s string) string {
+	return `"` + fluxEscaper.Replace(s) + `"`
+}
+
+var fluxEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `${`, `\${`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// floatList parses a list of numbers written as JSON, or by older versions
+// as Go's %v formatting.
+func floatList(s string) []float64 {
+	fields := strings.FieldsFunc(strings.Trim(s, "[]"), func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	values := make([]float64, 0, len(fields))
+	for _, f := range fields {
+		if v, err := strconv.ParseFloat(f, 64); err == nil {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// floatValue reads a numeric column, which is nil where Flux had no value.
+func floatValue(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int64:
+		return float64(n)
+	}
+	return 0
 }
@@ -0,0 +1,62 @@
+package influxdb
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/model"
+)
+
+func TestFluxString(t *testing.T) {
+	cases := map[string]string{
+		`temp-001`:        `"temp-001"`,
+		`a"b`:             `"a\"b"`,
+		`c:\dir`:          `"c:\\dir"`,
+		"${x}\n":          `"\${x}\n"`,
+		`") |> drop() //`: `"\") |> drop() //"`,
+	}
+	for in, want := range cases {
+		if got := fluxString(in); got != want {
+			t.Errorf("fluxString(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestSelectSensorData(t *testing.T) {
+	repo := &SensorDataRepository{client: &Client{bucket: "isac"}}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	flux := repo.selectSensorData(&model.SensorDataQuery{
+		SensorID:  `temp"001`,
+		Location:  "Room-A",
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+	}, `r._field == "value"`)
+
+	for _, want := range []string{
+		`from(bucket: "isac")`,
+		`range(start: 2025-01-01T00:00:00Z, stop: 2025-01-01T01:00:00Z)`,
+		`r._measurement == "sensor_data"`,
+		`r.sensor_id == "temp\"001"`,
+		`r.location == "Room-A"`,
+	} {
+		if !strings.Contains(flux, want) {
+			t.Errorf("Expected %s in\n%s", want, flux)
+		}
+	}
+	if strings.Contains(flux, "sensor_type") {
+		t.Errorf("Expected no filter on an empty tag in\n%s", flux)
+	}
+}
+
+func TestFloatList(t *testing.T) {
+	for _, in := range []string{"[0.5,1,-2e-3]", "[0.5 1 -2e-3]"} {
+		got := floatList(in)
+		if len(got) != 3 || got[0] != 0.5 || got[1] != 1 || got[2] != -2e-3 {
+			t.Errorf("floatList(%q) = %v", in, got)
+		}
+	}
+	if got := floatList("[]"); len(got) != 0 {
+		t.Errorf("Expected an empty list, got %v", got)
+	}
+}
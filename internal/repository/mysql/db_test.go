@@ -0,0 +1,211 @@
+package mysql
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/internal/service"
+	"isac-cran-system/pkg/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	sqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newMockDB(t *testing.T) (*DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		&gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &DB{DB: gdb, replicas: &replicaSet{}, batchSize: DefaultBatchSize}, mock
+}
+
+func q(sql string) string {
+	return regexp.QuoteMeta(sql)
+}
+
+func testReservationRequest() *model.ReservationRequest {
+	return &model.ReservationRequest{
+		Device:    model.ReservableDeviceIRS,
+		StartTime: time.Now(),
+		EndTime:   time.Now().Add(time.Hour),
+	}
+}
+
+func newReservationService(db *DB) *service.ReservationService {
+	svc := service.NewReservationService(NewReservationRepository(db))
+	svc.SetUnitOfWork(db)
+	svc.SetAuditStore(NewAuditRepository(db))
+	return svc
+}
+
+func TestReservationCreateLocksDevice(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(q("SELECT * FROM `device_lock` WHERE device = ? FOR UPDATE")).
+		WithArgs(model.ReservableDeviceIRS).
+		WillReturnRows(sqlmock.NewRows([]string{"device"}).AddRow(model.ReservableDeviceIRS))
+	mock.ExpectQuery(q("SELECT * FROM `device_reservation` WHERE device = ? AND start_time < ? AND end_time > ?")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec(q("INSERT INTO `device_reservation`")).WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectExec(q("INSERT INTO `audit_log`")).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	r, err := newReservationService(db).Create(service.WithHolder(context.Background(), "alice"), testReservationRequest())
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if r.ID != 7 {
+		t.Errorf("reservation id = %d, want 7", r.ID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReservationCreateOverlapRollsBack(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(q("SELECT * FROM `device_lock`")).
+		WillReturnRows(sqlmock.NewRows([]string{"device"}).AddRow(model.ReservableDeviceIRS))
+	mock.ExpectQuery(q("SELECT * FROM `device_reservation`")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "device", "holder", "end_time"}).
+			AddRow(3, model.ReservableDeviceIRS, "bob", time.Now().Add(time.Hour)))
+	mock.ExpectRollback()
+
+	_, err := newReservationService(db).Create(service.WithHolder(context.Background(), "alice"), testReservationRequest())
+	if !errors.IsCode(err, errors.CodeDeviceReserved) {
+		t.Fatalf("Create error = %v, want CodeDeviceReserved", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReservationCreateCreatesMissingLock(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(q("SELECT * FROM `device_lock`")).WillReturnRows(sqlmock.NewRows([]string{"device"}))
+	mock.ExpectExec(q("INSERT INTO `device_lock`")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(q("SELECT * FROM `device_lock`")).
+		WillReturnRows(sqlmock.NewRows([]string{"device"}).AddRow(model.ReservableDeviceIRS))
+	mock.ExpectQuery(q("SELECT * FROM `device_reservation`")).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	err := db.Transaction(context.Background(), func(ctx context.Context) error {
+		_, err := NewReservationRepository(db).ListOverlapping(ctx, model.ReservableDeviceIRS, time.Now(), time.Now().Add(time.Hour))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("ListOverlapping: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReservationCreateDeadlock(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(q("SELECT * FROM `device_lock`")).
+		WillReturnRows(sqlmock.NewRows([]string{"device"}).AddRow(model.ReservableDeviceIRS))
+	mock.ExpectQuery(q("SELECT * FROM `device_reservation`")).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec(q("INSERT INTO `device_reservation`")).
+		WillReturnError(&sqldriver.MySQLError{Number: errLockDeadlock, Message: "Deadlock found when trying to get lock"})
+	mock.ExpectRollback()
+
+	_, err := newReservationService(db).Create(service.WithHolder(context.Background(), "alice"), testReservationRequest())
+	if !errors.IsCode(err, errors.CodeDeviceReserved) {
+		t.Fatalf("Create error = %v, want CodeDeviceReserved", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReservationAuditFailureRollsBack(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(q("SELECT * FROM `device_lock`")).
+		WillReturnRows(sqlmock.NewRows([]string{"device"}).AddRow(model.ReservableDeviceIRS))
+	mock.ExpectQuery(q("SELECT * FROM `device_reservation`")).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec(q("INSERT INTO `device_reservation`")).WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectExec(q("INSERT INTO `audit_log`")).WillReturnError(&sqldriver.MySQLError{Number: 1146, Message: "Table doesn't exist"})
+	mock.ExpectRollback()
+
+	_, err := newReservationService(db).Create(service.WithHolder(context.Background(), "alice"), testReservationRequest())
+	if !errors.IsCode(err, errors.CodeDBInsertError) {
+		t.Fatalf("Create error = %v, want CodeDBInsertError", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExperimentUpdateStatusVersion(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewExperimentRepository(db)
+	result := &model.ExperimentResult{ID: 5, ExperimentID: "exp_1", Version: 2}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(q("UPDATE `experiment_result` SET `status`=?,`version`=version + 1 WHERE id = ? AND version = ?")).
+		WithArgs(model.ExperimentStatusRunning, int64(5), int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.UpdateStatus(context.Background(), result, model.ExperimentStatusRunning, ""); err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+	if result.Version != 3 || result.Status != model.ExperimentStatusRunning {
+		t.Errorf("result at version %d with status %d, want version 3 running", result.Version, result.Status)
+	}
+
+	// A stale version matches no row; the row still exists, so it is a
+	// conflict rather than a missing result.
+	mock.ExpectBegin()
+	mock.ExpectExec(q("UPDATE `experiment_result`")).
+		WithArgs(sqlmock.AnyArg(), model.ExperimentStatusFailed, int64(5), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectQuery(q("SELECT count(*) FROM `experiment_result` WHERE id = ?")).
+		WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	err := repo.UpdateStatus(context.Background(), result, model.ExperimentStatusFailed, "")
+	if !errors.IsCode(err, errors.CodeVersionConflict) {
+		t.Fatalf("stale UpdateStatus error = %v, want CodeVersionConflict", err)
+	}
+	if result.Version != 3 || result.Status != model.ExperimentStatusRunning {
+		t.Errorf("failed update changed the result to version %d status %d", result.Version, result.Status)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(q("UPDATE `experiment_result`")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectQuery(q("SELECT count(*) FROM `experiment_result` WHERE id = ?")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	err = repo.UpdateEnergyReport(context.Background(), result, "{}")
+	if !errors.IsCode(err, errors.CodeNotFound) {
+		t.Fatalf("UpdateEnergyReport on a deleted row error = %v, want CodeNotFound", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
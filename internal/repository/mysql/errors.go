@@ -0,0 +1,19 @@
+package mysql
+
+import (
+	stderrors "errors"
+
+	sqldriver "github.com/go-sql-driver/mysql"
+)
+
+// MySQL server error numbers handled by the repositories.
+const (
+	errDuplicateEntry = 1062
+	errLockDeadlock   = 1213
+)
+
+// isMySQLError reports whether err is the server error number.
+func isMySQLError(err error, number uint16) bool {
+	var mysqlErr *sqldriver.MySQLError
+	return stderrors.As(err, &mysqlErr) && mysqlErr.Number == number
+}
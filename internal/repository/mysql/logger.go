@@ -0,0 +1,85 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const DefaultSlowQueryThreshold = 200 * time.Millisecond
+
+// queryLogger sends GORM's logs to the application logger. Failed queries
+// and queries slower than slowThreshold are logged as warnings with the ID of
+// the request that issued them; every other query is logged at debug level.
+type queryLogger struct {
+	level         gormlogger.LogLevel
+	slowThreshold time.Duration
+}
+
+func newQueryLogger(slowThreshold time.Duration) *queryLogger {
+	if slowThreshold <= 0 {
+		slowThreshold = DefaultSlowQueryThreshold
+	}
+	return &queryLogger{level: gormlogger.Info, slowThreshold: slowThreshold}
+}
+
+func (l *queryLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	next := *l
+	next.level = level
+	return &next
+}
+
+func (l *queryLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Info {
+		logger.Info(fmt.Sprintf(msg, args...), requestField(ctx))
+	}
+}
+
+func (l *queryLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		logger.Warn(fmt.Sprintf(msg, args...), requestField(ctx))
+	}
+}
+
+func (l *queryLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Error {
+		logger.Error(fmt.Sprintf(msg, args...), requestField(ctx))
+	}
+}
+
+func (l *queryLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && err != gorm.ErrRecordNotFound && l.level >= gormlogger.Error:
+		sql, rows := fc()
+		logger.Warn("MySQL query failed", queryFields(ctx, sql, rows, elapsed, zap.Error(err))...)
+	case elapsed > l.slowThreshold && l.level >= gormlogger.Warn:
+		sql, rows := fc()
+		logger.Warn("Slow MySQL query", queryFields(ctx, sql, rows, elapsed, zap.Duration("threshold", l.slowThreshold))...)
+	case l.level >= gormlogger.Info:
+		sql, rows := fc()
+		logger.Debug("MySQL query", queryFields(ctx, sql, rows, elapsed)...)
+	}
+}
+
+func requestField(ctx context.Context) zap.Field {
+	return zap.String("request_id", logger.RequestIDFromContext(ctx))
+}
+
+func queryFields(ctx context.Context, sql string, rows int64, elapsed time.Duration, extra ...zap.Field) []zap.Field {
+	return append([]zap.Field{
+		requestField(ctx),
+		zap.String("sql", sql),
+		zap.Int64("rows", rows),
+		zap.Duration("elapsed", elapsed),
+	}, extra...)
+}
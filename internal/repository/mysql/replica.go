@@ -0,0 +1,173 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"isac-cran-system/internal/config"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultMaxReplicaLag        = 5 * time.Second
+	DefaultReplicaCheckInterval = 10 * time.Second
+)
+
+type replica struct {
+	db *gorm.DB
+
+	mu     sync.RWMutex
+	status model.ReplicaStatus
+}
+
+func (r *replica) healthy() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.status.Healthy
+}
+
+type replicaSet struct {
+	replicas []*replica
+	maxLag   time.Duration
+	interval time.Duration
+	next     atomic.Uint32
+}
+
+// openReplicas connects lazily so that a replica that is down at startup
+// only stays out of rotation until a health check finds it caught up.
+func openReplicas(cfg *config.MySQLConfig, gormConfig *gorm.Config) (*replicaSet, error) {
+	set := &replicaSet{maxLag: cfg.MaxReplicaLag, interval: cfg.ReplicaCheckInterval}
+	if set.maxLag <= 0 {
+		set.maxLag = DefaultMaxReplicaLag
+	}
+	if set.interval <= 0 {
+		set.interval = DefaultReplicaCheckInterval
+	}
+
+	for _, rc := range cfg.Replicas {
+		conf := *gormConfig
+		conf.DisableAutomaticPing = true
+		db, err := open(cfg.ReplicaDSN(rc), cfg, &conf)
+		if err != nil {
+			set.close()
+			return nil, err
+		}
+		set.replicas = append(set.replicas, &replica{
+			db:     db,
+			status: model.ReplicaStatus{Address: fmt.Sprintf("%s:%d", rc.Host, rc.Port), Error: "not checked yet"},
+		})
+	}
+	return set, nil
+}
+
+// pick returns the next healthy replica in round-robin order, or nil.
+func (s *replicaSet) pick() *replica {
+	n := len(s.replicas)
+	if n == 0 {
+		return nil
+	}
+	start := int(s.next.Add(1))
+	for i := 0; i < n; i++ {
+		if r := s.replicas[(start+i)%n]; r.healthy() {
+			return r
+		}
+	}
+	return nil
+}
+
+func (s *replicaSet) check(ctx context.Context) {
+	for _, r := range s.replicas {
+		checkCtx, cancel := context.WithTimeout(ctx, s.interval)
+		lag, err := replicationLag(checkCtx, r.db)
+		cancel()
+
+		status := model.ReplicaStatus{Lag: lag.Seconds(), CheckedAt: time.Now()}
+		switch {
+		case err != nil:
+			status.Error = err.Error()
+		case lag > s.maxLag:
+			status.Error = fmt.Sprintf("replica is %s behind the primary", lag)
+		default:
+			status.Healthy = true
+		}
+
+		r.mu.Lock()
+		status.Address = r.status.Address
+		r.status = status
+		r.mu.Unlock()
+	}
+}
+
+func (s *replicaSet) statuses() []model.ReplicaStatus {
+	out := make([]model.ReplicaStatus, len(s.replicas))
+	for i, r := range s.replicas {
+		r.mu.RLock()
+		out[i] = r.status
+		r.mu.RUnlock()
+	}
+	return out
+}
+
+func (s *replicaSet) close() {
+	for _, r := range s.replicas {
+		if sqlDB, err := r.db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+}
+
+// replicationLag reads how far the server is behind its source. A server
+// that is not replicating, or whose replication threads stopped, is an error.
+func replicationLag(ctx context.Context, db *gorm.DB) (time.Duration, error) {
+	rows, err := db.WithContext(ctx).Raw("SHOW REPLICA STATUS").Rows()
+	if err != nil {
+		// servers before MySQL 8.0.22
+		rows, err = db.WithContext(ctx).Raw("SHOW SLAVE STATUS").Rows()
+		if err != nil {
+			return 0, errors.Wrap(errors.CodeDBQueryError, "failed to read replica status", err)
+		}
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, errors.Wrap(errors.CodeDBQueryError, "failed to read replica status", err)
+		}
+		return 0, errors.New(errors.CodeDBQueryError, "server is not a replica")
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, errors.Wrap(errors.CodeDBQueryError, "failed to read replica status", err)
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return 0, errors.Wrap(errors.CodeDBQueryError, "failed to read replica status", err)
+	}
+
+	for i, column := range columns {
+		if column != "Seconds_Behind_Source" && column != "Seconds_Behind_Master" {
+			continue
+		}
+		if !values[i].Valid {
+			return 0, errors.New(errors.CodeDBQueryError, "replication is not running")
+		}
+		seconds, err := strconv.ParseInt(values[i].String, 10, 64)
+		if err != nil {
+			return 0, errors.Wrap(errors.CodeDBQueryError, "invalid replication lag", err)
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return 0, errors.New(errors.CodeDBQueryError, "replica status has no lag column")
+}
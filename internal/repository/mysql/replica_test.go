@@ -0,0 +1,32 @@
+package mysql
+
+import (
+	"testing"
+
+	"isac-cran-system/internal/model"
+)
+
+func TestReplicaSet_Pick(t *testing.T) {
+	set := &replicaSet{}
+	if r := set.pick(); r != nil {
+		t.Fatalf("pick() without replicas = %v, want nil", r)
+	}
+
+	a := &replica{status: model.ReplicaStatus{Address: "a", Healthy: true}}
+	b := &replica{status: model.ReplicaStatus{Address: "b", Healthy: false}}
+	c := &replica{status: model.ReplicaStatus{Address: "c", Healthy: true}}
+	set.replicas = []*replica{a, b, c}
+
+	seen := map[*replica]int{}
+	for i := 0; i < 6; i++ {
+		seen[set.pick()]++
+	}
+	if seen[b] != 0 || seen[a] == 0 || seen[c] == 0 {
+		t.Errorf("picks = a:%d b:%d c:%d, want a and c in rotation and b skipped", seen[a], seen[b], seen[c])
+	}
+
+	a.status.Healthy, c.status.Healthy = false, false
+	if r := set.pick(); r != nil {
+		t.Errorf("pick() with no healthy replica = %s, want nil", r.status.Address)
+	}
+}
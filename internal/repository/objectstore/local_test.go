@@ -0,0 +1,120 @@
+package objectstore
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocalStore(t *testing.T) *LocalStore {
+	t.Helper()
+	s, err := NewLocalStore(t.TempDir(), "http://localhost:8080/api/v1/objects/", "test-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestNewLocalStoreRequiresSigningKey(t *testing.T) {
+	if _, err := NewLocalStore(t.TempDir(), "", ""); err == nil {
+		t.Error("Expected an empty signing key to be rejected")
+	}
+}
+
+func TestLocalStoreRejectsTraversal(t *testing.T) {
+	s := newTestLocalStore(t)
+	outside := filepath.Join(filepath.Dir(s.rootDir), "outside")
+	ctx := context.Background()
+
+	for _, key := range []string{"", "/", "../outside", "exports/../../outside", "a/..", "..\\outside"} {
+		if err := s.Put(ctx, key, strings.NewReader("x"), 1); err != ErrInvalidKey {
+			t.Errorf("Put(%q) = %v, want ErrInvalidKey", key, err)
+		}
+		if _, err := s.PresignGet(ctx, key, time.Minute); err != ErrInvalidKey {
+			t.Errorf("PresignGet(%q) = %v, want ErrInvalidKey", key, err)
+		}
+	}
+	if _, err := os.Stat(outside); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing written outside the root, got %v", err)
+	}
+
+	// A leading slash stays inside the root.
+	if err := s.Put(ctx, "/exports/a.json", strings.NewReader("{}"), 2); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(s.rootDir, "exports", "a.json")); err != nil {
+		t.Errorf("Expected the object under the root: %v", err)
+	}
+}
+
+func TestLocalStoreRoundTrip(t *testing.T) {
+	s := newTestLocalStore(t)
+	ctx := context.Background()
+
+	if err := s.Put(ctx, "iq/capture.bin", strings.NewReader("0123456789"), 10); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	rc, err := s.GetRange(ctx, "iq/capture.bin", 4)
+	if err != nil {
+		t.Fatalf("GetRange failed: %v", err)
+	}
+	body, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(body) != "456789" {
+		t.Errorf("GetRange = %q, want 456789", body)
+	}
+
+	objects, err := s.List(ctx)
+	if err != nil || len(objects) != 1 || objects[0].Key != "iq/capture.bin" || objects[0].Size != 10 {
+		t.Errorf("List = %v, %v", objects, err)
+	}
+	if err := s.Delete(ctx, "iq/capture.bin"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := s.Get(ctx, "iq/capture.bin"); err != ErrObjectNotFound {
+		t.Errorf("Expected ErrObjectNotFound, got %v", err)
+	}
+}
+
+func TestLocalStorePresign(t *testing.T) {
+	s := newTestLocalStore(t)
+	ctx := context.Background()
+
+	link, err := s.PresignGet(ctx, "exports/exp-1.json", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Path != "/api/v1/objects/exports/exp-1.json" {
+		t.Errorf("Unexpected presigned path %s", u.Path)
+	}
+	expires, signature := u.Query().Get("expires"), u.Query().Get("signature")
+	if !s.VerifyPresigned("exports/exp-1.json", expires, signature) {
+		t.Error("Expected the presigned URL to verify")
+	}
+	if s.VerifyPresigned("exports/exp-2.json", expires, signature) {
+		t.Error("Expected the signature not to verify for another key")
+	}
+	if s.VerifyPresigned("exports/exp-1.json", expires+"0", signature) {
+		t.Error("Expected the signature not to verify for another expiry")
+	}
+
+	other, _ := NewLocalStore(t.TempDir(), "", "other-key")
+	if other.VerifyPresigned("exports/exp-1.json", expires, signature) {
+		t.Error("Expected the signature not to verify under another key")
+	}
+
+	expired := time.Now().Add(-time.Second).Unix()
+	if s.VerifyPresigned("exports/exp-1.json", strconv.FormatInt(expired, 10), s.sign("exports/exp-1.json", expired)) {
+		t.Error("Expected an expired URL to be rejected")
+	}
+}
@@ -0,0 +1,54 @@
+package objectstore
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+const (
+	PrefixMATLAB     = "matlab/"
+	PrefixIQCapture  = "iq/"
+	PrefixDataset    = "datasets/"
+	PrefixCheckpoint = "checkpoints/"
+	PrefixPlot       = "plots/"
+	PrefixExport     = "exports/"
+	PrefixReport     = "reports/"
+)
+
+type Backend string
+
+const (
+	BackendLocal Backend = "local"
+	BackendS3    Backend = "s3"
+)
+
+type ObjectInfo struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+	Backend      Backend   `json:"backend"`
+}
+
+type Store interface {
+	Backend() Backend
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	GetRange(ctx context.Context, key string, offset int64) (io.ReadCloser, error)
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	Delete(ctx context.Context, key string) error
+	PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+var (
+	ErrObjectNotFound = &StoreError{Message: "object not found"}
+	ErrInvalidKey     = &StoreError{Message: "invalid object key"}
+)
+
+type StoreError struct {
+	Message string
+}
+
+func (e *StoreError) Error() string {
+	return e.Message
+}
@@ -0,0 +1,155 @@
+package objectstore
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// TieredStore writes new objects to the hot local tier and moves them to the
+// cold S3/MinIO tier once they are older than tierAfter. Reads fall through
+// from hot to cold. A nil cold tier disables migration.
+type TieredStore struct {
+	hot       *LocalStore
+	cold      Store
+	tierAfter time.Duration
+	onMigrate func(ctx context.Context, key string, backend Backend)
+}
+
+func NewTieredStore(hot *LocalStore, cold Store, tierAfter time.Duration) *TieredStore {
+	return &TieredStore{
+		hot:       hot,
+		cold:      cold,
+		tierAfter: tierAfter,
+	}
+}
+
+func (s *TieredStore) Hot() *LocalStore {
+	return s.hot
+}
+
+// SetMigrationHook registers a callback invoked after an object has been
+// moved to the cold tier.
+func (s *TieredStore) SetMigrationHook(hook func(ctx context.Context, key string, backend Backend)) {
+	s.onMigrate = hook
+}
+
+func (s *TieredStore) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	return s.hot.Put(ctx, key, r, size)
+}
+
+func (s *TieredStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	rc, err := s.hot.Get(ctx, key)
+	if err != ErrObjectNotFound || s.cold == nil {
+		return rc, err
+	}
+	return s.cold.Get(ctx, key)
+}
+
+func (s *TieredStore) GetRange(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	rc, err := s.hot.GetRange(ctx, key, offset)
+	if err != ErrObjectNotFound || s.cold == nil {
+		return rc, err
+	}
+	return s.cold.GetRange(ctx, key, offset)
+}
+
+func (s *TieredStore) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	info, err := s.hot.Stat(ctx, key)
+	if err != ErrObjectNotFound || s.cold == nil {
+		return info, err
+	}
+	return s.cold.Stat(ctx, key)
+}
+
+func (s *TieredStore) Delete(ctx context.Context, key string) error {
+	if err := s.hot.Delete(ctx, key); err != nil {
+		return err
+	}
+	if s.cold == nil {
+		return nil
+	}
+	return s.cold.Delete(ctx, key)
+}
+
+func (s *TieredStore) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if _, err := s.hot.Stat(ctx, key); err == nil || s.cold == nil {
+		return s.hot.PresignGet(ctx, key, expiry)
+	}
+	return s.cold.PresignGet(ctx, key, expiry)
+}
+
+// Migrate moves every hot object older than tierAfter to the cold tier and
+// returns the number of objects moved.
+func (s *TieredStore) Migrate(ctx context.Context) (int, error) {
+	if s.cold == nil || s.tierAfter <= 0 {
+		return 0, nil
+	}
+
+	objects, err := s.hot.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-s.tierAfter)
+	moved := 0
+	for _, obj := range objects {
+		if obj.LastModified.After(cutoff) {
+			continue
+		}
+		if err := s.migrateObject(ctx, obj); err != nil {
+			logger.Warn("Failed to migrate object to cold tier",
+				zap.String("key", obj.Key),
+				zap.Error(err),
+			)
+			continue
+		}
+		moved++
+	}
+
+	return moved, nil
+}
+
+func (s *TieredStore) migrateObject(ctx context.Context, obj *ObjectInfo) error {
+	rc, err := s.hot.Get(ctx, obj.Key)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if err := s.cold.Put(ctx, obj.Key, rc, obj.Size); err != nil {
+		return err
+	}
+	if err := s.hot.Delete(ctx, obj.Key); err != nil {
+		return err
+	}
+	if s.onMigrate != nil {
+		s.onMigrate(ctx, obj.Key, s.cold.Backend())
+	}
+	return nil
+}
+
+func (s *TieredStore) StartTiering(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			moved, err := s.Migrate(ctx)
+			if err != nil {
+				logger.Warn("Object tiering failed", zap.Error(err))
+				continue
+			}
+			if moved > 0 {
+				logger.Info("Objects moved to cold tier", zap.Int("count", moved))
+			}
+		}
+	}
+}
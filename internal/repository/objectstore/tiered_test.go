@@ -0,0 +1,78 @@
+package objectstore
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTieredStoreMigrate(t *testing.T) {
+	hot := newTestLocalStore(t)
+	cold := newTestLocalStore(t)
+	s := NewTieredStore(hot, cold, time.Hour)
+	var migrated []string
+	s.SetMigrationHook(func(ctx context.Context, key string, backend Backend) {
+		migrated = append(migrated, key)
+	})
+	ctx := context.Background()
+
+	for _, key := range []string{"exports/old.json", "exports/new.json"} {
+		if err := s.Put(ctx, key, strings.NewReader(key), int64(len(key))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(filepath.Join(hot.rootDir, "exports", "old.json"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	moved, err := s.Migrate(ctx)
+	if err != nil || moved != 1 {
+		t.Fatalf("Migrate = %d, %v, want 1 object moved", moved, err)
+	}
+	if len(migrated) != 1 || migrated[0] != "exports/old.json" {
+		t.Errorf("Expected the hook for exports/old.json, got %v", migrated)
+	}
+	if _, err := hot.Stat(ctx, "exports/old.json"); err != ErrObjectNotFound {
+		t.Errorf("Expected the old object to leave the hot tier, got %v", err)
+	}
+	if _, err := cold.Stat(ctx, "exports/new.json"); err != ErrObjectNotFound {
+		t.Errorf("Expected the new object to stay hot, got %v", err)
+	}
+
+	rc, err := s.Get(ctx, "exports/old.json")
+	if err != nil {
+		t.Fatalf("Expected reads to fall through to the cold tier: %v", err)
+	}
+	body, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(body) != "exports/old.json" {
+		t.Errorf("Get = %q", body)
+	}
+
+	if err := s.Delete(ctx, "exports/old.json"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Stat(ctx, "exports/old.json"); err != ErrObjectNotFound {
+		t.Errorf("Expected the object to be deleted from both tiers, got %v", err)
+	}
+}
+
+func TestTieredStoreWithoutColdTier(t *testing.T) {
+	hot := newTestLocalStore(t)
+	s := NewTieredStore(hot, nil, time.Nanosecond)
+	ctx := context.Background()
+	if err := s.Put(ctx, "exports/a.json", strings.NewReader("{}"), 2); err != nil {
+		t.Fatal(err)
+	}
+	if moved, err := s.Migrate(ctx); moved != 0 || err != nil {
+		t.Errorf("Migrate = %d, %v, want nothing moved", moved, err)
+	}
+	if _, err := s.Get(ctx, "exports/missing.json"); err != ErrObjectNotFound {
+		t.Errorf("Expected ErrObjectNotFound, got %v", err)
+	}
+}
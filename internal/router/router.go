@@ -3,6 +3,7 @@ package router
 import (
 	"isac-cran-system/internal/handler"
 	"isac-cran-system/internal/middleware"
+	"isac-cran-system/pkg/response"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,7 +13,16 @@ func Setup(
 	channelHandler *handler.ChannelHandler,
 	algorithmHandler *handler.AlgorithmHandler,
 	sensorHandler *handler.SensorHandler,
+	powerHandler *handler.PowerHandler,
+	exportHandler *handler.ExportHandler,
+	artifactHandler *handler.ArtifactHandler,
+	deviceHandler *handler.DeviceHandler,
+	reservationHandler *handler.ReservationHandler,
+	recordingHandler *handler.RecordingHandler,
+	usrpHandler *handler.USRPHandler,
 	systemHandler *handler.SystemHandler,
+	graphqlHandler *handler.GraphQLHandler,
+	alertHandler *handler.AlertHandler,
 ) *gin.Engine {
 	router := gin.New()
 
@@ -20,18 +30,46 @@ func Setup(
 	router.Use(middleware.Logger())
 	router.Use(middleware.CORS())
 	router.Use(middleware.RequestID())
+	router.Use(middleware.OptionalAuth())
+	router.Use(middleware.Degradation())
 
-	api := router.Group("/api/v1")
+	router.GET("/readyz", systemHandler.Ready)
+
+	v1 := newAPIVersion("v1")
+	api := v1.table()
 	{
 		api.GET("/health", systemHandler.Health)
 		api.GET("/info", systemHandler.Info)
+		api.GET("/leader", systemHandler.Leader)
+		api.GET("/devices", deviceHandler.List)
+		api.GET("/graphql", graphqlHandler.Query)
+		api.POST("/graphql", graphqlHandler.Query)
 
 		irs := api.Group("/irs")
 		{
+			irs.GET("/panels", irsHandler.ListPanels)
 			irs.POST("/config", irsHandler.Configure)
 			irs.GET("/status", irsHandler.GetStatus)
+			irs.GET("/constraints", irsHandler.GetConstraints)
 			irs.GET("/config", irsHandler.GetCurrentConfig)
 			irs.POST("/optimal", irsHandler.ApplyOptimal)
+			irs.PUT("/groups/:name", irsHandler.ConfigureGroup)
+			irs.GET("/history", irsHandler.History)
+			irs.POST("/history/:id/rollback", irsHandler.Rollback)
+			irs.GET("/codebooks", irsHandler.ListCodebooks)
+			irs.POST("/codebooks", irsHandler.CreateCodebook)
+			irs.GET("/codebooks/:name", irsHandler.GetCodebook)
+			irs.DELETE("/codebooks/:name", irsHandler.DeleteCodebook)
+			irs.POST("/codebooks/:name/apply", irsHandler.ApplyCodebook)
+			irs.GET("/sequence", irsHandler.GetSequence)
+			irs.POST("/sequence", irsHandler.StartSequence)
+			irs.DELETE("/sequence", irsHandler.StopSequence)
+			irs.GET("/firmware", irsHandler.ListFirmware)
+			irs.POST("/firmware", irsHandler.UploadFirmware)
+			irs.GET("/firmware/updates", irsHandler.ListFirmwareUpdates)
+			irs.POST("/firmware/updates", irsHandler.ScheduleFirmwareUpdate)
+			irs.GET("/firmware/updates/:id", irsHandler.GetFirmwareUpdate)
+			irs.DELETE("/firmware/updates/:id", irsHandler.CancelFirmwareUpdate)
 		}
 
 		channel := api.Group("/channel")
@@ -39,25 +77,164 @@ func Setup(
 			channel.POST("/collect", channelHandler.Collect)
 			channel.GET("/data", channelHandler.Query)
 			channel.GET("/realtime", channelHandler.GetRealtime)
+			channel.POST("/probe", channelHandler.Probe)
+			channel.POST("/annotations", channelHandler.Annotate)
+			channel.GET("/annotations", channelHandler.ListAnnotations)
+			channel.GET("/annotations/:id", channelHandler.GetAnnotation)
+			channel.DELETE("/annotations/:id", channelHandler.DeleteAnnotation)
+		}
+
+		beams := api.Group("/beams/sweeps")
+		{
+			beams.POST("", channelHandler.StartBeamSweep)
+			beams.GET("", channelHandler.ListBeamSweeps)
+			beams.GET("/:id", channelHandler.GetBeamSweep)
+			beams.GET("/:id/result", channelHandler.BeamSweepResult)
+			beams.POST("/:id/stop", channelHandler.StopBeamSweep)
 		}
 
 		algorithm := api.Group("/algorithm")
 		{
 			algorithm.POST("/beamforming", algorithmHandler.RunBeamforming)
 			algorithm.POST("/doa", algorithmHandler.RunDOA)
+			algorithm.POST("/irs-impact", algorithmHandler.RunIRSImpact)
+			algorithm.POST("/joint-beamforming", algorithmHandler.RunJointBeamforming)
+			algorithm.POST("/hybrid-beamforming", algorithmHandler.RunHybridBeamforming)
+			algorithm.POST("/batch", algorithmHandler.RunBatch)
+			algorithm.POST("/doa/online", algorithmHandler.StartOnlineDOA)
+			algorithm.GET("/doa/online", algorithmHandler.GetOnlineDOA)
+			algorithm.POST("/doa/online/stop", algorithmHandler.StopOnlineDOA)
 			algorithm.GET("/result/:id", algorithmHandler.GetResult)
 			algorithm.GET("/results", algorithmHandler.ListResults)
+			algorithm.GET("/results/:id/snapshots", algorithmHandler.GetSnapshots)
+			algorithm.GET("/energy/ranking", algorithmHandler.EnergyRanking)
+			algorithm.GET("/cache", algorithmHandler.CacheStats)
+			algorithm.DELETE("/cache", algorithmHandler.InvalidateCache)
+			algorithm.POST("/result/:id/export", exportHandler.ExportResult)
+		}
+
+		pipelines := api.Group("/pipelines")
+		{
+			pipelines.POST("", algorithmHandler.SubmitPipeline)
+			pipelines.GET("", algorithmHandler.ListPipelines)
+			pipelines.GET("/:id", algorithmHandler.GetPipeline)
 		}
 
 		sensor := api.Group("/sensor")
 		{
+			sensor.POST("", sensorHandler.Register)
+			sensor.PUT("/:id", sensorHandler.Update)
+			sensor.DELETE("/:id", sensorHandler.Delete)
+			sensor.POST("/:id/calibrate", sensorHandler.Calibrate)
+			sensor.PUT("/:id/lifecycle", sensorHandler.SetLifecycle)
 			sensor.GET("/list", sensorHandler.List)
+			sensor.GET("/health", sensorHandler.Health)
 			sensor.GET("/data", sensorHandler.GetData)
 			sensor.GET("/read/:id", sensorHandler.ReadSensor)
+			sensor.POST("/batch-read", sensorHandler.BatchRead)
 			sensor.POST("/start", sensorHandler.StartCollection)
 			sensor.POST("/stop", sensorHandler.StopCollection)
+			sensor.POST("/replay", sensorHandler.StartReplay)
+			sensor.GET("/replay", sensorHandler.GetReplay)
+			sensor.POST("/replay/stop", sensorHandler.StopReplay)
+		}
+
+		stream := api.Group("/stream")
+		{
+			stream.GET("/events", systemHandler.StreamEvents)
+			stream.GET("/ws", systemHandler.StreamWebSocket)
+			stream.GET("/stats", systemHandler.StreamStats)
+		}
+
+		alerts := api.Group("/alerts")
+		{
+			alerts.GET("", alertHandler.List)
+			alerts.GET("/rules", alertHandler.ListRules)
+			alerts.POST("/rules", alertHandler.CreateRule)
+			alerts.PUT("/rules/:id", alertHandler.UpdateRule)
+			alerts.DELETE("/rules/:id", alertHandler.DeleteRule)
+			alerts.GET("/prometheus-rules", alertHandler.PrometheusRules)
+			alerts.POST("/alertmanager", alertHandler.AlertmanagerWebhook)
+		}
+
+		power := api.Group("/power")
+		{
+			power.POST("/estimate", powerHandler.Estimate)
+			power.GET("/crosscheck", powerHandler.CrossCheck)
+		}
+
+		artifacts := api.Group("/artifacts")
+		{
+			artifacts.GET("", artifactHandler.List)
+			artifacts.GET("/:id", artifactHandler.Get)
+			artifacts.GET("/:id/download", artifactHandler.Download)
+			artifacts.DELETE("/:id", artifactHandler.Delete)
+			artifacts.POST("/gc", artifactHandler.CollectGarbage)
+			artifacts.POST("/verify", artifactHandler.VerifyAll)
+			artifacts.POST("/:id/verify", artifactHandler.Verify)
+		}
+
+		api.POST("/datasets", exportHandler.BuildDataset)
+
+		reservations := api.Group("/reservations")
+		{
+			reservations.POST("", reservationHandler.Create)
+			reservations.GET("", reservationHandler.List)
+			reservations.DELETE("/:id", reservationHandler.Cancel)
+		}
+
+		recordings := api.Group("/recordings")
+		{
+			recordings.POST("", recordingHandler.Start)
+			recordings.GET("", recordingHandler.List)
+			recordings.POST("/:id/stop", recordingHandler.Stop)
+			recordings.POST("/:id/verify", recordingHandler.Verify)
+		}
+
+		sessions := api.Group("/sessions")
+		{
+			sessions.POST("", recordingHandler.StartSession)
+			sessions.GET("", recordingHandler.ListSessions)
+			sessions.GET("/:id", recordingHandler.GetSession)
+			sessions.POST("/:id/stop", recordingHandler.StopSession)
+			sessions.GET("/:id/data", recordingHandler.SessionData)
+		}
+
+		usrpGroup := api.Group("/usrp")
+		{
+			usrpGroup.GET("/devices", usrpHandler.ListDevices)
+			usrpGroup.POST("/bind", usrpHandler.Bind)
+			usrpGroup.GET("/gain", usrpHandler.GetGain)
+			usrpGroup.POST("/gain", usrpHandler.SetGain)
+			usrpGroup.POST("/gain/agc", usrpHandler.SetAGC)
+			usrpGroup.GET("/calibration", usrpHandler.GetCalibration)
+			usrpGroup.POST("/calibration", usrpHandler.Calibrate)
+			usrpGroup.DELETE("/calibration", usrpHandler.ClearCalibration)
+		}
+
+		admin := api.Group("/admin")
+		{
+			admin.GET("/irs/faults", irsHandler.GetFaults)
+			admin.POST("/irs/faults", irsHandler.InjectFaults)
+			admin.DELETE("/irs/faults", irsHandler.ClearFaults)
 		}
+
+		api.GET("/objects/*key", exportHandler.Download)
 	}
 
+	// v2 pages list results as {items, pagination}.
+	v2 := v1.successor("v2")
+	api = v2.table()
+	{
+		api.GET("/irs/history", response.PageV2, irsHandler.History)
+		api.GET("/channel/data", response.PageV2, channelHandler.Query)
+		api.GET("/algorithm/results", response.PageV2, algorithmHandler.ListResults)
+		api.GET("/artifacts", response.PageV2, artifactHandler.List)
+	}
+
+	v1.register(router)
+	v2.register(router)
+	router.NoRoute(negotiate(router, v1, v2))
+
 	return router
 }
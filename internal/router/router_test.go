@@ -0,0 +1,49 @@
+package router
+
+import (
+	"strings"
+	"testing"
+
+	"isac-cran-system/pkg/gateway"
+
+	"github.com/gin-gonic/gin"
+)
+
+// instanceRoutes report on the instance that serves them, so the gateway
+// does not proxy them to any one service.
+var instanceRoutes = map[string]bool{
+	"health": true,
+	"info":   true,
+	"leader": true,
+}
+
+// TestSetup_GatewayRoutes fails when an API group is added without a
+// gateway route, which leaves it unreachable in a split deployment.
+func TestSetup_GatewayRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := Setup(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	proxied := make(map[string]map[string]bool)
+	for _, route := range gateway.DefaultRoutes() {
+		group := strings.TrimPrefix(route.Path, "/api/:version/")
+		proxied[group] = make(map[string]bool)
+		for _, method := range route.Methods {
+			proxied[group][method] = true
+		}
+	}
+
+	for _, route := range engine.Routes() {
+		path, ok := strings.CutPrefix(route.Path, "/api/")
+		if !ok {
+			continue
+		}
+		_, path, _ = strings.Cut(path, "/")
+		group, _, _ := strings.Cut(path, "/")
+		if instanceRoutes[group] {
+			continue
+		}
+		if methods, ok := proxied[group]; !ok || !methods[route.Method] {
+			t.Errorf("%s %s is not routed by the gateway", route.Method, route.Path)
+		}
+	}
+}
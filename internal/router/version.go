@@ -0,0 +1,170 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+
+	"isac-cran-system/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// VersionHeader selects the API version of an unversioned /api request
+	// and names the version that served every versioned response.
+	VersionHeader = "API-Version"
+	// DefaultAPIVersion serves unversioned requests that do not ask for a
+	// version.
+	DefaultAPIVersion = "v1"
+
+	vendorMediaType = "application/vnd.isac."
+)
+
+type route struct {
+	method   string
+	path     string
+	handlers []gin.HandlerFunc
+}
+
+func (r route) key() string {
+	return r.method + " " + r.path
+}
+
+// apiVersion is the route table served under /api/<name>. A version starts
+// as a copy of its predecessor; registering a route that already exists
+// replaces it, and the predecessor's route then answers with deprecation
+// headers pointing at the successor.
+type apiVersion struct {
+	name       string
+	prev       *apiVersion
+	routes     []route
+	superseded map[string]string
+}
+
+func newAPIVersion(name string) *apiVersion {
+	return &apiVersion{name: name, superseded: make(map[string]string)}
+}
+
+// successor starts the next version with all of v's routes.
+func (v *apiVersion) successor(name string) *apiVersion {
+	next := newAPIVersion(name)
+	next.prev = v
+	next.routes = append([]route(nil), v.routes...)
+	return next
+}
+
+func (v *apiVersion) table() routeTable {
+	return routeTable{version: v}
+}
+
+func (v *apiVersion) handle(r route) {
+	for i := range v.routes {
+		if v.routes[i].key() == r.key() {
+			v.routes[i] = r
+			if v.prev != nil {
+				v.prev.superseded[r.key()] = v.name
+			}
+			return
+		}
+	}
+	v.routes = append(v.routes, r)
+}
+
+func (v *apiVersion) register(engine *gin.Engine) {
+	group := engine.Group("/api/"+v.name, func(c *gin.Context) {
+		c.Header(VersionHeader, v.name)
+	})
+	for _, r := range v.routes {
+		handlers := r.handlers
+		if successor, ok := v.superseded[r.key()]; ok {
+			handlers = append([]gin.HandlerFunc{deprecated(v.name, successor)}, handlers...)
+		}
+		group.Handle(r.method, r.path, handlers...)
+	}
+}
+
+// deprecated marks a response as coming from a route replaced in a later
+// version and links the replacement.
+func deprecated(name, successor string) gin.HandlerFunc {
+	from := "/api/" + name
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", "<"+"/api/"+successor+strings.TrimPrefix(c.Request.URL.Path, from)+`>; rel="successor-version"`)
+	}
+}
+
+// routeTable adds routes to an apiVersion with the method names of a gin
+// router group.
+type routeTable struct {
+	version *apiVersion
+	prefix  string
+}
+
+func (t routeTable) Group(path string) routeTable {
+	return routeTable{version: t.version, prefix: t.prefix + path}
+}
+
+func (t routeTable) GET(path string, handlers ...gin.HandlerFunc) {
+	t.version.handle(route{method: http.MethodGet, path: t.prefix + path, handlers: handlers})
+}
+
+func (t routeTable) POST(path string, handlers ...gin.HandlerFunc) {
+	t.version.handle(route{method: http.MethodPost, path: t.prefix + path, handlers: handlers})
+}
+
+func (t routeTable) PUT(path string, handlers ...gin.HandlerFunc) {
+	t.version.handle(route{method: http.MethodPut, path: t.prefix + path, handlers: handlers})
+}
+
+func (t routeTable) DELETE(path string, handlers ...gin.HandlerFunc) {
+	t.version.handle(route{method: http.MethodDelete, path: t.prefix + path, handlers: handlers})
+}
+
+// negotiate serves /api requests without a version prefix from the version
+// asked for in the API-Version header or a vendor media type in Accept
+// (application/vnd.isac.v2+json), defaulting to DefaultAPIVersion.
+func negotiate(engine *gin.Engine, versions ...*apiVersion) gin.HandlerFunc {
+	known := make(map[string]bool, len(versions))
+	for _, v := range versions {
+		known[v.name] = true
+	}
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		rest, ok := strings.CutPrefix(path, "/api/")
+		if !ok || known[strings.SplitN(rest, "/", 2)[0]] {
+			response.NotFound(c, "route not found")
+			return
+		}
+
+		version := requestedVersion(c)
+		if !known[version] {
+			response.BadRequest(c, "unsupported API version "+version)
+			return
+		}
+		// keep the request ID when the request goes through the router again
+		c.Request.Header.Set("X-Request-ID", c.GetString("request_id"))
+		c.Request.URL.Path = "/api/" + version + "/" + rest
+		engine.HandleContext(c)
+		// the context now holds the chain of the route it was sent to; stop
+		// the outer chain from resuming it
+		c.Abort()
+	}
+}
+
+func requestedVersion(c *gin.Context) string {
+	if v := c.GetHeader(VersionHeader); v != "" {
+		if !strings.HasPrefix(v, "v") {
+			v = "v" + v
+		}
+		return v
+	}
+	for _, accept := range strings.Split(c.GetHeader("Accept"), ",") {
+		_, media, ok := strings.Cut(strings.TrimSpace(accept), vendorMediaType)
+		if !ok {
+			continue
+		}
+		v, _, _ := strings.Cut(media, "+")
+		return v
+	}
+	return DefaultAPIVersion
+}
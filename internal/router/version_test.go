@@ -0,0 +1,82 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAPIVersion_Negotiation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+
+	reply := func(body string) gin.HandlerFunc {
+		return func(c *gin.Context) { c.String(http.StatusOK, body) }
+	}
+	v1 := newAPIVersion("v1")
+	api := v1.table()
+	api.GET("/items", reply("v1 items"))
+	api.GET("/items/:id", reply("v1 item"))
+	v2 := v1.successor("v2")
+	v2.table().GET("/items", reply("v2 items"))
+	v1.register(engine)
+	v2.register(engine)
+	engine.NoRoute(negotiate(engine, v1, v2))
+
+	tests := []struct {
+		name        string
+		path        string
+		header      string
+		value       string
+		status      int
+		body        string
+		version     string
+		deprecation string
+		link        string
+	}{
+		{name: "v1 replaced route", path: "/api/v1/items", status: 200, body: "v1 items", version: "v1",
+			deprecation: "true", link: `</api/v2/items>; rel="successor-version"`},
+		{name: "v1 kept route", path: "/api/v1/items/3", status: 200, body: "v1 item", version: "v1"},
+		{name: "v2 inherited route", path: "/api/v2/items/3", status: 200, body: "v1 item", version: "v2"},
+		{name: "v2 route", path: "/api/v2/items", status: 200, body: "v2 items", version: "v2"},
+		{name: "unversioned default", path: "/api/items", status: 200, body: "v1 items", version: "v1", deprecation: "true",
+			link: `</api/v2/items>; rel="successor-version"`},
+		{name: "unversioned header", path: "/api/items", header: VersionHeader, value: "2", status: 200, body: "v2 items", version: "v2"},
+		{name: "unversioned accept", path: "/api/items", header: "Accept", value: "application/vnd.isac.v2+json", status: 200, body: "v2 items", version: "v2"},
+		{name: "unknown version", path: "/api/items", header: VersionHeader, value: "v9", status: 400},
+		{name: "unknown route", path: "/api/v2/missing", status: 404},
+		{name: "outside api", path: "/missing", status: 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.status != 200 {
+				return
+			}
+			if w.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.body)
+			}
+			if got := w.Header().Get(VersionHeader); got != tt.version {
+				t.Errorf("%s = %q, want %q", VersionHeader, got, tt.version)
+			}
+			if got := w.Header().Get("Deprecation"); got != tt.deprecation {
+				t.Errorf("Deprecation = %q, want %q", got, tt.deprecation)
+			}
+			if got := w.Header().Get("Link"); got != tt.link {
+				t.Errorf("Link = %q, want %q", got, tt.link)
+			}
+		})
+	}
+}
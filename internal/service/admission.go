@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+// AdmissionConfig bounds the experiments the service takes on. Experiments
+// predicted to run longer than MaxDuration are rejected. Those predicted to
+// take at least HeavyThreshold are heavy: at most MaxConcurrent of them run
+// at once, up to MaxQueued more are queued until a slot frees up, and
+// further ones are rejected. Zero MaxDuration or MaxConcurrent disables the
+// limit.
+type AdmissionConfig struct {
+	MaxConcurrent  int
+	MaxQueued      int
+	MaxDuration    time.Duration
+	HeavyThreshold time.Duration
+}
+
+// ExperimentRejectedError is returned when admission control turns an
+// experiment away. Estimate is the prediction the decision was based on.
+type ExperimentRejectedError struct {
+	Err      *errors.AppError
+	Estimate *model.CostEstimate
+}
+
+func (e *ExperimentRejectedError) Error() string {
+	return e.Err.Error()
+}
+
+type admissionControl struct {
+	cfg   AdmissionConfig
+	slots chan struct{}
+
+	mu     sync.Mutex
+	queued int
+}
+
+func newAdmissionControl(cfg AdmissionConfig) *admissionControl {
+	a := &admissionControl{cfg: cfg}
+	if cfg.MaxConcurrent > 0 {
+		a.slots = make(chan struct{}, cfg.MaxConcurrent)
+	}
+	return a
+}
+
+// SetAdmission sets the budgets experiments are admitted against.
+func (s *AlgorithmService) SetAdmission(cfg AdmissionConfig) {
+	s.admission = newAdmissionControl(cfg)
+}
+
+func (a *admissionControl) check(estimate *model.CostEstimate) *ExperimentRejectedError {
+	if a.cfg.MaxDuration <= 0 || estimate.Runtime <= a.cfg.MaxDuration.Seconds() {
+		return nil
+	}
+	return &ExperimentRejectedError{
+		Err: errors.New(errors.CodeExperimentTooLong,
+			fmt.Sprintf("experiment is predicted to run %.3g s, over the %s budget", estimate.Runtime, a.cfg.MaxDuration)),
+		Estimate: estimate,
+	}
+}
+
+func (a *admissionControl) heavy(estimate *model.CostEstimate) bool {
+	return a.slots != nil && estimate.Runtime >= a.cfg.HeavyThreshold.Seconds()
+}
+
+func (a *admissionControl) busy() bool {
+	return a.slots != nil && len(a.slots) == cap(a.slots)
+}
+
+func (a *admissionControl) tryAcquire() bool {
+	select {
+	case a.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// enqueue takes a place in the queue, failing when the queue is full.
+func (a *admissionControl) enqueue(estimate *model.CostEstimate) *ExperimentRejectedError {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.queued >= a.cfg.MaxQueued {
+		return &ExperimentRejectedError{
+			Err: errors.New(errors.CodeExperimentQueueFull,
+				fmt.Sprintf("%d heavy experiments are running and %d are queued", cap(a.slots), a.queued)),
+			Estimate: estimate,
+		}
+	}
+	a.queued++
+	return nil
+}
+
+func (a *admissionControl) dequeue() {
+	a.mu.Lock()
+	a.queued--
+	a.mu.Unlock()
+}
+
+func (a *admissionControl) acquire(ctx context.Context) error {
+	select {
+	case a.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// wait leaves the queue for a slot once one is free.
+func (a *admissionControl) wait(ctx context.Context) error {
+	defer a.dequeue()
+	return a.acquire(ctx)
+}
+
+func (a *admissionControl) release() {
+	<-a.slots
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+	"isac-cran-system/pkg/mq"
+
+	"go.uber.org/zap"
+)
+
+// DefaultPrometheusJob is the scrape job of the servers in the bundled
+// Prometheus configuration.
+const DefaultPrometheusJob = "isac-cran-system"
+
+// PrometheusRules returns the recommended alerting rules for the metrics a
+// server exports on /debug/metrics in the Prometheus format, scraped as job.
+func PrometheusRules(job string) *model.PrometheusRuleFile {
+	if job == "" {
+		job = DefaultPrometheusJob
+	}
+	sel := fmt.Sprintf(`{job=%q}`, job)
+	rule := func(name, expr, wait, severity, summary, description string) model.PrometheusRule {
+		return model.PrometheusRule{
+			Alert:       name,
+			Expr:        expr,
+			For:         wait,
+			Labels:      map[string]string{"severity": severity},
+			Annotations: map[string]string{"summary": summary, "description": description},
+		}
+	}
+	return &model.PrometheusRuleFile{Groups: []model.PrometheusRuleGroup{
+		{
+			Name: "isac-cran-server",
+			Rules: []model.PrometheusRule{
+				rule("ISACInstanceDown", "up"+sel+" == 0", "1m", model.AlertLevelCritical,
+					"ISAC server down",
+					"{{ $labels.instance }} has not answered the metrics scrape for a minute."),
+				rule("ISACGoroutineLeak", "go_goroutines"+sel+" > 10000", "15m", model.AlertLevelWarning,
+					"Goroutine count is high",
+					"{{ $labels.instance }} runs {{ $value }} goroutines."),
+			},
+		},
+		{
+			Name: "isac-cran-tasks",
+			Rules: []model.PrometheusRule{
+				rule("ISACWorkerPoolSaturated",
+					"isac_worker_pool_queue_length"+sel+" / isac_worker_pool_queue_capacity"+sel+" > 0.8", "5m", model.AlertLevelWarning,
+					"Worker pool queue nearly full",
+					"The task queue of {{ $labels.instance }} is {{ $value | humanizePercentage }} full; new tasks will be rejected."),
+				rule("ISACTasksRejected", "increase(isac_worker_pool_tasks_rejected"+sel+"[5m]) > 0", "", model.AlertLevelWarning,
+					"Tasks rejected",
+					"{{ $labels.instance }} rejected {{ $value }} {{ $labels.task_type }} tasks in 5 minutes."),
+				rule("ISACTasksFailing",
+					"(increase(isac_worker_pool_tasks_failed"+sel+"[10m]) + increase(isac_worker_pool_tasks_timed_out"+sel+"[10m]))"+
+						" / increase(isac_worker_pool_tasks_submitted"+sel+"[10m]) > 0.2", "10m", model.AlertLevelWarning,
+					"Tasks failing",
+					"{{ $value | humanizePercentage }} of the {{ $labels.task_type }} tasks on {{ $labels.instance }} failed or timed out."),
+				rule("ISACTaskPanicked", "increase(isac_worker_pool_tasks_panicked"+sel+"[10m]) > 0", "", model.AlertLevelCritical,
+					"Task panicked",
+					"A {{ $labels.task_type }} task panicked on {{ $labels.instance }}."),
+				rule("ISACExperimentBacklog", "isac_experiment_queue"+sel+" > 20", "30m", model.AlertLevelWarning,
+					"Experiments waiting",
+					"{{ $value }} experiments on {{ $labels.instance }} have been waiting for devices for 30 minutes."),
+			},
+		},
+		{
+			Name: "isac-cran-streams",
+			Rules: []model.PrometheusRule{
+				rule("ISACStreamDropping", "rate(isac_stream_hub_dropped"+sel+"[5m]) > 1", "5m", model.AlertLevelWarning,
+					"Stream messages dropped",
+					"{{ $labels.instance }} drops {{ $value }} messages/s to slow stream clients."),
+				rule("ISACStreamClientsEvicted", "increase(isac_stream_hub_evicted"+sel+"[10m]) > 5", "", model.AlertLevelWarning,
+					"Stream clients evicted",
+					"{{ $labels.instance }} disconnected {{ $value }} slow stream clients in 10 minutes."),
+			},
+		},
+		{
+			Name: "isac-cran-database",
+			Rules: []model.PrometheusRule{
+				rule("ISACMySQLPoolExhausted",
+					"isac_mysql_pool_primary_in_use"+sel+" / isac_mysql_pool_primary_max_open"+sel+" > 0.9"+
+						" and isac_mysql_pool_primary_max_open"+sel+" > 0", "5m", model.AlertLevelCritical,
+					"MySQL connection pool exhausted",
+					"{{ $labels.instance }} uses {{ $value | humanizePercentage }} of its MySQL connections."),
+				rule("ISACMySQLWaiting", "rate(isac_mysql_pool_primary_wait_duration_s"+sel+"[5m]) > 0.5", "5m", model.AlertLevelWarning,
+					"Requests waiting for MySQL connections",
+					"Requests on {{ $labels.instance }} wait {{ $value }}s per second for a MySQL connection."),
+			},
+		},
+	}}
+}
+
+// RelayAlertmanager forwards the alerts of an Alertmanager notification to
+// the notification queue next to the sensor and IRS alerts, and returns how
+// many it forwarded. Without a queue they are only logged.
+func (s *AlertService) RelayAlertmanager(ctx context.Context, webhook *model.AlertmanagerWebhook) (int, error) {
+	for i := range webhook.Alerts {
+		alert := &webhook.Alerts[i]
+		name := alert.Labels["alertname"]
+		if alert.Status == "resolved" {
+			logger.Info("Infrastructure alert cleared", zap.String("alertname", name), zap.String("fingerprint", alert.Fingerprint))
+		} else {
+			logger.Warn("Infrastructure alert raised", zap.String("alertname", name), zap.String("fingerprint", alert.Fingerprint),
+				zap.String("summary", alert.Annotations["summary"]))
+		}
+		if s.publisher == nil {
+			continue
+		}
+		if err := s.publisher.Publish(ctx, mq.QueueNotification, infraNotification(alert)); err != nil {
+			return i, errors.Wrap(errors.CodeServiceUnavailable, "failed to publish infrastructure alert", err)
+		}
+	}
+	return len(webhook.Alerts), nil
+}
+
+// infraNotification converts an Alertmanager alert into a notification. Its
+// severity label, if warning or critical, is the level of a firing alert;
+// cleared alerts are info like the others.
+func infraNotification(alert *model.AlertmanagerAlert) *mq.NotificationMessage {
+	name := alert.Labels["alertname"]
+	level, title, at := model.AlertLevelWarning, "Infrastructure alert raised", alert.StartsAt
+	if severity := alert.Labels["severity"]; severity == model.AlertLevelCritical {
+		level = model.AlertLevelCritical
+	}
+	if alert.Status == "resolved" {
+		level, title, at = "info", "Infrastructure alert cleared", alert.EndsAt
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	message := alert.Annotations["summary"]
+	if description := alert.Annotations["description"]; description != "" {
+		if message != "" {
+			message += ": "
+		}
+		message += description
+	}
+	if message == "" {
+		message = name
+	}
+	return &mq.NotificationMessage{
+		Type:    "infra_alert",
+		Level:   level,
+		Title:   title,
+		Message: message,
+		Data: map[string]interface{}{
+			"alertname":     name,
+			"status":        alert.Status,
+			"fingerprint":   alert.Fingerprint,
+			"labels":        alert.Labels,
+			"annotations":   alert.Annotations,
+			"starts_at":     alert.StartsAt,
+			"generator_url": alert.GeneratorURL,
+		},
+		Timestamp: at.Unix(),
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/mq"
+
+	"gopkg.in/yaml.v3"
+)
+
+// memPublisher records the notifications published to it and fails once
+// fail is set.
+type memPublisher struct {
+	mu       sync.Mutex
+	messages []*mq.NotificationMessage
+	fail     bool
+}
+
+func (p *memPublisher) Publish(ctx context.Context, queueName string, message interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fail {
+		return fmt.Errorf("queue closed")
+	}
+	if queueName != mq.QueueNotification {
+		return fmt.Errorf("published to %s", queueName)
+	}
+	p.messages = append(p.messages, message.(*mq.NotificationMessage))
+	return nil
+}
+
+func TestRelayAlertmanager(t *testing.T) {
+	publisher := &memPublisher{}
+	s := NewAlertService(nil)
+	s.SetAlertPublisher(publisher)
+
+	started := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	webhook := &model.AlertmanagerWebhook{
+		Version: "4",
+		Status:  "firing",
+		Alerts: []model.AlertmanagerAlert{
+			{
+				Status:      "firing",
+				Labels:      map[string]string{"alertname": "NodeDiskFull", "severity": "critical", "instance": "node-1"},
+				Annotations: map[string]string{"summary": "Disk full", "description": "/data is 98% full"},
+				StartsAt:    started,
+			},
+			{
+				Status:   "resolved",
+				Labels:   map[string]string{"alertname": "ISACStreamDropping", "severity": "warning"},
+				StartsAt: started,
+				EndsAt:   started.Add(time.Minute),
+			},
+		},
+	}
+	n, err := s.RelayAlertmanager(context.Background(), webhook)
+	if err != nil || n != 2 {
+		t.Fatalf("RelayAlertmanager() = %d, %v, want both alerts forwarded", n, err)
+	}
+
+	raised, cleared := publisher.messages[0], publisher.messages[1]
+	if raised.Type != "infra_alert" || raised.Level != model.AlertLevelCritical || raised.Message != "Disk full: /data is 98% full" ||
+		raised.Timestamp != started.Unix() {
+		t.Errorf("raised = %+v", raised)
+	}
+	if raised.Data["alertname"] != "NodeDiskFull" {
+		t.Errorf("raised data = %v, want the alert name", raised.Data)
+	}
+	if cleared.Level != "info" || cleared.Message != "ISACStreamDropping" || cleared.Timestamp != started.Add(time.Minute).Unix() {
+		t.Errorf("cleared = %+v", cleared)
+	}
+
+	publisher.fail = true
+	if _, err := s.RelayAlertmanager(context.Background(), webhook); !errors.IsCode(err, errors.CodeServiceUnavailable) {
+		t.Errorf("error %v when the queue is down, want service unavailable so Alertmanager retries", err)
+	}
+}
+
+func TestPrometheusRules(t *testing.T) {
+	data, err := yaml.Marshal(PrometheusRules("lab"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file model.PrometheusRuleFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+
+	names := make(map[string]bool)
+	for _, group := range file.Groups {
+		for _, rule := range group.Rules {
+			if names[rule.Alert] {
+				t.Errorf("rule %s is defined twice", rule.Alert)
+			}
+			names[rule.Alert] = true
+			if !strings.Contains(rule.Expr, `{job="lab"}`) {
+				t.Errorf("%s: %q does not select the job", rule.Alert, rule.Expr)
+			}
+			if severity := rule.Labels["severity"]; severity != model.AlertLevelWarning && severity != model.AlertLevelCritical {
+				t.Errorf("%s: severity %q", rule.Alert, severity)
+			}
+		}
+	}
+	if !names["ISACWorkerPoolSaturated"] || !names["ISACInstanceDown"] {
+		t.Errorf("rules %v", names)
+	}
+	if !strings.Contains(PrometheusRules("").Groups[0].Rules[0].Expr, DefaultPrometheusJob) {
+		t.Error("rules without a job do not watch the default job")
+	}
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+	"isac-cran-system/pkg/mq"
+
+	"go.uber.org/zap"
+)
+
+type AlertStore interface {
+	CreateRule(ctx context.Context, rule *model.AlertRule) error
+	GetRule(ctx context.Context, id int64) (*model.AlertRule, error)
+	ListRules(ctx context.Context) ([]model.AlertRule, error)
+	UpdateRule(ctx context.Context, rule *model.AlertRule) error
+	DeleteRule(ctx context.Context, id int64) error
+	CreateAlert(ctx context.Context, alert *model.SensorAlert) error
+	ResolveAlert(ctx context.Context, id int64, resolvedAt time.Time) error
+	ListAlerts(ctx context.Context, query *model.AlertQuery) ([]model.SensorAlert, int64, error)
+	ListActiveAlerts(ctx context.Context) ([]model.SensorAlert, error)
+}
+
+// AlertService evaluates threshold rules against sensor readings. A rule
+// whose condition holds for its duration raises an alert, which is stored,
+// logged and published, and resolved by the first reading that no longer
+// meets the condition.
+type AlertService struct {
+	store     AlertStore
+	publisher AlertPublisher
+
+	mu    sync.Mutex
+	rules map[string][]*alertRuleState
+	// maintenance holds the maintenance windows by sensor; readings inside
+	// one are not evaluated.
+	maintenance map[string]*model.SensorMaintenance
+}
+
+type alertRuleState struct {
+	rule *model.AlertRule
+	// since is when the condition started to hold, zero while it does not
+	since  time.Time
+	active *model.SensorAlert
+}
+
+func NewAlertService(store AlertStore) *AlertService {
+	return &AlertService{
+		store:       store,
+		rules:       make(map[string][]*alertRuleState),
+		maintenance: make(map[string]*model.SensorMaintenance),
+	}
+}
+
+// SetMaintenance holds back the alerts of a sensor during window; nil
+// clears it. Alerts active when the window starts stay active until a
+// reading after it clears them.
+func (s *AlertService) SetMaintenance(sensorID string, window *model.SensorMaintenance) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if window == nil {
+		delete(s.maintenance, sensorID)
+		return
+	}
+	s.maintenance[sensorID] = window
+}
+
+func (s *AlertService) SetAlertPublisher(publisher AlertPublisher) {
+	s.publisher = publisher
+}
+
+func (s *AlertService) available() error {
+	if s.store == nil {
+		return errors.New(errors.CodeServiceUnavailable, "alert store not available")
+	}
+	return nil
+}
+
+// Load reads the stored rules and picks up the alerts still active, so that
+// they resolve once their sensor recovers.
+func (s *AlertService) Load(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	rules, err := s.store.ListRules(ctx)
+	if err != nil {
+		return err
+	}
+	active, err := s.store.ListActiveAlerts(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = make(map[string][]*alertRuleState)
+	for i := range rules {
+		s.addLocked(&rules[i])
+	}
+	for i := range active {
+		if state := s.stateLocked(active[i].RuleID); state != nil {
+			state.active = &active[i]
+			state.since = active[i].TriggeredAt
+		}
+	}
+	return nil
+}
+
+func (s *AlertService) CreateRule(ctx context.Context, req *model.AlertRuleRequest) (*model.AlertRule, error) {
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+	rule := req.Rule()
+	if err := s.store.CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.addLocked(rule)
+	s.mu.Unlock()
+	return rule, nil
+}
+
+func (s *AlertService) ListRules(ctx context.Context) ([]model.AlertRule, error) {
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+	return s.store.ListRules(ctx)
+}
+
+// UpdateRule replaces a rule. Its pending duration starts over; an active
+// alert stays until a reading clears the new condition, unless the rule is
+// disabled or moved to another sensor, which resolves it.
+func (s *AlertService) UpdateRule(ctx context.Context, id int64, req *model.AlertRuleRequest) (*model.AlertRule, error) {
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+	existing, err := s.store.GetRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	rule := req.Rule()
+	rule.ID = id
+	rule.CreatedAt = existing.CreatedAt
+	if err := s.store.UpdateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var active *model.SensorAlert
+	if state := s.stateLocked(id); state != nil {
+		active = state.active
+	}
+	s.removeLocked(id)
+	state := s.addLocked(rule)
+	if active != nil {
+		if active.SensorID == rule.SensorID && rule.Enabled {
+			state.active = active
+			state.since = active.TriggeredAt
+		} else {
+			s.resolve(active, time.Now())
+		}
+	}
+	return rule, nil
+}
+
+// DeleteRule removes a rule and resolves its active alert.
+func (s *AlertService) DeleteRule(ctx context.Context, id int64) error {
+	if err := s.available(); err != nil {
+		return err
+	}
+	if err := s.store.DeleteRule(ctx, id); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state := s.stateLocked(id); state != nil && state.active != nil {
+		s.resolve(state.active, time.Now())
+	}
+	s.removeLocked(id)
+	return nil
+}
+
+func (s *AlertService) ListAlerts(ctx context.Context, query *model.AlertQuery) ([]model.SensorAlert, int64, error) {
+	if err := s.available(); err != nil {
+		return nil, 0, err
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 || query.PageSize > 100 {
+		query.PageSize = 20
+	}
+	return s.store.ListAlerts(ctx, query)
+}
+
+// Observe evaluates the rules of the reading's sensor. Alerts are stored
+// before the next reading is evaluated, so an alert is never resolved
+// before it is recorded.
+func (s *AlertService) Observe(data *model.SensorData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if window, ok := s.maintenance[data.SensorID]; ok && window.Covers(data.Timestamp) {
+		return
+	}
+	for _, state := range s.rules[data.SensorID] {
+		if !state.rule.Enabled {
+			continue
+		}
+		if !state.rule.Matches(data.Value) {
+			state.since = time.Time{}
+			if state.active != nil {
+				s.resolve(state.active, data.Timestamp)
+				state.active = nil
+			}
+			continue
+		}
+		if state.since.IsZero() {
+			state.since = data.Timestamp
+		}
+		held := data.Timestamp.Sub(state.since).Seconds()
+		if state.active == nil && held >= state.rule.Duration {
+			state.active = &model.SensorAlert{
+				RuleID:      state.rule.ID,
+				SensorID:    data.SensorID,
+				Level:       state.rule.Level,
+				Status:      model.AlertStatusActive,
+				Comparison:  state.rule.Comparison,
+				Threshold:   state.rule.Threshold,
+				Value:       data.Value,
+				Message:     state.rule.Describe(data.Value),
+				TriggeredAt: data.Timestamp,
+			}
+			s.raise(state.active)
+		}
+	}
+}
+
+// raise and resolve are called with s.mu held.
+func (s *AlertService) raise(alert *model.SensorAlert) {
+	logger.Warn("Sensor alert raised", zap.Int64("rule_id", alert.RuleID), zap.String("sensor_id", alert.SensorID),
+		zap.Float64("value", alert.Value), zap.String("message", alert.Message))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if s.store != nil {
+		if err := s.store.CreateAlert(ctx, alert); err != nil {
+			logger.Warn("Failed to store sensor alert", zap.Int64("rule_id", alert.RuleID), zap.Error(err))
+		}
+	}
+	s.publish(ctx, alert, alert.Level, "Sensor alert raised")
+}
+
+func (s *AlertService) resolve(alert *model.SensorAlert, at time.Time) {
+	logger.Info("Sensor alert cleared", zap.Int64("rule_id", alert.RuleID), zap.String("sensor_id", alert.SensorID))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if s.store != nil && alert.ID != 0 {
+		if err := s.store.ResolveAlert(ctx, alert.ID, at); err != nil {
+			logger.Warn("Failed to resolve sensor alert", zap.Int64("id", alert.ID), zap.Error(err))
+		}
+	}
+	resolved := *alert
+	resolved.Status = model.AlertStatusResolved
+	resolved.ResolvedAt = &at
+	s.publish(ctx, &resolved, "info", "Sensor alert cleared")
+}
+
+func (s *AlertService) publish(ctx context.Context, alert *model.SensorAlert, level, title string) {
+	if s.publisher == nil {
+		return
+	}
+	timestamp := alert.TriggeredAt
+	if alert.ResolvedAt != nil {
+		timestamp = *alert.ResolvedAt
+	}
+	err := s.publisher.Publish(ctx, mq.QueueNotification, &mq.NotificationMessage{
+		Type:    "sensor_alert",
+		Level:   level,
+		Title:   title,
+		Message: alert.Message,
+		Data: map[string]interface{}{
+			"alert_id":  alert.ID,
+			"rule_id":   alert.RuleID,
+			"sensor_id": alert.SensorID,
+			"status":    alert.Status,
+			"value":     alert.Value,
+			"threshold": alert.Threshold,
+		},
+		Timestamp: timestamp.Unix(),
+	})
+	if err != nil {
+		logger.Warn("Failed to publish sensor alert", zap.Int64("rule_id", alert.RuleID), zap.Error(err))
+	}
+}
+
+func (s *AlertService) addLocked(rule *model.AlertRule) *alertRuleState {
+	state := &alertRuleState{rule: rule}
+	s.rules[rule.SensorID] = append(s.rules[rule.SensorID], state)
+	return state
+}
+
+func (s *AlertService) stateLocked(id int64) *alertRuleState {
+	for _, states := range s.rules {
+		for _, state := range states {
+			if state.rule.ID == id {
+				return state
+			}
+		}
+	}
+	return nil
+}
+
+func (s *AlertService) removeLocked(id int64) {
+	for sensorID, states := range s.rules {
+		for i, state := range states {
+			if state.rule.ID == id {
+				s.rules[sensorID] = append(states[:i:i], states[i+1:]...)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+// defaultBatchConcurrency bounds batch runs when no worker pool is set.
+const defaultBatchConcurrency = 4
+
+// RunBatch runs the items of req concurrently, at most one per pool worker,
+// and reports each outcome separately; a failed item does not fail the
+// batch. Items run beside the pool rather than in it: a DOA item submits its
+// MUSIC scan to the pool and waits for it, which would deadlock once every
+// worker held a batch item.
+func (s *AlgorithmService) RunBatch(ctx context.Context, req *model.AlgorithmBatchRequest) *model.AlgorithmBatchResponse {
+	concurrency := defaultBatchConcurrency
+	if s.pool != nil {
+		concurrency = s.pool.WorkerCount()
+	}
+	sem := make(chan struct{}, concurrency)
+	results := make([]model.AlgorithmBatchResult, len(req.Items))
+	var wg sync.WaitGroup
+	for i, item := range req.Items {
+		wg.Add(1)
+		go func(i int, item model.AlgorithmBatchItem) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results[i] = batchResult(i, item, nil, ctx.Err(), 0)
+				return
+			}
+			defer func() { <-sem }()
+
+			started := time.Now()
+			data, err := s.runBatchItem(ctx, item)
+			results[i] = batchResult(i, item, data, err, time.Since(started))
+		}(i, item)
+	}
+	wg.Wait()
+
+	resp := &model.AlgorithmBatchResponse{Results: results}
+	for _, r := range results {
+		switch r.Status {
+		case model.BatchItemCompleted:
+			resp.Completed++
+		case model.BatchItemQueued:
+			resp.Queued++
+		default:
+			resp.Failed++
+		}
+	}
+	return resp
+}
+
+func (s *AlgorithmService) runBatchItem(ctx context.Context, item model.AlgorithmBatchItem) (interface{}, error) {
+	switch item.AlgorithmType {
+	case model.AlgorithmTypeBeamforming:
+		var params model.BeamformingParams
+		if err := json.Unmarshal(item.Params, &params); err != nil {
+			return nil, errors.Wrap(errors.CodeInvalidParam, "invalid beamforming params: "+err.Error(), err)
+		}
+		return s.RunBeamforming(ctx, item.ExperimentID, &params)
+	case model.AlgorithmTypeDOA:
+		var params model.DOAParams
+		if err := json.Unmarshal(item.Params, &params); err != nil {
+			return nil, errors.Wrap(errors.CodeInvalidParam, "invalid DOA params: "+err.Error(), err)
+		}
+		return s.RunDOA(ctx, item.ExperimentID, &params)
+	}
+	return nil, errors.New(errors.CodeInvalidParam, fmt.Sprintf("unsupported algorithm type %s", item.AlgorithmType))
+}
+
+func batchResult(i int, item model.AlgorithmBatchItem, data interface{}, err error, elapsed time.Duration) model.AlgorithmBatchResult {
+	r := model.AlgorithmBatchResult{
+		Index:         i,
+		AlgorithmType: item.AlgorithmType,
+		ExperimentID:  item.ExperimentID,
+		Status:        model.BatchItemCompleted,
+		Result:        data,
+		Duration:      elapsed.Seconds(),
+	}
+	if err == nil {
+		return r
+	}
+
+	r.Result = nil
+	if queued, ok := err.(*ExperimentQueuedError); ok {
+		r.Status = model.BatchItemQueued
+		r.Queued = queued.Queued
+		return r
+	}
+	r.Status = model.BatchItemFailed
+	if rejected, ok := err.(*ExperimentRejectedError); ok {
+		err = rejected.Err
+	}
+	appErr, ok := err.(*errors.AppError)
+	if !ok {
+		appErr = errors.InternalError("internal server error", err)
+	}
+	r.Code = appErr.Code.Int()
+	r.Error = appErr.Message
+	return r
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+type PowerMeter interface {
+	MeasurePower(ctx context.Context) (float64, error)
+}
+
+type energyMeasurement struct {
+	startTime time.Time
+	powerSum  float64
+	samples   int
+}
+
+// defaultTransmissionInterval is how long the result of an experiment is
+// assumed to carry data when no interval is configured.
+const defaultTransmissionInterval = time.Second
+
+type energyUsage struct {
+	variant          string
+	irsElements      int
+	reconfigurations int
+	// bitRate is the rate in bit/s the result carries once in use; zero for
+	// runs, such as DOA, that carry no data.
+	bitRate float64
+}
+
+func (s *AlgorithmService) SetPowerMeter(meter PowerMeter) {
+	s.powerMeter = meter
+}
+
+// SetTransmissionInterval sets how long the result of an experiment is
+// taken to carry data for its achieved bits; the default applies when
+// interval is not positive.
+func (s *AlgorithmService) SetTransmissionInterval(interval time.Duration) {
+	s.transmissionInterval = interval
+}
+
+// activeIRSElements is the element count of the default panel, which draws
+// static power during an experiment whether or not the experiment switches
+// it; 0 without a panel.
+func (s *AlgorithmService) activeIRSElements() int {
+	if s.irsPanels == nil {
+		return 0
+	}
+	if c := s.irsPanels.Default(); c != nil {
+		return c.ElementCount()
+	}
+	return 0
+}
+
+func (s *AlgorithmService) beginEnergyMeasurement(ctx context.Context) *energyMeasurement {
+	m := &energyMeasurement{startTime: time.Now()}
+	s.samplePower(ctx, m)
+	return m
+}
+
+func (s *AlgorithmService) samplePower(ctx context.Context, m *energyMeasurement) {
+	if s.powerMeter == nil {
+		return
+	}
+	watts, err := s.powerMeter.MeasurePower(ctx)
+	if err != nil {
+		return
+	}
+	m.powerSum += watts
+	m.samples++
+}
+
+// recordEnergy closes the measurement window and stores both the model
+// estimate and the sensor-based energy report with the experiment result.
+// The achieved bits are those the result carries at usage.bitRate over the
+// transmission interval, so energy per bit is what the experiment cost per
+// bit it then delivers. It is derived from measured energy when power
+// sensors were sampled, falling back to the model estimate otherwise. The experiment has
+// already completed, so a failed write is logged rather than returned; after
+// a version conflict the report is not written either.
+func (s *AlgorithmService) recordEnergy(ctx context.Context, result *model.ExperimentResult, m *energyMeasurement, usage energyUsage) {
+	s.samplePower(ctx, m)
+	duration := time.Since(m.startTime)
+
+	if s.resultStore == nil {
+		return
+	}
+
+	report := &model.EnergyReport{
+		ExperimentID:  result.ExperimentID,
+		AlgorithmType: result.AlgorithmType,
+		Variant:       usage.variant,
+		Duration:      duration.Seconds(),
+		PowerSamples:  m.samples,
+	}
+	if usage.bitRate > 0 {
+		interval := s.transmissionInterval
+		if interval <= 0 {
+			interval = defaultTransmissionInterval
+		}
+		report.TransmissionInterval = interval.Seconds()
+		report.AchievedBits = usage.bitRate * interval.Seconds()
+	}
+
+	if s.powerModel != nil {
+		estimate := s.powerModel.EstimateExperiment(usage.irsElements, usage.reconfigurations, duration)
+		estimateJSON, _ := json.Marshal(estimate)
+		if err := s.resultStore.UpdatePowerEstimate(ctx, result, string(estimateJSON)); err != nil {
+			logEnergyWriteFailure(result, "power estimate", err)
+			if errors.IsCode(err, errors.CodeVersionConflict) {
+				return
+			}
+		}
+		report.EstimatedEnergy = estimate.TotalEnergy
+	}
+
+	if m.samples > 0 {
+		report.MeasuredPower = m.powerSum / float64(m.samples)
+		report.MeasuredEnergy = report.MeasuredPower * report.Duration
+	}
+
+	energy := report.MeasuredEnergy
+	if m.samples == 0 {
+		energy = report.EstimatedEnergy
+	}
+	if report.AchievedBits > 0 {
+		report.EnergyPerBit = energy / report.AchievedBits
+	}
+
+	reportJSON, _ := json.Marshal(report)
+	if err := s.resultStore.UpdateEnergyReport(ctx, result, string(reportJSON)); err != nil {
+		logEnergyWriteFailure(result, "energy report", err)
+	}
+}
+
+func logEnergyWriteFailure(result *model.ExperimentResult, what string, err error) {
+	logger.Warn("Failed to store experiment "+what,
+		zap.String("experiment_id", result.ExperimentID),
+		zap.Error(err),
+	)
+}
+
+// RankByEnergy groups completed experiments by algorithm and variant and
+// ranks the groups by average energy per achieved bit, lowest first.
+// Experiments that achieved no bits (e.g. DOA runs) are not ranked.
+func (s *AlgorithmService) RankByEnergy(ctx context.Context, algorithmType model.AlgorithmType) ([]*model.AlgorithmEnergyRanking, error) {
+	if s.resultStore == nil {
+		return []*model.AlgorithmEnergyRanking{}, nil
+	}
+
+	results, err := s.resultStore.ListWithEnergyReport(ctx, algorithmType)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]*model.AlgorithmEnergyRanking)
+	order := make([]*model.AlgorithmEnergyRanking, 0)
+	for _, r := range results {
+		if r.EnergyReport == nil {
+			continue
+		}
+		var report model.EnergyReport
+		if err := json.Unmarshal([]byte(*r.EnergyReport), &report); err != nil {
+			continue
+		}
+		if report.EnergyPerBit <= 0 {
+			continue
+		}
+
+		key := string(report.AlgorithmType) + "/" + report.Variant
+		group, ok := groups[key]
+		if !ok {
+			group = &model.AlgorithmEnergyRanking{
+				AlgorithmType: report.AlgorithmType,
+				Variant:       report.Variant,
+			}
+			groups[key] = group
+			order = append(order, group)
+		}
+
+		energy := report.MeasuredEnergy
+		if report.PowerSamples == 0 {
+			energy = report.EstimatedEnergy
+		}
+		group.Experiments++
+		group.AverageEnergy += energy
+		group.AverageEnergyPerBit += report.EnergyPerBit
+	}
+
+	for _, group := range order {
+		group.AverageEnergy /= float64(group.Experiments)
+		group.AverageEnergyPerBit /= float64(group.Experiments)
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return order[i].AverageEnergyPerBit < order[j].AverageEnergyPerBit
+	})
+	for i, group := range order {
+		group.Rank = i + 1
+	}
+
+	return order, nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/device/irs"
+	"isac-cran-system/internal/device/power"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+// energyStore fails power estimate writes with estimateErr, keeps the last
+// estimate and report and counts the energy reports written.
+type energyStore struct {
+	*memResultStore
+	estimateErr error
+	estimate    string
+	report      string
+	reports     int
+}
+
+func (s *energyStore) UpdatePowerEstimate(ctx context.Context, result *model.ExperimentResult, estimate string) error {
+	s.estimate = estimate
+	return s.estimateErr
+}
+
+func (s *energyStore) UpdateEnergyReport(ctx context.Context, result *model.ExperimentResult, report string) error {
+	s.report = report
+	s.reports++
+	return nil
+}
+
+func TestRecordEnergyWriteFailure(t *testing.T) {
+	tests := []struct {
+		name        string
+		estimateErr error
+		reports     int
+	}{
+		{"stored", nil, 1},
+		{"write error", errors.New(errors.CodeDBUpdateError, "failed to update power estimate"), 1},
+		{"version conflict", errors.New(errors.CodeVersionConflict, "experiment result was modified concurrently"), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &energyStore{memResultStore: newMemResultStore(), estimateErr: tt.estimateErr}
+			s := NewAlgorithmService(store)
+			s.SetPowerModel(power.NewModel(power.DefaultConfig()))
+
+			result := &model.ExperimentResult{ExperimentID: "exp_1", AlgorithmType: model.AlgorithmTypeDOA}
+			ctx := context.Background()
+			s.recordEnergy(ctx, result, s.beginEnergyMeasurement(ctx), energyUsage{variant: "MUSIC"})
+			if store.reports != tt.reports {
+				t.Errorf("wrote %d energy reports, want %d", store.reports, tt.reports)
+			}
+		})
+	}
+}
+
+func TestBeamformingEnergy(t *testing.T) {
+	ctx := context.Background()
+	controller := irs.NewController(irs.NewSimulator(16, "3.5GHz"))
+	if err := controller.Configure(ctx, &model.IRSConfigRequest{Name: "flat", ElementCount: 16, PhaseShifts: make([]float64, 16), FrequencyBand: "3.5GHz"}); err != nil {
+		t.Fatal(err)
+	}
+	panels := irs.NewManager()
+	panels.Add("irs0", controller)
+
+	store := &energyStore{memResultStore: newMemResultStore()}
+	s := NewAlgorithmService(store)
+	s.SetPowerModel(power.NewModel(power.DefaultConfig()))
+	s.SetIRSPanels(panels)
+	s.SetTransmissionInterval(10 * time.Second)
+	result, err := s.RunBeamforming(ctx, "bf_energy", &model.BeamformingParams{ElementCount: 64, TargetDirection: 0.3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var estimate model.PowerEstimate
+	if err := json.Unmarshal([]byte(store.estimate), &estimate); err != nil {
+		t.Fatal(err)
+	}
+	if estimate.IRSElementCount != 16 || estimate.IRSDynamicEnergy != 0 {
+		t.Errorf("estimate for %d elements with %g J switching, want the 16 panel elements and no switching",
+			estimate.IRSElementCount, estimate.IRSDynamicEnergy)
+	}
+
+	var report model.EnergyReport
+	if err := json.Unmarshal([]byte(store.report), &report); err != nil {
+		t.Fatal(err)
+	}
+	bits := result.EnergyEfficiency * result.TotalPower * 10
+	if report.TransmissionInterval != 10 || bits <= 0 || math.Abs(report.AchievedBits-bits) > 1e-9*bits {
+		t.Errorf("%g bits over %g s, want the %g bits the beam carries in 10 s", report.AchievedBits, report.TransmissionInterval, bits)
+	}
+	if want := report.EstimatedEnergy / bits; math.Abs(report.EnergyPerBit-want) > 1e-9*want {
+		t.Errorf("energy per bit %g J, want %g J", report.EnergyPerBit, want)
+	}
+}
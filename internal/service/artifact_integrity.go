@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/internal/repository/objectstore"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+const verifyPageSize = 100
+
+// VerifyArtifact re-reads the file of an artifact and compares its SHA-256
+// with the checksum recorded at upload, to catch files altered or lost on
+// storage since.
+func (s *ArtifactService) VerifyArtifact(ctx context.Context, id int64) (*model.IntegrityCheck, error) {
+	artifact, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.verify(ctx, artifact)
+}
+
+// VerifyArtifacts verifies every artifact matching query's experiment and
+// type. A file that fails the check does not stop the run.
+func (s *ArtifactService) VerifyArtifacts(ctx context.Context, query *model.ArtifactQuery) (*model.IntegrityReport, error) {
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+
+	report := model.NewIntegrityReport()
+	q := *query
+	q.PageSize = verifyPageSize
+	for q.Page = 1; ; q.Page++ {
+		artifacts, total, err := s.artifacts.List(ctx, &q)
+		if err != nil {
+			return nil, err
+		}
+		for i := range artifacts {
+			check, err := s.verify(ctx, &artifacts[i])
+			if err != nil {
+				return nil, err
+			}
+			report.Add(check)
+		}
+		if len(artifacts) == 0 || int64(q.Page*q.PageSize) >= total {
+			break
+		}
+	}
+
+	if len(report.Failures) > 0 {
+		logger.Warn("Artifact integrity check found damaged files",
+			zap.Int("mismatched", report.Mismatched),
+			zap.Int("missing", report.Missing),
+			zap.Int("unreadable", report.Unreadable),
+		)
+	}
+	return report, nil
+}
+
+func (s *ArtifactService) verify(ctx context.Context, artifact *model.Artifact) (*model.IntegrityCheck, error) {
+	check := &model.IntegrityCheck{
+		ArtifactID: artifact.ID,
+		Path:       artifact.StorageKey,
+		Expected:   artifact.Checksum,
+		CheckedAt:  time.Now(),
+	}
+
+	rc, err := s.store.GetRange(ctx, artifact.StorageKey, 0)
+	if err == objectstore.ErrObjectNotFound {
+		check.Status = model.IntegrityMissing
+		return check, nil
+	}
+	if err != nil {
+		check.Status = model.IntegrityUnreadable
+		check.Error = err.Error()
+		return check, nil
+	}
+	defer rc.Close()
+
+	check.Actual, check.Size, err = checksum(rc)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		check.Status = model.IntegrityUnreadable
+		check.Error = err.Error()
+		return check, nil
+	}
+
+	switch {
+	case artifact.Checksum == "":
+		if err := s.artifacts.UpdateChecksum(ctx, artifact.ID, check.Actual, check.Size); err != nil {
+			return nil, err
+		}
+		check.Status = model.IntegrityRecorded
+	case check.Actual == artifact.Checksum && check.Size == artifact.Size:
+		check.Status = model.IntegrityOK
+	default:
+		check.Status = model.IntegrityMismatch
+		logger.Warn("Artifact checksum mismatch",
+			zap.Int64("id", artifact.ID),
+			zap.String("key", artifact.StorageKey),
+			zap.String("expected", artifact.Checksum),
+			zap.String("actual", check.Actual),
+		)
+	}
+	return check, nil
+}
+
+// checksum returns the hex SHA-256 of r and the number of bytes read.
+func checksum(r io.Reader) (string, int64, error) {
+	hash := sha256.New()
+	n, err := io.Copy(hash, r)
+	if err != nil {
+		return "", n, errors.Wrap(errors.CodeObjectStoreError, "failed to read file", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), n, nil
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/internal/repository/objectstore"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+type ArtifactStore interface {
+	Create(ctx context.Context, artifact *model.Artifact) error
+	GetByID(ctx context.Context, id int64) (*model.Artifact, error)
+	List(ctx context.Context, query *model.ArtifactQuery) ([]model.Artifact, int64, error)
+	Delete(ctx context.Context, id int64) error
+	ListStorageKeys(ctx context.Context) ([]string, error)
+	UpdateBackend(ctx context.Context, key, backend string) error
+	UpdateChecksum(ctx context.Context, id int64, checksum string, size int64) error
+	ListOrphaned(ctx context.Context) ([]model.Artifact, error)
+}
+
+var artifactPrefixes = map[model.ArtifactType]string{
+	model.ArtifactTypeMATLAB:     objectstore.PrefixMATLAB,
+	model.ArtifactTypeIQCapture:  objectstore.PrefixIQCapture,
+	model.ArtifactTypeDataset:    objectstore.PrefixDataset,
+	model.ArtifactTypeCheckpoint: objectstore.PrefixCheckpoint,
+	model.ArtifactTypePlot:       objectstore.PrefixPlot,
+	model.ArtifactTypeExport:     objectstore.PrefixExport,
+	model.ArtifactTypeReport:     objectstore.PrefixReport,
+}
+
+// ArtifactService tracks every file written to the object store so it can be
+// listed, downloaded and deleted per experiment. Objects without a record
+// and records whose experiment is gone are removed by CollectGarbage.
+type ArtifactService struct {
+	store     *objectstore.TieredStore
+	artifacts ArtifactStore
+	gcGrace   time.Duration
+}
+
+func NewArtifactService(store *objectstore.TieredStore, artifacts ArtifactStore, gcGrace time.Duration) *ArtifactService {
+	if gcGrace <= 0 {
+		gcGrace = 24 * time.Hour
+	}
+	s := &ArtifactService{
+		store:     store,
+		artifacts: artifacts,
+		gcGrace:   gcGrace,
+	}
+	if store != nil && artifacts != nil {
+		store.SetMigrationHook(s.onMigrate)
+	}
+	return s
+}
+
+func (s *ArtifactService) available() error {
+	if s.store == nil {
+		return errors.New(errors.CodeServiceUnavailable, "object store not available")
+	}
+	if s.artifacts == nil {
+		return errors.New(errors.CodeServiceUnavailable, "artifact store not available")
+	}
+	return nil
+}
+
+// Upload stores r under the prefix of artifactType and records it with its
+// size and SHA-256 checksum.
+func (s *ArtifactService) Upload(ctx context.Context, artifactType model.ArtifactType, experimentID, name string, r io.Reader, size int64) (*model.Artifact, error) {
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+
+	prefix, ok := artifactPrefixes[artifactType]
+	if !ok {
+		return nil, errors.NewWithDetail(errors.CodeInvalidParam, "unsupported artifact type", string(artifactType))
+	}
+	if name == "" || path.Base(name) != name {
+		return nil, errors.NewWithDetail(errors.CodeInvalidParam, "invalid artifact name", name)
+	}
+
+	owner := experimentID
+	if owner == "" {
+		owner = "shared"
+	}
+	key := prefix + owner + "/" + name
+
+	// The record is created first so that the unique storage key settles
+	// concurrent uploads of the same name before any file is written.
+	artifact := &model.Artifact{
+		ExperimentID: experimentID,
+		ArtifactType: artifactType,
+		Name:         name,
+		StorageKey:   key,
+		Backend:      string(objectstore.BackendLocal),
+	}
+	if err := s.artifacts.Create(ctx, artifact); err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	counter := &countingWriter{}
+	if err := s.store.Put(ctx, key, io.TeeReader(r, io.MultiWriter(hash, counter)), size); err != nil {
+		s.artifacts.Delete(ctx, artifact.ID)
+		return nil, errors.Wrap(errors.CodeObjectStoreError, "failed to store artifact", err)
+	}
+
+	artifact.Size = counter.n
+	artifact.Checksum = hex.EncodeToString(hash.Sum(nil))
+	if err := s.artifacts.UpdateChecksum(ctx, artifact.ID, artifact.Checksum, artifact.Size); err != nil {
+		s.store.Delete(ctx, key)
+		s.artifacts.Delete(ctx, artifact.ID)
+		return nil, err
+	}
+
+	return artifact, nil
+}
+
+func (s *ArtifactService) Get(ctx context.Context, id int64) (*model.Artifact, error) {
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+	return s.artifacts.GetByID(ctx, id)
+}
+
+func (s *ArtifactService) List(ctx context.Context, query *model.ArtifactQuery) ([]model.Artifact, int64, error) {
+	if err := s.available(); err != nil {
+		return nil, 0, err
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 || query.PageSize > 100 {
+		query.PageSize = 20
+	}
+	return s.artifacts.List(ctx, query)
+}
+
+func (s *ArtifactService) Open(ctx context.Context, id int64) (io.ReadCloser, *model.Artifact, error) {
+	return s.OpenAt(ctx, id, 0)
+}
+
+// OpenAt opens the artifact file positioned at offset bytes, allowing
+// interrupted transfers to resume.
+func (s *ArtifactService) OpenAt(ctx context.Context, id int64, offset int64) (io.ReadCloser, *model.Artifact, error) {
+	artifact, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if offset < 0 || offset > artifact.Size {
+		return nil, nil, errors.NewWithDetail(errors.CodeInvalidParam, "offset out of range", fmt.Sprintf("%d of %d", offset, artifact.Size))
+	}
+
+	rc, err := s.store.GetRange(ctx, artifact.StorageKey, offset)
+	if err == objectstore.ErrObjectNotFound {
+		return nil, nil, errors.NewWithDetail(errors.CodeNotFound, "artifact file missing", artifact.StorageKey)
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(errors.CodeObjectStoreError, "failed to open artifact", err)
+	}
+	return rc, artifact, nil
+}
+
+func (s *ArtifactService) Delete(ctx context.Context, id int64) error {
+	artifact, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.Delete(ctx, artifact.StorageKey); err != nil {
+		return errors.Wrap(errors.CodeObjectStoreError, "failed to delete artifact file", err)
+	}
+	return s.artifacts.Delete(ctx, id)
+}
+
+// CollectGarbage deletes hot-tier files that have no artifact record and are
+// older than the grace period, and artifacts whose experiment was removed.
+func (s *ArtifactService) CollectGarbage(ctx context.Context) (*model.ArtifactGCReport, error) {
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+
+	report := &model.ArtifactGCReport{
+		DeletedKeys: make([]string, 0),
+		Timestamp:   time.Now(),
+	}
+
+	objects, err := s.store.Hot().List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeObjectStoreError, "failed to list objects", err)
+	}
+
+	keys, err := s.artifacts.ListStorageKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	referenced := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		referenced[key] = true
+	}
+
+	cutoff := time.Now().Add(-s.gcGrace)
+	for _, obj := range objects {
+		if obj.LastModified.After(cutoff) || referenced[obj.Key] {
+			continue
+		}
+		if err := s.store.Hot().Delete(ctx, obj.Key); err != nil {
+			logger.Warn("Failed to delete orphaned object", zap.String("key", obj.Key), zap.Error(err))
+			continue
+		}
+		report.OrphanedObjects++
+		report.FreedBytes += obj.Size
+		report.DeletedKeys = append(report.DeletedKeys, obj.Key)
+	}
+
+	orphaned, err := s.artifacts.ListOrphaned(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, artifact := range orphaned {
+		if err := s.store.Delete(ctx, artifact.StorageKey); err != nil {
+			logger.Warn("Failed to delete orphaned artifact", zap.String("key", artifact.StorageKey), zap.Error(err))
+			continue
+		}
+		if err := s.artifacts.Delete(ctx, artifact.ID); err != nil {
+			return nil, err
+		}
+		report.OrphanedRecords++
+		report.FreedBytes += artifact.Size
+		report.DeletedKeys = append(report.DeletedKeys, artifact.StorageKey)
+	}
+
+	return report, nil
+}
+
+func (s *ArtifactService) StartGarbageCollection(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.CollectGarbage(ctx)
+			if err != nil {
+				logger.Warn("Artifact garbage collection failed", zap.Error(err))
+				continue
+			}
+			if len(report.DeletedKeys) > 0 {
+				logger.Info("Artifact garbage collection finished",
+					zap.Int("orphaned_objects", report.OrphanedObjects),
+					zap.Int("orphaned_records", report.OrphanedRecords),
+					zap.Int64("freed_bytes", report.FreedBytes),
+				)
+			}
+		}
+	}
+}
+
+func (s *ArtifactService) onMigrate(ctx context.Context, key string, backend objectstore.Backend) {
+	if err := s.artifacts.UpdateBackend(ctx, key, string(backend)); err != nil {
+		logger.Warn("Failed to update artifact backend", zap.String("key", key), zap.Error(err))
+	}
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/internal/repository/objectstore"
+	"isac-cran-system/pkg/errors"
+)
+
+// memArtifactStore keeps artifacts in memory with a unique storage key, like
+// the artifact table.
+type memArtifactStore struct {
+	mu        sync.Mutex
+	nextID    int64
+	artifacts map[int64]*model.Artifact
+	keyLists  int
+}
+
+func newMemArtifactStore() *memArtifactStore {
+	return &memArtifactStore{artifacts: make(map[int64]*model.Artifact)}
+}
+
+func (m *memArtifactStore) Create(ctx context.Context, artifact *model.Artifact) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, a := range m.artifacts {
+		if a.StorageKey == artifact.StorageKey {
+			return errors.NewWithDetail(errors.CodeInvalidParam, "artifact already exists", artifact.StorageKey)
+		}
+	}
+	m.nextID++
+	artifact.ID = m.nextID
+	copied := *artifact
+	m.artifacts[artifact.ID] = &copied
+	return nil
+}
+
+func (m *memArtifactStore) GetByID(ctx context.Context, id int64) (*model.Artifact, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.artifacts[id]
+	if !ok {
+		return nil, errors.New(errors.CodeNotFound, "artifact not found")
+	}
+	copied := *a
+	return &copied, nil
+}
+
+func (m *memArtifactStore) List(ctx context.Context, query *model.ArtifactQuery) ([]model.Artifact, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *memArtifactStore) Delete(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.artifacts, id)
+	return nil
+}
+
+func (m *memArtifactStore) ListStorageKeys(ctx context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keyLists++
+	keys := make([]string, 0, len(m.artifacts))
+	for _, a := range m.artifacts {
+		keys = append(keys, a.StorageKey)
+	}
+	return keys, nil
+}
+
+func (m *memArtifactStore) UpdateBackend(ctx context.Context, key, backend string) error {
+	return nil
+}
+
+func (m *memArtifactStore) UpdateChecksum(ctx context.Context, id int64, checksum string, size int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if a, ok := m.artifacts[id]; ok {
+		a.Checksum, a.Size = checksum, size
+	}
+	return nil
+}
+
+func (m *memArtifactStore) ListOrphaned(ctx context.Context) ([]model.Artifact, error) {
+	return nil, nil
+}
+
+func newTestArtifactService(t *testing.T) (*ArtifactService, *memArtifactStore, string) {
+	t.Helper()
+	dir := t.TempDir()
+	hot, err := objectstore.NewLocalStore(dir, "http://localhost/objects", "test-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifacts := newMemArtifactStore()
+	return NewArtifactService(objectstore.NewTieredStore(hot, nil, 0), artifacts, time.Hour), artifacts, dir
+}
+
+func TestArtifactUploadConcurrentSameName(t *testing.T) {
+	s, artifacts, dir := newTestArtifactService(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	results := make([]*model.Artifact, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := strings.Repeat(fmt.Sprintf("upload-%d;", i), 1000)
+			results[i], _ = s.Upload(ctx, model.ArtifactTypeDataset, "exp-1", "data.npy", strings.NewReader(body), int64(len(body)))
+		}(i)
+	}
+	wg.Wait()
+
+	var winner *model.Artifact
+	for _, a := range results {
+		if a == nil {
+			continue
+		}
+		if winner != nil {
+			t.Fatal("Expected only one upload of the same name to succeed")
+		}
+		winner = a
+	}
+	if winner == nil {
+		t.Fatal("Expected one upload to succeed")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "datasets", "exp-1", "data.npy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	stored, _ := artifacts.GetByID(ctx, winner.ID)
+	if stored.Checksum != hex.EncodeToString(sum[:]) || stored.Size != int64(len(data)) {
+		t.Errorf("Expected the stored file to match the recorded checksum and size, got %s/%d", stored.Checksum, stored.Size)
+	}
+}
+
+func TestArtifactUploadExisting(t *testing.T) {
+	s, _, _ := newTestArtifactService(t)
+	ctx := context.Background()
+
+	if _, err := s.Upload(ctx, model.ArtifactTypePlot, "", "beam.png", strings.NewReader("png"), 3); err != nil {
+		t.Fatal(err)
+	}
+	_, err := s.Upload(ctx, model.ArtifactTypePlot, "", "beam.png", strings.NewReader("other"), 5)
+	if !errors.IsCode(err, errors.CodeInvalidParam) {
+		t.Fatalf("Expected the second upload to be rejected, got %v", err)
+	}
+
+	rc, a, err := s.Open(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	body, _ := io.ReadAll(rc)
+	if string(body) != "png" || a.StorageKey != "plots/shared/beam.png" {
+		t.Errorf("Expected the first upload to be kept, got %q at %s", body, a.StorageKey)
+	}
+}
+
+func TestArtifactCollectGarbage(t *testing.T) {
+	s, artifacts, dir := newTestArtifactService(t)
+	ctx := context.Background()
+
+	if _, err := s.Upload(ctx, model.ArtifactTypeExport, "exp-1", "kept.json", strings.NewReader("{}"), 2); err != nil {
+		t.Fatal(err)
+	}
+	hot := s.store.Hot()
+	for _, key := range []string{"exports/exp-1/orphan.json", "exports/exp-1/fresh.json"} {
+		if err := hot.Put(ctx, key, strings.NewReader("{}"), 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	for _, name := range []string{"kept.json", "orphan.json"} {
+		os.Chtimes(filepath.Join(dir, "exports", "exp-1", name), old, old)
+	}
+
+	report, err := s.CollectGarbage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OrphanedObjects != 1 || len(report.DeletedKeys) != 1 || report.DeletedKeys[0] != "exports/exp-1/orphan.json" {
+		t.Errorf("Expected only the old unreferenced object to be collected, got %+v", report)
+	}
+	if artifacts.keyLists != 1 {
+		t.Errorf("Expected the referenced keys to be loaded once, got %d queries", artifacts.keyLists)
+	}
+	for _, name := range []string{"kept.json", "fresh.json"} {
+		if _, err := os.Stat(filepath.Join(dir, "exports", "exp-1", name)); err != nil {
+			t.Errorf("Expected %s to be kept: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,550 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/algorithm/beamforming"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// BeamService runs beam sweeps: it measures the received power with every
+// beam of a codebook on an IRS panel or the receive array, selects the
+// strongest and, if asked, keeps tracking it. Sweeps are kept in memory,
+// like capture sessions; the channel measurements of the irs target are
+// stored as usual as well.
+type BeamService struct {
+	channels    *ChannelService
+	irs         *IRSService
+	snapshots   SnapshotSource
+	gate        DeviceGate
+	events      EventPublisher
+	maxDuration time.Duration
+
+	mu     sync.Mutex
+	sweeps map[string]*beamSweep
+	seq    int
+}
+
+type beamSweep struct {
+	req     *model.BeamSweepRequest
+	entries [][]float64
+	// label names the codebook in the configurations the sweep writes
+	label  string
+	dft    bool
+	sweep  model.BeamSweep
+	beams  []model.BeamMeasurement
+	track  []model.BeamTrackPoint
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewBeamService(channels *ChannelService, irs *IRSService, maxDuration time.Duration) *BeamService {
+	if maxDuration <= 0 {
+		maxDuration = time.Hour
+	}
+	return &BeamService{
+		channels:    channels,
+		irs:         irs,
+		maxDuration: maxDuration,
+		sweeps:      make(map[string]*beamSweep),
+	}
+}
+
+// SetSnapshotSource provides the multi-channel captures the array target
+// combines.
+func (s *BeamService) SetSnapshotSource(src SnapshotSource) {
+	s.snapshots = src
+}
+
+func (s *BeamService) SetDeviceGate(gate DeviceGate) {
+	s.gate = gate
+}
+
+// Start checks that the target can be swept with the codebook and starts
+// the sweep. The sweep keeps the caller's reservation holder, so that the
+// devices stay available to it while someone else's reservation does not.
+func (s *BeamService) Start(ctx context.Context, req *model.BeamSweepRequest) (*model.BeamSweep, error) {
+	if err := req.Validate(); err != nil {
+		return nil, errors.Wrap(errors.CodeInvalidParam, "invalid beam sweep", err)
+	}
+	p := req.WithDefaults()
+
+	elements, err := s.elementCount(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	bs := &beamSweep{req: p, label: p.Codebook}
+	if p.Codebook != "" {
+		if s.irs == nil {
+			return nil, errors.New(errors.CodeServiceUnavailable, "IRS codebooks not available")
+		}
+		codebook, err := s.irs.GetCodebook(ctx, p.Codebook)
+		if err != nil {
+			return nil, err
+		}
+		if codebook.ElementCount != elements {
+			return nil, errors.Wrap(errors.CodeInvalidParam, "invalid beam sweep", &model.ValidationError{
+				Field:   "codebook",
+				Message: fmt.Sprintf("codebook %s has %d elements, the %s has %d", codebook.Name, codebook.ElementCount, p.Target, elements),
+			})
+		}
+		bs.entries = codebook.Entries
+		bs.dft = codebook.Kind == model.CodebookKindDFT
+	} else {
+		size := p.Size
+		if size == 0 {
+			size = elements
+		}
+		bs.entries = beamforming.DFTCodebook(elements, size)
+		bs.label = fmt.Sprintf("dft%d", size)
+		bs.dft = true
+	}
+	if len(bs.entries) == 0 {
+		return nil, errors.Wrap(errors.CodeInvalidParam, "invalid beam sweep",
+			&model.ValidationError{Field: "codebook", Message: "codebook " + p.Codebook + " has no entries"})
+	}
+
+	duration := s.maxDuration
+	if p.Duration > 0 {
+		if requested := seconds(p.Duration); requested < duration {
+			duration = requested
+		}
+	}
+
+	now := time.Now()
+	runCtx, cancel := context.WithCancel(WithHolder(context.Background(), HolderFromContext(ctx)))
+	s.mu.Lock()
+	s.seq++
+	id := fmt.Sprintf("beam_%s_%d", now.UTC().Format("20060102T150405"), s.seq)
+	bs.sweep = model.BeamSweep{
+		ID:           id,
+		ExperimentID: p.ExperimentID,
+		Target:       p.Target,
+		IRSID:        p.IRSID,
+		Codebook:     p.Codebook,
+		Size:         len(bs.entries),
+		Track:        p.Track,
+		Status:       model.BeamSweepRunning,
+		StartedAt:    now,
+	}
+	bs.cancel = cancel
+	bs.done = make(chan struct{})
+	s.sweeps[id] = bs
+	sweep := bs.snapshot()
+	s.mu.Unlock()
+
+	go s.run(runCtx, bs, duration)
+
+	logger.Info("Beam sweep started",
+		zap.String("id", id),
+		zap.String("experiment_id", p.ExperimentID),
+		zap.String("target", p.Target),
+		zap.String("codebook", bs.label),
+		zap.Int("beams", len(bs.entries)),
+		zap.Bool("track", p.Track),
+	)
+	return sweep, nil
+}
+
+// elementCount checks that the devices of the target are there and free,
+// and returns how many elements its beams steer.
+func (s *BeamService) elementCount(ctx context.Context, p *model.BeamSweepRequest) (int, error) {
+	if p.Target == model.BeamTargetArray {
+		if s.snapshots == nil {
+			return 0, deviceUnavailable("usrp")
+		}
+		if err := checkReservation(ctx, s.gate, model.ReservableDeviceUSRP); err != nil {
+			return 0, err
+		}
+		return s.snapshots.ChannelCount(), nil
+	}
+
+	if s.irs == nil {
+		return 0, deviceUnavailable("irs")
+	}
+	if s.channels == nil || s.channels.receiver == nil {
+		return 0, deviceUnavailable("usrp")
+	}
+	controller, err := s.irs.controller(p.IRSID)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkReservation(ctx, s.gate, model.ReservableDeviceIRS); err != nil {
+		return 0, err
+	}
+	if err := checkReservation(ctx, s.gate, model.ReservableDeviceUSRP); err != nil {
+		return 0, err
+	}
+	elements := controller.ElementCount()
+	if elements == 0 {
+		return 0, errors.New(errors.CodeIRSDeviceError, "IRS panel element count unknown")
+	}
+	return elements, nil
+}
+
+// run sweeps every beam, settles on the strongest and tracks it for
+// duration if the sweep asks to.
+func (s *BeamService) run(ctx context.Context, bs *beamSweep, duration time.Duration) {
+	defer bs.cancel()
+
+	indices := make([]int, len(bs.entries))
+	for i := range indices {
+		indices[i] = i
+	}
+	beams, err := s.measure(ctx, bs, indices, func(m model.BeamMeasurement) {
+		s.mu.Lock()
+		bs.beams = append(bs.beams, m)
+		bs.sweep.Measured++
+		s.mu.Unlock()
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("stopped after %d of %d beams", len(beams), len(bs.entries))
+		}
+		s.finish(bs, model.BeamSweepFailed, err)
+		return
+	}
+
+	best := strongest(beams)
+	if err := s.settle(ctx, bs, best.Index, true); err != nil {
+		s.finish(bs, model.BeamSweepFailed, err)
+		return
+	}
+	s.mu.Lock()
+	bs.sweep.Best = &best
+	if bs.req.Track {
+		bs.sweep.Status = model.BeamSweepTracking
+	}
+	sweep := bs.snapshot()
+	s.mu.Unlock()
+	publish(s.events, TopicBeam+"."+sweep.ID, sweep)
+
+	logger.Info("Beam selected",
+		zap.String("id", sweep.ID),
+		zap.Int("beam", best.Index),
+		zap.Float64("power_db", best.Power),
+	)
+
+	if bs.req.Track {
+		trackCtx, cancel := context.WithTimeout(ctx, duration)
+		s.track(trackCtx, bs)
+		cancel()
+	}
+	s.finish(bs, model.BeamSweepCompleted, nil)
+}
+
+// track measures the best beam and its neighbours every track interval and
+// moves to a neighbour that beats the best by more than the hysteresis.
+// Failed rounds are counted and skipped.
+func (s *BeamService) track(ctx context.Context, bs *beamSweep) {
+	ticker := time.NewTicker(seconds(bs.req.TrackInterval))
+	defer ticker.Stop()
+	for round := 1; ; round++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		current := bs.sweep.Best.Index
+		s.mu.Unlock()
+		beams, err := s.measure(ctx, bs, neighbourhood(current, bs.req.TrackSpan, len(bs.entries)), nil)
+		if ctx.Err() != nil {
+			return
+		}
+		var chosen model.BeamMeasurement
+		switched := false
+		if err == nil {
+			// the current beam is measured first
+			chosen = beams[0]
+			if best := strongest(beams); best.Power > chosen.Power+bs.req.Hysteresis {
+				chosen, switched = best, true
+			}
+			err = s.settle(ctx, bs, chosen.Index, switched)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if err != nil {
+			bs.sweep.Missed++
+			bs.sweep.Error = err.Error()
+			s.mu.Unlock()
+			logger.Warn("Beam tracking round failed", zap.String("id", bs.sweep.ID), zap.Int("round", round), zap.Error(err))
+			continue
+		}
+		bs.sweep.Rounds++
+		bs.sweep.Best = &chosen
+		bs.track = append(bs.track, model.BeamTrackPoint{
+			Round:     round,
+			Index:     chosen.Index,
+			Power:     chosen.Power,
+			Switched:  switched,
+			Timestamp: chosen.Timestamp,
+		})
+		if len(bs.track) > model.MaxBeamTrackPoints {
+			bs.track = bs.track[len(bs.track)-model.MaxBeamTrackPoints:]
+		}
+		sweep := bs.snapshot()
+		s.mu.Unlock()
+
+		if switched {
+			publish(s.events, TopicBeam+"."+sweep.ID, sweep)
+			logger.Info("Beam switched",
+				zap.String("id", sweep.ID),
+				zap.Int("beam", chosen.Index),
+				zap.Float64("power_db", chosen.Power),
+			)
+		}
+	}
+}
+
+// measure returns the power with each of the beams indices, in order,
+// calling each as it is measured. The irs target writes every beam to the
+// panel and measures the channel through it; the array target combines
+// one capture with every beam.
+func (s *BeamService) measure(ctx context.Context, bs *beamSweep, indices []int, each func(model.BeamMeasurement)) ([]model.BeamMeasurement, error) {
+	beams := make([]model.BeamMeasurement, 0, len(indices))
+	keep := func(m model.BeamMeasurement) {
+		if bs.dft {
+			angle := model.DFTBeamAngle(m.Index, len(bs.entries))
+			m.Angle = &angle
+		}
+		beams = append(beams, m)
+		if each != nil {
+			each(m)
+		}
+	}
+
+	if bs.req.Target == model.BeamTargetArray {
+		data, err := s.snapshots.CollectMultiChannel(ctx, seconds(bs.req.Dwell))
+		if err != nil {
+			return nil, errors.Wrap(errors.CodeUSRPReceiveError, "failed to collect beam sweep capture", err)
+		}
+		now := time.Now()
+		for _, index := range indices {
+			keep(model.BeamMeasurement{Index: index, Power: combinedPower(data, bs.entries[index]), Timestamp: now})
+		}
+		return beams, nil
+	}
+
+	for _, index := range indices {
+		if err := s.irs.applyBeam(ctx, bs.req.IRSID, fmt.Sprintf("%s[%d]", bs.label, index), bs.entries[index]); err != nil {
+			return beams, err
+		}
+		measurement, err := s.channels.CollectData(ctx, &model.ChannelCollectRequest{
+			ExperimentID:  bs.req.ExperimentID,
+			UserID:        bs.req.UserID,
+			FrequencyBand: bs.req.FrequencyBand,
+			Duration:      bs.req.Dwell,
+		})
+		if err != nil {
+			return beams, err
+		}
+		var sum float64
+		for _, a := range measurement.Amplitude {
+			sum += a * a
+		}
+		keep(model.BeamMeasurement{
+			Index:     index,
+			Power:     powerDB(sum / float64(max(len(measurement.Amplitude), 1))),
+			SNR:       measurement.SNR,
+			Timestamp: measurement.Timestamp,
+		})
+	}
+	return beams, nil
+}
+
+// settle leaves the panel of an irs sweep on beam index, recording the
+// configuration when the sweep has newly selected it. The array target has
+// nothing to set.
+func (s *BeamService) settle(ctx context.Context, bs *beamSweep, index int, selected bool) error {
+	if bs.req.Target == model.BeamTargetArray {
+		return nil
+	}
+	if err := s.irs.applyBeam(ctx, bs.req.IRSID, fmt.Sprintf("%s[%d]", bs.label, index), bs.entries[index]); err != nil {
+		return err
+	}
+	if selected {
+		s.irs.recordBeam(ctx, bs.req.IRSID)
+	}
+	return nil
+}
+
+// combinedPower is the mean power of the channels of data combined with the
+// unit-norm weights of phases, wᴴx with wₙ = e^{jφₙ}/√N.
+func combinedPower(data [][]model.ChannelDataPoint, phases []float64) float64 {
+	samples := 0
+	for _, ch := range data {
+		if samples == 0 || len(ch) < samples {
+			samples = len(ch)
+		}
+	}
+	if samples == 0 || len(data) != len(phases) {
+		return powerDB(0)
+	}
+	scale := 1 / math.Sqrt(float64(len(phases)))
+	var sum float64
+	for t := 0; t < samples; t++ {
+		var y complex128
+		for n, ch := range data {
+			y += cmplx.Rect(scale, -phases[n]) * complex(ch[t].I, ch[t].Q)
+		}
+		sum += real(y)*real(y) + imag(y)*imag(y)
+	}
+	return powerDB(sum / float64(samples))
+}
+
+// powerDB is a mean power in dB, floored at -300 dB for silence.
+func powerDB(p float64) float64 {
+	if p <= 1e-30 {
+		return -300
+	}
+	return 10 * math.Log10(p)
+}
+
+func strongest(beams []model.BeamMeasurement) model.BeamMeasurement {
+	best := beams[0]
+	for _, m := range beams[1:] {
+		if m.Power > best.Power {
+			best = m
+		}
+	}
+	return best
+}
+
+// neighbourhood is beam center followed by the span beams on either side
+// of it, wrapping around the codebook.
+func neighbourhood(center, span, size int) []int {
+	indices := []int{center}
+	seen := map[int]bool{center: true}
+	for d := 1; d <= span; d++ {
+		for _, i := range []int{(center + d) % size, (center - d + size) % size} {
+			if !seen[i] {
+				seen[i] = true
+				indices = append(indices, i)
+			}
+		}
+	}
+	return indices
+}
+
+func (s *BeamService) finish(bs *beamSweep, status model.BeamSweepStatus, err error) {
+	s.mu.Lock()
+	stopped := time.Now()
+	bs.sweep.StoppedAt = &stopped
+	bs.sweep.Status = status
+	if err != nil {
+		bs.sweep.Error = err.Error()
+	}
+	sweep := bs.snapshot()
+	s.mu.Unlock()
+	close(bs.done)
+	publish(s.events, TopicBeam+"."+sweep.ID, sweep)
+
+	logger.Info("Beam sweep finished",
+		zap.String("id", sweep.ID),
+		zap.String("status", string(sweep.Status)),
+		zap.Int("measured", sweep.Measured),
+		zap.Int("rounds", sweep.Rounds),
+		zap.String("error", sweep.Error),
+	)
+}
+
+// Stop ends a sweep, or its tracking, and waits for it to finish. A sweep
+// stopped before it measured every beam fails.
+func (s *BeamService) Stop(ctx context.Context, id string) (*model.BeamSweep, error) {
+	s.mu.Lock()
+	bs, ok := s.sweeps[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil, errors.NewWithDetail(errors.CodeNotFound, "beam sweep not found", id)
+	}
+
+	bs.cancel()
+	select {
+	case <-bs.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return s.Get(id)
+}
+
+// StopAll ends every running sweep; used on shutdown.
+func (s *BeamService) StopAll() {
+	s.mu.Lock()
+	sweeps := make([]*beamSweep, 0, len(s.sweeps))
+	for _, bs := range s.sweeps {
+		sweeps = append(sweeps, bs)
+	}
+	s.mu.Unlock()
+
+	for _, bs := range sweeps {
+		bs.cancel()
+		<-bs.done
+	}
+}
+
+func (s *BeamService) Get(id string) (*model.BeamSweep, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bs, ok := s.sweeps[id]
+	if !ok {
+		return nil, errors.NewWithDetail(errors.CodeNotFound, "beam sweep not found", id)
+	}
+	return bs.snapshot(), nil
+}
+
+// List returns every sweep, newest first.
+func (s *BeamService) List() []*model.BeamSweep {
+	s.mu.Lock()
+	sweeps := make([]*model.BeamSweep, 0, len(s.sweeps))
+	for _, bs := range s.sweeps {
+		sweeps = append(sweeps, bs.snapshot())
+	}
+	s.mu.Unlock()
+	sort.Slice(sweeps, func(i, j int) bool {
+		if sweeps[i].StartedAt.Equal(sweeps[j].StartedAt) {
+			return sweeps[i].ID > sweeps[j].ID
+		}
+		return sweeps[i].StartedAt.After(sweeps[j].StartedAt)
+	})
+	return sweeps
+}
+
+// Result returns the beams measured so far and the tracking history.
+func (s *BeamService) Result(id string) (*model.BeamSweepResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bs, ok := s.sweeps[id]
+	if !ok {
+		return nil, errors.NewWithDetail(errors.CodeNotFound, "beam sweep not found", id)
+	}
+	return &model.BeamSweepResult{
+		Sweep: bs.snapshot(),
+		Beams: append([]model.BeamMeasurement{}, bs.beams...),
+		Track: append([]model.BeamTrackPoint{}, bs.track...),
+	}, nil
+}
+
+// snapshot copies the sweep state; s.mu must be held.
+func (bs *beamSweep) snapshot() *model.BeamSweep {
+	sweep := bs.sweep
+	if sweep.Best != nil {
+		best := *sweep.Best
+		sweep.Best = &best
+	}
+	return &sweep
+}
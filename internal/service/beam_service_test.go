@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+// movingSource is a plane wave source whose direction can change while a
+// sweep runs.
+type movingSource struct {
+	mu     sync.Mutex
+	source planeWaveSource
+}
+
+func (s *movingSource) CollectMultiChannel(ctx context.Context, duration time.Duration) ([][]model.ChannelDataPoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.source.CollectMultiChannel(ctx, duration)
+}
+
+func (s *movingSource) ChannelCount() int { return s.source.ChannelCount() }
+
+func (s *movingSource) GetConfig() (sampleRate, centerFreq float64) {
+	return s.source.GetConfig()
+}
+
+func (s *movingSource) move(azimuth float64) {
+	s.mu.Lock()
+	s.source.azimuth = azimuth
+	s.mu.Unlock()
+}
+
+func TestBeamSweepArray(t *testing.T) {
+	src := &movingSource{source: planeWaveSource{azimuth: model.DFTBeamAngle(2, 8), rand: rand.New(rand.NewSource(1))}}
+	s := NewBeamService(nil, nil, time.Minute)
+	s.SetSnapshotSource(src)
+	ctx := context.Background()
+
+	if _, err := s.Start(ctx, &model.BeamSweepRequest{ExperimentID: "exp", Target: model.BeamTargetArray, IRSID: "panel0"}); !errors.IsCode(err, errors.CodeInvalidParam) {
+		t.Errorf("irs_id on the array error = %v, want invalid parameter", err)
+	}
+
+	sweep, err := s.Start(ctx, &model.BeamSweepRequest{
+		ExperimentID:  "exp_beam",
+		Target:        model.BeamTargetArray,
+		Size:          8,
+		Dwell:         0.01,
+		Track:         true,
+		TrackInterval: 0.1,
+		Hysteresis:    3,
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if sweep.Size != 8 || sweep.Status != model.BeamSweepRunning {
+		t.Fatalf("started sweep = %+v", sweep)
+	}
+
+	waitFor := func(what string, done func(*model.BeamSweep) bool) *model.BeamSweep {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			sweep, err := s.Get(sweep.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if done(sweep) {
+				return sweep
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%s: sweep = %+v", what, sweep)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	sweep = waitFor("no beam selected", func(b *model.BeamSweep) bool { return b.Best != nil })
+	if sweep.Status != model.BeamSweepTracking || sweep.Best.Index != 2 || sweep.Measured != 8 {
+		t.Fatalf("after the sweep: %+v, best %+v", sweep, sweep.Best)
+	}
+
+	src.move(model.DFTBeamAngle(3, 8))
+	waitFor("did not follow the source", func(b *model.BeamSweep) bool { return b.Best.Index == 3 })
+
+	sweep, err = s.Stop(ctx, sweep.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sweep.Status != model.BeamSweepCompleted || sweep.StoppedAt == nil {
+		t.Errorf("stopped sweep = %+v", sweep)
+	}
+
+	result, err := s.Result(sweep.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Beams) != 8 || result.Beams[2].Angle == nil {
+		t.Errorf("beams = %+v", result.Beams)
+	}
+	switched := false
+	for _, p := range result.Track {
+		switched = switched || p.Switched && p.Index == 3
+	}
+	if !switched {
+		t.Errorf("track = %+v, want a switch to beam 3", result.Track)
+	}
+
+	if _, err := s.Get("beam_missing"); !errors.IsCode(err, errors.CodeNotFound) {
+		t.Errorf("missing sweep error = %v", err)
+	}
+}
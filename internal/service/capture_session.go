@@ -0,0 +1,467 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// captureLead is how far ahead of the request the first tick of a session
+// is, so that every stream is waiting for it when it comes.
+const captureLead = 100 * time.Millisecond
+
+// CaptureSessionService runs capture sessions, which sample the channel,
+// the sensors and the IRS status on one clock so that the streams can be
+// analysed together. Sessions and their samples are kept in memory; the
+// channel measurements and sensor readings are stored as usual as well.
+type CaptureSessionService struct {
+	channels    *ChannelService
+	sensors     *SensorService
+	irs         *IRSService
+	maxDuration time.Duration
+	gate        DeviceGate
+
+	mu       sync.Mutex
+	sessions map[string]*captureSession
+	seq      int
+}
+
+type captureSession struct {
+	req       *model.CaptureSessionRequest
+	sensorIDs []string
+	session   model.CaptureSession
+	channel   []model.CaptureChannelSample
+	sensors   []model.CaptureSensorSample
+	irs       []model.CaptureIRSSample
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// captureRead takes the sample of one stream at a tick and keeps it.
+type captureRead func(ctx context.Context, sample model.CaptureSample) error
+
+func NewCaptureSessionService(channels *ChannelService, sensors *SensorService, irs *IRSService, maxDuration time.Duration) *CaptureSessionService {
+	if maxDuration <= 0 {
+		maxDuration = time.Hour
+	}
+	return &CaptureSessionService{
+		channels:    channels,
+		sensors:     sensors,
+		irs:         irs,
+		maxDuration: maxDuration,
+		sessions:    make(map[string]*captureSession),
+	}
+}
+
+func (s *CaptureSessionService) SetDeviceGate(gate DeviceGate) {
+	s.gate = gate
+}
+
+// Start checks that every stream of the request can be read and starts
+// them together at the first tick.
+func (s *CaptureSessionService) Start(ctx context.Context, req *model.CaptureSessionRequest) (*model.CaptureSession, error) {
+	if err := req.Validate(); err != nil {
+		return nil, errors.Wrap(errors.CodeInvalidParam, "invalid capture session", err)
+	}
+	p := req.WithDefaults()
+
+	var sensorIDs []string
+	if p.Has(model.CaptureStreamChannel) {
+		if s.channels == nil || s.channels.receiver == nil {
+			return nil, deviceUnavailable("usrp")
+		}
+		if err := checkReservation(ctx, s.gate, model.ReservableDeviceUSRP); err != nil {
+			return nil, err
+		}
+	}
+	if p.Has(model.CaptureStreamSensors) {
+		if s.sensors == nil || s.sensors.collector == nil {
+			return nil, deviceUnavailable("sensor")
+		}
+		sensorIDs = p.SensorIDs
+		if len(sensorIDs) == 0 {
+			sensorIDs = s.activeSensors(ctx)
+		}
+		if len(sensorIDs) == 0 {
+			return nil, errors.Wrap(errors.CodeInvalidParam, "invalid capture session",
+				&model.ValidationError{Field: "sensor_ids", Message: "there are no active sensors to read"})
+		}
+	}
+	if p.Has(model.CaptureStreamIRS) {
+		if s.irs == nil {
+			return nil, deviceUnavailable("irs")
+		}
+		if _, err := s.irs.controller(p.IRSID); err != nil {
+			return nil, err
+		}
+	}
+
+	duration := s.maxDuration
+	if p.Duration > 0 {
+		if requested := time.Duration(p.Duration * float64(time.Second)); requested < duration {
+			duration = requested
+		}
+	}
+
+	now := time.Now()
+	start := now.Add(captureLead)
+	runCtx, cancel := context.WithDeadline(context.Background(), start.Add(duration))
+	s.mu.Lock()
+	s.seq++
+	id := fmt.Sprintf("cap_%s_%d", now.UTC().Format("20060102T150405"), s.seq)
+	cs := &captureSession{
+		req:       p,
+		sensorIDs: sensorIDs,
+		session: model.CaptureSession{
+			ID:           id,
+			ExperimentID: p.ExperimentID,
+			Description:  p.Description,
+			Streams:      append([]string(nil), p.Streams...),
+			Interval:     p.Interval,
+			Status:       model.CaptureSessionRunning,
+			StartedAt:    start,
+			Samples:      make(map[string]int),
+			Missed:       make(map[string]int),
+			Errors:       make(map[string]string),
+		},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	for _, stream := range p.Streams {
+		cs.session.Samples[stream] = 0
+		cs.session.Missed[stream] = 0
+	}
+	s.sessions[id] = cs
+	session := cs.snapshot()
+	s.mu.Unlock()
+
+	reads := map[string]captureRead{
+		model.CaptureStreamChannel: s.readChannel(cs),
+		model.CaptureStreamSensors: s.readSensors(cs),
+		model.CaptureStreamIRS:     s.readIRS(cs),
+	}
+	var wg sync.WaitGroup
+	for _, stream := range p.Streams {
+		wg.Add(1)
+		go func(stream string) {
+			defer wg.Done()
+			s.runStream(runCtx, cs, stream, reads[stream])
+		}(stream)
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+		s.finish(cs)
+	}()
+
+	logger.Info("Capture session started",
+		zap.String("id", id),
+		zap.String("experiment_id", p.ExperimentID),
+		zap.Strings("streams", p.Streams),
+		zap.Float64("interval_s", p.Interval),
+		zap.Duration("max_duration", duration),
+	)
+	return session, nil
+}
+
+func (s *CaptureSessionService) activeSensors(ctx context.Context) []string {
+	sensors, err := s.sensors.ListSensors(ctx, "")
+	if err != nil {
+		return nil
+	}
+	now := time.Now()
+	var ids []string
+	for _, info := range sensors {
+		if info.LifecycleAt(now) == model.SensorLifecycleActive {
+			ids = append(ids, info.SensorID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// runStream reads stream at every tick of the session until it ends. Ticks
+// that pass while a read is still running are skipped and counted as
+// missed, so that the samples stay on the session clock.
+func (s *CaptureSessionService) runStream(ctx context.Context, cs *captureSession, stream string, read captureRead) {
+	start := cs.session.StartedAt
+	interval := time.Duration(cs.session.Interval * float64(time.Second))
+	for tick := 0; ; {
+		at := start.Add(time.Duration(tick) * interval)
+		timer := time.NewTimer(time.Until(at))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		err := read(ctx, model.CaptureSample{Tick: tick, Offset: at.Sub(start).Seconds(), Timestamp: at})
+		if ctx.Err() != nil {
+			// a read cut short by the end of the session is not a sample
+			return
+		}
+		next := int(time.Since(start)/interval) + 1
+		s.mu.Lock()
+		if err != nil {
+			cs.session.Missed[stream]++
+			cs.session.Errors[stream] = err.Error()
+		} else {
+			cs.session.Samples[stream]++
+		}
+		cs.session.Missed[stream] += next - tick - 1
+		full := cs.session.Samples[stream] >= model.MaxCaptureSessionSamples
+		if full {
+			cs.session.Errors[stream] = fmt.Sprintf("stopped after %d samples", model.MaxCaptureSessionSamples)
+		}
+		s.mu.Unlock()
+		if err != nil {
+			logger.Warn("Capture session read failed", zap.String("id", cs.session.ID), zap.String("stream", stream), zap.Int("tick", tick), zap.Error(err))
+		}
+		if full {
+			return
+		}
+		tick = next
+	}
+}
+
+func (s *CaptureSessionService) readChannel(cs *captureSession) captureRead {
+	return func(ctx context.Context, sample model.CaptureSample) error {
+		measurement, err := s.channels.CollectData(ctx, &model.ChannelCollectRequest{
+			ExperimentID:  cs.req.ExperimentID,
+			UserID:        cs.req.UserID,
+			FrequencyBand: cs.req.FrequencyBand,
+			Duration:      cs.req.ChannelDuration,
+		})
+		if err != nil {
+			return err
+		}
+		summary := *measurement
+		summary.Amplitude, summary.Phase = nil, nil
+		s.mu.Lock()
+		cs.channel = append(cs.channel, model.CaptureChannelSample{CaptureSample: sample, Measurement: &summary})
+		s.mu.Unlock()
+		return nil
+	}
+}
+
+// readSensors keeps the readings of a tick unless every sensor failed.
+func (s *CaptureSessionService) readSensors(cs *captureSession) captureRead {
+	return func(ctx context.Context, sample model.CaptureSample) error {
+		readings, err := s.sensors.ReadSensors(ctx, cs.sensorIDs)
+		if err != nil {
+			return err
+		}
+		var failure string
+		for _, r := range readings {
+			if r.Error == "" {
+				failure = ""
+				break
+			}
+			failure = r.SensorID + ": " + r.Error
+		}
+		if failure != "" {
+			return errors.New(errors.CodeSensorDataError, failure)
+		}
+		s.mu.Lock()
+		cs.sensors = append(cs.sensors, model.CaptureSensorSample{CaptureSample: sample, Readings: readings})
+		s.mu.Unlock()
+		return nil
+	}
+}
+
+func (s *CaptureSessionService) readIRS(cs *captureSession) captureRead {
+	return func(ctx context.Context, sample model.CaptureSample) error {
+		status, err := s.irs.GetStatus(ctx, cs.req.IRSID)
+		if err != nil {
+			return err
+		}
+		status.PhaseShifts = append([]float64(nil), status.PhaseShifts...)
+		s.mu.Lock()
+		cs.irs = append(cs.irs, model.CaptureIRSSample{CaptureSample: sample, Status: status})
+		s.mu.Unlock()
+		return nil
+	}
+}
+
+// finish closes a session whose streams have all ended. It fails only if
+// no stream kept a single sample.
+func (s *CaptureSessionService) finish(cs *captureSession) {
+	s.mu.Lock()
+	stopped := time.Now()
+	if stopped.Before(cs.session.StartedAt) {
+		stopped = cs.session.StartedAt
+	}
+	cs.session.StoppedAt = &stopped
+	cs.session.Status = model.CaptureSessionCompleted
+	total := 0
+	for _, n := range cs.session.Samples {
+		total += n
+	}
+	if total == 0 && len(cs.session.Errors) > 0 {
+		cs.session.Status = model.CaptureSessionFailed
+	}
+	session := cs.snapshot()
+	s.mu.Unlock()
+	close(cs.done)
+
+	logger.Info("Capture session finished",
+		zap.String("id", session.ID),
+		zap.String("status", string(session.Status)),
+		zap.Any("samples", session.Samples),
+		zap.Any("missed", session.Missed),
+	)
+}
+
+// Stop ends a session and waits for its streams to finish their reads.
+func (s *CaptureSessionService) Stop(ctx context.Context, id string) (*model.CaptureSession, error) {
+	s.mu.Lock()
+	cs, ok := s.sessions[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil, errors.NewWithDetail(errors.CodeNotFound, "capture session not found", id)
+	}
+
+	cs.cancel()
+	select {
+	case <-cs.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return s.Get(id)
+}
+
+// StopAll ends every running session; used on shutdown.
+func (s *CaptureSessionService) StopAll() {
+	s.mu.Lock()
+	sessions := make([]*captureSession, 0, len(s.sessions))
+	for _, cs := range s.sessions {
+		sessions = append(sessions, cs)
+	}
+	s.mu.Unlock()
+
+	for _, cs := range sessions {
+		cs.cancel()
+		<-cs.done
+	}
+}
+
+func (s *CaptureSessionService) Get(id string) (*model.CaptureSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cs, ok := s.sessions[id]
+	if !ok {
+		return nil, errors.NewWithDetail(errors.CodeNotFound, "capture session not found", id)
+	}
+	return cs.snapshot(), nil
+}
+
+// List returns every session, newest first.
+func (s *CaptureSessionService) List() []*model.CaptureSession {
+	s.mu.Lock()
+	sessions := make([]*model.CaptureSession, 0, len(s.sessions))
+	for _, cs := range s.sessions {
+		sessions = append(sessions, cs.snapshot())
+	}
+	s.mu.Unlock()
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].StartedAt.Equal(sessions[j].StartedAt) {
+			return sessions[i].ID > sessions[j].ID
+		}
+		return sessions[i].StartedAt.After(sessions[j].StartedAt)
+	})
+	return sessions
+}
+
+// Data returns the samples of every stream of a session whose ticks lie in
+// the window of q, which is cut to the session: from its start to its stop,
+// or to now while it runs.
+func (s *CaptureSessionService) Data(id string, q *model.CaptureSessionQuery) (*model.CaptureSessionData, error) {
+	if (q.From != nil && *q.From < 0) || (q.To != nil && *q.To < 0) {
+		return nil, errors.Wrap(errors.CodeInvalidParam, "invalid capture session query",
+			&model.ValidationError{Field: "from", Message: "offsets must not be negative"})
+	}
+	if q.From != nil && q.To != nil && *q.To < *q.From {
+		return nil, errors.Wrap(errors.CodeInvalidParam, "invalid capture session query",
+			&model.ValidationError{Field: "to", Message: "must not be before from"})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cs, ok := s.sessions[id]
+	if !ok {
+		return nil, errors.NewWithDetail(errors.CodeNotFound, "capture session not found", id)
+	}
+
+	start := cs.session.StartedAt
+	from, to := start, time.Now()
+	if cs.session.StoppedAt != nil {
+		to = *cs.session.StoppedAt
+	}
+	if q.From != nil {
+		from = start.Add(seconds(*q.From))
+	}
+	if q.To != nil && start.Add(seconds(*q.To)).Before(to) {
+		to = start.Add(seconds(*q.To))
+	}
+	in := func(sample model.CaptureSample) bool {
+		return !sample.Timestamp.Before(from) && !sample.Timestamp.After(to)
+	}
+
+	data := &model.CaptureSessionData{
+		Session: cs.snapshot(),
+		From:    from,
+		To:      to,
+		Channel: []model.CaptureChannelSample{},
+		Sensors: []model.CaptureSensorSample{},
+		IRS:     []model.CaptureIRSSample{},
+	}
+	for _, sample := range cs.channel {
+		if in(sample.CaptureSample) {
+			data.Channel = append(data.Channel, sample)
+		}
+	}
+	for _, sample := range cs.sensors {
+		if in(sample.CaptureSample) {
+			data.Sensors = append(data.Sensors, sample)
+		}
+	}
+	for _, sample := range cs.irs {
+		if in(sample.CaptureSample) {
+			data.IRS = append(data.IRS, sample)
+		}
+	}
+	return data, nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+func (cs *captureSession) snapshot() *model.CaptureSession {
+	session := cs.session
+	session.Samples = make(map[string]int, len(cs.session.Samples))
+	for k, v := range cs.session.Samples {
+		session.Samples[k] = v
+	}
+	session.Missed = make(map[string]int, len(cs.session.Missed))
+	for k, v := range cs.session.Missed {
+		session.Missed[k] = v
+	}
+	session.Errors = nil
+	if len(cs.session.Errors) > 0 {
+		session.Errors = make(map[string]string, len(cs.session.Errors))
+		for k, v := range cs.session.Errors {
+			session.Errors[k] = v
+		}
+	}
+	return &session
+}
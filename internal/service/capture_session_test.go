@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/device/irs"
+	"isac-cran-system/internal/device/sensor"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+// toneReceiver returns a constant tone after waiting out the capture.
+type toneReceiver struct{}
+
+func (toneReceiver) CollectData(ctx context.Context, duration time.Duration) ([]model.ChannelDataPoint, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(duration):
+	}
+	points := make([]model.ChannelDataPoint, 64)
+	for i := range points {
+		points[i] = model.ChannelDataPoint{Index: i, Amplitude: 1 + 0.01*float64(i%2), I: 1}
+	}
+	return points, nil
+}
+
+func (toneReceiver) GetConfig() (sampleRate, centerFreq float64) {
+	return 1e6, 28e9
+}
+
+func TestCaptureSession(t *testing.T) {
+	collector := sensor.NewCollector(sensor.NewSimulator(), time.Second)
+	if err := collector.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	controller := irs.NewController(irs.NewSimulator(4, "28GHz"))
+	controller.Connect(context.Background())
+	panels := irs.NewManager()
+	panels.Add("panel0", controller)
+
+	s := NewCaptureSessionService(NewChannelService(toneReceiver{}, nil), NewSensorService(collector, nil), NewIRSService(panels), time.Minute)
+	if _, err := s.Start(context.Background(), &model.CaptureSessionRequest{ExperimentID: "exp", Streams: []string{"radar"}}); !errors.IsCode(err, errors.CodeInvalidParam) {
+		t.Errorf("unknown stream error = %v, want invalid parameter", err)
+	}
+	if _, err := s.Start(context.Background(), &model.CaptureSessionRequest{ExperimentID: "exp", Interval: 0.1, ChannelDuration: 0.2}); !errors.IsCode(err, errors.CodeInvalidParam) {
+		t.Errorf("capture longer than the interval error = %v, want invalid parameter", err)
+	}
+
+	session, err := s.Start(context.Background(), &model.CaptureSessionRequest{
+		ExperimentID:    "exp_session",
+		Interval:        0.1,
+		Duration:        0.55,
+		ChannelDuration: 0.02,
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if session.Status != model.CaptureSessionRunning || len(session.Streams) != 3 {
+		t.Fatalf("started session = %+v", session)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for session.Status == model.CaptureSessionRunning {
+		if time.Now().After(deadline) {
+			t.Fatalf("session did not end: %+v", session)
+		}
+		time.Sleep(20 * time.Millisecond)
+		session, _ = s.Get(session.ID)
+	}
+	if session.Status != model.CaptureSessionCompleted || session.StoppedAt == nil {
+		t.Fatalf("ended session = %+v", session)
+	}
+
+	data, err := s.Data(session.ID, &model.CaptureSessionQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// six ticks fit 0.55 s; allow for a slow scheduler
+	for stream, n := range map[string]int{"channel": len(data.Channel), "sensors": len(data.Sensors), "irs": len(data.IRS)} {
+		if n < 4 || n > 6 || n != session.Samples[stream] {
+			t.Errorf("%d %s samples, session counts %d", n, stream, session.Samples[stream])
+		}
+	}
+	ticks := make(map[int]time.Time)
+	for _, sample := range data.IRS {
+		ticks[sample.Tick] = sample.Timestamp
+		want := session.StartedAt.Add(time.Duration(sample.Tick) * 100 * time.Millisecond)
+		if !sample.Timestamp.Equal(want) {
+			t.Errorf("tick %d at %v, want %v on the session clock", sample.Tick, sample.Timestamp, want)
+		}
+		if sample.Status == nil || len(sample.Status.PhaseShifts) != 4 {
+			t.Errorf("tick %d status = %+v", sample.Tick, sample.Status)
+		}
+	}
+	for _, sample := range data.Channel {
+		if at, ok := ticks[sample.Tick]; ok && !at.Equal(sample.Timestamp) {
+			t.Errorf("channel tick %d at %v, irs at %v", sample.Tick, sample.Timestamp, at)
+		}
+		if m := sample.Measurement; m == nil || m.ExperimentID != "exp_session" || m.Amplitude != nil {
+			t.Errorf("channel sample = %+v, want the measurement without traces", m)
+		}
+	}
+	for _, sample := range data.Sensors {
+		if len(sample.Readings) == 0 {
+			t.Errorf("tick %d has no sensor readings", sample.Tick)
+		}
+	}
+
+	from, to := 0.15, 0.35
+	window, err := s.Data(session.ID, &model.CaptureSessionQuery{From: &from, To: &to})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sample := range window.IRS {
+		if sample.Tick != 2 && sample.Tick != 3 {
+			t.Errorf("tick %d outside the window %g–%g s", sample.Tick, from, to)
+		}
+	}
+	if _, err := s.Data(session.ID, &model.CaptureSessionQuery{From: &to, To: &from}); !errors.IsCode(err, errors.CodeInvalidParam) {
+		t.Errorf("reversed window error = %v, want invalid parameter", err)
+	}
+
+	running, err := s.Start(context.Background(), &model.CaptureSessionRequest{ExperimentID: "exp_stop", Streams: []string{"irs"}, Interval: 0.05})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopped, err := s.Stop(context.Background(), running.ID)
+	if err != nil || stopped.Status != model.CaptureSessionCompleted {
+		t.Errorf("Stop() = %+v, %v", stopped, err)
+	}
+	if list := s.List(); len(list) != 2 || list[0].ID != running.ID {
+		t.Errorf("List() = %d sessions, want the stopped one first", len(list))
+	}
+	if _, err := s.Stop(context.Background(), "cap_missing"); !errors.IsCode(err, errors.CodeNotFound) {
+		t.Errorf("Stop of an unknown session error = %v, want not found", err)
+	}
+}
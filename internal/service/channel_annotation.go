@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// ChannelAnnotationStore keeps the labels attached to channel measurements.
+type ChannelAnnotationStore interface {
+	Create(ctx context.Context, a *model.ChannelAnnotation) error
+	Get(ctx context.Context, id string) (*model.ChannelAnnotation, error)
+	List(ctx context.Context, q *model.ChannelAnnotationQuery) ([]model.ChannelAnnotation, error)
+	Delete(ctx context.Context, id string) error
+}
+
+func (s *ChannelService) SetAnnotationStore(store ChannelAnnotationStore) {
+	s.annotations = store
+}
+
+func (s *ChannelService) annotationsAvailable() error {
+	if s.annotations == nil {
+		return errors.New(errors.CodeServiceUnavailable, "channel annotation store not available")
+	}
+	return nil
+}
+
+// Annotate labels a measurement, or every measurement in a time range.
+func (s *ChannelService) Annotate(ctx context.Context, req *model.ChannelAnnotationRequest) (*model.ChannelAnnotation, error) {
+	if err := s.annotationsAvailable(); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	a := &model.ChannelAnnotation{
+		ID:            generateAnnotationID(now),
+		ExperimentID:  req.ExperimentID,
+		MeasurementID: req.MeasurementID,
+		StartTime:     req.StartTime,
+		EndTime:       req.EndTime,
+		Labels:        req.Labels,
+		Note:          req.Note,
+		Target:        req.Target,
+		CreatedAt:     now,
+	}
+	if a.MeasurementID != "" {
+		// Measurement annotations are stored at creation time, which the
+		// measurement precedes.
+		a.StartTime, a.EndTime = now, now
+	}
+
+	if err := s.annotations.Create(ctx, a); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Channel annotation created",
+		zap.String("annotation_id", a.ID),
+		zap.String("experiment_id", a.ExperimentID),
+		zap.String("measurement_id", a.MeasurementID),
+		zap.Strings("labels", a.Labels),
+	)
+	return a, nil
+}
+
+func (s *ChannelService) GetAnnotation(ctx context.Context, id string) (*model.ChannelAnnotation, error) {
+	if err := s.annotationsAvailable(); err != nil {
+		return nil, err
+	}
+	return s.annotations.Get(ctx, id)
+}
+
+func (s *ChannelService) ListAnnotations(ctx context.Context, q *model.ChannelAnnotationQuery) ([]model.ChannelAnnotation, error) {
+	if err := s.annotationsAvailable(); err != nil {
+		return nil, err
+	}
+	return s.annotations.List(ctx, q)
+}
+
+func (s *ChannelService) DeleteAnnotation(ctx context.Context, id string) error {
+	if err := s.annotationsAvailable(); err != nil {
+		return err
+	}
+	return s.annotations.Delete(ctx, id)
+}
+
+// attachAnnotations fills in the annotations covering each measurement.
+// Range annotations are looked up over the queried time range, measurement
+// annotations by ID since they are stored at their creation time.
+func (s *ChannelService) attachAnnotations(ctx context.Context, q *model.ChannelDataQuery, data []*model.ChannelMeasurement) error {
+	if s.annotations == nil || len(data) == 0 {
+		return nil
+	}
+	start, end := q.Range()
+	// Not filtered by experiment: annotations without one cover every
+	// experiment, which Covers takes care of.
+	annotations, err := s.annotations.List(ctx, &model.ChannelAnnotationQuery{StartTime: start, EndTime: end})
+	if err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(data))
+	for _, m := range data {
+		if m.MeasurementID != "" {
+			ids = append(ids, m.MeasurementID)
+		}
+	}
+	if len(ids) > 0 {
+		byID, err := s.annotations.List(ctx, &model.ChannelAnnotationQuery{MeasurementIDs: ids})
+		if err != nil {
+			return err
+		}
+		annotations = append(annotations, byID...)
+	}
+
+	seen := make(map[string]bool, len(annotations))
+	for i := range annotations {
+		a := &annotations[i]
+		if seen[a.ID] {
+			continue
+		}
+		seen[a.ID] = true
+		for _, m := range data {
+			if a.Covers(m) {
+				m.Annotations = append(m.Annotations, *a)
+			}
+		}
+	}
+	return nil
+}
+
+func generateAnnotationID(now time.Time) string {
+	return fmt.Sprintf("ann_%s%09d", now.Format("20060102150405"), now.Nanosecond())
+}
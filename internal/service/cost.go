@@ -0,0 +1,397 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/algorithm/doa"
+	"isac-cran-system/internal/model"
+)
+
+const (
+	// defaultOpsPerSecond is a conservative rate of complex multiply-adds per
+	// worker, used until measured runs calibrate it.
+	defaultOpsPerSecond = 1e8
+	complexBytes        = 16
+	beamPatternPoints   = 360
+	// timingWeight is the weight of the newest run once a few have been
+	// seen; earlier runs are averaged evenly.
+	timingWeight = 0.2
+)
+
+// experimentCost is the predicted work of one experiment. Work counts the
+// operations on the critical path, with parallel parts divided by the
+// workers, and Fixed the seconds spent capturing, which do not scale with it.
+type experimentCost struct {
+	algorithmType model.AlgorithmType
+	estimate      model.CostEstimate
+	work          float64
+	fixed         float64
+}
+
+func (s *AlgorithmService) beamformingCost(params *model.BeamformingParams) *experimentCost {
+	c := &experimentCost{algorithmType: model.AlgorithmTypeBeamforming}
+	if params.Mode == model.BeamformingModeEigen {
+		c.estimate.Devices = []string{model.ReservableDeviceUSRP}
+		channels := 0
+		if s.snapshots != nil {
+			channels = s.snapshots.ChannelCount()
+		}
+		length := params.SnapshotLength
+		if length <= 0 {
+			length = defaultCovarianceSnapshots
+		}
+		s.covarianceCost(c, channels, length, true)
+		c.estimate.Operations += float64(beamPatternPoints * channels)
+		c.work = c.estimate.Operations
+		return c
+	}
+
+	c.estimate.Devices = []string{model.ReservableDeviceIRS}
+	n := float64(params.ElementCount)
+	if params.Mode == model.BeamformingModeWMMSE {
+		// every iteration builds the K-user matrix, decomposes its 2N×2N
+		// real embedding and bisects the power multiplier
+		k := float64(len(params.Users))
+		iterations := params.MaxIterations
+		if iterations <= 0 {
+			iterations = s.beamformingOptimizer.MaxIterations()
+		}
+		c.estimate.Iterations = iterations
+		c.estimate.Operations = float64(iterations)*(k*n*n+10*8*n*n*n+k*4*n*n+100*k*2*n) + beamPatternPoints*n
+		c.estimate.Memory = int64(complexBytes*(n*n+2*k*n) + 8*4*n*n + 8*beamPatternPoints)
+		c.work = c.estimate.Operations
+		return c
+	}
+	if params.Mode == model.BeamformingModeOFDM && params.OFDM != nil {
+		// every resource block, and the band as a whole, decomposes the
+		// smaller Gram matrix of its stacked channels
+		o := params.OFDM.WithDefaults()
+		n = float64(params.Elements())
+		k := float64(max(o.Subcarriers, 0))
+		rows, paths := 1.0, float64(len(o.Paths))
+		if len(o.Channels) > 0 && len(o.Channels[0]) > 0 {
+			rows, paths = float64(len(o.Channels[0])), 1
+		}
+		block := float64(max(o.ResourceBlockSize, 1))
+		decomposition := func(stacked float64) float64 {
+			m := math.Min(stacked, n)
+			return stacked*m*n + 10*8*m*m*m
+		}
+		m := math.Min(k*rows, n)
+		c.estimate.Iterations = 1
+		c.estimate.Operations = k*rows*n*paths + math.Ceil(k/block)*decomposition(block*rows) + decomposition(k*rows) + 2*k*rows*n + beamPatternPoints*n
+		c.estimate.Memory = int64(complexBytes*(2*k*rows*n+m*m) + 8*4*m*m + 8*(2*k+beamPatternPoints))
+		c.work = c.estimate.Operations
+		return c
+	}
+	if params.Mode == model.BeamformingModeRobust {
+		// one decomposition of the covariance's 2N×2N real embedding, then
+		// a solve and the worst-case SINR for every loading on the grid,
+		// 8 per decade over 16 decades, and in the golden-section search
+		n = float64(params.Elements())
+		k := float64(len(params.InterferenceAngles))
+		iterations := s.beamformingOptimizer.MaxIterations()
+		loadings := float64(2*8*8+2) + float64(iterations)
+		c.estimate.Iterations = iterations
+		c.estimate.Operations = k*n*n + 10*8*n*n*n + loadings*(4*n*n+n*n) + beamPatternPoints*n
+		c.estimate.Memory = int64(complexBytes*(n*n+(k+3)*n) + 8*4*n*n + 8*beamPatternPoints)
+		c.work = c.estimate.Operations
+		return c
+	}
+	steerings := float64(len(params.InterferenceAngles) + 1)
+	if len(params.InterferenceAngles) > 0 {
+		// null steering builds the constraint Gram matrix, or the modelled
+		// covariance for MVDR, and solves it once
+		size := steerings
+		if params.InterferenceINR != nil {
+			size = n
+		}
+		c.estimate.Iterations = 1
+		c.estimate.Operations = size*size*steerings + 3*size*size*size + (steerings+beamPatternPoints)*n
+		c.estimate.Memory = int64(complexBytes*(n*steerings+4*size*size) + 8*beamPatternPoints)
+		c.work = c.estimate.Operations
+		return c
+	}
+	iterations := s.beamformingOptimizer.MaxIterations()
+	c.estimate.Iterations = iterations
+	c.estimate.Operations = float64(iterations)*(n*n+2*n) + (steerings+beamPatternPoints)*n
+	c.estimate.Memory = int64(complexBytes*n*(steerings+2) + 8*beamPatternPoints)
+	c.work = c.estimate.Operations
+	return c
+}
+
+// doaCost returns the cost of a DOA run and the error of an invalid search
+// grid, if any.
+func (s *AlgorithmService) doaCost(params *model.DOAParams) (*experimentCost, error) {
+	c := &experimentCost{algorithmType: model.AlgorithmTypeDOA}
+	c.estimate.Devices = []string{model.ReservableDeviceUSRP}
+
+	channels := params.Elements()
+	usrp := params.Source == model.DOASourceUSRP
+	if usrp && s.snapshots != nil {
+		channels = s.snapshots.ChannelCount()
+	}
+	s.covarianceCost(c, channels, params.SnapshotLength, usrp)
+	if !params.Captured() {
+		c.estimate.Operations += float64(channels * params.SnapshotLength * params.NumSources)
+	}
+	m := float64(channels)
+	wideband := params.Wideband != nil && params.Wideband.Subbands > 1
+	var subbands float64
+	if wideband {
+		// an FFT per run of samples and an eigendecomposition per subband
+		subbands = float64(params.Wideband.Subbands)
+		c.estimate.Operations += m*float64(params.SnapshotLength)*math.Log2(subbands) + subbands*m*m*m
+	}
+	c.work = c.estimate.Operations
+
+	var err error
+	perPoint := m * math.Max(m-float64(params.NumSources)+1, 1)
+	if wideband && params.Wideband.Method == model.WidebandCSSM {
+		// the incoherent preliminary scan that places the focusing angles
+		preliminary := *params
+		preliminary.Refine = true
+		points, scanErr := doa.ScanPoints(&preliminary)
+		err = scanErr
+		focus := float64(points)*perPoint*subbands + subbands*m*m*m
+		c.estimate.Operations += focus
+		c.work += focus / float64(s.workers())
+	}
+	if params.Method != "ESPRIT" {
+		points, scanErr := doa.ScanPoints(params)
+		if err == nil {
+			err = scanErr
+		}
+		music := float64(points) * perPoint
+		if wideband && params.Wideband.Method != model.WidebandCSSM {
+			music *= subbands
+		}
+		c.estimate.GridPoints = points
+		c.estimate.Operations += music
+		c.estimate.Memory += int64(8 * points)
+		c.work += music / float64(s.workers())
+	}
+	return c, err
+}
+
+// irsImpactCost is the cost of the two captures and covariances of every
+// trial of an IRS impact measurement, with the settling waits.
+func (s *AlgorithmService) irsImpactCost(params *model.IRSImpactParams) *experimentCost {
+	p := params.WithDefaults(defaultCovarianceSnapshots)
+	c := &experimentCost{algorithmType: model.AlgorithmTypeIRSImpact}
+	c.estimate.Devices = []string{model.ReservableDeviceIRS, model.ReservableDeviceUSRP}
+	channels := 0
+	if s.snapshots != nil {
+		channels = s.snapshots.ChannelCount()
+	}
+	s.covarianceCost(c, channels, p.SnapshotLength, true)
+	captures := float64(2 * p.Trials)
+	c.estimate.Iterations = p.Trials
+	c.estimate.Operations *= captures
+	c.work = c.estimate.Operations
+	c.fixed = captures * (c.fixed + p.Settle)
+	return c
+}
+
+// jointBeamformingCost is the cost of drawing the cascaded channel and of
+// the alternations, each of which forms the effective channel and aligns
+// the phases, up to the iteration limit. The sdr method instead forms the
+// (N+1)×(N+1) relaxation, sweeps its rank-r factor up to the iteration
+// limit and evaluates every Gaussian candidate, and the manifold method
+// runs its line searches up to the iteration limit.
+func (s *AlgorithmService) jointBeamformingCost(params *model.JointBeamformingParams) *experimentCost {
+	p := params.WithDefaults()
+	c := &experimentCost{algorithmType: model.AlgorithmTypeJointBeamforming}
+	c.estimate.Devices = []string{model.ReservableDeviceIRS}
+	m, n := float64(p.BSAntennas), float64(p.IRSElements)
+	iterations := s.beamformingOptimizer.MaxIterations()
+	c.estimate.Iterations = iterations
+	c.estimate.Channels = p.BSAntennas
+	c.estimate.Operations = float64(iterations+3)*3*m*n + m*n
+	c.estimate.Memory = int64(complexBytes * (m*n + 2*n + 3*m))
+	switch p.Method {
+	case model.JointMethodSDR:
+		rank := math.Ceil(math.Sqrt(2*(n+1))) + 1
+		c.estimate.Operations = (n+1)*(n+1)*m + float64(iterations)*2*(n+1)*(n+1)*rank +
+			float64(p.Randomizations+1)*(n*rank+m*n) + 4*m*n
+		c.estimate.Memory = int64(complexBytes * ((n+1)*(n+1) + (n+1)*rank + m*n + 2*n + 3*m))
+	case model.JointMethodManifold:
+		// a few trial steps per line search, each forming the effective
+		// channel and the gradient
+		c.estimate.Operations = float64(iterations)*4*2*m*n + 3*m*n
+		c.estimate.Memory = int64(complexBytes * (2*m*n + 6*n + 3*m))
+	}
+	c.work = c.estimate.Operations
+	return c
+}
+
+// hybridBeamformingCost is the cost of drawing the channel, decomposing
+// the N×N Gram matrix for the digital precoder and picking every RF chain
+// from the dictionary, each pick correlating the dictionary with the
+// residual and solving the least squares for the chains so far. The DFT
+// dictionary has 4 beams per antenna. The alternating method adds its
+// alternations up to the iteration limit.
+func (s *AlgorithmService) hybridBeamformingCost(params *model.HybridBeamformingParams) *experimentCost {
+	p := params.WithDefaults()
+	c := &experimentCost{algorithmType: model.AlgorithmTypeHybridBeamforming}
+	c.estimate.Devices = []string{model.ReservableDeviceIRS}
+	n, r, k, ns := float64(p.Antennas), float64(p.ReceiveAntennas), float64(p.RFChains), float64(p.Streams)
+	beams := float64(p.NumPaths)
+	if p.Dictionary == model.HybridDictionaryDFT {
+		beams = 4 * n
+	}
+	c.estimate.Channels = p.Antennas
+	c.estimate.Iterations = p.RFChains
+	c.estimate.Operations = float64(p.NumPaths)*r*n + r*n*n + 10*n*n*n + k*(beams*n*ns+k*k*n)
+	c.estimate.Memory = int64(complexBytes * (r*n + n*n + beams*n + 2*k*n))
+	if p.Method == model.HybridMethodAlternating {
+		iterations := s.beamformingOptimizer.MaxIterations()
+		c.estimate.Iterations += iterations
+		c.estimate.Operations += float64(iterations) * (2*k*n*ns + k*k*n)
+	}
+	c.work = c.estimate.Operations
+	return c
+}
+
+// covarianceCost adds the capture and covariance estimation of length
+// snapshots on channels antennas, including the eigendecomposition.
+func (s *AlgorithmService) covarianceCost(c *experimentCost, channels, length int, capture bool) {
+	m, t := float64(channels), float64(length)
+	c.estimate.Channels = channels
+	c.estimate.Snapshots = length
+	c.estimate.Operations += m*m*t + 10*m*m*m
+	c.estimate.Memory += int64(complexBytes * (m*t + 2*m*m))
+	if capture && s.snapshots != nil {
+		if sampleRate, _ := s.snapshots.GetConfig(); sampleRate > 0 {
+			c.fixed = t / sampleRate
+		}
+	}
+}
+
+func (s *AlgorithmService) workers() int {
+	if s.pool != nil {
+		return s.pool.WorkerCount()
+	}
+	return 1
+}
+
+// runtimeModel turns the work of an experiment into seconds. Each algorithm
+// type keeps its own rate, which follows the measured runs of that type.
+type runtimeModel struct {
+	mu           sync.Mutex
+	secondsPerOp map[model.AlgorithmType]float64
+	samples      map[model.AlgorithmType]int
+}
+
+func newRuntimeModel() *runtimeModel {
+	return &runtimeModel{
+		secondsPerOp: make(map[model.AlgorithmType]float64),
+		samples:      make(map[model.AlgorithmType]int),
+	}
+}
+
+// predict returns the estimate of c with its runtime filled in.
+func (m *runtimeModel) predict(c *experimentCost) *model.CostEstimate {
+	m.mu.Lock()
+	rate, ok := m.secondsPerOp[c.algorithmType]
+	samples := m.samples[c.algorithmType]
+	m.mu.Unlock()
+	if !ok {
+		rate = 1 / defaultOpsPerSecond
+	}
+
+	estimate := c.estimate
+	estimate.Runtime = c.fixed + c.work*rate
+	estimate.Samples = samples
+	return &estimate
+}
+
+// observe calibrates the rate of c's algorithm type with a measured run.
+func (m *runtimeModel) observe(c *experimentCost, elapsed time.Duration) {
+	compute := elapsed.Seconds() - c.fixed
+	if c.work <= 0 || compute <= 0 {
+		return
+	}
+	rate := compute / c.work
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := m.samples[c.algorithmType] + 1
+	weight := math.Max(1/float64(n), timingWeight)
+	if n == 1 {
+		m.secondsPerOp[c.algorithmType] = rate
+	} else {
+		m.secondsPerOp[c.algorithmType] += weight * (rate - m.secondsPerOp[c.algorithmType])
+	}
+	m.samples[c.algorithmType] = n
+}
+
+// LoadTimings calibrates the runtime estimates with the measured durations
+// in the energy reports of past experiments.
+func (s *AlgorithmService) LoadTimings(ctx context.Context) (int, error) {
+	if s.resultStore == nil {
+		return 0, nil
+	}
+	loaded := 0
+	for _, algorithmType := range []model.AlgorithmType{model.AlgorithmTypeBeamforming, model.AlgorithmTypeDOA, model.AlgorithmTypeIRSImpact, model.AlgorithmTypeJointBeamforming, model.AlgorithmTypeHybridBeamforming} {
+		results, err := s.resultStore.ListWithEnergyReport(ctx, algorithmType)
+		if err != nil {
+			return loaded, err
+		}
+		// oldest first, so that the newest runs weigh the most
+		for i := len(results) - 1; i >= 0; i-- {
+			if c, elapsed, ok := s.historicCost(&results[i]); ok {
+				s.timings.observe(c, elapsed)
+				loaded++
+			}
+		}
+	}
+	return loaded, nil
+}
+
+func (s *AlgorithmService) historicCost(result *model.ExperimentResult) (*experimentCost, time.Duration, bool) {
+	var report model.EnergyReport
+	if result.EnergyReport == nil || json.Unmarshal([]byte(*result.EnergyReport), &report) != nil || report.Duration <= 0 {
+		return nil, 0, false
+	}
+	elapsed := time.Duration(report.Duration * float64(time.Second))
+
+	switch result.AlgorithmType {
+	case model.AlgorithmTypeBeamforming:
+		var params model.BeamformingParams
+		if json.Unmarshal([]byte(result.Parameters), &params) != nil {
+			return nil, 0, false
+		}
+		return s.beamformingCost(&params), elapsed, true
+	case model.AlgorithmTypeDOA:
+		var params model.DOAParams
+		if json.Unmarshal([]byte(result.Parameters), &params) != nil {
+			return nil, 0, false
+		}
+		c, err := s.doaCost(&params)
+		return c, elapsed, err == nil
+	case model.AlgorithmTypeIRSImpact:
+		var params model.IRSImpactParams
+		if json.Unmarshal([]byte(result.Parameters), &params) != nil {
+			return nil, 0, false
+		}
+		return s.irsImpactCost(&params), elapsed, true
+	case model.AlgorithmTypeJointBeamforming:
+		var params model.JointBeamformingParams
+		if json.Unmarshal([]byte(result.Parameters), &params) != nil {
+			return nil, 0, false
+		}
+		return s.jointBeamformingCost(&params), elapsed, true
+	case model.AlgorithmTypeHybridBeamforming:
+		var params model.HybridBeamformingParams
+		if json.Unmarshal([]byte(result.Parameters), &params) != nil {
+			return nil, 0, false
+		}
+		return s.hybridBeamformingCost(&params), elapsed, true
+	}
+	return nil, 0, false
+}
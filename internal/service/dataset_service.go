@@ -0,0 +1,372 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+	"isac-cran-system/pkg/npy"
+
+	"go.uber.org/zap"
+)
+
+const (
+	datasetPageSize     = 1000
+	defaultDatasetLimit = 100000
+	defaultSensorWindow = 60 * time.Second
+)
+
+// DatasetService builds supervised learning datasets from labeled channel
+// measurements, annotated targets and sensor readings.
+type DatasetService struct {
+	channels  *ChannelService
+	sensors   SensorDataStore
+	artifacts *ArtifactService
+}
+
+func NewDatasetService(channels *ChannelService, sensors SensorDataStore, artifacts *ArtifactService) *DatasetService {
+	return &DatasetService{
+		channels:  channels,
+		sensors:   sensors,
+		artifacts: artifacts,
+	}
+}
+
+// datasetSample is one measurement with its labels and context.
+type datasetSample struct {
+	m       *model.ChannelMeasurement
+	labels  []float64
+	target  *model.TargetPosition
+	sensors []float64
+}
+
+// Build gathers the samples selected by req, splits them and stores the
+// dataset as an artifact.
+func (s *DatasetService) Build(ctx context.Context, req *model.DatasetRequest) (*model.DatasetResult, error) {
+	if s.artifacts == nil {
+		return nil, errors.New(errors.CodeServiceUnavailable, "artifact store not available")
+	}
+	if s.channels == nil || s.channels.dataStore == nil {
+		return nil, errors.New(errors.CodeServiceUnavailable, "channel data store not available")
+	}
+	if req.Format == "" {
+		req.Format = model.DatasetFormatNPZ
+	}
+	if req.Split == (model.DatasetSplit{}) {
+		req.Split = model.DefaultDatasetSplit
+	}
+	if req.MaxSamples == 0 {
+		req.MaxSamples = defaultDatasetLimit
+	}
+
+	measurements, err := s.measurements(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	classes := req.Labels
+	if len(classes) == 0 {
+		classes = labelsOf(measurements)
+	}
+	samples := labelSamples(measurements, classes, req.IncludeUnlabeled)
+	if len(samples) == 0 {
+		return nil, errors.New(errors.CodeNotFound, "no labeled measurements in the time range")
+	}
+	sensorIDs, err := s.attachSensors(ctx, req, samples)
+	if err != nil {
+		return nil, err
+	}
+
+	splits := splitSamples(samples, req.Split, req.Seed)
+	subcarriers := 0
+	for _, sample := range samples {
+		subcarriers = max(subcarriers, len(sample.m.Amplitude), len(sample.m.Phase))
+	}
+
+	var buf bytes.Buffer
+	z := npy.NewNPZWriter(&buf)
+	counts := make(map[string]int, len(splits))
+	for _, split := range []string{model.DatasetSplitTrain, model.DatasetSplitVal, model.DatasetSplitTest} {
+		counts[split] = len(splits[split])
+		if err := writeSplit(z, split, splits[split], subcarriers, len(classes), len(sensorIDs)); err != nil {
+			return nil, errors.Wrap(errors.CodeInternalError, "failed to encode dataset", err)
+		}
+	}
+
+	now := time.Now()
+	result := &model.DatasetResult{
+		Name:        req.Name,
+		Format:      req.Format,
+		Samples:     len(samples),
+		Splits:      counts,
+		Classes:     classes,
+		Sensors:     sensorIDs,
+		Subcarriers: subcarriers,
+		CreatedAt:   now,
+	}
+	if result.Name == "" {
+		result.Name = "dataset_" + now.Format("20060102150405")
+	}
+	meta, _ := json.MarshalIndent(map[string]interface{}{
+		"dataset": result,
+		"request": req,
+	}, "", "  ")
+	if err := z.WriteFile("meta.json", meta); err != nil {
+		return nil, errors.Wrap(errors.CodeInternalError, "failed to encode dataset", err)
+	}
+	if err := z.Close(); err != nil {
+		return nil, errors.Wrap(errors.CodeInternalError, "failed to encode dataset", err)
+	}
+
+	artifact, err := s.artifacts.Upload(ctx, model.ArtifactTypeDataset, req.ExperimentID,
+		result.Name+"."+string(req.Format), bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		return nil, err
+	}
+	result.ArtifactID = artifact.ID
+	result.Key = artifact.StorageKey
+	result.Size = artifact.Size
+	result.Checksum = artifact.Checksum
+
+	logger.Info("Dataset built",
+		zap.String("name", result.Name),
+		zap.Int64("artifact_id", artifact.ID),
+		zap.Int("samples", result.Samples),
+		zap.Int("classes", len(classes)),
+		zap.Int("sensors", len(sensorIDs)),
+	)
+	return result, nil
+}
+
+// measurements pages through the channel measurements of the request,
+// oldest first, with their annotations attached.
+func (s *DatasetService) measurements(ctx context.Context, req *model.DatasetRequest) ([]*model.ChannelMeasurement, error) {
+	var all []*model.ChannelMeasurement
+	for page := 1; len(all) < req.MaxSamples; page++ {
+		data, _, err := s.channels.QueryData(ctx, &model.ChannelDataQuery{
+			ExperimentID:  req.ExperimentID,
+			FrequencyBand: req.FrequencyBand,
+			StartTime:     req.StartTime,
+			EndTime:       req.EndTime,
+			Page:          page,
+			PageSize:      datasetPageSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, data...)
+		if len(data) < datasetPageSize {
+			break
+		}
+	}
+	if len(all) > req.MaxSamples {
+		all = all[:req.MaxSamples]
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Timestamp.Before(all[j].Timestamp) })
+	return all, nil
+}
+
+func labelsOf(measurements []*model.ChannelMeasurement) []string {
+	seen := make(map[string]bool)
+	labels := make([]string, 0)
+	for _, m := range measurements {
+		for _, a := range m.Annotations {
+			for _, l := range a.Labels {
+				if !seen[l] {
+					seen[l] = true
+					labels = append(labels, l)
+				}
+			}
+		}
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// labelSamples turns measurements into samples with multi-hot labels over
+// classes. A measurement without any class is kept only if unlabeled is set.
+func labelSamples(measurements []*model.ChannelMeasurement, classes []string, unlabeled bool) []*datasetSample {
+	index := make(map[string]int, len(classes))
+	for i, c := range classes {
+		index[c] = i
+	}
+	samples := make([]*datasetSample, 0, len(measurements))
+	for _, m := range measurements {
+		sample := &datasetSample{m: m, labels: make([]float64, len(classes))}
+		labeled := false
+		var targetAt time.Time
+		for i := range m.Annotations {
+			a := &m.Annotations[i]
+			for _, l := range a.Labels {
+				if j, ok := index[l]; ok {
+					sample.labels[j] = 1
+					labeled = true
+				}
+			}
+			// the latest annotated position is the ground truth
+			if a.Target != nil && (sample.target == nil || a.CreatedAt.After(targetAt)) {
+				sample.target, targetAt = a.Target, a.CreatedAt
+			}
+		}
+		if labeled || unlabeled {
+			samples = append(samples, sample)
+		}
+	}
+	return samples
+}
+
+// attachSensors fills in the sensor context of each sample and returns the
+// sensor IDs in column order.
+func (s *DatasetService) attachSensors(ctx context.Context, req *model.DatasetRequest, samples []*datasetSample) ([]string, error) {
+	if s.sensors == nil {
+		return []string{}, nil
+	}
+	window := defaultSensorWindow
+	if req.SensorWindow > 0 {
+		window = time.Duration(req.SensorWindow * float64(time.Second))
+	}
+
+	ids := req.SensorIDs
+	if len(ids) == 0 {
+		ids = []string{""}
+	}
+	readings := make(map[string][]*model.SensorData)
+	for _, id := range ids {
+		for page := 1; ; page++ {
+			data, err := s.sensors.Query(ctx, &model.SensorDataQuery{
+				SensorID:  id,
+				StartTime: req.StartTime.Add(-window),
+				EndTime:   req.EndTime,
+				Page:      page,
+				PageSize:  datasetPageSize,
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, d := range data {
+				readings[d.SensorID] = append(readings[d.SensorID], d)
+			}
+			if len(data) < datasetPageSize {
+				break
+			}
+		}
+	}
+
+	sensorIDs := req.SensorIDs
+	if len(sensorIDs) == 0 {
+		sensorIDs = make([]string, 0, len(readings))
+		for id := range readings {
+			sensorIDs = append(sensorIDs, id)
+		}
+		sort.Strings(sensorIDs)
+	}
+	for _, id := range sensorIDs {
+		r := readings[id]
+		sort.Slice(r, func(i, j int) bool { return r[i].Timestamp.Before(r[j].Timestamp) })
+	}
+
+	for _, sample := range samples {
+		sample.sensors = make([]float64, len(sensorIDs))
+		t := sample.m.Timestamp
+		for j, id := range sensorIDs {
+			r := readings[id]
+			// the last reading taken at or before the measurement
+			k := sort.Search(len(r), func(i int) bool { return r[i].Timestamp.After(t) }) - 1
+			if k >= 0 && t.Sub(r[k].Timestamp) <= window {
+				sample.sensors[j] = r[k].Value
+			} else {
+				sample.sensors[j] = math.NaN()
+			}
+		}
+	}
+	return sensorIDs, nil
+}
+
+// splitSamples shuffles samples with seed and deals them into the splits.
+// The same request always yields the same splits.
+func splitSamples(samples []*datasetSample, split model.DatasetSplit, seed int64) map[string][]*datasetSample {
+	order := rand.New(rand.NewSource(seed)).Perm(len(samples))
+	shuffled := make([]*datasetSample, len(samples))
+	for i, j := range order {
+		shuffled[i] = samples[j]
+	}
+	n := float64(len(samples))
+	train := int(math.Round(n * split.Train))
+	val := int(math.Round(n*(split.Train+split.Val))) - train
+	return map[string][]*datasetSample{
+		model.DatasetSplitTrain: shuffled[:train],
+		model.DatasetSplitVal:   shuffled[train : train+val],
+		model.DatasetSplitTest:  shuffled[train+val:],
+	}
+}
+
+// writeSplit adds the arrays of one split, one row per sample. Missing
+// values, such as the subcarriers beyond a measurement's own or the angles of
+// a sample without a target, are NaN.
+func writeSplit(z *npy.NPZWriter, split string, samples []*datasetSample, subcarriers, classes, sensors int) error {
+	n := len(samples)
+	amplitude := make([]float64, 0, n*subcarriers)
+	phase := make([]float64, 0, n*subcarriers)
+	snr := make([]float64, 0, n)
+	ber := make([]float64, 0, n)
+	timestamp := make([]float64, 0, n)
+	labels := make([]float64, 0, n*classes)
+	doa := make([]float64, 0, n*2)
+	target := make([]float64, 0, n*3)
+	sensorContext := make([]float64, 0, n*sensors)
+	for _, sample := range samples {
+		m := sample.m
+		amplitude = appendPadded(amplitude, m.Amplitude, subcarriers)
+		phase = appendPadded(phase, m.Phase, subcarriers)
+		snr = append(snr, m.SNR)
+		ber = append(ber, m.BER)
+		timestamp = append(timestamp, float64(m.Timestamp.UnixNano())/1e9)
+		labels = append(labels, sample.labels...)
+		if p := sample.target; p != nil {
+			az, el := p.Angles()
+			doa = append(doa, az, el)
+			target = append(target, p.X, p.Y, p.Z)
+		} else {
+			doa = append(doa, math.NaN(), math.NaN())
+			target = append(target, math.NaN(), math.NaN(), math.NaN())
+		}
+		sensorContext = append(sensorContext, sample.sensors...)
+	}
+
+	arrays := []struct {
+		name  string
+		shape []int
+		data  []float64
+	}{
+		{"amplitude", []int{n, subcarriers}, amplitude},
+		{"phase", []int{n, subcarriers}, phase},
+		{"snr", []int{n}, snr},
+		{"ber", []int{n}, ber},
+		{"timestamp", []int{n}, timestamp},
+		{"labels", []int{n, classes}, labels},
+		{"doa", []int{n, 2}, doa},
+		{"target", []int{n, 3}, target},
+		{"sensors", []int{n, sensors}, sensorContext},
+	}
+	for _, a := range arrays {
+		if err := z.WriteFloat64(fmt.Sprintf("%s_%s", split, a.name), a.shape, a.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendPadded(dst, values []float64, width int) []float64 {
+	dst = append(dst, values...)
+	for i := len(values); i < width; i++ {
+		dst = append(dst, math.NaN())
+	}
+	return dst
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/model"
+)
+
+// memChannelStore pages its measurements newest first, as the InfluxDB
+// repository does.
+type memChannelStore struct {
+	data []*model.ChannelMeasurement
+}
+
+func (m *memChannelStore) Write(ctx context.Context, data *model.ChannelMeasurement) error {
+	m.data = append(m.data, data)
+	return nil
+}
+
+func (m *memChannelStore) Query(ctx context.Context, q *model.ChannelDataQuery) ([]*model.ChannelMeasurement, error) {
+	start, end := q.Range()
+	var matched []*model.ChannelMeasurement
+	for _, d := range m.data {
+		if (q.ExperimentID == "" || d.ExperimentID == q.ExperimentID) && !d.Timestamp.Before(start) && !d.Timestamp.After(end) {
+			copied := *d
+			matched = append(matched, &copied)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].Timestamp.After(matched[j].Timestamp) })
+	return page(matched, q.Page, q.PageSize), nil
+}
+
+type memAnnotationStore struct {
+	annotations []model.ChannelAnnotation
+}
+
+func (m *memAnnotationStore) Create(ctx context.Context, a *model.ChannelAnnotation) error {
+	m.annotations = append(m.annotations, *a)
+	return nil
+}
+
+func (m *memAnnotationStore) Get(ctx context.Context, id string) (*model.ChannelAnnotation, error) {
+	return nil, nil
+}
+
+func (m *memAnnotationStore) List(ctx context.Context, q *model.ChannelAnnotationQuery) ([]model.ChannelAnnotation, error) {
+	var out []model.ChannelAnnotation
+	for _, a := range m.annotations {
+		if len(q.MeasurementIDs) > 0 {
+			for _, id := range q.MeasurementIDs {
+				if a.MeasurementID == id {
+					out = append(out, a)
+				}
+			}
+		} else if a.MeasurementID == "" && q.Matches(&a) {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (m *memAnnotationStore) Delete(ctx context.Context, id string) error { return nil }
+
+type memSensorStore struct {
+	data []*model.SensorData
+}
+
+func (m *memSensorStore) Write(ctx context.Context, data *model.SensorData) error {
+	m.data = append(m.data, data)
+	return nil
+}
+
+func (m *memSensorStore) WriteBatch(ctx context.Context, dataPoints []*model.SensorData) error {
+	m.data = append(m.data, dataPoints...)
+	return nil
+}
+
+func (m *memSensorStore) Query(ctx context.Context, q *model.SensorDataQuery) ([]*model.SensorData, error) {
+	start, end := q.Range()
+	var matched []*model.SensorData
+	for _, d := range m.data {
+		if (q.SensorID == "" || d.SensorID == q.SensorID) && !d.Timestamp.Before(start) && !d.Timestamp.After(end) {
+			matched = append(matched, d)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].Timestamp.After(matched[j].Timestamp) })
+	return page(matched, q.Page, q.PageSize), nil
+}
+
+func (m *memSensorStore) Aggregate(ctx context.Context, q *model.SensorDataQuery) ([]*model.SensorAggregatedData, error) {
+	return nil, nil
+}
+
+func page[T any](items []T, page, size int) []T {
+	lo := (page - 1) * size
+	if lo >= len(items) {
+		return nil
+	}
+	return items[lo:min(lo+size, len(items))]
+}
+
+// readNPZ decodes the float64 arrays of an archive into flat slices.
+func readNPZ(t *testing.T, data []byte) (map[string][]float64, []byte) {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("dataset is not a zip archive: %v", err)
+	}
+	arrays := make(map[string][]float64)
+	var meta []byte
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(rc)
+		rc.Close()
+		if !strings.HasSuffix(f.Name, ".npy") {
+			meta = b
+			continue
+		}
+		body := b[10+int(binary.LittleEndian.Uint16(b[8:])):]
+		values := make([]float64, len(body)/8)
+		for i := range values {
+			values[i] = math.Float64frombits(binary.LittleEndian.Uint64(body[i*8:]))
+		}
+		arrays[strings.TrimSuffix(f.Name, ".npy")] = values
+	}
+	return arrays, meta
+}
+
+func TestDatasetBuild(t *testing.T) {
+	artifacts, _, _ := newTestArtifactService(t)
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(i int) time.Time { return start.Add(time.Duration(i) * time.Minute) }
+
+	channels := &memChannelStore{}
+	for i := 0; i < 10; i++ {
+		amplitude := []float64{1, 2, 3}
+		if i == 8 {
+			amplitude = amplitude[:2]
+		}
+		channels.Write(context.Background(), &model.ChannelMeasurement{
+			MeasurementID: string(rune('a' + i)),
+			ExperimentID:  "exp_1",
+			Amplitude:     amplitude,
+			Phase:         []float64{0, 0.5, 1},
+			SNR:           float64(i),
+			Timestamp:     at(i),
+		})
+	}
+	annotations := &memAnnotationStore{annotations: []model.ChannelAnnotation{
+		{ID: "los", StartTime: at(0), EndTime: at(4), Labels: []string{"los"}},
+		{ID: "blocked", ExperimentID: "exp_1", StartTime: at(5), EndTime: at(8), Labels: []string{"blocked"},
+			Target: &model.TargetPosition{X: 1, Y: 1}},
+		{ID: "irs", MeasurementID: "a", Labels: []string{"irs_off"}},
+	}}
+	channelSvc := NewChannelService(nil, channels)
+	channelSvc.SetAnnotationStore(annotations)
+
+	sensors := &memSensorStore{}
+	for i := 0; i < 5; i++ {
+		sensors.Write(context.Background(), &model.SensorData{SensorID: "temp_1", Value: 20 + float64(i), Timestamp: at(i).Add(-time.Second)})
+	}
+
+	s := NewDatasetService(channelSvc, sensors, artifacts)
+	req := &model.DatasetRequest{
+		Name:         "blockage",
+		ExperimentID: "exp_1",
+		StartTime:    at(0),
+		EndTime:      at(9),
+		SensorWindow: 30,
+		Split:        model.DatasetSplit{Train: 0.6, Val: 0.2, Test: 0.2},
+		Seed:         7,
+	}
+	result, err := s.Build(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	// The last measurement has no label and is left out.
+	if result.Samples != 9 || result.Splits["train"] != 5 || result.Splits["val"] != 2 || result.Splits["test"] != 2 {
+		t.Errorf("samples %d split %v, want 9 split 5/2/2", result.Samples, result.Splits)
+	}
+	if strings.Join(result.Classes, ",") != "blocked,irs_off,los" {
+		t.Errorf("classes = %v", result.Classes)
+	}
+	if len(result.Sensors) != 1 || result.Sensors[0] != "temp_1" || result.Subcarriers != 3 {
+		t.Errorf("sensors %v, subcarriers %d", result.Sensors, result.Subcarriers)
+	}
+
+	rc, artifact, err := artifacts.Open(context.Background(), result.ArtifactID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if artifact.ArtifactType != model.ArtifactTypeDataset || artifact.Name != "blockage.npz" {
+		t.Errorf("stored as %s %q", artifact.ArtifactType, artifact.Name)
+	}
+	arrays, meta := readNPZ(t, data)
+	if !bytes.Contains(meta, []byte(`"classes"`)) {
+		t.Errorf("meta.json = %s", meta)
+	}
+
+	seen := 0
+	for _, split := range []string{"train", "val", "test"} {
+		snr := arrays[split+"_snr"]
+		labels := arrays[split+"_labels"]
+		doa := arrays[split+"_doa"]
+		sensorContext := arrays[split+"_sensors"]
+		if len(snr) != result.Splits[split] || len(arrays[split+"_amplitude"]) != 3*len(snr) {
+			t.Fatalf("%s arrays hold %d samples, want %d", split, len(snr), result.Splits[split])
+		}
+		for k, v := range snr {
+			i := int(v)
+			seen++
+			blocked, irsOff, los := labels[3*k], labels[3*k+1], labels[3*k+2]
+			if (blocked == 1) != (i >= 5) || (los == 1) != (i <= 4) || (irsOff == 1) != (i == 0) {
+				t.Errorf("measurement %d labeled %v", i, labels[3*k:3*k+3])
+			}
+			if i >= 5 {
+				if math.Abs(doa[2*k]-45) > 1e-9 || doa[2*k+1] != 0 {
+					t.Errorf("measurement %d DOA = %v, want 45, 0", i, doa[2*k:2*k+2])
+				}
+			} else if !math.IsNaN(doa[2*k]) {
+				t.Errorf("measurement %d without a target has DOA %v", i, doa[2*k])
+			}
+			if amplitude := arrays[split+"_amplitude"][3*k+2]; (i == 8) != math.IsNaN(amplitude) {
+				t.Errorf("measurement %d third amplitude = %v", i, amplitude)
+			}
+			if i <= 4 && sensorContext[k] != 20+float64(i) {
+				t.Errorf("measurement %d sensor context = %v, want %v", i, sensorContext[k], 20+float64(i))
+			}
+			if i > 5 && !math.IsNaN(sensorContext[k]) {
+				t.Errorf("measurement %d has a stale sensor reading %v", i, sensorContext[k])
+			}
+		}
+	}
+	if seen != 9 {
+		t.Errorf("splits hold %d samples, want 9", seen)
+	}
+
+	// The same request is split the same way.
+	req.Name = "blockage_again"
+	again, err := s.Build(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, _, _ = artifacts.Open(context.Background(), again.ArtifactID)
+	data, _ = io.ReadAll(rc)
+	rc.Close()
+	repeated, _ := readNPZ(t, data)
+	for k, v := range arrays["train_snr"] {
+		if repeated["train_snr"][k] != v {
+			t.Fatalf("train split differs between builds: %v and %v", arrays["train_snr"], repeated["train_snr"])
+		}
+	}
+}
+
+func TestDatasetRequestValidate(t *testing.T) {
+	start := time.Now()
+	tests := []struct {
+		name  string
+		req   model.DatasetRequest
+		valid bool
+	}{
+		{"default split", model.DatasetRequest{StartTime: start, EndTime: start.Add(time.Hour)}, true},
+		{"custom split", model.DatasetRequest{StartTime: start, EndTime: start.Add(time.Hour), Split: model.DatasetSplit{Train: 0.8, Test: 0.2}}, true},
+		{"split not summing to one", model.DatasetRequest{StartTime: start, EndTime: start.Add(time.Hour), Split: model.DatasetSplit{Train: 0.8}}, false},
+		{"reversed range", model.DatasetRequest{StartTime: start, EndTime: start.Add(-time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.req.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: Validate() = %v", tt.name, err)
+		}
+	}
+}
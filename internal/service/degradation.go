@@ -0,0 +1,447 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultDependencyCheckInterval = 10 * time.Second
+	defaultDependencyBufferSize    = 10000
+	// replayTimeout bounds each buffered write replayed after a recovery.
+	replayTimeout = 10 * time.Second
+)
+
+// DegradationService applies the degradation policy of each external
+// dependency: it checks them periodically, decides which requests the
+// server still admits while one is down and holds the writes of those
+// under buffer-and-retry until they are back.
+type DegradationService struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	deps map[string]*Dependency
+}
+
+// DependencyConfig registers a dependency. Probe checks that it is
+// reachable; Err is why it could not be connected at startup, in which
+// case it starts unavailable. Only dependencies whose writes can be
+// replayed later are Bufferable. Routes are the API routes, below /api and
+// the version, whose requests use the dependency; its policy applies to
+// those alone, or to every route when there are none.
+type DependencyConfig struct {
+	Name       string
+	Policy     string
+	Code       errors.Code
+	BufferSize int
+	Bufferable bool
+	Probe      func(ctx context.Context) error
+	Err        error
+	Routes     []string
+}
+
+// Dependency is the state of one dependency; its fields are guarded by the
+// service's mutex.
+type Dependency struct {
+	svc        *DegradationService
+	name       string
+	policy     string
+	code       errors.Code
+	bufferSize int
+	probe      func(ctx context.Context) error
+	routes     []string
+
+	available bool
+	since     time.Time
+	checkedAt *time.Time
+	err       string
+	buffer    []*bufferedWrite
+	dropped   int64
+	replayed  int64
+	replaying bool
+}
+
+func NewDegradationService(interval time.Duration) *DegradationService {
+	if interval <= 0 {
+		interval = defaultDependencyCheckInterval
+	}
+	return &DegradationService{interval: interval, deps: make(map[string]*Dependency)}
+}
+
+func (s *DegradationService) Register(cfg DependencyConfig) (*Dependency, error) {
+	if !model.ValidDegradationPolicy(cfg.Policy) {
+		return nil, fmt.Errorf("%s: unknown degradation policy %q", cfg.Name, cfg.Policy)
+	}
+	if cfg.Policy == model.DegradationBufferAndRetry && !cfg.Bufferable {
+		return nil, fmt.Errorf("%s: writes cannot be buffered, use %s or %s", cfg.Name, model.DegradationFailRequests, model.DegradationReadOnly)
+	}
+	d := &Dependency{
+		svc:        s,
+		name:       cfg.Name,
+		policy:     cfg.Policy,
+		code:       cfg.Code,
+		bufferSize: cfg.BufferSize,
+		probe:      cfg.Probe,
+		routes:     cfg.Routes,
+		available:  cfg.Err == nil,
+		since:      time.Now(),
+	}
+	if d.bufferSize <= 0 {
+		d.bufferSize = defaultDependencyBufferSize
+	}
+	if cfg.Err != nil {
+		d.err = cfg.Err.Error()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.deps[cfg.Name]; ok {
+		return nil, fmt.Errorf("dependency %s registered twice", cfg.Name)
+	}
+	s.deps[cfg.Name] = d
+	return d, nil
+}
+
+// Run checks every dependency each interval until ctx is done.
+func (s *DegradationService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.CheckAll(ctx)
+		}
+	}
+}
+
+func (s *DegradationService) CheckAll(ctx context.Context) {
+	s.mu.Lock()
+	deps := make([]*Dependency, 0, len(s.deps))
+	for _, d := range s.deps {
+		deps = append(deps, d)
+	}
+	s.mu.Unlock()
+	for _, d := range deps {
+		d.Check(ctx)
+	}
+}
+
+// Admit decides whether a request to route may go ahead while dependencies
+// are down. write is whether the request changes state.
+func (s *DegradationService) Admit(route string, write bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, name := range s.names() {
+		d := s.deps[name]
+		if d.available || !d.uses(route) {
+			continue
+		}
+		switch d.policy {
+		case model.DegradationFailRequests:
+			return d.Unavailable()
+		case model.DegradationReadOnly:
+			if write {
+				return errors.New(d.code, d.name+" is unavailable, the server is read-only")
+			}
+		}
+	}
+	return nil
+}
+
+func (s *DegradationService) Status() *model.DegradationStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := &model.DegradationStatus{Ready: true, Dependencies: make([]model.DependencyStatus, 0, len(s.deps))}
+	for _, name := range s.names() {
+		d := s.deps[name]
+		status.Dependencies = append(status.Dependencies, model.DependencyStatus{
+			Name:      d.name,
+			Policy:    d.policy,
+			Available: d.available,
+			Since:     d.since,
+			CheckedAt: d.checkedAt,
+			Error:     d.err,
+			Buffered:  len(d.buffer),
+			Dropped:   d.dropped,
+			Replayed:  d.replayed,
+		})
+		if d.available {
+			continue
+		}
+		status.Degraded = true
+		switch d.policy {
+		case model.DegradationFailRequests:
+			status.Ready = false
+		case model.DegradationReadOnly:
+			status.ReadOnly = true
+		}
+	}
+	return status
+}
+
+// names returns the dependencies in a stable order; s.mu must be held.
+func (s *DegradationService) names() []string {
+	names := make([]string, 0, len(s.deps))
+	for name := range s.deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// uses reports whether requests to route use the dependency.
+func (d *Dependency) uses(route string) bool {
+	if len(d.routes) == 0 {
+		return true
+	}
+	for _, prefix := range d.routes {
+		if route == prefix || strings.HasPrefix(route, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Dependency) Available() bool {
+	d.svc.mu.Lock()
+	defer d.svc.mu.Unlock()
+	return d.available
+}
+
+func (d *Dependency) Buffering() bool {
+	return d.policy == model.DegradationBufferAndRetry
+}
+
+// Check probes the dependency and records the result. A dependency that
+// has come back gets its buffered writes replayed. Without a probe the
+// dependency keeps the state it was registered with.
+func (d *Dependency) Check(ctx context.Context) error {
+	if d.probe == nil {
+		return nil
+	}
+	err := d.probe(ctx)
+	d.svc.mu.Lock()
+	now := time.Now()
+	d.checkedAt = &now
+	d.svc.mu.Unlock()
+	if err != nil {
+		d.Fail(err)
+		return err
+	}
+
+	d.svc.mu.Lock()
+	recovered := !d.available
+	if recovered {
+		d.available = true
+		d.since = now
+	}
+	replay := len(d.buffer) > 0 && !d.replaying
+	if replay {
+		d.replaying = true
+	}
+	d.svc.mu.Unlock()
+
+	if recovered {
+		logger.Info("Dependency available again", zap.String("dependency", d.name))
+	}
+	if replay {
+		d.replay(ctx)
+	}
+	return nil
+}
+
+// Fail marks the dependency unavailable because of err.
+func (d *Dependency) Fail(err error) {
+	d.svc.mu.Lock()
+	d.err = err.Error()
+	lost := d.available
+	if lost {
+		d.available = false
+		d.since = time.Now()
+	}
+	d.svc.mu.Unlock()
+
+	if lost {
+		logger.Warn("Dependency unavailable",
+			zap.String("dependency", d.name),
+			zap.String("policy", d.policy),
+			zap.Error(err),
+		)
+	}
+}
+
+type bufferedWrite struct {
+	write func(ctx context.Context) error
+}
+
+// Defer holds write until the dependency is back. When the buffer is full
+// the oldest write is dropped.
+func (d *Dependency) Defer(write func(ctx context.Context) error) {
+	d.svc.mu.Lock()
+	defer d.svc.mu.Unlock()
+	if len(d.buffer) >= d.bufferSize {
+		d.buffer = d.buffer[1:]
+		d.dropped++
+		if d.dropped == 1 || d.dropped%1000 == 0 {
+			logger.Warn("Dependency write buffer full, dropping the oldest writes",
+				zap.String("dependency", d.name),
+				zap.Int64("dropped", d.dropped),
+			)
+		}
+	}
+	d.buffer = append(d.buffer, &bufferedWrite{write: write})
+}
+
+// Unavailable is the error of a request that needs the dependency while it
+// is down.
+func (d *Dependency) Unavailable() error {
+	return errors.New(d.code, d.name+" is unavailable")
+}
+
+// replay writes the buffered writes in order. A write that fails puts the
+// dependency back down, and it and the rest wait for the next recovery.
+// Writes made meanwhile go straight through; the points stored carry their
+// own timestamps, so the order they arrive in does not matter.
+func (d *Dependency) replay(ctx context.Context) {
+	defer func() {
+		d.svc.mu.Lock()
+		d.replaying = false
+		d.svc.mu.Unlock()
+	}()
+
+	var replayed int
+	for {
+		d.svc.mu.Lock()
+		if len(d.buffer) == 0 {
+			d.svc.mu.Unlock()
+			break
+		}
+		head := d.buffer[0]
+		d.svc.mu.Unlock()
+
+		writeCtx, cancel := context.WithTimeout(ctx, replayTimeout)
+		err := head.write(writeCtx)
+		cancel()
+		if err != nil {
+			d.Fail(err)
+			logger.Warn("Replaying buffered writes stopped",
+				zap.String("dependency", d.name),
+				zap.Int("replayed", replayed),
+				zap.Error(err),
+			)
+			return
+		}
+
+		d.svc.mu.Lock()
+		// a full buffer may have dropped it while it was written
+		if len(d.buffer) > 0 && d.buffer[0] == head {
+			d.buffer = d.buffer[1:]
+		}
+		d.replayed++
+		d.svc.mu.Unlock()
+		replayed++
+	}
+	logger.Info("Buffered writes replayed", zap.String("dependency", d.name), zap.Int("writes", replayed))
+}
+
+// write runs a store write under the dependency's policy. While it is down
+// a buffering dependency holds the write and the others reject it. A write
+// that fails while a probe fails too takes the dependency down.
+func (d *Dependency) write(ctx context.Context, write func(ctx context.Context) error) error {
+	if !d.Available() {
+		if d.Buffering() {
+			d.Defer(write)
+			return nil
+		}
+		return d.Unavailable()
+	}
+	err := write(ctx)
+	if err == nil {
+		return nil
+	}
+	if d.probe == nil || d.probe(ctx) == nil {
+		// the dependency is fine, the write itself failed
+		return err
+	}
+	d.Fail(err)
+	if d.Buffering() {
+		d.Defer(write)
+		return nil
+	}
+	return err
+}
+
+// read runs a store read, failing at once while the dependency is down.
+func (d *Dependency) read() error {
+	if !d.Available() {
+		return d.Unavailable()
+	}
+	return nil
+}
+
+// DegradedChannelStore applies a dependency's policy to a channel data
+// store.
+type DegradedChannelStore struct {
+	dep   *Dependency
+	inner ChannelDataStore
+}
+
+func NewDegradedChannelStore(dep *Dependency, inner ChannelDataStore) *DegradedChannelStore {
+	return &DegradedChannelStore{dep: dep, inner: inner}
+}
+
+func (s *DegradedChannelStore) Write(ctx context.Context, data *model.ChannelMeasurement) error {
+	return s.dep.write(ctx, func(ctx context.Context) error { return s.inner.Write(ctx, data) })
+}
+
+func (s *DegradedChannelStore) Query(ctx context.Context, q *model.ChannelDataQuery) ([]*model.ChannelMeasurement, error) {
+	if err := s.dep.read(); err != nil {
+		return nil, err
+	}
+	return s.inner.Query(ctx, q)
+}
+
+// DegradedSensorStore applies a dependency's policy to a sensor data
+// store.
+type DegradedSensorStore struct {
+	dep   *Dependency
+	inner SensorDataStore
+}
+
+func NewDegradedSensorStore(dep *Dependency, inner SensorDataStore) *DegradedSensorStore {
+	return &DegradedSensorStore{dep: dep, inner: inner}
+}
+
+func (s *DegradedSensorStore) Write(ctx context.Context, data *model.SensorData) error {
+	return s.dep.write(ctx, func(ctx context.Context) error { return s.inner.Write(ctx, data) })
+}
+
+func (s *DegradedSensorStore) WriteBatch(ctx context.Context, dataPoints []*model.SensorData) error {
+	return s.dep.write(ctx, func(ctx context.Context) error { return s.inner.WriteBatch(ctx, dataPoints) })
+}
+
+func (s *DegradedSensorStore) Query(ctx context.Context, q *model.SensorDataQuery) ([]*model.SensorData, error) {
+	if err := s.dep.read(); err != nil {
+		return nil, err
+	}
+	return s.inner.Query(ctx, q)
+}
+
+func (s *DegradedSensorStore) Aggregate(ctx context.Context, q *model.SensorDataQuery) ([]*model.SensorAggregatedData, error) {
+	if err := s.dep.read(); err != nil {
+		return nil, err
+	}
+	return s.inner.Aggregate(ctx, q)
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+// flakySensorStore fails every write while down and records the ones that
+// go through.
+type flakySensorStore struct {
+	failingSensorStore
+	down    bool
+	written []string
+}
+
+func (f *flakySensorStore) Write(ctx context.Context, data *model.SensorData) error {
+	if f.down {
+		return errors.New(errors.CodeInfluxWriteError, "influx unavailable")
+	}
+	f.written = append(f.written, data.SensorID)
+	return nil
+}
+
+func TestDegradationAdmit(t *testing.T) {
+	svc := NewDegradationService(0)
+	if _, err := svc.Register(DependencyConfig{Name: "mysql", Policy: model.DegradationReadOnly, Code: errors.CodeDBConnectError,
+		Err: errors.New(errors.CodeDBConnectError, "refused"), Routes: []string{"/algorithm", "/reservations"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Admit("/algorithm/beamforming", false); err != nil {
+		t.Errorf("read rejected while read-only: %v", err)
+	}
+	if err := svc.Admit("/irs/config", true); err != nil {
+		t.Errorf("write to a route without the database rejected: %v", err)
+	}
+	if err := svc.Admit("/reservationsx", true); err != nil {
+		t.Errorf("write to a route sharing only a prefix rejected: %v", err)
+	}
+	err := svc.Admit("/reservations", true)
+	if err == nil {
+		t.Fatal("write admitted while read-only")
+	}
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.CodeDBConnectError {
+		t.Errorf("err = %v, want a database connection error", err)
+	}
+	status := svc.Status()
+	if !status.Ready || !status.Degraded || !status.ReadOnly {
+		t.Errorf("status = %+v, want ready, degraded and read-only", status)
+	}
+
+	if _, err := svc.Register(DependencyConfig{Name: "cache", Policy: model.DegradationFailRequests, Err: errors.New(errors.CodeInternalError, "down")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Admit("/irs/status", false); err == nil {
+		t.Error("read admitted while a fail-requests dependency is down")
+	}
+	if svc.Status().Ready {
+		t.Error("ready while a fail-requests dependency is down")
+	}
+}
+
+func TestDegradationRegister(t *testing.T) {
+	svc := NewDegradationService(0)
+	if _, err := svc.Register(DependencyConfig{Name: "mysql", Policy: "retry-forever"}); err == nil {
+		t.Error("unknown policy accepted")
+	}
+	if _, err := svc.Register(DependencyConfig{Name: "mysql", Policy: model.DegradationBufferAndRetry}); err == nil {
+		t.Error("buffer-and-retry accepted for a dependency that cannot buffer")
+	}
+	if _, err := svc.Register(DependencyConfig{Name: "mysql", Policy: model.DegradationReadOnly}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Register(DependencyConfig{Name: "mysql", Policy: model.DegradationReadOnly}); err == nil {
+		t.Error("dependency registered twice")
+	}
+}
+
+func TestDegradedStoreBuffersAndReplays(t *testing.T) {
+	ctx := context.Background()
+	inner := &flakySensorStore{}
+	svc := NewDegradationService(0)
+	dep, err := svc.Register(DependencyConfig{
+		Name:       "influxdb",
+		Policy:     model.DegradationBufferAndRetry,
+		Code:       errors.CodeInfluxConnectError,
+		BufferSize: 2,
+		Bufferable: true,
+		Probe: func(ctx context.Context) error {
+			if inner.down {
+				return errors.New(errors.CodeInfluxConnectError, "influx unavailable")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewDegradedSensorStore(dep, inner)
+
+	if err := store.Write(ctx, &model.SensorData{SensorID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	inner.down = true
+	for _, id := range []string{"s2", "s3", "s4"} {
+		if err := store.Write(ctx, &model.SensorData{SensorID: id}); err != nil {
+			t.Fatalf("write %s while buffering: %v", id, err)
+		}
+	}
+	if dep.Available() {
+		t.Fatal("dependency available after a failed write and probe")
+	}
+	if _, err := store.Query(ctx, &model.SensorDataQuery{}); err == nil {
+		t.Error("query served while the dependency is down")
+	}
+	if err := svc.Admit("/sensor/start", true); err != nil {
+		t.Errorf("write rejected while buffering: %v", err)
+	}
+	status := svc.Status().Dependencies[0]
+	if status.Buffered != 2 || status.Dropped != 1 {
+		t.Errorf("buffered %d, dropped %d; want 2 and 1", status.Buffered, status.Dropped)
+	}
+
+	svc.CheckAll(ctx)
+	if dep.Available() {
+		t.Fatal("dependency available while its probe fails")
+	}
+	inner.down = false
+	svc.CheckAll(ctx)
+	if !dep.Available() {
+		t.Fatal("dependency still down after its probe succeeded")
+	}
+	if got := inner.written; len(got) != 3 || got[0] != "s1" || got[1] != "s3" || got[2] != "s4" {
+		t.Errorf("written %v, want [s1 s3 s4]", got)
+	}
+	status = svc.Status().Dependencies[0]
+	if status.Buffered != 0 || status.Replayed != 2 {
+		t.Errorf("buffered %d, replayed %d; want 0 and 2", status.Buffered, status.Replayed)
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/model"
+)
+
+type DeviceConnection interface {
+	IsConnected() bool
+}
+
+// SyncReporter is implemented by connections that can report reference lock.
+type SyncReporter interface {
+	SyncStatus() (*model.SyncStatus, error)
+}
+
+// VersionReporter is implemented by connections that know their firmware
+// and driver versions.
+type VersionReporter interface {
+	Versions() (*model.DeviceVersions, error)
+}
+
+type registeredDevice struct {
+	info model.DeviceInfo
+	conn DeviceConnection
+}
+
+// DeviceService reports which devices were configured at startup, which
+// driver each one uses, and whether it is currently connected.
+type DeviceService struct {
+	devices []*registeredDevice
+	mu      sync.RWMutex
+}
+
+func NewDeviceService() *DeviceService {
+	return &DeviceService{
+		devices: make([]*registeredDevice, 0),
+	}
+}
+
+// Register records a device. conn may be nil for devices that are disabled
+// or whose driver could not be created.
+func (s *DeviceService) Register(info model.DeviceInfo, conn DeviceConnection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info.Available = conn != nil
+	s.devices = append(s.devices, &registeredDevice{info: info, conn: conn})
+}
+
+func (s *DeviceService) List() []*model.DeviceInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	devices := make([]*model.DeviceInfo, 0, len(s.devices))
+	for _, d := range s.devices {
+		info := d.info
+		if d.conn != nil {
+			info.Connected = d.conn.IsConnected()
+		}
+		if reporter, ok := d.conn.(SyncReporter); ok && info.Connected {
+			info.Sync = syncStatus(reporter)
+		}
+		if reporter, ok := d.conn.(VersionReporter); ok && info.Connected {
+			if versions, err := reporter.Versions(); err == nil {
+				info.Versions = versions
+			}
+		}
+		devices = append(devices, &info)
+	}
+	return devices
+}
+
+// Update changes the recorded info of a registered device, e.g. after the
+// USRP was rebound to another radio.
+func (s *DeviceService) Update(name string, fn func(info *model.DeviceInfo)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, d := range s.devices {
+		if d.info.Name == name {
+			fn(&d.info)
+			return
+		}
+	}
+}
+
+func syncStatus(reporter SyncReporter) *model.SyncStatus {
+	status, err := reporter.SyncStatus()
+	if err != nil {
+		return &model.SyncStatus{CheckedAt: time.Now(), Error: err.Error()}
+	}
+	return status
+}
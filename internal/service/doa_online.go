@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/algorithm/covariance"
+	"isac-cran-system/internal/algorithm/doa"
+	"isac-cran-system/internal/device/usrp"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultOnlineBlockSize = 1024
+	defaultOnlineInterval  = 10 * time.Millisecond
+)
+
+// StreamSource delivers continuous sample-aligned multi-channel blocks.
+type StreamSource interface {
+	StartStreaming(ctx context.Context, blockSize int, callback usrp.StreamCallback, opts ...usrp.StreamOption) (*usrp.Stream, error)
+	ChannelCount() int
+}
+
+type onlineDOA struct {
+	source  StreamSource
+	mu      sync.Mutex
+	session *onlineSession
+}
+
+// onlineSession folds every streamed block into a covariance tracker and
+// re-estimates DOA from it on a fixed interval, independently of the block
+// rate.
+type onlineSession struct {
+	params   model.DOAParams
+	opts     model.CovarianceOptions
+	tracker  *covariance.Tracker
+	stream   *usrp.Stream
+	cancel   context.CancelFunc
+	done     chan struct{}
+	interval time.Duration
+
+	mu     sync.Mutex
+	status model.OnlineDOAStatus
+}
+
+// SetStreamSource enables online DOA on the receiver's continuous stream.
+func (s *AlgorithmService) SetStreamSource(src StreamSource) {
+	s.online.source = src
+}
+
+// StartOnlineDOA starts tracking DOA from the receiver stream, replacing any
+// session already running.
+func (s *AlgorithmService) StartOnlineDOA(ctx context.Context, req *model.OnlineDOARequest) (*model.OnlineDOAStatus, error) {
+	if err := s.remoteDevices("online DOA"); err != nil {
+		return nil, err
+	}
+	source := s.online.source
+	if source == nil {
+		return nil, deviceUnavailable("usrp")
+	}
+	if err := checkReservation(ctx, s.gate, model.ReservableDeviceUSRP); err != nil {
+		return nil, err
+	}
+
+	channels := source.ChannelCount()
+	if channels < 2 {
+		return nil, errors.New(errors.CodeInvalidParam, "online DOA needs a multi-channel receiver")
+	}
+	if req.Params.NumSources >= channels {
+		return nil, errors.New(errors.CodeInvalidParam, "need more antenna channels than sources")
+	}
+	opts := s.covariance
+	if req.Params.Covariance != nil {
+		opts = *req.Params.Covariance
+	}
+	if _, err := covariance.Regularize([][]complex128{{1}}, opts); err != nil {
+		return nil, errors.Wrap(errors.CodeInvalidParam, err.Error(), err)
+	}
+	tracker, err := covariance.NewTracker(channels, req.ForgettingFactor)
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeInvalidParam, err.Error(), err)
+	}
+
+	blockSize := req.BlockSize
+	if blockSize <= 0 {
+		blockSize = defaultOnlineBlockSize
+	}
+	interval := defaultOnlineInterval
+	if req.Interval > 0 {
+		interval = time.Duration(req.Interval * float64(time.Second))
+	}
+
+	s.online.mu.Lock()
+	defer s.online.mu.Unlock()
+	s.online.stop()
+
+	// the session outlives the request that started it
+	sessionCtx, cancel := context.WithCancel(context.Background())
+	session := &onlineSession{
+		params:   req.Params,
+		opts:     opts,
+		tracker:  tracker,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		interval: interval,
+		status:   model.OnlineDOAStatus{Running: true, Request: *req, StartedAt: time.Now()},
+	}
+	stream, err := source.StartStreaming(sessionCtx, blockSize, session.absorb)
+	if err != nil {
+		cancel()
+		return nil, errors.Wrap(errors.CodeUSRPReceiveError, "failed to start receiver stream", err)
+	}
+	session.stream = stream
+	go session.run(sessionCtx, s.doaEstimator)
+	s.online.session = session
+
+	logger.Info("Online DOA started",
+		zap.Int("channels", channels),
+		zap.Int("block_size", blockSize),
+		zap.Duration("interval", interval),
+	)
+	return session.snapshot(), nil
+}
+
+// OnlineDOA returns the latest estimate of the current or last session.
+func (s *AlgorithmService) OnlineDOA() *model.OnlineDOAStatus {
+	s.online.mu.Lock()
+	defer s.online.mu.Unlock()
+
+	if s.online.session == nil {
+		return &model.OnlineDOAStatus{}
+	}
+	return s.online.session.snapshot()
+}
+
+func (s *AlgorithmService) StopOnlineDOA() *model.OnlineDOAStatus {
+	s.online.mu.Lock()
+	defer s.online.mu.Unlock()
+
+	s.online.stop()
+	if s.online.session == nil {
+		return &model.OnlineDOAStatus{}
+	}
+	return s.online.session.snapshot()
+}
+
+func (o *onlineDOA) stop() {
+	if o.session != nil {
+		o.session.cancel()
+		<-o.session.done
+	}
+}
+
+func (o *onlineSession) absorb(block *usrp.StreamBlock) error {
+	X := make([][]complex128, len(block.Channels))
+	for m, samples := range block.Channels {
+		X[m] = make([]complex128, len(samples))
+		for t, p := range samples {
+			X[m][t] = complex(p.I, p.Q)
+		}
+	}
+	if err := o.tracker.Update(X); err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	o.status.Snapshots = o.tracker.Updates()
+	o.status.DroppedBlocks += uint64(block.Dropped)
+	o.mu.Unlock()
+	return nil
+}
+
+func (o *onlineSession) run(ctx context.Context, estimator *doa.Estimator) {
+	defer close(o.done)
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+
+	var seen uint64
+	for {
+		select {
+		case <-ctx.Done():
+			o.finish(nil)
+			return
+		case <-o.stream.Done():
+			o.finish(o.stream.Err())
+			return
+		case <-ticker.C:
+		}
+
+		updates := o.tracker.Updates()
+		if updates == seen {
+			continue
+		}
+		seen = updates
+		result, err := o.estimate(estimator)
+
+		o.mu.Lock()
+		o.status.UpdatedAt = time.Now()
+		if err != nil {
+			o.status.Error = err.Error()
+		} else {
+			o.status.Result = result
+			o.status.Estimates++
+			o.status.Error = ""
+		}
+		o.mu.Unlock()
+	}
+}
+
+func (o *onlineSession) estimate(estimator *doa.Estimator) (*model.DOAResult, error) {
+	R, err := covariance.Regularize(o.tracker.Covariance(), o.opts)
+	if err != nil {
+		return nil, err
+	}
+	return estimator.EstimateCovariance(R, &o.params)
+}
+
+func (o *onlineSession) finish(err error) {
+	o.cancel()
+	o.stream.Stop()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.status.Running = false
+	if err != nil {
+		o.status.Error = err.Error()
+		logger.Warn("Online DOA stopped by stream error", zap.Error(err))
+		return
+	}
+	logger.Info("Online DOA stopped", zap.Uint64("estimates", o.status.Estimates))
+}
+
+func (o *onlineSession) snapshot() *model.OnlineDOAStatus {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	status := o.status
+	return &status
+}
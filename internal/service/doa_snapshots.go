@@ -0,0 +1,157 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+	"isac-cran-system/pkg/npy"
+
+	"go.uber.org/zap"
+)
+
+const (
+	snapshotArtifactName = "doa_snapshots.npy"
+	snapshotFormatNPY    = "npy"
+)
+
+type snapshotArchive struct {
+	artifacts *ArtifactService
+	always    bool
+	maxBytes  int64
+}
+
+// SetSnapshotArchive stores the snapshot matrix of DOA runs as artifacts so
+// they can be re-processed offline. always archives every run; otherwise only
+// runs that ask for it. maxBytes caps each archive, zero means no cap.
+func (s *AlgorithmService) SetSnapshotArchive(artifacts *ArtifactService, always bool, maxBytes int64) {
+	s.archive = snapshotArchive{artifacts: artifacts, always: always, maxBytes: maxBytes}
+}
+
+// archiveSnapshots uploads X as a complex64 .npy artifact. Failures are
+// logged rather than failing the experiment, whose estimate is still valid.
+func (s *AlgorithmService) archiveSnapshots(ctx context.Context, experimentID string, X [][]complex128) *model.SnapshotArchive {
+	if s.archive.artifacts == nil || len(X) == 0 {
+		logger.Warn("Snapshot archive not available", zap.String("experiment_id", experimentID))
+		return nil
+	}
+
+	antennas, captured := len(X), len(X[0])
+	n := captured
+	if max := s.archive.maxBytes; max > 0 && npy.EncodedSize(antennas, n) > max {
+		n = int((max - npy.EncodedSize(antennas, 0)) / int64(antennas*npy.ComplexItemSize))
+		// the header grows with the digits of the shape
+		for n > 0 && npy.EncodedSize(antennas, n) > max {
+			n--
+		}
+		if n <= 0 {
+			logger.Warn("Snapshot archive cap too small for a single snapshot",
+				zap.String("experiment_id", experimentID),
+				zap.Int64("max_bytes", max),
+			)
+			return nil
+		}
+	}
+
+	stored := X
+	if n < captured {
+		stored = make([][]complex128, antennas)
+		for m := range X {
+			stored[m] = X[m][:n]
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := npy.WriteComplex64(&buf, stored); err != nil {
+		logger.Warn("Failed to encode snapshots", zap.String("experiment_id", experimentID), zap.Error(err))
+		return nil
+	}
+	artifact, err := s.archive.artifacts.Upload(ctx, model.ArtifactTypeIQCapture, experimentID, snapshotArtifactName, &buf, int64(buf.Len()))
+	if err != nil {
+		logger.Warn("Failed to archive snapshots", zap.String("experiment_id", experimentID), zap.Error(err))
+		return nil
+	}
+
+	return &model.SnapshotArchive{
+		ArtifactID: artifact.ID,
+		Format:     snapshotFormatNPY,
+		Antennas:   antennas,
+		Snapshots:  n,
+		Captured:   captured,
+		Truncated:  n < captured,
+	}
+}
+
+// OpenSnapshots opens the archived snapshot matrix of a DOA experiment.
+func (s *AlgorithmService) OpenSnapshots(ctx context.Context, experimentID string) (io.ReadCloser, *model.Artifact, error) {
+	archive, err := s.snapshotArchiveOf(ctx, experimentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.archive.artifacts == nil {
+		return nil, nil, errors.New(errors.CodeServiceUnavailable, "artifact store not available")
+	}
+	return s.archive.artifacts.Open(ctx, archive.ArtifactID)
+}
+
+// SnapshotWindow returns count snapshots starting at start; a zero count
+// returns the rest of the archive.
+func (s *AlgorithmService) SnapshotWindow(ctx context.Context, experimentID string, start, count int) (*model.SnapshotWindow, error) {
+	rc, _, err := s.OpenSnapshots(ctx, experimentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	X, err := npy.ReadComplex64(rc)
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeObjectStoreError, "failed to decode snapshots", err)
+	}
+
+	total := 0
+	if len(X) > 0 {
+		total = len(X[0])
+	}
+	if start >= total {
+		return nil, errors.NewWithDetail(errors.CodeInvalidParam, "start is beyond the stored snapshots", experimentID)
+	}
+	if count <= 0 || start+count > total {
+		count = total - start
+	}
+
+	window := &model.SnapshotWindow{
+		ExperimentID: experimentID,
+		Antennas:     len(X),
+		Snapshots:    total,
+		Start:        start,
+		Count:        count,
+		Real:         make([][]float64, len(X)),
+		Imag:         make([][]float64, len(X)),
+	}
+	for m, row := range X {
+		window.Real[m] = make([]float64, count)
+		window.Imag[m] = make([]float64, count)
+		for t, v := range row[start : start+count] {
+			window.Real[m][t] = real(v)
+			window.Imag[m][t] = imag(v)
+		}
+	}
+	return window, nil
+}
+
+func (s *AlgorithmService) snapshotArchiveOf(ctx context.Context, experimentID string) (*model.SnapshotArchive, error) {
+	doaResult, err := GetResultAs[*model.DOAResult](ctx, s, experimentID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeInvalidParam) {
+			return nil, errors.New(errors.CodeNotFound, "no snapshots stored for experiment")
+		}
+		return nil, err
+	}
+	if doaResult.Snapshots == nil {
+		return nil, errors.New(errors.CodeNotFound, "no snapshots stored for experiment")
+	}
+	return doaResult.Snapshots, nil
+}
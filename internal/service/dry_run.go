@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+// DryRunBeamforming checks a beamforming request and estimates its cost
+// without running it or touching a device.
+func (s *AlgorithmService) DryRunBeamforming(ctx context.Context, experimentID string, params *model.BeamformingParams) *model.DryRunResult {
+	d := params.Diagnose()
+	s.diagnoseExperimentID(ctx, &d, experimentID)
+
+	cost := s.beamformingCost(params)
+	diagnoseReservation(ctx, &d, s.gate, cost.estimate.Devices[0])
+	if params.Mode == model.BeamformingModeEigen {
+		channels := s.diagnoseReceiver(&d)
+		if params.NumBeams > channels && channels > 0 {
+			d.Warnf("num_beams", "num_beams %d exceeds the %d receiver channels", params.NumBeams, channels)
+		}
+	} else if iterations := cost.estimate.Iterations; params.Mode != model.BeamformingModeWMMSE && params.MaxIterations > 0 && params.MaxIterations != iterations {
+		d.Warnf("max_iterations", "max_iterations is not applied, the optimizer stops after at most %d iterations", iterations)
+	}
+	return s.dryRunResult(d, cost)
+}
+
+// DryRunDOA checks a DOA request and estimates its cost without capturing
+// or estimating anything.
+func (s *AlgorithmService) DryRunDOA(ctx context.Context, experimentID string, params *model.DOAParams) *model.DryRunResult {
+	d := params.Diagnose()
+	s.diagnoseExperimentID(ctx, &d, experimentID)
+	diagnoseReservation(ctx, &d, s.gate, model.ReservableDeviceUSRP)
+
+	switch params.Source {
+	case model.DOASourceUSRP:
+		channels := s.diagnoseReceiver(&d)
+		if channels > 0 && channels <= params.NumSources {
+			d.Errorf("num_sources", "the %d receiver channels cannot resolve %d sources", channels, params.NumSources)
+		}
+	case model.DOASourceRecording:
+		if err := s.remoteDevices("DOA source recording"); err != nil {
+			d.Errorf("source", "%s", err.Message)
+			break
+		}
+		if params.Recording == "" || params.SnapshotLength < 1 {
+			break
+		}
+		// reading the window back is cheap next to the estimate
+		X, _, err := s.readRecording(ctx, params)
+		if err != nil {
+			d.Add(err)
+		} else if len(X) <= params.NumSources {
+			d.Errorf("num_sources", "the %d recorded channels cannot resolve %d sources", len(X), params.NumSources)
+		}
+	}
+	cost, err := s.doaCost(params)
+	d.Add(err)
+	return s.dryRunResult(d, cost)
+}
+
+// DryRunIRSImpact checks an IRS impact measurement against the panel and
+// the receiver and estimates how long it takes, without switching anything.
+func (s *AlgorithmService) DryRunIRSImpact(ctx context.Context, experimentID string, params *model.IRSImpactParams) *model.DryRunResult {
+	d := params.Violations()
+	if d.HasErrors() {
+		return model.NewDryRunResult(d, nil, nil)
+	}
+	s.diagnoseExperimentID(ctx, &d, experimentID)
+	diagnoseReservation(ctx, &d, s.gate, model.ReservableDeviceIRS)
+	diagnoseReservation(ctx, &d, s.gate, model.ReservableDeviceUSRP)
+	if err := s.remoteDevices("IRS impact measurement"); err != nil {
+		d.Errorf("", "%s", err.Message)
+		return model.NewDryRunResult(d, nil, nil)
+	}
+	s.diagnoseReceiver(&d)
+
+	controller, err := panelController(s.irsPanels, params.IRSID)
+	if err != nil {
+		d.Add(err)
+		return model.NewDryRunResult(d, nil, nil)
+	}
+	if params.Off == model.IRSOffAbsorptive && !controller.CanAbsorb() {
+		d.Errorf("off", "the panel cannot switch to absorptive")
+	}
+	if params.Config != nil {
+		preview, err := controller.Preview(params.Config)
+		if err != nil {
+			d.Add(err)
+		} else if preview.QuantizationError > 0 {
+			d.Warnf("config.phase_shifts", "%d-bit quantization changes the phases by %.3g rad RMS", preview.QuantizationBits, preview.QuantizationError)
+		}
+	} else if controller.GetCurrentConfig() == nil {
+		d.Errorf("config", "the panel has no active configuration to measure")
+	}
+	return s.dryRunResult(d, s.irsImpactCost(params))
+}
+
+// DryRunJointBeamforming checks a joint beamforming request and estimates
+// its cost without optimizing.
+func (s *AlgorithmService) DryRunJointBeamforming(ctx context.Context, experimentID string, params *model.JointBeamformingParams) *model.DryRunResult {
+	d := params.Diagnose()
+	if d.HasErrors() {
+		return model.NewDryRunResult(d, nil, nil)
+	}
+	s.diagnoseExperimentID(ctx, &d, experimentID)
+	diagnoseReservation(ctx, &d, s.gate, model.ReservableDeviceIRS)
+	return s.dryRunResult(d, s.jointBeamformingCost(params))
+}
+
+// DryRunHybridBeamforming checks a hybrid beamforming request and
+// estimates its cost without optimizing.
+func (s *AlgorithmService) DryRunHybridBeamforming(ctx context.Context, experimentID string, params *model.HybridBeamformingParams) *model.DryRunResult {
+	d := params.Diagnose()
+	if d.HasErrors() {
+		return model.NewDryRunResult(d, nil, nil)
+	}
+	s.diagnoseExperimentID(ctx, &d, experimentID)
+	diagnoseReservation(ctx, &d, s.gate, model.ReservableDeviceIRS)
+	return s.dryRunResult(d, s.hybridBeamformingCost(params))
+}
+
+// dryRunResult adds the admission decision to the diagnostics and returns
+// the estimate of a request without errors.
+func (s *AlgorithmService) dryRunResult(d model.Diagnostics, cost *experimentCost) *model.DryRunResult {
+	if d.HasErrors() {
+		return model.NewDryRunResult(d, nil, nil)
+	}
+	estimate := s.timings.predict(cost)
+	if err := s.admission.check(estimate); err != nil {
+		d.Errorf("", "%s", err.Err.Message)
+	} else if s.admission.heavy(estimate) && s.admission.busy() {
+		d.Warnf("", "all heavy experiment slots are taken, the request would be queued")
+	}
+	return model.NewDryRunResult(d, estimate, nil)
+}
+
+// diagnoseReceiver returns the receiver's channel count, recording an error
+// when it cannot take multi-channel snapshots.
+func (s *AlgorithmService) diagnoseReceiver(d *model.Diagnostics) int {
+	if err := s.remoteDevices("usrp capture"); err != nil {
+		d.Errorf("source", "%s", err.Message)
+		return 0
+	}
+	if s.snapshots == nil {
+		d.Errorf("source", "usrp device not available")
+		return 0
+	}
+	channels := s.snapshots.ChannelCount()
+	if channels < 2 {
+		d.Errorf("source", "usrp source needs a multi-channel receiver")
+	}
+	return channels
+}
+
+func (s *AlgorithmService) diagnoseExperimentID(ctx context.Context, d *model.Diagnostics, experimentID string) {
+	if s.resultStore == nil {
+		return
+	}
+	_, err := s.resultStore.GetByExperimentID(ctx, experimentID)
+	switch {
+	case err == nil:
+		d.Errorf("experiment_id", "experiment %s already exists", experimentID)
+	case !errors.IsCode(err, errors.CodeNotFound):
+		d.Warnf("experiment_id", "could not check the experiment: %v", err)
+	}
+}
+
+// diagnoseReservation warns when device is reserved by someone else, in
+// which case the request would be queued rather than run.
+func diagnoseReservation(ctx context.Context, d *model.Diagnostics, gate DeviceGate, device string) {
+	if gate == nil {
+		return
+	}
+	r, err := gate.Blocking(ctx, device)
+	if err != nil {
+		d.Warnf("", "could not check reservations of %s: %v", device, err)
+		return
+	}
+	if r != nil {
+		d.Warnf("", "%s is reserved by %s until %s, the request would wait for it", device, r.Holder, r.EndTime.Format(time.RFC3339))
+	}
+}
+
+// DryRunConfigure checks an IRS configuration against the panel and
+// previews the phases that would be written, after grouping and
+// quantization, without writing them.
+func (s *IRSService) DryRunConfigure(ctx context.Context, irsID string, req *model.IRSConfigRequest) (*model.DryRunResult, error) {
+	controller, err := s.controller(irsID)
+	if err != nil {
+		return nil, err
+	}
+
+	var d model.Diagnostics
+	if err := req.Validate(); err != nil {
+		d.Add(err)
+		return model.NewDryRunResult(d, nil, nil), nil
+	}
+	diagnoseReservation(ctx, &d, s.gate, model.ReservableDeviceIRS)
+	if n := controller.ElementCount(); n > 0 && n != req.ElementCount {
+		d.Errorf("element_count", "panel %s has %d elements, not %d", s.panelID(irsID), n, req.ElementCount)
+	}
+	if !controller.IsConnected() {
+		d.Warnf("", "panel %s is not connected", s.panelID(irsID))
+	}
+
+	preview, err := controller.Preview(req)
+	if err != nil {
+		appErr, ok := err.(*errors.AppError)
+		if ok && appErr.Code == errors.CodeVersionConflict {
+			d.Errorf("version", "%s", appErr.Message)
+		} else {
+			d.Add(err)
+		}
+		return model.NewDryRunResult(d, nil, nil), nil
+	}
+	if preview.QuantizationError > 0 {
+		d.Warnf("phase_shifts", "%d-bit quantization changes the phases by %.3g rad RMS", preview.QuantizationBits, preview.QuantizationError)
+	}
+	estimate := &model.CostEstimate{
+		Operations: float64(req.ElementCount),
+		Memory:     int64(8 * req.ElementCount),
+		Devices:    []string{model.ReservableDeviceIRS},
+	}
+	return model.NewDryRunResult(d, estimate, preview), nil
+}
+
+// DryRunSequence checks a phase sequence against the panel and reports how
+// long it would play; for a looping sequence, how long one pass takes.
+func (s *IRSService) DryRunSequence(ctx context.Context, irsID string, req *model.IRSSequenceRequest) (*model.DryRunResult, error) {
+	controller, err := s.controller(irsID)
+	if err != nil {
+		return nil, err
+	}
+
+	var d model.Diagnostics
+	if err := req.Validate(); err != nil {
+		d.Add(err)
+		return model.NewDryRunResult(d, nil, nil), nil
+	}
+	diagnoseReservation(ctx, &d, s.gate, model.ReservableDeviceIRS)
+	elements := len(req.Steps[0].PhaseShifts)
+	switch n := controller.ElementCount(); {
+	case n == 0:
+		d.Warnf("steps", "element count of panel %s is not known yet", s.panelID(irsID))
+	case n != elements:
+		d.Errorf("steps", "sequence steps must set every element of the panel (%d)", n)
+	}
+	if err := controller.Constraints().CheckSequence(req); err != nil {
+		d.Add(err)
+	}
+
+	var pass float64
+	for _, step := range req.Steps {
+		dwell := step.Dwell
+		if dwell == 0 {
+			dwell = req.Dwell
+		}
+		pass += dwell
+	}
+	passes := req.Repeat
+	if passes == 0 {
+		passes = 1
+	}
+	estimate := &model.CostEstimate{
+		Runtime:    pass * float64(passes),
+		Operations: float64(len(req.Steps) * elements * passes),
+		Memory:     int64(8 * len(req.Steps) * elements),
+		Iterations: passes,
+		Devices:    []string{model.ReservableDeviceIRS},
+	}
+	if req.Loop {
+		estimate.Runtime = pass
+		estimate.Iterations = 0
+		d.Warnf("loop", "the sequence loops until stopped, the estimate covers one pass of %g s", pass)
+	}
+
+	if d.HasErrors() {
+		return model.NewDryRunResult(d, nil, nil), nil
+	}
+	return model.NewDryRunResult(d, estimate, nil), nil
+}
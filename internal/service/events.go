@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+
+	"isac-cran-system/internal/model"
+)
+
+// Stream topics. Events about one sensor, experiment, pipeline run or beam
+// sweep are published on a subtopic named after it, e.g. "sensor.temp_01".
+const (
+	TopicChannel    = "channel"
+	TopicSensor     = "sensor"
+	TopicExperiment = "experiment"
+	TopicPipeline   = "pipeline"
+	TopicBeam       = "beam"
+)
+
+// EventPublisher broadcasts live events to the stream subscribers of their
+// topic. It must not block.
+type EventPublisher interface {
+	Publish(topic string, data interface{})
+}
+
+func publish(p EventPublisher, topic string, data interface{}) {
+	if p != nil {
+		p.Publish(topic, data)
+	}
+}
+
+func (s *ChannelService) SetEventPublisher(p EventPublisher) {
+	s.events = p
+}
+
+func (s *SensorService) SetEventPublisher(p EventPublisher) {
+	s.events = p
+}
+
+func (s *AlgorithmService) SetEventPublisher(p EventPublisher) {
+	s.events = p
+}
+
+func (s *PipelineService) SetEventPublisher(p EventPublisher) {
+	s.events = p
+}
+
+func (s *BeamService) SetEventPublisher(p EventPublisher) {
+	s.events = p
+}
+
+// setStatus stores a new experiment status and announces it to the
+// experiment's subscribers once stored.
+func (s *AlgorithmService) setStatus(ctx context.Context, result *model.ExperimentResult, status model.ExperimentStatus, resultData string) error {
+	if s.resultStore != nil {
+		if err := s.resultStore.UpdateStatus(ctx, result, status, resultData); err != nil {
+			return err
+		}
+	}
+	s.publishStatus(result, status)
+	return nil
+}
+
+func (s *AlgorithmService) publishStatus(result *model.ExperimentResult, status model.ExperimentStatus) {
+	publish(s.events, TopicExperiment+"."+result.ExperimentID, &model.ExperimentEvent{
+		ExperimentID:  result.ExperimentID,
+		AlgorithmType: result.AlgorithmType,
+		Status:        status,
+	})
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// queuedRun is an admitted experiment waiting for its device or for a heavy
+// experiment slot.
+type queuedRun struct {
+	result *model.ExperimentResult
+	holder string
+	device string
+	heavy  bool
+	// waitSlot means the run holds a place in the admission queue.
+	waitSlot bool
+	deadline time.Time
+	run      func(context.Context, *model.ExperimentResult) error
+}
+
+// experimentQueue runs queued experiments one at a time per device, in the
+// order they were queued.
+type experimentQueue struct {
+	mu    sync.Mutex
+	lanes map[string][]*queuedRun
+}
+
+func (q *experimentQueue) push(s *AlgorithmService, r *queuedRun) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.lanes == nil {
+		q.lanes = make(map[string][]*queuedRun)
+	}
+	lane := q.lanes[r.device]
+	q.lanes[r.device] = append(lane, r)
+	if len(lane) == 0 {
+		go q.drain(s, r.device)
+	}
+}
+
+// drain runs the lane of device until it is empty.
+func (q *experimentQueue) drain(s *AlgorithmService, device string) {
+	for {
+		q.mu.Lock()
+		lane := q.lanes[device]
+		if len(lane) == 0 {
+			delete(q.lanes, device)
+			q.mu.Unlock()
+			return
+		}
+		r := lane[0]
+		q.mu.Unlock()
+
+		s.runQueued(r)
+
+		q.mu.Lock()
+		q.lanes[device] = q.lanes[device][1:]
+		q.mu.Unlock()
+	}
+}
+
+// Len returns the number of queued experiments, including the ones waiting
+// at the head of their device's queue.
+func (q *experimentQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := 0
+	for _, lane := range q.lanes {
+		n += len(lane)
+	}
+	return n
+}
+
+// QueuedExperiments returns the number of experiments waiting to run.
+func (s *AlgorithmService) QueuedExperiments() int {
+	return s.queue.Len()
+}
+
+// runQueued waits for the device and a slot, then runs r on behalf of its
+// holder. An experiment still waiting at its deadline is marked failed.
+func (s *AlgorithmService) runQueued(r *queuedRun) {
+	ctx, cancel := context.WithDeadline(WithHolder(context.Background(), r.holder), r.deadline)
+	defer cancel()
+	experimentID := r.result.ExperimentID
+
+	abandon := func(err error) {
+		logger.Warn("Queued experiment abandoned", zap.String("experiment_id", experimentID), zap.Error(err))
+		s.setStatus(context.Background(), r.result, model.ExperimentStatusFailed, "")
+	}
+	if s.gate != nil {
+		if err := s.gate.WaitUntilFree(ctx, r.device); err != nil {
+			if r.waitSlot {
+				s.admission.dequeue()
+			}
+			abandon(err)
+			return
+		}
+	}
+	if r.heavy {
+		wait := s.admission.acquire
+		if r.waitSlot {
+			wait = s.admission.wait
+		}
+		if err := wait(ctx); err != nil {
+			abandon(err)
+			return
+		}
+		defer s.admission.release()
+	}
+
+	// another controller may have taken over the experiment meanwhile
+	if err := s.setStatus(ctx, r.result, model.ExperimentStatusRunning, ""); errors.IsCode(err, errors.CodeVersionConflict) {
+		logger.Warn("Queued experiment changed while waiting", zap.String("experiment_id", experimentID), zap.Error(err))
+		return
+	}
+	if err := r.run(ctx, r.result); err != nil {
+		logger.Warn("Queued experiment failed", zap.String("experiment_id", experimentID), zap.Error(err))
+	}
+}
+
+// ResumeQueued queues the experiments left pending by a previous run, oldest
+// first, and returns how many were queued. They keep waiting for at most
+// maxQueueWait from when they were first admitted. Pending experiments of a
+// type that cannot be resumed are marked failed.
+func (s *AlgorithmService) ResumeQueued(ctx context.Context) (int, error) {
+	if s.resultStore == nil {
+		return 0, nil
+	}
+	status := model.ExperimentStatusPending
+	var pending []model.ExperimentResult
+	for page := 1; ; page++ {
+		results, _, err := s.resultStore.List(ctx, &model.ExperimentQuery{
+			Status:   &status,
+			Sort:     "created_at",
+			Order:    "asc",
+			Page:     page,
+			PageSize: 100,
+		})
+		if err != nil {
+			return 0, err
+		}
+		pending = append(pending, results...)
+		if len(results) < 100 {
+			break
+		}
+	}
+
+	n := 0
+	for i := range pending {
+		result := &pending[i]
+		cost, run, err := s.resumable(result)
+		if err != nil {
+			logger.Warn("Pending experiment cannot be resumed", zap.String("experiment_id", result.ExperimentID), zap.Error(err))
+			s.setStatus(ctx, result, model.ExperimentStatusFailed, "")
+			continue
+		}
+		s.queue.push(s, &queuedRun{
+			result:   result,
+			holder:   result.Holder,
+			device:   cost.estimate.Devices[0],
+			heavy:    s.admission.heavy(s.timings.predict(cost)),
+			deadline: result.CreatedAt.Add(maxQueueWait),
+			run:      run,
+		})
+		n++
+	}
+	return n, nil
+}
+
+// resumable rebuilds the run of a stored experiment from its parameters.
+func (s *AlgorithmService) resumable(result *model.ExperimentResult) (*experimentCost, func(context.Context, *model.ExperimentResult) error, error) {
+	switch result.AlgorithmType {
+	case model.AlgorithmTypeBeamforming:
+		var params model.BeamformingParams
+		if err := json.Unmarshal([]byte(result.Parameters), &params); err != nil {
+			return nil, nil, err
+		}
+		cost := s.beamformingCost(&params)
+		return cost, func(ctx context.Context, result *model.ExperimentResult) error {
+			_, err := s.runBeamforming(ctx, result, &params, cost)
+			return err
+		}, nil
+	case model.AlgorithmTypeDOA:
+		var params model.DOAParams
+		if err := json.Unmarshal([]byte(result.Parameters), &params); err != nil {
+			return nil, nil, err
+		}
+		cost, _ := s.doaCost(&params)
+		return cost, func(ctx context.Context, result *model.ExperimentResult) error {
+			_, err := s.runDOA(ctx, result, &params, cost)
+			return err
+		}, nil
+	case model.AlgorithmTypeIRSImpact:
+		var params model.IRSImpactParams
+		if err := json.Unmarshal([]byte(result.Parameters), &params); err != nil {
+			return nil, nil, err
+		}
+		cost := s.irsImpactCost(&params)
+		return cost, func(ctx context.Context, result *model.ExperimentResult) error {
+			_, err := s.runIRSImpact(ctx, result, &params, cost)
+			return err
+		}, nil
+	case model.AlgorithmTypeJointBeamforming:
+		var params model.JointBeamformingParams
+		if err := json.Unmarshal([]byte(result.Parameters), &params); err != nil {
+			return nil, nil, err
+		}
+		cost := s.jointBeamformingCost(&params)
+		return cost, func(ctx context.Context, result *model.ExperimentResult) error {
+			_, err := s.runJointBeamforming(ctx, result, &params, cost)
+			return err
+		}, nil
+	case model.AlgorithmTypeHybridBeamforming:
+		var params model.HybridBeamformingParams
+		if err := json.Unmarshal([]byte(result.Parameters), &params); err != nil {
+			return nil, nil, err
+		}
+		cost := s.hybridBeamformingCost(&params)
+		return cost, func(ctx context.Context, result *model.ExperimentResult) error {
+			_, err := s.runHybridBeamforming(ctx, result, &params, cost)
+			return err
+		}, nil
+	}
+	return nil, nil, errors.NewWithDetail(errors.CodeInvalidParam, "unsupported algorithm type", string(result.AlgorithmType))
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+// memResultStore keeps experiment results in memory with version checked
+// updates, and records the order experiments started running in.
+type memResultStore struct {
+	mu      sync.Mutex
+	results map[string]*model.ExperimentResult
+	started []string
+	active  int
+	peak    int
+}
+
+func newMemResultStore() *memResultStore {
+	return &memResultStore{results: make(map[string]*model.ExperimentResult)}
+}
+
+func (m *memResultStore) Create(ctx context.Context, result *model.ExperimentResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result.ID = int64(len(m.results) + 1)
+	result.Version = 1
+	if result.CreatedAt.IsZero() {
+		result.CreatedAt = time.Now()
+	}
+	copied := *result
+	m.results[result.ExperimentID] = &copied
+	return nil
+}
+
+func (m *memResultStore) GetByExperimentID(ctx context.Context, experimentID string) (*model.ExperimentResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.results[experimentID]
+	if !ok {
+		return nil, errors.New(errors.CodeNotFound, "experiment result not found")
+	}
+	copied := *r
+	return &copied, nil
+}
+
+func (m *memResultStore) UpdateStatus(ctx context.Context, result *model.ExperimentResult, status model.ExperimentStatus, resultData string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, ok := m.results[result.ExperimentID]
+	if !ok || stored.Version != result.Version {
+		return errors.New(errors.CodeVersionConflict, "experiment result was modified concurrently")
+	}
+	switch {
+	case status == model.ExperimentStatusRunning:
+		m.started = append(m.started, result.ExperimentID)
+		m.active++
+		if m.active > m.peak {
+			m.peak = m.active
+		}
+	case stored.Status == model.ExperimentStatusRunning:
+		m.active--
+	}
+	stored.Status = status
+	stored.Version++
+	if resultData != "" {
+		stored.ResultData = &resultData
+	}
+	result.Status, result.Version = stored.Status, stored.Version
+	return nil
+}
+
+func (m *memResultStore) List(ctx context.Context, q *model.ExperimentQuery) ([]model.ExperimentResult, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []model.ExperimentResult
+	if q.Page > 1 {
+		return out, 0, nil
+	}
+	for _, r := range m.results {
+		if q.Status == nil || r.Status == *q.Status {
+			out = append(out, *r)
+		}
+	}
+	return out, int64(len(out)), nil
+}
+
+func (m *memResultStore) UpdatePowerEstimate(ctx context.Context, result *model.ExperimentResult, estimate string) error {
+	return nil
+}
+
+func (m *memResultStore) UpdateEnergyReport(ctx context.Context, result *model.ExperimentResult, report string) error {
+	return nil
+}
+
+func (m *memResultStore) ListWithEnergyReport(ctx context.Context, algorithmType model.AlgorithmType) ([]model.ExperimentResult, error) {
+	return nil, nil
+}
+
+func (m *memResultStore) status(experimentID string) model.ExperimentStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.results[experimentID].Status
+}
+
+// waitDone waits until none of ids is pending or running.
+func (m *memResultStore) waitDone(t *testing.T, ids ...string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for _, id := range ids {
+		for s := m.status(id); s == model.ExperimentStatusPending || s == model.ExperimentStatusRunning; s = m.status(id) {
+			if time.Now().After(deadline) {
+				t.Fatalf("experiment %s still has status %d", id, s)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+// heldGate keeps a device reserved by someone else until released, and
+// records the holders it was asked about.
+type heldGate struct {
+	released chan struct{}
+	mu       sync.Mutex
+	holders  []string
+}
+
+func (g *heldGate) Blocking(ctx context.Context, device string) (*model.Reservation, error) {
+	select {
+	case <-g.released:
+		return nil, nil
+	default:
+		return &model.Reservation{Device: device, Holder: "someone-else", EndTime: time.Now().Add(time.Hour)}, nil
+	}
+}
+
+func (g *heldGate) WaitUntilFree(ctx context.Context, device string) error {
+	g.mu.Lock()
+	g.holders = append(g.holders, HolderFromContext(ctx))
+	g.mu.Unlock()
+	select {
+	case <-g.released:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func testBeamformingParams() *model.BeamformingParams {
+	return &model.BeamformingParams{ElementCount: 8, TargetDirection: 20, MaxIterations: 10}
+}
+
+func TestQueuedExperimentsRunInOrder(t *testing.T) {
+	store := newMemResultStore()
+	gate := &heldGate{released: make(chan struct{})}
+	svc := NewAlgorithmService(store)
+	svc.SetDeviceGate(gate)
+
+	ctx := WithHolder(context.Background(), "alice")
+	ids := []string{"exp_1", "exp_2", "exp_3"}
+	for _, id := range ids {
+		_, err := svc.RunBeamforming(ctx, id, testBeamformingParams())
+		var queued *ExperimentQueuedError
+		if !stderrors.As(err, &queued) {
+			t.Fatalf("%s: error = %v, want ExperimentQueuedError", id, err)
+		}
+		if r, _ := store.GetByExperimentID(ctx, id); r.Status != model.ExperimentStatusPending || r.Holder != "alice" {
+			t.Fatalf("%s stored as status %d for %q, want pending for alice", id, r.Status, r.Holder)
+		}
+	}
+	if n := svc.QueuedExperiments(); n != 3 {
+		t.Errorf("QueuedExperiments = %d, want 3", n)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(gate.released)
+	store.waitDone(t, ids...)
+
+	for _, id := range ids {
+		if s := store.status(id); s != model.ExperimentStatusCompleted {
+			t.Errorf("%s status = %d, want completed", id, s)
+		}
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for i, id := range ids {
+		if i >= len(store.started) || store.started[i] != id {
+			t.Fatalf("run order = %v, want %v", store.started, ids)
+		}
+	}
+	if store.peak != 1 {
+		t.Errorf("%d queued experiments ran at once, want 1", store.peak)
+	}
+	gate.mu.Lock()
+	defer gate.mu.Unlock()
+	for _, holder := range gate.holders {
+		if holder != "alice" {
+			t.Errorf("queued experiment waited as %q, want alice", holder)
+		}
+	}
+}
+
+func TestResumeQueued(t *testing.T) {
+	store := newMemResultStore()
+	ctx := context.Background()
+	pending := []*model.ExperimentResult{
+		{ExperimentID: "exp_old", AlgorithmType: model.AlgorithmTypeBeamforming, Holder: "bob",
+			Parameters: `{"element_count":8,"target_direction":10,"max_iterations":10}`, CreatedAt: time.Now().Add(-time.Minute)},
+		{ExperimentID: "exp_new", AlgorithmType: model.AlgorithmTypeBeamforming, Holder: "bob",
+			Parameters: `{"element_count":8,"target_direction":30,"max_iterations":10}`, CreatedAt: time.Now()},
+		{ExperimentID: "exp_sched", AlgorithmType: model.AlgorithmTypeScheduling, Parameters: `{}`},
+	}
+	for _, r := range pending {
+		r.Status = model.ExperimentStatusPending
+		store.Create(ctx, r)
+	}
+
+	gate := &heldGate{released: make(chan struct{})}
+	close(gate.released)
+	svc := NewAlgorithmService(store)
+	svc.SetDeviceGate(gate)
+
+	n, err := svc.ResumeQueued(ctx)
+	if err != nil {
+		t.Fatalf("ResumeQueued: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("resumed %d experiments, want 2", n)
+	}
+	store.waitDone(t, "exp_old", "exp_new")
+
+	for id, want := range map[string]model.ExperimentStatus{
+		"exp_old":   model.ExperimentStatusCompleted,
+		"exp_new":   model.ExperimentStatusCompleted,
+		"exp_sched": model.ExperimentStatusFailed,
+	} {
+		if s := store.status(id); s != want {
+			t.Errorf("%s status = %d, want %d", id, s, want)
+		}
+	}
+	gate.mu.Lock()
+	defer gate.mu.Unlock()
+	if len(gate.holders) != 2 || gate.holders[0] != "bob" {
+		t.Errorf("resumed experiments waited as %v, want bob", gate.holders)
+	}
+}
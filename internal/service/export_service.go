@@ -0,0 +1,172 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/internal/repository/objectstore"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/report"
+)
+
+type ExportService struct {
+	store         *objectstore.TieredStore
+	artifacts     *ArtifactService
+	results       AlgorithmResultStore
+	devices       DeviceLister
+	sensors       SensorAggregator
+	presignExpiry time.Duration
+}
+
+func NewExportService(store *objectstore.TieredStore, artifacts *ArtifactService, results AlgorithmResultStore, presignExpiry time.Duration) *ExportService {
+	if presignExpiry <= 0 {
+		presignExpiry = time.Hour
+	}
+	return &ExportService{
+		store:         store,
+		artifacts:     artifacts,
+		results:       results,
+		presignExpiry: presignExpiry,
+	}
+}
+
+// SetDevices adds the state of the devices to experiment reports.
+func (s *ExportService) SetDevices(devices DeviceLister) {
+	s.devices = devices
+}
+
+// SetSensors adds the environment sensor readings taken during an
+// experiment to its report.
+func (s *ExportService) SetSensors(sensors SensorAggregator) {
+	s.sensors = sensors
+}
+
+func (s *ExportService) ExportExperiment(ctx context.Context, experimentID string, format model.ExportFormat) (*model.ExportResult, error) {
+	if s.store == nil {
+		return nil, errors.New(errors.CodeServiceUnavailable, "object store not available")
+	}
+	if s.results == nil {
+		return nil, errors.New(errors.CodeNotFound, "result store not available")
+	}
+	if s.artifacts == nil {
+		return nil, errors.New(errors.CodeServiceUnavailable, "artifact store not available")
+	}
+
+	result, err := s.results.GetByExperimentID(ctx, experimentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	artifactType := model.ArtifactTypeExport
+	switch format {
+	case model.ExportFormatJSON, "":
+		format = model.ExportFormatJSON
+		data, err = json.MarshalIndent(result, "", "  ")
+	case model.ExportFormatCSV:
+		data, err = experimentToCSV(result)
+	case model.ExportFormatHTML, model.ExportFormatPDF:
+		artifactType = model.ArtifactTypeReport
+		data, err = s.renderReport(ctx, result, format)
+	default:
+		return nil, errors.NewWithDetail(errors.CodeInvalidParam, "unsupported export format", string(format))
+	}
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeMATLABExportError, "failed to encode experiment", err)
+	}
+
+	name := fmt.Sprintf("%s.%s", time.Now().Format("20060102150405"), format)
+	artifact, err := s.artifacts.Upload(ctx, artifactType, experimentID, name, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := s.store.PresignGet(ctx, artifact.StorageKey, s.presignExpiry)
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeObjectStoreError, "failed to presign export", err)
+	}
+
+	return &model.ExportResult{
+		ExperimentID: experimentID,
+		ArtifactID:   artifact.ID,
+		Format:       format,
+		Key:          artifact.StorageKey,
+		Size:         artifact.Size,
+		Checksum:     artifact.Checksum,
+		URL:          url,
+		ExpiresAt:    time.Now().Add(s.presignExpiry),
+	}, nil
+}
+
+func (s *ExportService) renderReport(ctx context.Context, result *model.ExperimentResult, format model.ExportFormat) ([]byte, error) {
+	doc, err := s.buildReport(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if format == model.ExportFormatPDF {
+		err = report.WritePDF(&buf, doc)
+	} else {
+		err = report.WriteHTML(&buf, doc)
+	}
+	return buf.Bytes(), err
+}
+
+// OpenSigned opens a hot-tier object after validating the signature produced
+// by the local store's presigned URL.
+func (s *ExportService) OpenSigned(ctx context.Context, key, expires, signature string) (io.ReadCloser, *objectstore.ObjectInfo, error) {
+	if s.store == nil {
+		return nil, nil, errors.New(errors.CodeServiceUnavailable, "object store not available")
+	}
+	if !s.store.Hot().VerifyPresigned(key, expires, signature) {
+		return nil, nil, errors.New(errors.CodeForbidden, "invalid or expired download signature")
+	}
+
+	info, err := s.store.Hot().Stat(ctx, key)
+	if err == objectstore.ErrObjectNotFound {
+		return nil, nil, errors.New(errors.CodeNotFound, "object not found")
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(errors.CodeObjectStoreError, "failed to stat object", err)
+	}
+
+	rc, err := s.store.Hot().Get(ctx, key)
+	if err != nil {
+		return nil, nil, errors.Wrap(errors.CodeObjectStoreError, "failed to open object", err)
+	}
+	return rc, info, nil
+}
+
+func experimentToCSV(result *model.ExperimentResult) ([]byte, error) {
+	completedAt := ""
+	if result.CompletedAt != nil {
+		completedAt = result.CompletedAt.Format(time.RFC3339)
+	}
+	resultData := ""
+	if result.ResultData != nil {
+		resultData = *result.ResultData
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"id", "experiment_id", "algorithm_type", "status", "created_at", "completed_at", "parameters", "result_data"})
+	w.Write([]string{
+		strconv.FormatInt(result.ID, 10),
+		result.ExperimentID,
+		string(result.AlgorithmType),
+		strconv.Itoa(int(result.Status)),
+		result.CreatedAt.Format(time.RFC3339),
+		completedAt,
+		result.Parameters,
+		resultData,
+	})
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+// RunHybridBeamforming splits the precoder of a MIMO link between phase
+// shifters and a few RF chains and stores the result as a
+// hybrid_beamforming experiment.
+func (s *AlgorithmService) RunHybridBeamforming(ctx context.Context, experimentID string, params *model.HybridBeamformingParams) (*model.HybridBeamformingResult, error) {
+	if err := params.Validate(); err != nil {
+		return nil, errors.Wrap(errors.CodeInvalidParam, "invalid hybrid beamforming parameters", err)
+	}
+	cost := s.hybridBeamformingCost(params)
+	result, release, err := s.admit(ctx, experimentID, params, cost, func(ctx context.Context, result *model.ExperimentResult) error {
+		_, err := s.runHybridBeamforming(ctx, result, params, cost)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.runHybridBeamforming(ctx, result, params, cost)
+}
+
+func (s *AlgorithmService) runHybridBeamforming(ctx context.Context, result *model.ExperimentResult, params *model.HybridBeamformingParams, cost *experimentCost) (*model.HybridBeamformingResult, error) {
+	measurement := s.beginEnergyMeasurement(ctx)
+
+	hybrid, err := s.beamformingOptimizer.HybridOptimize(params)
+	if err != nil {
+		s.setStatus(ctx, result, model.ExperimentStatusFailed, "")
+		return nil, errors.Wrap(algorithmErrorCode(err), "hybrid beamforming optimization failed", err)
+	}
+
+	if err := s.completeResult(ctx, result, hybrid); err != nil {
+		return nil, err
+	}
+	// experiments rank by method and phase resolution
+	variant := hybrid.Method
+	if params.PhaseBits > 0 {
+		variant = fmt.Sprintf("%s %d-bit", hybrid.Method, params.PhaseBits)
+	}
+	s.timings.observe(cost, time.Since(measurement.startTime))
+	s.recordEnergy(ctx, result, measurement, energyUsage{
+		variant:          variant,
+		reconfigurations: 1,
+	})
+	return hybrid, nil
+}
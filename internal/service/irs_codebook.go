@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"isac-cran-system/internal/algorithm/beamforming"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+// CodebookStore keeps the IRS codebooks by name.
+type CodebookStore interface {
+	Create(ctx context.Context, codebook *model.IRSCodebook) error
+	GetByName(ctx context.Context, name string) (*model.IRSCodebook, error)
+	List(ctx context.Context) ([]model.IRSCodebook, error)
+	Delete(ctx context.Context, name string) error
+}
+
+func (s *IRSService) SetCodebookStore(store CodebookStore) {
+	s.codebooks = store
+}
+
+func (s *IRSService) codebookStore() (CodebookStore, error) {
+	if s.codebooks == nil {
+		return nil, errors.New(errors.CodeServiceUnavailable, "IRS codebooks not available")
+	}
+	return s.codebooks, nil
+}
+
+// CreateCodebook stores a custom codebook or generates a DFT or random one.
+// Names are unique; delete a codebook to replace it.
+func (s *IRSService) CreateCodebook(ctx context.Context, req *model.IRSCodebookRequest) (*model.IRSCodebook, error) {
+	store, err := s.codebookStore()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := store.GetByName(ctx, req.Name); err == nil {
+		return nil, errors.New(errors.CodeInvalidParam, "IRS codebook "+req.Name+" already exists")
+	} else if !errors.IsCode(err, errors.CodeNotFound) {
+		return nil, err
+	}
+
+	codebook := &model.IRSCodebook{
+		Name:         req.Name,
+		Kind:         req.Kind,
+		ElementCount: req.ElementCount,
+	}
+	switch req.Kind {
+	case model.CodebookKindDFT:
+		size := req.Size
+		if size == 0 {
+			size = req.ElementCount
+		}
+		codebook.Entries = beamforming.DFTCodebook(req.ElementCount, size)
+	case model.CodebookKindRandom:
+		codebook.Entries = beamforming.RandomCodebook(req.ElementCount, req.Size, req.Seed)
+	default:
+		codebook.Entries = req.Entries
+	}
+	codebook.Size = len(codebook.Entries)
+
+	if err := store.Create(ctx, codebook); err != nil {
+		return nil, err
+	}
+	return codebook, nil
+}
+
+func (s *IRSService) ListCodebooks(ctx context.Context) ([]model.IRSCodebook, error) {
+	store, err := s.codebookStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.List(ctx)
+}
+
+func (s *IRSService) GetCodebook(ctx context.Context, name string) (*model.IRSCodebook, error) {
+	store, err := s.codebookStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.GetByName(ctx, name)
+}
+
+func (s *IRSService) DeleteCodebook(ctx context.Context, name string) error {
+	store, err := s.codebookStore()
+	if err != nil {
+		return err
+	}
+	return store.Delete(ctx, name)
+}
+
+// ApplyCodebookEntry configures a panel with one codebook entry. With a
+// group the entry must match the group's element count and only that group
+// changes; otherwise the entry covers the whole surface.
+func (s *IRSService) ApplyCodebookEntry(ctx context.Context, irsID, name string, req *model.IRSCodebookApplyRequest) (*model.IRSConfig, error) {
+	store, err := s.codebookStore()
+	if err != nil {
+		return nil, err
+	}
+	controller, err := s.controller(irsID)
+	if err != nil {
+		return nil, err
+	}
+	codebook, err := store.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	index := *req.Index
+	if index < 0 || index >= len(codebook.Entries) {
+		return nil, errors.New(errors.CodeInvalidParam,
+			fmt.Sprintf("index %d out of range for codebook %s with %d entries", index, name, len(codebook.Entries)))
+	}
+	entry := codebook.Entries[index]
+	if err := s.checkReservation(ctx); err != nil {
+		return nil, err
+	}
+	s.stopSequence(irsID)
+
+	if req.Group != "" {
+		groupReq := &model.IRSGroupRequest{PhaseShifts: entry, Version: req.Version, ExperimentID: req.ExperimentID}
+		if err := controller.ConfigureGroup(ctx, req.Group, groupReq); err != nil {
+			return nil, err
+		}
+	} else {
+		configReq := surfaceRequest(fmt.Sprintf("%s[%d]", name, index), controller.GetCurrentConfig(), entry)
+		configReq.Version = req.Version
+		configReq.ExperimentID = req.ExperimentID
+		if err := controller.Configure(ctx, configReq); err != nil {
+			return nil, err
+		}
+	}
+
+	config := controller.GetCurrentConfig()
+	s.record(ctx, irsID, config, nil)
+	return config, nil
+}
+
+// applyBeam writes one beam of a sweep to the whole surface of a panel.
+// Unlike a configuration the write is not kept in the history; the sweep
+// records the beam it settles on with recordBeam.
+func (s *IRSService) applyBeam(ctx context.Context, irsID, name string, phaseShifts []float64) error {
+	controller, err := s.controller(irsID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkReservation(ctx); err != nil {
+		return err
+	}
+	s.stopSequence(irsID)
+	return controller.Configure(ctx, surfaceRequest(name, controller.GetCurrentConfig(), phaseShifts))
+}
+
+func (s *IRSService) recordBeam(ctx context.Context, irsID string) {
+	if controller, err := s.controller(irsID); err == nil {
+		s.record(ctx, irsID, controller.GetCurrentConfig(), nil)
+	}
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"isac-cran-system/internal/device/irs"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+func (s *IRSService) faultInjector(irsID string) (irs.FaultInjector, error) {
+	controller, err := s.controller(irsID)
+	if err != nil {
+		return nil, err
+	}
+	injector, ok := controller.FaultInjector()
+	if !ok {
+		return nil, errors.New(errors.CodeInvalidParam, "IRS panel "+s.panelID(irsID)+" is not simulated")
+	}
+	return injector, nil
+}
+
+// SimulatorState returns the impairments and faults of a simulated panel.
+func (s *IRSService) SimulatorState(irsID string) (*model.IRSSimulatorState, error) {
+	injector, err := s.faultInjector(irsID)
+	if err != nil {
+		return nil, err
+	}
+	return injector.State(), nil
+}
+
+func (s *IRSService) InjectFaults(irsID string, req *model.IRSFaultRequest) (*model.IRSSimulatorState, error) {
+	injector, err := s.faultInjector(irsID)
+	if err != nil {
+		return nil, err
+	}
+	if err := injector.InjectFaults(req); err != nil {
+		return nil, errors.Wrap(errors.CodeInvalidParam, err.Error(), err)
+	}
+	return injector.State(), nil
+}
+
+func (s *IRSService) ClearFaults(irsID string) (*model.IRSSimulatorState, error) {
+	injector, err := s.faultInjector(irsID)
+	if err != nil {
+		return nil, err
+	}
+	injector.ClearFaults()
+	return injector.State(), nil
+}
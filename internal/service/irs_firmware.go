@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// firmwareApplyTimeout bounds flashing a panel and reading its version back.
+const firmwareApplyTimeout = 2 * time.Minute
+
+// firmwareState holds the uploaded packages and the update history. Images
+// are kept in memory until the server restarts.
+type firmwareState struct {
+	mu       sync.Mutex
+	packages map[string]*firmwarePackage
+	updates  []*firmwareUpdate
+}
+
+type firmwarePackage struct {
+	model.FirmwarePackage
+	image []byte
+}
+
+type firmwareUpdate struct {
+	model.FirmwareUpdate
+	holder string
+	timer  *time.Timer
+}
+
+// UploadFirmware stores a firmware image for version. checksum, when set, is
+// the expected SHA-256 of the image in hex and guards against a truncated
+// upload.
+func (s *IRSService) UploadFirmware(version, checksum string, image []byte) (*model.FirmwarePackage, error) {
+	if strings.TrimSpace(version) == "" {
+		return nil, errors.New(errors.CodeInvalidParam, "version is required")
+	}
+	if len(image) == 0 {
+		return nil, errors.New(errors.CodeInvalidParam, "firmware image is empty")
+	}
+	sum := sha256.Sum256(image)
+	digest := hex.EncodeToString(sum[:])
+	if checksum != "" && !strings.EqualFold(checksum, digest) {
+		return nil, errors.NewWithDetail(errors.CodeInvalidParam, "firmware checksum mismatch",
+			fmt.Sprintf("image has sha256 %s", digest))
+	}
+
+	s.firmware.mu.Lock()
+	defer s.firmware.mu.Unlock()
+	if s.firmware.packages == nil {
+		s.firmware.packages = make(map[string]*firmwarePackage)
+	}
+	id := "fw_" + digest[:12]
+	if pkg, ok := s.firmware.packages[id]; ok {
+		if pkg.Version != version {
+			return nil, errors.New(errors.CodeInvalidParam, "image was already uploaded as version "+pkg.Version)
+		}
+		result := pkg.FirmwarePackage
+		return &result, nil
+	}
+	pkg := &firmwarePackage{
+		FirmwarePackage: model.FirmwarePackage{
+			ID:         id,
+			Version:    version,
+			Size:       len(image),
+			Checksum:   digest,
+			UploadedAt: time.Now(),
+		},
+		image: image,
+	}
+	s.firmware.packages[id] = pkg
+	logger.Info("Firmware package uploaded", zap.String("package_id", id), zap.String("version", version), zap.Int("size", len(image)))
+	result := pkg.FirmwarePackage
+	return &result, nil
+}
+
+// FirmwarePackages lists the uploaded packages, newest first.
+func (s *IRSService) FirmwarePackages() []model.FirmwarePackage {
+	s.firmware.mu.Lock()
+	defer s.firmware.mu.Unlock()
+	packages := make([]model.FirmwarePackage, 0, len(s.firmware.packages))
+	for _, pkg := range s.firmware.packages {
+		packages = append(packages, pkg.FirmwarePackage)
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].UploadedAt.After(packages[j].UploadedAt) })
+	return packages
+}
+
+// ScheduleFirmwareUpdate schedules a package onto a panel whose board
+// supports remote updates. A panel has at most one pending update. When it
+// runs, the update stops the panel's sequence, flashes the image and checks
+// that the panel reports the package's version afterwards.
+func (s *IRSService) ScheduleFirmwareUpdate(ctx context.Context, req *model.FirmwareUpdateRequest) (*model.FirmwareUpdate, error) {
+	controller, err := s.controller(req.IRSID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := controller.FirmwareUpdater(); !ok {
+		return nil, errors.New(errors.CodeInvalidParam, "IRS panel "+s.panelID(req.IRSID)+" does not support remote firmware updates")
+	}
+	if err := s.checkReservation(ctx); err != nil {
+		return nil, err
+	}
+
+	s.firmware.mu.Lock()
+	defer s.firmware.mu.Unlock()
+	pkg, ok := s.firmware.packages[req.PackageID]
+	if !ok {
+		return nil, errors.New(errors.CodeNotFound, "firmware package "+req.PackageID+" not found")
+	}
+	irsID := s.panelID(req.IRSID)
+	for _, u := range s.firmware.updates {
+		if u.IRSID == irsID && !u.Status.Done() {
+			return nil, errors.New(errors.CodeIRSDeviceError, fmt.Sprintf("IRS panel %s already has firmware update %d pending", irsID, u.ID))
+		}
+	}
+
+	at := time.Now()
+	if req.ScheduledAt != nil && req.ScheduledAt.After(at) {
+		at = *req.ScheduledAt
+	}
+	update := &firmwareUpdate{
+		FirmwareUpdate: model.FirmwareUpdate{
+			ID:          int64(len(s.firmware.updates) + 1),
+			PackageID:   pkg.ID,
+			Version:     pkg.Version,
+			IRSID:       irsID,
+			Status:      model.FirmwareUpdateScheduled,
+			ScheduledAt: at,
+		},
+		holder: HolderFromContext(ctx),
+	}
+	s.firmware.updates = append(s.firmware.updates, update)
+	id := update.ID
+	update.timer = time.AfterFunc(time.Until(at), func() { s.applyFirmware(id) })
+	logger.Info("Firmware update scheduled",
+		zap.Int64("update_id", id), zap.String("irs_id", irsID),
+		zap.String("version", pkg.Version), zap.Time("at", at))
+	result := update.FirmwareUpdate
+	return &result, nil
+}
+
+// FirmwareUpdates lists the updates of a panel, or of every panel when irsID
+// is empty, newest first.
+func (s *IRSService) FirmwareUpdates(irsID string) []model.FirmwareUpdate {
+	s.firmware.mu.Lock()
+	defer s.firmware.mu.Unlock()
+	updates := make([]model.FirmwareUpdate, 0, len(s.firmware.updates))
+	for i := len(s.firmware.updates) - 1; i >= 0; i-- {
+		if u := s.firmware.updates[i]; irsID == "" || u.IRSID == irsID {
+			updates = append(updates, u.FirmwareUpdate)
+		}
+	}
+	return updates
+}
+
+func (s *IRSService) GetFirmwareUpdate(id int64) (*model.FirmwareUpdate, error) {
+	s.firmware.mu.Lock()
+	defer s.firmware.mu.Unlock()
+	u, err := s.firmwareUpdate(id)
+	if err != nil {
+		return nil, err
+	}
+	result := u.FirmwareUpdate
+	return &result, nil
+}
+
+// CancelFirmwareUpdate cancels an update that has not started yet.
+func (s *IRSService) CancelFirmwareUpdate(id int64) (*model.FirmwareUpdate, error) {
+	s.firmware.mu.Lock()
+	defer s.firmware.mu.Unlock()
+	u, err := s.firmwareUpdate(id)
+	if err != nil {
+		return nil, err
+	}
+	if u.Status != model.FirmwareUpdateScheduled {
+		return nil, errors.New(errors.CodeInvalidParam, "only scheduled firmware updates can be cancelled, update is "+string(u.Status))
+	}
+	u.timer.Stop()
+	now := time.Now()
+	u.Status = model.FirmwareUpdateCancelled
+	u.FinishedAt = &now
+	result := u.FirmwareUpdate
+	return &result, nil
+}
+
+// firmwareUpdate must be called with s.firmware.mu held.
+func (s *IRSService) firmwareUpdate(id int64) (*firmwareUpdate, error) {
+	if id < 1 || id > int64(len(s.firmware.updates)) {
+		return nil, errors.New(errors.CodeNotFound, fmt.Sprintf("firmware update %d not found", id))
+	}
+	return s.firmware.updates[id-1], nil
+}
+
+func (s *IRSService) applyFirmware(id int64) {
+	s.firmware.mu.Lock()
+	u, _ := s.firmwareUpdate(id)
+	if u.Status != model.FirmwareUpdateScheduled {
+		s.firmware.mu.Unlock()
+		return
+	}
+	started := time.Now()
+	u.Status = model.FirmwareUpdateApplying
+	u.StartedAt = &started
+	image := s.firmware.packages[u.PackageID].image
+	irsID, version, holder := u.IRSID, u.Version, u.holder
+	s.firmware.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(WithHolder(context.Background(), holder), firmwareApplyTimeout)
+	defer cancel()
+	previous, err := s.flashFirmware(ctx, id, irsID, image, version)
+	s.finishFirmware(id, previous, err)
+}
+
+// flashFirmware applies the image and verifies the version the panel reports
+// afterwards. It returns the firmware that ran before.
+func (s *IRSService) flashFirmware(ctx context.Context, id int64, irsID string, image []byte, version string) (string, error) {
+	controller, err := s.controller(irsID)
+	if err != nil {
+		return "", err
+	}
+	if err := s.checkReservation(ctx); err != nil {
+		return "", err
+	}
+	var previous string
+	if versions, err := controller.Versions(); err == nil {
+		previous = versions.Firmware
+	}
+	s.stopSequence(irsID)
+	if err := controller.UpdateFirmware(ctx, image, version); err != nil {
+		return previous, err
+	}
+
+	s.firmware.mu.Lock()
+	s.firmware.updates[id-1].Status = model.FirmwareUpdateVerifying
+	s.firmware.mu.Unlock()
+	versions, err := controller.Versions()
+	if err != nil {
+		return previous, errors.Wrap(errors.CodeIRSStatusError, "failed to read firmware version", err)
+	}
+	if versions.Firmware != version {
+		return previous, errors.New(errors.CodeIRSDeviceError,
+			fmt.Sprintf("panel reports firmware %q after updating to %q", versions.Firmware, version))
+	}
+	return previous, nil
+}
+
+func (s *IRSService) finishFirmware(id int64, previous string, err error) {
+	s.firmware.mu.Lock()
+	defer s.firmware.mu.Unlock()
+	u := s.firmware.updates[id-1]
+	now := time.Now()
+	u.FinishedAt = &now
+	u.PreviousVersion = previous
+	if err != nil {
+		u.Status = model.FirmwareUpdateFailed
+		u.Error = err.Error()
+		logger.Error("Firmware update failed", zap.Int64("update_id", id), zap.String("irs_id", u.IRSID), zap.Error(err))
+		return
+	}
+	u.Status = model.FirmwareUpdateSucceeded
+	logger.Info("Firmware update succeeded",
+		zap.Int64("update_id", id), zap.String("irs_id", u.IRSID),
+		zap.String("from", previous), zap.String("to", u.Version))
+}
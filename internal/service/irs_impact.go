@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+
+	"isac-cran-system/internal/algorithm/covariance"
+	"isac-cran-system/internal/algorithm/doa"
+	"isac-cran-system/internal/device/irs"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// minImpactSNR keeps the decibels of a capture without a detectable source
+// finite.
+const minImpactSNR = 1e-6
+
+// SetIRSPanels gives IRS impact measurements the panels to switch.
+func (s *AlgorithmService) SetIRSPanels(panels *irs.Manager) {
+	s.irsPanels = panels
+}
+
+// RunIRSImpact measures the SNR and rate an IRS configuration adds to the
+// received signal and stores the result as an irs_impact experiment.
+func (s *AlgorithmService) RunIRSImpact(ctx context.Context, experimentID string, params *model.IRSImpactParams) (*model.IRSImpactResult, error) {
+	if err := params.Validate(); err != nil {
+		return nil, errors.Wrap(errors.CodeInvalidParam, "invalid IRS impact parameters", err)
+	}
+	if err := s.remoteDevices("IRS impact measurement"); err != nil {
+		return nil, err
+	}
+	cost := s.irsImpactCost(params)
+	result, release, err := s.admit(ctx, experimentID, params, cost, func(ctx context.Context, result *model.ExperimentResult) error {
+		_, err := s.runIRSImpact(ctx, result, params, cost)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.runIRSImpact(ctx, result, params, cost)
+}
+
+func (s *AlgorithmService) runIRSImpact(ctx context.Context, result *model.ExperimentResult, params *model.IRSImpactParams, cost *experimentCost) (*model.IRSImpactResult, error) {
+	measurement := s.beginEnergyMeasurement(ctx)
+
+	params = params.WithDefaults(defaultCovarianceSnapshots)
+	impact, elements, err := s.measureIRSImpact(ctx, params)
+	if err != nil {
+		s.setStatus(ctx, result, model.ExperimentStatusFailed, "")
+		return nil, errors.Wrap(algorithmErrorCode(err), "IRS impact measurement failed", err)
+	}
+
+	if err := s.completeResult(ctx, result, impact); err != nil {
+		return nil, err
+	}
+	s.timings.observe(cost, time.Since(measurement.startTime))
+	s.recordEnergy(ctx, result, measurement, energyUsage{
+		variant:          params.Off,
+		irsElements:      elements,
+		reconfigurations: 2 * params.Trials,
+	})
+	return impact, nil
+}
+
+// measureIRSImpact alternates the panel between the pattern and the off
+// state, capturing after each switch, and restores the panel's active
+// configuration when done. It returns the result and the element count.
+func (s *AlgorithmService) measureIRSImpact(ctx context.Context, params *model.IRSImpactParams) (*model.IRSImpactResult, int, error) {
+	controller, err := panelController(s.irsPanels, params.IRSID)
+	if err != nil {
+		return nil, 0, err
+	}
+	// queued runs wait for the panel; the receiver must be free as well
+	if err := checkReservation(ctx, s.gate, model.ReservableDeviceUSRP); err != nil {
+		return nil, 0, err
+	}
+	if s.snapshots == nil {
+		return nil, 0, deviceUnavailable("usrp")
+	}
+	if params.Off == model.IRSOffAbsorptive && !controller.CanAbsorb() {
+		return nil, 0, &model.ValidationError{Field: "off", Message: "the panel cannot switch to absorptive"}
+	}
+
+	var pattern []float64
+	if params.Config != nil {
+		planned, err := controller.Preview(params.Config)
+		if err != nil {
+			return nil, 0, err
+		}
+		pattern = planned.PhaseShifts
+	} else if active := controller.GetCurrentConfig(); active != nil {
+		pattern = active.PhaseShifts
+	} else {
+		return nil, 0, &model.ValidationError{Field: "config", Message: "the panel has no active configuration to measure"}
+	}
+	defer func() {
+		if err := controller.Restore(context.Background()); err != nil {
+			logger.Warn("Failed to restore IRS configuration after impact measurement", zap.Error(err))
+		}
+	}()
+
+	seed := time.Now().UnixNano()
+	if params.Seed != nil {
+		seed = *params.Seed
+	}
+	rng := rand.New(rand.NewSource(seed))
+	settle := time.Duration(params.Settle * float64(time.Second))
+
+	on := func() error { return controller.Hold(ctx, pattern) }
+	off := func() error {
+		if params.Off == model.IRSOffAbsorptive {
+			return controller.Absorb(ctx)
+		}
+		phases := make([]float64, len(pattern))
+		for i := range phases {
+			phases[i] = rng.Float64() * 2 * math.Pi
+		}
+		return controller.Hold(ctx, phases)
+	}
+	measure := func(set func() error) (float64, error) {
+		if err := set(); err != nil {
+			return 0, err
+		}
+		if settle > 0 {
+			timer := time.NewTimer(settle)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return 0, ctx.Err()
+			case <-timer.C:
+			}
+		}
+		X, _, err := s.captureSnapshots(ctx, params.SnapshotLength)
+		if err != nil {
+			return 0, err
+		}
+		// shrinkage would bias the eigenvalues the SNR is read from
+		return doa.SubspaceSNR(covariance.Sample(X)), nil
+	}
+
+	impact := &model.IRSImpactResult{
+		IRSID:      params.IRSID,
+		Off:        params.Off,
+		Trials:     params.Trials,
+		Antennas:   s.snapshots.ChannelCount(),
+		Confidence: params.Confidence,
+		Samples:    make([]model.IRSImpactTrial, params.Trials),
+	}
+	if impact.IRSID == "" {
+		impact.IRSID = s.irsPanels.DefaultID()
+	}
+	snrGains := make([]float64, params.Trials)
+	rateGains := make([]float64, params.Trials)
+	for i := range impact.Samples {
+		// every other pair starts with the off state, so that a drift of
+		// the channel over the run weighs on both states alike
+		first, second := on, off
+		if i%2 == 1 {
+			first, second = off, on
+		}
+		a, err := measure(first)
+		if err != nil {
+			return nil, 0, err
+		}
+		b, err := measure(second)
+		if err != nil {
+			return nil, 0, err
+		}
+		snrOn, snrOff := a, b
+		if i%2 == 1 {
+			snrOn, snrOff = b, a
+		}
+
+		trial := model.IRSImpactTrial{
+			SNROn:   decibels(snrOn),
+			SNROff:  decibels(snrOff),
+			RateOn:  math.Log2(1 + snrOn),
+			RateOff: math.Log2(1 + snrOff),
+		}
+		impact.Samples[i] = trial
+		impact.SNROn += trial.SNROn / float64(params.Trials)
+		impact.SNROff += trial.SNROff / float64(params.Trials)
+		snrGains[i] = trial.SNROn - trial.SNROff
+		rateGains[i] = trial.RateOn - trial.RateOff
+	}
+	impact.SNRGain = pairedInterval(snrGains, params.Confidence)
+	impact.RateGain = pairedInterval(rateGains, params.Confidence)
+	return impact, len(pattern), nil
+}
+
+func decibels(snr float64) float64 {
+	return 10 * math.Log10(math.Max(snr, minImpactSNR))
+}
+
+// pairedInterval is the mean of the per-trial differences with its
+// two-sided confidence interval from the t distribution.
+func pairedInterval(diffs []float64, confidence float64) model.IRSImpactInterval {
+	n := float64(len(diffs))
+	mean, std := stat.MeanStdDev(diffs, nil)
+	t := distuv.StudentsT{Mu: 0, Sigma: 1, Nu: n - 1}.Quantile(0.5 + confidence/2)
+	half := t * std / math.Sqrt(n)
+	return model.IRSImpactInterval{Mean: mean, StdDev: std, Low: mean - half, High: mean + half}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/device/irs"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+// reflectingSource receives a signal over the panel: each element reflects
+// with the phase the panel sets plus the phase of its own path, so that
+// the received amplitude peaks when the panel cancels the path phases.
+type reflectingSource struct {
+	panel *irs.Simulator
+	paths []float64
+	rand  *rand.Rand
+}
+
+func (s *reflectingSource) CollectMultiChannel(ctx context.Context, duration time.Duration) ([][]model.ChannelDataPoint, error) {
+	status, err := s.panel.GetStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var gain complex128
+	if !status.Absorbing {
+		for i, phase := range status.PhaseShifts {
+			gain += cmplx.Exp(complex(0, phase+s.paths[i]))
+		}
+		gain /= complex(float64(len(s.paths)), 0)
+	}
+
+	n := int(duration.Seconds() * 1e6)
+	channels := make([][]model.ChannelDataPoint, 4)
+	for m := range channels {
+		channels[m] = make([]model.ChannelDataPoint, n)
+	}
+	for t := 0; t < n; t++ {
+		symbol := cmplx.Exp(complex(0, s.rand.Float64()*2*math.Pi))
+		for m := range channels {
+			// a source broadside of the receive array, 10 dB above the noise per
+			// antenna when the panel focuses
+			x := 3*gain*symbol + complex(s.rand.NormFloat64(), s.rand.NormFloat64())/complex(math.Sqrt2, 0)
+			channels[m][t] = model.ChannelDataPoint{Index: t, I: real(x), Q: imag(x)}
+		}
+	}
+	return channels, nil
+}
+
+func (s *reflectingSource) ChannelCount() int { return 4 }
+
+func (s *reflectingSource) GetConfig() (sampleRate, centerFreq float64) {
+	return 1e6, 3.5e9
+}
+
+func TestRunIRSImpact(t *testing.T) {
+	ctx := context.Background()
+	sim := irs.NewSimulator(16, "3.5GHz")
+	controller := irs.NewController(sim)
+	panels := irs.NewManager()
+	panels.Add("irs0", controller)
+
+	paths := make([]float64, 16)
+	focus := make([]float64, 16)
+	for i := range paths {
+		paths[i] = float64(i) * 0.7
+		focus[i] = math.Mod(4*math.Pi-paths[i], 2*math.Pi)
+	}
+	active := &model.IRSConfigRequest{Name: "focus", ElementCount: 16, PhaseShifts: focus, FrequencyBand: "3.5GHz"}
+	if err := controller.Configure(ctx, active); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewAlgorithmService(nil)
+	svc.SetIRSPanels(panels)
+	svc.SetSnapshotSource(&reflectingSource{panel: sim, paths: paths, rand: rand.New(rand.NewSource(3))})
+
+	seed := int64(7)
+	result, err := svc.RunIRSImpact(ctx, "impact_random", &model.IRSImpactParams{Trials: 6, SnapshotLength: 256, Seed: &seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IRSID != "irs0" || result.Off != model.IRSOffRandom || len(result.Samples) != 6 || result.Confidence != 0.95 {
+		t.Fatalf("result %+v, want 6 random-off trials on irs0", result)
+	}
+	// 16 random phases keep about 1/16 of the focused power
+	if result.SNRGain.Low < 6 || result.SNRGain.Low > result.SNRGain.Mean || result.SNRGain.High < result.SNRGain.Mean {
+		t.Errorf("SNR gain %+v dB, want a significant gain of about 12 dB", result.SNRGain)
+	}
+	if result.RateGain.Low <= 0 {
+		t.Errorf("rate gain %+v bit/s/Hz, want it above 0", result.RateGain)
+	}
+	status, err := sim.GetStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, phase := range status.PhaseShifts {
+		if math.Abs(phase-focus[i]) > 1e-9 {
+			t.Fatalf("panel phases %v after the measurement, want the active %v", status.PhaseShifts, focus)
+		}
+	}
+
+	// a flat pattern reflects little of the path, but more than an absorbing
+	// panel, whose captures hold noise only
+	flat := &model.IRSConfigRequest{Name: "flat", ElementCount: 16, PhaseShifts: make([]float64, 16), FrequencyBand: "3.5GHz"}
+	result, err = svc.RunIRSImpact(ctx, "impact_absorptive", &model.IRSImpactParams{Config: flat, Off: model.IRSOffAbsorptive, Trials: 4, SnapshotLength: 256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SNROff > -3 || result.SNRGain.Low <= 0 || result.SNRGain.Mean > result.SNRGain.High {
+		t.Errorf("absorptive off at %g dB, gain %+v dB, want noise when off and a small gain", result.SNROff, result.SNRGain)
+	}
+	if got := controller.GetCurrentConfig().Name; got != "focus" {
+		t.Errorf("active configuration %q after measuring another pattern, want focus", got)
+	}
+
+	if _, err := svc.RunIRSImpact(ctx, "impact_bad", &model.IRSImpactParams{Trials: 1}); !errors.IsCode(err, errors.CodeInvalidParam) {
+		t.Errorf("single trial: error = %v, want invalid param", err)
+	}
+}
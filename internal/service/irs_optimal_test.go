@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"isac-cran-system/internal/algorithm/beamforming"
+	"isac-cran-system/internal/device/irs"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+func TestApplyOptimalPhaseShifts_SDR(t *testing.T) {
+	ctx := context.Background()
+	controller := irs.NewController(irs.NewSimulator(16, "3.5GHz"))
+	if err := controller.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	panels := irs.NewManager()
+	panels.Add("irs0", controller)
+	if err := controller.Configure(ctx, &model.IRSConfigRequest{Name: "flat", ElementCount: 16, PhaseShifts: make([]float64, 16)}); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewIRSService(panels)
+
+	seed := int64(3)
+	channel := &model.JointBeamformingParams{BSAntennas: 2, DirectAngle: 0.1, IRSAngle: -0.4, IRSArrivalAngle: 0.3, Seed: &seed}
+	req := &model.IRSOptimalRequest{TargetAngle: -0.2, Method: model.IRSOptimalSDR, Channel: channel}
+	config, err := svc.ApplyOptimalPhaseShifts(ctx, "", req)
+	if err != nil {
+		t.Fatalf("ApplyOptimalPhaseShifts() error = %v", err)
+	}
+
+	// the panel applies the phases the relaxation finds for its 16 elements
+	// with the user at the target angle
+	params := *channel
+	params.IRSElements, params.UserAngle, params.Method = 16, req.TargetAngle, model.JointMethodSDR
+	want, err := beamforming.NewOptimizer(16, 100, 0.001).JointOptimize(&params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.PhaseShifts) != 16 {
+		t.Fatalf("%d phase shifts, want 16", len(config.PhaseShifts))
+	}
+	for n, phase := range config.PhaseShifts {
+		if d := math.Abs(phase - want.IRSPhases[n]); d > 1e-9 && math.Abs(d-2*math.Pi) > 1e-9 {
+			t.Fatalf("phase %d is %.4f, the relaxation gives %.4f", n, phase, want.IRSPhases[n])
+		}
+	}
+
+	req.Channel = &model.JointBeamformingParams{BSAntennas: model.MaxJointBSAntennas + 1}
+	if _, err := svc.ApplyOptimalPhaseShifts(ctx, "", req); !errors.IsCode(err, errors.CodeInvalidParam) {
+		t.Errorf("bs_antennas %d error = %v, want invalid parameter", req.Channel.BSAntennas, err)
+	}
+	req.Channel, req.Group = nil, "left"
+	if v := req.Violations(); len(v) != 1 || v[0].Field != "group" {
+		t.Errorf("sdr for a group: violations %+v, want group", v)
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"isac-cran-system/internal/device/irs"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/logger"
+	"isac-cran-system/pkg/mq"
+
+	"go.uber.org/zap"
+)
+
+// AlertPublisher sends notifications to a message queue.
+type AlertPublisher interface {
+	Publish(ctx context.Context, queueName string, message interface{}) error
+}
+
+func (s *IRSService) SetAlertPublisher(publisher AlertPublisher) {
+	s.alerts = publisher
+}
+
+// StartWatchdog watches panel irsID until ctx is done.
+func (s *IRSService) StartWatchdog(ctx context.Context, irsID string, cfg irs.WatchdogConfig) error {
+	controller, err := s.controller(irsID)
+	if err != nil {
+		return err
+	}
+	id := s.panelID(irsID)
+	w := irs.NewWatchdog(id, controller, cfg, s.raiseAlert)
+
+	s.mu.Lock()
+	s.watchdogs[id] = w
+	s.mu.Unlock()
+
+	go w.Run(ctx)
+	return nil
+}
+
+func (s *IRSService) watchdog(irsID string) *irs.Watchdog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.watchdogs[s.panelID(irsID)]
+}
+
+// health reports whether panel irsID has no active watchdog alert. Panels
+// without a watchdog are taken as healthy.
+func (s *IRSService) health(irsID string) (bool, []model.IRSAlert) {
+	w := s.watchdog(irsID)
+	if w == nil {
+		return true, nil
+	}
+	return w.Healthy(), w.Alerts()
+}
+
+func (s *IRSService) raiseAlert(alert model.IRSAlert) {
+	level := "critical"
+	title := "IRS alert raised"
+	if alert.Active {
+		logger.Warn("IRS alert raised", zap.String("irs_id", alert.IRSID), zap.String("kind", string(alert.Kind)),
+			zap.Int("code", alert.Code), zap.String("message", alert.Message))
+	} else {
+		level = "info"
+		title = "IRS alert cleared"
+		logger.Info("IRS alert cleared", zap.String("irs_id", alert.IRSID), zap.String("kind", string(alert.Kind)))
+	}
+	if s.alerts == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := s.alerts.Publish(ctx, mq.QueueNotification, &mq.NotificationMessage{
+		Type:    "irs_alert",
+		Level:   level,
+		Title:   title,
+		Message: alert.Message,
+		Data: map[string]interface{}{
+			"irs_id":      alert.IRSID,
+			"kind":        alert.Kind,
+			"code":        alert.Code,
+			"active":      alert.Active,
+			"temperature": alert.Temperature,
+			"since":       alert.Since,
+		},
+		Timestamp: alert.Time.Unix(),
+	})
+	if err != nil {
+		logger.Warn("Failed to publish IRS alert", zap.String("irs_id", alert.IRSID), zap.Error(err))
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+// RunJointBeamforming optimizes the BS weights and IRS phases of a cascaded
+// channel together and stores the result as a joint_beamforming
+// experiment.
+func (s *AlgorithmService) RunJointBeamforming(ctx context.Context, experimentID string, params *model.JointBeamformingParams) (*model.JointBeamformingResult, error) {
+	if err := params.Validate(); err != nil {
+		return nil, errors.Wrap(errors.CodeInvalidParam, "invalid joint beamforming parameters", err)
+	}
+	cost := s.jointBeamformingCost(params)
+	result, release, err := s.admit(ctx, experimentID, params, cost, func(ctx context.Context, result *model.ExperimentResult) error {
+		_, err := s.runJointBeamforming(ctx, result, params, cost)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.runJointBeamforming(ctx, result, params, cost)
+}
+
+func (s *AlgorithmService) runJointBeamforming(ctx context.Context, result *model.ExperimentResult, params *model.JointBeamformingParams, cost *experimentCost) (*model.JointBeamformingResult, error) {
+	measurement := s.beginEnergyMeasurement(ctx)
+
+	joint, err := s.beamformingOptimizer.JointOptimize(params)
+	if err != nil {
+		s.setStatus(ctx, result, model.ExperimentStatusFailed, "")
+		return nil, errors.Wrap(algorithmErrorCode(err), "joint beamforming optimization failed", err)
+	}
+
+	if err := s.completeResult(ctx, result, joint); err != nil {
+		return nil, err
+	}
+	// experiments rank by phase resolution
+	variant := "continuous"
+	if params.PhaseBits > 0 {
+		variant = fmt.Sprintf("%d-bit", params.PhaseBits)
+	}
+	s.timings.observe(cost, time.Since(measurement.startTime))
+	s.recordEnergy(ctx, result, measurement, energyUsage{
+		variant:          variant,
+		irsElements:      len(joint.IRSPhases),
+		reconfigurations: 1,
+	})
+	return joint, nil
+}
@@ -0,0 +1,503 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+	"isac-cran-system/pkg/queue"
+
+	"go.uber.org/zap"
+)
+
+// PipelineTaskType is the task queue type that executes pipeline runs.
+const PipelineTaskType = "pipeline"
+
+// PipelineService runs declarative experiment pipelines on the task queue.
+// Each run is one task whose steps execute one at a time in dependency
+// order, since they contend for the same radios and panels anyway.
+type PipelineService struct {
+	queue      *queue.TaskQueue
+	channels   *ChannelService
+	algorithms *AlgorithmService
+	irs        *IRSService
+	events     EventPublisher
+
+	mu   sync.Mutex
+	runs map[string]*pipelineRun
+	seq  int
+}
+
+type pipelineRun struct {
+	req    *model.PipelineRequest
+	holder string
+	run    model.PipelineRun
+}
+
+func NewPipelineService(q *queue.TaskQueue, channels *ChannelService, algorithms *AlgorithmService, irs *IRSService) *PipelineService {
+	s := &PipelineService{
+		queue:      q,
+		channels:   channels,
+		algorithms: algorithms,
+		irs:        irs,
+		runs:       make(map[string]*pipelineRun),
+	}
+	q.RegisterHandler(PipelineTaskType, s.execute)
+	return s
+}
+
+// Submit queues a validated pipeline and returns its pending run. Steps run
+// on behalf of the reservation holder in ctx.
+func (s *PipelineService) Submit(ctx context.Context, req *model.PipelineRequest) (*model.PipelineRun, error) {
+	if err := req.Validate(); err != nil {
+		return nil, errors.New(errors.CodeInvalidParam, err.Error())
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	s.seq++
+	id := fmt.Sprintf("pipe_%s_%d", now.UTC().Format("20060102T150405"), s.seq)
+	r := &pipelineRun{
+		req:    req,
+		holder: HolderFromContext(ctx),
+		run: model.PipelineRun{
+			ID:        id,
+			Name:      req.Name,
+			Status:    model.PipelineStatusPending,
+			Steps:     make([]model.PipelineStepResult, len(req.Steps)),
+			CreatedAt: now,
+		},
+	}
+	for i, step := range req.Steps {
+		r.run.Steps[i] = model.PipelineStepResult{ID: step.ID, Kind: step.Kind, Status: model.PipelineStatusPending}
+	}
+	s.runs[id] = r
+	s.mu.Unlock()
+
+	taskID := s.queue.Submit(PipelineTaskType, map[string]interface{}{"run_id": id})
+	s.mu.Lock()
+	r.run.TaskID = taskID
+	snapshot := r.snapshot()
+	s.mu.Unlock()
+	return snapshot, nil
+}
+
+func (s *PipelineService) Get(id string) (*model.PipelineRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.runs[id]
+	if !ok {
+		return nil, errors.New(errors.CodeNotFound, "pipeline run not found")
+	}
+	return r.snapshot(), nil
+}
+
+// List returns every run, newest first.
+func (s *PipelineService) List() []*model.PipelineRun {
+	s.mu.Lock()
+	runs := make([]*model.PipelineRun, 0, len(s.runs))
+	for _, r := range s.runs {
+		runs = append(runs, r.snapshot())
+	}
+	s.mu.Unlock()
+	sort.Slice(runs, func(i, j int) bool {
+		if runs[i].CreatedAt.Equal(runs[j].CreatedAt) {
+			return runs[i].ID > runs[j].ID
+		}
+		return runs[i].CreatedAt.After(runs[j].CreatedAt)
+	})
+	return runs
+}
+
+func (r *pipelineRun) snapshot() *model.PipelineRun {
+	run := r.run
+	run.Steps = append([]model.PipelineStepResult(nil), r.run.Steps...)
+	return &run
+}
+
+// publishRun streams the progress of a run to its subscribers.
+func (s *PipelineService) publishRun(r *pipelineRun) {
+	if s.events == nil {
+		return
+	}
+	s.mu.Lock()
+	snapshot := r.snapshot()
+	s.mu.Unlock()
+	publish(s.events, TopicPipeline+"."+snapshot.ID, snapshot)
+}
+
+// execute is the task handler of a pipeline run.
+func (s *PipelineService) execute(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	id, _ := payload["run_id"].(string)
+	s.mu.Lock()
+	r, ok := s.runs[id]
+	if ok {
+		started := time.Now()
+		r.run.Status = model.PipelineStatusRunning
+		r.run.StartedAt = &started
+	}
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("pipeline run %s not found", id)
+	}
+	s.publishRun(r)
+	ctx = WithHolder(ctx, r.holder)
+
+	order, _ := r.req.Order()
+	index := make(map[string]int, len(r.req.Steps))
+	for i, step := range r.req.Steps {
+		index[step.ID] = i
+	}
+	// results holds the JSON form of each completed step's result, which
+	// conditions and param references are resolved against.
+	results := make(map[string]interface{}, len(r.req.Steps))
+	failed := false
+	for _, i := range order {
+		step := r.req.Steps[i]
+		result := s.runStep(ctx, r, step, index, results)
+		if result.Status == model.PipelineStatusFailed {
+			failed = true
+		}
+		s.mu.Lock()
+		r.run.Steps[i] = result
+		s.mu.Unlock()
+		s.publishRun(r)
+	}
+
+	s.mu.Lock()
+	ended := time.Now()
+	r.run.EndedAt = &ended
+	r.run.Status = model.PipelineStatusCompleted
+	if failed {
+		r.run.Status = model.PipelineStatusFailed
+	}
+	snapshot := r.snapshot()
+	s.mu.Unlock()
+	publish(s.events, TopicPipeline+"."+id, snapshot)
+
+	logger.Info("Pipeline finished",
+		zap.String("pipeline_id", id),
+		zap.String("status", string(snapshot.Status)),
+		zap.Duration("duration", ended.Sub(*snapshot.StartedAt)),
+	)
+	return snapshot, nil
+}
+
+func (s *PipelineService) runStep(ctx context.Context, r *pipelineRun, step model.PipelineStep, index map[string]int, results map[string]interface{}) model.PipelineStepResult {
+	result := model.PipelineStepResult{ID: step.ID, Kind: step.Kind, Status: model.PipelineStatusSkipped}
+
+	s.mu.Lock()
+	for _, dep := range step.DependsOn {
+		if status := r.run.Steps[index[dep]].Status; status != model.PipelineStatusCompleted {
+			result.Reason = fmt.Sprintf("dependency %s %s", dep, status)
+			break
+		}
+	}
+	s.mu.Unlock()
+	if result.Reason != "" {
+		return result
+	}
+	if step.When != nil {
+		holds, err := evaluateCondition(step.When, results)
+		if err != nil {
+			return stepFailure(result, errors.New(errors.CodeInvalidParam, err.Error()), time.Time{})
+		}
+		if !holds {
+			result.Reason = "condition not met"
+			return result
+		}
+	}
+
+	s.mu.Lock()
+	r.run.Steps[index[step.ID]].Status = model.PipelineStatusRunning
+	s.mu.Unlock()
+
+	started := time.Now()
+	params, err := resolveParams(step.Params, results)
+	if err != nil {
+		return stepFailure(result, errors.New(errors.CodeInvalidParam, fmt.Sprintf("step %s: %v", step.ID, err)), started)
+	}
+	for {
+		result.Attempts++
+		var data interface{}
+		data, err = s.runAction(ctx, step.Kind, params)
+		if err == nil {
+			encoded, err := toJSONValue(data)
+			if err != nil {
+				return stepFailure(result, errors.InternalError("encode step result", err), started)
+			}
+			results[step.ID] = encoded
+			result.Status = model.PipelineStatusCompleted
+			result.Result = data
+			result.Duration = time.Since(started).Seconds()
+			return result
+		}
+		// A queued experiment finishes outside the pipeline, so rerunning it
+		// would only queue it again.
+		if _, queued := err.(*ExperimentQueuedError); queued {
+			break
+		}
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.CodeInvalidParam {
+			break
+		}
+		if result.Attempts > step.Retries {
+			break
+		}
+		logger.Warn("Pipeline step failed, retrying",
+			zap.String("step", step.ID),
+			zap.Int("attempt", result.Attempts),
+			zap.Error(err),
+		)
+		select {
+		case <-time.After(time.Duration(step.RetryDelay * float64(time.Second))):
+		case <-ctx.Done():
+			return stepFailure(result, ctx.Err(), started)
+		}
+	}
+	return stepFailure(result, err, started)
+}
+
+func stepFailure(result model.PipelineStepResult, err error, started time.Time) model.PipelineStepResult {
+	result.Status = model.PipelineStatusFailed
+	switch e := err.(type) {
+	case *ExperimentRejectedError:
+		err = e.Err
+	case *ExperimentQueuedError:
+		err = errors.New(errors.CodeServiceUnavailable, e.Error())
+	}
+	appErr, ok := err.(*errors.AppError)
+	if !ok {
+		appErr = errors.InternalError(err.Error(), err)
+	}
+	result.Code = appErr.Code.Int()
+	result.Error = appErr.Message
+	if !started.IsZero() {
+		result.Duration = time.Since(started).Seconds()
+	}
+	return result
+}
+
+func (s *PipelineService) runAction(ctx context.Context, kind model.PipelineStepKind, params json.RawMessage) (interface{}, error) {
+	switch kind {
+	case model.PipelineStepCollect:
+		var req model.ChannelCollectRequest
+		if err := decodeStepParams(params, &req); err != nil {
+			return nil, err
+		}
+		if req.ExperimentID == "" || req.FrequencyBand == "" || req.Duration <= 0 || req.SampleRate <= 0 {
+			return nil, errors.New(errors.CodeInvalidParam, "collect needs experiment_id, frequency_band, duration and sample_rate")
+		}
+		return s.channels.CollectData(ctx, &req)
+	case model.PipelineStepBeamforming, model.PipelineStepDOA:
+		var req model.PipelineAlgorithmParams
+		if err := decodeStepParams(params, &req); err != nil {
+			return nil, err
+		}
+		if req.ExperimentID == "" {
+			return nil, errors.New(errors.CodeInvalidParam, string(kind)+" needs an experiment_id")
+		}
+		if kind == model.PipelineStepBeamforming {
+			var bf model.BeamformingParams
+			if err := decodeStepParams(req.Params, &bf); err != nil {
+				return nil, err
+			}
+			return s.algorithms.RunBeamforming(ctx, req.ExperimentID, &bf)
+		}
+		var doa model.DOAParams
+		if err := decodeStepParams(req.Params, &doa); err != nil {
+			return nil, err
+		}
+		return s.algorithms.RunDOA(ctx, req.ExperimentID, &doa)
+	case model.PipelineStepIRSConfigure, model.PipelineStepIRSApply:
+		var req model.PipelineIRSParams
+		if err := decodeStepParams(params, &req); err != nil {
+			return nil, err
+		}
+		if kind == model.PipelineStepIRSConfigure {
+			if req.Config == nil {
+				return nil, errors.New(errors.CodeInvalidParam, "irs_configure needs a config")
+			}
+			if err := req.Config.Validate(); err != nil {
+				return nil, errors.New(errors.CodeInvalidParam, err.Error())
+			}
+			return s.irs.Configure(ctx, req.IRSID, req.Config)
+		}
+		if req.TargetAngle == nil {
+			return nil, errors.New(errors.CodeInvalidParam, "irs_apply needs a target_angle")
+		}
+		optimal := &model.IRSOptimalRequest{TargetAngle: *req.TargetAngle, Group: req.Group, Method: req.Method, Channel: req.Channel}
+		if v := optimal.Violations(); v.HasErrors() {
+			return nil, errors.New(errors.CodeInvalidParam, fmt.Sprintf("%s: %s", v[0].Field, v[0].Message))
+		}
+		return s.irs.ApplyOptimalPhaseShifts(ctx, req.IRSID, optimal)
+	case model.PipelineStepMeasure:
+		var req model.PipelineMeasureParams
+		if len(params) > 0 {
+			if err := decodeStepParams(params, &req); err != nil {
+				return nil, err
+			}
+		}
+		return s.measure(ctx, req.Repetitions)
+	}
+	return nil, errors.New(errors.CodeInvalidParam, fmt.Sprintf("unsupported step kind %s", kind))
+}
+
+// measure summarises realtime captures of the receiver.
+func (s *PipelineService) measure(ctx context.Context, repetitions int) (*model.PipelineMeasurement, error) {
+	if repetitions <= 0 {
+		repetitions = 1
+	}
+	m := &model.PipelineMeasurement{Repetitions: repetitions}
+	var amplitudes []float64
+	for i := 0; i < repetitions; i++ {
+		points, err := s.channels.GetRealtimeData(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range points {
+			amplitudes = append(amplitudes, p.Amplitude)
+			m.MeanAmplitude += p.Amplitude
+			if p.Clipped {
+				m.Clipped++
+			}
+		}
+	}
+	m.Samples = len(amplitudes)
+	if m.Samples > 0 {
+		m.MeanAmplitude /= float64(m.Samples)
+	}
+	m.SNR = s.channels.calculateSNR(amplitudes)
+	return m, nil
+}
+
+func decodeStepParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return errors.New(errors.CodeInvalidParam, "params are required")
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return errors.Wrap(errors.CodeInvalidParam, "invalid params: "+err.Error(), err)
+	}
+	return nil
+}
+
+func toJSONValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
+
+// resolveParams replaces every "${step.field}" string in params with the
+// referenced result field.
+func resolveParams(params json.RawMessage, results map[string]interface{}) (json.RawMessage, error) {
+	if len(params) == 0 {
+		return params, nil
+	}
+	var tree interface{}
+	if err := json.Unmarshal(params, &tree); err != nil {
+		return nil, fmt.Errorf("invalid params: %v", err)
+	}
+	resolved, err := substitute(tree, results)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(resolved)
+}
+
+func substitute(v interface{}, results map[string]interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case string:
+		if !strings.HasPrefix(t, "${") || !strings.HasSuffix(t, "}") {
+			return t, nil
+		}
+		ref := strings.TrimSuffix(strings.TrimPrefix(t, "${"), "}")
+		stepID, field, _ := strings.Cut(ref, ".")
+		return lookupResult(results, stepID, field)
+	case map[string]interface{}:
+		for k, item := range t {
+			resolved, err := substitute(item, results)
+			if err != nil {
+				return nil, err
+			}
+			t[k] = resolved
+		}
+	case []interface{}:
+		for i, item := range t {
+			resolved, err := substitute(item, results)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = resolved
+		}
+	}
+	return v, nil
+}
+
+// lookupResult follows the dot path field into the result of stepID.
+func lookupResult(results map[string]interface{}, stepID, field string) (interface{}, error) {
+	v, ok := results[stepID]
+	if !ok {
+		return nil, fmt.Errorf("step %s has no result", stepID)
+	}
+	if field == "" {
+		return v, nil
+	}
+	for _, key := range strings.Split(field, ".") {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			if v, ok = t[key]; !ok {
+				return nil, fmt.Errorf("result of step %s has no field %s", stepID, field)
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil, fmt.Errorf("result of step %s has no field %s", stepID, field)
+			}
+			v = t[i]
+		default:
+			return nil, fmt.Errorf("result of step %s has no field %s", stepID, field)
+		}
+	}
+	return v, nil
+}
+
+func evaluateCondition(c *model.PipelineCondition, results map[string]interface{}) (bool, error) {
+	v, err := lookupResult(results, c.Step, c.Field)
+	if err != nil {
+		return false, err
+	}
+	switch c.Op {
+	case model.PipelineOpEq:
+		return reflect.DeepEqual(v, c.Value), nil
+	case model.PipelineOpNe:
+		return !reflect.DeepEqual(v, c.Value), nil
+	}
+	got, ok := v.(float64)
+	want, wantOK := c.Value.(float64)
+	if !ok || !wantOK {
+		return false, fmt.Errorf("condition %s %s needs numbers", c.Field, c.Op)
+	}
+	switch c.Op {
+	case model.PipelineOpLt:
+		return got < want, nil
+	case model.PipelineOpLe:
+		return got <= want, nil
+	case model.PipelineOpGt:
+		return got > want, nil
+	case model.PipelineOpGe:
+		return got >= want, nil
+	}
+	return false, fmt.Errorf("unknown condition op %q", c.Op)
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/device/irs"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/queue"
+)
+
+func TestPipelineRun(t *testing.T) {
+	controller := irs.NewController(irs.NewSimulator(4, "28GHz"))
+	controller.Connect(context.Background())
+	panels := irs.NewManager()
+	panels.Add("panel0", controller)
+
+	q := queue.NewTaskQueue(1, 10)
+	q.Start()
+	defer q.Stop()
+	// Without a receiver the measure step fails on every attempt.
+	s := NewPipelineService(q, NewChannelService(nil, nil), NewAlgorithmService(nil), NewIRSService(panels))
+
+	var req model.PipelineRequest
+	err := json.Unmarshal([]byte(`{"name": "steer", "steps": [
+		{"id": "measure", "kind": "measure", "depends_on": ["apply"], "retries": 2},
+		{"id": "configure", "kind": "irs_configure",
+			"params": {"config": {"name": "flat", "element_count": 4, "phase_shifts": [0, 0, 0, 0], "frequency_band": "28GHz"}}},
+		{"id": "beam", "kind": "beamforming",
+			"params": {"experiment_id": "exp_pipe", "params": {"element_count": 4, "target_direction": 0.3, "max_iterations": 20}}},
+		{"id": "apply", "kind": "irs_apply", "depends_on": ["configure", "beam"],
+			"params": {"target_angle": "${beam.main_lobe_direction}"}},
+		{"id": "boost", "kind": "irs_apply", "depends_on": ["beam"],
+			"when": {"step": "beam", "field": "iterations", "op": "gt", "value": 1e9},
+			"params": {"target_angle": 0}},
+		{"id": "report", "kind": "measure", "depends_on": ["measure"]}
+	]}`), &req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run, err := s.Submit(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if run.Status != model.PipelineStatusPending || run.TaskID == "" {
+		t.Errorf("submitted run = %+v", run)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for run.EndedAt == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("pipeline did not finish: %+v", run)
+		}
+		time.Sleep(10 * time.Millisecond)
+		run, _ = s.Get(run.ID)
+	}
+
+	if run.Status != model.PipelineStatusFailed {
+		t.Errorf("run status = %s, want failed", run.Status)
+	}
+	want := map[string]model.PipelineStatus{
+		"measure":   model.PipelineStatusFailed,
+		"configure": model.PipelineStatusCompleted,
+		"beam":      model.PipelineStatusCompleted,
+		"apply":     model.PipelineStatusCompleted,
+		"boost":     model.PipelineStatusSkipped,
+		"report":    model.PipelineStatusSkipped,
+	}
+	steps := make(map[string]model.PipelineStepResult)
+	for _, step := range run.Steps {
+		steps[step.ID] = step
+		if step.Status != want[step.ID] {
+			t.Errorf("step %s is %s (%s%s), want %s", step.ID, step.Status, step.Error, step.Reason, want[step.ID])
+		}
+	}
+	if m := steps["measure"]; m.Attempts != 3 || m.Code != errors.CodeServiceUnavailable.Int() {
+		t.Errorf("measure made %d attempts with code %d, want 3 with %d", m.Attempts, m.Code, errors.CodeServiceUnavailable)
+	}
+	if steps["boost"].Reason != "condition not met" || steps["report"].Reason != "dependency measure failed" {
+		t.Errorf("skip reasons %q and %q", steps["boost"].Reason, steps["report"].Reason)
+	}
+	beam := steps["beam"].Result.(*model.BeamformingResult)
+	applied := steps["apply"].Result.(*model.IRSConfig)
+	if current := controller.GetCurrentConfig(); current.Name != applied.Name || len(applied.PhaseShifts) != 4 {
+		t.Errorf("applied config %+v, panel holds %+v", applied, current)
+	}
+	if beam.Iterations == 0 {
+		t.Errorf("beamforming result %+v", beam)
+	}
+
+	if runs := s.List(); len(runs) != 1 || runs[0].ID != run.ID {
+		t.Errorf("List() = %v", runs)
+	}
+	if _, err := s.Get("pipe_missing"); err == nil {
+		t.Error("expected an unknown run to be rejected")
+	}
+}
+
+func TestPipelineRequestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps string
+		valid bool
+	}{
+		{"chain", `[{"id": "a", "kind": "measure"}, {"id": "b", "kind": "measure", "depends_on": ["a"]}]`, true},
+		{"duplicate id", `[{"id": "a", "kind": "measure"}, {"id": "a", "kind": "measure"}]`, false},
+		{"unknown kind", `[{"id": "a", "kind": "sweep"}]`, false},
+		{"unknown dependency", `[{"id": "a", "kind": "measure", "depends_on": ["b"]}]`, false},
+		{"cycle", `[{"id": "a", "kind": "measure", "depends_on": ["b"]}, {"id": "b", "kind": "measure", "depends_on": ["a"]}]`, false},
+		{"condition on a non-dependency", `[{"id": "a", "kind": "measure"}, {"id": "b", "kind": "measure",
+			"when": {"step": "a", "field": "snr", "op": "gt", "value": 3}}]`, false},
+		{"ordering a string", `[{"id": "a", "kind": "measure"}, {"id": "b", "kind": "measure", "depends_on": ["a"],
+			"when": {"step": "a", "field": "snr", "op": "gt", "value": "high"}}]`, false},
+		{"too many retries", `[{"id": "a", "kind": "measure", "retries": 11}]`, false},
+	}
+	for _, tt := range tests {
+		req := model.PipelineRequest{}
+		if err := json.Unmarshal([]byte(tt.steps), &req.Steps); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if err := req.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: Validate() = %v", tt.name, err)
+		}
+	}
+
+	req := model.PipelineRequest{Steps: []model.PipelineStep{
+		{ID: "measure", DependsOn: []string{"apply"}},
+		{ID: "collect"},
+		{ID: "apply", DependsOn: []string{"collect"}},
+	}}
+	order, err := req.Order()
+	if err != nil || len(order) != 3 || order[0] != 1 || order[1] != 2 || order[2] != 0 {
+		t.Errorf("Order() = %v, %v; want [1 2 0]", order, err)
+	}
+}
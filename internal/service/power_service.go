@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"isac-cran-system/internal/device/irs"
+	"isac-cran-system/internal/device/power"
+	"isac-cran-system/internal/device/sensor"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+type PowerService struct {
+	model      *power.Model
+	controller *irs.Controller
+	collector  *sensor.Collector
+}
+
+func NewPowerService(m *power.Model, controller *irs.Controller, collector *sensor.Collector) *PowerService {
+	return &PowerService{
+		model:      m,
+		controller: controller,
+		collector:  collector,
+	}
+}
+
+func (s *PowerService) currentConfig() *model.IRSConfig {
+	if s.controller == nil {
+		return nil
+	}
+	return s.controller.GetCurrentConfig()
+}
+
+func (s *PowerService) EstimateConfiguration(ctx context.Context, req *model.PowerEstimateRequest) (*model.PowerEstimate, error) {
+	var current []float64
+	if config := s.currentConfig(); config != nil {
+		current = config.PhaseShifts
+	}
+
+	next := req.PhaseShifts
+	if len(next) == 0 {
+		if current == nil {
+			return nil, errors.New(errors.CodeIRSDeviceError, "no active IRS configuration")
+		}
+		next = current
+	}
+
+	hold := time.Duration(req.HoldDuration * float64(time.Second))
+	return s.model.EstimateConfiguration(current, next, hold), nil
+}
+
+func (s *PowerService) CrossCheck(ctx context.Context) (*model.PowerCrossCheck, error) {
+	elementCount := 0
+	if config := s.currentConfig(); config != nil {
+		elementCount = config.ElementCount
+	}
+	estimate := s.model.EstimateExperiment(elementCount, 0, 0)
+
+	readings, err := s.readPowerSensors(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.model.CrossCheck(estimate, readings), nil
+}
+
+func (s *PowerService) MeasurePower(ctx context.Context) (float64, error) {
+	readings, err := s.readPowerSensors(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(readings) == 0 {
+		return 0, errors.New(errors.CodeSensorDataError, "no power sensors registered")
+	}
+
+	var total float64
+	for _, r := range readings {
+		total += power.ToWatts(r.Value, r.Unit)
+	}
+	return total, nil
+}
+
+func (s *PowerService) readPowerSensors(ctx context.Context) ([]*model.SensorData, error) {
+	if s.collector == nil {
+		return nil, deviceUnavailable("sensor")
+	}
+
+	readings := make([]*model.SensorData, 0)
+	for _, info := range s.collector.GetAllSensors() {
+		if info.SensorType != model.SensorTypePower {
+			continue
+		}
+		data, err := s.collector.ReadSensor(ctx, info.SensorID)
+		if err != nil {
+			return nil, errors.Wrap(errors.CodeSensorDataError, "failed to read power sensor", err)
+		}
+		readings = append(readings, data)
+	}
+	return readings, nil
+}
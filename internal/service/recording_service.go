@@ -0,0 +1,471 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+	"isac-cran-system/pkg/sigmf"
+
+	"go.uber.org/zap"
+)
+
+// recordingChunk is how much is requested from the receiver per read. The
+// loop is paced to real time so simulated sources do not outrun the clock.
+const recordingChunk = 100 * time.Millisecond
+
+const (
+	extExperimentID = "isac:experiment_id"
+	extRecordingID  = "isac:recording_id"
+	extStatus       = "isac:status"
+	extStartedAt    = "isac:started_at"
+	extStoppedAt    = "isac:stopped_at"
+	extError        = "isac:error"
+	extSHA256       = "isac:sha256"
+)
+
+// RecordingService streams raw IQ from the USRP receiver to SigMF files in
+// dir. The metadata file is the record of a recording, so completed ones are
+// listed from disk and survive restarts.
+type RecordingService struct {
+	source      SnapshotSource
+	dir         string
+	maxDuration time.Duration
+	gate        DeviceGate
+
+	mu     sync.Mutex
+	active map[string]*activeRecording
+}
+
+type activeRecording struct {
+	rec    model.Recording
+	writer *sigmf.Writer
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewRecordingService(source SnapshotSource, dir string, maxDuration time.Duration) *RecordingService {
+	if maxDuration <= 0 {
+		maxDuration = time.Hour
+	}
+	return &RecordingService{
+		source:      source,
+		dir:         dir,
+		maxDuration: maxDuration,
+		active:      make(map[string]*activeRecording),
+	}
+}
+
+func (s *RecordingService) SetDeviceGate(gate DeviceGate) {
+	s.gate = gate
+}
+
+func (s *RecordingService) Start(ctx context.Context, req *model.RecordingRequest) (*model.Recording, error) {
+	if s.source == nil {
+		return nil, deviceUnavailable("usrp")
+	}
+	if err := checkReservation(ctx, s.gate, model.ReservableDeviceUSRP); err != nil {
+		return nil, err
+	}
+
+	duration := s.maxDuration
+	if req.MaxDuration > 0 {
+		requested := time.Duration(req.MaxDuration * float64(time.Second))
+		if requested < duration {
+			duration = requested
+		}
+	}
+
+	s.mu.Lock()
+	busy := len(s.active) > 0
+	s.mu.Unlock()
+	if busy {
+		return nil, errors.New(errors.CodeDeviceReserved, "usrp is already recording")
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, errors.Wrap(errors.CodeInternalError, "failed to create recording directory", err)
+	}
+
+	now := time.Now()
+	id := "rec_" + now.UTC().Format("20060102T150405.000")
+	id = strings.Replace(id, ".", "_", 1)
+	base := filepath.Join(s.dir, id)
+	sampleRate, centerFreq := s.source.GetConfig()
+	channels := s.source.ChannelCount()
+
+	writer, err := sigmf.Create(base, sigmf.Global{
+		SampleRate:  sampleRate,
+		NumChannels: channels,
+		Description: req.Description,
+		Recorder:    "isac-cran-system",
+		Extensions:  []sigmf.Extension{{Name: "isac", Version: "1.0.0", Optional: true}},
+		Extra: map[string]interface{}{
+			extExperimentID: req.ExperimentID,
+			extRecordingID:  id,
+			extStatus:       string(model.RecordingStatusRecording),
+			extStartedAt:    now.UTC().Format(time.RFC3339Nano),
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeInternalError, "failed to create recording", err)
+	}
+
+	runCtx, cancel := context.WithTimeout(context.Background(), duration)
+	a := &activeRecording{
+		rec: model.Recording{
+			ID:           id,
+			ExperimentID: req.ExperimentID,
+			Description:  req.Description,
+			Status:       model.RecordingStatusRecording,
+			DataPath:     base + sigmf.DataExt,
+			MetaPath:     base + sigmf.MetaExt,
+			SampleRate:   sampleRate,
+			CenterFreq:   centerFreq,
+			Channels:     channels,
+			StartedAt:    now,
+		},
+		writer: writer,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	rec := a.rec
+	s.mu.Lock()
+	s.active[id] = a
+	s.mu.Unlock()
+
+	go s.run(runCtx, a)
+
+	logger.Info("Recording started",
+		zap.String("id", id),
+		zap.String("experiment_id", req.ExperimentID),
+		zap.Duration("max_duration", duration),
+	)
+
+	return &rec, nil
+}
+
+func (s *RecordingService) run(ctx context.Context, a *activeRecording) {
+	defer close(a.done)
+	defer a.cancel()
+
+	var runErr error
+	for ctx.Err() == nil {
+		start := time.Now()
+		data, err := s.source.CollectMultiChannel(ctx, recordingChunk)
+		if err != nil {
+			if ctx.Err() == nil {
+				runErr = err
+			}
+			break
+		}
+		_, centerFreq := s.source.GetConfig()
+
+		X := make([][]complex128, len(data))
+		for m, samples := range data {
+			X[m] = make([]complex128, len(samples))
+			for t, p := range samples {
+				X[m][t] = complex(p.I, p.Q)
+			}
+		}
+		if err := a.writer.Write(start, centerFreq, X); err != nil {
+			runErr = err
+			break
+		}
+		s.mu.Lock()
+		a.rec.Samples = a.writer.Samples()
+		a.rec.Bytes = a.writer.Bytes()
+		s.mu.Unlock()
+
+		if len(X) > 0 {
+			span := time.Duration(float64(len(X[0])) / a.rec.SampleRate * float64(time.Second))
+			if wait := time.Until(start.Add(span)); wait > 0 {
+				select {
+				case <-ctx.Done():
+				case <-time.After(wait):
+				}
+			}
+		}
+	}
+
+	stopped := time.Now()
+	status := model.RecordingStatusCompleted
+	if runErr != nil {
+		status = model.RecordingStatusFailed
+		a.writer.SetExtra(extError, runErr.Error())
+	}
+	a.writer.SetExtra(extStatus, string(status))
+	a.writer.SetExtra(extStoppedAt, stopped.UTC().Format(time.RFC3339Nano))
+	checksum := ""
+	if err := a.writer.Flush(); err == nil {
+		checksum = a.writer.Checksum()
+		a.writer.SetExtra(extSHA256, checksum)
+	}
+	if err := a.writer.Close(); err != nil && runErr == nil {
+		runErr = err
+		status = model.RecordingStatusFailed
+	}
+
+	s.mu.Lock()
+	a.rec.Status = status
+	a.rec.StoppedAt = &stopped
+	a.rec.Checksum = checksum
+	if runErr != nil {
+		a.rec.Error = runErr.Error()
+	}
+	delete(s.active, a.rec.ID)
+	s.mu.Unlock()
+
+	if runErr != nil {
+		logger.Warn("Recording failed", zap.String("id", a.rec.ID), zap.Error(runErr))
+		return
+	}
+	logger.Info("Recording finished",
+		zap.String("id", a.rec.ID),
+		zap.Int64("samples", a.rec.Samples),
+		zap.Int64("bytes", a.rec.Bytes),
+	)
+}
+
+// Stop ends an active recording and waits for its files to be finalized.
+func (s *RecordingService) Stop(ctx context.Context, id string) (*model.Recording, error) {
+	s.mu.Lock()
+	a, ok := s.active[id]
+	s.mu.Unlock()
+	if !ok {
+		rec, err := s.load(filepath.Join(s.dir, id+sigmf.MetaExt))
+		if err != nil {
+			return nil, errors.NewWithDetail(errors.CodeNotFound, "recording not found", id)
+		}
+		return rec, nil
+	}
+
+	a.cancel()
+	select {
+	case <-a.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.mu.Lock()
+	rec := a.rec
+	s.mu.Unlock()
+	return &rec, nil
+}
+
+// StopAll ends every active recording; used on shutdown.
+func (s *RecordingService) StopAll() {
+	s.mu.Lock()
+	active := make([]*activeRecording, 0, len(s.active))
+	for _, a := range s.active {
+		active = append(active, a)
+	}
+	s.mu.Unlock()
+
+	for _, a := range active {
+		a.cancel()
+		<-a.done
+	}
+}
+
+// List returns all recordings in the directory, newest first.
+func (s *RecordingService) List(ctx context.Context) ([]model.Recording, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*"+sigmf.MetaExt))
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeInternalError, "failed to list recordings", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recordings := make([]model.Recording, 0, len(paths))
+	for _, path := range paths {
+		id := strings.TrimSuffix(filepath.Base(path), sigmf.MetaExt)
+		if a, ok := s.active[id]; ok {
+			recordings = append(recordings, a.rec)
+			continue
+		}
+		rec, err := s.load(path)
+		if err != nil {
+			logger.Warn("Skipping unreadable recording metadata", zap.String("path", path), zap.Error(err))
+			continue
+		}
+		recordings = append(recordings, *rec)
+	}
+
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].StartedAt.After(recordings[j].StartedAt)
+	})
+	return recordings, nil
+}
+
+// Verify re-hashes the data file of a finished recording and compares it
+// with the SHA-256 recorded when it was closed. Recordings made before
+// checksums were kept get theirs recorded.
+func (s *RecordingService) Verify(ctx context.Context, id string) (*model.IntegrityCheck, error) {
+	s.mu.Lock()
+	_, active := s.active[id]
+	s.mu.Unlock()
+	if active {
+		return nil, errors.NewWithDetail(errors.CodeInvalidParam, "recording is still in progress", id)
+	}
+
+	metaPath := filepath.Join(s.dir, id+sigmf.MetaExt)
+	if filepath.Base(id) != id {
+		return nil, errors.NewWithDetail(errors.CodeNotFound, "recording not found", id)
+	}
+	meta, err := sigmf.ReadMeta(metaPath)
+	if err != nil {
+		return nil, errors.NewWithDetail(errors.CodeNotFound, "recording not found", id)
+	}
+	expected, _ := meta.Global.Extra[extSHA256].(string)
+	check := &model.IntegrityCheck{
+		RecordingID: id,
+		Path:        strings.TrimSuffix(metaPath, sigmf.MetaExt) + sigmf.DataExt,
+		Expected:    expected,
+		CheckedAt:   time.Now(),
+	}
+
+	file, err := os.Open(check.Path)
+	if os.IsNotExist(err) {
+		check.Status = model.IntegrityMissing
+		return check, nil
+	}
+	if err != nil {
+		check.Status = model.IntegrityUnreadable
+		check.Error = err.Error()
+		return check, nil
+	}
+	defer file.Close()
+
+	check.Actual, check.Size, err = checksum(file)
+	switch {
+	case err != nil:
+		check.Status = model.IntegrityUnreadable
+		check.Error = err.Error()
+	case expected == "":
+		if meta.Global.Extra == nil {
+			meta.Global.Extra = make(map[string]interface{})
+		}
+		meta.Global.Extra[extSHA256] = check.Actual
+		if err := sigmf.WriteMeta(metaPath, meta); err != nil {
+			return nil, errors.Wrap(errors.CodeInternalError, "failed to record recording checksum", err)
+		}
+		check.Status = model.IntegrityRecorded
+	case check.Actual == expected:
+		check.Status = model.IntegrityOK
+	default:
+		check.Status = model.IntegrityMismatch
+		logger.Warn("Recording checksum mismatch",
+			zap.String("id", id),
+			zap.String("expected", expected),
+			zap.String("actual", check.Actual),
+		)
+	}
+	return check, nil
+}
+
+func (s *RecordingService) load(metaPath string) (*model.Recording, error) {
+	meta, err := sigmf.ReadMeta(metaPath)
+	if err != nil {
+		return nil, err
+	}
+
+	base := strings.TrimSuffix(metaPath, sigmf.MetaExt)
+	extra := func(key string) string {
+		v, _ := meta.Global.Extra[key].(string)
+		return v
+	}
+
+	rec := &model.Recording{
+		ID:           filepath.Base(base),
+		ExperimentID: extra(extExperimentID),
+		Description:  meta.Global.Description,
+		Status:       model.RecordingStatus(extra(extStatus)),
+		DataPath:     base + sigmf.DataExt,
+		MetaPath:     metaPath,
+		SampleRate:   meta.Global.SampleRate,
+		Channels:     meta.Global.NumChannels,
+		Error:        extra(extError),
+		Checksum:     extra(extSHA256),
+	}
+	if len(meta.Captures) > 0 {
+		rec.CenterFreq = meta.Captures[0].Frequency
+	}
+	rec.StartedAt, _ = time.Parse(time.RFC3339Nano, extra(extStartedAt))
+	if stopped, err := time.Parse(time.RFC3339Nano, extra(extStoppedAt)); err == nil {
+		rec.StoppedAt = &stopped
+	}
+	if info, err := os.Stat(rec.DataPath); err == nil {
+		rec.Bytes = info.Size()
+		if rec.Channels > 0 {
+			rec.Samples = info.Size() / int64(8*rec.Channels)
+		}
+	}
+	// a recording still marked in progress but not active was interrupted
+	if rec.Status == model.RecordingStatusRecording {
+		rec.Status = model.RecordingStatusFailed
+		rec.Error = "recording interrupted"
+	}
+	return rec, nil
+}
+
+// ReadSnapshots reads length samples per channel of a finished recording,
+// starting at sample offset, as an antenna-by-snapshot matrix. The samples
+// must lie within one capture segment, so that they are contiguous and at
+// one frequency; the returned recording gives that segment's frequency.
+func (s *RecordingService) ReadSnapshots(ctx context.Context, id string, offset int64, length int) ([][]complex128, *model.Recording, error) {
+	s.mu.Lock()
+	_, active := s.active[id]
+	s.mu.Unlock()
+	if active {
+		return nil, nil, errors.NewWithDetail(errors.CodeInvalidParam, "recording is still in progress", id)
+	}
+	if filepath.Base(id) != id {
+		return nil, nil, errors.NewWithDetail(errors.CodeNotFound, "recording not found", id)
+	}
+	metaPath := filepath.Join(s.dir, id+sigmf.MetaExt)
+	rec, err := s.load(metaPath)
+	if err != nil {
+		return nil, nil, errors.NewWithDetail(errors.CodeNotFound, "recording not found", id)
+	}
+	meta, err := sigmf.ReadMeta(metaPath)
+	if err != nil {
+		return nil, nil, errors.NewWithDetail(errors.CodeNotFound, "recording not found", id)
+	}
+
+	if offset < 0 || offset+int64(length) > rec.Samples {
+		return nil, nil, &model.ValidationError{Field: "recording_offset",
+			Message: fmt.Sprintf("recording %s holds %d samples, cannot read %d from sample %d", id, rec.Samples, length, offset)}
+	}
+	end := rec.Samples
+	for i, capture := range meta.Captures {
+		if capture.SampleStart > offset {
+			break
+		}
+		rec.CenterFreq = capture.Frequency
+		end = rec.Samples
+		if i+1 < len(meta.Captures) {
+			end = meta.Captures[i+1].SampleStart
+		}
+	}
+	if offset+int64(length) > end {
+		return nil, nil, &model.ValidationError{Field: "recording_offset",
+			Message: fmt.Sprintf("samples %d to %d of recording %s span a retune or a gap", offset, offset+int64(length), id)}
+	}
+
+	X, err := sigmf.ReadSamples(rec.DataPath, rec.Channels, offset, length)
+	if err != nil {
+		return nil, nil, errors.Wrap(errors.CodeInternalError, "failed to read recording", err)
+	}
+	return X, rec, nil
+}
@@ -0,0 +1,412 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/report"
+)
+
+const (
+	// reportSensorMargin widens the environment summary to the readings
+	// taken shortly before the experiment started.
+	reportSensorMargin = 5 * time.Minute
+	// reportListLimit is the longest list a report prints in full; longer
+	// ones are summarized by their length and range.
+	reportListLimit = 8
+	// reportFloorDB clips the normalized patterns and spectra plotted in
+	// reports, whose nulls would otherwise stretch the axis.
+	reportFloorDB = -60
+)
+
+// DeviceLister lists the devices and their state for experiment reports.
+type DeviceLister interface {
+	List() []*model.DeviceInfo
+}
+
+// SensorAggregator summarizes the environment sensor readings of a time
+// range for experiment reports.
+type SensorAggregator interface {
+	AggregateSensorData(ctx context.Context, q *model.SensorDataQuery) ([]*model.SensorAggregatedData, error)
+}
+
+var reportStatus = map[model.ExperimentStatus]string{
+	model.ExperimentStatusPending:   "pending",
+	model.ExperimentStatusRunning:   "running",
+	model.ExperimentStatusCompleted: "completed",
+	model.ExperimentStatusFailed:    "failed",
+}
+
+// buildReport lays out an experiment as a report: its summary, parameters,
+// KPIs, results with their plots, the devices and the environment sensor
+// readings taken while it ran.
+func (s *ExportService) buildReport(ctx context.Context, result *model.ExperimentResult) (*report.Document, error) {
+	kpis, err := result.KPIs()
+	if err != nil {
+		return nil, err
+	}
+	var payload model.ResultPayload
+	if result.ResultData != nil {
+		if payload, err = model.DecodeResult(result.AlgorithmType, *result.ResultData); err != nil {
+			return nil, err
+		}
+	}
+
+	doc := &report.Document{
+		Title:    fmt.Sprintf("Experiment %s", result.ExperimentID),
+		Subtitle: fmt.Sprintf("%s experiment, report generated %s", result.AlgorithmType, time.Now().UTC().Format(time.RFC3339)),
+	}
+	doc.Sections = append(doc.Sections, report.Section{Heading: "Summary", Fields: reportSummary(result)})
+
+	parameters, err := flattenJSON(result.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	doc.Sections = append(doc.Sections, report.Section{Heading: "Parameters", Fields: parameters})
+
+	section := report.Section{Heading: "KPIs"}
+	for _, name := range model.KPINames {
+		if v, ok := kpis[name]; ok {
+			section.Fields = append(section.Fields, report.Field{Name: name, Value: formatReportValue(v)})
+		}
+	}
+	if len(section.Fields) == 0 {
+		section.Text = []string{"The experiment reported no KPIs."}
+	}
+	doc.Sections = append(doc.Sections, section)
+
+	section = report.Section{Heading: "Results"}
+	if result.ResultData == nil {
+		section.Text = []string{"The experiment has no result."}
+	} else {
+		if section.Fields, err = flattenJSON(*result.ResultData); err != nil {
+			return nil, err
+		}
+		section.Plots = resultPlots(result, payload)
+	}
+	doc.Sections = append(doc.Sections, section)
+
+	if s.devices != nil {
+		doc.Sections = append(doc.Sections, deviceSection(s.devices.List()))
+	}
+	if s.sensors != nil {
+		doc.Sections = append(doc.Sections, s.environmentSection(ctx, result))
+	}
+	return doc, nil
+}
+
+func reportSummary(result *model.ExperimentResult) []report.Field {
+	status, ok := reportStatus[result.Status]
+	if !ok {
+		status = strconv.Itoa(int(result.Status))
+	}
+	fields := []report.Field{
+		{Name: "Experiment", Value: result.ExperimentID},
+		{Name: "Algorithm", Value: string(result.AlgorithmType)},
+		{Name: "Status", Value: status},
+	}
+	if result.Holder != "" {
+		fields = append(fields, report.Field{Name: "Holder", Value: result.Holder})
+	}
+	fields = append(fields, report.Field{Name: "Created", Value: result.CreatedAt.UTC().Format(time.RFC3339)})
+	if result.CompletedAt != nil {
+		fields = append(fields,
+			report.Field{Name: "Completed", Value: result.CompletedAt.UTC().Format(time.RFC3339)},
+			report.Field{Name: "Duration", Value: result.CompletedAt.Sub(result.CreatedAt).Round(time.Millisecond).String()},
+		)
+	}
+	return fields
+}
+
+// resultPlots draws the curves of a result: the beam pattern of a
+// beamformer and its sum rate per WMMSE iteration, the spatial spectrum of
+// a DOA scan, the per-trial SNR of an IRS impact measurement and the SNR
+// per alternation of a joint optimizer.
+func resultPlots(result *model.ExperimentResult, payload model.ResultPayload) []report.Plot {
+	switch r := payload.(type) {
+	case *model.BeamformingResult:
+		if len(r.BeamPattern) == 0 {
+			return nil
+		}
+		// the optimizer samples the pattern over [-90°, 90°)
+		x := make([]float64, len(r.BeamPattern))
+		for i := range x {
+			x[i] = -90 + float64(i)*180/float64(len(x))
+		}
+		plots := []report.Plot{{
+			Title:  "Beam pattern",
+			XLabel: "angle (deg)",
+			YLabel: "gain (dB)",
+			Series: []report.Series{{X: x, Y: normalizedDB(r.BeamPattern, 20)}},
+		}}
+		if len(r.RateHistory) > 0 {
+			iterations := make([]float64, len(r.RateHistory))
+			for i := range iterations {
+				iterations[i] = float64(i + 1)
+			}
+			plots = append(plots, report.Plot{
+				Title:  "Sum rate per iteration",
+				XLabel: "iteration",
+				YLabel: "weighted sum rate (bit/s/Hz)",
+				Series: []report.Series{{X: iterations, Y: r.RateHistory}},
+			})
+		}
+		return plots
+	case *model.DOAResult:
+		if len(r.Spectrum) == 0 {
+			return nil
+		}
+		var params model.DOAParams
+		json.Unmarshal([]byte(result.Parameters), &params)
+		lo, hi := -90.0, 89.5
+		if params.SearchStep > 0 && params.SearchRangeMin < params.SearchRangeMax {
+			lo, hi = params.SearchRangeMin, params.SearchRangeMax
+		}
+		return []report.Plot{{
+			Title:  "Spatial spectrum",
+			XLabel: "azimuth (deg)",
+			YLabel: "power (dB)",
+			Series: []report.Series{{X: linspace(lo, hi, len(r.Spectrum)), Y: normalizedDB(r.Spectrum, 10)}},
+		}}
+	case *model.IRSImpactResult:
+		trials := make([]float64, len(r.Samples))
+		on := make([]float64, len(r.Samples))
+		off := make([]float64, len(r.Samples))
+		for i, sample := range r.Samples {
+			trials[i] = float64(i + 1)
+			on[i], off[i] = sample.SNROn, sample.SNROff
+		}
+		return []report.Plot{{
+			Title:  "SNR per trial",
+			XLabel: "trial",
+			YLabel: "SNR (dB)",
+			Series: []report.Series{
+				{Name: "IRS on", X: trials, Y: on},
+				{Name: "IRS off", X: trials, Y: off},
+			},
+		}}
+	case *model.JointBeamformingResult:
+		iterations := make([]float64, len(r.SNRHistory))
+		for i := range iterations {
+			iterations[i] = float64(i + 1)
+		}
+		plot := report.Plot{
+			Title:  "SNR per iteration",
+			XLabel: "iteration",
+			YLabel: "SNR (dB)",
+			Series: []report.Series{{X: iterations, Y: r.SNRHistory}},
+		}
+		if r.Method == model.JointMethodSDR {
+			plot.Title, plot.XLabel = "Relaxation SNR per sweep", "sweep"
+		}
+		plots := []report.Plot{plot}
+		if m := r.Manifold; m != nil && len(m.GradientNorms) > 0 {
+			steps := make([]float64, len(m.GradientNorms))
+			for i := range steps {
+				steps[i] = float64(i + 1)
+			}
+			plots = append(plots, report.Plot{
+				Title:  "Relative Riemannian gradient norm per iteration",
+				XLabel: "iteration",
+				YLabel: "gradient norm",
+				Series: []report.Series{{X: steps, Y: m.GradientNorms}},
+			})
+		}
+		return plots
+	case *model.HybridBeamformingResult:
+		steps := make([]float64, len(r.ErrorHistory))
+		for i := range steps {
+			steps[i] = float64(i + 1)
+		}
+		return []report.Plot{{
+			Title:  "Distance to the digital precoder per step",
+			XLabel: "step",
+			YLabel: "relative error",
+			Series: []report.Series{{X: steps, Y: r.ErrorHistory}},
+		}}
+	}
+	return nil
+}
+
+func deviceSection(devices []*model.DeviceInfo) report.Section {
+	table := &report.Table{Columns: []string{"Device", "ID", "Driver", "Simulator", "Connected", "Firmware", "Error"}}
+	for _, d := range devices {
+		firmware := ""
+		if d.Versions != nil {
+			firmware = d.Versions.Firmware
+		}
+		table.Rows = append(table.Rows, []string{
+			d.Name, d.ID, d.DriverType, strconv.FormatBool(d.Simulator), strconv.FormatBool(d.Connected), firmware, d.Error,
+		})
+	}
+	return report.Section{
+		Heading: "Devices",
+		Text:    []string{"State of the devices when the report was generated."},
+		Table:   table,
+	}
+}
+
+// environmentSection summarizes each sensor over the run of the experiment,
+// from shortly before it was created until it completed, or until now while
+// it is still running.
+func (s *ExportService) environmentSection(ctx context.Context, result *model.ExperimentResult) report.Section {
+	section := report.Section{Heading: "Environment"}
+	start, end := result.CreatedAt.Add(-reportSensorMargin), time.Now()
+	if result.CompletedAt != nil {
+		end = *result.CompletedAt
+	}
+	aggregates, err := s.sensors.AggregateSensorData(ctx, &model.SensorDataQuery{StartTime: start, EndTime: end, Window: end.Sub(start)})
+	if err != nil {
+		section.Text = []string{"Sensor readings are unavailable: " + err.Error()}
+		return section
+	}
+	section.Text = []string{fmt.Sprintf("Sensor readings from %s to %s.", start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339))}
+	if len(aggregates) == 0 {
+		section.Text = append(section.Text, "No readings were stored in this period.")
+		return section
+	}
+	section.Table = &report.Table{Columns: []string{"Sensor", "Type", "Location", "Mean", "Min", "Max", "Std dev", "Readings"}}
+	for _, a := range aggregates {
+		section.Table.Rows = append(section.Table.Rows, []string{
+			a.SensorID, a.SensorType, a.Location,
+			report.FormatFloat(a.AvgValue), report.FormatFloat(a.MinValue), report.FormatFloat(a.MaxValue), report.FormatFloat(a.StdDev),
+			strconv.Itoa(a.Count),
+		})
+	}
+	return section
+}
+
+// flattenJSON lists the leaves of a JSON document as fields named by their
+// dotted paths, in sorted order, with long lists summarized.
+func flattenJSON(data string) ([]report.Field, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		return nil, err
+	}
+	var fields []report.Field
+	flattenValue("", v, &fields)
+	return fields, nil
+}
+
+func flattenValue(path string, v interface{}, fields *[]report.Field) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			name := k
+			if path != "" {
+				name = path + "." + k
+			}
+			flattenValue(name, t[k], fields)
+		}
+	case []interface{}:
+		if len(t) <= reportListLimit && scalars(t) {
+			*fields = append(*fields, report.Field{Name: path, Value: formatReportValue(t)})
+			return
+		}
+		if len(t) <= reportListLimit {
+			for i, item := range t {
+				flattenValue(fmt.Sprintf("%s.%d", path, i), item, fields)
+			}
+			return
+		}
+		*fields = append(*fields, report.Field{Name: path, Value: summarizeList(t)})
+	default:
+		*fields = append(*fields, report.Field{Name: path, Value: formatReportValue(t)})
+	}
+}
+
+func scalars(list []interface{}) bool {
+	for _, item := range list {
+		switch item.(type) {
+		case map[string]interface{}, []interface{}:
+			return false
+		}
+	}
+	return true
+}
+
+// summarizeList describes a list too long to print by its length and, for
+// numbers, their range.
+func summarizeList(list []interface{}) string {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, item := range list {
+		f, ok := item.(float64)
+		if !ok {
+			return fmt.Sprintf("%d items", len(list))
+		}
+		lo, hi = math.Min(lo, f), math.Max(hi, f)
+	}
+	return fmt.Sprintf("%d values from %s to %s", len(list), report.FormatFloat(lo), report.FormatFloat(hi))
+}
+
+func formatReportValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return report.FormatFloat(t)
+	case int:
+		return strconv.Itoa(t)
+	case []float64:
+		items := make([]interface{}, len(t))
+		for i, f := range t {
+			items[i] = f
+		}
+		return formatReportValue(items)
+	case []interface{}:
+		if len(t) > reportListLimit {
+			return summarizeList(t)
+		}
+		items := make([]string, len(t))
+		for i, item := range t {
+			items[i] = formatReportValue(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
+
+// normalizedDB converts a magnitude (factor 20) or power (factor 10) curve
+// to decibels below its peak, clipped at reportFloorDB.
+func normalizedDB(values []float64, factor float64) []float64 {
+	peak := 0.0
+	for _, v := range values {
+		peak = math.Max(peak, v)
+	}
+	db := make([]float64, len(values))
+	for i, v := range values {
+		db[i] = reportFloorDB
+		if peak > 0 && v > 0 {
+			db[i] = math.Max(factor*math.Log10(v/peak), reportFloorDB)
+		}
+	}
+	return db
+}
+
+func linspace(lo, hi float64, n int) []float64 {
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = lo
+		if n > 1 {
+			x[i] = lo + float64(i)*(hi-lo)/float64(n-1)
+		}
+	}
+	return x
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/internal/repository/objectstore"
+)
+
+type staticDevices []*model.DeviceInfo
+
+func (d staticDevices) List() []*model.DeviceInfo { return d }
+
+type staticSensors struct {
+	aggregates []*model.SensorAggregatedData
+	query      *model.SensorDataQuery
+}
+
+func (s *staticSensors) AggregateSensorData(ctx context.Context, q *model.SensorDataQuery) ([]*model.SensorAggregatedData, error) {
+	s.query = q
+	return s.aggregates, nil
+}
+
+func TestExportService_Report(t *testing.T) {
+	ctx := context.Background()
+	artifacts, _, dir := newTestArtifactService(t)
+	hot, err := objectstore.NewLocalStore(dir, "http://localhost/objects", "test-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := newMemResultStore()
+	s := NewExportService(objectstore.NewTieredStore(hot, nil, 0), artifacts, results, time.Hour)
+	s.SetDevices(staticDevices{{Name: "usrp", Simulator: true, Connected: true, DriverType: "simulator"}})
+	sensors := &staticSensors{aggregates: []*model.SensorAggregatedData{
+		{SensorID: "temp-1", SensorType: "temperature", Location: "lab", AvgValue: 23.5, MinValue: 22, MaxValue: 25, Count: 12},
+	}}
+	s.SetSensors(sensors)
+
+	spectrum := make([]float64, 360)
+	for i := range spectrum {
+		spectrum[i] = 1 / (1 + math.Pow(float64(i-220)/4, 2))
+	}
+	params, _ := json.Marshal(&model.DOAParams{ElementCount: 8, NumSources: 1, SnapshotLength: 200, Method: "MUSIC"})
+	data, _ := json.Marshal(&model.DOAResult{EstimatedAngles: []float64{0.35}, Spectrum: spectrum})
+	resultData := string(data)
+	created := time.Now().Add(-time.Minute)
+	completed := created.Add(2 * time.Second)
+	results.Create(ctx, &model.ExperimentResult{
+		ExperimentID: "doa-1", AlgorithmType: model.AlgorithmTypeDOA, Parameters: string(params),
+		ResultData: &resultData, Status: model.ExperimentStatusCompleted, CreatedAt: created, CompletedAt: &completed,
+	})
+
+	read := func(format model.ExportFormat) []byte {
+		t.Helper()
+		export, err := s.ExportExperiment(ctx, "doa-1", format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if export.Format != format || !strings.HasPrefix(export.Key, objectstore.PrefixReport) {
+			t.Errorf("%s exported as %s to %s, want a report", format, export.Format, export.Key)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(export.Key)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	page := string(read(model.ExportFormatHTML))
+	for _, want := range []string{
+		"Experiment doa-1", "completed", "element_count", "estimated_angles",
+		"360 values from", "Spatial spectrum", "<svg", "usrp", "temp-1", "23.5",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("HTML report lacks %q", want)
+		}
+	}
+	if sensors.query == nil || !sensors.query.EndTime.Equal(completed) || !sensors.query.StartTime.Before(created) {
+		t.Errorf("sensor query %+v, want the run of the experiment", sensors.query)
+	}
+
+	if pdf := read(model.ExportFormatPDF); !bytes.HasPrefix(pdf, []byte("%PDF-")) || !bytes.Contains(pdf, []byte("Spatial spectrum")) {
+		t.Error("PDF report is not a PDF with the spectrum plot")
+	}
+
+	if _, err := s.ExportExperiment(ctx, "doa-1", "docx"); err == nil {
+		t.Error("unsupported format was exported")
+	}
+}
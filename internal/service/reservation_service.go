@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+type ReservationStore interface {
+	Create(ctx context.Context, reservation *model.Reservation) error
+	GetByID(ctx context.Context, id int64) (*model.Reservation, error)
+	List(ctx context.Context, query *model.ReservationQuery) ([]model.Reservation, error)
+	Delete(ctx context.Context, id int64) error
+	ListOverlapping(ctx context.Context, device string, start, end time.Time) ([]model.Reservation, error)
+}
+
+// DeviceGate is consulted before an experiment or configuration change
+// touches a device.
+type DeviceGate interface {
+	Blocking(ctx context.Context, device string) (*model.Reservation, error)
+	WaitUntilFree(ctx context.Context, device string) error
+}
+
+type holderKey struct{}
+
+// WithHolder attaches the caller's reservation holder name to ctx.
+func WithHolder(ctx context.Context, holder string) context.Context {
+	return context.WithValue(ctx, holderKey{}, holder)
+}
+
+func HolderFromContext(ctx context.Context) string {
+	holder, _ := ctx.Value(holderKey{}).(string)
+	return holder
+}
+
+// ReservationService books the IRS and USRP for time windows. A device is
+// blocked for a caller while a reservation held by someone else is active.
+type ReservationService struct {
+	store ReservationStore
+	uow   UnitOfWork
+	audit AuditStore
+}
+
+func NewReservationService(store ReservationStore) *ReservationService {
+	return &ReservationService{store: store}
+}
+
+// SetUnitOfWork makes the overlap check and the insert of a new reservation
+// one transaction, so concurrent bookings of the same window cannot both
+// succeed.
+func (s *ReservationService) SetUnitOfWork(uow UnitOfWork) {
+	s.uow = uow
+}
+
+func (s *ReservationService) SetAuditStore(store AuditStore) {
+	s.audit = store
+}
+
+// Create books a device for the holder in ctx, who must be authenticated.
+func (s *ReservationService) Create(ctx context.Context, req *model.ReservationRequest) (*model.Reservation, error) {
+	if s.store == nil {
+		return nil, errors.New(errors.CodeServiceUnavailable, "reservation store not available")
+	}
+	holder := HolderFromContext(ctx)
+	if holder == "" {
+		return nil, errors.New(errors.CodeUnauthorized, "reservations require an authenticated caller")
+	}
+	if !req.EndTime.After(req.StartTime) {
+		return nil, errors.New(errors.CodeInvalidParam, "end_time must be after start_time")
+	}
+	if !req.EndTime.After(time.Now()) {
+		return nil, errors.New(errors.CodeInvalidParam, "reservation window is in the past")
+	}
+
+	reservation := &model.Reservation{
+		Device:    req.Device,
+		Holder:    holder,
+		Purpose:   req.Purpose,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+	}
+	err := transact(ctx, s.uow, func(ctx context.Context) error {
+		overlapping, err := s.store.ListOverlapping(ctx, req.Device, req.StartTime, req.EndTime)
+		if err != nil {
+			return err
+		}
+		if len(overlapping) > 0 {
+			return reservedError(&overlapping[0])
+		}
+		if err := s.store.Create(ctx, reservation); err != nil {
+			return err
+		}
+		return audit(ctx, s.audit, model.AuditActionCreate, model.AuditResourceReservation,
+			strconv.FormatInt(reservation.ID, 10),
+			fmt.Sprintf("%s for %s from %s to %s", reservation.Device, reservation.Holder,
+				reservation.StartTime.Format(time.RFC3339), reservation.EndTime.Format(time.RFC3339)))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+func (s *ReservationService) List(ctx context.Context, query *model.ReservationQuery) ([]model.Reservation, error) {
+	if s.store == nil {
+		return []model.Reservation{}, nil
+	}
+	return s.store.List(ctx, query)
+}
+
+// Cancel deletes a reservation of the holder in ctx. Reservations of other
+// holders cannot be cancelled.
+func (s *ReservationService) Cancel(ctx context.Context, id int64) error {
+	if s.store == nil {
+		return errors.New(errors.CodeServiceUnavailable, "reservation store not available")
+	}
+	holder := HolderFromContext(ctx)
+	return transact(ctx, s.uow, func(ctx context.Context) error {
+		reservation, err := s.store.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if holder == "" || reservation.Holder != holder {
+			return errors.NewWithDetail(errors.CodeForbidden, "reservation is held by someone else", reservation.Holder)
+		}
+		if err := s.store.Delete(ctx, id); err != nil {
+			return err
+		}
+		return audit(ctx, s.audit, model.AuditActionDelete, model.AuditResourceReservation, strconv.FormatInt(id, 10), "")
+	})
+}
+
+// Blocking returns the active reservation that keeps the holder in ctx off
+// device, or nil if the device is free for them.
+func (s *ReservationService) Blocking(ctx context.Context, device string) (*model.Reservation, error) {
+	if s.store == nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	active, err := s.store.ListOverlapping(ctx, device, now, now.Add(time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+
+	holder := HolderFromContext(ctx)
+	for i := range active {
+		if active[i].Holder != holder {
+			return &active[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// WaitUntilFree blocks until no other holder's reservation of device is
+// active. Back-to-back reservations are waited out one after another.
+func (s *ReservationService) WaitUntilFree(ctx context.Context, device string) error {
+	for {
+		r, err := s.Blocking(ctx, device)
+		if err != nil {
+			return err
+		}
+		if r == nil {
+			return nil
+		}
+
+		timer := time.NewTimer(time.Until(r.EndTime))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func reservedError(r *model.Reservation) error {
+	return errors.NewWithDetail(errors.CodeDeviceReserved, r.Device+" is reserved",
+		fmt.Sprintf("held by %s until %s", r.Holder, r.EndTime.Format(time.RFC3339)))
+}
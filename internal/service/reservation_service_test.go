@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+type memReservationStore struct {
+	mu           sync.Mutex
+	nextID       int64
+	reservations map[int64]model.Reservation
+}
+
+func newMemReservationStore() *memReservationStore {
+	return &memReservationStore{reservations: make(map[int64]model.Reservation)}
+}
+
+func (m *memReservationStore) Create(ctx context.Context, r *model.Reservation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	r.ID = m.nextID
+	m.reservations[r.ID] = *r
+	return nil
+}
+
+func (m *memReservationStore) GetByID(ctx context.Context, id int64) (*model.Reservation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.reservations[id]
+	if !ok {
+		return nil, errors.New(errors.CodeNotFound, "reservation not found")
+	}
+	return &r, nil
+}
+
+func (m *memReservationStore) List(ctx context.Context, q *model.ReservationQuery) ([]model.Reservation, error) {
+	return m.ListOverlapping(ctx, q.Device, time.Time{}, time.Now().Add(24*time.Hour))
+}
+
+func (m *memReservationStore) Delete(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.reservations, id)
+	return nil
+}
+
+func (m *memReservationStore) ListOverlapping(ctx context.Context, device string, start, end time.Time) ([]model.Reservation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []model.Reservation
+	for _, r := range m.reservations {
+		if (device == "" || r.Device == device) && r.StartTime.Before(end) && r.EndTime.After(start) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func TestReservationHolder(t *testing.T) {
+	svc := NewReservationService(newMemReservationStore())
+	req := &model.ReservationRequest{
+		Device:    model.ReservableDeviceIRS,
+		StartTime: time.Now().Add(-time.Minute),
+		EndTime:   time.Now().Add(time.Hour),
+	}
+
+	if _, err := svc.Create(context.Background(), req); !errors.IsCode(err, errors.CodeUnauthorized) {
+		t.Fatalf("anonymous Create error = %v, want CodeUnauthorized", err)
+	}
+
+	alice := WithHolder(context.Background(), "alice")
+	bob := WithHolder(context.Background(), "bob")
+	r, err := svc.Create(alice, req)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if r.Holder != "alice" {
+		t.Errorf("holder = %q, want alice", r.Holder)
+	}
+
+	if blocking, err := svc.Blocking(alice, model.ReservableDeviceIRS); err != nil || blocking != nil {
+		t.Errorf("Blocking(alice) = %v, %v; want nil", blocking, err)
+	}
+	if blocking, err := svc.Blocking(bob, model.ReservableDeviceIRS); err != nil || blocking == nil {
+		t.Errorf("Blocking(bob) = %v, %v; want alice's reservation", blocking, err)
+	}
+
+	for _, ctx := range []context.Context{bob, context.Background()} {
+		if err := svc.Cancel(ctx, r.ID); !errors.IsCode(err, errors.CodeForbidden) {
+			t.Errorf("Cancel by %q error = %v, want CodeForbidden", HolderFromContext(ctx), err)
+		}
+	}
+	if err := svc.Cancel(alice, r.ID); err != nil {
+		t.Fatalf("Cancel by holder: %v", err)
+	}
+	if err := svc.Cancel(alice, r.ID); !errors.IsCode(err, errors.CodeNotFound) {
+		t.Errorf("second Cancel error = %v, want CodeNotFound", err)
+	}
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/cache"
+)
+
+// ResultCacheConfig sizes the result cache; zero Size disables it. Entries
+// expire after TTL, defaultCacheTTL when unset.
+type ResultCacheConfig struct {
+	Size int
+	TTL  time.Duration
+}
+
+const defaultCacheTTL = 10 * time.Minute
+
+// resultCache holds the results of deterministic runs, keyed by algorithm
+// type and the canonical JSON of their parameters, so that identical
+// requests, typically from dashboards refreshing, return at once.
+type resultCache struct {
+	entries *cache.L1Cache
+	ttl     time.Duration
+	hits    atomic.Int64
+	misses  atomic.Int64
+}
+
+type cachedResult struct {
+	experimentID string
+	result       interface{}
+}
+
+type cacheBypassKey struct{}
+
+// WithCacheBypass makes runs under ctx skip the result cache lookup; their
+// results still replace the cached ones.
+func WithCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
+
+// SetResultCache enables the result cache, dropping whatever was cached.
+func (s *AlgorithmService) SetResultCache(cfg ResultCacheConfig) {
+	if cfg.Size <= 0 {
+		s.cache = nil
+		return
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultCacheTTL
+	}
+	s.cache = &resultCache{entries: cache.NewL1Cache(cfg.Size), ttl: cfg.TTL}
+}
+
+// beamformingCacheKey is empty for eigen beamforming, which depends on the
+// live channel.
+func beamformingCacheKey(params *model.BeamformingParams) string {
+	if params.Mode != "" && params.Mode != model.BeamformingModeTarget {
+		return ""
+	}
+	return resultCacheKey(model.AlgorithmTypeBeamforming, params)
+}
+
+// doaCacheKey is empty for captured snapshots, for runs that archive their
+// snapshots and for unseeded phase noise.
+func doaCacheKey(params *model.DOAParams) string {
+	if params.Captured() || params.StoreSnapshots {
+		return ""
+	}
+	if params.Impairments.Enabled() && params.Impairments.PhaseNoiseOffset > 0 && params.Seed == nil {
+		return ""
+	}
+	return resultCacheKey(model.AlgorithmTypeDOA, params)
+}
+
+func resultCacheKey(algorithmType model.AlgorithmType, params interface{}) string {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return string(algorithmType) + ":" + hex.EncodeToString(sum[:])
+}
+
+// lookup returns the cached result for key unless ctx bypasses the cache.
+func (c *resultCache) lookup(ctx context.Context, key string) (*cachedResult, bool) {
+	if c == nil || key == "" || cacheBypassed(ctx) {
+		return nil, false
+	}
+	v, ok := c.entries.Get(key)
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	return v.(*cachedResult), true
+}
+
+func (c *resultCache) store(key, experimentID string, result interface{}) {
+	if c == nil || key == "" {
+		return
+	}
+	c.entries.Set(key, &cachedResult{experimentID: experimentID, result: result}, c.ttl)
+}
+
+func (s *AlgorithmService) cachedBeamforming(ctx context.Context, key string) (*model.BeamformingResult, bool) {
+	entry, ok := s.cache.lookup(ctx, key)
+	if !ok {
+		return nil, false
+	}
+	result := *entry.result.(*model.BeamformingResult)
+	result.CachedFrom = entry.experimentID
+	return &result, true
+}
+
+func (s *AlgorithmService) cachedDOA(ctx context.Context, key string) (*model.DOAResult, bool) {
+	entry, ok := s.cache.lookup(ctx, key)
+	if !ok {
+		return nil, false
+	}
+	result := *entry.result.(*model.DOAResult)
+	result.CachedFrom = entry.experimentID
+	return &result, true
+}
+
+// InvalidateCache drops the cached results of algorithmType, or all of them
+// when it is empty, and returns how many were dropped.
+func (s *AlgorithmService) InvalidateCache(algorithmType model.AlgorithmType) int {
+	if s.cache == nil {
+		return 0
+	}
+	prefix := ""
+	if algorithmType != "" {
+		prefix = string(algorithmType) + ":"
+	}
+	return s.cache.entries.DeletePrefix(prefix)
+}
+
+// CacheStats reports the size and hit rate of the result cache.
+func (s *AlgorithmService) CacheStats() *model.ResultCacheStats {
+	if s.cache == nil {
+		return &model.ResultCacheStats{}
+	}
+	return &model.ResultCacheStats{
+		Enabled: true,
+		Entries: s.cache.entries.Len(),
+		TTL:     s.cache.ttl.Seconds(),
+		Hits:    s.cache.hits.Load(),
+		Misses:  s.cache.misses.Load(),
+	}
+}
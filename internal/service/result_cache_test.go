@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/model"
+)
+
+func TestResultCache(t *testing.T) {
+	store := newMemResultStore()
+	s := NewAlgorithmService(store)
+	s.SetResultCache(ResultCacheConfig{Size: 8, TTL: time.Minute})
+	ctx := context.Background()
+	params := &model.BeamformingParams{ElementCount: 8, TargetDirection: 0.2, MaxIterations: 20}
+
+	first, err := s.RunBeamforming(ctx, "exp_1", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	same := *params
+	second, err := s.RunBeamforming(ctx, "exp_2", &same)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.CachedFrom != "exp_1" || second.MainLobeDirection != first.MainLobeDirection || first.CachedFrom != "" {
+		t.Errorf("second run cached from %q, want exp_1", second.CachedFrom)
+	}
+	if _, err := store.GetByExperimentID(ctx, "exp_2"); err == nil {
+		t.Error("a cache hit created an experiment")
+	}
+
+	// Bypassing runs again and refreshes the entry.
+	third, err := s.RunBeamforming(WithCacheBypass(ctx), "exp_3", params)
+	if err != nil || third.CachedFrom != "" {
+		t.Fatalf("bypassed run = %+v, %v", third, err)
+	}
+	if again, _ := s.RunBeamforming(ctx, "exp_4", params); again.CachedFrom != "exp_3" {
+		t.Errorf("cached from %q after the bypass, want exp_3", again.CachedFrom)
+	}
+
+	other := *params
+	other.TargetDirection = 0.4
+	if r, _ := s.RunBeamforming(ctx, "exp_5", &other); r.CachedFrom != "" {
+		t.Error("different parameters hit the cache")
+	}
+
+	doaParams := &model.DOAParams{ElementCount: 8, NumSources: 1, SnapshotLength: 64, Method: "MUSIC",
+		SearchRangeMin: -90, SearchRangeMax: 90, SearchStep: 1}
+	if _, err := s.RunDOA(ctx, "exp_doa_1", doaParams); err != nil {
+		t.Fatal(err)
+	}
+	if r, _ := s.RunDOA(ctx, "exp_doa_2", doaParams); r.CachedFrom != "exp_doa_1" {
+		t.Errorf("DOA run cached from %q, want exp_doa_1", r.CachedFrom)
+	}
+	noisy := *doaParams
+	noisy.Impairments = &model.RFImpairments{PhaseNoisePSD: -80, PhaseNoiseOffset: 1e3}
+	s.RunDOA(ctx, "exp_doa_3", &noisy)
+	if r, _ := s.RunDOA(ctx, "exp_doa_4", &noisy); r.CachedFrom != "" {
+		t.Error("unseeded phase noise was cached")
+	}
+	seed := int64(5)
+	noisy.Seed = &seed
+	s.RunDOA(ctx, "exp_doa_5", &noisy)
+	if r, _ := s.RunDOA(ctx, "exp_doa_6", &noisy); r.CachedFrom != "exp_doa_5" {
+		t.Errorf("seeded run cached from %q, want exp_doa_5", r.CachedFrom)
+	}
+
+	stats := s.CacheStats()
+	if !stats.Enabled || stats.Entries != 4 || stats.Hits != 4 {
+		t.Errorf("stats = %+v, want 4 entries and 4 hits", stats)
+	}
+	if n := s.InvalidateCache(model.AlgorithmTypeDOA); n != 2 {
+		t.Errorf("invalidated %d DOA results, want 2", n)
+	}
+	if r, _ := s.RunDOA(ctx, "exp_doa_7", doaParams); r.CachedFrom != "" {
+		t.Error("invalidated result was returned")
+	}
+	if n := s.InvalidateCache(""); n != 3 {
+		t.Errorf("invalidated %d results, want 3", n)
+	}
+}
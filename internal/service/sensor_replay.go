@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// maxReplayPoints bounds the readings a replay loads into memory.
+const maxReplayPoints = 200000
+
+// SensorHistoryReader is implemented by data stores that can read a time
+// window in chronological order.
+type SensorHistoryReader interface {
+	QueryRange(ctx context.Context, q *model.SensorDataQuery, limit int) ([]*model.SensorData, error)
+}
+
+type sensorReplay struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	mu     sync.Mutex
+	status model.SensorReplayStatus
+}
+
+// StartReplay loads the stored readings of the requested window and emits
+// them through the collector's data callback with their original spacing,
+// divided by the speed, so that alert rules and other observers see them as
+// live readings; they keep their original timestamps and are not stored
+// again. A running replay is replaced.
+func (s *SensorService) StartReplay(ctx context.Context, req *model.SensorReplayRequest) (*model.SensorReplayStatus, error) {
+	if s.collector == nil {
+		return nil, deviceUnavailable("sensor")
+	}
+	reader, ok := s.dataStore.(SensorHistoryReader)
+	if !ok {
+		return nil, errors.New(errors.CodeServiceUnavailable, "sensor history not available")
+	}
+	points, err := reader.QueryRange(ctx, &model.SensorDataQuery{
+		SensorID:   req.SensorID,
+		SensorType: req.SensorType,
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+	}, maxReplayPoints+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) > maxReplayPoints {
+		return nil, errors.New(errors.CodeInvalidParam, fmt.Sprintf("the window holds more than %d readings, narrow it", maxReplayPoints))
+	}
+
+	speed := req.Speed
+	if speed == 0 {
+		speed = 1
+	}
+	replayCtx, cancel := context.WithCancel(context.Background())
+	replay := &sensorReplay{
+		cancel: cancel,
+		done:   make(chan struct{}),
+		status: model.SensorReplayStatus{
+			Running:    true,
+			StartTime:  req.StartTime,
+			EndTime:    req.EndTime,
+			SensorID:   req.SensorID,
+			SensorType: req.SensorType,
+			Speed:      speed,
+			Total:      len(points),
+			StartedAt:  time.Now(),
+		},
+	}
+
+	s.mu.Lock()
+	previous := s.replay
+	s.replay = replay
+	s.mu.Unlock()
+	if previous != nil {
+		previous.stop()
+	}
+
+	s.collector.SetDataCallback(s.handleData)
+	go s.runReplay(replayCtx, replay, points)
+	logger.Info("Sensor replay started",
+		zap.Time("start", req.StartTime), zap.Time("end", req.EndTime),
+		zap.Int("points", len(points)), zap.Float64("speed", speed))
+	return replay.snapshot(), nil
+}
+
+func (s *SensorService) runReplay(ctx context.Context, replay *sensorReplay, points []*model.SensorData) {
+	defer close(replay.done)
+	started := time.Now()
+	stopped := false
+	for _, point := range points {
+		offset := time.Duration(float64(point.Timestamp.Sub(points[0].Timestamp)) / replay.status.Speed)
+		if wait := time.Until(started.Add(offset)); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+			case <-timer.C:
+			}
+		}
+		if ctx.Err() != nil {
+			stopped = true
+			break
+		}
+
+		data := *point
+		data.Replayed = true
+		s.collector.Emit(&data)
+
+		replay.mu.Lock()
+		replay.status.Emitted++
+		position := point.Timestamp
+		replay.status.Position = &position
+		replay.mu.Unlock()
+	}
+
+	replay.mu.Lock()
+	finished := time.Now()
+	replay.status.Running = false
+	replay.status.Stopped = stopped
+	replay.status.FinishedAt = &finished
+	emitted := replay.status.Emitted
+	replay.mu.Unlock()
+	logger.Info("Sensor replay finished", zap.Int("emitted", emitted), zap.Bool("stopped", stopped))
+}
+
+// ReplayStatus reports the running or last replay.
+func (s *SensorService) ReplayStatus() (*model.SensorReplayStatus, error) {
+	s.mu.RLock()
+	replay := s.replay
+	s.mu.RUnlock()
+	if replay == nil {
+		return nil, errors.New(errors.CodeNotFound, "no sensor replay has run")
+	}
+	return replay.snapshot(), nil
+}
+
+// StopReplay stops the running replay and waits for it to finish.
+func (s *SensorService) StopReplay() (*model.SensorReplayStatus, error) {
+	s.mu.RLock()
+	replay := s.replay
+	s.mu.RUnlock()
+	if replay == nil {
+		return nil, errors.New(errors.CodeNotFound, "no sensor replay has run")
+	}
+	replay.stop()
+	return replay.snapshot(), nil
+}
+
+func (r *sensorReplay) stop() {
+	r.cancel()
+	<-r.done
+}
+
+func (r *sensorReplay) snapshot() *model.SensorReplayStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := r.status
+	return &status
+}
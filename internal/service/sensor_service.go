@@ -3,23 +3,45 @@ package service
 import (
 	"context"
 	"sync"
+	"time"
 
 	"isac-cran-system/internal/device/sensor"
 	"isac-cran-system/internal/model"
 	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
 )
 
 type SensorService struct {
 	collector *sensor.Collector
 	dataStore SensorDataStore
+	infoStore SensorInfoStore
+	observer  SensorObserver
+	events    EventPublisher
 	mu        sync.RWMutex
 	running   bool
+	replay    *sensorReplay
 }
 
 type SensorDataStore interface {
 	Write(ctx context.Context, data *model.SensorData) error
 	WriteBatch(ctx context.Context, dataPoints []*model.SensorData) error
 	Query(ctx context.Context, q *model.SensorDataQuery) ([]*model.SensorData, error)
+	Aggregate(ctx context.Context, q *model.SensorDataQuery) ([]*model.SensorAggregatedData, error)
+}
+
+type SensorInfoStore interface {
+	Create(ctx context.Context, info *model.SensorInfo) error
+	CreateBatch(ctx context.Context, infos []*model.SensorInfo) error
+	List(ctx context.Context, sensorType model.SensorType) ([]model.SensorInfo, error)
+	Update(ctx context.Context, info *model.SensorInfo) error
+	Delete(ctx context.Context, sensorID string) error
+}
+
+// SensorObserver is handed every reading the collector takes.
+type SensorObserver interface {
+	Observe(data *model.SensorData)
 }
 
 func NewSensorService(collector *sensor.Collector, store SensorDataStore) *SensorService {
@@ -29,7 +51,191 @@ func NewSensorService(collector *sensor.Collector, store SensorDataStore) *Senso
 	}
 }
 
+func (s *SensorService) SetInfoStore(store SensorInfoStore) {
+	s.infoStore = store
+}
+
+// MaintenanceObserver is an observer told about maintenance windows, during
+// which it should hold back alerts.
+type MaintenanceObserver interface {
+	SetMaintenance(sensorID string, window *model.SensorMaintenance)
+}
+
+// SetObserver also hands the observer the maintenance windows of the sensors
+// already registered.
+func (s *SensorService) SetObserver(observer SensorObserver) {
+	s.observer = observer
+	if s.collector == nil {
+		return
+	}
+	for _, info := range s.collector.GetAllSensors() {
+		s.notifyMaintenance(info)
+	}
+}
+
+func (s *SensorService) notifyMaintenance(info *model.SensorInfo) {
+	observer, ok := s.observer.(MaintenanceObserver)
+	if !ok {
+		return
+	}
+	var window *model.SensorMaintenance
+	if info.Lifecycle == model.SensorLifecycleMaintenance {
+		window = info.Maintenance
+		if window == nil {
+			window = &model.SensorMaintenance{}
+		}
+	}
+	observer.SetMaintenance(info.SensorID, window)
+}
+
+// SyncSensors stores the metadata of every sensor known to the collector in
+// one bulk write.
+func (s *SensorService) SyncSensors(ctx context.Context) error {
+	if s.collector == nil || s.infoStore == nil {
+		return nil
+	}
+	return s.infoStore.CreateBatch(ctx, s.collector.GetAllSensors())
+}
+
+// LoadSensors registers the stored sensors with the collector, so that
+// sensors added over the API survive a restart. Stored metadata replaces
+// what the driver reports.
+func (s *SensorService) LoadSensors(ctx context.Context) (int, error) {
+	if s.collector == nil || s.infoStore == nil {
+		return 0, nil
+	}
+	infos, err := s.infoStore.List(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+	for i := range infos {
+		s.collector.RegisterSensor(&infos[i])
+	}
+	return len(infos), nil
+}
+
+// RegisterSensor stores a new sensor and adds it to the collector, which
+// reads it from the next collection round on.
+func (s *SensorService) RegisterSensor(ctx context.Context, req *model.SensorRequest) (*model.SensorInfo, error) {
+	if s.collector == nil {
+		return nil, deviceUnavailable("sensor")
+	}
+	if _, ok := s.collector.GetSensor(req.SensorID); ok {
+		return nil, errors.New(errors.CodeInvalidParam, "sensor "+req.SensorID+" already exists")
+	}
+
+	info := req.Info()
+	if s.infoStore != nil {
+		if err := s.infoStore.Create(ctx, info); err != nil {
+			return nil, err
+		}
+	}
+	s.collector.RegisterSensor(info)
+	return info, nil
+}
+
+func (s *SensorService) UpdateSensor(ctx context.Context, sensorID string, req *model.SensorRequest) (*model.SensorInfo, error) {
+	if s.collector == nil {
+		return nil, deviceUnavailable("sensor")
+	}
+	existing, ok := s.collector.GetSensor(sensorID)
+	if !ok {
+		return nil, errors.New(errors.CodeNotFound, "sensor "+sensorID+" not found")
+	}
+
+	info := req.Info()
+	info.SensorID = sensorID
+	info.Status = existing.Status
+	info.Lifecycle = existing.Lifecycle
+	info.Maintenance = existing.Maintenance
+	info.CreatedAt = existing.CreatedAt
+	if info.Calibration == nil {
+		info.Calibration = existing.Calibration
+	}
+	if s.infoStore != nil {
+		if err := s.infoStore.Update(ctx, info); err != nil {
+			return nil, err
+		}
+	}
+	s.collector.RegisterSensor(info)
+	return info, nil
+}
+
+// Calibrate fits offset and scale through two reference points and applies
+// them to the sensor's readings from now on.
+func (s *SensorService) Calibrate(ctx context.Context, sensorID string, req *model.SensorCalibrateRequest) (*model.SensorInfo, error) {
+	if s.collector == nil {
+		return nil, deviceUnavailable("sensor")
+	}
+	existing, ok := s.collector.GetSensor(sensorID)
+	if !ok {
+		return nil, errors.New(errors.CodeNotFound, "sensor "+sensorID+" not found")
+	}
+	info := *existing
+	info.Calibration = req.Calibration()
+	if s.infoStore != nil {
+		if err := s.infoStore.Update(ctx, &info); err != nil {
+			return nil, err
+		}
+	}
+	s.collector.RegisterSensor(&info)
+	return &info, nil
+}
+
+// SetLifecycle moves a sensor to another lifecycle state. The collector
+// skips sensors in maintenance or decommissioned, and alerts are held back
+// during maintenance; a scheduled window takes effect at its start and the
+// sensor returns to service at its end.
+func (s *SensorService) SetLifecycle(ctx context.Context, sensorID string, req *model.SensorLifecycleRequest) (*model.SensorInfo, error) {
+	if s.collector == nil {
+		return nil, deviceUnavailable("sensor")
+	}
+	existing, ok := s.collector.GetSensor(sensorID)
+	if !ok {
+		return nil, errors.New(errors.CodeNotFound, "sensor "+sensorID+" not found")
+	}
+	info := *existing
+	info.Lifecycle = req.Lifecycle
+	info.Maintenance = req.Window(time.Now())
+	if s.infoStore != nil {
+		if err := s.infoStore.Update(ctx, &info); err != nil {
+			return nil, err
+		}
+	}
+	s.collector.RegisterSensor(&info)
+	s.notifyMaintenance(&info)
+	logger.Info("Sensor lifecycle changed",
+		zap.String("sensor_id", sensorID),
+		zap.String("lifecycle", string(info.Lifecycle)),
+	)
+	return &info, nil
+}
+
+func (s *SensorService) DeleteSensor(ctx context.Context, sensorID string) error {
+	if s.collector == nil {
+		return deviceUnavailable("sensor")
+	}
+	if _, ok := s.collector.GetSensor(sensorID); !ok {
+		return errors.New(errors.CodeNotFound, "sensor "+sensorID+" not found")
+	}
+
+	if s.infoStore != nil {
+		// sensors the driver reports are only stored once synced
+		if err := s.infoStore.Delete(ctx, sensorID); err != nil && !errors.IsCode(err, errors.CodeNotFound) {
+			return err
+		}
+	}
+	s.collector.UnregisterSensor(sensorID)
+	if observer, ok := s.observer.(MaintenanceObserver); ok {
+		observer.SetMaintenance(sensorID, nil)
+	}
+	return nil
+}
+
 func (s *SensorService) ListSensors(ctx context.Context, sensorType model.SensorType) ([]*model.SensorInfo, error) {
+	if s.collector == nil {
+		return nil, deviceUnavailable("sensor")
+	}
 	sensors := s.collector.GetAllSensors()
 
 	if sensorType != "" {
@@ -45,6 +251,14 @@ func (s *SensorService) ListSensors(ctx context.Context, sensorType model.Sensor
 	return sensors, nil
 }
 
+// Health reports the read health of every registered sensor.
+func (s *SensorService) Health(ctx context.Context) ([]*model.SensorHealth, error) {
+	if s.collector == nil {
+		return nil, deviceUnavailable("sensor")
+	}
+	return s.collector.Health(), nil
+}
+
 func (s *SensorService) GetSensorData(ctx context.Context, q *model.SensorDataQuery) ([]*model.SensorData, error) {
 	if s.dataStore == nil {
 		return []*model.SensorData{}, nil
@@ -53,7 +267,20 @@ func (s *SensorService) GetSensorData(ctx context.Context, q *model.SensorDataQu
 	return s.dataStore.Query(ctx, q)
 }
 
+// AggregateSensorData summarizes the stored readings per sensor over
+// windows of q.Window.
+func (s *SensorService) AggregateSensorData(ctx context.Context, q *model.SensorDataQuery) ([]*model.SensorAggregatedData, error) {
+	if s.dataStore == nil {
+		return []*model.SensorAggregatedData{}, nil
+	}
+
+	return s.dataStore.Aggregate(ctx, q)
+}
+
 func (s *SensorService) ReadSensor(ctx context.Context, sensorID string) (*model.SensorData, error) {
+	if s.collector == nil {
+		return nil, deviceUnavailable("sensor")
+	}
 	data, err := s.collector.ReadSensor(ctx, sensorID)
 	if err != nil {
 		return nil, errors.Wrap(errors.CodeSensorDataError, "failed to read sensor", err)
@@ -68,19 +295,46 @@ func (s *SensorService) ReadSensor(ctx context.Context, sensorID string) (*model
 	return data, nil
 }
 
+func (s *SensorService) ReadSensors(ctx context.Context, sensorIDs []string) ([]*model.SensorReadResult, error) {
+	if s.collector == nil {
+		return nil, deviceUnavailable("sensor")
+	}
+	results := s.collector.ReadSensors(ctx, sensorIDs)
+
+	if s.dataStore != nil {
+		points := make([]*model.SensorData, 0, len(results))
+		for _, r := range results {
+			if r.Data != nil {
+				points = append(points, r.Data)
+			}
+		}
+		// The readings were taken; failing to persist them should not keep
+		// them from the caller.
+		if len(points) > 0 {
+			if err := s.dataStore.WriteBatch(ctx, points); err != nil {
+				logger.Warn("Failed to persist sensor batch read",
+					zap.Int("points", len(points)),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+
+	return results, nil
+}
+
 func (s *SensorService) StartCollection(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.collector == nil {
+		return deviceUnavailable("sensor")
+	}
 	if s.running {
 		return errors.New(errors.CodeExperimentRunning, "collection already running")
 	}
 
-	s.collector.SetDataCallback(func(data *model.SensorData) {
-		if s.dataStore != nil {
-			s.dataStore.Write(context.Background(), data)
-		}
-	})
+	s.collector.SetDataCallback(s.handleData)
 
 	go func() {
 		s.collector.StartCollection(ctx)
@@ -90,10 +344,25 @@ func (s *SensorService) StartCollection(ctx context.Context) error {
 	return nil
 }
 
+// handleData stores, observes and publishes each reading the collector
+// takes or a replay emits; replayed readings are already stored.
+func (s *SensorService) handleData(data *model.SensorData) {
+	if s.dataStore != nil && !data.Replayed {
+		s.dataStore.Write(context.Background(), data)
+	}
+	if s.observer != nil {
+		s.observer.Observe(data)
+	}
+	publish(s.events, TopicSensor+"."+data.SensorID, data)
+}
+
 func (s *SensorService) StopCollection() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.collector == nil {
+		return
+	}
 	s.collector.StopCollection()
 	s.running = false
 }
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/device/sensor"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+// failingSensorStore rejects every write.
+type failingSensorStore struct {
+	batches int
+}
+
+func (f *failingSensorStore) Write(ctx context.Context, data *model.SensorData) error {
+	return errors.New(errors.CodeInfluxWriteError, "influx unavailable")
+}
+
+func (f *failingSensorStore) WriteBatch(ctx context.Context, dataPoints []*model.SensorData) error {
+	f.batches++
+	return errors.New(errors.CodeInfluxWriteError, "influx unavailable")
+}
+
+func (f *failingSensorStore) Query(ctx context.Context, q *model.SensorDataQuery) ([]*model.SensorData, error) {
+	return nil, nil
+}
+
+func (f *failingSensorStore) Aggregate(ctx context.Context, q *model.SensorDataQuery) ([]*model.SensorAggregatedData, error) {
+	return nil, nil
+}
+
+func TestReadSensorsPersistFailure(t *testing.T) {
+	collector := sensor.NewCollector(sensor.NewSimulator(), time.Second)
+	if err := collector.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	sensors := collector.GetAllSensors()
+	if len(sensors) == 0 {
+		t.Fatal("simulator has no sensors")
+	}
+
+	store := &failingSensorStore{}
+	svc := NewSensorService(collector, store)
+	results, err := svc.ReadSensors(context.Background(), []string{sensors[0].SensorID, "missing"})
+	if err != nil {
+		t.Fatalf("ReadSensors: %v", err)
+	}
+	if store.batches != 1 {
+		t.Errorf("WriteBatch called %d times, want 1", store.batches)
+	}
+	if len(results) != 2 || results[0].Data == nil || results[1].Error == "" {
+		t.Fatalf("results = %+v, want a reading and an error", results)
+	}
+}
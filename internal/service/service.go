@@ -3,67 +3,475 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
 	"time"
 
+	"isac-cran-system/internal/algorithm/array"
 	"isac-cran-system/internal/algorithm/beamforming"
+	"isac-cran-system/internal/algorithm/covariance"
 	"isac-cran-system/internal/algorithm/doa"
 	"isac-cran-system/internal/device/irs"
+	"isac-cran-system/internal/device/power"
+	"isac-cran-system/internal/device/usrp"
 	"isac-cran-system/internal/model"
 	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+	"isac-cran-system/pkg/pool"
+
+	"go.uber.org/zap"
 )
 
+// IRSService configures the IRS panels held by a Manager. Every call takes
+// the panel ID; an empty ID selects the default panel.
 type IRSService struct {
-	controller *irs.Controller
+	panels     *irs.Manager
+	gate       DeviceGate
+	geometries map[string]*array.Geometry
+	configs    IRSConfigStore
+	codebooks  CodebookStore
+	alerts     AlertPublisher
+
+	mu        sync.Mutex
+	sequences map[string]*irs.Sequencer
+	watchdogs map[string]*irs.Watchdog
+
+	firmware firmwareState
+}
+
+func NewIRSService(panels *irs.Manager) *IRSService {
+	return &IRSService{
+		panels:     panels,
+		geometries: make(map[string]*array.Geometry),
+		sequences:  make(map[string]*irs.Sequencer),
+		watchdogs:  make(map[string]*irs.Watchdog),
+	}
 }
 
-func NewIRSService(controller *irs.Controller) *IRSService {
-	return &IRSService{controller: controller}
+// IRSConfigStore keeps the history of applied IRS configurations.
+type IRSConfigStore interface {
+	Create(ctx context.Context, config *model.IRSConfig) error
+	GetByID(ctx context.Context, id int64) (*model.IRSConfig, error)
+	ListHistory(ctx context.Context, q *model.IRSHistoryQuery) ([]model.IRSConfig, int64, error)
 }
 
-func (s *IRSService) Configure(ctx context.Context, req *model.IRSConfigRequest) (*model.IRSConfig, error) {
-	if err := s.controller.Configure(ctx, req); err != nil {
+func (s *IRSService) SetDeviceGate(gate DeviceGate) {
+	s.gate = gate
+}
+
+func (s *IRSService) SetConfigStore(store IRSConfigStore) {
+	s.configs = store
+}
+
+// SetArrayGeometry sets the element layout of panel irsID used for optimal
+// phase shifts.
+func (s *IRSService) SetArrayGeometry(irsID string, g *array.Geometry) {
+	s.geometries[irsID] = g
+}
+
+func (s *IRSService) controller(irsID string) (*irs.Controller, error) {
+	return panelController(s.panels, irsID)
+}
+
+// panelController looks panel irsID up, the default panel for an empty ID.
+func panelController(panels *irs.Manager, irsID string) (*irs.Controller, error) {
+	if panels == nil {
+		return nil, deviceUnavailable("irs")
+	}
+	c, err := panels.Get(irsID)
+	switch err {
+	case nil:
+		return c, nil
+	case irs.ErrUnknownPanel:
+		return nil, errors.New(errors.CodeNotFound, "IRS panel "+irsID+" not found")
+	default:
+		return nil, deviceUnavailable("irs")
+	}
+}
+
+// Panels lists the IRS panels with their connection state and active
+// configuration.
+func (s *IRSService) Panels() []model.IRSPanel {
+	if s.panels == nil {
+		return []model.IRSPanel{}
+	}
+	ids := s.panels.IDs()
+	panels := make([]model.IRSPanel, len(ids))
+	for i, id := range ids {
+		c, _ := s.panels.Get(id)
+		healthy, _ := s.health(id)
+		panels[i] = model.IRSPanel{
+			ID:        id,
+			Default:   i == 0,
+			Connected: c.IsConnected(),
+			Healthy:   healthy,
+			Config:    c.GetCurrentConfig(),
+		}
+	}
+	return panels
+}
+
+func (s *IRSService) checkReservation(ctx context.Context) error {
+	return checkReservation(ctx, s.gate, model.ReservableDeviceIRS)
+}
+
+func checkReservation(ctx context.Context, gate DeviceGate, device string) error {
+	if gate == nil {
+		return nil
+	}
+	r, err := gate.Blocking(ctx, device)
+	if err != nil {
+		return err
+	}
+	if r != nil {
+		return reservedError(r)
+	}
+	return nil
+}
+
+func deviceUnavailable(device string) error {
+	return errors.New(errors.CodeServiceUnavailable, device+" device not available")
+}
+
+func (s *IRSService) Configure(ctx context.Context, irsID string, req *model.IRSConfigRequest) (*model.IRSConfig, error) {
+	controller, err := s.controller(irsID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkReservation(ctx); err != nil {
+		return nil, err
+	}
+	s.stopSequence(irsID)
+	if err := controller.Configure(ctx, req); err != nil {
 		return nil, err
 	}
 
-	config := s.controller.GetCurrentConfig()
+	config := controller.GetCurrentConfig()
+	s.record(ctx, irsID, config, nil)
 	return config, nil
 }
 
-func (s *IRSService) GetStatus(ctx context.Context) (*model.IRSStatus, error) {
-	return s.controller.GetStatus(ctx)
+func (s *IRSService) GetStatus(ctx context.Context, irsID string) (*model.IRSStatus, error) {
+	controller, err := s.controller(irsID)
+	if err != nil {
+		return nil, err
+	}
+	status, err := controller.GetStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// the driver may hand out a status it still holds
+	out := *status
+	out.Healthy, out.Alerts = s.health(irsID)
+	return &out, nil
+}
+
+func (s *IRSService) GetCurrentConfig(irsID string) (*model.IRSConfig, error) {
+	controller, err := s.controller(irsID)
+	if err != nil {
+		return nil, err
+	}
+	return controller.GetCurrentConfig(), nil
 }
 
-func (s *IRSService) GetCurrentConfig() *model.IRSConfig {
-	return s.controller.GetCurrentConfig()
+// ConfigureGroup sets the phases of one element group of the active
+// configuration.
+func (s *IRSService) ConfigureGroup(ctx context.Context, irsID, name string, req *model.IRSGroupRequest) (*model.IRSConfig, error) {
+	controller, err := s.controller(irsID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkReservation(ctx); err != nil {
+		return nil, err
+	}
+	s.stopSequence(irsID)
+	if err := controller.ConfigureGroup(ctx, name, req); err != nil {
+		return nil, err
+	}
+	config := controller.GetCurrentConfig()
+	s.record(ctx, irsID, config, nil)
+	return config, nil
 }
 
-func (s *IRSService) ApplyOptimalPhaseShifts(ctx context.Context, targetAngle float64) (*model.IRSConfig, error) {
-	config := s.controller.GetCurrentConfig()
+// ApplyOptimalPhaseShifts steers the surface towards req.TargetAngle. With
+// a group name only that group is steered; otherwise the whole surface is,
+// and the existing groups take their share of the new phases.
+func (s *IRSService) ApplyOptimalPhaseShifts(ctx context.Context, irsID string, req *model.IRSOptimalRequest) (*model.IRSConfig, error) {
+	controller, err := s.controller(irsID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkReservation(ctx); err != nil {
+		return nil, err
+	}
+	s.stopSequence(irsID)
+	config := controller.GetCurrentConfig()
 	if config == nil {
 		return nil, errors.New(errors.CodeIRSDeviceError, "no active IRS configuration")
 	}
 
-	optimizer := beamforming.NewWeightsCalculator(config.ElementCount, 0.5)
-	weights := optimizer.ComputeConjugateBeamforming(targetAngle)
-	phaseShifts := optimizer.ComputePhaseShifts(weights)
+	var phaseShifts []float64
+	if req.Method == model.IRSOptimalSDR || req.Method == model.IRSOptimalManifold {
+		phaseShifts, err = s.jointPhaseShifts(irsID, config.ElementCount, req)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		optimizer := beamforming.NewWeightsCalculator(config.ElementCount, array.HalfWavelength)
+		if g := s.geometries[s.panelID(irsID)]; g != nil && g.Len() == config.ElementCount {
+			optimizer = beamforming.NewGeometryWeightsCalculator(g)
+		}
+		weights := optimizer.ComputeConjugateBeamforming(req.TargetAngle)
+		// conjugate phases depend only on each element's own position, so a
+		// group's share of the full-surface solution steers the group alone
+		phaseShifts = optimizer.ComputePhaseShifts(weights)
+	}
+
+	group := req.Group
+	if group != "" {
+		for _, g := range config.Groups {
+			if g.Name != group {
+				continue
+			}
+			groupReq := &model.IRSGroupRequest{PhaseShifts: make([]float64, len(g.Elements)), Version: config.Version}
+			for j, e := range g.Elements {
+				groupReq.PhaseShifts[j] = phaseShifts[e]
+			}
+			if err := controller.ConfigureGroup(ctx, group, groupReq); err != nil {
+				return nil, err
+			}
+			config := controller.GetCurrentConfig()
+			s.record(ctx, irsID, config, nil)
+			return config, nil
+		}
+		return nil, errors.New(errors.CodeNotFound, "IRS element group "+group+" not found")
+	}
+
+	surface := surfaceRequest("optimal_"+time.Now().Format("20060102150405"), config, phaseShifts)
+	surface.Version = config.Version
+
+	if err := controller.Configure(ctx, surface); err != nil {
+		return nil, err
+	}
+
+	config = controller.GetCurrentConfig()
+	s.record(ctx, irsID, config, nil)
+	return config, nil
+}
+
+// jointPhaseShifts optimizes the phases of a panel of elementCount elements
+// for the cascaded channel of req with the joint beamforming method of
+// req. The panel quantizes them to its own resolution when they are
+// applied.
+func (s *IRSService) jointPhaseShifts(irsID string, elementCount int, req *model.IRSOptimalRequest) ([]float64, error) {
+	var params model.JointBeamformingParams
+	if req.Channel != nil {
+		params = *req.Channel
+	}
+	params.IRSElements = elementCount
+	params.UserAngle = req.TargetAngle
+	params.Method = req.Method
+	params.PhaseBits = 0
 
+	optimizer := beamforming.NewOptimizer(elementCount, 100, 0.001)
+	if g := s.geometries[s.panelID(irsID)]; g != nil && g.Len() == elementCount {
+		optimizer.SetGeometry(g)
+	}
+	result, err := optimizer.JointOptimize(&params)
+	if err != nil {
+		return nil, errors.Wrap(algorithmErrorCode(err), req.Method+" phase optimization failed", err)
+	}
+	return result.IRSPhases, nil
+}
+
+// surfaceRequest configures the whole surface with phaseShifts. The groups
+// of current are kept, taking their share of the new phases, when the element
+// count is unchanged.
+func surfaceRequest(name string, current *model.IRSConfig, phaseShifts []float64) *model.IRSConfigRequest {
 	req := &model.IRSConfigRequest{
-		Name:          "optimal_" + time.Now().Format("20060102150405"),
-		ElementCount:  config.ElementCount,
-		PhaseShifts:   phaseShifts,
-		FrequencyBand: config.FrequencyBand,
+		Name:         name,
+		ElementCount: len(phaseShifts),
+		PhaseShifts:  phaseShifts,
+	}
+	if current == nil {
+		return req
+	}
+	req.FrequencyBand = current.FrequencyBand
+	if current.ElementCount != len(phaseShifts) {
+		return req
+	}
+	req.Groups = make([]model.IRSElementGroup, len(current.Groups))
+	for i, g := range current.Groups {
+		req.Groups[i] = model.IRSElementGroup{Name: g.Name, Elements: g.Elements, PhaseShifts: make([]float64, len(g.Elements))}
+		for j, e := range g.Elements {
+			req.Groups[i].PhaseShifts[j] = phaseShifts[e]
+		}
+	}
+	return req
+}
+
+// History lists the configurations applied to a panel, newest first.
+func (s *IRSService) History(ctx context.Context, q *model.IRSHistoryQuery) ([]model.IRSConfig, int64, error) {
+	if s.configs == nil {
+		return nil, 0, errors.New(errors.CodeServiceUnavailable, "IRS configuration history not available")
+	}
+	if _, err := s.controller(q.IRSID); err != nil {
+		return nil, 0, err
+	}
+	q.IRSID = s.panelID(q.IRSID)
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 || q.PageSize > 100 {
+		q.PageSize = 20
+	}
+	return s.configs.ListHistory(ctx, q)
+}
+
+// Rollback re-applies configuration id from the history to the panel it was
+// applied to. The rollback is itself recorded as a new history entry.
+func (s *IRSService) Rollback(ctx context.Context, id int64, req *model.IRSRollbackRequest) (*model.IRSConfig, error) {
+	if s.configs == nil {
+		return nil, errors.New(errors.CodeServiceUnavailable, "IRS configuration history not available")
+	}
+	previous, err := s.configs.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	controller, err := s.controller(previous.IRSID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkReservation(ctx); err != nil {
+		return nil, err
+	}
+	s.stopSequence(previous.IRSID)
+	if err := controller.Configure(ctx, &model.IRSConfigRequest{
+		Name:          previous.Name,
+		ElementCount:  previous.ElementCount,
+		PhaseShifts:   previous.PhaseShifts,
+		Groups:        previous.Groups,
+		FrequencyBand: previous.FrequencyBand,
+		Version:       req.Version,
+		ExperimentID:  req.ExperimentID,
+	}); err != nil {
+		return nil, err
+	}
+
+	config := controller.GetCurrentConfig()
+	s.record(ctx, previous.IRSID, config, &previous.ID)
+	return config, nil
+}
+
+// record appends an applied configuration to the history. The panel already
+// runs the configuration, so a failed write is logged rather than returned.
+func (s *IRSService) record(ctx context.Context, irsID string, config *model.IRSConfig, rollbackOf *int64) {
+	if s.configs == nil || config == nil {
+		return
+	}
+	entry := *config
+	entry.ID = 0
+	entry.IRSID = s.panelID(irsID)
+	entry.RollbackOf = rollbackOf
+	if err := s.configs.Create(ctx, &entry); err != nil {
+		logger.Warn("Failed to record IRS configuration", zap.String("irs_id", entry.IRSID), zap.Error(err))
+	}
+}
+
+// StartSequence plays a phase sequence on a panel, replacing the one already
+// running there. Manual configuration of the panel stops the sequence.
+func (s *IRSService) StartSequence(ctx context.Context, irsID string, req *model.IRSSequenceRequest) (*model.IRSSequenceStatus, error) {
+	controller, err := s.controller(irsID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkReservation(ctx); err != nil {
+		return nil, err
 	}
 
-	if err := s.controller.Configure(ctx, req); err != nil {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.panelID(irsID)
+	if seq := s.sequences[id]; seq != nil {
+		seq.Stop()
+	}
+	// the sequence outlives the request that started it
+	seq, err := controller.StartSequence(context.Background(), req)
+	if err != nil {
+		return nil, err
+	}
+	s.sequences[id] = seq
+	status := seq.Status()
+	return &status, nil
+}
+
+// SequenceStatus reports the current or last sequence of a panel.
+// Constraints reports what panel irsID can realize and the requests that
+// asked for more.
+func (s *IRSService) Constraints(irsID string) (*model.IRSConstraintReport, error) {
+	controller, err := s.controller(irsID)
+	if err != nil {
+		return nil, err
+	}
+	report := controller.ConstraintReport()
+	report.IRSID = s.panelID(irsID)
+	return report, nil
+}
+
+func (s *IRSService) SequenceStatus(irsID string) (*model.IRSSequenceStatus, error) {
+	if _, err := s.controller(irsID); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seq := s.sequences[s.panelID(irsID)]
+	if seq == nil {
+		return &model.IRSSequenceStatus{}, nil
+	}
+	status := seq.Status()
+	return &status, nil
+}
+
+func (s *IRSService) StopSequence(irsID string) (*model.IRSSequenceStatus, error) {
+	if _, err := s.controller(irsID); err != nil {
 		return nil, err
 	}
+	s.stopSequence(irsID)
+	return s.SequenceStatus(irsID)
+}
+
+// StopSequences stops the sequences of every panel.
+func (s *IRSService) StopSequences() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, seq := range s.sequences {
+		seq.Stop()
+	}
+}
+
+func (s *IRSService) stopSequence(irsID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if seq := s.sequences[s.panelID(irsID)]; seq != nil {
+		seq.Stop()
+	}
+}
 
-	return s.controller.GetCurrentConfig(), nil
+func (s *IRSService) panelID(irsID string) string {
+	if irsID == "" && s.panels != nil {
+		return s.panels.DefaultID()
+	}
+	return irsID
 }
 
 type ChannelService struct {
-	receiver  ChannelReceiver
-	dataStore ChannelDataStore
+	receiver    ChannelReceiver
+	transmitter ChannelTransmitter
+	dataStore   ChannelDataStore
+	annotations ChannelAnnotationStore
+	gate        DeviceGate
+	events      EventPublisher
 }
 
 type ChannelReceiver interface {
@@ -71,6 +479,11 @@ type ChannelReceiver interface {
 	GetConfig() (sampleRate, centerFreq float64)
 }
 
+// ChannelTransmitter sends baseband bursts on the USRP TX chain.
+type ChannelTransmitter interface {
+	Transmit(ctx context.Context, samples []complex128) error
+}
+
 type ChannelDataStore interface {
 	Write(ctx context.Context, data *model.ChannelMeasurement) error
 	Query(ctx context.Context, q *model.ChannelDataQuery) ([]*model.ChannelMeasurement, error)
@@ -83,13 +496,86 @@ func NewChannelService(receiver ChannelReceiver, store ChannelDataStore) *Channe
 	}
 }
 
+func (s *ChannelService) SetTransmitter(tx ChannelTransmitter) {
+	s.transmitter = tx
+}
+
+func (s *ChannelService) SetDeviceGate(gate DeviceGate) {
+	s.gate = gate
+}
+
+// EmitProbe generates a pilot waveform and transmits it Repetitions times at
+// the receiver's sample rate and center frequency.
+func (s *ChannelService) EmitProbe(ctx context.Context, req *model.ProbeRequest) (*model.ProbeResult, error) {
+	if s.transmitter == nil || s.receiver == nil {
+		return nil, deviceUnavailable("usrp")
+	}
+	if err := checkReservation(ctx, s.gate, model.ReservableDeviceUSRP); err != nil {
+		return nil, err
+	}
+
+	amplitude := req.Amplitude
+	if amplitude == 0 {
+		amplitude = 1
+	}
+	repetitions := req.Repetitions
+	if repetitions == 0 {
+		repetitions = 1
+	}
+
+	sampleRate, centerFreq := s.receiver.GetConfig()
+	samples, err := usrp.GenerateWaveform(usrp.WaveformSpec{
+		Type:       string(req.Waveform),
+		Length:     req.Length,
+		Amplitude:  amplitude,
+		SampleRate: sampleRate,
+		ToneFreq:   req.ToneFreq,
+		Bandwidth:  req.Bandwidth,
+		Root:       req.Root,
+	})
+	if err != nil {
+		return nil, errors.NewWithDetail(errors.CodeInvalidParam, "invalid probe waveform", err.Error())
+	}
+
+	for i := 0; i < repetitions; i++ {
+		if err := s.transmitter.Transmit(ctx, samples); err != nil {
+			return nil, errors.Wrap(errors.CodeUSRPTransmitError, "failed to transmit probe", err)
+		}
+	}
+
+	logger.Info("Probe waveform transmitted",
+		zap.String("experiment_id", req.ExperimentID),
+		zap.String("waveform", string(req.Waveform)),
+		zap.Int("samples", len(samples)),
+		zap.Int("repetitions", repetitions),
+	)
+
+	return &model.ProbeResult{
+		ExperimentID: req.ExperimentID,
+		Waveform:     req.Waveform,
+		Samples:      len(samples),
+		Repetitions:  repetitions,
+		SampleRate:   sampleRate,
+		CenterFreq:   centerFreq,
+		Duration:     float64(len(samples)*repetitions) / sampleRate,
+		Timestamp:    time.Now(),
+	}, nil
+}
+
 func (s *ChannelService) CollectData(ctx context.Context, req *model.ChannelCollectRequest) (*model.ChannelMeasurement, error) {
+	if s.receiver == nil {
+		return nil, deviceUnavailable("usrp")
+	}
 	duration := time.Duration(req.Duration * float64(time.Second))
 
 	dataPoints, err := s.receiver.CollectData(ctx, duration)
 	if err != nil {
 		return nil, errors.Wrap(errors.CodeUSRPReceiveError, "failed to collect channel data", err)
 	}
+	if req.Impairments.Enabled() {
+		sampleRate, _ := s.receiver.GetConfig()
+		usrp.NewImpairer(*req.Impairments).Apply([][]model.ChannelDataPoint{dataPoints}, sampleRate)
+	}
 
 	amplitudes := make([]float64, len(dataPoints))
 	phases := make([]float64, len(dataPoints))
@@ -99,6 +585,13 @@ func (s *ChannelService) CollectData(ctx context.Context, req *model.ChannelColl
 	}
 
 	snr := s.calculateSNR(amplitudes)
+	adc := usrp.CaptureStats([][]model.ChannelDataPoint{dataPoints})
+	if adc.ClippedSamples > 0 {
+		logger.Warn("Channel capture clipped",
+			zap.String("experiment_id", req.ExperimentID),
+			zap.Float64("clip_rate", adc.ClipRate),
+		)
+	}
 
 	measurement := &model.ChannelMeasurement{
 		MeasurementID: generateMeasurementID(),
@@ -109,6 +602,7 @@ func (s *ChannelService) CollectData(ctx context.Context, req *model.ChannelColl
 		Phase:         phases,
 		SNR:           snr,
 		Timestamp:     time.Now(),
+		ADC:           adc,
 	}
 
 	if s.dataStore != nil {
@@ -116,6 +610,7 @@ func (s *ChannelService) CollectData(ctx context.Context, req *model.ChannelColl
 			return nil, err
 		}
 	}
+	publish(s.events, TopicChannel, measurement)
 
 	return measurement, nil
 }
@@ -129,11 +624,17 @@ func (s *ChannelService) QueryData(ctx context.Context, q *model.ChannelDataQuer
 	if err != nil {
 		return nil, 0, err
 	}
+	if err := s.attachAnnotations(ctx, q, data); err != nil {
+		return nil, 0, err
+	}
 
 	return data, int64(len(data)), nil
 }
 
 func (s *ChannelService) GetRealtimeData(ctx context.Context) ([]model.ChannelDataPoint, error) {
+	if s.receiver == nil {
+		return nil, deviceUnavailable("usrp")
+	}
 	data, err := s.receiver.CollectData(ctx, 100*time.Millisecond)
 	if err != nil {
 		return nil, err
@@ -162,21 +663,59 @@ func (s *ChannelService) calculateSNR(amplitudes []float64) float64 {
 	return 10 * (mean * mean / variance)
 }
 
+// generateMeasurementID is unique per measurement so that annotations can
+// refer to it.
 func generateMeasurementID() string {
-	return "meas_" + time.Now().Format("20060102150405")
+	now := time.Now()
+	return fmt.Sprintf("meas_%s%09d", now.Format("20060102150405"), now.Nanosecond())
 }
 
 type AlgorithmService struct {
 	beamformingOptimizer *beamforming.Optimizer
 	doaEstimator         *doa.Estimator
 	resultStore          AlgorithmResultStore
+	powerModel           *power.Model
+	powerMeter           PowerMeter
+	transmissionInterval time.Duration
+	gate                 DeviceGate
+	uow                  UnitOfWork
+	audit                AuditStore
+	snapshots            SnapshotSource
+	recordings           RecordingReader
+	archive              snapshotArchive
+	covariance           model.CovarianceOptions
+	online               onlineDOA
+	pool                 *pool.WorkerPool
+	timings              *runtimeModel
+	admission            *admissionControl
+	queue                experimentQueue
+	cache                *resultCache
+	events               EventPublisher
+	irsPanels            *irs.Manager
+	deviceService        string
+}
+
+// SnapshotSource provides synchronized multi-antenna captures for DOA.
+type SnapshotSource interface {
+	CollectMultiChannel(ctx context.Context, duration time.Duration) ([][]model.ChannelDataPoint, error)
+	ChannelCount() int
+	GetConfig() (sampleRate, centerFreq float64)
+}
+
+// RecordingReader reads snapshots back from SigMF recordings of the
+// receiver.
+type RecordingReader interface {
+	ReadSnapshots(ctx context.Context, id string, offset int64, length int) ([][]complex128, *model.Recording, error)
 }
 
 type AlgorithmResultStore interface {
 	Create(ctx context.Context, result *model.ExperimentResult) error
 	GetByExperimentID(ctx context.Context, experimentID string) (*model.ExperimentResult, error)
-	UpdateStatus(ctx context.Context, id int64, status model.ExperimentStatus, resultData string) error
-	List(ctx context.Context, algorithmType model.AlgorithmType, page, pageSize int) ([]model.ExperimentResult, int64, error)
+	UpdateStatus(ctx context.Context, result *model.ExperimentResult, status model.ExperimentStatus, resultData string) error
+	List(ctx context.Context, q *model.ExperimentQuery) ([]model.ExperimentResult, int64, error)
+	UpdatePowerEstimate(ctx context.Context, result *model.ExperimentResult, estimate string) error
+	UpdateEnergyReport(ctx context.Context, result *model.ExperimentResult, report string) error
+	ListWithEnergyReport(ctx context.Context, algorithmType model.AlgorithmType) ([]model.ExperimentResult, error)
 }
 
 func NewAlgorithmService(store AlgorithmResultStore) *AlgorithmService {
@@ -184,72 +723,453 @@ func NewAlgorithmService(store AlgorithmResultStore) *AlgorithmService {
 		beamformingOptimizer: beamforming.NewOptimizer(64, 100, 0.001),
 		doaEstimator:         doa.NewEstimator(64, 3, 1024, "MUSIC"),
 		resultStore:          store,
+		timings:              newRuntimeModel(),
+		admission:            newAdmissionControl(AdmissionConfig{}),
 	}
 }
 
-func (s *AlgorithmService) RunBeamforming(ctx context.Context, experimentID string, params *model.BeamformingParams) (*model.BeamformingResult, error) {
-	result := &model.ExperimentResult{
-		ExperimentID:  experimentID,
-		AlgorithmType: model.AlgorithmTypeBeamforming,
-		Status:        model.ExperimentStatusRunning,
+// SetArrayGeometry sets the IRS array used for beamforming and the receive
+// array used for DOA estimation.
+func (s *AlgorithmService) SetArrayGeometry(irsArray, rxArray *array.Geometry) {
+	s.beamformingOptimizer.SetGeometry(irsArray)
+	s.doaEstimator.SetGeometry(rxArray)
+	s.InvalidateCache("")
+}
+
+// SetCovariance sets the default covariance estimator for DOA runs.
+func (s *AlgorithmService) SetCovariance(opts model.CovarianceOptions) {
+	s.covariance = opts
+	s.doaEstimator.SetCovariance(opts)
+	s.InvalidateCache(model.AlgorithmTypeDOA)
+}
+
+// SetSmoothing sets the default spatial smoothing for DOA runs.
+func (s *AlgorithmService) SetSmoothing(opts model.SpatialSmoothingOptions) {
+	s.doaEstimator.SetSmoothing(opts)
+	s.InvalidateCache(model.AlgorithmTypeDOA)
+}
+
+func (s *AlgorithmService) SetWorkerPool(p *pool.WorkerPool) {
+	s.pool = p
+	s.doaEstimator.SetWorkerPool(p)
+}
+
+func (s *AlgorithmService) SetPowerModel(m *power.Model) {
+	s.powerModel = m
+}
+
+// maxQueueWait bounds how long a queued experiment waits for a reservation
+// to end before it is marked failed.
+const maxQueueWait = 24 * time.Hour
+
+// ExperimentQueuedError is returned when an experiment was accepted but has
+// to wait for another holder's reservation; it runs once the device is free.
+type ExperimentQueuedError struct {
+	Queued *model.QueuedExperiment
+}
+
+func (e *ExperimentQueuedError) Error() string {
+	if e.Queued.BlockedBy == nil {
+		return fmt.Sprintf("experiment %s queued for a heavy experiment slot", e.Queued.ExperimentID)
 	}
+	return fmt.Sprintf("experiment %s queued until %s is free", e.Queued.ExperimentID, e.Queued.Device)
+}
 
-	paramsJSON, _ := json.Marshal(params)
-	result.Parameters = string(paramsJSON)
+func (s *AlgorithmService) SetDeviceGate(gate DeviceGate) {
+	s.gate = gate
+}
 
-	if s.resultStore != nil {
-		if err := s.resultStore.Create(ctx, result); err != nil {
+// SetUnitOfWork makes the reservation check, the experiment row and its
+// audit entry one transaction when an experiment is admitted.
+func (s *AlgorithmService) SetUnitOfWork(uow UnitOfWork) {
+	s.uow = uow
+}
+
+func (s *AlgorithmService) SetAuditStore(store AuditStore) {
+	s.audit = store
+}
+
+func (s *AlgorithmService) SetSnapshotSource(src SnapshotSource) {
+	s.snapshots = src
+}
+
+// SetRecordings lets DOA runs estimate from recorded captures.
+func (s *AlgorithmService) SetRecordings(recordings RecordingReader) {
+	s.recordings = recordings
+}
+
+// SetDeviceService names the service of a split deployment that drives the
+// receiver, its recordings and the IRS panels. Runs that need them are then
+// rejected up front, since this process has none of them.
+func (s *AlgorithmService) SetDeviceService(name string) {
+	s.deviceService = name
+}
+
+// remoteDevices returns the error for a run of what when the devices it
+// needs are driven by another service, and nil when they are local.
+func (s *AlgorithmService) remoteDevices(what string) *errors.AppError {
+	if s.deviceService == "" {
+		return nil
+	}
+	return errors.New(errors.CodeInvalidParam, fmt.Sprintf(
+		"%s is not available in a split deployment, %s drives the receiver and IRS panels; run it on a server with role all", what, s.deviceService))
+}
+
+// RunBeamforming returns the cached result of an identical earlier run, if
+// there is one, without creating an experiment.
+func (s *AlgorithmService) RunBeamforming(ctx context.Context, experimentID string, params *model.BeamformingParams) (*model.BeamformingResult, error) {
+	if params.Mode == model.BeamformingModeEigen {
+		if err := s.remoteDevices("beamforming mode eigen"); err != nil {
 			return nil, err
 		}
 	}
-
-	bfResult, err := s.beamformingOptimizer.Optimize(params)
+	key := beamformingCacheKey(params)
+	if cached, ok := s.cachedBeamforming(ctx, key); ok {
+		return cached, nil
+	}
+	cost := s.beamformingCost(params)
+	result, release, err := s.admit(ctx, experimentID, params, cost, func(ctx context.Context, result *model.ExperimentResult) error {
+		_, err := s.runBeamforming(ctx, result, params, cost)
+		return err
+	})
 	if err != nil {
-		if s.resultStore != nil {
-			s.resultStore.UpdateStatus(ctx, result.ID, model.ExperimentStatusFailed, "")
+		return nil, err
+	}
+	defer release()
+	bfResult, err := s.runBeamforming(ctx, result, params, cost)
+	if err == nil {
+		s.cache.store(key, experimentID, bfResult)
+	}
+	return bfResult, err
+}
+
+func (s *AlgorithmService) runBeamforming(ctx context.Context, result *model.ExperimentResult, params *model.BeamformingParams, cost *experimentCost) (*model.BeamformingResult, error) {
+	measurement := s.beginEnergyMeasurement(ctx)
+
+	var bfResult *model.BeamformingResult
+	var err error
+	switch params.Mode {
+	case "", model.BeamformingModeTarget:
+		bfResult, err = s.beamformingOptimizer.Optimize(params)
+	case model.BeamformingModeEigen:
+		var R [][]complex128
+		R, err = s.measureCovariance(ctx, params.SnapshotLength, params.Covariance)
+		if err == nil {
+			bfResult, err = s.beamformingOptimizer.EigenBeamform(R, params)
 		}
-		return nil, errors.Wrap(errors.CodeAlgorithmRunError, "beamforming optimization failed", err)
+	case model.BeamformingModeWMMSE:
+		bfResult, err = s.beamformingOptimizer.WMMSE(params)
+	case model.BeamformingModeOFDM:
+		bfResult, err = s.beamformingOptimizer.OFDM(params)
+	case model.BeamformingModeRobust:
+		bfResult, err = s.beamformingOptimizer.Robust(params)
+	default:
+		err = model.NewValidationErrorf("unsupported beamforming mode: %s", params.Mode)
+	}
+	if err != nil {
+		s.setStatus(ctx, result, model.ExperimentStatusFailed, "")
+		return nil, errors.Wrap(algorithmErrorCode(err), "beamforming optimization failed", err)
 	}
 
-	resultJSON, _ := json.Marshal(bfResult)
-	if s.resultStore != nil {
-		s.resultStore.UpdateStatus(ctx, result.ID, model.ExperimentStatusCompleted, string(resultJSON))
+	if err := s.completeResult(ctx, result, bfResult); err != nil {
+		return nil, err
 	}
+	s.timings.observe(cost, time.Since(measurement.startTime))
+	// the weights are computed, not applied: the panel holds its phases
+	s.recordEnergy(ctx, result, measurement, energyUsage{
+		variant:     string(bfResult.Objective),
+		irsElements: s.activeIRSElements(),
+		bitRate:     bfResult.EnergyEfficiency * bfResult.TotalPower,
+	})
 
 	return bfResult, nil
 }
 
+// RunDOA, like RunBeamforming, answers repeated deterministic runs from the
+// result cache.
 func (s *AlgorithmService) RunDOA(ctx context.Context, experimentID string, params *model.DOAParams) (*model.DOAResult, error) {
-	result := &model.ExperimentResult{
+	if params.Captured() {
+		if err := s.remoteDevices("DOA source " + params.Source); err != nil {
+			return nil, err
+		}
+	}
+	key := doaCacheKey(params)
+	if cached, ok := s.cachedDOA(ctx, key); ok {
+		return cached, nil
+	}
+	// an invalid grid fails the run itself, with the usual error
+	cost, _ := s.doaCost(params)
+	result, release, err := s.admit(ctx, experimentID, params, cost, func(ctx context.Context, result *model.ExperimentResult) error {
+		_, err := s.runDOA(ctx, result, params, cost)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	doaResult, err := s.runDOA(ctx, result, params, cost)
+	if err == nil {
+		s.cache.store(key, experimentID, doaResult)
+	}
+	return doaResult, err
+}
+
+func (s *AlgorithmService) runDOA(ctx context.Context, result *model.ExperimentResult, params *model.DOAParams, cost *experimentCost) (*model.DOAResult, error) {
+	measurement := s.beginEnergyMeasurement(ctx)
+
+	var X [][]complex128
+	var adc *model.ADCStats
+	var err error
+	switch params.Source {
+	case model.DOASourceUSRP:
+		X, adc, err = s.captureSnapshots(ctx, params.SnapshotLength)
+		if err == nil {
+			s.impairSnapshots(X, params.Impairments, s.snapshotSampleRate(), nil)
+			sampleRate, centerFreq := s.snapshots.GetConfig()
+			params = withBand(params, sampleRate, centerFreq)
+		}
+	case model.DOASourceRecording:
+		var rec *model.Recording
+		X, rec, err = s.readRecording(ctx, params)
+		if err == nil {
+			s.impairSnapshots(X, params.Impairments, rec.SampleRate, params.Seed)
+			params = withBand(params, rec.SampleRate, rec.CenterFreq)
+		}
+	default:
+		X = s.doaEstimator.SynthesizeSnapshots(generateTestSignal(params.SnapshotLength), params)
+		s.impairSnapshots(X, params.Impairments, syntheticSampleRate, params.Seed)
+	}
+
+	var doaResult *model.DOAResult
+	if err == nil {
+		doaResult, err = s.doaEstimator.EstimateSnapshots(X, params)
+	}
+	if err != nil {
+		s.setStatus(ctx, result, model.ExperimentStatusFailed, "")
+		return nil, errors.Wrap(algorithmErrorCode(err), "DOA estimation failed", err)
+	}
+	doaResult.ADC = adc
+	if s.archive.always || params.StoreSnapshots {
+		doaResult.Snapshots = s.archiveSnapshots(ctx, result.ExperimentID, X)
+	}
+
+	if err := s.completeResult(ctx, result, doaResult); err != nil {
+		return nil, err
+	}
+	s.timings.observe(cost, time.Since(measurement.startTime))
+	s.recordEnergy(ctx, result, measurement, energyUsage{variant: params.Method})
+
+	return doaResult, nil
+}
+
+// syntheticSampleRate is the nominal rate used to apply frequency offset and
+// phase noise to synthesized snapshots, which have no radio behind them.
+const syntheticSampleRate = 1e6
+
+// defaultCovarianceSnapshots is the capture length for a measured covariance
+// when the request does not set one.
+const defaultCovarianceSnapshots = 1024
+
+func (s *AlgorithmService) snapshotSampleRate() float64 {
+	sampleRate, _ := s.snapshots.GetConfig()
+	return sampleRate
+}
+
+// withBand fills in the wideband sample rate and center frequency a run on
+// captured snapshots leaves out from those they were captured at.
+func withBand(params *model.DOAParams, sampleRate, centerFreq float64) *model.DOAParams {
+	if params.Wideband == nil || (params.Wideband.SampleRate > 0 && params.Wideband.CenterFrequency > 0) {
+		return params
+	}
+	wideband := *params.Wideband
+	if wideband.SampleRate == 0 {
+		wideband.SampleRate = sampleRate
+	}
+	if wideband.CenterFrequency == 0 {
+		wideband.CenterFrequency = centerFreq
+	}
+	filled := *params
+	filled.Wideband = &wideband
+	return &filled
+}
+
+// readRecording reads the snapshots of a recording source.
+func (s *AlgorithmService) readRecording(ctx context.Context, params *model.DOAParams) ([][]complex128, *model.Recording, error) {
+	if s.recordings == nil {
+		return nil, nil, errors.New(errors.CodeServiceUnavailable, "recordings are not available")
+	}
+	X, rec, err := s.recordings.ReadSnapshots(ctx, params.Recording, params.RecordingOffset, params.SnapshotLength)
+	if errors.IsCode(err, errors.CodeNotFound) || errors.IsCode(err, errors.CodeInvalidParam) {
+		// the request names a recording it cannot use
+		return nil, nil, &model.ValidationError{Field: "recording", Message: err.Error()}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(X) < 2 {
+		return nil, nil, &model.ValidationError{Field: "recording", Message: "recording source needs a multi-channel recording"}
+	}
+	return X, rec, nil
+}
+
+func (s *AlgorithmService) impairSnapshots(X [][]complex128, impairments *model.RFImpairments, sampleRate float64, seed *int64) {
+	if !impairments.Enabled() {
+		return
+	}
+	impairer := usrp.NewImpairer(*impairments)
+	if seed != nil {
+		impairer = usrp.NewSeededImpairer(*impairments, *seed)
+	}
+	impairer.ApplyIQ(X, sampleRate)
+}
+
+// captureSnapshots reads length samples from every receiver channel and
+// returns them as an antenna-by-snapshot matrix with the capture's clipping
+// statistics.
+func (s *AlgorithmService) captureSnapshots(ctx context.Context, length int) ([][]complex128, *model.ADCStats, error) {
+	if s.snapshots == nil {
+		return nil, nil, deviceUnavailable("usrp")
+	}
+	if s.snapshots.ChannelCount() < 2 {
+		return nil, nil, &model.ValidationError{Field: "source", Message: "usrp source needs a multi-channel receiver"}
+	}
+
+	sampleRate, _ := s.snapshots.GetConfig()
+	channels, err := s.snapshots.CollectMultiChannel(ctx, captureDuration(length, sampleRate))
+	if err != nil {
+		return nil, nil, errors.Wrap(errors.CodeUSRPReceiveError, "failed to capture snapshots", err)
+	}
+
+	X := make([][]complex128, len(channels))
+	for m, samples := range channels {
+		if len(samples) < length {
+			return nil, nil, errors.NewWithDetail(errors.CodeUSRPReceiveError, "capture is shorter than the snapshot length",
+				fmt.Sprintf("%d of %d samples on channel %d", len(samples), length, m))
+		}
+		samples = samples[:length]
+		X[m] = make([]complex128, len(samples))
+		for t, p := range samples {
+			X[m][t] = complex(p.I, p.Q)
+		}
+	}
+	return X, usrp.CaptureStats(channels), nil
+}
+
+// captureDuration asks for one sample more than length, rounded up, so
+// that drivers, which truncate duration times the sample rate, return at
+// least length samples.
+func captureDuration(length int, sampleRate float64) time.Duration {
+	return time.Duration(math.Ceil(float64(length+1) / sampleRate * float64(time.Second)))
+}
+
+// measureCovariance estimates the spatial covariance of a live capture with
+// the configured estimator unless opts overrides it.
+func (s *AlgorithmService) measureCovariance(ctx context.Context, length int, opts *model.CovarianceOptions) ([][]complex128, error) {
+	if length <= 0 {
+		length = defaultCovarianceSnapshots
+	}
+	X, _, err := s.captureSnapshots(ctx, length)
+	if err != nil {
+		return nil, err
+	}
+	estimator := s.covariance
+	if opts != nil {
+		estimator = *opts
+	}
+	return covariance.Estimate(X, estimator)
+}
+
+// admit records the experiment and decides whether it can run now. An
+// experiment over the duration budget, or heavy while the heavy slots and
+// the queue are full, is rejected with an *ExperimentRejectedError. If the
+// device is reserved by someone else, or the experiment is heavy and every
+// slot is taken, the experiment is stored as pending and queued behind the
+// experiments already waiting for the device, and an *ExperimentQueuedError
+// is returned.
+// Otherwise the caller runs the experiment and calls release when done.
+func (s *AlgorithmService) admit(ctx context.Context, experimentID string, params interface{}, cost *experimentCost, run func(context.Context, *model.ExperimentResult) error) (result *model.ExperimentResult, release func(), err error) {
+	algorithmType, device := cost.algorithmType, cost.estimate.Devices[0]
+	estimate := s.timings.predict(cost)
+	if rejected := s.admission.check(estimate); rejected != nil {
+		return nil, nil, rejected
+	}
+	heavy := s.admission.heavy(estimate)
+	slot := heavy && s.admission.tryAcquire()
+	waitSlot := heavy && !slot
+	if waitSlot {
+		if rejected := s.admission.enqueue(estimate); rejected != nil {
+			return nil, nil, rejected
+		}
+	}
+
+	paramsJSON, _ := json.Marshal(params)
+	result = &model.ExperimentResult{
 		ExperimentID:  experimentID,
-		AlgorithmType: model.AlgorithmTypeDOA,
+		AlgorithmType: algorithmType,
 		Status:        model.ExperimentStatusRunning,
+		Parameters:    string(paramsJSON),
+		Holder:        HolderFromContext(ctx),
 	}
 
-	paramsJSON, _ := json.Marshal(params)
-	result.Parameters = string(paramsJSON)
+	var blocking *model.Reservation
+	err = transact(ctx, s.uow, func(ctx context.Context) error {
+		if s.gate != nil {
+			r, err := s.gate.Blocking(ctx, device)
+			if err != nil {
+				return err
+			}
+			blocking = r
+		}
+		if blocking != nil || waitSlot {
+			result.Status = model.ExperimentStatusPending
+		}
 
-	if s.resultStore != nil {
+		if s.resultStore == nil {
+			return nil
+		}
 		if err := s.resultStore.Create(ctx, result); err != nil {
-			return nil, err
+			return err
 		}
-	}
-
-	data := generateTestSignal(params.SnapshotLength)
-	doaResult, err := s.doaEstimator.Estimate(data, params)
+		return audit(ctx, s.audit, model.AuditActionCreate, model.AuditResourceExperiment, experimentID,
+			fmt.Sprintf("%s on %s, queued=%t", algorithmType, device, result.Status == model.ExperimentStatusPending))
+	})
 	if err != nil {
-		if s.resultStore != nil {
-			s.resultStore.UpdateStatus(ctx, result.ID, model.ExperimentStatusFailed, "")
+		if slot {
+			s.admission.release()
+		} else if waitSlot {
+			s.admission.dequeue()
 		}
-		return nil, errors.Wrap(errors.CodeAlgorithmRunError, "DOA estimation failed", err)
+		return nil, nil, err
 	}
+	s.publishStatus(result, result.Status)
 
-	resultJSON, _ := json.Marshal(doaResult)
-	if s.resultStore != nil {
-		s.resultStore.UpdateStatus(ctx, result.ID, model.ExperimentStatusCompleted, string(resultJSON))
+	if blocking == nil && !waitSlot {
+		if slot {
+			return result, s.admission.release, nil
+		}
+		return result, func() {}, nil
+	}
+	if slot {
+		// no point holding a slot while the reservation lasts
+		s.admission.release()
 	}
 
-	return doaResult, nil
+	s.queue.push(s, &queuedRun{
+		result:   result,
+		holder:   result.Holder,
+		device:   device,
+		heavy:    heavy,
+		waitSlot: waitSlot,
+		deadline: time.Now().Add(maxQueueWait),
+		run:      run,
+	})
+
+	return nil, nil, &ExperimentQueuedError{Queued: &model.QueuedExperiment{
+		ExperimentID: experimentID,
+		Device:       device,
+		BlockedBy:    blocking,
+		Estimate:     estimate,
+	}}
 }
 
 func (s *AlgorithmService) GetResult(ctx context.Context, experimentID string) (*model.ExperimentResult, error) {
@@ -260,12 +1180,61 @@ func (s *AlgorithmService) GetResult(ctx context.Context, experimentID string) (
 	return s.resultStore.GetByExperimentID(ctx, experimentID)
 }
 
-func (s *AlgorithmService) ListResults(ctx context.Context, algorithmType model.AlgorithmType, page, pageSize int) ([]model.ExperimentResult, int64, error) {
+// GetResultAs returns the decoded result of a completed experiment. Stored
+// data that fails its schema is reported rather than returned half-filled.
+func GetResultAs[T model.ResultPayload](ctx context.Context, s *AlgorithmService, experimentID string) (T, error) {
+	var zero T
+	result, err := s.GetResult(ctx, experimentID)
+	if err != nil {
+		return zero, err
+	}
+	if result.ResultData == nil {
+		return zero, errors.New(errors.CodeNotFound, "experiment has no result")
+	}
+	payload, err := model.DecodeResult(result.AlgorithmType, *result.ResultData)
+	if err != nil {
+		return zero, errors.Wrap(errors.CodeInternalError, "stored experiment result is invalid", err)
+	}
+	typed, ok := payload.(T)
+	if !ok {
+		return zero, errors.New(errors.CodeInvalidParam, fmt.Sprintf("experiment %s is a %s experiment", experimentID, result.AlgorithmType))
+	}
+	return typed, nil
+}
+
+// completeResult validates the payload against the experiment's result
+// schema before storing it; a payload that fails marks the experiment
+// failed. A result that cannot be stored, e.g. because another controller
+// updated the experiment first, is reported as an error.
+func (s *AlgorithmService) completeResult(ctx context.Context, result *model.ExperimentResult, payload model.ResultPayload) error {
+	data, err := model.EncodeResult(result.AlgorithmType, payload)
+	if err != nil {
+		s.setStatus(ctx, result, model.ExperimentStatusFailed, "")
+		logger.Error("Experiment result rejected", zap.String("experiment_id", result.ExperimentID), zap.Error(err))
+		return errors.Wrap(errors.CodeInternalError, "experiment result failed validation", err)
+	}
+	return s.setStatus(ctx, result, model.ExperimentStatusCompleted, data)
+}
+
+func (s *AlgorithmService) ListResults(ctx context.Context, q *model.ExperimentQuery) ([]model.ExperimentResult, int64, error) {
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 || q.PageSize > 100 {
+		q.PageSize = 20
+	}
 	if s.resultStore == nil {
 		return []model.ExperimentResult{}, 0, nil
 	}
 
-	return s.resultStore.List(ctx, algorithmType, page, pageSize)
+	return s.resultStore.List(ctx, q)
+}
+
+func algorithmErrorCode(err error) errors.Code {
+	if model.IsValidationError(err) {
+		return errors.CodeInvalidParam
+	}
+	return errors.CodeAlgorithmRunError
 }
 
 func generateTestSignal(length int) []complex128 {
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"isac-cran-system/internal/algorithm/array"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+)
+
+// truncatingSource returns duration times the sample rate samples per
+// channel, truncated like the USRP drivers, up to limit.
+type truncatingSource struct {
+	sampleRate float64
+	limit      int
+}
+
+func (s *truncatingSource) CollectMultiChannel(ctx context.Context, duration time.Duration) ([][]model.ChannelDataPoint, error) {
+	n := int(duration.Seconds() * s.sampleRate)
+	if s.limit > 0 && n > s.limit {
+		n = s.limit
+	}
+	channels := make([][]model.ChannelDataPoint, 4)
+	for m := range channels {
+		channels[m] = make([]model.ChannelDataPoint, n)
+		for i := range channels[m] {
+			channels[m][i] = model.ChannelDataPoint{Index: i, I: float64(i), Q: float64(m)}
+		}
+	}
+	return channels, nil
+}
+
+func (s *truncatingSource) ChannelCount() int { return 4 }
+
+func (s *truncatingSource) GetConfig() (sampleRate, centerFreq float64) {
+	return s.sampleRate, 3.5e9
+}
+
+func TestCaptureSnapshotsLength(t *testing.T) {
+	for _, tc := range []struct {
+		length     int
+		sampleRate float64
+	}{
+		{1024, 1e6},
+		{1000, 3e6},
+		{333, 30.72e6},
+		{4096, 61.44e6},
+		{7, 1e3},
+	} {
+		svc := NewAlgorithmService(nil)
+		svc.SetSnapshotSource(&truncatingSource{sampleRate: tc.sampleRate})
+		X, _, err := svc.captureSnapshots(context.Background(), tc.length)
+		if err != nil {
+			t.Fatalf("%d samples at %g S/s: %v", tc.length, tc.sampleRate, err)
+		}
+		for m := range X {
+			if len(X[m]) != tc.length {
+				t.Fatalf("%d samples at %g S/s: channel %d has %d", tc.length, tc.sampleRate, m, len(X[m]))
+			}
+		}
+		if X[2][5] != complex(5, 2) {
+			t.Errorf("X[2][5] = %v, want (5+2i)", X[2][5])
+		}
+	}
+}
+
+func TestCaptureSnapshotsShort(t *testing.T) {
+	svc := NewAlgorithmService(nil)
+	svc.SetSnapshotSource(&truncatingSource{sampleRate: 1e6, limit: 500})
+	if _, _, err := svc.captureSnapshots(context.Background(), 1024); !errors.IsCode(err, errors.CodeUSRPReceiveError) {
+		t.Fatalf("error = %v, want CodeUSRPReceiveError", err)
+	}
+}
+
+// planeWaveSource receives one source at azimuth on a half-wavelength ULA,
+// 10 dB above the noise per antenna.
+type planeWaveSource struct {
+	azimuth float64
+	rand    *rand.Rand
+}
+
+func (s *planeWaveSource) CollectMultiChannel(ctx context.Context, duration time.Duration) ([][]model.ChannelDataPoint, error) {
+	a := array.NewULA(4, array.HalfWavelength).SteeringVector(s.azimuth)
+	n := int(duration.Seconds() * 1e5)
+	channels := make([][]model.ChannelDataPoint, len(a))
+	for m := range channels {
+		channels[m] = make([]model.ChannelDataPoint, n)
+	}
+	for t := 0; t < n; t++ {
+		symbol := complex(s.rand.NormFloat64(), s.rand.NormFloat64()) * complex(math.Sqrt(5), 0)
+		for m := range channels {
+			x := a[m]*symbol + complex(s.rand.NormFloat64(), s.rand.NormFloat64())/complex(math.Sqrt2, 0)
+			channels[m][t] = model.ChannelDataPoint{Index: t, I: real(x), Q: imag(x)}
+		}
+	}
+	return channels, nil
+}
+
+func (s *planeWaveSource) ChannelCount() int { return 4 }
+
+func (s *planeWaveSource) GetConfig() (sampleRate, centerFreq float64) {
+	return 1e5, 3.5e9
+}
+
+func TestRunDOARecording(t *testing.T) {
+	ctx := context.Background()
+	recordings := NewRecordingService(&planeWaveSource{azimuth: 20 * math.Pi / 180, rand: rand.New(rand.NewSource(1))}, t.TempDir(), time.Minute)
+	rec, err := recordings.Start(ctx, &model.RecordingRequest{ExperimentID: "rec", MaxDuration: 0.15})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the recording ends on its own after a chunk or two
+	for deadline := time.Now().Add(5 * time.Second); rec.Status == model.RecordingStatusRecording && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		listed, err := recordings.List(ctx)
+		if err != nil || len(listed) != 1 {
+			t.Fatalf("recordings %v, error %v", listed, err)
+		}
+		rec = &listed[0]
+	}
+	if rec.Status != model.RecordingStatusCompleted || rec.Samples < 1000 {
+		t.Fatalf("recording %+v, want a completed recording of at least 1000 samples", rec)
+	}
+
+	svc := NewAlgorithmService(nil)
+	svc.SetRecordings(recordings)
+	params := &model.DOAParams{
+		NumSources:      1,
+		SnapshotLength:  512,
+		Method:          "MUSIC",
+		SearchRangeMin:  -90,
+		SearchRangeMax:  90,
+		SearchStep:      0.1,
+		Source:          model.DOASourceRecording,
+		Recording:       rec.ID,
+		RecordingOffset: 100,
+	}
+	result, err := svc.RunDOA(ctx, "doa_recording", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.EstimatedAngles) != 1 || math.Abs(result.EstimatedAngles[0]*180/math.Pi-20) > 1 {
+		t.Errorf("estimated angles %v rad, want 20°", result.EstimatedAngles)
+	}
+	if len(result.CRB) != 1 {
+		t.Errorf("CRB %v, want one bound", result.CRB)
+	}
+
+	past := *params
+	past.RecordingOffset = rec.Samples
+	if _, err := svc.RunDOA(ctx, "doa_past_end", &past); !errors.IsCode(err, errors.CodeInvalidParam) {
+		t.Errorf("offset past the end: error = %v, want invalid param", err)
+	}
+	missing := *params
+	missing.Recording = "rec_missing"
+	if _, err := svc.RunDOA(ctx, "doa_missing", &missing); !errors.IsCode(err, errors.CodeInvalidParam) {
+		t.Errorf("unknown recording: error = %v, want invalid param", err)
+	}
+}
+
+func TestRemoteDevicesRejectCaptures(t *testing.T) {
+	ctx := context.Background()
+	svc := NewAlgorithmService(nil)
+	svc.SetDeviceService("device-service")
+
+	params := &model.DOAParams{ElementCount: 4, NumSources: 1, SnapshotLength: 256, Method: "MUSIC",
+		SearchRangeMin: -90, SearchRangeMax: 90, SearchStep: 1, Source: model.DOASourceUSRP}
+	if _, err := svc.RunDOA(ctx, "doa_usrp", params); !errors.IsCode(err, errors.CodeInvalidParam) {
+		t.Errorf("usrp source: error = %v, want invalid param", err)
+	}
+	recorded := *params
+	recorded.Source, recorded.Recording = model.DOASourceRecording, "rec"
+	if _, err := svc.RunDOA(ctx, "doa_recording", &recorded); !errors.IsCode(err, errors.CodeInvalidParam) {
+		t.Errorf("recording source: error = %v, want invalid param", err)
+	}
+	eigen := &model.BeamformingParams{ElementCount: 16, Mode: model.BeamformingModeEigen}
+	if _, err := svc.RunBeamforming(ctx, "bf_eigen", eigen); !errors.IsCode(err, errors.CodeInvalidParam) {
+		t.Errorf("eigen beamforming: error = %v, want invalid param", err)
+	}
+	if _, err := svc.RunIRSImpact(ctx, "impact", &model.IRSImpactParams{Trials: 2}); !errors.IsCode(err, errors.CodeInvalidParam) {
+		t.Errorf("IRS impact: error = %v, want invalid param", err)
+	}
+	if dry := svc.DryRunDOA(ctx, "doa_usrp", params); dry.Valid {
+		t.Error("dry run of a usrp source passed")
+	}
+
+	synthetic := *params
+	synthetic.Source = model.DOASourceSynthetic
+	if _, err := svc.RunDOA(ctx, "doa_synthetic", &synthetic); err != nil {
+		t.Errorf("synthetic source: %v", err)
+	}
+}
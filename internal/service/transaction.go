@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+
+	"isac-cran-system/internal/model"
+)
+
+// UnitOfWork runs fn atomically. Store calls made with the context passed to
+// fn take part in the same transaction.
+type UnitOfWork interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type AuditStore interface {
+	Create(ctx context.Context, entry *model.AuditLog) error
+}
+
+// transact runs fn in a transaction of uow, or directly without one.
+func transact(ctx context.Context, uow UnitOfWork, fn func(ctx context.Context) error) error {
+	if uow == nil {
+		return fn(ctx)
+	}
+	return uow.Transaction(ctx, fn)
+}
+
+func audit(ctx context.Context, store AuditStore, action, resource, resourceID, detail string) error {
+	if store == nil {
+		return nil
+	}
+	return store.Create(ctx, &model.AuditLog{
+		Action:     action,
+		Resource:   resource,
+		ResourceID: resourceID,
+		Actor:      HolderFromContext(ctx),
+		Detail:     detail,
+	})
+}
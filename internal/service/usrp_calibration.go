@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"isac-cran-system/internal/device/usrp"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// SetCalibrationFile sets where the RX calibration is persisted; an empty
+// path keeps it in memory only.
+func (s *USRPService) SetCalibrationFile(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calibrationFile = path
+}
+
+// LoadCalibration applies the persisted calibration if it was measured on
+// the bound device. A missing file is not an error.
+func (s *USRPService) LoadCalibration() error {
+	if s.receiver == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadCalibration()
+}
+
+func (s *USRPService) loadCalibration() error {
+	if s.calibrationFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.calibrationFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var cal model.RXCalibration
+	if err := json.Unmarshal(data, &cal); err != nil {
+		return err
+	}
+
+	if cal.Serial != s.bound {
+		logger.Warn("USRP calibration belongs to another device, not applied",
+			zap.String("calibration_serial", cal.Serial),
+			zap.String("bound_serial", s.bound),
+		)
+		return nil
+	}
+	if _, centerFreq := s.receiver.GetConfig(); math.Abs(centerFreq-cal.CenterFreq) > cal.SampleRate {
+		logger.Warn("USRP calibration was measured at another frequency",
+			zap.Float64("calibration_freq", cal.CenterFreq),
+			zap.Float64("center_freq", centerFreq),
+		)
+	}
+	if err := s.receiver.SetCalibration(&cal); err != nil {
+		return err
+	}
+	logger.Info("USRP calibration loaded", zap.Time("measured_at", cal.MeasuredAt))
+	return nil
+}
+
+// Calibrate measures the channel offsets from a known tone, applies the
+// correction to every later capture and persists it.
+func (s *USRPService) Calibrate(ctx context.Context, req *model.RXCalibrationRequest) (*model.RXCalibration, error) {
+	if s.receiver == nil {
+		return nil, deviceUnavailable("usrp")
+	}
+	if err := checkReservation(ctx, s.gate, model.ReservableDeviceUSRP); err != nil {
+		return nil, err
+	}
+
+	toneFreq := req.ToneFreq
+	if toneFreq == 0 {
+		toneFreq = usrp.DefaultCalibrationToneFreq
+	}
+	duration := usrp.DefaultCalibrationDuration
+	if req.Duration > 0 {
+		duration = time.Duration(req.Duration * float64(time.Second))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cal, err := s.receiver.MeasureCalibration(ctx, toneFreq, req.Amplitude, duration)
+	if err != nil {
+		return nil, calibrationError(err)
+	}
+	cal.Serial = s.bound
+	if err := s.receiver.SetCalibration(cal); err != nil {
+		return nil, calibrationError(err)
+	}
+	if err := s.saveCalibration(cal); err != nil {
+		logger.Warn("Failed to persist USRP calibration", zap.Error(err))
+	}
+	return cal, nil
+}
+
+func (s *USRPService) GetCalibration(ctx context.Context) (*model.RXCalibration, error) {
+	if s.receiver == nil {
+		return nil, deviceUnavailable("usrp")
+	}
+	cal := s.receiver.Calibration()
+	if cal == nil {
+		return nil, errors.New(errors.CodeNotFound, "usrp receiver is not calibrated")
+	}
+	return cal, nil
+}
+
+// ClearCalibration removes the correction and its persisted copy.
+func (s *USRPService) ClearCalibration(ctx context.Context) error {
+	if s.receiver == nil {
+		return deviceUnavailable("usrp")
+	}
+	if err := checkReservation(ctx, s.gate, model.ReservableDeviceUSRP); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.receiver.SetCalibration(nil)
+	if s.calibrationFile != "" {
+		if err := os.Remove(s.calibrationFile); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(errors.CodeInternalError, "failed to remove usrp calibration", err)
+		}
+	}
+	return nil
+}
+
+func (s *USRPService) saveCalibration(cal *model.RXCalibration) error {
+	if s.calibrationFile == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(cal, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.calibrationFile), 0755); err != nil {
+		return err
+	}
+	tmp := s.calibrationFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, s.calibrationFile)
+}
+
+func calibrationError(err error) error {
+	switch err {
+	case usrp.ErrInvalidCalibrationTone, usrp.ErrCalibrationToneNotFound, usrp.ErrCalibrationMismatch:
+		return errors.Wrap(errors.CodeInvalidParam, err.Error(), err)
+	case usrp.ErrReceiverNotConnected:
+		return deviceUnavailable("usrp")
+	}
+	return errors.Wrap(errors.CodeUSRPReceiveError, "usrp calibration failed", err)
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"isac-cran-system/internal/device/usrp"
+	"isac-cran-system/internal/model"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// USRPService lists the radios the receiver could use and rebinds it, and the
+// transmitter sharing its driver, to one of them at runtime.
+type USRPService struct {
+	receiver    *usrp.Receiver
+	transmitter *usrp.Transmitter
+	options     []usrp.DriverOption
+	devices     *DeviceService
+	gate        DeviceGate
+
+	mu              sync.Mutex
+	bound           string
+	calibrationFile string
+
+	agcMu       sync.Mutex
+	agc         *usrp.AGC
+	agcDefaults model.AGCConfig
+}
+
+// NewUSRPService takes the driver options the receiver was created with;
+// binding reuses them with the selected device's args and channel count.
+func NewUSRPService(receiver *usrp.Receiver, transmitter *usrp.Transmitter, options ...usrp.DriverOption) *USRPService {
+	return &USRPService{
+		receiver:    receiver,
+		transmitter: transmitter,
+		options:     options,
+	}
+}
+
+func (s *USRPService) SetDeviceService(devices *DeviceService) {
+	s.devices = devices
+}
+
+func (s *USRPService) SetDeviceGate(gate DeviceGate) {
+	s.gate = gate
+}
+
+// SetAGCDefaults sets the AGC tuning used for settings a request leaves out.
+func (s *USRPService) SetAGCDefaults(cfg model.AGCConfig) {
+	s.agcMu.Lock()
+	defer s.agcMu.Unlock()
+	s.agcDefaults = cfg
+}
+
+// SetBound records the serial of the device the receiver was started on.
+func (s *USRPService) SetBound(serial string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bound = serial
+}
+
+func (s *USRPService) List(ctx context.Context) ([]model.USRPDevice, error) {
+	devices, err := usrp.Enumerate()
+	if err != nil {
+		// simulated devices are still usable when hardware discovery fails
+		logger.Warn("USRP hardware discovery failed", zap.Error(err))
+	}
+
+	s.mu.Lock()
+	bound := s.bound
+	s.mu.Unlock()
+
+	for i := range devices {
+		devices[i].Bound = bound != "" && devices[i].Serial == bound
+	}
+	return devices, nil
+}
+
+func (s *USRPService) Bind(ctx context.Context, req *model.USRPBindRequest) (*model.USRPDevice, error) {
+	if s.receiver == nil {
+		return nil, deviceUnavailable("usrp")
+	}
+	if err := checkReservation(ctx, s.gate, model.ReservableDeviceUSRP); err != nil {
+		return nil, err
+	}
+
+	devices, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var device *model.USRPDevice
+	for i := range devices {
+		if devices[i].Serial == req.Serial {
+			device = &devices[i]
+			break
+		}
+	}
+	if device == nil {
+		return nil, errors.New(errors.CodeNotFound, "usrp device not found: "+req.Serial)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	driverType := usrp.DriverTypeHardware
+	if device.Simulated {
+		driverType = usrp.DriverTypeSimulator
+	}
+	options := append(append([]usrp.DriverOption{}, s.options...),
+		usrp.WithDeviceArgs(device.Args),
+		usrp.WithChannels(device.Channels),
+	)
+	driver, err := usrp.NewDriverFactory().Create(driverType, options...)
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeUSRPDeviceError, "failed to bind usrp device", err)
+	}
+	if err := s.receiver.SwapDriver(ctx, driver); err != nil {
+		return nil, errors.Wrap(errors.CodeUSRPDeviceError, "failed to bind usrp device", err)
+	}
+	if s.transmitter != nil {
+		s.transmitter.SetDriver(driver)
+	}
+
+	s.bound = device.Serial
+	device.Bound = true
+	if err := s.loadCalibration(); err != nil {
+		logger.Warn("Failed to load USRP calibration", zap.Error(err))
+	}
+	if s.devices != nil {
+		s.devices.Update("usrp", func(info *model.DeviceInfo) {
+			info.DriverType = string(driverType)
+			info.Simulator = device.Simulated
+			info.Error = ""
+		})
+	}
+
+	logger.Info("USRP receiver bound to device",
+		zap.String("serial", device.Serial),
+		zap.String("product", device.Product),
+		zap.Int("channels", device.Channels),
+	)
+	return device, nil
+}
+
+func (s *USRPService) Gain(ctx context.Context) (*model.USRPGainStatus, error) {
+	if s.receiver == nil {
+		return nil, deviceUnavailable("usrp")
+	}
+
+	s.agcMu.Lock()
+	defer s.agcMu.Unlock()
+	return s.gainStatus(), nil
+}
+
+// SetGain applies a manual gain. A running AGC loop is stopped first so it
+// does not immediately override the value.
+func (s *USRPService) SetGain(ctx context.Context, req *model.USRPGainRequest) (*model.USRPGainStatus, error) {
+	if s.receiver == nil {
+		return nil, deviceUnavailable("usrp")
+	}
+	if err := checkReservation(ctx, s.gate, model.ReservableDeviceUSRP); err != nil {
+		return nil, err
+	}
+
+	s.agcMu.Lock()
+	defer s.agcMu.Unlock()
+
+	s.stopAGC()
+	if err := s.receiver.SetGain(*req.Gain); err != nil {
+		return nil, gainError(err)
+	}
+	return s.gainStatus(), nil
+}
+
+func (s *USRPService) SetAGC(ctx context.Context, req *model.USRPAGCRequest) (*model.USRPGainStatus, error) {
+	if s.receiver == nil {
+		return nil, deviceUnavailable("usrp")
+	}
+	if err := checkReservation(ctx, s.gate, model.ReservableDeviceUSRP); err != nil {
+		return nil, err
+	}
+
+	s.agcMu.Lock()
+	defer s.agcMu.Unlock()
+
+	s.stopAGC()
+	if req.Enabled {
+		// the loop outlives the request that started it
+		agc, err := s.receiver.StartAGC(context.Background(), usrp.MergeAGCConfig(req.AGCConfig, s.agcDefaults))
+		if err != nil {
+			return nil, gainError(err)
+		}
+		s.agc = agc
+	}
+	return s.gainStatus(), nil
+}
+
+// StopAGC stops the AGC loop if one is running.
+func (s *USRPService) StopAGC() {
+	s.agcMu.Lock()
+	defer s.agcMu.Unlock()
+	s.stopAGC()
+}
+
+func (s *USRPService) stopAGC() {
+	if s.agc != nil {
+		s.agc.Stop()
+		s.agc = nil
+	}
+}
+
+func (s *USRPService) gainStatus() *model.USRPGainStatus {
+	status := &model.USRPGainStatus{Gain: s.receiver.GetGain()}
+	if s.agc != nil {
+		agc := s.agc.Status()
+		status.AGC = &agc
+	}
+	return status
+}
+
+func gainError(err error) error {
+	switch err {
+	case usrp.ErrGainOutOfRange, usrp.ErrInvalidAGCConfig, usrp.ErrZMQGainUnsupported:
+		return errors.Wrap(errors.CodeInvalidParam, err.Error(), err)
+	case usrp.ErrReceiverNotConnected:
+		return deviceUnavailable("usrp")
+	}
+	return errors.Wrap(errors.CodeUSRPDeviceError, "failed to set usrp gain", err)
+}
@@ -0,0 +1,160 @@
+(function () {
+    'use strict';
+
+    const API = '../api/v1';
+    const REFRESH_MS = 5000;
+    const EXPERIMENT_STATUS = ['等待中', '运行中', '已完成', '失败'];
+    const EXPERIMENT_CLASS = ['muted', 'warn', 'ok', 'bad'];
+
+    function escape(value) {
+        return String(value === undefined || value === null ? '' : value)
+            .replace(/&/g, '&amp;')
+            .replace(/</g, '&lt;')
+            .replace(/>/g, '&gt;')
+            .replace(/"/g, '&quot;')
+            .replace(/'/g, '&#39;');
+    }
+
+    function formatTime(value) {
+        if (!value) return '-';
+        const date = new Date(value);
+        return isNaN(date.getTime()) ? '-' : date.toLocaleString('zh-CN', { hour12: false });
+    }
+
+    function flag(on, yes, no) {
+        return on ? '<span class="ok">' + yes + '</span>' : '<span class="bad">' + no + '</span>';
+    }
+
+    async function request(path, options) {
+        const resp = await fetch(API + path, options);
+        const body = await resp.json();
+        if (body.code !== 0) {
+            throw new Error(body.message || ('HTTP ' + resp.status));
+        }
+        return body.data;
+    }
+
+    function fill(id, columns, rows, render) {
+        const tbody = document.getElementById(id);
+        if (!rows || rows.length === 0) {
+            tbody.innerHTML = '<tr><td colspan="' + columns + '" class="muted">暂无数据</td></tr>';
+            return;
+        }
+        tbody.innerHTML = rows.map(render).join('');
+    }
+
+    function fail(id, columns, err) {
+        document.getElementById(id).innerHTML =
+            '<tr><td colspan="' + columns + '" class="bad">加载失败: ' + escape(err.message) + '</td></tr>';
+    }
+
+    async function loadHealth() {
+        const badge = document.getElementById('health');
+        try {
+            await request('/health');
+            badge.textContent = '服务正常';
+            badge.className = 'badge ok';
+        } catch (err) {
+            badge.textContent = '服务异常';
+            badge.className = 'badge bad';
+        }
+    }
+
+    async function loadDevices() {
+        try {
+            const devices = await request('/devices');
+            fill('devices', 4, devices, function (d) {
+                return '<tr><td>' + escape(d.name) + (d.id ? ' <span class="muted">' + escape(d.id) + '</span>' : '') + '</td>' +
+                    '<td>' + escape(d.simulator ? '模拟器' : (d.driver_type || '-')) + '</td>' +
+                    '<td>' + (d.enabled ? flag(d.connected, '已连接', '未连接') : '<span class="muted">未启用</span>') + '</td>' +
+                    '<td class="bad">' + escape(d.error) + '</td></tr>';
+            });
+        } catch (err) {
+            fail('devices', 4, err);
+        }
+    }
+
+    async function loadPanels() {
+        try {
+            const panels = await request('/irs/panels');
+            fill('panels', 5, panels, function (p) {
+                const config = p.config || {};
+                return '<tr><td>' + escape(p.id) + (p.default ? ' <span class="muted">默认</span>' : '') + '</td>' +
+                    '<td>' + flag(p.connected, '已连接', '未连接') + '</td>' +
+                    '<td>' + flag(p.healthy, '正常', '告警') + '</td>' +
+                    '<td>' + escape(config.element_count || '-') + '</td>' +
+                    '<td>' + escape(config.frequency_band || '-') + '</td></tr>';
+            });
+        } catch (err) {
+            fail('panels', 5, err);
+        }
+    }
+
+    async function loadSensors() {
+        try {
+            const sensors = await request('/sensor/list');
+            let readings = {};
+            if (sensors && sensors.length > 0) {
+                const results = await request('/sensor/batch-read', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ sensor_ids: sensors.map(function (s) { return s.sensor_id; }) })
+                });
+                (results || []).forEach(function (r) { readings[r.sensor_id] = r; });
+            }
+            fill('sensors', 6, sensors, function (s) {
+                const r = readings[s.sensor_id] || {};
+                let value = '<span class="muted">-</span>';
+                if (r.data) {
+                    const out = r.data.value < s.min_value || r.data.value > s.max_value;
+                    value = '<span class="' + (out ? 'bad' : 'ok') + '">' + escape(r.data.value.toFixed(2)) + ' ' + escape(s.unit) + '</span>';
+                } else if (r.error) {
+                    value = '<span class="bad">' + escape(r.error) + '</span>';
+                }
+                return '<tr><td>' + escape(s.sensor_id) + '</td>' +
+                    '<td>' + escape(s.sensor_type) + '</td>' +
+                    '<td>' + escape(s.location) + '</td>' +
+                    '<td>' + value + '</td>' +
+                    '<td>' + escape(s.min_value) + ' ~ ' + escape(s.max_value) + '</td>' +
+                    '<td>' + escape(formatTime(r.data && r.data.timestamp)) + '</td></tr>';
+            });
+        } catch (err) {
+            fail('sensors', 6, err);
+        }
+    }
+
+    async function loadAlerts() {
+        const badge = document.getElementById('alerts');
+        try {
+            const page = await request('/alerts?status=active&page_size=1');
+            const total = page.total || 0;
+            badge.textContent = total > 0 ? total + ' 条告警' : '';
+            badge.className = 'badge bad';
+        } catch (err) {
+            badge.textContent = '';
+        }
+    }
+
+    async function loadExperiments() {
+        try {
+            const page = await request('/algorithm/results?page=1&page_size=10');
+            fill('experiments', 5, page.list, function (e) {
+                return '<tr><td>' + escape(e.experiment_id) + '</td>' +
+                    '<td>' + escape(e.algorithm_type) + '</td>' +
+                    '<td class="' + (EXPERIMENT_CLASS[e.status] || '') + '">' + escape(EXPERIMENT_STATUS[e.status] || e.status) + '</td>' +
+                    '<td>' + escape(formatTime(e.created_at)) + '</td>' +
+                    '<td>' + escape(formatTime(e.completed_at)) + '</td></tr>';
+            });
+        } catch (err) {
+            fail('experiments', 5, err);
+        }
+    }
+
+    async function refresh() {
+        await Promise.all([loadHealth(), loadDevices(), loadPanels(), loadSensors(), loadAlerts(), loadExperiments()]);
+        document.getElementById('updated').textContent = '更新于 ' + new Date().toLocaleTimeString('zh-CN', { hour12: false });
+    }
+
+    refresh();
+    setInterval(refresh, REFRESH_MS);
+})();
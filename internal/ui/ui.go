@@ -0,0 +1,23 @@
+// Package ui serves the operations dashboard that is built into the binary,
+// so that small deployments need no separate web server.
+package ui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed static
+var static embed.FS
+
+// Register serves the dashboard under prefix, e.g. /ui.
+func Register(router *gin.Engine, prefix string) {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	router.StaticFS(prefix, http.FS(files))
+}
@@ -0,0 +1,66 @@
+package arrow
+
+import (
+	"time"
+)
+
+type DataType int
+
+const (
+	TypeInt64 DataType = iota
+	TypeFloat64
+	TypeString
+	TypeTimestamp
+	TypeFloat64List
+)
+
+// Column is a named, non-nullable column of a record batch.
+type Column struct {
+	Name string
+	Type DataType
+
+	ints    []int64
+	floats  []float64
+	strings []string
+	lists   [][]float64
+}
+
+func Int64Column(name string, values []int64) Column {
+	return Column{Name: name, Type: TypeInt64, ints: values}
+}
+
+func Float64Column(name string, values []float64) Column {
+	return Column{Name: name, Type: TypeFloat64, floats: values}
+}
+
+func StringColumn(name string, values []string) Column {
+	return Column{Name: name, Type: TypeString, strings: values}
+}
+
+// TimestampColumn stores times as nanoseconds since the Unix epoch in UTC.
+func TimestampColumn(name string, values []time.Time) Column {
+	ints := make([]int64, len(values))
+	for i, t := range values {
+		ints[i] = t.UnixNano()
+	}
+	return Column{Name: name, Type: TypeTimestamp, ints: ints}
+}
+
+func Float64ListColumn(name string, values [][]float64) Column {
+	return Column{Name: name, Type: TypeFloat64List, lists: values}
+}
+
+func (c Column) Len() int {
+	switch c.Type {
+	case TypeInt64, TypeTimestamp:
+		return len(c.ints)
+	case TypeFloat64:
+		return len(c.floats)
+	case TypeString:
+		return len(c.strings)
+	case TypeFloat64List:
+		return len(c.lists)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,182 @@
+package arrow
+
+import (
+	"encoding/binary"
+)
+
+// Minimal flatbuffer encoder for Arrow IPC metadata. Objects are laid out in
+// pre-order so every uoffset points forward, and each table is preceded by
+// its own vtable.
+
+type fbObject interface {
+	writeTo(e *fbEncoder) int
+}
+
+type fbEncoder struct {
+	buf []byte
+}
+
+func (e *fbEncoder) pad(align int) {
+	for len(e.buf)%align != 0 {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *fbEncoder) patchOffset(at, target int) {
+	binary.LittleEndian.PutUint32(e.buf[at:], uint32(target-at))
+}
+
+func fbFinish(root fbObject) []byte {
+	e := &fbEncoder{buf: make([]byte, 4)}
+	pos := root.writeTo(e)
+	binary.LittleEndian.PutUint32(e.buf[0:], uint32(pos))
+	e.pad(8)
+	return e.buf
+}
+
+type fbSlot struct {
+	set  bool
+	size int
+	bits uint64
+	ref  fbObject
+}
+
+type fbTable struct {
+	slots []fbSlot
+}
+
+func newFBTable(slotCount int) *fbTable {
+	return &fbTable{slots: make([]fbSlot, slotCount)}
+}
+
+func (t *fbTable) setScalar(slot, size int, bits uint64) *fbTable {
+	t.slots[slot] = fbSlot{set: true, size: size, bits: bits}
+	return t
+}
+
+func (t *fbTable) setBool(slot int, v bool) *fbTable {
+	var bits uint64
+	if v {
+		bits = 1
+	}
+	return t.setScalar(slot, 1, bits)
+}
+
+func (t *fbTable) setUint8(slot int, v uint8) *fbTable {
+	return t.setScalar(slot, 1, uint64(v))
+}
+
+func (t *fbTable) setInt16(slot int, v int16) *fbTable {
+	return t.setScalar(slot, 2, uint64(uint16(v)))
+}
+
+func (t *fbTable) setInt32(slot int, v int32) *fbTable {
+	return t.setScalar(slot, 4, uint64(uint32(v)))
+}
+
+func (t *fbTable) setInt64(slot int, v int64) *fbTable {
+	return t.setScalar(slot, 8, uint64(v))
+}
+
+func (t *fbTable) setRef(slot int, obj fbObject) *fbTable {
+	t.slots[slot] = fbSlot{set: true, size: 4, ref: obj}
+	return t
+}
+
+func (t *fbTable) writeTo(e *fbEncoder) int {
+	offsets := make([]int, len(t.slots))
+	size := 4
+	for _, width := range []int{8, 4, 2, 1} {
+		for i, slot := range t.slots {
+			if !slot.set || slot.size != width {
+				continue
+			}
+			size = (size + width - 1) / width * width
+			offsets[i] = size
+			size += width
+		}
+	}
+
+	vtableLen := 4 + 2*len(t.slots)
+	for (len(e.buf)+vtableLen)%8 != 0 {
+		e.buf = append(e.buf, 0)
+	}
+	vtablePos := len(e.buf)
+	e.buf = binary.LittleEndian.AppendUint16(e.buf, uint16(vtableLen))
+	e.buf = binary.LittleEndian.AppendUint16(e.buf, uint16(size))
+	for _, off := range offsets {
+		e.buf = binary.LittleEndian.AppendUint16(e.buf, uint16(off))
+	}
+
+	tablePos := len(e.buf)
+	e.buf = append(e.buf, make([]byte, size)...)
+	binary.LittleEndian.PutUint32(e.buf[tablePos:], uint32(int32(tablePos-vtablePos)))
+
+	for i, slot := range t.slots {
+		if !slot.set || slot.ref != nil {
+			continue
+		}
+		at := tablePos + offsets[i]
+		switch slot.size {
+		case 1:
+			e.buf[at] = byte(slot.bits)
+		case 2:
+			binary.LittleEndian.PutUint16(e.buf[at:], uint16(slot.bits))
+		case 4:
+			binary.LittleEndian.PutUint32(e.buf[at:], uint32(slot.bits))
+		case 8:
+			binary.LittleEndian.PutUint64(e.buf[at:], slot.bits)
+		}
+	}
+
+	for i, slot := range t.slots {
+		if slot.ref == nil {
+			continue
+		}
+		at := tablePos + offsets[i]
+		e.patchOffset(at, slot.ref.writeTo(e))
+	}
+
+	return tablePos
+}
+
+type fbString string
+
+func (s fbString) writeTo(e *fbEncoder) int {
+	e.pad(4)
+	pos := len(e.buf)
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(len(s)))
+	e.buf = append(e.buf, s...)
+	e.buf = append(e.buf, 0)
+	return pos
+}
+
+type fbVector []fbObject
+
+func (v fbVector) writeTo(e *fbEncoder) int {
+	e.pad(4)
+	pos := len(e.buf)
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(len(v)))
+	e.buf = append(e.buf, make([]byte, 4*len(v))...)
+	for i, obj := range v {
+		e.patchOffset(pos+4+4*i, obj.writeTo(e))
+	}
+	return pos
+}
+
+// fbStructVector is a vector of inline structs whose largest member is
+// eight bytes wide.
+type fbStructVector struct {
+	data  []byte
+	count int
+}
+
+func (v fbStructVector) writeTo(e *fbEncoder) int {
+	for (len(e.buf)+4)%8 != 0 {
+		e.buf = append(e.buf, 0)
+	}
+	pos := len(e.buf)
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(v.count))
+	e.buf = append(e.buf, v.data...)
+	return pos
+}
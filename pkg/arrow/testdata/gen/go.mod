@@ -0,0 +1,5 @@
+module arrowgen
+
+go 1.21
+
+require github.com/apache/arrow/go/arrow v0.0.0-20211112161151-bc219186db40
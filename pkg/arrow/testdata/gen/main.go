@@ -0,0 +1,56 @@
+// Command gen writes ../arrow_go.arrows, the table of
+// TestWriter_MatchesArrowGo as the Apache Arrow Go IPC writer streams it.
+// Run it from this directory with go run . after go mod tidy.
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/apache/arrow/go/arrow/memory"
+)
+
+func main() {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "sensor_id", Type: arrow.BinaryTypes.String},
+		{Name: "value", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "timestamp", Type: &arrow.TimestampType{Unit: arrow.Nanosecond, TimeZone: "UTC"}},
+		{Name: "amplitude", Type: arrow.ListOf(arrow.PrimitiveTypes.Float64)},
+		{Name: "count", Type: arrow.PrimitiveTypes.Int64},
+	}, nil)
+
+	b := array.NewRecordBuilder(memory.NewGoAllocator(), schema)
+	defer b.Release()
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.Field(0).(*array.StringBuilder).AppendValues([]string{"temp-001", "hum-01"}, nil)
+	b.Field(1).(*array.Float64Builder).AppendValues([]float64{21.5, 48}, nil)
+	b.Field(2).(*array.TimestampBuilder).AppendValues([]arrow.Timestamp{
+		arrow.Timestamp(ts.UnixNano()), arrow.Timestamp(ts.Add(time.Second).UnixNano()),
+	}, nil)
+	lists := b.Field(3).(*array.ListBuilder)
+	values := lists.ValueBuilder().(*array.Float64Builder)
+	lists.Append(true)
+	values.AppendValues([]float64{1, 2, 3}, nil)
+	lists.Append(true)
+	values.AppendValues([]float64{4}, nil)
+	b.Field(4).(*array.Int64Builder).AppendValues([]int64{7, -1}, nil)
+	record := b.NewRecord()
+	defer record.Release()
+
+	f, err := os.Create("../arrow_go.arrows")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	w := ipc.NewWriter(f, ipc.WithSchema(schema))
+	if err := w.Write(record); err != nil {
+		log.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,240 @@
+package arrow
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ContentType is the media type of the Arrow IPC streaming format.
+const ContentType = "application/vnd.apache.arrow.stream"
+
+const (
+	metadataVersionV5 = 4
+
+	headerSchema      = 1
+	headerRecordBatch = 3
+
+	typeInt           = 2
+	typeFloatingPoint = 3
+	typeUtf8          = 5
+	typeTimestamp     = 10
+	typeList          = 12
+
+	precisionDouble = 2
+	unitNanosecond  = 3
+
+	continuationMarker = 0xFFFFFFFF
+)
+
+// Writer encodes record batches in the Arrow IPC streaming format. The
+// schema is taken from the first batch; later batches must have the same
+// column names and types.
+type Writer struct {
+	w      io.Writer
+	schema []Column
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+func (w *Writer) Write(columns ...Column) error {
+	length := 0
+	if len(columns) > 0 {
+		length = columns[0].Len()
+	}
+	for _, c := range columns {
+		if c.Len() != length {
+			return fmt.Errorf("column %q has %d rows, want %d", c.Name, c.Len(), length)
+		}
+	}
+
+	if w.schema == nil {
+		w.schema = columns
+		if err := w.writeMessage(headerSchema, schemaTable(columns), nil); err != nil {
+			return err
+		}
+	} else if err := w.checkSchema(columns); err != nil {
+		return err
+	}
+
+	batch := newBatchBuilder()
+	for _, c := range columns {
+		batch.addColumn(c)
+	}
+	return w.writeMessage(headerRecordBatch, batch.table(length), batch.body)
+}
+
+// Close writes the end-of-stream marker. It does not close the underlying
+// writer.
+func (w *Writer) Close() error {
+	var eos [8]byte
+	binary.LittleEndian.PutUint32(eos[0:], continuationMarker)
+	_, err := w.w.Write(eos[:])
+	return err
+}
+
+func (w *Writer) checkSchema(columns []Column) error {
+	if len(columns) != len(w.schema) {
+		return fmt.Errorf("batch has %d columns, schema has %d", len(columns), len(w.schema))
+	}
+	for i, c := range columns {
+		if c.Name != w.schema[i].Name || c.Type != w.schema[i].Type {
+			return fmt.Errorf("column %d (%s) does not match schema", i, c.Name)
+		}
+	}
+	return nil
+}
+
+func (w *Writer) writeMessage(headerType uint8, header *fbTable, body []byte) error {
+	message := newFBTable(5).
+		setInt16(0, metadataVersionV5).
+		setUint8(1, headerType).
+		setRef(2, header).
+		setInt64(3, int64(len(body)))
+	metadata := fbFinish(message)
+
+	var prefix [8]byte
+	binary.LittleEndian.PutUint32(prefix[0:], continuationMarker)
+	binary.LittleEndian.PutUint32(prefix[4:], uint32(len(metadata)))
+
+	if _, err := w.w.Write(prefix[:]); err != nil {
+		return err
+	}
+	if _, err := w.w.Write(metadata); err != nil {
+		return err
+	}
+	_, err := w.w.Write(body)
+	return err
+}
+
+func schemaTable(columns []Column) *fbTable {
+	fields := make(fbVector, len(columns))
+	for i, c := range columns {
+		fields[i] = fieldTable(c.Name, c.Type, false)
+	}
+	return newFBTable(4).
+		setInt16(0, 0).
+		setRef(1, fields)
+}
+
+func fieldTable(name string, dataType DataType, nullable bool) *fbTable {
+	children := fbVector{}
+	var typeID uint8
+	var typ *fbTable
+
+	switch dataType {
+	case TypeInt64:
+		typeID = typeInt
+		typ = newFBTable(2).setInt32(0, 64).setBool(1, true)
+	case TypeFloat64:
+		typeID = typeFloatingPoint
+		typ = newFBTable(1).setInt16(0, precisionDouble)
+	case TypeString:
+		typeID = typeUtf8
+		typ = newFBTable(0)
+	case TypeTimestamp:
+		typeID = typeTimestamp
+		typ = newFBTable(2).setInt16(0, unitNanosecond).setRef(1, fbString("UTC"))
+	case TypeFloat64List:
+		typeID = typeList
+		typ = newFBTable(0)
+		children = fbVector{fieldTable("item", TypeFloat64, true)}
+	}
+
+	return newFBTable(7).
+		setRef(0, fbString(name)).
+		setBool(1, nullable).
+		setUint8(2, typeID).
+		setRef(3, typ).
+		setRef(5, children)
+}
+
+type batchBuilder struct {
+	nodes   []byte
+	buffers []byte
+	body    []byte
+	nNodes  int
+	nBufs   int
+}
+
+func newBatchBuilder() *batchBuilder {
+	return &batchBuilder{}
+}
+
+func (b *batchBuilder) addNode(length int) {
+	b.nodes = binary.LittleEndian.AppendUint64(b.nodes, uint64(length))
+	b.nodes = binary.LittleEndian.AppendUint64(b.nodes, 0)
+	b.nNodes++
+}
+
+func (b *batchBuilder) addBuffer(data []byte) {
+	b.buffers = binary.LittleEndian.AppendUint64(b.buffers, uint64(len(b.body)))
+	b.buffers = binary.LittleEndian.AppendUint64(b.buffers, uint64(len(data)))
+	b.nBufs++
+
+	b.body = append(b.body, data...)
+	for len(b.body)%8 != 0 {
+		b.body = append(b.body, 0)
+	}
+}
+
+func (b *batchBuilder) addColumn(c Column) {
+	b.addNode(c.Len())
+	b.addBuffer(nil)
+
+	switch c.Type {
+	case TypeInt64, TypeTimestamp:
+		b.addBuffer(int64Bytes(c.ints))
+	case TypeFloat64:
+		b.addBuffer(float64Bytes(c.floats))
+	case TypeString:
+		offsets := make([]byte, 0, 4*(len(c.strings)+1))
+		data := make([]byte, 0)
+		offsets = binary.LittleEndian.AppendUint32(offsets, 0)
+		for _, s := range c.strings {
+			data = append(data, s...)
+			offsets = binary.LittleEndian.AppendUint32(offsets, uint32(len(data)))
+		}
+		b.addBuffer(offsets)
+		b.addBuffer(data)
+	case TypeFloat64List:
+		offsets := make([]byte, 0, 4*(len(c.lists)+1))
+		values := make([]float64, 0)
+		offsets = binary.LittleEndian.AppendUint32(offsets, 0)
+		for _, l := range c.lists {
+			values = append(values, l...)
+			offsets = binary.LittleEndian.AppendUint32(offsets, uint32(len(values)))
+		}
+		b.addBuffer(offsets)
+
+		b.addNode(len(values))
+		b.addBuffer(nil)
+		b.addBuffer(float64Bytes(values))
+	}
+}
+
+func (b *batchBuilder) table(length int) *fbTable {
+	return newFBTable(4).
+		setInt64(0, int64(length)).
+		setRef(1, fbStructVector{data: b.nodes, count: b.nNodes}).
+		setRef(2, fbStructVector{data: b.buffers, count: b.nBufs})
+}
+
+func int64Bytes(values []int64) []byte {
+	buf := make([]byte, 0, 8*len(values))
+	for _, v := range values {
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(v))
+	}
+	return buf
+}
+
+func float64Bytes(values []float64) []byte {
+	buf := make([]byte, 0, 8*len(values))
+	for _, v := range values {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+	}
+	return buf
+}
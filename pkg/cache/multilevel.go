@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
@@ -62,12 +63,35 @@ func (c *L1Cache) Delete(key string) {
 	delete(c.items, key)
 }
 
+// DeletePrefix removes every key starting with prefix and returns how many
+// were removed.
+func (c *L1Cache) DeletePrefix(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for k := range c.items {
+		if strings.HasPrefix(k, prefix) {
+			delete(c.items, k)
+			n++
+		}
+	}
+	return n
+}
+
+func (c *L1Cache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.items)
+}
+
+// evict drops the item that expires first, so a full cache of live items
+// still makes room.
 func (c *L1Cache) evict() {
 	oldestKey := ""
-	oldestTime := time.Now()
+	var oldestTime time.Time
 
 	for k, v := range c.items {
-		if v.Expiration.Before(oldestTime) {
+		if oldestKey == "" || v.Expiration.Before(oldestTime) {
 			oldestTime = v.Expiration
 			oldestKey = k
 		}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// StartBeamSweep starts a beam sweep; it runs on the server, and
+// GetBeamSweep follows it.
+func (c *Client) StartBeamSweep(ctx context.Context, req *BeamSweepRequest) (*BeamSweep, error) {
+	r, err := c.do(ctx, http.MethodPost, "/beams/sweeps", nil, nil, req)
+	if err != nil {
+		return nil, err
+	}
+	var sweep BeamSweep
+	if err := r.decode(&sweep); err != nil {
+		return nil, err
+	}
+	return &sweep, nil
+}
+
+func (c *Client) GetBeamSweep(ctx context.Context, id string) (*BeamSweep, error) {
+	var sweep BeamSweep
+	if err := c.get(ctx, "/beams/sweeps/"+url.PathEscape(id), nil, &sweep); err != nil {
+		return nil, err
+	}
+	return &sweep, nil
+}
+
+// BeamSweepResult returns the power of every beam measured so far and the
+// tracking history of a sweep.
+func (c *Client) BeamSweepResult(ctx context.Context, id string) (*BeamSweepResult, error) {
+	var result BeamSweepResult
+	if err := c.get(ctx, "/beams/sweeps/"+url.PathEscape(id)+"/result", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// StopBeamSweep ends a sweep, or its tracking, and returns its final state.
+func (c *Client) StopBeamSweep(ctx context.Context, id string) (*BeamSweep, error) {
+	r, err := c.do(ctx, http.MethodPost, "/beams/sweeps/"+url.PathEscape(id)+"/stop", nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var sweep BeamSweep
+	if err := r.decode(&sweep); err != nil {
+		return nil, err
+	}
+	return &sweep, nil
+}
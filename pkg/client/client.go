@@ -0,0 +1,356 @@
+// Package client is a Go SDK for the testbed's REST and gRPC APIs. Errors
+// the server reports come back as *errors.AppError carrying the server's
+// code, so errors.IsCode works on them as it does inside the server; the
+// HTTP status and any data sent with the error are in the wrapped
+// *HTTPError.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/rpc"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	apiPrefix = "/api/v1"
+
+	defaultTimeout    = 60 * time.Second
+	defaultMaxRetries = 3
+	defaultBackoff    = 200 * time.Millisecond
+	maxBackoff        = 5 * time.Second
+)
+
+// TokenSource returns the bearer token for a request. It is asked before
+// every attempt, so a source that refreshes its token is picked up
+// without recreating the client.
+type TokenSource func(ctx context.Context) (string, error)
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      TokenSource
+	maxRetries int
+	backoff    time.Duration
+
+	grpcAddr string
+	grpcOpts []grpc.DialOption
+	rpc      *rpc.ClientPool
+	capture  *rpc.CaptureClient
+}
+
+type Option func(*Client)
+
+// WithToken authenticates every request with a fixed bearer token.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = func(context.Context) (string, error) { return token, nil }
+	}
+}
+
+func WithTokenSource(source TokenSource) Option {
+	return func(c *Client) {
+		c.token = source
+	}
+}
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetry retries a failed request up to maxRetries times, waiting
+// backoff before the first retry and doubling it, with jitter, before each
+// further one. Zero retries disables retrying.
+func WithRetry(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// WithGRPC also connects to the gRPC server at addr, which serves the
+// algorithm, IRS, sensor and capture services. The client's token is sent
+// with every call.
+func WithGRPC(addr string, opts ...grpc.DialOption) Option {
+	return func(c *Client) {
+		c.grpcAddr = addr
+		c.grpcOpts = opts
+	}
+}
+
+// New returns a client for the server at baseURL, e.g.
+// "http://testbed:8080".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/") + apiPrefix,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.maxRetries < 0 {
+		return nil, fmt.Errorf("max retries must not be negative")
+	}
+
+	if c.grpcAddr != "" {
+		dialOpts := c.grpcOpts
+		if c.token != nil {
+			dialOpts = append([]grpc.DialOption{grpc.WithPerRPCCredentials(tokenCredentials(c.token))}, dialOpts...)
+		}
+		if c.rpc, err = rpc.NewClientPool(c.grpcAddr, c.grpcAddr, c.grpcAddr, dialOpts...); err != nil {
+			return nil, err
+		}
+		if c.capture, err = rpc.NewCaptureClient(c.grpcAddr, dialOpts...); err != nil {
+			c.rpc.Close()
+			return nil, fmt.Errorf("failed to create capture client: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// RPC returns the gRPC clients, or nil without WithGRPC.
+func (c *Client) RPC() *rpc.ClientPool {
+	return c.rpc
+}
+
+// Capture returns the gRPC capture client, which resumes interrupted IQ
+// downloads, or nil without WithGRPC.
+func (c *Client) Capture() *rpc.CaptureClient {
+	return c.capture
+}
+
+func (c *Client) Close() error {
+	if c.rpc != nil {
+		c.rpc.Close()
+	}
+	if c.capture != nil {
+		c.capture.Close()
+	}
+	return nil
+}
+
+// HTTPError is the HTTP side of an error the server reported: the status
+// and the data sent along with it, such as the rules a run's parameters
+// break.
+type HTTPError struct {
+	StatusCode int
+	Data       json.RawMessage
+}
+
+func (e *HTTPError) Error() string {
+	if len(e.Data) == 0 {
+		return fmt.Sprintf("HTTP %d", e.StatusCode)
+	}
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Data)
+}
+
+// envelope is the body of every JSON response.
+type envelope struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type reply struct {
+	status int
+	header http.Header
+	data   json.RawMessage
+}
+
+func (r *reply) decode(out interface{}) error {
+	if out == nil || len(r.data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(r.data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// get sends a GET and decodes the data of its response into out.
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	r, err := c.do(ctx, http.MethodGet, path, query, nil, nil)
+	if err != nil {
+		return err
+	}
+	return r.decode(out)
+}
+
+// do sends a request with body encoded as JSON and reads the envelope of
+// its response. Statuses other than 2xx and 304 are returned as errors.
+//
+// Requests that are safe to repeat are retried on transport errors and on
+// 429, 502, 503 and 504; a POST only on 429, which the server answers
+// before doing any work. A Retry-After header overrides the backoff.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header, body interface{}) (*reply, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		r, wait, err := c.send(ctx, method, target, header, payload)
+		if err == nil || attempt >= c.maxRetries || !retryable(method, r, err) {
+			return r, err
+		}
+		if wait == 0 {
+			wait = jitter(backoff)
+			backoff = min(2*backoff, maxBackoff)
+		}
+		select {
+		case <-ctx.Done():
+			return r, err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// send makes one attempt and returns how long the server asked to wait
+// before the next.
+func (c *Client) send(ctx context.Context, method, target string, header http.Header, payload []byte) (*reply, time.Duration, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, 0, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != nil {
+		token, err := c.token(ctx)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	r := &reply{status: resp.StatusCode, header: resp.Header}
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return r, 0, err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		return r, 0, nil
+	}
+
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		if resp.StatusCode < 300 {
+			return r, 0, fmt.Errorf("failed to decode response: %w", err)
+		}
+		// not one of ours, e.g. a proxy error page
+		return r, retryAfter(resp.Header), statusError(resp.StatusCode)
+	}
+	r.data = env.Data
+	if resp.StatusCode >= 300 {
+		return r, retryAfter(resp.Header), errorFromEnvelope(resp.StatusCode, &env)
+	}
+	return r, 0, nil
+}
+
+// statusError reports a response without an envelope by its HTTP status,
+// which is also the code of the server's generic errors.
+func statusError(status int) error {
+	return errors.Wrap(errors.Code(status), http.StatusText(status), &HTTPError{StatusCode: status})
+}
+
+func errorFromEnvelope(status int, env *envelope) error {
+	return errors.Wrap(errors.Code(env.Code), env.Message, &HTTPError{StatusCode: status, Data: env.Data})
+}
+
+func retryable(method string, r *reply, err error) bool {
+	if stderrors.Is(err, context.Canceled) || stderrors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	idempotent := method != http.MethodPost
+	if r == nil {
+		// transport error: the request may or may not have been handled
+		return idempotent
+	}
+	switch r.status {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent
+	}
+	return false
+}
+
+// retryAfter reads a Retry-After header given in seconds.
+func retryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// jitter spreads d over [d/2, d) so that clients failing together do not
+// retry together.
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
+
+// tokenCredentials sends the bearer token as gRPC metadata. It does not
+// require transport security, as the testbed's gRPC server runs without
+// it.
+type tokenCredentials TokenSource
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := t(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"isac-cran-system/pkg/errors"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	c, err := New(server.URL, WithToken("secret"), WithRetry(2, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestClientRetries(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"code": 401, "message": "unauthorized"}`)
+			return
+		}
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"code": 40001, "message": "database unavailable"}`)
+			return
+		}
+		fmt.Fprint(w, `{"code": 0, "message": "success", "data": {"experiment_id": "exp1", "status": 2}}`)
+	})
+
+	experiment, err := c.GetExperiment(context.Background(), "exp1")
+	if err != nil || experiment.ExperimentID != "exp1" || experiment.Status != ExperimentStatusCompleted {
+		t.Fatalf("GetExperiment = %+v, %v", experiment, err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("made %d attempts, want 3", n)
+	}
+
+	// a POST is not repeated after the server may have acted on it
+	calls.Store(0)
+	_, err = c.RunBeamforming(context.Background(), "exp2", &BeamformingParams{})
+	if !errors.IsCode(err, errors.CodeDBConnectError) || calls.Load() != 1 {
+		t.Errorf("RunBeamforming made %d attempts and returned %v", calls.Load(), err)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/algorithm/doa":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"code": 10001, "message": "1 invalid parameters", "data": [{"field": "source", "message": "must be one of synthetic, usrp, recording"}]}`)
+		case "/api/v1/algorithm/beamforming":
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprint(w, `{"code": 0, "message": "accepted", "data": {"experiment_id": "exp1", "device": "usrp"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "404 page not found")
+		}
+	})
+	ctx := context.Background()
+
+	_, err := c.RunDOA(ctx, "exp1", &DOAParams{Source: "radar"})
+	var httpErr *HTTPError
+	if !errors.IsCode(err, errors.CodeInvalidParam) || !stderrors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("RunDOA returned %v", err)
+	}
+	if string(httpErr.Data) == "" {
+		t.Error("violations were not kept")
+	}
+
+	_, err = c.RunBeamforming(ctx, "exp1", &BeamformingParams{})
+	var queued *QueuedError
+	if !stderrors.As(err, &queued) || queued.Queued.Device != "usrp" {
+		t.Errorf("RunBeamforming returned %v", err)
+	}
+
+	if _, err := c.GetExperiment(ctx, "missing"); !errors.IsCode(err, errors.CodeNotFound) {
+		t.Errorf("GetExperiment returned %v", err)
+	}
+}
+
+func TestClientWaitExperiment(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("wait") == "" {
+			t.Errorf("request %s does not long-poll", r.URL)
+		}
+		switch calls.Add(1) {
+		case 1:
+			w.Header().Set("ETag", `"running"`)
+			fmt.Fprint(w, `{"code": 0, "message": "success", "data": {"experiment_id": "exp1", "status": 1}}`)
+		case 2:
+			if r.Header.Get("If-None-Match") != `"running"` {
+				t.Errorf("If-None-Match = %q", r.Header.Get("If-None-Match"))
+			}
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			fmt.Fprint(w, `{"code": 0, "message": "success", "data": {"experiment_id": "exp1", "status": 3}}`)
+		}
+	})
+
+	experiment, err := c.WaitExperiment(context.Background(), "exp1")
+	if err != nil || experiment.Status != ExperimentStatusFailed || calls.Load() != 3 {
+		t.Errorf("WaitExperiment = %+v, %v after %d requests", experiment, err, calls.Load())
+	}
+}
+
+func TestClientEvents(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("topics") != "experiment.exp1,sensor" {
+			t.Errorf("topics = %q", r.URL.Query().Get("topics"))
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "event:experiment.exp1\ndata:{\"topic\":\"experiment.exp1\",\"time\":\"2025-01-01T00:00:00Z\",\"data\":{\"experiment_id\":\"exp1\",\"algorithm_type\":\"doa\",\"status\":2}}\n\n")
+		fmt.Fprint(w, "event:evicted\ndata:send buffer full\n\n")
+	})
+
+	stream, err := c.Events(context.Background(), "experiment.exp1", "sensor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	msg, err := stream.Next()
+	if err != nil || msg.Topic != "experiment.exp1" {
+		t.Fatalf("Next = %+v, %v", msg, err)
+	}
+	event, err := DecodeExperimentEvent(msg)
+	if err != nil || event.ExperimentID != "exp1" || event.Status != ExperimentStatusCompleted {
+		t.Errorf("event = %+v, %v", event, err)
+	}
+	if _, err := stream.Next(); err != ErrEvicted {
+		t.Errorf("Next after eviction = %v", err)
+	}
+	if _, err := stream.Next(); err != io.EOF {
+		t.Errorf("Next at the end = %v", err)
+	}
+}
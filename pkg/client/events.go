@@ -0,0 +1,120 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"isac-cran-system/pkg/hub"
+)
+
+// Experiment events are published on the "experiment.<id>" topic, and
+// subscribing to "experiment" receives those of every experiment.
+const TopicExperiment = "experiment"
+
+// ErrEvicted is returned by EventStream.Next when the server dropped the
+// stream because the client fell too far behind.
+var ErrEvicted = stderrors.New("event stream evicted: send buffer full")
+
+// EventStream reads the server-sent events of a subscription.
+type EventStream struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+}
+
+// Events subscribes to the stream topics, e.g. "sensor" or
+// "experiment.exp_001". The stream stays open until Close or until ctx
+// ends.
+func (c *Client) Events(ctx context.Context, topics ...string) (*EventStream, error) {
+	if len(topics) == 0 {
+		return nil, fmt.Errorf("at least one topic is required")
+	}
+	target := c.baseURL + "/stream/events?" + url.Values{"topics": {strings.Join(topics, ",")}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if c.token != nil {
+		token, err := c.token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	// the client's timeout would cut the stream
+	httpClient := *c.httpClient
+	httpClient.Timeout = 0
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var env envelope
+		if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+			return nil, statusError(resp.StatusCode)
+		}
+		return nil, errorFromEnvelope(resp.StatusCode, &env)
+	}
+	return &EventStream{body: resp.Body, reader: bufio.NewReader(resp.Body)}, nil
+}
+
+// Next blocks until the next event and returns it. It returns io.EOF when
+// the server ends the stream and ErrEvicted when it drops a slow client.
+func (s *EventStream) Next() (*hub.Message, error) {
+	var event, data string
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case line == "":
+			if event == "" && data == "" {
+				continue
+			}
+			if event == "evicted" {
+				return nil, ErrEvicted
+			}
+			var msg hub.Message
+			if err := json.Unmarshal([]byte(data), &msg); err != nil {
+				return nil, fmt.Errorf("failed to decode %s event: %w", event, err)
+			}
+			return &msg, nil
+		case strings.HasPrefix(line, ":"):
+			// keep-alive comment
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			chunk := strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " ")
+			if data != "" {
+				data += "\n"
+			}
+			data += chunk
+		}
+	}
+}
+
+func (s *EventStream) Close() error {
+	return s.body.Close()
+}
+
+// DecodeExperimentEvent decodes the data of an event published on an
+// experiment topic.
+func DecodeExperimentEvent(msg *hub.Message) (*ExperimentEvent, error) {
+	var event ExperimentEvent
+	if err := json.Unmarshal(msg.Data, &event); err != nil {
+		return nil, fmt.Errorf("failed to decode experiment event: %w", err)
+	}
+	return &event, nil
+}
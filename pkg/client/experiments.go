@@ -0,0 +1,220 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// longPoll is how long one GET of a result is held open by the server
+// waiting for a change; the server caps it at 25 s.
+const longPoll = 25 * time.Second
+
+// QueuedError is returned when the server accepted a run but has to wait
+// for a device reservation or a heavy experiment slot. The run goes ahead
+// once it is free; WaitExperiment follows it.
+type QueuedError struct {
+	Queued *QueuedExperiment
+}
+
+func (e *QueuedError) Error() string {
+	if e.Queued.BlockedBy == nil {
+		return fmt.Sprintf("experiment %s queued for a heavy experiment slot", e.Queued.ExperimentID)
+	}
+	return fmt.Sprintf("experiment %s queued until %s is free", e.Queued.ExperimentID, e.Queued.Device)
+}
+
+type cacheBypassKey struct{}
+
+// WithCacheBypass makes the runs made with ctx recompute their result
+// rather than reuse a cached one.
+func WithCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+var runPaths = map[AlgorithmType]string{
+	AlgorithmTypeBeamforming:       "/algorithm/beamforming",
+	AlgorithmTypeDOA:               "/algorithm/doa",
+	AlgorithmTypeIRSImpact:         "/algorithm/irs-impact",
+	AlgorithmTypeJointBeamforming:  "/algorithm/joint-beamforming",
+	AlgorithmTypeHybridBeamforming: "/algorithm/hybrid-beamforming",
+}
+
+type runRequest struct {
+	ExperimentID string      `json:"experiment_id"`
+	Params       interface{} `json:"params"`
+}
+
+func (c *Client) RunBeamforming(ctx context.Context, experimentID string, params *BeamformingParams) (*BeamformingResult, error) {
+	var result BeamformingResult
+	if err := c.run(ctx, AlgorithmTypeBeamforming, experimentID, params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) RunDOA(ctx context.Context, experimentID string, params *DOAParams) (*DOAResult, error) {
+	var result DOAResult
+	if err := c.run(ctx, AlgorithmTypeDOA, experimentID, params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) RunIRSImpact(ctx context.Context, experimentID string, params *IRSImpactParams) (*IRSImpactResult, error) {
+	var result IRSImpactResult
+	if err := c.run(ctx, AlgorithmTypeIRSImpact, experimentID, params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) RunJointBeamforming(ctx context.Context, experimentID string, params *JointBeamformingParams) (*JointBeamformingResult, error) {
+	var result JointBeamformingResult
+	if err := c.run(ctx, AlgorithmTypeJointBeamforming, experimentID, params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) RunHybridBeamforming(ctx context.Context, experimentID string, params *HybridBeamformingParams) (*HybridBeamformingResult, error) {
+	var result HybridBeamformingResult
+	if err := c.run(ctx, AlgorithmTypeHybridBeamforming, experimentID, params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) run(ctx context.Context, algorithmType AlgorithmType, experimentID string, params, out interface{}) error {
+	var query url.Values
+	if bypass, _ := ctx.Value(cacheBypassKey{}).(bool); bypass {
+		query = url.Values{"no_cache": {"true"}}
+	}
+	r, err := c.do(ctx, http.MethodPost, runPaths[algorithmType], query, nil, &runRequest{ExperimentID: experimentID, Params: params})
+	if err != nil {
+		return err
+	}
+	if r.status == http.StatusAccepted {
+		var queued QueuedExperiment
+		if err := r.decode(&queued); err != nil {
+			return err
+		}
+		return &QueuedError{Queued: &queued}
+	}
+	return r.decode(out)
+}
+
+// DryRun checks params for an algorithmType run and estimates its cost
+// without running it. params is the matching *BeamformingParams,
+// *DOAParams, *IRSImpactParams, *JointBeamformingParams or
+// *HybridBeamformingParams.
+func (c *Client) DryRun(ctx context.Context, algorithmType AlgorithmType, experimentID string, params interface{}) (*DryRunResult, error) {
+	path, ok := runPaths[algorithmType]
+	if !ok {
+		return nil, fmt.Errorf("no dry run for algorithm type %q", algorithmType)
+	}
+	r, err := c.do(ctx, http.MethodPost, path, url.Values{"dry_run": {"true"}}, nil, &runRequest{ExperimentID: experimentID, Params: params})
+	if err != nil {
+		return nil, err
+	}
+	var result DryRunResult
+	if err := r.decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) GetExperiment(ctx context.Context, experimentID string) (*Experiment, error) {
+	var experiment Experiment
+	if err := c.get(ctx, "/algorithm/result/"+url.PathEscape(experimentID), nil, &experiment); err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+type ExperimentPage struct {
+	List     []Experiment `json:"list"`
+	Total    int64        `json:"total"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"page_size"`
+}
+
+// ListExperiments returns one page of the stored experiments matching q;
+// a nil q lists the first page of all of them.
+func (c *Client) ListExperiments(ctx context.Context, q *ExperimentQuery) (*ExperimentPage, error) {
+	query := url.Values{}
+	if q != nil {
+		if q.AlgorithmType != "" {
+			query.Set("algorithm_type", string(q.AlgorithmType))
+		}
+		if q.Status != nil {
+			query.Set("status", strconv.Itoa(int(*q.Status)))
+		}
+		if !q.StartTime.IsZero() {
+			query.Set("start_time", q.StartTime.Format("2006-01-02T15:04:05"))
+		}
+		if !q.EndTime.IsZero() {
+			query.Set("end_time", q.EndTime.Format("2006-01-02T15:04:05"))
+		}
+		if q.Sort != "" {
+			query.Set("sort", q.Sort)
+		}
+		if q.Order != "" {
+			query.Set("order", q.Order)
+		}
+		if q.Page > 0 {
+			query.Set("page", strconv.Itoa(q.Page))
+		}
+		if q.PageSize > 0 {
+			query.Set("page_size", strconv.Itoa(q.PageSize))
+		}
+	}
+
+	var page ExperimentPage
+	if err := c.get(ctx, "/algorithm/results", query, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// WaitExperiment blocks until the experiment has completed or failed, or
+// ctx ends, and returns its final record. It long-polls the result, so
+// the server answers as soon as the status changes.
+func (c *Client) WaitExperiment(ctx context.Context, experimentID string) (*Experiment, error) {
+	path := "/algorithm/result/" + url.PathEscape(experimentID)
+	query := url.Values{"wait": {longPoll.String()}}
+
+	var experiment Experiment
+	etag := ""
+	for {
+		var header http.Header
+		if etag != "" {
+			header = http.Header{"If-None-Match": {etag}}
+		}
+		r, err := c.do(ctx, http.MethodGet, path, query, header, nil)
+		if err != nil {
+			return nil, err
+		}
+		if r.status == http.StatusNotModified {
+			continue
+		}
+		if err := r.decode(&experiment); err != nil {
+			return nil, err
+		}
+		if experiment.Status == ExperimentStatusCompleted || experiment.Status == ExperimentStatusFailed {
+			return &experiment, nil
+		}
+		etag = r.header.Get("ETag")
+		if etag == "" {
+			// no long poll without a tag to compare against
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(defaultBackoff):
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package client
+
+import "isac-cran-system/internal/model"
+
+// The request and result types are the server's own, aliased here so that
+// programs outside this module can name them and they never drift from
+// what the server accepts.
+type (
+	Experiment       = model.ExperimentResult
+	ExperimentQuery  = model.ExperimentQuery
+	ExperimentStatus = model.ExperimentStatus
+	ExperimentEvent  = model.ExperimentEvent
+	AlgorithmType    = model.AlgorithmType
+	QueuedExperiment = model.QueuedExperiment
+	DryRunResult     = model.DryRunResult
+	Diagnostics      = model.Diagnostics
+
+	BeamformingParams       = model.BeamformingParams
+	BeamformingResult       = model.BeamformingResult
+	DOAParams               = model.DOAParams
+	DOAResult               = model.DOAResult
+	IRSImpactParams         = model.IRSImpactParams
+	IRSImpactResult         = model.IRSImpactResult
+	JointBeamformingParams  = model.JointBeamformingParams
+	JointBeamformingResult  = model.JointBeamformingResult
+	HybridBeamformingParams = model.HybridBeamformingParams
+	HybridBeamformingResult = model.HybridBeamformingResult
+
+	BeamSweepRequest = model.BeamSweepRequest
+	BeamSweep        = model.BeamSweep
+	BeamSweepStatus  = model.BeamSweepStatus
+	BeamSweepResult  = model.BeamSweepResult
+	BeamMeasurement  = model.BeamMeasurement
+)
+
+const (
+	ExperimentStatusPending   = model.ExperimentStatusPending
+	ExperimentStatusRunning   = model.ExperimentStatusRunning
+	ExperimentStatusCompleted = model.ExperimentStatusCompleted
+	ExperimentStatusFailed    = model.ExperimentStatusFailed
+
+	AlgorithmTypeBeamforming       = model.AlgorithmTypeBeamforming
+	AlgorithmTypeDOA               = model.AlgorithmTypeDOA
+	AlgorithmTypeIRSImpact         = model.AlgorithmTypeIRSImpact
+	AlgorithmTypeJointBeamforming  = model.AlgorithmTypeJointBeamforming
+	AlgorithmTypeHybridBeamforming = model.AlgorithmTypeHybridBeamforming
+
+	BeamTargetIRS      = model.BeamTargetIRS
+	BeamTargetArray    = model.BeamTargetArray
+	BeamSweepRunning   = model.BeamSweepRunning
+	BeamSweepTracking  = model.BeamSweepTracking
+	BeamSweepCompleted = model.BeamSweepCompleted
+	BeamSweepFailed    = model.BeamSweepFailed
+)
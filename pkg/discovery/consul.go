@@ -94,35 +94,45 @@ func (d *ServiceDiscovery) Discover(serviceName string) ([]*ServiceInfo, error)
 }
 
 func (d *ServiceDiscovery) Watch(serviceName string, callback func([]*ServiceInfo)) {
-	go func() {
-		var lastIndex uint64
-		for {
-			services, meta, err := d.client.Health().Service(serviceName, "", true, &api.QueryOptions{
-				WaitIndex: lastIndex,
-				WaitTime:  30 * time.Second,
-			})
-			if err != nil {
-				log.Printf("Watch error: %v", err)
-				time.Sleep(5 * time.Second)
-				continue
+	go d.WatchContext(context.Background(), serviceName, callback)
+}
+
+// WatchContext calls callback with the healthy instances of serviceName
+// whenever they change, until ctx is done.
+func (d *ServiceDiscovery) WatchContext(ctx context.Context, serviceName string, callback func([]*ServiceInfo)) {
+	var lastIndex uint64
+	for ctx.Err() == nil {
+		services, meta, err := d.client.Health().Service(serviceName, "", true, (&api.QueryOptions{
+			WaitIndex: lastIndex,
+			WaitTime:  30 * time.Second,
+		}).WithContext(ctx))
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Watch error: %v", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(5 * time.Second):
 			}
+			continue
+		}
 
-			if meta.LastIndex > lastIndex {
-				lastIndex = meta.LastIndex
-				var results []*ServiceInfo
-				for _, service := range services {
-					results = append(results, &ServiceInfo{
-						ID:      service.Service.ID,
-						Name:    service.Service.Service,
-						Address: service.Service.Address,
-						Port:    service.Service.Port,
-						Tags:    service.Service.Tags,
-					})
-				}
-				callback(results)
+		if meta.LastIndex > lastIndex {
+			lastIndex = meta.LastIndex
+			var results []*ServiceInfo
+			for _, service := range services {
+				results = append(results, &ServiceInfo{
+					ID:      service.Service.ID,
+					Name:    service.Service.Service,
+					Address: service.Service.Address,
+					Port:    service.Service.Port,
+					Tags:    service.Service.Tags,
+				})
 			}
+			callback(results)
 		}
-	}()
+	}
 }
 
 func (d *ServiceDiscovery) HealthCheck(ctx context.Context) error {
@@ -0,0 +1,85 @@
+package election
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// ConsulBackend holds the leader lock on a Consul key through a session.
+// The session is renewed while the process lives; if the leader dies or
+// loses Consul, the session expires after its TTL and another replica
+// acquires the key.
+type ConsulBackend struct {
+	client *api.Client
+	key    string
+	ttl    string
+
+	mu   sync.Mutex
+	lock *api.Lock
+}
+
+// NewConsulBackend campaigns for key on the Consul agent at consulAddr. ttl
+// is how long a vanished leader keeps the lock, as a Consul duration such
+// as "15s".
+func NewConsulBackend(consulAddr, key, ttl string) (*ConsulBackend, error) {
+	config := api.DefaultConfig()
+	config.Address = consulAddr
+
+	client, err := api.NewClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consul client: %w", err)
+	}
+	return &ConsulBackend{client: client, key: key, ttl: ttl}, nil
+}
+
+func (b *ConsulBackend) Acquire(ctx context.Context, node string) (<-chan struct{}, error) {
+	lock, err := b.client.LockOpts(&api.LockOptions{
+		Key:        b.key,
+		Value:      []byte(node),
+		SessionTTL: b.ttl,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create leader lock: %w", err)
+	}
+	lost, err := lock.Lock(ctx.Done())
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire leader lock: %w", err)
+	}
+	if lost == nil {
+		return nil, nil
+	}
+	b.mu.Lock()
+	b.lock = lock
+	b.mu.Unlock()
+	return lost, nil
+}
+
+func (b *ConsulBackend) Release() error {
+	b.mu.Lock()
+	lock := b.lock
+	b.lock = nil
+	b.mu.Unlock()
+	if lock == nil {
+		return nil
+	}
+	if err := lock.Unlock(); err != nil && err != api.ErrLockNotHeld {
+		return fmt.Errorf("failed to release leader lock: %w", err)
+	}
+	return nil
+}
+
+// Leader reads the holder from the key, which is only held while a session
+// locks it.
+func (b *ConsulBackend) Leader() (string, error) {
+	pair, _, err := b.client.KV().Get(b.key, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to read leader: %w", err)
+	}
+	if pair == nil || pair.Session == "" {
+		return "", nil
+	}
+	return string(pair.Value), nil
+}
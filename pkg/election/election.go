@@ -0,0 +1,181 @@
+// Package election elects one of several server replicas to run the
+// background jobs that must not run twice, and hands them to another replica
+// when the leader goes away.
+package election
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// retryInterval is how long a replica waits before campaigning again after
+// the backend failed.
+const retryInterval = 5 * time.Second
+
+// Backend is a lock shared by all replicas.
+type Backend interface {
+	// Acquire blocks until node holds the lock, or returns a nil channel
+	// once ctx is done. The returned channel is closed when the lock is lost.
+	Acquire(ctx context.Context, node string) (<-chan struct{}, error)
+	// Release gives the lock up if node holds it.
+	Release() error
+	// Leader is the node holding the lock, or "" if none does.
+	Leader() (string, error)
+}
+
+// Job runs on the leader until ctx is cancelled, which happens when the
+// node loses leadership or shuts down.
+type Job func(ctx context.Context)
+
+// Status reports the election as seen by one replica.
+type Status struct {
+	Enabled bool   `json:"enabled"`
+	Node    string `json:"node"`
+	Leader  string `json:"leader"`
+	// IsLeader is set while this node runs the jobs; LeaderSince is when it
+	// was last elected.
+	IsLeader    bool      `json:"is_leader"`
+	LeaderSince time.Time `json:"leader_since,omitempty"`
+	Jobs        []string  `json:"jobs"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Elector runs the registered jobs while its node is the leader. Without a
+// backend the node is the only replica and always leads.
+type Elector struct {
+	backend Backend
+	node    string
+
+	mu      sync.Mutex
+	jobs    map[string]Job
+	leading bool
+	since   time.Time
+	lastErr error
+}
+
+func NewElector(backend Backend, node string) *Elector {
+	return &Elector{
+		backend: backend,
+		node:    node,
+		jobs:    make(map[string]Job),
+	}
+}
+
+// Register adds a job to run on the leader. Jobs must be registered before
+// Run.
+func (e *Elector) Register(name string, job Job) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.jobs[name] = job
+}
+
+// Run campaigns for leadership until ctx is done, starting the jobs each
+// time the node is elected and stopping them when it loses the lock.
+func (e *Elector) Run(ctx context.Context) {
+	if e.backend == nil {
+		e.lead(ctx, nil)
+		return
+	}
+	for ctx.Err() == nil {
+		lost, err := e.backend.Acquire(ctx, e.node)
+		if err != nil {
+			e.setErr(err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryInterval):
+			}
+			continue
+		}
+		if lost == nil {
+			return
+		}
+		e.setErr(nil)
+		e.lead(ctx, lost)
+	}
+}
+
+// lead runs the jobs until ctx is done or lost is closed, and waits for them
+// to stop before giving the lock up.
+func (e *Elector) lead(ctx context.Context, lost <-chan struct{}) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	e.mu.Lock()
+	e.leading = true
+	e.since = time.Now()
+	var wg sync.WaitGroup
+	for _, job := range e.jobs {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			job(jobCtx)
+		}(job)
+	}
+	e.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+	case <-lost:
+	}
+	cancel()
+	wg.Wait()
+
+	e.mu.Lock()
+	e.leading = false
+	e.mu.Unlock()
+	if e.backend != nil {
+		if err := e.backend.Release(); err != nil {
+			e.setErr(err)
+		}
+	}
+}
+
+func (e *Elector) setErr(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lastErr = err
+}
+
+// IsLeader reports whether the node currently runs the jobs.
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leading
+}
+
+// Status reports the current leader, asking the backend so that followers
+// know it too.
+func (e *Elector) Status() Status {
+	e.mu.Lock()
+	status := Status{
+		Enabled:  e.backend != nil,
+		Node:     e.node,
+		IsLeader: e.leading,
+		Jobs:     make([]string, 0, len(e.jobs)),
+	}
+	if e.leading {
+		status.Leader = e.node
+		status.LeaderSince = e.since
+	}
+	for name := range e.jobs {
+		status.Jobs = append(status.Jobs, name)
+	}
+	lastErr := e.lastErr
+	e.mu.Unlock()
+	sort.Strings(status.Jobs)
+
+	if e.backend != nil {
+		leader, err := e.backend.Leader()
+		if err != nil {
+			lastErr = err
+		} else {
+			status.Leader = leader
+		}
+	}
+	if lastErr != nil {
+		status.Error = lastErr.Error()
+	}
+	return status
+}
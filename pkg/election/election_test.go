@@ -0,0 +1,175 @@
+package election
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memLock is a lock shared by the backends of one test, standing in for
+// Consul.
+type memLock struct {
+	mu     sync.Mutex
+	holder string
+	lost   chan struct{}
+	freed  chan struct{}
+}
+
+func newMemLock() *memLock {
+	return &memLock{freed: make(chan struct{})}
+}
+
+// expire takes the lock from its holder as an expired session would.
+func (l *memLock) expire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holder == "" {
+		return
+	}
+	close(l.lost)
+	l.holder = ""
+	close(l.freed)
+	l.freed = make(chan struct{})
+}
+
+type memBackend struct {
+	lock *memLock
+	node string
+}
+
+func (b *memBackend) Acquire(ctx context.Context, node string) (<-chan struct{}, error) {
+	for {
+		b.lock.mu.Lock()
+		if b.lock.holder == "" {
+			b.lock.holder = node
+			b.lock.lost = make(chan struct{})
+			b.node = node
+			lost := b.lock.lost
+			b.lock.mu.Unlock()
+			return lost, nil
+		}
+		freed := b.lock.freed
+		b.lock.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-freed:
+		}
+	}
+}
+
+func (b *memBackend) Release() error {
+	b.lock.mu.Lock()
+	holds := b.lock.holder == b.node
+	b.lock.mu.Unlock()
+	if holds {
+		b.lock.expire()
+	}
+	return nil
+}
+
+func (b *memBackend) Leader() (string, error) {
+	b.lock.mu.Lock()
+	defer b.lock.mu.Unlock()
+	return b.lock.holder, nil
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestElector_Failover(t *testing.T) {
+	lock := newMemLock()
+	var running [2]atomic.Int32
+	var starts atomic.Int32
+	electors := make([]*Elector, 2)
+	cancels := make([]context.CancelFunc, 2)
+	done := make([]chan struct{}, 2)
+	for i, node := range []string{"node-a", "node-b"} {
+		i := i
+		electors[i] = NewElector(&memBackend{lock: lock}, node)
+		electors[i].Register("gc", func(ctx context.Context) {
+			running[i].Add(1)
+			starts.Add(1)
+			<-ctx.Done()
+			running[i].Add(-1)
+		})
+		var ctx context.Context
+		ctx, cancels[i] = context.WithCancel(context.Background())
+		done[i] = make(chan struct{})
+		go func() {
+			defer close(done[i])
+			electors[i].Run(ctx)
+		}()
+	}
+	defer func() {
+		for i := range cancels {
+			cancels[i]()
+			<-done[i]
+		}
+	}()
+
+	waitFor(t, "a leader", func() bool { return running[0].Load()+running[1].Load() == 1 })
+	leader := 0
+	if running[1].Load() == 1 {
+		leader = 1
+	}
+	follower := 1 - leader
+	status := electors[follower].Status()
+	if status.IsLeader || status.Leader != electors[leader].node || !status.Enabled {
+		t.Errorf("follower status %+v, want leader %s", status, electors[leader].node)
+	}
+
+	// a lost session hands the jobs over, and the old leader stops its own
+	lock.expire()
+	waitFor(t, "the jobs to run exactly once after failover", func() bool {
+		return starts.Load() == 2 && running[0].Load()+running[1].Load() == 1
+	})
+
+	// a leader that shuts down releases the lock for the other replica
+	current := 0
+	if running[1].Load() == 1 {
+		current = 1
+	}
+	cancels[current]()
+	<-done[current]
+	waitFor(t, "the other replica to take over", func() bool {
+		return running[current].Load() == 0 && running[1-current].Load() == 1
+	})
+	if got := electors[1-current].Status(); !got.IsLeader || got.Leader != electors[1-current].node {
+		t.Errorf("new leader status %+v", got)
+	}
+}
+
+func TestElector_SingleReplica(t *testing.T) {
+	e := NewElector(nil, "solo")
+	started := make(chan struct{})
+	e.Register("tiering", func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.Run(ctx)
+	}()
+	<-started
+	if status := e.Status(); status.Enabled || !status.IsLeader || status.Leader != "solo" {
+		t.Errorf("status %+v, want the single replica to lead", status)
+	}
+	cancel()
+	<-done
+	if e.IsLeader() {
+		t.Error("still leading after shutdown")
+	}
+}
@@ -17,12 +17,16 @@ const (
 	CodeInvalidChannelID Code = 10003
 	CodeInvalidAlgorithm Code = 10004
 
-	CodeIRSDeviceError  Code = 20001
-	CodeIRSConfigFailed Code = 20002
-	CodeIRSStatusError  Code = 20003
+	CodeIRSDeviceError     Code = 20001
+	CodeIRSConfigFailed    Code = 20002
+	CodeIRSStatusError     Code = 20003
+	CodeIRSOverTemperature Code = 20004
+	CodeIRSPowerLoss       Code = 20005
+	CodeIRSStatusStale     Code = 20006
 
-	CodeUSRPDeviceError  Code = 21001
-	CodeUSRPReceiveError Code = 21002
+	CodeUSRPDeviceError   Code = 21001
+	CodeUSRPReceiveError  Code = 21002
+	CodeUSRPTransmitError Code = 21003
 
 	CodeSensorConnectError Code = 22001
 	CodeSensorDataError    Code = 22002
@@ -46,11 +50,18 @@ const (
 	CodeMQTTConnectError Code = 43001
 	CodeMQTTPublishError Code = 43002
 
+	CodeObjectStoreError Code = 44001
+
 	CodeMATLABExportError Code = 50001
 	CodeMATLABImportError Code = 50002
 
 	CodeExperimentNotFound Code = 60001
 	CodeExperimentRunning  Code = 60002
+	CodeDeviceReserved     Code = 60003
+	CodeVersionConflict    Code = 60004
+	// the experiment would exceed the admission budgets
+	CodeExperimentTooLong   Code = 60005
+	CodeExperimentQueueFull Code = 60006
 )
 
 var codeMessages = map[Code]string{
@@ -67,12 +78,16 @@ var codeMessages = map[Code]string{
 	CodeInvalidChannelID: "invalid channel ID",
 	CodeInvalidAlgorithm: "invalid algorithm type",
 
-	CodeIRSDeviceError:  "IRS device error",
-	CodeIRSConfigFailed: "IRS configuration failed",
-	CodeIRSStatusError:  "IRS status error",
+	CodeIRSDeviceError:     "IRS device error",
+	CodeIRSConfigFailed:    "IRS configuration failed",
+	CodeIRSStatusError:     "IRS status error",
+	CodeIRSOverTemperature: "IRS over temperature",
+	CodeIRSPowerLoss:       "IRS power lost",
+	CodeIRSStatusStale:     "IRS status stale",
 
-	CodeUSRPDeviceError:  "USRP device error",
-	CodeUSRPReceiveError: "USRP receive error",
+	CodeUSRPDeviceError:   "USRP device error",
+	CodeUSRPReceiveError:  "USRP receive error",
+	CodeUSRPTransmitError: "USRP transmit error",
 
 	CodeSensorConnectError: "sensor connection error",
 	CodeSensorDataError:    "sensor data error",
@@ -96,11 +111,17 @@ var codeMessages = map[Code]string{
 	CodeMQTTConnectError: "mqtt connection error",
 	CodeMQTTPublishError: "mqtt publish error",
 
+	CodeObjectStoreError: "object store error",
+
 	CodeMATLABExportError: "matlab export error",
 	CodeMATLABImportError: "matlab import error",
 
-	CodeExperimentNotFound: "experiment not found",
-	CodeExperimentRunning:  "experiment is running",
+	CodeExperimentNotFound:  "experiment not found",
+	CodeExperimentRunning:   "experiment is running",
+	CodeDeviceReserved:      "device reserved",
+	CodeVersionConflict:     "record was modified concurrently",
+	CodeExperimentTooLong:   "experiment exceeds the runtime budget",
+	CodeExperimentQueueFull: "experiment queue is full",
 }
 
 func (c Code) Message() string {
@@ -105,6 +105,12 @@ func (e *AppError) HTTPStatus() int {
 		return http.StatusForbidden
 	case e.Code == CodeNotFound:
 		return http.StatusNotFound
+	case e.Code == CodeDeviceReserved, e.Code == CodeVersionConflict:
+		return http.StatusConflict
+	case e.Code == CodeExperimentTooLong:
+		return http.StatusUnprocessableEntity
+	case e.Code == CodeExperimentQueueFull:
+		return http.StatusTooManyRequests
 	case e.Code >= 10001 && e.Code < 20000:
 		return http.StatusBadRequest
 	default:
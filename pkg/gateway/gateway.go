@@ -7,6 +7,7 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"isac-cran-system/pkg/discovery"
@@ -31,6 +32,7 @@ type Gateway struct {
 }
 
 type RateLimiter struct {
+	mu       sync.Mutex
 	requests map[string]*ClientInfo
 	limit    int
 	window   time.Duration
@@ -50,6 +52,8 @@ func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
 }
 
 func (rl *RateLimiter) Allow(clientID string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
 	now := time.Now()
 	info, exists := rl.requests[clientID]
 
@@ -82,22 +86,25 @@ func (g *Gateway) AddRoute(route Route) {
 	g.routes = append(g.routes, route)
 }
 
+// SetupRoutes proxies each route's path and everything below it.
 func (g *Gateway) SetupRoutes(r *gin.Engine) {
 	for _, route := range g.routes {
 		handler := g.createProxyHandler(route)
 
-		for _, method := range route.Methods {
-			switch method {
-			case "GET":
-				r.GET(route.Path, handler)
-			case "POST":
-				r.POST(route.Path, handler)
-			case "PUT":
-				r.PUT(route.Path, handler)
-			case "DELETE":
-				r.DELETE(route.Path, handler)
-			case "PATCH":
-				r.PATCH(route.Path, handler)
+		for _, path := range []string{route.Path, route.Path + "/*path"} {
+			for _, method := range route.Methods {
+				switch method {
+				case "GET":
+					r.GET(path, handler)
+				case "POST":
+					r.POST(path, handler)
+				case "PUT":
+					r.PUT(path, handler)
+				case "DELETE":
+					r.DELETE(path, handler)
+				case "PATCH":
+					r.PATCH(path, handler)
+				}
 			}
 		}
 	}
@@ -163,11 +170,32 @@ func (g *Gateway) Start(ctx context.Context, port int) error {
 	return srv.ListenAndServe()
 }
 
+// DefaultRoutes maps the API of every version onto the services of a split
+// deployment. Pipelines and live streams stay with the devices they drive.
 func DefaultRoutes() []Route {
+	methods := []string{"GET", "POST", "PUT", "DELETE"}
+	route := func(prefix, service string) Route {
+		return Route{Path: "/api/:version" + prefix, Service: service, Methods: methods}
+	}
 	return []Route{
-		{Path: "/api/v1/irs", Service: "irs-service", Methods: []string{"GET", "POST"}, StripPath: false},
-		{Path: "/api/v1/algorithm", Service: "algorithm-service", Methods: []string{"GET", "POST"}, StripPath: false},
-		{Path: "/api/v1/sensor", Service: "sensor-service", Methods: []string{"GET", "POST"}, StripPath: false},
-		{Path: "/api/v1/channel", Service: "channel-service", Methods: []string{"GET", "POST"}, StripPath: false},
+		route("/algorithm", "algorithm-service"),
+		route("/artifacts", "algorithm-service"),
+		route("/objects", "algorithm-service"),
+		route("/graphql", "algorithm-service"),
+		route("/irs", "device-service"),
+		route("/admin", "device-service"),
+		route("/channel", "device-service"),
+		route("/beams", "device-service"),
+		route("/datasets", "device-service"),
+		route("/usrp", "device-service"),
+		route("/devices", "device-service"),
+		route("/power", "device-service"),
+		route("/reservations", "device-service"),
+		route("/recordings", "device-service"),
+		route("/sessions", "device-service"),
+		route("/pipelines", "device-service"),
+		route("/stream", "device-service"),
+		route("/sensor", "sensor-service"),
+		route("/alerts", "sensor-service"),
 	}
 }
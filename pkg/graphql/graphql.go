@@ -0,0 +1,559 @@
+// Package graphql executes read-only GraphQL queries against a schema of
+// resolver functions, so that clients can fetch nested data in one round
+// trip. It supports the query language needed for that — operations,
+// variables, aliases, arguments and fragments — but not mutations,
+// subscriptions, directives or introspection beyond __typename.
+//
+// It is kept in the tree rather than taken from a GraphQL library because
+// the API only serves a fixed read-only schema of resolver functions: the
+// subset above covers it without a new dependency, and owning the validator
+// is what lets every query be bounded in depth and fields before any
+// resolver runs.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"isac-cran-system/pkg/errors"
+)
+
+// ResolveFunc returns the value of a field. A nil ResolveFunc reads the
+// field from the parent value, see ResolveField.
+type ResolveFunc func(p ResolveParams) (interface{}, error)
+
+type ResolveParams struct {
+	Context context.Context
+	// Source is the value of the parent object.
+	Source interface{}
+	Args   Args
+}
+
+// Object is a GraphQL object type.
+type Object struct {
+	Name   string
+	Fields map[string]*Field
+}
+
+// Field is a field of an object. A field with a nil Type is a leaf and its
+// value is written out as JSON; otherwise the value, or each element of a
+// slice value, is resolved against Type.
+type Field struct {
+	Type    *Object
+	Args    []string
+	Resolve ResolveFunc
+}
+
+// StructObject returns an object with a leaf field for every json-tagged
+// field of sample, a struct or a pointer to one.
+func StructObject(name string, sample interface{}) *Object {
+	obj := &Object{Name: name, Fields: make(map[string]*Field)}
+	t := reflect.TypeOf(sample)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for _, f := range reflect.VisibleFields(t) {
+		if key := jsonName(f); key != "" {
+			obj.Fields[key] = &Field{}
+		}
+	}
+	return obj
+}
+
+// Default limits of a schema. Lists are not counted element by element, so
+// the field budget bounds the selections after fragments are expanded; the
+// resolvers bound list lengths with their page sizes.
+const (
+	DefaultMaxDepth  = 10
+	DefaultMaxFields = 500
+)
+
+// Schema is the query type and the limits a query must stay within to be
+// executed: MaxDepth levels of nested fields and MaxFields fields in all,
+// counting the fields of a fragment each time it is spread. Zero disables a
+// limit.
+type Schema struct {
+	Query     *Object
+	MaxDepth  int
+	MaxFields int
+}
+
+func NewSchema(query *Object) *Schema {
+	return &Schema{Query: query, MaxDepth: DefaultMaxDepth, MaxFields: DefaultMaxFields}
+}
+
+// Args holds the arguments of a field with variables substituted. Numbers
+// may be int64 or, when they come from variables, float64.
+type Args map[string]interface{}
+
+// String returns argument name, or "" if it is not set.
+func (a Args) String(name string) string {
+	switch v := a[name].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Int returns argument name, or def if it is not set.
+func (a Args) Int(name string, def int) (int, error) {
+	switch v := a[name].(type) {
+	case nil:
+		return def, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("argument %q must be an integer", name)
+}
+
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type Error struct {
+	Message    string                 `json:"message"`
+	Locations  []Location             `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Do runs the operation of req. If the request cannot be parsed or does not
+// validate against the schema, the response has no data and the request
+// errors only; otherwise field errors come alongside the data.
+func Do(ctx context.Context, schema *Schema, req Request) *Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		var gqlErr *Error
+		if !stderrors.As(err, &gqlErr) {
+			gqlErr = &Error{Message: err.Error()}
+		}
+		return &Response{Errors: []*Error{gqlErr}}
+	}
+	op, errs := validate(schema, doc, req)
+	if len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+	vars, errs := coerceVariables(op, req.Variables)
+	if len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+
+	e := &executor{ctx: ctx, doc: doc, vars: vars}
+	data := e.selectionSet(schema.Query, nil, op.sel, nil)
+	return &Response{Data: data, Errors: e.errs}
+}
+
+type executor struct {
+	ctx  context.Context
+	doc  *document
+	vars map[string]interface{}
+	errs []*Error
+}
+
+func (e *executor) selectionSet(obj *Object, source interface{}, sel []selection, path []interface{}) *orderedMap {
+	result := &orderedMap{values: make(map[string]interface{})}
+	for _, group := range e.collect(obj, sel, nil) {
+		f := group[0]
+		key := f.key()
+		fieldPath := append(append([]interface{}(nil), path...), key)
+		if f.name == "__typename" {
+			result.set(key, obj.Name)
+			continue
+		}
+
+		def := obj.Fields[f.name]
+		var subsel []selection
+		for _, g := range group {
+			subsel = append(subsel, g.sel...)
+		}
+		v, err := e.resolve(def, source, f)
+		if err != nil {
+			e.fieldError(err, f, fieldPath)
+			result.set(key, nil)
+			continue
+		}
+		result.set(key, e.complete(def.Type, v, subsel, fieldPath))
+	}
+	return result
+}
+
+// collect groups the fields of sel by response key, following fragments,
+// so that fields asked for twice are resolved once.
+func (e *executor) collect(obj *Object, sel []selection, groups [][]*field) [][]*field {
+	for _, s := range sel {
+		switch s := s.(type) {
+		case *field:
+			found := false
+			for i, g := range groups {
+				if g[0].key() == s.key() {
+					groups[i] = append(g, s)
+					found = true
+					break
+				}
+			}
+			if !found {
+				groups = append(groups, []*field{s})
+			}
+		case *fragmentSpread:
+			groups = e.collect(obj, e.doc.fragments[s.name].sel, groups)
+		case *inlineFragment:
+			groups = e.collect(obj, s.sel, groups)
+		}
+	}
+	return groups
+}
+
+func (e *executor) resolve(def *Field, source interface{}, f *field) (interface{}, error) {
+	args := make(Args, len(f.args))
+	for _, arg := range f.args {
+		args[arg.name] = e.substitute(arg.val)
+	}
+	if def.Resolve == nil {
+		return ResolveField(source, f.name), nil
+	}
+	return def.Resolve(ResolveParams{Context: e.ctx, Source: source, Args: args})
+}
+
+func (e *executor) substitute(v value) interface{} {
+	switch v := v.(type) {
+	case variable:
+		return e.vars[string(v)]
+	case listValue:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = e.substitute(item)
+		}
+		return list
+	case objectValue:
+		obj := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			obj[k] = e.substitute(item)
+		}
+		return obj
+	}
+	return v
+}
+
+func (e *executor) complete(typ *Object, v interface{}, sel []selection, path []interface{}) interface{} {
+	if isNil(v) {
+		return nil
+	}
+	if typ == nil {
+		return v
+	}
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			itemPath := append(append([]interface{}(nil), path...), i)
+			list[i] = e.complete(typ, rv.Index(i).Interface(), sel, itemPath)
+		}
+		return list
+	}
+	return e.selectionSet(typ, v, sel, path)
+}
+
+func (e *executor) fieldError(err error, f *field, path []interface{}) {
+	gqlErr := &Error{Message: err.Error(), Locations: []Location{f.loc}, Path: path}
+	var appErr *errors.AppError
+	if stderrors.As(err, &appErr) {
+		gqlErr.Message = appErr.Message
+		gqlErr.Extensions = map[string]interface{}{"code": appErr.Code}
+	}
+	e.errs = append(e.errs, gqlErr)
+}
+
+// ResolveField reads field name from source: a map key, or the struct field
+// with that json name.
+func ResolveField(source interface{}, name string) interface{} {
+	if m, ok := source.(map[string]interface{}); ok {
+		return m[name]
+	}
+	rv := reflect.ValueOf(source)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	for _, f := range reflect.VisibleFields(rv.Type()) {
+		if jsonName(f) == name {
+			fv, err := rv.FieldByIndexErr(f.Index)
+			if err != nil {
+				return nil
+			}
+			return fv.Interface()
+		}
+	}
+	return nil
+}
+
+func jsonName(f reflect.StructField) string {
+	if !f.IsExported() || f.Anonymous {
+		return ""
+	}
+	tag := f.Tag.Get("json")
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// orderedMap is a result object; its keys are written out in the order the
+// query selected them.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) set(key string, v interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = v
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		b.Write(k)
+		b.WriteByte(':')
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return []byte(b.String()), nil
+}
+
+func coerceVariables(op *operation, given map[string]interface{}) (map[string]interface{}, []*Error) {
+	vars := make(map[string]interface{}, len(op.vars))
+	var errs []*Error
+	for _, def := range op.vars {
+		v, ok := given[def.name]
+		switch {
+		case ok && v != nil:
+			vars[def.name] = v
+		case !ok && def.def != nil:
+			vars[def.name] = def.def
+		case def.nonNull:
+			errs = append(errs, &Error{
+				Message:   fmt.Sprintf("variable $%s of required type %s! was not provided", def.name, def.typ),
+				Locations: []Location{def.loc},
+			})
+		}
+	}
+	return vars, errs
+}
+
+func validate(schema *Schema, doc *document, req Request) (*operation, []*Error) {
+	var op *operation
+	switch {
+	case req.OperationName != "":
+		for _, o := range doc.operations {
+			if o.name == req.OperationName {
+				op = o
+			}
+		}
+		if op == nil {
+			return nil, []*Error{{Message: fmt.Sprintf("unknown operation %q", req.OperationName)}}
+		}
+	case len(doc.operations) > 1:
+		return nil, []*Error{{Message: "operationName is required when the document has several operations"}}
+	default:
+		op = doc.operations[0]
+	}
+	if op.kind != "query" {
+		return nil, []*Error{{Message: op.kind + " operations are not supported", Locations: []Location{op.loc}}}
+	}
+
+	v := &validator{doc: doc, defined: make(map[string]bool), maxDepth: schema.MaxDepth, maxFields: schema.MaxFields}
+	for _, def := range op.vars {
+		v.defined[def.name] = true
+	}
+	v.selectionSet(schema.Query, op.sel, nil, 1)
+	if v.overBudget {
+		return op, []*Error{{Message: fmt.Sprintf("query selects more than %d fields", v.maxFields), Locations: []Location{op.loc}}}
+	}
+
+	names := make([]string, 0, len(doc.fragments))
+	for name := range doc.fragments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if frag := doc.fragments[name]; !v.used[name] {
+			v.errorf(frag.loc, "fragment %q is never used", name)
+		}
+	}
+	return op, v.errs
+}
+
+type validator struct {
+	doc     *document
+	defined map[string]bool
+	used    map[string]bool
+	errs    []*Error
+
+	maxDepth, maxFields int
+	// fields counts the fields validated so far; once it passes maxFields
+	// the rest of the query is skipped.
+	fields     int
+	overBudget bool
+}
+
+func (v *validator) errorf(loc Location, format string, args ...interface{}) {
+	v.errs = append(v.errs, &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{loc}})
+}
+
+// selectionSet checks sel, nested depth selections deep, against obj;
+// spreading lists the fragments being expanded, to catch fragments that
+// spread themselves.
+func (v *validator) selectionSet(obj *Object, sel []selection, spreading []string, depth int) {
+	for _, s := range sel {
+		if v.overBudget {
+			return
+		}
+		switch s := s.(type) {
+		case *field:
+			v.field(obj, s, spreading, depth)
+		case *inlineFragment:
+			if s.typeCond != "" && s.typeCond != obj.Name {
+				v.errorf(s.loc, "fragment on %s cannot be spread within %s", s.typeCond, obj.Name)
+				continue
+			}
+			v.selectionSet(obj, s.sel, spreading, depth)
+		case *fragmentSpread:
+			frag, ok := v.doc.fragments[s.name]
+			if !ok {
+				v.errorf(s.loc, "unknown fragment %q", s.name)
+				continue
+			}
+			if v.used == nil {
+				v.used = make(map[string]bool)
+			}
+			v.used[s.name] = true
+			if frag.typeCond != obj.Name {
+				v.errorf(s.loc, "fragment %q on %s cannot be spread within %s", s.name, frag.typeCond, obj.Name)
+				continue
+			}
+			cycle := false
+			for _, name := range spreading {
+				cycle = cycle || name == s.name
+			}
+			if cycle {
+				v.errorf(s.loc, "fragment %q spreads itself", s.name)
+				continue
+			}
+			v.selectionSet(obj, frag.sel, append(spreading, s.name), depth)
+		}
+	}
+}
+
+func (v *validator) field(obj *Object, f *field, spreading []string, depth int) {
+	v.fields++
+	if v.maxFields > 0 && v.fields > v.maxFields {
+		v.overBudget = true
+		return
+	}
+	if f.name == "__typename" {
+		if f.sel != nil || len(f.args) > 0 {
+			v.errorf(f.loc, "field __typename takes no arguments or selections")
+		}
+		return
+	}
+	def, ok := obj.Fields[f.name]
+	if !ok {
+		v.errorf(f.loc, "unknown field %q on %s", f.name, obj.Name)
+		return
+	}
+	for _, arg := range f.args {
+		known := false
+		for _, name := range def.Args {
+			known = known || name == arg.name
+		}
+		if !known {
+			v.errorf(arg.loc, "unknown argument %q on field %s.%s", arg.name, obj.Name, f.name)
+		}
+		v.variables(arg.val, arg.loc)
+	}
+	switch {
+	case def.Type == nil && f.sel != nil:
+		v.errorf(f.loc, "field %s.%s is a leaf and takes no selections", obj.Name, f.name)
+	case def.Type != nil && f.sel == nil:
+		v.errorf(f.loc, "field %s.%s of type %s needs a selection of subfields", obj.Name, f.name, def.Type.Name)
+	case def.Type != nil && v.maxDepth > 0 && depth >= v.maxDepth:
+		v.errorf(f.loc, "field %s.%s is nested more than %d levels deep", obj.Name, f.name, v.maxDepth)
+	case def.Type != nil:
+		v.selectionSet(def.Type, f.sel, spreading, depth+1)
+	}
+}
+
+func (v *validator) variables(val value, loc Location) {
+	switch val := val.(type) {
+	case variable:
+		if !v.defined[string(val)] {
+			v.errorf(loc, "variable $%s is not defined", string(val))
+		}
+	case listValue:
+		for _, item := range val {
+			v.variables(item, loc)
+		}
+	case objectValue:
+		for _, item := range val {
+			v.variables(item, loc)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"isac-cran-system/pkg/errors"
+)
+
+type testItem struct {
+	ID     int64   `json:"id"`
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"`
+	secret string
+}
+
+func testSchema() *Schema {
+	item := StructObject("Item", testItem{})
+	item.Fields["children"] = &Field{
+		Type: item,
+		Resolve: func(p ResolveParams) (interface{}, error) {
+			parent := p.Source.(*testItem)
+			return []*testItem{{ID: parent.ID*10 + 1, Name: "child"}}, nil
+		},
+	}
+	item.Fields["broken"] = &Field{
+		Resolve: func(p ResolveParams) (interface{}, error) {
+			return nil, errors.New(errors.CodeNotFound, "nothing here")
+		},
+	}
+
+	query := &Object{Name: "Query", Fields: map[string]*Field{
+		"item": {
+			Type: item,
+			Args: []string{"id"},
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				id, err := p.Args.Int("id", 1)
+				if err != nil {
+					return nil, err
+				}
+				return &testItem{ID: int64(id), Name: "item", Weight: 0.5}, nil
+			},
+		},
+		"items": {
+			Type: item,
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				return []*testItem{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}, nil
+			},
+		},
+		"echo": {
+			Args: []string{"text"},
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				return p.Args.String("text"), nil
+			},
+		},
+	}}
+	return NewSchema(query)
+}
+
+func run(t *testing.T, req Request) (string, *Response) {
+	t.Helper()
+	resp := Do(context.Background(), testSchema(), req)
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	return string(data), resp
+}
+
+func TestDo_NestedQuery(t *testing.T) {
+	got, _ := run(t, Request{
+		Query: `query Explore($id: Int = 7) {
+			first: item(id: $id) { ...ItemFields children { id __typename } }
+			items { name }
+			echo(text: "a \"quoted\" string")
+		}
+		fragment ItemFields on Item { id name weight }`,
+	})
+	want := `{"data":{"first":{"id":7,"name":"item","weight":0.5,"children":[{"id":71,"__typename":"Item"}]},` +
+		`"items":[{"name":"a"},{"name":"b"}],"echo":"a \"quoted\" string"}}`
+	if got != want {
+		t.Errorf("Do() = %s\nwant %s", got, want)
+	}
+}
+
+func TestDo_Variables(t *testing.T) {
+	got, _ := run(t, Request{
+		Query:     `query($id: Int!) { item(id: $id) { id } }`,
+		Variables: map[string]interface{}{"id": float64(3)},
+	})
+	if want := `{"data":{"item":{"id":3}}}`; got != want {
+		t.Errorf("Do() = %s, want %s", got, want)
+	}
+
+	_, resp := run(t, Request{Query: `query($id: Int!) { item(id: $id) { id } }`})
+	if resp.Data != nil || len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, "$id") {
+		t.Errorf("missing required variable: %+v", resp)
+	}
+}
+
+func TestDo_FieldError(t *testing.T) {
+	got, _ := run(t, Request{Query: `{ items { id broken } }`})
+	want := `{"data":{"items":[{"id":1,"broken":null},{"id":2,"broken":null}]},"errors":[` +
+		`{"message":"nothing here","locations":[{"line":1,"column":14}],"path":["items",0,"broken"],"extensions":{"code":404}},` +
+		`{"message":"nothing here","locations":[{"line":1,"column":14}],"path":["items",1,"broken"],"extensions":{"code":404}}]}`
+	if got != want {
+		t.Errorf("Do() = %s\nwant %s", got, want)
+	}
+}
+
+func TestDo_RequestErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"syntax", `{ item(id: ) { id } }`, "syntax error"},
+		{"unknown field", `{ item { missing } }`, `unknown field "missing"`},
+		{"unknown argument", `{ item(size: 1) { id } }`, `unknown argument "size"`},
+		{"leaf selection", `{ echo { id } }`, "is a leaf"},
+		{"missing selection", `{ item }`, "needs a selection"},
+		{"unexported field", `{ item { secret } }`, `unknown field "secret"`},
+		{"undefined variable", `{ item(id: $id) { id } }`, "$id is not defined"},
+		{"fragment cycle", `{ item { ...A } } fragment A on Item { children { ...A } }`, "spreads itself"},
+		{"fragment type", `{ item { ...Q } } fragment Q on Query { echo }`, "cannot be spread"},
+		{"mutation", `mutation { item { id } }`, "not supported"},
+		{"directive", `{ item @skip(if: true) { id } }`, "directives are not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, resp := run(t, Request{Query: tt.query})
+			if resp.Data != nil || len(resp.Errors) == 0 || !strings.Contains(resp.Errors[0].Message, tt.want) {
+				t.Errorf("Do(%q) = %+v, want error containing %q", tt.query, resp.Errors, tt.want)
+			}
+		})
+	}
+}
+
+func TestDo_Limits(t *testing.T) {
+	schema := testSchema()
+	schema.MaxDepth = 3
+	schema.MaxFields = 20
+
+	resp := Do(context.Background(), schema, Request{Query: `{ item { children { id } } }`})
+	if resp.Data == nil || len(resp.Errors) > 0 {
+		t.Errorf("query at the depth limit: %+v", resp.Errors)
+	}
+	resp = Do(context.Background(), schema, Request{Query: `{ item { children { children { id } } } }`})
+	if resp.Data != nil || len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, "levels deep") {
+		t.Errorf("query past the depth limit: %+v", resp.Errors)
+	}
+
+	// Each fragment doubles the fields of the one it spreads, so the query
+	// is short but selects 2^10 ids.
+	query := `{ item { ...F0 } } fragment F10 on Item { id }`
+	for i := 0; i < 10; i++ {
+		query += fmt.Sprintf(" fragment F%d on Item { ...F%d ...F%d }", i, i+1, i+1)
+	}
+	resp = Do(context.Background(), schema, Request{Query: query})
+	if resp.Data != nil || len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, "more than 20 fields") {
+		t.Errorf("query over the field budget: %+v", resp.Errors)
+	}
+}
@@ -0,0 +1,237 @@
+// Package hub fans published events out to the WebSocket and SSE clients
+// subscribed to their topics.
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBufferSize is the number of messages a client may fall behind by
+// before it is evicted.
+const DefaultBufferSize = 64
+
+// Message is one published event. Topics are dot-separated, e.g.
+// "sensor.temp_01"; subscribing to a topic also receives its subtopics and
+// "*" receives everything.
+type Message struct {
+	Topic string          `json:"topic"`
+	Time  time.Time       `json:"time"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Stats reports the connected clients and what has been broadcast.
+// Published counts messages by root topic.
+type Stats struct {
+	Clients     int              `json:"clients"`
+	Subscribers map[string]int   `json:"subscribers" prom:"topic"`
+	Published   map[string]int64 `json:"published" prom:"topic"`
+	Delivered   int64            `json:"delivered"`
+	Dropped     int64            `json:"dropped"`
+	Evicted     int64            `json:"evicted"`
+}
+
+type Hub struct {
+	mu        sync.RWMutex
+	clients   map[*Client]struct{}
+	buffer    int
+	seq       atomic.Uint64
+	published map[string]int64
+	delivered atomic.Int64
+	dropped   atomic.Int64
+	evicted   atomic.Int64
+}
+
+// NewHub creates a hub whose clients buffer up to bufferSize messages,
+// DefaultBufferSize when zero.
+func NewHub(bufferSize int) *Hub {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	return &Hub{
+		clients:   make(map[*Client]struct{}),
+		buffer:    bufferSize,
+		published: make(map[string]int64),
+	}
+}
+
+// Client is one connection's subscription. Messages arrive on Messages
+// until Done is closed, either by Close or because the client fell a full
+// buffer behind.
+type Client struct {
+	ID      string
+	hub     *Hub
+	mu      sync.RWMutex
+	topics  map[string]bool
+	send    chan *Message
+	done    chan struct{}
+	once    sync.Once
+	evicted atomic.Bool
+}
+
+// Subscribe connects a client to topics; it can change them later.
+func (h *Hub) Subscribe(topics ...string) *Client {
+	c := &Client{
+		ID:     fmt.Sprintf("client_%d", h.seq.Add(1)),
+		hub:    h,
+		topics: make(map[string]bool),
+		send:   make(chan *Message, h.buffer),
+		done:   make(chan struct{}),
+	}
+	c.Subscribe(topics...)
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+	return c
+}
+
+// Publish sends data, encoded as JSON once, to every client subscribed to
+// topic. A client whose buffer is full is evicted rather than allowed to
+// hold up the others.
+func (h *Hub) Publish(topic string, data interface{}) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	msg := &Message{Topic: topic, Time: time.Now(), Data: raw}
+
+	var slow []*Client
+	h.mu.Lock()
+	h.published[rootTopic(topic)]++
+	for c := range h.clients {
+		if !c.matches(topic) {
+			continue
+		}
+		select {
+		case c.send <- msg:
+			h.delivered.Add(1)
+		default:
+			h.dropped.Add(1)
+			slow = append(slow, c)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, c := range slow {
+		if c.evicted.CompareAndSwap(false, true) {
+			h.evicted.Add(1)
+		}
+		c.Close()
+	}
+}
+
+// Close disconnects every client.
+func (h *Hub) Close() {
+	h.mu.RLock()
+	clients := make([]*Client, 0, len(h.clients))
+	for c := range h.clients {
+		clients = append(clients, c)
+	}
+	h.mu.RUnlock()
+	for _, c := range clients {
+		c.Close()
+	}
+}
+
+func (h *Hub) Stats() Stats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	stats := Stats{
+		Clients:     len(h.clients),
+		Subscribers: make(map[string]int),
+		Published:   make(map[string]int64, len(h.published)),
+		Delivered:   h.delivered.Load(),
+		Dropped:     h.dropped.Load(),
+		Evicted:     h.evicted.Load(),
+	}
+	for topic, n := range h.published {
+		stats.Published[topic] = n
+	}
+	for c := range h.clients {
+		for _, topic := range c.Topics() {
+			stats.Subscribers[topic]++
+		}
+	}
+	return stats
+}
+
+func (h *Hub) remove(c *Client) {
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.mu.Unlock()
+}
+
+func rootTopic(topic string) string {
+	if i := strings.IndexByte(topic, '.'); i >= 0 {
+		return topic[:i]
+	}
+	return topic
+}
+
+func (c *Client) Subscribe(topics ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, topic := range topics {
+		if topic != "" {
+			c.topics[topic] = true
+		}
+	}
+}
+
+func (c *Client) Unsubscribe(topics ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, topic := range topics {
+		delete(c.topics, topic)
+	}
+}
+
+func (c *Client) Topics() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	topics := make([]string, 0, len(c.topics))
+	for topic := range c.topics {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+func (c *Client) matches(topic string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.topics["*"] || c.topics[topic] {
+		return true
+	}
+	for i := strings.LastIndexByte(topic, '.'); i > 0; i = strings.LastIndexByte(topic, '.') {
+		topic = topic[:i]
+		if c.topics[topic] {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) Messages() <-chan *Message {
+	return c.send
+}
+
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Evicted reports whether the hub dropped the client for falling behind.
+func (c *Client) Evicted() bool {
+	return c.evicted.Load()
+}
+
+// Close unsubscribes the client. It is safe to call more than once.
+func (c *Client) Close() {
+	c.once.Do(func() {
+		c.hub.remove(c)
+		close(c.done)
+	})
+}
@@ -0,0 +1,75 @@
+package hub
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestHubTopics(t *testing.T) {
+	h := NewHub(4)
+	sensors := h.Subscribe("sensor")
+	one := h.Subscribe("sensor.temp_01")
+	all := h.Subscribe("*")
+
+	h.Publish("sensor.temp_01", map[string]float64{"value": 21.5})
+	h.Publish("sensor.temp_02", map[string]float64{"value": 19})
+	h.Publish("sensors", nil)
+	h.Publish("channel", nil)
+
+	if n := len(sensors.Messages()); n != 2 {
+		t.Errorf("sensor subscriber got %d messages, want 2", n)
+	}
+	if n := len(one.Messages()); n != 1 {
+		t.Errorf("sensor.temp_01 subscriber got %d messages, want 1", n)
+	}
+	if n := len(all.Messages()); n != 4 {
+		t.Errorf("wildcard subscriber got %d messages, want 4", n)
+	}
+	msg := <-one.Messages()
+	var data map[string]float64
+	if err := json.Unmarshal(msg.Data, &data); err != nil || msg.Topic != "sensor.temp_01" || data["value"] != 21.5 {
+		t.Errorf("message = %+v, %v", msg, err)
+	}
+
+	one.Unsubscribe("sensor.temp_01")
+	one.Subscribe("channel")
+	h.Publish("sensor.temp_01", nil)
+	h.Publish("channel", nil)
+	if msg := <-one.Messages(); msg.Topic != "channel" || len(one.Messages()) != 0 {
+		t.Errorf("after resubscribing got %q", msg.Topic)
+	}
+}
+
+func TestHubEvictsSlowClients(t *testing.T) {
+	h := NewHub(2)
+	slow := h.Subscribe("channel")
+	fast := h.Subscribe("channel")
+
+	for i := 0; i < 3; i++ {
+		h.Publish("channel", i)
+		<-fast.Messages()
+	}
+
+	select {
+	case <-slow.Done():
+	default:
+		t.Fatal("slow client was not evicted")
+	}
+	if !slow.Evicted() || fast.Evicted() {
+		t.Errorf("evicted: slow %t, fast %t", slow.Evicted(), fast.Evicted())
+	}
+
+	stats := h.Stats()
+	if stats.Clients != 1 || stats.Evicted != 1 || stats.Dropped != 1 || stats.Delivered != 5 || stats.Published["channel"] != 3 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if stats.Subscribers["channel"] != 1 {
+		t.Errorf("channel has %d subscribers, want 1", stats.Subscribers["channel"])
+	}
+
+	fast.Close()
+	fast.Close()
+	if h.Stats().Clients != 0 {
+		t.Error("closed client still connected")
+	}
+}
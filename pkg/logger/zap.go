@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"context"
 	"os"
 	"strings"
 
@@ -144,3 +145,16 @@ func Fatal(msg string, fields ...zap.Field) {
 func With(fields ...zap.Field) *zap.Logger {
 	return L().With(fields...)
 }
+
+type requestIDKey struct{}
+
+// WithRequestID attaches the ID of the HTTP request being served to ctx so
+// that logs written deeper down can be correlated with it.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
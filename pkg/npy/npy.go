@@ -0,0 +1,150 @@
+// Package npy writes and reads complex matrices in the NumPy .npy format so
+// captured snapshots can be loaded with numpy.load for offline processing,
+// and writes float64 arrays and .npz archives of them for datasets.
+package npy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var magic = []byte("\x93NUMPY")
+
+// headerAlign is the alignment NumPy uses for the start of the data.
+const headerAlign = 64
+
+// ComplexItemSize is the encoded size of one complex64 element.
+const ComplexItemSize = 8
+
+// FloatItemSize is the encoded size of one float64 element.
+const FloatItemSize = 8
+
+// WriteComplex64 encodes X, one row per antenna, as a C-ordered little-endian
+// complex64 array of shape (len(X), len(X[0])).
+func WriteComplex64(w io.Writer, X [][]complex128) error {
+	rows, cols := len(X), 0
+	if rows > 0 {
+		cols = len(X[0])
+	}
+	for i, row := range X {
+		if len(row) != cols {
+			return fmt.Errorf("npy: row %d has %d columns, want %d", i, len(row), cols)
+		}
+	}
+
+	if _, err := w.Write(header("<c8", rows, cols)); err != nil {
+		return err
+	}
+
+	buf := make([]byte, cols*ComplexItemSize)
+	for _, row := range X {
+		for j, v := range row {
+			binary.LittleEndian.PutUint32(buf[j*8:], math.Float32bits(float32(real(v))))
+			binary.LittleEndian.PutUint32(buf[j*8+4:], math.Float32bits(float32(imag(v))))
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodedSize returns the size WriteComplex64 produces for a rows×cols matrix.
+func EncodedSize(rows, cols int) int64 {
+	return int64(len(header("<c8", rows, cols))) + int64(rows)*int64(cols)*ComplexItemSize
+}
+
+// WriteFloat64 encodes data as a C-ordered little-endian float64 array of the
+// given shape, e.g. (n,) for a vector or (rows, cols) for a matrix.
+func WriteFloat64(w io.Writer, shape []int, data []float64) error {
+	n := 1
+	for _, d := range shape {
+		n *= d
+	}
+	if n != len(data) {
+		return fmt.Errorf("npy: shape %v holds %d values, got %d", shape, n, len(data))
+	}
+
+	if _, err := w.Write(header("<f8", shape...)); err != nil {
+		return err
+	}
+	buf := make([]byte, len(data)*FloatItemSize)
+	for i, v := range data {
+		binary.LittleEndian.PutUint64(buf[i*8:], math.Float64bits(v))
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+func header(descr string, shape ...int) []byte {
+	dims := make([]string, len(shape))
+	for i, d := range shape {
+		dims[i] = strconv.Itoa(d)
+	}
+	tuple := strings.Join(dims, ", ")
+	if len(shape) == 1 {
+		tuple += ","
+	}
+	dict := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%s), }", descr, tuple)
+	// magic, version 1.0, 2-byte header length, dict padded with spaces and
+	// terminated by a newline
+	prefix := len(magic) + 2 + 2
+	total := prefix + len(dict) + 1
+	pad := (headerAlign - total%headerAlign) % headerAlign
+
+	h := make([]byte, 0, total+pad)
+	h = append(h, magic...)
+	h = append(h, 1, 0)
+	h = binary.LittleEndian.AppendUint16(h, uint16(len(dict)+pad+1))
+	h = append(h, dict...)
+	h = append(h, bytes.Repeat([]byte{' '}, pad)...)
+	return append(h, '\n')
+}
+
+var shapePattern = regexp.MustCompile(`'shape':\s*\((\d+),\s*(\d+)\)`)
+
+// ReadComplex64 decodes a matrix written by WriteComplex64.
+func ReadComplex64(r io.Reader) ([][]complex128, error) {
+	pre := make([]byte, len(magic)+4)
+	if _, err := io.ReadFull(r, pre); err != nil {
+		return nil, fmt.Errorf("npy: read header: %w", err)
+	}
+	if !bytes.Equal(pre[:len(magic)], magic) || pre[len(magic)] != 1 {
+		return nil, fmt.Errorf("npy: not a version 1 .npy file")
+	}
+
+	dict := make([]byte, binary.LittleEndian.Uint16(pre[len(magic)+2:]))
+	if _, err := io.ReadFull(r, dict); err != nil {
+		return nil, fmt.Errorf("npy: read header: %w", err)
+	}
+	if !bytes.Contains(dict, []byte("'descr': '<c8'")) || bytes.Contains(dict, []byte("'fortran_order': True")) {
+		return nil, fmt.Errorf("npy: only C-ordered complex64 arrays are supported")
+	}
+	m := shapePattern.FindSubmatch(dict)
+	if m == nil {
+		return nil, fmt.Errorf("npy: expected a 2-D shape")
+	}
+	rows, _ := strconv.Atoi(string(m[1]))
+	cols, _ := strconv.Atoi(string(m[2]))
+
+	buf := make([]byte, cols*ComplexItemSize)
+	X := make([][]complex128, rows)
+	for i := range X {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, fmt.Errorf("npy: read row %d: %w", i, err)
+		}
+		X[i] = make([]complex128, cols)
+		for j := range X[i] {
+			re := math.Float32frombits(binary.LittleEndian.Uint32(buf[j*8:]))
+			im := math.Float32frombits(binary.LittleEndian.Uint32(buf[j*8+4:]))
+			X[i][j] = complex(float64(re), float64(im))
+		}
+	}
+	return X, nil
+}
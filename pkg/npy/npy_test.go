@@ -0,0 +1,103 @@
+package npy
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestComplex64_RoundTrip(t *testing.T) {
+	X := [][]complex128{
+		{1 + 2i, -0.5i, 3},
+		{0, 0.25 - 1i, -2 + 2i},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteComplex64(&buf, X); err != nil {
+		t.Fatalf("WriteComplex64() error = %v", err)
+	}
+	if int64(buf.Len()) != EncodedSize(2, 3) {
+		t.Errorf("encoded %d bytes, EncodedSize = %d", buf.Len(), EncodedSize(2, 3))
+	}
+	if (buf.Len()-2*3*ComplexItemSize)%headerAlign != 0 {
+		t.Errorf("data does not start on a %d-byte boundary", headerAlign)
+	}
+
+	got, err := ReadComplex64(&buf)
+	if err != nil {
+		t.Fatalf("ReadComplex64() error = %v", err)
+	}
+	for i := range X {
+		for j := range X[i] {
+			if got[i][j] != X[i][j] {
+				t.Errorf("X[%d][%d] = %v, want %v", i, j, got[i][j], X[i][j])
+			}
+		}
+	}
+}
+
+func TestWriteFloat64(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteFloat64(&buf, []int{3}, []float64{1, -2.5, 4}); err != nil {
+		t.Fatalf("WriteFloat64() error = %v", err)
+	}
+	data := buf.Bytes()
+	if !bytes.Contains(data, []byte("'descr': '<f8'")) || !bytes.Contains(data, []byte("'shape': (3,)")) {
+		t.Errorf("unexpected header %q", data[:headerAlign])
+	}
+	if (len(data)-3*FloatItemSize)%headerAlign != 0 {
+		t.Errorf("data does not start on a %d-byte boundary", headerAlign)
+	}
+	if got := math.Float64frombits(binary.LittleEndian.Uint64(data[len(data)-2*FloatItemSize:])); got != -2.5 {
+		t.Errorf("second value = %v, want -2.5", got)
+	}
+
+	if err := WriteFloat64(&buf, []int{2, 2}, []float64{1, 2, 3}); err == nil {
+		t.Error("expected an error for data not matching the shape")
+	}
+}
+
+func TestNPZWriter(t *testing.T) {
+	var buf bytes.Buffer
+	z := NewNPZWriter(&buf)
+	if err := z.WriteFloat64("snr", []int{2, 2}, []float64{1, 2, 3, 4}); err != nil {
+		t.Fatal(err)
+	}
+	if err := z.WriteComplex64("iq", [][]complex128{{1 + 1i}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := z.WriteFile("meta.json", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("not a zip archive: %v", err)
+	}
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+		if f.Method != zip.Store {
+			t.Errorf("%s is compressed", f.Name)
+		}
+	}
+	if strings.Join(names, ",") != "snr.npy,iq.npy,meta.json" {
+		t.Errorf("archive holds %v", names)
+	}
+
+	rc, err := r.File[1].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	X, err := ReadComplex64(rc)
+	if err != nil || X[0][0] != 1+1i {
+		t.Errorf("iq = %v, %v", X, err)
+	}
+}
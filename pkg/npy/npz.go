@@ -0,0 +1,56 @@
+package npy
+
+import (
+	"archive/zip"
+	"io"
+)
+
+// NPZWriter writes a NumPy .npz archive: a zip of .npy files that
+// numpy.load opens as a mapping from array name to array.
+type NPZWriter struct {
+	zw *zip.Writer
+}
+
+func NewNPZWriter(w io.Writer) *NPZWriter {
+	return &NPZWriter{zw: zip.NewWriter(w)}
+}
+
+// WriteFloat64 adds the float64 array name with the given shape.
+func (z *NPZWriter) WriteFloat64(name string, shape []int, data []float64) error {
+	w, err := z.create(name + ".npy")
+	if err != nil {
+		return err
+	}
+	return WriteFloat64(w, shape, data)
+}
+
+// WriteComplex64 adds the complex64 matrix name.
+func (z *NPZWriter) WriteComplex64(name string, X [][]complex128) error {
+	w, err := z.create(name + ".npy")
+	if err != nil {
+		return err
+	}
+	return WriteComplex64(w, X)
+}
+
+// WriteFile adds a file that is not an array, such as a JSON description.
+// numpy.load returns its raw bytes.
+func (z *NPZWriter) WriteFile(name string, data []byte) error {
+	w, err := z.create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// create stores the entry uncompressed, as numpy.savez does.
+func (z *NPZWriter) create(name string) (io.Writer, error) {
+	return z.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+}
+
+// Close writes the archive directory; it does not close the underlying
+// writer.
+func (z *NPZWriter) Close() error {
+	return z.zw.Close()
+}
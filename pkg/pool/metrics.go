@@ -0,0 +1,121 @@
+package pool
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+type Stats struct {
+	Workers       int                      `json:"workers"`
+	QueueLength   int                      `json:"queue_length"`
+	QueueCapacity int                      `json:"queue_capacity"`
+	Overrunning   int                      `json:"overrunning"`
+	Tasks         map[string]TaskTypeStats `json:"tasks" prom:"task_type"`
+}
+
+type TaskTypeStats struct {
+	Submitted      int64   `json:"submitted"`
+	Rejected       int64   `json:"rejected"`
+	Completed      int64   `json:"completed"`
+	Failed         int64   `json:"failed"`
+	TimedOut       int64   `json:"timed_out"`
+	Panicked       int64   `json:"panicked"`
+	AvgQueueWaitMs float64 `json:"avg_queue_wait_ms"`
+	MaxQueueWaitMs float64 `json:"max_queue_wait_ms"`
+	AvgRunTimeMs   float64 `json:"avg_run_time_ms"`
+	MaxRunTimeMs   float64 `json:"max_run_time_ms"`
+}
+
+type typeCounters struct {
+	submitted, rejected                   int64
+	completed, failed, timedOut, panicked int64
+	totalWait, maxWait                    time.Duration
+	totalRun, maxRun                      time.Duration
+}
+
+type metrics struct {
+	mu    sync.Mutex
+	types map[string]*typeCounters
+}
+
+func newMetrics() *metrics {
+	return &metrics{types: make(map[string]*typeCounters)}
+}
+
+func (m *metrics) counters(taskType string) *typeCounters {
+	c, ok := m.types[taskType]
+	if !ok {
+		c = &typeCounters{}
+		m.types[taskType] = c
+	}
+	return c
+}
+
+func (m *metrics) submitted(taskType string) {
+	m.mu.Lock()
+	m.counters(taskType).submitted++
+	m.mu.Unlock()
+}
+
+func (m *metrics) rejected(taskType string) {
+	m.mu.Lock()
+	m.counters(taskType).rejected++
+	m.mu.Unlock()
+}
+
+func (m *metrics) record(taskType string, wait, run time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := m.counters(taskType)
+	var panicErr *PanicError
+	switch {
+	case err == nil:
+		c.completed++
+	case errors.Is(err, ErrTaskTimeout):
+		c.timedOut++
+	case errors.As(err, &panicErr):
+		c.panicked++
+	default:
+		c.failed++
+	}
+
+	c.totalWait += wait
+	if wait > c.maxWait {
+		c.maxWait = wait
+	}
+	c.totalRun += run
+	if run > c.maxRun {
+		c.maxRun = run
+	}
+}
+
+func (m *metrics) snapshot() map[string]TaskTypeStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make(map[string]TaskTypeStats, len(m.types))
+	for name, c := range m.types {
+		s := TaskTypeStats{
+			Submitted:      c.submitted,
+			Rejected:       c.rejected,
+			Completed:      c.completed,
+			Failed:         c.failed,
+			TimedOut:       c.timedOut,
+			Panicked:       c.panicked,
+			MaxQueueWaitMs: milliseconds(c.maxWait),
+			MaxRunTimeMs:   milliseconds(c.maxRun),
+		}
+		if finished := c.completed + c.failed + c.timedOut + c.panicked; finished > 0 {
+			s.AvgQueueWaitMs = milliseconds(c.totalWait) / float64(finished)
+			s.AvgRunTimeMs = milliseconds(c.totalRun) / float64(finished)
+		}
+		stats[name] = s
+	}
+	return stats
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
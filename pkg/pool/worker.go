@@ -2,33 +2,82 @@ package pool
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTaskType is used for tasks submitted without a type name.
+const DefaultTaskType = "default"
+
+var (
+	ErrTaskTimeout = errors.New("task timed out")
+	ErrPoolStopped = errors.New("worker pool stopped")
 )
 
 type Task func() (interface{}, error)
 
+// ContextTask receives a context that is cancelled when the task's timeout
+// expires or the pool stops. Tasks should return promptly once it is done:
+// the caller gets ErrTaskTimeout right away, but the worker stays occupied
+// until the task actually returns, so the pool never runs more tasks than it
+// has workers.
+type ContextTask func(ctx context.Context) (interface{}, error)
+
 type Result struct {
+	Type  string
 	Data  interface{}
 	Error error
 }
 
+// PanicError is returned as the task error when a task panics. The worker
+// keeps running.
+type PanicError struct {
+	Value interface{}
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("task panicked: %v", e.Value)
+}
+
+type job struct {
+	taskType   string
+	timeout    time.Duration
+	fn         ContextTask
+	enqueuedAt time.Time
+	done       chan Result
+}
+
 type WorkerPool struct {
 	workers   int
-	taskQueue chan Task
+	taskQueue chan *job
 	results   chan Result
 	wg        sync.WaitGroup
 	ctx       context.Context
 	cancel    context.CancelFunc
+	metrics   *metrics
+
+	// mu guards sends on taskQueue against Stop closing it.
+	mu      sync.RWMutex
+	stopped bool
+
+	// overrunning counts timed-out tasks that have not returned yet.
+	overrunning int32
 }
 
 func NewWorkerPool(workers int, queueSize int) *WorkerPool {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &WorkerPool{
 		workers:   workers,
-		taskQueue: make(chan Task, queueSize),
+		taskQueue: make(chan *job, queueSize),
 		results:   make(chan Result, queueSize),
 		ctx:       ctx,
 		cancel:    cancel,
+		metrics:   newMetrics(),
 	}
 }
 
@@ -45,33 +94,143 @@ func (p *WorkerPool) worker(id int) {
 		select {
 		case <-p.ctx.Done():
 			return
-		case task, ok := <-p.taskQueue:
+		case j, ok := <-p.taskQueue:
 			if !ok {
 				return
 			}
-			data, err := task()
-			p.results <- Result{Data: data, Error: err}
+			if p.ctx.Err() != nil {
+				p.abandon(j)
+				return
+			}
+			p.run(j)
+		}
+	}
+}
+
+// abandon drops a queued job the pool stopped before running.
+func (p *WorkerPool) abandon(j *job) {
+	if j.done != nil {
+		j.done <- Result{Type: j.taskType, Error: ErrPoolStopped}
+	}
+}
+
+func (p *WorkerPool) deliver(j *job, result Result) {
+	if j.done != nil {
+		j.done <- result
+	} else {
+		p.results <- result
+	}
+}
+
+// run executes j and delivers its result. A task that outlives its timeout
+// is reported as timed out immediately, and run then waits for it to return
+// before the worker takes another task.
+func (p *WorkerPool) run(j *job) {
+	started := time.Now()
+	wait := started.Sub(j.enqueuedAt)
+
+	ctx := p.ctx
+	cancel := func() {}
+	if j.timeout > 0 {
+		ctx, cancel = context.WithTimeout(p.ctx, j.timeout)
+	}
+	defer cancel()
+
+	finished := make(chan Result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				finished <- Result{Error: &PanicError{Value: r, Stack: string(debug.Stack())}}
+			}
+		}()
+		data, err := j.fn(ctx)
+		finished <- Result{Data: data, Error: err}
+	}()
+
+	var result Result
+	overran := false
+	select {
+	case result = <-finished:
+	case <-ctx.Done():
+		result = Result{Error: ErrTaskTimeout}
+		if p.ctx.Err() != nil {
+			result.Error = p.ctx.Err()
 		}
+		overran = true
+	}
+	result.Type = j.taskType
+
+	p.metrics.record(j.taskType, wait, time.Since(started), result.Error)
+	p.deliver(j, result)
+
+	if overran {
+		atomic.AddInt32(&p.overrunning, 1)
+		<-finished
+		atomic.AddInt32(&p.overrunning, -1)
+	}
+}
+
+func newJob(taskType string, timeout time.Duration, fn ContextTask, done chan Result) *job {
+	if taskType == "" {
+		taskType = DefaultTaskType
+	}
+	return &job{
+		taskType:   taskType,
+		timeout:    timeout,
+		fn:         fn,
+		enqueuedAt: time.Now(),
+		done:       done,
 	}
 }
 
 func (p *WorkerPool) Submit(task Task) bool {
+	return p.SubmitTask(DefaultTaskType, 0, wrapTask(task))
+}
+
+// SubmitTask queues fn under taskType without blocking. A timeout of zero
+// means the task runs until it returns. It reports false if the queue is
+// full or the pool has stopped.
+func (p *WorkerPool) SubmitTask(taskType string, timeout time.Duration, fn ContextTask) bool {
+	j := newJob(taskType, timeout, fn, nil)
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.stopped {
+		return false
+	}
 	select {
-	case p.taskQueue <- task:
+	case p.taskQueue <- j:
+		p.metrics.submitted(j.taskType)
 		return true
 	default:
+		p.metrics.rejected(j.taskType)
 		return false
 	}
 }
 
 func (p *WorkerPool) SubmitAndWait(task Task) (interface{}, error) {
-	resultChan := make(chan Result, 1)
-	p.taskQueue <- func() (interface{}, error) {
-		data, err := task()
-		resultChan <- Result{Data: data, Error: err}
-		return data, err
+	return p.SubmitTaskAndWait(DefaultTaskType, 0, wrapTask(task))
+}
+
+// SubmitTaskAndWait queues fn, blocking while the queue is full, and returns
+// its result. The result is not sent to Results. It returns ErrPoolStopped
+// if the pool stops before the task runs.
+func (p *WorkerPool) SubmitTaskAndWait(taskType string, timeout time.Duration, fn ContextTask) (interface{}, error) {
+	j := newJob(taskType, timeout, fn, make(chan Result, 1))
+	p.mu.RLock()
+	if p.stopped {
+		p.mu.RUnlock()
+		return nil, ErrPoolStopped
+	}
+	select {
+	case p.taskQueue <- j:
+	case <-p.ctx.Done():
+		p.mu.RUnlock()
+		return nil, ErrPoolStopped
 	}
-	result := <-resultChan
+	p.mu.RUnlock()
+	p.metrics.submitted(j.taskType)
+
+	result := <-j.done
 	return result.Data, result.Error
 }
 
@@ -79,10 +238,19 @@ func (p *WorkerPool) Results() <-chan Result {
 	return p.results
 }
 
+// Stop cancels running tasks and waits for them to return. Tasks still
+// queued are not run; their waiters get ErrPoolStopped.
 func (p *WorkerPool) Stop() {
 	p.cancel()
+	p.mu.Lock()
+	p.stopped = true
 	close(p.taskQueue)
+	p.mu.Unlock()
 	p.wg.Wait()
+
+	for j := range p.taskQueue {
+		p.abandon(j)
+	}
 	close(p.results)
 }
 
@@ -93,3 +261,21 @@ func (p *WorkerPool) QueueSize() int {
 func (p *WorkerPool) WorkerCount() int {
 	return p.workers
 }
+
+// Stats returns queue occupancy and per-task-type execution metrics.
+// Overrunning counts timed-out tasks that still hold a worker.
+func (p *WorkerPool) Stats() *Stats {
+	return &Stats{
+		Workers:       p.workers,
+		QueueLength:   len(p.taskQueue),
+		QueueCapacity: cap(p.taskQueue),
+		Overrunning:   int(atomic.LoadInt32(&p.overrunning)),
+		Tasks:         p.metrics.snapshot(),
+	}
+}
+
+func wrapTask(task Task) ContextTask {
+	return func(ctx context.Context) (interface{}, error) {
+		return task()
+	}
+}
@@ -1,6 +1,8 @@
 package pool
 
 import (
+	"context"
+	"errors"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -100,3 +102,148 @@ func TestWorkerPoolQueueFull(t *testing.T) {
 		t.Error("expected queue to be full")
 	}
 }
+
+func TestWorkerPoolTaskTimeout(t *testing.T) {
+	pool := NewWorkerPool(1, 5)
+	pool.Start()
+	defer pool.Stop()
+
+	_, err := pool.SubmitTaskAndWait("slow", 20*time.Millisecond, func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		time.Sleep(50 * time.Millisecond)
+		return nil, nil
+	})
+	if !errors.Is(err, ErrTaskTimeout) {
+		t.Fatalf("expected ErrTaskTimeout, got %v", err)
+	}
+
+	if stats := pool.Stats().Tasks["slow"]; stats.TimedOut != 1 {
+		t.Errorf("expected 1 timed out task, got %d", stats.TimedOut)
+	}
+}
+
+func TestWorkerPoolTimeoutHoldsWorker(t *testing.T) {
+	pool := NewWorkerPool(1, 5)
+	pool.Start()
+	defer pool.Stop()
+
+	release := make(chan struct{})
+	_, err := pool.SubmitTaskAndWait("stuck", 10*time.Millisecond, func(ctx context.Context) (interface{}, error) {
+		<-release
+		return nil, nil
+	})
+	if !errors.Is(err, ErrTaskTimeout) {
+		t.Fatalf("expected ErrTaskTimeout, got %v", err)
+	}
+	if n := pool.Stats().Overrunning; n != 1 {
+		t.Errorf("expected 1 overrunning task, got %d", n)
+	}
+
+	// The only worker is still busy, so the next task must wait for the
+	// timed-out one to return.
+	var running int32
+	done := make(chan struct{})
+	go func() {
+		pool.SubmitAndWait(func() (interface{}, error) {
+			atomic.StoreInt32(&running, 1)
+			return nil, nil
+		})
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if atomic.LoadInt32(&running) != 0 {
+		t.Fatal("next task ran while the timed-out task still held the worker")
+	}
+
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("next task did not run after the timed-out task returned")
+	}
+	if n := pool.Stats().Overrunning; n != 0 {
+		t.Errorf("expected no overrunning tasks, got %d", n)
+	}
+}
+
+func TestWorkerPoolSubmitAfterStop(t *testing.T) {
+	pool := NewWorkerPool(1, 1)
+	pool.Start()
+
+	block := make(chan struct{})
+	started := make(chan struct{})
+	pool.SubmitTask("busy", 0, func(ctx context.Context) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		<-block
+		return nil, nil
+	})
+	<-started
+	pool.SubmitTask("queued", 0, func(ctx context.Context) (interface{}, error) {
+		return nil, nil
+	})
+
+	// The queue is full, so this waits until Stop.
+	waitErr := make(chan error, 1)
+	go func() {
+		_, err := pool.SubmitTaskAndWait("waiting", 0, func(ctx context.Context) (interface{}, error) {
+			return nil, nil
+		})
+		waitErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(block)
+	}()
+	pool.Stop()
+
+	select {
+	case err := <-waitErr:
+		if !errors.Is(err, ErrPoolStopped) {
+			t.Errorf("expected ErrPoolStopped, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("SubmitTaskAndWait blocked after Stop")
+	}
+
+	if _, err := pool.SubmitAndWait(func() (interface{}, error) { return nil, nil }); !errors.Is(err, ErrPoolStopped) {
+		t.Errorf("expected ErrPoolStopped after Stop, got %v", err)
+	}
+	if pool.SubmitTask("late", 0, func(ctx context.Context) (interface{}, error) { return nil, nil }) {
+		t.Error("expected SubmitTask to fail after Stop")
+	}
+}
+
+func TestWorkerPoolPanicIsolation(t *testing.T) {
+	pool := NewWorkerPool(1, 5)
+	pool.Start()
+	defer pool.Stop()
+
+	_, err := pool.SubmitTaskAndWait("bad", 0, func(ctx context.Context) (interface{}, error) {
+		panic("boom")
+	})
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("expected PanicError, got %v", err)
+	}
+	if panicErr.Stack == "" {
+		t.Error("expected stack trace to be captured")
+	}
+
+	result, err := pool.SubmitAndWait(func() (interface{}, error) {
+		return "ok", nil
+	})
+	if err != nil || result != "ok" {
+		t.Errorf("worker did not survive panic: %v, %v", result, err)
+	}
+
+	stats := pool.Stats().Tasks
+	if stats["bad"].Panicked != 1 {
+		t.Errorf("expected 1 panicked task, got %d", stats["bad"].Panicked)
+	}
+	if stats[DefaultTaskType].Completed != 1 {
+		t.Errorf("expected 1 completed default task, got %d", stats[DefaultTaskType].Completed)
+	}
+}
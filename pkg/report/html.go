@@ -0,0 +1,93 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"strings"
+)
+
+const (
+	svgWidth  = 640
+	svgHeight = 260
+	svgMargin = 48
+)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"svg": svgPlot,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; margin: 2em auto; max-width: 52em; color: #222; }
+h1 { margin-bottom: 0.2em; }
+.subtitle { color: #666; margin-top: 0; }
+table { border-collapse: collapse; margin: 0.5em 0 1em; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: left; vertical-align: top; }
+th { background: #f3f3f3; }
+td.name { color: #555; white-space: nowrap; }
+figure { margin: 1em 0; }
+figcaption { font-size: 0.9em; color: #444; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Subtitle}}<p class="subtitle">{{.Subtitle}}</p>{{end}}
+{{range .Sections}}
+<h2>{{.Heading}}</h2>
+{{range .Text}}<p>{{.}}</p>
+{{end}}
+{{if .Fields}}<table>
+{{range .Fields}}<tr><td class="name">{{.Name}}</td><td>{{.Value}}</td></tr>
+{{end}}</table>{{end}}
+{{with .Table}}<table>
+<tr>{{range .Columns}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>{{end}}
+{{range .Plots}}<figure>{{svg .}}<figcaption>{{.Title}}</figcaption></figure>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML renders doc as a standalone HTML page.
+func WriteHTML(w io.Writer, doc *Document) error {
+	return htmlTemplate.Execute(w, doc)
+}
+
+// svgPlot draws p as an inline SVG with its axes labelled at their ends.
+func svgPlot(p Plot) template.HTML {
+	w, h := float64(svgWidth-2*svgMargin), float64(svgHeight-2*svgMargin)
+	xMin, xMax, yMin, yMax := p.bounds()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-size="11" font-family="Helvetica, Arial, sans-serif">`, svgWidth, svgHeight)
+	fmt.Fprintf(&b, `<g transform="translate(%d,%d)">`, svgMargin, svgMargin/2)
+	fmt.Fprintf(&b, `<rect width="%g" height="%g" fill="none" stroke="#888"/>`, w, h)
+	for i, s := range p.Series {
+		c := palette[i%len(palette)]
+		colour := fmt.Sprintf("rgb(%d,%d,%d)", int(c[0]*255), int(c[1]*255), int(c[2]*255))
+		for _, line := range p.polyline(s, w, h) {
+			points := make([]string, len(line))
+			for j, pt := range line {
+				points[j] = fmt.Sprintf("%.2f,%.2f", pt[0], pt[1])
+			}
+			fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/>`, colour, strings.Join(points, " "))
+		}
+		if s.Name != "" {
+			fmt.Fprintf(&b, `<text x="%g" y="%d" fill="%s" text-anchor="end">%s</text>`, w-6, 14*(i+1), colour, html.EscapeString(s.Name))
+		}
+	}
+	fmt.Fprintf(&b, `<text x="0" y="%g">%s</text>`, h+14, FormatFloat(xMin))
+	fmt.Fprintf(&b, `<text x="%g" y="%g" text-anchor="end">%s</text>`, w, h+14, FormatFloat(xMax))
+	fmt.Fprintf(&b, `<text x="%g" y="%g" text-anchor="middle">%s</text>`, w/2, h+30, html.EscapeString(p.XLabel))
+	fmt.Fprintf(&b, `<text x="-4" y="%g" text-anchor="end">%s</text>`, h, FormatFloat(yMin))
+	fmt.Fprintf(&b, `<text x="-4" y="10" text-anchor="end">%s</text>`, FormatFloat(yMax))
+	fmt.Fprintf(&b, `<text transform="translate(-36,%g) rotate(-90)" text-anchor="middle">%s</text>`, h/2, html.EscapeString(p.YLabel))
+	b.WriteString(`</g></svg>`)
+	return template.HTML(b.String())
+}
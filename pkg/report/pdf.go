@@ -0,0 +1,295 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 pages in points, laid out top to bottom in the standard Helvetica
+// fonts, which every PDF reader has, so nothing is embedded. Text outside
+// Latin-1 is shown as '?'.
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+	pageMargin = 50.0
+	textWidth  = pageWidth - 2*pageMargin
+
+	titleSize   = 18.0
+	headingSize = 13.0
+	bodySize    = 10.0
+	tableSize   = 9.0
+	lineGap     = 1.4
+
+	fieldNameWidth = 150.0
+	plotHeight     = 200.0
+	plotInset      = 45.0
+
+	// charWidth is the mean Helvetica advance per em, which wrapping and
+	// truncation estimate text widths with.
+	charWidth = 0.52
+)
+
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+)
+
+// WritePDF renders doc as a PDF document.
+func WritePDF(w io.Writer, doc *Document) error {
+	l := &pdfLayout{}
+	l.newPage()
+	l.wrap(pageMargin, textWidth, fontBold, titleSize, doc.Title)
+	if doc.Subtitle != "" {
+		l.wrap(pageMargin, textWidth, fontRegular, bodySize, doc.Subtitle)
+	}
+	for _, s := range doc.Sections {
+		l.space(bodySize)
+		l.need(headingSize*lineGap + 3*bodySize*lineGap)
+		l.wrap(pageMargin, textWidth, fontBold, headingSize, s.Heading)
+		for _, p := range s.Text {
+			l.wrap(pageMargin, textWidth, fontRegular, bodySize, p)
+			l.space(bodySize / 2)
+		}
+		for _, f := range s.Fields {
+			l.field(f)
+		}
+		if s.Table != nil {
+			l.table(s.Table)
+		}
+		for _, p := range s.Plots {
+			l.plot(p)
+		}
+	}
+	return l.write(w, doc.Title)
+}
+
+type pdfLayout struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	// y is the top of the free space on the page, from the bottom edge.
+	y float64
+}
+
+func (l *pdfLayout) newPage() {
+	l.page = &bytes.Buffer{}
+	l.pages = append(l.pages, l.page)
+	l.y = pageHeight - pageMargin
+}
+
+// need starts a new page unless height fits on this one.
+func (l *pdfLayout) need(height float64) {
+	if l.y-height < pageMargin {
+		l.newPage()
+	}
+}
+
+func (l *pdfLayout) space(height float64) {
+	l.y -= height
+}
+
+// text draws s with its baseline at y.
+func (l *pdfLayout) text(x, y float64, font string, size float64, s string) {
+	fmt.Fprintf(l.page, "BT /%s %g Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfString(s))
+}
+
+// wrap writes s as lines of at most width points from x, breaking between
+// words.
+func (l *pdfLayout) wrap(x, width float64, font string, size float64, s string) {
+	for _, line := range wrapText(s, width, size) {
+		l.need(size * lineGap)
+		l.y -= size * lineGap
+		l.text(x, l.y+size*(lineGap-1), font, size, line)
+	}
+}
+
+func (l *pdfLayout) field(f Field) {
+	lines := wrapText(f.Value, textWidth-fieldNameWidth, bodySize)
+	l.need(float64(len(lines)) * bodySize * lineGap)
+	top := l.y
+	l.wrap(pageMargin+fieldNameWidth, textWidth-fieldNameWidth, fontRegular, bodySize, f.Value)
+	l.text(pageMargin, top-bodySize, fontBold, bodySize, truncate(f.Name, fieldNameWidth-6, bodySize))
+}
+
+func (l *pdfLayout) table(t *Table) {
+	if len(t.Columns) == 0 {
+		return
+	}
+	column := textWidth / float64(len(t.Columns))
+	row := func(cells []string, font string) {
+		l.need(tableSize * lineGap * 1.5)
+		l.y -= tableSize * lineGap
+		for i, cell := range cells {
+			if i < len(t.Columns) {
+				l.text(pageMargin+float64(i)*column, l.y+2, font, tableSize, truncate(cell, column-6, tableSize))
+			}
+		}
+		fmt.Fprintf(l.page, "0.8 G 0.5 w %.2f %.2f m %.2f %.2f l S 0 G\n", pageMargin, l.y, pageMargin+textWidth, l.y)
+	}
+	l.space(tableSize / 2)
+	row(t.Columns, fontBold)
+	for _, r := range t.Rows {
+		row(r, fontRegular)
+	}
+	l.space(tableSize / 2)
+}
+
+// plot draws p in a box the width of the text, with the axes labelled at
+// their ends and the title below.
+func (l *pdfLayout) plot(p Plot) {
+	l.need(plotHeight + bodySize*lineGap*2)
+	l.space(bodySize)
+	x0, w := pageMargin+plotInset, textWidth-plotInset
+	h := plotHeight - 2*bodySize*lineGap
+	bottom := l.y - h
+	xMin, xMax, yMin, yMax := p.bounds()
+
+	fmt.Fprintf(l.page, "0.5 G 0.5 w %.2f %.2f %.2f %.2f re S\n", x0, bottom, w, h)
+	for i, s := range p.Series {
+		c := palette[i%len(palette)]
+		fmt.Fprintf(l.page, "%.2f %.2f %.2f RG 1 w\n", c[0], c[1], c[2])
+		for _, line := range p.polyline(s, w, h) {
+			for j, pt := range line {
+				op := "l"
+				if j == 0 {
+					op = "m"
+				}
+				fmt.Fprintf(l.page, "%.2f %.2f %s ", x0+pt[0], l.y-pt[1], op)
+			}
+			l.page.WriteString("S\n")
+		}
+		if s.Name != "" {
+			fmt.Fprintf(l.page, "%.2f %.2f %.2f rg\n", c[0], c[1], c[2])
+			name := truncate(s.Name, w/3, tableSize)
+			l.text(x0+w-4-textLength(name, tableSize), l.y-float64(i+1)*tableSize*lineGap, fontRegular, tableSize, name)
+			l.page.WriteString("0 g\n")
+		}
+	}
+	l.page.WriteString("0 G\n")
+
+	small := tableSize
+	l.text(x0, bottom-small*lineGap, fontRegular, small, FormatFloat(xMin))
+	right := FormatFloat(xMax)
+	l.text(x0+w-textLength(right, small), bottom-small*lineGap, fontRegular, small, right)
+	label := truncate(p.XLabel, w/2, small)
+	l.text(x0+(w-textLength(label, small))/2, bottom-small*lineGap, fontRegular, small, label)
+	top := FormatFloat(yMax)
+	l.text(x0-4-textLength(top, small), l.y-small, fontRegular, small, top)
+	low := FormatFloat(yMin)
+	l.text(x0-4-textLength(low, small), bottom, fontRegular, small, low)
+	yLabel := truncate(p.YLabel, plotInset-4, small)
+	l.text(pageMargin, bottom+h/2, fontRegular, small, yLabel)
+
+	l.y = bottom - 2*small*lineGap
+	title := truncate(p.Title, textWidth, bodySize)
+	l.text(pageMargin+(textWidth-textLength(title, bodySize))/2, l.y, fontRegular, bodySize, title)
+	l.space(bodySize * lineGap)
+}
+
+// write assembles the pages into a PDF file with its cross-reference table.
+func (l *pdfLayout) write(out io.Writer, title string) error {
+	w := bufio.NewWriter(out)
+	var offsets []int
+	var n int
+	object := func(body string) {
+		offsets = append(offsets, n)
+		written, _ := fmt.Fprintf(w, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+		n += written
+	}
+
+	header, _ := w.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	n += header
+
+	// 1 catalog, 2 page tree, 3 and 4 fonts, 5 info, then a page and its
+	// content stream for each page
+	kids := make([]string, len(l.pages))
+	for i := range l.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(l.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title (%s) /Producer (isac-cran-system) >>", pdfString(title)))
+	for i, page := range l.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %g %g] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, fontRegular, fontBold, 7+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	fmt.Fprintf(w, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(w, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(w, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, n)
+	return w.Flush()
+}
+
+// pdfString escapes s for a literal string in WinAnsi encoding.
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteByte(' ')
+		case r < 0x20 || (r >= 0x7f && r < 0xa0) || r > 0xff:
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
+
+func textLength(s string, size float64) float64 {
+	return float64(len([]rune(s))) * charWidth * size
+}
+
+// truncate shortens s to fit width, marking the cut.
+func truncate(s string, width, size float64) string {
+	max := int(width / (charWidth * size))
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	if max < 4 {
+		return string(runes[:max])
+	}
+	return string(runes[:max-3]) + "..."
+}
+
+// wrapText breaks s into lines that fit width, between words where it can.
+func wrapText(s string, width, size float64) []string {
+	max := int(width / (charWidth * size))
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(s) {
+		w := []rune(word)
+		for len(w) > max {
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+				line = nil
+			}
+			lines = append(lines, string(w[:max]))
+			w = w[max:]
+		}
+		switch {
+		case len(line) == 0:
+			line = w
+		case len(line)+1+len(w) <= max:
+			line = append(append(line, ' '), w...)
+		default:
+			lines = append(lines, string(line))
+			line = w
+		}
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
@@ -0,0 +1,120 @@
+// Package report lays out experiment reports, made of tables, text and line
+// plots, and renders them as standalone HTML with inline SVG plots or as
+// PDF, without external tools, for lab notebooks and papers.
+package report
+
+import (
+	"math"
+	"strconv"
+)
+
+// Document is a report: a title over a sequence of sections.
+type Document struct {
+	Title    string
+	Subtitle string
+	Sections []Section
+}
+
+// Section is a heading followed by its paragraphs, name-value fields, a
+// table and plots, in that order; any of them may be empty.
+type Section struct {
+	Heading string
+	Text    []string
+	Fields  []Field
+	Table   *Table
+	Plots   []Plot
+}
+
+type Field struct {
+	Name  string
+	Value string
+}
+
+type Table struct {
+	Columns []string
+	Rows    [][]string
+}
+
+// Plot draws one or more series as lines over shared axes.
+type Plot struct {
+	Title  string
+	XLabel string
+	YLabel string
+	Series []Series
+}
+
+// Series is one line of a plot; X and Y have the same length.
+type Series struct {
+	Name string
+	X    []float64
+	Y    []float64
+}
+
+// palette colours the series of a plot in turn.
+var palette = [][3]float64{
+	{0.12, 0.47, 0.71},
+	{0.84, 0.15, 0.16},
+	{0.17, 0.63, 0.17},
+	{1.00, 0.50, 0.05},
+	{0.58, 0.40, 0.74},
+}
+
+// bounds returns the ranges of the finite points of a plot, widened when
+// they are empty or a single value.
+func (p *Plot) bounds() (xMin, xMax, yMin, yMax float64) {
+	xMin, yMin = math.Inf(1), math.Inf(1)
+	xMax, yMax = math.Inf(-1), math.Inf(-1)
+	for _, s := range p.Series {
+		for i := range s.X {
+			if i >= len(s.Y) || !finite(s.X[i]) || !finite(s.Y[i]) {
+				continue
+			}
+			xMin, xMax = math.Min(xMin, s.X[i]), math.Max(xMax, s.X[i])
+			yMin, yMax = math.Min(yMin, s.Y[i]), math.Max(yMax, s.Y[i])
+		}
+	}
+	if xMin > xMax {
+		xMin, xMax, yMin, yMax = 0, 1, 0, 1
+	}
+	if xMin == xMax {
+		xMin, xMax = xMin-1, xMax+1
+	}
+	if yMin == yMax {
+		yMin, yMax = yMin-1, yMax+1
+	}
+	return xMin, xMax, yMin, yMax
+}
+
+// polyline maps the finite points of s into a w×h box with y growing
+// downwards, and splits the line where points are missing.
+func (p *Plot) polyline(s Series, w, h float64) [][][2]float64 {
+	xMin, xMax, yMin, yMax := p.bounds()
+	var lines [][][2]float64
+	var line [][2]float64
+	for i := range s.X {
+		if i >= len(s.Y) || !finite(s.X[i]) || !finite(s.Y[i]) {
+			if len(line) > 0 {
+				lines = append(lines, line)
+				line = nil
+			}
+			continue
+		}
+		x := (s.X[i] - xMin) / (xMax - xMin) * w
+		y := h - (s.Y[i]-yMin)/(yMax-yMin)*h
+		line = append(line, [2]float64{x, y})
+	}
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// FormatFloat prints v with four significant digits, the precision reports
+// show values at.
+func FormatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
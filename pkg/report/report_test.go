@@ -0,0 +1,87 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func testDocument() *Document {
+	x := make([]float64, 181)
+	y := make([]float64, len(x))
+	for i := range x {
+		x[i] = float64(i - 90)
+		y[i] = math.Cos(x[i] * math.Pi / 180)
+	}
+	y[10] = math.NaN()
+	rows := make([][]string, 80)
+	for i := range rows {
+		rows[i] = []string{fmt.Sprintf("sensor_%d", i), "temperature", FormatFloat(21.5 + float64(i)/10)}
+	}
+	return &Document{
+		Title:    "Experiment exp_1 <draft>",
+		Subtitle: "doa, completed",
+		Sections: []Section{
+			{Heading: "Summary", Fields: []Field{{"experiment_id", "exp_1"}, {"note", strings.Repeat("long (value) ", 40)}}},
+			{Heading: "Spectrum", Text: []string{"MUSIC pseudo-spectrum."}, Plots: []Plot{{
+				Title: "Spectrum", XLabel: "azimuth (deg)", YLabel: "dB",
+				Series: []Series{{Name: "music", X: x, Y: y}},
+			}}},
+			{Heading: "Environment", Table: &Table{Columns: []string{"sensor", "type", "mean"}, Rows: rows}},
+		},
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, testDocument()); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	for _, want := range []string{"Experiment exp_1 &lt;draft&gt;", "<svg", "<polyline", "azimuth (deg)", "sensor_79", "<td>21.5</td>"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("HTML lacks %q", want)
+		}
+	}
+	// the NaN splits the line in two
+	if n := strings.Count(page, "<polyline"); n != 2 {
+		t.Errorf("%d polylines, want 2", n)
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePDF(&buf, testDocument()); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("not a PDF file")
+	}
+
+	// every cross-reference entry points at its object
+	start := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(data)
+	xref, _ := strconv.Atoi(string(start[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(data[xref:], -1)
+	for i, e := range entries {
+		offset, _ := strconv.Atoi(string(e[1]))
+		if want := fmt.Sprintf("%d 0 obj", i+1); !bytes.HasPrefix(data[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", i+1, data[offset:offset+10])
+		}
+	}
+
+	// the plot and the long table run onto further pages
+	if !bytes.Contains(data, []byte("/Count 3")) {
+		t.Error("want three pages")
+	}
+	if !bytes.Contains(data, []byte(`(long \(value\) long`)) {
+		t.Error("parentheses in text are not escaped")
+	}
+}
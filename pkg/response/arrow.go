@@ -0,0 +1,32 @@
+package response
+
+import (
+	"net/http"
+	"strings"
+
+	"isac-cran-system/pkg/arrow"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WantsArrow reports whether the client asked for the Arrow IPC stream
+// format via the Accept header.
+func WantsArrow(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), arrow.ContentType)
+}
+
+// Arrow writes columns as a single record batch in the Arrow IPC stream
+// format.
+func Arrow(c *gin.Context, columns ...arrow.Column) {
+	c.Header("Content-Type", arrow.ContentType)
+	c.Status(http.StatusOK)
+
+	w := arrow.NewWriter(c.Writer)
+	if err := w.Write(columns...); err != nil {
+		c.Error(err)
+		return
+	}
+	if err := w.Close(); err != nil {
+		c.Error(err)
+	}
+}
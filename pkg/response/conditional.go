@@ -0,0 +1,49 @@
+package response
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag returns a strong entity tag for the JSON encoding of v.
+func ETag(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// NotModified reports whether the If-None-Match header of the request
+// matches etag.
+func NotModified(c *gin.Context, etag string) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Conditional writes data tagged with etag, or an empty 304 when the client
+// already holds that version.
+func Conditional(c *gin.Context, etag string, data interface{}) {
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+	if NotModified(c, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	Success(c, data)
+}
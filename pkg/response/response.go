@@ -29,6 +29,15 @@ func Success(c *gin.Context, data interface{}) {
 	})
 }
 
+// Accepted reports that the request was queued and will complete later.
+func Accepted(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusAccepted, Response{
+		Code:    int(errors.CodeSuccess),
+		Message: "accepted",
+		Data:    data,
+	})
+}
+
 func SuccessWithMessage(c *gin.Context, message string, data interface{}) {
 	c.JSON(http.StatusOK, Response{
 		Code:    int(errors.CodeSuccess),
@@ -51,6 +60,15 @@ func Error(c *gin.Context, err error) {
 	})
 }
 
+// ErrorWithData reports err along with data that explains it.
+func ErrorWithData(c *gin.Context, err *errors.AppError, data interface{}) {
+	c.JSON(err.HTTPStatus(), Response{
+		Code:    err.Code.Int(),
+		Message: err.Message,
+		Data:    data,
+	})
+}
+
 func ErrorWithCode(c *gin.Context, code errors.Code, message string) {
 	c.JSON(http.StatusBadRequest, Response{
 		Code:    code.Int(),
@@ -93,7 +111,40 @@ func InternalError(c *gin.Context, message string) {
 	})
 }
 
+// PageDataV2 is the /api/v2 page layout: the items with the paging state
+// kept apart, including the page count.
+type PageDataV2 struct {
+	Items      interface{} `json:"items"`
+	Pagination Pagination  `json:"pagination"`
+}
+
+type Pagination struct {
+	Total      int64 `json:"total"`
+	Page       int   `json:"page"`
+	PageSize   int   `json:"page_size"`
+	TotalPages int   `json:"total_pages"`
+}
+
+const pageLayoutKey = "response.page_layout"
+
+// PageV2 makes SuccessPage write PageDataV2 for the rest of the request.
+// Routes register it ahead of their handler.
+func PageV2(c *gin.Context) {
+	c.Set(pageLayoutKey, 2)
+}
+
 func SuccessPage(c *gin.Context, list interface{}, total int64, page, pageSize int) {
+	if c.GetInt(pageLayoutKey) == 2 {
+		pages := 0
+		if pageSize > 0 {
+			pages = int((total + int64(pageSize) - 1) / int64(pageSize))
+		}
+		Success(c, PageDataV2{
+			Items:      list,
+			Pagination: Pagination{Total: total, Page: page, PageSize: pageSize, TotalPages: pages},
+		})
+		return
+	}
 	Success(c, PageData{
 		List:     list,
 		Total:    total,
@@ -3,6 +3,7 @@ package rpc
 import (
 	"context"
 	"fmt"
+	"io"
 	"time"
 
 	pb "isac-cran-system/api/proto"
@@ -11,13 +12,19 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 )
 
+// dial connects without transport security unless opts say otherwise.
+func dial(addr string, opts []grpc.DialOption) (*grpc.ClientConn, error) {
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
+	return grpc.Dial(addr, opts...)
+}
+
 type AlgorithmClient struct {
 	conn   *grpc.ClientConn
 	client pb.AlgorithmServiceClient
 }
 
-func NewAlgorithmClient(addr string) (*AlgorithmClient, error) {
-	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+func NewAlgorithmClient(addr string, opts ...grpc.DialOption) (*AlgorithmClient, error) {
+	conn, err := dial(addr, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
@@ -51,8 +58,8 @@ type IRSClient struct {
 	client pb.IRSServiceClient
 }
 
-func NewIRSClient(addr string) (*IRSClient, error) {
-	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+func NewIRSClient(addr string, opts ...grpc.DialOption) (*IRSClient, error) {
+	conn, err := dial(addr, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
@@ -63,18 +70,19 @@ func NewIRSClient(addr string) (*IRSClient, error) {
 	}, nil
 }
 
-func (c *IRSClient) GetStatus(ctx context.Context) (*pb.IRSStatus, error) {
+// GetStatus reads panel irsID, or the default panel for an empty ID.
+func (c *IRSClient) GetStatus(ctx context.Context, irsID string) (*pb.IRSStatus, error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	return c.client.GetStatus(ctx, &pb.Empty{})
+	return c.client.GetStatus(ctx, &pb.IRSStatusRequest{IrsId: irsID})
 }
 
-func (c *IRSClient) Configure(ctx context.Context, phaseShifts []float64) error {
+func (c *IRSClient) Configure(ctx context.Context, irsID string, phaseShifts []float64) error {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	_, err := c.client.Configure(ctx, &pb.IRSConfigRequest{PhaseShifts: phaseShifts})
+	_, err := c.client.Configure(ctx, &pb.IRSConfigRequest{PhaseShifts: phaseShifts, IrsId: irsID})
 	return err
 }
 
@@ -87,8 +95,8 @@ type SensorClient struct {
 	client pb.SensorServiceClient
 }
 
-func NewSensorClient(addr string) (*SensorClient, error) {
-	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+func NewSensorClient(addr string, opts ...grpc.DialOption) (*SensorClient, error) {
+	conn, err := dial(addr, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
@@ -110,25 +118,97 @@ func (c *SensorClient) Close() error {
 	return c.conn.Close()
 }
 
+type CaptureClient struct {
+	conn       *grpc.ClientConn
+	client     pb.CaptureServiceClient
+	maxRetries int
+}
+
+func NewCaptureClient(addr string, opts ...grpc.DialOption) (*CaptureClient, error) {
+	conn, err := dial(addr, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	return &CaptureClient{
+		conn:       conn,
+		client:     pb.NewCaptureServiceClient(conn),
+		maxRetries: 3,
+	}, nil
+}
+
+// DownloadIQ streams an IQ capture into w starting at offset and returns the
+// offset reached. Broken streams are resumed from the last received block.
+func (c *CaptureClient) DownloadIQ(ctx context.Context, artifactID, offset int64, w io.Writer) (int64, error) {
+	retries := 0
+	for {
+		next, done, err := c.receiveIQ(ctx, artifactID, offset, w)
+		if done {
+			return next, err
+		}
+		if next > offset {
+			retries = 0
+		}
+		offset = next
+		if err != nil {
+			if ctx.Err() != nil || retries >= c.maxRetries {
+				return offset, err
+			}
+			retries++
+		}
+	}
+}
+
+func (c *CaptureClient) receiveIQ(ctx context.Context, artifactID, offset int64, w io.Writer) (int64, bool, error) {
+	stream, err := c.client.StreamIQ(ctx, &pb.IQStreamRequest{ArtifactId: artifactID, Offset: offset})
+	if err != nil {
+		return offset, false, err
+	}
+
+	for {
+		block, err := stream.Recv()
+		if err == io.EOF {
+			return offset, true, nil
+		}
+		if err != nil {
+			return offset, false, err
+		}
+		if block.Offset != offset {
+			return offset, false, fmt.Errorf("unexpected block offset %d, want %d", block.Offset, offset)
+		}
+		if _, err := w.Write(block.Data); err != nil {
+			return offset, true, err
+		}
+		offset += int64(len(block.Data))
+		if block.Last {
+			return offset, true, nil
+		}
+	}
+}
+
+func (c *CaptureClient) Close() error {
+	return c.conn.Close()
+}
+
 type ClientPool struct {
 	algorithm *AlgorithmClient
 	irs       *IRSClient
 	sensor    *SensorClient
 }
 
-func NewClientPool(algorithmAddr, irsAddr, sensorAddr string) (*ClientPool, error) {
-	algorithm, err := NewAlgorithmClient(algorithmAddr)
+func NewClientPool(algorithmAddr, irsAddr, sensorAddr string, opts ...grpc.DialOption) (*ClientPool, error) {
+	algorithm, err := NewAlgorithmClient(algorithmAddr, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create algorithm client: %w", err)
 	}
 
-	irs, err := NewIRSClient(irsAddr)
+	irs, err := NewIRSClient(irsAddr, opts...)
 	if err != nil {
 		algorithm.Close()
 		return nil, fmt.Errorf("failed to create IRS client: %w", err)
 	}
 
-	sensor, err := NewSensorClient(sensorAddr)
+	sensor, err := NewSensorClient(sensorAddr, opts...)
 	if err != nil {
 		algorithm.Close()
 		irs.Close()
@@ -142,6 +222,13 @@ func NewClientPool(algorithmAddr, irsAddr, sensorAddr string) (*ClientPool, erro
 	}, nil
 }
 
+// NewDiscoveryClientPool dials the services by name through d, balancing
+// each client across the healthy instances of its service.
+func NewDiscoveryClientPool(d Discoverer, opts ...grpc.DialOption) (*ClientPool, error) {
+	return NewClientPool(DiscoveryTarget(AlgorithmServiceName), DiscoveryTarget(DeviceServiceName),
+		DiscoveryTarget(SensorServiceName), append(WithDiscovery(d), opts...)...)
+}
+
 func (p *ClientPool) Algorithm() *AlgorithmClient {
 	return p.algorithm
 }
@@ -0,0 +1,133 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"isac-cran-system/pkg/discovery"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/resolver"
+)
+
+// DiscoveryScheme is the target scheme resolved through service discovery.
+const DiscoveryScheme = "discovery"
+
+// Service names the servers of a split deployment register under. The
+// device service hosts the IRS panels and the USRP and serves the IRS gRPC
+// service.
+const (
+	AlgorithmServiceName = "algorithm-service"
+	DeviceServiceName    = "device-service"
+	SensorServiceName    = "sensor-service"
+)
+
+// roundRobinConfig balances calls across every resolved instance instead of
+// sticking to the first, which is gRPC's default.
+const roundRobinConfig = `{"loadBalancingConfig": [{"round_robin": {}}]}`
+
+// Discoverer finds the healthy instances of a service; ServiceDiscovery
+// implements it with Consul.
+type Discoverer interface {
+	Discover(serviceName string) ([]*discovery.ServiceInfo, error)
+	WatchContext(ctx context.Context, serviceName string, callback func([]*discovery.ServiceInfo))
+}
+
+// DiscoveryTarget is the dial target of a service registered with discovery.
+func DiscoveryTarget(serviceName string) string {
+	return DiscoveryScheme + ":///" + serviceName
+}
+
+// WithDiscovery resolves discovery targets through d and balances calls
+// round-robin across the healthy instances, following them as they come and
+// go.
+func WithDiscovery(d Discoverer) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithResolvers(&discoveryBuilder{discoverer: d}),
+		grpc.WithDefaultServiceConfig(roundRobinConfig),
+	}
+}
+
+type discoveryBuilder struct {
+	discoverer Discoverer
+}
+
+func (b *discoveryBuilder) Scheme() string {
+	return DiscoveryScheme
+}
+
+// Build looks the service up once and then watches it. A failed lookup is
+// reported to the connection, which retries through ResolveNow.
+func (b *discoveryBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	name := target.Endpoint()
+	if name == "" {
+		return nil, fmt.Errorf("discovery target %q has no service name", target.URL.String())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &discoveryResolver{
+		discoverer: b.discoverer,
+		service:    name,
+		cc:         cc,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+	r.resolve()
+	go b.discoverer.WatchContext(ctx, name, r.update)
+	return r, nil
+}
+
+type discoveryResolver struct {
+	discoverer Discoverer
+	service    string
+	cc         resolver.ClientConn
+	ctx        context.Context
+	cancel     context.CancelFunc
+	// mu orders updates against Close, after which none are passed on.
+	mu sync.Mutex
+}
+
+func (r *discoveryResolver) resolve() {
+	services, err := r.discoverer.Discover(r.service)
+	if err != nil {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.ctx.Err() == nil {
+			r.cc.ReportError(err)
+		}
+		return
+	}
+	r.update(services)
+}
+
+// update hands the instances to the connection; an empty list is an error,
+// so that calls fail fast instead of waiting for an instance.
+func (r *discoveryResolver) update(services []*discovery.ServiceInfo) {
+	addrs := make([]resolver.Address, 0, len(services))
+	for _, s := range services {
+		addrs = append(addrs, resolver.Address{Addr: net.JoinHostPort(s.Address, strconv.Itoa(s.Port))})
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ctx.Err() != nil {
+		return
+	}
+	if len(addrs) == 0 {
+		r.cc.ReportError(fmt.Errorf("no healthy instances of %s", r.service))
+		return
+	}
+	r.cc.UpdateState(resolver.State{Addresses: addrs})
+}
+
+func (r *discoveryResolver) ResolveNow(resolver.ResolveNowOptions) {
+	go r.resolve()
+}
+
+func (r *discoveryResolver) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cancel()
+}
@@ -0,0 +1,51 @@
+// Package serial opens and configures serial ports without cgo.
+package serial
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	ParityNone = "none"
+	ParityEven = "even"
+	ParityOdd  = "odd"
+)
+
+// Config describes the line settings; zero values mean 115200 8N1.
+type Config struct {
+	BaudRate int
+	DataBits int
+	Parity   string
+	StopBits int
+}
+
+func (c Config) withDefaults() Config {
+	if c.BaudRate == 0 {
+		c.BaudRate = 115200
+	}
+	if c.DataBits == 0 {
+		c.DataBits = 8
+	}
+	if c.Parity == "" {
+		c.Parity = ParityNone
+	}
+	if c.StopBits == 0 {
+		c.StopBits = 1
+	}
+	return c
+}
+
+// Port is an open serial line. Reads honour the deadline so a silent device
+// cannot block the caller.
+type Port interface {
+	io.ReadWriteCloser
+	SetReadDeadline(t time.Time) error
+}
+
+var (
+	ErrUnsupportedBaudRate = errors.New("serial: unsupported baud rate")
+	ErrInvalidConfig       = errors.New("serial: data bits must be 5-8, stop bits 1 or 2, parity none, even or odd")
+	ErrUnsupportedPlatform = errors.New("serial: ports are only supported on linux")
+)
@@ -0,0 +1,72 @@
+//go:build linux
+
+package serial
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+var baudRates = map[int]uint32{
+	1200:    unix.B1200,
+	2400:    unix.B2400,
+	4800:    unix.B4800,
+	9600:    unix.B9600,
+	19200:   unix.B19200,
+	38400:   unix.B38400,
+	57600:   unix.B57600,
+	115200:  unix.B115200,
+	230400:  unix.B230400,
+	460800:  unix.B460800,
+	921600:  unix.B921600,
+	1000000: unix.B1000000,
+}
+
+var dataBits = map[int]uint32{5: unix.CS5, 6: unix.CS6, 7: unix.CS7, 8: unix.CS8}
+
+// Open opens path in raw mode with the given line settings. The descriptor
+// is non-blocking so the returned port supports read deadlines.
+func Open(path string, cfg Config) (Port, error) {
+	cfg = cfg.withDefaults()
+	speed, ok := baudRates[cfg.BaudRate]
+	if !ok {
+		return nil, ErrUnsupportedBaudRate
+	}
+	size, ok := dataBits[cfg.DataBits]
+	if !ok || (cfg.StopBits != 1 && cfg.StopBits != 2) {
+		return nil, ErrInvalidConfig
+	}
+
+	cflag := size | unix.CREAD | unix.CLOCAL | speed
+	switch cfg.Parity {
+	case ParityNone:
+	case ParityEven:
+		cflag |= unix.PARENB
+	case ParityOdd:
+		cflag |= unix.PARENB | unix.PARODD
+	default:
+		return nil, ErrInvalidConfig
+	}
+	if cfg.StopBits == 2 {
+		cflag |= unix.CSTOPB
+	}
+
+	fd, err := unix.Open(path, unix.O_RDWR|unix.O_NOCTTY|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	t := &unix.Termios{Cflag: cflag, Ispeed: speed, Ospeed: speed}
+	// raw input: return whatever is available, the poller does the waiting
+	t.Cc[unix.VMIN] = 1
+	t.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, t); err != nil {
+		unix.Close(fd)
+		return nil, &os.PathError{Op: "configure", Path: path, Err: err}
+	}
+	if err := unix.IoctlSetInt(fd, unix.TCFLSH, unix.TCIOFLUSH); err != nil {
+		unix.Close(fd)
+		return nil, &os.PathError{Op: "flush", Path: path, Err: err}
+	}
+	return os.NewFile(uintptr(fd), path), nil
+}
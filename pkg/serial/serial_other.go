@@ -0,0 +1,7 @@
+//go:build !linux
+
+package serial
+
+func Open(path string, cfg Config) (Port, error) {
+	return nil, ErrUnsupportedPlatform
+}
@@ -0,0 +1,312 @@
+package sigmf
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	Version = "1.0.0"
+
+	// DatatypeCF32 is interleaved little-endian float32 I/Q, the format the
+	// UHD fc32 host type produces.
+	DatatypeCF32 = "cf32_le"
+
+	DataExt = ".sigmf-data"
+	MetaExt = ".sigmf-meta"
+
+	sampleSize = 8
+)
+
+type Extension struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Optional bool   `json:"optional"`
+}
+
+// Global holds the core global fields plus free-form extension keys, which
+// must be namespaced (e.g. "isac:experiment_id").
+type Global struct {
+	Datatype    string                 `json:"core:datatype"`
+	SampleRate  float64                `json:"core:sample_rate"`
+	Version     string                 `json:"core:version"`
+	NumChannels int                    `json:"core:num_channels,omitempty"`
+	Description string                 `json:"core:description,omitempty"`
+	Recorder    string                 `json:"core:recorder,omitempty"`
+	HW          string                 `json:"core:hw,omitempty"`
+	Extensions  []Extension            `json:"core:extensions,omitempty"`
+	Extra       map[string]interface{} `json:"-"`
+}
+
+func (g Global) MarshalJSON() ([]byte, error) {
+	type core Global
+	data, err := json.Marshal(core(g))
+	if err != nil || len(g.Extra) == 0 {
+		return data, err
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for k, v := range g.Extra {
+		fields[k] = v
+	}
+	return json.Marshal(fields)
+}
+
+func (g *Global) UnmarshalJSON(data []byte) error {
+	type core Global
+	if err := json.Unmarshal(data, (*core)(g)); err != nil {
+		return err
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for k, v := range fields {
+		if strings.HasPrefix(k, "core:") {
+			continue
+		}
+		if g.Extra == nil {
+			g.Extra = make(map[string]interface{})
+		}
+		g.Extra[k] = v
+	}
+	return nil
+}
+
+// Capture starts a segment of contiguous samples. SampleStart counts
+// samples per channel.
+type Capture struct {
+	SampleStart int64   `json:"core:sample_start"`
+	Frequency   float64 `json:"core:frequency"`
+	Datetime    string  `json:"core:datetime"`
+}
+
+type Annotation struct {
+	SampleStart int64  `json:"core:sample_start"`
+	SampleCount int64  `json:"core:sample_count,omitempty"`
+	Comment     string `json:"core:comment,omitempty"`
+}
+
+type Metadata struct {
+	Global      Global       `json:"global"`
+	Captures    []Capture    `json:"captures"`
+	Annotations []Annotation `json:"annotations"`
+}
+
+func ReadMeta(path string) (*Metadata, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var meta Metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &meta, nil
+}
+
+// WriteMeta replaces the metadata file at path, atomically.
+func WriteMeta(path string, meta *Metadata) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Writer streams a cf32_le recording. Multi-channel samples are interleaved
+// per sample index as SigMF requires. A new capture segment is started
+// whenever the frequency changes or a block does not continue the previous
+// one in time.
+type Writer struct {
+	base string
+	file *os.File
+	buf  *bufio.Writer
+	hash hash.Hash
+	meta Metadata
+
+	samples  int64
+	lastFreq float64
+	nextTime time.Time
+}
+
+// gapTolerance is how far a block may start from the expected time before
+// it is treated as a discontinuity.
+const gapTolerance = time.Millisecond
+
+// Create opens base+DataExt for writing and writes an initial metadata file
+// so the recording is discoverable while in progress.
+func Create(base string, global Global) (*Writer, error) {
+	if global.Datatype == "" {
+		global.Datatype = DatatypeCF32
+	}
+	if global.Datatype != DatatypeCF32 {
+		return nil, fmt.Errorf("unsupported datatype %q", global.Datatype)
+	}
+	if global.Version == "" {
+		global.Version = Version
+	}
+	if global.NumChannels <= 0 {
+		global.NumChannels = 1
+	}
+
+	file, err := os.Create(base + DataExt)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.New()
+	w := &Writer{
+		base: base,
+		file: file,
+		buf:  bufio.NewWriterSize(io.MultiWriter(file, digest), 1<<20),
+		hash: digest,
+		meta: Metadata{Global: global, Captures: []Capture{}, Annotations: []Annotation{}},
+	}
+	if err := w.writeMeta(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write appends one block of samples per channel, captured starting at
+// start with the radio tuned to frequency.
+func (w *Writer) Write(start time.Time, frequency float64, channels [][]complex128) error {
+	if len(channels) != w.meta.Global.NumChannels {
+		return fmt.Errorf("got %d channels, recording has %d", len(channels), w.meta.Global.NumChannels)
+	}
+	n := len(channels[0])
+	for _, ch := range channels {
+		if len(ch) != n {
+			return fmt.Errorf("channels have different lengths")
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+
+	gap := start.Sub(w.nextTime)
+	if len(w.meta.Captures) == 0 || frequency != w.lastFreq || gap > gapTolerance || gap < -gapTolerance {
+		w.meta.Captures = append(w.meta.Captures, Capture{
+			SampleStart: w.samples,
+			Frequency:   frequency,
+			Datetime:    start.UTC().Format(time.RFC3339Nano),
+		})
+	}
+
+	var sample [sampleSize]byte
+	for i := 0; i < n; i++ {
+		for _, ch := range channels {
+			binary.LittleEndian.PutUint32(sample[0:], math.Float32bits(float32(real(ch[i]))))
+			binary.LittleEndian.PutUint32(sample[4:], math.Float32bits(float32(imag(ch[i]))))
+			if _, err := w.buf.Write(sample[:]); err != nil {
+				return err
+			}
+		}
+	}
+
+	w.samples += int64(n)
+	w.lastFreq = frequency
+	w.nextTime = start.Add(time.Duration(float64(n) / w.meta.Global.SampleRate * float64(time.Second)))
+	return nil
+}
+
+func (w *Writer) Annotate(a Annotation) {
+	w.meta.Annotations = append(w.meta.Annotations, a)
+}
+
+func (w *Writer) SetExtra(key string, value interface{}) {
+	if w.meta.Global.Extra == nil {
+		w.meta.Global.Extra = make(map[string]interface{})
+	}
+	w.meta.Global.Extra[key] = value
+}
+
+// Samples returns the number of samples written per channel.
+func (w *Writer) Samples() int64 {
+	return w.samples
+}
+
+func (w *Writer) Bytes() int64 {
+	return w.samples * int64(w.meta.Global.NumChannels) * sampleSize
+}
+
+// Flush writes buffered samples to the data file.
+func (w *Writer) Flush() error {
+	return w.buf.Flush()
+}
+
+// Checksum returns the hex SHA-256 of the samples flushed to the data file.
+func (w *Writer) Checksum() string {
+	return hex.EncodeToString(w.hash.Sum(nil))
+}
+
+// Close flushes the data file and writes the final metadata.
+func (w *Writer) Close() error {
+	flushErr := w.buf.Flush()
+	closeErr := w.file.Close()
+	if flushErr != nil {
+		return flushErr
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+	return w.writeMeta()
+}
+
+func (w *Writer) writeMeta() error {
+	return WriteMeta(w.base+MetaExt, &w.meta)
+}
+
+// ReadSamples reads count samples per channel of a cf32_le recording with
+// the given channel count, starting at sample offset, as one row per
+// channel.
+func ReadSamples(dataPath string, channels int, offset int64, count int) ([][]complex128, error) {
+	if channels < 1 || offset < 0 || count < 0 {
+		return nil, fmt.Errorf("invalid read of %d samples at %d from %d channels", count, offset, channels)
+	}
+	file, err := os.Open(dataPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	frame := int64(channels * sampleSize)
+	data := make([]byte, int64(count)*frame)
+	if _, err := file.ReadAt(data, offset*frame); err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s holds fewer than %d samples after %d", dataPath, count, offset)
+		}
+		return nil, err
+	}
+
+	X := make([][]complex128, channels)
+	for m := range X {
+		X[m] = make([]complex128, count)
+	}
+	for i := 0; i < count; i++ {
+		for m := range X {
+			p := data[int64(i)*frame+int64(m*sampleSize):]
+			re := math.Float32frombits(binary.LittleEndian.Uint32(p))
+			im := math.Float32frombits(binary.LittleEndian.Uint32(p[4:]))
+			X[m][i] = complex(float64(re), float64(im))
+		}
+	}
+	return X, nil
+}
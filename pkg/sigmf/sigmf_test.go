@@ -0,0 +1,82 @@
+package sigmf
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriter_Roundtrip(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "rec")
+	w, err := Create(base, Global{SampleRate: 1000, NumChannels: 2, Extra: map[string]interface{}{"isac:experiment_id": "exp-1"}})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	block := [][]complex128{{1 + 2i, 3 + 4i}, {-1, -2i}}
+	if err := w.Write(start, 2.4e9, block); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	// continues the first block exactly: 2 samples at 1 kS/s
+	if err := w.Write(start.Add(2*time.Millisecond), 2.4e9, block); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(start.Add(time.Second), 2.4e9, block); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(start, 2.4e9, block[:1]); err == nil {
+		t.Error("expected error for wrong channel count")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(base + DataExt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 6*2*sampleSize {
+		t.Fatalf("data size = %d, want %d", len(data), 6*2*sampleSize)
+	}
+	if sum := sha256.Sum256(data); w.Checksum() != hex.EncodeToString(sum[:]) {
+		t.Errorf("Checksum() = %s, want SHA-256 of the data file", w.Checksum())
+	}
+	// sample 0 of channel 1 follows sample 0 of channel 0
+	if got := math.Float32frombits(binary.LittleEndian.Uint32(data[8:])); got != -1 {
+		t.Errorf("interleaved channel 1 I = %v, want -1", got)
+	}
+	if got := math.Float32frombits(binary.LittleEndian.Uint32(data[20:])); got != 4 {
+		t.Errorf("channel 0 sample 1 Q = %v, want 4", got)
+	}
+
+	meta, err := ReadMeta(base + MetaExt)
+	if err != nil {
+		t.Fatalf("ReadMeta() error = %v", err)
+	}
+	if meta.Global.Datatype != DatatypeCF32 || meta.Global.NumChannels != 2 {
+		t.Errorf("global = %+v", meta.Global)
+	}
+	if meta.Global.Extra["isac:experiment_id"] != "exp-1" {
+		t.Errorf("extension field lost: %v", meta.Global.Extra)
+	}
+	if len(meta.Captures) != 2 || meta.Captures[1].SampleStart != 4 {
+		t.Errorf("captures = %+v, want a second segment at sample 4", meta.Captures)
+	}
+
+	X, err := ReadSamples(base+DataExt, 2, 3, 2)
+	if err != nil {
+		t.Fatalf("ReadSamples() error = %v", err)
+	}
+	if X[0][0] != 3+4i || X[1][0] != -2i || X[0][1] != 1+2i || X[1][1] != -1 {
+		t.Errorf("ReadSamples() = %v, want samples 3 and 4 of both channels", X)
+	}
+	if _, err := ReadSamples(base+DataExt, 2, 5, 2); err == nil {
+		t.Error("expected error reading past the end")
+	}
+}
@@ -9,6 +9,7 @@ CREATE TABLE IF NOT EXISTS irs_config (
     phase_shifts JSON COMMENT 'Phase shift values array',
     frequency_band VARCHAR(50) COMMENT 'Frequency band',
     status TINYINT DEFAULT 1 COMMENT 'Status: 0=inactive, 1=active, 2=applied',
+    version BIGINT NOT NULL DEFAULT 1 COMMENT 'Row version, incremented by every update',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     INDEX idx_status (status),
@@ -21,8 +22,11 @@ CREATE TABLE IF NOT EXISTS experiment_result (
     algorithm_type VARCHAR(50) NOT NULL COMMENT 'Algorithm type: beamforming, doa, scheduling, rateless',
     parameters JSON COMMENT 'Experiment parameters',
     result_data JSON COMMENT 'Result data',
-    matlab_file_path VARCHAR(255) COMMENT 'MATLAB file path for data exchange',
+    power_estimate JSON COMMENT 'Estimated power and energy consumption',
+    energy_report JSON COMMENT 'Estimated and measured energy report',
     status TINYINT DEFAULT 0 COMMENT 'Status: 0=pending, 1=running, 2=completed, 3=failed',
+    holder VARCHAR(100) COMMENT 'Caller that started the experiment; queued experiments run on their behalf',
+    version BIGINT NOT NULL DEFAULT 1 COMMENT 'Row version, incremented by every update',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     completed_at TIMESTAMP NULL COMMENT 'Completion time',
     INDEX idx_experiment_id (experiment_id),
@@ -31,6 +35,38 @@ CREATE TABLE IF NOT EXISTS experiment_result (
     INDEX idx_created_at (created_at)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Experiment result table';
 
+CREATE TABLE IF NOT EXISTS artifact (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    experiment_id VARCHAR(50) COMMENT 'Linked experiment ID, empty for shared artifacts',
+    artifact_type VARCHAR(30) NOT NULL COMMENT 'Artifact type: matlab, iq_capture, dataset, checkpoint, plot, export',
+    name VARCHAR(255) COMMENT 'File name',
+    storage_key VARCHAR(255) NOT NULL UNIQUE COMMENT 'Object store key',
+    backend VARCHAR(20) COMMENT 'Storage backend: local, s3',
+    size BIGINT COMMENT 'Size in bytes',
+    checksum CHAR(64) COMMENT 'SHA-256 checksum',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_experiment_id (experiment_id),
+    INDEX idx_artifact_type (artifact_type),
+    INDEX idx_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Experiment artifact table';
+
+CREATE TABLE IF NOT EXISTS device_reservation (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    device VARCHAR(20) NOT NULL COMMENT 'Reserved device: irs, usrp',
+    holder VARCHAR(100) NOT NULL COMMENT 'User holding the reservation',
+    purpose VARCHAR(255) COMMENT 'Reservation purpose',
+    start_time TIMESTAMP NOT NULL COMMENT 'Window start',
+    end_time TIMESTAMP NOT NULL COMMENT 'Window end',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_device_window (device, start_time)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Device reservation table';
+
+CREATE TABLE IF NOT EXISTS device_lock (
+    device VARCHAR(20) PRIMARY KEY COMMENT 'Reservable device, locked while a booking is checked and inserted'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Per-device booking lock table';
+
+INSERT IGNORE INTO device_lock (device) VALUES ('irs'), ('usrp');
+
 CREATE TABLE IF NOT EXISTS sensor_info (
     sensor_id VARCHAR(50) PRIMARY KEY COMMENT 'Sensor ID',
     sensor_type VARCHAR(50) NOT NULL COMMENT 'Sensor type: temperature, humidity, pressure, etc.',
@@ -39,6 +75,9 @@ CREATE TABLE IF NOT EXISTS sensor_info (
     min_value DOUBLE COMMENT 'Minimum value range',
     max_value DOUBLE COMMENT 'Maximum value range',
     status TINYINT DEFAULT 1 COMMENT 'Status: 0=offline, 1=online',
+    calibration JSON COMMENT 'Calibration: offset, scale, polynomial',
+    lifecycle VARCHAR(20) DEFAULT 'active' COMMENT 'Lifecycle: active, maintenance, decommissioned',
+    maintenance JSON COMMENT 'Maintenance window: start, end, reason',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     INDEX idx_sensor_type (sensor_type),
@@ -46,6 +85,38 @@ CREATE TABLE IF NOT EXISTS sensor_info (
     INDEX idx_status (status)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Sensor information table';
 
+CREATE TABLE IF NOT EXISTS sensor_alert_rule (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(100) COMMENT 'Rule name',
+    sensor_id VARCHAR(50) NOT NULL COMMENT 'Watched sensor',
+    comparison VARCHAR(10) NOT NULL COMMENT 'Comparison: gt, gte, lt, lte',
+    threshold DOUBLE COMMENT 'Threshold value',
+    duration DOUBLE COMMENT 'Seconds the condition must hold before alerting',
+    level VARCHAR(20) DEFAULT 'warning' COMMENT 'Alert level: warning, critical',
+    enabled BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_sensor_id (sensor_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Sensor alert rule table';
+
+CREATE TABLE IF NOT EXISTS sensor_alert (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    rule_id BIGINT COMMENT 'Rule that raised the alert',
+    sensor_id VARCHAR(50) COMMENT 'Sensor ID',
+    level VARCHAR(20) COMMENT 'Alert level',
+    status VARCHAR(20) COMMENT 'Status: active, resolved',
+    comparison VARCHAR(10) COMMENT 'Comparison of the rule when raised',
+    threshold DOUBLE COMMENT 'Threshold of the rule when raised',
+    value DOUBLE COMMENT 'Reading that raised the alert',
+    message VARCHAR(255),
+    triggered_at TIMESTAMP NULL COMMENT 'Time the alert was raised',
+    resolved_at TIMESTAMP NULL COMMENT 'Time the alert cleared',
+    INDEX idx_rule_id (rule_id),
+    INDEX idx_sensor_id (sensor_id),
+    INDEX idx_status (status),
+    INDEX idx_triggered_at (triggered_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Sensor alert table';
+
 INSERT INTO sensor_info (sensor_id, sensor_type, location, unit, min_value, max_value, status) VALUES
 ('temp-001', 'temperature', 'Room-A', '°C', 15, 35, 1),
 ('temp-002', 'temperature', 'Room-B', '°C', 15, 35, 1),
@@ -1,27 +1,60 @@
 package integration
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"isac-cran-system/internal/device/irs"
+	"isac-cran-system/internal/device/sensor"
 	"isac-cran-system/internal/handler"
+	"isac-cran-system/internal/model"
 	"isac-cran-system/internal/router"
+	"isac-cran-system/internal/service"
+	"isac-cran-system/internal/ui"
+	"isac-cran-system/pkg/client"
+	"isac-cran-system/pkg/errors"
+	"isac-cran-system/pkg/hub"
+	"isac-cran-system/pkg/mq"
+	"isac-cran-system/pkg/queue"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
 )
 
 func setupTestRouter() *gin.Engine {
+	return setupTestRouterWith(service.NewAlgorithmService(nil))
+}
+
+func setupTestRouterWith(algorithmSvc *service.AlgorithmService) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 
 	irsHandler := handler.NewIRSHandler(nil)
 	channelHandler := handler.NewChannelHandler(nil)
-	algorithmHandler := handler.NewAlgorithmHandler(nil)
+	algorithmHandler := handler.NewAlgorithmHandler(algorithmSvc)
 	sensorHandler := handler.NewSensorHandler(nil)
+	powerHandler := handler.NewPowerHandler(nil)
+	exportHandler := handler.NewExportHandler(nil)
+	artifactHandler := handler.NewArtifactHandler(nil)
+	deviceHandler := handler.NewDeviceHandler(service.NewDeviceService())
+	reservationHandler := handler.NewReservationHandler(service.NewReservationService(nil))
+	recordingHandler := handler.NewRecordingHandler(service.NewRecordingService(nil, "", 0))
+	usrpHandler := handler.NewUSRPHandler(service.NewUSRPService(nil, nil))
 	systemHandler := handler.NewSystemHandler()
+	graphqlHandler := handler.NewGraphQLHandler(nil, nil, nil, service.NewDeviceService(), nil)
+	alertHandler := handler.NewAlertHandler(service.NewAlertService(nil))
 
-	return router.Setup(irsHandler, channelHandler, algorithmHandler, sensorHandler, systemHandler)
+	return router.Setup(irsHandler, channelHandler, algorithmHandler, sensorHandler, powerHandler, exportHandler, artifactHandler, deviceHandler, reservationHandler, recordingHandler, usrpHandler, systemHandler, graphqlHandler, alertHandler)
 }
 
 func TestHealthEndpoint(t *testing.T) {
@@ -69,14 +102,32 @@ func TestAPIRoutesRegistered(t *testing.T) {
 	routes := router.Routes()
 
 	expectedRoutes := []string{
+		"/readyz",
 		"/api/v1/health",
 		"/api/v1/info",
+		"/api/v1/leader",
 		"/api/v1/irs/config",
 		"/api/v1/irs/status",
+		"/api/v1/irs/constraints",
+		"/api/v1/irs/firmware",
+		"/api/v1/irs/firmware/updates",
 		"/api/v1/channel/data",
 		"/api/v1/algorithm/beamforming",
 		"/api/v1/algorithm/doa",
+		"/api/v1/algorithm/irs-impact",
+		"/api/v1/algorithm/joint-beamforming",
+		"/api/v1/algorithm/hybrid-beamforming",
+		"/api/v1/sessions",
+		"/api/v1/beams/sweeps",
 		"/api/v1/sensor/list",
+		"/api/v1/sensor/health",
+		"/api/v1/sensor/replay",
+		"/api/v1/alerts",
+		"/api/v1/alerts/prometheus-rules",
+		"/api/v1/alerts/alertmanager",
+		"/api/v1/graphql",
+		"/api/v1/stream/events",
+		"/api/v1/stream/ws",
 	}
 
 	routeMap := make(map[string]bool)
@@ -91,6 +142,896 @@ func TestAPIRoutesRegistered(t *testing.T) {
 	}
 }
 
+func TestGraphQLEndpoint(t *testing.T) {
+	router := setupTestRouter()
+
+	req, _ := http.NewRequest("POST", "/api/v1/graphql", strings.NewReader(`{"query": "{ devices { name } }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if body := w.Body.String(); body != `{"data":{"devices":[]}}` {
+		t.Errorf("Unexpected response %s", body)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/graphql?query="+url.QueryEscape("{ devices { missing } }"), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid query, got %d", w.Code)
+	}
+
+	query := "{ devices { name " + strings.Repeat(" ", 64<<10) + "} }"
+	req, _ = http.NewRequest("POST", "/api/v1/graphql", strings.NewReader(`{"query": "`+query+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an oversized body, got %d", w.Code)
+	}
+}
+
+func TestAlgorithmDryRun(t *testing.T) {
+	router := setupTestRouter()
+
+	body := `{"experiment_id": "dry1", "params": {"element_count": 16, "target_direction": 0.5, "snr_threshold": 1}}`
+	req, _ := http.NewRequest("POST", "/api/v1/algorithm/beamforming?dry_run=true", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response struct {
+		Data model.DryRunResult `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusOK || !response.Data.Valid || response.Data.Estimate == nil {
+		t.Fatalf("Expected a valid dry run with an estimate, got %d: %s", w.Code, w.Body.String())
+	}
+	if response.Data.Estimate.Iterations != 100 || response.Data.Estimate.Devices[0] != model.ReservableDeviceIRS {
+		t.Errorf("Unexpected estimate %+v", response.Data.Estimate)
+	}
+
+	body = `{"experiment_id": "dry2", "params": {"element_count": 2, "num_sources": 2, "snapshot_length": 64, "search_step": -1}}`
+	req, _ = http.NewRequest("POST", "/api/v1/algorithm/doa?dry_run=true", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	response.Data = model.DryRunResult{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusOK || response.Data.Valid || response.Data.Estimate != nil {
+		t.Fatalf("Expected an invalid dry run without an estimate, got %d: %s", w.Code, w.Body.String())
+	}
+	fields := make(map[string]bool)
+	for _, d := range response.Data.Diagnostics {
+		fields[d.Field] = true
+	}
+	if !fields["element_count"] || !fields["search_step"] {
+		t.Errorf("Expected element_count and search_step diagnostics, got %+v", response.Data.Diagnostics)
+	}
+}
+
+func TestAlgorithmInvalidParams(t *testing.T) {
+	router := setupTestRouter()
+
+	body := `{"experiment_id": "bad1", "params": {"element_count": 8, "num_sources": 2, "snapshot_length": 4, "source": "radar"}}`
+	req, _ := http.NewRequest("POST", "/api/v1/algorithm/doa", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response struct {
+		Code int               `json:"code"`
+		Data model.Diagnostics `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusBadRequest || response.Code != 10001 {
+		t.Fatalf("Expected the parameters to be rejected, got %d: %s", w.Code, w.Body.String())
+	}
+	fields := make(map[string]bool)
+	for _, d := range response.Data {
+		fields[d.Field] = true
+	}
+	if len(fields) != 2 || !fields["source"] || !fields["snapshot_length"] {
+		t.Errorf("Expected source and snapshot_length violations, got %+v", response.Data)
+	}
+}
+
+func TestAlgorithmAdmission(t *testing.T) {
+	algorithmSvc := service.NewAlgorithmService(nil)
+	algorithmSvc.SetAdmission(service.AdmissionConfig{MaxDuration: time.Nanosecond})
+	router := setupTestRouterWith(algorithmSvc)
+
+	body := `{"experiment_id": "long1", "params": {"element_count": 64, "target_direction": 0.5, "snr_threshold": 1}}`
+	req, _ := http.NewRequest("POST", "/api/v1/algorithm/beamforming", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response struct {
+		Code int                `json:"code"`
+		Data model.CostEstimate `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusUnprocessableEntity || response.Code != 60005 {
+		t.Fatalf("Expected the experiment to be rejected, got %d: %s", w.Code, w.Body.String())
+	}
+	if response.Data.Runtime <= 0 || response.Data.Iterations != 100 {
+		t.Errorf("Expected the estimate with the rejection, got %+v", response.Data)
+	}
+}
+
+func TestIRSStatusLongPoll(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	simulator := irs.NewSimulator(4, "28GHz")
+	temperature := 30.0
+	simulator.InjectFaults(&model.IRSFaultRequest{IRSFaults: model.IRSFaults{Temperature: &temperature}})
+	controller := irs.NewController(simulator)
+	controller.Connect(context.Background())
+	panels := irs.NewManager()
+	panels.Add("panel0", controller)
+
+	router := gin.New()
+	router.GET("/status", handler.NewIRSHandler(service.NewIRSService(panels)).GetStatus)
+	get := func(query, etag string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/status"+query, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("", "")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("Expected status with an ETag, got %d: %s", w.Code, w.Body.String())
+	}
+	if w = get("", etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("Expected 304 for an unchanged status, got %d: %s", w.Code, w.Body.String())
+	}
+
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		simulator.SetPhaseShifts(context.Background(), []float64{0.1, 0.2, 0.3, 0.4})
+	}()
+	start := time.Now()
+	w = get("?wait=5s", etag)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Fatalf("Expected the changed status, got %d: %s", w.Code, w.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("Expected the long poll to return on the change, returned after %s", elapsed)
+	}
+
+	etag = w.Header().Get("ETag")
+	if w = get("?wait=0.3", etag); w.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 once the wait runs out, got %d", w.Code)
+	}
+	if w = get("?wait=soon", etag); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid wait, got %d", w.Code)
+	}
+}
+
+func TestIRSFirmwareUpdate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	simulator := irs.NewSimulator(4, "28GHz")
+	controller := irs.NewController(simulator)
+	controller.Connect(context.Background())
+	panels := irs.NewManager()
+	panels.Add("panel0", controller)
+	devices := service.NewDeviceService()
+	devices.Register(model.DeviceInfo{Name: "irs", ID: "panel0"}, controller)
+	irsService := service.NewIRSService(panels)
+	irsHandler := handler.NewIRSHandler(irsService)
+
+	router := gin.New()
+	router.GET("/devices", handler.NewDeviceHandler(devices).List)
+	router.POST("/irs/config", irsHandler.Configure)
+	router.POST("/irs/firmware", irsHandler.UploadFirmware)
+	router.POST("/irs/firmware/updates", irsHandler.ScheduleFirmwareUpdate)
+	router.GET("/irs/firmware/updates/:id", irsHandler.GetFirmwareUpdate)
+	router.DELETE("/irs/firmware/updates/:id", irsHandler.CancelFirmwareUpdate)
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	waitFor := func(id int64) model.FirmwareUpdate {
+		var response struct {
+			Data model.FirmwareUpdate `json:"data"`
+		}
+		for deadline := time.Now().Add(3 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+			json.Unmarshal(send("GET", fmt.Sprintf("/irs/firmware/updates/%d", id), "").Body.Bytes(), &response)
+			if response.Data.Status.Done() {
+				break
+			}
+		}
+		return response.Data
+	}
+
+	phases := `{"name": "fixed", "element_count": 4, "frequency_band": "28GHz", "phase_shifts": [0.5, 1, 1.5, 2]}`
+	if w := send("POST", "/irs/config", phases); w.Code != http.StatusOK {
+		t.Fatalf("Expected the panel to be configured, got %d: %s", w.Code, w.Body.String())
+	}
+
+	image := "firmware image 1.1.0"
+	if w := send("POST", "/irs/firmware?version=1.1.0&sha256=0000", image); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a checksum mismatch to be rejected, got %d", w.Code)
+	}
+	w := send("POST", "/irs/firmware?version=1.1.0", image)
+	var uploaded struct {
+		Data model.FirmwarePackage `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &uploaded)
+	if w.Code != http.StatusOK || uploaded.Data.Size != len(image) || len(uploaded.Data.Checksum) != 64 {
+		t.Fatalf("Expected the package to be stored, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send("POST", "/irs/firmware?version=2.0.0", image); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected the same image under another version to be rejected, got %d", w.Code)
+	}
+
+	later := time.Now().Add(time.Hour).Format(time.RFC3339)
+	w = send("POST", "/irs/firmware/updates", fmt.Sprintf(`{"package_id": %q, "scheduled_at": %q}`, uploaded.Data.ID, later))
+	var scheduled struct {
+		Data model.FirmwareUpdate `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &scheduled)
+	if w.Code != http.StatusOK || scheduled.Data.Status != model.FirmwareUpdateScheduled || scheduled.Data.IRSID != "panel0" {
+		t.Fatalf("Expected the update to be scheduled, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send("POST", "/irs/firmware/updates", fmt.Sprintf(`{"package_id": %q}`, uploaded.Data.ID)); w.Code == http.StatusOK {
+		t.Error("Expected a second pending update of the panel to be rejected")
+	}
+	if w := send("DELETE", fmt.Sprintf("/irs/firmware/updates/%d", scheduled.Data.ID), ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected the scheduled update to be cancelled, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = send("POST", "/irs/firmware/updates", fmt.Sprintf(`{"package_id": %q}`, uploaded.Data.ID))
+	json.Unmarshal(w.Body.Bytes(), &scheduled)
+	update := waitFor(scheduled.Data.ID)
+	if update.Status != model.FirmwareUpdateSucceeded || update.PreviousVersion != "1.0.0" || update.Version != "1.1.0" {
+		t.Fatalf("Expected the update to succeed, got %+v", update)
+	}
+	status, _ := controller.GetStatus(context.Background())
+	if status.PhaseShifts[3] != 2 {
+		t.Errorf("Expected the active configuration to be reapplied after the restart, got %v", status.PhaseShifts)
+	}
+	var listed struct {
+		Data []model.DeviceInfo `json:"data"`
+	}
+	json.Unmarshal(send("GET", "/devices", "").Body.Bytes(), &listed)
+	if v := listed.Data[0].Versions; v == nil || v.Firmware != "1.1.0" || v.Driver != irs.DriverVersion {
+		t.Errorf("Expected the devices to report firmware 1.1.0, got %+v", v)
+	}
+
+	simulator.InjectFaults(&model.IRSFaultRequest{IRSFaults: model.IRSFaults{WriteFailure: true}})
+	w = send("POST", "/irs/firmware?version=1.2.0", "firmware image 1.2.0")
+	json.Unmarshal(w.Body.Bytes(), &uploaded)
+	w = send("POST", "/irs/firmware/updates", fmt.Sprintf(`{"package_id": %q}`, uploaded.Data.ID))
+	json.Unmarshal(w.Body.Bytes(), &scheduled)
+	if update := waitFor(scheduled.Data.ID); update.Status != model.FirmwareUpdateFailed || update.Error == "" {
+		t.Errorf("Expected a failed flash to fail the update, got %+v", update)
+	}
+}
+
+func TestSensorRegistration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	collector := sensor.NewCollector(sensor.NewSimulator(), time.Second)
+	collector.Connect(context.Background())
+	sensorHandler := handler.NewSensorHandler(service.NewSensorService(collector, nil))
+
+	router := gin.New()
+	router.POST("/sensor", sensorHandler.Register)
+	router.PUT("/sensor/:id", sensorHandler.Update)
+	router.DELETE("/sensor/:id", sensorHandler.Delete)
+	router.POST("/sensor/:id/calibrate", sensorHandler.Calibrate)
+	router.GET("/sensor/read/:id", sensorHandler.ReadSensor)
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	body := `{"sensor_id": "temp-100", "sensor_type": "temperature", "location": "Lab", "unit": "°C", "min_value": 10, "max_value": 30}`
+	if w := send("POST", "/sensor", body); w.Code != http.StatusOK {
+		t.Fatalf("Expected the sensor to be registered, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send("POST", "/sensor", body); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a duplicate sensor to be rejected, got %d", w.Code)
+	}
+	if w := send("POST", "/sensor", `{"sensor_id": "x", "sensor_type": "temperature", "min_value": 5, "max_value": 5}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected an empty value range to be rejected, got %d", w.Code)
+	}
+
+	w := send("GET", "/sensor/read/temp-100", "")
+	var read struct {
+		Data model.SensorData `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &read)
+	if w.Code != http.StatusOK || read.Data.Value < 10 || read.Data.Value > 30 || read.Data.Location != "Lab" {
+		t.Fatalf("Expected a reading of the new sensor, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := send("PUT", "/sensor/temp-100", `{"sensor_type": "temperature", "location": "Hall", "min_value": 0, "max_value": 5}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected the sensor to be updated, got %d: %s", w.Code, w.Body.String())
+	}
+	w = send("GET", "/sensor/read/temp-100", "")
+	json.Unmarshal(w.Body.Bytes(), &read)
+	if read.Data.Location != "Hall" || read.Data.Value > 5 {
+		t.Errorf("Expected readings of the updated sensor, got %s", w.Body.String())
+	}
+
+	if w := send("POST", "/sensor/temp-100/calibrate", `{"points": [{"raw": 1, "reference": 1}, {"raw": 1, "reference": 2}]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected equal raw readings to be rejected, got %d", w.Code)
+	}
+	if w := send("POST", "/sensor/temp-100/calibrate", `{"points": [{"raw": 0, "reference": 32}, {"raw": 5, "reference": 41}]}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected the sensor to be calibrated, got %d: %s", w.Code, w.Body.String())
+	}
+	// an update without calibration keeps the current one
+	send("PUT", "/sensor/temp-100", `{"sensor_type": "temperature", "location": "Hall", "min_value": 0, "max_value": 5}`)
+	read.Data = model.SensorData{}
+	w = send("GET", "/sensor/read/temp-100", "")
+	json.Unmarshal(w.Body.Bytes(), &read)
+	if raw := read.Data.RawValue; raw == nil || math.Abs(read.Data.Value-(*raw*1.8+32)) > 1e-9 {
+		t.Errorf("Expected a calibrated reading, got %s", w.Body.String())
+	}
+
+	if w := send("DELETE", "/sensor/temp-100", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected the sensor to be deleted, got %d", w.Code)
+	}
+	if w := send("DELETE", "/sensor/temp-100", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a deleted sensor, got %d", w.Code)
+	}
+}
+
+// memSensorDataStore answers aggregated queries with one window per query.
+type memSensorDataStore struct {
+	query *model.SensorDataQuery
+}
+
+func (m *memSensorDataStore) Write(ctx context.Context, data *model.SensorData) error {
+	return nil
+}
+
+func (m *memSensorDataStore) WriteBatch(ctx context.Context, dataPoints []*model.SensorData) error {
+	return nil
+}
+
+func (m *memSensorDataStore) Query(ctx context.Context, q *model.SensorDataQuery) ([]*model.SensorData, error) {
+	return []*model.SensorData{}, nil
+}
+
+func (m *memSensorDataStore) Aggregate(ctx context.Context, q *model.SensorDataQuery) ([]*model.SensorAggregatedData, error) {
+	m.query = q
+	start, _ := q.Range()
+	return []*model.SensorAggregatedData{{SensorID: q.SensorID, WindowStart: start, WindowEnd: start.Add(q.Window), Count: 3}}, nil
+}
+
+func TestSensorDataAggregation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &memSensorDataStore{}
+	sensorHandler := handler.NewSensorHandler(service.NewSensorService(nil, store))
+	router := gin.New()
+	router.GET("/sensor/data", sensorHandler.GetData)
+	get := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/sensor/data?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, query := range []string{
+		"window=500ms",
+		"window=1s&start_time=2025-01-01T00:00:00&end_time=2025-01-02T00:00:00",
+		"window=1m&start_time=2025-01-02T00:00:00&end_time=2025-01-01T00:00:00",
+	} {
+		if w := get(query); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s to be rejected, got %d", query, w.Code)
+		}
+	}
+
+	w := get("sensor_id=temp-001&window=5m&start_time=2025-01-01T00:00:00&end_time=2025-01-01T01:00:00")
+	var resp struct {
+		Data []model.SensorAggregatedData `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || len(resp.Data) != 1 || resp.Data[0].SensorID != "temp-001" || resp.Data[0].Count != 3 {
+		t.Fatalf("Expected one aggregated window, got %d: %s", w.Code, w.Body.String())
+	}
+	if store.query == nil || store.query.Window != 5*time.Minute {
+		t.Errorf("Expected the 5m window to reach the store, got %+v", store.query)
+	}
+}
+
+// memAlertStore keeps alert rules and alerts in memory.
+type memAlertStore struct {
+	mu     sync.Mutex
+	rules  []model.AlertRule
+	alerts []model.SensorAlert
+}
+
+func (m *memAlertStore) CreateRule(ctx context.Context, rule *model.AlertRule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rule.ID = int64(len(m.rules) + 1)
+	m.rules = append(m.rules, *rule)
+	return nil
+}
+
+func (m *memAlertStore) GetRule(ctx context.Context, id int64) (*model.AlertRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, rule := range m.rules {
+		if rule.ID == id {
+			return &rule, nil
+		}
+	}
+	return nil, errors.New(errors.CodeNotFound, "alert rule not found")
+}
+
+func (m *memAlertStore) ListRules(ctx context.Context) ([]model.AlertRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]model.AlertRule(nil), m.rules...), nil
+}
+
+func (m *memAlertStore) UpdateRule(ctx context.Context, rule *model.AlertRule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.rules {
+		if m.rules[i].ID == rule.ID {
+			m.rules[i] = *rule
+		}
+	}
+	return nil
+}
+
+func (m *memAlertStore) DeleteRule(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.rules {
+		if m.rules[i].ID == id {
+			m.rules = append(m.rules[:i], m.rules[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New(errors.CodeNotFound, "alert rule not found")
+}
+
+func (m *memAlertStore) CreateAlert(ctx context.Context, alert *model.SensorAlert) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	alert.ID = int64(len(m.alerts) + 1)
+	m.alerts = append(m.alerts, *alert)
+	return nil
+}
+
+func (m *memAlertStore) ResolveAlert(ctx context.Context, id int64, resolvedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.alerts[id-1].Status = model.AlertStatusResolved
+	m.alerts[id-1].ResolvedAt = &resolvedAt
+	return nil
+}
+
+func (m *memAlertStore) ListAlerts(ctx context.Context, q *model.AlertQuery) ([]model.SensorAlert, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	alerts := []model.SensorAlert{}
+	for _, alert := range m.alerts {
+		if q.Status == "" || alert.Status == q.Status {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts, int64(len(alerts)), nil
+}
+
+func (m *memAlertStore) ListActiveAlerts(ctx context.Context) ([]model.SensorAlert, error) {
+	alerts, _, err := m.ListAlerts(ctx, &model.AlertQuery{Status: model.AlertStatusActive})
+	return alerts, err
+}
+
+type recordingPublisher struct {
+	messages []*mq.NotificationMessage
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, queueName string, message interface{}) error {
+	p.messages = append(p.messages, message.(*mq.NotificationMessage))
+	return nil
+}
+
+func TestSensorAlerts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	alertSvc := service.NewAlertService(&memAlertStore{})
+	publisher := &recordingPublisher{}
+	alertSvc.SetAlertPublisher(publisher)
+	alertHandler := handler.NewAlertHandler(alertSvc)
+
+	router := gin.New()
+	router.POST("/alerts/rules", alertHandler.CreateRule)
+	router.GET("/alerts", alertHandler.List)
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	listAlerts := func(status string) []model.SensorAlert {
+		var response struct {
+			Data struct {
+				List []model.SensorAlert `json:"list"`
+			} `json:"data"`
+		}
+		json.Unmarshal(send("GET", "/alerts?status="+status, "").Body.Bytes(), &response)
+		return response.Data.List
+	}
+
+	if w := send("POST", "/alerts/rules", `{"sensor_id": "temp-001", "comparison": "gte", "threshold": 35, "duration": 60, "level": "critical"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected the rule to be created, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send("POST", "/alerts/rules", `{"sensor_id": "temp-001", "comparison": "above", "threshold": 35}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown comparison to be rejected, got %d", w.Code)
+	}
+
+	start := time.Now()
+	reading := func(offset time.Duration, value float64) {
+		alertSvc.Observe(&model.SensorData{SensorID: "temp-001", Value: value, Timestamp: start.Add(offset)})
+	}
+	reading(0, 36)
+	reading(30*time.Second, 37)
+	if alerts := listAlerts("active"); len(alerts) != 0 {
+		t.Fatalf("Expected no alert before the duration has passed, got %+v", alerts)
+	}
+	reading(45*time.Second, 34)
+	reading(50*time.Second, 35)
+	reading(100*time.Second, 36)
+	if alerts := listAlerts("active"); len(alerts) != 0 {
+		t.Fatalf("Expected the dip below the threshold to restart the duration, got %+v", alerts)
+	}
+	reading(110*time.Second, 38)
+	alerts := listAlerts("active")
+	if len(alerts) != 1 || alerts[0].Value != 38 || alerts[0].Level != "critical" {
+		t.Fatalf("Expected one active alert, got %+v", alerts)
+	}
+
+	reading(120*time.Second, 30)
+	if alerts := listAlerts("resolved"); len(alerts) != 1 || alerts[0].ResolvedAt == nil {
+		t.Fatalf("Expected the alert to be resolved, got %+v", alerts)
+	}
+	if len(publisher.messages) != 2 || publisher.messages[0].Level != "critical" || publisher.messages[1].Level != "info" {
+		t.Errorf("Expected a raise and a clear notification, got %+v", publisher.messages)
+	}
+}
+
+func TestListResultsQuery(t *testing.T) {
+	router := setupTestRouter()
+	get := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/v1/algorithm/results?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, query := range []string{
+		"page_size=500",
+		"status=7",
+		"sort=parameters",
+		"order=sideways",
+		"start_time=2025-02-01T00:00:00&end_time=2025-01-01T00:00:00",
+	} {
+		if w := get(query); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s to be rejected, got %d", query, w.Code)
+		}
+	}
+
+	w := get("page=3&page_size=5&status=2&sort=completed_at&order=asc&start_time=2025-01-01T00:00:00")
+	var page struct {
+		Data struct {
+			Page     int `json:"page"`
+			PageSize int `json:"page_size"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &page)
+	if w.Code != http.StatusOK || page.Data.Page != 3 || page.Data.PageSize != 5 {
+		t.Errorf("Expected page 3 of size 5, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// queryRecordingStore records the last list query it was asked for.
+type queryRecordingStore struct {
+	query *model.ExperimentQuery
+}
+
+func (m *queryRecordingStore) Create(ctx context.Context, result *model.ExperimentResult) error {
+	return nil
+}
+
+func (m *queryRecordingStore) GetByExperimentID(ctx context.Context, experimentID string) (*model.ExperimentResult, error) {
+	return nil, nil
+}
+
+func (m *queryRecordingStore) UpdateStatus(ctx context.Context, result *model.ExperimentResult, status model.ExperimentStatus, resultData string) error {
+	return nil
+}
+
+func (m *queryRecordingStore) List(ctx context.Context, q *model.ExperimentQuery) ([]model.ExperimentResult, int64, error) {
+	copied := *q
+	m.query = &copied
+	return []model.ExperimentResult{}, 0, nil
+}
+
+func (m *queryRecordingStore) UpdatePowerEstimate(ctx context.Context, result *model.ExperimentResult, estimate string) error {
+	return nil
+}
+
+func (m *queryRecordingStore) UpdateEnergyReport(ctx context.Context, result *model.ExperimentResult, report string) error {
+	return nil
+}
+
+func (m *queryRecordingStore) ListWithEnergyReport(ctx context.Context, algorithmType model.AlgorithmType) ([]model.ExperimentResult, error) {
+	return nil, nil
+}
+
+func TestListResultsStoreQuery(t *testing.T) {
+	store := &queryRecordingStore{}
+	router := setupTestRouterWith(service.NewAlgorithmService(store))
+	list := func(query string) *model.ExperimentQuery {
+		t.Helper()
+		store.query = nil
+		req, _ := http.NewRequest("GET", "/api/v1/algorithm/results?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || store.query == nil {
+			t.Fatalf("Expected %q to reach the store, got %d: %s", query, w.Code, w.Body.String())
+		}
+		return store.query
+	}
+
+	q := list("")
+	if q.Page != 1 || q.PageSize != 20 || q.Status != nil || !q.StartTime.IsZero() {
+		t.Errorf("Expected the first page of 20 unfiltered, got %+v", q)
+	}
+	if order := q.OrderBy(); order != "created_at DESC, id DESC" {
+		t.Errorf("Expected newest first, got ORDER BY %s", order)
+	}
+
+	q = list("page=3&page_size=5&algorithm_type=doa&status=2&sort=completed_at&order=asc" +
+		"&start_time=2025-01-01T00:00:00&end_time=2025-02-01T00:00:00")
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	if q.Page != 3 || q.PageSize != 5 || q.AlgorithmType != model.AlgorithmTypeDOA {
+		t.Errorf("Expected page 3 of 5 DOA results, got %+v", q)
+	}
+	if q.Status == nil || *q.Status != model.ExperimentStatusCompleted {
+		t.Errorf("Expected the completed status filter, got %v", q.Status)
+	}
+	if !q.StartTime.Equal(start) || !q.EndTime.Equal(end) {
+		t.Errorf("Expected January 2025, got %v to %v", q.StartTime, q.EndTime)
+	}
+	if order := q.OrderBy(); order != "completed_at ASC, id ASC" {
+		t.Errorf("Expected oldest completion first, got ORDER BY %s", order)
+	}
+}
+
+func TestClientSDK(t *testing.T) {
+	store := &queryRecordingStore{}
+	server := httptest.NewServer(setupTestRouterWith(service.NewAlgorithmService(store)))
+	defer server.Close()
+	c, err := client.New(server.URL, client.WithRetry(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	// the store claims every experiment ID is taken
+	dry, err := c.DryRun(ctx, client.AlgorithmTypeBeamforming, "dry1", &client.BeamformingParams{ElementCount: 16, TargetDirection: 0.5, SNRThreshold: 1})
+	if err != nil || dry.Valid || len(dry.Diagnostics) != 1 || dry.Diagnostics[0].Field != "experiment_id" {
+		t.Fatalf("Expected only the experiment ID to be reported, got %+v, %v", dry, err)
+	}
+
+	_, err = c.RunDOA(ctx, "bad1", &client.DOAParams{ElementCount: 8, NumSources: 2, SnapshotLength: 4, Source: "radar"})
+	var httpErr *client.HTTPError
+	if !errors.IsCode(err, errors.CodeInvalidParam) || !stderrors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected the parameters to be rejected, got %v", err)
+	}
+	var violations client.Diagnostics
+	if err := json.Unmarshal(httpErr.Data, &violations); err != nil || len(violations) != 2 {
+		t.Errorf("Expected source and snapshot_length violations, got %s", httpErr.Data)
+	}
+
+	completed := client.ExperimentStatusCompleted
+	page, err := c.ListExperiments(ctx, &client.ExperimentQuery{
+		AlgorithmType: client.AlgorithmTypeDOA,
+		Status:        &completed,
+		StartTime:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Sort:          "completed_at",
+		Order:         "asc",
+		Page:          3,
+		PageSize:      5,
+	})
+	if err != nil || page.Page != 3 || page.PageSize != 5 {
+		t.Fatalf("Expected page 3 of size 5, got %+v, %v", page, err)
+	}
+	q := store.query
+	if q.AlgorithmType != model.AlgorithmTypeDOA || q.Status == nil || *q.Status != completed ||
+		!q.StartTime.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) || q.OrderBy() != "completed_at ASC, id ASC" {
+		t.Errorf("Expected the query to reach the store unchanged, got %+v", q)
+	}
+}
+
+// memChannelStore returns its measurements for every query.
+type memChannelStore struct {
+	data []*model.ChannelMeasurement
+}
+
+func (m *memChannelStore) Write(ctx context.Context, data *model.ChannelMeasurement) error {
+	m.data = append(m.data, data)
+	return nil
+}
+
+func (m *memChannelStore) Query(ctx context.Context, q *model.ChannelDataQuery) ([]*model.ChannelMeasurement, error) {
+	data := make([]*model.ChannelMeasurement, len(m.data))
+	for i, d := range m.data {
+		copied := *d
+		data[i] = &copied
+	}
+	return data, nil
+}
+
+// memAnnotationStore keeps channel annotations in memory.
+type memAnnotationStore struct {
+	annotations []model.ChannelAnnotation
+}
+
+func (m *memAnnotationStore) Create(ctx context.Context, a *model.ChannelAnnotation) error {
+	m.annotations = append(m.annotations, *a)
+	return nil
+}
+
+func (m *memAnnotationStore) Get(ctx context.Context, id string) (*model.ChannelAnnotation, error) {
+	for i := range m.annotations {
+		if m.annotations[i].ID == id {
+			return &m.annotations[i], nil
+		}
+	}
+	return nil, errors.New(errors.CodeNotFound, "channel annotation not found")
+}
+
+func (m *memAnnotationStore) List(ctx context.Context, q *model.ChannelAnnotationQuery) ([]model.ChannelAnnotation, error) {
+	var matched []model.ChannelAnnotation
+	for _, a := range m.annotations {
+		if q.ExperimentID != "" && a.ExperimentID != q.ExperimentID ||
+			q.MeasurementID != "" && a.MeasurementID != q.MeasurementID {
+			continue
+		}
+		if len(q.MeasurementIDs) > 0 {
+			found := false
+			for _, id := range q.MeasurementIDs {
+				found = found || a.MeasurementID == id
+			}
+			if !found {
+				continue
+			}
+		}
+		if q.Matches(&a) {
+			matched = append(matched, a)
+		}
+	}
+	return matched, nil
+}
+
+func (m *memAnnotationStore) Delete(ctx context.Context, id string) error {
+	for i := range m.annotations {
+		if m.annotations[i].ID == id {
+			m.annotations = append(m.annotations[:i], m.annotations[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New(errors.CodeNotFound, "channel annotation not found")
+}
+
+func TestChannelAnnotations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	start := time.Now().Add(-30 * time.Minute).Truncate(time.Second)
+	store := &memChannelStore{data: []*model.ChannelMeasurement{
+		{MeasurementID: "meas_1", ExperimentID: "exp-1", Timestamp: start.Add(time.Minute)},
+		{MeasurementID: "meas_2", ExperimentID: "exp-1", Timestamp: start.Add(10 * time.Minute)},
+		{MeasurementID: "meas_3", ExperimentID: "exp-2", Timestamp: start.Add(2 * time.Minute)},
+	}}
+	channelSvc := service.NewChannelService(nil, store)
+	channelSvc.SetAnnotationStore(&memAnnotationStore{})
+	channelHandler := handler.NewChannelHandler(channelSvc)
+	router := gin.New()
+	router.POST("/channel/annotations", channelHandler.Annotate)
+	router.GET("/channel/annotations", channelHandler.ListAnnotations)
+	router.DELETE("/channel/annotations/:id", channelHandler.DeleteAnnotation)
+	router.GET("/channel/data", channelHandler.Query)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	timeJSON := func(t time.Time) string {
+		return `"` + t.Format(time.RFC3339) + `"`
+	}
+
+	for _, body := range []string{
+		`{"labels": []}`,
+		`{"labels": ["LOS blocked"]}`,
+		`{"labels": ["LOS blocked"], "start_time": ` + timeJSON(start.Add(time.Hour)) + `, "end_time": ` + timeJSON(start) + `}`,
+		`{"labels": ["LOS blocked"], "measurement_id": "meas_1", "start_time": ` + timeJSON(start) + `}`,
+	} {
+		if w := do("POST", "/channel/annotations", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s to be rejected, got %d", body, w.Code)
+		}
+	}
+
+	rangeBody := `{"experiment_id": "exp-1", "labels": ["LOS blocked"], "target": {"x": 1.5, "y": 2, "z": 0},
+		"start_time": ` + timeJSON(start) + `, "end_time": ` + timeJSON(start.Add(5*time.Minute)) + `}`
+	var created struct {
+		Data model.ChannelAnnotation `json:"data"`
+	}
+	w := do("POST", "/channel/annotations", rangeBody)
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if w.Code != http.StatusOK || created.Data.ID == "" || created.Data.Target == nil || created.Data.Target.X != 1.5 {
+		t.Fatalf("Expected the range annotation to be created, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do("POST", "/channel/annotations", `{"measurement_id": "meas_2", "labels": ["IRS off"]}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected the measurement annotation to be created, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var page struct {
+		Data struct {
+			List []model.ChannelMeasurement `json:"list"`
+		} `json:"data"`
+	}
+	w = do("GET", "/channel/data", "")
+	json.Unmarshal(w.Body.Bytes(), &page)
+	if w.Code != http.StatusOK || len(page.Data.List) != 3 {
+		t.Fatalf("Expected 3 measurements, got %d: %s", w.Code, w.Body.String())
+	}
+	labels := map[string][]string{}
+	for _, m := range page.Data.List {
+		for _, a := range m.Annotations {
+			labels[m.MeasurementID] = append(labels[m.MeasurementID], a.Labels...)
+		}
+	}
+	if len(labels["meas_1"]) != 1 || labels["meas_1"][0] != "LOS blocked" ||
+		len(labels["meas_2"]) != 1 || labels["meas_2"][0] != "IRS off" || len(labels["meas_3"]) != 0 {
+		t.Errorf("Expected meas_1 LOS blocked and meas_2 IRS off, got %v", labels)
+	}
+
+	var listed struct {
+		Data []model.ChannelAnnotation `json:"data"`
+	}
+	w = do("GET", "/channel/annotations?label=IRS+off", "")
+	json.Unmarshal(w.Body.Bytes(), &listed)
+	if w.Code != http.StatusOK || len(listed.Data) != 1 || listed.Data[0].MeasurementID != "meas_2" {
+		t.Errorf("Expected the IRS off annotation, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := do("DELETE", "/channel/annotations/"+created.Data.ID, ""); w.Code != http.StatusOK {
+		t.Errorf("Expected the annotation to be deleted, got %d", w.Code)
+	}
+	if w := do("DELETE", "/channel/annotations/"+created.Data.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 deleting it again, got %d", w.Code)
+	}
+}
+
 func TestCORSMiddleware(t *testing.T) {
 	router := setupTestRouter()
 
@@ -103,6 +1044,30 @@ func TestCORSMiddleware(t *testing.T) {
 	}
 }
 
+func TestDashboard(t *testing.T) {
+	router := setupTestRouter()
+	ui.Register(router, "/ui")
+
+	req, _ := http.NewRequest("GET", "/ui/", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "app.js") {
+		t.Errorf("Expected the dashboard page, got %q", w.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/ui/app.js", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for app.js, got %d", w.Code)
+	}
+}
+
 func TestRequestIDMiddleware(t *testing.T) {
 	router := setupTestRouter()
 
@@ -136,3 +1101,405 @@ func TestResponseFormat(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildDatasetValidation(t *testing.T) {
+	router := setupTestRouter()
+	post := func(body string) int {
+		req, _ := http.NewRequest("POST", "/api/v1/datasets", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for _, body := range []string{
+		`{"end_time": "2025-01-02T00:00:00Z"}`,
+		`{"start_time": "2025-01-02T00:00:00Z", "end_time": "2025-01-01T00:00:00Z"}`,
+		`{"start_time": "2025-01-01T00:00:00Z", "end_time": "2025-01-02T00:00:00Z", "split": {"train": 0.5}}`,
+		`{"start_time": "2025-01-01T00:00:00Z", "end_time": "2025-01-02T00:00:00Z", "format": "hdf5"}`,
+	} {
+		if code := post(body); code != http.StatusBadRequest {
+			t.Errorf("Expected %s to be rejected, got %d", body, code)
+		}
+	}
+
+	req, _ := http.NewRequest("POST", "/api/v1/datasets",
+		strings.NewReader(`{"start_time": "2025-01-01T00:00:00Z", "end_time": "2025-01-02T00:00:00Z"}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var resp struct {
+		Code int `json:"code"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Code != int(errors.CodeServiceUnavailable) {
+		t.Errorf("Expected service unavailable without a dataset builder, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestPipelineEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	q := queue.NewTaskQueue(1, 10)
+	q.Start()
+	defer q.Stop()
+	algorithmHandler := handler.NewAlgorithmHandler(service.NewAlgorithmService(nil))
+	algorithmHandler.SetPipelineService(service.NewPipelineService(q, service.NewChannelService(nil, nil), service.NewAlgorithmService(nil), service.NewIRSService(irs.NewManager())))
+	router := gin.New()
+	router.POST("/pipelines", algorithmHandler.SubmitPipeline)
+	router.GET("/pipelines/:id", algorithmHandler.GetPipeline)
+
+	var resp struct {
+		Code int               `json:"code"`
+		Data model.PipelineRun `json:"data"`
+	}
+	do := func(method, path, body string) int {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		resp.Code = 0
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code
+	}
+
+	cycle := `{"steps": [{"id": "a", "kind": "measure", "depends_on": ["b"]}, {"id": "b", "kind": "measure", "depends_on": ["a"]}]}`
+	if code := do("POST", "/pipelines", cycle); code != http.StatusBadRequest {
+		t.Errorf("Expected a cyclic pipeline to be rejected, got %d", code)
+	}
+
+	if code := do("POST", "/pipelines", `{"name": "probe", "steps": [{"id": "m", "kind": "measure"}]}`); code != http.StatusAccepted {
+		t.Fatalf("Expected the pipeline to be accepted, got %d", code)
+	}
+	id := resp.Data.ID
+	deadline := time.Now().Add(5 * time.Second)
+	for resp.Data.EndedAt == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		do("GET", "/pipelines/"+id, "")
+	}
+	if resp.Data.Status != model.PipelineStatusFailed || resp.Data.Steps[0].Code != int(errors.CodeServiceUnavailable) {
+		t.Errorf("Expected the measure step to fail without a receiver, got %+v", resp.Data)
+	}
+
+	if code := do("GET", "/pipelines/pipe_missing", ""); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown run, got %d", code)
+	}
+}
+
+func TestResultCacheEndpoints(t *testing.T) {
+	algorithmSvc := service.NewAlgorithmService(nil)
+	algorithmSvc.SetResultCache(service.ResultCacheConfig{Size: 4})
+	router := setupTestRouterWith(algorithmSvc)
+	var resp struct {
+		Code int                    `json:"code"`
+		Data map[string]interface{} `json:"data"`
+	}
+	do := func(method, path, body string) int {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		resp.Data = nil
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code
+	}
+
+	body := `{"experiment_id": "%s", "params": {"element_count": 8, "target_direction": 0.1, "max_iterations": 10}}`
+	do("POST", "/api/v1/algorithm/beamforming", strings.Replace(body, "%s", "exp_cache_1", 1))
+	do("POST", "/api/v1/algorithm/beamforming", strings.Replace(body, "%s", "exp_cache_2", 1))
+	if resp.Data["cached_from"] != "exp_cache_1" {
+		t.Errorf("Expected the repeated run from the cache, got %v", resp.Data)
+	}
+	do("POST", "/api/v1/algorithm/beamforming?no_cache=true", strings.Replace(body, "%s", "exp_cache_3", 1))
+	if _, ok := resp.Data["cached_from"]; ok {
+		t.Errorf("Expected no_cache to run again, got %v", resp.Data)
+	}
+
+	do("GET", "/api/v1/algorithm/cache", "")
+	if resp.Data["entries"] != float64(1) || resp.Data["hits"] != float64(1) {
+		t.Errorf("Expected one entry and one hit, got %v", resp.Data)
+	}
+	if code := do("DELETE", "/api/v1/algorithm/cache?algorithm_type=music", ""); code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown algorithm type to be rejected, got %d", code)
+	}
+	do("DELETE", "/api/v1/algorithm/cache", "")
+	if resp.Data["invalidated"] != float64(1) {
+		t.Errorf("Expected one result invalidated, got %v", resp.Data)
+	}
+}
+
+func TestStreamEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	streams := hub.NewHub(16)
+	defer streams.Close()
+	algorithmSvc := service.NewAlgorithmService(nil)
+	algorithmSvc.SetEventPublisher(streams)
+	systemHandler := handler.NewSystemHandler()
+	router := gin.New()
+	router.GET("/stream/events", systemHandler.StreamEvents)
+	router.GET("/stream/ws", systemHandler.StreamWebSocket)
+	router.GET("/stream/stats", systemHandler.StreamStats)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	if resp, err := http.Get(server.URL + "/stream/stats"); err != nil || resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected streams to be unavailable without a hub, got %v", err)
+	}
+	systemHandler.SetStreamHub(streams)
+	if resp, _ := http.Get(server.URL + "/stream/events"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected an SSE stream without topics to be rejected, got %d", resp.StatusCode)
+	}
+
+	events, err := http.Get(server.URL + "/stream/events?topics=experiment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer events.Body.Close()
+	if ct := events.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Errorf("Expected an event stream, got %q", ct)
+	}
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/stream/ws", "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	websocket.JSON.Send(ws, map[string]interface{}{"action": "subscribe", "topics": []string{"experiment.exp_stream"}})
+	deadline := time.Now().Add(2 * time.Second)
+	for streams.Stats().Subscribers["experiment.exp_stream"] == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := algorithmSvc.RunBeamforming(context.Background(), "exp_stream", &model.BeamformingParams{ElementCount: 8, MaxIterations: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Running, then completed.
+	ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for _, want := range []model.ExperimentStatus{model.ExperimentStatusRunning, model.ExperimentStatusCompleted} {
+		var msg hub.Message
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			t.Fatal(err)
+		}
+		var event model.ExperimentEvent
+		json.Unmarshal(msg.Data, &event)
+		if msg.Topic != "experiment.exp_stream" || event.Status != want {
+			t.Errorf("Expected status %d on the WebSocket, got %s %+v", want, msg.Topic, event)
+		}
+	}
+
+	reader := bufio.NewReader(events.Body)
+	var lines []string
+	for len(lines) < 2 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if lines[0] != "event:experiment.exp_stream" || !strings.Contains(lines[1], `"experiment_id":"exp_stream"`) {
+		t.Errorf("Unexpected SSE event %q", lines)
+	}
+
+	var stats struct {
+		Data hub.Stats `json:"data"`
+	}
+	resp, _ := http.Get(server.URL + "/stream/stats")
+	json.NewDecoder(resp.Body).Decode(&stats)
+	resp.Body.Close()
+	if stats.Data.Clients != 2 || stats.Data.Published["experiment"] != 2 || stats.Data.Delivered != 4 {
+		t.Errorf("Unexpected stream stats %+v", stats.Data)
+	}
+}
+
+func TestSensorMaintenance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	collector := sensor.NewCollector(sensor.NewSimulator(), time.Second)
+	collector.Connect(context.Background())
+	sensorSvc := service.NewSensorService(collector, nil)
+	alertSvc := service.NewAlertService(&memAlertStore{})
+	publisher := &recordingPublisher{}
+	alertSvc.SetAlertPublisher(publisher)
+	sensorSvc.SetObserver(alertSvc)
+	sensorHandler := handler.NewSensorHandler(sensorSvc)
+	alertHandler := handler.NewAlertHandler(alertSvc)
+
+	router := gin.New()
+	router.PUT("/sensor/:id/lifecycle", sensorHandler.SetLifecycle)
+	router.GET("/sensor/read/:id", sensorHandler.ReadSensor)
+	router.POST("/alerts/rules", alertHandler.CreateRule)
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	id := collector.GetAllSensors()[0].SensorID
+	send("POST", "/alerts/rules", `{"sensor_id": "`+id+`", "comparison": "gte", "threshold": 35, "level": "warning"}`)
+
+	if w := send("PUT", "/sensor/"+id+"/lifecycle", `{"lifecycle": "retired"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown lifecycle to be rejected, got %d", w.Code)
+	}
+	if w := send("PUT", "/sensor/"+id+"/lifecycle", `{"lifecycle": "active", "end": "2030-01-01T00:00:00Z"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a window outside maintenance to be rejected, got %d", w.Code)
+	}
+	if w := send("PUT", "/sensor/missing/lifecycle", `{"lifecycle": "maintenance"}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected an unknown sensor to be rejected, got %d", w.Code)
+	}
+
+	end := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	w := send("PUT", "/sensor/"+id+"/lifecycle", `{"lifecycle": "maintenance", "end": "`+end+`", "reason": "recalibration"}`)
+	var updated struct {
+		Data model.SensorInfo `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &updated)
+	if w.Code != http.StatusOK || updated.Data.Lifecycle != model.SensorLifecycleMaintenance || updated.Data.Maintenance == nil || updated.Data.Maintenance.Reason != "recalibration" {
+		t.Fatalf("Expected the sensor to enter maintenance, got %d: %s", w.Code, w.Body.String())
+	}
+	alertSvc.Observe(&model.SensorData{SensorID: id, Value: 40, Timestamp: time.Now()})
+	if len(publisher.messages) != 0 {
+		t.Errorf("Expected alerts to be held back during maintenance, got %+v", publisher.messages)
+	}
+	if data, _ := collector.ReadAllSensors(context.Background()); len(data) != len(collector.GetAllSensors())-1 {
+		t.Errorf("Expected the collector to skip the sensor in maintenance, read %d", len(data))
+	}
+
+	send("PUT", "/sensor/"+id+"/lifecycle", `{"lifecycle": "active"}`)
+	alertSvc.Observe(&model.SensorData{SensorID: id, Value: 40, Timestamp: time.Now()})
+	if len(publisher.messages) != 1 {
+		t.Errorf("Expected an alert once back in service, got %+v", publisher.messages)
+	}
+
+	send("PUT", "/sensor/"+id+"/lifecycle", `{"lifecycle": "decommissioned"}`)
+	if w := send("GET", "/sensor/read/"+id, ""); w.Code == http.StatusOK {
+		t.Error("Expected a decommissioned sensor not to be read")
+	}
+}
+
+// historySensorStore serves a fixed history in time order and counts writes.
+type historySensorStore struct {
+	memSensorDataStore
+	history []*model.SensorData
+	mu      sync.Mutex
+	writes  int
+}
+
+func (m *historySensorStore) Write(ctx context.Context, data *model.SensorData) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.writes++
+	return nil
+}
+
+func (m *historySensorStore) QueryRange(ctx context.Context, q *model.SensorDataQuery, limit int) ([]*model.SensorData, error) {
+	var points []*model.SensorData
+	for _, p := range m.history {
+		if !p.Timestamp.Before(q.StartTime) && p.Timestamp.Before(q.EndTime) && (q.SensorID == "" || p.SensorID == q.SensorID) {
+			points = append(points, p)
+		}
+	}
+	return points, nil
+}
+
+type recordingObserver struct {
+	mu   sync.Mutex
+	data []*model.SensorData
+}
+
+func (o *recordingObserver) Observe(data *model.SensorData) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.data = append(o.data, data)
+}
+
+func (o *recordingObserver) count() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.data)
+}
+
+func TestSensorReplay(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := &historySensorStore{}
+	for i := 0; i < 5; i++ {
+		store.history = append(store.history, &model.SensorData{
+			SensorID: "temp-001", SensorType: "temperature", Value: float64(30 + i), Timestamp: start.Add(time.Duration(i) * time.Second),
+		})
+	}
+	store.history = append(store.history, &model.SensorData{SensorID: "temp-001", Value: 99, Timestamp: start.Add(time.Hour)})
+	collector := sensor.NewCollector(sensor.NewSimulator(), time.Second)
+	sensorSvc := service.NewSensorService(collector, store)
+	observer := &recordingObserver{}
+	sensorSvc.SetObserver(observer)
+	sensorHandler := handler.NewSensorHandler(sensorSvc)
+
+	router := gin.New()
+	router.POST("/sensor/replay", sensorHandler.StartReplay)
+	router.GET("/sensor/replay", sensorHandler.GetReplay)
+	router.POST("/sensor/replay/stop", sensorHandler.StopReplay)
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	status := func(w *httptest.ResponseRecorder) model.SensorReplayStatus {
+		var resp struct {
+			Data model.SensorReplayStatus `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data
+	}
+
+	if w := send("GET", "/sensor/replay", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 before any replay, got %d", w.Code)
+	}
+	window := `"start_time": "2025-01-01T00:00:00Z", "end_time": "2025-01-01T00:10:00Z"`
+	if w := send("POST", "/sensor/replay", `{"start_time": "2025-01-01T00:10:00Z", "end_time": "2025-01-01T00:00:00Z"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a reversed window to be rejected, got %d", w.Code)
+	}
+	if w := send("POST", "/sensor/replay", `{`+window+`, "speed": 100000}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected an excessive speed to be rejected, got %d", w.Code)
+	}
+
+	started := time.Now()
+	w := send("POST", "/sensor/replay", `{`+window+`, "speed": 20}`)
+	if w.Code != http.StatusOK || status(w).Total != 5 {
+		t.Fatalf("Expected a replay of 5 readings, got %d: %s", w.Code, w.Body.String())
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for status(send("GET", "/sensor/replay", "")).Running && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// 4 seconds of history at 20x take 200ms
+	if elapsed := time.Since(started); elapsed < 180*time.Millisecond {
+		t.Errorf("Expected the replay to keep the original pacing, finished in %v", elapsed)
+	}
+	final := status(send("GET", "/sensor/replay", ""))
+	if final.Running || final.Emitted != 5 || final.Position == nil || !final.Position.Equal(start.Add(4*time.Second)) {
+		t.Fatalf("Expected the replay to finish after 5 readings, got %+v", final)
+	}
+	observer.mu.Lock()
+	for i, data := range observer.data {
+		if !data.Replayed || !data.Timestamp.Equal(start.Add(time.Duration(i)*time.Second)) {
+			t.Errorf("Expected reading %d to be replayed with its original timestamp, got %+v", i, data)
+		}
+	}
+	observer.mu.Unlock()
+	if store.writes != 0 {
+		t.Errorf("Expected replayed readings not to be stored again, got %d writes", store.writes)
+	}
+
+	if w := send("POST", "/sensor/replay", `{`+window+`, "speed": 1}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected a second replay to start, got %d", w.Code)
+	}
+	stopped := status(send("POST", "/sensor/replay/stop", ""))
+	if stopped.Running || !stopped.Stopped || stopped.Emitted >= 5 {
+		t.Errorf("Expected the real-time replay to be stopped early, got %+v", stopped)
+	}
+	if emitted := observer.count(); emitted != 5+stopped.Emitted {
+		t.Errorf("Expected no readings after stopping, observed %d", emitted)
+	}
+}